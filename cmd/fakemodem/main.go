@@ -0,0 +1,75 @@
+// Command fakemodem serves a fake Motorola MB8600 for the end-to-end tests.
+// It answers the HNAP login, status and reboot of the real driver over
+// HTTPS and goes offline for a while after each reboot. A plain HTTP
+// control port lets tests read what the watchdog did: GET /state and
+// POST /reset.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/certs"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem/modemtest"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	var (
+		listen        = flag.String("listen", ":443", "HTTPS address of the modem web interface")
+		controlListen = flag.String("control-listen", ":8080", "HTTP address of the control API")
+		username      = flag.String("username", "admin", "Modem username")
+		password      = flag.String("password", "motorola", "Modem password")
+		downtime      = flag.Duration("reboot-downtime", 15*time.Second, "How long the modem is offline after a reboot")
+		certDir       = flag.String("cert-dir", filepath.Join(os.TempDir(), "fakemodem"), "Directory of the self-signed certificate")
+	)
+	flag.Parse()
+
+	logger := logrus.New()
+	fake := modemtest.NewFake(*username, *password)
+	fake.RebootDowntime = *downtime
+
+	hostname, _ := os.Hostname()
+	cert, err := certs.SelfSigned(*certDir, []string{hostname, "localhost"}, time.Now())
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to create certificate")
+	}
+
+	modemServer := &http.Server{
+		Addr:      *listen,
+		Handler:   fake,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		// The modem speaks HTTP/1.1 only, which also lets a reboot drop connections
+		TLSNextProto: map[string]func(*http.Server, *tls.Conn, http.Handler){},
+	}
+	controlServer := &http.Server{Addr: *controlListen, Handler: fake.ControlHandler()}
+
+	go func() {
+		if err := modemServer.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.WithError(err).Fatal("Modem server failed")
+		}
+	}()
+	go func() {
+		if err := controlServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.WithError(err).Fatal("Control server failed")
+		}
+	}()
+	logger.WithFields(logrus.Fields{"listen": *listen, "control_listen": *controlListen}).Info("Fake modem running")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	modemServer.Shutdown(shutdownCtx)
+	controlServer.Shutdown(shutdownCtx)
+}
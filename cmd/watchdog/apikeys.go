@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/audit"
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var apiKeyScopes []string
+
+var apiKeyCmd = &cobra.Command{
+	Use:   "api-key",
+	Short: "Manage API keys for integrations",
+	Long: `Create, list and revoke API keys. Keys are sent as bearer tokens
+("Authorization: Bearer <key>") and carry scopes:
+
+  read    read status, statistics and reports
+  reboot  trigger modem reboots
+  admin   everything, including changing settings
+
+A running service picks up created and revoked keys without a restart.`,
+}
+
+var apiKeyCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an API key",
+	Example: `  watchdog api-key create home-assistant --scope read,reboot
+  watchdog api-key create grafana --scope read`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAPIKeyCreate,
+}
+
+var apiKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API keys",
+	Args:  cobra.NoArgs,
+	RunE:  runAPIKeyList,
+}
+
+var apiKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke an API key",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAPIKeyRevoke,
+}
+
+func init() {
+	rootCmd.AddCommand(apiKeyCmd)
+	apiKeyCmd.AddCommand(apiKeyCreateCmd, apiKeyListCmd, apiKeyRevokeCmd)
+
+	apiKeyCreateCmd.Flags().StringSliceVar(&apiKeyScopes, "scope", []string{string(auth.ScopeRead)}, "Scopes of the key: read, reboot, admin")
+}
+
+// openAPIKeys opens the configured API keys file
+func openAPIKeys(cmd *cobra.Command) (*config.Config, *auth.Keys, error) {
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	keys, err := auth.OpenKeys(cfg.APIKeysPath())
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, keys, nil
+}
+
+// runAPIKeyCreate creates a key and prints its token once
+func runAPIKeyCreate(cmd *cobra.Command, args []string) error {
+	scopes, err := auth.ParseScopes(apiKeyScopes)
+	if err != nil {
+		return err
+	}
+	cfg, keys, err := openAPIKeys(cmd)
+	if err != nil {
+		return err
+	}
+
+	token, key, err := keys.Create(args[0], scopes, time.Now())
+	if err != nil {
+		return err
+	}
+	recordKeyAction(cfg, audit.ActionKeyCreate, key)
+
+	fmt.Printf("Created API key %s (%s) with scopes %s\n", key.ID, key.Name, formatScopes(key.Scopes))
+	fmt.Println("Store this key now, it will not be shown again:")
+	fmt.Println()
+	fmt.Println("  " + token)
+	return nil
+}
+
+// runAPIKeyList prints every key without its secret
+func runAPIKeyList(cmd *cobra.Command, args []string) error {
+	_, keys, err := openAPIKeys(cmd)
+	if err != nil {
+		return err
+	}
+
+	list := keys.List()
+	if len(list) == 0 {
+		fmt.Printf("No API keys in %s\n", keys.Path())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSCOPES\tCREATED\tSTATUS")
+	for _, key := range list {
+		status := "active"
+		if key.Revoked() {
+			status = "revoked " + key.RevokedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, formatScopes(key.Scopes),
+			key.CreatedAt.Local().Format("2006-01-02 15:04"), status)
+	}
+	return w.Flush()
+}
+
+// runAPIKeyRevoke revokes a key
+func runAPIKeyRevoke(cmd *cobra.Command, args []string) error {
+	cfg, keys, err := openAPIKeys(cmd)
+	if err != nil {
+		return err
+	}
+	if err := keys.Revoke(args[0], time.Now()); err != nil {
+		return err
+	}
+
+	for _, key := range keys.List() {
+		if key.ID == args[0] {
+			recordKeyAction(cfg, audit.ActionKeyRevoke, key)
+		}
+	}
+	fmt.Printf("Revoked API key %s\n", args[0])
+	return nil
+}
+
+// recordKeyAction audits the creation or revocation of a key
+func recordKeyAction(cfg *config.Config, action string, key auth.APIKey) {
+	entry := audit.Entry{
+		Action:  action,
+		Actor:   currentUser(),
+		Source:  audit.SourceCLI,
+		Outcome: audit.OutcomeSucceeded,
+		Details: map[string]interface{}{
+			"key_id":   key.ID,
+			"key_name": key.Name,
+			"scopes":   formatScopes(key.Scopes),
+		},
+	}
+	if err := audit.Append(cfg.AuditLogPath(), entry); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+// formatScopes joins scopes for display
+func formatScopes(scopes []auth.Scope) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/audit"
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+)
+
+// controlReason is recorded in the audit log for reload and stop
+var controlReason string
+
+func init() {
+	reloadCmd.Flags().StringVar(&controlReason, "reason", "", "Reason recorded in the audit log")
+	stopCmd.Flags().StringVar(&controlReason, "reason", "", "Reason recorded in the audit log")
+}
+
+// recordControlAction appends a CLI control action to the audit log. The
+// action has already happened, so failures are only reported.
+func recordControlAction(cfg *config.Config, action string, err error) {
+	entry := audit.Entry{
+		Action:  action,
+		Actor:   currentUser(),
+		Source:  audit.SourceCLI,
+		Reason:  controlReason,
+		Outcome: audit.OutcomeSucceeded,
+	}
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailed
+		entry.Error = err.Error()
+	}
+
+	if err := audit.Append(cfg.AuditLogPath(), entry); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+// currentUser names the user running the CLI
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return fmt.Sprintf("uid:%d", os.Getuid())
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/backup"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// backupUpload stores the backup in the configured destination
+	backupUpload bool
+	// restoreForce restores while the service is running
+	restoreForce bool
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [file]",
+	Short: "Write the state, history and configuration to an archive",
+	Long: `Write a single gzipped tar archive of the state directory, with the
+history, outages, reports and service state, and of the configuration file
+given with --config. The log file is left out. The archive is written to file,
+by default watchdog-backup-<time>.tar.gz in the current directory, or to the
+standard output with "-". With --upload it is stored in BACKUP_DESTINATION
+instead, a directory, an s3://bucket/prefix, a webdavs:// or an sftp:// URL.`,
+	Example: `  watchdog backup
+  watchdog backup /media/usb/watchdog.tar.gz
+  watchdog backup --upload`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBackup,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Restore the state, history and configuration from a backup",
+	Long: `Restore a backup written by 'watchdog backup' or a scheduled backup: the
+state files to the state directory, replacing those there, and the
+configuration file to the path given with --config. Stop the service first,
+it would otherwise overwrite the restored state.`,
+	Example: `  watchdog restore watchdog-backup-20240501T120000Z.tar.gz --config /etc/watchdog/config.json`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+
+	backupCmd.Flags().BoolVar(&backupUpload, "upload", false, "Store the backup in BACKUP_DESTINATION")
+	restoreCmd.Flags().BoolVar(&restoreForce, "force", false, "Restore even though the service is running")
+}
+
+// runBackup writes a backup to a file, the standard output or the
+// configured destination
+func runBackup(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	now := time.Now()
+
+	if backupUpload {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = cfg.Proxy()
+		dest, err := backup.NewDestination(cfg.Backup(), &http.Client{Timeout: 10 * time.Minute, Transport: transport})
+		if err != nil {
+			return err
+		}
+		tmp, err := os.CreateTemp("", "watchdog-backup-*")
+		if err != nil {
+			return fmt.Errorf("failed to create backup: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		manifest, err := backup.Create(tmp, cfg.BackupSources(), now)
+		if err != nil {
+			return err
+		}
+		size, err := tmp.Seek(0, io.SeekCurrent)
+		if err == nil {
+			_, err = tmp.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+		name := backup.FileName(now)
+		if err := dest.Store(context.Background(), name, tmp, size); err != nil {
+			return err
+		}
+		fmt.Println(i18n.T("backup.stored", len(manifest.Files), dest, name))
+		return nil
+	}
+
+	path := backup.FileName(now)
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "-" {
+		_, err := backup.Create(os.Stdout, cfg.BackupSources(), now)
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	manifest, err := backup.Create(file, cfg.BackupSources(), now)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	fmt.Println(i18n.T("backup.written", len(manifest.Files), path))
+	return nil
+}
+
+// runRestore restores a backup to the state directory and the
+// configuration file
+func runRestore(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.PidFile != "" && checkProcessStatus(cfg.PidFile) == nil && !restoreForce {
+		return fmt.Errorf("the service is running (PID file %s); stop it first, or pass --force", cfg.PidFile)
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer file.Close()
+
+	manifest, err := backup.Restore(file, cfg.BackupSources())
+	if err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("backup.restored", len(manifest.Files), manifest.Created.Local().Format("2006-01-02 15:04:05")))
+	switch {
+	case manifest.ConfigFile != "" && cfg.ConfigFile != "":
+		fmt.Println(i18n.T("backup.config", cfg.ConfigFile))
+	case manifest.ConfigFile != "":
+		fmt.Println(i18n.T("backup.no_config", manifest.ConfigFile))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem/modemtest"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	captureOutput        string
+	captureIncludeReboot bool
+	captureRedact        []string
+)
+
+var captureModemCmd = &cobra.Command{
+	Use:   "capture-modem",
+	Short: "Record sanitized modem transcripts for driver development",
+	Long: `Log in to the configured modem, fetch its status and record every HTTP
+exchange as a transcript usable by the driver conformance suite.
+
+Credentials are replaced by placeholders, MAC addresses and public IP addresses
+are scrubbed, and any --redact values are replaced by "REDACTED". Review the
+files before sharing them. Rebooting is only captured with --include-reboot.`,
+	RunE: runCaptureModem,
+}
+
+func init() {
+	rootCmd.AddCommand(captureModemCmd)
+
+	captureModemCmd.Flags().StringVarP(&captureOutput, "output", "o", "", "Output directory (default testdata/conformance/<modem-type>)")
+	captureModemCmd.Flags().BoolVar(&captureIncludeReboot, "include-reboot", false, "Also capture a reboot (the modem WILL reboot)")
+	captureModemCmd.Flags().StringSliceVar(&captureRedact, "redact", nil, "Additional strings to scrub, e.g. serial numbers")
+}
+
+// runCaptureModem records login, status and optionally reboot transcripts
+func runCaptureModem(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	modemType := cfg.ModemType
+	if modemType == "" {
+		modemType = modem.DefaultType
+	}
+
+	output := captureOutput
+	if output == "" {
+		output = filepath.Join("testdata", "conformance", modemType)
+	}
+
+	operations := []string{modemtest.OperationStatus}
+	if captureIncludeReboot {
+		operations = append(operations, modemtest.OperationReboot)
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	fmt.Printf("📡 Capturing %s transcripts from %s\n", modemType, cfg.ModemHost)
+
+	transport, err := modemTransport(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*cfg.HTTPTimeout)
+	defer cancel()
+
+	transcripts, err := modemtest.Capture(ctx, modemtest.CaptureOptions{
+		ModemType: modemType,
+		Modem: modem.Options{
+			Host:      cfg.ModemHost,
+			Port:      cfg.ModemPort,
+			Username:  cfg.ModemUsername,
+			Password:  cfg.ModemPassword,
+			NoVerify:  cfg.ModemNoVerify,
+			Timeout:   cfg.HTTPTimeout,
+			Transport: transport,
+		},
+		Operations: operations,
+		Redact:     captureRedact,
+	}, logger)
+	if err != nil {
+		fmt.Printf("❌ Capture failed: %v\n", err)
+		return err
+	}
+
+	paths, err := modemtest.WriteTranscripts(output, transcripts)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		fmt.Printf("✅ Wrote %s\n", path)
+	}
+	fmt.Println("\n⚠️  Review the transcripts for personal data before sharing them.")
+	fmt.Println("   session_expiry.json and auth_failure.json must be added by hand;")
+	fmt.Println("   see the existing transcripts in internal/modem/testdata/conformance.")
+	return nil
+}
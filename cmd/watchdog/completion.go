@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/output"
+	"github.com/perezjoseph/mb8600-watchdog/internal/power"
+	"github.com/perezjoseph/mb8600-watchdog/internal/verdict"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish",
+	Short: "Print the shell completion script",
+	Long: `Print the completion script of a shell, which completes commands, flags
+and the values of flags such as --modem-type and --log-level.
+
+Bash, with the bash-completion package installed:
+  watchdog completion bash > /etc/bash_completion.d/watchdog
+
+Zsh, with a directory of $fpath:
+  watchdog completion zsh > "${fpath[1]}/_watchdog"
+
+Fish:
+  watchdog completion fish > ~/.config/fish/completions/watchdog.fish
+
+Start a new shell for the completions to take effect.`,
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE:                  runCompletion,
+}
+
+func init() {
+	// completionCmd replaces cobra's default, which also offers PowerShell
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+}
+
+// registerFlagCompletions completes the flags that take one of a fixed set
+// of values; it runs once the flags are defined
+func registerFlagCompletions() {
+	completeFlag("modem-type", append(append([]string(nil), config.SupportedModemTypes...), config.PluginModemType)...)
+	completeFlag("log-level", "DEBUG", "INFO", "WARN", "ERROR", "FATAL", "PANIC")
+	completeFlag("log-format", "console", "json", "text")
+	completeFlag("reboot-shutdown-policy", "wait", "abort")
+	completeFlag("check-overlap", "skip", "queue")
+	completeFlag("api-tls", "auto", "off")
+	completeFlag("low-power-mode", power.ModeOff, power.ModeOn, power.ModeAuto)
+	completeFlag("lan-failure-action", verdict.Actions...)
+	completeFlag("wan-failure-action", verdict.Actions...)
+	completeFlag("upstream-failure-action", verdict.Actions...)
+	completeFlag("language", i18n.Languages()...)
+	completeFlag("color", output.ColorModes()...)
+	completeFlag("ddns-provider", "cloudflare", "duckdns", "script")
+	completeFlag("sms-provider", "twilio", "gsm")
+	completeFlag("cluster-role", "primary", "standby")
+}
+
+// completeFlag completes the values of a persistent flag of the root
+// command with values
+func completeFlag(name string, values ...string) {
+	err := rootCmd.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+	if err != nil {
+		panic(fmt.Sprintf("flag completion for --%s: %v", name, err))
+	}
+}
+
+// useInvokedName names the root command after the name the binary was run
+// as, such as the mb8600-watchdog link of make install, so completions and
+// man pages are for the command users type
+func useInvokedName() {
+	if name := filepath.Base(os.Args[0]); name != "" && name != "." {
+		rootCmd.Use = name
+	}
+}
+
+// runCompletion prints the completion script of the shell in args
+func runCompletion(cmd *cobra.Command, args []string) error {
+	useInvokedName()
+	switch strings.ToLower(args[0]) {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(os.Stdout)
+	case "fish":
+		return rootCmd.GenFishCompletion(os.Stdout, true)
+	}
+	return fmt.Errorf("unsupported shell %q", args[0])
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Export the config file schema or validate a config file",
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the config file",
+	Long: `Print the JSON Schema of the JSON config file. Point an editor at it, or
+add "$schema" to the config file, for autocomplete and inline errors:
+
+  {"$schema": "` + config.SchemaURL + `"}
+
+Every release also ships the schema as config.schema.json.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigSchema,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a config file against the schema and the watchdog's rules",
+	Long: `Check a config file, or the one given with --config, without starting the
+watchdog. JSON files are checked against the schema first, which reports
+every unknown setting and mistyped value with its path, such as
+$.PingHosts[1]; the merged configuration is then validated as on startup.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configValidateCmd)
+}
+
+// runConfigSchema prints the schema of the config file
+func runConfigSchema(cmd *cobra.Command, args []string) error {
+	data, err := config.MarshalSchema()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// runConfigValidate reports every schema error of a config file, then
+// validates it as the service would on startup
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	path := configFile
+	if len(args) == 1 {
+		path = args[0]
+	}
+	if path == "" {
+		return fmt.Errorf("no config file given, pass a file or --config")
+	}
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := config.ValidateSchema(data); err != nil {
+			var schemaErrs config.SchemaErrors
+			if !errors.As(err, &schemaErrs) {
+				return err
+			}
+			for _, schemaErr := range schemaErrs {
+				fmt.Println(i18n.T("config.invalid", schemaErr.Path, schemaErr.Message))
+			}
+			return fmt.Errorf("%s has %d schema error(s)", path, len(schemaErrs))
+		}
+	}
+
+	if _, err := config.LoadFromFile(path); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("config.valid", path))
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/api"
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/exitcode"
+)
+
+// controlRequest sends a request to the API of the running service over its
+// control socket and decodes the JSON response into out
+func controlRequest(cfg *config.Config, method, path string, body, out interface{}) error {
+	socket := cfg.ControlSocketPath()
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: api.DialUnix(socket)},
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, "http://watchdog"+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set(api.LocalUserHeader, currentUser())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// A missing or refused socket means the service is not running
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+			return exitcode.Wrap(exitcode.NotRunning, fmt.Errorf("cannot reach the service on %s: %w", socket, err))
+		}
+		return fmt.Errorf("cannot reach the service on %s: %w", socket, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("service refused the request: %s", apiErr.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from service: %w", err)
+	}
+	return nil
+}
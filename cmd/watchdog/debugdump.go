@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+var debugDumpCmd = &cobra.Command{
+	Use:   "debug-dump",
+	Short: "Write a debug snapshot of the running service",
+	Long: `Send SIGUSR2 to the running service, which writes goroutine stacks, the
+current configuration with credentials redacted, the service state, circuit
+breaker states and the last test results to the debug directory in the state
+directory. The service keeps running.`,
+	RunE: runDebugDump,
+}
+
+func init() {
+	rootCmd.AddCommand(debugDumpCmd)
+}
+
+// runDebugDump sends SIGUSR2 to request a debug dump
+func runDebugDump(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if cfg.PidFile == "" {
+		return fmt.Errorf("no PID file configured, cannot request a debug dump")
+	}
+
+	pidData, err := os.ReadFile(cfg.PidFile)
+	if err != nil {
+		return fmt.Errorf("cannot read PID file: %w", err)
+	}
+
+	pidStr := strings.TrimSpace(string(pidData))
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil {
+		return fmt.Errorf("invalid PID in file: %s", pidStr)
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("process not found: %d", pid)
+	}
+
+	if err := process.Signal(syscall.SIGUSR2); err != nil {
+		return fmt.Errorf("failed to send SIGUSR2 signal: %w", err)
+	}
+
+	fmt.Println(i18n.T("debug_dump.sent", pid, cfg.StatePath("debug")))
+	return nil
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/features"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/outage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// historySince limits the listing to recent events
+	historySince time.Duration
+	// historyKinds limits the listing to some kinds of events
+	historyKinds []string
+	// historyExplain lists reboot decisions with their inputs
+	historyExplain bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recorded network events and reboot decisions",
+	Long: `List the events in the history of the state directory: public IP
+observations and changes, every reboot the service triggered or
+deliberately skipped, and connectivity check results, which are folded into
+hourly aggregates once older than HISTORY_RAW_RETENTION.
+
+With --explain only reboot decisions are listed, each with the inputs that led
+to it: failure count and threshold, health scores and cutoffs, diagnostic
+failure patterns and cause, and the recovery wait and time since the last
+reboot.`,
+	Example: `  watchdog history --since 24h
+  watchdog history --explain`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+var historyAnnotateCmd = &cobra.Command{
+	Use:   "annotate <outage-id> <note>",
+	Short: "Attach a note to a recorded outage",
+	Long: `Attach free text to an outage after the fact, such as what the ISP said
+caused it. Notes are kept in the history and included with the outage in
+outage reports. List outages and their IDs with 'watchdog history outages'.`,
+	Example: `  watchdog history annotate outage_1714560000_123456 "ISP confirmed node maintenance"`,
+	Args:    cobra.MinimumNArgs(2),
+	RunE:    runHistoryAnnotate,
+}
+
+var historyPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Downsample and prune the history and outage reports now",
+	Long: `Apply the retention settings right away: fold check results older than
+HISTORY_RAW_RETENTION into hourly aggregates, drop the oldest events beyond
+HISTORY_MAX_AGE, HISTORY_MAX_EVENTS and HISTORY_MAX_SIZE, and remove outage
+reports beyond REPORT_MAX_AGE and REPORT_MAX_SIZE. The service does the same
+every hour; the history is rewritten in full, so the space is reclaimed.`,
+	Args: cobra.NoArgs,
+	RunE: runHistoryPrune,
+}
+
+var historyOutagesCmd = &cobra.Command{
+	Use:   "outages",
+	Short: "List recorded outages with their IDs and notes",
+	Args:  cobra.NoArgs,
+	RunE:  runHistoryOutages,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyAnnotateCmd)
+	historyCmd.AddCommand(historyOutagesCmd)
+	historyCmd.AddCommand(historyPruneCmd)
+
+	historyCmd.Flags().DurationVar(&historySince, "since", 0, "Only list events of the last duration, e.g. 24h; 0 lists all")
+	historyCmd.Flags().StringSliceVar(&historyKinds, "kind", nil, "Only list events of these kinds: "+strings.Join([]string{history.KindIPChange, history.KindPublicIP, history.KindRebootDecision, history.KindAnnotation, history.KindFirmwareChange, history.KindCheck, history.KindCheckHourly}, ", "))
+	historyCmd.Flags().BoolVar(&historyExplain, "explain", false, "List reboot decisions with the inputs that led to them")
+}
+
+// runHistory prints the recorded events, oldest first
+func runHistory(cmd *cobra.Command, args []string) error {
+	if !features.History {
+		return fmt.Errorf("this binary was built without the history, see the nohistory build tag")
+	}
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	var since time.Time
+	if historySince > 0 {
+		since = time.Now().Add(-historySince)
+	}
+	kinds := historyKinds
+	if historyExplain {
+		kinds = []string{history.KindRebootDecision}
+	}
+
+	events, err := history.Read(cfg.HistoryPath(), since, kinds...)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		fmt.Println(i18n.T("history.none", cfg.HistoryPath()))
+		return nil
+	}
+
+	for _, event := range events {
+		fmt.Printf("%s  %-16s %s\n", event.Time.Local().Format("2006-01-02 15:04:05"), event.Kind, summarizeEvent(event))
+		if historyExplain {
+			explainEvent(event)
+		}
+	}
+	return nil
+}
+
+// runHistoryAnnotate records a note for a recorded outage
+func runHistoryAnnotate(cmd *cobra.Command, args []string) error {
+	if !features.History {
+		return fmt.Errorf("this binary was built without the history, see the nohistory build tag")
+	}
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	id := args[0]
+	outages, err := outage.ReadOutages(cfg.OutagesPath())
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, recorded := range outages {
+		if recorded.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("no outage %s in %s, see 'watchdog history outages'", id, cfg.OutagesPath())
+	}
+
+	note := outage.Note{Time: time.Now(), Text: strings.Join(args[1:], " ")}
+	if err := outage.Annotate(cfg.HistoryPath(), id, note); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("history.annotated", id))
+	return nil
+}
+
+// runHistoryOutages prints the recorded outages with their notes, oldest
+// first
+func runHistoryOutages(cmd *cobra.Command, args []string) error {
+	if !features.History {
+		return fmt.Errorf("this binary was built without the history, see the nohistory build tag")
+	}
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	outages, err := outage.ReadOutages(cfg.OutagesPath())
+	if err != nil {
+		return err
+	}
+	if len(outages) == 0 {
+		fmt.Println(i18n.T("history.no_outages", cfg.OutagesPath()))
+		return nil
+	}
+	notes, err := outage.ReadNotes(cfg.HistoryPath())
+	if err != nil {
+		return err
+	}
+
+	for _, recorded := range outages {
+		duration := i18n.T("history.ongoing")
+		if recorded.Resolved {
+			duration = recorded.Duration.Round(time.Second).String()
+		}
+		fmt.Printf("%s  %-28s %-10s %s\n", recorded.StartTime.Local().Format("2006-01-02 15:04:05"), recorded.ID, duration, recorded.Cause)
+		for _, note := range notes[recorded.ID] {
+			fmt.Printf("    %s  %s\n", note.Time.Local().Format("2006-01-02 15:04"), note.Text)
+		}
+	}
+	return nil
+}
+
+// runHistoryPrune applies the retention settings to the history and the
+// outage reports
+func runHistoryPrune(cmd *cobra.Command, args []string) error {
+	if !features.History {
+		return fmt.Errorf("this binary was built without the history, see the nohistory build tag")
+	}
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	now := time.Now()
+	replaced := 0
+	if cfg.HistoryRawRetention > 0 {
+		if replaced, err = history.Downsample(cfg.HistoryPath(), now.Add(-cfg.HistoryRawRetention)); err != nil {
+			return err
+		}
+	}
+	dropped, err := history.Prune(cfg.HistoryPath(), now, cfg.HistoryRetention())
+	if err != nil {
+		return err
+	}
+	removed, err := outage.PruneReports(cfg.ReportsPath(), now, time.Duration(cfg.ReportMaxAge)*24*time.Hour, int64(cfg.ReportMaxSize)*1024*1024)
+	if err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("history.pruned", cfg.HistoryPath(), replaced, dropped, removed, cfg.ReportsPath()))
+	return nil
+}
+
+// summarizeEvent describes event on one line
+func summarizeEvent(event history.Event) string {
+	switch event.Kind {
+	case history.KindRebootDecision:
+		return i18n.T("history.decision", event.Details["outcome"], event.Details["trigger"], event.Details["reason"])
+	case history.KindIPChange:
+		return fmt.Sprintf("%v -> %v", event.Details["old_ip"], event.Details["new_ip"])
+	case history.KindFirmwareChange:
+		return fmt.Sprintf("%v -> %v", event.Details["old_version"], event.Details["new_version"])
+	case history.KindAnnotation:
+		return fmt.Sprintf("%v: %v", event.Details["outage_id"], event.Details["note"])
+	case history.KindCheckHourly:
+		return fmt.Sprintf("%v checks, %v failed, avg %vms, max %vms", event.Details["checks"], event.Details["failures"], event.Details["avg_duration_ms"], event.Details["max_duration_ms"])
+	}
+
+	parts := make([]string, 0, len(event.Details))
+	for _, key := range sortedKeys(event.Details) {
+		parts = append(parts, fmt.Sprintf("%s=%v", key, event.Details[key]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// explainEvent prints the inputs of a reboot decision, one per line
+func explainEvent(event history.Event) {
+	for _, key := range sortedKeys(event.Details) {
+		switch key {
+		case "outcome", "trigger", "reason":
+			continue
+		}
+		value := event.Details[key]
+		if list, ok := value.([]interface{}); ok {
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = fmt.Sprint(item)
+			}
+			value = strings.Join(items, ", ")
+		}
+		fmt.Printf("    %-20s %v\n", key+":", value)
+	}
+}
+
+// sortedKeys returns the keys of details in sorted order
+func sortedKeys(details map[string]interface{}) []string {
+	keys := make([]string, 0, len(details))
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
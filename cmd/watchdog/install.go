@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/openwrt"
+	"github.com/perezjoseph/mb8600-watchdog/internal/systemd"
+	"github.com/perezjoseph/mb8600-watchdog/internal/update"
+	"github.com/spf13/cobra"
+)
+
+var (
+	installBinary       string
+	installUser         string
+	installUnitDir      string
+	installWatchdogSec  time.Duration
+	installCapabilities []string
+	installPrint        bool
+	installDocker       bool
+	installNoEnable     bool
+	installOpenWrt      bool
+)
+
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the watchdog as a hardened systemd service",
+	Long: `Set up the watchdog as a system service: create the service user, copy this
+binary to --binary, prepare the state and log directories for that user,
+write a starter configuration if --config does not exist yet, install a
+hardened Type=notify unit and enable it.
+
+The unit runs as the service user with only the capabilities in
+--capabilities, a read-only filesystem outside its own directories and a
+systemd watchdog: when the service sends no keep-alive for --watchdog-sec,
+systemd restarts it.
+
+Use --print to see the unit without installing anything, and --docker for a
+Docker Compose service with the same confinement.
+
+On OpenWrt, --openwrt installs a procd init script and a UCI configuration
+in /etc/config/mb8600-watchdog instead. procd respawns the watchdog and
+sends its output to logd, so logread shows it.`,
+	Example: `  sudo watchdog install
+  sudo watchdog install --user root --watchdog-sec 0 --no-enable
+  watchdog install --print
+  watchdog install --docker > compose.yaml
+  watchdog install --openwrt`,
+	Args: cobra.NoArgs,
+	RunE: runInstall,
+}
+
+func init() {
+	rootCmd.AddCommand(installCmd)
+
+	installCmd.Flags().StringVar(&installBinary, "binary", systemd.DefaultBinary, "Path to install the binary to")
+	installCmd.Flags().StringVar(&installUser, "user", systemd.DefaultUser, "System user the service runs as; created if missing")
+	installCmd.Flags().StringVar(&installUnitDir, "unit-dir", systemd.DefaultUnitDir, "Directory to install the unit to")
+	installCmd.Flags().DurationVar(&installWatchdogSec, "watchdog-sec", systemd.DefaultWatchdogSec, "Restart the service after this long without a keep-alive; 0 disables")
+	installCmd.Flags().StringSliceVar(&installCapabilities, "capabilities", systemd.DefaultCapabilities, "Capabilities granted to the service")
+	installCmd.Flags().BoolVar(&installPrint, "print", false, "Print the unit instead of installing")
+	installCmd.Flags().BoolVar(&installDocker, "docker", false, "Print a Docker Compose service instead of installing")
+	installCmd.Flags().BoolVar(&installNoEnable, "no-enable", false, "Install without enabling and starting the service")
+	installCmd.Flags().BoolVar(&installOpenWrt, "openwrt", false, "Install a procd init script and UCI configuration for OpenWrt")
+}
+
+// runInstall sets up the service user, binary, directories, configuration
+// and unit
+func runInstall(cmd *cobra.Command, args []string) error {
+	if installWatchdogSec < 0 || (installWatchdogSec > 0 && installWatchdogSec < time.Second) {
+		return fmt.Errorf("--watchdog-sec must be 0 or at least 1s, got %s", installWatchdogSec)
+	}
+	for i, capability := range installCapabilities {
+		installCapabilities[i] = strings.ToUpper(strings.TrimSpace(capability))
+		if !strings.HasPrefix(installCapabilities[i], "CAP_") {
+			installCapabilities[i] = "CAP_" + installCapabilities[i]
+		}
+	}
+
+	if installDocker {
+		fmt.Print(systemd.ComposeSnippet(installCapabilities))
+		return nil
+	}
+	if installOpenWrt {
+		return runInstallOpenWrt(cmd)
+	}
+
+	opts := systemd.UnitOptions{
+		Binary:       installBinary,
+		ConfigFile:   systemd.DefaultConfigFile,
+		User:         installUser,
+		WatchdogSec:  installWatchdogSec,
+		Capabilities: installCapabilities,
+	}
+	if configFile != "" {
+		opts.ConfigFile = configFile
+	}
+	if err := unitFeatures(&opts); err != nil {
+		return err
+	}
+	unit := systemd.RenderUnit(opts)
+	if installPrint {
+		fmt.Print(unit)
+		return nil
+	}
+
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("install must run as root, or use --print to see the unit")
+	}
+
+	uid, gid, err := ensureServiceUser(installUser)
+	if err != nil {
+		return err
+	}
+
+	if err := copyBinary(installBinary); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("install.binary", installBinary))
+
+	for _, dir := range []string{systemd.DefaultStateDir, systemd.DefaultLogDir} {
+		if err := systemd.PrepareDirectory(dir, uid, gid, 0750); err != nil {
+			return err
+		}
+		fmt.Println(i18n.T("install.dir", dir))
+	}
+
+	if err := writeStarterConfig(opts.ConfigFile, gid); err != nil {
+		return err
+	}
+
+	unitPath := filepath.Join(installUnitDir, systemd.DefaultUnitName+".service")
+	if err := os.WriteFile(unitPath, []byte(unit), 0644); err != nil {
+		return fmt.Errorf("failed to write unit: %w", err)
+	}
+	fmt.Println(i18n.T("install.unit", unitPath))
+
+	if installNoEnable || !update.UnderSystemd() {
+		fmt.Println(i18n.T("install.next", systemd.DefaultUnitName))
+		return nil
+	}
+	for _, args := range [][]string{{"daemon-reload"}, {"enable", "--now", systemd.DefaultUnitName}} {
+		if output, err := exec.Command("systemctl", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("systemctl %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+		}
+	}
+	fmt.Println(i18n.T("install.enabled", systemd.DefaultUnitName))
+	return nil
+}
+
+// runInstallOpenWrt installs the binary, a UCI configuration unless one
+// exists and the procd init script, then enables and starts the service
+func runInstallOpenWrt(cmd *cobra.Command) error {
+	binary := installBinary
+	if !cmd.Flags().Changed("binary") {
+		binary = openwrt.DefaultBinary
+	}
+	uciFile := config.OpenWrtConfigFile
+	if configFile != "" {
+		uciFile = configFile
+	}
+	script := openwrt.RenderInitScript(binary, uciFile)
+	if installPrint {
+		fmt.Print(script)
+		return nil
+	}
+
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("install must run as root, or use --print to see the init script")
+	}
+
+	if err := copyBinary(binary); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("install.binary", binary))
+
+	if _, err := os.Stat(uciFile); os.IsNotExist(err) {
+		if err := os.WriteFile(uciFile, []byte(openwrt.DefaultConfig()), 0600); err != nil {
+			return fmt.Errorf("failed to write configuration: %w", err)
+		}
+		fmt.Println(i18n.T("install.config", uciFile))
+	}
+
+	if err := os.WriteFile(openwrt.DefaultInitPath, []byte(script), 0755); err != nil {
+		return fmt.Errorf("failed to write init script: %w", err)
+	}
+	fmt.Println(i18n.T("install.unit", openwrt.DefaultInitPath))
+
+	if installNoEnable {
+		fmt.Println(i18n.T("install.next_openwrt", openwrt.DefaultInitPath))
+		return nil
+	}
+	for _, action := range []string{"enable", "start"} {
+		if output, err := exec.Command(openwrt.DefaultInitPath, action).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s %s failed: %w: %s", openwrt.DefaultInitPath, action, err, strings.TrimSpace(string(output)))
+		}
+	}
+	fmt.Println(i18n.T("install.enabled", config.OpenWrtPackage))
+	return nil
+}
+
+// ensureServiceUser returns the uid and gid of name, creating it as a system
+// user without a login shell if it does not exist
+func ensureServiceUser(name string) (int, int, error) {
+	account, err := user.Lookup(name)
+	if _, unknown := err.(user.UnknownUserError); unknown {
+		output, err := exec.Command("useradd", "--system", "--user-group", "--no-create-home",
+			"--home-dir", systemd.DefaultStateDir, "--shell", "/usr/sbin/nologin", name).CombinedOutput()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to create user %s: %w: %s", name, err, strings.TrimSpace(string(output)))
+		}
+		fmt.Println(i18n.T("install.user", name))
+		account, err = user.Lookup(name)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to look up user %s: %w", name, err)
+	}
+
+	uid, err := strconv.Atoi(account.Uid)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid uid of user %s: %w", name, err)
+	}
+	gid, err := strconv.Atoi(account.Gid)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid gid of user %s: %w", name, err)
+	}
+	return uid, gid, nil
+}
+
+// copyBinary copies the running binary to path unless it already runs from
+// there, replacing an existing binary atomically
+func copyBinary(path string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate current binary: %w", err)
+	}
+	if self, err = filepath.EvalSymlinks(self); err != nil {
+		return fmt.Errorf("failed to locate current binary: %w", err)
+	}
+	if target, err := filepath.EvalSymlinks(path); err == nil && target == self {
+		return nil
+	}
+
+	data, err := os.ReadFile(self)
+	if err != nil {
+		return fmt.Errorf("failed to read current binary: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create binary directory: %w", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return update.Install(data, path)
+	}
+	if err := os.WriteFile(path, data, 0755); err != nil {
+		return fmt.Errorf("failed to write binary: %w", err)
+	}
+	return nil
+}
+
+// unitFeatures relaxes the hardening of the unit for the features the
+// existing configuration enables: the GSM modem of SMSDevice needs its serial
+// device, and ModemTunnelSSH needs the keys in home directories. A new
+// install has no configuration yet and gets the full hardening.
+func unitFeatures(opts *systemd.UnitOptions) error {
+	if _, err := os.Stat(opts.ConfigFile); err != nil {
+		return nil
+	}
+	cfg, err := config.LoadFromFile(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", opts.ConfigFile, err)
+	}
+	if cfg.SMSDevice != "" {
+		opts.Devices = append(opts.Devices, cfg.SMSDevice)
+	}
+	opts.SSHTunnel = cfg.ModemTunnelSSH != ""
+	return nil
+}
+
+// writeStarterConfig writes the default configuration to path, readable by
+// the service group only, unless a configuration is already there
+func writeStarterConfig(path string, gid int) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := systemd.PrepareDirectory(filepath.Dir(path), 0, gid, 0750); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, systemd.DefaultConfig(), 0640); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	if err := os.Chown(path, 0, gid); err != nil {
+		return fmt.Errorf("failed to set owner of %s: %w", path, err)
+	}
+	fmt.Println(i18n.T("install.config", path))
+	return nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+)
+
+// localize switches CLI output to the configured language unless --language
+// was given; without either, the language comes from the environment
+func localize(cfg *config.Config) {
+	if language != "" {
+		return
+	}
+	i18n.SetLanguage(i18n.Detect(cfg.Language))
+}
+
+// printField adds a field with a translated label to the output
+func printField(key string, value interface{}) {
+	printer.Field(i18n.T(key), value)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/logger"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// logLevelFor is how long a changed log level holds
+	logLevelFor time.Duration
+	// logLevelModule limits the change to one module
+	logLevelModule string
+)
+
+var logLevelCmd = &cobra.Command{
+	Use:   "log-level [level|reset]",
+	Short: "Show or change the log level of the running service",
+	Long: `Show the log level of the running service, change it, or reset it to the
+configured level. With --for the configured level returns on its own, which
+suits capturing verbose logs around an intermittent problem:
+
+  mb8600-watchdog log-level debug --for 10m
+
+With --module only that subsystem changes, so the modem client can log at
+trace level without the other modules flooding the log:
+
+  mb8600-watchdog log-level trace --module modem --for 10m
+
+The command talks to the service over the control socket in its state
+directory, so it needs the same configuration and permissions as the service.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLogLevel,
+}
+
+func init() {
+	rootCmd.AddCommand(logLevelCmd)
+
+	logLevelCmd.Flags().DurationVar(&logLevelFor, "for", 0, "Restore the configured level after this long, e.g. 10m")
+	logLevelCmd.Flags().StringVar(&logLevelModule, "module", "", "Change one module: "+strings.Join(logger.Modules, ", "))
+}
+
+// runLogLevel reads or changes the log level over the control socket
+func runLogLevel(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	method, path := http.MethodGet, "/api/v1/log-level"
+	var body interface{}
+	if len(args) == 1 {
+		if args[0] == "reset" {
+			method = http.MethodDelete
+			if logLevelModule != "" {
+				path += "?module=" + url.QueryEscape(logLevelModule)
+			}
+		} else {
+			if _, err := logrus.ParseLevel(args[0]); err != nil {
+				return err
+			}
+			req := map[string]string{"level": args[0]}
+			if logLevelModule != "" {
+				req["module"] = logLevelModule
+			}
+			if logLevelFor > 0 {
+				req["for"] = logLevelFor.String()
+			}
+			method, body = http.MethodPut, req
+		}
+	}
+
+	var status logger.LevelStatus
+	if err := controlRequest(cfg, method, path, body, &status); err != nil {
+		return err
+	}
+
+	fmt.Println(i18n.T("log_level.current", status.Level, status.Configured))
+	if status.Until != nil {
+		fmt.Println(i18n.T("log_level.until", status.Configured, status.Until.Local().Format(time.RFC3339)))
+	}
+	for _, name := range logger.Modules {
+		module, ok := status.Modules[name]
+		if !ok {
+			continue
+		}
+		line := i18n.T("log_level.module", name, module.Level, module.Configured)
+		if module.Until != nil {
+			line += " - " + i18n.T("log_level.until", module.Configured, module.Until.Local().Format(time.RFC3339))
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
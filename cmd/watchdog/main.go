@@ -2,7 +2,9 @@ package main
 
 import (
 	"bufio"
-	"crypto/tls"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -15,17 +17,30 @@ import (
 	"time"
 
 	"github.com/perezjoseph/mb8600-watchdog/internal/app"
+	"github.com/perezjoseph/mb8600-watchdog/internal/audit"
+	"github.com/perezjoseph/mb8600-watchdog/internal/backup"
+	"github.com/perezjoseph/mb8600-watchdog/internal/beacon"
+	"github.com/perezjoseph/mb8600-watchdog/internal/buildinfo"
+	"github.com/perezjoseph/mb8600-watchdog/internal/cluster"
 	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/connectivity"
+	"github.com/perezjoseph/mb8600-watchdog/internal/exitcode"
+	"github.com/perezjoseph/mb8600-watchdog/internal/features"
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+	"github.com/perezjoseph/mb8600-watchdog/internal/hosthealth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/perezjoseph/mb8600-watchdog/internal/output"
+	"github.com/perezjoseph/mb8600-watchdog/internal/power"
+	"github.com/perezjoseph/mb8600-watchdog/internal/quorum"
+	"github.com/perezjoseph/mb8600-watchdog/internal/textfile"
+	"github.com/perezjoseph/mb8600-watchdog/internal/tunnel"
+	"github.com/perezjoseph/mb8600-watchdog/internal/verdict"
 	"github.com/spf13/cobra"
 )
 
-// Build-time variables (set via ldflags)
-var (
-	version   = "dev"
-	commit    = "unknown"
-	buildTime = "unknown"
-)
-
 func init() {
 	// Ensure application only runs on Linux
 	if runtime.GOOS != "linux" {
@@ -39,30 +54,85 @@ var (
 	healthCheck bool
 	showVersion bool
 	configFile  string
+	envFile     string
+	outputColor string
+	outputWide  bool
+	outputQuiet bool
+	verbose     bool
 
 	// Configuration flags
+	modemType     string
 	modemHost     string
 	modemUsername string
 	modemPassword string
-	modemNoVerify bool
+	modemNoVerify toggleValue
+	modemPort     int
+
+	modemStatsInterval time.Duration
+
+	partialServiceChannels    int
+	partialServiceRebootAfter time.Duration
+	modemMaxTemperature       float64
+	docsisTimeoutsPerHour     int
+	docsisTimeoutsPerDay      int
+
+	modemTunnelSSH           string
+	modemTunnelSSHKey        string
+	modemTunnelSSHKnownHosts string
+	modemTunnelInterface     string
 
 	checkInterval    time.Duration
 	failureThreshold int
 	recoveryWait     time.Duration
+	scheduledReboot  string
+	scheduledSkip    time.Duration
+	rebootTimeout    time.Duration
+	rebootShutdown   string
 	pingHosts        []string
 	httpHosts        []string
-
-	logLevel    string
-	logFile     string
-	logFormat   string
-	enableDebug bool
-	logRotation bool
-	logMaxSize  int
-	logMaxAge   int
-
-	enableDiagnostics    bool
-	diagnosticsTimeout   time.Duration
-	outageReportInterval time.Duration
+	responderAddress string
+	responderURL     string
+
+	logLevel        string
+	logModuleLevels []string
+	logFile         string
+	logFormat       string
+	enableDebug     toggleValue
+	logRotation     toggleValue
+	logMaxSize      int
+	logMaxAge       int
+
+	enableDiagnostics     toggleValue
+	diagnosticsTimeout    time.Duration
+	diagnosticPingTargets []string
+	diagnosticTCPTargets  []string
+	diagnosticDNSTargets  []string
+	diagnosticHTTPTargets []string
+	cycleBudget           time.Duration
+	cycleRetryBudget      int
+	healthWeights         []string
+	healthDegradedScore   int
+	healthRebootScore     int
+	checkOverlapPolicy    string
+	escalationFailures    int
+	escalationValidation  time.Duration
+	escalationWindows     []string
+	checkDependencies     []string
+	gatewayTarget         string
+	targetsPerCheck       int
+	quarantineFailures    int
+	quarantineDuration    time.Duration
+	outboundProxy         string
+	wireguardInterface    string
+	wireguardPeer         string
+	wireguardAge          time.Duration
+	wireguardProbe        string
+	lowPowerMode          string
+	powerSourceFile       string
+	lowPowerInterval      time.Duration
+	messageTemplates      string
+	notificationQueueSize int
+	outageReportInterval  time.Duration
 
 	maxConcurrentTests int
 	connectionTimeout  time.Duration
@@ -70,9 +140,82 @@ var (
 	retryAttempts      int
 	retryBackoffFactor float64
 
-	enableSystemd    bool
-	pidFile          string
-	workingDirectory string
+	enableSystemd       toggleValue
+	pidFile             string
+	workingDirectory    string
+	stateDirectory      string
+	historyRawRetention time.Duration
+	historyMaxAge       int
+	historyMaxEvents    int
+	historyMaxSize      int
+	reportMaxAge        int
+	reportMaxSize       int
+	sandboxMode         toggleValue
+	startupCheck        toggleValue
+	hostHealthCheck     toggleValue
+	hostMaxCPU          float64
+	lanFailureAction    string
+	wanFailureAction    string
+	upstreamAction      string
+	disabledFeatures    []string
+
+	apiListenAddress string
+	apiUsersFile     string
+	apiTLS           string
+	apiTLSCert       string
+	apiTLSKey        string
+	apiActionLimit   int
+	apiActionWindow  time.Duration
+	apiRefresh       time.Duration
+	auditLogFile     string
+	apiKeysFile      string
+
+	dockerRestart      []string
+	dockerRestartAfter time.Duration
+	dockerSocket       string
+
+	plugins []string
+
+	publicIPCheck        toggleValue
+	publicIPServices     []string
+	publicIPRecordCycles int
+	publicIPGeoService   string
+	routingProbe         toggleValue
+	allowLocalTargets    toggleValue
+	routingProbeTargets  []string
+	ddnsProvider         string
+	ddnsDomain           string
+	ddnsZoneID           string
+	ddnsScript           string
+	smsProvider          string
+	smsTo                []string
+	smsDevice            string
+	twilioAccountSID     string
+	twilioFrom           string
+	beaconURL            string
+	beaconInterface      string
+	beaconInterval       time.Duration
+	textfilePath         string
+	textfileInterval     time.Duration
+	backupInterval       time.Duration
+	backupDestination    string
+	backupKeep           int
+	backupS3Endpoint     string
+	backupS3Region       string
+	syncInterval         time.Duration
+	syncDestination      string
+	syncSite             string
+	clusterRole          string
+	clusterPeer          string
+	clusterFingerprint   string
+	clusterHeartbeat     time.Duration
+	clusterFailoverAfter time.Duration
+	quorumPeers          []string
+	quorumRequired       int
+	quorumMaxAge         time.Duration
+	quorumNoVerify       toggleValue
+
+	language string
 )
 
 var rootCmd = &cobra.Command{
@@ -88,16 +231,52 @@ Configuration precedence (highest to lowest):
 3. Configuration file
 4. Default values
 
-Environment variables:
-  MODEM_HOST, MODEM_USERNAME, MODEM_PASSWORD, MODEM_NOVERIFY
+Environment variables, each also read with a WATCHDOG_ prefix that takes
+precedence, such as WATCHDOG_MODEM_HOST. A .env file in the working directory,
+or the one given with --env-file, sets variables not already set:
+  MODEM_TYPE, MODEM_HOST, MODEM_PORT, MODEM_USERNAME, MODEM_PASSWORD, MODEM_NOVERIFY, MODEM_STATS_INTERVAL
+  PARTIAL_SERVICE_CHANNELS, PARTIAL_SERVICE_REBOOT_AFTER, MODEM_MAX_TEMPERATURE
+  DOCSIS_TIMEOUTS_PER_HOUR, DOCSIS_TIMEOUTS_PER_DAY
+  MODEM_TUNNEL_SSH, MODEM_TUNNEL_SSH_KEY, MODEM_TUNNEL_SSH_KNOWN_HOSTS, MODEM_TUNNEL_INTERFACE
   CHECK_INTERVAL, FAILURE_THRESHOLD, RECOVERY_WAIT
-  PING_HOSTS, HTTP_HOSTS (comma-separated)
-  LOG_LEVEL, LOG_FILE, LOG_FORMAT, ENABLE_DEBUG
+  SCHEDULED_REBOOT, SCHEDULED_REBOOT_SKIP_WITHIN
+  REBOOT_TIMEOUT, REBOOT_SHUTDOWN_POLICY (wait or abort)
+  PING_HOSTS, HTTP_HOSTS (comma-separated), ALLOW_LOCAL_TARGETS, RESPONDER, RESPONDER_URL, RESPONDER_KEY
+  LOG_LEVEL, LOG_MODULE_LEVELS, LOG_FILE, LOG_FORMAT, ENABLE_DEBUG
   LOG_ROTATION, LOG_MAX_SIZE, LOG_MAX_AGE
-  ENABLE_DIAGNOSTICS, DIAGNOSTICS_TIMEOUT, OUTAGE_REPORT_INTERVAL
+  ENABLE_DIAGNOSTICS, DIAGNOSTICS_TIMEOUT, CYCLE_BUDGET, CYCLE_RETRY_BUDGET, OUTAGE_REPORT_INTERVAL
+  DIAGNOSTIC_PING_TARGETS, DIAGNOSTIC_TCP_TARGETS, DIAGNOSTIC_DNS_TARGETS, DIAGNOSTIC_HTTP_TARGETS
+  HEALTH_WEIGHTS, HEALTH_DEGRADED_SCORE, HEALTH_REBOOT_SCORE
+  ROUTING_PROBE, ROUTING_PROBE_TARGETS
+  CHECK_OVERLAP_POLICY, MESSAGE_TEMPLATES, NOTIFICATION_QUEUE_SIZE, WATCHDOG_LANGUAGE
+  ESCALATION_FAILURES, ESCALATION_VALIDATION_INTERVAL, ESCALATION_WINDOWS
+  CHECK_DEPENDENCIES, GATEWAY_TARGET
+  TARGETS_PER_CHECK, TARGET_QUARANTINE_FAILURES, TARGET_QUARANTINE_DURATION
+  OUTBOUND_PROXY (proxy URL or direct; HTTP_PROXY, HTTPS_PROXY and NO_PROXY apply without it)
+  WIREGUARD_INTERFACE, WIREGUARD_PEER, WIREGUARD_HANDSHAKE_AGE, WIREGUARD_PROBE
+  LOW_POWER_MODE (off, on, auto), POWER_SOURCE_FILE, LOW_POWER_CHECK_INTERVAL
+  API_LISTEN_ADDRESS, API_USERS_FILE, API_TLS, API_TLS_CERT, API_TLS_KEY
+  API_ACTION_LIMIT, API_ACTION_WINDOW, API_REFRESH_INTERVAL, AUDIT_LOG_FILE, API_KEYS_FILE
+  DOCKER_RESTART_CONTAINERS, DOCKER_RESTART_AFTER, DOCKER_SOCKET
+  PLUGINS
+  PUBLIC_IP_CHECK, PUBLIC_IP_SERVICES, PUBLIC_IP_RECORD_CYCLES, PUBLIC_IP_GEO_SERVICE
+  DDNS_PROVIDER, DDNS_DOMAIN, DDNS_TOKEN, DDNS_ZONE_ID, DDNS_SCRIPT
+  SMS_PROVIDER, SMS_TO, SMS_DEVICE, TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN, TWILIO_FROM
+  BEACON_URL, BEACON_INTERFACE, BEACON_INTERVAL, BEACON_TOKEN
+  TEXTFILE_PATH, TEXTFILE_INTERVAL
+  BACKUP_INTERVAL, BACKUP_DESTINATION, BACKUP_KEEP
+  BACKUP_S3_ENDPOINT, BACKUP_S3_REGION, BACKUP_S3_ACCESS_KEY, BACKUP_S3_SECRET_KEY
+  BACKUP_SFTP_KEY, BACKUP_SFTP_KNOWN_HOSTS, SYNC_INTERVAL, SYNC_DESTINATION, SYNC_SITE
+  CLUSTER_ROLE, CLUSTER_PEER, CLUSTER_PEER_KEY, CLUSTER_PEER_FINGERPRINT
+  CLUSTER_HEARTBEAT_INTERVAL, CLUSTER_FAILOVER_AFTER
+  QUORUM_PEERS, QUORUM_KEY, QUORUM_REQUIRED, QUORUM_MAX_AGE, QUORUM_NOVERIFY
   MAX_CONCURRENT_TESTS, CONNECTION_TIMEOUT, HTTP_TIMEOUT
   RETRY_ATTEMPTS, RETRY_BACKOFF_FACTOR
-  ENABLE_SYSTEMD, PID_FILE, WORKING_DIRECTORY`,
+  ENABLE_SYSTEMD, PID_FILE, WORKING_DIRECTORY, STATE_DIRECTORY, HISTORY_RAW_RETENTION
+  HISTORY_MAX_AGE, HISTORY_MAX_EVENTS, HISTORY_MAX_SIZE, REPORT_MAX_AGE, REPORT_MAX_SIZE
+  SANDBOX, STARTUP_CHECK, HOST_HEALTH_CHECK, HOST_MAX_CPU, DISABLED_FEATURES
+  LAN_FAILURE_ACTION, WAN_FAILURE_ACTION, UPSTREAM_FAILURE_ACTION
+  HA_OPTIONS_FILE (Home Assistant add-on options)`,
 	RunE: runWatchdog,
 }
 
@@ -123,6 +302,23 @@ var stopCmd = &cobra.Command{
 }
 
 func init() {
+	// Output language is resolved before any command prints
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		// Flags and arguments are valid by now, so errors from here on are
+		// not usage errors
+		cmd.SilenceUsage = true
+		if err := loadEnvFile(); err != nil {
+			return exitcode.Wrap(exitcode.Config, err)
+		}
+		i18n.SetLanguage(i18n.Detect(language))
+		return setupOutput()
+	}
+	// main prints errors, and flag errors exit with exitcode.Config
+	rootCmd.SilenceErrors = true
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return exitcode.Wrap(exitcode.Config, err)
+	})
+
 	// Add subcommands
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(reloadCmd)
@@ -130,35 +326,87 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().BoolVar(&healthCheck, "health-check", false, "Perform health check and exit")
-	rootCmd.PersistentFlags().BoolVarP(&showVersion, "version", "v", false, "Show version information")
+	rootCmd.PersistentFlags().BoolVarP(&showVersion, "version", "V", false, "Show version information")
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Configuration file path (JSON format)")
+	rootCmd.PersistentFlags().StringVar(&outputColor, "color", output.ColorAuto, "Color output: "+strings.Join(output.ColorModes(), ", ")+"; auto colors a terminal unless NO_COLOR is set")
+	rootCmd.PersistentFlags().BoolVar(&outputWide, "wide", false, "Never cut long values to the terminal width")
+	rootCmd.PersistentFlags().BoolVarP(&outputQuiet, "quiet", "q", false, "Print only warnings and failures, nothing when all is well")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Print details such as skipped checks, check durations and paths; implies --wide")
+	rootCmd.PersistentFlags().StringVar(&envFile, "env-file", "", "File of KEY=value environment variables, defaults to .env in the working directory")
 
 	// Modem configuration flags
+	rootCmd.PersistentFlags().StringVar(&modemType, "modem-type", "", "Modem driver: mb8600, arris-sb, netgear-cm, technicolor, plugin (env: MODEM_TYPE)")
 	rootCmd.PersistentFlags().StringVar(&modemHost, "modem-host", "", "Modem IP address or hostname (env: MODEM_HOST)")
+	rootCmd.PersistentFlags().IntVar(&modemPort, "modem-port", 0, "Port of the modem web interface, 0 for 443 over HTTPS and 80 over HTTP (env: MODEM_PORT)")
+	rootCmd.PersistentFlags().DurationVar(&modemStatsInterval, "modem-stats-interval", 0, "How often the modem's channels are read for metrics, 0 at startup only (env: MODEM_STATS_INTERVAL)")
+	rootCmd.PersistentFlags().IntVar(&partialServiceChannels, "partial-service-channels", config.DefaultPartialServiceChannels, "Unlocked downstream channels that put the modem in partial service, 0 disables it (env: PARTIAL_SERVICE_CHANNELS)")
+	rootCmd.PersistentFlags().DurationVar(&partialServiceRebootAfter, "partial-service-reboot-after", 0, "Reboot the modem once partial service lasts this long, 0 only notifies (env: PARTIAL_SERVICE_REBOOT_AFTER)")
+	rootCmd.PersistentFlags().Float64Var(&modemMaxTemperature, "modem-max-temperature", config.DefaultModemMaxTemperature, "Modem temperature in Celsius above which an overheating alert is sent, 0 never (env: MODEM_MAX_TEMPERATURE)")
+	rootCmd.PersistentFlags().IntVar(&docsisTimeoutsPerHour, "docsis-timeouts-per-hour", config.DefaultDOCSISTimeoutsPerHour, "T3 and T4 timeouts per hour that bring the docsis_timeouts health input to 0, 0 leaves the hour out (env: DOCSIS_TIMEOUTS_PER_HOUR)")
+	rootCmd.PersistentFlags().IntVar(&docsisTimeoutsPerDay, "docsis-timeouts-per-day", config.DefaultDOCSISTimeoutsPerDay, "T3 and T4 timeouts per day that bring the docsis_timeouts health input to 0, 0 leaves the day out (env: DOCSIS_TIMEOUTS_PER_DAY)")
 	rootCmd.PersistentFlags().StringVarP(&modemUsername, "modem-username", "u", "", "Modem admin username (env: MODEM_USERNAME)")
 	rootCmd.PersistentFlags().StringVarP(&modemPassword, "modem-password", "p", "", "Modem admin password (env: MODEM_PASSWORD)")
-	rootCmd.PersistentFlags().BoolVarP(&modemNoVerify, "modem-noverify", "n", false, "Disable SSL certificate verification (env: MODEM_NOVERIFY)")
+	toggleVarP(rootCmd, &modemNoVerify, "modem-noverify", "n", "Disable SSL certificate verification (env: MODEM_NOVERIFY)")
+	rootCmd.PersistentFlags().StringVar(&modemTunnelSSH, "modem-tunnel-ssh", "", "Reach the modem through this SSH jump host, [user@]host[:port] (env: MODEM_TUNNEL_SSH)")
+	rootCmd.PersistentFlags().StringVar(&modemTunnelSSHKey, "modem-tunnel-ssh-key", "", "Identity file for the SSH jump host (env: MODEM_TUNNEL_SSH_KEY)")
+	rootCmd.PersistentFlags().StringVar(&modemTunnelSSHKnownHosts, "modem-tunnel-ssh-known-hosts", "", "Known hosts file for the SSH jump host (env: MODEM_TUNNEL_SSH_KNOWN_HOSTS)")
+	rootCmd.PersistentFlags().StringVar(&modemTunnelInterface, "modem-tunnel-interface", "", "Reach the modem through this interface, such as a WireGuard wg0 (env: MODEM_TUNNEL_INTERFACE)")
 
 	// Monitoring configuration flags
 	rootCmd.PersistentFlags().DurationVar(&checkInterval, "check-interval", 0, "Interval between connectivity checks (env: CHECK_INTERVAL)")
 	rootCmd.PersistentFlags().IntVar(&failureThreshold, "failure-threshold", 0, "Number of consecutive failures before reboot (env: FAILURE_THRESHOLD)")
 	rootCmd.PersistentFlags().DurationVar(&recoveryWait, "recovery-wait", 0, "Wait time after modem reboot (env: RECOVERY_WAIT)")
+	rootCmd.PersistentFlags().StringVar(&scheduledReboot, "scheduled-reboot", "", "Cron expression of a preventive reboot regardless of health, e.g. \"0 4 * * sun\" (env: SCHEDULED_REBOOT)")
+	rootCmd.PersistentFlags().DurationVar(&scheduledSkip, "scheduled-reboot-skip-within", config.DefaultScheduledRebootSkipWithin, "Skip a scheduled reboot this soon after an outage or reboot; 0 never skips (env: SCHEDULED_REBOOT_SKIP_WITHIN)")
+	rootCmd.PersistentFlags().DurationVar(&rebootTimeout, "reboot-timeout", 0, "Deadline of a reboot and its recovery wait; 0 allows 10m plus the recovery wait (env: REBOOT_TIMEOUT)")
+	rootCmd.PersistentFlags().StringVar(&rebootShutdown, "reboot-shutdown-policy", config.DefaultRebootShutdownPolicy, "On shutdown, wait for a reboot in progress or abort it: wait or abort (env: REBOOT_SHUTDOWN_POLICY)")
 	rootCmd.PersistentFlags().StringSliceVar(&pingHosts, "ping-hosts", nil, "Comma-separated list of hosts to ping (env: PING_HOSTS)")
 	rootCmd.PersistentFlags().StringSliceVar(&httpHosts, "http-hosts", nil, "Comma-separated list of HTTP URLs to check (env: HTTP_HOSTS)")
+	toggleVarP(rootCmd, &allowLocalTargets, "allow-local-targets", "", "Allow ping and HTTP targets on the local network or on the modem (env: ALLOW_LOCAL_TARGETS)")
+	rootCmd.PersistentFlags().StringVar(&responderAddress, "responder", "", "host:port of a self-hosted responder to check instead of public services (env: RESPONDER)")
+	rootCmd.PersistentFlags().StringVar(&responderURL, "responder-url", "", "HTTP check URL of the responder (env: RESPONDER_URL)")
 
 	// Logging configuration flags
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "Log level: DEBUG, INFO, WARN, ERROR, FATAL, PANIC (env: LOG_LEVEL)")
+	rootCmd.PersistentFlags().StringSliceVar(&logModuleLevels, "log-module-levels", nil, "Comma-separated module=level overrides, e.g. modem=trace,connectivity=debug (env: LOG_MODULE_LEVELS)")
 	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Log file path, empty for stdout only (env: LOG_FILE)")
 	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "", "Log format: console, json, text (env: LOG_FORMAT)")
-	rootCmd.PersistentFlags().BoolVar(&enableDebug, "enable-debug", false, "Enable debug logging (env: ENABLE_DEBUG)")
-	rootCmd.PersistentFlags().BoolVar(&logRotation, "log-rotation", false, "Enable log rotation (env: LOG_ROTATION)")
+	toggleVarP(rootCmd, &enableDebug, "enable-debug", "", "Enable debug logging (env: ENABLE_DEBUG)")
+	toggleVarP(rootCmd, &logRotation, "log-rotation", "", "Enable log rotation (env: LOG_ROTATION)")
 	rootCmd.PersistentFlags().IntVar(&logMaxSize, "log-max-size", 0, "Maximum log file size in MB (env: LOG_MAX_SIZE)")
 	rootCmd.PersistentFlags().IntVar(&logMaxAge, "log-max-age", 0, "Maximum log file age in days (env: LOG_MAX_AGE)")
 
 	// Enhanced features flags
-	rootCmd.PersistentFlags().BoolVar(&enableDiagnostics, "enable-diagnostics", false, "Enable network diagnostics (env: ENABLE_DIAGNOSTICS)")
-	rootCmd.PersistentFlags().BoolVar(&enableDiagnostics, "disable-diagnostics", false, "Disable network diagnostics")
+	togglePairVar(rootCmd, &enableDiagnostics, "enable-diagnostics", "disable-diagnostics", "Enable network diagnostics (env: ENABLE_DIAGNOSTICS)", "Disable network diagnostics")
 	rootCmd.PersistentFlags().DurationVar(&diagnosticsTimeout, "diagnostics-timeout", 0, "Timeout for diagnostics tests (env: DIAGNOSTICS_TIMEOUT)")
+	rootCmd.PersistentFlags().StringSliceVar(&diagnosticPingTargets, "diagnostic-ping-targets", nil, "Comma-separated hosts pinged during diagnostics, defaults to the ping hosts (env: DIAGNOSTIC_PING_TARGETS)")
+	rootCmd.PersistentFlags().StringSliceVar(&diagnosticTCPTargets, "diagnostic-tcp-targets", nil, "Comma-separated host:port addresses connected to during diagnostics, defaults to the ping and HTTP hosts (env: DIAGNOSTIC_TCP_TARGETS)")
+	rootCmd.PersistentFlags().StringSliceVar(&diagnosticDNSTargets, "diagnostic-dns-targets", nil, "Comma-separated domains resolved during diagnostics, defaults to the HTTP hosts (env: DIAGNOSTIC_DNS_TARGETS)")
+	rootCmd.PersistentFlags().StringSliceVar(&diagnosticHTTPTargets, "diagnostic-http-targets", nil, "Comma-separated URLs requested during diagnostics, defaults to the HTTP hosts (env: DIAGNOSTIC_HTTP_TARGETS)")
+	rootCmd.PersistentFlags().DurationVar(&cycleBudget, "cycle-budget", 0, "Time a check cycle may spend testing and diagnosing, defaults to the check interval (env: CYCLE_BUDGET)")
+	rootCmd.PersistentFlags().IntVar(&cycleRetryBudget, "cycle-retry-budget", 0, "Retries a check cycle may spend across all targets, defaults to 6 (env: CYCLE_RETRY_BUDGET)")
+	rootCmd.PersistentFlags().StringSliceVar(&healthWeights, "health-weights", nil, "Comma-separated input=weight overrides of the health score, e.g. network=4,http=2 (env: HEALTH_WEIGHTS)")
+	rootCmd.PersistentFlags().IntVar(&healthDegradedScore, "health-degraded-score", 0, "Health score below which the connection is degraded, defaults to 60 (env: HEALTH_DEGRADED_SCORE)")
+	rootCmd.PersistentFlags().IntVar(&healthRebootScore, "health-reboot-score", 0, "Health score below which a check fails and a reboot is recommended, defaults to 50 (env: HEALTH_REBOOT_SCORE)")
+	rootCmd.PersistentFlags().StringVar(&checkOverlapPolicy, "check-overlap", "", "Skip or queue a check that comes due while the previous one still runs: skip, queue (env: CHECK_OVERLAP_POLICY)")
+	rootCmd.PersistentFlags().IntVar(&escalationFailures, "escalation-failures", connectivity.DefaultEscalationFailures, "Consecutive failed checks after which checks run the comprehensive tests right away; 0 never (env: ESCALATION_FAILURES)")
+	rootCmd.PersistentFlags().DurationVar(&escalationValidation, "escalation-validation-interval", connectivity.DefaultEscalationValidationInterval, "Run the comprehensive tests at least this often while the lightweight tests pass; 0 never (env: ESCALATION_VALIDATION_INTERVAL)")
+	rootCmd.PersistentFlags().StringSliceVar(&escalationWindows, "escalation-windows", nil, "Comma-separated HH:MM-HH:MM times of day during which every check is comprehensive (env: ESCALATION_WINDOWS)")
+	rootCmd.PersistentFlags().StringSliceVar(&checkDependencies, "check-dependencies", nil, "Comma-separated check:dependency entries, such as http:dns,dns:gateway; failures of a check whose dependency failed are suppressed (env: CHECK_DEPENDENCIES)")
+	rootCmd.PersistentFlags().StringVar(&gatewayTarget, "gateway-target", "", "host[:port] of the local gateway for the gateway check, port 80 by default (env: GATEWAY_TARGET)")
+	rootCmd.PersistentFlags().IntVar(&targetsPerCheck, "targets-per-check", 0, "DNS servers and HTTP hosts each check uses, taking turns through the lists; 0 uses all (env: TARGETS_PER_CHECK)")
+	rootCmd.PersistentFlags().IntVar(&quarantineFailures, "target-quarantine-failures", connectivity.DefaultQuarantineFailures, "Failures of a target while others passed, in its last 20 checks, that quarantine it; 0 never (env: TARGET_QUARANTINE_FAILURES)")
+	rootCmd.PersistentFlags().DurationVar(&quarantineDuration, "target-quarantine-duration", connectivity.DefaultQuarantineDuration, "How long a quarantined target sits out (env: TARGET_QUARANTINE_DURATION)")
+	rootCmd.PersistentFlags().StringVar(&outboundProxy, "outbound-proxy", "", "Proxy URL (http, https, socks5) for HTTP checks and the public IP and DDNS services, or direct for none; the modem never goes through it (env: OUTBOUND_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&wireguardInterface, "wireguard-interface", "", "WireGuard interface whose tunnel the lightweight tests check, such as wg0 (env: WIREGUARD_INTERFACE)")
+	rootCmd.PersistentFlags().StringVar(&wireguardPeer, "wireguard-peer", "", "Public key of the WireGuard peer to check, defaults to the peer with the latest handshake (env: WIREGUARD_PEER)")
+	rootCmd.PersistentFlags().DurationVar(&wireguardAge, "wireguard-handshake-age", connectivity.DefaultWireGuardHandshakeAge, "How old the latest WireGuard handshake may be (env: WIREGUARD_HANDSHAKE_AGE)")
+	rootCmd.PersistentFlags().StringVar(&wireguardProbe, "wireguard-probe", "", "host:port across the WireGuard tunnel to connect to, to check that traffic flows (env: WIREGUARD_PROBE)")
+	rootCmd.PersistentFlags().StringVar(&lowPowerMode, "low-power-mode", power.ModeOff, "Low-power mode for battery or solar power: off, on, or auto to follow --power-source-file (env: LOW_POWER_MODE)")
+	rootCmd.PersistentFlags().StringVar(&powerSourceFile, "power-source-file", "", "File reporting mains or battery power, such as /sys/class/power_supply/AC/online or a UPS status (env: POWER_SOURCE_FILE)")
+	rootCmd.PersistentFlags().DurationVar(&lowPowerInterval, "low-power-check-interval", power.DefaultCheckInterval, "Check interval in low-power mode (env: LOW_POWER_CHECK_INTERVAL)")
+	rootCmd.PersistentFlags().StringVar(&messageTemplates, "message-templates", "", "Template file or directory overriding notification and report text (env: MESSAGE_TEMPLATES)")
+	rootCmd.PersistentFlags().IntVar(&notificationQueueSize, "notification-queue-size", notify.DefaultQueueSize, "Undeliverable notifications kept for delivery once connectivity returns; 0 drops them (env: NOTIFICATION_QUEUE_SIZE)")
 	rootCmd.PersistentFlags().DurationVar(&outageReportInterval, "outage-report-interval", 0, "Interval for outage reports (env: OUTAGE_REPORT_INTERVAL)")
 
 	// Performance settings flags
@@ -169,24 +417,146 @@ func init() {
 	rootCmd.PersistentFlags().Float64Var(&retryBackoffFactor, "retry-backoff-factor", 0, "Exponential backoff factor for retries (env: RETRY_BACKOFF_FACTOR)")
 
 	// System settings flags
-	rootCmd.PersistentFlags().BoolVar(&enableSystemd, "enable-systemd", false, "Enable systemd integration (env: ENABLE_SYSTEMD)")
+	toggleVarP(rootCmd, &enableSystemd, "enable-systemd", "", "Enable systemd integration (env: ENABLE_SYSTEMD)")
 	rootCmd.PersistentFlags().StringVar(&pidFile, "pid-file", "", "PID file path (env: PID_FILE)")
 	rootCmd.PersistentFlags().StringVar(&workingDirectory, "working-directory", "", "Working directory (env: WORKING_DIRECTORY)")
+	rootCmd.PersistentFlags().StringVar(&stateDirectory, "state-directory", "", "Directory for state, reports and history (env: STATE_DIRECTORY)")
+	rootCmd.PersistentFlags().DurationVar(&historyRawRetention, "history-raw-retention", config.DefaultHistoryRawRetention, "How long check results are kept in the history before they are downsampled to hourly aggregates; 0 does not record them (env: HISTORY_RAW_RETENTION)")
+	rootCmd.PersistentFlags().IntVar(&historyMaxAge, "history-max-age", 0, "Maximum history age in days; 0 for no limit (env: HISTORY_MAX_AGE)")
+	rootCmd.PersistentFlags().IntVar(&historyMaxEvents, "history-max-events", 0, "Maximum number of history events; 0 for no limit (env: HISTORY_MAX_EVENTS)")
+	rootCmd.PersistentFlags().IntVar(&historyMaxSize, "history-max-size", 0, "Maximum history size in MB; 0 for no limit (env: HISTORY_MAX_SIZE)")
+	rootCmd.PersistentFlags().IntVar(&reportMaxAge, "report-max-age", 0, "Maximum outage report age in days; 0 for no limit (env: REPORT_MAX_AGE)")
+	rootCmd.PersistentFlags().IntVar(&reportMaxSize, "report-max-size", 0, "Maximum size of the outage reports in MB; 0 for no limit (env: REPORT_MAX_SIZE)")
+	toggleVarP(rootCmd, &sandboxMode, "sandbox", "", "Confine the process with landlock and seccomp (env: SANDBOX)")
+	toggleVarP(rootCmd, &startupCheck, "startup-check", "", "Check the configuration, modem login, targets and paths at startup (env: STARTUP_CHECK)")
+	toggleVarP(rootCmd, &hostHealthCheck, "host-health-check", "", "Hold reboots back while this host's interface, route, CPU or clock looks broken (env: HOST_HEALTH_CHECK)")
+	rootCmd.PersistentFlags().Float64Var(&hostMaxCPU, "host-max-cpu", hosthealth.DefaultMaxCPU, "CPU usage in percent above which this host counts as degraded (env: HOST_MAX_CPU)")
+	actions := strings.Join(verdict.Actions, ", ")
+	rootCmd.PersistentFlags().StringVar(&lanFailureAction, "lan-failure-action", verdict.DefaultLANAction, "Action for a failure between this host and the modem: "+actions+" (env: LAN_FAILURE_ACTION)")
+	rootCmd.PersistentFlags().StringVar(&wanFailureAction, "wan-failure-action", verdict.DefaultWANAction, "Action for a failure of the modem or its line: "+actions+" (env: WAN_FAILURE_ACTION)")
+	rootCmd.PersistentFlags().StringVar(&upstreamAction, "upstream-failure-action", verdict.DefaultUpstreamAction, "Action for a failure beyond the modem, at the ISP: "+actions+" (env: UPSTREAM_FAILURE_ACTION)")
+	rootCmd.PersistentFlags().StringSliceVar(&disabledFeatures, "disable-feature", nil, "Switch off optional subsystems: "+strings.Join(features.Names(), ", ")+" (env: DISABLED_FEATURES)")
+
+	// Local API flags
+	rootCmd.PersistentFlags().StringVar(&language, "language", "", "Language of CLI output and notifications: "+strings.Join(i18n.Languages(), ", ")+" (env: WATCHDOG_LANGUAGE, defaults to the locale)")
+	rootCmd.PersistentFlags().StringVar(&apiListenAddress, "api-listen", "", "Serve the local API on host:port, e.g. 127.0.0.1:8600 (env: API_LISTEN_ADDRESS)")
+	rootCmd.PersistentFlags().StringVar(&apiTLS, "api-tls", config.DefaultAPITLS, "Serve the API over HTTPS (auto) or plain HTTP (off) (env: API_TLS)")
+	rootCmd.PersistentFlags().StringVar(&apiTLSCert, "api-tls-cert", "", "API certificate file, defaults to a persisted self-signed certificate (env: API_TLS_CERT)")
+	rootCmd.PersistentFlags().StringVar(&apiTLSKey, "api-tls-key", "", "API private key file (env: API_TLS_KEY)")
+	rootCmd.PersistentFlags().IntVar(&apiActionLimit, "api-action-limit", config.DefaultAPIActionLimit, "Control actions, such as reboots, allowed per window; 0 disables the limit (env: API_ACTION_LIMIT)")
+	rootCmd.PersistentFlags().DurationVar(&apiActionWindow, "api-action-window", config.DefaultAPIActionWindow, "Window of the control action limit (env: API_ACTION_WINDOW)")
+	rootCmd.PersistentFlags().DurationVar(&apiRefresh, "api-refresh-interval", config.DefaultAPIRefreshInterval, "A forced status refresh reuses a check completed this recently (env: API_REFRESH_INTERVAL)")
+	rootCmd.PersistentFlags().StringVar(&auditLogFile, "audit-log", "", "Append-only log of control actions, defaults to logs/audit.log in the working directory (env: AUDIT_LOG_FILE)")
+	rootCmd.PersistentFlags().StringVar(&apiKeysFile, "api-keys", "", "API keys file managed with the api-key command, defaults to api-keys.json in the working directory (env: API_KEYS_FILE)")
+	rootCmd.PersistentFlags().StringVar(&apiUsersFile, "api-users", "", "JSON file of API users and roles; enables authentication (env: API_USERS_FILE)")
+
+	// Recovery action flags
+	rootCmd.PersistentFlags().StringSliceVar(&dockerRestart, "docker-restart", nil, "Comma-separated containers to restart after a long outage (env: DOCKER_RESTART_CONTAINERS)")
+	rootCmd.PersistentFlags().StringSliceVar(&plugins, "plugin", nil, "Absolute path of a plugin program to start, repeatable (env: PLUGINS)")
+	rootCmd.PersistentFlags().DurationVar(&dockerRestartAfter, "docker-restart-after", config.DefaultDockerRestartAfter, "Minimum outage length that restarts the containers (env: DOCKER_RESTART_AFTER)")
+	rootCmd.PersistentFlags().StringVar(&dockerSocket, "docker-socket", config.DefaultDockerSocket, "Docker Engine API socket (env: DOCKER_SOCKET)")
+
+	// Public IP and dynamic DNS flags; the DDNS token is only read from the
+	// environment or config file, so it does not show up in the process list
+	toggleVarP(rootCmd, &publicIPCheck, "public-ip-check", "", "Look up the public IP after outages and record changes (env: PUBLIC_IP_CHECK)")
+	rootCmd.PersistentFlags().StringSliceVar(&publicIPServices, "public-ip-services", nil, "Comma-separated IP echo service URLs (env: PUBLIC_IP_SERVICES)")
+	rootCmd.PersistentFlags().IntVar(&publicIPRecordCycles, "public-ip-record-cycles", 0, "Record the public IP in the history every N check cycles; 0 disables (env: PUBLIC_IP_RECORD_CYCLES)")
+	rootCmd.PersistentFlags().StringVar(&publicIPGeoService, "public-ip-geo-service", "", "Geolocation service URL with an {ip} placeholder (env: PUBLIC_IP_GEO_SERVICE)")
+	toggleVarP(rootCmd, &routingProbe, "routing-probe", "", "Compare anycast targets during diagnostics to detect ISP routing incidents (env: ROUTING_PROBE)")
+	rootCmd.PersistentFlags().StringSliceVar(&routingProbeTargets, "routing-probe-targets", nil, "Comma-separated host:port routing probe targets (env: ROUTING_PROBE_TARGETS)")
+	rootCmd.PersistentFlags().StringVar(&ddnsProvider, "ddns-provider", "", "Dynamic DNS provider updated on IP changes: cloudflare, duckdns, script (env: DDNS_PROVIDER)")
+	rootCmd.PersistentFlags().StringVar(&ddnsDomain, "ddns-domain", "", "Cloudflare record name or DuckDNS subdomain (env: DDNS_DOMAIN)")
+	rootCmd.PersistentFlags().StringVar(&ddnsZoneID, "ddns-zone-id", "", "Cloudflare zone ID of the record (env: DDNS_ZONE_ID)")
+	rootCmd.PersistentFlags().StringVar(&ddnsScript, "ddns-script", "", "Script run with the old and new IP (env: DDNS_SCRIPT)")
+
+	// SMS notification flags; the Twilio auth token is only read from the
+	// environment or config file
+	rootCmd.PersistentFlags().StringVar(&smsProvider, "sms-provider", "", "Send notifications by SMS: twilio, or gsm for a GSM modem on a serial port (env: SMS_PROVIDER)")
+	rootCmd.PersistentFlags().StringSliceVar(&smsTo, "sms-to", nil, "Comma-separated phone numbers to text, such as +15551234567 (env: SMS_TO)")
+	rootCmd.PersistentFlags().StringVar(&smsDevice, "sms-device", "", "Serial port of the GSM modem, such as /dev/ttyUSB2 (env: SMS_DEVICE)")
+	rootCmd.PersistentFlags().StringVar(&twilioAccountSID, "twilio-account-sid", "", "Twilio account SID (env: TWILIO_ACCOUNT_SID)")
+	rootCmd.PersistentFlags().StringVar(&twilioFrom, "twilio-from", "", "Twilio phone number or messaging service SID to send from (env: TWILIO_FROM)")
+
+	// Status beacon flags; the token is only read from the environment or
+	// config file
+	rootCmd.PersistentFlags().StringVar(&beaconURL, "beacon-url", "", "Endpoint to send periodic status reports to (env: BEACON_URL)")
+	rootCmd.PersistentFlags().StringVar(&beaconInterface, "beacon-interface", "", "Send status reports through this secondary interface, such as an LTE stick's wwan0 (env: BEACON_INTERFACE)")
+	rootCmd.PersistentFlags().DurationVar(&beaconInterval, "beacon-interval", beacon.DefaultInterval, "Time between status reports (env: BEACON_INTERVAL)")
+
+	// node_exporter textfile flags
+	rootCmd.PersistentFlags().StringVar(&textfilePath, "textfile-path", "", "Write metrics to this .prom file in the node_exporter textfile collector directory (env: TEXTFILE_PATH)")
+	rootCmd.PersistentFlags().DurationVar(&textfileInterval, "textfile-interval", textfile.DefaultInterval, "Time between rewrites of the metrics textfile (env: TEXTFILE_INTERVAL)")
+
+	// Backup flags; the S3 keys are only read from the environment or config
+	// file
+	rootCmd.PersistentFlags().DurationVar(&backupInterval, "backup-interval", 0, "Time between scheduled backups; 0 disables them (env: BACKUP_INTERVAL)")
+	rootCmd.PersistentFlags().StringVar(&backupDestination, "backup-destination", "", "Directory, s3://, webdavs:// or sftp:// URL backups are stored in (env: BACKUP_DESTINATION)")
+	rootCmd.PersistentFlags().IntVar(&backupKeep, "backup-keep", backup.DefaultKeep, "Backups kept in a backup directory; 0 keeps all (env: BACKUP_KEEP)")
+	rootCmd.PersistentFlags().StringVar(&backupS3Endpoint, "backup-s3-endpoint", "", "S3-compatible endpoint for backups, by default AWS (env: BACKUP_S3_ENDPOINT)")
+	rootCmd.PersistentFlags().StringVar(&backupS3Region, "backup-s3-region", backup.DefaultS3Region, "Region S3 backup uploads are signed for (env: BACKUP_S3_REGION)")
+
+	// Remote sync flags; the destination's credentials are those of backups
+	rootCmd.PersistentFlags().DurationVar(&syncInterval, "sync-interval", 0, "Time between uploads of the history and outage reports; 0 disables them (env: SYNC_INTERVAL)")
+	rootCmd.PersistentFlags().StringVar(&syncDestination, "sync-destination", "", "Directory, s3://, webdavs:// or sftp:// URL the history and reports are uploaded to (env: SYNC_DESTINATION)")
+	rootCmd.PersistentFlags().StringVar(&syncSite, "sync-site", "", "Folder of this host at the sync destination, by default the hostname (env: SYNC_SITE)")
+
+	// Cluster flags; the peer key is only read from the environment or
+	// config file
+	rootCmd.PersistentFlags().StringVar(&clusterRole, "cluster-role", "", "Role in an active/standby cluster: primary or standby (env: CLUSTER_ROLE)")
+	rootCmd.PersistentFlags().StringVar(&clusterPeer, "cluster-peer", "", "URL of the other cluster node's API (env: CLUSTER_PEER)")
+	rootCmd.PersistentFlags().StringVar(&clusterFingerprint, "cluster-peer-fingerprint", "", "SHA-256 fingerprint of the other cluster node's self-signed API certificate (env: CLUSTER_PEER_FINGERPRINT)")
+	rootCmd.PersistentFlags().DurationVar(&clusterHeartbeat, "cluster-heartbeat-interval", cluster.DefaultHeartbeatInterval, "Time between heartbeat polls of the other cluster node (env: CLUSTER_HEARTBEAT_INTERVAL)")
+	rootCmd.PersistentFlags().DurationVar(&clusterFailoverAfter, "cluster-failover-after", cluster.DefaultFailoverAfter, "Silence of the primary after which the standby takes over (env: CLUSTER_FAILOVER_AFTER)")
+
+	// Reboot quorum flags; the key is only read from the environment or
+	// config file
+	rootCmd.PersistentFlags().StringSliceVar(&quorumPeers, "quorum-peers", nil, "Comma-separated URLs of other agents' APIs that must agree the internet is down before a reboot (env: QUORUM_PEERS)")
+	rootCmd.PersistentFlags().IntVar(&quorumRequired, "quorum-required", 0, "Agents, this one included, that must see the internet down, 0 for a majority (env: QUORUM_REQUIRED)")
+	rootCmd.PersistentFlags().DurationVar(&quorumMaxAge, "quorum-max-age", quorum.DefaultMaxAge, "How old an agent's last check may be to count (env: QUORUM_MAX_AGE)")
+	toggleVarP(rootCmd, &quorumNoVerify, "quorum-noverify", "", "Disable SSL certificate verification of the quorum agents (env: QUORUM_NOVERIFY)")
+
+	// Shell completion of flag values
+	registerFlagCompletions()
 }
 
+// main runs the command and exits with the most severe code of its output
+// and its error; see package exitcode for the codes
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	usageErrors(rootCmd)
+	err := rootCmd.Execute()
+	code := exitcode.Of(err)
+	if printer != nil {
+		printer.Flush()
+		code = exitcode.Worst(code, printer.ExitCode())
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	}
+	os.Exit(code)
+}
+
+// usageErrors makes invalid arguments of cmd and its subcommands exit with
+// exitcode.Config, as invalid flags do
+func usageErrors(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			return exitcode.Wrap(exitcode.Config, validate(cmd, args))
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		usageErrors(sub)
 	}
 }
 
 // runWatchdog is the main command handler
 func runWatchdog(cmd *cobra.Command, args []string) error {
 	if showVersion {
-		fmt.Printf("MB8600 Watchdog %s\n", version)
-		fmt.Printf("Commit: %s\n", commit)
-		fmt.Printf("Built: %s\n", buildTime)
+		// Features switched off in the configuration are reported as disabled
+		var enabled func(string) bool
+		if cfg, err := loadConfigWithCLIOverrides(cmd); err == nil {
+			enabled = cfg.FeatureEnabled
+		}
+		printVersion(buildinfo.Get(enabled))
 		return nil
 	}
 
@@ -203,6 +573,18 @@ func runWatchdog(cmd *cobra.Command, args []string) error {
 	return app.RunWithConfig(cfg)
 }
 
+// loadEnvFile loads --env-file, or .env in the working directory if there
+// is one
+func loadEnvFile() error {
+	if envFile != "" {
+		return config.LoadEnvFile(envFile)
+	}
+	if _, err := os.Stat(config.DefaultEnvFile); err != nil {
+		return nil
+	}
+	return config.LoadEnvFile(config.DefaultEnvFile)
+}
+
 // loadConfigWithCLIOverrides loads configuration with CLI argument precedence
 func loadConfigWithCLIOverrides(cmd *cobra.Command) (*config.Config, error) {
 	// Load base configuration (environment variables + file + defaults)
@@ -211,26 +593,62 @@ func loadConfigWithCLIOverrides(cmd *cobra.Command) (*config.Config, error) {
 
 	if configFile != "" {
 		cfg, err = config.LoadFromFile(configFile)
+	} else if uci := config.OpenWrtConfig(); uci != "" {
+		cfg, err = config.LoadFromFile(uci)
 	} else {
 		cfg, err = config.Load()
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, exitcode.Wrap(exitcode.Config, err)
 	}
 
 	// Override with CLI arguments (only if they were explicitly set)
+	if cmd.Flags().Changed("modem-type") {
+		cfg.ModemType = modemType
+	}
 	if cmd.Flags().Changed("modem-host") {
 		cfg.ModemHost = modemHost
 	}
+	if cmd.Flags().Changed("modem-port") {
+		cfg.ModemPort = modemPort
+	}
+	if cmd.Flags().Changed("modem-stats-interval") {
+		cfg.ModemStatsInterval = modemStatsInterval
+	}
+	if cmd.Flags().Changed("partial-service-channels") {
+		cfg.PartialServiceChannels = partialServiceChannels
+	}
+	if cmd.Flags().Changed("partial-service-reboot-after") {
+		cfg.PartialServiceRebootAfter = partialServiceRebootAfter
+	}
+	if cmd.Flags().Changed("modem-max-temperature") {
+		cfg.ModemMaxTemperature = modemMaxTemperature
+	}
+	if cmd.Flags().Changed("docsis-timeouts-per-hour") {
+		cfg.DOCSISTimeoutsPerHour = docsisTimeoutsPerHour
+	}
+	if cmd.Flags().Changed("docsis-timeouts-per-day") {
+		cfg.DOCSISTimeoutsPerDay = docsisTimeoutsPerDay
+	}
 	if cmd.Flags().Changed("modem-username") {
 		cfg.ModemUsername = modemUsername
 	}
 	if cmd.Flags().Changed("modem-password") {
 		cfg.ModemPassword = modemPassword
 	}
-	if cmd.Flags().Changed("modem-noverify") {
-		cfg.ModemNoVerify = modemNoVerify
+	modemNoVerify.Apply(&cfg.ModemNoVerify)
+	if cmd.Flags().Changed("modem-tunnel-ssh") {
+		cfg.ModemTunnelSSH = modemTunnelSSH
+	}
+	if cmd.Flags().Changed("modem-tunnel-ssh-key") {
+		cfg.ModemTunnelSSHKey = modemTunnelSSHKey
+	}
+	if cmd.Flags().Changed("modem-tunnel-ssh-known-hosts") {
+		cfg.ModemTunnelSSHKnownHosts = modemTunnelSSHKnownHosts
+	}
+	if cmd.Flags().Changed("modem-tunnel-interface") {
+		cfg.ModemTunnelInterface = modemTunnelInterface
 	}
 
 	if cmd.Flags().Changed("check-interval") {
@@ -242,28 +660,45 @@ func loadConfigWithCLIOverrides(cmd *cobra.Command) (*config.Config, error) {
 	if cmd.Flags().Changed("recovery-wait") {
 		cfg.RecoveryWait = recoveryWait
 	}
+	if cmd.Flags().Changed("scheduled-reboot") {
+		cfg.ScheduledReboot = scheduledReboot
+	}
+	if cmd.Flags().Changed("scheduled-reboot-skip-within") {
+		cfg.ScheduledRebootSkipWithin = scheduledSkip
+	}
+	if cmd.Flags().Changed("reboot-timeout") {
+		cfg.RebootTimeout = rebootTimeout
+	}
+	if cmd.Flags().Changed("reboot-shutdown-policy") {
+		cfg.RebootShutdownPolicy = rebootShutdown
+	}
 	if cmd.Flags().Changed("ping-hosts") {
 		cfg.PingHosts = pingHosts
 	}
 	if cmd.Flags().Changed("http-hosts") {
 		cfg.HTTPHosts = httpHosts
 	}
+	if cmd.Flags().Changed("responder") {
+		cfg.Responder = responderAddress
+	}
+	if cmd.Flags().Changed("responder-url") {
+		cfg.ResponderURL = responderURL
+	}
 
 	if cmd.Flags().Changed("log-level") {
 		cfg.LogLevel = logLevel
 	}
+	if cmd.Flags().Changed("log-module-levels") {
+		cfg.LogModuleLevels = logModuleLevels
+	}
 	if cmd.Flags().Changed("log-file") {
 		cfg.LogFile = logFile
 	}
 	if cmd.Flags().Changed("log-format") {
 		cfg.LogFormat = logFormat
 	}
-	if cmd.Flags().Changed("enable-debug") {
-		cfg.EnableDebug = enableDebug
-	}
-	if cmd.Flags().Changed("log-rotation") {
-		cfg.LogRotation = logRotation
-	}
+	enableDebug.Apply(&cfg.EnableDebug)
+	logRotation.Apply(&cfg.LogRotation)
 	if cmd.Flags().Changed("log-max-size") {
 		cfg.LogMaxSize = logMaxSize
 	}
@@ -271,15 +706,94 @@ func loadConfigWithCLIOverrides(cmd *cobra.Command) (*config.Config, error) {
 		cfg.LogMaxAge = logMaxAge
 	}
 
-	if cmd.Flags().Changed("enable-diagnostics") {
-		cfg.EnableDiagnostics = enableDiagnostics
-	}
-	if cmd.Flags().Changed("disable-diagnostics") {
-		cfg.EnableDiagnostics = false
-	}
+	enableDiagnostics.Apply(&cfg.EnableDiagnostics)
 	if cmd.Flags().Changed("diagnostics-timeout") {
 		cfg.DiagnosticsTimeout = diagnosticsTimeout
 	}
+	if cmd.Flags().Changed("diagnostic-ping-targets") {
+		cfg.DiagnosticPingTargets = diagnosticPingTargets
+	}
+	if cmd.Flags().Changed("diagnostic-tcp-targets") {
+		cfg.DiagnosticTCPTargets = diagnosticTCPTargets
+	}
+	if cmd.Flags().Changed("diagnostic-dns-targets") {
+		cfg.DiagnosticDNSTargets = diagnosticDNSTargets
+	}
+	if cmd.Flags().Changed("diagnostic-http-targets") {
+		cfg.DiagnosticHTTPTargets = diagnosticHTTPTargets
+	}
+	if cmd.Flags().Changed("cycle-budget") {
+		cfg.CycleBudget = cycleBudget
+	}
+	if cmd.Flags().Changed("cycle-retry-budget") {
+		cfg.CycleRetryBudget = cycleRetryBudget
+	}
+	if cmd.Flags().Changed("health-weights") {
+		cfg.HealthWeights = healthWeights
+	}
+	if cmd.Flags().Changed("health-degraded-score") {
+		cfg.HealthDegradedScore = healthDegradedScore
+	}
+	if cmd.Flags().Changed("health-reboot-score") {
+		cfg.HealthRebootScore = healthRebootScore
+	}
+	if cmd.Flags().Changed("check-overlap") {
+		cfg.CheckOverlapPolicy = checkOverlapPolicy
+	}
+	if cmd.Flags().Changed("escalation-failures") {
+		cfg.EscalationFailures = escalationFailures
+	}
+	if cmd.Flags().Changed("escalation-validation-interval") {
+		cfg.EscalationValidationInterval = escalationValidation
+	}
+	if cmd.Flags().Changed("escalation-windows") {
+		cfg.EscalationWindows = escalationWindows
+	}
+	if cmd.Flags().Changed("check-dependencies") {
+		cfg.CheckDependencies = checkDependencies
+	}
+	if cmd.Flags().Changed("gateway-target") {
+		cfg.GatewayTarget = gatewayTarget
+	}
+	if cmd.Flags().Changed("targets-per-check") {
+		cfg.TargetsPerCheck = targetsPerCheck
+	}
+	if cmd.Flags().Changed("target-quarantine-failures") {
+		cfg.TargetQuarantineFailures = quarantineFailures
+	}
+	if cmd.Flags().Changed("target-quarantine-duration") {
+		cfg.TargetQuarantineDuration = quarantineDuration
+	}
+	if cmd.Flags().Changed("outbound-proxy") {
+		cfg.OutboundProxy = outboundProxy
+	}
+	if cmd.Flags().Changed("wireguard-interface") {
+		cfg.WireGuardInterface = wireguardInterface
+	}
+	if cmd.Flags().Changed("wireguard-peer") {
+		cfg.WireGuardPeer = wireguardPeer
+	}
+	if cmd.Flags().Changed("wireguard-handshake-age") {
+		cfg.WireGuardHandshakeAge = wireguardAge
+	}
+	if cmd.Flags().Changed("wireguard-probe") {
+		cfg.WireGuardProbe = wireguardProbe
+	}
+	if cmd.Flags().Changed("low-power-mode") {
+		cfg.LowPowerMode = lowPowerMode
+	}
+	if cmd.Flags().Changed("power-source-file") {
+		cfg.PowerSourceFile = powerSourceFile
+	}
+	if cmd.Flags().Changed("low-power-check-interval") {
+		cfg.LowPowerCheckInterval = lowPowerInterval
+	}
+	if cmd.Flags().Changed("message-templates") {
+		cfg.MessageTemplates = messageTemplates
+	}
+	if cmd.Flags().Changed("notification-queue-size") {
+		cfg.NotificationQueueSize = notificationQueueSize
+	}
 	if cmd.Flags().Changed("outage-report-interval") {
 		cfg.OutageReportInterval = outageReportInterval
 	}
@@ -300,87 +814,318 @@ func loadConfigWithCLIOverrides(cmd *cobra.Command) (*config.Config, error) {
 		cfg.RetryBackoffFactor = retryBackoffFactor
 	}
 
-	if cmd.Flags().Changed("enable-systemd") {
-		cfg.EnableSystemd = enableSystemd
-	}
+	enableSystemd.Apply(&cfg.EnableSystemd)
 	if cmd.Flags().Changed("pid-file") {
 		cfg.PidFile = pidFile
 	}
 	if cmd.Flags().Changed("working-directory") {
 		cfg.WorkingDirectory = workingDirectory
 	}
+	if cmd.Flags().Changed("state-directory") {
+		cfg.StateDirectory = stateDirectory
+	}
+	if cmd.Flags().Changed("history-raw-retention") {
+		cfg.HistoryRawRetention = historyRawRetention
+	}
+	if cmd.Flags().Changed("history-max-age") {
+		cfg.HistoryMaxAge = historyMaxAge
+	}
+	if cmd.Flags().Changed("history-max-events") {
+		cfg.HistoryMaxEvents = historyMaxEvents
+	}
+	if cmd.Flags().Changed("history-max-size") {
+		cfg.HistoryMaxSize = historyMaxSize
+	}
+	if cmd.Flags().Changed("report-max-age") {
+		cfg.ReportMaxAge = reportMaxAge
+	}
+	if cmd.Flags().Changed("report-max-size") {
+		cfg.ReportMaxSize = reportMaxSize
+	}
+	sandboxMode.Apply(&cfg.Sandbox)
+	startupCheck.Apply(&cfg.StartupCheck)
+	hostHealthCheck.Apply(&cfg.HostHealthCheck)
+	if cmd.Flags().Changed("host-max-cpu") {
+		cfg.HostMaxCPU = hostMaxCPU
+	}
+	if cmd.Flags().Changed("lan-failure-action") {
+		cfg.LANFailureAction = lanFailureAction
+	}
+	if cmd.Flags().Changed("wan-failure-action") {
+		cfg.WANFailureAction = wanFailureAction
+	}
+	if cmd.Flags().Changed("upstream-failure-action") {
+		cfg.UpstreamFailureAction = upstreamAction
+	}
+	if cmd.Flags().Changed("plugin") {
+		cfg.Plugins = plugins
+	}
+	if cmd.Flags().Changed("disable-feature") {
+		cfg.DisabledFeatures = disabledFeatures
+	}
+
+	if cmd.Flags().Changed("language") {
+		cfg.Language = language
+	}
+	if cmd.Flags().Changed("api-listen") {
+		cfg.APIListenAddress = apiListenAddress
+	}
+	if cmd.Flags().Changed("api-users") {
+		cfg.APIUsersFile = apiUsersFile
+	}
+	if cmd.Flags().Changed("api-action-limit") {
+		cfg.APIActionLimit = apiActionLimit
+	}
+	if cmd.Flags().Changed("api-action-window") {
+		cfg.APIActionWindow = apiActionWindow
+	}
+	if cmd.Flags().Changed("api-refresh-interval") {
+		cfg.APIRefreshInterval = apiRefresh
+	}
+	if cmd.Flags().Changed("audit-log") {
+		cfg.AuditLogFile = auditLogFile
+	}
+	if cmd.Flags().Changed("api-keys") {
+		cfg.APIKeysFile = apiKeysFile
+	}
+	if cmd.Flags().Changed("api-tls") {
+		cfg.APITLS = apiTLS
+	}
+	if cmd.Flags().Changed("api-tls-cert") {
+		cfg.APITLSCert = apiTLSCert
+	}
+	if cmd.Flags().Changed("api-tls-key") {
+		cfg.APITLSKey = apiTLSKey
+	}
+	if cmd.Flags().Changed("docker-restart") {
+		cfg.DockerRestartContainers = dockerRestart
+	}
+	if cmd.Flags().Changed("docker-restart-after") {
+		cfg.DockerRestartAfter = dockerRestartAfter
+	}
+	if cmd.Flags().Changed("docker-socket") {
+		cfg.DockerSocket = dockerSocket
+	}
+	publicIPCheck.Apply(&cfg.PublicIPCheck)
+	if cmd.Flags().Changed("public-ip-services") {
+		cfg.PublicIPServices = publicIPServices
+	}
+	if cmd.Flags().Changed("public-ip-record-cycles") {
+		cfg.PublicIPRecordCycles = publicIPRecordCycles
+	}
+	if cmd.Flags().Changed("public-ip-geo-service") {
+		cfg.PublicIPGeoService = publicIPGeoService
+	}
+	routingProbe.Apply(&cfg.RoutingProbe)
+	allowLocalTargets.Apply(&cfg.AllowLocalTargets)
+	if cmd.Flags().Changed("routing-probe-targets") {
+		cfg.RoutingProbeTargets = routingProbeTargets
+	}
+	if cmd.Flags().Changed("ddns-provider") {
+		cfg.DDNSProvider = ddnsProvider
+	}
+	if cmd.Flags().Changed("ddns-domain") {
+		cfg.DDNSDomain = ddnsDomain
+	}
+	if cmd.Flags().Changed("ddns-zone-id") {
+		cfg.DDNSZoneID = ddnsZoneID
+	}
+	if cmd.Flags().Changed("ddns-script") {
+		cfg.DDNSScript = ddnsScript
+	}
+	if cmd.Flags().Changed("sms-provider") {
+		cfg.SMSProvider = smsProvider
+	}
+	if cmd.Flags().Changed("sms-to") {
+		cfg.SMSTo = smsTo
+	}
+	if cmd.Flags().Changed("sms-device") {
+		cfg.SMSDevice = smsDevice
+	}
+	if cmd.Flags().Changed("twilio-account-sid") {
+		cfg.TwilioAccountSID = twilioAccountSID
+	}
+	if cmd.Flags().Changed("twilio-from") {
+		cfg.TwilioFrom = twilioFrom
+	}
+	if cmd.Flags().Changed("beacon-url") {
+		cfg.BeaconURL = beaconURL
+	}
+	if cmd.Flags().Changed("beacon-interface") {
+		cfg.BeaconInterface = beaconInterface
+	}
+	if cmd.Flags().Changed("beacon-interval") {
+		cfg.BeaconInterval = beaconInterval
+	}
+	if cmd.Flags().Changed("textfile-path") {
+		cfg.TextfilePath = textfilePath
+	}
+	if cmd.Flags().Changed("textfile-interval") {
+		cfg.TextfileInterval = textfileInterval
+	}
+	if cmd.Flags().Changed("backup-interval") {
+		cfg.BackupInterval = backupInterval
+	}
+	if cmd.Flags().Changed("backup-destination") {
+		cfg.BackupDestination = backupDestination
+	}
+	if cmd.Flags().Changed("backup-keep") {
+		cfg.BackupKeep = backupKeep
+	}
+	if cmd.Flags().Changed("backup-s3-endpoint") {
+		cfg.BackupS3Endpoint = backupS3Endpoint
+	}
+	if cmd.Flags().Changed("backup-s3-region") {
+		cfg.BackupS3Region = backupS3Region
+	}
+	if cmd.Flags().Changed("sync-interval") {
+		cfg.SyncInterval = syncInterval
+	}
+	if cmd.Flags().Changed("sync-destination") {
+		cfg.SyncDestination = syncDestination
+	}
+	if cmd.Flags().Changed("sync-site") {
+		cfg.SyncSite = syncSite
+	}
+	if cmd.Flags().Changed("cluster-role") {
+		cfg.ClusterRole = clusterRole
+	}
+	if cmd.Flags().Changed("cluster-peer") {
+		cfg.ClusterPeer = clusterPeer
+	}
+	if cmd.Flags().Changed("cluster-peer-fingerprint") {
+		cfg.ClusterPeerFingerprint = clusterFingerprint
+	}
+	if cmd.Flags().Changed("cluster-heartbeat-interval") {
+		cfg.ClusterHeartbeatInterval = clusterHeartbeat
+	}
+	if cmd.Flags().Changed("cluster-failover-after") {
+		cfg.ClusterFailoverAfter = clusterFailoverAfter
+	}
+	if cmd.Flags().Changed("quorum-peers") {
+		cfg.QuorumPeers = quorumPeers
+	}
+	if cmd.Flags().Changed("quorum-required") {
+		cfg.QuorumRequired = quorumRequired
+	}
+	if cmd.Flags().Changed("quorum-max-age") {
+		cfg.QuorumMaxAge = quorumMaxAge
+	}
+	quorumNoVerify.Apply(&cfg.QuorumNoVerify)
 
 	// Validate the final configuration
 	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("configuration validation failed: %w", err)
+		return nil, exitcode.Wrap(exitcode.Config, fmt.Errorf("configuration validation failed: %w", err))
 	}
 
 	return cfg, nil
 }
 
 // performHealthCheck implements comprehensive health checking
+// printVersion prints the build information of --version
+func printVersion(info buildinfo.Info) {
+	fmt.Printf("MB8600 Watchdog %s\n", info.Version)
+	fmt.Printf("Commit: %s\n", info.Commit)
+	fmt.Printf("Built: %s\n", info.BuildTime)
+	fmt.Printf("Go: %s (%s)\n", info.GoVersion, info.Platform)
+	names := make([]string, len(info.Features))
+	for i, feature := range info.Features {
+		switch {
+		case !feature.Compiled:
+			names[i] = feature.Name + " (not compiled)"
+		case !feature.Enabled:
+			names[i] = feature.Name + " (disabled)"
+		default:
+			names[i] = feature.Name
+		}
+	}
+	fmt.Printf("Features: %s\n", strings.Join(names, ", "))
+	fmt.Printf("Modem drivers: %s\n", strings.Join(info.ModemDrivers, ", "))
+}
+
+// performHealthCheck runs every health check and reports each result; the
+// command exits with the code of the most severe failure, such as
+// exitcode.ModemUnreachable, or exitcode.Degraded for warnings
 func performHealthCheck() error {
-	fmt.Println("MB8600 Watchdog Health Check")
-	fmt.Println("============================")
+	printer.Title(i18n.T("health.title"))
 
 	// Load configuration for health check
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Printf("❌ Configuration: FAILED - %v\n", err)
-		return fmt.Errorf("configuration check failed: %w", err)
+		printer.Fail(exitcode.Config, i18n.T("component.configuration"), err.Error())
+		return nil
 	}
-	fmt.Println("✅ Configuration: OK")
-
-	// Check if PID file exists and process is running
-	if cfg.PidFile != "" {
-		if err := checkProcessStatus(cfg.PidFile); err != nil {
-			fmt.Printf("❌ Process Status: FAILED - %v\n", err)
-			return fmt.Errorf("process check failed: %w", err)
+	localize(cfg)
+	printer.Check(output.OK, i18n.T("component.configuration"), "")
+
+	checks := []struct {
+		component string
+		// skip leaves out a check the configuration has no use for
+		skip bool
+		// warnOnly reports a failure as a warning
+		warnOnly bool
+		run      func() (string, error)
+	}{
+		{component: "component.process", skip: cfg.PidFile == "", run: func() (string, error) {
+			return "", checkProcessStatus(cfg.PidFile)
+		}},
+		{component: "component.working_directory", skip: cfg.WorkingDirectory == "", run: func() (string, error) {
+			return "", checkDirectoryAccess(cfg.WorkingDirectory)
+		}},
+		{component: "component.state_directory", skip: cfg.StateDirectory == "", run: func() (string, error) {
+			return "", checkDirectoryAccess(cfg.StateDirectory)
+		}},
+		{component: "component.log_file", skip: cfg.LogFile == "", run: func() (string, error) {
+			return "", checkLogFileAccess(cfg.LogFile)
+		}},
+		{component: "component.modem_connectivity", run: func() (string, error) {
+			return checkModemConnectivity(cfg)
+		}},
+		{component: "component.internet_connectivity", run: func() (string, error) {
+			return "", exitcode.Wrap(exitcode.Outage, checkInternetConnectivity(cfg))
+		}},
+		// Missing capabilities only limit some checks
+		{component: "component.system_capabilities", warnOnly: true, run: func() (string, error) {
+			return "", checkSystemCapabilities()
+		}},
+	}
+
+	failed, warned := 0, 0
+	for _, check := range checks {
+		if check.skip {
+			if printer.Verbose() {
+				printer.Check(output.Info, i18n.T(check.component), i18n.T("health.skipped"))
+			}
+			continue
 		}
-		fmt.Println("✅ Process Status: OK")
-	}
-
-	// Check working directory permissions
-	if cfg.WorkingDirectory != "" {
-		if err := checkDirectoryAccess(cfg.WorkingDirectory); err != nil {
-			fmt.Printf("❌ Working Directory: FAILED - %v\n", err)
-			return fmt.Errorf("directory check failed: %w", err)
+		start := time.Now()
+		detail, err := check.run()
+		if err != nil {
+			detail = err.Error()
 		}
-		fmt.Println("✅ Working Directory: OK")
-	}
-
-	// Check log file permissions
-	if cfg.LogFile != "" {
-		if err := checkLogFileAccess(cfg.LogFile); err != nil {
-			fmt.Printf("❌ Log File Access: FAILED - %v\n", err)
-			return fmt.Errorf("log file check failed: %w", err)
+		if printer.Verbose() {
+			detail = strings.TrimSpace(detail + " (" + time.Since(start).Round(time.Microsecond).String() + ")")
+		}
+		switch {
+		case err == nil:
+			printer.Check(output.OK, i18n.T(check.component), detail)
+		case check.warnOnly:
+			warned++
+			printer.Check(output.Warning, i18n.T(check.component), detail)
+		default:
+			failed++
+			printer.Fail(exitcode.Of(err), i18n.T(check.component), detail)
 		}
-		fmt.Println("✅ Log File Access: OK")
-	}
-
-	// Test modem connectivity
-	if err := checkModemConnectivity(cfg); err != nil {
-		fmt.Printf("❌ Modem Connectivity: FAILED - %v\n", err)
-		return fmt.Errorf("modem connectivity check failed: %w", err)
 	}
-	fmt.Println("✅ Modem Connectivity: OK")
 
-	// Test internet connectivity
-	if err := checkInternetConnectivity(cfg); err != nil {
-		fmt.Printf("❌ Internet Connectivity: FAILED - %v\n", err)
-		return fmt.Errorf("internet connectivity check failed: %w", err)
+	switch {
+	case failed > 0:
+		printer.Line(i18n.T("health.failed", failed))
+	case warned > 0:
+		printer.Line(i18n.T("health.warnings", warned))
+	default:
+		printer.Line(i18n.T("health.passed"))
 	}
-	fmt.Println("✅ Internet Connectivity: OK")
-
-	// Check system capabilities (if running as non-root)
-	if err := checkSystemCapabilities(); err != nil {
-		fmt.Printf("⚠️  System Capabilities: WARNING - %v\n", err)
-		// Don't fail on capability warnings, just warn
-	} else {
-		fmt.Println("✅ System Capabilities: OK")
-	}
-
-	fmt.Println("\n🎉 All health checks passed!")
 	return nil
 }
 
@@ -389,7 +1134,7 @@ func checkProcessStatus(pidFile string) error {
 	pidData, err := os.ReadFile(pidFile)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("PID file not found (service not running?)")
+			return exitcode.Wrap(exitcode.NotRunning, fmt.Errorf("PID file not found (service not running?)"))
 		}
 		return fmt.Errorf("cannot read PID file: %w", err)
 	}
@@ -403,12 +1148,12 @@ func checkProcessStatus(pidFile string) error {
 	// Check if process exists (Unix-specific)
 	process, err := os.FindProcess(pid)
 	if err != nil {
-		return fmt.Errorf("process not found: %d", pid)
+		return exitcode.Wrap(exitcode.NotRunning, fmt.Errorf("process not found: %d", pid))
 	}
 
 	// Send signal 0 to check if process is alive
 	if err := process.Signal(syscall.Signal(0)); err != nil {
-		return fmt.Errorf("process not responding: %d", pid)
+		return exitcode.Wrap(exitcode.NotRunning, fmt.Errorf("process not responding: %d", pid))
 	}
 
 	return nil
@@ -457,38 +1202,72 @@ func checkLogFileAccess(logFile string) error {
 	return nil
 }
 
-// checkModemConnectivity tests basic connectivity to the modem
-func checkModemConnectivity(cfg *config.Config) error {
+// checkModemConnectivity tests basic connectivity to the modem and
+// describes the URL its web interface answers at
+func checkModemConnectivity(cfg *config.Config) (string, error) {
 	if cfg == nil {
-		return fmt.Errorf("configuration is nil")
+		return "", fmt.Errorf("configuration is nil")
 	}
 	if cfg.ModemHost == "" {
-		return fmt.Errorf("modem host is not configured")
+		return "", exitcode.Wrap(exitcode.Config, fmt.Errorf("modem host is not configured"))
 	}
 
-	// Create a simple HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: cfg.ModemNoVerify,
-			},
-		},
+	transport, err := modemTransport(cfg)
+	if err != nil {
+		return "", exitcode.Wrap(exitcode.Config, err)
+	}
+
+	// Try the modem web interface over HTTPS and HTTP at once
+	prober := modem.NewProber(modem.Options{
+		Host:      cfg.ModemHost,
+		Port:      cfg.ModemPort,
+		NoVerify:  cfg.ModemNoVerify,
+		Timeout:   10 * time.Second,
+		Transport: transport,
+	})
+	scheme, err := prober.Probe(context.Background())
+	if err != nil {
+		return "", exitcode.Wrap(exitcode.ModemUnreachable, err)
 	}
+	return i18n.T("check.modem_url", prober.URL(scheme)), nil
+}
 
-	// Try to connect to modem web interface
-	modemURL := "https://" + cfg.ModemHost
-	resp, err := client.Get(modemURL)
+// modemTransport returns the transport that reaches the modem through the
+// configured tunnel, or nil when the modem is reached directly
+func modemTransport(cfg *config.Config) (http.RoundTripper, error) {
+	tunnelCfg := cfg.ModemTunnel()
+	if !tunnelCfg.Enabled() {
+		return nil, nil
+	}
+	modemTunnel, err := tunnel.New(tunnelCfg)
 	if err != nil {
-		return fmt.Errorf("cannot connect to modem at %s: %w", cfg.ModemHost, err)
+		return nil, fmt.Errorf("invalid modem tunnel: %w", err)
 	}
-	defer resp.Body.Close()
+	return modemTunnel.Transport(cfg.ModemNoVerify), nil
+}
 
-	if resp.StatusCode >= 500 {
-		return fmt.Errorf("modem returned server error: %d", resp.StatusCode)
+// printConnection reports the connection as of the last check of the
+// running service: an outage while checks fail, degraded while the health
+// score is low
+func printConnection(state *monitor.ServiceState) {
+	label := i18n.T("status.connection")
+	switch {
+	case state.FailureCount > 0 || state.Health == health.Unhealthy:
+		printer.Fail(exitcode.Outage, label, i18n.T("status.connection_outage", state.FailureCount, state.HealthScore))
+	case state.Health == health.Degraded:
+		printer.Check(output.Warning, label, i18n.T("status.connection_degraded", state.HealthScore))
+	case state.Health == health.Healthy:
+		printer.Check(output.OK, label, i18n.T("status.connection_healthy", state.HealthScore))
 	}
+}
 
-	return nil
+// printTunnelHealth reports the last check of the tunnel to the modem
+func printTunnelHealth(health *tunnel.Health) {
+	if health.Healthy {
+		printer.Check(output.OK, i18n.T("status.tunnel"), i18n.T("status.tunnel_up", health.Kind, health.Endpoint, health.LatencyMS))
+		return
+	}
+	printer.Check(output.Warning, i18n.T("status.tunnel"), i18n.T("status.tunnel_down", health.Kind, health.Endpoint, health.Error))
 }
 
 // checkInternetConnectivity tests basic internet connectivity
@@ -565,42 +1344,80 @@ func checkSystemCapabilities() error {
 
 // runStatus displays service status and statistics
 func runStatus(cmd *cobra.Command, args []string) error {
-	fmt.Println("MB8600 Watchdog Service Status")
-	fmt.Println("==============================")
+	printer.Title(i18n.T("status.title"))
 
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Printf("❌ Configuration: FAILED - %v\n", err)
-		return err
+		printer.Fail(exitcode.Config, i18n.T("component.configuration"), err.Error())
+		return nil
 	}
+	localize(cfg)
 
 	// Check if service is running
+	service := i18n.T("status.service")
 	if cfg.PidFile != "" {
 		if err := checkProcessStatus(cfg.PidFile); err != nil {
-			fmt.Printf("❌ Service Status: STOPPED - %v\n", err)
+			printer.Fail(exitcode.Of(err), service, i18n.T("status.stopped", err))
 		} else {
-			fmt.Println("✅ Service Status: RUNNING")
+			printer.Check(output.OK, service, i18n.T("status.running"))
+
+			// Only the running service knows the connection and a reboot
+			// in progress
+			var state monitor.ServiceState
+			if err := controlRequest(cfg, http.MethodGet, "/api/v1/status", nil, &state); err == nil {
+				printConnection(&state)
+			}
+			if state.Reboot != nil {
+				remaining := state.Reboot.Remaining(time.Now()).Round(time.Second)
+				printer.Check(output.Warning, i18n.T("status.modem_status"), i18n.T("status.rebooting", remaining, state.Reboot.Phase))
+			}
+			if state.Tunnel != nil {
+				printTunnelHealth(state.Tunnel)
+			}
+			if state.LowPower {
+				printer.Check(output.Info, i18n.T("status.power_mode"), i18n.T("status.low_power", cfg.LowPowerCheckInterval))
+			}
+			if state.Cluster != nil {
+				if state.Cluster.Active {
+					printer.Check(output.Info, i18n.T("status.cluster"), i18n.T("status.cluster_active", state.Cluster.Role, state.Cluster.Reason))
+				} else {
+					printer.Check(output.Info, i18n.T("status.cluster"), i18n.T("status.cluster_standby", state.Cluster.Role, state.Cluster.Reason))
+				}
+			}
+			if pause, err := monitor.ReadPause(monitor.PausePath(cfg)); err == nil && pause != nil && time.Now().Before(pause.Until) {
+				until := i18n.T("status.paused_until", pause.Until.Local().Format(time.RFC3339))
+				if pause.Reason != "" {
+					until += " - " + pause.Reason
+				}
+				printer.Check(output.Info, i18n.T("status.paused"), until)
+			}
 
 			// Try to read service state if available
-			stateFile := filepath.Join(cfg.WorkingDirectory, "state", "watchdog.state")
-			if err := displayServiceStatistics(stateFile); err != nil {
-				fmt.Printf("⚠️  Statistics: %v\n", err)
+			stateFile := cfg.StatePath("state", "watchdog.state")
+			if err := displayServiceStatistics(stateFile, state.Diagnostics); err != nil {
+				printer.Check(output.Warning, i18n.T("component.statistics"), err.Error())
 			}
 		}
 	} else {
-		fmt.Println("⚠️  Service Status: UNKNOWN (no PID file configured)")
+		printer.Check(output.Warning, service, i18n.T("status.unknown"))
 	}
 
 	// Display configuration summary
-	fmt.Println("\nConfiguration Summary:")
-	fmt.Printf("  Modem Host: %s\n", cfg.ModemHost)
-	fmt.Printf("  Check Interval: %v\n", cfg.CheckInterval)
-	fmt.Printf("  Failure Threshold: %d\n", cfg.FailureThreshold)
-	fmt.Printf("  Recovery Wait: %v\n", cfg.RecoveryWait)
-	fmt.Printf("  Diagnostics Enabled: %t\n", cfg.EnableDiagnostics)
-	fmt.Printf("  Log Level: %s\n", cfg.LogLevel)
-	fmt.Printf("  Log File: %s\n", cfg.LogFile)
+	printer.Section(i18n.T("status.config_summary"))
+	printField("status.modem_type", cfg.ModemType)
+	printField("status.modem_host", cfg.ModemHost)
+	printField("status.check_interval", cfg.CheckInterval)
+	printField("status.failure_threshold", cfg.FailureThreshold)
+	printField("status.recovery_wait", cfg.RecoveryWait)
+	printField("status.diagnostics_enabled", cfg.EnableDiagnostics)
+	printField("status.log_level", cfg.LogLevel)
+	printField("status.log_file", cfg.LogFile)
+	if printer.Verbose() {
+		printField("status.pid_file", cfg.PidFile)
+		printField("status.state_directory", cfg.StatePath())
+		printField("status.control_socket", cfg.ControlSocketPath())
+	}
 
 	return nil
 }
@@ -609,14 +1426,17 @@ func runStatus(cmd *cobra.Command, args []string) error {
 func runReload(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return exitcode.Wrap(exitcode.Config, fmt.Errorf("failed to load configuration: %w", err))
 	}
 
 	if cfg.PidFile == "" {
-		return fmt.Errorf("no PID file configured, cannot reload")
+		return exitcode.Wrap(exitcode.Config, fmt.Errorf("no PID file configured, cannot reload"))
 	}
 
 	pidData, err := os.ReadFile(cfg.PidFile)
+	if os.IsNotExist(err) {
+		return exitcode.Wrap(exitcode.NotRunning, fmt.Errorf("PID file not found (service not running?)"))
+	}
 	if err != nil {
 		return fmt.Errorf("cannot read PID file: %w", err)
 	}
@@ -629,14 +1449,19 @@ func runReload(cmd *cobra.Command, args []string) error {
 
 	process, err := os.FindProcess(pid)
 	if err != nil {
-		return fmt.Errorf("process not found: %d", pid)
+		return exitcode.Wrap(exitcode.NotRunning, fmt.Errorf("process not found: %d", pid))
 	}
 
-	if err := process.Signal(syscall.SIGHUP); err != nil {
+	err = process.Signal(syscall.SIGHUP)
+	recordControlAction(cfg, audit.ActionConfigReload, err)
+	if errors.Is(err, os.ErrProcessDone) {
+		return exitcode.Wrap(exitcode.NotRunning, fmt.Errorf("process not running: %d", pid))
+	}
+	if err != nil {
 		return fmt.Errorf("failed to send SIGHUP signal: %w", err)
 	}
 
-	fmt.Printf("Configuration reload signal sent to process %d\n", pid)
+	printer.Line(i18n.T("reload.sent", pid))
 	return nil
 }
 
@@ -644,14 +1469,17 @@ func runReload(cmd *cobra.Command, args []string) error {
 func runStop(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return exitcode.Wrap(exitcode.Config, fmt.Errorf("failed to load configuration: %w", err))
 	}
 
 	if cfg.PidFile == "" {
-		return fmt.Errorf("no PID file configured, cannot stop")
+		return exitcode.Wrap(exitcode.Config, fmt.Errorf("no PID file configured, cannot stop"))
 	}
 
 	pidData, err := os.ReadFile(cfg.PidFile)
+	if os.IsNotExist(err) {
+		return exitcode.Wrap(exitcode.NotRunning, fmt.Errorf("PID file not found (service not running?)"))
+	}
 	if err != nil {
 		return fmt.Errorf("cannot read PID file: %w", err)
 	}
@@ -664,11 +1492,16 @@ func runStop(cmd *cobra.Command, args []string) error {
 
 	process, err := os.FindProcess(pid)
 	if err != nil {
-		return fmt.Errorf("process not found: %d", pid)
+		return exitcode.Wrap(exitcode.NotRunning, fmt.Errorf("process not found: %d", pid))
 	}
 
-	fmt.Printf("Sending graceful shutdown signal to process %d...\n", pid)
-	if err := process.Signal(syscall.SIGTERM); err != nil {
+	printer.Line(i18n.T("stop.sending", pid))
+	err = process.Signal(syscall.SIGTERM)
+	recordControlAction(cfg, audit.ActionServiceStop, err)
+	if errors.Is(err, os.ErrProcessDone) {
+		return exitcode.Wrap(exitcode.NotRunning, fmt.Errorf("process not running: %d", pid))
+	}
+	if err != nil {
 		return fmt.Errorf("failed to send SIGTERM signal: %w", err)
 	}
 
@@ -680,29 +1513,32 @@ func runStop(cmd *cobra.Command, args []string) error {
 	for {
 		select {
 		case <-timeout:
-			fmt.Println("⚠️  Graceful shutdown timeout, process may still be running")
+			printer.Line(i18n.T("stop.timeout"))
+			printer.Exit(exitcode.Failure)
 			return nil
 		case <-ticker.C:
 			if err := process.Signal(syscall.Signal(0)); err != nil {
-				fmt.Println("✅ Service stopped successfully")
+				printer.Line(i18n.T("stop.stopped"))
 				return nil
 			}
 		}
 	}
 }
 
-// displayServiceStatistics reads and displays service statistics
-func displayServiceStatistics(stateFile string) error {
+// displayServiceStatistics reads and displays service statistics, followed by
+// the last diagnostic analysis: diagnostics from the running service if
+// given, otherwise the one in the state file
+func displayServiceStatistics(stateFile string, diagnostics *monitor.DiagnosticsSummary) error {
 	file, err := os.Open(stateFile)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("no statistics available (state file not found)")
+			return errors.New(i18n.T("status.no_statistics"))
 		}
-		return fmt.Errorf("cannot read statistics: %w", err)
+		return fmt.Errorf("%s: %w", i18n.T("status.statistics_read_fail"), err)
 	}
 	defer file.Close()
 
-	fmt.Println("\nService Statistics:")
+	printer.Section(i18n.T("status.service_statistics"))
 
 	// Parse state file
 	scanner := bufio.NewScanner(file)
@@ -722,36 +1558,110 @@ func displayServiceStatistics(stateFile string) error {
 
 	// Display formatted statistics
 	if failureCount, ok := stats["failure_count"]; ok {
-		fmt.Printf("  Current Failure Count: %s\n", failureCount)
+		printField("status.failure_count", failureCount)
 	}
 
 	if totalChecks, ok := stats["total_checks"]; ok {
-		fmt.Printf("  Total Connectivity Checks: %s\n", totalChecks)
+		printField("status.total_checks", totalChecks)
+	}
+
+	if modemModel, ok := stats["modem_model"]; ok {
+		printField("status.modem_model", modemModel)
+	}
+
+	if modemMode, ok := stats["modem_mode"]; ok {
+		printField("status.modem_mode", modemMode)
+	}
+
+	if modemFirmware, ok := stats["modem_firmware"]; ok {
+		printField("status.modem_firmware", modemFirmware)
+	}
+
+	if modemAccess, ok := stats["modem_access"]; ok {
+		printField("status.modem_access", modemAccess)
+	}
+
+	if health, ok := stats["health"]; ok {
+		printField("status.health", i18n.T("status.health_score", health, stats["health_score"]))
 	}
 
 	if totalReboots, ok := stats["total_reboots"]; ok {
-		fmt.Printf("  Total Modem Reboots: %s\n", totalReboots)
+		printField("status.total_reboots", totalReboots)
+	}
+
+	var byReason []string
+	for _, reason := range monitor.RebootReasons {
+		if count, ok := stats[monitor.RebootStatePrefix+reason]; ok {
+			byReason = append(byReason, i18n.T("status.reboot_reason."+reason)+" "+count)
+		}
+	}
+	if len(byReason) > 0 {
+		printField("status.reboots_by_reason", strings.Join(byReason, ", "))
 	}
 
 	if lastCheck, ok := stats["last_check"]; ok {
 		if timestamp, err := strconv.ParseInt(lastCheck, 10, 64); err == nil {
 			lastCheckTime := time.Unix(timestamp, 0)
-			fmt.Printf("  Last Check: %s (%s ago)\n",
+			printField("status.last_check", i18n.T("status.ago",
 				lastCheckTime.Format("2006-01-02 15:04:05"),
-				time.Since(lastCheckTime).Round(time.Second))
+				time.Since(lastCheckTime).Round(time.Second)))
 		}
 	}
 
 	if lastReboot, ok := stats["last_reboot"]; ok {
 		if timestamp, err := strconv.ParseInt(lastReboot, 10, 64); err == nil && timestamp > 0 {
 			lastRebootTime := time.Unix(timestamp, 0)
-			fmt.Printf("  Last Reboot: %s (%s ago)\n",
+			printField("status.last_reboot", i18n.T("status.ago",
 				lastRebootTime.Format("2006-01-02 15:04:05"),
-				time.Since(lastRebootTime).Round(time.Second))
+				time.Since(lastRebootTime).Round(time.Second)))
 		} else {
-			fmt.Println("  Last Reboot: Never")
+			printField("status.last_reboot", i18n.T("status.never"))
 		}
 	}
 
+	if encoded, ok := stats["diagnostics"]; ok && diagnostics == nil {
+		var summary monitor.DiagnosticsSummary
+		if err := json.Unmarshal([]byte(encoded), &summary); err == nil {
+			diagnostics = &summary
+		}
+	}
+	if diagnostics != nil {
+		printDiagnosticsSummary(diagnostics)
+	}
+
 	return nil
 }
+
+// printDiagnosticsSummary prints the last diagnostic analysis: when it ran,
+// its verdict, the success rate of every layer, the failure patterns it
+// found and what it recommended
+func printDiagnosticsSummary(summary *monitor.DiagnosticsSummary) {
+	verdict := i18n.T("status.diagnostics.no_reboot")
+	if summary.ShouldReboot {
+		verdict = i18n.T("status.diagnostics.reboot")
+	}
+	printer.Section(i18n.T("status.diagnostics.title"))
+	printField("status.diagnostics.time", i18n.T("status.ago",
+		summary.Time.Local().Format("2006-01-02 15:04:05"),
+		time.Since(summary.Time).Round(time.Second)))
+	printField("status.diagnostics.verdict", verdict)
+	printField("status.diagnostics.score", i18n.T("status.diagnostics.score_value",
+		summary.HealthScore, fmt.Sprintf("%.0f%%", summary.SuccessRate*100)))
+	if summary.Cause != "" {
+		printField("status.diagnostics.cause", summary.Cause)
+	}
+
+	if len(summary.Layers) > 0 {
+		layers := make([]string, 0, len(summary.Layers))
+		for _, layer := range summary.Layers {
+			layers = append(layers, fmt.Sprintf("%s %.0f%%", layer.Layer, layer.SuccessRate*100))
+		}
+		printField("status.diagnostics.layers", strings.Join(layers, ", "))
+	}
+	if len(summary.Patterns) > 0 {
+		printField("status.diagnostics.patterns", strings.Join(summary.Patterns, "\n"))
+	}
+	if len(summary.Recommendations) > 0 {
+		printField("status.diagnostics.recommendations", strings.Join(summary.Recommendations, "\n"))
+	}
+}
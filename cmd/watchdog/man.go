@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/buildinfo"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var manCmd = &cobra.Command{
+	Use:   "man [directory]",
+	Short: "Generate the man pages of the watchdog and its commands",
+	Long: `Print the man page of the watchdog, or write the man pages of the watchdog
+and every command, such as mb8600-watchdog-status.8, to a directory. The
+pages are named after the command the binary is run as and generated from
+the command help, so they always match this binary:
+
+  watchdog man | man -l -
+  watchdog man /usr/local/share/man/man8
+
+SOURCE_DATE_EPOCH sets the date of the pages for reproducible builds.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMan,
+}
+
+func init() {
+	rootCmd.AddCommand(manCmd)
+}
+
+// manHeader is the header of the watchdog's man pages, in section 8 with
+// the other system daemons
+func manHeader() *doc.GenManHeader {
+	return &doc.GenManHeader{
+		Title:   strings.ToUpper(rootCmd.Name()),
+		Section: "8",
+		Source:  "mb8600-watchdog " + buildinfo.Version,
+		Manual:  "MB8600 Watchdog Manual",
+	}
+}
+
+// runMan prints the root man page, or writes every man page to the
+// directory in args
+func runMan(cmd *cobra.Command, args []string) error {
+	useInvokedName()
+	rootCmd.DisableAutoGenTag = true
+	if len(args) == 0 {
+		return doc.GenMan(rootCmd, manHeader(), os.Stdout)
+	}
+
+	dir := args[0]
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create man page directory: %w", err)
+	}
+	if err := doc.GenManTree(rootCmd, manHeader(), dir); err != nil {
+		return fmt.Errorf("failed to write man pages: %w", err)
+	}
+	fmt.Println(i18n.T("man.written", dir))
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var notifyTestEvent string
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Check the notification setup",
+}
+
+var notifyTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Send synthetic outage, reboot and recovery notifications",
+	Long: `Send synthetic events through the same notification pipeline the watchdog
+uses, with the configured message templates, language and notifiers, so the
+notification setup can be checked without unplugging the modem. Titles of
+test notifications start with [TEST]. Without --event one notification is
+sent for each event, in the order they happen during an outage.`,
+	Example: `  watchdog notify test
+  watchdog notify test --event recovery`,
+	Args: cobra.NoArgs,
+	RunE: runNotifyTest,
+}
+
+func init() {
+	rootCmd.AddCommand(notifyCmd)
+	notifyCmd.AddCommand(notifyTestCmd)
+
+	notifyTestCmd.Flags().StringVar(&notifyTestEvent, "event", "", "Event to send: "+strings.Join(monitor.TestEvents, ", ")+" (default all)")
+}
+
+// runNotifyTest sends test notifications and reports each delivery
+func runNotifyTest(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	localize(cfg)
+
+	events := monitor.TestEvents
+	if notifyTestEvent != "" {
+		events = []string{notifyTestEvent}
+	}
+	for _, event := range events {
+		if _, _, err := monitor.TestNotification(event, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	notifier := monitor.NewNotifier(cfg, logger, monitor.Options{})
+	if !notifier.Enabled() {
+		fmt.Println(i18n.T("notify.disabled"))
+		return errors.New("notifications are disabled")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var failed int
+	for _, event := range events {
+		kind, err := monitor.SendTestNotification(ctx, notifier, event, time.Now())
+		if err != nil {
+			failed++
+			fmt.Println(i18n.T("notify.failed", event, kind, err))
+			continue
+		}
+		fmt.Println(i18n.T("notify.sent", event, kind))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d test notifications failed", failed, len(events))
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/exitcode"
+	"github.com/perezjoseph/mb8600-watchdog/internal/output"
+)
+
+// printer formats the output of commands such as status and
+// --health-check; its most severe outcome is the exit code of the command
+var printer *output.Printer
+
+// setupOutput creates the printer from --color, --wide, --quiet and
+// --verbose
+func setupOutput() error {
+	var err error
+	printer, err = output.New(os.Stdout, output.Options{
+		Color:   outputColor,
+		Wide:    outputWide,
+		Quiet:   outputQuiet,
+		Verbose: verbose,
+	})
+	return exitcode.Wrap(exitcode.Config, err)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// pauseChecks pauses connectivity checks as well as reboots
+	pauseChecks bool
+	// pauseReason is recorded with the pause
+	pauseReason string
+)
+
+// pauseStatus is the pause state returned by the service
+type pauseStatus struct {
+	Paused bool           `json:"paused"`
+	Pause  *monitor.Pause `json:"pause"`
+}
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause [duration]",
+	Short: "Pause automatic reboots of the running service",
+	Long: `Pause automatic modem reboots for a while, such as while an ISP technician
+works on the line, or show the current pause without a duration. Checks keep
+running and outages are still recorded unless --checks is given. Monitoring
+resumes on its own when the pause runs out, or earlier with the resume
+command. The pause is kept across restarts of the service.
+
+  mb8600-watchdog pause 2h --reason "ISP technician on site"
+
+The command talks to the service over the control socket in its state
+directory, so it needs the same configuration and permissions as the service.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPause,
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume automatic reboots after a pause",
+	Args:  cobra.NoArgs,
+	RunE:  runResume,
+}
+
+func init() {
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+
+	pauseCmd.Flags().BoolVar(&pauseChecks, "checks", false, "Pause connectivity checks as well as reboots")
+	pauseCmd.Flags().StringVar(&pauseReason, "reason", "", "Why monitoring is paused, for the audit log and notifications")
+}
+
+// runPause starts a pause, or shows the current one, over the control socket
+func runPause(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	method := http.MethodGet
+	var body interface{}
+	if len(args) == 1 {
+		duration, err := time.ParseDuration(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", args[0], err)
+		}
+		method = http.MethodPut
+		body = map[string]interface{}{"duration": duration.String(), "checks": pauseChecks, "reason": pauseReason}
+	}
+
+	var status pauseStatus
+	if err := controlRequest(cfg, method, "/api/v1/pause", body, &status); err != nil {
+		return err
+	}
+	printPause(status.Pause)
+	return nil
+}
+
+// runResume ends a pause over the control socket
+func runResume(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	var status pauseStatus
+	if err := controlRequest(cfg, http.MethodDelete, "/api/v1/pause", nil, &status); err != nil {
+		return err
+	}
+	printer.Line(i18n.T("pause.resumed"))
+	return nil
+}
+
+// printPause describes pause, or reports that monitoring is not paused
+func printPause(pause *monitor.Pause) {
+	if pause == nil {
+		fmt.Println(i18n.T("pause.none"))
+		return
+	}
+	until := pause.Until.Local().Format(time.RFC3339)
+	if pause.Checks {
+		fmt.Println(i18n.T("pause.checks", until))
+	} else {
+		fmt.Println(i18n.T("pause.reboots", until))
+	}
+	if pause.Reason != "" {
+		fmt.Println(i18n.T("pause.reason", pause.Reason))
+	}
+	if pause.By != "" {
+		fmt.Println(i18n.T("pause.by", pause.By, pause.Since.Local().Format(time.RFC3339)))
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/responder"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	responderListen     string
+	responderHTTPListen string
+	responderKeyFile    string
+)
+
+var responderCmd = &cobra.Command{
+	Use:   "responder",
+	Short: "Answer watchdog checks from a server of your own",
+	Long: `Run a small check target, such as on a VPS, so the watchdog at home
+probes it instead of public services. It answers TCP and UDP probes on
+--listen and HTTP probes on --http-listen. Every answer carries the probe's
+nonce and an HMAC-SHA256 signature made with a key shared with the watchdog.
+
+The key comes from --key-file or the RESPONDER_KEY environment variable.
+Point the watchdog at the responder with RESPONDER and RESPONDER_URL, and
+give it the same RESPONDER_KEY to verify the answers:
+
+  RESPONDER=vps.example.net:8600
+  RESPONDER_URL=http://vps.example.net:8601/check
+
+UDP probes smaller than ` + strconv.Itoa(responder.MinUDPRequest) + ` bytes are dropped, so the responder never
+answers with more than it receives.`,
+	Example: `  RESPONDER_KEY=$(cat /etc/watchdog/responder.key) watchdog responder
+  watchdog responder --listen :8600 --http-listen "" --key-file /etc/watchdog/responder.key`,
+	Args: cobra.NoArgs,
+	RunE: runResponder,
+}
+
+func init() {
+	rootCmd.AddCommand(responderCmd)
+
+	responderCmd.Flags().StringVar(&responderListen, "listen", fmt.Sprintf(":%d", responder.DefaultPort), "Address for TCP and UDP probes")
+	responderCmd.Flags().StringVar(&responderHTTPListen, "http-listen", fmt.Sprintf(":%d", responder.DefaultHTTPPort), "Address for HTTP probes; empty disables HTTP")
+	responderCmd.Flags().StringVar(&responderKeyFile, "key-file", "", "File holding the signing key (env: RESPONDER_KEY)")
+}
+
+// runResponder answers probes until interrupted
+func runResponder(cmd *cobra.Command, args []string) error {
+	key, err := responderKey()
+	if err != nil {
+		return err
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	server := responder.NewServer(key, logger)
+	return server.ListenAndServe(ctx, responderListen, responderHTTPListen)
+}
+
+// responderKey reads the signing key from --key-file or RESPONDER_KEY
+func responderKey() ([]byte, error) {
+	key := config.Getenv("RESPONDER_KEY")
+	if responderKeyFile != "" {
+		data, err := os.ReadFile(responderKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read responder key: %w", err)
+		}
+		key = string(data)
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("no responder key, set --key-file or RESPONDER_KEY")
+	}
+	return []byte(key), nil
+}
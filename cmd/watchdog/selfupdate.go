@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/buildinfo"
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/update"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	selfUpdateCheck      bool
+	selfUpdateForce      bool
+	selfUpdateNoRestart  bool
+	selfUpdateUnit       string
+	selfUpdateRepository string
+	selfUpdatePublicKey  string
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Replace this binary with the latest signed release",
+	Long: `Download the release binary for this platform, verify it and swap it in
+for the running binary, then restart the service through systemd.
+
+The release's checksums.txt must carry a valid ed25519 signature from the
+release key built into the binary, or the one given with --public-key or
+UPDATE_PUBLIC_KEY, and list the SHA-256 of the downloaded binary. The new
+binary is written next to the current one and renamed over it, so an
+interrupted update never leaves a partial binary; the previous one is kept
+with a .old suffix.
+
+To check for updates on a schedule, enable the mb8600-watchdog-update.timer
+systemd unit, which runs this command once a day.`,
+	Example: `  watchdog self-update --check
+  sudo watchdog self-update
+  sudo watchdog self-update --no-restart`,
+	Args: cobra.NoArgs,
+	RunE: runSelfUpdate,
+}
+
+func init() {
+	rootCmd.AddCommand(selfUpdateCmd)
+
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateCheck, "check", false, "Only report whether an update is available")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateForce, "force", false, "Install the latest release even if it is not newer, such as over a dev build")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateNoRestart, "no-restart", false, "Do not restart the service after installing")
+	selfUpdateCmd.Flags().StringVar(&selfUpdateUnit, "unit", update.DefaultUnit, "systemd unit to restart after installing")
+	selfUpdateCmd.Flags().StringVar(&selfUpdateRepository, "repository", update.DefaultRepository, "GitHub repository to take releases from")
+	selfUpdateCmd.Flags().StringVar(&selfUpdatePublicKey, "public-key", "", "Base64 ed25519 key that signs releases (env: UPDATE_PUBLIC_KEY)")
+}
+
+// runSelfUpdate installs the latest release if it is newer than this binary
+func runSelfUpdate(cmd *cobra.Command, args []string) error {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	encodedKey := update.PublicKey
+	if env := config.Getenv("UPDATE_PUBLIC_KEY"); env != "" {
+		encodedKey = env
+	}
+	if selfUpdatePublicKey != "" {
+		encodedKey = selfUpdatePublicKey
+	}
+	key, err := update.ParsePublicKey(encodedKey)
+	if err != nil && !selfUpdateCheck {
+		return fmt.Errorf("%w, set --public-key or UPDATE_PUBLIC_KEY", err)
+	}
+
+	updater := update.NewUpdater(selfUpdateRepository, key, logger)
+	release, err := updater.Latest(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("self_update.current", buildinfo.Version, release.Version))
+
+	newer, err := update.Newer(release.Version, buildinfo.Version)
+	if err != nil && !selfUpdateForce {
+		return fmt.Errorf("cannot compare versions, use --force to install anyway: %w", err)
+	}
+	if !newer && !selfUpdateForce {
+		fmt.Println(i18n.T("self_update.up_to_date"))
+		return nil
+	}
+	if selfUpdateCheck {
+		fmt.Println(i18n.T("self_update.available"))
+		return nil
+	}
+
+	binary, err := updater.Download(ctx, release, update.CurrentAssetName())
+	if err != nil {
+		return err
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate current binary: %w", err)
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return fmt.Errorf("failed to locate current binary: %w", err)
+	}
+	if err := update.Install(binary, path); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("self_update.installed", release.Version, path))
+
+	if selfUpdateNoRestart || !update.UnderSystemd() {
+		fmt.Println(i18n.T("self_update.restart_now"))
+		return nil
+	}
+	if err := update.Restart(ctx, selfUpdateUnit); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("self_update.restarted", selfUpdateUnit))
+	return nil
+}
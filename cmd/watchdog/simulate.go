@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/simulator"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	simulateScenario       string
+	simulateRebootDuration time.Duration
+	simulateSeed           int64
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Run the watchdog against a scripted outage scenario",
+	Long: `Replay a scenario file against the monitoring logic in virtual time, using
+the configured check interval, failure threshold and recovery wait. Connectivity
+results are scripted and reboots go to a built-in fake modem, so no network
+traffic is generated and a day-long scenario completes in seconds.
+
+Scenario files list phases one per line or separated by commas:
+
+  healthy 10m
+  outage 20m until-reboot
+  degraded 15m 30%
+  modem-unreachable 5m`,
+	RunE: runSimulate,
+}
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+
+	simulateCmd.Flags().StringVar(&simulateScenario, "scenario", "", "Scenario file to replay (required)")
+	simulateCmd.Flags().DurationVar(&simulateRebootDuration, "reboot-duration", simulator.DefaultRebootDuration, "How long the fake modem is offline after a reboot")
+	simulateCmd.Flags().Int64Var(&simulateSeed, "seed", 1, "Random seed for degraded phases")
+	simulateCmd.MarkFlagRequired("scenario")
+}
+
+// runSimulate replays a scenario and prints the timeline and summary
+func runSimulate(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	scenario, err := simulator.LoadScenario(simulateScenario)
+	if err != nil {
+		return err
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	sim, err := simulator.New(cfg, scenario, simulator.Options{
+		RebootDuration: simulateRebootDuration,
+		Seed:           simulateSeed,
+	}, logger)
+	if err != nil {
+		return err
+	}
+	defer sim.Close()
+
+	localize(cfg)
+	fmt.Println(i18n.T("simulate.start", scenario.Duration(), cfg.CheckInterval, cfg.FailureThreshold, cfg.RecoveryWait))
+	fmt.Println()
+
+	report, err := sim.Run(context.Background())
+	if err != nil {
+		return fmt.Errorf("simulation failed: %w", err)
+	}
+
+	for _, event := range report.Events {
+		fmt.Printf("  %10s  %s\n", event.Offset, describeSimulationEvent(event))
+	}
+
+	fmt.Println("\n" + i18n.T("simulate.summary"))
+	for _, phase := range report.Phases {
+		fmt.Printf("  %-36s %s\n", phase.Phase, i18n.T("simulate.phase", phase.Checks, phase.FailedChecks, phase.Reboots))
+	}
+	fmt.Println("\n  " + i18n.T("simulate.totals", report.Checks, report.FailedChecks, report.Reboots))
+
+	if unnecessary := report.UnnecessaryReboots(); unnecessary > 0 {
+		fmt.Println(i18n.T("simulate.unnecessary", unnecessary))
+	}
+	return nil
+}
+
+// describeSimulationEvent formats a timeline entry
+func describeSimulationEvent(event simulator.Event) string {
+	switch event.Kind {
+	case simulator.EventPhase:
+		return "▶️  " + event.Message
+	case simulator.EventConnectivityLost:
+		return i18n.T("simulate.lost")
+	case simulator.EventConnectivityRestored:
+		return i18n.T("simulate.restored")
+	case simulator.EventReboot:
+		return i18n.T("simulate.reboot")
+	case simulator.EventCheckError:
+		return i18n.T("simulate.check_error", event.Message)
+	default:
+		return event.Kind + " " + event.Message
+	}
+}
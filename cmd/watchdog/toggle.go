@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// toggleValue is a boolean setting given on the command line, shared by the
+// flags that set it, such as --enable-diagnostics and --disable-diagnostics
+type toggleValue struct {
+	toggle config.Toggle
+	// setBy is the flag that set the toggle
+	setBy string
+}
+
+// Apply sets target when the toggle was given on the command line
+func (v *toggleValue) Apply(target *bool) {
+	v.toggle.Apply(target)
+}
+
+// toggleFlag is one flag of a toggle; an inverted flag turns it off
+type toggleFlag struct {
+	value  *toggleValue
+	name   string
+	invert bool
+}
+
+func (f *toggleFlag) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	toggle := config.ToggleOf(on != f.invert)
+	if f.value.setBy != "" && f.value.setBy != f.name && f.value.toggle != toggle {
+		return fmt.Errorf("conflicts with --%s", f.value.setBy)
+	}
+	f.value.toggle, f.value.setBy = toggle, f.name
+	return nil
+}
+
+func (f *toggleFlag) String() string {
+	return strconv.FormatBool(f.value.setBy == f.name && f.value.toggle == config.ToggleOf(!f.invert))
+}
+
+func (f *toggleFlag) Type() string {
+	return "bool"
+}
+
+// toggleVarP registers a boolean flag that leaves its setting unset unless
+// given, so --name=false overrides a true from the environment or config file
+func toggleVarP(cmd *cobra.Command, value *toggleValue, name, shorthand, usage string) {
+	flag := cmd.PersistentFlags().VarPF(&toggleFlag{value: value, name: name}, name, shorthand, usage)
+	flag.NoOptDefVal = "true"
+}
+
+// togglePairVar registers an enable and a disable flag of one setting;
+// giving both with opposite meanings is an error
+func togglePairVar(cmd *cobra.Command, value *toggleValue, enable, disable, enableUsage, disableUsage string) {
+	toggleVarP(cmd, value, enable, "", enableUsage)
+	flag := cmd.PersistentFlags().VarPF(&toggleFlag{value: value, name: disable, invert: true}, disable, "", disableUsage)
+	flag.NoOptDefVal = "true"
+}
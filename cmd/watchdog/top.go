@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/top"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// topInterval is the time between polls of the service
+	topInterval time.Duration
+	// topEvents is the number of recent events shown
+	topEvents int
+)
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show a live view of the running service",
+	Long: `Show a live view of the running service in the terminal: its state, such
+as monitoring, failing or rebooting, the modem and health score, the last
+checks with sparklines of their latency and health score, and the recent
+reboot decisions, IP and firmware changes and outage notes. The view polls
+the service over its control socket in the state directory, so it needs no
+API address or credentials, only access to the socket.
+
+Press q to quit and r to refresh right away.`,
+	Args: cobra.NoArgs,
+	RunE: runTop,
+}
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+	topCmd.Flags().DurationVar(&topInterval, "interval", top.DefaultInterval, "Time between polls of the service")
+	topCmd.Flags().IntVar(&topEvents, "events", top.DefaultEvents, "Number of recent events shown")
+}
+
+// runTop runs the live view until the user quits
+func runTop(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigWithCLIOverrides(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if topInterval < 100*time.Millisecond {
+		return fmt.Errorf("--interval must be at least 100ms, got %s", topInterval)
+	}
+
+	view := top.New(controlSource{cfg: cfg}, top.Options{
+		Interval: topInterval,
+		Events:   topEvents,
+		Describe: summarizeEvent,
+	})
+	if _, err := tea.NewProgram(view, tea.WithAltScreen()).Run(); err != nil {
+		return fmt.Errorf("live view failed: %w", err)
+	}
+	return nil
+}
+
+// controlSource reads the state and history of the service over its
+// control socket
+type controlSource struct {
+	cfg *config.Config
+}
+
+// Status returns the state of the service as of its last check
+func (s controlSource) Status() (monitor.ServiceState, error) {
+	var state monitor.ServiceState
+	err := controlRequest(s.cfg, http.MethodGet, "/api/v1/status", nil, &state)
+	return state, err
+}
+
+// Events returns the newest events of the history
+func (s controlSource) Events(since time.Duration, limit int, kinds ...string) ([]history.Event, error) {
+	query := url.Values{}
+	query.Set("since", since.String())
+	query.Set("limit", strconv.Itoa(limit))
+	if len(kinds) > 0 {
+		query.Set("kind", strings.Join(kinds, ","))
+	}
+	var response struct {
+		Events []history.Event `json:"events"`
+	}
+	err := controlRequest(s.cfg, http.MethodGet, "/api/v1/events?"+query.Encode(), nil, &response)
+	return response.Events, err
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/spf13/cobra"
+)
+
+var hashPasswordRole string
+
+var hashPasswordCmd = &cobra.Command{
+	Use:   "hash-password <user>",
+	Short: "Create an entry for the API users file",
+	Long: `Read a password from standard input and print a users file entry with its
+salted hash, ready to paste into the file referenced by --api-users.
+
+  echo 'correct horse battery' | watchdog hash-password alice --role operator`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHashPassword,
+}
+
+func init() {
+	rootCmd.AddCommand(hashPasswordCmd)
+
+	hashPasswordCmd.Flags().StringVar(&hashPasswordRole, "role", string(auth.RoleViewer), "Role of the user: viewer or operator")
+}
+
+// runHashPassword prints a users file entry for the password read from stdin
+func runHashPassword(cmd *cobra.Command, args []string) error {
+	role := auth.Role(hashPasswordRole)
+	if !role.Valid() {
+		return fmt.Errorf("invalid role %q (expected %s or %s)", hashPasswordRole, auth.RoleViewer, auth.RoleOperator)
+	}
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("failed to read password from standard input: %w", err)
+	}
+	password = strings.TrimRight(password, "\r\n")
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	entry, err := json.MarshalIndent(auth.User{Name: args[0], PasswordHash: hash, Role: role}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode user: %w", err)
+	}
+	fmt.Println(string(entry))
+	return nil
+}
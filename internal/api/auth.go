@@ -0,0 +1,187 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/ratelimit"
+	"github.com/sirupsen/logrus"
+)
+
+// authRealm is announced to clients that must authenticate
+const authRealm = `Basic realm="mb8600-watchdog", charset="UTF-8"`
+
+// Failed logins are limited per client address: every wrong password costs
+// a bcrypt hash, so unthrottled guessing would also load the host
+const (
+	loginFailureLimit  = 5
+	loginFailureWindow = time.Minute
+)
+
+// SetUsers requires every request to authenticate as one of users with HTTP
+// basic auth. Without users or API keys the API is open, which is only safe
+// on loopback: Serve warns when it listens elsewhere, and behind Home
+// Assistant ingress only ingress requests are let in without credentials.
+func (s *Server) SetUsers(users *auth.Users) {
+	s.users = users
+}
+
+// SetAPIKeys accepts the keys in keys as bearer tokens. Authentication is
+// required as soon as the set holds a key that is not revoked.
+func (s *Server) SetAPIKeys(keys *auth.Keys) {
+	s.keys = keys
+}
+
+// authenticationRequired reports whether requests must carry credentials.
+// Behind ingress they always must, unless they come through the ingress
+// proxy: the add-on listens on every interface.
+func (s *Server) authenticationRequired() bool {
+	return s.users != nil || (s.keys != nil && s.keys.Active()) || s.ingressProxy != ""
+}
+
+// authorize checks that the request comes from a user or API key holding
+// required. It writes the error response and returns false otherwise.
+// Requests through the ingress proxy without credentials act for the Home
+// Assistant user with ingressScopes only.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, required auth.Scope) (auth.Principal, bool) {
+	if s.local {
+		return auth.Principal{Name: r.Header.Get(LocalUserHeader), Scopes: []auth.Scope{auth.ScopeAdmin}}, true
+	}
+	if s.fromIngress(r) && r.Header.Get("Authorization") == "" {
+		return s.allow(w, ingressPrincipal(r), required)
+	}
+	if !s.authenticationRequired() {
+		return auth.Principal{Scopes: []auth.Scope{auth.ScopeAdmin}}, true
+	}
+
+	host := clientHost(r)
+	if retryAfter := s.logins.blocked(host); retryAfter > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"remote_addr": r.RemoteAddr,
+			"retry_after": retryAfter,
+		}).Warn("API login throttled")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, fmt.Sprintf("too many failed logins, retry in %v", retryAfter.Round(time.Second)))
+		return auth.Principal{}, false
+	}
+
+	principal, name, ok := s.authenticate(r)
+	if !ok {
+		if name != "" {
+			s.logins.fail(host)
+			s.logger.WithFields(logrus.Fields{
+				"user":        name,
+				"remote_addr": r.RemoteAddr,
+			}).Warn("API authentication failed")
+		}
+		w.Header().Set("WWW-Authenticate", authRealm)
+		if name == "" {
+			writeError(w, http.StatusUnauthorized, "authentication required")
+		} else {
+			writeError(w, http.StatusUnauthorized, "invalid credentials")
+		}
+		return auth.Principal{}, false
+	}
+	return s.allow(w, principal, required)
+}
+
+// allow checks that principal holds required, writing 403 Forbidden when it
+// does not
+func (s *Server) allow(w http.ResponseWriter, principal auth.Principal, required auth.Scope) (auth.Principal, bool) {
+	if !principal.Allows(required) {
+		writeError(w, http.StatusForbidden, "the "+string(required)+" scope is required")
+		return auth.Principal{}, false
+	}
+	return principal, true
+}
+
+// authenticate resolves the credentials of a request: an API key as bearer
+// token or a user with basic auth. The returned name identifies the failed
+// credentials for logging and is empty when none were sent.
+func (s *Server) authenticate(r *http.Request) (auth.Principal, string, bool) {
+	header := r.Header.Get("Authorization")
+	if token := strings.TrimPrefix(header, "Bearer "); token != header {
+		if s.keys == nil {
+			return auth.Principal{}, "api key", false
+		}
+		principal, ok := s.keys.Authenticate(strings.TrimSpace(token))
+		return principal, "api key", ok
+	}
+
+	name, password, ok := r.BasicAuth()
+	if !ok {
+		return auth.Principal{}, "", false
+	}
+	if s.users == nil {
+		return auth.Principal{}, name, false
+	}
+	user, ok := s.users.Authenticate(name, password)
+	if !ok {
+		return auth.Principal{}, name, false
+	}
+	return auth.Principal{Name: user.Name, Scopes: user.Role.Scopes()}, name, true
+}
+
+// clientHost is the address of the client of r, without the port
+func clientHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// loginThrottle counts failed logins by client address
+type loginThrottle struct {
+	clock clock.Clock
+
+	mu       sync.Mutex
+	failures map[string]*ratelimit.Limiter
+}
+
+// newLoginThrottle creates a throttle driven by c
+func newLoginThrottle(c clock.Clock) *loginThrottle {
+	return &loginThrottle{clock: c, failures: make(map[string]*ratelimit.Limiter)}
+}
+
+// blocked returns how long host must wait before its credentials are
+// checked again, or 0 when they can be checked now
+func (t *loginThrottle) blocked(host string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	limiter := t.failures[host]
+	if limiter == nil || limiter.Remaining() > 0 {
+		return 0
+	}
+	// The limiter is full, so Allow records nothing
+	_, retryAfter := limiter.Allow()
+	return retryAfter
+}
+
+// fail records a failed login of host and forgets the addresses whose
+// failures all left the window
+func (t *loginThrottle) fail(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for other, limiter := range t.failures {
+		if other != host && limiter.Remaining() == loginFailureLimit {
+			delete(t.failures, other)
+		}
+	}
+	limiter := t.failures[host]
+	if limiter == nil {
+		limiter = ratelimit.New(t.clock, loginFailureLimit, loginFailureWindow)
+		t.failures[host] = limiter
+	}
+	limiter.Allow()
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/audit"
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/ratelimit"
+	"github.com/sirupsen/logrus"
+)
+
+// Rebooter reboots the modem on request; *monitor.Service satisfies it
+type Rebooter interface {
+	Reboot(ctx context.Context, reason string) error
+}
+
+// SetRebooter registers POST /api/v1/reboot, which reboots the modem through r
+func (s *Server) SetRebooter(r Rebooter) {
+	s.rebooter = r
+	s.mux.HandleFunc("/api/v1/reboot", s.handleReboot)
+}
+
+// SetAudit records control actions in log
+func (s *Server) SetAudit(log *audit.Log) {
+	s.audit = log
+}
+
+// SetActionLimit bounds how often control actions may be performed
+func (s *Server) SetActionLimit(limiter *ratelimit.Limiter) {
+	s.limiter = limiter
+}
+
+// rebootRequest is the body of a reboot request
+type rebootRequest struct {
+	Reason string `json:"reason"`
+}
+
+// handleReboot starts a modem reboot in the background
+func (s *Server) handleReboot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	user, ok := s.authorize(w, r, auth.ScopeReboot)
+	if !ok {
+		return
+	}
+
+	var req rebootRequest
+	if r.ContentLength != 0 {
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "requested via API"
+	}
+
+	entry := s.auditEntry(r, user, audit.ActionReboot, req.Reason)
+	if !s.allowAction(w, entry) {
+		return
+	}
+	s.record(entry, audit.OutcomeAccepted, nil)
+
+	// The reboot outlives the request; its outcome goes to the audit log
+	go func() {
+		err := s.rebooter.Reboot(context.Background(), req.Reason)
+		outcome := audit.OutcomeSucceeded
+		if err != nil {
+			outcome = audit.OutcomeFailed
+		}
+		s.record(entry, outcome, err)
+	}()
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "reboot started"})
+}
+
+// auditEntry describes a control action requested by user
+func (s *Server) auditEntry(r *http.Request, user auth.Principal, action, reason string) audit.Entry {
+	actor := user.Name
+	if actor == "" {
+		actor = "anonymous"
+	}
+	source := audit.SourceAPI
+	if s.local {
+		source = audit.SourceCLI
+	}
+	return audit.Entry{
+		Action:     action,
+		Actor:      actor,
+		Source:     source,
+		RemoteAddr: r.RemoteAddr,
+		Reason:     reason,
+	}
+}
+
+// allowAction applies the action rate limit. A refused action is audited
+// and answered with 429 Too Many Requests.
+func (s *Server) allowAction(w http.ResponseWriter, entry audit.Entry) bool {
+	allowed, retryAfter := s.limiter.Allow()
+	if allowed {
+		return true
+	}
+
+	s.record(entry, audit.OutcomeRateLimited, nil)
+	s.logger.WithFields(logrus.Fields{
+		"action":      entry.Action,
+		"actor":       entry.Actor,
+		"retry_after": retryAfter,
+	}).Warn("Control action rate limited")
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	writeError(w, http.StatusTooManyRequests, fmt.Sprintf("too many control actions, retry in %v", retryAfter.Round(time.Second)))
+	return false
+}
+
+// record writes entry with outcome to the audit log, if one is configured
+func (s *Server) record(entry audit.Entry, outcome string, err error) {
+	if s.audit == nil {
+		return
+	}
+	entry.Time = time.Now()
+	entry.Outcome = outcome
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if recordErr := s.audit.Record(entry); recordErr != nil {
+		s.logger.WithError(recordErr).WithField("action", entry.Action).Error("Failed to write audit entry")
+	}
+}
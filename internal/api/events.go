@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+)
+
+// Defaults and limits of GET /api/v1/events
+const (
+	defaultEventsSince = 24 * time.Hour
+	defaultEventsLimit = 50
+	maxEventsLimit     = 1000
+)
+
+// EventSource returns the events of the history; *monitor.Service satisfies
+// it
+type EventSource interface {
+	Events(since time.Time, kinds ...string) ([]history.Event, error)
+}
+
+// eventsResponse is the body of GET /api/v1/events
+type eventsResponse struct {
+	Events []history.Event `json:"events"`
+}
+
+// SetEvents registers /api/v1/events, which lists the recent events of the
+// history, such as reboot decisions and check results
+func (s *Server) SetEvents(events EventSource) {
+	s.events = events
+	s.mux.HandleFunc("/api/v1/events", s.handleEvents)
+}
+
+// handleEvents returns the newest events of the history, oldest first.
+// since is how far back to look, 24h by default; limit caps the number of
+// events, 50 by default; kind, repeated or comma-separated, keeps only
+// events of those kinds.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if _, ok := s.authorize(w, r, auth.ScopeRead); !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	since := defaultEventsSince
+	if value := query.Get("since"); value != "" {
+		var err error
+		if since, err = time.ParseDuration(value); err != nil || since <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid since %q", value))
+			return
+		}
+	}
+	limit := defaultEventsLimit
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > maxEventsLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q, must be 1 to %d", value, maxEventsLimit))
+			return
+		}
+	}
+	var kinds []string
+	for _, value := range query["kind"] {
+		for _, kind := range strings.Split(value, ",") {
+			if kind = strings.TrimSpace(kind); kind != "" {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+
+	events, err := s.events.Events(time.Now().Add(-since), kinds...)
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to read history for the API")
+		writeError(w, http.StatusInternalServerError, "failed to read history")
+		return
+	}
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	if events == nil {
+		events = []history.Event{}
+	}
+	writeJSON(w, http.StatusOK, eventsResponse{Events: events})
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+)
+
+// ingressScopes are granted to Home Assistant users reaching the API through
+// ingress. Home Assistant already authenticated them, but changing settings
+// still needs an API key or user with the admin scope.
+var ingressScopes = []auth.Scope{auth.ScopeRead, auth.ScopeReboot}
+
+// SetIngress trusts requests from the Home Assistant ingress proxy at proxy
+// and serves an index at the root that links the API under the ingress path
+func (s *Server) SetIngress(proxy string) {
+	s.ingressProxy = proxy
+	s.mux.HandleFunc("/", s.handleIndex)
+}
+
+// fromIngress reports whether the request was forwarded by the ingress proxy
+func (s *Server) fromIngress(r *http.Request) bool {
+	if s.ingressProxy == "" {
+		return false
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	return err == nil && host == s.ingressProxy
+}
+
+// ingressPrincipal identifies the Home Assistant user behind an ingress request
+func ingressPrincipal(r *http.Request) auth.Principal {
+	name := r.Header.Get("X-Remote-User-Name")
+	if name == "" {
+		name = "home-assistant"
+	}
+	return auth.Principal{Name: "ingress:" + name, Scopes: ingressScopes}
+}
+
+// ingressPath returns the path prefix the ingress proxy serves the API
+// under; it is empty for requests that did not come through ingress
+func (s *Server) ingressPath(r *http.Request) string {
+	if !s.fromIngress(r) {
+		return ""
+	}
+	return strings.TrimSuffix(r.Header.Get("X-Ingress-Path"), "/")
+}
+
+// indexResponse links the endpoints of the API
+type indexResponse struct {
+	Name  string            `json:"name"`
+	Links map[string]string `json:"links"`
+}
+
+// handleIndex lists the API endpoints with paths that work behind ingress
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if _, ok := s.authorize(w, r, auth.ScopeRead); !ok {
+		return
+	}
+
+	prefix := s.ingressPath(r)
+	links := map[string]string{
+		"health": prefix + "/api/v1/health",
+		"status": prefix + "/api/v1/status",
+	}
+	if s.rebooter != nil {
+		links["reboot"] = prefix + "/api/v1/reboot"
+	}
+	if s.pauser != nil {
+		links["pause"] = prefix + "/api/v1/pause"
+	}
+	if s.injector != nil {
+		links["faults"] = prefix + "/api/v1/debug/faults"
+	}
+	writeJSON(w, http.StatusOK, indexResponse{Name: "mb8600-watchdog", Links: links})
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// LocalUserHeader names the user running the CLI on the control socket, for
+// the audit log
+const LocalUserHeader = "X-Watchdog-User"
+
+// SetLocal makes the server the control socket of the CLI. Access is limited
+// by the permissions of the socket file, so requests need no credentials and
+// are audited as CLI actions of the user in LocalUserHeader.
+func (s *Server) SetLocal() {
+	s.local = true
+}
+
+// ListenUnix opens the control socket at path, readable and writable by the
+// owner only. A socket left behind by a previous run is replaced.
+func ListenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	} else if err == nil {
+		return nil, fmt.Errorf("%s exists and is not a socket", path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to check %s: %w", path, err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict %s: %w", path, err)
+	}
+	return listener, nil
+}
+
+// DialUnix returns a dial function for HTTP clients of the control socket at
+// path
+func DialUnix(path string) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "unix", path)
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/audit"
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// SetLogLevels registers /api/v1/log-level, which reads and changes the log
+// level through levels
+func (s *Server) SetLogLevels(levels *logger.LevelController) {
+	s.levels = levels
+	s.mux.HandleFunc("/api/v1/log-level", s.handleLogLevel)
+}
+
+// logLevelRequest is the body of a log level change
+type logLevelRequest struct {
+	Level string `json:"level"`
+	// Module limits the change to one module, such as "modem"; empty
+	// changes the global level
+	Module string `json:"module,omitempty"`
+	// For is how long the level holds, such as "10m"; empty keeps it until
+	// reset or reload
+	For string `json:"for"`
+}
+
+// handleLogLevel reads (GET), changes (PUT) or resets (DELETE) the log
+// level; DELETE resets one module with ?module=, or every level without it
+func (s *Server) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	required := auth.ScopeAdmin
+	if r.Method == http.MethodGet {
+		required = auth.ScopeRead
+	}
+	user, ok := s.authorize(w, r, required)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req logLevelRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		level, err := logrus.ParseLevel(req.Level)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var duration time.Duration
+		if req.For != "" {
+			if duration, err = time.ParseDuration(req.For); err != nil || duration <= 0 {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid duration: %s", req.For))
+				return
+			}
+		}
+
+		entry := s.auditEntry(r, user, audit.ActionLogLevel, "")
+		entry.Details = map[string]interface{}{"level": level.String(), "for": duration.String()}
+		if req.Module != "" {
+			entry.Details["module"] = req.Module
+		}
+		if req.Module == "" {
+			s.levels.Set(level, duration)
+		} else if err := s.levels.SetModule(req.Module, level, duration); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.record(entry, audit.OutcomeSucceeded, nil)
+	case http.MethodDelete:
+		module := r.URL.Query().Get("module")
+		entry := s.auditEntry(r, user, audit.ActionLogLevel, "")
+		entry.Details = map[string]interface{}{"reset": true}
+		if module != "" {
+			entry.Details["module"] = module
+		}
+		if module == "" {
+			s.levels.Reset()
+		} else if err := s.levels.ResetModule(module); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.record(entry, audit.OutcomeSucceeded, nil)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.levels.Status())
+}
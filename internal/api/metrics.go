@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/performance"
+)
+
+// MetricsProvider returns the performance metrics; *monitor.Service
+// satisfies it
+type MetricsProvider interface {
+	PerformanceMetrics() performance.Metrics
+}
+
+// ModemStatusProvider returns the last modem status read, with its
+// channels; *monitor.Service satisfies it
+type ModemStatusProvider interface {
+	ModemStatus() *modem.Status
+}
+
+// SetMetrics registers /api/v1/metrics, which exports the performance
+// metrics of p, the service state and the reboot counters in the Prometheus
+// text format, and the DOCSIS channel metrics when p is also a
+// ModemStatusProvider
+func (s *Server) SetMetrics(p MetricsProvider) {
+	s.metrics = p
+	s.mux.HandleFunc("/api/v1/metrics", s.handleMetrics)
+}
+
+// handleMetrics writes the performance metrics for a Prometheus scrape
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if _, ok := s.authorize(w, r, auth.ScopeRead); !ok {
+		return
+	}
+	w.Header().Set("Content-Type", performance.PrometheusContentType)
+	if err := performance.WritePrometheus(w, s.metrics.PerformanceMetrics()); err != nil {
+		s.logger.WithError(err).Debug("Failed to write metrics")
+		return
+	}
+	state := s.state.Snapshot()
+	if err := monitor.WriteStateMetrics(w, state); err != nil {
+		s.logger.WithError(err).Debug("Failed to write metrics")
+		return
+	}
+	if err := monitor.WriteRebootCounters(w, state); err != nil {
+		s.logger.WithError(err).Debug("Failed to write metrics")
+		return
+	}
+	if provider, ok := s.metrics.(ModemStatusProvider); ok {
+		if err := modem.WritePrometheus(w, provider.ModemStatus()); err != nil {
+			s.logger.WithError(err).Debug("Failed to write metrics")
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/audit"
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/sirupsen/logrus"
+)
+
+// Pauser pauses and resumes automatic reboots; *monitor.Service satisfies it
+type Pauser interface {
+	PauseMonitoring(ctx context.Context, duration time.Duration, pause monitor.Pause) (monitor.Pause, error)
+	ResumeMonitoring(ctx context.Context, by string) (bool, error)
+	ActivePause(ctx context.Context) *monitor.Pause
+}
+
+// SetPauser registers /api/v1/pause, which pauses and resumes automatic
+// reboots through p
+func (s *Server) SetPauser(p Pauser) {
+	s.pauser = p
+	s.mux.HandleFunc("/api/v1/pause", s.handlePause)
+}
+
+// pauseRequest is the body of a pause request
+type pauseRequest struct {
+	// Duration is how long to pause for, such as "2h"
+	Duration string `json:"duration"`
+	// Checks pauses connectivity checks as well as reboots
+	Checks bool   `json:"checks"`
+	Reason string `json:"reason"`
+}
+
+// pauseResponse reports whether monitoring is paused
+type pauseResponse struct {
+	Paused bool           `json:"paused"`
+	Pause  *monitor.Pause `json:"pause,omitempty"`
+}
+
+// handlePause reads (GET), starts (PUT) or ends (DELETE) a pause of
+// automatic reboots. Pausing and resuming need the reboot scope and are
+// audited, but not rate limited.
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	required := auth.ScopeReboot
+	if r.Method == http.MethodGet {
+		required = auth.ScopeRead
+	}
+	user, ok := s.authorize(w, r, required)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req pauseRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid duration: %s", req.Duration))
+			return
+		}
+
+		entry := s.auditEntry(r, user, audit.ActionPause, req.Reason)
+		entry.Details = map[string]interface{}{"duration": duration.String(), "checks": req.Checks}
+		pause, err := s.pauser.PauseMonitoring(r.Context(), duration, monitor.Pause{
+			Checks: req.Checks,
+			Reason: req.Reason,
+			By:     entry.Actor,
+		})
+		if err != nil {
+			s.record(entry, audit.OutcomeFailed, err)
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.record(entry, audit.OutcomeSucceeded, nil)
+		s.logger.WithFields(logrus.Fields{
+			"remote_addr": r.RemoteAddr,
+			"user":        entry.Actor,
+			"until":       pause.Until,
+		}).Warn("Monitoring paused via API")
+	case http.MethodDelete:
+		entry := s.auditEntry(r, user, audit.ActionResume, "")
+		if _, err := s.pauser.ResumeMonitoring(r.Context(), entry.Actor); err != nil {
+			s.record(entry, audit.OutcomeFailed, err)
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.record(entry, audit.OutcomeSucceeded, nil)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	pause := s.pauser.ActivePause(r.Context())
+	writeJSON(w, http.StatusOK, pauseResponse{Paused: pause != nil, Pause: pause})
+}
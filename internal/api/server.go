@@ -0,0 +1,310 @@
+// Package api serves the watchdog's local HTTP API. It exposes the monitoring
+// state and, when fault injection is enabled, debug endpoints to inject
+// faults into a running instance.
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/audit"
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/buildinfo"
+	"github.com/perezjoseph/mb8600-watchdog/internal/chaos"
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/logger"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/ratelimit"
+	"github.com/sirupsen/logrus"
+)
+
+// StateProvider returns the current monitoring state; *monitor.Service satisfies it
+type StateProvider interface {
+	Snapshot() monitor.ServiceState
+}
+
+// Refresher runs a check cycle on request; *monitor.Service satisfies it
+type Refresher interface {
+	Refresh(ctx context.Context) (monitor.ServiceState, error)
+}
+
+// Server is the local HTTP API server
+type Server struct {
+	address   string
+	state     StateProvider
+	refresher Refresher
+	pauser    Pauser
+	injector  *chaos.Injector
+	users     *auth.Users
+	keys      *auth.Keys
+	logins    *loginThrottle
+	tls       *tls.Config
+	rebooter  Rebooter
+	audit     *audit.Log
+	limiter   *ratelimit.Limiter
+	levels    *logger.LevelController
+	metrics   MetricsProvider
+	events    EventSource
+	buildInfo buildinfo.Info
+	logger    *logrus.Logger
+	// ingressProxy is the address of the Home Assistant ingress proxy
+	ingressProxy string
+	// local marks the control socket of the CLI
+	local bool
+	mux   *http.ServeMux
+}
+
+// NewServer creates an API server listening on address. Fault injection
+// endpoints are only registered when injector is non-nil.
+func NewServer(address string, state StateProvider, injector *chaos.Injector, logger *logrus.Logger) *Server {
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.InfoLevel)
+	}
+
+	s := &Server{
+		address:  address,
+		state:    state,
+		injector: injector,
+		logins:   newLoginThrottle(clock.New()),
+		logger:   logger,
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/api/v1/health", s.handleHealth)
+	s.mux.HandleFunc("/api/v1/status", s.handleStatus)
+	if injector != nil {
+		s.mux.HandleFunc("/api/v1/debug/faults", s.handleFaults)
+	}
+	return s
+}
+
+// SetRefresher lets GET /api/v1/status?refresh=true run a check through r
+// instead of returning the state of the last check
+func (s *Server) SetRefresher(r Refresher) {
+	s.refresher = r
+}
+
+// SetTLS serves the API over HTTPS with config
+func (s *Server) SetTLS(config *tls.Config) {
+	s.tls = config
+}
+
+// Handler returns the HTTP handler serving the API
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Start serves the API until ctx is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.address, err)
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves the API on listener until ctx is cancelled
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	if s.tls != nil {
+		listener = tls.NewListener(listener, s.tls)
+	}
+
+	server := &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	s.logger.WithFields(logrus.Fields{
+		"address":         listener.Addr().String(),
+		"fault_injection": s.injector != nil,
+		"authentication":  s.authenticationRequired(),
+		"tls":             s.tls != nil,
+		"ingress":         s.ingressProxy != "",
+	}).Info("API server listening")
+	if !s.authenticationRequired() && !loopback(listener.Addr()) {
+		s.logger.WithField("address", listener.Addr().String()).Warn("API is open to the network without authentication, configure API users or keys or listen on loopback")
+	}
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("API server failed: %w", err)
+	}
+	return nil
+}
+
+// healthResponse reports whether monitoring is running
+type healthResponse struct {
+	Status    string    `json:"status"`
+	LastCheck time.Time `json:"last_check"`
+}
+
+// handleHealth reports 200 while monitoring runs and 503 otherwise. It needs
+// no credentials, so supervisors such as the Home Assistant add-on watchdog
+// and container health checks can poll it.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	state := s.state.Snapshot()
+	if !state.IsRunning {
+		writeJSON(w, http.StatusServiceUnavailable, healthResponse{Status: "stopped", LastCheck: state.LastCheck})
+		return
+	}
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok", LastCheck: state.LastCheck})
+}
+
+// handleStatus returns the monitoring state as of the last check, so any
+// number of clients can poll it without probing the network. With
+// refresh=true it runs a check first; since that check counts towards the
+// failure threshold, it needs the reboot scope.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	refresh := false
+	if value := r.URL.Query().Get("refresh"); value != "" {
+		var err error
+		if refresh, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid refresh: %v", err))
+			return
+		}
+	}
+	if !refresh || s.refresher == nil {
+		if _, ok := s.authorize(w, r, auth.ScopeRead); !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, s.state.Snapshot())
+		return
+	}
+
+	user, ok := s.authorize(w, r, auth.ScopeReboot)
+	if !ok {
+		return
+	}
+	state, err := s.refresher.Refresh(r.Context())
+	if err != nil {
+		s.logger.WithError(err).WithField("user", user.Name).Warn("Status refresh check failed")
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+// faultsDocument is the JSON form of the active faults
+type faultsDocument struct {
+	DropPercent    int    `json:"drop_percent"`
+	ModemDelay     string `json:"modem_delay"`
+	FailRebootOnce bool   `json:"fail_reboot_once"`
+}
+
+// faultsResponse reports the active faults and how often they fired
+type faultsResponse struct {
+	Faults faultsDocument `json:"faults"`
+	Stats  chaos.Stats    `json:"stats"`
+}
+
+// handleFaults reads (GET), replaces (PUT) or clears (DELETE) the injected faults
+func (s *Server) handleFaults(w http.ResponseWriter, r *http.Request) {
+	// Reading faults is harmless; changing them needs admin rights
+	required := auth.ScopeAdmin
+	if r.Method == http.MethodGet {
+		required = auth.ScopeRead
+	}
+	user, ok := s.authorize(w, r, required)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var doc faultsDocument
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&doc); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+
+		faults := chaos.Faults{DropPercent: doc.DropPercent, FailRebootOnce: doc.FailRebootOnce}
+		if doc.ModemDelay != "" {
+			delay, err := time.ParseDuration(doc.ModemDelay)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid modem_delay: %v", err))
+				return
+			}
+			faults.ModemDelay = delay
+		}
+
+		entry := s.auditEntry(r, user, audit.ActionFaultsSet, "")
+		entry.Details = map[string]interface{}{
+			"drop_percent":     faults.DropPercent,
+			"modem_delay":      faults.ModemDelay.String(),
+			"fail_reboot_once": faults.FailRebootOnce,
+		}
+		if !s.allowAction(w, entry) {
+			return
+		}
+		if err := s.injector.Set(faults); err != nil {
+			s.record(entry, audit.OutcomeFailed, err)
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.record(entry, audit.OutcomeSucceeded, nil)
+		s.logger.WithFields(logrus.Fields{
+			"remote_addr": r.RemoteAddr,
+			"user":        user.Name,
+		}).Warn("Faults injected via API")
+	case http.MethodDelete:
+		// Clearing faults is never rate limited, it only makes things safer
+		entry := s.auditEntry(r, user, audit.ActionFaultsClear, "")
+		s.injector.Clear()
+		s.record(entry, audit.OutcomeSucceeded, nil)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	faults := s.injector.Faults()
+	writeJSON(w, http.StatusOK, faultsResponse{
+		Faults: faultsDocument{
+			DropPercent:    faults.DropPercent,
+			ModemDelay:     faults.ModemDelay.String(),
+			FailRebootOnce: faults.FailRebootOnce,
+		},
+		Stats: s.injector.Stats(),
+	})
+}
+
+// writeJSON writes value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// loopback reports whether addr only accepts connections from this host
+func loopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
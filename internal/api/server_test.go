@@ -0,0 +1,920 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/audit"
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/buildinfo"
+	"github.com/perezjoseph/mb8600-watchdog/internal/certs"
+	"github.com/perezjoseph/mb8600-watchdog/internal/chaos"
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/logger"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/performance"
+	"github.com/perezjoseph/mb8600-watchdog/internal/ratelimit"
+	"github.com/sirupsen/logrus"
+)
+
+type stubState struct {
+	state monitor.ServiceState
+}
+
+func (s *stubState) Snapshot() monitor.ServiceState {
+	return s.state
+}
+
+func newTestServer(injector *chaos.Injector) *Server {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	state := &stubState{state: monitor.ServiceState{TotalChecks: 42, IsRunning: true}}
+	return NewServer("127.0.0.1:0", state, injector, logger)
+}
+
+func do(t *testing.T, handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStatusEndpoint(t *testing.T) {
+	server := newTestServer(nil)
+
+	rec := do(t, server.Handler(), http.MethodGet, "/api/v1/status", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	var state monitor.ServiceState
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if state.TotalChecks != 42 || !state.IsRunning {
+		t.Errorf("Unexpected state %+v", state)
+	}
+
+	if rec := do(t, server.Handler(), http.MethodPost, "/api/v1/status", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", rec.Code)
+	}
+}
+
+func TestFaultEndpointsDisabledByDefault(t *testing.T) {
+	server := newTestServer(nil)
+
+	rec := do(t, server.Handler(), http.MethodPut, "/api/v1/debug/faults", `{"drop_percent": 50}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected fault injection to be unavailable, got %d", rec.Code)
+	}
+}
+
+func TestFaultEndpoints(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	injector := chaos.NewInjector(logger)
+	server := newTestServer(injector)
+
+	rec := do(t, server.Handler(), http.MethodPut, "/api/v1/debug/faults",
+		`{"drop_percent": 25, "modem_delay": "3s", "fail_reboot_once": true}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	faults := injector.Faults()
+	if faults.DropPercent != 25 || faults.ModemDelay != 3*time.Second || !faults.FailRebootOnce {
+		t.Errorf("Faults not applied: %+v", faults)
+	}
+
+	var response faultsResponse
+	rec = do(t, server.Handler(), http.MethodGet, "/api/v1/debug/faults", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if response.Faults.ModemDelay != "3s" || response.Faults.DropPercent != 25 {
+		t.Errorf("Unexpected response %+v", response)
+	}
+
+	for _, body := range []string{`{"drop_percent": 150}`, `{"modem_delay": "soon"}`, `{"unknown": 1}`, `not json`} {
+		if rec := do(t, server.Handler(), http.MethodPut, "/api/v1/debug/faults", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+
+	rec = do(t, server.Handler(), http.MethodDelete, "/api/v1/debug/faults", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if injector.Faults() != (chaos.Faults{}) {
+		t.Errorf("Expected faults to be cleared, got %+v", injector.Faults())
+	}
+}
+
+func TestFailedLoginsThrottledByAddress(t *testing.T) {
+	server := newTestServer(nil)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	server.logins = newLoginThrottle(fake)
+
+	hash, _ := auth.HashPassword("viewer-password")
+	users, err := auth.NewUsers(auth.User{Name: "viewer", PasswordHash: hash, Role: auth.RoleViewer})
+	if err != nil {
+		t.Fatalf("NewUsers() failed: %v", err)
+	}
+	server.SetUsers(users)
+
+	request := func(remote, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+		req.RemoteAddr = remote
+		req.SetBasicAuth("viewer", password)
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < loginFailureLimit; i++ {
+		if rec := request("192.0.2.10:1234", "nope"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("Failure %d: expected 401, got %d", i+1, rec.Code)
+		}
+	}
+	rec := request("192.0.2.10:5678", "viewer-password")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the address to be throttled, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+	if rec := request("192.0.2.20:1234", "viewer-password"); rec.Code != http.StatusOK {
+		t.Errorf("Expected another address to log in, got %d", rec.Code)
+	}
+
+	fake.Advance(loginFailureWindow)
+	if rec := request("192.0.2.10:1234", "viewer-password"); rec.Code != http.StatusOK {
+		t.Errorf("Expected the address to log in after the window, got %d", rec.Code)
+	}
+}
+
+func TestRoleBasedAccess(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	server := newTestServer(chaos.NewInjector(logger))
+
+	viewerHash, _ := auth.HashPassword("viewer-password")
+	operatorHash, _ := auth.HashPassword("operator-password")
+	users, err := auth.NewUsers(
+		auth.User{Name: "viewer", PasswordHash: viewerHash, Role: auth.RoleViewer},
+		auth.User{Name: "operator", PasswordHash: operatorHash, Role: auth.RoleOperator},
+	)
+	if err != nil {
+		t.Fatalf("NewUsers() failed: %v", err)
+	}
+	server.SetUsers(users)
+
+	request := func(method, path, user, password, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name           string
+		method, path   string
+		user, password string
+		want           int
+	}{
+		{"anonymous status", http.MethodGet, "/api/v1/status", "", "", http.StatusUnauthorized},
+		{"wrong password", http.MethodGet, "/api/v1/status", "viewer", "nope", http.StatusUnauthorized},
+		{"viewer status", http.MethodGet, "/api/v1/status", "viewer", "viewer-password", http.StatusOK},
+		{"viewer reads faults", http.MethodGet, "/api/v1/debug/faults", "viewer", "viewer-password", http.StatusOK},
+		{"viewer injects faults", http.MethodPut, "/api/v1/debug/faults", "viewer", "viewer-password", http.StatusForbidden},
+		{"viewer clears faults", http.MethodDelete, "/api/v1/debug/faults", "viewer", "viewer-password", http.StatusForbidden},
+		{"operator injects faults", http.MethodPut, "/api/v1/debug/faults", "operator", "operator-password", http.StatusOK},
+		{"operator status", http.MethodGet, "/api/v1/status", "operator", "operator-password", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if got := request(tt.method, tt.path, tt.user, tt.password, `{"drop_percent": 10}`); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, got)
+		}
+	}
+
+	rec := do(t, server.Handler(), http.MethodGet, "/api/v1/status", "")
+	if rec.Header().Get("WWW-Authenticate") == "" {
+		t.Error("Expected a WWW-Authenticate challenge")
+	}
+}
+
+func TestServeOverTLS(t *testing.T) {
+	tlsConfig, err := certs.ServerConfig("", "", t.TempDir(), []string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("ServerConfig() failed: %v", err)
+	}
+	server := newTestServer(nil)
+	server.SetTLS(tlsConfig)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Serve(ctx, listener)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(tlsConfig.Certificates[0].Leaf)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	resp, err := client.Get("https://" + listener.Addr().String() + "/api/v1/status")
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode)
+	}
+}
+
+type stubRebooter struct {
+	reasons chan string
+	err     error
+}
+
+func (r *stubRebooter) Reboot(ctx context.Context, reason string) error {
+	r.reasons <- reason
+	return r.err
+}
+
+func TestRebootIsAuditedAndRateLimited(t *testing.T) {
+	server := newTestServer(nil)
+	rebooter := &stubRebooter{reasons: make(chan string, 4)}
+	server.SetRebooter(rebooter)
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.Open(auditPath)
+	if err != nil {
+		t.Fatalf("audit.Open() failed: %v", err)
+	}
+	defer auditLog.Close()
+	server.SetAudit(auditLog)
+	server.SetActionLimit(ratelimit.New(nil, 1, time.Hour))
+
+	if rec := do(t, server.Handler(), http.MethodGet, "/api/v1/reboot", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", rec.Code)
+	}
+
+	rec := do(t, server.Handler(), http.MethodPost, "/api/v1/reboot", `{"reason": "modem stuck"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	select {
+	case reason := <-rebooter.reasons:
+		if reason != "modem stuck" {
+			t.Errorf("Unexpected reason %q", reason)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reboot was not started")
+	}
+
+	rec = do(t, server.Handler(), http.MethodPost, "/api/v1/reboot", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+
+	// The reboot outcome is written once the reboot returns
+	var entries []audit.Entry
+	deadline := time.Now().Add(5 * time.Second)
+	for len(entries) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		entries, _ = audit.Read(auditPath)
+	}
+
+	outcomes := map[string]int{}
+	for _, entry := range entries {
+		if entry.Action != audit.ActionReboot || entry.Source != audit.SourceAPI || entry.Actor != "anonymous" {
+			t.Errorf("Unexpected entry %+v", entry)
+		}
+		outcomes[entry.Outcome]++
+	}
+	if outcomes[audit.OutcomeAccepted] != 1 || outcomes[audit.OutcomeSucceeded] != 1 || outcomes[audit.OutcomeRateLimited] != 1 {
+		t.Errorf("Unexpected audit outcomes %v", outcomes)
+	}
+}
+
+func TestRebootRequiresOperator(t *testing.T) {
+	server := newTestServer(nil)
+	server.SetRebooter(&stubRebooter{reasons: make(chan string, 1)})
+
+	hash, _ := auth.HashPassword("viewer-password")
+	users, _ := auth.NewUsers(auth.User{Name: "viewer", PasswordHash: hash, Role: auth.RoleViewer})
+	server.SetUsers(users)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/reboot", nil)
+	req.SetBasicAuth("viewer", "viewer-password")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a viewer, got %d", rec.Code)
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	server := newTestServer(chaos.NewInjector(logger))
+	rebooter := &stubRebooter{reasons: make(chan string, 4)}
+	server.SetRebooter(rebooter)
+
+	keys, err := auth.OpenKeys(filepath.Join(t.TempDir(), "api-keys.json"))
+	if err != nil {
+		t.Fatalf("OpenKeys() failed: %v", err)
+	}
+	server.SetAPIKeys(keys)
+
+	// Keys alone turn authentication on, without a users file
+	if rec := do(t, server.Handler(), http.MethodGet, "/api/v1/status", ""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected open access without active keys, got %d", rec.Code)
+	}
+	token, key, err := keys.Create("home-assistant", []auth.Scope{auth.ScopeRead, auth.ScopeReboot}, time.Now())
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	readToken, _, _ := keys.Create("grafana", []auth.Scope{auth.ScopeRead}, time.Now())
+
+	request := func(method, path, token, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		token        string
+		want         int
+	}{
+		{"anonymous status", http.MethodGet, "/api/v1/status", "", http.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/api/v1/status", auth.KeyPrefix + "000000000000_secret", http.StatusUnauthorized},
+		{"read key status", http.MethodGet, "/api/v1/status", readToken, http.StatusOK},
+		{"read key reboots", http.MethodPost, "/api/v1/reboot", readToken, http.StatusForbidden},
+		{"reboot key status", http.MethodGet, "/api/v1/status", token, http.StatusOK},
+		{"reboot key injects faults", http.MethodPut, "/api/v1/debug/faults", token, http.StatusForbidden},
+		{"reboot key reboots", http.MethodPost, "/api/v1/reboot", token, http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		body := `{"drop_percent": 10}`
+		if tt.path == "/api/v1/reboot" {
+			body = `{"reason": "scheduled by home assistant"}`
+		}
+		if got := request(tt.method, tt.path, tt.token, body); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, got)
+		}
+	}
+	<-rebooter.reasons
+
+	if err := keys.Revoke(key.ID, time.Now()); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	if got := request(http.MethodGet, "/api/v1/status", token, ""); got != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a revoked key, got %d", got)
+	}
+}
+
+func TestHealthEndpoint(t *testing.T) {
+	server := newTestServer(nil)
+	keys, _ := auth.OpenKeys(filepath.Join(t.TempDir(), "api-keys.json"))
+	keys.Create("grafana", []auth.Scope{auth.ScopeRead}, time.Now())
+	server.SetAPIKeys(keys)
+
+	rec := do(t, server.Handler(), http.MethodGet, "/api/v1/health", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 without credentials, got %d", rec.Code)
+	}
+	var health healthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil || health.Status != "ok" {
+		t.Errorf("Unexpected health response %s", rec.Body.String())
+	}
+
+	stopped := NewServer("127.0.0.1:0", &stubState{}, nil, nil)
+	if rec := do(t, stopped.Handler(), http.MethodGet, "/api/v1/health", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while monitoring is stopped, got %d", rec.Code)
+	}
+}
+
+func TestIngress(t *testing.T) {
+	server := newTestServer(nil)
+	server.SetRebooter(&stubRebooter{reasons: make(chan string, 1)})
+	keys, _ := auth.OpenKeys(filepath.Join(t.TempDir(), "api-keys.json"))
+	keys.Create("grafana", []auth.Scope{auth.ScopeRead}, time.Now())
+	server.SetAPIKeys(keys)
+	server.SetIngress("172.30.32.2")
+
+	request := func(method, path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"reason": "ingress"}`))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Ingress-Path", "/api/hassio_ingress/abc123")
+		req.Header.Set("X-Remote-User-Name", "alice")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request(http.MethodGet, "/", "172.30.32.2:51000")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected ingress index, got %d", rec.Code)
+	}
+	var index indexResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &index); err != nil {
+		t.Fatalf("Invalid index: %v", err)
+	}
+	if index.Links["status"] != "/api/hassio_ingress/abc123/api/v1/status" || index.Links["reboot"] == "" {
+		t.Errorf("Expected links under the ingress path, got %v", index.Links)
+	}
+
+	if rec := request(http.MethodPost, "/api/v1/reboot", "172.30.32.2:51000"); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected ingress users to reboot, got %d", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/api/v1/status", "192.168.1.20:51000"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected other clients to authenticate, got %d", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/missing", "172.30.32.2:51000"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown paths, got %d", rec.Code)
+	}
+}
+
+func TestIngressWithoutAuthentication(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	server := newTestServer(chaos.NewInjector(logger))
+	server.SetIngress("172.30.32.2")
+
+	request := func(method, path, remoteAddr string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"drop_percent": 10}`))
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request(http.MethodGet, "/api/v1/status", "172.30.32.2:51000"); code != http.StatusOK {
+		t.Errorf("Expected ingress users to read the status, got %d", code)
+	}
+	if code := request(http.MethodPut, "/api/v1/debug/faults", "172.30.32.2:51000"); code != http.StatusForbidden {
+		t.Errorf("Expected ingress users to need the admin scope, got %d", code)
+	}
+	if code := request(http.MethodGet, "/api/v1/status", "192.168.1.20:51000"); code != http.StatusUnauthorized {
+		t.Errorf("Expected other clients to authenticate, got %d", code)
+	}
+}
+
+func TestLogLevelEndpoint(t *testing.T) {
+	server := newTestServer(nil)
+	log := logrus.New()
+	log.SetLevel(logrus.InfoLevel)
+	server.SetLogLevels(logger.NewLevelController(log, nil))
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.Open(auditPath)
+	if err != nil {
+		t.Fatalf("audit.Open() failed: %v", err)
+	}
+	defer auditLog.Close()
+	server.SetAudit(auditLog)
+
+	rec := do(t, server.Handler(), http.MethodPut, "/api/v1/log-level", `{"level": "debug", "for": "10m"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var status logger.LevelStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if status.Level != "debug" || status.Until == nil || log.GetLevel() != logrus.DebugLevel {
+		t.Errorf("Expected a temporary debug level, got %+v", status)
+	}
+
+	for _, body := range []string{`{"level": "loud"}`, `{"level": "debug", "for": "-1m"}`} {
+		if rec := do(t, server.Handler(), http.MethodPut, "/api/v1/log-level", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+
+	if rec := do(t, server.Handler(), http.MethodDelete, "/api/v1/log-level", ""); rec.Code != http.StatusOK || log.GetLevel() != logrus.InfoLevel {
+		t.Errorf("Expected reset to info, got %d and %s", rec.Code, log.GetLevel())
+	}
+
+	entries, err := audit.Read(auditPath)
+	if err != nil || len(entries) != 2 || entries[0].Action != audit.ActionLogLevel {
+		t.Errorf("Expected two audited log level changes, got %+v %v", entries, err)
+	}
+}
+
+func TestControlSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "control.sock")
+
+	// A stale socket from a previous run is replaced
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to create stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := ListenUnix(path)
+	if err != nil {
+		t.Fatalf("ListenUnix() failed: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a socket only the owner can use, got %v %v", info.Mode(), err)
+	}
+
+	server := newTestServer(nil)
+	server.SetLocal()
+	hash, _ := auth.HashPassword("password")
+	users, _ := auth.NewUsers(auth.User{Name: "viewer", PasswordHash: hash, Role: auth.RoleViewer})
+	server.SetUsers(users)
+	log := logrus.New()
+	server.SetLogLevels(logger.NewLevelController(log, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Serve(ctx, listener)
+
+	client := &http.Client{Transport: &http.Transport{DialContext: DialUnix(path)}}
+	req, _ := http.NewRequest(http.MethodPut, "http://watchdog/api/v1/log-level", strings.NewReader(`{"level": "trace"}`))
+	req.Header.Set(LocalUserHeader, "alice")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request over the control socket failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || log.GetLevel() != logrus.TraceLevel {
+		t.Errorf("Expected the control socket to need no credentials, got %d", resp.StatusCode)
+	}
+
+	if _, err := ListenUnix(filepath.Join(t.TempDir())); err == nil {
+		t.Error("Expected an error for a path that is not a socket")
+	}
+}
+
+func TestLogLevelEndpointModule(t *testing.T) {
+	server := newTestServer(nil)
+	levels := logger.NewLevelController(logrus.New(), nil)
+	server.SetLogLevels(levels)
+	modem := levels.Module("modem")
+
+	rec := do(t, server.Handler(), http.MethodPut, "/api/v1/log-level", `{"level": "trace", "module": "modem"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var status logger.LevelStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if status.Level != "info" || status.Modules["modem"].Level != "trace" || modem.GetLevel() != logrus.TraceLevel {
+		t.Errorf("Expected only the modem module at trace, got %+v", status)
+	}
+
+	if rec := do(t, server.Handler(), http.MethodPut, "/api/v1/log-level", `{"level": "debug", "module": "router"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown module, got %d", rec.Code)
+	}
+
+	if rec := do(t, server.Handler(), http.MethodDelete, "/api/v1/log-level?module=modem", ""); rec.Code != http.StatusOK || modem.GetLevel() != logrus.InfoLevel {
+		t.Errorf("Expected the modem module reset, got %d and %s", rec.Code, modem.GetLevel())
+	}
+}
+
+type stubMetrics struct{}
+
+func (stubMetrics) PerformanceMetrics() performance.Metrics {
+	return performance.Metrics{OperationMetrics: map[string]performance.OperationStat{
+		"connectivity_check": {Count: 3, TotalDuration: time.Second},
+	}}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	server := newTestServer(nil)
+	if rec := do(t, server.Handler(), http.MethodGet, "/api/v1/metrics", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 without metrics, got %d", rec.Code)
+	}
+
+	server.SetMetrics(stubMetrics{})
+	rec := do(t, server.Handler(), http.MethodGet, "/api/v1/metrics", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != performance.PrometheusContentType {
+		t.Errorf("Expected the Prometheus content type, got %q", got)
+	}
+	if !strings.Contains(rec.Body.String(), `watchdog_operation_duration_seconds_count{operation="connectivity_check"} 3`) {
+		t.Errorf("Unexpected metrics:\n%s", rec.Body.String())
+	}
+
+	server.state.(*stubState).state.RebootsByReason = map[string]int{monitor.RebootThreshold: 2}
+	rec = do(t, server.Handler(), http.MethodGet, "/api/v1/metrics", "")
+	for _, want := range []string{
+		`watchdog_reboots_total{reason="threshold",automated="true"} 2`,
+		`watchdog_reboots_total{reason="manual",automated="false"} 0`,
+		`watchdog_consecutive_failures 0`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected %s in metrics:\n%s", want, rec.Body.String())
+		}
+	}
+	if rec := do(t, server.Handler(), http.MethodPost, "/api/v1/metrics", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", rec.Code)
+	}
+}
+
+func TestVersionEndpoint(t *testing.T) {
+	server := newTestServer(nil)
+	if rec := do(t, server.Handler(), http.MethodGet, "/api/v1/version", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 without build information, got %d", rec.Code)
+	}
+
+	server.SetBuildInfo(buildinfo.Info{
+		Version:      "v1.4.0",
+		Commit:       "0a1b2c3",
+		Features:     []buildinfo.Feature{{Name: "history", Compiled: true, Enabled: true}},
+		ModemDrivers: []string{"mb8600"},
+	})
+	rec := do(t, server.Handler(), http.MethodGet, "/api/v1/version", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var info buildinfo.Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if info.Version != "v1.4.0" || info.Commit != "0a1b2c3" || len(info.Features) != 1 || info.ModemDrivers[0] != "mb8600" {
+		t.Errorf("Unexpected build information %+v", info)
+	}
+	if rec := do(t, server.Handler(), http.MethodPost, "/api/v1/version", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", rec.Code)
+	}
+}
+
+// stubEvents is a history of events
+type stubEvents struct {
+	events []history.Event
+	kinds  []string
+}
+
+func (e *stubEvents) Events(since time.Time, kinds ...string) ([]history.Event, error) {
+	e.kinds = kinds
+	var events []history.Event
+	for _, event := range e.events {
+		if !event.Time.Before(since) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func TestEventsEndpoint(t *testing.T) {
+	server := newTestServer(nil)
+	if rec := do(t, server.Handler(), http.MethodGet, "/api/v1/events", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 without an event source, got %d", rec.Code)
+	}
+
+	now := time.Now()
+	source := &stubEvents{events: []history.Event{
+		{Time: now.Add(-48 * time.Hour), Kind: history.KindCheck},
+		{Time: now.Add(-3 * time.Minute), Kind: history.KindCheck},
+		{Time: now.Add(-2 * time.Minute), Kind: history.KindRebootDecision},
+		{Time: now.Add(-time.Minute), Kind: history.KindCheck},
+	}}
+	server.SetEvents(source)
+
+	decode := func(rec *httptest.ResponseRecorder) []history.Event {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+		var response eventsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Events
+	}
+
+	if events := decode(do(t, server.Handler(), http.MethodGet, "/api/v1/events", "")); len(events) != 3 {
+		t.Errorf("Expected the 3 events of the last day, got %d", len(events))
+	}
+	events := decode(do(t, server.Handler(), http.MethodGet, "/api/v1/events?limit=2&kind=check,reboot_decision&kind=ip_change", ""))
+	if len(events) != 2 || events[0].Kind != history.KindRebootDecision || events[1].Kind != history.KindCheck {
+		t.Errorf("Expected the newest 2 events, oldest first, got %+v", events)
+	}
+	if strings.Join(source.kinds, ",") != "check,reboot_decision,ip_change" {
+		t.Errorf("Expected the kinds to be passed on, got %v", source.kinds)
+	}
+	if events := decode(do(t, server.Handler(), http.MethodGet, "/api/v1/events?since=72h", "")); len(events) != 4 {
+		t.Errorf("Expected all 4 events of the last 3 days, got %d", len(events))
+	}
+
+	for _, path := range []string{"/api/v1/events?since=soon", "/api/v1/events?limit=0", "/api/v1/events?limit=5000"} {
+		if rec := do(t, server.Handler(), http.MethodGet, path, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", path, rec.Code)
+		}
+	}
+	if rec := do(t, server.Handler(), http.MethodPost, "/api/v1/events", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", rec.Code)
+	}
+}
+
+// stubModemMetrics adds a modem status to stubMetrics
+type stubModemMetrics struct {
+	stubMetrics
+	status *modem.Status
+}
+
+func (m stubModemMetrics) ModemStatus() *modem.Status { return m.status }
+
+func TestMetricsEndpointChannels(t *testing.T) {
+	server := newTestServer(nil)
+	server.SetMetrics(stubModemMetrics{status: &modem.Status{
+		Model:      "MB8600",
+		Downstream: []modem.Channel{{ChannelID: 5, LockStatus: "Locked", Modulation: "QAM256", PowerDBmV: 3.1, SNRDB: 39.5, Uncorrectables: 7}},
+	}})
+	rec := do(t, server.Handler(), http.MethodGet, "/api/v1/metrics", "")
+	for _, want := range []string{
+		`watchdog_reboots_total{reason="threshold",automated="true"} 0`,
+		`docsis_modem_info{model="MB8600",firmware="",mode=""} 1`,
+		`docsis_downstream_snr_db{channel="5",modulation="QAM256"} 39.5`,
+		`docsis_downstream_uncorrectable_codewords_total{channel="5",modulation="QAM256"} 7`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected %s in metrics:\n%s", want, rec.Body.String())
+		}
+	}
+
+	// Before the modem was read there are no channel metrics
+	server = newTestServer(nil)
+	server.SetMetrics(stubModemMetrics{})
+	if rec := do(t, server.Handler(), http.MethodGet, "/api/v1/metrics", ""); strings.Contains(rec.Body.String(), "docsis_") {
+		t.Errorf("Expected no channel metrics without a modem status:\n%s", rec.Body.String())
+	}
+}
+
+type stubRefresher struct {
+	refreshes int
+}
+
+func (r *stubRefresher) Refresh(ctx context.Context) (monitor.ServiceState, error) {
+	r.refreshes++
+	return monitor.ServiceState{TotalChecks: 43, IsRunning: true}, nil
+}
+
+func TestStatusRefresh(t *testing.T) {
+	server := newTestServer(nil)
+	refresher := &stubRefresher{}
+	server.SetRefresher(refresher)
+
+	viewerHash, _ := auth.HashPassword("viewer-password")
+	operatorHash, _ := auth.HashPassword("operator-password")
+	users, err := auth.NewUsers(
+		auth.User{Name: "viewer", PasswordHash: viewerHash, Role: auth.RoleViewer},
+		auth.User{Name: "operator", PasswordHash: operatorHash, Role: auth.RoleOperator},
+	)
+	if err != nil {
+		t.Fatalf("NewUsers() failed: %v", err)
+	}
+	server.SetUsers(users)
+
+	request := func(path, user, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.SetBasicAuth(user, password)
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request("/api/v1/status", "viewer", "viewer-password"); rec.Code != http.StatusOK || refresher.refreshes != 0 {
+		t.Errorf("Expected the last state without a check, got %d after %d refreshes", rec.Code, refresher.refreshes)
+	}
+	if rec := request("/api/v1/status?refresh=true", "viewer", "viewer-password"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a viewer refresh, got %d", rec.Code)
+	}
+	if rec := request("/api/v1/status?refresh=maybe", "operator", "operator-password"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid refresh, got %d", rec.Code)
+	}
+
+	rec := request("/api/v1/status?refresh=true", "operator", "operator-password")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var state monitor.ServiceState
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if refresher.refreshes != 1 || state.TotalChecks != 43 {
+		t.Errorf("Expected the refreshed state, got %+v after %d refreshes", state, refresher.refreshes)
+	}
+}
+
+type stubPauser struct {
+	pause *monitor.Pause
+}
+
+func (p *stubPauser) PauseMonitoring(ctx context.Context, duration time.Duration, pause monitor.Pause) (monitor.Pause, error) {
+	if duration > monitor.MaxPause {
+		return monitor.Pause{}, fmt.Errorf("pause too long")
+	}
+	pause.Until = time.Now().Add(duration)
+	p.pause = &pause
+	return pause, nil
+}
+
+func (p *stubPauser) ResumeMonitoring(ctx context.Context, by string) (bool, error) {
+	resumed := p.pause != nil
+	p.pause = nil
+	return resumed, nil
+}
+
+func (p *stubPauser) ActivePause(ctx context.Context) *monitor.Pause {
+	return p.pause
+}
+
+func TestPauseEndpoint(t *testing.T) {
+	server := newTestServer(nil)
+	pauser := &stubPauser{}
+	server.SetPauser(pauser)
+
+	viewerHash, _ := auth.HashPassword("viewer-password")
+	operatorHash, _ := auth.HashPassword("operator-password")
+	users, err := auth.NewUsers(
+		auth.User{Name: "viewer", PasswordHash: viewerHash, Role: auth.RoleViewer},
+		auth.User{Name: "operator", PasswordHash: operatorHash, Role: auth.RoleOperator},
+	)
+	if err != nil {
+		t.Fatalf("NewUsers() failed: %v", err)
+	}
+	server.SetUsers(users)
+
+	request := func(method, body, user, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/pause", strings.NewReader(body))
+		req.SetBasicAuth(user, password)
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(http.MethodPut, `{"duration":"2h"}`, "viewer", "viewer-password"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a viewer pause, got %d", rec.Code)
+	}
+	if rec := request(http.MethodPut, `{"duration":"soon"}`, "operator", "operator-password"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid duration, got %d", rec.Code)
+	}
+	if rec := request(http.MethodPut, `{"duration":"720h"}`, "operator", "operator-password"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a pause that is too long, got %d", rec.Code)
+	}
+
+	rec := request(http.MethodPut, `{"duration":"2h","reason":"technician"}`, "operator", "operator-password")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if pauser.pause == nil || pauser.pause.By != "operator" || pauser.pause.Reason != "technician" {
+		t.Errorf("Expected a pause by operator, got %+v", pauser.pause)
+	}
+
+	rec = request(http.MethodGet, "", "viewer", "viewer-password")
+	var response pauseResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if !response.Paused || response.Pause == nil {
+		t.Errorf("Expected the pause to be reported, got %+v", response)
+	}
+
+	if rec := request(http.MethodDelete, "", "operator", "operator-password"); rec.Code != http.StatusOK || pauser.pause != nil {
+		t.Errorf("Expected the pause to end, got %d with %+v", rec.Code, pauser.pause)
+	}
+}
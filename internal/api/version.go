@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/buildinfo"
+)
+
+// SetBuildInfo registers /api/v1/version, which describes the running
+// binary: its version, commit, Go version, features and modem drivers
+func (s *Server) SetBuildInfo(info buildinfo.Info) {
+	s.buildInfo = info
+	s.mux.HandleFunc("/api/v1/version", s.handleVersion)
+}
+
+// handleVersion returns the build information, so integrations can check
+// what the watchdog supports
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if _, ok := s.authorize(w, r, auth.ScopeRead); !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.buildInfo)
+}
@@ -2,17 +2,30 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/perezjoseph/mb8600-watchdog/internal/api"
+	"github.com/perezjoseph/mb8600-watchdog/internal/audit"
+	"github.com/perezjoseph/mb8600-watchdog/internal/auth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/buildinfo"
+	"github.com/perezjoseph/mb8600-watchdog/internal/certs"
+	"github.com/perezjoseph/mb8600-watchdog/internal/chaos"
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
 	"github.com/perezjoseph/mb8600-watchdog/internal/config"
 	"github.com/perezjoseph/mb8600-watchdog/internal/logger"
 	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/plugin"
+	"github.com/perezjoseph/mb8600-watchdog/internal/ratelimit"
+	"github.com/perezjoseph/mb8600-watchdog/internal/sandbox"
+	"github.com/perezjoseph/mb8600-watchdog/internal/systemd"
 	"github.com/sirupsen/logrus"
 )
 
@@ -23,10 +36,32 @@ type App struct {
 	monitorService *monitor.Service
 	shutdownChan   chan struct{}
 	shutdownDone   chan struct{}
+	clock          clock.Clock
+	faults         *chaos.Injector
+	levels         *logger.LevelController
+	// systemd receives readiness and watchdog keep-alives under a
+	// Type=notify unit
+	systemd *systemd.Notifier
+	// exit ends the process after a crash report; os.Exit by default
+	exit func(code int)
+	// plugins are the plugin programs started with the watchdog
+	plugins *plugin.Set
+	// apiKeys are the API keys of the API server, reread on SIGHUP
+	apiKeys *auth.Keys
 }
 
 // NewApp creates a new application instance
 func NewApp(cfg *config.Config) (*App, error) {
+	return NewAppWithOptions(cfg, monitor.Options{})
+}
+
+// NewAppWithOptions creates a new application instance with injected clock,
+// network and modem dependencies
+func NewAppWithOptions(cfg *config.Config, opts monitor.Options) (*App, error) {
+	if opts.Clock == nil {
+		opts.Clock = clock.New()
+	}
+
 	// Set up logger with enhanced configuration
 	loggerConfig := &logger.LoggerConfig{
 		Level:       cfg.LogLevel,
@@ -43,16 +78,53 @@ func NewApp(cfg *config.Config) (*App, error) {
 		return nil, fmt.Errorf("failed to setup logger: %w", err)
 	}
 
+	if cfg.EnableFaultInjection && opts.Faults == nil {
+		log.Warn("Fault injection is enabled; do not use this in production")
+		opts.Faults = chaos.NewInjector(log)
+	}
+
+	// Each module logs through a logger of its own, so its level can differ
+	levels := logger.NewLevelController(log, opts.Clock)
+	moduleLevels, err := logger.ParseModuleLevels(cfg.LogModuleLevels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup logger: %w", err)
+	}
+	if err := levels.SetConfiguredModules(moduleLevels); err != nil {
+		return nil, fmt.Errorf("failed to setup logger: %w", err)
+	}
+	if opts.Loggers == nil {
+		opts.Loggers = levels
+	}
+
+	app := &App{
+		config:       cfg,
+		logger:       levels.Module("app"),
+		shutdownChan: make(chan struct{}, 1), // Buffered to prevent blocking
+		shutdownDone: make(chan struct{}),
+		clock:        opts.Clock,
+		faults:       opts.Faults,
+		levels:       levels,
+		systemd:      &systemd.Notifier{},
+		exit:         os.Exit,
+	}
+	if opts.OnPanic == nil {
+		opts.OnPanic = app.crash
+	}
+
+	// Plugins start first, so a plugin's modem driver is registered before
+	// the service creates its driver
+	if len(cfg.Plugins) > 0 {
+		app.plugins = plugin.Load(context.Background(), cfg.Plugins, levels.Module("plugin"))
+		opts.Checks = append(opts.Checks, app.plugins.Checks()...)
+		opts.PluginNotifiers = append(opts.PluginNotifiers, app.plugins.Notifiers()...)
+		if policy := app.plugins.Policy(); policy != nil && opts.Policy == nil {
+			opts.Policy = policy
+		}
+	}
+
 	// Create monitoring service
-	monitorService := monitor.NewService(cfg, log)
-
-	return &App{
-		config:         cfg,
-		logger:         log,
-		monitorService: monitorService,
-		shutdownChan:   make(chan struct{}, 1), // Buffered to prevent blocking
-		shutdownDone:   make(chan struct{}),
-	}, nil
+	app.monitorService = monitor.NewServiceWithOptions(cfg, levels.Module("monitor"), opts)
+	return app, nil
 }
 
 // Run starts the main application
@@ -94,16 +166,35 @@ func (a *App) Start() error {
 		a.logger.WithError(err).Warn("Failed to load persisted state, starting fresh")
 	}
 
+	// Connect to systemd before the sandbox may forbid it
+	notifier, err := systemd.NewNotifier()
+	if err != nil {
+		a.logger.WithError(err).Warn("Failed to connect to systemd, readiness and watchdog notifications disabled")
+	}
+	a.systemd = notifier
+	defer a.systemd.Close()
+	defer a.closePlugins()
+
+	// Confine the process once every file it needs at startup is open
+	if a.config.Sandbox {
+		if err := a.applySandbox(); err != nil {
+			return fmt.Errorf("failed to apply sandbox: %w", err)
+		}
+	}
+
 	// Log startup with structured metadata
 	startupMetadata := map[string]interface{}{
-		"version":           "go-dev",
+		"version":           buildinfo.Version,
+		"commit":            buildinfo.Commit,
 		"modem_host":        a.config.ModemHost,
 		"log_level":         a.config.LogLevel,
+		"log_module_levels": a.config.LogModuleLevels,
 		"log_format":        a.config.LogFormat,
 		"log_file":          a.config.LogFile,
 		"enable_systemd":    a.config.EnableSystemd,
 		"pid_file":          a.config.PidFile,
 		"working_directory": a.config.WorkingDirectory,
+		"state_directory":   a.config.StateDir(),
 	}
 
 	logger.WithStructuredMetadata(a.logger, startupMetadata).Info("MB8600 Watchdog starting...")
@@ -114,23 +205,36 @@ func (a *App) Start() error {
 
 // runWithGracefulShutdown handles the main application loop with graceful shutdown
 func (a *App) runWithGracefulShutdown() error {
+	defer a.recoverPanic("main")
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR2)
 	defer signal.Stop(sigChan)
 
+	a.startAPIServer(ctx)
+	a.startControlSocket(ctx)
+
 	errChan := make(chan error, 1)
 	go func() {
+		defer a.recoverPanic("monitor")
 		defer close(a.shutdownDone)
 		errChan <- a.monitorService.Start(ctx)
 	}()
 
+	if err := a.systemd.Ready(); err != nil {
+		a.logger.WithError(err).Warn("Failed to report readiness to systemd")
+	}
+	go a.keepSystemdAlive(ctx)
+
 	for {
 		select {
 		case sig := <-sigChan:
-			return a.handleSignal(sig, cancel)
+			if stop, err := a.handleSignal(sig, cancel); stop {
+				return err
+			}
 		case err := <-errChan:
 			if err != nil && err != context.Canceled {
 				a.logger.WithError(err).Error("Monitoring service error")
@@ -140,17 +244,177 @@ func (a *App) runWithGracefulShutdown() error {
 			return nil
 		case <-a.shutdownChan:
 			a.logger.Info("Shutdown requested via API")
+			a.systemd.Stopping()
 			cancel()
 			return a.waitForShutdown()
 		}
 	}
 }
 
-// handleSignal processes incoming signals and initiates graceful shutdown
-func (a *App) handleSignal(sig os.Signal, cancel context.CancelFunc) error {
+// keepSystemdAlive sends watchdog keep-alives at half the WatchdogSec of the
+// unit until ctx ends, as long as the check loop makes progress, so systemd
+// restarts a process whose checks hang rather than only one that is gone
+func (a *App) keepSystemdAlive(ctx context.Context) {
+	defer a.recoverPanic("systemd")
+
+	timeout := a.systemd.WatchdogTimeout()
+	if timeout <= 0 {
+		return
+	}
+	a.logger.WithField("timeout", timeout).Info("Sending systemd watchdog keep-alives")
+
+	ticker := a.clock.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	stalled := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if !a.monitorService.Progressing() {
+				if !stalled {
+					a.logger.Error("Check loop stalled, withholding systemd watchdog keep-alives")
+				}
+				stalled = true
+				continue
+			}
+			stalled = false
+			if err := a.systemd.Watchdog(); err != nil {
+				a.logger.WithError(err).Warn("Failed to send systemd watchdog keep-alive")
+			}
+		}
+	}
+}
+
+// startAPIServer serves the local API in the background when configured;
+// API failures are logged but do not stop monitoring
+func (a *App) startAPIServer(ctx context.Context) {
+	if a.config.APIListenAddress == "" {
+		return
+	}
+
+	server := api.NewServer(a.config.APIListenAddress, a.monitorService, a.faults, a.levels.Module("api"))
+	if a.config.APIUsersFile != "" {
+		users, err := auth.LoadUsers(a.config.APIUsersFile)
+		if err != nil {
+			// Never fall back to an open API when authentication was requested
+			a.logger.WithError(err).Error("Failed to load API users, API server not started")
+			return
+		}
+		server.SetUsers(users)
+	}
+
+	// Keys are reloaded on change, so the file may appear later
+	keys, err := auth.OpenKeys(a.config.APIKeysPath())
+	if err != nil {
+		a.logger.WithError(err).Error("Failed to load API keys, API server not started")
+		return
+	}
+	server.SetAPIKeys(keys)
+	a.apiKeys = keys
+
+	if a.config.APITLS != config.APITLSOff {
+		tlsConfig, err := certs.ServerConfig(a.config.APITLSCert, a.config.APITLSKey,
+			a.config.StatePath("tls"), certs.Hosts(a.config.APIListenAddress))
+		if err != nil {
+			// Never fall back to plain HTTP when TLS was expected
+			a.logger.WithError(err).Error("Failed to set up API certificate, API server not started")
+			return
+		}
+		if len(tlsConfig.Certificates) > 0 {
+			a.logger.WithField("fingerprint", certs.Fingerprint(tlsConfig.Certificates[0])).
+				Info("Serving API with self-signed certificate")
+		}
+		server.SetTLS(tlsConfig)
+	}
+
+	auditLog, err := audit.Open(a.config.AuditLogPath())
+	if err != nil {
+		// Control actions must not go unrecorded
+		a.logger.WithError(err).Error("Failed to open audit log, API server not started")
+		return
+	}
+	server.SetAudit(auditLog)
+	server.SetActionLimit(ratelimit.New(a.clock, a.config.APIActionLimit, a.config.APIActionWindow))
+	server.SetRebooter(a.monitorService)
+	server.SetRefresher(a.monitorService)
+	server.SetPauser(a.monitorService)
+	server.SetLogLevels(a.levels)
+	server.SetMetrics(a.monitorService)
+	server.SetEvents(a.monitorService)
+	server.SetBuildInfo(buildinfo.Get(a.config.FeatureEnabled))
+	if a.config.HomeAssistant() {
+		server.SetIngress(config.HomeAssistantIngressProxy)
+	}
+	go func() {
+		defer a.recoverPanic("api")
+		defer auditLog.Close()
+		if err := server.Start(ctx); err != nil {
+			a.logger.WithError(err).Error("API server stopped")
+		}
+	}()
+}
+
+// startControlSocket serves the API on a Unix socket in the state directory
+// for CLI commands such as log-level; failures are logged but do not stop
+// monitoring
+func (a *App) startControlSocket(ctx context.Context) {
+	if a.config.StateDir() == "" {
+		return
+	}
+	path := a.config.ControlSocketPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		a.logger.WithError(err).Warn("Failed to create control socket directory, CLI control commands unavailable")
+		return
+	}
+	listener, err := api.ListenUnix(path)
+	if err != nil {
+		a.logger.WithError(err).Warn("Failed to open control socket, CLI control commands unavailable")
+		return
+	}
+
+	auditLog, err := audit.Open(a.config.AuditLogPath())
+	if err != nil {
+		// Control actions must not go unrecorded
+		listener.Close()
+		a.logger.WithError(err).Error("Failed to open audit log, control socket not started")
+		return
+	}
+
+	server := api.NewServer(path, a.monitorService, a.faults, a.levels.Module("api"))
+	server.SetLocal()
+	server.SetAudit(auditLog)
+	server.SetLogLevels(a.levels)
+	server.SetPauser(a.monitorService)
+	server.SetEvents(a.monitorService)
+	go func() {
+		defer a.recoverPanic("control_socket")
+		defer auditLog.Close()
+		if err := server.Serve(ctx, listener); err != nil {
+			a.logger.WithError(err).Error("Control socket stopped")
+		}
+	}()
+}
+
+// recordAudit appends a control action and its outcome to the audit log
+func (a *App) recordAudit(entry audit.Entry, err error) {
+	entry.Outcome = audit.OutcomeSucceeded
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailed
+		entry.Error = err.Error()
+	}
+	if err := audit.Append(a.config.AuditLogPath(), entry); err != nil {
+		a.logger.WithError(err).Warn("Failed to write audit entry")
+	}
+}
+
+// handleSignal processes an incoming signal and reports whether the
+// application stops; only shutdown signals stop it
+func (a *App) handleSignal(sig os.Signal, cancel context.CancelFunc) (bool, error) {
 	switch sig {
 	case syscall.SIGINT, syscall.SIGTERM:
 		a.logger.WithField("signal", sig).Info("Received shutdown signal, stopping gracefully...")
+		a.systemd.Stopping()
 
 		// Persist current state before shutdown
 		if err := a.persistState(); err != nil {
@@ -161,15 +425,32 @@ func (a *App) handleSignal(sig os.Signal, cancel context.CancelFunc) error {
 		cancel()
 
 		// Wait for graceful shutdown with timeout
-		return a.waitForShutdown()
+		return true, a.waitForShutdown()
 
 	case syscall.SIGHUP:
+		// A failed reload keeps the previous configuration running
 		a.logger.Info("Received SIGHUP signal, reloading configuration...")
-		return a.reloadConfiguration()
+		err := a.reloadConfiguration()
+		a.recordAudit(audit.Entry{Action: audit.ActionConfigReload, Actor: "SIGHUP", Source: audit.SourceSignal}, err)
+		if a.apiKeys != nil {
+			if err := a.apiKeys.Reload(); err != nil {
+				a.logger.WithError(err).Warn("Failed to reload API keys")
+			}
+		}
+		return false, nil
+
+	case syscall.SIGUSR2:
+		path, err := a.writeDebugDump()
+		if err != nil {
+			a.logger.WithError(err).Error("Failed to write debug dump")
+		} else {
+			a.logger.WithField("file", path).Info("Debug dump written")
+		}
+		return false, nil
 
 	default:
 		a.logger.WithField("signal", sig).Warn("Received unhandled signal")
-		return nil
+		return false, nil
 	}
 }
 
@@ -184,13 +465,34 @@ func (a *App) getShutdownTimeout() time.Duration {
 // waitForShutdown waits for graceful shutdown with timeout
 func (a *App) waitForShutdown() error {
 	shutdownTimeout := a.getShutdownTimeout()
+
+	// Under the wait policy a reboot in progress finishes first, so the
+	// timeout grows by what is left of it
+	reboot := a.monitorService.ActiveReboot()
+	if reboot != nil && a.config.RebootShutdownPolicy != config.RebootShutdownAbort {
+		remaining := reboot.Remaining(a.clock.Now())
+		shutdownTimeout += remaining
+		a.logger.WithFields(logrus.Fields{
+			"phase":     reboot.Phase,
+			"remaining": remaining.Round(time.Second),
+		}).Warn("Reboot in progress, waiting for it to finish before stopping")
+		if err := a.systemd.ExtendTimeout(shutdownTimeout); err != nil {
+			a.logger.WithError(err).Warn("Failed to extend the systemd stop timeout")
+		}
+	}
 	a.logger.WithField("timeout", shutdownTimeout).Debug("Waiting for graceful shutdown")
 
 	select {
 	case <-a.shutdownDone:
 		a.logger.Info("Graceful shutdown completed")
+		if reboot != nil {
+			// The state persisted on the signal predates the reboot
+			if err := a.persistState(); err != nil {
+				a.logger.WithError(err).Warn("Failed to persist state after reboot")
+			}
+		}
 		return nil
-	case <-time.After(shutdownTimeout):
+	case <-a.clock.After(shutdownTimeout):
 		a.logger.Warn("Graceful shutdown timeout exceeded, forcing exit")
 		a.forceCleanup()
 		return fmt.Errorf("shutdown timeout exceeded")
@@ -211,11 +513,11 @@ func (a *App) Shutdown() {
 
 // persistState saves current application state for recovery after restart
 func (a *App) persistState() error {
-	if a.config.WorkingDirectory == "" {
+	if a.config.StateDir() == "" {
 		return nil
 	}
 
-	stateDir := filepath.Join(a.config.WorkingDirectory, "state")
+	stateDir := a.config.StatePath("state")
 	if err := os.MkdirAll(stateDir, 0755); err != nil {
 		return fmt.Errorf("failed to create state directory: %w", err)
 	}
@@ -236,6 +538,45 @@ func (a *App) persistState() error {
 		fmt.Sprintf("total_checks=%d", state.TotalChecks),
 		fmt.Sprintf("total_reboots=%d", state.TotalReboots),
 	}
+	for _, reason := range monitor.RebootReasons {
+		if count := state.RebootsByReason[reason]; count > 0 {
+			stateData = append(stateData, fmt.Sprintf("%s%s=%d", monitor.RebootStatePrefix, reason, count))
+		}
+	}
+	if state.ModemModel != "" {
+		stateData = append(stateData, fmt.Sprintf("modem_model=%s", state.ModemModel))
+	}
+	if state.ModemMode != "" {
+		stateData = append(stateData, fmt.Sprintf("modem_mode=%s", state.ModemMode))
+	}
+	if state.ModemFirmware != "" {
+		stateData = append(stateData, fmt.Sprintf("modem_firmware=%s", state.ModemFirmware))
+	}
+	if state.ModemAccess != "" {
+		stateData = append(stateData, fmt.Sprintf("modem_access=%s", state.ModemAccess))
+	}
+	if state.LowPower {
+		stateData = append(stateData, "low_power=true")
+	}
+	if state.Health != "" {
+		stateData = append(stateData,
+			fmt.Sprintf("health=%s", state.Health),
+			fmt.Sprintf("health_score=%.0f", state.HealthScore))
+	}
+	if state.Diagnostics != nil {
+		summary, err := json.Marshal(state.Diagnostics)
+		if err != nil {
+			return fmt.Errorf("failed to encode diagnostics summary: %w", err)
+		}
+		stateData = append(stateData, fmt.Sprintf("diagnostics=%s", summary))
+	}
+	if state.Tunnel != nil {
+		tunnel, err := json.Marshal(state.Tunnel)
+		if err != nil {
+			return fmt.Errorf("failed to encode tunnel health: %w", err)
+		}
+		stateData = append(stateData, fmt.Sprintf("tunnel=%s", tunnel))
+	}
 
 	for _, line := range stateData {
 		if _, err := fmt.Fprintln(file, line); err != nil {
@@ -250,6 +591,7 @@ func (a *App) persistState() error {
 // needsLoggerReconfiguration checks if logger configuration has changed
 func (a *App) needsLoggerReconfiguration(newConfig *config.Config) bool {
 	return a.config.LogLevel != newConfig.LogLevel ||
+		strings.Join(a.config.LogModuleLevels, ",") != strings.Join(newConfig.LogModuleLevels, ",") ||
 		a.config.LogFormat != newConfig.LogFormat ||
 		a.config.LogFile != newConfig.LogFile
 }
@@ -271,15 +613,22 @@ func (a *App) reconfigureLogger(newConfig *config.Config) error {
 		return fmt.Errorf("failed to reconfigure logger: %w", err)
 	}
 
-	a.logger = newLogger
-	return nil
+	moduleLevels, err := logger.ParseModuleLevels(newConfig.LogModuleLevels)
+	if err != nil {
+		return fmt.Errorf("failed to reconfigure logger: %w", err)
+	}
+
+	// Update the loggers in place, so every module picks up the change
+	a.levels.SetOutput(newLogger.Formatter, newLogger.Out)
+	a.levels.SetConfigured(newLogger.GetLevel())
+	return a.levels.SetConfiguredModules(moduleLevels)
 }
 
 // reloadConfiguration handles SIGHUP signal for configuration reload
 func (a *App) reloadConfiguration() error {
 	a.logger.Info("Reloading configuration...")
 
-	newConfig, err := config.Load()
+	newConfig, err := config.LoadFromFile(a.config.ConfigFile)
 	if err != nil {
 		a.logger.WithError(err).Error("Failed to reload configuration")
 		return err
@@ -369,13 +718,42 @@ func (a *App) changeWorkingDirectory() error {
 	return nil
 }
 
+// applySandbox confines the process to the paths and system calls the
+// configuration needs; landlock is skipped with a warning where unavailable
+func (a *App) applySandbox() error {
+	status, err := sandbox.Apply(sandbox.PolicyFor(a.config))
+	if err != nil {
+		return err
+	}
+
+	a.logger.WithFields(logrus.Fields{
+		"landlock":     status.Landlock,
+		"landlock_abi": status.LandlockABI,
+		"seccomp":      status.Seccomp,
+	}).Info("Sandbox applied")
+	if !status.Landlock {
+		a.logger.WithField("reason", status.LandlockError).Warn("Sandbox running without landlock filesystem restrictions")
+	}
+	return nil
+}
+
+// closePlugins stops the plugin programs
+func (a *App) closePlugins() {
+	if a.plugins == nil {
+		return
+	}
+	if err := a.plugins.Close(); err != nil {
+		a.logger.WithError(err).Warn("Failed to stop plugins")
+	}
+}
+
 // loadPersistedState loads previously saved application state
 func (a *App) loadPersistedState() error {
-	if a.config.WorkingDirectory == "" {
-		return nil // No working directory configured, skip state loading
+	if a.config.StateDir() == "" {
+		return nil // No state directory configured, skip state loading
 	}
 
-	stateFile := filepath.Join(a.config.WorkingDirectory, "state", "watchdog.state")
+	stateFile := a.config.StatePath("state", "watchdog.state")
 	if err := a.monitorService.LoadPersistedState(stateFile); err != nil {
 		return fmt.Errorf("failed to load persisted state: %w", err)
 	}
@@ -3,11 +3,17 @@ package app
 import (
 	"context"
 	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
 	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
 )
 
 // TestApplicationLifecycle tests the complete application lifecycle
@@ -264,3 +270,170 @@ func TestApplicationErrorHandling(t *testing.T) {
 		t.Error("App did not complete within timeout")
 	}
 }
+
+// TestShutdownTimeoutUsesInjectedClock tests the shutdown deadline is measured on the app clock
+func TestShutdownTimeoutUsesInjectedClock(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.LogLevel = "ERROR"
+	cfg.LogFile = ""
+	cfg.WorkingDirectory = t.TempDir()
+	cfg.PidFile = ""
+	cfg.EnableSystemd = false
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	app, err := NewAppWithOptions(cfg, monitor.Options{Clock: fake})
+	if err != nil {
+		t.Fatalf("Failed to create app: %v", err)
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- app.waitForShutdown()
+	}()
+
+	fake.BlockUntil(1)
+	select {
+	case err := <-errChan:
+		t.Fatalf("Shutdown wait returned before the timeout: %v", err)
+	default:
+	}
+
+	fake.Advance(app.getShutdownTimeout())
+	select {
+	case err := <-errChan:
+		if err == nil {
+			t.Error("Expected shutdown timeout error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown wait did not return after advancing the clock")
+	}
+}
+
+func TestDebugDump(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.LogLevel = "ERROR"
+	cfg.LogFile = ""
+	cfg.WorkingDirectory = t.TempDir()
+	cfg.PidFile = ""
+	cfg.ModemPassword = "hunter2"
+
+	app, err := NewAppWithOptions(cfg, monitor.Options{})
+	if err != nil {
+		t.Fatalf("Failed to create app: %v", err)
+	}
+
+	if stop, err := app.handleSignal(syscall.SIGUSR2, func() {}); stop || err != nil {
+		t.Fatalf("Expected SIGUSR2 to keep the application running, got %v %v", stop, err)
+	}
+
+	dumps, err := filepath.Glob(filepath.Join(cfg.WorkingDirectory, "debug", "dump-*.txt"))
+	if err != nil || len(dumps) != 1 {
+		t.Fatalf("Expected one debug dump, got %v %v", dumps, err)
+	}
+	data, err := os.ReadFile(dumps[0])
+	if err != nil {
+		t.Fatalf("Failed to read debug dump: %v", err)
+	}
+	dump := string(data)
+	for _, want := range []string{`"circuit_breakers"`, `"check_running": false`, `"ModemPassword": "REDACTED"`, "goroutine "} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("Expected %q in the debug dump", want)
+		}
+	}
+	if strings.Contains(dump, "hunter2") {
+		t.Error("Expected the modem password to be redacted")
+	}
+}
+
+// recordingNotifier keeps the notifications it receives
+type recordingNotifier struct {
+	received []notify.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	n.received = append(n.received, notification)
+	return nil
+}
+
+func TestPanicWritesCrashReport(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.LogLevel = "ERROR"
+	cfg.LogFile = ""
+	cfg.WorkingDirectory = t.TempDir()
+	cfg.PidFile = ""
+	cfg.ModemPassword = "hunter2"
+	if err := history.Append(cfg.HistoryPath(), history.Event{Time: time.Now(), Kind: history.KindPublicIP}); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &recordingNotifier{}
+	app, err := NewAppWithOptions(cfg, monitor.Options{Notifiers: []notify.Notifier{notifier}})
+	if err != nil {
+		t.Fatalf("Failed to create app: %v", err)
+	}
+	exitCode := -1
+	app.exit = func(code int) { exitCode = code }
+
+	func() {
+		defer app.recoverPanic("test")
+		panic("boom")
+	}()
+
+	if exitCode != CrashExitCode {
+		t.Errorf("Expected exit code %d, got %d", CrashExitCode, exitCode)
+	}
+	reports, err := filepath.Glob(filepath.Join(cfg.WorkingDirectory, "crash", "crash-*.txt"))
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("Expected one crash report, got %v %v", reports, err)
+	}
+	data, err := os.ReadFile(reports[0])
+	if err != nil {
+		t.Fatalf("Failed to read crash report: %v", err)
+	}
+	report := string(data)
+	for _, want := range []string{`"panic": "boom"`, `"goroutine": "test"`, `"kind": "public_ip"`, `"ModemPassword": "REDACTED"`, "TestPanicWritesCrashReport"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected %q in the crash report", want)
+		}
+	}
+	if strings.Contains(report, "hunter2") {
+		t.Error("Expected the modem password to be redacted")
+	}
+
+	if len(notifier.received) != 1 || notifier.received[0].Kind != notify.KindCrashed ||
+		!strings.Contains(notifier.received[0].Body, reports[0]) {
+		t.Errorf("Expected a crash notification naming the report, got %+v", notifier.received)
+	}
+}
+
+func TestReloadSignalKeepsRunning(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("WORKING_DIRECTORY", dir)
+	t.Setenv("LOG_FILE", filepath.Join(dir, "watchdog.log"))
+	t.Setenv("LOG_LEVEL", "ERROR")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.LogLevel = "ERROR"
+	cfg.PidFile = ""
+
+	app, err := NewAppWithOptions(cfg, monitor.Options{})
+	if err != nil {
+		t.Fatalf("Failed to create app: %v", err)
+	}
+
+	if stop, _ := app.handleSignal(syscall.SIGHUP, func() {}); stop {
+		t.Error("Expected SIGHUP to reload without stopping the application")
+	}
+}
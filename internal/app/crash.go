@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/features"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// CrashExitCode is the exit status after a panic, so that systemd and
+// container runtimes restart the service
+const CrashExitCode = 70
+
+const (
+	// crashEvents is the number of recent history events in a crash report
+	crashEvents = 20
+	// crashNotifyTimeout bounds the delivery of the crash notification
+	crashNotifyTimeout = 10 * time.Second
+)
+
+// crashReport is the JSON part of a crash report; the stack of the
+// panicking goroutine and those of every goroutine follow it as text
+type crashReport struct {
+	Time         time.Time         `json:"time"`
+	PID          int               `json:"pid"`
+	GoVersion    string            `json:"go_version"`
+	Goroutine    string            `json:"goroutine"`
+	Panic        string            `json:"panic"`
+	RecentEvents []history.Event   `json:"recent_events,omitempty"`
+	Service      monitor.DebugInfo `json:"service"`
+	Config       config.Config     `json:"config"`
+}
+
+// recoverPanic recovers a panic of the goroutine named where and crashes
+// with a report. It must be deferred at the top of the goroutine.
+func (a *App) recoverPanic(where string) {
+	if value := recover(); value != nil {
+		a.crash(where, value, debug.Stack())
+	}
+}
+
+// crash writes a crash report for a panic of the goroutine named where,
+// sends a crash notification and exits with CrashExitCode
+func (a *App) crash(where string, value interface{}, stack []byte) {
+	a.logger.WithFields(logrus.Fields{
+		"goroutine": where,
+		"panic":     fmt.Sprint(value),
+	}).Error("Watchdog panicked, writing crash report")
+
+	path, err := a.writeCrashReport(where, value, stack)
+	if err != nil {
+		a.logger.WithError(err).Error("Failed to write crash report")
+		os.Stderr.Write(stack)
+	} else {
+		a.logger.WithField("path", path).Error("Crash report written")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), crashNotifyTimeout)
+	a.monitorService.Notifier().Send(ctx, notify.KindCrashed, notify.Data{
+		Time:   a.clock.Now(),
+		Fields: map[string]interface{}{"panic": fmt.Sprint(value), "report": path},
+	})
+	cancel()
+
+	a.exit(CrashExitCode)
+}
+
+// writeCrashReport writes the panic, its stack, the recent history events,
+// the service state and the configuration with credentials redacted to a
+// new file in the crash directory and returns its path
+func (a *App) writeCrashReport(where string, value interface{}, stack []byte) (string, error) {
+	now := a.clock.Now().UTC()
+
+	var events []history.Event
+	if features.History && a.config.FeatureEnabled(features.NameHistory) {
+		var err error
+		events, err = history.Read(a.config.HistoryPath(), time.Time{})
+		if err != nil {
+			a.logger.WithError(err).Warn("Failed to read history for crash report")
+		}
+	}
+	if len(events) > crashEvents {
+		events = events[len(events)-crashEvents:]
+	}
+
+	report := crashReport{
+		Time:         now,
+		PID:          os.Getpid(),
+		GoVersion:    runtime.Version(),
+		Goroutine:    where,
+		Panic:        fmt.Sprint(value),
+		RecentEvents: events,
+		Service:      a.crashDebugInfo(),
+		Config:       a.config.Redacted(),
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode crash report: %w", err)
+	}
+
+	dir := a.config.StatePath("crash")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %w", err)
+	}
+	path := filepath.Join(dir, "crash-"+now.Format("20060102T150405.000Z")+".txt")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create crash report: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "%s\n\npanic: %v\n\n%s\n", data, value, stack); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+	if err := pprof.Lookup("goroutine").WriteTo(file, 2); err != nil {
+		return "", fmt.Errorf("failed to write goroutine stacks: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+	return path, nil
+}
+
+// crashDebugInfo collects the service state, leaving it empty if the
+// service is too broken to report it
+func (a *App) crashDebugInfo() (info monitor.DebugInfo) {
+	defer func() {
+		if value := recover(); value != nil {
+			a.logger.WithField("panic", fmt.Sprint(value)).Warn("Failed to collect service state for crash report")
+		}
+	}()
+	return a.monitorService.DebugInfo()
+}
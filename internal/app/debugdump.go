@@ -0,0 +1,71 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+)
+
+// debugDump is the JSON part of a debug dump; the goroutine stacks follow it
+// as text
+type debugDump struct {
+	Time       time.Time         `json:"time"`
+	PID        int               `json:"pid"`
+	GoVersion  string            `json:"go_version"`
+	Goroutines int               `json:"goroutines"`
+	HeapAlloc  uint64            `json:"heap_alloc_bytes"`
+	Service    monitor.DebugInfo `json:"service"`
+	Config     config.Config     `json:"config"`
+}
+
+// writeDebugDump writes a snapshot of the running service and the stacks of
+// every goroutine to a new file in the debug directory and returns its path.
+// Credentials in the configuration are redacted.
+func (a *App) writeDebugDump() (string, error) {
+	now := a.clock.Now().UTC()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	dump := debugDump{
+		Time:       now,
+		PID:        os.Getpid(),
+		GoVersion:  runtime.Version(),
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  mem.HeapAlloc,
+		Service:    a.monitorService.DebugInfo(),
+		Config:     a.config.Redacted(),
+	}
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode debug dump: %w", err)
+	}
+
+	dir := a.config.StatePath("debug")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create debug directory: %w", err)
+	}
+	path := filepath.Join(dir, "dump-"+now.Format("20060102T150405.000Z")+".txt")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create debug dump: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "%s\n\n", data); err != nil {
+		return "", fmt.Errorf("failed to write debug dump: %w", err)
+	}
+	if err := pprof.Lookup("goroutine").WriteTo(file, 2); err != nil {
+		return "", fmt.Errorf("failed to write goroutine stacks: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write debug dump: %w", err)
+	}
+	return path, nil
+}
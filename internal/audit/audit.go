@@ -0,0 +1,149 @@
+// Package audit records control actions, such as reboots and configuration
+// changes, in an append-only JSON lines log. Each entry names the actor, where
+// the request came from, why it was made and how it ended.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Actions
+const (
+	ActionReboot       = "reboot"
+	ActionFaultsSet    = "faults.set"
+	ActionFaultsClear  = "faults.clear"
+	ActionConfigReload = "config.reload"
+	ActionServiceStop  = "service.stop"
+	ActionKeyCreate    = "apikey.create"
+	ActionKeyRevoke    = "apikey.revoke"
+	ActionLogLevel     = "log.level"
+	ActionPause        = "monitoring.pause"
+	ActionResume       = "monitoring.resume"
+)
+
+// Sources
+const (
+	SourceAPI    = "api"
+	SourceCLI    = "cli"
+	SourceSignal = "signal"
+)
+
+// Outcomes
+const (
+	OutcomeAccepted    = "accepted"
+	OutcomeSucceeded   = "succeeded"
+	OutcomeFailed      = "failed"
+	OutcomeRateLimited = "rate_limited"
+)
+
+// Entry is one audit record
+type Entry struct {
+	Time       time.Time              `json:"time"`
+	Action     string                 `json:"action"`
+	Actor      string                 `json:"actor"`
+	Source     string                 `json:"source"`
+	RemoteAddr string                 `json:"remote_addr,omitempty"`
+	Reason     string                 `json:"reason,omitempty"`
+	Outcome    string                 `json:"outcome"`
+	Error      string                 `json:"error,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+// Log appends entries to the audit file. The file is opened in append mode
+// and never truncated or rotated by the watchdog.
+type Log struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Open opens the audit log at path, creating it and its directory if needed
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Log{file: file}, nil
+}
+
+// Record appends entry, stamping it with the current time when unset.
+// Each entry is written with a single write and synced to disk.
+func (l *Log) Record(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+	if _, err := l.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return l.file.Sync()
+}
+
+// Close closes the audit log
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// Append opens the audit log at path, records entry and closes it again,
+// for one-off writers such as CLI commands
+func Append(path string, entry Entry) error {
+	log, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+	return log.Record(entry)
+}
+
+// Read returns the entries of the audit log at path, oldest first
+func Read(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid audit entry on line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
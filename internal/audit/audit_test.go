@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.log")
+
+	log, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	if err := log.Record(Entry{Action: ActionReboot, Actor: "alice", Source: SourceAPI, RemoteAddr: "10.0.0.2:5000", Reason: "stuck", Outcome: OutcomeAccepted}); err != nil {
+		t.Fatalf("Record() failed: %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if err := log.Record(Entry{Action: ActionReboot}); err == nil {
+		t.Error("Expected Record() on a closed log to fail")
+	}
+
+	// Reopening appends instead of truncating
+	if err := Append(path, Entry{Action: ActionConfigReload, Actor: "root", Source: SourceCLI, Outcome: OutcomeSucceeded}); err != nil {
+		t.Fatalf("Append() failed: %v", err)
+	}
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	first := entries[0]
+	if first.Actor != "alice" || first.RemoteAddr != "10.0.0.2:5000" || first.Reason != "stuck" || first.Time.IsZero() {
+		t.Errorf("Unexpected first entry %+v", first)
+	}
+	if entries[1].Action != ActionConfigReload || entries[1].Source != SourceCLI {
+		t.Errorf("Unexpected second entry %+v", entries[1])
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Read(filepath.Join(dir, "missing.log")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not-exist error, got %v", err)
+	}
+
+	path := filepath.Join(dir, "audit.log")
+	os.WriteFile(path, []byte("{\"action\":\"reboot\"}\nnot json\n"), 0640)
+	if _, err := Read(path); err == nil {
+		t.Error("Expected an error for a corrupt entry")
+	}
+}
@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+// KeyPrefix starts every API key, so leaked keys are easy to recognise
+const KeyPrefix = "mbw_"
+
+// keysCheckInterval is how often requests look for changes of the keys file
+const keysCheckInterval = 5 * time.Second
+
+// APIKey is a stored API key. Only a hash of the secret is kept.
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Hash      string     `json:"hash"`
+	Scopes    []Scope    `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Revoked reports whether the key was revoked
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// keysFile is the JSON document holding the API keys
+type keysFile struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// Keys is the set of API keys stored in a file. The file is reloaded when it
+// changes, so keys created or revoked by the CLI apply to a running service:
+// requests look for a change at most every keysCheckInterval, and Reload
+// picks it up right away.
+type Keys struct {
+	path  string
+	clock clock.Clock
+
+	mu      sync.Mutex
+	keys    []APIKey
+	modTime time.Time
+	size    int64
+	// checked is when the file was last looked at
+	checked time.Time
+}
+
+// OpenKeys reads the API keys file at path; a missing file holds no keys
+func OpenKeys(path string) (*Keys, error) {
+	k := &Keys{path: path, clock: clock.New()}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Path returns the keys file
+func (k *Keys) Path() string {
+	return k.path
+}
+
+// List returns every key, including revoked ones, oldest first
+func (k *Keys) List() []APIKey {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.reloadLocked()
+	return append([]APIKey(nil), k.keys...)
+}
+
+// Active reports whether any key is not revoked
+func (k *Keys) Active() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.refreshLocked()
+	for _, key := range k.keys {
+		if !key.Revoked() {
+			return true
+		}
+	}
+	return false
+}
+
+// Create stores a new key and returns its token. The token is not stored
+// and cannot be shown again.
+func (k *Keys) Create(name string, scopes []Scope, now time.Time) (string, APIKey, error) {
+	if name == "" {
+		return "", APIKey{}, fmt.Errorf("API key needs a name")
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return "", APIKey{}, fmt.Errorf("invalid scope %q", scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return "", APIKey{}, fmt.Errorf("API key needs at least one scope")
+	}
+
+	id, err := randomString(6, hex.EncodeToString)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	token := KeyPrefix + id + "_" + secret
+
+	key := APIKey{
+		ID:        id,
+		Name:      name,
+		Hash:      hashToken(token),
+		Scopes:    scopes,
+		CreatedAt: now.UTC(),
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.reloadLocked(); err != nil {
+		return "", APIKey{}, err
+	}
+	k.keys = append(k.keys, key)
+	if err := k.saveLocked(); err != nil {
+		k.keys = k.keys[:len(k.keys)-1]
+		return "", APIKey{}, err
+	}
+	return token, key, nil
+}
+
+// Revoke marks the key with id as revoked; revoked keys stay listed
+func (k *Keys) Revoke(id string, now time.Time) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.reloadLocked(); err != nil {
+		return err
+	}
+	for i := range k.keys {
+		if k.keys[i].ID != id {
+			continue
+		}
+		if k.keys[i].Revoked() {
+			return fmt.Errorf("API key %s is already revoked", id)
+		}
+		revokedAt := now.UTC()
+		k.keys[i].RevokedAt = &revokedAt
+		return k.saveLocked()
+	}
+	return fmt.Errorf("API key %s not found", id)
+}
+
+// Authenticate returns the principal of token if it is a valid, unrevoked key
+func (k *Keys) Authenticate(token string) (Principal, bool) {
+	id, ok := tokenID(token)
+	if !ok {
+		return Principal{}, false
+	}
+	hash := hashToken(token)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.refreshLocked()
+	for _, key := range k.keys {
+		if key.ID != id || key.Revoked() {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hash)) == 1 {
+			return Principal{Name: "key:" + key.Name, Scopes: key.Scopes}, true
+		}
+	}
+	return Principal{}, false
+}
+
+// Reload rereads the keys file if it changed, such as on SIGHUP
+func (k *Keys) Reload() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.reloadLocked()
+}
+
+// refreshLocked reloads the keys file unless it was looked at within
+// keysCheckInterval, so requests do not each go to the disk. Callers hold
+// k.mu.
+func (k *Keys) refreshLocked() {
+	if !k.checked.IsZero() && k.clock.Since(k.checked) < keysCheckInterval {
+		return
+	}
+	k.reloadLocked()
+}
+
+// reloadLocked rereads the keys file if it changed since the last read. When
+// the file cannot be read, the keys read before stay in effect. Callers hold
+// k.mu.
+func (k *Keys) reloadLocked() error {
+	k.checked = k.clock.Now()
+	info, err := os.Stat(k.path)
+	if errors.Is(err, os.ErrNotExist) {
+		k.keys = nil
+		k.modTime = time.Time{}
+		k.size = 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read API keys file: %w", err)
+	}
+	if info.ModTime().Equal(k.modTime) && info.Size() == k.size && k.keys != nil {
+		return nil
+	}
+
+	content, err := os.ReadFile(k.path)
+	if err != nil {
+		return fmt.Errorf("failed to read API keys file: %w", err)
+	}
+	var doc keysFile
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("failed to parse API keys file %s: %w", k.path, err)
+	}
+	if doc.Keys == nil {
+		doc.Keys = []APIKey{}
+	}
+	k.keys = doc.Keys
+	k.modTime = info.ModTime()
+	k.size = info.Size()
+	return nil
+}
+
+// saveLocked writes the keys file atomically; callers hold k.mu
+func (k *Keys) saveLocked() error {
+	content, err := json.MarshalIndent(keysFile{Keys: k.keys}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode API keys: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(k.path), 0755); err != nil {
+		return fmt.Errorf("failed to create API keys directory: %w", err)
+	}
+	tmp := k.path + ".tmp"
+	if err := os.WriteFile(tmp, append(content, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write API keys file: %w", err)
+	}
+	if err := os.Rename(tmp, k.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace API keys file: %w", err)
+	}
+
+	if info, err := os.Stat(k.path); err == nil {
+		k.modTime = info.ModTime()
+		k.size = info.Size()
+	}
+	return nil
+}
+
+// tokenID extracts the key ID from a token of the form mbw_<id>_<secret>
+func tokenID(token string) (string, bool) {
+	if !strings.HasPrefix(token, KeyPrefix) {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(strings.TrimPrefix(token, KeyPrefix), "_")
+	if !ok || id == "" || secret == "" {
+		return "", false
+	}
+	return id, true
+}
+
+// hashToken hashes a token for storage. Tokens carry 256 random bits, so a
+// plain SHA-256 is enough; unlike passwords they cannot be guessed.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString encodes n random bytes with encode
+func randomString(n int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return encode(buf), nil
+}
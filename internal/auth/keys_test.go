@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+func TestKeysCreateAuthenticateRevoke(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "api-keys.json")
+	keys, err := OpenKeys(path)
+	if err != nil {
+		t.Fatalf("OpenKeys() failed: %v", err)
+	}
+	if keys.Active() || len(keys.List()) != 0 {
+		t.Fatal("A missing keys file should hold no keys")
+	}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	token, key, err := keys.Create("home-assistant", []Scope{ScopeRead, ScopeReboot}, now)
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if !strings.HasPrefix(token, KeyPrefix+key.ID+"_") {
+		t.Errorf("Unexpected token format %q", token)
+	}
+	if strings.Contains(key.Hash, token) || key.Hash == "" {
+		t.Error("The token must only be stored hashed")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Keys file was not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected keys file mode 0600, got %v", info.Mode().Perm())
+	}
+	content, _ := os.ReadFile(path)
+	if strings.Contains(string(content), token) {
+		t.Error("The keys file must not contain the token")
+	}
+
+	principal, ok := keys.Authenticate(token)
+	if !ok {
+		t.Fatal("Expected the new token to authenticate")
+	}
+	if principal.Name != "key:home-assistant" || !principal.Allows(ScopeReboot) || principal.Allows(ScopeAdmin) {
+		t.Errorf("Unexpected principal %+v", principal)
+	}
+
+	if err := keys.Revoke(key.ID, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	if _, ok := keys.Authenticate(token); ok {
+		t.Error("A revoked key must not authenticate")
+	}
+	if err := keys.Revoke(key.ID, now); err == nil {
+		t.Error("Expected an error revoking a key twice")
+	}
+	if err := keys.Revoke("missing", now); err == nil {
+		t.Error("Expected an error revoking an unknown key")
+	}
+
+	list := keys.List()
+	if len(list) != 1 || !list[0].Revoked() || !list[0].RevokedAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Revoked keys should stay listed, got %+v", list)
+	}
+	if keys.Active() {
+		t.Error("Expected no active keys")
+	}
+}
+
+func TestKeysCreateValidation(t *testing.T) {
+	keys, err := OpenKeys(filepath.Join(t.TempDir(), "api-keys.json"))
+	if err != nil {
+		t.Fatalf("OpenKeys() failed: %v", err)
+	}
+
+	if _, _, err := keys.Create("", []Scope{ScopeRead}, time.Now()); err == nil {
+		t.Error("Expected an error for a key without a name")
+	}
+	if _, _, err := keys.Create("grafana", nil, time.Now()); err == nil {
+		t.Error("Expected an error for a key without scopes")
+	}
+	if _, _, err := keys.Create("grafana", []Scope{"root"}, time.Now()); err == nil {
+		t.Error("Expected an error for an unknown scope")
+	}
+}
+
+func TestKeysReloadChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	fake := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	service := &Keys{path: path, clock: fake}
+	if err := service.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+
+	// The CLI opens the same file separately, as a running service would see it
+	cli, _ := OpenKeys(path)
+	token, key, err := cli.Create("grafana", []Scope{ScopeRead}, time.Now())
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	fake.Advance(keysCheckInterval)
+	if _, ok := service.Authenticate(token); !ok {
+		t.Fatal("Expected a key created elsewhere to be picked up")
+	}
+
+	// Make sure the revocation gets another modification time
+	past := time.Now().Add(-time.Minute)
+	os.Chtimes(path, past, past)
+	if err := cli.Revoke(key.ID, time.Now()); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	if _, ok := service.Authenticate(token); !ok {
+		t.Error("Expected requests within the check interval not to reread the file")
+	}
+	if err := service.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if _, ok := service.Authenticate(token); ok {
+		t.Error("Expected a key revoked elsewhere to stop authenticating after Reload")
+	}
+}
+
+func TestKeysRejectMalformedTokens(t *testing.T) {
+	keys, _ := OpenKeys(filepath.Join(t.TempDir(), "api-keys.json"))
+	token, key, err := keys.Create("grafana", []Scope{ScopeRead}, time.Now())
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	for _, bad := range []string{
+		"",
+		"mbw_",
+		strings.TrimPrefix(token, KeyPrefix),
+		KeyPrefix + key.ID + "_",
+		KeyPrefix + key.ID + "_wrong-secret",
+		token + "x",
+	} {
+		if _, ok := keys.Authenticate(bad); ok {
+			t.Errorf("Token %q should not authenticate", bad)
+		}
+	}
+}
+
+func TestOpenKeysInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	os.WriteFile(path, []byte("not json"), 0600)
+	if _, err := OpenKeys(path); err == nil {
+		t.Error("Expected an error for an invalid keys file")
+	}
+}
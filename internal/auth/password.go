@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashes are bcrypt hashes, such as "$2a$10$...", so the cost
+// travels with each hash and can be raised for new ones
+const (
+	hashCost          = bcrypt.DefaultCost
+	minPasswordLength = 8
+	// maxPasswordLength is the most bcrypt uses of a password
+	maxPasswordLength = 72
+)
+
+var (
+	// ErrWeakPassword is returned for passwords shorter than eight characters
+	ErrWeakPassword = errors.New("password must be at least 8 characters")
+	// ErrLongPassword is returned for passwords bcrypt would cut short
+	ErrLongPassword = fmt.Errorf("password must be at most %d bytes", maxPasswordLength)
+)
+
+// HashPassword returns a salted hash of password for the users file
+func HashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", ErrWeakPassword
+	}
+	if len(password) > maxPasswordLength {
+		return "", ErrLongPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), hashCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches hash
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// ValidateHash checks that hash was produced by HashPassword
+func ValidateHash(hash string) error {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return fmt.Errorf("password hash must be a bcrypt hash, such as one printed by hash-password: %w", err)
+	}
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("invalid cost %d in password hash", cost)
+	}
+	return nil
+}
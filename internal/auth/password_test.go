@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword() failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$2a$") {
+		t.Errorf("Unexpected hash format %q", hash)
+	}
+	if err := ValidateHash(hash); err != nil {
+		t.Errorf("ValidateHash() failed: %v", err)
+	}
+
+	if !CheckPassword(hash, "correct horse") {
+		t.Error("Expected the password to match its hash")
+	}
+	if CheckPassword(hash, "wrong horse") {
+		t.Error("Expected a different password not to match")
+	}
+
+	again, _ := HashPassword("correct horse")
+	if again == hash {
+		t.Error("Expected hashes of the same password to use different salts")
+	}
+
+	if _, err := HashPassword("short"); err != ErrWeakPassword {
+		t.Errorf("Expected ErrWeakPassword, got %v", err)
+	}
+	if _, err := HashPassword(strings.Repeat("x", maxPasswordLength+1)); err != ErrLongPassword {
+		t.Errorf("Expected ErrLongPassword, got %v", err)
+	}
+}
+
+func TestInvalidHashes(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"plaintext",
+		"bcrypt$10$abc$def",
+		"pbkdf2-sha256$210000$c2FsdA$a2V5",
+		"$2a$10$tooshort",
+		"$2a$99$abcdefghijklmnopqrstuuabcdefghijklmnopqrstuvwxyz01234",
+	} {
+		if err := ValidateHash(hash); err == nil {
+			t.Errorf("Expected %q to be rejected", hash)
+		}
+		if CheckPassword(hash, "anything") {
+			t.Errorf("Expected no password to match %q", hash)
+		}
+	}
+}
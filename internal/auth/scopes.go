@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope is a permission checked by the API
+type Scope string
+
+// Scopes, as granted to API keys
+const (
+	// ScopeRead allows reading status, statistics and reports
+	ScopeRead Scope = "read"
+	// ScopeReboot allows triggering modem reboots
+	ScopeReboot Scope = "reboot"
+	// ScopeAdmin allows everything, including changing settings
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes lists every scope
+var Scopes = []Scope{ScopeRead, ScopeReboot, ScopeAdmin}
+
+// Valid reports whether s is a known scope
+func (s Scope) Valid() bool {
+	for _, scope := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes parses scope names, accepting a comma separated list per value
+func ParseScopes(values []string) ([]Scope, error) {
+	var scopes []Scope
+	seen := make(map[Scope]bool)
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			scope := Scope(strings.TrimSpace(name))
+			if scope == "" {
+				continue
+			}
+			if !scope.Valid() {
+				return nil, fmt.Errorf("invalid scope %q (expected read, reboot or admin)", scope)
+			}
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	return scopes, nil
+}
+
+// Scopes returns the scopes a role grants
+func (r Role) Scopes() []Scope {
+	switch r {
+	case RoleOperator:
+		return []Scope{ScopeAdmin}
+	case RoleViewer:
+		return []Scope{ScopeRead}
+	default:
+		return nil
+	}
+}
+
+// Principal is an authenticated user or API key
+type Principal struct {
+	Name   string
+	Scopes []Scope
+}
+
+// Allows reports whether the principal holds required; admin implies every scope
+func (p Principal) Allows(required Scope) bool {
+	for _, scope := range p.Scopes {
+		if scope == required || scope == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
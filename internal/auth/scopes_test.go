@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		values  []string
+		want    []Scope
+		wantErr bool
+	}{
+		{[]string{"read"}, []Scope{ScopeRead}, false},
+		{[]string{"read,reboot"}, []Scope{ScopeRead, ScopeReboot}, false},
+		{[]string{"read", " reboot ", "read"}, []Scope{ScopeRead, ScopeReboot}, false},
+		{[]string{"admin"}, []Scope{ScopeAdmin}, false},
+		{[]string{"read,root"}, nil, true},
+		{[]string{""}, nil, true},
+		{nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseScopes(tt.values)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseScopes(%q) error = %v, wantErr %v", tt.values, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseScopes(%q) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestPrincipalAllows(t *testing.T) {
+	reader := Principal{Scopes: []Scope{ScopeRead}}
+	if !reader.Allows(ScopeRead) || reader.Allows(ScopeReboot) || reader.Allows(ScopeAdmin) {
+		t.Error("A read key should only read")
+	}
+
+	rebooter := Principal{Scopes: []Scope{ScopeReboot}}
+	if rebooter.Allows(ScopeRead) || !rebooter.Allows(ScopeReboot) {
+		t.Error("Scopes other than admin should not imply each other")
+	}
+
+	admin := Principal{Scopes: []Scope{ScopeAdmin}}
+	for _, scope := range Scopes {
+		if !admin.Allows(scope) {
+			t.Errorf("Admin should imply %s", scope)
+		}
+	}
+
+	if (Principal{}).Allows(ScopeRead) {
+		t.Error("A principal without scopes should allow nothing")
+	}
+}
+
+func TestRoleScopes(t *testing.T) {
+	if got := RoleViewer.Scopes(); !reflect.DeepEqual(got, []Scope{ScopeRead}) {
+		t.Errorf("Viewer scopes = %v", got)
+	}
+	if got := RoleOperator.Scopes(); !reflect.DeepEqual(got, []Scope{ScopeAdmin}) {
+		t.Errorf("Operator scopes = %v", got)
+	}
+	if got := Role("guest").Scopes(); got != nil {
+		t.Errorf("Unknown role scopes = %v", got)
+	}
+}
@@ -0,0 +1,227 @@
+// Package auth authenticates users of the local API and decides what their
+// role allows. Users are kept in a JSON file with bcrypt password hashes.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+// A verified password is accepted again for verifiedTTL without hashing it,
+// so dashboards and peers polling with basic auth do not pay for bcrypt on
+// every request. At most maxVerified logins are remembered.
+const (
+	verifiedTTL = time.Minute
+	maxVerified = 256
+)
+
+// Role decides which API operations a user may perform
+type Role string
+
+// Roles, from least to most privileged
+const (
+	// RoleViewer can read status, statistics and reports
+	RoleViewer Role = "viewer"
+	// RoleOperator can also trigger reboots, inject faults and change settings
+	RoleOperator Role = "operator"
+)
+
+// roleRank orders roles by privilege
+var roleRank = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// Allows reports whether r grants the privileges of required
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleRank[r] >= roleRank[required]
+}
+
+// User is an API user
+type User struct {
+	Name         string `json:"name"`
+	PasswordHash string `json:"password_hash"`
+	Role         Role   `json:"role"`
+}
+
+// usersFile is the JSON document holding the users
+type usersFile struct {
+	Users []User `json:"users"`
+}
+
+// Users is the set of API users
+type Users struct {
+	mu    sync.RWMutex
+	users map[string]User
+	clock clock.Clock
+
+	// verified maps keyed digests of recently verified credentials, never
+	// the passwords themselves, to the hash they matched and their expiry
+	verifiedMu  sync.Mutex
+	verifiedKey []byte
+	verified    map[string]verifiedLogin
+}
+
+// verifiedLogin is a remembered successful password check
+type verifiedLogin struct {
+	hash    string
+	expires time.Time
+}
+
+// NewUsers creates a user set from users
+func NewUsers(users ...User) (*Users, error) {
+	u := &Users{
+		users:       make(map[string]User),
+		clock:       clock.New(),
+		verifiedKey: make([]byte, 32),
+		verified:    make(map[string]verifiedLogin),
+	}
+	if _, err := rand.Read(u.verifiedKey); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	for _, user := range users {
+		if err := u.add(user); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// LoadUsers reads the users file at path
+func LoadUsers(path string) (*Users, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users file: %w", err)
+	}
+
+	var doc usersFile
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse users file %s: %w", path, err)
+	}
+	if len(doc.Users) == 0 {
+		return nil, fmt.Errorf("users file %s defines no users", path)
+	}
+
+	users, err := NewUsers(doc.Users...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid users file %s: %w", path, err)
+	}
+	return users, nil
+}
+
+// add validates and registers a user
+func (u *Users) add(user User) error {
+	if user.Name == "" {
+		return fmt.Errorf("user without a name")
+	}
+	if _, exists := u.users[user.Name]; exists {
+		return fmt.Errorf("user %q is defined twice", user.Name)
+	}
+	if !user.Role.Valid() {
+		return fmt.Errorf("user %q has invalid role %q (expected %s or %s)", user.Name, user.Role, RoleViewer, RoleOperator)
+	}
+	if err := ValidateHash(user.PasswordHash); err != nil {
+		return fmt.Errorf("user %q: %w", user.Name, err)
+	}
+	u.users[user.Name] = user
+	return nil
+}
+
+// Authenticate returns the user matching name and password
+func (u *Users) Authenticate(name, password string) (User, bool) {
+	u.mu.RLock()
+	user, ok := u.users[name]
+	u.mu.RUnlock()
+
+	if !ok {
+		// Spend the same time on unknown users so names cannot be probed
+		CheckPassword(dummyHash(), password)
+		return User{}, false
+	}
+	digest := u.digest(name, password)
+	if u.recentlyVerified(digest, user.PasswordHash) {
+		return user, true
+	}
+	if !CheckPassword(user.PasswordHash, password) {
+		return User{}, false
+	}
+	u.remember(digest, user.PasswordHash)
+	return user, true
+}
+
+// digest identifies a name and password with a key of this process
+func (u *Users) digest(name, password string) string {
+	mac := hmac.New(sha256.New, u.verifiedKey)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// recentlyVerified reports whether digest matched hash within verifiedTTL
+func (u *Users) recentlyVerified(digest, hash string) bool {
+	u.verifiedMu.Lock()
+	defer u.verifiedMu.Unlock()
+
+	login, ok := u.verified[digest]
+	return ok && login.hash == hash && u.clock.Now().Before(login.expires)
+}
+
+// remember records that digest matched hash, dropping expired logins first
+func (u *Users) remember(digest, hash string) {
+	u.verifiedMu.Lock()
+	defer u.verifiedMu.Unlock()
+
+	now := u.clock.Now()
+	for other, login := range u.verified {
+		if !now.Before(login.expires) {
+			delete(u.verified, other)
+		}
+	}
+	if len(u.verified) >= maxVerified {
+		u.verified = make(map[string]verifiedLogin)
+	}
+	u.verified[digest] = verifiedLogin{hash: hash, expires: now.Add(verifiedTTL)}
+}
+
+// Names returns the user names in order
+func (u *Users) Names() []string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	names := make([]string, 0, len(u.users))
+	for name := range u.users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	dummyOnce sync.Once
+	dummy     string
+)
+
+// dummyHash returns a hash checked for unknown users; it matches no password
+func dummyHash() string {
+	dummyOnce.Do(func() {
+		dummy, _ = HashPassword("unknown-user-placeholder")
+	})
+	return dummy
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+func mustHash(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := HashPassword(password)
+	if err != nil {
+		t.Fatalf("HashPassword() failed: %v", err)
+	}
+	return hash
+}
+
+func TestRoles(t *testing.T) {
+	if !RoleOperator.Allows(RoleViewer) || !RoleOperator.Allows(RoleOperator) {
+		t.Error("Operators should hold every role")
+	}
+	if !RoleViewer.Allows(RoleViewer) || RoleViewer.Allows(RoleOperator) {
+		t.Error("Viewers should only hold the viewer role")
+	}
+	if Role("admin").Allows(RoleViewer) {
+		t.Error("Unknown roles should allow nothing")
+	}
+}
+
+func TestLoadUsers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	content := `{"users": [
+		{"name": "alice", "password_hash": "` + mustHash(t, "alice-password") + `", "role": "operator"},
+		{"name": "bob", "password_hash": "` + mustHash(t, "bob-password") + `", "role": "viewer"}
+	]}`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write users file: %v", err)
+	}
+
+	users, err := LoadUsers(path)
+	if err != nil {
+		t.Fatalf("LoadUsers() failed: %v", err)
+	}
+	if names := users.Names(); len(names) != 2 || names[0] != "alice" || names[1] != "bob" {
+		t.Errorf("Unexpected users %v", names)
+	}
+
+	user, ok := users.Authenticate("bob", "bob-password")
+	if !ok || user.Role != RoleViewer {
+		t.Errorf("Expected bob to authenticate as viewer, got %+v, %v", user, ok)
+	}
+	if _, ok := users.Authenticate("bob", "alice-password"); ok {
+		t.Error("Expected a wrong password to fail")
+	}
+	if _, ok := users.Authenticate("carol", "alice-password"); ok {
+		t.Error("Expected an unknown user to fail")
+	}
+}
+
+func TestLoadUsersErrors(t *testing.T) {
+	dir := t.TempDir()
+	hash := mustHash(t, "password123")
+
+	if _, err := LoadUsers(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+
+	for name, content := range map[string]string{
+		"invalid_json": `{"users": [`,
+		"empty":        `{"users": []}`,
+		"no_name":      `{"users": [{"password_hash": "` + hash + `", "role": "viewer"}]}`,
+		"bad_role":     `{"users": [{"name": "a", "password_hash": "` + hash + `", "role": "admin"}]}`,
+		"plain":        `{"users": [{"name": "a", "password_hash": "secret", "role": "viewer"}]}`,
+		"duplicate": `{"users": [{"name": "a", "password_hash": "` + hash + `", "role": "viewer"},
+			{"name": "a", "password_hash": "` + hash + `", "role": "operator"}]}`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write users file: %v", err)
+		}
+		if _, err := LoadUsers(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestVerifiedPasswordsRemembered(t *testing.T) {
+	users, err := NewUsers(User{Name: "alice", PasswordHash: mustHash(t, "alice-password"), Role: RoleViewer})
+	if err != nil {
+		t.Fatalf("NewUsers() failed: %v", err)
+	}
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	users.clock = fake
+
+	if _, ok := users.Authenticate("alice", "wrong-password"); ok {
+		t.Fatal("Expected a wrong password to fail")
+	}
+	if len(users.verified) != 0 {
+		t.Fatalf("Expected failed logins not to be remembered, got %d", len(users.verified))
+	}
+
+	if _, ok := users.Authenticate("alice", "alice-password"); !ok {
+		t.Fatal("Expected the password to authenticate")
+	}
+	digest := users.digest("alice", "alice-password")
+	if !users.recentlyVerified(digest, users.users["alice"].PasswordHash) {
+		t.Error("Expected the verified login to be remembered")
+	}
+	if users.recentlyVerified(users.digest("alice", "wrong-password"), users.users["alice"].PasswordHash) {
+		t.Error("Expected another password not to count as verified")
+	}
+	if users.recentlyVerified(digest, mustHash(t, "new-password")) {
+		t.Error("Expected a changed hash to need a new check")
+	}
+
+	fake.Advance(verifiedTTL)
+	if users.recentlyVerified(digest, users.users["alice"].PasswordHash) {
+		t.Error("Expected the verified login to expire")
+	}
+	if _, ok := users.Authenticate("alice", "alice-password"); !ok {
+		t.Error("Expected the password to authenticate after the cache expired")
+	}
+}
@@ -0,0 +1,291 @@
+// Package backup writes the state directory and configuration file of the
+// watchdog to a single gzipped tar archive, and restores them from one, so
+// the outage history survives a corrupted SD card.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FormatVersion is the version of the archive layout
+const FormatVersion = 1
+
+// Archive layout: the manifest, the state directory under stateDir and the
+// configuration file under configDir
+const (
+	manifestName = "manifest.json"
+	stateDir     = "state"
+	configDir    = "config"
+)
+
+// StateEntries are the files and directories of the state directory a
+// backup holds; everything else there, such as debug dumps, is left out
+var StateEntries = []string{"state", "logs", "crash", "tls", "public-ip.json", "api-keys.json"}
+
+// Sources are the files a backup is made of, and restored to
+type Sources struct {
+	// StateDir is the state directory
+	StateDir string
+	// ConfigFile is the configuration file, empty when there is none
+	ConfigFile string
+	// Exclude are files left out together with their rotations, such as
+	// the log file
+	Exclude []string
+}
+
+// Manifest describes a backup
+type Manifest struct {
+	Version  int       `json:"version"`
+	Created  time.Time `json:"created"`
+	Hostname string    `json:"hostname,omitempty"`
+	// Files are the files of the state directory, relative to it
+	Files []string `json:"files"`
+	// ConfigFile is the name of the configuration file, if there was one
+	ConfigFile string `json:"config_file,omitempty"`
+}
+
+// FileName returns the name of a backup created at t
+func FileName(t time.Time) string {
+	return "watchdog-backup-" + t.UTC().Format("20060102T150405Z") + ".tar.gz"
+}
+
+// excluded reports whether file is one of exclude or a rotation of one:
+// a file in the same directory whose name starts with its name without the
+// extension
+func excluded(file string, exclude []string) bool {
+	for _, skip := range exclude {
+		if skip == "" || filepath.Dir(file) != filepath.Dir(skip) {
+			continue
+		}
+		base := filepath.Base(skip)
+		if strings.HasPrefix(filepath.Base(file), strings.TrimSuffix(base, filepath.Ext(base))) {
+			return true
+		}
+	}
+	return false
+}
+
+// stateFiles returns the regular files of src's state directory a backup
+// holds, relative to it and sorted. Hidden files are temporary and left out.
+func stateFiles(src Sources) ([]string, error) {
+	exclude := make([]string, len(src.Exclude))
+	for i, file := range src.Exclude {
+		exclude[i], _ = filepath.Abs(file)
+	}
+	root, err := filepath.Abs(src.StateDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range StateEntries {
+		err := filepath.WalkDir(filepath.Join(root, entry), func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if strings.HasPrefix(d.Name(), ".") || !d.Type().IsRegular() || excluded(file, exclude) {
+				return nil
+			}
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", entry, err)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Create writes a backup of src to w and returns its manifest
+func Create(w io.Writer, src Sources, now time.Time) (*Manifest, error) {
+	files, err := stateFiles(src)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	manifest := &Manifest{Version: FormatVersion, Created: now, Hostname: hostname, Files: files}
+	if src.ConfigFile != "" {
+		manifest.ConfigFile = filepath.Base(src.ConfigFile)
+	}
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	header := &tar.Header{Name: manifestName, Mode: 0644, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg}
+	if err := archive.WriteHeader(header); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if _, err := archive.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	for _, file := range files {
+		if err := addFile(archive, filepath.Join(src.StateDir, filepath.FromSlash(file)), path.Join(stateDir, file)); err != nil {
+			return nil, err
+		}
+	}
+	if src.ConfigFile != "" {
+		if err := addFile(archive, src.ConfigFile, path.Join(configDir, manifest.ConfigFile)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	return manifest, nil
+}
+
+// addFile writes the file at file to archive as name
+func addFile(archive *tar.Writer, file, name string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", file, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", file, err)
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", file, err)
+	}
+	header.Name = name
+	if err := archive.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	// A file still growing is cut at the size in its header
+	if _, err := io.CopyN(archive, f, header.Size); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", file, err)
+	}
+	return nil
+}
+
+// Restore extracts the backup in r to src: the state files to its state
+// directory, replacing those there, and the configuration file to
+// src.ConfigFile, or nowhere when that is empty. Files of the state
+// directory the backup does not hold are left alone.
+func Restore(r io.Reader, src Sources) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup: %w", err)
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+
+	var manifest *Manifest
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+
+		if manifest == nil {
+			if header.Name != manifestName {
+				return nil, fmt.Errorf("not a backup: %s does not start with a manifest", header.Name)
+			}
+			manifest = &Manifest{}
+			if err := json.NewDecoder(archive).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid backup manifest: %w", err)
+			}
+			if manifest.Version > FormatVersion {
+				return nil, fmt.Errorf("backup format %d is newer than this version supports (%d)", manifest.Version, FormatVersion)
+			}
+			continue
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		target, err := restorePath(header.Name, src)
+		if err != nil {
+			return nil, err
+		}
+		if target == "" {
+			continue
+		}
+		if err := writeFile(target, archive, fs.FileMode(header.Mode).Perm()); err != nil {
+			return nil, err
+		}
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("not a backup: the archive is empty")
+	}
+	return manifest, nil
+}
+
+// restorePath returns where the archive entry name is restored to, empty
+// for entries not restored
+func restorePath(name string, src Sources) (string, error) {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("invalid path %s in backup", name)
+	}
+	dir, rest, _ := strings.Cut(clean, "/")
+	switch {
+	case rest == "":
+		return "", nil
+	case dir == stateDir:
+		return filepath.Join(src.StateDir, filepath.FromSlash(rest)), nil
+	case dir == configDir && src.ConfigFile != "":
+		return src.ConfigFile, nil
+	}
+	return "", nil
+}
+
+// writeFile replaces the file at target with the contents of r, through a
+// temporary file so it is never left half written
+func writeFile(target string, r io.Reader, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", target, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", target, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to restore %s: %w", target, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", target, err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", target, err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", target, err)
+	}
+	return nil
+}
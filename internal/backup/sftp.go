@@ -0,0 +1,126 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// sftpTarget uploads files with the sftp command in batch mode, so keys,
+// agents and known hosts work as they do for ssh
+type sftpTarget struct {
+	// sftp is the sftp binary, replaced in tests
+	sftp       string
+	host, port string
+	user       string
+	dir        string
+	key        string
+	knownHosts string
+}
+
+// newSFTP returns the SFTP destination at an sftp://user@host:port/path URL
+func newSFTP(destination, key, knownHosts string) (*sftpTarget, error) {
+	target, err := url.Parse(destination)
+	if err != nil || target.Hostname() == "" {
+		return nil, fmt.Errorf("invalid SFTP destination, expected sftp://user@host/path")
+	}
+	if _, ok := target.User.Password(); ok {
+		return nil, fmt.Errorf("SFTP destinations take no password, use a key")
+	}
+	return &sftpTarget{
+		sftp:       "sftp",
+		host:       target.Hostname(),
+		port:       target.Port(),
+		user:       target.User.Username(),
+		dir:        strings.TrimSuffix(target.Path, "/"),
+		key:        key,
+		knownHosts: knownHosts,
+	}, nil
+}
+
+func (s *sftpTarget) String() string {
+	target := url.URL{Scheme: "sftp", Host: s.host, Path: s.dir}
+	if s.port != "" {
+		target.Host += ":" + s.port
+	}
+	if s.user != "" {
+		target.User = url.User(s.user)
+	}
+	return target.String()
+}
+
+// args returns the arguments of sftp
+func (s *sftpTarget) args() []string {
+	args := []string{"-b", "-", "-o", "BatchMode=yes", "-o", "ConnectTimeout=30"}
+	if s.port != "" {
+		args = append(args, "-P", s.port)
+	}
+	if s.key != "" {
+		args = append(args, "-i", s.key, "-o", "IdentitiesOnly=yes")
+	}
+	if s.knownHosts != "" {
+		args = append(args, "-o", "UserKnownHostsFile="+s.knownHosts)
+	}
+	destination := s.host
+	if s.user != "" {
+		destination = s.user + "@" + s.host
+	}
+	return append(args, "--", destination)
+}
+
+// Store uploads the file under a temporary name and renames it, so a
+// reader never sees it half written. sftp needs a local file, so r is
+// copied to one unless it is one.
+func (s *sftpTarget) Store(ctx context.Context, name string, r io.ReadSeeker, size int64) error {
+	local, ok := r.(*os.File)
+	if !ok {
+		tmp, err := os.CreateTemp("", "watchdog-upload-*")
+		if err != nil {
+			return fmt.Errorf("failed to prepare upload: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if _, err := io.Copy(tmp, r); err != nil {
+			return fmt.Errorf("failed to prepare upload: %w", err)
+		}
+		local = tmp
+	}
+
+	remote := path.Join(s.dir, name)
+	var batch strings.Builder
+	// A leading - lets the batch go on when the directory exists or the
+	// file does not
+	dir := ""
+	for _, part := range strings.Split(path.Dir(remote), "/") {
+		if part == "" {
+			dir = "/"
+			continue
+		}
+		dir = path.Join(dir, part)
+		fmt.Fprintf(&batch, "-mkdir %s\n", quoteSFTP(dir))
+	}
+	fmt.Fprintf(&batch, "put %s %s\n", quoteSFTP(local.Name()), quoteSFTP(remote+".part"))
+	fmt.Fprintf(&batch, "-rm %s\n", quoteSFTP(remote))
+	fmt.Fprintf(&batch, "rename %s %s\n", quoteSFTP(remote+".part"), quoteSFTP(remote))
+
+	cmd := exec.CommandContext(ctx, s.sftp, s.args()...)
+	cmd.Stdin = strings.NewReader(batch.String())
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sftp upload of %s to %s failed: %w: %s", name, s, err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+// quoteSFTP quotes a path for an sftp batch file
+func quoteSFTP(p string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(p) + `"`
+}
@@ -0,0 +1,109 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SyncFile is a local file copied to a destination by Sync
+type SyncFile struct {
+	// Path is the file's local path
+	Path string
+	// Name is the file's name at the destination, which may hold slashes
+	Name string
+}
+
+// syncState records what Sync last uploaded, so unchanged files are not
+// uploaded again
+type syncState struct {
+	// Destination is the destination the files went to; a new one gets
+	// every file again
+	Destination string                `json:"destination"`
+	Files       map[string]syncedFile `json:"files"`
+}
+
+// syncedFile is the size and modification time of an uploaded file
+type syncedFile struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Sync uploads those of files that changed since the last sync to dest and
+// returns how many it uploaded. What was uploaded is recorded in the file at
+// statePath. Files that do not exist are skipped; a file that fails to
+// upload does not keep the others from being uploaded, and is tried again
+// next time.
+func Sync(ctx context.Context, dest Destination, statePath string, files []SyncFile) (int, error) {
+	state := syncState{Files: map[string]syncedFile{}}
+	if data, err := os.ReadFile(statePath); err == nil {
+		// A corrupt state uploads everything again
+		_ = json.Unmarshal(data, &state)
+	}
+	if state.Destination != dest.String() || state.Files == nil {
+		state = syncState{Destination: dest.String(), Files: map[string]syncedFile{}}
+	}
+
+	uploaded := 0
+	var firstErr error
+	for _, file := range files {
+		synced, changed, err := syncFile(ctx, dest, file, state.Files[file.Name])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if changed {
+			state.Files[file.Name] = synced
+			uploaded++
+		}
+	}
+
+	if uploaded > 0 {
+		data, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return uploaded, fmt.Errorf("failed to encode sync state: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+			return uploaded, fmt.Errorf("failed to save sync state: %w", err)
+		}
+		if err := os.WriteFile(statePath, data, 0644); err != nil {
+			return uploaded, fmt.Errorf("failed to save sync state: %w", err)
+		}
+	}
+	return uploaded, firstErr
+}
+
+// syncFile uploads file unless it is unchanged since last, and returns its
+// size and modification time and whether it was uploaded
+func syncFile(ctx context.Context, dest Destination, file SyncFile, last syncedFile) (syncedFile, bool, error) {
+	f, err := os.Open(file.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return last, false, nil
+	}
+	if err != nil {
+		return last, false, fmt.Errorf("failed to read %s: %w", file.Path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return last, false, fmt.Errorf("failed to read %s: %w", file.Path, err)
+	}
+	current := syncedFile{Size: info.Size(), ModTime: info.ModTime().UTC()}
+	if current.Size == last.Size && current.ModTime.Equal(last.ModTime) {
+		return last, false, nil
+	}
+	// A file still growing, such as the history, is uploaded up to the size
+	// it had now
+	if err := dest.Store(ctx, file.Name, io.NewSectionReader(f, 0, current.Size), current.Size); err != nil {
+		return last, false, err
+	}
+	return current, true, nil
+}
@@ -0,0 +1,53 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	history := filepath.Join(dir, "logs", "history.jsonl")
+	writeTestFile(t, history, "{}\n")
+	statePath := filepath.Join(dir, "state", "sync.json")
+	remote := filepath.Join(dir, "remote")
+	dest, err := NewDestination(Config{Destination: remote}, nil)
+	if err != nil {
+		t.Fatalf("NewDestination() failed: %v", err)
+	}
+	files := []SyncFile{
+		{Path: history, Name: "site/history.jsonl"},
+		{Path: filepath.Join(dir, "logs", "outages.json"), Name: "site/outages.json"},
+	}
+
+	sync := func(want int) {
+		t.Helper()
+		uploaded, err := Sync(context.Background(), dest, statePath, files)
+		if err != nil || uploaded != want {
+			t.Fatalf("Expected %d files uploaded, got %d, %v", want, uploaded, err)
+		}
+	}
+	// The outages file does not exist yet
+	sync(1)
+	sync(0)
+
+	writeTestFile(t, history, "{}\n{}\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(history, later, later); err != nil {
+		t.Fatal(err)
+	}
+	sync(1)
+	data, err := os.ReadFile(filepath.Join(remote, "site", "history.jsonl"))
+	if err != nil || string(data) != "{}\n{}\n" {
+		t.Errorf("Expected the changed history uploaded, got %q, %v", data, err)
+	}
+
+	// A new destination gets every file again
+	if dest, err = NewDestination(Config{Destination: filepath.Join(dir, "other")}, nil); err != nil {
+		t.Fatal(err)
+	}
+	sync(1)
+}
@@ -0,0 +1,106 @@
+// Package beacon reports the watchdog's health to a remote endpoint over a
+// secondary link, such as an LTE stick, so that it can be seen from afar
+// whether the watchdog is alive even while the primary link is down. The
+// receiver should alert when beacons stop arriving.
+package beacon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/tunnel"
+)
+
+// DefaultInterval is how often the beacon is sent unless configured
+const DefaultInterval = 5 * time.Minute
+
+// requestTimeout bounds one beacon; a slow secondary link must not hold up
+// the next one
+const requestTimeout = 30 * time.Second
+
+// Config configures the beacon
+type Config struct {
+	// URL receives the status as a JSON POST
+	URL string
+	// Interface is the network interface the beacon leaves through; empty
+	// follows the routing table
+	Interface string
+	// Token, if set, is sent as a bearer token
+	Token string
+}
+
+// Status is the minimal health report a beacon carries
+type Status struct {
+	Hostname     string    `json:"hostname"`
+	Time         time.Time `json:"time"`
+	Uptime       int64     `json:"uptime_s"`
+	Health       string    `json:"health"`
+	HealthScore  float64   `json:"health_score"`
+	FailureCount int       `json:"failure_count"`
+	LastCheck    time.Time `json:"last_check"`
+	TotalReboots int       `json:"total_reboots"`
+	Rebooting    bool      `json:"rebooting,omitempty"`
+	Paused       bool      `json:"paused,omitempty"`
+	LowPower     bool      `json:"low_power,omitempty"`
+}
+
+// Beacon sends status reports to the configured endpoint
+type Beacon struct {
+	cfg    Config
+	client *http.Client
+}
+
+// New creates a beacon. With an interface configured its requests, and the
+// DNS lookups for them, are bound to it and bypass any proxy; otherwise they
+// go through client, or a default client when it is nil.
+func New(cfg Config, client *http.Client) *Beacon {
+	if cfg.Interface != "" {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.DialContext = tunnel.InterfaceDialer(cfg.Interface, 10*time.Second).DialContext
+		client = &http.Client{Transport: transport}
+	} else if client == nil {
+		client = &http.Client{}
+	}
+	return &Beacon{cfg: cfg, client: client}
+}
+
+// Send posts status to the endpoint, which must answer with a 2xx status
+func (b *Beacon) Send(ctx context.Context, status Status) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to encode beacon: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create beacon request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mb8600-watchdog")
+	if b.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.cfg.Token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		if b.cfg.Interface != "" {
+			return fmt.Errorf("failed to send beacon through %s: %w", b.cfg.Interface, err)
+		}
+		return fmt.Errorf("failed to send beacon: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("beacon endpoint returned %s", resp.Status)
+	}
+	return nil
+}
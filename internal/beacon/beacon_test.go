@@ -0,0 +1,63 @@
+package beacon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSend(t *testing.T) {
+	var received Status
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode beacon: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	status := Status{
+		Hostname:     "watchdog",
+		Time:         time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Health:       "outage",
+		FailureCount: 4,
+		Rebooting:    true,
+	}
+	b := New(Config{URL: server.URL, Token: "secret"}, server.Client())
+	if err := b.Send(context.Background(), status); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+	if received != status {
+		t.Errorf("Received %+v, want %+v", received, status)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected the bearer token, got %q", auth)
+	}
+}
+
+func TestSendErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	b := New(Config{URL: server.URL}, server.Client())
+	if err := b.Send(context.Background(), Status{}); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected the endpoint's status, got %v", err)
+	}
+
+	// Binding to an interface that does not exist fails the beacon
+	b = New(Config{URL: server.URL, Interface: "nosuchif0"}, nil)
+	if err := b.Send(context.Background(), Status{}); err == nil || !strings.Contains(err.Error(), "nosuchif0") {
+		t.Errorf("Expected a failure naming the interface, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+// Package budget splits the time of one check cycle between its stages.
+// Each stage gets a derived context whose deadline ends its slice, so a
+// single slow target cannot push the cycle past the check interval. The
+// cycle also holds a bucket of retry tokens that every retry spends, so
+// retries stay bounded however many targets fail at once.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+// Stages of a check cycle, in the order they run
+const (
+	StageLightweight = "lightweight"
+	StageEscalation  = "escalation"
+	StageDiagnostics = "diagnostics"
+)
+
+// stageEnds is the share of the cycle budget, from the cycle start, by which
+// each stage must finish. Time left over by an early stage carries over to
+// the later ones.
+var stageEnds = map[string]float64{
+	StageLightweight: 0.3,
+	StageEscalation:  0.6,
+	StageDiagnostics: 1.0,
+}
+
+// minimumSlice is the share of the budget a stage still gets when earlier
+// stages used up its slice, so it can fail fast instead of not running at all
+const minimumSlice = 0.1
+
+// Overrun records a stage that ran past its slice
+type Overrun struct {
+	Stage     string        `json:"stage"`
+	Allocated time.Duration `json:"allocated"`
+	Used      time.Duration `json:"used"`
+}
+
+// String describes the overrun for logs
+func (o Overrun) String() string {
+	return fmt.Sprintf("%s stage used %v of its %v slice", o.Stage, o.Used, o.Allocated)
+}
+
+// Budget tracks the time of one check cycle
+type Budget struct {
+	clock clock.Clock
+	start time.Time
+	total time.Duration
+
+	mu       sync.Mutex
+	overruns []Overrun
+	// retries is the number of retry tokens left; negative without a limit
+	retries       int
+	retriesUsed   int
+	retriesDenied int
+}
+
+// New starts a budget of total for a cycle beginning now
+func New(c clock.Clock, total time.Duration) *Budget {
+	if c == nil {
+		c = clock.New()
+	}
+	return &Budget{clock: c, start: c.Now(), total: total, retries: -1}
+}
+
+// Total returns the budget of the whole cycle
+func (b *Budget) Total() time.Duration {
+	return b.total
+}
+
+// Remaining returns the time left in the cycle
+func (b *Budget) Remaining() time.Duration {
+	remaining := b.total - b.clock.Since(b.start)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Overruns returns the stages that ran past their slice
+func (b *Budget) Overruns() []Overrun {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Overrun(nil), b.overruns...)
+}
+
+// SetRetries limits the cycle to n retries across all callers; 0 allows
+// none. Without a limit every retry is allowed.
+func (b *Budget) SetRetries(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	b.retries = n
+}
+
+// TakeRetry spends a retry token. It returns false once the cycle has spent
+// its retries, and the caller should give up with the error it has.
+func (b *Budget) TakeRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.retries == 0 {
+		b.retriesDenied++
+		return false
+	}
+	if b.retries > 0 {
+		b.retries--
+	}
+	b.retriesUsed++
+	return true
+}
+
+// Retries returns the retries spent in the cycle and those refused because
+// the budget was spent
+func (b *Budget) Retries() (used, denied int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retriesUsed, b.retriesDenied
+}
+
+// slice returns the time a stage starting now may use
+func (b *Budget) slice(stage string) time.Duration {
+	end, ok := stageEnds[stage]
+	if !ok {
+		end = 1.0
+	}
+
+	slice := time.Duration(float64(b.total)*end) - b.clock.Since(b.start)
+	if minimum := time.Duration(float64(b.total) * minimumSlice); slice < minimum {
+		slice = minimum
+	}
+	return slice
+}
+
+// Stage derives the context of a stage from ctx. The returned function ends
+// the stage, records an overrun if it ran past its slice and releases the
+// context; call it as soon as the stage is done.
+func (b *Budget) Stage(ctx context.Context, stage string) (context.Context, func()) {
+	slice := b.slice(stage)
+	started := b.clock.Now()
+	stageCtx, cancel := context.WithTimeout(ctx, slice)
+
+	return stageCtx, func() {
+		used := b.clock.Since(started)
+		timedOut := errors.Is(stageCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+		cancel()
+
+		if used > slice || timedOut {
+			if used < slice {
+				used = slice
+			}
+			b.mu.Lock()
+			b.overruns = append(b.overruns, Overrun{Stage: stage, Allocated: slice, Used: used})
+			b.mu.Unlock()
+		}
+	}
+}
+
+type contextKey struct{}
+
+// WithBudget returns a context carrying b, so code deep in the call chain
+// can derive its stage context without the budget being threaded through
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	return context.WithValue(ctx, contextKey{}, b)
+}
+
+// FromContext returns the budget carried by ctx, if any
+func FromContext(ctx context.Context) *Budget {
+	b, _ := ctx.Value(contextKey{}).(*Budget)
+	return b
+}
+
+// Stage derives a stage context from the budget carried by ctx. Without a
+// budget the stage simply inherits ctx.
+func Stage(ctx context.Context, stage string) (context.Context, func()) {
+	if b := FromContext(ctx); b != nil {
+		return b.Stage(ctx, stage)
+	}
+	stageCtx, cancel := context.WithCancel(ctx)
+	return stageCtx, cancel
+}
+
+// TakeRetry spends a retry token of the budget carried by ctx. Without a
+// budget every retry is allowed.
+func TakeRetry(ctx context.Context) bool {
+	if b := FromContext(ctx); b != nil {
+		return b.TakeRetry()
+	}
+	return true
+}
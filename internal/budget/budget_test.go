@@ -0,0 +1,147 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+func TestStageSlicesCarryOver(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := New(fake, 100*time.Second)
+
+	ctx, end := b.Stage(context.Background(), StageLightweight)
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > 30*time.Second || time.Until(deadline) < 29*time.Second {
+		t.Errorf("Lightweight stage should get 30s, deadline in %v", time.Until(deadline))
+	}
+	fake.Advance(10 * time.Second)
+	end()
+
+	// The escalation stage inherits the 20s the lightweight stage left over
+	ctx, end = b.Stage(context.Background(), StageEscalation)
+	deadline, _ = ctx.Deadline()
+	if left := time.Until(deadline); left > 50*time.Second || left < 49*time.Second {
+		t.Errorf("Escalation stage should get 50s, deadline in %v", left)
+	}
+	end()
+
+	if overruns := b.Overruns(); len(overruns) != 0 {
+		t.Errorf("Expected no overruns, got %v", overruns)
+	}
+	if remaining := b.Remaining(); remaining != 90*time.Second {
+		t.Errorf("Remaining() = %v, want 90s", remaining)
+	}
+}
+
+func TestStageOverrunIsRecorded(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := New(fake, 100*time.Second)
+
+	_, end := b.Stage(context.Background(), StageLightweight)
+	fake.Advance(45 * time.Second)
+	end()
+
+	overruns := b.Overruns()
+	if len(overruns) != 1 {
+		t.Fatalf("Expected one overrun, got %v", overruns)
+	}
+	if overruns[0].Stage != StageLightweight || overruns[0].Allocated != 30*time.Second || overruns[0].Used != 45*time.Second {
+		t.Errorf("Unexpected overrun %+v", overruns[0])
+	}
+
+	// Escalation only has 15s left of its slice
+	ctx, end := b.Stage(context.Background(), StageEscalation)
+	deadline, _ := ctx.Deadline()
+	if left := time.Until(deadline); left > 15*time.Second || left < 14*time.Second {
+		t.Errorf("Escalation stage should get the remaining 15s, deadline in %v", left)
+	}
+	end()
+
+	// An exhausted budget still leaves a minimum slice
+	fake.Advance(time.Hour)
+	ctx, end = b.Stage(context.Background(), StageDiagnostics)
+	deadline, _ = ctx.Deadline()
+	if left := time.Until(deadline); left > 10*time.Second || left < 9*time.Second {
+		t.Errorf("Exhausted budget should leave a 10s minimum slice, deadline in %v", left)
+	}
+	end()
+}
+
+func TestStageDeadlineEnforced(t *testing.T) {
+	b := New(clock.New(), 100*time.Millisecond)
+
+	ctx, end := b.Stage(context.Background(), StageLightweight)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stage context was not cancelled at the end of its slice")
+	}
+	end()
+
+	overruns := b.Overruns()
+	if len(overruns) != 1 || overruns[0].Stage != StageLightweight {
+		t.Errorf("Expected a lightweight overrun, got %v", overruns)
+	}
+}
+
+func TestParentCancellationIsNotAnOverrun(t *testing.T) {
+	b := New(clock.New(), time.Minute)
+
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, end := b.Stage(parent, StageEscalation)
+	cancel()
+	<-ctx.Done()
+	end()
+
+	if overruns := b.Overruns(); len(overruns) != 0 {
+		t.Errorf("Cancellation by the caller should not count as overrun, got %v", overruns)
+	}
+}
+
+func TestStageFromContext(t *testing.T) {
+	// Without a budget the stage inherits the caller's context
+	ctx, end := Stage(context.Background(), StageLightweight)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Stage without budget should not have a deadline")
+	}
+	end()
+
+	b := New(clock.New(), time.Minute)
+	ctx, end = Stage(WithBudget(context.Background(), b), StageLightweight)
+	defer end()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("Stage with budget should have a deadline")
+	}
+	if FromContext(ctx) != b {
+		t.Error("Stage context should still carry the budget")
+	}
+}
+
+func TestRetryTokens(t *testing.T) {
+	b := New(clock.New(), time.Minute)
+	for i := 0; i < 10; i++ {
+		if !b.TakeRetry() {
+			t.Fatal("A budget without a retry limit should allow every retry")
+		}
+	}
+
+	b = New(clock.New(), time.Minute)
+	b.SetRetries(2)
+	ctx := WithBudget(context.Background(), b)
+	if !TakeRetry(ctx) || !TakeRetry(ctx) {
+		t.Fatal("Expected the first two retries to be allowed")
+	}
+	if TakeRetry(ctx) {
+		t.Error("Expected a retry past the budget to be denied")
+	}
+	if used, denied := b.Retries(); used != 2 || denied != 1 {
+		t.Errorf("Retries() = %d used, %d denied, want 2 and 1", used, denied)
+	}
+
+	if !TakeRetry(context.Background()) {
+		t.Error("Retries without a budget should be allowed")
+	}
+}
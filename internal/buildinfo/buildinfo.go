@@ -0,0 +1,85 @@
+// Package buildinfo describes the running binary: the release and commit it
+// was built from, the Go toolchain, and the optional subsystems and modem
+// drivers compiled into it.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/features"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+)
+
+// Build-time variables, set with -ldflags "-X .../internal/buildinfo.Version=..."
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Info describes the running binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	// Features are the optional subsystems, compiled in or not
+	Features []Feature `json:"features"`
+	// ModemDrivers are the supported modem types
+	ModemDrivers []string `json:"modem_drivers"`
+}
+
+// Feature is an optional subsystem of the binary
+type Feature struct {
+	Name     string `json:"name"`
+	Compiled bool   `json:"compiled"`
+	// Enabled means compiled in and not switched off in the configuration
+	Enabled bool `json:"enabled"`
+}
+
+// Get returns the build information. enabled reports whether a compiled
+// subsystem is switched on, such as config.Config.FeatureEnabled; nil takes
+// every compiled subsystem as enabled.
+func Get(enabled func(name string) bool) Info {
+	info := Info{
+		Version:      Version,
+		Commit:       Commit,
+		BuildTime:    BuildTime,
+		GoVersion:    runtime.Version(),
+		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
+		ModemDrivers: modem.SupportedTypes(),
+	}
+	// Without ldflags, go build still stamps the VCS revision and time
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "unknown":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildTime == "unknown":
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+	for _, name := range features.Names() {
+		compiled := features.Compiled(name)
+		info.Features = append(info.Features, Feature{
+			Name:     name,
+			Compiled: compiled,
+			Enabled:  compiled && (enabled == nil || enabled(name)),
+		})
+	}
+	return info
+}
+
+// EnabledFeatures returns the names of the enabled subsystems
+func (i Info) EnabledFeatures() []string {
+	var names []string
+	for _, feature := range i.Features {
+		if feature.Enabled {
+			names = append(names, feature.Name)
+		}
+	}
+	return names
+}
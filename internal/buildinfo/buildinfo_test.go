@@ -0,0 +1,33 @@
+package buildinfo
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/features"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+)
+
+func TestGet(t *testing.T) {
+	info := Get(nil)
+	if info.Version != Version || info.GoVersion != runtime.Version() || info.Platform != runtime.GOOS+"/"+runtime.GOARCH {
+		t.Errorf("Unexpected build information %+v", info)
+	}
+	if !reflect.DeepEqual(info.ModemDrivers, modem.SupportedTypes()) || len(info.ModemDrivers) == 0 {
+		t.Errorf("Expected the supported modem types, got %v", info.ModemDrivers)
+	}
+	if got := info.EnabledFeatures(); !reflect.DeepEqual(got, features.CompiledNames()) {
+		t.Errorf("Expected every compiled feature enabled, got %v", got)
+	}
+
+	info = Get(func(name string) bool { return name != features.NameHistory })
+	for _, feature := range info.Features {
+		if feature.Compiled != features.Compiled(feature.Name) {
+			t.Errorf("Unexpected compiled state of %+v", feature)
+		}
+		if want := feature.Compiled && feature.Name != features.NameHistory; feature.Enabled != want {
+			t.Errorf("Expected %s enabled %t, got %t", feature.Name, want, feature.Enabled)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Package certs provides the TLS certificates of the local API: a persisted
+// self-signed certificate generated on first use, or certificate files
+// supplied by the user that are reloaded when they change on disk.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File names of the self-signed certificate in its directory
+const (
+	SelfSignedCertFile = "api-cert.pem"
+	SelfSignedKeyFile  = "api-key.pem"
+)
+
+const (
+	// selfSignedValidity is the lifetime of a generated certificate
+	selfSignedValidity = 365 * 24 * time.Hour
+	// renewBefore regenerates a certificate this long before it expires
+	renewBefore = 30 * 24 * time.Hour
+)
+
+// SelfSigned loads the self-signed certificate persisted in dir, generating
+// a new one valid for hosts when it is missing, unreadable or about to
+// expire. The certificate is kept across restarts so clients can pin it.
+// Without dir it is only kept in memory and changes with every start.
+func SelfSigned(dir string, hosts []string, now time.Time) (tls.Certificate, error) {
+	if dir == "" {
+		certPEM, keyPEM, err := generate(hosts, now)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		return loadGenerated(certPEM, keyPEM)
+	}
+
+	certFile := filepath.Join(dir, SelfSignedCertFile)
+	keyFile := filepath.Join(dir, SelfSignedKeyFile)
+
+	if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil &&
+			now.Add(renewBefore).Before(leaf.NotAfter) && coversHosts(leaf, hosts) {
+			cert.Leaf = leaf
+			return cert, nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+
+	certPEM, keyPEM, err := generate(hosts, now)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to write certificate key: %w", err)
+	}
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to write certificate: %w", err)
+	}
+	return loadGenerated(certPEM, keyPEM)
+}
+
+// loadGenerated parses a certificate made by generate
+func loadGenerated(certPEM, keyPEM []byte) (tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load generated certificate: %w", err)
+	}
+	cert.Leaf, _ = x509.ParseCertificate(cert.Certificate[0])
+	return cert, nil
+}
+
+// generate creates a self-signed ECDSA P-256 certificate for hosts
+func generate(hosts []string, now time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "mb8600-watchdog", Organization: []string{"MB8600 Watchdog"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// coversHosts reports whether leaf is valid for every host
+func coversHosts(leaf *x509.Certificate, hosts []string) bool {
+	for _, host := range hosts {
+		if host != "" && leaf.VerifyHostname(host) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Hosts returns the names a self-signed certificate for the API listening on
+// address should cover: localhost, the loopback addresses, the machine's
+// hostname and the listen host itself
+func Hosts(address string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		hosts = append(hosts, hostname)
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil || host == "" {
+		return hosts
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		return hosts
+	}
+	for _, existing := range hosts {
+		if strings.EqualFold(existing, host) {
+			return hosts
+		}
+	}
+	return append(hosts, host)
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a certificate, for pinning
+func Fingerprint(cert tls.Certificate) string {
+	if len(cert.Certificate) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(cert.Certificate[0])
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// Reloader serves a certificate from files and reloads it when they change,
+// so certificates renewed by an external tool are picked up without a restart
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewReloader loads the certificate in certFile and keyFile
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate; it is used as
+// tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.load()
+}
+
+// load reloads the certificate when either file changed. When a changed
+// certificate fails to load, the previous one keeps being served.
+func (r *Reloader) load() (*tls.Certificate, error) {
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil || !modTime.After(r.modTime) {
+		if r.cert == nil {
+			if err == nil {
+				err = errors.New("no certificate loaded")
+			}
+			return nil, fmt.Errorf("failed to read certificate: %w", err)
+		}
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert == nil {
+			return nil, fmt.Errorf("failed to load certificate: %w", err)
+		}
+		// Keep serving the old certificate, e.g. while files are half written
+		r.modTime = modTime
+		return r.cert, nil
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	return r.cert, nil
+}
+
+// latestModTime returns the newest modification time of files
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// ServerConfig returns the TLS configuration of the API. With certFile and
+// keyFile the user's certificate is served and reloaded on change; otherwise
+// a self-signed certificate for hosts is persisted in dir.
+func ServerConfig(certFile, keyFile, dir string, hosts []string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if certFile != "" || keyFile != "" {
+		reloader, err := NewReloader(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.GetCertificate = reloader.GetCertificate
+		return config, nil
+	}
+
+	cert, err := SelfSigned(dir, hosts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	config.Certificates = []tls.Certificate{cert}
+	return config, nil
+}
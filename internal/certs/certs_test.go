@@ -0,0 +1,177 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSelfSignedIsPersisted(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tls")
+	now := time.Now()
+	hosts := []string{"localhost", "127.0.0.1", "watchdog.lan"}
+
+	cert, err := SelfSigned(dir, hosts, now)
+	if err != nil {
+		t.Fatalf("SelfSigned() failed: %v", err)
+	}
+	for _, host := range hosts {
+		if err := cert.Leaf.VerifyHostname(host); err != nil {
+			t.Errorf("Certificate does not cover %s: %v", host, err)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(dir, SelfSignedKeyFile))
+	if err != nil {
+		t.Fatalf("Key was not persisted: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected key mode 0600, got %v", info.Mode().Perm())
+	}
+
+	again, err := SelfSigned(dir, hosts, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("SelfSigned() failed: %v", err)
+	}
+	if Fingerprint(again) != Fingerprint(cert) {
+		t.Error("Expected the persisted certificate to be reused")
+	}
+
+	// A new host or an expiring certificate gets a fresh certificate
+	renamed, err := SelfSigned(dir, append(hosts, "other.lan"), now)
+	if err != nil {
+		t.Fatalf("SelfSigned() failed: %v", err)
+	}
+	if Fingerprint(renamed) == Fingerprint(cert) {
+		t.Error("Expected a new certificate for a new host")
+	}
+
+	renewed, err := SelfSigned(dir, hosts, now.Add(selfSignedValidity-renewBefore/2))
+	if err != nil {
+		t.Fatalf("SelfSigned() failed: %v", err)
+	}
+	if Fingerprint(renewed) == Fingerprint(renamed) {
+		t.Error("Expected an expiring certificate to be renewed")
+	}
+}
+
+func TestSelfSignedWithoutDirectory(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cert, err := SelfSigned("", []string{"localhost"}, time.Now())
+	if err != nil {
+		t.Fatalf("SelfSigned() failed: %v", err)
+	}
+	if err := cert.Leaf.VerifyHostname("localhost"); err != nil {
+		t.Errorf("Certificate does not cover localhost: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected nothing written to the current directory, found %d entries", len(entries))
+	}
+}
+
+func TestHosts(t *testing.T) {
+	contains := func(hosts []string, host string) bool {
+		for _, h := range hosts {
+			if h == host {
+				return true
+			}
+		}
+		return false
+	}
+
+	hosts := Hosts("192.168.1.10:8600")
+	for _, want := range []string{"localhost", "127.0.0.1", "::1", "192.168.1.10"} {
+		if !contains(hosts, want) {
+			t.Errorf("Hosts() = %v, missing %s", hosts, want)
+		}
+	}
+
+	if contains(Hosts("0.0.0.0:8600"), "0.0.0.0") {
+		t.Error("Unspecified addresses should not be added")
+	}
+}
+
+func TestReloaderPicksUpNewCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	write := func(host string, modTime time.Time) {
+		certPEM, keyPEM, err := generate([]string{host}, time.Now())
+		if err != nil {
+			t.Fatalf("generate() failed: %v", err)
+		}
+		if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(certFile, modTime, modTime)
+		os.Chtimes(keyFile, modTime, modTime)
+	}
+	leafHost := func(cert *tls.Certificate) string {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return leaf.DNSNames[0]
+	}
+
+	start := time.Now().Add(-time.Hour)
+	write("first.lan", start)
+
+	reloader, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewReloader() failed: %v", err)
+	}
+	cert, _ := reloader.GetCertificate(nil)
+	if leafHost(cert) != "first.lan" {
+		t.Fatalf("Unexpected certificate for %s", leafHost(cert))
+	}
+
+	write("second.lan", start.Add(time.Minute))
+	cert, _ = reloader.GetCertificate(nil)
+	if leafHost(cert) != "second.lan" {
+		t.Errorf("Expected the renewed certificate, got %s", leafHost(cert))
+	}
+
+	// A broken file keeps the previous certificate in service
+	os.WriteFile(certFile, []byte("garbage"), 0644)
+	later := start.Add(2 * time.Minute)
+	os.Chtimes(certFile, later, later)
+	cert, err = reloader.GetCertificate(nil)
+	if err != nil || leafHost(cert) != "second.lan" {
+		t.Errorf("Expected the previous certificate, got %v", err)
+	}
+
+	if _, err := NewReloader(filepath.Join(dir, "missing.pem"), keyFile); err == nil {
+		t.Error("Expected an error for a missing certificate")
+	}
+}
+
+func TestServerConfig(t *testing.T) {
+	config, err := ServerConfig("", "", t.TempDir(), []string{"localhost"})
+	if err != nil {
+		t.Fatalf("ServerConfig() failed: %v", err)
+	}
+	if len(config.Certificates) != 1 || config.MinVersion != tls.VersionTLS12 {
+		t.Errorf("Unexpected self-signed configuration %+v", config)
+	}
+
+	if _, err := ServerConfig(filepath.Join(t.TempDir(), "cert.pem"), "key.pem", "", nil); err == nil {
+		t.Error("Expected an error for missing certificate files")
+	}
+}
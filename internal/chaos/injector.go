@@ -0,0 +1,224 @@
+// Package chaos injects faults into connectivity probes and modem calls of a
+// running instance, so retry, circuit breaker and escalation paths can be
+// validated without unplugging anything. Faults are only reachable when
+// fault injection is enabled in the configuration.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/connectivity"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxModemDelay bounds the injected modem delay so a typo cannot stall the service for hours
+const MaxModemDelay = 5 * time.Minute
+
+// ErrInjected is wrapped by every injected failure
+var ErrInjected = errors.New("injected fault")
+
+// Faults describes the faults currently injected
+type Faults struct {
+	// DropPercent is the share of connectivity probe connections that fail
+	DropPercent int
+	// ModemDelay is added before every modem request
+	ModemDelay time.Duration
+	// FailRebootOnce makes the next reboot command fail
+	FailRebootOnce bool
+}
+
+// Stats counts the faults injected so far
+type Stats struct {
+	DroppedProbes     int `json:"dropped_probes"`
+	DelayedModemCalls int `json:"delayed_modem_calls"`
+	FailedReboots     int `json:"failed_reboots"`
+}
+
+// Injector holds the active faults and applies them to wrapped dependencies
+type Injector struct {
+	logger *logrus.Logger
+
+	mu     sync.Mutex
+	faults Faults
+	stats  Stats
+	rand   *rand.Rand
+}
+
+// NewInjector creates an injector with no faults active
+func NewInjector(logger *logrus.Logger) *Injector {
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.WarnLevel)
+	}
+	return &Injector{
+		logger: logger,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Set replaces the active faults
+func (i *Injector) Set(faults Faults) error {
+	if faults.DropPercent < 0 || faults.DropPercent > 100 {
+		return fmt.Errorf("drop percentage must be between 0 and 100, got %d", faults.DropPercent)
+	}
+	if faults.ModemDelay < 0 || faults.ModemDelay > MaxModemDelay {
+		return fmt.Errorf("modem delay must be between 0 and %v, got %v", MaxModemDelay, faults.ModemDelay)
+	}
+
+	i.mu.Lock()
+	i.faults = faults
+	i.mu.Unlock()
+
+	i.logger.WithFields(logrus.Fields{
+		"drop_percent":     faults.DropPercent,
+		"modem_delay":      faults.ModemDelay,
+		"fail_reboot_once": faults.FailRebootOnce,
+	}).Warn("Fault injection updated")
+	return nil
+}
+
+// Clear removes all faults
+func (i *Injector) Clear() {
+	i.mu.Lock()
+	i.faults = Faults{}
+	i.mu.Unlock()
+
+	i.logger.Warn("Fault injection cleared")
+}
+
+// Faults returns the active faults; FailRebootOnce is reset once consumed
+func (i *Injector) Faults() Faults {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.faults
+}
+
+// Stats returns the number of faults injected so far
+func (i *Injector) Stats() Stats {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.stats
+}
+
+// dropProbe decides whether the next probe connection fails
+func (i *Injector) dropProbe() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.faults.DropPercent == 0 || i.rand.Intn(100) >= i.faults.DropPercent {
+		return false
+	}
+	i.stats.DroppedProbes++
+	return true
+}
+
+// modemDelay returns the delay to apply to the next modem call
+func (i *Injector) modemDelay() time.Duration {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.faults.ModemDelay > 0 {
+		i.stats.DelayedModemCalls++
+	}
+	return i.faults.ModemDelay
+}
+
+// takeRebootFailure consumes the pending reboot failure, if any
+func (i *Injector) takeRebootFailure() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.faults.FailRebootOnce {
+		return false
+	}
+	i.faults.FailRebootOnce = false
+	i.stats.FailedReboots++
+	return true
+}
+
+// WrapDialer returns a dialer that drops connections according to the active faults
+func (i *Injector) WrapDialer(dialer connectivity.Dialer) connectivity.Dialer {
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	return &faultyDialer{dialer: dialer, injector: i}
+}
+
+type faultyDialer struct {
+	dialer   connectivity.Dialer
+	injector *Injector
+}
+
+func (d *faultyDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.injector.dropProbe() {
+		d.injector.logger.WithField("address", address).Debug("Dropping probe connection")
+		return nil, fmt.Errorf("%w: dropped connection to %s", ErrInjected, address)
+	}
+	return d.dialer.DialContext(ctx, network, address)
+}
+
+// WrapDriver returns a modem driver that delays calls and fails reboots
+// according to the active faults. The wrapper hides driver specific reboot
+// cycle monitoring, so the generic poll-based monitoring is used instead.
+func (i *Injector) WrapDriver(driver modem.Driver) modem.Driver {
+	return &faultyDriver{Driver: driver, injector: i}
+}
+
+type faultyDriver struct {
+	modem.Driver
+	injector *Injector
+}
+
+// Unwrap returns the wrapped driver
+func (d *faultyDriver) Unwrap() modem.Driver {
+	return d.Driver
+}
+
+// delay waits for the injected modem delay or until ctx is done
+func (d *faultyDriver) delay(ctx context.Context) error {
+	delay := d.injector.modemDelay()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (d *faultyDriver) Login(ctx context.Context) error {
+	if err := d.delay(ctx); err != nil {
+		return err
+	}
+	return d.Driver.Login(ctx)
+}
+
+func (d *faultyDriver) GetStatus(ctx context.Context) (*modem.Status, error) {
+	if err := d.delay(ctx); err != nil {
+		return nil, err
+	}
+	return d.Driver.GetStatus(ctx)
+}
+
+func (d *faultyDriver) Reboot(ctx context.Context) error {
+	if err := d.delay(ctx); err != nil {
+		return err
+	}
+	if d.injector.takeRebootFailure() {
+		d.injector.logger.Warn("Failing reboot command by fault injection")
+		return fmt.Errorf("%w: reboot command rejected", ErrInjected)
+	}
+	return d.Driver.Reboot(ctx)
+}
@@ -0,0 +1,149 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/sirupsen/logrus"
+)
+
+type stubDialer struct {
+	dials int
+}
+
+func (d *stubDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.dials++
+	client, server := net.Pipe()
+	server.Close()
+	return client, nil
+}
+
+type stubDriver struct {
+	logins  int
+	reboots int
+}
+
+func (d *stubDriver) Name() string                    { return "stub" }
+func (d *stubDriver) Login(ctx context.Context) error { d.logins++; return nil }
+func (d *stubDriver) GetStatus(ctx context.Context) (*modem.Status, error) {
+	return &modem.Status{Model: "stub"}, nil
+}
+func (d *stubDriver) Reboot(ctx context.Context) error { d.reboots++; return nil }
+
+func newTestInjector() *Injector {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	return NewInjector(logger)
+}
+
+func TestSetValidatesFaults(t *testing.T) {
+	injector := newTestInjector()
+
+	invalid := []Faults{
+		{DropPercent: -1},
+		{DropPercent: 101},
+		{ModemDelay: -time.Second},
+		{ModemDelay: MaxModemDelay + time.Second},
+	}
+	for _, faults := range invalid {
+		if err := injector.Set(faults); err == nil {
+			t.Errorf("Expected %+v to be rejected", faults)
+		}
+	}
+
+	if err := injector.Set(Faults{DropPercent: 50, ModemDelay: time.Second}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := injector.Faults(); got.DropPercent != 50 || got.ModemDelay != time.Second {
+		t.Errorf("Unexpected faults %+v", got)
+	}
+
+	injector.Clear()
+	if got := injector.Faults(); got != (Faults{}) {
+		t.Errorf("Expected no faults after Clear, got %+v", got)
+	}
+}
+
+func TestWrapDialerDropsProbes(t *testing.T) {
+	injector := newTestInjector()
+	base := &stubDialer{}
+	dialer := injector.WrapDialer(base)
+
+	conn, err := dialer.DialContext(context.Background(), "tcp", "1.1.1.1:53")
+	if err != nil {
+		t.Fatalf("Expected dial to pass without faults: %v", err)
+	}
+	conn.Close()
+
+	injector.Set(Faults{DropPercent: 100})
+	for i := 0; i < 5; i++ {
+		if _, err := dialer.DialContext(context.Background(), "tcp", "1.1.1.1:53"); !errors.Is(err, ErrInjected) {
+			t.Fatalf("Expected injected failure, got %v", err)
+		}
+	}
+
+	if base.dials != 1 {
+		t.Errorf("Expected dropped probes not to reach the network, got %d dials", base.dials)
+	}
+	if injector.Stats().DroppedProbes != 5 {
+		t.Errorf("Expected 5 dropped probes, got %d", injector.Stats().DroppedProbes)
+	}
+}
+
+func TestWrapDriverFailsRebootOnce(t *testing.T) {
+	injector := newTestInjector()
+	base := &stubDriver{}
+	driver := injector.WrapDriver(base)
+
+	if driver.Name() != "stub" {
+		t.Errorf("Expected wrapped driver to keep its name, got %s", driver.Name())
+	}
+
+	injector.Set(Faults{FailRebootOnce: true})
+	if err := driver.Reboot(context.Background()); !errors.Is(err, ErrInjected) {
+		t.Fatalf("Expected first reboot to fail, got %v", err)
+	}
+	if err := driver.Reboot(context.Background()); err != nil {
+		t.Fatalf("Expected second reboot to succeed, got %v", err)
+	}
+
+	if base.reboots != 1 {
+		t.Errorf("Expected one reboot to reach the modem, got %d", base.reboots)
+	}
+	if injector.Faults().FailRebootOnce {
+		t.Error("Expected the reboot failure to be consumed")
+	}
+	if injector.Stats().FailedReboots != 1 {
+		t.Errorf("Expected 1 failed reboot, got %d", injector.Stats().FailedReboots)
+	}
+}
+
+func TestWrapDriverDelaysCalls(t *testing.T) {
+	injector := newTestInjector()
+	base := &stubDriver{}
+	driver := injector.WrapDriver(base)
+
+	injector.Set(Faults{ModemDelay: 50 * time.Millisecond})
+
+	start := time.Now()
+	if err := driver.Login(context.Background()); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected login to be delayed, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := driver.GetStatus(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the delay to honour the context, got %v", err)
+	}
+
+	if injector.Stats().DelayedModemCalls != 2 {
+		t.Errorf("Expected 2 delayed calls, got %d", injector.Stats().DelayedModemCalls)
+	}
+}
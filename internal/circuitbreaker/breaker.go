@@ -1,10 +1,12 @@
 package circuitbreaker
 
 import (
-	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
 )
 
 // State represents the circuit breaker state
@@ -40,6 +42,7 @@ type Breaker struct {
 	state                int32
 	mutex                sync.RWMutex
 	halfOpenTest         int32 // Atomic flag for half-open state testing
+	clock                clock.Clock
 }
 
 // New creates a new circuit breaker
@@ -48,14 +51,20 @@ func New(maxFailures int32, resetTimeout time.Duration) *Breaker {
 		maxFailures:  maxFailures,
 		resetTimeout: resetTimeout,
 		state:        int32(Closed),
+		clock:        clock.New(),
 	}
 }
 
+// SetClock replaces the clock used for the reset timeout; call before use
+func (cb *Breaker) SetClock(c clock.Clock) {
+	cb.clock = c
+}
+
 // Execute runs the operation with circuit breaker protection
 func (cb *Breaker) Execute(operation func() error) error {
 	// Check if we can execute
 	if !cb.allowRequest() {
-		return fmt.Errorf("circuit breaker is open")
+		return werrors.ErrCircuitOpen
 	}
 
 	// Execute the operation
@@ -95,7 +104,7 @@ func (cb *Breaker) allowRequest() bool {
 // shouldAttemptReset checks if enough time has passed to attempt reset
 func (cb *Breaker) shouldAttemptReset() bool {
 	lastFailure := atomic.LoadInt64(&cb.lastFailureTime)
-	return time.Since(time.Unix(lastFailure, 0)) >= cb.resetTimeout
+	return cb.clock.Since(time.Unix(0, lastFailure)) >= cb.resetTimeout
 }
 
 // transitionToHalfOpen safely transitions from open to half-open state
@@ -135,7 +144,7 @@ func (cb *Breaker) onSuccess() {
 
 // onFailure handles failed operation execution
 func (cb *Breaker) onFailure() {
-	atomic.StoreInt64(&cb.lastFailureTime, time.Now().Unix())
+	atomic.StoreInt64(&cb.lastFailureTime, cb.clock.Now().UnixNano())
 	failures := atomic.AddInt32(&cb.failureCount, 1)
 
 	state := State(atomic.LoadInt32(&cb.state))
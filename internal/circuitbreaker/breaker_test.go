@@ -4,6 +4,8 @@ import (
 	"errors"
 	"testing"
 	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
 )
 
 func TestCircuitBreakerStateTransitions(t *testing.T) {
@@ -134,3 +136,28 @@ func TestCircuitBreakerReset(t *testing.T) {
 		t.Errorf("Expected failure count to be 0 after reset, got %d", cb.GetFailureCount())
 	}
 }
+
+// TestResetTimeoutUsesInjectedClock verifies the cooldown is measured on the breaker clock
+func TestResetTimeoutUsesInjectedClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cb := New(1, time.Minute)
+	cb.SetClock(fake)
+
+	cb.Execute(func() error { return errors.New("failure") })
+	if !cb.IsOpen() {
+		t.Fatal("Expected breaker to open after one failure")
+	}
+
+	fake.Advance(59 * time.Second)
+	if err := cb.Execute(func() error { return nil }); err == nil {
+		t.Error("Expected breaker to reject requests before the reset timeout")
+	}
+
+	fake.Advance(time.Second)
+	if err := cb.Execute(func() error { return nil }); err != nil {
+		t.Errorf("Expected half-open probe after the reset timeout, got %v", err)
+	}
+	if cb.GetState() != Closed {
+		t.Errorf("Expected breaker to close after successful probe, got %v", cb.GetState())
+	}
+}
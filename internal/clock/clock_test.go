@@ -0,0 +1,173 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeAfter(t *testing.T) {
+	fake := NewFake(epoch)
+	ch := fake.After(time.Minute)
+
+	fake.Advance(59 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("After fired before its deadline")
+	default:
+	}
+
+	fake.Advance(time.Second)
+	select {
+	case fired := <-ch:
+		if !fired.Equal(epoch.Add(time.Minute)) {
+			t.Errorf("Expected fire time %v, got %v", epoch.Add(time.Minute), fired)
+		}
+	default:
+		t.Fatal("After did not fire at its deadline")
+	}
+
+	if fake.Waiters() != 0 {
+		t.Errorf("Expected fired timer to be removed, %d waiters remain", fake.Waiters())
+	}
+	if fake.Since(epoch) != time.Minute {
+		t.Errorf("Expected Since to report 1m, got %v", fake.Since(epoch))
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	fake := NewFake(epoch)
+	ticker := fake.NewTicker(10 * time.Second)
+
+	ticks := 0
+	for i := 0; i < 3; i++ {
+		fake.Advance(10 * time.Second)
+		select {
+		case <-ticker.C():
+			ticks++
+		default:
+		}
+	}
+	if ticks != 3 {
+		t.Errorf("Expected 3 ticks, got %d", ticks)
+	}
+
+	// Slow receivers lose ticks instead of queueing them
+	fake.Advance(time.Minute)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Error("Expected missed ticks to be dropped")
+	default:
+	}
+
+	ticker.Reset(time.Hour)
+	fake.Advance(30 * time.Minute)
+	select {
+	case <-ticker.C():
+		t.Error("Ticker fired before reset interval")
+	default:
+	}
+
+	ticker.Stop()
+	fake.Advance(2 * time.Hour)
+	select {
+	case <-ticker.C():
+		t.Error("Stopped ticker fired")
+	default:
+	}
+}
+
+func TestFakeTimer(t *testing.T) {
+	fake := NewFake(epoch)
+
+	timer := fake.NewTimer(time.Minute)
+	stopped := fake.NewTimer(time.Minute)
+	if !stopped.Stop() {
+		t.Error("Expected Stop to report a pending timer")
+	}
+	if fake.Waiters() != 1 {
+		t.Errorf("Expected stopped timer to be removed, %d waiters", fake.Waiters())
+	}
+
+	fake.Advance(time.Minute)
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("Timer did not fire at its deadline")
+	}
+	select {
+	case <-stopped.C():
+		t.Error("Stopped timer fired")
+	default:
+	}
+	if timer.Stop() {
+		t.Error("Expected Stop to report a fired timer as not pending")
+	}
+}
+
+func TestFakeAdvanceToNext(t *testing.T) {
+	fake := NewFake(epoch)
+	if fake.AdvanceToNext() {
+		t.Fatal("Expected AdvanceToNext to report no pending deadline")
+	}
+
+	late := fake.After(time.Hour)
+	early := fake.After(10 * time.Minute)
+
+	if !fake.AdvanceToNext() {
+		t.Fatal("Expected AdvanceToNext to find a deadline")
+	}
+	if got := fake.Now(); !got.Equal(epoch.Add(10 * time.Minute)) {
+		t.Errorf("Expected clock at +10m, got %v", got.Sub(epoch))
+	}
+	select {
+	case <-early:
+	default:
+		t.Error("Expected the earliest timer to fire")
+	}
+	select {
+	case <-late:
+		t.Error("Later timer fired early")
+	default:
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	fake := NewFake(epoch)
+	done := make(chan struct{})
+
+	go func() {
+		<-fake.After(time.Hour)
+		close(done)
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Hour)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Goroutine waiting on fake timer was not released")
+	}
+}
+
+func TestRealClock(t *testing.T) {
+	c := New()
+	start := c.Now()
+
+	select {
+	case <-c.After(time.Millisecond):
+	case <-time.After(5 * time.Second):
+		t.Fatal("Real After did not fire")
+	}
+
+	ticker := c.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	<-ticker.C()
+
+	if c.Since(start) <= 0 {
+		t.Error("Expected real time to advance")
+	}
+}
@@ -0,0 +1,290 @@
+// Package cluster runs two watchdogs watching the same modem as a primary
+// and a standby. Both check the connection, but only the active one reboots
+// the modem: the primary, or the standby once the primary has not answered
+// its heartbeats for a while. The heartbeat is a poll of the peer's status
+// API.
+package cluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Roles
+const (
+	// RolePrimary reboots the modem while it runs
+	RolePrimary = "primary"
+	// RoleStandby reboots the modem only while the primary is gone
+	RoleStandby = "standby"
+)
+
+// DefaultHeartbeatInterval is how often the peer is polled unless configured
+const DefaultHeartbeatInterval = 10 * time.Second
+
+// DefaultFailoverAfter is how long the primary may be silent before the
+// standby takes over unless configured
+const DefaultFailoverAfter = time.Minute
+
+// statusPath is the status endpoint of the peer's API
+const statusPath = "/api/v1/status"
+
+// Config configures the node
+type Config struct {
+	// Role is RolePrimary or RoleStandby, empty when not clustered
+	Role string
+	// Peer is the base URL of the other node's API, such as
+	// http://192.168.1.11:8080
+	Peer string
+	// PeerKey is an API key of the other node with the read scope, sent as
+	// a bearer token
+	PeerKey string
+	// PeerFingerprint is the SHA-256 fingerprint of the other node's
+	// self-signed API certificate, which is then trusted instead of the
+	// system's certificate authorities
+	PeerFingerprint string
+	// HeartbeatInterval is the time between polls of the peer
+	HeartbeatInterval time.Duration
+	// FailoverAfter is how long the peer may go unseen before it is taken
+	// for gone
+	FailoverAfter time.Duration
+}
+
+// Enabled reports whether cfg makes the watchdog a cluster node
+func (cfg Config) Enabled() bool {
+	return cfg.Role != ""
+}
+
+// Validate rejects an unknown role, a missing or invalid peer, and a
+// failover delay that a single late heartbeat would trigger
+func (cfg Config) Validate() error {
+	if !cfg.Enabled() {
+		if cfg.Peer != "" || cfg.PeerKey != "" || cfg.PeerFingerprint != "" {
+			return errors.New("the peer needs a role, primary or standby")
+		}
+		return nil
+	}
+	if cfg.Role != RolePrimary && cfg.Role != RoleStandby {
+		return fmt.Errorf("role must be %s or %s, got %q", RolePrimary, RoleStandby, cfg.Role)
+	}
+	peer, err := url.Parse(cfg.Peer)
+	if err != nil || (peer.Scheme != "http" && peer.Scheme != "https") || peer.Host == "" {
+		return fmt.Errorf("peer must be the http or https URL of the other node's API, got %q", cfg.Peer)
+	}
+	if cfg.HeartbeatInterval < time.Second {
+		return fmt.Errorf("heartbeat interval must be at least 1s, got %v", cfg.HeartbeatInterval)
+	}
+	if cfg.PeerFingerprint != "" {
+		if peer.Scheme != "https" {
+			return errors.New("a peer fingerprint needs an https peer")
+		}
+		if fingerprint := normalizeFingerprint(cfg.PeerFingerprint); len(fingerprint) != sha256.Size*2 || strings.Trim(fingerprint, "0123456789ABCDEF") != "" {
+			return fmt.Errorf("peer fingerprint must be a hex SHA-256 fingerprint, got %q", cfg.PeerFingerprint)
+		}
+	}
+	if cfg.FailoverAfter < 2*cfg.HeartbeatInterval {
+		return fmt.Errorf("failover delay must be at least twice the heartbeat interval, got %v", cfg.FailoverAfter)
+	}
+	return nil
+}
+
+// Status is the node's view of the cluster, published in the service state
+// so that the peer can read it
+type Status struct {
+	Role string `json:"role"`
+	// Active is set on the node that reboots the modem
+	Active bool   `json:"active"`
+	Peer   string `json:"peer"`
+	// PeerSeen is when the peer last answered, nil if it never did
+	PeerSeen *time.Time `json:"peer_seen,omitempty"`
+	// PeerRunning is set while the peer answers and monitors
+	PeerRunning bool `json:"peer_running"`
+	// PeerRebooting is set while the peer reboots the modem
+	PeerRebooting bool `json:"peer_rebooting,omitempty"`
+	// Reason explains why the node is active or not
+	Reason string `json:"reason"`
+}
+
+// peerState is the part of the peer's status the node uses
+type peerState struct {
+	IsRunning bool            `json:"is_running"`
+	Reboot    json.RawMessage `json:"reboot"`
+	Cluster   *Status         `json:"cluster"`
+}
+
+// rebooting reports whether the peer is rebooting the modem
+func (p peerState) rebooting() bool {
+	return len(p.Reboot) > 0 && string(p.Reboot) != "null"
+}
+
+// Node is this watchdog's side of the cluster
+type Node struct {
+	cfg     Config
+	client  *http.Client
+	started time.Time
+
+	mu   sync.Mutex
+	seen time.Time
+	peer peerState
+}
+
+// New creates the node of cfg, started at now. Until FailoverAfter has
+// passed the peer is assumed to be there, so a standby starting together
+// with its primary does not take over before the first heartbeat. With a
+// peer fingerprint polls go through a client pinning it; otherwise through
+// client, or a default client when it is nil.
+func New(cfg Config, client *http.Client, now time.Time) (*Node, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled() {
+		return nil, errors.New("no cluster role configured")
+	}
+	if cfg.PeerFingerprint != "" {
+		client = pinnedClient(normalizeFingerprint(cfg.PeerFingerprint))
+	} else if client == nil {
+		client = &http.Client{}
+	}
+	return &Node{cfg: cfg, client: client, started: now}, nil
+}
+
+// pinnedClient returns a client that trusts only the certificate with the
+// SHA-256 fingerprint, as logged by the peer at startup
+func pinnedClient(fingerprint string) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.TLSClientConfig = &tls.Config{
+		// The chain is checked against the fingerprint below instead
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("peer sent no certificate")
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if got := strings.ToUpper(hex.EncodeToString(sum[:])); got != fingerprint {
+				return fmt.Errorf("peer certificate fingerprint %s does not match the configured one", got)
+			}
+			return nil
+		},
+	}
+	return &http.Client{Transport: transport}
+}
+
+// normalizeFingerprint returns fingerprint in upper case without the colons
+// some tools separate bytes with
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+}
+
+// Role returns the node's role
+func (n *Node) Role() string {
+	return n.cfg.Role
+}
+
+// Poll reads the peer's status, which counts as a heartbeat if it is the
+// other role of the cluster
+func (n *Node) Poll(ctx context.Context, now time.Time) error {
+	timeout := n.cfg.HeartbeatInterval
+	if timeout > 10*time.Second {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(n.cfg.Peer, "/")+statusPath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create heartbeat request: %w", err)
+	}
+	if n.cfg.PeerKey != "" {
+		req.Header.Set("Authorization", "Bearer "+n.cfg.PeerKey)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("peer %s unreachable: %w", n.cfg.Peer, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer %s answered %s", n.cfg.Peer, resp.Status)
+	}
+
+	var peer peerState
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&peer); err != nil {
+		return fmt.Errorf("invalid status from peer %s: %w", n.cfg.Peer, err)
+	}
+	if peer.Cluster == nil {
+		return fmt.Errorf("peer %s is not a cluster node", n.cfg.Peer)
+	}
+	if peer.Cluster.Role == n.cfg.Role {
+		return fmt.Errorf("peer %s is %s as well", n.cfg.Peer, n.cfg.Role)
+	}
+
+	n.mu.Lock()
+	n.seen = now
+	n.peer = peer
+	n.mu.Unlock()
+	return nil
+}
+
+// peerAlive reports whether the peer answered and monitored within
+// FailoverAfter; the caller holds mu
+func (n *Node) peerAlive(now time.Time) bool {
+	if n.seen.IsZero() {
+		return now.Sub(n.started) < n.cfg.FailoverAfter
+	}
+	return now.Sub(n.seen) < n.cfg.FailoverAfter && n.peer.IsRunning
+}
+
+// Status returns the node's view of the cluster at now
+func (n *Node) Status(now time.Time) Status {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	status := Status{Role: n.cfg.Role, Peer: n.cfg.Peer}
+	alive := n.peerAlive(now)
+	if !n.seen.IsZero() {
+		seen := n.seen
+		status.PeerSeen = &seen
+		status.PeerRunning = alive
+		status.PeerRebooting = alive && n.peer.rebooting()
+	}
+	switch {
+	case n.cfg.Role == RolePrimary:
+		status.Active, status.Reason = true, "primary"
+	case alive && n.seen.IsZero():
+		status.Reason = "waiting for the first heartbeat of the primary"
+	case alive:
+		status.Reason = "primary is active"
+	default:
+		status.Active = true
+		status.Reason = fmt.Sprintf("primary unseen for over %v, standing in", n.cfg.FailoverAfter)
+	}
+	return status
+}
+
+// MayReboot reports whether the node may reboot the modem at now and, if
+// not, why. A standby may while the primary is gone; the primary may unless
+// the standby, standing in, is rebooting the modem as the primary returns.
+func (n *Node) MayReboot(now time.Time) (bool, string) {
+	status := n.Status(now)
+	if !status.Active {
+		return false, fmt.Sprintf("standby node, %s", status.Reason)
+	}
+	n.mu.Lock()
+	standbyRebooting := n.cfg.Role == RolePrimary && status.PeerRebooting && n.peer.Cluster.Active
+	n.mu.Unlock()
+	if standbyRebooting {
+		return false, "the standby node is rebooting the modem"
+	}
+	return true, ""
+}
@@ -0,0 +1,168 @@
+package cluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newPeer serves body as the peer's status and returns its URL and the
+// authorization headers it received
+func newPeer(t *testing.T, body *string) (string, *[]string) {
+	t.Helper()
+	var auths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != statusPath {
+			http.NotFound(w, r)
+			return
+		}
+		auths = append(auths, r.Header.Get("Authorization"))
+		w.Write([]byte(*body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &auths
+}
+
+func TestStandbyTakesOverWhenPrimaryIsGone(t *testing.T) {
+	body := `{"is_running":true,"cluster":{"role":"primary","active":true}}`
+	peer, auths := newPeer(t, &body)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	node, err := New(Config{
+		Role:              RoleStandby,
+		Peer:              peer,
+		PeerKey:           "key",
+		HeartbeatInterval: 10 * time.Second,
+		FailoverAfter:     time.Minute,
+	}, nil, start)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if ok, _ := node.MayReboot(start); ok {
+		t.Error("Expected the standby to wait for the primary at startup")
+	}
+	if err := node.Poll(context.Background(), start.Add(10*time.Second)); err != nil {
+		t.Fatalf("Poll() failed: %v", err)
+	}
+	if (*auths)[0] != "Bearer key" {
+		t.Errorf("Expected the peer key as bearer token, got %q", (*auths)[0])
+	}
+	if ok, reason := node.MayReboot(start.Add(50 * time.Second)); ok || !strings.Contains(reason, "primary is active") {
+		t.Errorf("Expected the standby to stay passive while the primary answers, got %v, %q", ok, reason)
+	}
+
+	// The primary stops answering
+	if ok, _ := node.MayReboot(start.Add(2 * time.Minute)); !ok {
+		t.Error("Expected the standby to take over once the primary is unseen")
+	}
+	if status := node.Status(start.Add(2 * time.Minute)); !status.Active || status.PeerRunning || status.PeerSeen == nil {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+
+	// It answers again, but has stopped monitoring
+	body = `{"is_running":false,"cluster":{"role":"primary","active":true}}`
+	if err := node.Poll(context.Background(), start.Add(3*time.Minute)); err != nil {
+		t.Fatalf("Poll() failed: %v", err)
+	}
+	if ok, _ := node.MayReboot(start.Add(3 * time.Minute)); !ok {
+		t.Error("Expected the standby to stand in for a primary that is not monitoring")
+	}
+
+	// It is back
+	body = `{"is_running":true,"cluster":{"role":"primary","active":true}}`
+	if err := node.Poll(context.Background(), start.Add(4*time.Minute)); err != nil {
+		t.Fatalf("Poll() failed: %v", err)
+	}
+	if ok, _ := node.MayReboot(start.Add(4 * time.Minute)); ok {
+		t.Error("Expected the standby to yield to the returning primary")
+	}
+}
+
+func TestPrimaryDefersToRebootingStandby(t *testing.T) {
+	body := `{"is_running":true,"reboot":{"phase":"rebooting"},"cluster":{"role":"standby","active":true}}`
+	peer, _ := newPeer(t, &body)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	node, err := New(Config{Role: RolePrimary, Peer: peer, HeartbeatInterval: 10 * time.Second, FailoverAfter: time.Minute}, nil, start)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if ok, _ := node.MayReboot(start); !ok {
+		t.Error("Expected the primary to be active at startup")
+	}
+	if err := node.Poll(context.Background(), start); err != nil {
+		t.Fatalf("Poll() failed: %v", err)
+	}
+	if ok, reason := node.MayReboot(start); ok || !strings.Contains(reason, "standby") {
+		t.Errorf("Expected the primary to wait for the standby's reboot, got %v, %q", ok, reason)
+	}
+
+	body = `{"is_running":true,"reboot":null,"cluster":{"role":"standby","active":false}}`
+	if err := node.Poll(context.Background(), start.Add(10*time.Second)); err != nil {
+		t.Fatalf("Poll() failed: %v", err)
+	}
+	if ok, _ := node.MayReboot(start.Add(10 * time.Second)); !ok {
+		t.Error("Expected the primary to be active again")
+	}
+
+	body = `{"is_running":true,"cluster":{"role":"primary","active":true}}`
+	if err := node.Poll(context.Background(), start); err == nil {
+		t.Error("Expected an error for a peer with the same role")
+	}
+	body = `{"is_running":true}`
+	if err := node.Poll(context.Background(), start); err == nil {
+		t.Error("Expected an error for a peer that is not clustered")
+	}
+}
+
+func TestPeerFingerprintIsPinned(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"is_running":true,"cluster":{"role":"primary","active":true}}`))
+	}))
+	defer server.Close()
+	sum := sha256.Sum256(server.Certificate().Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	cfg := Config{Role: RoleStandby, Peer: server.URL, PeerFingerprint: fingerprint, HeartbeatInterval: time.Second, FailoverAfter: time.Minute}
+	node, err := New(cfg, nil, time.Now())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := node.Poll(context.Background(), time.Now()); err != nil {
+		t.Errorf("Expected the pinned certificate to be trusted, got %v", err)
+	}
+
+	cfg.PeerFingerprint = strings.Repeat("AB", sha256.Size)
+	if node, err = New(cfg, nil, time.Now()); err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := node.Poll(context.Background(), time.Now()); err == nil || !strings.Contains(err.Error(), "fingerprint") {
+		t.Errorf("Expected another certificate to be refused, got %v", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{Role: RoleStandby, Peer: "http://pi1:8080", HeartbeatInterval: DefaultHeartbeatInterval, FailoverAfter: DefaultFailoverAfter}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected %+v to be valid, got %v", valid, err)
+	}
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("Expected no cluster to be valid, got %v", err)
+	}
+	for _, cfg := range []Config{
+		{Peer: "http://pi1:8080"},
+		{Role: "leader", Peer: valid.Peer, HeartbeatInterval: valid.HeartbeatInterval, FailoverAfter: valid.FailoverAfter},
+		{Role: RolePrimary, Peer: "pi1:8080", HeartbeatInterval: valid.HeartbeatInterval, FailoverAfter: valid.FailoverAfter},
+		{Role: RolePrimary, Peer: valid.Peer, HeartbeatInterval: valid.HeartbeatInterval, FailoverAfter: 15 * time.Second},
+		{Role: RolePrimary, Peer: valid.Peer, PeerFingerprint: strings.Repeat("AB", 32), HeartbeatInterval: valid.HeartbeatInterval, FailoverAfter: valid.FailoverAfter},
+		{Role: RolePrimary, Peer: "https://pi1:8600", PeerFingerprint: "not hex", HeartbeatInterval: valid.HeartbeatInterval, FailoverAfter: valid.FailoverAfter},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", cfg)
+		}
+	}
+}
@@ -4,11 +4,33 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/backup"
+	"github.com/perezjoseph/mb8600-watchdog/internal/beacon"
+	"github.com/perezjoseph/mb8600-watchdog/internal/cluster"
+	"github.com/perezjoseph/mb8600-watchdog/internal/connectivity"
+	"github.com/perezjoseph/mb8600-watchdog/internal/features"
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/hosthealth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/logger"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/perezjoseph/mb8600-watchdog/internal/power"
+	"github.com/perezjoseph/mb8600-watchdog/internal/publicip"
+	"github.com/perezjoseph/mb8600-watchdog/internal/quorum"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+	"github.com/perezjoseph/mb8600-watchdog/internal/sms"
+	"github.com/perezjoseph/mb8600-watchdog/internal/textfile"
+	"github.com/perezjoseph/mb8600-watchdog/internal/tunnel"
+	"github.com/perezjoseph/mb8600-watchdog/internal/verdict"
 )
 
 // Default configuration values
@@ -18,7 +40,10 @@ const (
 	DefaultRecoveryWait          = 600 * time.Second
 	DefaultLogLevel              = "INFO"
 	DefaultLogFile               = "/app/logs/watchdog.log"
+	LogFileNone                  = "none" // LogFile value that logs to stdout only
 	DefaultLogFormat             = "console"
+	DefaultCheckOverlapPolicy    = "skip"
+	DefaultCycleRetryBudget      = 6
 	DefaultLogMaxSize            = 100
 	DefaultLogMaxAge             = 30
 	DefaultTimeout               = 10 * time.Second
@@ -29,10 +54,69 @@ const (
 	DefaultMemoryLimitMB         = 20
 	DefaultStartupTimeLimitMS    = 50
 	DefaultResourceCheckInterval = 30 * time.Second
+	DefaultMetricsMaxOperations  = 100
+	DefaultMetricsRetention      = 7 * 24 * time.Hour
+	DefaultHistoryRawRetention   = 7 * 24 * time.Hour
+	DefaultHistoryMaxSize        = 20
+	DefaultReportMaxAge          = 30
+	DefaultReportMaxSize         = 20
 	DefaultPidFile               = "/var/run/watchdog.pid"
 	DefaultWorkingDirectory      = "/app"
+	DefaultAPITLS                = APITLSAuto
+	DefaultAPIActionLimit        = 5
+	DefaultAPIActionWindow       = time.Hour
+	DefaultAPIRefreshInterval    = 10 * time.Second
+	DefaultDockerSocket          = "/var/run/docker.sock"
+	DefaultDockerRestartAfter    = 5 * time.Minute
+
+	// DefaultPartialServiceChannels is how many unlocked downstream channels
+	// put the modem in partial service
+	DefaultPartialServiceChannels = 2
+
+	// DefaultModemMaxTemperature is the modem temperature in Celsius above
+	// which the modem counts as overheating
+	DefaultModemMaxTemperature = 80.0
+
+	// DOCSIS timeouts in the modem's event log that bring the
+	// docsis_timeouts health input to 0
+	DefaultDOCSISTimeoutsPerHour = 10
+	DefaultDOCSISTimeoutsPerDay  = 50
+
+	// DefaultScheduledRebootSkipWithin skips a scheduled reboot this soon
+	// after an outage or reboot
+	DefaultScheduledRebootSkipWithin = 24 * time.Hour
+
+	// DefaultRebootCommandTimeout bounds the reboot command and the wait for
+	// the modem to cycle, before the recovery wait
+	DefaultRebootCommandTimeout = 10 * time.Minute
+	// DefaultRebootShutdownPolicy is what a shutdown does to a reboot in
+	// progress
+	DefaultRebootShutdownPolicy = RebootShutdownWait
+)
+
+// Reboot shutdown policies
+const (
+	// RebootShutdownWait finishes a reboot in progress before stopping
+	RebootShutdownWait = "wait"
+	// RebootShutdownAbort cancels a reboot in progress and stops right away
+	RebootShutdownAbort = "abort"
+)
+
+// ProxyDirect is the OutboundProxy value that sends outbound requests
+// without a proxy, whatever HTTP_PROXY and HTTPS_PROXY say
+const ProxyDirect = "direct"
+
+// API TLS modes
+const (
+	// APITLSAuto serves HTTPS with the configured or a self-signed certificate
+	APITLSAuto = "auto"
+	// APITLSOff serves plain HTTP
+	APITLSOff = "off"
 )
 
+// RedactedValue replaces credentials in Redacted configurations
+const RedactedValue = "REDACTED"
+
 // getDefaultPingHosts returns default ping hosts
 func getDefaultPingHosts() []string {
 	return []string{"1.1.1.1", "8.8.8.8", "9.9.9.9"}
@@ -46,10 +130,28 @@ func getDefaultHTTPHosts() []string {
 // ConfigJSON is used for JSON marshaling/unmarshaling with string durations
 type ConfigJSON struct {
 	// Modem configuration
+	ModemType     string `json:"ModemType,omitempty"`
 	ModemHost     string `json:"ModemHost,omitempty"`
 	ModemUsername string `json:"ModemUsername,omitempty"`
 	ModemPassword string `json:"ModemPassword,omitempty"`
 	ModemNoVerify *bool  `json:"ModemNoVerify,omitempty"`
+	ModemPort     *int   `json:"ModemPort,omitempty"`
+	// ModemStatsInterval is how often the modem's channels are read for metrics
+	ModemStatsInterval string `json:"ModemStatsInterval,omitempty"`
+	// Partial service, when downstream channels lose their lock
+	PartialServiceChannels    *int   `json:"PartialServiceChannels,omitempty"`
+	PartialServiceRebootAfter string `json:"PartialServiceRebootAfter,omitempty"`
+	// ModemMaxTemperature is the modem temperature in Celsius that alerts
+	ModemMaxTemperature *float64 `json:"ModemMaxTemperature,omitempty"`
+	// DOCSIS timeout thresholds, per hour and per day
+	DOCSISTimeoutsPerHour *int `json:"DOCSISTimeoutsPerHour,omitempty"`
+	DOCSISTimeoutsPerDay  *int `json:"DOCSISTimeoutsPerDay,omitempty"`
+
+	// Tunnel to a modem that is not on the local network
+	ModemTunnelSSH           string `json:"ModemTunnelSSH,omitempty"`
+	ModemTunnelSSHKey        string `json:"ModemTunnelSSHKey,omitempty"`
+	ModemTunnelSSHKnownHosts string `json:"ModemTunnelSSHKnownHosts,omitempty"`
+	ModemTunnelInterface     string `json:"ModemTunnelInterface,omitempty"`
 
 	// Monitoring configuration
 	CheckInterval    string   `json:"CheckInterval,omitempty"`
@@ -57,20 +159,71 @@ type ConfigJSON struct {
 	RecoveryWait     string   `json:"RecoveryWait,omitempty"`
 	PingHosts        []string `json:"PingHosts,omitempty"`
 	HTTPHosts        []string `json:"HTTPHosts,omitempty"`
+	// AllowLocalTargets allows ping and HTTP targets on the local network
+	AllowLocalTargets *bool  `json:"AllowLocalTargets,omitempty"`
+	Responder         string `json:"Responder,omitempty"`
+	ResponderURL      string `json:"ResponderURL,omitempty"`
+	ResponderKey      string `json:"ResponderKey,omitempty"`
 
 	// Logging configuration
-	LogLevel    string `json:"LogLevel,omitempty"`
-	LogFile     string `json:"LogFile,omitempty"`
-	LogFormat   string `json:"LogFormat,omitempty"`
-	EnableDebug *bool  `json:"EnableDebug,omitempty"`
-	LogRotation *bool  `json:"LogRotation,omitempty"`
-	LogMaxSize  *int   `json:"LogMaxSize,omitempty"`
-	LogMaxAge   *int   `json:"LogMaxAge,omitempty"`
+	LogLevel string `json:"LogLevel,omitempty"`
+	// LogModuleLevels are module=level entries, e.g. "modem=trace"
+	LogModuleLevels []string `json:"LogModuleLevels,omitempty"`
+	LogFile         string   `json:"LogFile,omitempty"`
+	LogFormat       string   `json:"LogFormat,omitempty"`
+	EnableDebug     *bool    `json:"EnableDebug,omitempty"`
+	LogRotation     *bool    `json:"LogRotation,omitempty"`
+	LogMaxSize      *int     `json:"LogMaxSize,omitempty"`
+	LogMaxAge       *int     `json:"LogMaxAge,omitempty"`
 
 	// Enhanced features
-	EnableDiagnostics    *bool  `json:"EnableDiagnostics,omitempty"`
-	DiagnosticsTimeout   string `json:"DiagnosticsTimeout,omitempty"`
-	OutageReportInterval string `json:"OutageReportInterval,omitempty"`
+	EnableDiagnostics  *bool  `json:"EnableDiagnostics,omitempty"`
+	DiagnosticsTimeout string `json:"DiagnosticsTimeout,omitempty"`
+	// Diagnostic targets, empty ones derive from PingHosts and HTTPHosts
+	DiagnosticPingTargets []string `json:"DiagnosticPingTargets,omitempty"`
+	DiagnosticTCPTargets  []string `json:"DiagnosticTCPTargets,omitempty"`
+	DiagnosticDNSTargets  []string `json:"DiagnosticDNSTargets,omitempty"`
+	DiagnosticHTTPTargets []string `json:"DiagnosticHTTPTargets,omitempty"`
+	CycleBudget           string   `json:"CycleBudget,omitempty"`
+	CycleRetryBudget      *int     `json:"CycleRetryBudget,omitempty"`
+	// HealthWeights are input=weight entries, e.g. "network=4"
+	HealthWeights         []string `json:"HealthWeights,omitempty"`
+	HealthDegradedScore   *int     `json:"HealthDegradedScore,omitempty"`
+	HealthRebootScore     *int     `json:"HealthRebootScore,omitempty"`
+	CheckOverlapPolicy    string   `json:"CheckOverlapPolicy,omitempty"`
+	MessageTemplates      string   `json:"MessageTemplates,omitempty"`
+	NotificationQueueSize *int     `json:"NotificationQueueSize,omitempty"`
+	Language              string   `json:"Language,omitempty"`
+	OutageReportInterval  string   `json:"OutageReportInterval,omitempty"`
+
+	// Escalation to the comprehensive tests
+	EscalationFailures           *int   `json:"EscalationFailures,omitempty"`
+	EscalationValidationInterval string `json:"EscalationValidationInterval,omitempty"`
+	// EscalationWindows are HH:MM-HH:MM times of day, e.g. "01:00-05:00"
+	EscalationWindows []string `json:"EscalationWindows,omitempty"`
+
+	// Dependencies between checks, as check:dependency entries
+	CheckDependencies []string `json:"CheckDependencies,omitempty"`
+	GatewayTarget     string   `json:"GatewayTarget,omitempty"`
+
+	// Rotation and quarantine of check targets
+	TargetsPerCheck          *int   `json:"TargetsPerCheck,omitempty"`
+	TargetQuarantineFailures *int   `json:"TargetQuarantineFailures,omitempty"`
+	TargetQuarantineDuration string `json:"TargetQuarantineDuration,omitempty"`
+
+	// OutboundProxy is a proxy URL, or "direct"
+	OutboundProxy string `json:"OutboundProxy,omitempty"`
+
+	// WireGuard tunnel check of the lightweight tests
+	WireGuardInterface    string `json:"WireGuardInterface,omitempty"`
+	WireGuardPeer         string `json:"WireGuardPeer,omitempty"`
+	WireGuardHandshakeAge string `json:"WireGuardHandshakeAge,omitempty"`
+	WireGuardProbe        string `json:"WireGuardProbe,omitempty"`
+
+	// Low-power mode for battery or solar deployments
+	LowPowerMode          string `json:"LowPowerMode,omitempty"`
+	PowerSourceFile       string `json:"PowerSourceFile,omitempty"`
+	LowPowerCheckInterval string `json:"LowPowerCheckInterval,omitempty"`
 
 	// Reboot monitoring configuration
 	EnableRebootMonitoring *bool  `json:"EnableRebootMonitoring,omitempty"`
@@ -78,6 +231,14 @@ type ConfigJSON struct {
 	RebootOfflineTimeout   string `json:"RebootOfflineTimeout,omitempty"`
 	RebootOnlineTimeout    string `json:"RebootOnlineTimeout,omitempty"`
 
+	// Scheduled preventive reboot
+	ScheduledReboot           string `json:"ScheduledReboot,omitempty"`
+	ScheduledRebootSkipWithin string `json:"ScheduledRebootSkipWithin,omitempty"`
+
+	// Reboot workflow
+	RebootTimeout        string `json:"RebootTimeout,omitempty"`
+	RebootShutdownPolicy string `json:"RebootShutdownPolicy,omitempty"`
+
 	// Performance settings
 	MaxConcurrentTests *int     `json:"MaxConcurrentTests,omitempty"`
 	ConnectionTimeout  string   `json:"ConnectionTimeout,omitempty"`
@@ -90,41 +251,219 @@ type ConfigJSON struct {
 	StartupTimeLimitMS    *int   `json:"StartupTimeLimitMS,omitempty"`
 	EnableResourceLimits  *bool  `json:"EnableResourceLimits,omitempty"`
 	ResourceCheckInterval string `json:"ResourceCheckInterval,omitempty"`
+	MetricsMaxOperations  *int   `json:"MetricsMaxOperations,omitempty"`
+	MetricsRetention      string `json:"MetricsRetention,omitempty"`
+	HistoryRawRetention   string `json:"HistoryRawRetention,omitempty"`
+	HistoryMaxAge         *int   `json:"HistoryMaxAge,omitempty"`
+	HistoryMaxEvents      *int   `json:"HistoryMaxEvents,omitempty"`
+	HistoryMaxSize        *int   `json:"HistoryMaxSize,omitempty"`
+	ReportMaxAge          *int   `json:"ReportMaxAge,omitempty"`
+	ReportMaxSize         *int   `json:"ReportMaxSize,omitempty"`
 
 	// System settings
 	EnableSystemd    *bool  `json:"EnableSystemd,omitempty"`
 	PidFile          string `json:"PidFile,omitempty"`
 	WorkingDirectory string `json:"WorkingDirectory,omitempty"`
+	StateDirectory   string `json:"StateDirectory,omitempty"`
+	Sandbox          *bool  `json:"Sandbox,omitempty"`
+	StartupCheck     *bool  `json:"StartupCheck,omitempty"`
+	// Optional subsystems switched off, such as ["history", "notifications"]
+	DisabledFeatures []string `json:"DisabledFeatures,omitempty"`
+
+	// Local API
+	APIListenAddress     string `json:"APIListenAddress,omitempty"`
+	APIUsersFile         string `json:"APIUsersFile,omitempty"`
+	APITLS               string `json:"APITLS,omitempty"`
+	APITLSCert           string `json:"APITLSCert,omitempty"`
+	APITLSKey            string `json:"APITLSKey,omitempty"`
+	APIActionLimit       *int   `json:"APIActionLimit,omitempty"`
+	APIActionWindow      string `json:"APIActionWindow,omitempty"`
+	APIRefreshInterval   string `json:"APIRefreshInterval,omitempty"`
+	AuditLogFile         string `json:"AuditLogFile,omitempty"`
+	APIKeysFile          string `json:"APIKeysFile,omitempty"`
+	EnableFaultInjection *bool  `json:"EnableFaultInjection,omitempty"`
+
+	// Recovery actions
+	DockerRestartContainers []string `json:"DockerRestartContainers,omitempty"`
+	DockerRestartAfter      string   `json:"DockerRestartAfter,omitempty"`
+	DockerSocket            string   `json:"DockerSocket,omitempty"`
+
+	// Public IP and dynamic DNS
+	PublicIPCheck        *bool    `json:"PublicIPCheck,omitempty"`
+	PublicIPServices     []string `json:"PublicIPServices,omitempty"`
+	PublicIPRecordCycles *int     `json:"PublicIPRecordCycles,omitempty"`
+	PublicIPGeoService   string   `json:"PublicIPGeoService,omitempty"`
+	RoutingProbe         *bool    `json:"RoutingProbe,omitempty"`
+	RoutingProbeTargets  []string `json:"RoutingProbeTargets,omitempty"`
+	DDNSProvider         string   `json:"DDNSProvider,omitempty"`
+	DDNSDomain           string   `json:"DDNSDomain,omitempty"`
+	DDNSToken            string   `json:"DDNSToken,omitempty"`
+	DDNSZoneID           string   `json:"DDNSZoneID,omitempty"`
+	DDNSScript           string   `json:"DDNSScript,omitempty"`
+
+	// SMS notifications
+	SMSProvider      string   `json:"SMSProvider,omitempty"`
+	SMSTo            []string `json:"SMSTo,omitempty"`
+	SMSDevice        string   `json:"SMSDevice,omitempty"`
+	TwilioAccountSID string   `json:"TwilioAccountSID,omitempty"`
+	TwilioAuthToken  string   `json:"TwilioAuthToken,omitempty"`
+	TwilioFrom       string   `json:"TwilioFrom,omitempty"`
+
+	// Status beacon
+	BeaconURL       string `json:"BeaconURL,omitempty"`
+	BeaconInterface string `json:"BeaconInterface,omitempty"`
+	BeaconInterval  string `json:"BeaconInterval,omitempty"`
+	BeaconToken     string `json:"BeaconToken,omitempty"`
+
+	// node_exporter textfile
+	TextfilePath     string `json:"TextfilePath,omitempty"`
+	TextfileInterval string `json:"TextfileInterval,omitempty"`
+
+	// Scheduled backups
+	BackupInterval       string `json:"BackupInterval,omitempty"`
+	BackupDestination    string `json:"BackupDestination,omitempty"`
+	BackupKeep           *int   `json:"BackupKeep,omitempty"`
+	BackupS3Endpoint     string `json:"BackupS3Endpoint,omitempty"`
+	BackupS3Region       string `json:"BackupS3Region,omitempty"`
+	BackupS3AccessKey    string `json:"BackupS3AccessKey,omitempty"`
+	BackupS3SecretKey    string `json:"BackupS3SecretKey,omitempty"`
+	BackupSFTPKey        string `json:"BackupSFTPKey,omitempty"`
+	BackupSFTPKnownHosts string `json:"BackupSFTPKnownHosts,omitempty"`
+
+	// Remote sync
+	SyncInterval    string `json:"SyncInterval,omitempty"`
+	SyncDestination string `json:"SyncDestination,omitempty"`
+	SyncSite        string `json:"SyncSite,omitempty"`
+
+	// Active/standby cluster
+	ClusterRole              string `json:"ClusterRole,omitempty"`
+	ClusterPeer              string `json:"ClusterPeer,omitempty"`
+	ClusterPeerKey           string `json:"ClusterPeerKey,omitempty"`
+	ClusterPeerFingerprint   string `json:"ClusterPeerFingerprint,omitempty"`
+	ClusterHeartbeatInterval string `json:"ClusterHeartbeatInterval,omitempty"`
+	ClusterFailoverAfter     string `json:"ClusterFailoverAfter,omitempty"`
+
+	// Reboot quorum
+	QuorumPeers    []string `json:"QuorumPeers,omitempty"`
+	QuorumKey      string   `json:"QuorumKey,omitempty"`
+	QuorumRequired *int     `json:"QuorumRequired,omitempty"`
+	QuorumMaxAge   string   `json:"QuorumMaxAge,omitempty"`
+	QuorumNoVerify *bool    `json:"QuorumNoVerify,omitempty"`
+
+	// Monitoring host health
+	HostHealthCheck *bool    `json:"HostHealthCheck,omitempty"`
+	HostMaxCPU      *float64 `json:"HostMaxCPU,omitempty"`
+
+	// Failure verdict actions
+	LANFailureAction      string `json:"LANFailureAction,omitempty"`
+	WANFailureAction      string `json:"WANFailureAction,omitempty"`
+	UpstreamFailureAction string `json:"UpstreamFailureAction,omitempty"`
+
+	// Plugins
+	Plugins []string `json:"Plugins,omitempty"`
 }
 
 // Config holds all configuration parameters for the watchdog service
 type Config struct {
+	// ConfigFile is the file the configuration was loaded from, read again
+	// when the configuration is reloaded
+	ConfigFile string
+
 	// Modem configuration
+	ModemType     string // mb8600, arris-sb, netgear-cm, technicolor
 	ModemHost     string
 	ModemUsername string
 	ModemPassword string
 	ModemNoVerify bool
+	ModemPort     int // port of the modem web interface over HTTP and HTTPS, 0 for the scheme default
+	// ModemStatsInterval is how often the modem's channel statistics are
+	// read for the metrics endpoint; 0 reads them at startup only
+	ModemStatsInterval time.Duration
+	// PartialServiceChannels is how many downstream channels must be
+	// unlocked for the modem to count as in partial service; 0 disables it
+	PartialServiceChannels    int
+	PartialServiceRebootAfter time.Duration // reboot once partial service has lasted this long, 0 to only notify
+	ModemMaxTemperature       float64       // modem temperature in Celsius above which it counts as overheating, 0 to never alert
+	// DOCSISTimeoutsPerHour and DOCSISTimeoutsPerDay are the T3 and T4
+	// timeouts in the modem's event log that bring the docsis_timeouts
+	// health input to 0; 0 leaves a window out
+	DOCSISTimeoutsPerHour int
+	DOCSISTimeoutsPerDay  int
+
+	// Tunnel to a modem that is not on the local network, for a watchdog
+	// running off-site; at most one of ModemTunnelSSH and ModemTunnelInterface
+	ModemTunnelSSH           string // SSH jump host as [user@]host[:port]
+	ModemTunnelSSHKey        string // identity file for the jump host, empty for the ssh defaults
+	ModemTunnelSSHKnownHosts string // known hosts file for the jump host, empty for the ssh default
+	ModemTunnelInterface     string // interface to the modem's network, such as wg0
 
 	// Monitoring configuration
-	CheckInterval    time.Duration
-	FailureThreshold int
-	RecoveryWait     time.Duration
-	PingHosts        []string
-	HTTPHosts        []string
+	CheckInterval     time.Duration
+	FailureThreshold  int
+	RecoveryWait      time.Duration
+	PingHosts         []string
+	HTTPHosts         []string
+	AllowLocalTargets bool   // allow PingHosts and HTTPHosts on the local network or on the modem, which answer while the internet is down
+	Responder         string // host:port of a self-hosted responder that replaces PingHosts and DNS tests, empty disables
+	ResponderURL      string // HTTP check URL of the responder that replaces HTTPHosts, empty skips HTTP tests with a responder
+	ResponderKey      string // key shared with the responder to verify its signed answers, empty checks the nonce only
 
 	// Logging configuration
-	LogLevel    string
-	LogFile     string
-	LogFormat   string // console, json, file
-	EnableDebug bool
-	LogRotation bool
-	LogMaxSize  int // MB
-	LogMaxAge   int // days
+	LogLevel        string
+	LogModuleLevels []string // per-module levels as module=level; other modules use LogLevel
+	LogFile         string   // empty or LogFileNone logs to stdout only
+	LogFormat       string   // console, json, file
+	EnableDebug     bool
+	LogRotation     bool
+	LogMaxSize      int // MB
+	LogMaxAge       int // days
 
 	// Enhanced features
-	EnableDiagnostics    bool
-	DiagnosticsTimeout   time.Duration
-	OutageReportInterval time.Duration
+	EnableDiagnostics  bool
+	DiagnosticsTimeout time.Duration
+	// Diagnostic targets; an empty list derives its targets from PingHosts and HTTPHosts
+	DiagnosticPingTargets []string      // hosts pinged during diagnostics
+	DiagnosticTCPTargets  []string      // host:port addresses connected to during diagnostics
+	DiagnosticDNSTargets  []string      // domains resolved during diagnostics
+	DiagnosticHTTPTargets []string      // URLs requested during diagnostics
+	CycleBudget           time.Duration // time a check cycle may spend testing and diagnosing, 0 uses CheckInterval
+	CycleRetryBudget      int           // retries a check cycle may spend across all targets, 0 uses DefaultCycleRetryBudget
+	HealthWeights         []string      // health score weights as input=weight; other inputs keep their default weight
+	HealthDegradedScore   int           // health score below which the connection is DEGRADED, 0 uses the default
+	HealthRebootScore     int           // health score below which a check fails and a reboot is recommended, 0 uses the default
+	CheckOverlapPolicy    string        // skip or queue a check that comes due while the previous one still runs
+	MessageTemplates      string        // template file or directory overriding notification and report text
+	NotificationQueueSize int           // undeliverable notifications kept for delivery once connectivity returns, 0 drops them
+	Language              string        // language of CLI output and notifications, empty detects it from the locale
+	OutageReportInterval  time.Duration
+
+	// Escalation policy of the connectivity checks
+	EscalationFailures           int           // consecutive failed checks that make the next one comprehensive, 0 never
+	EscalationValidationInterval time.Duration // run the comprehensive tests at least this often, 0 never
+	EscalationWindows            []string      // HH:MM-HH:MM times of day during which every check is comprehensive
+
+	// Dependencies between connectivity checks
+	CheckDependencies []string // check:dependency entries, such as http:dns; failures of a check whose dependency failed are suppressed
+	GatewayTarget     string   // host[:port] of the local gateway the gateway check connects to, empty disables it
+
+	// Rotation and quarantine of check targets
+	TargetsPerCheck          int           // targets of each kind a check uses, taking turns; 0 uses all
+	TargetQuarantineFailures int           // failures of a target while others passed, in its last 20 checks, that quarantine it; 0 never
+	TargetQuarantineDuration time.Duration // how long a quarantined target sits out
+
+	// Proxy of outbound requests other than to the modem
+	OutboundProxy string // proxy URL, ProxyDirect for none, empty follows HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+
+	// WireGuard tunnel check of the lightweight tests
+	WireGuardInterface    string        // WireGuard interface to check, such as wg0; empty disables the check
+	WireGuardPeer         string        // public key of the peer to check, empty for the peer with the latest handshake
+	WireGuardHandshakeAge time.Duration // how old the latest handshake may be
+	WireGuardProbe        string        // host:port across the tunnel to connect to, empty checks received traffic instead
+
+	// Low-power mode for battery or solar deployments
+	LowPowerMode          string        // off, on, or auto to follow PowerSourceFile
+	PowerSourceFile       string        // file reporting mains or battery power, such as /sys/class/power_supply/AC/online
+	LowPowerCheckInterval time.Duration // check interval in low-power mode
 
 	// Reboot monitoring configuration
 	EnableRebootMonitoring bool
@@ -132,6 +471,14 @@ type Config struct {
 	RebootOfflineTimeout   time.Duration
 	RebootOnlineTimeout    time.Duration
 
+	// Scheduled preventive reboot
+	ScheduledReboot           string        // cron expression of a reboot regardless of health, empty disables it
+	ScheduledRebootSkipWithin time.Duration // skip a scheduled reboot this soon after an outage or reboot
+
+	// Reboot workflow
+	RebootTimeout        time.Duration // deadline of a reboot and its recovery wait, 0 for the reboot command timeout plus RecoveryWait
+	RebootShutdownPolicy string        // wait for or abort a reboot in progress on shutdown
+
 	// Performance settings
 	MaxConcurrentTests int
 	ConnectionTimeout  time.Duration
@@ -144,42 +491,208 @@ type Config struct {
 	StartupTimeLimitMS    int           // Startup time limit in milliseconds (0 = no limit)
 	EnableResourceLimits  bool          // Enable resource monitoring and limits
 	ResourceCheckInterval time.Duration // Interval for resource monitoring checks
+	MetricsMaxOperations  int           // Operations, and targets, tracked in performance metrics (0 = no cap)
+	MetricsRetention      time.Duration // Operations not recorded for this long are pruned (0 = never)
+	HistoryRawRetention   time.Duration // Check results are kept this long, then downsampled to hourly aggregates (0 = not recorded)
+	HistoryMaxAge         int           // days of history kept (0 = no limit)
+	HistoryMaxEvents      int           // events kept in the history (0 = no limit)
+	HistoryMaxSize        int           // MB of history kept (0 = no limit)
+	ReportMaxAge          int           // days outage reports are kept (0 = no limit)
+	ReportMaxSize         int           // MB of outage reports kept (0 = no limit)
 
 	// System settings
 	EnableSystemd    bool
 	PidFile          string
 	WorkingDirectory string
+	StateDirectory   string   // state, reports, history, API keys and certificates; empty uses the working directory
+	Sandbox          bool     // confine the process with landlock and seccomp
+	StartupCheck     bool     // check the configuration, modem login, targets and paths at startup and report problems
+	DisabledFeatures []string // optional subsystems switched off at runtime, see internal/features
+
+	// Local API
+	APIListenAddress     string        // host:port of the local HTTP API, empty disables it
+	APIUsersFile         string        // JSON file of API users and roles, empty leaves the API unauthenticated
+	APITLS               string        // "auto" serves the API over HTTPS, "off" over plain HTTP
+	APITLSCert           string        // certificate file of the API, empty uses a persisted self-signed one
+	APITLSKey            string        // private key file matching APITLSCert
+	APIActionLimit       int           // control actions allowed per APIActionWindow, 0 disables the limit
+	APIActionWindow      time.Duration // sliding window of APIActionLimit
+	APIRefreshInterval   time.Duration // a forced status refresh reuses a check completed this recently
+	AuditLogFile         string        // append-only log of control actions, empty uses logs/audit.log in the state directory
+	APIKeysFile          string        // API keys managed with the api-key command, empty uses api-keys.json in the state directory
+	EnableFaultInjection bool          // expose fault injection under /api/v1/debug/faults
+
+	// Recovery actions
+	DockerRestartContainers []string      // containers restarted after connectivity returns from a long outage
+	DockerRestartAfter      time.Duration // minimum outage length that restarts DockerRestartContainers
+	DockerSocket            string        // Docker Engine API socket
+
+	// Public IP and dynamic DNS
+	PublicIPCheck        bool     // look up the public IP after outages and record changes
+	PublicIPServices     []string // IP echo services, empty uses the built-in list
+	PublicIPRecordCycles int      // record the public IP in the history every N check cycles, 0 disables
+	PublicIPGeoService   string   // geolocation service URL with an {ip} placeholder, empty disables
+	RoutingProbe         bool     // compare anycast targets during diagnostics to detect ISP routing incidents
+	RoutingProbeTargets  []string // host:port routing probe targets, empty uses the built-in list
+	DDNSProvider         string   // cloudflare, duckdns or script, empty disables DDNS updates
+	DDNSDomain           string   // record name (cloudflare) or subdomain (duckdns)
+	DDNSToken            string   // API token of the DDNS provider
+	DDNSZoneID           string   // Cloudflare zone of DDNSDomain
+	DDNSScript           string   // script run with the old and new IP for the script provider
+
+	// SMS notifications
+	SMSProvider      string   // twilio or gsm, empty disables SMS notifications
+	SMSTo            []string // recipients' phone numbers, such as +15551234567
+	SMSDevice        string   // serial port of the GSM modem, such as /dev/ttyUSB2
+	TwilioAccountSID string   // Twilio account SID
+	TwilioAuthToken  string   // Twilio auth token
+	TwilioFrom       string   // Twilio phone number or messaging service SID to send from
+
+	// Status beacon
+	BeaconURL       string        // endpoint receiving status reports, empty disables the beacon
+	BeaconInterface string        // secondary interface the beacon leaves through, such as wwan0
+	BeaconInterval  time.Duration // time between status reports
+	BeaconToken     string        // bearer token sent with status reports
+
+	// node_exporter textfile
+	TextfilePath     string        // .prom file metrics are written to, empty disables it
+	TextfileInterval time.Duration // time between rewrites of the file
+
+	// Scheduled backups
+	BackupInterval       time.Duration // time between backups, 0 disables them
+	BackupDestination    string        // directory, or s3://bucket/prefix, backups are stored in
+	BackupKeep           int           // backups kept in a directory, 0 keeps all
+	BackupS3Endpoint     string        // S3-compatible endpoint, by default AWS in BackupS3Region
+	BackupS3Region       string        // region S3 requests are signed for
+	BackupS3AccessKey    string        // S3 access key ID
+	BackupS3SecretKey    string        // S3 secret access key
+	BackupSFTPKey        string        // private key for sftp:// destinations, by default those of ssh
+	BackupSFTPKnownHosts string        // known hosts file for sftp:// destinations, by default that of ssh
+
+	// Remote sync
+	SyncInterval    time.Duration // time between uploads of the history and reports, 0 disables them
+	SyncDestination string        // directory, s3://, webdav(s):// or sftp:// URL the files are uploaded to
+	SyncSite        string        // folder of this host at the destination, by default the hostname
+
+	// Active/standby cluster
+	ClusterRole              string        // primary or standby, empty when not clustered
+	ClusterPeer              string        // URL of the other node's API
+	ClusterPeerKey           string        // API key of the other node with the read scope
+	ClusterPeerFingerprint   string        // SHA-256 fingerprint of the other node's self-signed API certificate
+	ClusterHeartbeatInterval time.Duration // time between polls of the other node
+	ClusterFailoverAfter     time.Duration // silence of the primary after which the standby takes over
+
+	// Reboot quorum
+	QuorumPeers    []string      // URLs of the other agents' APIs asked before an automatic reboot, empty disables the quorum
+	QuorumKey      string        // API key with the read scope sent to the agents
+	QuorumRequired int           // agents, this one included, that must see the internet down; 0 is a majority
+	QuorumMaxAge   time.Duration // how old an agent's last check may be to count
+	QuorumNoVerify bool          // skip verifying the agents' TLS certificates
+
+	// Monitoring host health
+	HostHealthCheck bool    // hold reboots back while this host looks broken
+	HostMaxCPU      float64 // CPU usage in percent above which the host counts as degraded
+
+	// Failure verdict actions: reboot, wait or notify
+	LANFailureAction      string // between this host and the modem
+	WANFailureAction      string // at the modem or its line
+	UpstreamFailureAction string // beyond the modem, at the ISP
+
+	// Plugins
+	Plugins []string // absolute paths of plugin programs started with the watchdog
+
+	// Home Assistant add-on
+	HomeAssistantOptionsFile string // add-on options.json, set by the add-on image to enable the integration
+
+	// toggles are the boolean settings the source of this configuration set
+	// explicitly, by environment variable, see toggleSettings
+	toggles map[string]Toggle
 }
 
 // Load loads configuration from environment variables with defaults
 func Load() (*Config, error) {
 	cfg := &Config{
 		// Default values for modem configuration
-		ModemHost:     getEnvString("MODEM_HOST", DefaultModemHost),
-		ModemUsername: getEnvString("MODEM_USERNAME", "admin"),
-		ModemPassword: getEnvString("MODEM_PASSWORD", "motorola"),
-		ModemNoVerify: getEnvBool("MODEM_NOVERIFY", true),
+		ModemType:          getEnvString("MODEM_TYPE", DefaultModemType),
+		ModemHost:          getEnvString("MODEM_HOST", DefaultModemHost),
+		ModemUsername:      getEnvString("MODEM_USERNAME", "admin"),
+		ModemPassword:      getEnvString("MODEM_PASSWORD", "motorola"),
+		ModemNoVerify:      getEnvBool("MODEM_NOVERIFY", true),
+		ModemPort:          getEnvInt("MODEM_PORT", 0),
+		ModemStatsInterval: getEnvDuration("MODEM_STATS_INTERVAL", 0),
+
+		PartialServiceChannels:    getEnvInt("PARTIAL_SERVICE_CHANNELS", DefaultPartialServiceChannels),
+		PartialServiceRebootAfter: getEnvDuration("PARTIAL_SERVICE_REBOOT_AFTER", 0),
+		ModemMaxTemperature:       getEnvFloat("MODEM_MAX_TEMPERATURE", DefaultModemMaxTemperature),
+		DOCSISTimeoutsPerHour:     getEnvInt("DOCSIS_TIMEOUTS_PER_HOUR", DefaultDOCSISTimeoutsPerHour),
+		DOCSISTimeoutsPerDay:      getEnvInt("DOCSIS_TIMEOUTS_PER_DAY", DefaultDOCSISTimeoutsPerDay),
+
+		ModemTunnelSSH:           getEnvString("MODEM_TUNNEL_SSH", ""),
+		ModemTunnelSSHKey:        getEnvString("MODEM_TUNNEL_SSH_KEY", ""),
+		ModemTunnelSSHKnownHosts: getEnvString("MODEM_TUNNEL_SSH_KNOWN_HOSTS", ""),
+		ModemTunnelInterface:     getEnvString("MODEM_TUNNEL_INTERFACE", ""),
 
 		// Default values for monitoring configuration
-		CheckInterval:    getEnvDuration("CHECK_INTERVAL", DefaultCheckInterval),
-		FailureThreshold: getEnvInt("FAILURE_THRESHOLD", DefaultFailureThreshold),
-		RecoveryWait:     getEnvDuration("RECOVERY_WAIT", DefaultRecoveryWait),
-		PingHosts:        getEnvStringSlice("PING_HOSTS", getDefaultPingHosts()),
-		HTTPHosts:        getEnvStringSlice("HTTP_HOSTS", getDefaultHTTPHosts()),
+		CheckInterval:     getEnvDuration("CHECK_INTERVAL", DefaultCheckInterval),
+		FailureThreshold:  getEnvInt("FAILURE_THRESHOLD", DefaultFailureThreshold),
+		RecoveryWait:      getEnvDuration("RECOVERY_WAIT", DefaultRecoveryWait),
+		PingHosts:         getEnvStringSlice("PING_HOSTS", getDefaultPingHosts()),
+		HTTPHosts:         getEnvStringSlice("HTTP_HOSTS", getDefaultHTTPHosts()),
+		AllowLocalTargets: getEnvBool("ALLOW_LOCAL_TARGETS", false),
+		Responder:         getEnvString("RESPONDER", ""),
+		ResponderURL:      getEnvString("RESPONDER_URL", ""),
+		ResponderKey:      getEnvString("RESPONDER_KEY", ""),
 
 		// Default values for logging configuration
-		LogLevel:    getEnvString("LOG_LEVEL", DefaultLogLevel),
-		LogFile:     getEnvString("LOG_FILE", DefaultLogFile),
-		LogFormat:   getEnvString("LOG_FORMAT", DefaultLogFormat),
-		EnableDebug: getEnvBool("ENABLE_DEBUG", false),
-		LogRotation: getEnvBool("LOG_ROTATION", true),
-		LogMaxSize:  getEnvInt("LOG_MAX_SIZE", DefaultLogMaxSize),
-		LogMaxAge:   getEnvInt("LOG_MAX_AGE", DefaultLogMaxAge),
+		LogLevel:        getEnvString("LOG_LEVEL", DefaultLogLevel),
+		LogModuleLevels: getEnvStringSlice("LOG_MODULE_LEVELS", nil),
+		LogFile:         getEnvString("LOG_FILE", DefaultLogFile),
+		LogFormat:       getEnvString("LOG_FORMAT", DefaultLogFormat),
+		EnableDebug:     getEnvBool("ENABLE_DEBUG", false),
+		LogRotation:     getEnvBool("LOG_ROTATION", true),
+		LogMaxSize:      getEnvInt("LOG_MAX_SIZE", DefaultLogMaxSize),
+		LogMaxAge:       getEnvInt("LOG_MAX_AGE", DefaultLogMaxAge),
 
 		// Default values for enhanced features
-		EnableDiagnostics:    getEnvBool("ENABLE_DIAGNOSTICS", true),
-		DiagnosticsTimeout:   getEnvDuration("DIAGNOSTICS_TIMEOUT", 120*time.Second),
-		OutageReportInterval: getEnvDuration("OUTAGE_REPORT_INTERVAL", 3600*time.Second),
+		EnableDiagnostics:     getEnvBool("ENABLE_DIAGNOSTICS", true),
+		DiagnosticsTimeout:    getEnvDuration("DIAGNOSTICS_TIMEOUT", 120*time.Second),
+		DiagnosticPingTargets: getEnvStringSlice("DIAGNOSTIC_PING_TARGETS", nil),
+		DiagnosticTCPTargets:  getEnvStringSlice("DIAGNOSTIC_TCP_TARGETS", nil),
+		DiagnosticDNSTargets:  getEnvStringSlice("DIAGNOSTIC_DNS_TARGETS", nil),
+		DiagnosticHTTPTargets: getEnvStringSlice("DIAGNOSTIC_HTTP_TARGETS", nil),
+		CycleBudget:           getEnvDuration("CYCLE_BUDGET", 0),
+		CycleRetryBudget:      getEnvInt("CYCLE_RETRY_BUDGET", 0),
+		HealthWeights:         getEnvStringSlice("HEALTH_WEIGHTS", nil),
+		HealthDegradedScore:   getEnvInt("HEALTH_DEGRADED_SCORE", 0),
+		HealthRebootScore:     getEnvInt("HEALTH_REBOOT_SCORE", 0),
+		CheckOverlapPolicy:    getEnvString("CHECK_OVERLAP_POLICY", DefaultCheckOverlapPolicy),
+		MessageTemplates:      getEnvString("MESSAGE_TEMPLATES", ""),
+		NotificationQueueSize: getEnvInt("NOTIFICATION_QUEUE_SIZE", notify.DefaultQueueSize),
+		Language:              getEnvString("WATCHDOG_LANGUAGE", ""),
+		OutageReportInterval:  getEnvDuration("OUTAGE_REPORT_INTERVAL", 3600*time.Second),
+
+		// Escalation to the comprehensive tests
+		EscalationFailures:           getEnvInt("ESCALATION_FAILURES", connectivity.DefaultEscalationFailures),
+		EscalationValidationInterval: getEnvDuration("ESCALATION_VALIDATION_INTERVAL", connectivity.DefaultEscalationValidationInterval),
+		EscalationWindows:            getEnvStringSlice("ESCALATION_WINDOWS", nil),
+
+		CheckDependencies: getEnvStringSlice("CHECK_DEPENDENCIES", nil),
+		GatewayTarget:     getEnvString("GATEWAY_TARGET", ""),
+
+		TargetsPerCheck:          getEnvInt("TARGETS_PER_CHECK", 0),
+		TargetQuarantineFailures: getEnvInt("TARGET_QUARANTINE_FAILURES", connectivity.DefaultQuarantineFailures),
+		TargetQuarantineDuration: getEnvDuration("TARGET_QUARANTINE_DURATION", connectivity.DefaultQuarantineDuration),
+
+		OutboundProxy: getEnvString("OUTBOUND_PROXY", ""),
+
+		WireGuardInterface:    getEnvString("WIREGUARD_INTERFACE", ""),
+		WireGuardPeer:         getEnvString("WIREGUARD_PEER", ""),
+		WireGuardHandshakeAge: getEnvDuration("WIREGUARD_HANDSHAKE_AGE", connectivity.DefaultWireGuardHandshakeAge),
+		WireGuardProbe:        getEnvString("WIREGUARD_PROBE", ""),
+
+		LowPowerMode:          getEnvString("LOW_POWER_MODE", power.ModeOff),
+		PowerSourceFile:       getEnvString("POWER_SOURCE_FILE", ""),
+		LowPowerCheckInterval: getEnvDuration("LOW_POWER_CHECK_INTERVAL", power.DefaultCheckInterval),
 
 		// Default values for reboot monitoring
 		EnableRebootMonitoring: getEnvBool("ENABLE_REBOOT_MONITORING", true),
@@ -187,6 +700,12 @@ func Load() (*Config, error) {
 		RebootOfflineTimeout:   getEnvDuration("REBOOT_OFFLINE_TIMEOUT", 120*time.Second),
 		RebootOnlineTimeout:    getEnvDuration("REBOOT_ONLINE_TIMEOUT", 300*time.Second),
 
+		ScheduledReboot:           getEnvString("SCHEDULED_REBOOT", ""),
+		ScheduledRebootSkipWithin: getEnvDuration("SCHEDULED_REBOOT_SKIP_WITHIN", DefaultScheduledRebootSkipWithin),
+
+		RebootTimeout:        getEnvDuration("REBOOT_TIMEOUT", 0),
+		RebootShutdownPolicy: getEnvString("REBOOT_SHUTDOWN_POLICY", DefaultRebootShutdownPolicy),
+
 		// Default values for performance settings
 		MaxConcurrentTests: getEnvInt("MAX_CONCURRENT_TESTS", DefaultMaxConcurrentTests),
 		ConnectionTimeout:  getEnvDuration("CONNECTION_TIMEOUT", DefaultTimeout),
@@ -199,11 +718,118 @@ func Load() (*Config, error) {
 		StartupTimeLimitMS:    getEnvInt("STARTUP_TIME_LIMIT_MS", DefaultStartupTimeLimitMS),
 		EnableResourceLimits:  getEnvBool("ENABLE_RESOURCE_LIMITS", true),
 		ResourceCheckInterval: getEnvDuration("RESOURCE_CHECK_INTERVAL", DefaultResourceCheckInterval),
+		MetricsMaxOperations:  getEnvInt("METRICS_MAX_OPERATIONS", DefaultMetricsMaxOperations),
+		MetricsRetention:      getEnvDuration("METRICS_RETENTION", DefaultMetricsRetention),
+		HistoryRawRetention:   getEnvDuration("HISTORY_RAW_RETENTION", DefaultHistoryRawRetention),
+		HistoryMaxAge:         getEnvInt("HISTORY_MAX_AGE", 0),
+		HistoryMaxEvents:      getEnvInt("HISTORY_MAX_EVENTS", 0),
+		HistoryMaxSize:        getEnvInt("HISTORY_MAX_SIZE", DefaultHistoryMaxSize),
+		ReportMaxAge:          getEnvInt("REPORT_MAX_AGE", DefaultReportMaxAge),
+		ReportMaxSize:         getEnvInt("REPORT_MAX_SIZE", DefaultReportMaxSize),
 
 		// Default values for system settings
 		EnableSystemd:    getEnvBool("ENABLE_SYSTEMD", false),
-		PidFile:          getEnvString("PID_FILE", DefaultPidFile),
+		PidFile:          getEnvString("PID_FILE", defaultPidFile()),
 		WorkingDirectory: getEnvString("WORKING_DIRECTORY", DefaultWorkingDirectory),
+		StateDirectory:   getEnvDirectory("STATE_DIRECTORY"),
+		Sandbox:          getEnvBool("SANDBOX", false),
+		StartupCheck:     getEnvBool("STARTUP_CHECK", true),
+		DisabledFeatures: getEnvStringSlice("DISABLED_FEATURES", nil),
+
+		// Default values for the local API
+		APIListenAddress:     getEnvString("API_LISTEN_ADDRESS", ""),
+		APIUsersFile:         getEnvString("API_USERS_FILE", ""),
+		APITLS:               getEnvString("API_TLS", DefaultAPITLS),
+		APITLSCert:           getEnvString("API_TLS_CERT", ""),
+		APITLSKey:            getEnvString("API_TLS_KEY", ""),
+		APIActionLimit:       getEnvInt("API_ACTION_LIMIT", DefaultAPIActionLimit),
+		APIActionWindow:      getEnvDuration("API_ACTION_WINDOW", DefaultAPIActionWindow),
+		APIRefreshInterval:   getEnvDuration("API_REFRESH_INTERVAL", DefaultAPIRefreshInterval),
+		AuditLogFile:         getEnvString("AUDIT_LOG_FILE", ""),
+		APIKeysFile:          getEnvString("API_KEYS_FILE", ""),
+		EnableFaultInjection: getEnvBool("ENABLE_FAULT_INJECTION", false),
+
+		DockerRestartContainers: getEnvStringSlice("DOCKER_RESTART_CONTAINERS", nil),
+		DockerRestartAfter:      getEnvDuration("DOCKER_RESTART_AFTER", DefaultDockerRestartAfter),
+		DockerSocket:            getEnvString("DOCKER_SOCKET", DefaultDockerSocket),
+
+		PublicIPCheck:        getEnvBool("PUBLIC_IP_CHECK", false),
+		PublicIPServices:     getEnvStringSlice("PUBLIC_IP_SERVICES", nil),
+		PublicIPRecordCycles: getEnvInt("PUBLIC_IP_RECORD_CYCLES", 0),
+		PublicIPGeoService:   getEnvString("PUBLIC_IP_GEO_SERVICE", ""),
+		RoutingProbe:         getEnvBool("ROUTING_PROBE", false),
+		RoutingProbeTargets:  getEnvStringSlice("ROUTING_PROBE_TARGETS", nil),
+		DDNSProvider:         getEnvString("DDNS_PROVIDER", ""),
+		DDNSDomain:           getEnvString("DDNS_DOMAIN", ""),
+		DDNSToken:            getEnvString("DDNS_TOKEN", ""),
+		DDNSZoneID:           getEnvString("DDNS_ZONE_ID", ""),
+		DDNSScript:           getEnvString("DDNS_SCRIPT", ""),
+
+		SMSProvider:      getEnvString("SMS_PROVIDER", ""),
+		SMSTo:            getEnvStringSlice("SMS_TO", nil),
+		SMSDevice:        getEnvString("SMS_DEVICE", ""),
+		TwilioAccountSID: getEnvString("TWILIO_ACCOUNT_SID", ""),
+		TwilioAuthToken:  getEnvString("TWILIO_AUTH_TOKEN", ""),
+		TwilioFrom:       getEnvString("TWILIO_FROM", ""),
+
+		BeaconURL:       getEnvString("BEACON_URL", ""),
+		BeaconInterface: getEnvString("BEACON_INTERFACE", ""),
+		BeaconInterval:  getEnvDuration("BEACON_INTERVAL", beacon.DefaultInterval),
+		BeaconToken:     getEnvString("BEACON_TOKEN", ""),
+
+		TextfilePath:     getEnvString("TEXTFILE_PATH", ""),
+		TextfileInterval: getEnvDuration("TEXTFILE_INTERVAL", textfile.DefaultInterval),
+
+		BackupInterval:       getEnvDuration("BACKUP_INTERVAL", 0),
+		BackupDestination:    getEnvString("BACKUP_DESTINATION", ""),
+		BackupKeep:           getEnvInt("BACKUP_KEEP", backup.DefaultKeep),
+		BackupS3Endpoint:     getEnvString("BACKUP_S3_ENDPOINT", ""),
+		BackupS3Region:       getEnvString("BACKUP_S3_REGION", backup.DefaultS3Region),
+		BackupS3AccessKey:    getEnvString("BACKUP_S3_ACCESS_KEY", ""),
+		BackupS3SecretKey:    getEnvString("BACKUP_S3_SECRET_KEY", ""),
+		BackupSFTPKey:        getEnvString("BACKUP_SFTP_KEY", ""),
+		BackupSFTPKnownHosts: getEnvString("BACKUP_SFTP_KNOWN_HOSTS", ""),
+
+		SyncInterval:    getEnvDuration("SYNC_INTERVAL", 0),
+		SyncDestination: getEnvString("SYNC_DESTINATION", ""),
+		SyncSite:        getEnvString("SYNC_SITE", ""),
+
+		ClusterRole:              getEnvString("CLUSTER_ROLE", ""),
+		ClusterPeer:              getEnvString("CLUSTER_PEER", ""),
+		ClusterPeerKey:           getEnvString("CLUSTER_PEER_KEY", ""),
+		ClusterPeerFingerprint:   getEnvString("CLUSTER_PEER_FINGERPRINT", ""),
+		ClusterHeartbeatInterval: getEnvDuration("CLUSTER_HEARTBEAT_INTERVAL", cluster.DefaultHeartbeatInterval),
+		ClusterFailoverAfter:     getEnvDuration("CLUSTER_FAILOVER_AFTER", cluster.DefaultFailoverAfter),
+
+		QuorumPeers:    getEnvStringSlice("QUORUM_PEERS", nil),
+		QuorumKey:      getEnvString("QUORUM_KEY", ""),
+		QuorumRequired: getEnvInt("QUORUM_REQUIRED", 0),
+		QuorumMaxAge:   getEnvDuration("QUORUM_MAX_AGE", quorum.DefaultMaxAge),
+		QuorumNoVerify: getEnvBool("QUORUM_NOVERIFY", false),
+
+		HostHealthCheck: getEnvBool("HOST_HEALTH_CHECK", true),
+		HostMaxCPU:      getEnvFloat("HOST_MAX_CPU", hosthealth.DefaultMaxCPU),
+
+		LANFailureAction:      getEnvString("LAN_FAILURE_ACTION", verdict.DefaultLANAction),
+		WANFailureAction:      getEnvString("WAN_FAILURE_ACTION", verdict.DefaultWANAction),
+		UpstreamFailureAction: getEnvString("UPSTREAM_FAILURE_ACTION", verdict.DefaultUpstreamAction),
+
+		Plugins: getEnvStringSlice("PLUGINS", nil),
+
+		HomeAssistantOptionsFile: getEnvString("HA_OPTIONS_FILE", ""),
+
+		toggles: envToggles(),
+	}
+
+	// Add-on options are the user's configuration, so they replace the
+	// environment defaults of the add-on image
+	if cfg.HomeAssistant() {
+		if err := applyHomeAssistantOptions(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.LogFile == LogFileNone {
+		cfg.LogFile = ""
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -222,6 +848,7 @@ func LoadFromFile(configPath string) (*Config, error) {
 	}
 
 	// If config file exists, load and merge it
+	cfg.ConfigFile = configPath
 	if configPath != "" {
 		if _, err := os.Stat(configPath); err == nil {
 			fileConfig, err := loadConfigFile(configPath)
@@ -231,6 +858,9 @@ func LoadFromFile(configPath string) (*Config, error) {
 
 			// Merge file config with environment config (environment takes precedence)
 			mergeConfigs(cfg, fileConfig)
+			if cfg.LogFile == LogFileNone {
+				cfg.LogFile = ""
+			}
 		}
 	}
 
@@ -260,24 +890,59 @@ func loadConfigFile(configPath string) (*Config, error) {
 
 	var jsonCfg ConfigJSON
 
-	// Determine file type by extension
+	// Determine file type by extension; OpenWrt UCI files have none
 	ext := strings.ToLower(filepath.Ext(configPath))
-	switch ext {
-	case ".json":
+	switch {
+	case ext == ".json":
+		if err := ValidateSchema(data); err != nil {
+			return nil, fmt.Errorf("config does not match schema: %w", err)
+		}
 		if err := json.Unmarshal(data, &jsonCfg); err != nil {
 			return nil, fmt.Errorf("failed to parse JSON config: %w", err)
 		}
+	case isUCI(data):
+		if err := decodeUCI(data, &jsonCfg); err != nil {
+			return nil, fmt.Errorf("failed to parse UCI config: %w", err)
+		}
 	default:
-		return nil, fmt.Errorf("unsupported config file format: %s (supported: .json)", ext)
+		return nil, fmt.Errorf("unsupported config file format: %s (supported: .json, OpenWrt UCI)", ext)
 	}
 
 	// Convert JSON config to regular config
-	cfg := &Config{}
+	cfg := &Config{toggles: fileToggles(&jsonCfg)}
 
 	// String fields
+	if jsonCfg.ModemType != "" {
+		cfg.ModemType = jsonCfg.ModemType
+	}
 	if jsonCfg.ModemHost != "" {
 		cfg.ModemHost = jsonCfg.ModemHost
 	}
+	if jsonCfg.ModemPort != nil {
+		cfg.ModemPort = *jsonCfg.ModemPort
+	}
+	if jsonCfg.ModemStatsInterval != "" {
+		if d, err := time.ParseDuration(jsonCfg.ModemStatsInterval); err == nil {
+			cfg.ModemStatsInterval = d
+		}
+	}
+	if jsonCfg.PartialServiceChannels != nil {
+		cfg.PartialServiceChannels = *jsonCfg.PartialServiceChannels
+	}
+	if jsonCfg.PartialServiceRebootAfter != "" {
+		if d, err := time.ParseDuration(jsonCfg.PartialServiceRebootAfter); err == nil {
+			cfg.PartialServiceRebootAfter = d
+		}
+	}
+	if jsonCfg.ModemMaxTemperature != nil {
+		cfg.ModemMaxTemperature = *jsonCfg.ModemMaxTemperature
+	}
+	if jsonCfg.DOCSISTimeoutsPerHour != nil {
+		cfg.DOCSISTimeoutsPerHour = *jsonCfg.DOCSISTimeoutsPerHour
+	}
+	if jsonCfg.DOCSISTimeoutsPerDay != nil {
+		cfg.DOCSISTimeoutsPerDay = *jsonCfg.DOCSISTimeoutsPerDay
+	}
 	if jsonCfg.ModemUsername != "" {
 		cfg.ModemUsername = jsonCfg.ModemUsername
 	}
@@ -287,6 +952,9 @@ func loadConfigFile(configPath string) (*Config, error) {
 	if jsonCfg.LogLevel != "" {
 		cfg.LogLevel = jsonCfg.LogLevel
 	}
+	if len(jsonCfg.LogModuleLevels) > 0 {
+		cfg.LogModuleLevels = jsonCfg.LogModuleLevels
+	}
 	if jsonCfg.LogFile != "" {
 		cfg.LogFile = jsonCfg.LogFile
 	}
@@ -299,6 +967,239 @@ func loadConfigFile(configPath string) (*Config, error) {
 	if jsonCfg.WorkingDirectory != "" {
 		cfg.WorkingDirectory = jsonCfg.WorkingDirectory
 	}
+	if jsonCfg.StateDirectory != "" {
+		cfg.StateDirectory = jsonCfg.StateDirectory
+	}
+	if jsonCfg.APIListenAddress != "" {
+		cfg.APIListenAddress = jsonCfg.APIListenAddress
+	}
+	if jsonCfg.APIUsersFile != "" {
+		cfg.APIUsersFile = jsonCfg.APIUsersFile
+	}
+	if jsonCfg.APITLS != "" {
+		cfg.APITLS = jsonCfg.APITLS
+	}
+	if jsonCfg.APITLSCert != "" {
+		cfg.APITLSCert = jsonCfg.APITLSCert
+	}
+	if jsonCfg.APITLSKey != "" {
+		cfg.APITLSKey = jsonCfg.APITLSKey
+	}
+	if jsonCfg.APIActionLimit != nil {
+		cfg.APIActionLimit = *jsonCfg.APIActionLimit
+	}
+	if jsonCfg.APIActionWindow != "" {
+		if d, err := time.ParseDuration(jsonCfg.APIActionWindow); err == nil {
+			cfg.APIActionWindow = d
+		}
+	}
+	if jsonCfg.APIRefreshInterval != "" {
+		if d, err := time.ParseDuration(jsonCfg.APIRefreshInterval); err == nil {
+			cfg.APIRefreshInterval = d
+		}
+	}
+	if jsonCfg.AuditLogFile != "" {
+		cfg.AuditLogFile = jsonCfg.AuditLogFile
+	}
+	if jsonCfg.APIKeysFile != "" {
+		cfg.APIKeysFile = jsonCfg.APIKeysFile
+	}
+	if len(jsonCfg.DockerRestartContainers) > 0 {
+		cfg.DockerRestartContainers = jsonCfg.DockerRestartContainers
+	}
+	if jsonCfg.DockerRestartAfter != "" {
+		if d, err := time.ParseDuration(jsonCfg.DockerRestartAfter); err == nil {
+			cfg.DockerRestartAfter = d
+		}
+	}
+	if jsonCfg.DockerSocket != "" {
+		cfg.DockerSocket = jsonCfg.DockerSocket
+	}
+	if jsonCfg.PublicIPCheck != nil {
+		cfg.PublicIPCheck = *jsonCfg.PublicIPCheck
+	}
+	if len(jsonCfg.DisabledFeatures) > 0 {
+		cfg.DisabledFeatures = jsonCfg.DisabledFeatures
+	}
+	if len(jsonCfg.PublicIPServices) > 0 {
+		cfg.PublicIPServices = jsonCfg.PublicIPServices
+	}
+	if jsonCfg.PublicIPRecordCycles != nil {
+		cfg.PublicIPRecordCycles = *jsonCfg.PublicIPRecordCycles
+	}
+	if jsonCfg.PublicIPGeoService != "" {
+		cfg.PublicIPGeoService = jsonCfg.PublicIPGeoService
+	}
+	if jsonCfg.RoutingProbe != nil {
+		cfg.RoutingProbe = *jsonCfg.RoutingProbe
+	}
+	if len(jsonCfg.RoutingProbeTargets) > 0 {
+		cfg.RoutingProbeTargets = jsonCfg.RoutingProbeTargets
+	}
+	if jsonCfg.DDNSProvider != "" {
+		cfg.DDNSProvider = jsonCfg.DDNSProvider
+	}
+	if jsonCfg.DDNSDomain != "" {
+		cfg.DDNSDomain = jsonCfg.DDNSDomain
+	}
+	if jsonCfg.DDNSToken != "" {
+		cfg.DDNSToken = jsonCfg.DDNSToken
+	}
+	if jsonCfg.DDNSZoneID != "" {
+		cfg.DDNSZoneID = jsonCfg.DDNSZoneID
+	}
+	if jsonCfg.DDNSScript != "" {
+		cfg.DDNSScript = jsonCfg.DDNSScript
+	}
+	if jsonCfg.SMSProvider != "" {
+		cfg.SMSProvider = jsonCfg.SMSProvider
+	}
+	if len(jsonCfg.SMSTo) > 0 {
+		cfg.SMSTo = jsonCfg.SMSTo
+	}
+	if jsonCfg.SMSDevice != "" {
+		cfg.SMSDevice = jsonCfg.SMSDevice
+	}
+	if jsonCfg.TwilioAccountSID != "" {
+		cfg.TwilioAccountSID = jsonCfg.TwilioAccountSID
+	}
+	if jsonCfg.TwilioAuthToken != "" {
+		cfg.TwilioAuthToken = jsonCfg.TwilioAuthToken
+	}
+	if jsonCfg.TwilioFrom != "" {
+		cfg.TwilioFrom = jsonCfg.TwilioFrom
+	}
+	if jsonCfg.BeaconURL != "" {
+		cfg.BeaconURL = jsonCfg.BeaconURL
+	}
+	if jsonCfg.BeaconInterface != "" {
+		cfg.BeaconInterface = jsonCfg.BeaconInterface
+	}
+	if jsonCfg.BeaconInterval != "" {
+		if d, err := time.ParseDuration(jsonCfg.BeaconInterval); err == nil {
+			cfg.BeaconInterval = d
+		}
+	}
+	if jsonCfg.BeaconToken != "" {
+		cfg.BeaconToken = jsonCfg.BeaconToken
+	}
+	if jsonCfg.TextfilePath != "" {
+		cfg.TextfilePath = jsonCfg.TextfilePath
+	}
+	if jsonCfg.TextfileInterval != "" {
+		if d, err := time.ParseDuration(jsonCfg.TextfileInterval); err == nil {
+			cfg.TextfileInterval = d
+		}
+	}
+	if jsonCfg.BackupInterval != "" {
+		if d, err := time.ParseDuration(jsonCfg.BackupInterval); err == nil {
+			cfg.BackupInterval = d
+		}
+	}
+	if jsonCfg.BackupDestination != "" {
+		cfg.BackupDestination = jsonCfg.BackupDestination
+	}
+	if jsonCfg.BackupKeep != nil {
+		cfg.BackupKeep = *jsonCfg.BackupKeep
+	}
+	if jsonCfg.BackupS3Endpoint != "" {
+		cfg.BackupS3Endpoint = jsonCfg.BackupS3Endpoint
+	}
+	if jsonCfg.BackupS3Region != "" {
+		cfg.BackupS3Region = jsonCfg.BackupS3Region
+	}
+	if jsonCfg.BackupS3AccessKey != "" {
+		cfg.BackupS3AccessKey = jsonCfg.BackupS3AccessKey
+	}
+	if jsonCfg.BackupS3SecretKey != "" {
+		cfg.BackupS3SecretKey = jsonCfg.BackupS3SecretKey
+	}
+	if jsonCfg.BackupSFTPKey != "" {
+		cfg.BackupSFTPKey = jsonCfg.BackupSFTPKey
+	}
+	if jsonCfg.BackupSFTPKnownHosts != "" {
+		cfg.BackupSFTPKnownHosts = jsonCfg.BackupSFTPKnownHosts
+	}
+	if jsonCfg.SyncInterval != "" {
+		if d, err := time.ParseDuration(jsonCfg.SyncInterval); err == nil {
+			cfg.SyncInterval = d
+		}
+	}
+	if jsonCfg.SyncDestination != "" {
+		cfg.SyncDestination = jsonCfg.SyncDestination
+	}
+	if jsonCfg.SyncSite != "" {
+		cfg.SyncSite = jsonCfg.SyncSite
+	}
+	if jsonCfg.ClusterRole != "" {
+		cfg.ClusterRole = jsonCfg.ClusterRole
+	}
+	if jsonCfg.ClusterPeer != "" {
+		cfg.ClusterPeer = jsonCfg.ClusterPeer
+	}
+	if jsonCfg.ClusterPeerKey != "" {
+		cfg.ClusterPeerKey = jsonCfg.ClusterPeerKey
+	}
+	if jsonCfg.ClusterPeerFingerprint != "" {
+		cfg.ClusterPeerFingerprint = jsonCfg.ClusterPeerFingerprint
+	}
+	if jsonCfg.ClusterHeartbeatInterval != "" {
+		if d, err := time.ParseDuration(jsonCfg.ClusterHeartbeatInterval); err == nil {
+			cfg.ClusterHeartbeatInterval = d
+		}
+	}
+	if jsonCfg.ClusterFailoverAfter != "" {
+		if d, err := time.ParseDuration(jsonCfg.ClusterFailoverAfter); err == nil {
+			cfg.ClusterFailoverAfter = d
+		}
+	}
+	if len(jsonCfg.QuorumPeers) > 0 {
+		cfg.QuorumPeers = jsonCfg.QuorumPeers
+	}
+	if jsonCfg.QuorumKey != "" {
+		cfg.QuorumKey = jsonCfg.QuorumKey
+	}
+	if jsonCfg.QuorumRequired != nil {
+		cfg.QuorumRequired = *jsonCfg.QuorumRequired
+	}
+	if jsonCfg.QuorumMaxAge != "" {
+		if d, err := time.ParseDuration(jsonCfg.QuorumMaxAge); err == nil {
+			cfg.QuorumMaxAge = d
+		}
+	}
+	if jsonCfg.QuorumNoVerify != nil {
+		cfg.QuorumNoVerify = *jsonCfg.QuorumNoVerify
+	}
+	if jsonCfg.HostHealthCheck != nil {
+		cfg.HostHealthCheck = *jsonCfg.HostHealthCheck
+	}
+	if jsonCfg.HostMaxCPU != nil {
+		cfg.HostMaxCPU = *jsonCfg.HostMaxCPU
+	}
+	if jsonCfg.LANFailureAction != "" {
+		cfg.LANFailureAction = jsonCfg.LANFailureAction
+	}
+	if jsonCfg.WANFailureAction != "" {
+		cfg.WANFailureAction = jsonCfg.WANFailureAction
+	}
+	if jsonCfg.UpstreamFailureAction != "" {
+		cfg.UpstreamFailureAction = jsonCfg.UpstreamFailureAction
+	}
+	if len(jsonCfg.Plugins) > 0 {
+		cfg.Plugins = jsonCfg.Plugins
+	}
+	if jsonCfg.ModemTunnelSSH != "" {
+		cfg.ModemTunnelSSH = jsonCfg.ModemTunnelSSH
+	}
+	if jsonCfg.ModemTunnelSSHKey != "" {
+		cfg.ModemTunnelSSHKey = jsonCfg.ModemTunnelSSHKey
+	}
+	if jsonCfg.ModemTunnelSSHKnownHosts != "" {
+		cfg.ModemTunnelSSHKnownHosts = jsonCfg.ModemTunnelSSHKnownHosts
+	}
+	if jsonCfg.ModemTunnelInterface != "" {
+		cfg.ModemTunnelInterface = jsonCfg.ModemTunnelInterface
+	}
 
 	// Bool pointers
 	if jsonCfg.ModemNoVerify != nil {
@@ -319,6 +1220,15 @@ func loadConfigFile(configPath string) (*Config, error) {
 	if jsonCfg.EnableSystemd != nil {
 		cfg.EnableSystemd = *jsonCfg.EnableSystemd
 	}
+	if jsonCfg.Sandbox != nil {
+		cfg.Sandbox = *jsonCfg.Sandbox
+	}
+	if jsonCfg.StartupCheck != nil {
+		cfg.StartupCheck = *jsonCfg.StartupCheck
+	}
+	if jsonCfg.EnableFaultInjection != nil {
+		cfg.EnableFaultInjection = *jsonCfg.EnableFaultInjection
+	}
 
 	// Int pointers
 	if jsonCfg.FailureThreshold != nil {
@@ -349,6 +1259,18 @@ func loadConfigFile(configPath string) (*Config, error) {
 	if len(jsonCfg.HTTPHosts) > 0 {
 		cfg.HTTPHosts = jsonCfg.HTTPHosts
 	}
+	if jsonCfg.AllowLocalTargets != nil {
+		cfg.AllowLocalTargets = *jsonCfg.AllowLocalTargets
+	}
+	if jsonCfg.Responder != "" {
+		cfg.Responder = jsonCfg.Responder
+	}
+	if jsonCfg.ResponderURL != "" {
+		cfg.ResponderURL = jsonCfg.ResponderURL
+	}
+	if jsonCfg.ResponderKey != "" {
+		cfg.ResponderKey = jsonCfg.ResponderKey
+	}
 
 	// Duration fields
 	if jsonCfg.CheckInterval != "" {
@@ -366,6 +1288,103 @@ func loadConfigFile(configPath string) (*Config, error) {
 			cfg.DiagnosticsTimeout = d
 		}
 	}
+	if len(jsonCfg.DiagnosticPingTargets) > 0 {
+		cfg.DiagnosticPingTargets = jsonCfg.DiagnosticPingTargets
+	}
+	if len(jsonCfg.DiagnosticTCPTargets) > 0 {
+		cfg.DiagnosticTCPTargets = jsonCfg.DiagnosticTCPTargets
+	}
+	if len(jsonCfg.DiagnosticDNSTargets) > 0 {
+		cfg.DiagnosticDNSTargets = jsonCfg.DiagnosticDNSTargets
+	}
+	if len(jsonCfg.DiagnosticHTTPTargets) > 0 {
+		cfg.DiagnosticHTTPTargets = jsonCfg.DiagnosticHTTPTargets
+	}
+	if jsonCfg.CycleBudget != "" {
+		if d, err := time.ParseDuration(jsonCfg.CycleBudget); err == nil {
+			cfg.CycleBudget = d
+		}
+	}
+	if jsonCfg.CycleRetryBudget != nil {
+		cfg.CycleRetryBudget = *jsonCfg.CycleRetryBudget
+	}
+	if len(jsonCfg.HealthWeights) > 0 {
+		cfg.HealthWeights = jsonCfg.HealthWeights
+	}
+	if jsonCfg.HealthDegradedScore != nil {
+		cfg.HealthDegradedScore = *jsonCfg.HealthDegradedScore
+	}
+	if jsonCfg.HealthRebootScore != nil {
+		cfg.HealthRebootScore = *jsonCfg.HealthRebootScore
+	}
+	if jsonCfg.CheckOverlapPolicy != "" {
+		cfg.CheckOverlapPolicy = jsonCfg.CheckOverlapPolicy
+	}
+	if jsonCfg.EscalationFailures != nil {
+		cfg.EscalationFailures = *jsonCfg.EscalationFailures
+	}
+	if jsonCfg.EscalationValidationInterval != "" {
+		if d, err := time.ParseDuration(jsonCfg.EscalationValidationInterval); err == nil {
+			cfg.EscalationValidationInterval = d
+		}
+	}
+	if len(jsonCfg.EscalationWindows) > 0 {
+		cfg.EscalationWindows = jsonCfg.EscalationWindows
+	}
+	if len(jsonCfg.CheckDependencies) > 0 {
+		cfg.CheckDependencies = jsonCfg.CheckDependencies
+	}
+	if jsonCfg.GatewayTarget != "" {
+		cfg.GatewayTarget = jsonCfg.GatewayTarget
+	}
+	if jsonCfg.TargetsPerCheck != nil {
+		cfg.TargetsPerCheck = *jsonCfg.TargetsPerCheck
+	}
+	if jsonCfg.TargetQuarantineFailures != nil {
+		cfg.TargetQuarantineFailures = *jsonCfg.TargetQuarantineFailures
+	}
+	if jsonCfg.TargetQuarantineDuration != "" {
+		if d, err := time.ParseDuration(jsonCfg.TargetQuarantineDuration); err == nil {
+			cfg.TargetQuarantineDuration = d
+		}
+	}
+	if jsonCfg.OutboundProxy != "" {
+		cfg.OutboundProxy = jsonCfg.OutboundProxy
+	}
+	if jsonCfg.WireGuardInterface != "" {
+		cfg.WireGuardInterface = jsonCfg.WireGuardInterface
+	}
+	if jsonCfg.WireGuardPeer != "" {
+		cfg.WireGuardPeer = jsonCfg.WireGuardPeer
+	}
+	if jsonCfg.WireGuardHandshakeAge != "" {
+		if d, err := time.ParseDuration(jsonCfg.WireGuardHandshakeAge); err == nil {
+			cfg.WireGuardHandshakeAge = d
+		}
+	}
+	if jsonCfg.WireGuardProbe != "" {
+		cfg.WireGuardProbe = jsonCfg.WireGuardProbe
+	}
+	if jsonCfg.LowPowerMode != "" {
+		cfg.LowPowerMode = jsonCfg.LowPowerMode
+	}
+	if jsonCfg.PowerSourceFile != "" {
+		cfg.PowerSourceFile = jsonCfg.PowerSourceFile
+	}
+	if jsonCfg.LowPowerCheckInterval != "" {
+		if d, err := time.ParseDuration(jsonCfg.LowPowerCheckInterval); err == nil {
+			cfg.LowPowerCheckInterval = d
+		}
+	}
+	if jsonCfg.MessageTemplates != "" {
+		cfg.MessageTemplates = jsonCfg.MessageTemplates
+	}
+	if jsonCfg.NotificationQueueSize != nil {
+		cfg.NotificationQueueSize = *jsonCfg.NotificationQueueSize
+	}
+	if jsonCfg.Language != "" {
+		cfg.Language = jsonCfg.Language
+	}
 	if jsonCfg.OutageReportInterval != "" {
 		if d, err := time.ParseDuration(jsonCfg.OutageReportInterval); err == nil {
 			cfg.OutageReportInterval = d
@@ -396,6 +1415,22 @@ func loadConfigFile(configPath string) (*Config, error) {
 			cfg.RebootOnlineTimeout = d
 		}
 	}
+	if jsonCfg.ScheduledReboot != "" {
+		cfg.ScheduledReboot = jsonCfg.ScheduledReboot
+	}
+	if jsonCfg.ScheduledRebootSkipWithin != "" {
+		if d, err := time.ParseDuration(jsonCfg.ScheduledRebootSkipWithin); err == nil {
+			cfg.ScheduledRebootSkipWithin = d
+		}
+	}
+	if jsonCfg.RebootTimeout != "" {
+		if d, err := time.ParseDuration(jsonCfg.RebootTimeout); err == nil {
+			cfg.RebootTimeout = d
+		}
+	}
+	if jsonCfg.RebootShutdownPolicy != "" {
+		cfg.RebootShutdownPolicy = jsonCfg.RebootShutdownPolicy
+	}
 
 	// Resource monitoring and limits
 	if jsonCfg.MemoryLimitMB != nil {
@@ -412,6 +1447,34 @@ func loadConfigFile(configPath string) (*Config, error) {
 			cfg.ResourceCheckInterval = d
 		}
 	}
+	if jsonCfg.MetricsMaxOperations != nil {
+		cfg.MetricsMaxOperations = *jsonCfg.MetricsMaxOperations
+	}
+	if jsonCfg.MetricsRetention != "" {
+		if d, err := time.ParseDuration(jsonCfg.MetricsRetention); err == nil {
+			cfg.MetricsRetention = d
+		}
+	}
+	if jsonCfg.HistoryRawRetention != "" {
+		if d, err := time.ParseDuration(jsonCfg.HistoryRawRetention); err == nil {
+			cfg.HistoryRawRetention = d
+		}
+	}
+	if jsonCfg.HistoryMaxAge != nil {
+		cfg.HistoryMaxAge = *jsonCfg.HistoryMaxAge
+	}
+	if jsonCfg.HistoryMaxEvents != nil {
+		cfg.HistoryMaxEvents = *jsonCfg.HistoryMaxEvents
+	}
+	if jsonCfg.HistoryMaxSize != nil {
+		cfg.HistoryMaxSize = *jsonCfg.HistoryMaxSize
+	}
+	if jsonCfg.ReportMaxAge != nil {
+		cfg.ReportMaxAge = *jsonCfg.ReportMaxAge
+	}
+	if jsonCfg.ReportMaxSize != nil {
+		cfg.ReportMaxSize = *jsonCfg.ReportMaxSize
+	}
 
 	return cfg, nil
 }
@@ -424,10 +1487,48 @@ func isDefaultValue(current, defaultVal interface{}) bool {
 // mergeConfigs merges file configuration into environment configuration
 // Environment variables take precedence over file configuration
 func mergeConfigs(envConfig, fileConfig *Config) {
+	mergeToggles(envConfig, fileConfig)
+
 	// Modem configuration
+	if envConfig.ModemType == DefaultModemType && fileConfig.ModemType != "" {
+		envConfig.ModemType = fileConfig.ModemType
+	}
 	if envConfig.ModemHost == DefaultModemHost && fileConfig.ModemHost != "" {
 		envConfig.ModemHost = fileConfig.ModemHost
 	}
+	if envConfig.ModemPort == 0 && fileConfig.ModemPort != 0 {
+		envConfig.ModemPort = fileConfig.ModemPort
+	}
+	if envConfig.ModemStatsInterval == 0 && fileConfig.ModemStatsInterval != 0 {
+		envConfig.ModemStatsInterval = fileConfig.ModemStatsInterval
+	}
+	if envConfig.PartialServiceChannels == DefaultPartialServiceChannels && fileConfig.PartialServiceChannels != 0 {
+		envConfig.PartialServiceChannels = fileConfig.PartialServiceChannels
+	}
+	if envConfig.PartialServiceRebootAfter == 0 && fileConfig.PartialServiceRebootAfter != 0 {
+		envConfig.PartialServiceRebootAfter = fileConfig.PartialServiceRebootAfter
+	}
+	if envConfig.ModemMaxTemperature == DefaultModemMaxTemperature && fileConfig.ModemMaxTemperature != 0 {
+		envConfig.ModemMaxTemperature = fileConfig.ModemMaxTemperature
+	}
+	if envConfig.DOCSISTimeoutsPerHour == DefaultDOCSISTimeoutsPerHour && fileConfig.DOCSISTimeoutsPerHour != 0 {
+		envConfig.DOCSISTimeoutsPerHour = fileConfig.DOCSISTimeoutsPerHour
+	}
+	if envConfig.DOCSISTimeoutsPerDay == DefaultDOCSISTimeoutsPerDay && fileConfig.DOCSISTimeoutsPerDay != 0 {
+		envConfig.DOCSISTimeoutsPerDay = fileConfig.DOCSISTimeoutsPerDay
+	}
+	if envConfig.ModemTunnelSSH == "" && fileConfig.ModemTunnelSSH != "" {
+		envConfig.ModemTunnelSSH = fileConfig.ModemTunnelSSH
+	}
+	if envConfig.ModemTunnelSSHKey == "" && fileConfig.ModemTunnelSSHKey != "" {
+		envConfig.ModemTunnelSSHKey = fileConfig.ModemTunnelSSHKey
+	}
+	if envConfig.ModemTunnelSSHKnownHosts == "" && fileConfig.ModemTunnelSSHKnownHosts != "" {
+		envConfig.ModemTunnelSSHKnownHosts = fileConfig.ModemTunnelSSHKnownHosts
+	}
+	if envConfig.ModemTunnelInterface == "" && fileConfig.ModemTunnelInterface != "" {
+		envConfig.ModemTunnelInterface = fileConfig.ModemTunnelInterface
+	}
 	if envConfig.ModemUsername == "admin" && fileConfig.ModemUsername != "" {
 		envConfig.ModemUsername = fileConfig.ModemUsername
 	}
@@ -453,11 +1554,23 @@ func mergeConfigs(envConfig, fileConfig *Config) {
 	if len(fileConfig.HTTPHosts) > 0 && isDefaultHTTPHosts(envConfig.HTTPHosts) {
 		envConfig.HTTPHosts = fileConfig.HTTPHosts
 	}
+	if envConfig.Responder == "" && fileConfig.Responder != "" {
+		envConfig.Responder = fileConfig.Responder
+	}
+	if envConfig.ResponderURL == "" && fileConfig.ResponderURL != "" {
+		envConfig.ResponderURL = fileConfig.ResponderURL
+	}
+	if envConfig.ResponderKey == "" && fileConfig.ResponderKey != "" {
+		envConfig.ResponderKey = fileConfig.ResponderKey
+	}
 
 	// Logging configuration
 	if envConfig.LogLevel == DefaultLogLevel && fileConfig.LogLevel != "" {
 		envConfig.LogLevel = fileConfig.LogLevel
 	}
+	if len(envConfig.LogModuleLevels) == 0 && len(fileConfig.LogModuleLevels) > 0 {
+		envConfig.LogModuleLevels = fileConfig.LogModuleLevels
+	}
 	if envConfig.LogFormat == DefaultLogFormat && fileConfig.LogFormat != "" {
 		envConfig.LogFormat = fileConfig.LogFormat
 	}
@@ -475,6 +1588,93 @@ func mergeConfigs(envConfig, fileConfig *Config) {
 	if envConfig.DiagnosticsTimeout == 120*time.Second && fileConfig.DiagnosticsTimeout != 0 {
 		envConfig.DiagnosticsTimeout = fileConfig.DiagnosticsTimeout
 	}
+	if len(envConfig.DiagnosticPingTargets) == 0 && len(fileConfig.DiagnosticPingTargets) > 0 {
+		envConfig.DiagnosticPingTargets = fileConfig.DiagnosticPingTargets
+	}
+	if len(envConfig.DiagnosticTCPTargets) == 0 && len(fileConfig.DiagnosticTCPTargets) > 0 {
+		envConfig.DiagnosticTCPTargets = fileConfig.DiagnosticTCPTargets
+	}
+	if len(envConfig.DiagnosticDNSTargets) == 0 && len(fileConfig.DiagnosticDNSTargets) > 0 {
+		envConfig.DiagnosticDNSTargets = fileConfig.DiagnosticDNSTargets
+	}
+	if len(envConfig.DiagnosticHTTPTargets) == 0 && len(fileConfig.DiagnosticHTTPTargets) > 0 {
+		envConfig.DiagnosticHTTPTargets = fileConfig.DiagnosticHTTPTargets
+	}
+	if envConfig.CycleBudget == 0 && fileConfig.CycleBudget != 0 {
+		envConfig.CycleBudget = fileConfig.CycleBudget
+	}
+	if envConfig.CycleRetryBudget == 0 && fileConfig.CycleRetryBudget != 0 {
+		envConfig.CycleRetryBudget = fileConfig.CycleRetryBudget
+	}
+	if len(envConfig.HealthWeights) == 0 && len(fileConfig.HealthWeights) > 0 {
+		envConfig.HealthWeights = fileConfig.HealthWeights
+	}
+	if envConfig.HealthDegradedScore == 0 && fileConfig.HealthDegradedScore != 0 {
+		envConfig.HealthDegradedScore = fileConfig.HealthDegradedScore
+	}
+	if envConfig.HealthRebootScore == 0 && fileConfig.HealthRebootScore != 0 {
+		envConfig.HealthRebootScore = fileConfig.HealthRebootScore
+	}
+	if envConfig.CheckOverlapPolicy == DefaultCheckOverlapPolicy && fileConfig.CheckOverlapPolicy != "" {
+		envConfig.CheckOverlapPolicy = fileConfig.CheckOverlapPolicy
+	}
+	if envConfig.EscalationFailures == connectivity.DefaultEscalationFailures && fileConfig.EscalationFailures != 0 {
+		envConfig.EscalationFailures = fileConfig.EscalationFailures
+	}
+	if envConfig.EscalationValidationInterval == connectivity.DefaultEscalationValidationInterval && fileConfig.EscalationValidationInterval != 0 {
+		envConfig.EscalationValidationInterval = fileConfig.EscalationValidationInterval
+	}
+	if len(envConfig.EscalationWindows) == 0 && len(fileConfig.EscalationWindows) > 0 {
+		envConfig.EscalationWindows = fileConfig.EscalationWindows
+	}
+	if len(envConfig.CheckDependencies) == 0 && len(fileConfig.CheckDependencies) > 0 {
+		envConfig.CheckDependencies = fileConfig.CheckDependencies
+	}
+	if envConfig.GatewayTarget == "" && fileConfig.GatewayTarget != "" {
+		envConfig.GatewayTarget = fileConfig.GatewayTarget
+	}
+	if envConfig.TargetsPerCheck == 0 && fileConfig.TargetsPerCheck != 0 {
+		envConfig.TargetsPerCheck = fileConfig.TargetsPerCheck
+	}
+	if envConfig.TargetQuarantineFailures == connectivity.DefaultQuarantineFailures && fileConfig.TargetQuarantineFailures != 0 {
+		envConfig.TargetQuarantineFailures = fileConfig.TargetQuarantineFailures
+	}
+	if envConfig.TargetQuarantineDuration == connectivity.DefaultQuarantineDuration && fileConfig.TargetQuarantineDuration != 0 {
+		envConfig.TargetQuarantineDuration = fileConfig.TargetQuarantineDuration
+	}
+	if envConfig.OutboundProxy == "" && fileConfig.OutboundProxy != "" {
+		envConfig.OutboundProxy = fileConfig.OutboundProxy
+	}
+	if envConfig.WireGuardInterface == "" && fileConfig.WireGuardInterface != "" {
+		envConfig.WireGuardInterface = fileConfig.WireGuardInterface
+	}
+	if envConfig.WireGuardPeer == "" && fileConfig.WireGuardPeer != "" {
+		envConfig.WireGuardPeer = fileConfig.WireGuardPeer
+	}
+	if envConfig.WireGuardHandshakeAge == connectivity.DefaultWireGuardHandshakeAge && fileConfig.WireGuardHandshakeAge != 0 {
+		envConfig.WireGuardHandshakeAge = fileConfig.WireGuardHandshakeAge
+	}
+	if envConfig.WireGuardProbe == "" && fileConfig.WireGuardProbe != "" {
+		envConfig.WireGuardProbe = fileConfig.WireGuardProbe
+	}
+	if envConfig.LowPowerMode == power.ModeOff && fileConfig.LowPowerMode != "" {
+		envConfig.LowPowerMode = fileConfig.LowPowerMode
+	}
+	if envConfig.PowerSourceFile == "" && fileConfig.PowerSourceFile != "" {
+		envConfig.PowerSourceFile = fileConfig.PowerSourceFile
+	}
+	if envConfig.LowPowerCheckInterval == power.DefaultCheckInterval && fileConfig.LowPowerCheckInterval != 0 {
+		envConfig.LowPowerCheckInterval = fileConfig.LowPowerCheckInterval
+	}
+	if envConfig.MessageTemplates == "" && fileConfig.MessageTemplates != "" {
+		envConfig.MessageTemplates = fileConfig.MessageTemplates
+	}
+	if envConfig.NotificationQueueSize == notify.DefaultQueueSize && fileConfig.NotificationQueueSize != 0 {
+		envConfig.NotificationQueueSize = fileConfig.NotificationQueueSize
+	}
+	if envConfig.Language == "" && fileConfig.Language != "" {
+		envConfig.Language = fileConfig.Language
+	}
 	if envConfig.OutageReportInterval == 3600*time.Second && fileConfig.OutageReportInterval != 0 {
 		envConfig.OutageReportInterval = fileConfig.OutageReportInterval
 	}
@@ -489,6 +1689,18 @@ func mergeConfigs(envConfig, fileConfig *Config) {
 	if envConfig.RebootOnlineTimeout == 300*time.Second && fileConfig.RebootOnlineTimeout != 0 {
 		envConfig.RebootOnlineTimeout = fileConfig.RebootOnlineTimeout
 	}
+	if envConfig.ScheduledReboot == "" && fileConfig.ScheduledReboot != "" {
+		envConfig.ScheduledReboot = fileConfig.ScheduledReboot
+	}
+	if envConfig.ScheduledRebootSkipWithin == DefaultScheduledRebootSkipWithin && fileConfig.ScheduledRebootSkipWithin != 0 {
+		envConfig.ScheduledRebootSkipWithin = fileConfig.ScheduledRebootSkipWithin
+	}
+	if envConfig.RebootTimeout == 0 && fileConfig.RebootTimeout != 0 {
+		envConfig.RebootTimeout = fileConfig.RebootTimeout
+	}
+	if envConfig.RebootShutdownPolicy == DefaultRebootShutdownPolicy && fileConfig.RebootShutdownPolicy != "" {
+		envConfig.RebootShutdownPolicy = fileConfig.RebootShutdownPolicy
+	}
 
 	// Performance settings
 	if envConfig.MaxConcurrentTests == DefaultMaxConcurrentTests && fileConfig.MaxConcurrentTests != 0 {
@@ -517,46 +1729,265 @@ func mergeConfigs(envConfig, fileConfig *Config) {
 	if envConfig.ResourceCheckInterval == DefaultResourceCheckInterval && fileConfig.ResourceCheckInterval != 0 {
 		envConfig.ResourceCheckInterval = fileConfig.ResourceCheckInterval
 	}
+	if envConfig.MetricsMaxOperations == DefaultMetricsMaxOperations && fileConfig.MetricsMaxOperations != 0 {
+		envConfig.MetricsMaxOperations = fileConfig.MetricsMaxOperations
+	}
+	if envConfig.MetricsRetention == DefaultMetricsRetention && fileConfig.MetricsRetention != 0 {
+		envConfig.MetricsRetention = fileConfig.MetricsRetention
+	}
+	if envConfig.HistoryRawRetention == DefaultHistoryRawRetention && fileConfig.HistoryRawRetention != 0 {
+		envConfig.HistoryRawRetention = fileConfig.HistoryRawRetention
+	}
+	if envConfig.HistoryMaxAge == 0 && fileConfig.HistoryMaxAge != 0 {
+		envConfig.HistoryMaxAge = fileConfig.HistoryMaxAge
+	}
+	if envConfig.HistoryMaxEvents == 0 && fileConfig.HistoryMaxEvents != 0 {
+		envConfig.HistoryMaxEvents = fileConfig.HistoryMaxEvents
+	}
+	if envConfig.HistoryMaxSize == DefaultHistoryMaxSize && fileConfig.HistoryMaxSize != 0 {
+		envConfig.HistoryMaxSize = fileConfig.HistoryMaxSize
+	}
+	if envConfig.ReportMaxAge == DefaultReportMaxAge && fileConfig.ReportMaxAge != 0 {
+		envConfig.ReportMaxAge = fileConfig.ReportMaxAge
+	}
+	if envConfig.ReportMaxSize == DefaultReportMaxSize && fileConfig.ReportMaxSize != 0 {
+		envConfig.ReportMaxSize = fileConfig.ReportMaxSize
+	}
 
 	// System settings
-	if envConfig.PidFile == DefaultPidFile && fileConfig.PidFile != "" {
+	if envConfig.PidFile == defaultPidFile() && fileConfig.PidFile != "" {
 		envConfig.PidFile = fileConfig.PidFile
 	}
 	if envConfig.WorkingDirectory == DefaultWorkingDirectory && fileConfig.WorkingDirectory != "" {
 		envConfig.WorkingDirectory = fileConfig.WorkingDirectory
 	}
-}
+	if envConfig.StateDirectory == "" && fileConfig.StateDirectory != "" {
+		envConfig.StateDirectory = fileConfig.StateDirectory
+	}
 
-// Helper functions to check if values are defaults
-func isDefaultPingHosts(hosts []string) bool {
-	defaults := getDefaultPingHosts()
-	if len(hosts) != len(defaults) {
-		return false
+	// Local API
+	if envConfig.APIListenAddress == "" && fileConfig.APIListenAddress != "" {
+		envConfig.APIListenAddress = fileConfig.APIListenAddress
 	}
-	for i, host := range hosts {
-		if host != defaults[i] {
-			return false
-		}
+	if envConfig.APIUsersFile == "" && fileConfig.APIUsersFile != "" {
+		envConfig.APIUsersFile = fileConfig.APIUsersFile
 	}
-	return true
-}
-
-func isDefaultHTTPHosts(hosts []string) bool {
-	defaults := getDefaultHTTPHosts()
-	if len(hosts) != len(defaults) {
-		return false
+	if envConfig.APITLS == DefaultAPITLS && fileConfig.APITLS != "" {
+		envConfig.APITLS = fileConfig.APITLS
 	}
-	for i, host := range hosts {
-		if host != defaults[i] {
-			return false
-		}
+	if envConfig.APITLSCert == "" && fileConfig.APITLSCert != "" {
+		envConfig.APITLSCert = fileConfig.APITLSCert
 	}
-	return true
-}
-
-// Validate checks if the configuration is valid
-func (c *Config) Validate() error {
-	// Validate modem configuration
+	if envConfig.APITLSKey == "" && fileConfig.APITLSKey != "" {
+		envConfig.APITLSKey = fileConfig.APITLSKey
+	}
+	if envConfig.APIActionLimit == DefaultAPIActionLimit && fileConfig.APIActionLimit != 0 {
+		envConfig.APIActionLimit = fileConfig.APIActionLimit
+	}
+	if envConfig.APIActionWindow == DefaultAPIActionWindow && fileConfig.APIActionWindow != 0 {
+		envConfig.APIActionWindow = fileConfig.APIActionWindow
+	}
+	if envConfig.APIRefreshInterval == DefaultAPIRefreshInterval && fileConfig.APIRefreshInterval != 0 {
+		envConfig.APIRefreshInterval = fileConfig.APIRefreshInterval
+	}
+	if envConfig.AuditLogFile == "" && fileConfig.AuditLogFile != "" {
+		envConfig.AuditLogFile = fileConfig.AuditLogFile
+	}
+	if envConfig.APIKeysFile == "" && fileConfig.APIKeysFile != "" {
+		envConfig.APIKeysFile = fileConfig.APIKeysFile
+	}
+	if len(envConfig.DockerRestartContainers) == 0 && len(fileConfig.DockerRestartContainers) > 0 {
+		envConfig.DockerRestartContainers = fileConfig.DockerRestartContainers
+	}
+	if envConfig.DockerRestartAfter == DefaultDockerRestartAfter && fileConfig.DockerRestartAfter != 0 {
+		envConfig.DockerRestartAfter = fileConfig.DockerRestartAfter
+	}
+	if envConfig.DockerSocket == DefaultDockerSocket && fileConfig.DockerSocket != "" {
+		envConfig.DockerSocket = fileConfig.DockerSocket
+	}
+	if len(envConfig.PublicIPServices) == 0 && len(fileConfig.PublicIPServices) > 0 {
+		envConfig.PublicIPServices = fileConfig.PublicIPServices
+	}
+	if envConfig.PublicIPRecordCycles == 0 && fileConfig.PublicIPRecordCycles != 0 {
+		envConfig.PublicIPRecordCycles = fileConfig.PublicIPRecordCycles
+	}
+	if envConfig.PublicIPGeoService == "" && fileConfig.PublicIPGeoService != "" {
+		envConfig.PublicIPGeoService = fileConfig.PublicIPGeoService
+	}
+	if len(envConfig.RoutingProbeTargets) == 0 && len(fileConfig.RoutingProbeTargets) > 0 {
+		envConfig.RoutingProbeTargets = fileConfig.RoutingProbeTargets
+	}
+	if envConfig.DDNSProvider == "" && fileConfig.DDNSProvider != "" {
+		envConfig.DDNSProvider = fileConfig.DDNSProvider
+	}
+	if envConfig.DDNSDomain == "" && fileConfig.DDNSDomain != "" {
+		envConfig.DDNSDomain = fileConfig.DDNSDomain
+	}
+	if envConfig.DDNSToken == "" && fileConfig.DDNSToken != "" {
+		envConfig.DDNSToken = fileConfig.DDNSToken
+	}
+	if envConfig.DDNSZoneID == "" && fileConfig.DDNSZoneID != "" {
+		envConfig.DDNSZoneID = fileConfig.DDNSZoneID
+	}
+	if envConfig.DDNSScript == "" && fileConfig.DDNSScript != "" {
+		envConfig.DDNSScript = fileConfig.DDNSScript
+	}
+	if envConfig.SMSProvider == "" && fileConfig.SMSProvider != "" {
+		envConfig.SMSProvider = fileConfig.SMSProvider
+	}
+	if len(envConfig.SMSTo) == 0 && len(fileConfig.SMSTo) > 0 {
+		envConfig.SMSTo = fileConfig.SMSTo
+	}
+	if envConfig.SMSDevice == "" && fileConfig.SMSDevice != "" {
+		envConfig.SMSDevice = fileConfig.SMSDevice
+	}
+	if envConfig.TwilioAccountSID == "" && fileConfig.TwilioAccountSID != "" {
+		envConfig.TwilioAccountSID = fileConfig.TwilioAccountSID
+	}
+	if envConfig.TwilioAuthToken == "" && fileConfig.TwilioAuthToken != "" {
+		envConfig.TwilioAuthToken = fileConfig.TwilioAuthToken
+	}
+	if envConfig.TwilioFrom == "" && fileConfig.TwilioFrom != "" {
+		envConfig.TwilioFrom = fileConfig.TwilioFrom
+	}
+	if envConfig.BeaconURL == "" && fileConfig.BeaconURL != "" {
+		envConfig.BeaconURL = fileConfig.BeaconURL
+	}
+	if envConfig.BeaconInterface == "" && fileConfig.BeaconInterface != "" {
+		envConfig.BeaconInterface = fileConfig.BeaconInterface
+	}
+	if envConfig.BeaconInterval == beacon.DefaultInterval && fileConfig.BeaconInterval != 0 {
+		envConfig.BeaconInterval = fileConfig.BeaconInterval
+	}
+	if envConfig.BeaconToken == "" && fileConfig.BeaconToken != "" {
+		envConfig.BeaconToken = fileConfig.BeaconToken
+	}
+	if envConfig.TextfilePath == "" && fileConfig.TextfilePath != "" {
+		envConfig.TextfilePath = fileConfig.TextfilePath
+	}
+	if envConfig.TextfileInterval == textfile.DefaultInterval && fileConfig.TextfileInterval != 0 {
+		envConfig.TextfileInterval = fileConfig.TextfileInterval
+	}
+	if envConfig.BackupInterval == 0 && fileConfig.BackupInterval != 0 {
+		envConfig.BackupInterval = fileConfig.BackupInterval
+	}
+	if envConfig.BackupDestination == "" && fileConfig.BackupDestination != "" {
+		envConfig.BackupDestination = fileConfig.BackupDestination
+	}
+	if envConfig.BackupKeep == backup.DefaultKeep && fileConfig.BackupKeep != 0 {
+		envConfig.BackupKeep = fileConfig.BackupKeep
+	}
+	if envConfig.BackupS3Endpoint == "" && fileConfig.BackupS3Endpoint != "" {
+		envConfig.BackupS3Endpoint = fileConfig.BackupS3Endpoint
+	}
+	if envConfig.BackupS3Region == backup.DefaultS3Region && fileConfig.BackupS3Region != "" {
+		envConfig.BackupS3Region = fileConfig.BackupS3Region
+	}
+	if envConfig.BackupS3AccessKey == "" && fileConfig.BackupS3AccessKey != "" {
+		envConfig.BackupS3AccessKey = fileConfig.BackupS3AccessKey
+	}
+	if envConfig.BackupS3SecretKey == "" && fileConfig.BackupS3SecretKey != "" {
+		envConfig.BackupS3SecretKey = fileConfig.BackupS3SecretKey
+	}
+	if envConfig.BackupSFTPKey == "" && fileConfig.BackupSFTPKey != "" {
+		envConfig.BackupSFTPKey = fileConfig.BackupSFTPKey
+	}
+	if envConfig.BackupSFTPKnownHosts == "" && fileConfig.BackupSFTPKnownHosts != "" {
+		envConfig.BackupSFTPKnownHosts = fileConfig.BackupSFTPKnownHosts
+	}
+	if envConfig.SyncInterval == 0 && fileConfig.SyncInterval != 0 {
+		envConfig.SyncInterval = fileConfig.SyncInterval
+	}
+	if envConfig.SyncDestination == "" && fileConfig.SyncDestination != "" {
+		envConfig.SyncDestination = fileConfig.SyncDestination
+	}
+	if envConfig.SyncSite == "" && fileConfig.SyncSite != "" {
+		envConfig.SyncSite = fileConfig.SyncSite
+	}
+	if envConfig.ClusterRole == "" && fileConfig.ClusterRole != "" {
+		envConfig.ClusterRole = fileConfig.ClusterRole
+	}
+	if envConfig.ClusterPeer == "" && fileConfig.ClusterPeer != "" {
+		envConfig.ClusterPeer = fileConfig.ClusterPeer
+	}
+	if envConfig.ClusterPeerKey == "" && fileConfig.ClusterPeerKey != "" {
+		envConfig.ClusterPeerKey = fileConfig.ClusterPeerKey
+	}
+	if envConfig.ClusterPeerFingerprint == "" && fileConfig.ClusterPeerFingerprint != "" {
+		envConfig.ClusterPeerFingerprint = fileConfig.ClusterPeerFingerprint
+	}
+	if envConfig.ClusterHeartbeatInterval == cluster.DefaultHeartbeatInterval && fileConfig.ClusterHeartbeatInterval != 0 {
+		envConfig.ClusterHeartbeatInterval = fileConfig.ClusterHeartbeatInterval
+	}
+	if envConfig.ClusterFailoverAfter == cluster.DefaultFailoverAfter && fileConfig.ClusterFailoverAfter != 0 {
+		envConfig.ClusterFailoverAfter = fileConfig.ClusterFailoverAfter
+	}
+	if len(envConfig.QuorumPeers) == 0 && len(fileConfig.QuorumPeers) > 0 {
+		envConfig.QuorumPeers = fileConfig.QuorumPeers
+	}
+	if envConfig.QuorumKey == "" && fileConfig.QuorumKey != "" {
+		envConfig.QuorumKey = fileConfig.QuorumKey
+	}
+	if envConfig.QuorumRequired == 0 && fileConfig.QuorumRequired != 0 {
+		envConfig.QuorumRequired = fileConfig.QuorumRequired
+	}
+	if envConfig.QuorumMaxAge == quorum.DefaultMaxAge && fileConfig.QuorumMaxAge != 0 {
+		envConfig.QuorumMaxAge = fileConfig.QuorumMaxAge
+	}
+	if envConfig.HostMaxCPU == hosthealth.DefaultMaxCPU && fileConfig.HostMaxCPU != 0 {
+		envConfig.HostMaxCPU = fileConfig.HostMaxCPU
+	}
+	if envConfig.LANFailureAction == verdict.DefaultLANAction && fileConfig.LANFailureAction != "" {
+		envConfig.LANFailureAction = fileConfig.LANFailureAction
+	}
+	if envConfig.WANFailureAction == verdict.DefaultWANAction && fileConfig.WANFailureAction != "" {
+		envConfig.WANFailureAction = fileConfig.WANFailureAction
+	}
+	if envConfig.UpstreamFailureAction == verdict.DefaultUpstreamAction && fileConfig.UpstreamFailureAction != "" {
+		envConfig.UpstreamFailureAction = fileConfig.UpstreamFailureAction
+	}
+	if len(envConfig.Plugins) == 0 && len(fileConfig.Plugins) > 0 {
+		envConfig.Plugins = fileConfig.Plugins
+	}
+	if len(envConfig.DisabledFeatures) == 0 && len(fileConfig.DisabledFeatures) > 0 {
+		envConfig.DisabledFeatures = fileConfig.DisabledFeatures
+	}
+}
+
+// Helper functions to check if values are defaults
+func isDefaultPingHosts(hosts []string) bool {
+	defaults := getDefaultPingHosts()
+	if len(hosts) != len(defaults) {
+		return false
+	}
+	for i, host := range hosts {
+		if host != defaults[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isDefaultHTTPHosts(hosts []string) bool {
+	defaults := getDefaultHTTPHosts()
+	if len(hosts) != len(defaults) {
+		return false
+	}
+	for i, host := range hosts {
+		if host != defaults[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks if the configuration is valid
+func (c *Config) Validate() error {
+	// Validate modem configuration
+	if c.ModemType != "" && !isSupportedModemType(c.ModemType) {
+		return fmt.Errorf("invalid MODEM_TYPE: %s, must be one of: %s", c.ModemType, strings.Join(modemTypes(), ", "))
+	}
+
 	if c.ModemHost == "" {
 		return fmt.Errorf("MODEM_HOST is required")
 	}
@@ -568,6 +1999,9 @@ func (c *Config) Validate() error {
 			if net.ParseIP(host) == nil && !isValidHostname(host) {
 				return fmt.Errorf("MODEM_HOST must be a valid IP address or hostname")
 			}
+			if c.ModemPort != 0 {
+				return fmt.Errorf("MODEM_PORT conflicts with the port in MODEM_HOST %s", c.ModemHost)
+			}
 		} else {
 			// If not an IP with port, check if it's a valid hostname format
 			if !isValidHostname(c.ModemHost) {
@@ -576,6 +2010,34 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.ModemPort < 0 || c.ModemPort > 65535 {
+		return fmt.Errorf("MODEM_PORT must be between 1 and 65535, or 0 for the scheme default, got %d", c.ModemPort)
+	}
+	if c.ModemStatsInterval != 0 && c.ModemStatsInterval < 10*time.Second {
+		return fmt.Errorf("MODEM_STATS_INTERVAL must be at least 10s, or 0 to read the channels at startup only, got %v", c.ModemStatsInterval)
+	}
+	if c.PartialServiceChannels < 0 {
+		return fmt.Errorf("PARTIAL_SERVICE_CHANNELS must be positive, or 0 to disable partial service detection, got %d", c.PartialServiceChannels)
+	}
+	if c.PartialServiceRebootAfter < 0 {
+		return fmt.Errorf("PARTIAL_SERVICE_REBOOT_AFTER must not be negative, got %v", c.PartialServiceRebootAfter)
+	}
+	if c.PartialServiceRebootAfter > 0 && (c.PartialServiceChannels == 0 || c.ModemStatsInterval == 0) {
+		return fmt.Errorf("PARTIAL_SERVICE_REBOOT_AFTER requires PARTIAL_SERVICE_CHANNELS and MODEM_STATS_INTERVAL, which reads the channels while partial service lasts")
+	}
+	if c.ModemMaxTemperature < 0 {
+		return fmt.Errorf("MODEM_MAX_TEMPERATURE must be positive, or 0 to never alert, got %v", c.ModemMaxTemperature)
+	}
+	if c.DOCSISTimeoutsPerHour < 0 {
+		return fmt.Errorf("DOCSIS_TIMEOUTS_PER_HOUR must be positive, or 0 to leave the hour out, got %d", c.DOCSISTimeoutsPerHour)
+	}
+	if c.DOCSISTimeoutsPerDay < 0 {
+		return fmt.Errorf("DOCSIS_TIMEOUTS_PER_DAY must be positive, or 0 to leave the day out, got %d", c.DOCSISTimeoutsPerDay)
+	}
+	if err := c.ModemTunnel().Validate(); err != nil {
+		return fmt.Errorf("invalid modem tunnel: %w", err)
+	}
+
 	if c.ModemUsername == "" {
 		return fmt.Errorf("MODEM_USERNAME is required")
 	}
@@ -630,6 +2092,54 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("HTTP host must start with http:// or https://, got: %s", url)
 		}
 	}
+	if !c.AllowLocalTargets {
+		if local := c.LocalTargets(); len(local) > 0 {
+			return fmt.Errorf("%s and would keep answering while the internet is down; set ALLOW_LOCAL_TARGETS=true if that is intended", local[0])
+		}
+	}
+
+	if c.Responder != "" {
+		host, port, err := net.SplitHostPort(c.Responder)
+		if _, portErr := strconv.Atoi(port); err != nil || host == "" || portErr != nil {
+			return fmt.Errorf("invalid host:port in RESPONDER: %s", c.Responder)
+		}
+	}
+	if c.ResponderURL != "" {
+		if c.Responder == "" {
+			return fmt.Errorf("RESPONDER_URL requires RESPONDER")
+		}
+		if !strings.HasPrefix(c.ResponderURL, "http://") && !strings.HasPrefix(c.ResponderURL, "https://") {
+			return fmt.Errorf("RESPONDER_URL must start with http:// or https://, got: %s", c.ResponderURL)
+		}
+	}
+	if c.ResponderKey != "" && c.Responder == "" {
+		return fmt.Errorf("RESPONDER_KEY requires RESPONDER")
+	}
+
+	// Validate performance metrics bounds
+	if c.MetricsMaxOperations < 0 {
+		return fmt.Errorf("METRICS_MAX_OPERATIONS must not be negative, got %d", c.MetricsMaxOperations)
+	}
+	if c.MetricsRetention < 0 {
+		return fmt.Errorf("METRICS_RETENTION must not be negative, got %v", c.MetricsRetention)
+	}
+	if c.HistoryRawRetention != 0 && c.HistoryRawRetention < time.Hour {
+		return fmt.Errorf("HISTORY_RAW_RETENTION must be at least 1h, or 0 to not record check results, got %v", c.HistoryRawRetention)
+	}
+	for _, limit := range []struct {
+		name  string
+		value int
+	}{
+		{"HISTORY_MAX_AGE", c.HistoryMaxAge},
+		{"HISTORY_MAX_EVENTS", c.HistoryMaxEvents},
+		{"HISTORY_MAX_SIZE", c.HistoryMaxSize},
+		{"REPORT_MAX_AGE", c.ReportMaxAge},
+		{"REPORT_MAX_SIZE", c.ReportMaxSize},
+	} {
+		if limit.value < 0 {
+			return fmt.Errorf("%s must be 0 (no limit) or positive, got %d", limit.name, limit.value)
+		}
+	}
 
 	// Validate logging configuration
 	validLogLevels := map[string]bool{
@@ -640,6 +2150,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid LOG_LEVEL: %s, must be one of: DEBUG, INFO, WARN, ERROR, FATAL, PANIC", c.LogLevel)
 	}
 
+	if _, err := logger.ParseModuleLevels(c.LogModuleLevels); err != nil {
+		return fmt.Errorf("invalid LOG_MODULE_LEVELS: %w", err)
+	}
+
 	validLogFormats := map[string]bool{
 		"console": true, "json": true, "text": true,
 	}
@@ -665,6 +2179,124 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("DIAGNOSTICS_TIMEOUT must be less than 10 minutes, got %v", c.DiagnosticsTimeout)
 	}
 
+	for _, host := range c.DiagnosticPingTargets {
+		if net.ParseIP(host) == nil && !isValidHostname(host) {
+			return fmt.Errorf("invalid host in DIAGNOSTIC_PING_TARGETS: %s", host)
+		}
+	}
+	for _, target := range c.DiagnosticTCPTargets {
+		host, port, err := net.SplitHostPort(target)
+		if _, portErr := strconv.Atoi(port); err != nil || host == "" || portErr != nil {
+			return fmt.Errorf("invalid host:port in DIAGNOSTIC_TCP_TARGETS: %s", target)
+		}
+	}
+	for _, domain := range c.DiagnosticDNSTargets {
+		if !isValidHostname(domain) {
+			return fmt.Errorf("invalid domain in DIAGNOSTIC_DNS_TARGETS: %s", domain)
+		}
+	}
+	for _, url := range c.DiagnosticHTTPTargets {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return fmt.Errorf("DIAGNOSTIC_HTTP_TARGETS must start with http:// or https://, got: %s", url)
+		}
+	}
+
+	if c.CycleBudget < 0 {
+		return fmt.Errorf("CYCLE_BUDGET must not be negative, got %v", c.CycleBudget)
+	}
+
+	if c.CycleBudget > 0 && c.CycleBudget < time.Second {
+		return fmt.Errorf("CYCLE_BUDGET must be at least 1 second, got %v", c.CycleBudget)
+	}
+
+	if c.CycleRetryBudget < 0 {
+		return fmt.Errorf("CYCLE_RETRY_BUDGET must not be negative, got %d", c.CycleRetryBudget)
+	}
+
+	if _, err := health.ParseWeights(c.HealthWeights); err != nil {
+		return fmt.Errorf("invalid HEALTH_WEIGHTS: %w", err)
+	}
+	if c.HealthDegradedScore < 0 || c.HealthDegradedScore > 100 {
+		return fmt.Errorf("HEALTH_DEGRADED_SCORE must be between 0 and 100, got %d", c.HealthDegradedScore)
+	}
+	if c.HealthRebootScore < 0 || c.HealthRebootScore > 100 {
+		return fmt.Errorf("HEALTH_REBOOT_SCORE must be between 0 and 100, got %d", c.HealthRebootScore)
+	}
+	if degraded, reboot := c.healthCutoffs(); reboot > degraded {
+		return fmt.Errorf("HEALTH_REBOOT_SCORE (%d) must not be above HEALTH_DEGRADED_SCORE (%d)", reboot, degraded)
+	}
+
+	if c.CheckOverlapPolicy != "" && c.CheckOverlapPolicy != "skip" && c.CheckOverlapPolicy != "queue" {
+		return fmt.Errorf("invalid CHECK_OVERLAP_POLICY: %s, must be one of: skip, queue", c.CheckOverlapPolicy)
+	}
+
+	if c.EscalationFailures < 0 {
+		return fmt.Errorf("ESCALATION_FAILURES must be 0 (never escalate) or positive, got %d", c.EscalationFailures)
+	}
+	if c.EscalationValidationInterval < 0 {
+		return fmt.Errorf("ESCALATION_VALIDATION_INTERVAL must be 0 (no validation) or positive, got %v", c.EscalationValidationInterval)
+	}
+	if _, err := connectivity.ParseTimeWindows(c.EscalationWindows); err != nil {
+		return fmt.Errorf("invalid ESCALATION_WINDOWS: %w", err)
+	}
+	deps, err := connectivity.ParseDependencies(c.CheckDependencies)
+	if err != nil {
+		return fmt.Errorf("invalid CHECK_DEPENDENCIES: %w", err)
+	}
+	if c.GatewayTarget == "" && deps.Uses(connectivity.CheckGateway) {
+		return fmt.Errorf("CHECK_DEPENDENCIES on gateway require GATEWAY_TARGET")
+	}
+	if _, _, err := net.SplitHostPort(c.GatewayTarget); err != nil && strings.Contains(c.GatewayTarget, ":") && net.ParseIP(c.GatewayTarget) == nil {
+		return fmt.Errorf("invalid GATEWAY_TARGET, expected host or host:port: %s", c.GatewayTarget)
+	}
+	if c.TargetsPerCheck < 0 {
+		return fmt.Errorf("TARGETS_PER_CHECK must be 0 (all targets) or positive, got %d", c.TargetsPerCheck)
+	}
+	if c.TargetQuarantineFailures < 0 || c.TargetQuarantineFailures > 20 {
+		return fmt.Errorf("TARGET_QUARANTINE_FAILURES must be between 0 (never) and 20, got %d", c.TargetQuarantineFailures)
+	}
+	if c.TargetQuarantineDuration < 0 {
+		return fmt.Errorf("TARGET_QUARANTINE_DURATION must not be negative, got %v", c.TargetQuarantineDuration)
+	}
+	if _, err := c.parseOutboundProxy(); err != nil {
+		return fmt.Errorf("invalid OUTBOUND_PROXY: %w", err)
+	}
+	if c.WireGuardHandshakeAge < 0 {
+		return fmt.Errorf("WIREGUARD_HANDSHAKE_AGE must not be negative, got %v", c.WireGuardHandshakeAge)
+	}
+	if c.WireGuardProbe != "" {
+		if _, _, err := net.SplitHostPort(c.WireGuardProbe); err != nil {
+			return fmt.Errorf("WIREGUARD_PROBE must be host:port: %w", err)
+		}
+	}
+	if c.WireGuardInterface == "" && (c.WireGuardPeer != "" || c.WireGuardProbe != "") {
+		return fmt.Errorf("WIREGUARD_PEER and WIREGUARD_PROBE need WIREGUARD_INTERFACE")
+	}
+	lowPowerMode, err := power.ParseMode(c.LowPowerMode)
+	if err != nil {
+		return fmt.Errorf("invalid LOW_POWER_MODE: %w", err)
+	}
+	if lowPowerMode == power.ModeAuto && c.PowerSourceFile == "" {
+		return fmt.Errorf("LOW_POWER_MODE=auto needs POWER_SOURCE_FILE")
+	}
+	if c.LowPowerCheckInterval < 0 {
+		return fmt.Errorf("LOW_POWER_CHECK_INTERVAL must not be negative, got %v", c.LowPowerCheckInterval)
+	}
+
+	if c.MessageTemplates != "" {
+		if _, err := os.Stat(c.MessageTemplates); err != nil {
+			return fmt.Errorf("MESSAGE_TEMPLATES must point to a template file or directory: %w", err)
+		}
+	}
+
+	if c.NotificationQueueSize < 0 {
+		return fmt.Errorf("NOTIFICATION_QUEUE_SIZE must be 0 (disabled) or positive, got %d", c.NotificationQueueSize)
+	}
+
+	if c.Language != "" && !i18n.Supported(c.Language) {
+		return fmt.Errorf("unsupported WATCHDOG_LANGUAGE: %s, must be one of: %s", c.Language, strings.Join(i18n.Languages(), ", "))
+	}
+
 	if c.OutageReportInterval < time.Minute {
 		return fmt.Errorf("OUTAGE_REPORT_INTERVAL must be at least 1 minute, got %v", c.OutageReportInterval)
 	}
@@ -694,6 +2326,21 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("REBOOT_ONLINE_TIMEOUT must be less than 30 minutes, got %v", c.RebootOnlineTimeout)
 	}
 
+	if c.ScheduledReboot != "" {
+		if _, err := scheduler.ParseCron(c.ScheduledReboot); err != nil {
+			return fmt.Errorf("invalid SCHEDULED_REBOOT: %w", err)
+		}
+	}
+	if c.ScheduledRebootSkipWithin < 0 {
+		return fmt.Errorf("SCHEDULED_REBOOT_SKIP_WITHIN must be 0 (never skip) or positive, got %v", c.ScheduledRebootSkipWithin)
+	}
+	if c.RebootTimeout < 0 {
+		return fmt.Errorf("REBOOT_TIMEOUT must be 0 (default) or positive, got %v", c.RebootTimeout)
+	}
+	if c.RebootShutdownPolicy != "" && c.RebootShutdownPolicy != RebootShutdownWait && c.RebootShutdownPolicy != RebootShutdownAbort {
+		return fmt.Errorf("invalid REBOOT_SHUTDOWN_POLICY: %s, must be one of: %s, %s", c.RebootShutdownPolicy, RebootShutdownWait, RebootShutdownAbort)
+	}
+
 	// Validate performance settings
 	if c.MaxConcurrentTests < 1 || c.MaxConcurrentTests > 50 {
 		return fmt.Errorf("MAX_CONCURRENT_TESTS must be between 1 and 50, got %d", c.MaxConcurrentTests)
@@ -715,9 +2362,559 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("RETRY_BACKOFF_FACTOR must be between 1.0 and 10.0, got %f", c.RetryBackoffFactor)
 	}
 
+	// Validate local API settings
+	if c.APIListenAddress != "" {
+		if _, _, err := net.SplitHostPort(c.APIListenAddress); err != nil {
+			return fmt.Errorf("API_LISTEN_ADDRESS must be host:port, got %q", c.APIListenAddress)
+		}
+	}
+
+	if c.EnableFaultInjection && c.APIListenAddress == "" {
+		return fmt.Errorf("ENABLE_FAULT_INJECTION requires API_LISTEN_ADDRESS")
+	}
+
+	if c.APIUsersFile != "" {
+		if _, err := os.Stat(c.APIUsersFile); err != nil {
+			return fmt.Errorf("API_USERS_FILE must point to a users file: %w", err)
+		}
+	}
+
+	switch c.APITLS {
+	case "", APITLSAuto, APITLSOff:
+	default:
+		return fmt.Errorf("invalid API_TLS: %s, must be %s or %s", c.APITLS, APITLSAuto, APITLSOff)
+	}
+	if c.APIActionLimit < 0 {
+		return fmt.Errorf("API_ACTION_LIMIT must be 0 (unlimited) or positive, got %d", c.APIActionLimit)
+	}
+	if c.APIActionLimit > 0 && c.APIActionWindow < time.Second {
+		return fmt.Errorf("API_ACTION_WINDOW must be at least 1s, got %v", c.APIActionWindow)
+	}
+	if c.APIRefreshInterval < 0 {
+		return fmt.Errorf("API_REFRESH_INTERVAL must be 0 (no reuse) or positive, got %v", c.APIRefreshInterval)
+	}
+
+	if c.DockerRestartAfter < 0 {
+		return fmt.Errorf("DOCKER_RESTART_AFTER must not be negative, got %v", c.DockerRestartAfter)
+	}
+	for _, container := range c.DockerRestartContainers {
+		if strings.TrimSpace(container) == "" || strings.Contains(container, "/") {
+			return fmt.Errorf("invalid container name in DOCKER_RESTART_CONTAINERS: %q", container)
+		}
+	}
+	if len(c.DockerRestartContainers) > 0 && c.DockerSocket == "" {
+		return fmt.Errorf("DOCKER_SOCKET is required to restart containers")
+	}
+
+	for _, plugin := range c.Plugins {
+		if !filepath.IsAbs(plugin) {
+			return fmt.Errorf("invalid path in PLUGINS, must be absolute: %q", plugin)
+		}
+	}
+	if strings.EqualFold(c.ModemType, PluginModemType) && len(c.Plugins) == 0 {
+		return fmt.Errorf("MODEM_TYPE %s requires PLUGINS", PluginModemType)
+	}
+
+	if err := features.Validate(c.DisabledFeatures); err != nil {
+		return fmt.Errorf("invalid DISABLED_FEATURES: %w", err)
+	}
+
+	for _, service := range c.PublicIPServices {
+		if !strings.HasPrefix(service, "http://") && !strings.HasPrefix(service, "https://") {
+			return fmt.Errorf("invalid URL in PUBLIC_IP_SERVICES: %s", service)
+		}
+	}
+	if c.PublicIPRecordCycles < 0 {
+		return fmt.Errorf("PUBLIC_IP_RECORD_CYCLES must be 0 (disabled) or positive, got %d", c.PublicIPRecordCycles)
+	}
+	if c.PublicIPGeoService != "" {
+		if !strings.HasPrefix(c.PublicIPGeoService, "http://") && !strings.HasPrefix(c.PublicIPGeoService, "https://") {
+			return fmt.Errorf("invalid URL in PUBLIC_IP_GEO_SERVICE: %s", c.PublicIPGeoService)
+		}
+		if !strings.Contains(c.PublicIPGeoService, "{ip}") {
+			return fmt.Errorf("PUBLIC_IP_GEO_SERVICE must contain an {ip} placeholder: %s", c.PublicIPGeoService)
+		}
+	}
+	switch c.DDNSProvider {
+	case "":
+	case publicip.ProviderCloudflare:
+		if c.DDNSDomain == "" || c.DDNSToken == "" || c.DDNSZoneID == "" {
+			return fmt.Errorf("DDNS_PROVIDER cloudflare requires DDNS_DOMAIN, DDNS_TOKEN and DDNS_ZONE_ID")
+		}
+	case publicip.ProviderDuckDNS:
+		if c.DDNSDomain == "" || c.DDNSToken == "" {
+			return fmt.Errorf("DDNS_PROVIDER duckdns requires DDNS_DOMAIN and DDNS_TOKEN")
+		}
+	case publicip.ProviderScript:
+		if c.DDNSScript == "" {
+			return fmt.Errorf("DDNS_PROVIDER script requires DDNS_SCRIPT")
+		}
+	default:
+		return fmt.Errorf("invalid DDNS_PROVIDER: %s, must be one of: %s", c.DDNSProvider, strings.Join(publicip.Providers, ", "))
+	}
+
+	switch c.SMSProvider {
+	case "":
+	case sms.ProviderTwilio:
+		if c.TwilioAccountSID == "" || c.TwilioAuthToken == "" || c.TwilioFrom == "" {
+			return fmt.Errorf("SMS_PROVIDER twilio requires TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM")
+		}
+	case sms.ProviderGSM:
+		if c.SMSDevice == "" {
+			return fmt.Errorf("SMS_PROVIDER gsm requires SMS_DEVICE")
+		}
+	default:
+		return fmt.Errorf("invalid SMS_PROVIDER: %s, must be one of: %s", c.SMSProvider, strings.Join(sms.Providers, ", "))
+	}
+	if c.SMSProvider != "" && len(c.SMSTo) == 0 {
+		return fmt.Errorf("SMS_PROVIDER requires SMS_TO")
+	}
+	for _, number := range c.SMSTo {
+		if !isPhoneNumber(number) {
+			return fmt.Errorf("invalid phone number in SMS_TO: %s", number)
+		}
+	}
+
+	if c.BeaconURL != "" {
+		if !strings.HasPrefix(c.BeaconURL, "http://") && !strings.HasPrefix(c.BeaconURL, "https://") {
+			return fmt.Errorf("BEACON_URL must start with http:// or https://, got: %s", c.BeaconURL)
+		}
+		if c.BeaconInterval < time.Minute {
+			return fmt.Errorf("BEACON_INTERVAL must be at least 1m, got %v", c.BeaconInterval)
+		}
+	}
+
+	if c.TextfilePath != "" {
+		// The collector only reads *.prom files
+		if !strings.HasSuffix(c.TextfilePath, textfile.Extension) {
+			return fmt.Errorf("TEXTFILE_PATH must end in %s, got: %s", textfile.Extension, c.TextfilePath)
+		}
+		if c.TextfileInterval < 5*time.Second {
+			return fmt.Errorf("TEXTFILE_INTERVAL must be at least 5s, got %v", c.TextfileInterval)
+		}
+	}
+
+	if c.BackupInterval != 0 && c.BackupInterval < time.Hour {
+		return fmt.Errorf("BACKUP_INTERVAL must be at least 1h, or 0 to disable scheduled backups, got %v", c.BackupInterval)
+	}
+	if c.BackupKeep < 0 {
+		return fmt.Errorf("BACKUP_KEEP must be 0 (keep all) or positive, got %d", c.BackupKeep)
+	}
+	if c.BackupInterval > 0 || c.BackupDestination != "" {
+		if _, err := backup.NewDestination(c.Backup(), nil); err != nil {
+			return fmt.Errorf("invalid BACKUP_DESTINATION: %w", err)
+		}
+	}
+	if c.SyncInterval != 0 && c.SyncInterval < 5*time.Minute {
+		return fmt.Errorf("SYNC_INTERVAL must be at least 5m, or 0 to disable remote sync, got %v", c.SyncInterval)
+	}
+	if c.SyncInterval > 0 || c.SyncDestination != "" {
+		if _, err := backup.NewDestination(c.Sync(), nil); err != nil {
+			return fmt.Errorf("invalid SYNC_DESTINATION: %w", err)
+		}
+	}
+	if strings.ContainsAny(c.SyncSite, `/\`) || c.SyncSite == "." || c.SyncSite == ".." {
+		return fmt.Errorf("SYNC_SITE must be a single folder name, got %q", c.SyncSite)
+	}
+	if err := c.Cluster().Validate(); err != nil {
+		return fmt.Errorf("invalid cluster configuration: %w", err)
+	}
+	if err := c.Quorum().Validate(); err != nil {
+		return fmt.Errorf("invalid reboot quorum: %w", err)
+	}
+	if c.HostHealthCheck {
+		if err := c.HostHealth().Validate(); err != nil {
+			return fmt.Errorf("HOST_MAX_CPU: %w", err)
+		}
+	}
+	if err := c.FailurePolicy().Validate(); err != nil {
+		return fmt.Errorf("invalid failure action: %w", err)
+	}
+
+	for _, target := range c.RoutingProbeTargets {
+		if host, port, err := net.SplitHostPort(target); err != nil || host == "" || port == "" {
+			return fmt.Errorf("invalid host:port in ROUTING_PROBE_TARGETS: %s", target)
+		}
+	}
+
+	if c.StateDirectory != "" && !filepath.IsAbs(c.StateDirectory) {
+		return fmt.Errorf("STATE_DIRECTORY must be an absolute path: %s", c.StateDirectory)
+	}
+
+	if (c.APITLSCert == "") != (c.APITLSKey == "") {
+		return fmt.Errorf("API_TLS_CERT and API_TLS_KEY must be set together")
+	}
+	for _, file := range []string{c.APITLSCert, c.APITLSKey} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("API TLS certificate file is not readable: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// AuditLogPath returns the file control actions are recorded in
+func (c *Config) AuditLogPath() string {
+	if c.AuditLogFile != "" {
+		return c.AuditLogFile
+	}
+	return c.StatePath("logs", "audit.log")
+}
+
+// HealthModel returns the model that scores connectivity and diagnostics
+// with the configured weights and cutoffs
+func (c *Config) HealthModel() *health.Model {
+	// Validate rejects invalid weights, so the error is not checked again
+	weights, _ := health.ParseWeights(c.HealthWeights)
+	degraded, reboot := c.healthCutoffs()
+	return health.NewModel(weights, float64(degraded), float64(reboot))
+}
+
+// ModemTunnel returns the tunnel to a modem that is not on the local
+// network; it is not enabled when the modem is reached directly
+func (c *Config) ModemTunnel() tunnel.Config {
+	return tunnel.Config{
+		SSHHost:       c.ModemTunnelSSH,
+		SSHKey:        c.ModemTunnelSSHKey,
+		SSHKnownHosts: c.ModemTunnelSSHKnownHosts,
+		Interface:     c.ModemTunnelInterface,
+	}
+}
+
+// Dependencies returns the dependencies between connectivity checks
+func (c *Config) Dependencies() connectivity.Dependencies {
+	// Validate rejects invalid dependencies, so the error is not checked again
+	deps, _ := connectivity.ParseDependencies(c.CheckDependencies)
+	return deps
+}
+
+// TargetRotation returns how the connectivity tests choose among their
+// targets
+func (c *Config) TargetRotation() connectivity.TargetRotation {
+	return connectivity.TargetRotation{
+		PerCheck:           c.TargetsPerCheck,
+		QuarantineFailures: c.TargetQuarantineFailures,
+		QuarantineDuration: c.TargetQuarantineDuration,
+	}
+}
+
+// EscalationPolicy returns the policy that decides when a check runs the
+// comprehensive tests right away
+func (c *Config) EscalationPolicy() connectivity.EscalationPolicy {
+	// Validate rejects invalid windows, so the error is not checked again
+	windows, _ := connectivity.ParseTimeWindows(c.EscalationWindows)
+	return connectivity.EscalationPolicy{
+		ConsecutiveFailures: c.EscalationFailures,
+		ValidationInterval:  c.EscalationValidationInterval,
+		Windows:             windows,
+	}
+}
+
+// WireGuardCheck returns the WireGuard tunnel the lightweight tests check
+func (c *Config) WireGuardCheck() connectivity.WireGuardCheck {
+	return connectivity.WireGuardCheck{
+		Interface:       c.WireGuardInterface,
+		Peer:            c.WireGuardPeer,
+		MaxHandshakeAge: c.WireGuardHandshakeAge,
+		Probe:           c.WireGuardProbe,
+	}
+}
+
+// Proxy returns the proxy of outbound HTTP requests other than to the modem,
+// such as the HTTP checks and the public IP and DDNS services, for
+// http.Transport.Proxy. Without OutboundProxy it follows HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY; with ProxyDirect it is nil, for no proxy.
+func (c *Config) Proxy() func(*http.Request) (*url.URL, error) {
+	// Validate rejects an invalid proxy, so the error is not checked again
+	proxy, _ := c.parseOutboundProxy()
+	switch {
+	case c.OutboundProxy == "":
+		return http.ProxyFromEnvironment
+	case proxy == nil:
+		return nil
+	}
+	return http.ProxyURL(proxy)
+}
+
+// parseOutboundProxy returns the URL of OutboundProxy, nil when it is empty
+// or ProxyDirect
+func (c *Config) parseOutboundProxy() (*url.URL, error) {
+	if c.OutboundProxy == "" || c.OutboundProxy == ProxyDirect {
+		return nil, nil
+	}
+	proxy, err := url.Parse(c.OutboundProxy)
+	if err != nil {
+		return nil, err
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("%s: scheme must be http, https, socks5 or socks5h", c.OutboundProxy)
+	}
+	if proxy.Host == "" {
+		return nil, fmt.Errorf("%s: missing host", c.OutboundProxy)
+	}
+	return proxy, nil
+}
+
+// healthCutoffs returns the degraded and reboot score cutoffs, with unset
+// ones replaced by the defaults
+func (c *Config) healthCutoffs() (degraded, reboot int) {
+	degraded, reboot = c.HealthDegradedScore, c.HealthRebootScore
+	if degraded == 0 {
+		degraded = health.DefaultDegradedScore
+	}
+	if reboot == 0 {
+		reboot = health.DefaultRebootScore
+	}
+	return degraded, reboot
+}
+
+// PublicIPEnabled reports whether the public IP is tracked; a DDNS provider
+// or periodic recording needs it, so configuring either turns tracking on
+func (c *Config) PublicIPEnabled() bool {
+	return c.PublicIPCheck || c.DDNSProvider != "" || c.PublicIPRecordCycles > 0
+}
+
+// FeatureEnabled reports whether the optional subsystem name, such as
+// features.NameHistory, is built in and not listed in DisabledFeatures
+func (c *Config) FeatureEnabled(name string) bool {
+	if !features.Compiled(name) {
+		return false
+	}
+	for _, disabled := range c.DisabledFeatures {
+		if strings.EqualFold(disabled, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// HistoryPath returns the file network events are recorded in
+func (c *Config) HistoryPath() string {
+	return c.StatePath("logs", "history.jsonl")
+}
+
+// NotificationQueuePath returns the file undeliverable notifications are
+// queued in
+func (c *Config) NotificationQueuePath() string {
+	return c.StatePath("logs", "notification-queue.json")
+}
+
+// OutagesPath returns the file the outage tracker keeps outages in
+func (c *Config) OutagesPath() string {
+	return c.StatePath("logs", "outages.json")
+}
+
+// ReportsPath returns the directory outage reports are written to
+func (c *Config) ReportsPath() string {
+	return c.StatePath("logs", "reports")
+}
+
+// HistoryRetention returns the limits the history is pruned to
+func (c *Config) HistoryRetention() history.Retention {
+	return history.Retention{
+		MaxAge:    time.Duration(c.HistoryMaxAge) * 24 * time.Hour,
+		MaxEvents: c.HistoryMaxEvents,
+		MaxBytes:  int64(c.HistoryMaxSize) * 1024 * 1024,
+	}
+}
+
+// APIKeysPath returns the file API keys are stored in
+func (c *Config) APIKeysPath() string {
+	if c.APIKeysFile != "" {
+		return c.APIKeysFile
+	}
+	return c.StatePath("api-keys.json")
+}
+
+// StateDir returns the directory everything the watchdog writes at runtime
+// is kept in, so the rest of the filesystem can be read-only
+func (c *Config) StateDir() string {
+	if c.StateDirectory != "" {
+		return c.StateDirectory
+	}
+	return c.WorkingDirectory
+}
+
+// StatePath joins elem to the state directory. Without a state directory
+// it is empty, so nothing is persisted rather than written relative to the
+// current directory.
+func (c *Config) StatePath(elem ...string) string {
+	if c.StateDir() == "" {
+		return ""
+	}
+	return filepath.Join(append([]string{c.StateDir()}, elem...)...)
+}
+
+// RebootDeadline returns how long a reboot and the recovery wait after it
+// may take: RebootTimeout, or by default the reboot command timeout plus
+// RecoveryWait
+func (c *Config) RebootDeadline() time.Duration {
+	if c.RebootTimeout > 0 {
+		return c.RebootTimeout
+	}
+	return DefaultRebootCommandTimeout + c.RecoveryWait
+}
+
+// ControlSocketPath returns the Unix socket CLI commands reach the running
+// service on
+func (c *Config) ControlSocketPath() string {
+	return c.StatePath("control.sock")
+}
+
+// Redacted returns a copy of the configuration with credentials masked, for
+// output that may be shared
+func (c *Config) Redacted() Config {
+	redacted := *c
+	for _, secret := range []*string{&redacted.ModemPassword, &redacted.DDNSToken, &redacted.ResponderKey, &redacted.TwilioAuthToken, &redacted.BeaconToken, &redacted.BackupS3SecretKey, &redacted.ClusterPeerKey, &redacted.QuorumKey} {
+		if *secret != "" {
+			*secret = RedactedValue
+		}
+	}
+	// WebDAV destinations carry their password in the URL
+	for _, destination := range []*string{&redacted.BackupDestination, &redacted.SyncDestination} {
+		if u, err := url.Parse(*destination); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), RedactedValue)
+				*destination = u.String()
+			}
+		}
+	}
+	return redacted
+}
+
+// isPhoneNumber reports whether number is digits, optionally after a +
+func isPhoneNumber(number string) bool {
+	digits := strings.TrimPrefix(number, "+")
+	if len(digits) < 3 || len(digits) > 15 {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// SMS returns the configuration of the SMS notifications
+func (c *Config) SMS() sms.Config {
+	return sms.Config{
+		Provider:   c.SMSProvider,
+		To:         c.SMSTo,
+		AccountSID: c.TwilioAccountSID,
+		AuthToken:  c.TwilioAuthToken,
+		From:       c.TwilioFrom,
+		Device:     c.SMSDevice,
+	}
+}
+
+// Beacon returns the configuration of the status beacon
+func (c *Config) Beacon() beacon.Config {
+	return beacon.Config{
+		URL:       c.BeaconURL,
+		Interface: c.BeaconInterface,
+		Token:     c.BeaconToken,
+	}
+}
+
+// Backup returns the configuration of scheduled backups
+func (c *Config) Backup() backup.Config {
+	return backup.Config{
+		Destination:    c.BackupDestination,
+		Keep:           c.BackupKeep,
+		S3Endpoint:     c.BackupS3Endpoint,
+		S3Region:       c.BackupS3Region,
+		S3AccessKey:    c.BackupS3AccessKey,
+		S3SecretKey:    c.BackupS3SecretKey,
+		SFTPKey:        c.BackupSFTPKey,
+		SFTPKnownHosts: c.BackupSFTPKnownHosts,
+	}
+}
+
+// Sync returns the configuration of remote sync, which shares the S3 and
+// SFTP credentials of backups
+func (c *Config) Sync() backup.Config {
+	cfg := c.Backup()
+	cfg.Destination = c.SyncDestination
+	cfg.Keep = 0
+	return cfg
+}
+
+// Cluster returns the configuration of the active/standby cluster
+func (c *Config) Cluster() cluster.Config {
+	return cluster.Config{
+		Role:              c.ClusterRole,
+		Peer:              c.ClusterPeer,
+		PeerKey:           c.ClusterPeerKey,
+		PeerFingerprint:   c.ClusterPeerFingerprint,
+		HeartbeatInterval: c.ClusterHeartbeatInterval,
+		FailoverAfter:     c.ClusterFailoverAfter,
+	}
+}
+
+// Quorum returns the configuration of the reboot quorum
+func (c *Config) Quorum() quorum.Config {
+	return quorum.Config{
+		Peers:    c.QuorumPeers,
+		Key:      c.QuorumKey,
+		Required: c.QuorumRequired,
+		MaxAge:   c.QuorumMaxAge,
+		NoVerify: c.QuorumNoVerify,
+	}
+}
+
+// HostHealth returns the configuration of the monitoring host checks
+func (c *Config) HostHealth() hosthealth.Config {
+	return hosthealth.Config{MaxCPU: c.HostMaxCPU}
+}
+
+// FailurePolicy returns the action taken for each failure verdict
+func (c *Config) FailurePolicy() verdict.Policy {
+	return verdict.Policy{LAN: c.LANFailureAction, WAN: c.WANFailureAction, Upstream: c.UpstreamFailureAction}
+}
+
+// SyncSiteName returns the folder of this host at the sync destination
+func (c *Config) SyncSiteName() string {
+	if c.SyncSite != "" {
+		return c.SyncSite
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return "watchdog"
+}
+
+// BackupSources returns the files a backup holds: the state directory
+// without the log file, and the configuration file
+func (c *Config) BackupSources() backup.Sources {
+	return backup.Sources{
+		StateDir:   c.StateDir(),
+		ConfigFile: c.ConfigFile,
+		Exclude:    []string{c.LogFile},
+	}
+}
+
+// modemTypes returns the modem types MODEM_TYPE accepts: those with a
+// built-in driver and PluginModemType
+func modemTypes() []string {
+	return append(append([]string(nil), SupportedModemTypes...), PluginModemType)
+}
+
+// isSupportedModemType checks if a modem type has a driver
+func isSupportedModemType(modemType string) bool {
+	for _, supported := range modemTypes() {
+		if strings.EqualFold(modemType, supported) {
+			return true
+		}
+	}
+	return false
+}
+
 // isValidHostname checks if a string is a valid hostname
 func isValidHostname(hostname string) bool {
 	if len(hostname) == 0 || len(hostname) > 253 {
@@ -742,16 +2939,17 @@ func isValidHostname(hostname string) bool {
 	return true
 }
 
-// Helper functions for environment variable parsing
+// Helper functions for environment variable parsing; every variable can
+// also be set with the EnvPrefix
 func getEnvString(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value := Getenv(key); value != "" {
 		if parsed, err := strconv.Atoi(value); err == nil {
 			return parsed
 		}
@@ -760,7 +2958,7 @@ func getEnvInt(key string, defaultValue int) int {
 }
 
 func getEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
+	if value := Getenv(key); value != "" {
 		if parsed, err := strconv.ParseBool(value); err == nil {
 			return parsed
 		}
@@ -769,7 +2967,7 @@ func getEnvBool(key string, defaultValue bool) bool {
 }
 
 func getEnvFloat(key string, defaultValue float64) float64 {
-	if value := os.Getenv(key); value != "" {
+	if value := Getenv(key); value != "" {
 		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
 			return parsed
 		}
@@ -778,7 +2976,7 @@ func getEnvFloat(key string, defaultValue float64) float64 {
 }
 
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	if value := Getenv(key); value != "" {
 		// Try parsing as duration first (e.g., "30s", "5m", "1h")
 		if parsed, err := time.ParseDuration(value); err == nil {
 			return parsed
@@ -792,7 +2990,7 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 }
 
 func getEnvStringSlice(key string, defaultValue []string) []string {
-	if value := os.Getenv(key); value != "" {
+	if value := Getenv(key); value != "" {
 		// Split by comma and trim whitespace
 		parts := strings.Split(value, ",")
 		result := make([]string, 0, len(parts))
@@ -808,3 +3006,21 @@ func getEnvStringSlice(key string, defaultValue []string) []string {
 	}
 	return defaultValue
 }
+
+// getEnvDirectory returns the first directory of the colon-separated list
+// in key, the format systemd uses for StateDirectory and RuntimeDirectory
+func getEnvDirectory(key string) string {
+	dir, _, _ := strings.Cut(Getenv(key), ":")
+	return dir
+}
+
+// defaultPidFile places the PID file in systemd's RuntimeDirectory, or the
+// state directory, when the environment names one
+func defaultPidFile() string {
+	for _, key := range []string{"RUNTIME_DIRECTORY", "STATE_DIRECTORY"} {
+		if dir := getEnvDirectory(key); dir != "" {
+			return filepath.Join(dir, "watchdog.pid")
+		}
+	}
+	return DefaultPidFile
+}
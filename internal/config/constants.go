@@ -2,3 +2,13 @@ package config
 
 // DefaultModemHost is the default IP address for MB8600 modems
 const DefaultModemHost = "192.168.100.1"
+
+// DefaultModemType selects the Motorola MB8600 HNAP driver
+const DefaultModemType = "mb8600"
+
+// SupportedModemTypes lists the modem types that have a driver
+var SupportedModemTypes = []string{"mb8600", "arris-sb", "netgear-cm", "technicolor"}
+
+// PluginModemType selects the modem driver of a plugin, available once a
+// plugin providing one has started
+const PluginModemType = "plugin"
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix prefixes every environment variable of the watchdog, such as
+// WATCHDOG_MODEM_HOST for MODEM_HOST, to avoid collisions with other
+// services; the unprefixed names are read when the prefixed one is unset
+const EnvPrefix = "WATCHDOG_"
+
+// DefaultEnvFile is the env file loaded from the working directory when no
+// --env-file is given
+const DefaultEnvFile = ".env"
+
+// Getenv returns the environment variable key, preferring its EnvPrefix
+// variant
+func Getenv(key string) string {
+	if !strings.HasPrefix(key, EnvPrefix) {
+		if value := os.Getenv(EnvPrefix + key); value != "" {
+			return value
+		}
+	}
+	return os.Getenv(key)
+}
+
+// LoadEnvFile sets the KEY=value lines of an env file as environment
+// variables. Variables already in the environment are kept, so the file
+// only provides defaults. Blank lines, # comments and an "export " before
+// the key are allowed, and values may be quoted.
+func LoadEnvFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read env file: %w", err)
+	}
+	vars, err := parseEnvFile(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse env file %s: %w", path, err)
+	}
+	for _, v := range vars {
+		if _, set := os.LookupEnv(v[0]); set {
+			continue
+		}
+		if err := os.Setenv(v[0], v[1]); err != nil {
+			return fmt.Errorf("failed to set %s: %w", v[0], err)
+		}
+	}
+	return nil
+}
+
+// parseEnvFile returns the key and value of every assignment of an env file
+func parseEnvFile(data []byte) ([][2]string, error) {
+	var vars [][2]string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=value", number)
+		}
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+		vars = append(vars, [2]string{key, value})
+	}
+	return vars, scanner.Err()
+}
+
+// parseEnvValue unquotes a value: double quotes take Go escapes such as \n,
+// single quotes are literal, and an unquoted value ends at " #"
+func parseEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := strings.LastIndex(value, `"`)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated double quote")
+		}
+		unquoted, err := strconv.Unquote(value[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid double-quoted value: %w", err)
+		}
+		return unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		end := strings.LastIndex(value, "'")
+		if end == 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return value[1:end], nil
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value, nil
+	}
+}
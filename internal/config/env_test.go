@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnvPrefix(t *testing.T) {
+	t.Setenv("MODEM_HOST", "192.168.100.1")
+	t.Setenv("CHECK_INTERVAL", "45s")
+	t.Setenv("WATCHDOG_CHECK_INTERVAL", "90s")
+	t.Setenv("WATCHDOG_FAILURE_THRESHOLD", "7")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.ModemHost != "192.168.100.1" {
+		t.Errorf("Expected the unprefixed MODEM_HOST as fallback, got %s", cfg.ModemHost)
+	}
+	if cfg.CheckInterval != 90*time.Second {
+		t.Errorf("Expected WATCHDOG_CHECK_INTERVAL to take precedence, got %v", cfg.CheckInterval)
+	}
+	if cfg.FailureThreshold != 7 {
+		t.Errorf("Expected WATCHDOG_FAILURE_THRESHOLD, got %d", cfg.FailureThreshold)
+	}
+
+	// Variables named with the prefix are read as they are
+	t.Setenv("WATCHDOG_LANGUAGE", "es")
+	if got := Getenv("WATCHDOG_LANGUAGE"); got != "es" {
+		t.Errorf("Expected WATCHDOG_LANGUAGE, got %q", got)
+	}
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# Watchdog settings
+export WATCHDOG_MODEM_HOST=10.0.0.1
+MODEM_PASSWORD="p@ss word\n"
+MODEM_USERNAME='admin # not a comment'
+FAILURE_THRESHOLD=4 # comment
+CHECK_INTERVAL=30s
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"WATCHDOG_MODEM_HOST", "MODEM_PASSWORD", "MODEM_USERNAME", "FAILURE_THRESHOLD"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	// The environment wins over the file
+	t.Setenv("CHECK_INTERVAL", "2m")
+
+	if err := LoadEnvFile(path); err != nil {
+		t.Fatalf("LoadEnvFile() failed: %v", err)
+	}
+	want := map[string]string{
+		"WATCHDOG_MODEM_HOST": "10.0.0.1",
+		"MODEM_PASSWORD":      "p@ss word\n",
+		"MODEM_USERNAME":      "admin # not a comment",
+		"FAILURE_THRESHOLD":   "4",
+		"CHECK_INTERVAL":      "2m",
+	}
+	for key, value := range want {
+		if got := os.Getenv(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+
+	invalid := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(invalid, []byte("MODEM_HOST=1.2.3.4\nnot an assignment\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadEnvFile(invalid); err == nil {
+		t.Error("Expected an error for a line without =")
+	}
+	if err := LoadEnvFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Home Assistant add-on settings
+const (
+	// HomeAssistantIngressPort is the port the supervisor's ingress proxy
+	// forwards the add-on panel to
+	HomeAssistantIngressPort = 8099
+	// HomeAssistantIngressProxy is the address ingress requests come from
+	HomeAssistantIngressProxy = "172.30.32.2"
+)
+
+// HomeAssistantOptions are the add-on options the supervisor writes to
+// /data/options.json. Options left out keep their environment or default value.
+type HomeAssistantOptions struct {
+	ModemType         string   `json:"modem_type"`
+	ModemHost         string   `json:"modem_host"`
+	ModemUsername     string   `json:"modem_username"`
+	ModemPassword     string   `json:"modem_password"`
+	ModemNoVerify     *bool    `json:"modem_noverify"`
+	CheckInterval     string   `json:"check_interval"`
+	FailureThreshold  int      `json:"failure_threshold"`
+	RecoveryWait      string   `json:"recovery_wait"`
+	PingHosts         []string `json:"ping_hosts"`
+	HTTPHosts         []string `json:"http_hosts"`
+	EnableDiagnostics *bool    `json:"enable_diagnostics"`
+	LogLevel          string   `json:"log_level"`
+	Language          string   `json:"language"`
+	APIActionLimit    *int     `json:"api_action_limit"`
+}
+
+// HomeAssistant reports whether the watchdog runs as a Home Assistant add-on
+func (c *Config) HomeAssistant() bool {
+	return c.HomeAssistantOptionsFile != ""
+}
+
+// applyHomeAssistantOptions reads the add-on options file and applies it.
+// The add-on keeps its state in the directory of the options file, logs to
+// stdout where the supervisor collects them, and serves the API on the
+// ingress port over plain HTTP, as the ingress proxy expects.
+func applyHomeAssistantOptions(cfg *Config) error {
+	content, err := os.ReadFile(cfg.HomeAssistantOptionsFile)
+	if err != nil {
+		return fmt.Errorf("failed to read Home Assistant options: %w", err)
+	}
+	var options HomeAssistantOptions
+	if err := json.Unmarshal(content, &options); err != nil {
+		return fmt.Errorf("failed to parse Home Assistant options %s: %w", cfg.HomeAssistantOptionsFile, err)
+	}
+
+	if options.ModemType != "" {
+		cfg.ModemType = options.ModemType
+	}
+	if options.ModemHost != "" {
+		cfg.ModemHost = options.ModemHost
+	}
+	if options.ModemUsername != "" {
+		cfg.ModemUsername = options.ModemUsername
+	}
+	if options.ModemPassword != "" {
+		cfg.ModemPassword = options.ModemPassword
+	}
+	if options.ModemNoVerify != nil {
+		cfg.ModemNoVerify = *options.ModemNoVerify
+	}
+	if options.CheckInterval != "" {
+		if cfg.CheckInterval, err = time.ParseDuration(options.CheckInterval); err != nil {
+			return fmt.Errorf("invalid check_interval option: %w", err)
+		}
+	}
+	if options.FailureThreshold != 0 {
+		cfg.FailureThreshold = options.FailureThreshold
+	}
+	if options.RecoveryWait != "" {
+		if cfg.RecoveryWait, err = time.ParseDuration(options.RecoveryWait); err != nil {
+			return fmt.Errorf("invalid recovery_wait option: %w", err)
+		}
+	}
+	if len(options.PingHosts) > 0 {
+		cfg.PingHosts = options.PingHosts
+	}
+	if len(options.HTTPHosts) > 0 {
+		cfg.HTTPHosts = options.HTTPHosts
+	}
+	if options.EnableDiagnostics != nil {
+		cfg.EnableDiagnostics = *options.EnableDiagnostics
+	}
+	if options.LogLevel != "" {
+		cfg.LogLevel = options.LogLevel
+	}
+	if options.Language != "" {
+		cfg.Language = options.Language
+	}
+	if options.APIActionLimit != nil {
+		cfg.APIActionLimit = *options.APIActionLimit
+	}
+
+	cfg.WorkingDirectory = filepath.Dir(cfg.HomeAssistantOptionsFile)
+	cfg.LogFile = ""
+	cfg.PidFile = ""
+	if cfg.APIListenAddress == "" {
+		cfg.APIListenAddress = fmt.Sprintf(":%d", HomeAssistantIngressPort)
+	}
+	cfg.APITLS = APITLSOff
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHomeAssistantOptions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "options.json")
+	content := `{
+		"modem_type": "arris-sb",
+		"modem_host": "192.168.0.1",
+		"modem_password": "secret",
+		"modem_noverify": false,
+		"check_interval": "30s",
+		"failure_threshold": 5,
+		"ping_hosts": ["9.9.9.9", "1.0.0.1"],
+		"enable_diagnostics": false,
+		"language": "es",
+		"api_action_limit": 0
+	}`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write options: %v", err)
+	}
+	t.Setenv("HA_OPTIONS_FILE", path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cfg.HomeAssistant() {
+		t.Fatal("Expected add-on mode")
+	}
+
+	if cfg.ModemType != "arris-sb" || cfg.ModemHost != "192.168.0.1" || cfg.ModemPassword != "secret" || cfg.ModemNoVerify {
+		t.Errorf("Unexpected modem settings %q %q %q %v", cfg.ModemType, cfg.ModemHost, cfg.ModemPassword, cfg.ModemNoVerify)
+	}
+	if cfg.ModemUsername != "admin" {
+		t.Errorf("Options left out should keep their default, got username %q", cfg.ModemUsername)
+	}
+	if cfg.CheckInterval != 30*time.Second || cfg.FailureThreshold != 5 {
+		t.Errorf("Unexpected monitoring settings %v %d", cfg.CheckInterval, cfg.FailureThreshold)
+	}
+	if !reflect.DeepEqual(cfg.PingHosts, []string{"9.9.9.9", "1.0.0.1"}) {
+		t.Errorf("Unexpected ping hosts %v", cfg.PingHosts)
+	}
+	if cfg.EnableDiagnostics || cfg.Language != "es" || cfg.APIActionLimit != 0 {
+		t.Errorf("Unexpected feature settings %v %q %d", cfg.EnableDiagnostics, cfg.Language, cfg.APIActionLimit)
+	}
+
+	if cfg.WorkingDirectory != dir || cfg.LogFile != "" || cfg.PidFile != "" {
+		t.Errorf("Expected state in %s and logs on stdout, got %q %q %q", dir, cfg.WorkingDirectory, cfg.LogFile, cfg.PidFile)
+	}
+	if cfg.APIListenAddress != ":8099" || cfg.APITLS != APITLSOff {
+		t.Errorf("Expected plain HTTP on the ingress port, got %q %q", cfg.APIListenAddress, cfg.APITLS)
+	}
+}
+
+func TestHomeAssistantOptionsErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv("HA_OPTIONS_FILE", filepath.Join(dir, "missing.json"))
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for a missing options file")
+	}
+
+	path := filepath.Join(dir, "options.json")
+	t.Setenv("HA_OPTIONS_FILE", path)
+	for _, content := range []string{
+		`not json`,
+		`{"check_interval": "often"}`,
+		`{"failure_threshold": -1}`,
+		`{"modem_type": "unknown"}`,
+	} {
+		os.WriteFile(path, []byte(content), 0600)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected an error for options %s", content)
+		}
+	}
+}
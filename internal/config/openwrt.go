@@ -0,0 +1,239 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// OpenWrt UCI configuration
+const (
+	// OpenWrtPackage is the UCI package, read from /etc/config/mb8600-watchdog
+	OpenWrtPackage = "mb8600-watchdog"
+	// OpenWrtSection is the type of the section holding the settings
+	OpenWrtSection = "watchdog"
+	// OpenWrtConfigFile is where OpenWrt keeps the UCI package
+	OpenWrtConfigFile = "/etc/config/" + OpenWrtPackage
+)
+
+// uciSection is one "config <type> [name]" section of a UCI file
+type uciSection struct {
+	typ     string
+	name    string
+	options map[string][]string
+	// lists are the options set with "list" rather than "option"
+	lists map[string]bool
+}
+
+// isUCI reports whether data looks like a UCI file rather than JSON
+func isUCI(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return strings.HasPrefix(line, "config ") || strings.HasPrefix(line, "config\t") || line == "config"
+	}
+	return false
+}
+
+// decodeUCI fills jsonCfg from the first watchdog section of a UCI file.
+// Options are the settings of the JSON file in snake_case, such as
+// check_interval for CheckInterval; list settings take "list" options or
+// one "option" of space-separated values. The enabled option belongs to the
+// init script and is skipped.
+func decodeUCI(data []byte, jsonCfg *ConfigJSON) error {
+	sections, err := parseUCI(data)
+	if err != nil {
+		return err
+	}
+	var section *uciSection
+	for i := range sections {
+		if sections[i].typ == OpenWrtSection {
+			section = &sections[i]
+			break
+		}
+	}
+	if section == nil {
+		return fmt.Errorf("no %q section in UCI config", OpenWrtSection)
+	}
+
+	fields := make(map[string]reflect.StructField)
+	configType := reflect.TypeOf(ConfigJSON{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		fields[strings.ToLower(field.Name)] = field
+	}
+
+	values := make(map[string]interface{}, len(section.options))
+	for name, raw := range section.options {
+		if name == "enabled" {
+			continue
+		}
+		field, ok := fields[strings.ReplaceAll(strings.ToLower(name), "_", "")]
+		if !ok {
+			return fmt.Errorf("unknown option %s in UCI config", name)
+		}
+		value, err := uciValue(field.Type, raw, section.lists[name])
+		if err != nil {
+			return fmt.Errorf("invalid option %s in UCI config: %w", name, err)
+		}
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		values[jsonName] = value
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to convert UCI config: %w", err)
+	}
+	if err := json.Unmarshal(encoded, jsonCfg); err != nil {
+		return fmt.Errorf("failed to convert UCI config: %w", err)
+	}
+	return nil
+}
+
+// uciValue converts the values of an option to the type of its setting
+func uciValue(t reflect.Type, raw []string, list bool) (interface{}, error) {
+	if t.Kind() == reflect.Slice {
+		if list {
+			return raw, nil
+		}
+		return strings.Fields(raw[len(raw)-1]), nil
+	}
+	if list {
+		return nil, fmt.Errorf("expected a single value, not a list")
+	}
+	value := raw[len(raw)-1]
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		switch strings.ToLower(value) {
+		case "1", "yes", "on", "true", "enabled":
+			return true, nil
+		case "0", "no", "off", "false", "disabled":
+			return false, nil
+		}
+		return nil, fmt.Errorf("expected a boolean, got %q", value)
+	case reflect.Int:
+		return strconv.Atoi(value)
+	case reflect.Float64:
+		return strconv.ParseFloat(value, 64)
+	default:
+		return value, nil
+	}
+}
+
+// parseUCI parses the sections of a UCI file: "config", "option" and "list"
+// lines, with values bare, single-quoted or double-quoted, and # comments
+func parseUCI(data []byte) ([]uciSection, error) {
+	var sections []uciSection
+	for number, line := range strings.Split(string(data), "\n") {
+		words, err := uciWords(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d of UCI config: %w", number+1, err)
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		switch words[0] {
+		case "config":
+			if len(words) < 2 || len(words) > 3 {
+				return nil, fmt.Errorf("line %d of UCI config: expected config <type> [name]", number+1)
+			}
+			section := uciSection{typ: words[1], options: make(map[string][]string), lists: make(map[string]bool)}
+			if len(words) == 3 {
+				section.name = words[2]
+			}
+			sections = append(sections, section)
+		case "option", "list":
+			if len(words) != 3 {
+				return nil, fmt.Errorf("line %d of UCI config: expected %s <name> <value>", number+1, words[0])
+			}
+			if len(sections) == 0 {
+				return nil, fmt.Errorf("line %d of UCI config: %s outside a config section", number+1, words[0])
+			}
+			section := &sections[len(sections)-1]
+			name := words[1]
+			if words[0] == "list" {
+				if !section.lists[name] {
+					section.options[name] = nil
+				}
+				section.lists[name] = true
+				section.options[name] = append(section.options[name], words[2])
+			} else {
+				section.lists[name] = false
+				section.options[name] = []string{words[2]}
+			}
+		default:
+			return nil, fmt.Errorf("line %d of UCI config: unknown keyword %q", number+1, words[0])
+		}
+	}
+	return sections, nil
+}
+
+// uciWords splits a UCI line into words, removing quotes and comments
+func uciWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '#' && !inWord:
+			return words, nil
+		case c == ' ' || c == '\t' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// OpenWrtConfig returns OpenWrtConfigFile when running on OpenWrt with the
+// UCI package installed, so commands find the configuration of the service
+// without --config, or an empty string otherwise
+func OpenWrtConfig() string {
+	if _, err := os.Stat("/etc/openwrt_release"); err != nil {
+		return ""
+	}
+	if _, err := os.Stat(OpenWrtConfigFile); err != nil {
+		return ""
+	}
+	return OpenWrtConfigFile
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadFromUCIFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), OpenWrtPackage)
+	content := `# MB8600 Watchdog
+config watchdog 'main'
+	option enabled '1'
+	option modem_host "192.168.100.1"
+	option modem_noverify 'yes'
+	option check_interval '45s'
+	option failure_threshold 4
+	option retry_backoff_factor '1.5'
+	list ping_hosts '9.9.9.9'
+	list ping_hosts '1.1.1.1' # comment
+	option http_hosts 'https://a.example https://b.example'
+
+config other 'ignored'
+	option modem_host '10.0.0.1'
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	if cfg.ModemHost != "192.168.100.1" {
+		t.Errorf("Expected modem host 192.168.100.1, got %s", cfg.ModemHost)
+	}
+	if !cfg.ModemNoVerify {
+		t.Error("Expected modem_noverify to be set")
+	}
+	if cfg.CheckInterval != 45*time.Second {
+		t.Errorf("Expected check interval 45s, got %s", cfg.CheckInterval)
+	}
+	if cfg.FailureThreshold != 4 {
+		t.Errorf("Expected failure threshold 4, got %d", cfg.FailureThreshold)
+	}
+	if cfg.RetryBackoffFactor != 1.5 {
+		t.Errorf("Expected retry backoff factor 1.5, got %v", cfg.RetryBackoffFactor)
+	}
+	if want := []string{"9.9.9.9", "1.1.1.1"}; !reflect.DeepEqual(cfg.PingHosts, want) {
+		t.Errorf("Expected ping hosts %v, got %v", want, cfg.PingHosts)
+	}
+	if want := []string{"https://a.example", "https://b.example"}; !reflect.DeepEqual(cfg.HTTPHosts, want) {
+		t.Errorf("Expected HTTP hosts %v, got %v", want, cfg.HTTPHosts)
+	}
+}
+
+func TestInvalidUCIFile(t *testing.T) {
+	tests := map[string]string{
+		"unknown option":     "config watchdog 'main'\n\toption no_such_setting '1'\n",
+		"invalid boolean":    "config watchdog 'main'\n\toption modem_noverify 'maybe'\n",
+		"invalid number":     "config watchdog 'main'\n\toption failure_threshold 'three'\n",
+		"list for a value":   "config watchdog 'main'\n\tlist modem_host 'a'\n",
+		"unterminated quote": "config watchdog 'main'\n\toption modem_host 'a\n",
+		"no section":         "config other 'main'\n\toption modem_host 'a'\n",
+		"option outside":     "option modem_host 'a'\nconfig watchdog\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			var jsonCfg ConfigJSON
+			if err := decodeUCI([]byte(content), &jsonCfg); err == nil {
+				t.Errorf("Expected an error for:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestIsUCI(t *testing.T) {
+	if !isUCI([]byte("# comment\n\nconfig watchdog 'main'\n")) {
+		t.Error("Expected a UCI file to be detected")
+	}
+	if isUCI([]byte(`{"ModemHost": "192.168.100.1"}`)) {
+		t.Error("Expected JSON not to be taken for UCI")
+	}
+	if words, err := uciWords(`option name "a \"quoted\" value"`); err != nil || strings.Join(words, "|") != `option|name|a "quoted" value` {
+		t.Errorf("Expected escaped quotes to be kept, got %q (%v)", words, err)
+	}
+}
@@ -0,0 +1,298 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/features"
+	"github.com/perezjoseph/mb8600-watchdog/internal/publicip"
+	"github.com/perezjoseph/mb8600-watchdog/internal/verdict"
+)
+
+// SchemaURL is where releases publish the JSON Schema of the config file;
+// a config file names it in "$schema" to get editor autocomplete
+const SchemaURL = "https://github.com/perezjoseph/mb8600-watchdog/releases/latest/download/config.schema.json"
+
+// durationPattern matches the Go durations accepted by duration settings
+const durationPattern = `^[-+]?(0|([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+$`
+
+// Schema is the subset of JSON Schema used to describe the config file
+type Schema struct {
+	SchemaVersion        string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Examples             []string           `json:"examples,omitempty"`
+}
+
+// schemaEnums are the settings that take one of a fixed set of values
+var schemaEnums = map[string][]string{
+	"CheckOverlapPolicy": {"skip", "queue"},
+	"APITLS":             {APITLSAuto, APITLSOff},
+	"DDNSProvider":       publicip.Providers,
+
+	"RebootShutdownPolicy": {RebootShutdownWait, RebootShutdownAbort},
+
+	"LANFailureAction":      verdict.Actions,
+	"WANFailureAction":      verdict.Actions,
+	"UpstreamFailureAction": verdict.Actions,
+}
+
+// schemaExamples are suggested values of settings that are matched without
+// regard to case, so a schema enum would reject valid files
+var schemaExamples = map[string][]string{
+	"ModemType": modemTypes(),
+	"LogLevel":  {"DEBUG", "INFO", "WARN", "ERROR"},
+	"LogFormat": {"console", "json", "text"},
+}
+
+// GenerateSchema returns the JSON Schema of the config file, derived from
+// ConfigJSON. Settings that are durations in Config must be Go durations.
+func GenerateSchema() *Schema {
+	closed := false
+	schema := &Schema{
+		SchemaVersion:        "http://json-schema.org/draft-07/schema#",
+		ID:                   SchemaURL,
+		Title:                "MB8600 Watchdog configuration",
+		Type:                 "object",
+		Properties:           map[string]*Schema{"$schema": {Type: "string", Description: "URL or path of this schema"}},
+		AdditionalProperties: &closed,
+	}
+
+	configType := reflect.TypeOf(Config{})
+	jsonType := reflect.TypeOf(ConfigJSON{})
+	for i := 0; i < jsonType.NumField(); i++ {
+		field := jsonType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		property := schemaType(field.Type)
+		if target, ok := configType.FieldByName(field.Name); ok && target.Type == reflect.TypeOf(time.Duration(0)) {
+			property.Pattern = durationPattern
+			property.Description = "Duration such as 30s, 5m or 1h30m"
+		}
+		property.Enum = schemaEnums[name]
+		property.Examples = schemaExamples[name]
+		if name == "DisabledFeatures" {
+			property.Items.Examples = features.Names()
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+// schemaType returns the schema of a ConfigJSON field type
+func schemaType(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int:
+		return &Schema{Type: "integer"}
+	case reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: schemaType(t.Elem())}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// MarshalSchema returns the schema of the config file as indented JSON
+func MarshalSchema() ([]byte, error) {
+	data, err := json.MarshalIndent(GenerateSchema(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// SchemaError is a value of a config file that does not match the schema,
+// at Path such as $.PingHosts[1]
+type SchemaError struct {
+	Path    string
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// SchemaErrors are all the schema errors of a config file
+type SchemaErrors []SchemaError
+
+func (e SchemaErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateSchema checks a JSON config file against the schema, returning
+// SchemaErrors with the path of every mismatch
+func ValidateSchema(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var errs SchemaErrors
+	GenerateSchema().validate("$", value, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validate appends to errs every mismatch between value and the schema
+func (s *Schema) validate(path string, value interface{}, errs *SchemaErrors) {
+	mismatch := func(format string, args ...interface{}) {
+		*errs = append(*errs, SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if got := jsonTypeOf(value); !typeMatches(s.Type, got, value) {
+		mismatch("expected %s, got %s", s.Type, got)
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property, ok := s.Properties[key]
+			if !ok {
+				if s.AdditionalProperties == nil || *s.AdditionalProperties {
+					continue
+				}
+				message := fmt.Sprintf("unknown setting %q", key)
+				if suggestion := s.suggestProperty(key); suggestion != "" {
+					message += fmt.Sprintf(", did you mean %q?", suggestion)
+				}
+				*errs = append(*errs, SchemaError{Path: path, Message: message})
+				continue
+			}
+			property.validate(path+"."+key, value[key], errs)
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range value {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case string:
+		if len(s.Enum) > 0 && !containsString(s.Enum, value) {
+			mismatch("%q is not one of %s", value, strings.Join(s.Enum, ", "))
+		}
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(value) {
+			if s.Pattern == durationPattern {
+				mismatch("%q is not a duration such as 30s or 5m", value)
+			} else {
+				mismatch("%q does not match %s", value, s.Pattern)
+			}
+		}
+	}
+}
+
+// suggestProperty returns the property key was probably meant to be: one
+// that differs only in case or by at most two edits
+func (s *Schema) suggestProperty(key string) string {
+	best, bestDistance := "", 3
+	for name := range s.Properties {
+		if strings.EqualFold(name, key) {
+			return name
+		}
+		if distance := editDistance(strings.ToLower(name), strings.ToLower(key)); distance < bestDistance ||
+			(distance == bestDistance && name < best) {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// jsonTypeOf returns the JSON type name of a decoded value
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// typeMatches reports whether a value of JSON type got satisfies the schema
+// type want; an integer is a number without a fraction
+func typeMatches(want, got string, value interface{}) bool {
+	switch {
+	case want == "":
+		return true
+	case want == "integer" && got == "number":
+		_, err := strconv.ParseInt(string(value.(json.Number)), 10, 64)
+		return err == nil
+	default:
+		return want == got
+	}
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
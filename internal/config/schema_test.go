@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	valid := `{
+  "$schema": "config.schema.json",
+  "ModemType": "arris-sb",
+  "CheckInterval": "30s",
+  "RecoveryWait": "10m",
+  "FailureThreshold": 3,
+  "RetryBackoffFactor": 1.5,
+  "ModemNoVerify": true,
+  "PingHosts": ["1.1.1.1", "8.8.8.8"],
+  "APITLS": "off"
+}`
+	if err := ValidateSchema([]byte(valid)); err != nil {
+		t.Errorf("Expected a valid config, got %v", err)
+	}
+
+	invalid := `{
+  "CheckIntervall": "30s",
+  "modemhost": "192.168.100.1",
+  "FailureThreshold": 2.5,
+  "RecoveryWait": "10 minutes",
+  "PingHosts": ["1.1.1.1", 8],
+  "EnableDebug": "yes",
+  "CheckOverlapPolicy": "wait"
+}`
+	err := ValidateSchema([]byte(invalid))
+	var errs SchemaErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected SchemaErrors, got %v", err)
+	}
+	want := []string{
+		`$: unknown setting "CheckIntervall", did you mean "CheckInterval"?`,
+		`$.CheckOverlapPolicy: "wait" is not one of skip, queue`,
+		`$.EnableDebug: expected boolean, got string`,
+		`$.FailureThreshold: expected integer, got number`,
+		`$.PingHosts[1]: expected string, got number`,
+		`$.RecoveryWait: "10 minutes" is not a duration such as 30s or 5m`,
+		`$: unknown setting "modemhost", did you mean "ModemHost"?`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(want), len(errs), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("Error %d = %s, want %s", i, err, want[i])
+		}
+	}
+
+	if err := ValidateSchema([]byte(`["not", "an", "object"]`)); err == nil || !strings.Contains(err.Error(), "expected object, got array") {
+		t.Errorf("Expected a type error for the document, got %v", err)
+	}
+	if err := ValidateSchema([]byte(`{"CheckInterval": `)); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
+
+func TestLoadConfigFileRejectsSchemaErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"CheckIntervall": "30s"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadFromFile(path)
+	if err == nil || !strings.Contains(err.Error(), `did you mean "CheckInterval"`) {
+		t.Errorf("Expected the misspelled setting to be reported, got %v", err)
+	}
+}
+
+// The schema shipped with releases and the example configs match the code
+func TestShippedSchemaAndConfigs(t *testing.T) {
+	generated, err := MarshalSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shipped, err := os.ReadFile("../../config/config.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(shipped) != string(generated) {
+		t.Error("config/config.schema.json is out of date, run: go run ./cmd/watchdog config schema > config/config.schema.json")
+	}
+
+	examples, err := filepath.Glob("../../config/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	more, _ := filepath.Glob("../../examples/*.json")
+	for _, path := range append(examples, more...) {
+		if filepath.Base(path) == "config.schema.json" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateSchema(data); err != nil {
+			t.Errorf("%s does not match the schema: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// LocalTarget is a ping or HTTP target on the local network, which keeps
+// answering while the internet is down
+type LocalTarget struct {
+	Setting string // PING_HOSTS or HTTP_HOSTS
+	Target  string
+	Address string // the address the target is or resolves to
+	Reason  string
+}
+
+func (t LocalTarget) String() string {
+	if t.Address == "" || t.Address == t.Target {
+		return fmt.Sprintf("%s target %s is %s", t.Setting, t.Target, t.Reason)
+	}
+	return fmt.Sprintf("%s target %s resolves to %s, which is %s", t.Setting, t.Target, t.Address, t.Reason)
+}
+
+// checkTarget is a ping or HTTP target with the host it reaches
+type checkTarget struct {
+	setting, target, host string
+}
+
+// checkTargets returns the ping and HTTP targets with their hosts
+func (c *Config) checkTargets() []checkTarget {
+	targets := make([]checkTarget, 0, len(c.PingHosts)+len(c.HTTPHosts))
+	for _, host := range c.PingHosts {
+		targets = append(targets, checkTarget{"PING_HOSTS", host, host})
+	}
+	for _, target := range c.HTTPHosts {
+		if u, err := url.Parse(target); err == nil && u.Hostname() != "" {
+			targets = append(targets, checkTarget{"HTTP_HOSTS", target, u.Hostname()})
+		}
+	}
+	return targets
+}
+
+// modemHost returns the modem's host without a port
+func (c *Config) modemHost() string {
+	if host, _, err := net.SplitHostPort(c.ModemHost); err == nil {
+		return host
+	}
+	return c.ModemHost
+}
+
+// localReason returns why ip does not stand for the internet, empty when
+// it does
+func (c *Config) localReason(ip net.IP) string {
+	switch modem := net.ParseIP(c.modemHost()); {
+	case modem != nil && modem.Equal(ip):
+		return "the modem"
+	case ip.IsLoopback():
+		return "a loopback address"
+	case ip.IsPrivate():
+		return "a private address"
+	case ip.IsLinkLocalUnicast():
+		return "a link-local address"
+	case ip.IsUnspecified():
+		return "an unspecified address"
+	}
+	return ""
+}
+
+// LocalTargets returns the ping and HTTP targets given as local addresses,
+// as localhost or as the modem's host name. Such targets answer while the
+// internet is down, so the outage would go unnoticed.
+func (c *Config) LocalTargets() []LocalTarget {
+	var local []LocalTarget
+	for _, target := range c.checkTargets() {
+		reason := ""
+		if ip := net.ParseIP(target.host); ip != nil {
+			reason = c.localReason(ip)
+		} else if strings.EqualFold(target.host, c.modemHost()) {
+			reason = "the modem"
+		} else if strings.EqualFold(strings.TrimSuffix(target.host, "."), "localhost") {
+			reason = "a loopback address"
+		}
+		if reason != "" {
+			local = append(local, LocalTarget{Setting: target.setting, Target: target.target, Reason: reason})
+		}
+	}
+	return local
+}
+
+// ResolveLocalTargets returns the local targets, including the host names
+// that resolve to a local address with lookup, such as
+// net.DefaultResolver.LookupIPAddr. Names that fail to resolve are left out.
+func (c *Config) ResolveLocalTargets(ctx context.Context, lookup func(ctx context.Context, host string) ([]net.IPAddr, error)) []LocalTarget {
+	local := c.LocalTargets()
+	found := make(map[string]bool, len(local))
+	for _, target := range local {
+		found[target.Setting+" "+target.Target] = true
+	}
+	for _, target := range c.checkTargets() {
+		if found[target.setting+" "+target.target] || net.ParseIP(target.host) != nil {
+			continue
+		}
+		addrs, err := lookup(ctx, target.host)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if reason := c.localReason(addr.IP); reason != "" {
+				local = append(local, LocalTarget{Setting: target.setting, Target: target.target, Address: addr.IP.String(), Reason: reason})
+				break
+			}
+		}
+	}
+	return local
+}
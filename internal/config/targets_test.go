@@ -0,0 +1,74 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLocalTargets(t *testing.T) {
+	t.Setenv("PING_HOSTS", "1.1.1.1,192.168.1.1")
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "PING_HOSTS target 192.168.1.1 is a private address") {
+		t.Errorf("Expected a validation error for a private ping host, got %v", err)
+	}
+
+	t.Setenv("ALLOW_LOCAL_TARGETS", "true")
+	if _, err := Load(); err != nil {
+		t.Errorf("Expected ALLOW_LOCAL_TARGETS to allow a private ping host, got %v", err)
+	}
+
+	cfg := &Config{
+		ModemHost: "203.0.113.7:8443",
+		PingHosts: []string{"8.8.8.8", "localhost", "203.0.113.7", "fe80::1"},
+		HTTPHosts: []string{"https://www.google.com", "http://127.0.0.1:8080/health"},
+	}
+	var got []string
+	for _, target := range cfg.LocalTargets() {
+		got = append(got, target.String())
+	}
+	want := []string{
+		"PING_HOSTS target localhost is a loopback address",
+		"PING_HOSTS target 203.0.113.7 is the modem",
+		"PING_HOSTS target fe80::1 is a link-local address",
+		"HTTP_HOSTS target http://127.0.0.1:8080/health is a loopback address",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LocalTargets() = %q, want %q", got, want)
+	}
+}
+
+func TestResolveLocalTargets(t *testing.T) {
+	cfg := &Config{
+		ModemHost: DefaultModemHost,
+		PingHosts: []string{"8.8.8.8", "nas.lan", "modem.lan", "unknown.example"},
+		HTTPHosts: []string{"https://www.google.com", "http://10.0.0.2/"},
+	}
+	addresses := map[string][]net.IPAddr{
+		"nas.lan":        {{IP: net.ParseIP("192.168.1.20")}},
+		"modem.lan":      {{IP: net.ParseIP(DefaultModemHost)}},
+		"www.google.com": {{IP: net.ParseIP("142.250.80.4")}, {IP: net.ParseIP("2607:f8b0:4006:80f::2004")}},
+	}
+	lookup := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if addrs, ok := addresses[host]; ok {
+			return addrs, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	var got []string
+	for _, target := range cfg.ResolveLocalTargets(context.Background(), lookup) {
+		got = append(got, target.String())
+	}
+	want := []string{
+		"HTTP_HOSTS target http://10.0.0.2/ is a private address",
+		"PING_HOSTS target nas.lan resolves to 192.168.1.20, which is a private address",
+		"PING_HOSTS target modem.lan resolves to 192.168.100.1, which is the modem",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveLocalTargets() = %q, want %q", got, want)
+	}
+}
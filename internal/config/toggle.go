@@ -0,0 +1,111 @@
+package config
+
+import "strconv"
+
+// Toggle is a boolean setting that is either unset or explicitly on or off.
+// Unlike a bool it tells an explicit off apart from a setting that was left
+// out, so an off from a source of higher precedence overrides an on from a
+// lower one.
+type Toggle int
+
+// Toggle states
+const (
+	ToggleUnset Toggle = iota
+	ToggleOff
+	ToggleOn
+)
+
+// ToggleOf returns the explicit toggle for on
+func ToggleOf(on bool) Toggle {
+	if on {
+		return ToggleOn
+	}
+	return ToggleOff
+}
+
+// IsSet reports whether the toggle was given explicitly
+func (t Toggle) IsSet() bool {
+	return t != ToggleUnset
+}
+
+// Apply sets target to the toggle, leaving it alone when unset
+func (t Toggle) Apply(target *bool) {
+	if t.IsSet() {
+		*target = t == ToggleOn
+	}
+}
+
+func (t Toggle) String() string {
+	switch t {
+	case ToggleOn:
+		return "true"
+	case ToggleOff:
+		return "false"
+	default:
+		return "unset"
+	}
+}
+
+// toggleSettings are the boolean settings, by environment variable, with
+// their Config field and config file value
+var toggleSettings = []struct {
+	env   string
+	field func(*Config) *bool
+	file  func(*ConfigJSON) *bool
+}{
+	{"MODEM_NOVERIFY", func(c *Config) *bool { return &c.ModemNoVerify }, func(j *ConfigJSON) *bool { return j.ModemNoVerify }},
+	{"ENABLE_DEBUG", func(c *Config) *bool { return &c.EnableDebug }, func(j *ConfigJSON) *bool { return j.EnableDebug }},
+	{"LOG_ROTATION", func(c *Config) *bool { return &c.LogRotation }, func(j *ConfigJSON) *bool { return j.LogRotation }},
+	{"ENABLE_DIAGNOSTICS", func(c *Config) *bool { return &c.EnableDiagnostics }, func(j *ConfigJSON) *bool { return j.EnableDiagnostics }},
+	{"ENABLE_REBOOT_MONITORING", func(c *Config) *bool { return &c.EnableRebootMonitoring }, func(j *ConfigJSON) *bool { return j.EnableRebootMonitoring }},
+	{"ENABLE_RESOURCE_LIMITS", func(c *Config) *bool { return &c.EnableResourceLimits }, func(j *ConfigJSON) *bool { return j.EnableResourceLimits }},
+	{"ENABLE_SYSTEMD", func(c *Config) *bool { return &c.EnableSystemd }, func(j *ConfigJSON) *bool { return j.EnableSystemd }},
+	{"SANDBOX", func(c *Config) *bool { return &c.Sandbox }, func(j *ConfigJSON) *bool { return j.Sandbox }},
+	{"STARTUP_CHECK", func(c *Config) *bool { return &c.StartupCheck }, func(j *ConfigJSON) *bool { return j.StartupCheck }},
+	{"ENABLE_FAULT_INJECTION", func(c *Config) *bool { return &c.EnableFaultInjection }, func(j *ConfigJSON) *bool { return j.EnableFaultInjection }},
+	{"PUBLIC_IP_CHECK", func(c *Config) *bool { return &c.PublicIPCheck }, func(j *ConfigJSON) *bool { return j.PublicIPCheck }},
+	{"ROUTING_PROBE", func(c *Config) *bool { return &c.RoutingProbe }, func(j *ConfigJSON) *bool { return j.RoutingProbe }},
+	{"ALLOW_LOCAL_TARGETS", func(c *Config) *bool { return &c.AllowLocalTargets }, func(j *ConfigJSON) *bool { return j.AllowLocalTargets }},
+	{"QUORUM_NOVERIFY", func(c *Config) *bool { return &c.QuorumNoVerify }, func(j *ConfigJSON) *bool { return j.QuorumNoVerify }},
+	{"HOST_HEALTH_CHECK", func(c *Config) *bool { return &c.HostHealthCheck }, func(j *ConfigJSON) *bool { return j.HostHealthCheck }},
+}
+
+// envToggles returns the boolean settings set in the environment; like
+// getEnvBool, a value that is not a boolean is ignored
+func envToggles() map[string]Toggle {
+	toggles := make(map[string]Toggle)
+	for _, setting := range toggleSettings {
+		if on, err := strconv.ParseBool(Getenv(setting.env)); err == nil {
+			toggles[setting.env] = ToggleOf(on)
+		}
+	}
+	return toggles
+}
+
+// fileToggles returns the boolean settings set in a config file
+func fileToggles(jsonCfg *ConfigJSON) map[string]Toggle {
+	toggles := make(map[string]Toggle)
+	for _, setting := range toggleSettings {
+		if value := setting.file(jsonCfg); value != nil {
+			toggles[setting.env] = ToggleOf(*value)
+		}
+	}
+	return toggles
+}
+
+// mergeToggles applies the boolean settings of fileConfig that the
+// environment left unset, so an explicit false in either wins over a default
+func mergeToggles(envConfig, fileConfig *Config) {
+	if envConfig.toggles == nil {
+		envConfig.toggles = make(map[string]Toggle)
+	}
+	for _, setting := range toggleSettings {
+		if envConfig.toggles[setting.env].IsSet() {
+			continue
+		}
+		if toggle := fileConfig.toggles[setting.env]; toggle.IsSet() {
+			toggle.Apply(setting.field(envConfig))
+			envConfig.toggles[setting.env] = toggle
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package connectivity
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TestTypeCustom is a custom check, such as one of a plugin
+const TestTypeCustom = "custom"
+
+// Check is a custom connectivity check the lightweight tests run
+type Check interface {
+	// Name names the check in its results, as their target
+	Name() string
+	// Run returns an error when the check fails
+	Run(ctx context.Context) error
+}
+
+// SetChecks makes the lightweight tests run checks alongside the TCP
+// handshakes; their success rate is the custom input of the health score
+func (t *Tester) SetChecks(checks []Check) {
+	t.checksMu.Lock()
+	defer t.checksMu.Unlock()
+	t.checks = append([]Check(nil), checks...)
+}
+
+// runCustomChecks runs the custom checks concurrently, returning no
+// results without any
+func (t *Tester) runCustomChecks(ctx context.Context) []TestResult {
+	t.checksMu.Lock()
+	checks := t.checks
+	t.checksMu.Unlock()
+
+	results := make([]TestResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(index int, check Check) {
+			defer wg.Done()
+			startTime := t.clock.Now()
+			err := check.Run(ctx)
+			results[index] = t.createTestResult(TestTypeCustom, check.Name(), startTime, err == nil, err, nil)
+
+			fields := logrus.Fields{
+				"check":       check.Name(),
+				"success":     err == nil,
+				"duration_ms": results[index].Duration.Milliseconds(),
+			}
+			if err != nil {
+				fields["error"] = err.Error()
+			}
+			t.logger.WithFields(fields).Debug("Custom check completed")
+		}(i, check)
+	}
+	wg.Wait()
+	return results
+}
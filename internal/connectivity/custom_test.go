@@ -0,0 +1,56 @@
+package connectivity
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+	"github.com/sirupsen/logrus"
+)
+
+// funcCheck is a custom check running a func
+type funcCheck struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+func (c funcCheck) Name() string                  { return c.name }
+func (c funcCheck) Run(ctx context.Context) error { return c.run(ctx) }
+
+func TestLightweightTestsRunCustomChecks(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	tester := NewTesterWithConfig(logger, time.Second, time.Second, []string{"192.0.2.1"}, nil)
+	tester.SetDialer(scriptedDialer{reachable: map[string]bool{"192.0.2.1:53": true}})
+	tester.retryConfig.MaxAttempts = 1
+	tester.SetHealthModel(health.NewModel(map[string]float64{health.InputCustom: 3}, 60, 50))
+	tester.SetChecks([]Check{
+		funcCheck{name: "nas", run: func(ctx context.Context) error { return nil }},
+		funcCheck{name: "camera", run: func(ctx context.Context) error { return errors.New("no answer") }},
+	})
+
+	result, err := tester.RunLightweightTests(context.Background())
+	if err != nil {
+		t.Fatalf("RunLightweightTests() failed: %v", err)
+	}
+	if len(result.CustomResults) != 2 || result.SuccessCount != 2 || result.FailureCount != 1 {
+		t.Fatalf("Expected the TCP test and one custom check to pass, got %+v", result)
+	}
+	failed := result.CustomResults[1]
+	if failed.TestType != TestTypeCustom || failed.Target != "camera" || failed.Success || failed.Error == nil {
+		t.Errorf("Expected the camera check to fail, got %+v", failed)
+	}
+	if !result.OverallSuccess || result.HealthScore != 62.5 {
+		t.Errorf("Expected the custom checks at half weight 3 to score 62.5, got %v (%v)", result.OverallSuccess, result.HealthScore)
+	}
+
+	tiered := &TieredTestResult{LightweightResult: result}
+	if rates := tiered.HealthInputs(); rates[health.InputCustom] != 0.5 {
+		t.Errorf("Expected the custom rate, got %v", rates)
+	}
+	if summary := tiered.GetTestSummary(); summary.Lightweight.CustomTests != 2 {
+		t.Errorf("Expected the summary to count the custom checks, got %+v", summary.Lightweight)
+	}
+}
@@ -0,0 +1,239 @@
+package connectivity
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+	"github.com/sirupsen/logrus"
+)
+
+// TestTypeGateway checks that the local gateway accepts a TCP connection
+const TestTypeGateway = "gateway"
+
+// CheckGateway names the gateway check in dependencies; the other checks
+// go by their health input names, such as health.InputDNS
+const CheckGateway = "gateway"
+
+// Checks are the check names dependencies may use
+var Checks = []string{CheckGateway, health.InputTCP, health.InputWireGuard, health.InputCustom, health.InputDNS, health.InputUDP, health.InputHTTP}
+
+// checkNames maps test types to the names of their checks
+var checkNames = map[string]string{
+	TestTypeGateway:          CheckGateway,
+	TestTypeTCPHandshake:     health.InputTCP,
+	TestTypeWireGuard:        health.InputWireGuard,
+	TestTypeCustom:           health.InputCustom,
+	TestTypeDNSResolution:    health.InputDNS,
+	TestTypeUDPProbe:         health.InputUDP,
+	TestTypeHTTPConnectivity: health.InputHTTP,
+}
+
+// Dependencies maps a check to the checks it depends on. When all tests of
+// a dependency fail, the failed tests of the checks depending on it are
+// suppressed: reported as caused by it rather than as failures of their own.
+type Dependencies map[string][]string
+
+// ParseDependencies parses check:dependency entries, such as http:dns or
+// dns:gateway, rejecting unknown checks and cycles
+func ParseDependencies(entries []string) (Dependencies, error) {
+	deps := make(Dependencies)
+	for _, entry := range entries {
+		check, dependency, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		check, dependency = strings.TrimSpace(check), strings.TrimSpace(dependency)
+		if !ok || check == "" || dependency == "" {
+			return nil, fmt.Errorf("invalid dependency %q, expected check:dependency", entry)
+		}
+		for _, name := range []string{check, dependency} {
+			if !isCheck(name) {
+				return nil, fmt.Errorf("unknown check %q in dependency %q, must be one of: %s", name, entry, strings.Join(Checks, ", "))
+			}
+		}
+		if check == dependency {
+			return nil, fmt.Errorf("check %s cannot depend on itself", check)
+		}
+		deps[check] = append(deps[check], dependency)
+	}
+	for check := range deps {
+		if deps.dependsOn(check, check, map[string]bool{}) {
+			return nil, fmt.Errorf("dependency cycle through %s", check)
+		}
+	}
+	return deps, nil
+}
+
+// Uses reports whether a check depends on check
+func (d Dependencies) Uses(check string) bool {
+	for _, dependencies := range d {
+		for _, dependency := range dependencies {
+			if dependency == check {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isCheck reports whether name is one of Checks
+func isCheck(name string) bool {
+	for _, check := range Checks {
+		if name == check {
+			return true
+		}
+	}
+	return false
+}
+
+// dependsOn reports whether check depends on target, directly or not
+func (d Dependencies) dependsOn(check, target string, seen map[string]bool) bool {
+	for _, dependency := range d[check] {
+		if dependency == target {
+			return true
+		}
+		if !seen[dependency] {
+			seen[dependency] = true
+			if d.dependsOn(dependency, target, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rootCause returns the failed dependency of check closest to the root of
+// the graph, empty when none of its dependencies failed
+func (d Dependencies) rootCause(check string, failed map[string]bool) string {
+	for _, dependency := range d[check] {
+		if root := d.rootCause(dependency, failed); root != "" {
+			return root
+		}
+		if failed[dependency] {
+			return dependency
+		}
+	}
+	return ""
+}
+
+// failedChecks adds to failed the checks all of whose results failed
+func failedChecks(failed map[string]bool, results ...[]TestResult) {
+	passed := make(map[string]bool)
+	for _, group := range results {
+		for _, result := range group {
+			check := checkNames[result.TestType]
+			if result.Success {
+				passed[check] = true
+			} else {
+				failed[check] = true
+			}
+		}
+	}
+	for check := range passed {
+		delete(failed, check)
+	}
+}
+
+// suppress marks the failed results whose check depends on a failed check
+func (d Dependencies) suppress(results []TestResult, failed map[string]bool) {
+	for i := range results {
+		if results[i].Success || results[i].Suppressed {
+			continue
+		}
+		if root := d.rootCause(checkNames[results[i].TestType], failed); root != "" {
+			results[i].Suppressed = true
+			results[i].SuppressedBy = root
+		}
+	}
+}
+
+// skipped returns the results of a check that was not run because root
+// failed, one for each of its targets
+func (t *Tester) skipped(testType string, targets []string, root string) []TestResult {
+	results := make([]TestResult, len(targets))
+	for i, target := range targets {
+		results[i] = TestResult{
+			TestType:     testType,
+			Target:       target,
+			Timestamp:    t.clock.Now(),
+			Error:        fmt.Errorf("skipped: depends on %s, which failed", root),
+			Details:      map[string]interface{}{"suppressed_by": root},
+			Suppressed:   true,
+			SuppressedBy: root,
+		}
+	}
+	return results
+}
+
+// logSuppressed logs the checks whose failures were put down to a failed
+// dependency, once per check rather than once per test
+func (t *Tester) logSuppressed(tier string, results ...[]TestResult) {
+	causes := make(map[string]string)
+	for _, group := range results {
+		for _, result := range group {
+			if result.Suppressed {
+				causes[checkNames[result.TestType]] = result.SuppressedBy
+			}
+		}
+	}
+	if len(causes) == 0 {
+		return
+	}
+	checks := make([]string, 0, len(causes))
+	for check := range causes {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+	for _, check := range checks {
+		t.logger.WithFields(logrus.Fields{
+			"check":     check,
+			"caused_by": causes[check],
+			"test_type": tier,
+		}).Debug("Suppressed checks whose dependency failed")
+	}
+}
+
+// SetDependencies sets the dependencies between checks; nil reports every
+// failure on its own
+func (t *Tester) SetDependencies(deps Dependencies) {
+	t.dependencies = deps
+}
+
+// SetGateway makes the lightweight tests open a TCP connection to the local
+// gateway at address, host or host:port with port 80 by default, before any
+// other test. An empty address disables the check.
+func (t *Tester) SetGateway(address string) {
+	if address != "" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			address = net.JoinHostPort(address, "80")
+		}
+	}
+	t.gateway = address
+}
+
+// runGatewayTests checks the gateway, returning no results when no gateway
+// is configured
+func (t *Tester) runGatewayTests(ctx context.Context) []TestResult {
+	if t.gateway == "" {
+		return nil
+	}
+	startTime := t.clock.Now()
+	connCtx, cancel := context.WithTimeout(ctx, t.connectionTimeout)
+	defer cancel()
+
+	conn, err := t.dialer.DialContext(connCtx, "tcp", t.gateway)
+	if err == nil {
+		conn.Close()
+	} else {
+		err = fmt.Errorf("gateway %s is unreachable: %w", t.gateway, err)
+	}
+	result := t.createTestResult(TestTypeGateway, t.gateway, startTime, err == nil, err, map[string]interface{}{"gateway": t.gateway})
+
+	t.logger.WithFields(logrus.Fields{
+		"gateway":     t.gateway,
+		"success":     result.Success,
+		"duration_ms": result.Duration.Milliseconds(),
+	}).Debug("Gateway test completed")
+	return []TestResult{result}
+}
@@ -0,0 +1,124 @@
+package connectivity
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseDependencies(t *testing.T) {
+	deps, err := ParseDependencies([]string{"http:dns", " dns : gateway", "tcp:gateway", "http:tcp"})
+	if err != nil {
+		t.Fatalf("ParseDependencies() failed: %v", err)
+	}
+	want := Dependencies{"http": {"dns", "tcp"}, "dns": {"gateway"}, "tcp": {"gateway"}}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("ParseDependencies() = %v, want %v", deps, want)
+	}
+	if root := deps.rootCause("http", map[string]bool{"dns": true, "gateway": true}); root != "gateway" {
+		t.Errorf("Expected the failure closest to the root, got %q", root)
+	}
+	if root := deps.rootCause("http", map[string]bool{"tcp": true}); root != "tcp" {
+		t.Errorf("Expected tcp, got %q", root)
+	}
+	if root := deps.rootCause("gateway", map[string]bool{"dns": true}); root != "" {
+		t.Errorf("Expected no root cause for a check without dependencies, got %q", root)
+	}
+
+	for _, entries := range [][]string{
+		{"http"},
+		{"http:"},
+		{"http:ping"},
+		{"dns:dns"},
+		{"http:dns", "dns:tcp", "tcp:http"},
+	} {
+		if _, err := ParseDependencies(entries); err == nil {
+			t.Errorf("ParseDependencies(%q) should fail", entries)
+		}
+	}
+}
+
+// newDependencyTester returns a tester reaching only the listed addresses
+func newDependencyTester(deps []string, reachable ...string) *Tester {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	tester := NewTesterWithConfig(logger, time.Second, time.Second, []string{"192.0.2.1", "192.0.2.2"}, []string{"http://example.com"})
+	addresses := make(map[string]bool)
+	for _, address := range reachable {
+		addresses[address] = true
+	}
+	tester.SetDialer(scriptedDialer{reachable: addresses})
+	tester.retryConfig.MaxAttempts = 1
+	tester.SetGateway("192.168.0.1")
+	parsed, err := ParseDependencies(deps)
+	if err != nil {
+		panic(err)
+	}
+	tester.SetDependencies(parsed)
+	return tester
+}
+
+func TestGatewayFailureSuppressesDependentChecks(t *testing.T) {
+	tester := newDependencyTester([]string{"tcp:gateway", "dns:gateway", "http:dns"})
+
+	result, err := tester.RunTieredTests(context.Background())
+	if err != nil {
+		t.Fatalf("RunTieredTests() failed: %v", err)
+	}
+	if result.OverallSuccess {
+		t.Fatal("Expected the check to fail with the gateway down")
+	}
+
+	// Only the gateway counts as a failure; the tests depending on it are
+	// skipped
+	lightweight := result.LightweightResult
+	if lightweight.FailureCount != 1 || lightweight.SuppressedCount != 2 {
+		t.Errorf("Expected 1 failure and 2 suppressed, got %d and %d", lightweight.FailureCount, lightweight.SuppressedCount)
+	}
+	comprehensive := result.ComprehensiveResult
+	if comprehensive == nil || comprehensive.FailureCount != 0 || comprehensive.SuppressedCount != 3 {
+		t.Fatalf("Expected the comprehensive tests to be suppressed, got %+v", comprehensive)
+	}
+	for _, test := range result.Results() {
+		if test.TestType == TestTypeGateway {
+			continue
+		}
+		if !test.Suppressed || test.SuppressedBy != CheckGateway {
+			t.Errorf("Expected %s %s to be suppressed by the gateway, got %+v", test.TestType, test.Target, test)
+		}
+	}
+	if summary := result.GetTestSummary(); summary.Lightweight.GatewayTests != 1 || summary.Comprehensive.SuppressedCount != 3 {
+		t.Errorf("Expected the gateway test and suppressed counts in the summary, got %+v", summary)
+	}
+}
+
+func TestDependencyFailureInSameTier(t *testing.T) {
+	// The gateway is up, DNS servers refuse resolution, so the HTTP check
+	// run alongside them is put down to DNS
+	tester := newDependencyTester([]string{"http:dns", "dns:gateway"}, "192.168.0.1:80")
+
+	result, err := tester.RunComprehensiveTests(context.Background())
+	if err != nil {
+		t.Fatalf("RunComprehensiveTests() failed: %v", err)
+	}
+	if result.FailureCount != 2 || result.SuppressedCount != 1 {
+		t.Errorf("Expected 2 DNS failures and the HTTP check suppressed, got %d and %d", result.FailureCount, result.SuppressedCount)
+	}
+	if len(result.HTTPResults) != 1 || result.HTTPResults[0].SuppressedBy != "dns" {
+		t.Errorf("Expected HTTP suppressed by dns, got %+v", result.HTTPResults)
+	}
+
+	// Without dependencies every failure counts
+	tester.SetDependencies(nil)
+	result, err = tester.RunComprehensiveTests(context.Background())
+	if err != nil {
+		t.Fatalf("RunComprehensiveTests() failed: %v", err)
+	}
+	if result.FailureCount != 3 || result.SuppressedCount != 0 {
+		t.Errorf("Expected 3 failures without dependencies, got %d and %d", result.FailureCount, result.SuppressedCount)
+	}
+}
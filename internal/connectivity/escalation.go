@@ -0,0 +1,134 @@
+package connectivity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Escalation policy defaults
+const (
+	DefaultEscalationFailures           = 3
+	DefaultEscalationValidationInterval = 5 * time.Minute
+)
+
+// Escalation reasons
+const (
+	EscalationConsecutiveFailures = "consecutive_failures"
+	EscalationPreviousEscalation  = "previous_escalation_failed"
+	EscalationValidation          = "periodic_validation"
+	EscalationWindow              = "time_window"
+)
+
+// EscalationPolicy decides when a check runs the comprehensive tests right
+// away instead of starting with the lightweight ones. A check that follows
+// an escalated check that failed is always comprehensive.
+type EscalationPolicy struct {
+	// ConsecutiveFailures escalates once this many checks in a row failed;
+	// 0 never escalates on failures
+	ConsecutiveFailures int
+	// ValidationInterval escalates when the comprehensive tests last ran
+	// this long ago, so they are validated while the lightweight ones pass;
+	// 0 disables the periodic validation
+	ValidationInterval time.Duration
+	// Windows are times of day during which every check is comprehensive
+	Windows []TimeWindow
+}
+
+// DefaultEscalationPolicy returns the escalation policy used unless one is
+// configured
+func DefaultEscalationPolicy() EscalationPolicy {
+	return EscalationPolicy{
+		ConsecutiveFailures: DefaultEscalationFailures,
+		ValidationInterval:  DefaultEscalationValidationInterval,
+	}
+}
+
+// Escalate reports whether the check at now runs the comprehensive tests
+// right away, and why. lastResult is the result of the previous check, nil
+// for the first one, and lastComprehensive is when the comprehensive tests
+// last ran, zero if they never did.
+func (p EscalationPolicy) Escalate(now time.Time, lastResult *TieredTestResult, consecutiveFailures int, lastComprehensive time.Time) (bool, string) {
+	if p.ConsecutiveFailures > 0 && consecutiveFailures >= p.ConsecutiveFailures {
+		return true, EscalationConsecutiveFailures
+	}
+	if lastResult != nil && lastResult.Strategy == "escalated_to_comprehensive" && !lastResult.OverallSuccess {
+		return true, EscalationPreviousEscalation
+	}
+	for _, window := range p.Windows {
+		if window.Contains(now) {
+			return true, EscalationWindow
+		}
+	}
+	if p.ValidationInterval > 0 && lastResult != nil && now.Sub(lastComprehensive) >= p.ValidationInterval {
+		return true, EscalationValidation
+	}
+	return false, ""
+}
+
+// TimeWindow is a daily time span, such as 01:00-05:00. A window whose end
+// is before its start spans midnight.
+type TimeWindow struct {
+	// Start and End are offsets from midnight
+	Start, End time.Duration
+}
+
+// ParseTimeWindow parses a window written as HH:MM-HH:MM
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	startText, endText, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q, expected HH:MM-HH:MM", s)
+	}
+	start, err := parseTimeOfDay(startText)
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	end, err := parseTimeOfDay(endText)
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	if start == end {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: start and end are the same", s)
+	}
+	return TimeWindow{Start: start, End: end}, nil
+}
+
+// ParseTimeWindows parses a list of HH:MM-HH:MM windows
+func ParseTimeWindows(values []string) ([]TimeWindow, error) {
+	windows := make([]TimeWindow, 0, len(values))
+	for _, value := range values {
+		window, err := ParseTimeWindow(value)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// parseTimeOfDay parses HH:MM into an offset from midnight
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether t, in its own location, falls in the window
+func (w TimeWindow) Contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// String returns the window as HH:MM-HH:MM
+func (w TimeWindow) String() string {
+	format := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return format(w.Start) + "-" + format(w.End)
+}
@@ -0,0 +1,78 @@
+package connectivity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEscalationPolicy(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	passed := &TieredTestResult{Strategy: "lightweight_only", OverallSuccess: true}
+	escalatedFailure := &TieredTestResult{Strategy: "escalated_to_comprehensive"}
+	night, _ := ParseTimeWindow("23:00-05:00")
+	noon, _ := ParseTimeWindow("11:30-12:30")
+
+	tests := []struct {
+		name              string
+		policy            EscalationPolicy
+		lastResult        *TieredTestResult
+		failures          int
+		lastComprehensive time.Time
+		want              string
+	}{
+		{"first check", DefaultEscalationPolicy(), nil, 0, time.Time{}, ""},
+		{"below failure trigger", DefaultEscalationPolicy(), passed, 2, now, ""},
+		{"failure trigger", DefaultEscalationPolicy(), passed, 3, now, EscalationConsecutiveFailures},
+		{"failure trigger disabled", EscalationPolicy{}, passed, 30, now, ""},
+		{"custom failure trigger", EscalationPolicy{ConsecutiveFailures: 1}, passed, 1, now, EscalationConsecutiveFailures},
+		{"failed escalation", EscalationPolicy{}, escalatedFailure, 1, now, EscalationPreviousEscalation},
+		{"validation due", DefaultEscalationPolicy(), passed, 0, now.Add(-5 * time.Minute), EscalationValidation},
+		{"validation not due", DefaultEscalationPolicy(), passed, 0, now.Add(-4 * time.Minute), ""},
+		{"validation never ran", DefaultEscalationPolicy(), passed, 0, time.Time{}, EscalationValidation},
+		{"validation disabled", EscalationPolicy{ConsecutiveFailures: 3}, passed, 0, time.Time{}, ""},
+		{"inside window", EscalationPolicy{Windows: []TimeWindow{night, noon}}, passed, 0, now, EscalationWindow},
+		{"outside window", EscalationPolicy{Windows: []TimeWindow{night}}, passed, 0, now, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			escalate, reason := tt.policy.Escalate(now, tt.lastResult, tt.failures, tt.lastComprehensive)
+			if escalate != (tt.want != "") || reason != tt.want {
+				t.Errorf("Escalate() = %v, %q; want %q", escalate, reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		window string
+		at     time.Duration
+		want   bool
+	}{
+		{"01:00-05:00", 3 * time.Hour, true},
+		{"01:00-05:00", 5 * time.Hour, false},
+		{"01:00-05:00", time.Hour, true},
+		{"23:00-02:00", 23*time.Hour + 30*time.Minute, true},
+		{"23:00-02:00", time.Hour, true},
+		{"23:00-02:00", 12 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		window, err := ParseTimeWindow(tt.window)
+		if err != nil {
+			t.Fatalf("ParseTimeWindow(%q) failed: %v", tt.window, err)
+		}
+		if window.String() != tt.window {
+			t.Errorf("String() = %q, want %q", window.String(), tt.window)
+		}
+		if got := window.Contains(day.Add(tt.at)); got != tt.want {
+			t.Errorf("%s.Contains(%s) = %v, want %v", tt.window, tt.at, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"", "01:00", "25:00-02:00", "01:00-01:00", "1am-2am"} {
+		if _, err := ParseTimeWindow(invalid); err == nil {
+			t.Errorf("ParseTimeWindow(%q) should fail", invalid)
+		}
+	}
+}
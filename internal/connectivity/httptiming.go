@@ -0,0 +1,111 @@
+package connectivity
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Phases of an HTTP check, in the order they happen
+const (
+	PhaseDNS     = "dns"
+	PhaseConnect = "connect"
+	PhaseTLS     = "tls"
+	// PhaseTTFB runs from writing the request to the first response byte
+	PhaseTTFB = "ttfb"
+)
+
+// HTTPPhases lists the phases of an HTTP check in the order they happen
+var HTTPPhases = []string{PhaseDNS, PhaseConnect, PhaseTLS, PhaseTTFB}
+
+// phaseTimer times the phases of one HTTP request through httptrace. A
+// phase that did not happen, such as DNS for an IP address, TLS over plain
+// HTTP or the connect of a reused connection, is left out.
+type phaseTimer struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	starts map[string]time.Time
+	phases map[string]time.Duration
+}
+
+func newPhaseTimer(now func() time.Time) *phaseTimer {
+	return &phaseTimer{
+		now:    now,
+		starts: make(map[string]time.Time, len(HTTPPhases)),
+		phases: make(map[string]time.Duration, len(HTTPPhases)),
+	}
+}
+
+// withTrace returns ctx with a client trace that feeds the timer
+func (p *phaseTimer) withTrace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { p.start(PhaseDNS) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { p.end(PhaseDNS) },
+		ConnectStart: func(string, string) { p.start(PhaseConnect) },
+		ConnectDone: func(_, _ string, err error) {
+			// A failed dial of one address is not the connect that served
+			// the request
+			if err == nil {
+				p.end(PhaseConnect)
+			}
+		},
+		TLSHandshakeStart:    func() { p.start(PhaseTLS) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { p.end(PhaseTLS) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { p.start(PhaseTTFB) },
+		GotFirstResponseByte: func() { p.end(PhaseTTFB) },
+	})
+}
+
+// start marks the start of phase. Dialing several addresses of a host
+// starts the connect phase more than once; the first start counts.
+func (p *phaseTimer) start(phase string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.starts[phase]; !ok {
+		p.starts[phase] = p.now()
+	}
+}
+
+// end marks the end of phase; the first end after a start counts
+func (p *phaseTimer) end(phase string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	started, ok := p.starts[phase]
+	if _, done := p.phases[phase]; !ok || done {
+		return
+	}
+	p.phases[phase] = p.now().Sub(started)
+}
+
+// result returns the duration of every phase that completed
+func (p *phaseTimer) result() map[string]time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	phases := make(map[string]time.Duration, len(p.phases))
+	for phase, d := range p.phases {
+		phases[phase] = d
+	}
+	return phases
+}
+
+// addPhaseDetails records phases in details as <phase>_ms, along with the
+// slowest phase, which tells a slow resolver from a slow or saturated link
+func addPhaseDetails(details map[string]interface{}, phases map[string]time.Duration) {
+	slowest := ""
+	for _, phase := range HTTPPhases {
+		d, ok := phases[phase]
+		if !ok {
+			continue
+		}
+		details[phase+"_ms"] = float64(d) / float64(time.Millisecond)
+		if slowest == "" || d > phases[slowest] {
+			slowest = phase
+		}
+	}
+	if slowest != "" {
+		details["slowest_phase"] = slowest
+	}
+}
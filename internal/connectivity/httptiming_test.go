@@ -0,0 +1,90 @@
+package connectivity
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestHTTPCheckPhases(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name string
+		tls  bool
+		want []string
+	}{
+		{"http", false, []string{PhaseDNS, PhaseConnect, PhaseTTFB}},
+		{"https", true, []string{PhaseDNS, PhaseConnect, PhaseTLS, PhaseTTFB}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(handler)
+			if tt.tls {
+				server.StartTLS()
+			} else {
+				server.Start()
+			}
+			defer server.Close()
+
+			tester := NewTester(logger)
+			tester.SetHTTPClient(&http.Client{
+				Timeout:   5 * time.Second,
+				Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+			})
+			// A host name rather than the address, so the check resolves it
+			target := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+			result := tester.testHTTPConnectivity(context.Background(), target)
+			if !result.Success {
+				t.Fatalf("Expected the check to succeed, got %v", result.Error)
+			}
+			if len(result.Phases) != len(tt.want) {
+				t.Errorf("Expected phases %v, got %v", tt.want, result.Phases)
+			}
+			for _, phase := range tt.want {
+				if _, ok := result.Phases[phase]; !ok {
+					t.Errorf("Expected the %s phase, got %v", phase, result.Phases)
+				}
+				if _, ok := result.Details[phase+"_ms"].(float64); !ok {
+					t.Errorf("Expected %s_ms in the details, got %v", phase, result.Details)
+				}
+			}
+			if _, ok := result.Details["slowest_phase"].(string); !ok {
+				t.Errorf("Expected the slowest phase in the details, got %v", result.Details)
+			}
+		})
+	}
+}
+
+func TestAddPhaseDetails(t *testing.T) {
+	details := map[string]interface{}{}
+	addPhaseDetails(details, map[string]time.Duration{
+		PhaseDNS:     900 * time.Millisecond,
+		PhaseConnect: 20 * time.Millisecond,
+		PhaseTTFB:    1500 * time.Microsecond,
+	})
+	if details["dns_ms"] != float64(900) || details["ttfb_ms"] != 1.5 {
+		t.Errorf("Expected the phases in milliseconds, got %v", details)
+	}
+	if _, ok := details["tls_ms"]; ok {
+		t.Errorf("Expected no TLS phase, got %v", details)
+	}
+	if details["slowest_phase"] != PhaseDNS {
+		t.Errorf("Expected DNS as the slowest phase, got %v", details["slowest_phase"])
+	}
+
+	empty := map[string]interface{}{}
+	addPhaseDetails(empty, nil)
+	if len(empty) != 0 {
+		t.Errorf("Expected no details without phases, got %v", empty)
+	}
+}
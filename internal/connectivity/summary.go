@@ -0,0 +1,115 @@
+package connectivity
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TestSummaryVersion is the version of the TestSummary JSON layout. It only
+// changes when a key is renamed or removed, or its meaning changes; new keys
+// are added without a new version.
+const TestSummaryVersion = 1
+
+// TestSummary is the outcome of a tiered connectivity check in a stable
+// JSON layout, for logging and for integrations such as the API
+type TestSummary struct {
+	Version         int       `json:"version"`
+	Strategy        string    `json:"strategy"`
+	OverallSuccess  bool      `json:"overall_success"`
+	ShortCircuited  bool      `json:"short_circuited"`
+	TotalDurationMS int64     `json:"total_duration_ms"`
+	Timestamp       time.Time `json:"timestamp"`
+	// CycleID is the monitoring cycle that ran the check, if any
+	CycleID string `json:"cycle_id,omitempty"`
+	// Lightweight and Comprehensive are the tiers that ran
+	Lightweight   *TierSummary `json:"lightweight,omitempty"`
+	Comprehensive *TierSummary `json:"comprehensive,omitempty"`
+}
+
+// TierSummary is the outcome of one tier of a connectivity check. The
+// WireGuard, gateway and custom test counts are only set for the lightweight
+// tier, the other test counts and EscalatedFrom only for the comprehensive
+// tier.
+// SuppressedCount counts failures put down to a failed dependency.
+type TierSummary struct {
+	Success         bool    `json:"success"`
+	HealthScore     float64 `json:"health_score"`
+	SuccessCount    int     `json:"success_count"`
+	FailureCount    int     `json:"failure_count"`
+	UnverifiedCount int     `json:"unverified_count"`
+	SuppressedCount int     `json:"suppressed_count,omitempty"`
+	DurationMS      int64   `json:"duration_ms"`
+	DNSTests        int     `json:"dns_tests,omitempty"`
+	HTTPTests       int     `json:"http_tests,omitempty"`
+	UDPTests        int     `json:"udp_tests,omitempty"`
+	WireGuardTests  int     `json:"wireguard_tests,omitempty"`
+	GatewayTests    int     `json:"gateway_tests,omitempty"`
+	CustomTests     int     `json:"custom_tests,omitempty"`
+	EscalatedFrom   string  `json:"escalated_from,omitempty"`
+}
+
+// GetTestSummary returns a summary of test results for logging and monitoring
+func (t *TieredTestResult) GetTestSummary() TestSummary {
+	summary := TestSummary{
+		Version:         TestSummaryVersion,
+		Strategy:        t.Strategy,
+		OverallSuccess:  t.OverallSuccess,
+		ShortCircuited:  t.ShortCircuited,
+		TotalDurationMS: t.TotalDuration.Milliseconds(),
+		Timestamp:       t.Timestamp,
+		CycleID:         t.CycleID,
+	}
+
+	if t.LightweightResult != nil {
+		summary.Lightweight = &TierSummary{
+			Success:         t.LightweightResult.OverallSuccess,
+			HealthScore:     t.LightweightResult.HealthScore,
+			SuccessCount:    t.LightweightResult.SuccessCount,
+			FailureCount:    t.LightweightResult.FailureCount,
+			UnverifiedCount: t.LightweightResult.UnverifiedCount,
+			SuppressedCount: t.LightweightResult.SuppressedCount,
+			DurationMS:      t.LightweightResult.Duration.Milliseconds(),
+			WireGuardTests:  len(t.LightweightResult.WireGuardResults),
+			GatewayTests:    len(t.LightweightResult.GatewayResults),
+			CustomTests:     len(t.LightweightResult.CustomResults),
+		}
+	}
+
+	if t.ComprehensiveResult != nil {
+		summary.Comprehensive = &TierSummary{
+			Success:         t.ComprehensiveResult.OverallSuccess,
+			HealthScore:     t.ComprehensiveResult.HealthScore,
+			SuccessCount:    t.ComprehensiveResult.SuccessCount,
+			FailureCount:    t.ComprehensiveResult.FailureCount,
+			UnverifiedCount: t.ComprehensiveResult.UnverifiedCount,
+			SuppressedCount: t.ComprehensiveResult.SuppressedCount,
+			DurationMS:      t.ComprehensiveResult.Duration.Milliseconds(),
+			DNSTests:        len(t.ComprehensiveResult.DNSResults),
+			HTTPTests:       len(t.ComprehensiveResult.HTTPResults),
+			UDPTests:        len(t.ComprehensiveResult.UDPResults),
+			EscalatedFrom:   t.ComprehensiveResult.EscalatedFrom,
+		}
+	}
+
+	return summary
+}
+
+// LogFields returns the summary as log fields, with the tiers that ran
+// under their JSON keys
+func (s TestSummary) LogFields() logrus.Fields {
+	fields := logrus.Fields{
+		"strategy":          s.Strategy,
+		"overall_success":   s.OverallSuccess,
+		"short_circuited":   s.ShortCircuited,
+		"total_duration_ms": s.TotalDurationMS,
+		"timestamp":         s.Timestamp,
+	}
+	if s.Lightweight != nil {
+		fields["lightweight"] = *s.Lightweight
+	}
+	if s.Comprehensive != nil {
+		fields["comprehensive"] = *s.Comprehensive
+	}
+	return fields
+}
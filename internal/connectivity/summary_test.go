@@ -0,0 +1,64 @@
+package connectivity
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// summaryJSON returns the summary of result as the JSON object integrations see
+func summaryJSON(result *TieredTestResult) map[string]interface{} {
+	data, err := json.Marshal(result.GetTestSummary())
+	if err != nil {
+		return nil
+	}
+	var summary map[string]interface{}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil
+	}
+	return summary
+}
+
+func TestTestSummaryJSONLayout(t *testing.T) {
+	result := &TieredTestResult{
+		Strategy:       "escalated_to_comprehensive",
+		OverallSuccess: false,
+		TotalDuration:  1500 * time.Millisecond,
+		Timestamp:      time.Date(2024, 1, 1, 3, 12, 0, 0, time.UTC),
+		CycleID:        "3f9c2a7b1e4d6c80",
+		LightweightResult: &LightweightTestResult{
+			HealthScore:  0,
+			FailureCount: 3,
+			Duration:     500 * time.Millisecond,
+		},
+		ComprehensiveResult: &ComprehensiveTestResult{
+			HealthScore:   40,
+			SuccessCount:  2,
+			FailureCount:  3,
+			DNSResults:    make([]TestResult, 2),
+			HTTPResults:   make([]TestResult, 3),
+			Duration:      time.Second,
+			EscalatedFrom: "lightweight",
+		},
+	}
+
+	data, err := json.Marshal(result.GetTestSummary())
+	if err != nil {
+		t.Fatalf("Failed to encode summary: %v", err)
+	}
+	// Integrations depend on this layout; changing a key needs a new
+	// TestSummaryVersion
+	want := `{"version":1,"strategy":"escalated_to_comprehensive","overall_success":false,"short_circuited":false,` +
+		`"total_duration_ms":1500,"timestamp":"2024-01-01T03:12:00Z","cycle_id":"3f9c2a7b1e4d6c80",` +
+		`"lightweight":{"success":false,"health_score":0,"success_count":0,"failure_count":3,"unverified_count":0,"duration_ms":500},` +
+		`"comprehensive":{"success":false,"health_score":40,"success_count":2,"failure_count":3,"unverified_count":0,"duration_ms":1000,` +
+		`"dns_tests":2,"http_tests":3,"escalated_from":"lightweight"}}`
+	if string(data) != want {
+		t.Errorf("Unexpected summary JSON\n got: %s\nwant: %s", data, want)
+	}
+
+	fields := result.GetTestSummary().LogFields()
+	if fields["strategy"] != result.Strategy || fields["comprehensive"] == nil {
+		t.Errorf("Expected the summary in the log fields, got %v", fields)
+	}
+}
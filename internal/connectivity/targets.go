@@ -0,0 +1,250 @@
+package connectivity
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Defaults of the target quarantine
+const (
+	DefaultQuarantineFailures = 5
+	DefaultQuarantineDuration = time.Hour
+)
+
+// targetWindow is the number of recent checks of a target its reliability
+// is judged by
+const targetWindow = 20
+
+// TargetRotation decides which targets each check uses. The zero value
+// checks every target every time and never quarantines one.
+type TargetRotation struct {
+	// PerCheck is the number of targets of each kind a check uses, taking
+	// turns through the pool; 0 uses all of them
+	PerCheck int
+	// QuarantineFailures is the number of lone failures within a target's
+	// last 20 checks that quarantine it: failures while another target of
+	// its kind passed, which point at the target rather than the line. 0
+	// never quarantines.
+	QuarantineFailures int
+	// QuarantineDuration is how long a quarantined target sits out, 0 for
+	// DefaultQuarantineDuration
+	QuarantineDuration time.Duration
+}
+
+// TargetHealth is the reliability of a check target over its recent checks
+type TargetHealth struct {
+	TestType string `json:"test_type"`
+	Target   string `json:"target"`
+	Checks   int    `json:"checks"`
+	Failures int    `json:"failures"`
+	// LoneFailures are failures while another target of its kind passed
+	LoneFailures     int        `json:"lone_failures"`
+	QuarantinedUntil *time.Time `json:"quarantined_until,omitempty"`
+}
+
+// outcome is the result of one check of a target
+type outcome uint8
+
+const (
+	outcomePassed outcome = iota
+	outcomeFailed
+	outcomeFailedAlone
+)
+
+// targetStats are the recent outcomes of a target
+type targetStats struct {
+	outcomes         []outcome
+	quarantinedUntil time.Time
+}
+
+// targetPool rotates through the targets of one test type and tracks how
+// reliable each is
+type targetPool struct {
+	mu       sync.Mutex
+	testType string
+	targets  []string
+	// next is the index in targets the next rotation starts at
+	next  int
+	stats map[string]*targetStats
+}
+
+// newTargetPool creates the pool of targets of testType
+func newTargetPool(testType string, targets []string) *targetPool {
+	stats := make(map[string]*targetStats, len(targets))
+	for _, target := range targets {
+		stats[target] = &targetStats{}
+	}
+	return &targetPool{testType: testType, targets: targets, stats: stats}
+}
+
+// choose returns the targets of the next check: PerCheck of them in turn,
+// leaving out quarantined targets unless too few others are left
+func (p *targetPool) choose(now time.Time, rotation TargetRotation, logger *logrus.Logger) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var available, quarantined []string
+	for _, target := range p.targets {
+		stats := p.stats[target]
+		if stats.quarantinedUntil.IsZero() {
+			available = append(available, target)
+			continue
+		}
+		if !now.Before(stats.quarantinedUntil) {
+			// Back on probation with a clean record
+			stats.quarantinedUntil = time.Time{}
+			stats.outcomes = nil
+			logger.WithFields(logrus.Fields{
+				"test_type": p.testType,
+				"target":    target,
+			}).Info("Check target released from quarantine")
+			available = append(available, target)
+			continue
+		}
+		quarantined = append(quarantined, target)
+	}
+
+	want := rotation.PerCheck
+	if want <= 0 || want > len(p.targets) {
+		want = len(p.targets)
+	}
+	if rotation.PerCheck <= 0 && len(available) > 0 {
+		return available
+	}
+
+	chosen := make([]string, 0, want)
+	if len(available) > 0 {
+		start := p.next % len(available)
+		for i := 0; i < len(available) && len(chosen) < want; i++ {
+			chosen = append(chosen, available[(start+i)%len(available)])
+		}
+		p.next = start + len(chosen)
+	}
+
+	// Too few targets left: the ones whose quarantine ends first fill in
+	sort.Slice(quarantined, func(i, j int) bool {
+		return p.stats[quarantined[i]].quarantinedUntil.Before(p.stats[quarantined[j]].quarantinedUntil)
+	})
+	for _, target := range quarantined {
+		if len(chosen) >= want {
+			break
+		}
+		chosen = append(chosen, target)
+	}
+	return chosen
+}
+
+// record adds the outcomes of a check to the targets' records, quarantining
+// a target whose lone failures reached rotation.QuarantineFailures as long
+// as another target of its kind is not quarantined. Suppressed results and
+// those of an open circuit breaker say nothing about their target.
+func (p *targetPool) record(now time.Time, results []TestResult, rotation TargetRotation, logger *logrus.Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	anyPassed := false
+	for _, result := range results {
+		if result.Success {
+			anyPassed = true
+		}
+	}
+
+	for _, result := range results {
+		stats, ok := p.stats[result.Target]
+		if !ok || result.Suppressed || result.CircuitOpen {
+			continue
+		}
+		o := outcomePassed
+		if !result.Success {
+			o = outcomeFailed
+			if anyPassed {
+				o = outcomeFailedAlone
+			}
+		}
+		stats.outcomes = append(stats.outcomes, o)
+		if len(stats.outcomes) > targetWindow {
+			stats.outcomes = stats.outcomes[len(stats.outcomes)-targetWindow:]
+		}
+
+		if rotation.QuarantineFailures <= 0 || o != outcomeFailedAlone || !stats.quarantinedUntil.IsZero() {
+			continue
+		}
+		_, _, lone := stats.counts()
+		if lone < rotation.QuarantineFailures || !p.othersAvailable(result.Target) {
+			continue
+		}
+		duration := rotation.QuarantineDuration
+		if duration <= 0 {
+			duration = DefaultQuarantineDuration
+		}
+		stats.quarantinedUntil = now.Add(duration)
+		logger.WithFields(logrus.Fields{
+			"test_type":     p.testType,
+			"target":        result.Target,
+			"lone_failures": lone,
+			"checks":        len(stats.outcomes),
+			"until":         stats.quarantinedUntil,
+		}).Warn("Quarantined flaky check target")
+	}
+}
+
+// othersAvailable reports whether a target other than target is not
+// quarantined; the caller holds mu
+func (p *targetPool) othersAvailable(target string) bool {
+	for _, other := range p.targets {
+		if other != target && p.stats[other].quarantinedUntil.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// counts returns the checks, failures and lone failures in the record
+func (s *targetStats) counts() (checks, failures, lone int) {
+	for _, o := range s.outcomes {
+		if o != outcomePassed {
+			failures++
+		}
+		if o == outcomeFailedAlone {
+			lone++
+		}
+	}
+	return len(s.outcomes), failures, lone
+}
+
+// health returns the reliability of every target in the pool
+func (p *targetPool) health() []TargetHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	health := make([]TargetHealth, 0, len(p.targets))
+	for _, target := range p.targets {
+		stats := p.stats[target]
+		checks, failures, lone := stats.counts()
+		entry := TargetHealth{TestType: p.testType, Target: target, Checks: checks, Failures: failures, LoneFailures: lone}
+		if !stats.quarantinedUntil.IsZero() {
+			until := stats.quarantinedUntil
+			entry.QuarantinedUntil = &until
+		}
+		health = append(health, entry)
+	}
+	return health
+}
+
+// SetTargetRotation sets how the tests choose among their targets
+func (t *Tester) SetTargetRotation(rotation TargetRotation) {
+	t.rotation = rotation
+}
+
+// TargetHealth returns the reliability of every TCP handshake, DNS and HTTP
+// target
+func (t *Tester) TargetHealth() []TargetHealth {
+	var health []TargetHealth
+	for _, pool := range []*targetPool{t.tcpTargets, t.dnsTargets, t.httpTargets} {
+		health = append(health, pool.health()...)
+	}
+	return health
+}
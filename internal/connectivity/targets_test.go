@@ -0,0 +1,112 @@
+package connectivity
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/sirupsen/logrus"
+)
+
+func TestTargetRotation(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	pool := newTargetPool(TestTypeTCPHandshake, []string{"a", "b", "c", "d", "e"})
+	rotation := TargetRotation{PerCheck: 2}
+	var got [][]string
+	for i := 0; i < 3; i++ {
+		got = append(got, pool.choose(now, rotation, logger))
+	}
+	want := [][]string{{"a", "b"}, {"c", "d"}, {"e", "a"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the checks to take turns, got %v", got)
+	}
+
+	if all := pool.choose(now, TargetRotation{}, logger); len(all) != 5 {
+		t.Errorf("Expected every target without rotation, got %v", all)
+	}
+}
+
+// newRotationTester returns a tester of three DNS servers of which only the
+// listed addresses are reachable
+func newRotationTester(rotation TargetRotation, reachable ...string) (*Tester, *clock.Fake) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	tester := NewTesterWithConfig(logger, time.Second, time.Second, []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"}, nil)
+	tester.SetClock(fake)
+	addresses := make(map[string]bool)
+	for _, address := range reachable {
+		addresses[address] = true
+	}
+	tester.SetDialer(scriptedDialer{reachable: addresses})
+	tester.retryConfig.MaxAttempts = 1
+	tester.SetTargetRotation(rotation)
+	return tester, fake
+}
+
+func TestFlakyTargetQuarantine(t *testing.T) {
+	rotation := TargetRotation{QuarantineFailures: 2, QuarantineDuration: time.Hour}
+	tester, fake := newRotationTester(rotation, "192.0.2.2:53", "192.0.2.3:53")
+
+	// The first server fails while the others pass, twice
+	for i := 0; i < 2; i++ {
+		result, err := tester.RunLightweightTests(context.Background())
+		if err != nil {
+			t.Fatalf("RunLightweightTests() failed: %v", err)
+		}
+		if len(result.TestResults) != 3 {
+			t.Fatalf("Expected all three servers before the quarantine, got %d", len(result.TestResults))
+		}
+		fake.Advance(time.Minute)
+	}
+
+	result, err := tester.RunLightweightTests(context.Background())
+	if err != nil {
+		t.Fatalf("RunLightweightTests() failed: %v", err)
+	}
+	if len(result.TestResults) != 2 || result.FailureCount != 0 {
+		t.Errorf("Expected the flaky server to sit out, got %+v", result.TestResults)
+	}
+	health := tester.TargetHealth()
+	if health[0].Target != "192.0.2.1:53" || health[0].LoneFailures != 2 || health[0].QuarantinedUntil == nil {
+		t.Errorf("Expected the flaky server quarantined, got %+v", health[0])
+	}
+
+	// After the quarantine it is back on probation
+	fake.Advance(time.Hour)
+	result, err = tester.RunLightweightTests(context.Background())
+	if err != nil {
+		t.Fatalf("RunLightweightTests() failed: %v", err)
+	}
+	if len(result.TestResults) != 3 {
+		t.Errorf("Expected the server back after its quarantine, got %d results", len(result.TestResults))
+	}
+	if health := tester.TargetHealth(); health[0].QuarantinedUntil != nil || health[0].Checks != 1 {
+		t.Errorf("Expected a clean record after the quarantine, got %+v", health[0])
+	}
+}
+
+func TestOutageQuarantinesNoTarget(t *testing.T) {
+	tester, fake := newRotationTester(TargetRotation{PerCheck: 2, QuarantineFailures: 1})
+
+	for i := 0; i < 5; i++ {
+		if _, err := tester.RunLightweightTests(context.Background()); err != nil {
+			t.Fatalf("RunLightweightTests() failed: %v", err)
+		}
+		fake.Advance(time.Minute)
+	}
+	for _, target := range tester.TargetHealth() {
+		if target.TestType != TestTypeTCPHandshake {
+			continue
+		}
+		if target.QuarantinedUntil != nil || target.LoneFailures != 0 || target.Checks == 0 {
+			t.Errorf("Expected failures of every target to quarantine none, got %+v", target)
+		}
+	}
+}
@@ -1,17 +1,26 @@
 package connectivity
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/perezjoseph/mb8600-watchdog/internal/budget"
 	"github.com/perezjoseph/mb8600-watchdog/internal/circuitbreaker"
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+	"github.com/perezjoseph/mb8600-watchdog/internal/responder"
+	"github.com/perezjoseph/mb8600-watchdog/internal/retry"
+	"github.com/perezjoseph/mb8600-watchdog/internal/system"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,14 +29,20 @@ const (
 	TestTypeTCPHandshake     = "tcp_handshake"
 	TestTypeDNSResolution    = "dns_resolution"
 	TestTypeHTTPConnectivity = "http_connectivity"
+	TestTypeUDPProbe         = "udp_probe"
 
+	// maxAnswer bounds the answer read from the responder
+	maxAnswer = 512
+
+	// CircuitBreakerOpenMsg is the message of errors.ErrCircuitOpen; match
+	// the error with errors.Is rather than by message
 	CircuitBreakerOpenMsg = "circuit breaker is open"
 	UserAgent             = "MB8600-Watchdog/1.0"
 )
 
 // createTestResult creates a standardized test result
-func createTestResult(testType string, startTime time.Time, success bool, err error, details map[string]interface{}) TestResult {
-	duration := time.Since(startTime)
+func (t *Tester) createTestResult(testType, target string, startTime time.Time, success bool, err error, details map[string]interface{}) TestResult {
+	duration := t.clock.Since(startTime)
 
 	if details == nil {
 		details = make(map[string]interface{})
@@ -35,6 +50,7 @@ func createTestResult(testType string, startTime time.Time, success bool, err er
 
 	return TestResult{
 		TestType:  testType,
+		Target:    target,
 		Timestamp: startTime,
 		Duration:  duration,
 		Success:   success,
@@ -45,59 +61,84 @@ func createTestResult(testType string, startTime time.Time, success bool, err er
 
 // isCircuitBreakerError checks if an error is from circuit breaker
 func isCircuitBreakerError(err error) bool {
-	return err != nil && strings.Contains(err.Error(), CircuitBreakerOpenMsg)
-}
-
-// RetryConfig defines retry behavior
-type RetryConfig struct {
-	MaxAttempts int
-	BaseDelay   time.Duration
-	MaxDelay    time.Duration
-	Multiplier  float64
+	return errors.Is(err, werrors.ErrCircuitOpen)
 }
 
-// DefaultRetryConfig returns a sensible default retry configuration
-func DefaultRetryConfig() RetryConfig {
-	return RetryConfig{
+// DefaultRetryPolicy returns the retry policy of connectivity tests
+func DefaultRetryPolicy() retry.Policy {
+	return retry.Policy{
 		MaxAttempts: 3,
 		BaseDelay:   100 * time.Millisecond,
 		MaxDelay:    2 * time.Second,
 		Multiplier:  2.0,
+		// A transparent proxy fakes the same answer again
+		Retryable: func(err error) bool { return !errors.Is(err, werrors.ErrUnverifiedResponse) },
 	}
 }
 
 // TestResult represents the result of a connectivity test
 type TestResult struct {
-	Success     bool
-	Duration    time.Duration
-	Error       error
-	TestType    string
+	Success  bool
+	Duration time.Duration
+	Error    error
+	TestType string
+	// Target is the host, server or URL the test checked
+	Target      string
 	Timestamp   time.Time
 	Details     map[string]interface{}
 	RetryCount  int
 	CircuitOpen bool
+	// Unverified is set when an answer came back but failed verification
+	Unverified bool
+	// Phases is the duration of each phase of an HTTP check, by HTTPPhases
+	// name; other tests leave it nil
+	Phases map[string]time.Duration
+	// Suppressed is set on a failure put down to the failed check
+	// SuppressedBy, a dependency of this test's check; the test may have
+	// been skipped rather than run
+	Suppressed   bool
+	SuppressedBy string
 }
 
 // LightweightTestResult represents results from lightweight connectivity tests
 type LightweightTestResult struct {
 	OverallSuccess bool
+	HealthScore    float64 // health score of the TCP handshake success rate
 	TestResults    []TestResult
 	Duration       time.Duration
 	Timestamp      time.Time
 	SuccessCount   int
 	FailureCount   int
+	// UnverifiedCount counts failures whose answers failed verification
+	UnverifiedCount int
+	// SuppressedCount counts failures put down to a failed dependency, which
+	// FailureCount leaves out
+	SuppressedCount int
+	// WireGuardResults are the checks of the WireGuard tunnel, if one is configured
+	WireGuardResults []TestResult
+	// GatewayResults are the checks of the local gateway, if one is configured
+	GatewayResults []TestResult
+	// CustomResults are the custom checks, such as those of plugins
+	CustomResults []TestResult
 }
 
 // ComprehensiveTestResult represents results from comprehensive connectivity tests
 type ComprehensiveTestResult struct {
 	OverallSuccess bool
+	HealthScore    float64 // health score of the DNS and HTTP success rates
 	DNSResults     []TestResult
 	HTTPResults    []TestResult
+	UDPResults     []TestResult // UDP probes of the responder, in place of DNS results
 	Duration       time.Duration
 	Timestamp      time.Time
 	SuccessCount   int
 	FailureCount   int
-	EscalatedFrom  string // "lightweight" if escalated from lightweight test failure
+	// UnverifiedCount counts failures whose answers failed verification
+	UnverifiedCount int
+	// SuppressedCount counts failures put down to a failed dependency, which
+	// FailureCount leaves out
+	SuppressedCount int
+	EscalatedFrom   string // "lightweight" if escalated from lightweight test failure
 }
 
 // TieredTestResult represents the result of tiered connectivity testing
@@ -108,7 +149,13 @@ type TieredTestResult struct {
 	OverallSuccess      bool
 	TotalDuration       time.Duration
 	Timestamp           time.Time
-	ShortCircuited      bool // true if lightweight tests succeeded and comprehensive tests were skipped
+	ShortCircuited      bool   // true if lightweight tests succeeded and comprehensive tests were skipped
+	CycleID             string // the monitoring cycle that ran the tests, if any
+}
+
+// Dialer opens network connections; *net.Dialer satisfies it
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
 }
 
 // Tester handles connectivity testing with tiered approach
@@ -121,7 +168,42 @@ type Tester struct {
 	httpClient         *http.Client
 	dnsCircuitBreaker  *circuitbreaker.Breaker
 	httpCircuitBreaker *circuitbreaker.Breaker
-	retryConfig        RetryConfig
+	retryConfig        retry.Policy
+	health             *health.Model
+	clock              clock.Clock
+	dialer             Dialer
+	// responder is the host:port of a self-hosted responder, empty without one
+	responder string
+	// responderKey verifies the signed answers of the responder; nil skips
+	// verification
+	responderKey []byte
+	escalation   EscalationPolicy
+	// lastComprehensive is when ScheduleTests last ran the comprehensive tests
+	lastComprehensive time.Time
+	// dependencies suppress the failures of checks whose dependency failed;
+	// gateway is the host:port of the local gateway, empty without a
+	// gateway check
+	dependencies Dependencies
+	gateway      string
+
+	// rotation chooses the targets of each check from the pools of TCP
+	// handshake, DNS and HTTP targets, which track their reliability
+	rotation    TargetRotation
+	tcpTargets  *targetPool
+	dnsTargets  *targetPool
+	httpTargets *targetPool
+
+	// wireguard is the WireGuard tunnel the lightweight tests check,
+	// wireguardRx the bytes received from each peer at the last check and
+	// listWireGuardPeers lists the peers of an interface, replaced in tests
+	wireguardMu        sync.Mutex
+	wireguard          WireGuardCheck
+	wireguardRx        map[string]int64
+	listWireGuardPeers func(ctx context.Context, iface string) ([]system.WireGuardPeer, error)
+
+	// checks are the custom checks the lightweight tests run
+	checksMu sync.Mutex
+	checks   []Check
 }
 
 // NewTester creates a new connectivity tester
@@ -158,8 +240,14 @@ func NewTesterWithConfig(logger *logrus.Logger, connectionTimeout, httpTimeout t
 		httpClient:         httpClient,
 		dnsCircuitBreaker:  circuitbreaker.New(3, 30*time.Second),
 		httpCircuitBreaker: circuitbreaker.New(3, 30*time.Second),
-		retryConfig:        DefaultRetryConfig(),
+		retryConfig:        DefaultRetryPolicy(),
+		health:             health.DefaultModel(),
+		clock:              clock.New(),
+		dialer:             &net.Dialer{},
+		escalation:         DefaultEscalationPolicy(),
+		wireguardRx:        make(map[string]int64),
 	}
+	tester.listWireGuardPeers = tester.wireguardPeers
 
 	// Ensure DNS servers have port numbers
 	for i, server := range dnsServers {
@@ -170,50 +258,144 @@ func NewTesterWithConfig(logger *logrus.Logger, connectionTimeout, httpTimeout t
 			tester.dnsServers[i] = server
 		}
 	}
+	tester.tcpTargets = newTargetPool(TestTypeTCPHandshake, tester.dnsServers)
+	tester.dnsTargets = newTargetPool(TestTypeDNSResolution, tester.dnsServers)
+	tester.httpTargets = newTargetPool(TestTypeHTTPConnectivity, httpHosts)
 
 	return tester
 }
 
-// executeWithRetry executes an operation with exponential backoff retry logic
-func (t *Tester) executeWithRetry(ctx context.Context, operation func() error, testType string) (int, error) {
-	var lastErr error
-	delay := t.retryConfig.BaseDelay
-
-	for attempt := 0; attempt < t.retryConfig.MaxAttempts; attempt++ {
-		if attempt > 0 {
-			select {
-			case <-ctx.Done():
-				return attempt, ctx.Err()
-			case <-time.After(delay):
-				// Continue with retry
-			}
+// SetClock replaces the clock used for timing and retry delays
+func (t *Tester) SetClock(c clock.Clock) {
+	t.clock = c
+	t.dnsCircuitBreaker.SetClock(c)
+	t.httpCircuitBreaker.SetClock(c)
+}
 
-			// Exponential backoff with jitter
-			delay = time.Duration(float64(delay) * t.retryConfig.Multiplier)
-			if delay > t.retryConfig.MaxDelay {
-				delay = t.retryConfig.MaxDelay
-			}
-		}
+// SetDialer replaces the dialer used for TCP handshake and DNS tests. The
+// default HTTP client is switched to the dialer as well.
+func (t *Tester) SetDialer(d Dialer) {
+	t.dialer = d
+	if transport, ok := t.httpClient.Transport.(*http.Transport); ok {
+		transport = transport.Clone()
+		transport.DialContext = d.DialContext
+		t.httpClient = &http.Client{Timeout: t.httpClient.Timeout, Transport: transport}
+	}
+}
 
-		err := operation()
-		if err == nil {
-			return attempt, nil
-		}
+// SetProxy routes the HTTP checks through proxy, as http.Transport.Proxy
+// does; nil sends them directly. TCP handshake and DNS tests always go
+// directly, as a proxy cannot carry them.
+func (t *Tester) SetProxy(proxy func(*http.Request) (*url.URL, error)) {
+	if transport, ok := t.httpClient.Transport.(*http.Transport); ok {
+		transport = transport.Clone()
+		transport.Proxy = proxy
+		t.httpClient = &http.Client{Timeout: t.httpClient.Timeout, Transport: transport}
+	}
+}
 
-		lastErr = err
-		t.logger.WithFields(logrus.Fields{
-			"attempt":      attempt + 1,
-			"max_attempts": t.retryConfig.MaxAttempts,
-			"test_type":    testType,
-			"error":        err.Error(),
-		}).Debug("Operation failed, retrying")
+// SetHealthModel replaces the model that scores test results; a tier passes
+// when its score is not unhealthy
+func (t *Tester) SetHealthModel(m *health.Model) {
+	t.health = m
+}
+
+// SetEscalationPolicy replaces the policy that decides when ScheduleTests
+// runs the comprehensive tests right away
+func (t *Tester) SetEscalationPolicy(p EscalationPolicy) {
+	t.escalation = p
+}
+
+// SetResponder makes the comprehensive tests send UDP probes to the
+// responder at address in place of DNS resolution tests, so no public
+// resolver is queried. An empty address restores the DNS tests.
+func (t *Tester) SetResponder(address string) {
+	t.responder = address
+}
+
+// SetResponderKey makes every test of the responder verify the nonce and
+// signature of its answer with key. An answer that fails verification, such
+// as one faked by a transparent proxy, fails the test as unverified.
+func (t *Tester) SetResponderKey(key []byte) {
+	t.responderKey = key
+}
+
+// verifying reports whether answers of the responder are verified
+func (t *Tester) verifying() bool {
+	return t.responder != "" && len(t.responderKey) > 0
+}
+
+// markUnverified flags result as unverified when its answer failed
+// verification
+func (t *Tester) markUnverified(result *TestResult, target string) {
+	if !errors.Is(result.Error, werrors.ErrUnverifiedResponse) {
+		return
 	}
+	result.Unverified = true
+	result.Details["error_type"] = "unverified"
+	t.logger.WithFields(logrus.Fields{
+		"target":    target,
+		"test_type": result.TestType,
+	}).WithError(result.Error).Warn("Answer failed verification, possibly faked by a transparent proxy")
+}
 
-	return t.retryConfig.MaxAttempts, lastErr
+// SetHTTPClient replaces the HTTP client used for HTTP connectivity tests
+func (t *Tester) SetHTTPClient(client *http.Client) {
+	t.httpClient = client
+}
+
+// BreakerStates returns the state of each circuit breaker by test type; it
+// is safe to call while tests run
+func (t *Tester) BreakerStates() map[string]string {
+	return map[string]string{
+		"dns":  t.dnsCircuitBreaker.GetState().String(),
+		"http": t.httpCircuitBreaker.GetState().String(),
+	}
+}
+
+// executeWithRetry runs an operation under the tester's retry policy. It
+// returns the number of retries after a success and the number of attempts
+// after a failure.
+func (t *Tester) executeWithRetry(ctx context.Context, operation func() error, testType string) (int, error) {
+	policy := t.retryConfig
+	policy.Clock = t.clock
+
+	attempts, err := retry.Do(ctx, policy, func(context.Context) error {
+		return operation()
+	}, func(attempt retry.Attempt) {
+		if attempt.Err == nil {
+			return
+		}
+		fields := logrus.Fields{
+			"attempt":      attempt.Number,
+			"max_attempts": policy.MaxAttempts,
+			"test_type":    testType,
+			"error":        attempt.Err.Error(),
+		}
+		switch {
+		case attempt.BudgetSpent:
+			t.logger.WithFields(fields).Debug("Cycle retry budget spent, not retrying")
+		case attempt.Delay > 0:
+			fields["delay_ms"] = attempt.Delay.Milliseconds()
+			t.logger.WithFields(fields).Debug("Operation failed, retrying")
+		default:
+			t.logger.WithFields(fields).Debug("Operation failed")
+		}
+	})
+	if err == nil {
+		return attempts - 1, nil
+	}
+	return attempts, err
 }
 
 // RunLightweightTests performs quick connectivity checks using TCP handshake tests to DNS servers
 func (t *Tester) RunLightweightTests(ctx context.Context) (*LightweightTestResult, error) {
+	return t.runLightweightTests(ctx, make(map[string]bool))
+}
+
+// runLightweightTests runs the lightweight tests, adding the checks that
+// failed to failed
+func (t *Tester) runLightweightTests(ctx context.Context, failed map[string]bool) (*LightweightTestResult, error) {
 	if t == nil {
 		return nil, fmt.Errorf("tester is nil")
 	}
@@ -224,24 +406,35 @@ func (t *Tester) RunLightweightTests(ctx context.Context) (*LightweightTestResul
 		return nil, fmt.Errorf("no DNS servers configured for testing")
 	}
 
-	startTime := time.Now()
+	startTime := t.clock.Now()
 	t.logger.Debug("Starting lightweight connectivity tests")
 
 	// Create context with timeout for the entire test suite
 	testCtx, cancel := context.WithTimeout(ctx, t.connectionTimeout*4) // Increased for retries
 	defer cancel()
 
-	// Run TCP handshake tests to DNS servers concurrently
-	results := make([]TestResult, len(t.dnsServers))
+	// The gateway goes first, so the tests depending on it can be skipped
+	gatewayResults := t.runGatewayTests(testCtx)
+	failedChecks(failed, gatewayResults)
+
+	// Run TCP handshake tests to this check's DNS servers concurrently
+	servers := t.tcpTargets.choose(startTime, t.rotation, t.logger)
+	results := make([]TestResult, len(servers))
 	var wg sync.WaitGroup
 
-	for i, server := range t.dnsServers {
+	if root := t.dependencies.rootCause(health.InputTCP, failed); root != "" {
+		results = t.skipped(TestTypeTCPHandshake, servers, root)
+	}
+	for i, server := range servers {
+		if results[i].Suppressed {
+			continue
+		}
 		if server == "" {
 			results[i] = TestResult{
 				TestType:  "tcp_handshake",
 				Success:   false,
 				Error:     fmt.Errorf("empty DNS server at index %d", i),
-				Timestamp: time.Now(),
+				Timestamp: t.clock.Now(),
 			}
 			continue
 		}
@@ -252,39 +445,85 @@ func (t *Tester) RunLightweightTests(ctx context.Context) (*LightweightTestResul
 		}(i, server)
 	}
 
+	var wireguardResults, customResults []TestResult
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		wireguardResults = t.runWireGuardTests(testCtx)
+	}()
+	go func() {
+		defer wg.Done()
+		customResults = t.runCustomChecks(testCtx)
+	}()
+
 	wg.Wait()
 
+	failedChecks(failed, results, wireguardResults, customResults)
+	t.dependencies.suppress(results, failed)
+	t.dependencies.suppress(wireguardResults, failed)
+	t.dependencies.suppress(customResults, failed)
+	t.logSuppressed("lightweight", results, wireguardResults, customResults)
+	t.tcpTargets.record(t.clock.Now(), results, t.rotation, t.logger)
+
 	// Aggregate results
 	successCount := 0
 	failureCount := 0
-	for _, result := range results {
+	unverifiedCount := 0
+	suppressedCount := 0
+	for _, result := range append(append(append(gatewayResults, results...), wireguardResults...), customResults...) {
 		if result.Success {
 			successCount++
+		} else if result.Suppressed {
+			suppressedCount++
 		} else {
 			failureCount++
 		}
+		if result.Unverified {
+			unverifiedCount++
+		}
 	}
 
-	// Consider test successful if at least 50% of DNS servers are reachable
-	overallSuccess := successCount > 0 && float64(successCount)/float64(len(results)) >= 0.5
+	// The tier passes unless the score of the reachable DNS servers, and of
+	// the WireGuard tunnel and custom checks if there are any, is unhealthy
+	rates := map[string]float64{health.InputTCP: successRate(results)}
+	if len(wireguardResults) > 0 {
+		rates[health.InputWireGuard] = successRate(wireguardResults)
+	}
+	if len(customResults) > 0 {
+		rates[health.InputCustom] = successRate(customResults)
+	}
+	score := t.health.Score(rates)
+	overallSuccess := successRate(append(append(results, wireguardResults...), customResults...)) > 0 && t.health.Passing(score)
 
-	duration := time.Since(startTime)
+	duration := t.clock.Since(startTime)
 
 	lightweightResult := &LightweightTestResult{
-		OverallSuccess: overallSuccess,
-		TestResults:    results,
-		Duration:       duration,
-		Timestamp:      startTime,
-		SuccessCount:   successCount,
-		FailureCount:   failureCount,
+		OverallSuccess:   overallSuccess,
+		HealthScore:      score,
+		TestResults:      results,
+		Duration:         duration,
+		Timestamp:        startTime,
+		SuccessCount:     successCount,
+		FailureCount:     failureCount,
+		UnverifiedCount:  unverifiedCount,
+		SuppressedCount:  suppressedCount,
+		WireGuardResults: wireguardResults,
+		GatewayResults:   gatewayResults,
+		CustomResults:    customResults,
 	}
 
 	t.logger.WithFields(logrus.Fields{
-		"overall_success": overallSuccess,
-		"success_count":   successCount,
-		"failure_count":   failureCount,
-		"duration_ms":     duration.Milliseconds(),
-		"test_type":       "lightweight",
+		"overall_success":  overallSuccess,
+		"health_score":     score,
+		"success_count":    successCount,
+		"failure_count":    failureCount,
+		"unverified_count": unverifiedCount,
+		"suppressed_count": suppressedCount,
+		"wireguard_tests":  len(wireguardResults),
+		"gateway_tests":    len(gatewayResults),
+		"custom_tests":     len(customResults),
+		"duration_ms":      duration.Milliseconds(),
+		"test_type":        "lightweight",
 	}).Debug("Lightweight connectivity tests completed")
 
 	return lightweightResult, nil
@@ -292,7 +531,7 @@ func (t *Tester) RunLightweightTests(ctx context.Context) (*LightweightTestResul
 
 // testTCPHandshakeWithReliability performs a TCP handshake test with circuit breaker and retry logic
 func (t *Tester) testTCPHandshakeWithReliability(ctx context.Context, server string) TestResult {
-	startTime := time.Now()
+	startTime := t.clock.Now()
 	var lastErr error
 	var retryCount int
 
@@ -318,9 +557,10 @@ func (t *Tester) testTCPHandshakeWithReliability(ctx context.Context, server str
 		"circuit_state": t.dnsCircuitBreaker.GetState().String(),
 	}
 
-	result := createTestResult(TestTypeTCPHandshake, startTime, err == nil, lastErr, details)
+	result := t.createTestResult(TestTypeTCPHandshake, server, startTime, err == nil, lastErr, details)
 	result.RetryCount = retryCount
 	result.CircuitOpen = circuitOpen
+	t.markUnverified(&result, server)
 
 	t.logger.WithFields(logrus.Fields{
 		"server":        server,
@@ -343,8 +583,7 @@ func (t *Tester) performTCPHandshake(ctx context.Context, server string) error {
 	connCtx, cancel := context.WithTimeout(ctx, t.connectionTimeout)
 	defer cancel()
 
-	dialer := &net.Dialer{}
-	conn, err := dialer.DialContext(connCtx, "tcp", server)
+	conn, err := t.dialer.DialContext(connCtx, "tcp", server)
 	if err != nil {
 		return fmt.Errorf("TCP handshake failed to %s (timeout: %v): %w", server, t.connectionTimeout, err)
 	}
@@ -352,52 +591,96 @@ func (t *Tester) performTCPHandshake(ctx context.Context, server string) error {
 	if conn == nil {
 		return fmt.Errorf("TCP handshake to %s returned nil connection", server)
 	}
+	defer conn.Close()
 
-	conn.Close()
-	return nil
+	if !t.verifying() {
+		return nil
+	}
+	if deadline, ok := connCtx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	nonce := responder.NewNonce()
+	if _, err := fmt.Fprintln(conn, nonce); err != nil {
+		return fmt.Errorf("failed to send nonce to %s: %w", server, err)
+	}
+	line, err := bufio.NewReader(io.LimitReader(conn, maxAnswer)).ReadString('\n')
+	if err != nil && line == "" {
+		return werrors.Mark(fmt.Errorf("no answer from %s after TCP handshake: %w", server, err), werrors.ErrUnverifiedResponse)
+	}
+	return responder.Verify(t.responderKey, nonce, line)
 }
 
 // RunComprehensiveTests performs full connectivity analysis
 func (t *Tester) RunComprehensiveTests(ctx context.Context) (*ComprehensiveTestResult, error) {
-	return t.runComprehensiveTestsWithEscalation(ctx, "")
+	return t.runComprehensiveTestsWithEscalation(ctx, "", make(map[string]bool))
 }
 
 // RunComprehensiveTestsEscalated performs comprehensive tests after lightweight test failure
 func (t *Tester) RunComprehensiveTestsEscalated(ctx context.Context) (*ComprehensiveTestResult, error) {
-	return t.runComprehensiveTestsWithEscalation(ctx, "lightweight")
+	return t.runComprehensiveTestsWithEscalation(ctx, "lightweight", make(map[string]bool))
 }
 
-// runComprehensiveTestsWithEscalation performs comprehensive connectivity tests
-func (t *Tester) runComprehensiveTestsWithEscalation(ctx context.Context, escalatedFrom string) (*ComprehensiveTestResult, error) {
-	startTime := time.Now()
+// runComprehensiveTestsWithEscalation performs comprehensive connectivity
+// tests, skipping those whose dependency is in failed and adding the checks
+// that failed to it
+func (t *Tester) runComprehensiveTestsWithEscalation(ctx context.Context, escalatedFrom string, failed map[string]bool) (*ComprehensiveTestResult, error) {
+	startTime := t.clock.Now()
 	t.logger.WithField("escalated_from", escalatedFrom).Debug("Starting comprehensive connectivity tests")
 
 	// Create context with timeout for the entire test suite
 	testCtx, cancel := context.WithTimeout(ctx, (t.connectionTimeout+t.httpTimeout)*2)
 	defer cancel()
 
-	// Run DNS resolution tests and HTTP connectivity tests concurrently
+	// Run DNS resolution tests, or UDP probes of the responder, and HTTP
+	// connectivity tests concurrently
 	var wg sync.WaitGroup
 	var dnsResults []TestResult
 	var httpResults []TestResult
+	var udpResults []TestResult
 	var dnsErr, httpErr error
+	dnsServers := t.dnsTargets.choose(startTime, t.rotation, t.logger)
+	httpHosts := t.httpTargets.choose(startTime, t.rotation, t.logger)
 
 	// DNS resolution tests
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		dnsResults, dnsErr = t.runDNSResolutionTests(testCtx)
+		if t.responder != "" {
+			if root := t.dependencies.rootCause(health.InputUDP, failed); root != "" {
+				udpResults = t.skipped(TestTypeUDPProbe, []string{t.responder}, root)
+				return
+			}
+			udpResults = []TestResult{t.testUDPProbe(testCtx, t.responder)}
+			return
+		}
+		if root := t.dependencies.rootCause(health.InputDNS, failed); root != "" {
+			dnsResults = t.skipped(TestTypeDNSResolution, dnsServers, root)
+			return
+		}
+		dnsResults, dnsErr = t.runDNSResolutionTests(testCtx, dnsServers)
 	}()
 
 	// HTTP connectivity tests
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		httpResults, httpErr = t.runHTTPConnectivityTests(testCtx)
+		if root := t.dependencies.rootCause(health.InputHTTP, failed); root != "" {
+			httpResults = t.skipped(TestTypeHTTPConnectivity, httpHosts, root)
+			return
+		}
+		httpResults, httpErr = t.runHTTPConnectivityTests(testCtx, httpHosts)
 	}()
 
 	wg.Wait()
 
+	failedChecks(failed, dnsResults, udpResults, httpResults)
+	for _, results := range [][]TestResult{dnsResults, udpResults, httpResults} {
+		t.dependencies.suppress(results, failed)
+	}
+	t.logSuppressed("comprehensive", dnsResults, udpResults, httpResults)
+	t.dnsTargets.record(t.clock.Now(), dnsResults, t.rotation, t.logger)
+	t.httpTargets.record(t.clock.Now(), httpResults, t.rotation, t.logger)
+
 	// Handle errors from concurrent tests
 	if dnsErr != nil {
 		t.logger.WithError(dnsErr).Warn("DNS resolution tests encountered error")
@@ -409,66 +692,86 @@ func (t *Tester) runComprehensiveTestsWithEscalation(ctx context.Context, escala
 	// Aggregate results
 	successCount := 0
 	failureCount := 0
-
-	for _, result := range dnsResults {
-		if result.Success {
-			successCount++
-		} else {
-			failureCount++
-		}
-	}
-
-	for _, result := range httpResults {
-		if result.Success {
-			successCount++
-		} else {
-			failureCount++
+	unverifiedCount := 0
+	suppressedCount := 0
+	for _, results := range [][]TestResult{dnsResults, httpResults, udpResults} {
+		for _, result := range results {
+			if result.Success {
+				successCount++
+			} else if result.Suppressed {
+				suppressedCount++
+			} else {
+				failureCount++
+			}
+			if result.Unverified {
+				unverifiedCount++
+			}
 		}
 	}
 
-	totalTests := len(dnsResults) + len(httpResults)
+	totalTests := len(dnsResults) + len(httpResults) + len(udpResults)
 
-	// Consider test successful if at least 60% of tests pass
-	overallSuccess := totalTests > 0 && float64(successCount)/float64(totalTests) >= 0.6
+	// The tier passes unless the weighted score of DNS, UDP and HTTP tests
+	// is unhealthy
+	rates := make(map[string]float64, 3)
+	if len(dnsResults) > 0 {
+		rates[health.InputDNS] = successRate(dnsResults)
+	}
+	if len(udpResults) > 0 {
+		rates[health.InputUDP] = successRate(udpResults)
+	}
+	if len(httpResults) > 0 {
+		rates[health.InputHTTP] = successRate(httpResults)
+	}
+	score := t.health.Score(rates)
+	overallSuccess := totalTests > 0 && t.health.Passing(score)
 
-	duration := time.Since(startTime)
+	duration := t.clock.Since(startTime)
 
 	comprehensiveResult := &ComprehensiveTestResult{
-		OverallSuccess: overallSuccess,
-		DNSResults:     dnsResults,
-		HTTPResults:    httpResults,
-		Duration:       duration,
-		Timestamp:      startTime,
-		SuccessCount:   successCount,
-		FailureCount:   failureCount,
-		EscalatedFrom:  escalatedFrom,
+		OverallSuccess:  overallSuccess,
+		HealthScore:     score,
+		DNSResults:      dnsResults,
+		HTTPResults:     httpResults,
+		UDPResults:      udpResults,
+		Duration:        duration,
+		Timestamp:       startTime,
+		SuccessCount:    successCount,
+		FailureCount:    failureCount,
+		UnverifiedCount: unverifiedCount,
+		SuppressedCount: suppressedCount,
+		EscalatedFrom:   escalatedFrom,
 	}
 
 	t.logger.WithFields(logrus.Fields{
-		"overall_success": overallSuccess,
-		"success_count":   successCount,
-		"failure_count":   failureCount,
-		"dns_tests":       len(dnsResults),
-		"http_tests":      len(httpResults),
-		"duration_ms":     duration.Milliseconds(),
-		"escalated_from":  escalatedFrom,
-		"test_type":       "comprehensive",
+		"overall_success":  overallSuccess,
+		"health_score":     score,
+		"success_count":    successCount,
+		"failure_count":    failureCount,
+		"unverified_count": unverifiedCount,
+		"suppressed_count": suppressedCount,
+		"dns_tests":        len(dnsResults),
+		"http_tests":       len(httpResults),
+		"udp_tests":        len(udpResults),
+		"duration_ms":      duration.Milliseconds(),
+		"escalated_from":   escalatedFrom,
+		"test_type":        "comprehensive",
 	}).Debug("Comprehensive connectivity tests completed")
 
 	return comprehensiveResult, nil
 }
 
-// runDNSResolutionTests performs DNS resolution tests against configured DNS servers
-func (t *Tester) runDNSResolutionTests(ctx context.Context) ([]TestResult, error) {
+// runDNSResolutionTests performs DNS resolution tests against servers
+func (t *Tester) runDNSResolutionTests(ctx context.Context, servers []string) ([]TestResult, error) {
 	t.logger.Debug("Running DNS resolution tests")
 
-	results := make([]TestResult, len(t.dnsServers))
+	results := make([]TestResult, len(servers))
 	var wg sync.WaitGroup
 
 	// Test domains to resolve
 	testDomains := []string{"google.com", "cloudflare.com", "amazon.com"}
 
-	for i, server := range t.dnsServers {
+	for i, server := range servers {
 		wg.Add(1)
 		go func(index int, dnsServer string) {
 			defer wg.Done()
@@ -483,7 +786,7 @@ func (t *Tester) runDNSResolutionTests(ctx context.Context) ([]TestResult, error
 
 // testDNSResolution tests DNS resolution against a specific DNS server
 func (t *Tester) testDNSResolution(ctx context.Context, dnsServer string, domains []string) TestResult {
-	startTime := time.Now()
+	startTime := t.clock.Now()
 	resolveCtx, cancel := context.WithTimeout(ctx, t.connectionTimeout)
 	defer cancel()
 
@@ -500,17 +803,20 @@ func (t *Tester) testDNSResolution(ctx context.Context, dnsServer string, domain
 	resolver := &net.Resolver{
 		PreferGo: true,
 		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := net.Dialer{Timeout: t.connectionTimeout}
-			return d.DialContext(ctx, network, dnsServer)
+			dialCtx, cancel := context.WithTimeout(ctx, t.connectionTimeout)
+			defer cancel()
+			return t.dialer.DialContext(dialCtx, network, dnsServer)
 		},
 	}
 
 	successfulResolutions := 0
+	timedOut := false
 	resolutionDetails := make(map[string]interface{})
 
 	for _, domain := range domains {
 		ips, err := resolver.LookupIPAddr(resolveCtx, domain)
 		if err != nil {
+			timedOut = timedOut || werrors.IsTimeout(err)
 			resolutionDetails[domain] = "failed: " + err.Error()
 		} else if len(ips) > 0 {
 			successfulResolutions++
@@ -533,9 +839,12 @@ func (t *Tester) testDNSResolution(ctx context.Context, dnsServer string, domain
 	var resultErr error
 	if !success {
 		resultErr = fmt.Errorf("insufficient successful resolutions: %d/%d", successfulResolutions, len(domains))
+		if timedOut {
+			resultErr = werrors.Mark(resultErr, werrors.ErrDNSTimeout)
+		}
 	}
 
-	result := createTestResult(TestTypeDNSResolution, startTime, success, resultErr, details)
+	result := t.createTestResult(TestTypeDNSResolution, dnsServer, startTime, success, resultErr, details)
 
 	if success {
 		t.logger.WithFields(logrus.Fields{
@@ -556,14 +865,73 @@ func (t *Tester) testDNSResolution(ctx context.Context, dnsServer string, domain
 	return result
 }
 
-// runHTTPConnectivityTests performs HTTP connectivity tests against configured hosts
-func (t *Tester) runHTTPConnectivityTests(ctx context.Context) ([]TestResult, error) {
+// testUDPProbe sends a UDP probe to the responder at address and expects
+// its nonce back
+func (t *Tester) testUDPProbe(ctx context.Context, address string) TestResult {
+	startTime := t.clock.Now()
+	nonce := responder.NewNonce()
+	details := map[string]interface{}{
+		"responder":  address,
+		"timeout_ms": t.connectionTimeout.Milliseconds(),
+	}
+
+	_, err := t.executeWithRetry(ctx, func() error {
+		return t.performUDPProbe(ctx, address, nonce)
+	}, TestTypeUDPProbe)
+
+	result := t.createTestResult(TestTypeUDPProbe, address, startTime, err == nil, err, details)
+	t.markUnverified(&result, address)
+	t.logger.WithFields(logrus.Fields{
+		"responder":   address,
+		"success":     result.Success,
+		"duration_ms": result.Duration.Milliseconds(),
+	}).Debug("UDP probe completed")
+	return result
+}
+
+// performUDPProbe sends one UDP probe and reads the answer
+func (t *Tester) performUDPProbe(ctx context.Context, address, nonce string) error {
+	probeCtx, cancel := context.WithTimeout(ctx, t.connectionTimeout)
+	defer cancel()
+
+	conn, err := t.dialer.DialContext(probeCtx, "udp", address)
+	if err != nil {
+		return fmt.Errorf("UDP probe failed to %s: %w", address, err)
+	}
+	defer conn.Close()
+	if deadline, ok := probeCtx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(responder.UDPProbe(nonce)); err != nil {
+		return fmt.Errorf("UDP probe failed to %s: %w", address, err)
+	}
+	buf := make([]byte, maxAnswer)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return fmt.Errorf("no answer to UDP probe from %s: %w", address, err)
+	}
+	if t.verifying() {
+		return responder.Verify(t.responderKey, nonce, string(buf[:n]))
+	}
+	response, err := responder.ParseResponse(string(buf[:n]))
+	if err != nil {
+		return err
+	}
+	if response.Nonce != nonce {
+		return fmt.Errorf("UDP probe answer from %s carries another nonce", address)
+	}
+	return nil
+}
+
+// runHTTPConnectivityTests performs HTTP connectivity tests against hosts
+func (t *Tester) runHTTPConnectivityTests(ctx context.Context, hosts []string) ([]TestResult, error) {
 	t.logger.Debug("Running HTTP connectivity tests")
 
-	results := make([]TestResult, len(t.httpHosts))
+	results := make([]TestResult, len(hosts))
 	var wg sync.WaitGroup
 
-	for i, host := range t.httpHosts {
+	for i, host := range hosts {
 		wg.Add(1)
 		go func(index int, httpHost string) {
 			defer wg.Done()
@@ -578,7 +946,7 @@ func (t *Tester) runHTTPConnectivityTests(ctx context.Context) ([]TestResult, er
 
 // testHTTPConnectivity tests HTTP connectivity to a specific host
 func (t *Tester) testHTTPConnectivity(ctx context.Context, httpHost string) TestResult {
-	startTime := time.Now()
+	startTime := t.clock.Now()
 	var lastErr error
 
 	t.logger.WithField("http_host", httpHost).Debug("Testing HTTP connectivity")
@@ -587,6 +955,7 @@ func (t *Tester) testHTTPConnectivity(ctx context.Context, httpHost string) Test
 		"http_host":  httpHost,
 		"timeout_ms": t.httpTimeout.Milliseconds(),
 	}
+	timer := newPhaseTimer(t.clock.Now)
 
 	err := t.httpCircuitBreaker.Execute(func() error {
 		parsedURL, parseErr := url.Parse(httpHost)
@@ -594,6 +963,11 @@ func (t *Tester) testHTTPConnectivity(ctx context.Context, httpHost string) Test
 			return fmt.Errorf("invalid URL format: %w", parseErr)
 		}
 
+		ctx := timer.withTrace(ctx)
+		if t.verifying() {
+			return t.performVerifiedHTTPCheck(ctx, parsedURL, details)
+		}
+
 		req, reqErr := http.NewRequestWithContext(ctx, "HEAD", httpHost, nil)
 		if reqErr != nil {
 			return fmt.Errorf("failed to create request: %w", reqErr)
@@ -625,25 +999,32 @@ func (t *Tester) testHTTPConnectivity(ctx context.Context, httpHost string) Test
 
 	details["circuit_open"] = circuitOpen
 	details["circuit_state"] = t.httpCircuitBreaker.GetState().String()
+	phases := timer.result()
+	addPhaseDetails(details, phases)
 
-	result := createTestResult(TestTypeHTTPConnectivity, startTime, err == nil, lastErr, details)
+	result := t.createTestResult(TestTypeHTTPConnectivity, httpHost, startTime, err == nil, lastErr, details)
 	result.CircuitOpen = circuitOpen
+	result.Phases = phases
+	t.markUnverified(&result, httpHost)
 
 	logFields := logrus.Fields{
 		"http_host":     httpHost,
 		"duration_ms":   result.Duration.Milliseconds(),
 		"circuit_state": t.httpCircuitBreaker.GetState().String(),
 	}
+	if slowest, ok := details["slowest_phase"]; ok {
+		logFields["slowest_phase"] = slowest
+	}
 
 	if result.Success {
 		logFields["status_code"] = details["status_code"]
 		t.logger.WithFields(logFields).Debug("HTTP connectivity test successful")
 	} else {
 		// Categorize error type for better diagnostics
-		if err != nil {
-			if strings.Contains(err.Error(), "timeout") {
+		if err != nil && !result.Unverified {
+			if werrors.IsTimeout(err) {
 				details["error_type"] = "timeout"
-			} else if strings.Contains(err.Error(), "connection") {
+			} else if werrors.IsUnreachable(err) {
 				details["error_type"] = "connection"
 			} else {
 				details["error_type"] = "other"
@@ -658,6 +1039,42 @@ func (t *Tester) testHTTPConnectivity(ctx context.Context, httpHost string) Test
 	return result
 }
 
+// performVerifiedHTTPCheck requests the responder check at checkURL with a
+// nonce and verifies the signed answer
+func (t *Tester) performVerifiedHTTPCheck(ctx context.Context, checkURL *url.URL, details map[string]interface{}) error {
+	nonce := responder.NewNonce()
+	withNonce := *checkURL
+	query := withNonce.Query()
+	query.Set("nonce", nonce)
+	withNonce.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, withNonce.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	details["status_code"] = resp.StatusCode
+	details["status"] = resp.Status
+	details["host"] = checkURL.Host
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAnswer))
+	if err != nil {
+		return fmt.Errorf("failed to read responder answer: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return werrors.Mark(fmt.Errorf("responder check returned status %d", resp.StatusCode), werrors.ErrUnverifiedResponse)
+	}
+	return responder.Verify(t.responderKey, nonce, string(body))
+}
+
 // RunTieredTests performs tiered connectivity testing with escalation logic
 func (t *Tester) RunTieredTests(ctx context.Context) (*TieredTestResult, error) {
 	return t.RunTieredTestsWithForce(ctx, false)
@@ -665,7 +1082,7 @@ func (t *Tester) RunTieredTests(ctx context.Context) (*TieredTestResult, error)
 
 // RunTieredTestsWithForce performs tiered connectivity testing with optional forced comprehensive testing
 func (t *Tester) RunTieredTestsWithForce(ctx context.Context, forceComprehensive bool) (*TieredTestResult, error) {
-	startTime := time.Now()
+	startTime := t.clock.Now()
 
 	t.logger.WithField("force_comprehensive", forceComprehensive).Debug("Starting tiered connectivity tests")
 
@@ -673,8 +1090,12 @@ func (t *Tester) RunTieredTestsWithForce(ctx context.Context, forceComprehensive
 		Timestamp: startTime,
 	}
 
-	// Step 1: Always run lightweight tests first
-	lightweightResult, err := t.RunLightweightTests(ctx)
+	// Step 1: Always run lightweight tests first. The checks that failed
+	// carry over, so comprehensive tests depending on them are skipped.
+	failed := make(map[string]bool)
+	lightweightCtx, endLightweight := budget.Stage(ctx, budget.StageLightweight)
+	lightweightResult, err := t.runLightweightTests(lightweightCtx, failed)
+	endLightweight()
 	if err != nil {
 		return nil, fmt.Errorf("lightweight tests failed: %w", err)
 	}
@@ -691,7 +1112,9 @@ func (t *Tester) RunTieredTestsWithForce(ctx context.Context, forceComprehensive
 		}).Debug("Escalating to comprehensive tests")
 
 		// Run comprehensive tests (escalated)
-		comprehensiveResult, err := t.RunComprehensiveTestsEscalated(ctx)
+		escalationCtx, endEscalation := budget.Stage(ctx, budget.StageEscalation)
+		comprehensiveResult, err := t.runComprehensiveTestsWithEscalation(escalationCtx, "lightweight", failed)
+		endEscalation()
 		if err != nil {
 			t.logger.WithError(err).Warn("Comprehensive tests encountered error, using lightweight results")
 			// Fall back to lightweight results if comprehensive tests fail
@@ -712,7 +1135,7 @@ func (t *Tester) RunTieredTestsWithForce(ctx context.Context, forceComprehensive
 		result.ShortCircuited = true
 	}
 
-	result.TotalDuration = time.Since(startTime)
+	result.TotalDuration = t.clock.Since(startTime)
 
 	t.logger.WithFields(logrus.Fields{
 		"strategy":          result.Strategy,
@@ -724,63 +1147,85 @@ func (t *Tester) RunTieredTestsWithForce(ctx context.Context, forceComprehensive
 	return result, nil
 }
 
-// ScheduleTests determines the appropriate testing strategy based on configuration and history
+// ScheduleTests determines the appropriate testing strategy based on the
+// escalation policy and history
 func (t *Tester) ScheduleTests(ctx context.Context, lastResult *TieredTestResult, consecutiveFailures int) (*TieredTestResult, error) {
-	// Test scheduling logic based on failure history and patterns
-	forceComprehensive := false
-
-	// Force comprehensive tests if we've had multiple consecutive failures
-	if consecutiveFailures >= 3 {
-		t.logger.WithField("consecutive_failures", consecutiveFailures).Debug("Forcing comprehensive tests due to consecutive failures")
-		forceComprehensive = true
-	}
-
-	// Force comprehensive tests if the last test was escalated and failed
-	if lastResult != nil && lastResult.Strategy == "escalated_to_comprehensive" && !lastResult.OverallSuccess {
-		t.logger.Debug("Forcing comprehensive tests due to previous escalated failure")
-		forceComprehensive = true
+	forceComprehensive, reason := t.escalation.Escalate(t.clock.Now(), lastResult, consecutiveFailures, t.lastComprehensive)
+	if forceComprehensive {
+		t.logger.WithFields(logrus.Fields{
+			"reason":               reason,
+			"consecutive_failures": consecutiveFailures,
+		}).Debug("Forcing comprehensive tests")
 	}
 
-	// Force comprehensive tests periodically (every 10th test) for validation
-	// This could be enhanced with time-based scheduling
-	if lastResult != nil && consecutiveFailures%10 == 0 {
-		t.logger.Debug("Forcing comprehensive tests for periodic validation")
-		forceComprehensive = true
+	result, err := t.RunTieredTestsWithForce(ctx, forceComprehensive)
+	if result != nil && result.ComprehensiveResult != nil {
+		t.lastComprehensive = t.clock.Now()
 	}
-
-	return t.RunTieredTestsWithForce(ctx, forceComprehensive)
+	return result, err
 }
 
-// GetTestSummary returns a summary of test results for logging and monitoring
-func (t *TieredTestResult) GetTestSummary() map[string]interface{} {
-	summary := map[string]interface{}{
-		"strategy":          t.Strategy,
-		"overall_success":   t.OverallSuccess,
-		"short_circuited":   t.ShortCircuited,
-		"total_duration_ms": t.TotalDuration.Milliseconds(),
-		"timestamp":         t.Timestamp,
+// HealthInputs returns the success rate of each kind of test the result ran,
+// the inputs of its health score. A result without test details reports its
+// overall outcome as the TCP rate.
+func (t *TieredTestResult) HealthInputs() map[string]float64 {
+	rates := make(map[string]float64, 3)
+	if t.LightweightResult != nil && len(t.LightweightResult.TestResults) > 0 {
+		rates[health.InputTCP] = successRate(t.LightweightResult.TestResults)
 	}
-
-	if t.LightweightResult != nil {
-		summary["lightweight"] = map[string]interface{}{
-			"success":       t.LightweightResult.OverallSuccess,
-			"success_count": t.LightweightResult.SuccessCount,
-			"failure_count": t.LightweightResult.FailureCount,
-			"duration_ms":   t.LightweightResult.Duration.Milliseconds(),
+	if t.LightweightResult != nil && len(t.LightweightResult.WireGuardResults) > 0 {
+		rates[health.InputWireGuard] = successRate(t.LightweightResult.WireGuardResults)
+	}
+	if t.LightweightResult != nil && len(t.LightweightResult.CustomResults) > 0 {
+		rates[health.InputCustom] = successRate(t.LightweightResult.CustomResults)
+	}
+	if t.ComprehensiveResult != nil {
+		if len(t.ComprehensiveResult.DNSResults) > 0 {
+			rates[health.InputDNS] = successRate(t.ComprehensiveResult.DNSResults)
+		}
+		if len(t.ComprehensiveResult.HTTPResults) > 0 {
+			rates[health.InputHTTP] = successRate(t.ComprehensiveResult.HTTPResults)
+		}
+		if len(t.ComprehensiveResult.UDPResults) > 0 {
+			rates[health.InputUDP] = successRate(t.ComprehensiveResult.UDPResults)
 		}
 	}
+	if len(rates) == 0 {
+		rates[health.InputTCP] = 0
+		if t.OverallSuccess {
+			rates[health.InputTCP] = 1
+		}
+	}
+	return rates
+}
 
+// Results returns the result of every test the tiered run performed
+func (t *TieredTestResult) Results() []TestResult {
+	var results []TestResult
+	if t.LightweightResult != nil {
+		results = append(results, t.LightweightResult.GatewayResults...)
+		results = append(results, t.LightweightResult.TestResults...)
+		results = append(results, t.LightweightResult.WireGuardResults...)
+		results = append(results, t.LightweightResult.CustomResults...)
+	}
 	if t.ComprehensiveResult != nil {
-		summary["comprehensive"] = map[string]interface{}{
-			"success":        t.ComprehensiveResult.OverallSuccess,
-			"success_count":  t.ComprehensiveResult.SuccessCount,
-			"failure_count":  t.ComprehensiveResult.FailureCount,
-			"dns_tests":      len(t.ComprehensiveResult.DNSResults),
-			"http_tests":     len(t.ComprehensiveResult.HTTPResults),
-			"duration_ms":    t.ComprehensiveResult.Duration.Milliseconds(),
-			"escalated_from": t.ComprehensiveResult.EscalatedFrom,
-		}
+		results = append(results, t.ComprehensiveResult.DNSResults...)
+		results = append(results, t.ComprehensiveResult.HTTPResults...)
+		results = append(results, t.ComprehensiveResult.UDPResults...)
 	}
+	return results
+}
 
-	return summary
+// successRate returns the share of successful results, 0 without results
+func successRate(results []TestResult) float64 {
+	if len(results) == 0 {
+		return 0
+	}
+	successful := 0
+	for _, result := range results {
+		if result.Success {
+			successful++
+		}
+	}
+	return float64(successful) / float64(len(results))
 }
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"sync"
 	"testing"
@@ -12,6 +15,9 @@ import (
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+	"github.com/perezjoseph/mb8600-watchdog/internal/responder"
 	"github.com/sirupsen/logrus"
 )
 
@@ -629,7 +635,7 @@ func TestTieredConnectivityTestingStrategy(t *testing.T) {
 			}
 
 			// Property 12: GetTestSummary should return valid summary
-			summary := result.GetTestSummary()
+			summary := summaryJSON(result)
 			if summary == nil {
 				t.Logf("GetTestSummary should return non-nil summary")
 				return false
@@ -912,7 +918,7 @@ func TestLightweightTestSuccessShortCircuit(t *testing.T) {
 			}
 
 			// Property 11: Test summary should reflect short-circuit behavior
-			summary := tieredResult.GetTestSummary()
+			summary := summaryJSON(tieredResult)
 			if summary == nil {
 				t.Logf("GetTestSummary should return non-nil summary")
 				return false
@@ -1291,7 +1297,7 @@ func TestLightweightTestFailureEscalation(t *testing.T) {
 			}
 
 			// Property 14: Test summary should reflect escalation behavior
-			summary := tieredResult.GetTestSummary()
+			summary := summaryJSON(tieredResult)
 			if summary == nil {
 				t.Logf("GetTestSummary should return non-nil summary")
 				return false
@@ -1442,3 +1448,229 @@ func TestLightweightTestFailureEscalation(t *testing.T) {
 
 	properties.TestingRun(t)
 }
+
+// scriptedDialer succeeds for listed addresses and refuses all others
+type scriptedDialer struct {
+	reachable map[string]bool
+}
+
+func (d scriptedDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.reachable[address] {
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+	return nil, fmt.Errorf("dial %s %s: connection refused", network, address)
+}
+
+// TestLightweightTestsWithInjectedDialer verifies results without real network access
+func TestLightweightTestsWithInjectedDialer(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	tester := NewTesterWithConfig(logger, time.Second, time.Second,
+		[]string{"192.0.2.1", "192.0.2.2"}, nil)
+	tester.SetDialer(scriptedDialer{reachable: map[string]bool{"192.0.2.1:53": true}})
+	tester.retryConfig.MaxAttempts = 1
+
+	result, err := tester.RunLightweightTests(context.Background())
+	if err != nil {
+		t.Fatalf("RunLightweightTests() failed: %v", err)
+	}
+	if result.SuccessCount != 1 || result.FailureCount != 1 || !result.OverallSuccess {
+		t.Errorf("Expected 1/2 reachable servers to count as success, got %+v", result)
+	}
+}
+
+// TestRetryDelaysUseInjectedClock verifies backoff delays wait on the clock, not wall time
+func TestRetryDelaysUseInjectedClock(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tester := NewTesterWithConfig(logger, time.Second, time.Second, []string{"192.0.2.1"}, nil)
+	tester.SetClock(fake)
+	tester.SetDialer(scriptedDialer{})
+
+	resultChan := make(chan TestResult, 1)
+	go func() {
+		resultChan <- tester.testTCPHandshakeWithReliability(context.Background(), "192.0.2.1:53")
+	}()
+
+	// Attempts 2 and 3 wait a jittered delay of at most MaxDelay
+	maxDelay := tester.retryConfig.MaxDelay
+	for i := 0; i < 2; i++ {
+		fake.BlockUntil(1)
+		select {
+		case <-resultChan:
+			t.Fatal("Test completed before retry delays elapsed")
+		default:
+		}
+		fake.Advance(maxDelay)
+	}
+
+	select {
+	case result := <-resultChan:
+		if result.Success || result.RetryCount != 3 {
+			t.Errorf("Expected failure after 3 attempts, got success=%t retries=%d", result.Success, result.RetryCount)
+		}
+		if result.Duration != 2*maxDelay {
+			t.Errorf("Expected duration measured on fake clock (%v), got %v", 2*maxDelay, result.Duration)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Test did not complete after advancing the clock")
+	}
+}
+
+// TestCircuitBreakerCooldownUsesInjectedClock verifies the open circuit resets on the clock
+func TestCircuitBreakerCooldownUsesInjectedClock(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tester := NewTesterWithConfig(logger, time.Second, time.Second, []string{"192.0.2.1"}, nil)
+	tester.SetClock(fake)
+	tester.SetDialer(scriptedDialer{})
+	tester.retryConfig.MaxAttempts = 1
+
+	for i := 0; i < 3; i++ {
+		tester.testTCPHandshakeWithReliability(context.Background(), "192.0.2.1:53")
+	}
+	if result := tester.testTCPHandshakeWithReliability(context.Background(), "192.0.2.1:53"); !result.CircuitOpen {
+		t.Fatal("Expected circuit to open after 3 failures")
+	}
+
+	tester.SetDialer(scriptedDialer{reachable: map[string]bool{"192.0.2.1:53": true}})
+	fake.Advance(29 * time.Second)
+	if result := tester.testTCPHandshakeWithReliability(context.Background(), "192.0.2.1:53"); !result.CircuitOpen {
+		t.Error("Expected circuit to stay open before the 30s cooldown")
+	}
+
+	fake.Advance(time.Second)
+	if result := tester.testTCPHandshakeWithReliability(context.Background(), "192.0.2.1:53"); !result.Success {
+		t.Errorf("Expected half-open probe to succeed after cooldown, got %v", result.Error)
+	}
+}
+
+func TestComprehensiveTestsProbeResponder(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go responder.NewServer([]byte("key"), logger).Serve(ctx, nil, udp, nil)
+
+	tester := NewTesterWithConfig(logger, time.Second, time.Second, []string{udp.LocalAddr().String()}, nil)
+	tester.SetResponder(udp.LocalAddr().String())
+
+	result, err := tester.RunComprehensiveTests(context.Background())
+	if err != nil {
+		t.Fatalf("RunComprehensiveTests() failed: %v", err)
+	}
+	if len(result.DNSResults) != 0 {
+		t.Errorf("Expected no DNS tests with a responder, got %d", len(result.DNSResults))
+	}
+	if len(result.UDPResults) != 1 || !result.UDPResults[0].Success {
+		t.Fatalf("Expected a successful UDP probe, got %+v", result.UDPResults)
+	}
+	if !result.OverallSuccess || result.HealthScore != 100 {
+		t.Errorf("Expected the responder alone to pass the tier, got %+v", result)
+	}
+
+	tiered := &TieredTestResult{ComprehensiveResult: result}
+	if rates := tiered.HealthInputs(); rates[health.InputUDP] != 1 {
+		t.Errorf("Expected the UDP probe as a health input, got %v", rates)
+	}
+}
+
+func TestResponderAnswersAreVerified(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	udp, err := net.ListenPacket("udp", tcp.Addr().String())
+	if err != nil {
+		tcp.Close()
+		t.Skipf("UDP port of the TCP listener is taken: %v", err)
+	}
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go responder.NewServer([]byte("key"), logger).Serve(ctx, tcp, udp, httpListener)
+
+	address := tcp.Addr().String()
+	checkURL := "http://" + httpListener.Addr().String() + responder.CheckPath
+
+	run := func(key string) (*LightweightTestResult, *ComprehensiveTestResult) {
+		tester := NewTesterWithConfig(logger, time.Second, time.Second, []string{address}, []string{checkURL})
+		tester.SetResponder(address)
+		tester.SetResponderKey([]byte(key))
+		lightweight, err := tester.RunLightweightTests(context.Background())
+		if err != nil {
+			t.Fatalf("RunLightweightTests() failed: %v", err)
+		}
+		comprehensive, err := tester.RunComprehensiveTests(context.Background())
+		if err != nil {
+			t.Fatalf("RunComprehensiveTests() failed: %v", err)
+		}
+		return lightweight, comprehensive
+	}
+
+	lightweight, comprehensive := run("key")
+	if !lightweight.OverallSuccess || lightweight.UnverifiedCount != 0 {
+		t.Errorf("Expected the TCP handshake to verify, got %+v", lightweight.TestResults)
+	}
+	if !comprehensive.OverallSuccess || comprehensive.UnverifiedCount != 0 {
+		t.Errorf("Expected the UDP and HTTP probes to verify, got %+v %+v", comprehensive.UDPResults, comprehensive.HTTPResults)
+	}
+
+	lightweight, comprehensive = run("wrong key")
+	if lightweight.OverallSuccess || lightweight.UnverifiedCount != 1 || !lightweight.TestResults[0].Unverified {
+		t.Errorf("Expected the TCP handshake to fail as unverified, got %+v", lightweight.TestResults)
+	}
+	if comprehensive.OverallSuccess || comprehensive.UnverifiedCount != 2 {
+		t.Errorf("Expected the UDP and HTTP probes to fail as unverified, got %+v %+v", comprehensive.UDPResults, comprehensive.HTTPResults)
+	}
+	for _, result := range append(comprehensive.UDPResults, comprehensive.HTTPResults...) {
+		if !result.Unverified || result.Details["error_type"] != "unverified" {
+			t.Errorf("Expected %s to be marked unverified, got %+v", result.TestType, result)
+		}
+	}
+}
+
+func TestHTTPCheckThroughProxy(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	// The target does not resolve, so only the proxy can answer for it
+	target := "http://watchdog-proxy-test.invalid/"
+	tester := NewTesterWithConfig(logger, time.Second, 2*time.Second, nil, []string{target})
+	tester.SetProxy(http.ProxyURL(proxyURL))
+
+	result := tester.testHTTPConnectivity(context.Background(), target)
+	if !result.Success || len(proxied) != 1 || proxied[0] != target {
+		t.Errorf("Expected the check to go through the proxy, got %v (%v)", proxied, result.Error)
+	}
+
+	tester.SetProxy(nil)
+	if result := tester.testHTTPConnectivity(context.Background(), target); result.Success {
+		t.Error("Expected the check to fail without the proxy")
+	}
+}
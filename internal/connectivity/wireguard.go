@@ -0,0 +1,172 @@
+package connectivity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/system"
+	"github.com/sirupsen/logrus"
+)
+
+// TestTypeWireGuard checks a WireGuard tunnel
+const TestTypeWireGuard = "wireguard"
+
+// DefaultWireGuardHandshakeAge is how old the latest handshake may be. A
+// tunnel carrying traffic renews its handshake every two minutes and drops
+// the session after three without one.
+const DefaultWireGuardHandshakeAge = 3 * time.Minute
+
+// WireGuardCheck selects the WireGuard tunnel the lightweight tests check
+type WireGuardCheck struct {
+	// Interface is the WireGuard interface, such as wg0; empty disables the check
+	Interface string
+	// Peer is the public key of the peer to check, empty for the peer with
+	// the latest handshake
+	Peer string
+	// MaxHandshakeAge is how old the peer's latest handshake may be, 0 for
+	// DefaultWireGuardHandshakeAge
+	MaxHandshakeAge time.Duration
+	// Probe is a host:port across the tunnel to open a TCP connection to,
+	// which sends traffic through it; empty checks that traffic arrived
+	// since the previous check instead
+	Probe string
+}
+
+// SetWireGuard makes the lightweight tests check the WireGuard tunnel of
+// check: that its peer completed a handshake recently and that traffic
+// flows over it. An empty interface disables the check.
+func (t *Tester) SetWireGuard(check WireGuardCheck) {
+	if check.MaxHandshakeAge <= 0 {
+		check.MaxHandshakeAge = DefaultWireGuardHandshakeAge
+	}
+	t.wireguardMu.Lock()
+	defer t.wireguardMu.Unlock()
+	t.wireguard = check
+	t.wireguardRx = make(map[string]int64)
+}
+
+// wireguardPeers lists the peers of a WireGuard interface
+func (t *Tester) wireguardPeers(ctx context.Context, iface string) ([]system.WireGuardPeer, error) {
+	commands := system.NewNetworkCommands(system.NewExecutor(t.logger))
+	result, err := commands.GetWireGuardDump(ctx, iface)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		message := strings.TrimSpace(result.Output)
+		if message == "" {
+			message = result.Error
+		}
+		return nil, fmt.Errorf("wg show %s failed: %s", iface, message)
+	}
+	return system.NewParser(runtime.GOOS).ParseWireGuardDump(result.Output)
+}
+
+// runWireGuardTests checks the WireGuard tunnel, returning no results when
+// no tunnel is configured
+func (t *Tester) runWireGuardTests(ctx context.Context) []TestResult {
+	t.wireguardMu.Lock()
+	defer t.wireguardMu.Unlock()
+
+	if t.wireguard.Interface == "" {
+		return nil
+	}
+	return []TestResult{t.testWireGuard(ctx)}
+}
+
+// testWireGuard checks the configured WireGuard tunnel; the caller holds
+// wireguardMu
+func (t *Tester) testWireGuard(ctx context.Context) TestResult {
+	startTime := t.clock.Now()
+	details := map[string]interface{}{"interface": t.wireguard.Interface}
+	err := t.checkWireGuard(ctx, details)
+	result := t.createTestResult(TestTypeWireGuard, t.wireguard.Interface, startTime, err == nil, err, details)
+
+	fields := logrus.Fields{
+		"interface":   t.wireguard.Interface,
+		"success":     result.Success,
+		"duration_ms": result.Duration.Milliseconds(),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	t.logger.WithFields(fields).Debug("WireGuard test completed")
+	return result
+}
+
+// checkWireGuard returns an error unless the peer's latest handshake is
+// recent and traffic flows over the tunnel, adding what it found to details;
+// the caller holds wireguardMu
+func (t *Tester) checkWireGuard(ctx context.Context, details map[string]interface{}) error {
+	check := t.wireguard
+
+	// The probe goes first: its traffic starts a new handshake when the
+	// last one expired on an idle tunnel
+	if check.Probe != "" {
+		details["probe"] = check.Probe
+		probeCtx, cancel := context.WithTimeout(ctx, t.connectionTimeout)
+		conn, err := t.dialer.DialContext(probeCtx, "tcp", check.Probe)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("no traffic over WireGuard to %s: %w", check.Probe, err)
+		}
+		conn.Close()
+	}
+
+	peers, err := t.listWireGuardPeers(ctx, check.Interface)
+	if err != nil {
+		return err
+	}
+	peer, err := selectWireGuardPeer(peers, check.Peer)
+	if err != nil {
+		return fmt.Errorf("WireGuard interface %s: %w", check.Interface, err)
+	}
+	details["peer"] = peer.PublicKey
+	details["endpoint"] = peer.Endpoint
+	details["rx_bytes"] = peer.RxBytes
+	details["tx_bytes"] = peer.TxBytes
+
+	if peer.LatestHandshake.IsZero() {
+		return fmt.Errorf("WireGuard peer %s never completed a handshake", peer.PublicKey)
+	}
+	age := t.clock.Now().Sub(peer.LatestHandshake)
+	details["handshake_age_s"] = int(age.Seconds())
+	if age > check.MaxHandshakeAge {
+		return fmt.Errorf("latest WireGuard handshake with %s was %s ago, more than %s", peer.PublicKey, age.Round(time.Second), check.MaxHandshakeAge)
+	}
+
+	// Without a probe, something must have arrived since the last check
+	previous, seen := t.wireguardRx[peer.PublicKey]
+	t.wireguardRx[peer.PublicKey] = peer.RxBytes
+	if check.Probe == "" && seen && peer.RxBytes <= previous {
+		return fmt.Errorf("no traffic received over WireGuard from %s since the last check", peer.PublicKey)
+	}
+	return nil
+}
+
+// selectWireGuardPeer returns the peer with publicKey, or without one the
+// peer with the latest handshake
+func selectWireGuardPeer(peers []system.WireGuardPeer, publicKey string) (system.WireGuardPeer, error) {
+	if len(peers) == 0 {
+		return system.WireGuardPeer{}, errors.New("no peers")
+	}
+	if publicKey != "" {
+		for _, peer := range peers {
+			if peer.PublicKey == publicKey {
+				return peer, nil
+			}
+		}
+		return system.WireGuardPeer{}, fmt.Errorf("no peer %s", publicKey)
+	}
+	latest := peers[0]
+	for _, peer := range peers[1:] {
+		if peer.LatestHandshake.After(latest.LatestHandshake) {
+			latest = peer
+		}
+	}
+	return latest, nil
+}
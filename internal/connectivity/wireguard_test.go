@@ -0,0 +1,125 @@
+package connectivity
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+	"github.com/perezjoseph/mb8600-watchdog/internal/system"
+	"github.com/sirupsen/logrus"
+)
+
+// newWireGuardTester returns a tester whose WireGuard interface wg0 lists
+// the peers *peers holds at the time of each check
+func newWireGuardTester(t *testing.T, check WireGuardCheck, peers *[]system.WireGuardPeer) (*Tester, *clock.Fake) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	tester := NewTesterWithConfig(logger, time.Second, time.Second, []string{"192.0.2.1"}, nil)
+	tester.SetClock(fake)
+	tester.SetDialer(scriptedDialer{reachable: map[string]bool{"192.0.2.1:53": true, "10.8.0.1:22": true}})
+	tester.retryConfig.MaxAttempts = 1
+	tester.listWireGuardPeers = func(ctx context.Context, iface string) ([]system.WireGuardPeer, error) {
+		if iface != "wg0" {
+			t.Errorf("Expected the peers of wg0, got %s", iface)
+		}
+		return *peers, nil
+	}
+	check.Interface = "wg0"
+	tester.SetWireGuard(check)
+	return tester, fake
+}
+
+func TestWireGuardCheck(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	peers := []system.WireGuardPeer{
+		{PublicKey: "laptopKey="},
+		{PublicKey: "vpsKey=", Endpoint: "203.0.113.7:51820", LatestHandshake: now.Add(-time.Minute), RxBytes: 1000},
+	}
+	tester, fake := newWireGuardTester(t, WireGuardCheck{}, &peers)
+
+	// The peer with the latest handshake is checked; there is no earlier
+	// traffic to compare with yet
+	result := tester.runWireGuardTests(context.Background())
+	if len(result) != 1 || !result[0].Success || result[0].Details["peer"] != "vpsKey=" {
+		t.Fatalf("Expected a recent handshake to pass, got %+v", result)
+	}
+
+	// Nothing arrived since the last check
+	fake.Advance(30 * time.Second)
+	if result := tester.runWireGuardTests(context.Background()); result[0].Success || !strings.Contains(result[0].Error.Error(), "no traffic received") {
+		t.Errorf("Expected no traffic to fail, got %+v", result[0])
+	}
+
+	peers[1].RxBytes = 1500
+	if result := tester.runWireGuardTests(context.Background()); !result[0].Success {
+		t.Errorf("Expected received traffic to pass, got %v", result[0].Error)
+	}
+
+	// The session is gone once the handshake is older than the limit
+	fake.Advance(3 * time.Minute)
+	peers[1].RxBytes = 2000
+	if result := tester.runWireGuardTests(context.Background()); result[0].Success || !strings.Contains(result[0].Error.Error(), "handshake") {
+		t.Errorf("Expected a stale handshake to fail, got %+v", result[0])
+	}
+
+	// A peer that never completed a handshake fails by name
+	tester.SetWireGuard(WireGuardCheck{Interface: "wg0", Peer: "laptopKey="})
+	if result := tester.runWireGuardTests(context.Background()); result[0].Success || !strings.Contains(result[0].Error.Error(), "never completed") {
+		t.Errorf("Expected the idle peer to fail, got %+v", result[0])
+	}
+
+	tester.SetWireGuard(WireGuardCheck{})
+	if result := tester.runWireGuardTests(context.Background()); result != nil {
+		t.Errorf("Expected no WireGuard test without an interface, got %+v", result)
+	}
+}
+
+func TestWireGuardCheckProbe(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	peers := []system.WireGuardPeer{{PublicKey: "vpsKey=", LatestHandshake: now.Add(-time.Minute), RxBytes: 1000}}
+	tester, _ := newWireGuardTester(t, WireGuardCheck{Probe: "10.8.0.1:22"}, &peers)
+
+	// With a probe, unchanged counters are fine: the probe proves traffic flows
+	for i := 0; i < 2; i++ {
+		if result := tester.runWireGuardTests(context.Background()); !result[0].Success {
+			t.Fatalf("Check %d: expected the probe to pass, got %v", i, result[0].Error)
+		}
+	}
+
+	tester.SetWireGuard(WireGuardCheck{Interface: "wg0", Probe: "10.8.0.2:22"})
+	if result := tester.runWireGuardTests(context.Background()); result[0].Success || !strings.Contains(result[0].Error.Error(), "10.8.0.2:22") {
+		t.Errorf("Expected an unreachable probe to fail, got %+v", result[0])
+	}
+}
+
+func TestLightweightTestsScoreWireGuard(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	peers := []system.WireGuardPeer{{PublicKey: "vpsKey=", LatestHandshake: now.Add(-10 * time.Minute)}}
+	tester, _ := newWireGuardTester(t, WireGuardCheck{}, &peers)
+	tester.SetHealthModel(health.NewModel(map[string]float64{health.InputWireGuard: 3}, 60, 50))
+
+	result, err := tester.RunLightweightTests(context.Background())
+	if err != nil {
+		t.Fatalf("RunLightweightTests() failed: %v", err)
+	}
+	if len(result.WireGuardResults) != 1 || result.SuccessCount != 1 || result.FailureCount != 1 {
+		t.Fatalf("Expected the TCP test to pass and the WireGuard test to fail, got %+v", result)
+	}
+	if result.OverallSuccess || result.HealthScore != 25 {
+		t.Errorf("Expected a heavily weighted tunnel that is down to fail the tier with score 25, got %v (%v)", result.OverallSuccess, result.HealthScore)
+	}
+
+	tiered := &TieredTestResult{LightweightResult: result}
+	if rates := tiered.HealthInputs(); rates[health.InputWireGuard] != 0 || rates[health.InputTCP] != 1 {
+		t.Errorf("Expected the WireGuard and TCP rates, got %v", rates)
+	}
+	if summary := tiered.GetTestSummary(); summary.Lightweight.WireGuardTests != 1 {
+		t.Errorf("Expected the summary to count the WireGuard test, got %+v", summary.Lightweight)
+	}
+}
@@ -2,9 +2,11 @@ package diagnostics
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -13,18 +15,13 @@ import (
 
 	"github.com/perezjoseph/mb8600-watchdog/internal/circuitbreaker"
 	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+	"github.com/perezjoseph/mb8600-watchdog/internal/retry"
 	"github.com/perezjoseph/mb8600-watchdog/internal/system"
 	"github.com/sirupsen/logrus"
 )
 
-// RetryConfig defines retry behavior for diagnostics
-type RetryConfig struct {
-	MaxAttempts int
-	BaseDelay   time.Duration
-	MaxDelay    time.Duration
-	Multiplier  float64
-}
-
 // Constants for repeated format strings and test names
 const (
 	TestNameICMPPing = "ICMP Ping - "
@@ -39,22 +36,19 @@ const (
 	ErrEmptyDomain   = "domain name is empty"
 	ErrEmptyServer   = "TCP handshake target server is empty"
 
-	// Success thresholds
-	DNSSuccessThreshold     = 0.5
-	OverallSuccessThreshold = 0.6
-	RebootThreshold         = 0.5
-	PhysicalLayerThreshold  = 0.3
-	NetworkLayerThreshold   = 0.4
-	HighSuccessThreshold    = 0.8
+	// Success thresholds; the reboot decision uses the health score instead
+	DNSSuccessThreshold = 0.5
 )
 
-// DefaultRetryConfig returns a sensible default retry configuration for diagnostics
-func DefaultRetryConfig() RetryConfig {
-	return RetryConfig{
+// DefaultRetryPolicy returns the retry policy of diagnostic tests. Only
+// timeouts are retried; a definite answer such as an unknown domain is not.
+func DefaultRetryPolicy() retry.Policy {
+	return retry.Policy{
 		MaxAttempts: 2, // Conservative for diagnostics
 		BaseDelay:   500 * time.Millisecond,
 		MaxDelay:    3 * time.Second,
 		Multiplier:  2.0,
+		Retryable:   werrors.IsTimeout,
 	}
 }
 
@@ -110,7 +104,16 @@ type Analyzer struct {
 	pingCircuitBreaker *circuitbreaker.Breaker
 	dnsCircuitBreaker  *circuitbreaker.Breaker
 	httpCircuitBreaker *circuitbreaker.Breaker
-	retryConfig        RetryConfig
+	retryConfig        retry.Policy
+	health             *health.Model
+	routingTargets     []string
+	targets            Targets
+	isWireless         func(iface string) bool
+	linkSettings       func(iface string) (system.LinkSettings, error)
+	// tcpPing replaces ICMP pings with TCP connections, see SetCapabilities
+	tcpPing bool
+	// transport carries the HTTP tests, nil for http.DefaultTransport
+	transport http.RoundTripper
 }
 
 // NewAnalyzer creates a new network diagnostics analyzer
@@ -129,7 +132,11 @@ func NewAnalyzer(logger *logrus.Logger, timeout time.Duration) *Analyzer {
 		pingCircuitBreaker: circuitbreaker.New(3, 30*time.Second),
 		dnsCircuitBreaker:  circuitbreaker.New(3, 30*time.Second),
 		httpCircuitBreaker: circuitbreaker.New(3, 30*time.Second),
-		retryConfig:        DefaultRetryConfig(),
+		retryConfig:        DefaultRetryPolicy(),
+		health:             health.DefaultModel(),
+		targets:            DefaultTargets(),
+		isWireless:         system.IsWireless,
+		linkSettings:       system.ReadLinkSettings,
 	}
 }
 
@@ -138,6 +145,20 @@ func (a *Analyzer) SetModemIP(ip string) {
 	a.modemIP = ip
 }
 
+// SetHealthModel replaces the model that scores the layers and decides on
+// a reboot
+func (a *Analyzer) SetHealthModel(m *health.Model) {
+	a.health = m
+}
+
+// SetProxy routes the HTTP tests through proxy, as http.Transport.Proxy
+// does; nil sends them directly
+func (a *Analyzer) SetProxy(proxy func(*http.Request) (*url.URL, error)) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	a.transport = transport
+}
+
 // SetTimeout sets the timeout for diagnostic operations
 func (a *Analyzer) SetTimeout(timeout time.Duration) {
 	a.timeout = timeout
@@ -148,6 +169,16 @@ func (a *Analyzer) SetMaxConcurrentTests(max int) {
 	a.maxConcurrentTests = max
 }
 
+// BreakerStates returns the state of each circuit breaker by test type; it
+// is safe to call while diagnostics run
+func (a *Analyzer) BreakerStates() map[string]string {
+	return map[string]string{
+		"ping": a.pingCircuitBreaker.GetState().String(),
+		"dns":  a.dnsCircuitBreaker.GetState().String(),
+		"http": a.httpCircuitBreaker.GetState().String(),
+	}
+}
+
 // validateAnalyzer performs basic validation checks
 func (a *Analyzer) validateAnalyzer(ctx context.Context) error {
 	if a == nil {
@@ -164,8 +195,9 @@ func (a *Analyzer) validateAnalyzer(ctx context.Context) error {
 
 // RunDiagnostics performs comprehensive network layer testing with concurrent execution
 func (a *Analyzer) RunDiagnostics(ctx context.Context) ([]DiagnosticResult, error) {
-	// Create fresh context with dedicated timeout for diagnostics (ignore inherited context timeouts)
-	diagnosticCtx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	// Bound diagnostics by their own timeout; cancellation and the cycle budget
+	// of the caller still apply
+	diagnosticCtx, cancel := context.WithTimeout(ctx, a.timeout)
 	defer cancel()
 
 	if err := a.validateAnalyzer(diagnosticCtx); err != nil {
@@ -177,9 +209,6 @@ func (a *Analyzer) RunDiagnostics(ctx context.Context) ([]DiagnosticResult, erro
 	var results []DiagnosticResult
 	var mu sync.Mutex
 
-	resultsChan := make(chan []DiagnosticResult, 5)
-	errorChan := make(chan error, 5)
-
 	layers := []struct {
 		name string
 		fn   func(context.Context) []DiagnosticResult
@@ -190,6 +219,15 @@ func (a *Analyzer) RunDiagnostics(ctx context.Context) ([]DiagnosticResult, erro
 		{"Transport", a.testTransportLayer},
 		{"Application", a.testApplicationLayer},
 	}
+	if len(a.routingTargets) > 0 {
+		layers = append(layers, struct {
+			name string
+			fn   func(context.Context) []DiagnosticResult
+		}{"Routing", a.testRouting})
+	}
+
+	resultsChan := make(chan []DiagnosticResult, len(layers))
+	errorChan := make(chan error, len(layers))
 
 	var wg sync.WaitGroup
 	for _, layer := range layers {
@@ -292,6 +330,13 @@ func (a *Analyzer) testPhysicalLayer(ctx context.Context) []DiagnosticResult {
 	result := a.testInterfaceStatus(ctx)
 	results = append(results, result)
 
+	// Test the link of the interface facing the modem
+	if iface := a.defaultInterface(ctx); a.isWireless(iface) {
+		results = append(results, a.testWirelessLink(ctx, iface)...)
+	} else if iface != "" {
+		results = append(results, a.testEthernetLink(ctx, iface)...)
+	}
+
 	return results
 }
 
@@ -646,6 +691,16 @@ func (a *Analyzer) testRoutingTable(ctx context.Context) DiagnosticResult {
 	}
 }
 
+// defaultInterface returns the interface of the default route, which faces
+// the modem, or an empty string when it cannot be determined
+func (a *Analyzer) defaultInterface(ctx context.Context) string {
+	routes, err := a.networkCommands.GetRoutingTable(ctx)
+	if err != nil || !routes.Success {
+		return ""
+	}
+	return a.parser.ParseDefaultInterface(routes.Output)
+}
+
 // parseRoutingTable parses the output of 'ip route show' command
 func (a *Analyzer) parseRoutingTable(output string) ([]string, string) {
 	var routes []string
@@ -665,22 +720,13 @@ func (a *Analyzer) parseRoutingTable(output string) ([]string, string) {
 	return routes, defaultRoute
 }
 
-// testICMPConnectivity tests ICMP connectivity to various targets
+// testICMPConnectivity tests ICMP connectivity to the modem and the ping targets
 func (a *Analyzer) testICMPConnectivity(ctx context.Context) []DiagnosticResult {
 	var results []DiagnosticResult
 
-	// Define test targets
-	targets := []struct {
-		name string
-		host string
-	}{
-		{"Gateway", a.modemIP},
-		{"Google DNS", "8.8.8.8"},
-		{"Cloudflare DNS", "1.1.1.1"},
-	}
-
-	for _, target := range targets {
-		result := a.testPing(ctx, target.name, target.host)
+	results = append(results, a.testPing(ctx, "Gateway", a.modemIP))
+	for _, host := range a.targets.Ping {
+		result := a.testPing(ctx, host, host)
 		results = append(results, result)
 	}
 
@@ -689,6 +735,10 @@ func (a *Analyzer) testICMPConnectivity(ctx context.Context) []DiagnosticResult
 
 // testPing performs a ping test to a specific target
 func (a *Analyzer) testPing(ctx context.Context, name, host string) DiagnosticResult {
+	if a.tcpPing {
+		return a.testTCPPing(ctx, name, host)
+	}
+
 	startTime := time.Now()
 	var lastErr error
 	circuitOpen := false
@@ -708,7 +758,7 @@ func (a *Analyzer) testPing(ctx context.Context, name, host string) DiagnosticRe
 		return nil
 	})
 
-	if err != nil && strings.Contains(err.Error(), "circuit breaker is open") {
+	if errors.Is(err, werrors.ErrCircuitOpen) {
 		circuitOpen = true
 		lastErr = err
 	} else if err != nil {
@@ -791,16 +841,26 @@ func (a *Analyzer) parsePingOutput(output string) (packetLoss, avgTime string) {
 	return packetLoss, avgTime
 }
 
+// AnalysisVersion is the version of the AnalysisResult JSON layout. It only
+// changes when a key is renamed or removed, or its meaning changes.
+const AnalysisVersion = 1
+
 // AnalysisResult represents the result of diagnostic analysis
 type AnalysisResult struct {
+	Version            int                   `json:"version"`
+	CycleID            string                `json:"cycle_id,omitempty"` // the monitoring cycle that ran the diagnostics, if any
 	OverallSuccessRate float64               `json:"overall_success_rate"`
+	HealthScore        float64               `json:"health_score"` // weighted score of the layer success rates, 0-100
 	TotalTests         int                   `json:"total_tests"`
 	SuccessfulTests    int                   `json:"successful_tests"`
 	LayerStatistics    map[string]LayerStats `json:"layer_statistics"`
 	Recommendations    []string              `json:"recommendations"`
 	ShouldReboot       bool                  `json:"should_reboot"`
 	FailurePatterns    []FailurePattern      `json:"failure_patterns"`
-	Timestamp          time.Time             `json:"timestamp"`
+	// Cause classifies the failure when diagnostics pinned it down: CauseLocalWiFi,
+	// CauseISPRouting or CauseLineDown
+	Cause     string    `json:"cause,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // LayerStats represents statistics for a specific network layer
@@ -831,6 +891,7 @@ func (a *Analyzer) PerformDetailedAnalysis(results []DiagnosticResult) AnalysisR
 
 	if len(results) == 0 {
 		return AnalysisResult{
+			Version:            AnalysisVersion,
 			OverallSuccessRate: 0.0,
 			TotalTests:         0,
 			SuccessfulTests:    0,
@@ -858,25 +919,37 @@ func (a *Analyzer) PerformDetailedAnalysis(results []DiagnosticResult) AnalysisR
 	// Detect failure patterns
 	failurePatterns := a.detectFailurePatterns(results, layerStats)
 
+	// Weigh the layers into one health score
+	score := a.health.Score(layerRates(layerStats))
+
 	// Generate recommendations
-	recommendations := a.generateRecommendations(layerStats, failurePatterns, overallSuccessRate)
+	recommendations := a.generateRecommendations(layerStats, failurePatterns, overallSuccessRate, score)
 
 	// Determine if reboot is necessary
-	shouldReboot := a.determineRebootNecessity(layerStats, failurePatterns, overallSuccessRate)
+	shouldReboot := a.determineRebootNecessity(failurePatterns, score)
+	cause, _, _ := classifyRouting(results)
+	if wirelessDegraded(results) {
+		cause = CauseLocalWiFi
+	}
 
 	analysis := AnalysisResult{
+		Version:            AnalysisVersion,
 		OverallSuccessRate: overallSuccessRate,
+		HealthScore:        score,
 		TotalTests:         totalTests,
 		SuccessfulTests:    successfulTests,
 		LayerStatistics:    layerStats,
 		Recommendations:    recommendations,
 		ShouldReboot:       shouldReboot,
 		FailurePatterns:    failurePatterns,
+		Cause:              cause,
 		Timestamp:          time.Now(),
 	}
 
 	a.logger.WithFields(logrus.Fields{
+		"cause":                cause,
 		"overall_success_rate": overallSuccessRate,
+		"health_score":         score,
 		"should_reboot":        shouldReboot,
 		"failure_patterns":     len(failurePatterns),
 		"recommendations":      len(recommendations),
@@ -1003,7 +1076,45 @@ func (a *Analyzer) detectFailurePatterns(results []DiagnosticResult, layerStats
 		})
 	}
 
-	// Pattern 7: High latency issues
+	// Pattern 7: Degraded Wi-Fi link of the watchdog host
+	if wirelessDegraded(results) {
+		patterns = append(patterns, FailurePattern{
+			Pattern:     CauseLocalWiFi,
+			Description: "The watchdog host's Wi-Fi link is down or degraded",
+			Layers:      []string{"Physical"},
+			Severity:    "high",
+		})
+	}
+
+	// Pattern 8: Degraded Ethernet speed or duplex towards the modem
+	if warning, ok := linkNegotiationWarning(results); ok {
+		patterns = append(patterns, FailurePattern{
+			Pattern:     PatternLinkNegotiation,
+			Description: warning,
+			Layers:      []string{"Physical"},
+			Severity:    "medium",
+		})
+	}
+
+	// Pattern 9: Upstream routing, when the routing probe ran
+	switch cause, reachable, unreachable := classifyRouting(results); cause {
+	case CauseISPRouting:
+		patterns = append(patterns, FailurePattern{
+			Pattern:     CauseISPRouting,
+			Description: fmt.Sprintf("%d of %d upstream networks unreachable while others respond, indicating an ISP routing incident", unreachable, reachable+unreachable),
+			Layers:      []string{"Transport"},
+			Severity:    "high",
+		})
+	case CauseLineDown:
+		patterns = append(patterns, FailurePattern{
+			Pattern:     CauseLineDown,
+			Description: "No upstream network is reachable, indicating the line is down",
+			Layers:      []string{"Transport"},
+			Severity:    "critical",
+		})
+	}
+
+	// Pattern 10: High latency issues
 	highLatencyLayers := []string{}
 	for layerName, stats := range layerStats {
 		// Consider high latency if average duration > 5 seconds
@@ -1024,9 +1135,16 @@ func (a *Analyzer) detectFailurePatterns(results []DiagnosticResult, layerStats
 }
 
 // generateRecommendations generates actionable recommendations based on analysis
-func (a *Analyzer) generateRecommendations(layerStats map[string]LayerStats, patterns []FailurePattern, overallSuccessRate float64) []string {
+func (a *Analyzer) generateRecommendations(layerStats map[string]LayerStats, patterns []FailurePattern, overallSuccessRate, score float64) []string {
 	var recommendations []string
 
+	// A degraded link negotiation is worth fixing even while the network works
+	for _, pattern := range patterns {
+		if pattern.Pattern == PatternLinkNegotiation {
+			recommendations = append(recommendations, "Ethernet link to the modem is degraded: "+pattern.Description)
+		}
+	}
+
 	// Overall health assessment
 	if overallSuccessRate > 0.9 {
 		recommendations = append(recommendations, "Network appears healthy - consider monitoring before taking action")
@@ -1055,6 +1173,12 @@ func (a *Analyzer) generateRecommendations(layerStats map[string]LayerStats, pat
 			recommendations = append(recommendations, "Multiple network layers affected - immediate modem reboot recommended")
 		case "high_latency":
 			recommendations = append(recommendations, "High network latency detected - monitor performance")
+		case CauseLocalWiFi:
+			recommendations = append(recommendations, "The watchdog host's Wi-Fi link is degraded - move it closer to the access point or use a wired connection")
+		case CauseISPRouting:
+			recommendations = append(recommendations, "Upstream routing incident at the ISP - a modem reboot is unlikely to help, check the ISP status page")
+		case CauseLineDown:
+			recommendations = append(recommendations, "No upstream network reachable - check the line and modem signal")
 		}
 	}
 
@@ -1067,8 +1191,9 @@ func (a *Analyzer) generateRecommendations(layerStats map[string]LayerStats, pat
 		recommendations = append(recommendations, "Severe network issues - immediate intervention required")
 	}
 
-	// Default recommendation if no specific patterns detected but success rate is low
-	if len(recommendations) == 0 && overallSuccessRate < 0.6 {
+	// Default recommendation if no specific patterns detected but the health
+	// score is degraded
+	if len(recommendations) == 0 && a.health.Status(score) != health.Healthy {
 		recommendations = append(recommendations, "Multiple connectivity issues detected - modem reboot recommended")
 	}
 
@@ -1076,7 +1201,20 @@ func (a *Analyzer) generateRecommendations(layerStats map[string]LayerStats, pat
 }
 
 // determineRebootNecessity determines if a modem reboot is necessary
-func (a *Analyzer) determineRebootNecessity(layerStats map[string]LayerStats, patterns []FailurePattern, overallSuccessRate float64) bool {
+func (a *Analyzer) determineRebootNecessity(patterns []FailurePattern, score float64) bool {
+	// Rebooting the modem cannot fix the host's own Wi-Fi or routing inside
+	// the ISP's network
+	for _, pattern := range patterns {
+		switch pattern.Pattern {
+		case CauseLocalWiFi:
+			a.logger.Info("Reboot not recommended: The watchdog host's Wi-Fi link is degraded")
+			return false
+		case CauseISPRouting:
+			a.logger.Info("Reboot not recommended: Upstream routing incident at the ISP")
+			return false
+		}
+	}
+
 	// High-priority reboot conditions
 	for _, pattern := range patterns {
 		switch pattern.Pattern {
@@ -1091,37 +1229,33 @@ func (a *Analyzer) determineRebootNecessity(layerStats map[string]LayerStats, pa
 		}
 	}
 
-	// Network layer specific conditions
-	if networkStats, exists := layerStats["Network"]; exists {
-		if networkStats.SuccessRate < 0.4 {
-			a.logger.Info("Reboot recommended: Network layer success rate below 40%")
-			return true
-		}
-	}
-
-	// Overall success rate conditions
-	if overallSuccessRate < 0.5 {
-		a.logger.Info("Reboot recommended: Overall success rate below 50%")
+	// Otherwise the weighted health score of all layers decides
+	if a.health.ShouldReboot(score) {
+		a.logger.WithField("health_score", score).Info("Reboot recommended: Health score below the reboot cutoff")
 		return true
 	}
 
-	// Physical layer issues that might be resolved by reboot
-	if physicalStats, exists := layerStats["Physical"]; exists {
-		if physicalStats.SuccessRate < 0.3 {
-			a.logger.Info("Reboot recommended: Severe physical layer issues")
-			return true
-		}
-	}
+	a.logger.WithField("health_score", score).Info("Reboot not recommended: Health score above the reboot cutoff")
+	return false
+}
 
-	// Don't reboot if success rate is high
-	if overallSuccessRate > 0.8 {
-		a.logger.Info("Reboot not recommended: High overall success rate")
-		return false
+// layerRates returns the success rate of each layer keyed by its health
+// score input
+func layerRates(layerStats map[string]LayerStats) map[string]float64 {
+	inputs := map[string]string{
+		PhysicalLayer.String():     health.InputPhysical,
+		DataLinkLayer.String():     health.InputDataLink,
+		NetworkLayerLevel.String(): health.InputNetwork,
+		TransportLayer.String():    health.InputTransport,
+		ApplicationLayer.String():  health.InputApplication,
+	}
+	rates := make(map[string]float64, len(layerStats))
+	for layer, stats := range layerStats {
+		if input, ok := inputs[layer]; ok {
+			rates[input] = stats.SuccessRate
+		}
 	}
-
-	// Default: don't reboot for moderate issues
-	a.logger.Info("Reboot not recommended: Issues present but not severe enough")
-	return false
+	return rates
 }
 
 // contains checks if a slice contains a specific string
@@ -1138,27 +1272,12 @@ func contains(slice []string, item string) bool {
 func (a *Analyzer) testTransportLayer(ctx context.Context) []DiagnosticResult {
 	a.logger.Debug("Testing Transport Layer")
 
-	// Define test targets
-	tcpTargets := []struct {
-		name string
-		host string
-		port int
-	}{
-		{"HTTP", "8.8.8.8", 80},
-		{"HTTPS", "8.8.8.8", 443},
-		{"DNS", "8.8.8.8", 53},
-	}
-
 	// Run TCP tests concurrently
-	return a.runConcurrentTCPTests(ctx, tcpTargets)
+	return a.runConcurrentTCPTests(ctx, a.tcpTargets())
 }
 
 // runConcurrentTCPTests runs multiple TCP connection tests concurrently
-func (a *Analyzer) runConcurrentTCPTests(ctx context.Context, targets []struct {
-	name string
-	host string
-	port int
-}) []DiagnosticResult {
+func (a *Analyzer) runConcurrentTCPTests(ctx context.Context, targets []tcpTarget) []DiagnosticResult {
 	var results []DiagnosticResult
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -1168,11 +1287,7 @@ func (a *Analyzer) runConcurrentTCPTests(ctx context.Context, targets []struct {
 
 	for _, target := range targets {
 		wg.Add(1)
-		go func(t struct {
-			name string
-			host string
-			port int
-		}) {
+		go func(t tcpTarget) {
 			defer wg.Done()
 
 			// Acquire semaphore
@@ -1249,10 +1364,9 @@ func (a *Analyzer) testApplicationLayer(ctx context.Context) []DiagnosticResult
 	return results
 }
 
-// testDNSResolution tests DNS resolution for various domains
+// testDNSResolution tests DNS resolution of the DNS targets
 func (a *Analyzer) testDNSResolution(ctx context.Context) []DiagnosticResult {
-	domains := []string{"google.com", "cloudflare.com", "github.com"}
-	return a.runConcurrentDNSTests(ctx, domains)
+	return a.runConcurrentDNSTests(ctx, a.targets.DNS)
 }
 
 // runConcurrentDNSTests runs multiple DNS lookup tests concurrently
@@ -1306,15 +1420,31 @@ func (a *Analyzer) testDNSLookup(ctx context.Context, domain string) DiagnosticR
 
 	// Execute with circuit breaker protection
 	err := a.dnsCircuitBreaker.Execute(func() error {
-		// Create context with timeout
-		lookupCtx, cancel := context.WithTimeout(ctx, a.timeout)
-		defer cancel()
-
-		// Perform DNS lookup
+		// Perform DNS lookup, retrying lookups that timed out
 		resolver := &net.Resolver{}
-		ips, lookupErr := resolver.LookupIPAddr(lookupCtx, domain)
+		var ips []net.IPAddr
+		_, lookupErr := retry.Do(ctx, a.retryConfig, func(ctx context.Context) error {
+			lookupCtx, cancel := context.WithTimeout(ctx, a.timeout)
+			defer cancel()
+
+			var err error
+			ips, err = resolver.LookupIPAddr(lookupCtx, domain)
+			return err
+		}, func(attempt retry.Attempt) {
+			if attempt.Delay > 0 {
+				a.logger.WithFields(logrus.Fields{
+					"domain":   domain,
+					"attempt":  attempt.Number,
+					"delay_ms": attempt.Delay.Milliseconds(),
+				}).WithError(attempt.Err).Debug("DNS lookup timed out, retrying")
+			}
+		})
 		if lookupErr != nil {
-			return fmt.Errorf("DNS lookup failed for domain %s: %w", domain, lookupErr)
+			err := fmt.Errorf("DNS lookup failed for domain %s: %w", domain, lookupErr)
+			if werrors.IsTimeout(lookupErr) {
+				return werrors.Mark(err, werrors.ErrDNSTimeout)
+			}
+			return err
 		}
 
 		if len(ips) == 0 {
@@ -1335,7 +1465,7 @@ func (a *Analyzer) testDNSLookup(ctx context.Context, domain string) DiagnosticR
 		return nil
 	})
 
-	if err != nil && strings.Contains(err.Error(), "circuit breaker is open") {
+	if errors.Is(err, werrors.ErrCircuitOpen) {
 		circuitOpen = true
 		lastErr = err
 	} else if err != nil {
@@ -1371,15 +1501,9 @@ func (a *Analyzer) testDNSLookup(ctx context.Context, domain string) DiagnosticR
 	}
 }
 
-// testHTTPConnectivity tests HTTP connectivity to various URLs
+// testHTTPConnectivity tests HTTP connectivity to the HTTP targets
 func (a *Analyzer) testHTTPConnectivity(ctx context.Context) []DiagnosticResult {
-	urls := []string{
-		"http://httpbin.org/get",
-		"https://www.google.com",
-		"https://api.github.com",
-	}
-
-	return a.runConcurrentHTTPTests(ctx, urls)
+	return a.runConcurrentHTTPTests(ctx, a.targets.HTTP)
 }
 
 // runConcurrentHTTPTests runs multiple HTTP request tests concurrently
@@ -1423,7 +1547,8 @@ func (a *Analyzer) testHTTPRequest(ctx context.Context, url string) DiagnosticRe
 	err := a.httpCircuitBreaker.Execute(func() error {
 		// Create HTTP client with timeout
 		client := &http.Client{
-			Timeout: a.timeout,
+			Timeout:   a.timeout,
+			Transport: a.transport,
 		}
 
 		// Create request with context
@@ -1447,7 +1572,7 @@ func (a *Analyzer) testHTTPRequest(ctx context.Context, url string) DiagnosticRe
 		return nil
 	})
 
-	if err != nil && strings.Contains(err.Error(), "circuit breaker is open") {
+	if errors.Is(err, werrors.ErrCircuitOpen) {
 		circuitOpen = true
 		lastErr = err
 	} else if err != nil {
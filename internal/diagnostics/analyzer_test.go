@@ -394,6 +394,9 @@ func TestPerformDetailedAnalysis(t *testing.T) {
 	}
 
 	analysis := analyzer.PerformDetailedAnalysis(results)
+	if analysis.Version != AnalysisVersion {
+		t.Errorf("Expected analysis version %d, got %d", AnalysisVersion, analysis.Version)
+	}
 
 	// Check basic statistics
 	if analysis.TotalTests != 3 {
@@ -561,7 +564,7 @@ func TestDetermineRebootNecessity(t *testing.T) {
 		"Network":  {SuccessRate: 0.9},
 	}
 	patterns1 := []FailurePattern{}
-	shouldReboot1 := analyzer.determineRebootNecessity(layerStats1, patterns1, 0.95)
+	shouldReboot1 := analyzer.determineRebootNecessity(patterns1, analyzer.health.Score(layerRates(layerStats1)))
 
 	if shouldReboot1 {
 		t.Error("Should not recommend reboot for high success rate")
@@ -577,21 +580,30 @@ func TestDetermineRebootNecessity(t *testing.T) {
 			Layers:  []string{"Network"},
 		},
 	}
-	shouldReboot2 := analyzer.determineRebootNecessity(layerStats2, patterns2, 0.3)
+	shouldReboot2 := analyzer.determineRebootNecessity(patterns2, analyzer.health.Score(layerRates(layerStats2)))
 
 	if !shouldReboot2 {
 		t.Error("Should recommend reboot for complete network layer failure")
 	}
 
-	// Test case 3: Low overall success rate - should reboot
+	// Test case 3: Health score below the reboot cutoff - should reboot
 	layerStats3 := map[string]LayerStats{
 		"Network": {SuccessRate: 0.4},
 	}
 	patterns3 := []FailurePattern{}
-	shouldReboot3 := analyzer.determineRebootNecessity(layerStats3, patterns3, 0.4)
+	shouldReboot3 := analyzer.determineRebootNecessity(patterns3, analyzer.health.Score(layerRates(layerStats3)))
 
 	if !shouldReboot3 {
-		t.Error("Should recommend reboot for low overall success rate")
+		t.Error("Should recommend reboot for a health score below the reboot cutoff")
+	}
+
+	// Test case 4: A failing light layer does not outweigh a healthy network layer
+	layerStats4 := map[string]LayerStats{
+		"Physical": {SuccessRate: 0.0},
+		"Network":  {SuccessRate: 1.0},
+	}
+	if analyzer.determineRebootNecessity(nil, analyzer.health.Score(layerRates(layerStats4))) {
+		t.Error("Should not recommend reboot while the weighted health score is above the cutoff")
 	}
 }
 
@@ -669,7 +681,7 @@ func TestGenerateRecommendations(t *testing.T) {
 		"Network":  {SuccessRate: 0.95},
 	}
 	patterns1 := []FailurePattern{}
-	recommendations1 := analyzer.generateRecommendations(layerStats1, patterns1, 0.95)
+	recommendations1 := analyzer.generateRecommendations(layerStats1, patterns1, 0.95, 95)
 
 	if len(recommendations1) == 0 {
 		t.Error("Expected at least one recommendation for high success rate")
@@ -690,7 +702,7 @@ func TestGenerateRecommendations(t *testing.T) {
 			Layers:  []string{"Network"},
 		},
 	}
-	recommendations2 := analyzer.generateRecommendations(layerStats2, patterns2, 0.3)
+	recommendations2 := analyzer.generateRecommendations(layerStats2, patterns2, 0.3, 30)
 
 	found := false
 	for _, rec := range recommendations2 {
@@ -708,7 +720,7 @@ func TestGenerateRecommendations(t *testing.T) {
 		"Network": {SuccessRate: 0.5},
 	}
 	patterns3 := []FailurePattern{}
-	recommendations3 := analyzer.generateRecommendations(layerStats3, patterns3, 0.5)
+	recommendations3 := analyzer.generateRecommendations(layerStats3, patterns3, 0.5, 50)
 
 	found = false
 	for _, rec := range recommendations3 {
@@ -0,0 +1,57 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/system"
+)
+
+// tcpPingPorts are tried in order when ICMP is unavailable; web ports are
+// open on the modem and on the public DNS resolvers alike
+var tcpPingPorts = []string{"443", "80"}
+
+// SetCapabilities selects check implementations the host supports; without
+// any way to send ICMP, pings fall back to TCP connections
+func (a *Analyzer) SetCapabilities(caps system.Capabilities) {
+	a.networkCommands.SetCapabilities(caps)
+	a.tcpPing = caps.PingMethod() == system.PingMethodTCP
+}
+
+// testTCPPing checks reachability of host with TCP connections. A refused
+// connection still proves the host answered, so it counts as reachable.
+func (a *Analyzer) testTCPPing(ctx context.Context, name, host string) DiagnosticResult {
+	startTime := time.Now()
+	details := map[string]interface{}{
+		"target": host,
+		"method": system.PingMethodTCP,
+	}
+
+	var lastErr error
+	for _, port := range tcpPingPorts {
+		connStart := time.Now()
+		dialer := &net.Dialer{Timeout: a.timeout}
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+		if conn != nil {
+			conn.Close()
+		}
+		if err == nil || errors.Is(err, syscall.ECONNREFUSED) {
+			details["port"] = port
+			details["average_time"] = float64(time.Since(connStart)) / float64(time.Millisecond)
+			details["packet_loss"] = 0.0
+			return createDiagnosticResult(NetworkLayerLevel, TestNameICMPPing+name, true, time.Since(startTime), details, nil)
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	details["packet_loss"] = 100.0
+	return createDiagnosticResult(NetworkLayerLevel, TestNameICMPPing+name, false, time.Since(startTime), details,
+		fmt.Errorf("host %s unreachable over TCP: %w", host, lastErr))
+}
@@ -0,0 +1,74 @@
+package diagnostics
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/system"
+	"github.com/sirupsen/logrus"
+)
+
+// withTCPPingPorts points the TCP ping at test ports
+func withTCPPingPorts(t *testing.T, ports ...string) {
+	t.Helper()
+	original := tcpPingPorts
+	tcpPingPorts = ports
+	t.Cleanup(func() { tcpPingPorts = original })
+}
+
+func TestTCPPingFallback(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	analyzer := NewAnalyzer(logger, time.Second)
+	analyzer.SetCapabilities(system.Capabilities{})
+
+	withTCPPingPorts(t, port)
+	result := analyzer.testPing(context.Background(), "Local", "127.0.0.1")
+	if !result.Success {
+		t.Fatalf("Expected TCP ping to succeed, got %v", result.Error)
+	}
+	if result.Details["method"] != system.PingMethodTCP || result.TestName != TestNameICMPPing+"Local" {
+		t.Errorf("Expected a TCP ping under the ICMP ping name, got %+v", result)
+	}
+}
+
+func TestTCPPingRefusedIsReachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	analyzer := NewAnalyzer(logger, time.Second)
+
+	withTCPPingPorts(t, port)
+	if result := analyzer.testTCPPing(context.Background(), "Local", "127.0.0.1"); !result.Success {
+		t.Errorf("Expected a refused connection to prove reachability, got %v", result.Error)
+	}
+}
+
+func TestSetCapabilitiesKeepsICMP(t *testing.T) {
+	logger := logrus.New()
+	analyzer := NewAnalyzer(logger, time.Second)
+
+	analyzer.SetCapabilities(system.Capabilities{ICMPDatagram: true})
+	if analyzer.tcpPing {
+		t.Error("Expected ICMP pings when ICMP sockets are available")
+	}
+	analyzer.SetCapabilities(system.Capabilities{Commands: map[string]bool{"ping": true}})
+	if analyzer.tcpPing {
+		t.Error("Expected the ping command to be used when installed")
+	}
+}
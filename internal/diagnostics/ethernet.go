@@ -0,0 +1,91 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/system"
+	"github.com/sirupsen/logrus"
+)
+
+// TestNameEthernetLink is the name of the Ethernet speed and duplex result
+const TestNameEthernetLink = "Ethernet Link"
+
+// PatternLinkNegotiation is reported when the interface facing the modem
+// negotiated a degraded speed or duplex
+const PatternLinkNegotiation = "link_negotiation_degraded"
+
+// EthernetMinSpeed is the slowest link speed in Mb/s not reported as
+// degraded; gigabit modems falling back to 100 Mb/s usually have a bad cable
+const EthernetMinSpeed = 1000
+
+// testEthernetLink checks the negotiated speed and duplex of iface. A slow or
+// half-duplex link still carries traffic, so it passes with a warning rather
+// than failing the physical layer. It returns nil when the settings cannot
+// be read.
+func (a *Analyzer) testEthernetLink(ctx context.Context, iface string) []DiagnosticResult {
+	startTime := time.Now()
+
+	settings, err := a.linkSettings(iface)
+	if err != nil {
+		// Fall back to ethtool where sysfs is not available
+		result, cmdErr := a.networkCommands.GetEthtool(ctx, iface)
+		if cmdErr != nil || !result.Success {
+			a.logger.WithError(err).WithField("interface", iface).Debug("Cannot read Ethernet link settings, skipping link test")
+			return nil
+		}
+		if settings, err = a.parser.ParseEthtool(result.Output); err != nil {
+			a.logger.WithError(err).WithField("interface", iface).Debug("Cannot parse Ethernet link settings, skipping link test")
+			return nil
+		}
+		settings.Interface = iface
+	}
+
+	result := evaluateEthernetLink(settings, time.Since(startTime))
+	if warning, ok := result.Details["warning"]; ok {
+		a.logger.WithFields(logrus.Fields{
+			"interface":  iface,
+			"speed_mbps": settings.SpeedMbps,
+			"duplex":     settings.Duplex,
+		}).Warn(warning)
+	}
+	return []DiagnosticResult{result}
+}
+
+// evaluateEthernetLink judges the negotiated link settings; a link without
+// carrier fails, a degraded one passes with a warning in its details
+func evaluateEthernetLink(settings system.LinkSettings, duration time.Duration) DiagnosticResult {
+	details := map[string]interface{}{
+		"interface":  settings.Interface,
+		"speed_mbps": settings.SpeedMbps,
+		"duplex":     settings.Duplex,
+	}
+
+	if settings.SpeedMbps == 0 && (settings.Duplex == "" || settings.Duplex == "unknown") {
+		return createDiagnosticResult(PhysicalLayer, TestNameEthernetLink, false, duration, details,
+			fmt.Errorf("no Ethernet link on %s", settings.Interface))
+	}
+
+	switch {
+	case settings.Duplex == "half":
+		details["warning"] = fmt.Sprintf("%s negotiated half duplex at %d Mb/s, expect collisions and poor throughput - check the cable and port settings", settings.Interface, settings.SpeedMbps)
+	case settings.SpeedMbps > 0 && settings.SpeedMbps < EthernetMinSpeed:
+		details["warning"] = fmt.Sprintf("%s negotiated only %d Mb/s - a damaged cable or one with missing pairs often forces 100 Mb/s", settings.Interface, settings.SpeedMbps)
+	}
+
+	return createDiagnosticResult(PhysicalLayer, TestNameEthernetLink, true, duration, details, nil)
+}
+
+// linkNegotiationWarning returns the warning of the Ethernet link result, if any
+func linkNegotiationWarning(results []DiagnosticResult) (string, bool) {
+	for _, result := range results {
+		if result.TestName != TestNameEthernetLink {
+			continue
+		}
+		if warning, ok := result.Details["warning"].(string); ok {
+			return warning, true
+		}
+	}
+	return "", false
+}
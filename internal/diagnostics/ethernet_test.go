@@ -0,0 +1,85 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/system"
+	"github.com/sirupsen/logrus"
+)
+
+func TestEvaluateEthernetLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings system.LinkSettings
+		success  bool
+		warning  string
+	}{
+		{"gigabit full duplex", system.LinkSettings{Interface: "eth0", SpeedMbps: 1000, Duplex: "full"}, true, ""},
+		{"2.5 gigabit", system.LinkSettings{Interface: "eth0", SpeedMbps: 2500, Duplex: "full"}, true, ""},
+		{"100 full duplex", system.LinkSettings{Interface: "eth0", SpeedMbps: 100, Duplex: "full"}, true, "100 Mb/s"},
+		{"100 half duplex", system.LinkSettings{Interface: "eth0", SpeedMbps: 100, Duplex: "half"}, true, "half duplex"},
+		{"no link", system.LinkSettings{Interface: "eth0", Duplex: "unknown"}, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := evaluateEthernetLink(tt.settings, time.Millisecond)
+			if result.Success != tt.success {
+				t.Errorf("Expected success=%v, got %v (%v)", tt.success, result.Success, result.Error)
+			}
+			warning, _ := result.Details["warning"].(string)
+			if tt.warning == "" && warning != "" {
+				t.Errorf("Unexpected warning %q", warning)
+			}
+			if !strings.Contains(warning, tt.warning) {
+				t.Errorf("Expected a warning mentioning %q, got %q", tt.warning, warning)
+			}
+		})
+	}
+}
+
+func TestEthernetLinkNegotiationPattern(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	analyzer := NewAnalyzer(logger, 5*time.Second)
+	analyzer.linkSettings = func(iface string) (system.LinkSettings, error) {
+		return system.LinkSettings{Interface: iface, SpeedMbps: 100, Duplex: "half"}, nil
+	}
+
+	results := analyzer.testEthernetLink(context.Background(), "eth0")
+	if len(results) != 1 || !results[0].Success {
+		t.Fatalf("Expected a passing link result with a warning, got %+v", results)
+	}
+	for i := 0; i < 9; i++ {
+		results = append(results, createDiagnosticResult(ApplicationLayer, fmt.Sprintf("%s%d", TestNameHTTPReq, i), true, time.Millisecond, nil, nil))
+	}
+
+	analysis := analyzer.PerformDetailedAnalysis(results)
+	found := false
+	for _, pattern := range analysis.FailurePatterns {
+		if pattern.Pattern == PatternLinkNegotiation {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the link negotiation pattern, got %+v", analysis.FailurePatterns)
+	}
+	if analysis.ShouldReboot {
+		t.Error("A slow link alone should not trigger a reboot")
+	}
+
+	// The recommendation survives an otherwise healthy network
+	recommended := false
+	for _, recommendation := range analysis.Recommendations {
+		if strings.Contains(recommendation, "half duplex") {
+			recommended = true
+		}
+	}
+	if !recommended {
+		t.Errorf("Expected a recommendation about the link, got %v", analysis.Recommendations)
+	}
+}
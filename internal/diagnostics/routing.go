@@ -0,0 +1,117 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TestNameRoutingProbe prefixes the results of the routing health probe
+const TestNameRoutingProbe = "Routing Probe - "
+
+// Failure causes reported by the analysis
+const (
+	// CauseISPRouting means some upstream networks are reachable and others
+	// are not, which points at a routing incident at the ISP rather than at
+	// the line or the modem
+	CauseISPRouting = "isp_routing_incident"
+	// CauseLineDown means none of the upstream networks are reachable
+	CauseLineDown = "line_down"
+)
+
+// DefaultRoutingTargets are anycast services run by independent networks,
+// so an outage of one provider does not look like a routing incident
+var DefaultRoutingTargets = []string{
+	"1.1.1.1:443",        // Cloudflare
+	"8.8.8.8:443",        // Google
+	"9.9.9.9:443",        // Quad9
+	"208.67.222.222:443", // OpenDNS (Cisco)
+	"94.140.14.14:443",   // AdGuard
+}
+
+// SetRoutingTargets enables the routing health probe against targets, given
+// as host:port; an empty list disables it
+func (a *Analyzer) SetRoutingTargets(targets []string) {
+	a.routingTargets = targets
+}
+
+// testRouting tests reachability of the routing targets
+func (a *Analyzer) testRouting(ctx context.Context) []DiagnosticResult {
+	a.logger.Debug("Testing upstream routing")
+
+	var results []DiagnosticResult
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	semaphore := make(chan struct{}, a.maxConcurrentTests)
+	for _, target := range a.routingTargets {
+		wg.Add(1)
+		go func(target string) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result := a.testRoutingTarget(ctx, target)
+
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(target)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// testRoutingTarget opens a TCP connection to one routing target
+func (a *Analyzer) testRoutingTarget(ctx context.Context, target string) DiagnosticResult {
+	startTime := time.Now()
+
+	connCtx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{Timeout: a.timeout}
+	conn, err := dialer.DialContext(connCtx, "tcp", target)
+	duration := time.Since(startTime)
+	if conn != nil {
+		conn.Close()
+	}
+
+	var resultErr error
+	if err != nil {
+		resultErr = fmt.Errorf("routing target %s unreachable: %w", target, err)
+	}
+
+	return createDiagnosticResult(TransportLayer, TestNameRoutingProbe+target, err == nil, duration,
+		map[string]interface{}{"target": target, "reachable": err == nil}, resultErr)
+}
+
+// classifyRouting compares the routing probe results; it returns the cause
+// they point at, or an empty string when the probe did not run or every
+// target was reachable
+func classifyRouting(results []DiagnosticResult) (string, int, int) {
+	reachable, unreachable := 0, 0
+	for _, result := range results {
+		if !strings.HasPrefix(result.TestName, TestNameRoutingProbe) {
+			continue
+		}
+		if result.Success {
+			reachable++
+		} else {
+			unreachable++
+		}
+	}
+
+	switch {
+	case unreachable == 0:
+		return "", reachable, unreachable
+	case reachable == 0:
+		return CauseLineDown, reachable, unreachable
+	default:
+		return CauseISPRouting, reachable, unreachable
+	}
+}
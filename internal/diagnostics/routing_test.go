@@ -0,0 +1,117 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// routingResults builds routing probe results, one per entry of reachable
+func routingResults(reachable ...bool) []DiagnosticResult {
+	var results []DiagnosticResult
+	for i, ok := range reachable {
+		target := fmt.Sprintf("192.0.2.%d:443", i+1)
+		results = append(results, createDiagnosticResult(TransportLayer, TestNameRoutingProbe+target, ok, time.Millisecond, nil, nil))
+	}
+	return results
+}
+
+func TestRoutingProbe(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	analyzer := NewAnalyzer(logger, 2*time.Second)
+	analyzer.SetRoutingTargets([]string{listener.Addr().String(), closedAddr})
+
+	results := analyzer.testRouting(context.Background())
+	if len(results) != 2 {
+		t.Fatalf("Expected a result per target, got %d", len(results))
+	}
+	for _, result := range results {
+		if want := result.Details["target"] == listener.Addr().String(); result.Success != want {
+			t.Errorf("Unexpected result for %v: %v (%v)", result.Details["target"], result.Success, result.Error)
+		}
+	}
+
+	if cause, reachable, unreachable := classifyRouting(results); cause != CauseISPRouting || reachable != 1 || unreachable != 1 {
+		t.Errorf("Expected an ISP routing incident, got %q (%d/%d)", cause, reachable, unreachable)
+	}
+}
+
+func TestRoutingClassification(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	analyzer := NewAnalyzer(logger, 5*time.Second)
+
+	// Network tests failing would normally trigger a reboot
+	networkFailures := []DiagnosticResult{
+		createDiagnosticResult(NetworkLayerLevel, TestNameICMPPing+"Google", false, time.Second, nil, nil),
+		createDiagnosticResult(NetworkLayerLevel, TestNameICMPPing+"Cloudflare", false, time.Second, nil, nil),
+	}
+
+	tests := []struct {
+		name       string
+		reachable  []bool
+		cause      string
+		noReboot   bool
+		hasPattern bool
+	}{
+		{"probe disabled", nil, "", false, false},
+		{"all reachable", []bool{true, true, true}, "", false, false},
+		{"isp routing incident", []bool{true, false, true, false}, CauseISPRouting, true, true},
+		{"line down", []bool{false, false, false}, CauseLineDown, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := append(append([]DiagnosticResult{}, networkFailures...), routingResults(tt.reachable...)...)
+			analysis := analyzer.PerformDetailedAnalysis(results)
+
+			if analysis.Cause != tt.cause {
+				t.Errorf("Expected cause %q, got %q", tt.cause, analysis.Cause)
+			}
+			if tt.noReboot && analysis.ShouldReboot {
+				t.Error("A routing incident at the ISP should not trigger a reboot")
+			}
+			if !tt.noReboot && !analysis.ShouldReboot {
+				t.Error("Expected the network failures to trigger a reboot")
+			}
+
+			found := false
+			for _, pattern := range analysis.FailurePatterns {
+				if pattern.Pattern == tt.cause {
+					found = true
+				}
+			}
+			if found != tt.hasPattern {
+				t.Errorf("Expected pattern %q present=%v, got %+v", tt.cause, tt.hasPattern, analysis.FailurePatterns)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package diagnostics
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// Targets are the hosts the diagnostics probe beyond the modem
+type Targets struct {
+	// Ping holds hosts pinged after the modem
+	Ping []string
+	// TCP holds host:port addresses connected to
+	TCP []string
+	// DNS holds domains resolved
+	DNS []string
+	// HTTP holds URLs requested
+	HTTP []string
+}
+
+// DefaultTargets are public services probed when no targets are set
+func DefaultTargets() Targets {
+	return Targets{
+		Ping: []string{"8.8.8.8", "1.1.1.1"},
+		TCP:  []string{"8.8.8.8:80", "8.8.8.8:443", "8.8.8.8:53"},
+		DNS:  []string{"google.com", "cloudflare.com", "github.com"},
+		HTTP: []string{"http://httpbin.org/get", "https://www.google.com", "https://api.github.com"},
+	}
+}
+
+// TargetsFor derives targets from the hosts of the connectivity checks. Ping
+// hosts are pinged and connected to on port 53; HTTP hosts are requested,
+// connected to on their port and, unless given as an IP address, resolved.
+func TargetsFor(pingHosts, httpHosts []string) Targets {
+	targets := Targets{
+		Ping: append([]string(nil), pingHosts...),
+		HTTP: append([]string(nil), httpHosts...),
+	}
+	for _, host := range pingHosts {
+		targets.TCP = append(targets.TCP, net.JoinHostPort(host, "53"))
+	}
+	for _, rawURL := range httpHosts {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+		targets.TCP = append(targets.TCP, net.JoinHostPort(u.Hostname(), port))
+		if net.ParseIP(u.Hostname()) == nil {
+			targets.DNS = append(targets.DNS, u.Hostname())
+		}
+	}
+	return targets
+}
+
+// TargetsForResponder derives targets from a self-hosted responder at
+// address, and its HTTP check at checkURL when set, so diagnostics probe no
+// public service. A responder given by name is resolved as well.
+func TargetsForResponder(address, checkURL string) Targets {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	targets := Targets{Ping: []string{host}, TCP: []string{address}}
+	if net.ParseIP(host) == nil {
+		targets.DNS = []string{host}
+	}
+	if checkURL != "" {
+		targets.HTTP = []string{checkURL}
+	}
+	return targets
+}
+
+// SetTargets replaces the hosts the diagnostics probe; an empty list skips
+// the tests of its kind
+func (a *Analyzer) SetTargets(targets Targets) {
+	a.targets = targets
+}
+
+// Targets returns the hosts the diagnostics probe
+func (a *Analyzer) Targets() Targets {
+	return a.targets
+}
+
+// tcpTarget is a TCP connection test
+type tcpTarget struct {
+	name string
+	host string
+	port int
+}
+
+// tcpTargets parses the TCP targets, skipping those that are not host:port
+func (a *Analyzer) tcpTargets() []tcpTarget {
+	targets := make([]tcpTarget, 0, len(a.targets.TCP))
+	for _, address := range a.targets.TCP {
+		host, portStr, err := net.SplitHostPort(address)
+		port, portErr := strconv.Atoi(portStr)
+		if err != nil || portErr != nil || host == "" {
+			a.logger.WithField("target", address).Warn("Skipping invalid TCP diagnostic target, expected host:port")
+			continue
+		}
+		targets = append(targets, tcpTarget{name: address, host: host, port: port})
+	}
+	return targets
+}
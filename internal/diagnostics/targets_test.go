@@ -0,0 +1,65 @@
+package diagnostics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTargetsFor(t *testing.T) {
+	targets := TargetsFor(
+		[]string{"223.5.5.5", "119.29.29.29"},
+		[]string{"https://www.baidu.com", "http://192.0.2.10:8080/health"},
+	)
+
+	expected := Targets{
+		Ping: []string{"223.5.5.5", "119.29.29.29"},
+		TCP:  []string{"223.5.5.5:53", "119.29.29.29:53", "www.baidu.com:443", "192.0.2.10:8080"},
+		DNS:  []string{"www.baidu.com"},
+		HTTP: []string{"https://www.baidu.com", "http://192.0.2.10:8080/health"},
+	}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Errorf("TargetsFor() = %+v, want %+v", targets, expected)
+	}
+}
+
+func TestTargetsForResponder(t *testing.T) {
+	targets := TargetsForResponder("vps.example.net:8600", "http://vps.example.net:8601/check")
+	expected := Targets{
+		Ping: []string{"vps.example.net"},
+		TCP:  []string{"vps.example.net:8600"},
+		DNS:  []string{"vps.example.net"},
+		HTTP: []string{"http://vps.example.net:8601/check"},
+	}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Errorf("TargetsForResponder() = %+v, want %+v", targets, expected)
+	}
+
+	targets = TargetsForResponder("192.0.2.20:8600", "")
+	if len(targets.DNS) != 0 || len(targets.HTTP) != 0 {
+		t.Errorf("Expected no DNS or HTTP targets for a responder address without a check URL, got %+v", targets)
+	}
+}
+
+func TestSetTargetsReplacesEveryKind(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	analyzer := NewAnalyzer(logger, time.Second)
+
+	analyzer.SetTargets(Targets{Ping: []string{"223.5.5.5"}, TCP: []string{"223.5.5.5:53", "no-port", "[::1]:dns"}})
+
+	targets := analyzer.Targets()
+	if !reflect.DeepEqual(targets.Ping, []string{"223.5.5.5"}) {
+		t.Errorf("Expected the configured ping targets, got %v", targets.Ping)
+	}
+	if len(targets.DNS) != 0 || len(targets.HTTP) != 0 {
+		t.Errorf("Expected the public DNS and HTTP targets to be dropped, got %v, %v", targets.DNS, targets.HTTP)
+	}
+
+	tcp := analyzer.tcpTargets()
+	if len(tcp) != 1 || tcp[0] != (tcpTarget{name: "223.5.5.5:53", host: "223.5.5.5", port: 53}) {
+		t.Errorf("Expected only the valid TCP target, got %+v", tcp)
+	}
+}
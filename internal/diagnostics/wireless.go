@@ -0,0 +1,93 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/system"
+)
+
+// TestNameWirelessLink is the name of the Wi-Fi link quality result
+const TestNameWirelessLink = "Wireless Link"
+
+// CauseLocalWiFi means the watchdog host's own Wi-Fi link is down or too
+// poor to carry traffic, so the modem is not at fault
+const CauseLocalWiFi = "local_wifi"
+
+// Wi-Fi link quality limits; a link beyond any of them is degraded
+const (
+	// WirelessMinSignal is the weakest usable signal in dBm
+	WirelessMinSignal = -75
+	// WirelessMaxTxFailureRate is the highest share of failed transmissions
+	WirelessMaxTxFailureRate = 0.1
+	// WirelessMaxInactive is the longest silence from the access point
+	WirelessMaxInactive = 10 * time.Second
+)
+
+// testWirelessLink checks the Wi-Fi link quality of iface. It returns nil
+// when the link cannot be read, e.g. without iw, so a missing tool is never
+// mistaken for a bad link.
+func (a *Analyzer) testWirelessLink(ctx context.Context, iface string) []DiagnosticResult {
+	startTime := time.Now()
+
+	result, err := a.networkCommands.GetStationDump(ctx, iface)
+	if err != nil || !result.Success {
+		a.logger.WithField("interface", iface).Debug("Cannot read Wi-Fi link quality, skipping wireless link test")
+		return nil
+	}
+
+	stations, _ := a.parser.ParseStationDump(result.Output)
+	return []DiagnosticResult{evaluateWirelessLink(iface, stations, time.Since(startTime))}
+}
+
+// WirelessLinkDegraded checks only the host's Wi-Fi link, so a reboot can be
+// ruled out cheaply even when full diagnostics are disabled
+func (a *Analyzer) WirelessLinkDegraded(ctx context.Context) (DiagnosticResult, bool) {
+	iface := a.defaultInterface(ctx)
+	if !a.isWireless(iface) {
+		return DiagnosticResult{}, false
+	}
+	results := a.testWirelessLink(ctx, iface)
+	if len(results) == 0 || results[0].Success {
+		return DiagnosticResult{}, false
+	}
+	return results[0], true
+}
+
+// evaluateWirelessLink judges the link of iface to its access point
+func evaluateWirelessLink(iface string, stations []system.WirelessStation, duration time.Duration) DiagnosticResult {
+	details := map[string]interface{}{"interface": iface}
+	if len(stations) == 0 {
+		return createDiagnosticResult(PhysicalLayer, TestNameWirelessLink, false, duration, details,
+			fmt.Errorf("wireless interface %s is not associated with an access point", iface))
+	}
+
+	station := stations[0]
+	details["access_point"] = station.MAC
+	details["signal_dbm"] = station.Signal
+	details["tx_bitrate_mbps"] = station.TxBitrate
+	details["tx_failure_rate"] = station.TxFailureRate()
+
+	var err error
+	switch {
+	case station.Signal != 0 && station.Signal < WirelessMinSignal:
+		err = fmt.Errorf("weak Wi-Fi signal on %s: %d dBm (minimum %d dBm)", iface, station.Signal, WirelessMinSignal)
+	case station.TxFailureRate() > WirelessMaxTxFailureRate:
+		err = fmt.Errorf("%.0f%% of Wi-Fi transmissions on %s failed", station.TxFailureRate()*100, iface)
+	case time.Duration(station.InactiveMs)*time.Millisecond > WirelessMaxInactive:
+		err = fmt.Errorf("no frames from the access point on %s for %v", iface, time.Duration(station.InactiveMs)*time.Millisecond)
+	}
+
+	return createDiagnosticResult(PhysicalLayer, TestNameWirelessLink, err == nil, duration, details, err)
+}
+
+// wirelessDegraded reports whether the results include a failed Wi-Fi link check
+func wirelessDegraded(results []DiagnosticResult) bool {
+	for _, result := range results {
+		if result.TestName == TestNameWirelessLink && !result.Success {
+			return true
+		}
+	}
+	return false
+}
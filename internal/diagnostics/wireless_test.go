@@ -0,0 +1,74 @@
+package diagnostics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/system"
+	"github.com/sirupsen/logrus"
+)
+
+func TestEvaluateWirelessLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		stations []system.WirelessStation
+		success  bool
+	}{
+		{"not associated", nil, false},
+		{"good link", []system.WirelessStation{{MAC: "aa", Signal: -55, TxPackets: 1000, TxFailed: 3, InactiveMs: 40}}, true},
+		{"weak signal", []system.WirelessStation{{MAC: "aa", Signal: -82, TxPackets: 1000}}, false},
+		{"failing transmissions", []system.WirelessStation{{MAC: "aa", Signal: -60, TxPackets: 1000, TxFailed: 200}}, false},
+		{"silent access point", []system.WirelessStation{{MAC: "aa", Signal: -60, InactiveMs: 30000}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := evaluateWirelessLink("wlan0", tt.stations, time.Millisecond)
+			if result.Success != tt.success {
+				t.Errorf("Expected success=%v, got %v (%v)", tt.success, result.Success, result.Error)
+			}
+			if result.Layer != PhysicalLayer || result.TestName != TestNameWirelessLink {
+				t.Errorf("Unexpected result %s/%s", result.Layer, result.TestName)
+			}
+			if !tt.success && result.Error == nil {
+				t.Error("Expected an error explaining the degraded link")
+			}
+		})
+	}
+}
+
+func TestWirelessLinkSkippedOnWiredHosts(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	analyzer := NewAnalyzer(logger, 5*time.Second)
+	analyzer.isWireless = func(string) bool { return false }
+
+	if result, degraded := analyzer.WirelessLinkDegraded(context.Background()); degraded {
+		t.Errorf("Expected no Wi-Fi check on a wired host, got %+v", result)
+	}
+}
+
+func TestLocalWiFiNeverTriggersReboot(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	analyzer := NewAnalyzer(logger, 5*time.Second)
+
+	// Everything upstream fails, as it does when the host's own Wi-Fi drops
+	results := []DiagnosticResult{
+		evaluateWirelessLink("wlan0", nil, time.Millisecond),
+		createDiagnosticResult(NetworkLayerLevel, TestNameICMPPing+"Gateway", false, time.Second, nil, nil),
+		createDiagnosticResult(NetworkLayerLevel, TestNameICMPPing+"Google DNS", false, time.Second, nil, nil),
+		createDiagnosticResult(TransportLayer, TestNameTCPConn+"HTTPS", false, time.Second, nil, nil),
+		createDiagnosticResult(ApplicationLayer, TestNameDNSRes+"google.com", false, time.Second, nil, nil),
+	}
+	results = append(results, routingResults(false, false)...)
+
+	analysis := analyzer.PerformDetailedAnalysis(results)
+	if analysis.ShouldReboot {
+		t.Error("A degraded Wi-Fi link on the watchdog host should never trigger a modem reboot")
+	}
+	if analysis.Cause != CauseLocalWiFi {
+		t.Errorf("Expected cause %q, got %q", CauseLocalWiFi, analysis.Cause)
+	}
+}
@@ -0,0 +1,100 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeDocker serves the restart endpoint of the Engine API on a unix socket
+type fakeDocker struct {
+	mu        sync.Mutex
+	restarted []string
+	missing   map[string]bool
+}
+
+func (f *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"+apiVersion+"/containers/"), "/restart")
+	if r.Method != http.MethodPost || r.URL.Query().Get("t") != "10" || strings.Contains(name, "/") {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if f.missing[name] {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "No such container: ` + name + `"}`))
+		return
+	}
+	f.mu.Lock()
+	f.restarted = append(f.restarted, name)
+	f.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func startFakeDocker(t *testing.T, fake *fakeDocker) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets are not available: %v", err)
+	}
+	server := httptest.NewUnstartedServer(fake)
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+	return socket
+}
+
+func TestRestart(t *testing.T) {
+	fake := &fakeDocker{missing: map[string]bool{"ghost": true}}
+	client := NewClient(startFakeDocker(t, fake))
+
+	if err := client.Restart(context.Background(), "wireguard", DefaultStopTimeout); err != nil {
+		t.Fatalf("Restart() failed: %v", err)
+	}
+	if len(fake.restarted) != 1 || fake.restarted[0] != "wireguard" {
+		t.Errorf("Unexpected restarts %v", fake.restarted)
+	}
+
+	err := client.Restart(context.Background(), "ghost", DefaultStopTimeout)
+	if err == nil || err.Error() != "container ghost not found" {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestRestartUnreachableSocket(t *testing.T) {
+	client := NewClient(filepath.Join(t.TempDir(), "missing.sock"))
+	if err := client.Restart(context.Background(), "wireguard", DefaultStopTimeout); err == nil {
+		t.Error("Expected an error without a Docker socket")
+	}
+}
+
+func TestRestartAction(t *testing.T) {
+	fake := &fakeDocker{missing: map[string]bool{"ghost": true}}
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	action := NewRestartAction(NewClient(startFakeDocker(t, fake)), []string{"wireguard", "ghost", "ddclient"}, 5*time.Minute, logger)
+
+	if err := action.Recover(context.Background(), time.Minute); err != nil {
+		t.Fatalf("Recover() failed for a short outage: %v", err)
+	}
+	if len(fake.restarted) != 0 {
+		t.Errorf("Short outages should not restart containers, got %v", fake.restarted)
+	}
+
+	err := action.Recover(context.Background(), 10*time.Minute)
+	if err == nil {
+		t.Error("Expected an error for the missing container")
+	}
+	if len(fake.restarted) != 2 || fake.restarted[0] != "wireguard" || fake.restarted[1] != "ddclient" {
+		t.Errorf("Expected the other containers to restart, got %v", fake.restarted)
+	}
+}
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"runtime"
 	"strings"
@@ -191,59 +192,60 @@ func NewDiagnosticError(layer, test, message string, err error) *WatchdogError {
 		WithContext("test", test)
 }
 
+// errorType returns the type of the first WatchdogError in the chain of err
+func errorType(err error) (ErrorType, bool) {
+	var we *WatchdogError
+	if stderrors.As(err, &we) {
+		return we.Type, true
+	}
+	return "", false
+}
+
 // Error classification helpers
 func IsNetworkError(err error) bool {
-	if we, ok := err.(*WatchdogError); ok {
-		return we.Type == NetworkError || we.Type == ConnectivityError || we.Type == DNSError
+	if IsUnreachable(err) {
+		return true
 	}
-	return false
+	t, ok := errorType(err)
+	return ok && (t == NetworkError || t == ConnectivityError || t == DNSError)
 }
 
 func IsTimeoutError(err error) bool {
-	if we, ok := err.(*WatchdogError); ok {
-		return we.Type == TimeoutError
+	if IsTimeout(err) {
+		return true
 	}
-	return false
+	t, ok := errorType(err)
+	return ok && t == TimeoutError
 }
 
 func IsAuthError(err error) bool {
-	if we, ok := err.(*WatchdogError); ok {
-		return we.Type == AuthError || we.Type == AuthzError
+	if stderrors.Is(err, ErrAuthFailed) {
+		return true
 	}
-	return false
+	t, ok := errorType(err)
+	return ok && (t == AuthError || t == AuthzError)
 }
 
 func IsConfigError(err error) bool {
-	if we, ok := err.(*WatchdogError); ok {
-		return we.Type == ConfigError || we.Type == ValidationError
-	}
-	return false
+	t, ok := errorType(err)
+	return ok && (t == ConfigError || t == ValidationError)
 }
 
 func IsSystemError(err error) bool {
-	if we, ok := err.(*WatchdogError); ok {
-		return we.Type == SystemError || we.Type == FileSystemError || we.Type == PermissionError
-	}
-	return false
+	t, ok := errorType(err)
+	return ok && (t == SystemError || t == FileSystemError || t == PermissionError)
 }
 
 func IsRecoverableError(err error) bool {
-	if we, ok := err.(*WatchdogError); ok {
-		// Network, timeout, and some system errors are typically recoverable
-		return we.Type == NetworkError ||
-			we.Type == ConnectivityError ||
-			we.Type == TimeoutError ||
-			we.Type == DNSError
+	// Network, timeout, and some system errors are typically recoverable
+	if IsNetworkError(err) || IsTimeoutError(err) {
+		return true
 	}
 	return false
 }
 
 func IsCriticalError(err error) bool {
-	if we, ok := err.(*WatchdogError); ok {
-		// Configuration, initialization, and permission errors are critical
-		return we.Type == ConfigError ||
-			we.Type == InitializationError ||
-			we.Type == PermissionError
-	}
-	return false
+	// Configuration, initialization, and permission errors are critical
+	t, ok := errorType(err)
+	return ok && (t == ConfigError || t == InitializationError || t == PermissionError)
 }
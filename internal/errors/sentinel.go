@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"os"
+)
+
+// Sentinel errors shared across modules. Decision logic tests for them with
+// errors.Is, so wording changes in wrapped messages do not change behavior.
+var (
+	// ErrModemUnreachable is a request that never got an answer from the
+	// modem web interface
+	ErrModemUnreachable = stderrors.New("modem unreachable")
+	// ErrAuthFailed is a modem login that was refused
+	ErrAuthFailed = stderrors.New("modem authentication failed")
+	// ErrRebootRejected is a reboot command the modem answered with a failure
+	ErrRebootRejected = stderrors.New("modem rejected the reboot")
+	// ErrDNSTimeout is a DNS lookup that ran out of time
+	ErrDNSTimeout = stderrors.New("DNS lookup timed out")
+	// ErrCircuitOpen is an operation refused by an open circuit breaker
+	ErrCircuitOpen = stderrors.New("circuit breaker is open")
+	// ErrUnverifiedResponse is an answer to a check whose signature or nonce
+	// does not match, such as one faked by a transparent proxy
+	ErrUnverifiedResponse = stderrors.New("response could not be verified")
+)
+
+// markedError keeps the message and cause of err while also matching a
+// sentinel
+type markedError struct {
+	err      error
+	sentinel error
+}
+
+// Mark returns err so that errors.Is also matches sentinel; the message and
+// the chain of causes stay those of err
+func Mark(err, sentinel error) error {
+	if err == nil {
+		return nil
+	}
+	return &markedError{err: err, sentinel: sentinel}
+}
+
+// Error returns the message of the marked error
+func (e *markedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the marked error
+func (e *markedError) Unwrap() error {
+	return e.err
+}
+
+// Is matches the sentinel
+func (e *markedError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+// IsTimeout reports whether err is a deadline running out: a context or I/O
+// deadline, a network timeout, or ErrDNSTimeout
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if stderrors.Is(err, context.DeadlineExceeded) || stderrors.Is(err, os.ErrDeadlineExceeded) ||
+		stderrors.Is(err, ErrDNSTimeout) {
+		return true
+	}
+	var netErr net.Error
+	return stderrors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsUnreachable reports whether err is a network failure: ErrModemUnreachable
+// or a failed dial, connection or DNS lookup
+func IsUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if stderrors.Is(err, ErrModemUnreachable) {
+		return true
+	}
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	return stderrors.As(err, &opErr) || stderrors.As(err, &dnsErr)
+}
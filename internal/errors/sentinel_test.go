@@ -0,0 +1,69 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestMark(t *testing.T) {
+	cause := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	err := fmt.Errorf("status request failed: %w", Mark(cause, ErrModemUnreachable))
+
+	if !stderrors.Is(err, ErrModemUnreachable) {
+		t.Error("Expected the marked error to match its sentinel")
+	}
+	var opErr *net.OpError
+	if !stderrors.As(err, &opErr) || opErr != cause {
+		t.Error("Expected the cause to stay reachable")
+	}
+	if err.Error() != "status request failed: "+cause.Error() {
+		t.Errorf("Expected the message of the cause, got %q", err.Error())
+	}
+	if stderrors.Is(err, ErrAuthFailed) {
+		t.Error("Expected the marked error not to match other sentinels")
+	}
+	if Mark(nil, ErrModemUnreachable) != nil {
+		t.Error("Expected marking nil to return nil")
+	}
+}
+
+func TestClassification(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		network     bool
+		timeout     bool
+		auth        bool
+		recoverable bool
+	}{
+		{"unreachable modem", fmt.Errorf("login: %w", ErrModemUnreachable), true, false, false, true},
+		{"refused connection", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true, false, false, true},
+		{"DNS timeout", fmt.Errorf("lookup: %w", ErrDNSTimeout), false, true, false, true},
+		{"deadline", fmt.Errorf("check: %w", context.DeadlineExceeded), false, true, false, true},
+		{"refused login", fmt.Errorf("login: %w", ErrAuthFailed), false, false, true, false},
+		{"wrapped auth error", fmt.Errorf("reboot: %w", NewAuthError("modem", "login", "denied")), false, false, true, false},
+		{"message only", fmt.Errorf("connection timeout during login"), false, false, false, false},
+		{"nil", nil, false, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNetworkError(tt.err); got != tt.network {
+				t.Errorf("IsNetworkError() = %v, want %v", got, tt.network)
+			}
+			if got := IsTimeoutError(tt.err); got != tt.timeout {
+				t.Errorf("IsTimeoutError() = %v, want %v", got, tt.timeout)
+			}
+			if got := IsAuthError(tt.err); got != tt.auth {
+				t.Errorf("IsAuthError() = %v, want %v", got, tt.auth)
+			}
+			if got := IsRecoverableError(tt.err); got != tt.recoverable {
+				t.Errorf("IsRecoverableError() = %v, want %v", got, tt.recoverable)
+			}
+		})
+	}
+}
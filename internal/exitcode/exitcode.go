@@ -0,0 +1,80 @@
+// Package exitcode defines the exit codes of the watchdog's commands, so
+// shell scripts and monitoring wrappers can branch on the result. Codes are
+// numbered by severity: when a command runs into several outcomes, such as
+// a health check that finds the modem unreachable and the internet down, it
+// exits with the highest.
+package exitcode
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Exit codes, from least to most severe
+const (
+	// OK means everything checked is fine
+	OK = 0
+	// Degraded means the connection or the service works with problems,
+	// such as a low health score, a reboot in progress or a tunnel down
+	Degraded = 1
+	// Outage means the internet is down
+	Outage = 2
+	// Config means the configuration, a flag or an argument is invalid
+	Config = 3
+	// ModemUnreachable means the modem did not answer
+	ModemUnreachable = 4
+	// NotRunning means the command needs the running service and it is
+	// not running
+	NotRunning = 5
+	// Permission means a file, socket or capability was not accessible
+	Permission = 6
+	// Failure is any other error
+	Failure = 7
+)
+
+// Error is an error with the exit code it ends the command with
+type Error struct {
+	Code int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap tags err with code; a nil err stays nil
+func Wrap(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Of returns the exit code of err: OK for nil, the code of the outermost
+// Error in its chain, Permission for permission errors and Failure for any
+// other error
+func Of(err error) int {
+	if err == nil {
+		return OK
+	}
+	var tagged *Error
+	if errors.As(err, &tagged) {
+		return tagged.Code
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		return Permission
+	}
+	return Failure
+}
+
+// Worst returns the more severe of two codes
+func Worst(a, b int) int {
+	if b > a {
+		return b
+	}
+	return a
+}
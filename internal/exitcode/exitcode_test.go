@@ -0,0 +1,50 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	permissionErr := &os.PathError{Op: "open", Path: "/run/watchdog.pid", Err: os.ErrPermission}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, OK},
+		{"plain", errors.New("boom"), Failure},
+		{"tagged", Wrap(Config, errors.New("bad flag")), Config},
+		{"wrapped tag", fmt.Errorf("failed to load configuration: %w", Wrap(Config, errors.New("bad file"))), Config},
+		{"outermost tag", Wrap(NotRunning, Wrap(Permission, errors.New("socket"))), NotRunning},
+		{"permission", fmt.Errorf("cannot read PID file: %w", permissionErr), Permission},
+	}
+	for _, tt := range tests {
+		if got := Of(tt.err); got != tt.want {
+			t.Errorf("%s: Of() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWrapKeepsNilAndMessage(t *testing.T) {
+	if Wrap(Outage, nil) != nil {
+		t.Error("Expected Wrap of nil to be nil")
+	}
+	cause := errors.New("connection refused")
+	err := Wrap(ModemUnreachable, cause)
+	if err.Error() != "connection refused" || !errors.Is(err, cause) {
+		t.Errorf("Expected the message and chain of the cause, got %v", err)
+	}
+}
+
+func TestWorst(t *testing.T) {
+	if got := Worst(Outage, ModemUnreachable); got != ModemUnreachable {
+		t.Errorf("Expected %d, got %d", ModemUnreachable, got)
+	}
+	if got := Worst(Degraded, OK); got != Degraded {
+		t.Errorf("Expected %d, got %d", Degraded, got)
+	}
+}
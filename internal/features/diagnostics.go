@@ -0,0 +1,7 @@
+//go:build !nodiagnostics
+
+package features
+
+// Diagnostics reports whether the network diagnostics run before a reboot
+// are built in; -tags nodiagnostics leaves them out
+const Diagnostics = true
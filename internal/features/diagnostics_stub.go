@@ -0,0 +1,6 @@
+//go:build nodiagnostics
+
+package features
+
+// Diagnostics reports whether the network diagnostics are built in
+const Diagnostics = false
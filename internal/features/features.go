@@ -0,0 +1,63 @@
+// Package features lists the optional subsystems of the watchdog. Each one
+// can be left out of the binary with a build tag, such as
+// go build -tags "nohistory nodiagnostics", and switched off at runtime with
+// DISABLED_FEATURES. Code guarded by the constants of a left out subsystem is
+// removed by the compiler.
+package features
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Names of the optional subsystems, as used in DISABLED_FEATURES
+const (
+	NameHistory       = "history"
+	NameNotifications = "notifications"
+	NameDiagnostics   = "diagnostics"
+)
+
+// Names returns the names of every optional subsystem
+func Names() []string {
+	return []string{NameHistory, NameNotifications, NameDiagnostics}
+}
+
+// Compiled reports whether the subsystem name is built into the binary
+func Compiled(name string) bool {
+	switch strings.ToLower(name) {
+	case NameHistory:
+		return History
+	case NameNotifications:
+		return Notifications
+	case NameDiagnostics:
+		return Diagnostics
+	}
+	return false
+}
+
+// CompiledNames returns the names of the subsystems built into the binary
+func CompiledNames() []string {
+	var names []string
+	for _, name := range Names() {
+		if Compiled(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Validate checks that every name is a known subsystem
+func Validate(names []string) error {
+	for _, name := range names {
+		known := false
+		for _, feature := range Names() {
+			if strings.EqualFold(name, feature) {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown feature %q, expected one of %s", name, strings.Join(Names(), ", "))
+		}
+	}
+	return nil
+}
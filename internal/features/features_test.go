@@ -0,0 +1,24 @@
+package features
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]string{"history", "Diagnostics"}); err != nil {
+		t.Errorf("Expected known features to validate, got %v", err)
+	}
+	if err := Validate([]string{"dashboard"}); err == nil {
+		t.Error("Expected an error for an unknown feature")
+	}
+}
+
+func TestCompiledNames(t *testing.T) {
+	names := CompiledNames()
+	for _, name := range names {
+		if !Compiled(name) {
+			t.Errorf("Expected %s to be compiled in", name)
+		}
+	}
+	if Compiled("unknown") {
+		t.Error("Expected an unknown feature not to be compiled in")
+	}
+}
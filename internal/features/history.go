@@ -0,0 +1,7 @@
+//go:build !nohistory
+
+package features
+
+// History reports whether the network event history, such as reboot
+// decisions and public IP changes, is built in; -tags nohistory leaves it out
+const History = true
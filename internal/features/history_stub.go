@@ -0,0 +1,6 @@
+//go:build nohistory
+
+package features
+
+// History reports whether the network event history is built in
+const History = false
@@ -0,0 +1,7 @@
+//go:build !nonotify
+
+package features
+
+// Notifications reports whether notification delivery is built in; -tags
+// nonotify leaves it out
+const Notifications = true
@@ -0,0 +1,6 @@
+//go:build nonotify
+
+package features
+
+// Notifications reports whether notification delivery is built in
+const Notifications = false
@@ -0,0 +1,173 @@
+// Package health combines the success rates of connectivity tests and
+// diagnostics into one score from 0 to 100. Each kind of test weighs in with
+// a configurable weight, and fixed cutoffs on the score decide whether the
+// connection is healthy, degraded or unhealthy enough to reboot the modem.
+package health
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Statuses derived from a score
+const (
+	Healthy   = "HEALTHY"
+	Degraded  = "DEGRADED"
+	Unhealthy = "UNHEALTHY"
+)
+
+// Score inputs: the success rate of a kind of connectivity test or of a
+// diagnostics layer
+const (
+	InputTCP         = "tcp"
+	InputDNS         = "dns"
+	InputHTTP        = "http"
+	InputUDP         = "udp"
+	InputWireGuard   = "wireguard"
+	InputCustom      = "custom" // custom checks, such as those of plugins
+	InputPhysical    = "physical"
+	InputDataLink    = "data_link"
+	InputNetwork     = "network"
+	InputTransport   = "transport"
+	InputApplication = "application"
+	// InputDOCSISTimeouts is the share of the T3 and T4 timeout thresholds
+	// the modem's event log is below
+	InputDOCSISTimeouts = "docsis_timeouts"
+)
+
+// Default cutoffs: a score below DefaultDegradedScore is degraded, one below
+// DefaultRebootScore is unhealthy
+const (
+	DefaultDegradedScore = 60
+	DefaultRebootScore   = 50
+)
+
+// DefaultWeights weigh the network layer most, since its failure is the one a
+// modem reboot most often fixes
+var DefaultWeights = map[string]float64{
+	InputTCP:         1,
+	InputDNS:         1,
+	InputHTTP:        1,
+	InputUDP:         1,
+	InputWireGuard:   1,
+	InputCustom:      1,
+	InputPhysical:    1,
+	InputDataLink:    1,
+	InputNetwork:     3,
+	InputTransport:   2,
+	InputApplication: 2,
+
+	InputDOCSISTimeouts: 1,
+}
+
+// Model scores success rates and classifies the score
+type Model struct {
+	weights  map[string]float64
+	degraded float64
+	reboot   float64
+}
+
+// DefaultModel returns the model with the default weights and cutoffs
+func DefaultModel() *Model {
+	return NewModel(nil, DefaultDegradedScore, DefaultRebootScore)
+}
+
+// NewModel creates a model. weights override the default weight of their
+// inputs; a weight of 0 leaves an input out of the score.
+func NewModel(weights map[string]float64, degraded, reboot float64) *Model {
+	m := &Model{
+		weights:  make(map[string]float64, len(DefaultWeights)),
+		degraded: degraded,
+		reboot:   reboot,
+	}
+	for input, weight := range DefaultWeights {
+		m.weights[input] = weight
+	}
+	for input, weight := range weights {
+		m.weights[input] = weight
+	}
+	return m
+}
+
+// Score returns the weighted mean of rates, each between 0 and 1, scaled to
+// 0–100. Inputs without a weight are ignored; without any weighted input the
+// score is 0.
+func (m *Model) Score(rates map[string]float64) float64 {
+	var sum, total float64
+	for input, rate := range rates {
+		weight := m.weights[input]
+		if weight <= 0 {
+			continue
+		}
+		sum += weight * rate
+		total += weight
+	}
+	if total == 0 {
+		return 0
+	}
+	return 100 * sum / total
+}
+
+// Status classifies score as Healthy, Degraded or Unhealthy
+func (m *Model) Status(score float64) string {
+	switch {
+	case score < m.reboot:
+		return Unhealthy
+	case score < m.degraded:
+		return Degraded
+	default:
+		return Healthy
+	}
+}
+
+// Passing reports whether score is high enough for a check to pass
+func (m *Model) Passing(score float64) bool {
+	return score >= m.reboot
+}
+
+// ShouldReboot reports whether score is low enough to reboot the modem
+func (m *Model) ShouldReboot(score float64) bool {
+	return score < m.reboot
+}
+
+// Cutoffs returns the scores below which the connection is degraded and
+// below which the modem is rebooted
+func (m *Model) Cutoffs() (degraded, reboot float64) {
+	return m.degraded, m.reboot
+}
+
+// Inputs returns the score inputs in sorted order
+func Inputs() []string {
+	inputs := make([]string, 0, len(DefaultWeights))
+	for input := range DefaultWeights {
+		inputs = append(inputs, input)
+	}
+	sort.Strings(inputs)
+	return inputs
+}
+
+// ParseWeights parses weights written as input=weight, such as "network=4"
+func ParseWeights(entries []string) (map[string]float64, error) {
+	weights := make(map[string]float64, len(entries))
+	for _, entry := range entries {
+		input, value, ok := strings.Cut(entry, "=")
+		input = strings.TrimSpace(input)
+		if !ok || input == "" {
+			return nil, fmt.Errorf("invalid health weight %q, expected input=weight", entry)
+		}
+		if _, known := DefaultWeights[input]; !known {
+			return nil, fmt.Errorf("unknown health input %q, must be one of: %s", input, strings.Join(Inputs(), ", "))
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight for health input %s: %w", input, err)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("weight for health input %s must not be negative, got %v", input, weight)
+		}
+		weights[input] = weight
+	}
+	return weights, nil
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"testing"
+)
+
+func TestScoreWeighsInputs(t *testing.T) {
+	m := DefaultModel()
+
+	// The network layer weighs three times the physical layer
+	score := m.Score(map[string]float64{InputPhysical: 0, InputNetwork: 1})
+	if score != 75 {
+		t.Errorf("Score() = %v, want 75", score)
+	}
+	if score := m.Score(map[string]float64{InputTCP: 0.5, "unknown": 0}); score != 50 {
+		t.Errorf("Inputs without a weight should be ignored, got %v", score)
+	}
+	if score := m.Score(nil); score != 0 {
+		t.Errorf("A score without inputs should be 0, got %v", score)
+	}
+
+	m = NewModel(map[string]float64{InputPhysical: 0}, DefaultDegradedScore, DefaultRebootScore)
+	if score := m.Score(map[string]float64{InputPhysical: 0, InputNetwork: 1}); score != 100 {
+		t.Errorf("A zero weight should leave the input out, got %v", score)
+	}
+}
+
+func TestStatusCutoffs(t *testing.T) {
+	m := NewModel(nil, 60, 50)
+	tests := []struct {
+		score   float64
+		status  string
+		passing bool
+	}{
+		{100, Healthy, true},
+		{60, Healthy, true},
+		{59.9, Degraded, true},
+		{50, Degraded, true},
+		{49.9, Unhealthy, false},
+		{0, Unhealthy, false},
+	}
+	for _, tt := range tests {
+		if status := m.Status(tt.score); status != tt.status {
+			t.Errorf("Status(%v) = %s, want %s", tt.score, status, tt.status)
+		}
+		if m.Passing(tt.score) != tt.passing || m.ShouldReboot(tt.score) == tt.passing {
+			t.Errorf("Passing(%v) = %t, want %t", tt.score, m.Passing(tt.score), tt.passing)
+		}
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	weights, err := ParseWeights([]string{"network=4", " http = 0.5 "})
+	if err != nil {
+		t.Fatalf("ParseWeights() failed: %v", err)
+	}
+	if weights[InputNetwork] != 4 || weights[InputHTTP] != 0.5 {
+		t.Errorf("Unexpected weights %v", weights)
+	}
+
+	for _, entry := range []string{"network", "=1", "latency=1", "dns=high", "dns=-1"} {
+		if _, err := ParseWeights([]string{entry}); err == nil {
+			t.Errorf("Expected an error for %q", entry)
+		}
+	}
+}
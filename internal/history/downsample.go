@@ -0,0 +1,125 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// hourlyBucket sums the checks of one hour
+type hourlyBucket struct {
+	checks, failures int
+	totalMS, maxMS   float64
+}
+
+// add adds the check or hourly aggregate event to the bucket
+func (b *hourlyBucket) add(event Event) {
+	if event.Kind == KindCheckHourly {
+		checks := int(number(event.Details["checks"]))
+		b.checks += checks
+		b.failures += int(number(event.Details["failures"]))
+		b.totalMS += number(event.Details["avg_duration_ms"]) * float64(checks)
+		b.maxMS = math.Max(b.maxMS, number(event.Details["max_duration_ms"]))
+		return
+	}
+	duration := number(event.Details["duration_ms"])
+	b.checks++
+	if success, _ := event.Details["success"].(bool); !success {
+		b.failures++
+	}
+	b.totalMS += duration
+	b.maxMS = math.Max(b.maxMS, duration)
+}
+
+// event returns the aggregate of the bucket as an event at start
+func (b *hourlyBucket) event(start time.Time) Event {
+	avg := 0.0
+	if b.checks > 0 {
+		avg = math.Round(b.totalMS / float64(b.checks))
+	}
+	return Event{Time: start, Kind: KindCheckHourly, Details: map[string]interface{}{
+		"checks":          b.checks,
+		"failures":        b.failures,
+		"avg_duration_ms": avg,
+		"max_duration_ms": b.maxMS,
+	}}
+}
+
+// number returns value as a float64, 0 if it is not a number
+func number(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return 0
+}
+
+// downsampled reports whether event is a check result or hourly aggregate
+// recorded before before
+func downsampled(event Event, before time.Time) bool {
+	return (event.Kind == KindCheck || event.Kind == KindCheckHourly) && event.Time.Before(before)
+}
+
+// Downsample replaces the check results at path recorded before the hour
+// of before with one hourly aggregate per hour, keeping every other event
+// as it is. It returns the number of check results replaced. Events held in
+// memory are not touched; a missing history is left alone.
+func Downsample(path string, before time.Time) (int, error) {
+	before = before.Truncate(time.Hour)
+
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	lines, events, err := readFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	buckets := make(map[time.Time]*hourlyBucket)
+	replaced := 0
+	for _, event := range events {
+		if !downsampled(event, before) {
+			continue
+		}
+		start := event.Time.Truncate(time.Hour)
+		if buckets[start] == nil {
+			buckets[start] = &hourlyBucket{}
+		}
+		buckets[start].add(event)
+		if event.Kind == KindCheck {
+			replaced++
+		}
+	}
+	if replaced == 0 {
+		return 0, nil
+	}
+
+	// Each aggregate takes the place of the first event of its hour
+	out := make([][]byte, 0, len(lines))
+	for i, event := range events {
+		if !downsampled(event, before) {
+			out = append(out, lines[i])
+			continue
+		}
+		start := event.Time.Truncate(time.Hour)
+		bucket, ok := buckets[start]
+		if !ok {
+			continue
+		}
+		delete(buckets, start)
+		line, err := json.Marshal(bucket.event(start))
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode history event: %w", err)
+		}
+		out = append(out, line)
+	}
+	if err := rewriteFile(path, out); err != nil {
+		return 0, err
+	}
+	return replaced, nil
+}
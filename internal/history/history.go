@@ -0,0 +1,191 @@
+// Package history keeps a JSON lines log of network events, such as public
+// IP changes, so they can be correlated with outages in reports. Events are
+// only appended; readers filter them by time and kind. Old check results
+// are the exception: Downsample folds them into hourly aggregates.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Event kinds
+const (
+	// KindIPChange records a change of the public IP address
+	KindIPChange = "ip_change"
+	// KindPublicIP records the public IP address observed by a check cycle
+	KindPublicIP = "public_ip"
+	// KindRebootDecision records why a reboot was triggered or skipped
+	KindRebootDecision = "reboot_decision"
+	// KindAnnotation records a note a user attached to an outage
+	KindAnnotation = "annotation"
+	// KindFirmwareChange records a change of the modem firmware version
+	KindFirmwareChange = "firmware_change"
+	// KindCheck records the outcome of a connectivity check
+	KindCheck = "check"
+	// KindCheckHourly records the checks of one hour, replacing their
+	// results once they are older than the raw retention
+	KindCheckHourly = "check_hourly"
+)
+
+// Event is one history record
+type Event struct {
+	Time    time.Time              `json:"time"`
+	Kind    string                 `json:"kind"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// appendMu serializes appends within the process, so concurrent writers do
+// not interleave lines. It also guards held and pending.
+var appendMu sync.Mutex
+
+// held is set while appends are held in memory, and pending holds the lines
+// appended meanwhile by path, oldest first
+var (
+	held    bool
+	pending = make(map[string][][]byte)
+)
+
+// Hold keeps appended events in memory instead of writing them, so a device
+// in low-power mode does not wake its disk for every event. Read still
+// returns them; Flush or Release writes them.
+func Hold() {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+	held = true
+}
+
+// Release writes the held events and makes Append write again
+func Release() error {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+	held = false
+	return flushLocked()
+}
+
+// Flush writes the held events in one go per history, and keeps holding
+// later ones
+func Flush() error {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+	return flushLocked()
+}
+
+// flushLocked writes the pending lines; the caller holds appendMu. Lines
+// that could not be written stay pending.
+func flushLocked() error {
+	var firstErr error
+	for path, lines := range pending {
+		if err := writeLines(path, lines); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		delete(pending, path)
+	}
+	return firstErr
+}
+
+// Append adds event to the history at path, creating the file and its
+// directory if needed. While held, the event is kept in memory instead. An
+// empty path records nothing.
+func Append(path string, event Event) error {
+	if path == "" {
+		return nil
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode history event: %w", err)
+	}
+	line = append(line, '\n')
+
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	if held {
+		pending[path] = append(pending[path], line)
+		return nil
+	}
+	return writeLines(path, [][]byte{line})
+}
+
+// writeLines appends lines to the history at path
+func writeLines(path string, lines [][]byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(bytes.Join(lines, nil)); err != nil {
+		return fmt.Errorf("failed to write history event: %w", err)
+	}
+	return nil
+}
+
+// pendingLines returns a copy of the lines held for path
+func pendingLines(path string) [][]byte {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+	return append([][]byte(nil), pending[path]...)
+}
+
+// Read returns the events at path recorded at or after since, oldest first,
+// including those this process holds in memory. Only events of the given
+// kinds are returned, or all events when no kind is given. A missing history
+// holds no events.
+func Read(path string, since time.Time, kinds ...string) ([]Event, error) {
+	held := bytes.Join(pendingLines(path), nil)
+
+	var input io.Reader = bytes.NewReader(held)
+	file, err := os.Open(path)
+	switch {
+	case err == nil:
+		defer file.Close()
+		input = io.MultiReader(file, input)
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	case len(held) == 0:
+		return nil, nil
+	}
+
+	wanted := make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		wanted[kind] = true
+	}
+
+	var events []Event
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("invalid history event on line %d: %w", line, err)
+		}
+		if event.Time.Before(since) || (len(wanted) > 0 && !wanted[event.Kind]) {
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return events, nil
+}
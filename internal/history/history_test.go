@@ -0,0 +1,216 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "history.jsonl")
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	events := []Event{
+		{Time: start, Kind: KindIPChange, Details: map[string]interface{}{"old_ip": "203.0.113.1", "new_ip": "203.0.113.2"}},
+		{Time: start.Add(time.Hour), Kind: "other"},
+		{Time: start.Add(2 * time.Hour), Kind: KindIPChange, Details: map[string]interface{}{"old_ip": "203.0.113.2", "new_ip": "203.0.113.3"}},
+	}
+	for _, event := range events {
+		if err := Append(path, event); err != nil {
+			t.Fatalf("Append() failed: %v", err)
+		}
+	}
+
+	all, err := Read(path, time.Time{})
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(all))
+	}
+
+	changes, err := Read(path, start.Add(time.Minute), KindIPChange)
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Details["new_ip"] != "203.0.113.3" {
+		t.Errorf("Expected the last IP change only, got %+v", changes)
+	}
+}
+
+func TestReadMissingAndInvalid(t *testing.T) {
+	dir := t.TempDir()
+	events, err := Read(filepath.Join(dir, "missing.jsonl"), time.Time{})
+	if err != nil || len(events) != 0 {
+		t.Errorf("Expected no events for a missing history, got %v, %v", events, err)
+	}
+
+	path := filepath.Join(dir, "history.jsonl")
+	os.WriteFile(path, []byte("{\"kind\": \"ip_change\"}\nnot json\n"), 0644)
+	if _, err := Read(path, time.Time{}); err == nil {
+		t.Error("Expected an error for an invalid line")
+	}
+}
+
+func TestAppendStampsTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	before := time.Now()
+	if err := Append(path, Event{Kind: KindIPChange}); err != nil {
+		t.Fatalf("Append() failed: %v", err)
+	}
+	events, _ := Read(path, time.Time{})
+	if len(events) != 1 || events[0].Time.Before(before) {
+		t.Errorf("Expected the event to be stamped with the current time, got %+v", events)
+	}
+}
+
+func TestHoldKeepsEventsInMemory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "history.jsonl")
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := Append(path, Event{Time: start, Kind: KindIPChange}); err != nil {
+		t.Fatalf("Append() failed: %v", err)
+	}
+
+	Hold()
+	defer Release()
+	info, _ := os.Stat(path)
+	for i := 1; i <= 2; i++ {
+		if err := Append(path, Event{Time: start.Add(time.Duration(i) * time.Hour), Kind: KindPublicIP}); err != nil {
+			t.Fatalf("Append() failed: %v", err)
+		}
+	}
+	if held, _ := os.Stat(path); held.Size() != info.Size() {
+		t.Errorf("Expected held events not to be written, the history grew from %d to %d bytes", info.Size(), held.Size())
+	}
+	if events, err := Read(path, time.Time{}); err != nil || len(events) != 3 || events[2].Kind != KindPublicIP {
+		t.Errorf("Expected Read to include the held events, got %+v (%v)", events, err)
+	}
+
+	if err := Flush(); err != nil {
+		t.Fatalf("Flush() failed: %v", err)
+	}
+	if err := Append(path, Event{Time: start.Add(3 * time.Hour), Kind: KindPublicIP}); err != nil {
+		t.Fatalf("Append() failed: %v", err)
+	}
+	if err := Release(); err != nil {
+		t.Fatalf("Release() failed: %v", err)
+	}
+	events, err := Read(path, time.Time{})
+	if err != nil || len(events) != 4 {
+		t.Fatalf("Expected all 4 events on disk, got %+v (%v)", events, err)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Time.Before(events[i-1].Time) {
+			t.Errorf("Expected the events in order, got %+v", events)
+		}
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	events := []Event{
+		{Time: start, Kind: KindCheck, Details: map[string]interface{}{"success": true, "duration_ms": 100}},
+		{Time: start.Add(10 * time.Minute), Kind: KindIPChange, Details: map[string]interface{}{"new_ip": "203.0.113.2"}},
+		{Time: start.Add(20 * time.Minute), Kind: KindCheck, Details: map[string]interface{}{"success": false, "duration_ms": 300}},
+		{Time: start.Add(70 * time.Minute), Kind: KindCheck, Details: map[string]interface{}{"success": true, "duration_ms": 50}},
+		{Time: start.Add(130 * time.Minute), Kind: KindCheck, Details: map[string]interface{}{"success": true, "duration_ms": 80}},
+	}
+	for _, event := range events {
+		if err := Append(path, event); err != nil {
+			t.Fatalf("Append() failed: %v", err)
+		}
+	}
+
+	// Only the hours before 12:00 are folded, even with a cutoff within it
+	replaced, err := Downsample(path, start.Add(125*time.Minute))
+	if err != nil {
+		t.Fatalf("Downsample() failed: %v", err)
+	}
+	if replaced != 3 {
+		t.Errorf("Expected 3 check results replaced, got %d", replaced)
+	}
+
+	all, err := Read(path, time.Time{})
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	kinds := make([]string, len(all))
+	for i, event := range all {
+		kinds[i] = event.Kind
+	}
+	want := []string{KindCheckHourly, KindIPChange, KindCheckHourly, KindCheck}
+	if len(kinds) != len(want) {
+		t.Fatalf("Expected kinds %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("Expected kinds %v, got %v", want, kinds)
+		}
+	}
+
+	first := all[0]
+	if !first.Time.Equal(start) || first.Details["checks"] != 2.0 || first.Details["failures"] != 1.0 ||
+		first.Details["avg_duration_ms"] != 200.0 || first.Details["max_duration_ms"] != 300.0 {
+		t.Errorf("Unexpected aggregate of the first hour: %v %+v", first.Time, first.Details)
+	}
+
+	// Downsampling again folds the next hour and leaves the aggregates be
+	if err := Append(path, Event{Time: start.Add(150 * time.Minute), Kind: KindCheck, Details: map[string]interface{}{"success": false, "duration_ms": 120}}); err != nil {
+		t.Fatalf("Append() failed: %v", err)
+	}
+	replaced, err = Downsample(path, start.Add(3*time.Hour))
+	if err != nil || replaced != 2 {
+		t.Fatalf("Expected 2 more check results replaced, got %d, %v", replaced, err)
+	}
+	hourly, _ := Read(path, time.Time{}, KindCheckHourly)
+	if len(hourly) != 3 || hourly[2].Details["checks"] != 2.0 || hourly[2].Details["avg_duration_ms"] != 100.0 {
+		t.Errorf("Unexpected aggregates: %+v", hourly)
+	}
+	if replaced, err := Downsample(path, start.Add(3*time.Hour)); err != nil || replaced != 0 {
+		t.Errorf("Expected nothing left to downsample, got %d, %v", replaced, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		event := Event{Time: start.Add(time.Duration(i) * 24 * time.Hour), Kind: KindPublicIP, Details: map[string]interface{}{"ip": "203.0.113.1"}}
+		if err := Append(path, event); err != nil {
+			t.Fatalf("Append() failed: %v", err)
+		}
+	}
+	now := start.Add(10 * 24 * time.Hour)
+
+	if dropped, err := Prune(path, now, Retention{}); err != nil || dropped != 0 {
+		t.Fatalf("Expected nothing pruned without limits, got %d, %v", dropped, err)
+	}
+
+	// Events of the first three days are older than a week
+	if dropped, err := Prune(path, now, Retention{MaxAge: 7 * 24 * time.Hour}); err != nil || dropped != 3 {
+		t.Fatalf("Expected 3 events pruned by age, got %d, %v", dropped, err)
+	}
+	if dropped, err := Prune(path, now, Retention{MaxEvents: 5}); err != nil || dropped != 2 {
+		t.Fatalf("Expected 2 events pruned by count, got %d, %v", dropped, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lineSize := info.Size() / 5
+	if dropped, err := Prune(path, now, Retention{MaxBytes: 2*lineSize + 1}); err != nil || dropped != 3 {
+		t.Fatalf("Expected 3 events pruned by size, got %d, %v", dropped, err)
+	}
+	events, err := Read(path, time.Time{})
+	if err != nil || len(events) != 2 || !events[0].Time.Equal(start.Add(8*24*time.Hour)) {
+		t.Errorf("Expected the last two events kept, got %+v, %v", events, err)
+	}
+
+	if dropped, err := Prune(filepath.Join(t.TempDir(), "missing.jsonl"), now, Retention{MaxEvents: 1}); err != nil || dropped != 0 {
+		t.Errorf("Expected a missing history to be left alone, got %d, %v", dropped, err)
+	}
+}
@@ -0,0 +1,127 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Retention bounds the history file. The oldest events are dropped first;
+// a zero limit does not apply.
+type Retention struct {
+	// MaxAge drops events older than it
+	MaxAge time.Duration
+	// MaxEvents is the number of events kept
+	MaxEvents int
+	// MaxBytes is the size of the file kept
+	MaxBytes int64
+}
+
+// Prune drops the oldest events at path until they fit retention, and
+// rewrites the file in full so the space they took is reclaimed. It returns
+// the number of events dropped. Events held in memory are not touched; a
+// missing history is left alone.
+func Prune(path string, now time.Time, retention Retention) (int, error) {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	lines, events, err := readFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	drop := 0
+	if retention.MaxAge > 0 {
+		cutoff := now.Add(-retention.MaxAge)
+		for drop < len(events) && events[drop].Time.Before(cutoff) {
+			drop++
+		}
+	}
+	if retention.MaxEvents > 0 && len(lines)-drop > retention.MaxEvents {
+		drop = len(lines) - retention.MaxEvents
+	}
+	if retention.MaxBytes > 0 {
+		size := int64(0)
+		for _, line := range lines[drop:] {
+			size += int64(len(line)) + 1
+		}
+		for ; drop < len(lines) && size > retention.MaxBytes; drop++ {
+			size -= int64(len(lines[drop])) + 1
+		}
+	}
+	if drop == 0 {
+		return 0, nil
+	}
+	if err := rewriteFile(path, lines[drop:]); err != nil {
+		return 0, err
+	}
+	return drop, nil
+}
+
+// readFile returns the lines of the history at path with their events,
+// skipping blank lines. A missing history has none. The caller holds
+// appendMu.
+func readFile(path string) ([][]byte, []Event, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var lines [][]byte
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, nil, fmt.Errorf("invalid history event on line %d: %w", line, err)
+		}
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return lines, events, nil
+}
+
+// rewriteFile replaces the history at path with lines, through a temporary
+// file so a crash leaves either the old or the new history. The caller
+// holds appendMu.
+func rewriteFile(path string, lines [][]byte) error {
+	var data bytes.Buffer
+	for _, line := range lines {
+		data.Write(line)
+		data.WriteByte('\n')
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".history-*")
+	if err != nil {
+		return fmt.Errorf("failed to rewrite history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to rewrite history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to rewrite history: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to rewrite history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to rewrite history: %w", err)
+	}
+	return nil
+}
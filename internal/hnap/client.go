@@ -8,17 +8,68 @@ import (
 	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"strings"
 	"time"
 
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	// ErrLoginFailed is returned when the modem rejects the HNAP credentials;
+	// it also matches errors.ErrAuthFailed
+	ErrLoginFailed = werrors.Mark(errors.New("HNAP login failed"), werrors.ErrAuthFailed)
+	// ErrSessionExpired is returned when the modem no longer accepts the
+	// session keys; logging in again renews them
+	ErrSessionExpired = errors.New("authentication expired")
+)
+
+// AccessMethod is a way of logging in to the modem: the URL scheme, and
+// whether the HTML form login precedes the HNAP one. Firmware updates change
+// which methods a modem accepts.
+type AccessMethod struct {
+	Scheme   string
+	HTMLForm bool
+}
+
+// AccessMethods are the methods Login tries, in order, until one works
+var AccessMethods = []AccessMethod{
+	{Scheme: "https", HTMLForm: true},
+	{Scheme: "https", HTMLForm: false},
+	{Scheme: "http", HTMLForm: true},
+	{Scheme: "http", HTMLForm: false},
+}
+
+// String returns the method as scheme+form or scheme+hnap, such as
+// https+form
+func (m AccessMethod) String() string {
+	if m.HTMLForm {
+		return m.Scheme + "+form"
+	}
+	return m.Scheme + "+hnap"
+}
+
+// ParseAccessMethod parses the String form of one of AccessMethods
+func ParseAccessMethod(s string) (AccessMethod, error) {
+	for _, method := range AccessMethods {
+		if method.String() == s {
+			return method, nil
+		}
+	}
+	names := make([]string, len(AccessMethods))
+	for i, method := range AccessMethods {
+		names[i] = method.String()
+	}
+	return AccessMethod{}, fmt.Errorf("unknown access method %q, must be one of: %s", s, strings.Join(names, ", "))
+}
+
 // SurfboardHNAP represents the Python SurfboardHNAP class ported to Go
 type SurfboardHNAP struct {
 	host       string
@@ -28,6 +79,8 @@ type SurfboardHNAP struct {
 	httpClient *http.Client
 	logger     *logrus.Logger
 	baseURL    string
+	// method is the access method tried first, the last one that worked
+	method AccessMethod
 
 	// HNAP authentication state
 	challenge  string
@@ -57,17 +110,43 @@ func NewSurfboardHNAP(host, username, password string, noVerify bool, logger *lo
 		Jar: jar,
 	}
 
-	baseURL := fmt.Sprintf("https://%s", host)
-
-	return &SurfboardHNAP{
+	s := &SurfboardHNAP{
 		host:       host,
 		username:   username,
 		password:   password,
 		noVerify:   noVerify,
 		httpClient: client,
 		logger:     logger,
-		baseURL:    baseURL,
 	}
+	s.SetAccessMethod(AccessMethods[0])
+	return s
+}
+
+// AccessMethod returns the access method the client tries first, the last
+// one that logged in
+func (s *SurfboardHNAP) AccessMethod() AccessMethod {
+	return s.method
+}
+
+// SetAccessMethod makes method the one tried first, such as the one that
+// worked before a restart
+func (s *SurfboardHNAP) SetAccessMethod(method AccessMethod) {
+	s.method = method
+	s.baseURL = fmt.Sprintf("%s://%s", method.Scheme, s.host)
+}
+
+// SetTransport replaces the HTTP transport, e.g. to record traffic
+func (s *SurfboardHNAP) SetTransport(transport http.RoundTripper) {
+	s.httpClient.Transport = transport
+}
+
+// do sends req; failures to get an answer match errors.ErrModemUnreachable
+func (s *SurfboardHNAP) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, werrors.Mark(err, werrors.ErrModemUnreachable)
+	}
+	return resp, nil
 }
 
 // loginHTMLForm performs HTML form login (Python: login_html_form)
@@ -81,7 +160,7 @@ func (s *SurfboardHNAP) loginHTMLForm(ctx context.Context) error {
 		return err
 	}
 
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
@@ -101,7 +180,7 @@ func (s *SurfboardHNAP) loginHTMLForm(ctx context.Context) error {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Referer", loginURL)
 
-	resp, err = s.httpClient.Do(req)
+	resp, err = s.do(req)
 	if err != nil {
 		return err
 	}
@@ -141,7 +220,7 @@ func (s *SurfboardHNAP) loginRequest(ctx context.Context) error {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("SOAPACTION", `"http://purenetworks.com/HNAP1/Login"`)
 
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
@@ -260,7 +339,7 @@ func (s *SurfboardHNAP) loginReal(ctx context.Context) error {
 
 	s.logger.WithField("HNAP_AUTH", authString).Debug("HNAP_AUTH header")
 
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
@@ -287,18 +366,63 @@ func (s *SurfboardHNAP) loginReal(ctx context.Context) error {
 
 	result, _ := loginResp["LoginResult"].(string)
 	if result != "OK" {
-		return fmt.Errorf("HNAP login failed: %s", result)
+		return fmt.Errorf("%w: %s", ErrLoginFailed, result)
 	}
 
 	s.logger.Info("HNAP authentication successful")
 	return nil
 }
 
-// Login performs complete authentication (HTML + HNAP)
+// Login authenticates with the last access method that worked, falling
+// back through AccessMethods when it fails. A modem that does not answer in
+// time, or rejects the credentials, is not tried with the other methods.
 func (s *SurfboardHNAP) Login(ctx context.Context) error {
+	first := s.method
+	err := s.login(ctx)
+	if err == nil || ctx.Err() != nil || isTimeout(err) || errors.Is(err, ErrLoginFailed) {
+		return err
+	}
+
+	for _, method := range AccessMethods {
+		if method == first {
+			continue
+		}
+		s.logger.WithError(err).WithField("next_method", method.String()).Debug("Access method failed, trying the next one")
+		s.SetAccessMethod(method)
+		methodErr := s.login(ctx)
+		if methodErr == nil {
+			s.logger.WithFields(logrus.Fields{
+				"method":   method.String(),
+				"previous": first.String(),
+			}).Info("Logged in with a different access method")
+			return nil
+		}
+		// The modem answered this method, so it is the one to keep
+		if errors.Is(methodErr, ErrLoginFailed) {
+			return methodErr
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	s.SetAccessMethod(first)
+	return err
+}
+
+// isTimeout reports whether err is a request that got no answer in time
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// login performs complete authentication with the current access method
+func (s *SurfboardHNAP) login(ctx context.Context) error {
 	// Step 1: HTML form login
-	if err := s.loginHTMLForm(ctx); err != nil {
-		return fmt.Errorf("HTML form login failed: %w", err)
+	if s.method.HTMLForm {
+		if err := s.loginHTMLForm(ctx); err != nil {
+			return fmt.Errorf("HTML form login failed: %w", err)
+		}
 	}
 
 	// Step 2: HNAP challenge request
@@ -341,7 +465,7 @@ func (s *SurfboardHNAP) Reboot(ctx context.Context) error {
 	err := s.tryRebootMethod(ctx, "SetStatusSecuritySettings", rebootPayload)
 	if err != nil {
 		// Check if it's an authentication error and retry once
-		if strings.Contains(err.Error(), "authentication expired") {
+		if errors.Is(err, ErrSessionExpired) {
 			s.logger.Info("Retrying reboot after authentication refresh")
 			if loginErr := s.Login(ctx); loginErr != nil {
 				return fmt.Errorf("re-authentication failed: %w", loginErr)
@@ -394,7 +518,7 @@ func (s *SurfboardHNAP) tryRebootMethod(ctx context.Context, action string, requ
 		"cookie":    s.cookie,
 	}).Debug("Sending reboot request")
 
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return fmt.Errorf("reboot request failed: %w", err)
 	}
@@ -419,13 +543,13 @@ func (s *SurfboardHNAP) tryRebootMethod(ctx context.Context, action string, requ
 		s.logger.Info("Reboot command confirmed successful")
 		return nil
 	} else if strings.Contains(responseStr, "FAILED") || strings.Contains(responseStr, "ERROR") {
-		return fmt.Errorf("reboot command failed: %s", responseStr)
+		return fmt.Errorf("%w: %s", werrors.ErrRebootRejected, responseStr)
 	} else if strings.Contains(responseStr, "UN-AUTH") || strings.Contains(responseStr, "UNAUTH") {
 		// Clear authentication state and trigger re-authentication
 		s.logger.Warn("Authentication session expired, clearing credentials")
 		s.privateKey = ""
 		s.cookie = ""
-		return fmt.Errorf("authentication expired: %s", responseStr)
+		return fmt.Errorf("%w: %s", ErrSessionExpired, responseStr)
 	}
 
 	s.logger.Info("Reboot command sent, response unclear but assuming success")
@@ -440,9 +564,99 @@ func NewClient(host, username, password string, noVerify bool, logger *logrus.Lo
 	return NewSurfboardHNAP(host, username, password, noVerify, logger)
 }
 
-// GetStatus placeholder
+// StatusActions are the HNAP actions queried by GetStatus. Some firmwares
+// report the modem temperature in the connection information.
+var StatusActions = []string{
+	"GetMotoStatusSoftware",
+	"GetMotoStatusConnectionInfo",
+	"GetMotoStatusDownstreamChannelInfo",
+	"GetMotoStatusUpstreamChannelInfo",
+}
+
+// GetStatus fetches software, connection and channel information in a single
+// GetMultipleHNAPs request. The returned map is keyed by "<Action>Response".
 func (s *SurfboardHNAP) GetStatus(ctx context.Context) (map[string]interface{}, error) {
-	return map[string]interface{}{"status": "python_port"}, nil
+	return s.GetMultipleHNAPs(ctx, StatusActions...)
+}
+
+// GetMultipleHNAPs queries several HNAP actions at once, logging in first if
+// needed and re-authenticating once when the session has expired
+func (s *SurfboardHNAP) GetMultipleHNAPs(ctx context.Context, actions ...string) (map[string]interface{}, error) {
+	if s.privateKey == "" {
+		if err := s.Login(ctx); err != nil {
+			return nil, fmt.Errorf("authentication required: %w", err)
+		}
+	}
+
+	result, err := s.getMultipleHNAPs(ctx, actions)
+	if errors.Is(err, ErrSessionExpired) {
+		s.logger.Info("Retrying status request after authentication refresh")
+		if loginErr := s.Login(ctx); loginErr != nil {
+			return nil, fmt.Errorf("re-authentication failed: %w", loginErr)
+		}
+		result, err = s.getMultipleHNAPs(ctx, actions)
+	}
+	return result, err
+}
+
+// getMultipleHNAPs sends a single GetMultipleHNAPs request
+func (s *SurfboardHNAP) getMultipleHNAPs(ctx context.Context, actions []string) (map[string]interface{}, error) {
+	query := make(map[string]interface{}, len(actions))
+	for _, action := range actions {
+		query[action] = ""
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{"GetMultipleHNAPs": query})
+	if err != nil {
+		return nil, err
+	}
+
+	hnapURL := s.baseURL + "/HNAP1/"
+	req, err := http.NewRequestWithContext(ctx, "POST", hnapURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("SOAPACTION", `"http://purenetworks.com/HNAP1/GetMultipleHNAPs"`)
+	req.Header.Set("HNAP_AUTH", s.generateHNAPAuth("GetMultipleHNAPs"))
+	if s.cookie != "" {
+		req.Header.Set("Cookie", fmt.Sprintf("uid=%s", s.cookie))
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("status request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status response: %d", resp.StatusCode)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid status response: %w", err)
+	}
+
+	multi, ok := response["GetMultipleHNAPsResponse"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid status response format")
+	}
+
+	result, _ := multi["GetMultipleHNAPsResult"].(string)
+	if strings.Contains(result, "UN-AUTH") || strings.Contains(result, "UNAUTH") {
+		s.logger.Warn("Authentication session expired, clearing credentials")
+		s.privateKey = ""
+		s.cookie = ""
+		return nil, fmt.Errorf("%w: %s", ErrSessionExpired, result)
+	}
+
+	return multi, nil
 }
 
 // RebootWithMonitoring with basic monitoring
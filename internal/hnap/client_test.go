@@ -2,7 +2,11 @@ package hnap
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/perezjoseph/mb8600-watchdog/internal/config"
@@ -129,3 +133,61 @@ func TestLoginWithHTMLFormIntegration(t *testing.T) {
 		t.Error("Expected meaningful error message")
 	}
 }
+
+// newHNAPServer serves the HNAP login of an MB8600 over plain HTTP, the way
+// some firmware does, counting the requests it gets
+func newHNAPServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/HNAP1/" {
+			return
+		}
+		if r.Header.Get("HNAP_AUTH") == "" {
+			fmt.Fprint(w, `{"LoginResponse":{"Challenge":"challenge","PublicKey":"public","Cookie":"cookie"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"LoginResponse":{"LoginResult":"OK"}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestLoginFallsBackToWorkingAccessMethod(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	server, requests := newHNAPServer(t)
+	client := NewClient(strings.TrimPrefix(server.URL, "http://"), "admin", "motorola", true, logger)
+
+	if err := client.Login(context.Background()); err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	if got := client.AccessMethod().String(); got != "http+form" {
+		t.Errorf("Expected the http+form access method, got %s", got)
+	}
+
+	// A later login goes straight to the method that worked
+	atomic.StoreInt32(requests, 0)
+	if err := client.Login(context.Background()); err != nil {
+		t.Fatalf("Second Login() failed: %v", err)
+	}
+	if got := atomic.LoadInt32(requests); got != 4 {
+		t.Errorf("Expected only the 4 requests of one login, got %d", got)
+	}
+
+	// The remembered method survives a new client
+	restored := NewClient(client.host, "admin", "motorola", true, logger)
+	method, err := ParseAccessMethod(client.AccessMethod().String())
+	if err != nil {
+		t.Fatalf("ParseAccessMethod() failed: %v", err)
+	}
+	restored.SetAccessMethod(method)
+	if restored.baseURL != server.URL {
+		t.Errorf("Expected the base URL %s, got %s", server.URL, restored.baseURL)
+	}
+
+	if _, err := ParseAccessMethod("ftp+form"); err == nil {
+		t.Error("Expected an error for an unknown access method")
+	}
+}
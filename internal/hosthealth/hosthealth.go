@@ -0,0 +1,318 @@
+// Package hosthealth checks that the host the watchdog runs on is healthy
+// enough for its connectivity checks to mean anything: a network interface
+// up, a default route, a CPU that is not pegged and a clock that is set. A
+// failed check on a broken host says nothing about the modem, so the
+// watchdog holds a reboot back while the host looks degraded.
+package hosthealth
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxCPU is the CPU usage, in percent, above which the CPU counts as
+// pegged unless configured
+const DefaultMaxCPU = 95.0
+
+// cpuSample is how long the CPU usage is measured for
+const cpuSample = 500 * time.Millisecond
+
+// earliestTime is the earliest time a set clock shows. Hosts without a
+// battery-backed clock, such as a Raspberry Pi, start in 1970 or at their
+// last shutdown until NTP sets the time.
+var earliestTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Checks
+const (
+	CheckInterface = "interface"
+	CheckRoute     = "route"
+	CheckCPU       = "cpu"
+	CheckClock     = "clock"
+)
+
+// Route flags from /proc/net/route and /proc/net/ipv6_route
+const (
+	rtfUp     = 0x0001
+	rtfReject = 0x0200
+)
+
+// Config configures the checks
+type Config struct {
+	// MaxCPU is the CPU usage, in percent, above which the CPU counts as
+	// pegged
+	MaxCPU float64
+}
+
+// Validate rejects a CPU limit that is not a percentage
+func (cfg Config) Validate() error {
+	if cfg.MaxCPU <= 0 || cfg.MaxCPU > 100 {
+		return fmt.Errorf("maximum CPU usage must be between 0 and 100 percent, got %v", cfg.MaxCPU)
+	}
+	return nil
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// Report is the outcome of every check
+type Report struct {
+	Checks []Result `json:"checks"`
+}
+
+// Degraded reports whether a check failed
+func (r Report) Degraded() bool {
+	return len(r.Problems()) > 0
+}
+
+// Problems returns the failed checks
+func (r Report) Problems() []Result {
+	var problems []Result
+	for _, check := range r.Checks {
+		if !check.OK {
+			problems = append(problems, check)
+		}
+	}
+	return problems
+}
+
+// String lists the failed checks, for logs and decisions
+func (r Report) String() string {
+	problems := r.Problems()
+	parts := make([]string, len(problems))
+	for i, problem := range problems {
+		parts[i] = problem.Name + ": " + problem.Detail
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Checker checks the host
+type Checker struct {
+	cfg Config
+	// procDir and sysNetDir are /proc and /sys/class/net; tests point them
+	// at fixtures
+	procDir   string
+	sysNetDir string
+	// wait waits between the two CPU samples
+	wait func(ctx context.Context) error
+}
+
+// New creates a checker of the host
+func New(cfg Config) *Checker {
+	return &Checker{
+		cfg:       cfg,
+		procDir:   "/proc",
+		sysNetDir: "/sys/class/net",
+		wait: func(ctx context.Context) error {
+			timer := time.NewTimer(cpuSample)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// Check runs every check as of now. A check that cannot read what it needs,
+// as on a host without /proc, passes: only a host known to be broken holds
+// a reboot back.
+func (c *Checker) Check(ctx context.Context, now time.Time) Report {
+	iface, route := c.checkRoute()
+	return Report{Checks: []Result{
+		c.checkInterface(iface),
+		route,
+		c.checkCPU(ctx),
+		checkClock(now),
+	}}
+}
+
+// checkRoute looks for a default route and returns its interface
+func (c *Checker) checkRoute() (string, Result) {
+	result := Result{Name: CheckRoute}
+	iface, found4, err4 := c.defaultRoute4()
+	if found4 {
+		result.OK, result.Detail = true, "default route via "+iface
+		return iface, result
+	}
+	iface, found6, err6 := c.defaultRoute6()
+	switch {
+	case found6:
+		result.OK, result.Detail = true, "IPv6 default route via "+iface
+	case err4 != nil && err6 != nil:
+		result.OK, result.Detail = true, "routing table unreadable, not checked"
+	default:
+		result.Detail = "no default route"
+	}
+	return iface, result
+}
+
+// defaultRoute4 returns the interface of the IPv4 default route
+func (c *Checker) defaultRoute4() (string, bool, error) {
+	var iface string
+	err := c.scanProc("net/route", func(fields []string) bool {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			return false
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&rtfUp == 0 || flags&rtfReject != 0 {
+			return false
+		}
+		iface = fields[0]
+		return true
+	})
+	return iface, iface != "", err
+}
+
+// defaultRoute6 returns the interface of the IPv6 default route
+func (c *Checker) defaultRoute6() (string, bool, error) {
+	var iface string
+	err := c.scanProc("net/ipv6_route", func(fields []string) bool {
+		// Destination PrefixLen Source SourcePrefixLen NextHop Metric RefCnt Use Flags Iface
+		if len(fields) < 10 || fields[0] != strings.Repeat("0", 32) || fields[1] != "00" || fields[9] == "lo" {
+			return false
+		}
+		flags, err := strconv.ParseUint(fields[8], 16, 32)
+		if err != nil || flags&rtfUp == 0 || flags&rtfReject != 0 {
+			return false
+		}
+		iface = fields[9]
+		return true
+	})
+	return iface, iface != "", err
+}
+
+// scanProc calls match with the fields of each line of the file under
+// /proc until it returns true
+func (c *Checker) scanProc(name string, match func(fields []string) bool) error {
+	file, err := os.Open(filepath.Join(c.procDir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if match(strings.Fields(scanner.Text())) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// checkInterface checks that the interface of the default route is up, or
+// without one that any interface but the loopback is
+func (c *Checker) checkInterface(iface string) Result {
+	result := Result{Name: CheckInterface}
+	if iface != "" {
+		state := c.operState(iface)
+		result.OK = interfaceUp(state)
+		result.Detail = fmt.Sprintf("%s is %s", iface, state)
+		return result
+	}
+
+	entries, err := os.ReadDir(c.sysNetDir)
+	if err != nil {
+		result.OK, result.Detail = true, "interfaces unreadable, not checked"
+		return result
+	}
+	for _, entry := range entries {
+		if entry.Name() != "lo" && interfaceUp(c.operState(entry.Name())) {
+			result.OK, result.Detail = true, entry.Name()+" is up"
+			return result
+		}
+	}
+	result.Detail = "no network interface is up"
+	return result
+}
+
+// operState returns the operational state of iface from sysfs
+func (c *Checker) operState(iface string) string {
+	data, err := os.ReadFile(filepath.Join(c.sysNetDir, iface, "operstate"))
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// interfaceUp reports whether an interface in state carries traffic.
+// Tunnels such as WireGuard and PPP report unknown.
+func interfaceUp(state string) bool {
+	return state == "up" || state == "unknown"
+}
+
+// checkCPU measures the CPU usage of the host
+func (c *Checker) checkCPU(ctx context.Context) Result {
+	result := Result{Name: CheckCPU, OK: true}
+	busy1, total1, err := c.cpuTimes()
+	if err == nil {
+		err = c.wait(ctx)
+	}
+	var busy2, total2 uint64
+	if err == nil {
+		busy2, total2, err = c.cpuTimes()
+	}
+	if err != nil || total2 <= total1 {
+		result.Detail = "CPU usage unknown, not checked"
+		return result
+	}
+	usage := float64(busy2-busy1) / float64(total2-total1) * 100
+	result.Detail = fmt.Sprintf("CPU %.0f%% busy", usage)
+	if usage > c.cfg.MaxCPU {
+		result.OK = false
+		result.Detail += fmt.Sprintf(", over %.0f%%", c.cfg.MaxCPU)
+	}
+	return result
+}
+
+// cpuTimes returns the busy and total CPU time from /proc/stat, in ticks
+func (c *Checker) cpuTimes() (uint64, uint64, error) {
+	var busy, total uint64
+	found := false
+	err := c.scanProc("stat", func(fields []string) bool {
+		if len(fields) < 5 || fields[0] != "cpu" {
+			return false
+		}
+		// user nice system idle iowait irq softirq steal; guest time is
+		// counted in user and nice already
+		for i, field := range fields[1:] {
+			if i == 8 {
+				break
+			}
+			ticks, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				continue
+			}
+			total += ticks
+			// idle and iowait are the 4th and 5th values
+			if i != 3 && i != 4 {
+				busy += ticks
+			}
+		}
+		found = true
+		return true
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("no cpu line in %s", filepath.Join(c.procDir, "stat"))
+	}
+	return busy, total, err
+}
+
+// checkClock checks that the clock is set
+func checkClock(now time.Time) Result {
+	if now.Before(earliestTime) {
+		return Result{Name: CheckClock, Detail: fmt.Sprintf("clock shows %s, it is not set", now.UTC().Format(time.RFC3339))}
+	}
+	return Result{Name: CheckClock, OK: true, Detail: "clock is set"}
+}
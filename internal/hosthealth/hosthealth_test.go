@@ -0,0 +1,123 @@
+package hosthealth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFixture returns a checker reading a fake /proc and /sys/class/net
+// holding files, whose CPU counters are stat1 and then stat2
+func newFixture(t *testing.T, files map[string]string, stat1, stat2 string) *Checker {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		write(name, content)
+	}
+	write("proc/stat", stat1)
+
+	checker := New(Config{MaxCPU: DefaultMaxCPU})
+	checker.procDir = filepath.Join(dir, "proc")
+	checker.sysNetDir = filepath.Join(dir, "sys")
+	checker.wait = func(ctx context.Context) error {
+		write("proc/stat", stat2)
+		return nil
+	}
+	return checker
+}
+
+const (
+	routeHeader = "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n"
+	idleStat1   = "cpu  100 0 100 800 0 0 0 0 0 0\ncpu0 100 0 100 800 0 0 0 0 0 0\n"
+	idleStat2   = "cpu  110 0 110 980 0 0 0 0 0 0\ncpu0 110 0 110 980 0 0 0 0 0 0\n"
+	busyStat2   = "cpu  290 0 200 810 0 0 0 0 0 0\ncpu0 290 0 200 810 0 0 0 0 0 0\n"
+)
+
+var now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestHealthyHost(t *testing.T) {
+	checker := newFixture(t, map[string]string{
+		"proc/net/route":     routeHeader + "eth0\t00000000\t0101A8C0\t0003\t0\t0\t100\t00000000\t0\t0\t0\n",
+		"sys/eth0/operstate": "up\n",
+		"sys/lo/operstate":   "unknown\n",
+	}, idleStat1, idleStat2)
+
+	report := checker.Check(context.Background(), now)
+	if report.Degraded() {
+		t.Fatalf("Expected a healthy host, got %s", report)
+	}
+	for _, check := range report.Checks {
+		if check.Name == CheckCPU && check.Detail != "CPU 10% busy" {
+			t.Errorf("Unexpected CPU detail %q", check.Detail)
+		}
+	}
+}
+
+func TestDegradedHost(t *testing.T) {
+	checker := newFixture(t, map[string]string{
+		"proc/net/route":      routeHeader + "eth0\t0001A8C0\t00000000\t0001\t0\t0\t100\t00FFFFFF\t0\t0\t0\n",
+		"proc/net/ipv6_route": "00000000000000000000000000000000 00 00000000000000000000000000000000 00 00000000000000000000000000000000 ffffffff 00000001 00000000 00200200       lo\n",
+		"sys/eth0/operstate":  "down\n",
+		"sys/lo/operstate":    "unknown\n",
+	}, idleStat1, busyStat2)
+
+	report := checker.Check(context.Background(), time.Date(1970, 1, 1, 0, 5, 0, 0, time.UTC))
+	failed := map[string]bool{}
+	for _, problem := range report.Problems() {
+		failed[problem.Name] = true
+	}
+	for _, name := range []string{CheckInterface, CheckRoute, CheckCPU, CheckClock} {
+		if !failed[name] {
+			t.Errorf("Expected the %s check to fail, got %s", name, report)
+		}
+	}
+	if !strings.Contains(report.String(), "no default route") || !strings.Contains(report.String(), "no network interface is up") {
+		t.Errorf("Unexpected report %q", report)
+	}
+}
+
+func TestDefaultRouteInterfaceDown(t *testing.T) {
+	checker := newFixture(t, map[string]string{
+		"proc/net/route":      routeHeader + "wlan0\t00000000\t0101A8C0\t0003\t0\t0\t600\t00000000\t0\t0\t0\n",
+		"sys/wlan0/operstate": "dormant\n",
+		"sys/eth0/operstate":  "up\n",
+	}, idleStat1, idleStat2)
+
+	problems := checker.Check(context.Background(), now).Problems()
+	if len(problems) != 1 || problems[0].Name != CheckInterface || problems[0].Detail != "wlan0 is dormant" {
+		t.Errorf("Expected the interface of the default route to be down, got %+v", problems)
+	}
+}
+
+func TestUnreadableHostPasses(t *testing.T) {
+	checker := New(Config{MaxCPU: DefaultMaxCPU})
+	checker.procDir = filepath.Join(t.TempDir(), "missing")
+	checker.sysNetDir = checker.procDir
+
+	if report := checker.Check(context.Background(), now); report.Degraded() {
+		t.Errorf("Expected checks that cannot run to pass, got %s", report)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := (Config{MaxCPU: DefaultMaxCPU}).Validate(); err != nil {
+		t.Errorf("Expected the default configuration to be valid, got %v", err)
+	}
+	for _, maxCPU := range []float64{0, -5, 101} {
+		if err := (Config{MaxCPU: maxCPU}).Validate(); err == nil {
+			t.Errorf("Expected a maximum CPU usage of %v to be invalid", maxCPU)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package i18n
+
+// english is the reference catalog; every key must be defined here
+var english = map[string]string{
+	// Status column of check results
+	"output.ok":      "OK",
+	"output.info":    "INFO",
+	"output.warning": "WARN",
+	"output.failed":  "FAIL",
+
+	"check.modem_url": "Web interface answers at %s",
+
+	// Components
+	"component.configuration":         "Configuration",
+	"component.process":               "Process Status",
+	"component.working_directory":     "Working Directory",
+	"component.state_directory":       "State Directory",
+	"component.log_file":              "Log File Access",
+	"component.modem_connectivity":    "Modem Connectivity",
+	"component.internet_connectivity": "Internet Connectivity",
+	"component.system_capabilities":   "System Capabilities",
+	"component.statistics":            "Statistics",
+
+	// Health command
+	"health.title":    "MB8600 Watchdog Health Check",
+	"health.passed":   "All health checks passed",
+	"health.warnings": "All health checks passed, %d with warnings",
+	"health.failed":   "Health checks failed: %d",
+	"health.skipped":  "not configured",
+
+	// Status command
+	"status.title":                "MB8600 Watchdog Service Status",
+	"status.stopped":              "STOPPED - %v",
+	"status.running":              "RUNNING",
+	"status.rebooting":            "REBOOTING, %s remaining (%s)",
+	"status.tunnel_up":            "UP via %s %s (%dms)",
+	"status.tunnel_down":          "DOWN via %s %s - %s",
+	"status.low_power":            "LOW POWER, checking every %s",
+	"status.cluster_active":       "ACTIVE %s, rebooting the modem (%s)",
+	"status.cluster_standby":      "STANDING BY as %s (%s)",
+	"status.unknown":              "UNKNOWN (no PID file configured)",
+	"status.service":              "Service Status",
+	"status.modem_status":         "Modem Status",
+	"status.tunnel":               "Modem Tunnel",
+	"status.power_mode":           "Power Mode",
+	"status.cluster":              "Cluster",
+	"status.paused":               "Paused",
+	"status.paused_until":         "until %s",
+	"status.config_summary":       "Configuration Summary",
+	"status.modem_type":           "Modem Type",
+	"status.modem_host":           "Modem Host",
+	"status.check_interval":       "Check Interval",
+	"status.failure_threshold":    "Failure Threshold",
+	"status.recovery_wait":        "Recovery Wait",
+	"status.diagnostics_enabled":  "Diagnostics Enabled",
+	"status.log_level":            "Log Level",
+	"status.log_file":             "Log File",
+	"status.pid_file":             "PID File",
+	"status.state_directory":      "State Directory",
+	"status.control_socket":       "Control Socket",
+	"status.connection":           "Connection",
+	"status.connection_healthy":   "HEALTHY (score %.0f/100)",
+	"status.connection_degraded":  "DEGRADED (score %.0f/100)",
+	"status.connection_outage":    "OUTAGE, %d failed checks (score %.0f/100)",
+	"status.service_statistics":   "Service Statistics",
+	"status.failure_count":        "Current Failure Count",
+	"status.total_checks":         "Total Connectivity Checks",
+	"status.modem_model":          "Modem Model",
+	"status.modem_mode":           "Modem Mode",
+	"status.modem_firmware":       "Modem Firmware",
+	"status.modem_access":         "Modem Access",
+	"status.total_reboots":        "Total Modem Reboots",
+	"status.health":               "Connection Health",
+	"status.health_score":         "%s (score %s/100)",
+	"status.last_check":           "Last Check",
+	"status.last_reboot":          "Last Reboot",
+	"status.ago":                  "%s (%s ago)",
+	"status.never":                "Never",
+	"status.no_statistics":        "no statistics available (state file not found)",
+	"status.statistics_read_fail": "cannot read statistics",
+	"status.reboots_by_reason":    "Reboots by Reason",
+
+	// Last diagnostic analysis in the status command
+	"status.diagnostics.title":           "Last Diagnostics",
+	"status.diagnostics.time":            "Analyzed",
+	"status.diagnostics.verdict":         "Verdict",
+	"status.diagnostics.reboot":          "reboot recommended",
+	"status.diagnostics.no_reboot":       "no reboot needed",
+	"status.diagnostics.score":           "Health Score",
+	"status.diagnostics.score_value":     "%.0f/100 (%s of tests passed)",
+	"status.diagnostics.cause":           "Cause",
+	"status.diagnostics.layers":          "Layers",
+	"status.diagnostics.patterns":        "Failure Patterns",
+	"status.diagnostics.recommendations": "Recommendations",
+
+	// Reboot reasons in the status command
+	"status.reboot_reason.threshold":  "threshold",
+	"status.reboot_reason.manual":     "manual",
+	"status.reboot_reason.scheduled":  "scheduled",
+	"status.reboot_reason.self_test":  "self-test",
+	"status.reboot_reason.escalation": "escalation",
+
+	// Reload and stop commands
+	"reload.sent":  "Configuration reload signal sent to process %d",
+	"stop.sending": "Sending graceful shutdown signal to process %d...",
+	"stop.timeout": "⚠️  Graceful shutdown timeout, process may still be running",
+	"stop.stopped": "✅ Service stopped successfully",
+
+	// Debug dump command
+	"debug_dump.sent": "Debug dump requested from process %d, written to %s",
+
+	// Man command
+	"man.written": "Man pages written to %s",
+
+	// Top command
+	"top.title":            "MB8600 Watchdog",
+	"top.connecting":       "Connecting to the service...",
+	"top.error":            "⚠️  %v",
+	"top.modem":            "Modem",
+	"top.health":           "Health",
+	"top.counts":           "failures %d · checks %d · reboots %d",
+	"top.last_check":       "Last",
+	"top.reboot":           "Reboot",
+	"top.reboot_progress":  "%s (%s) since %s, %s left",
+	"top.paused":           "Paused",
+	"top.paused_until":     "until %s %s",
+	"top.checks":           "Checks",
+	"top.latency":          "Latency",
+	"top.score":            "Score",
+	"top.events":           "Recent events",
+	"top.no_events":        "No events in the last 24 hours",
+	"top.keys":             "q quit · r refresh",
+	"top.state.monitoring": "monitoring",
+	"top.state.failing":    "failing (%d)",
+	"top.state.rebooting":  "rebooting",
+	"top.state.recovering": "recovering",
+	"top.state.paused":     "paused",
+	"top.state.standby":    "standing by",
+	"top.state.stopped":    "stopped",
+
+	// Backup commands
+	"backup.written":   "Backup of %d files written to %s",
+	"backup.stored":    "Backup of %d files stored in %s as %s",
+	"backup.restored":  "Restored %d files from the backup of %s",
+	"backup.config":    "Configuration restored to %s",
+	"backup.no_config": "The backup holds the configuration file %s; pass --config to restore it",
+
+	// Log level command
+	"log_level.current": "Log level: %s (configured: %s)",
+	"log_level.until":   "Returns to %s at %s",
+	"log_level.module":  "  %-12s %s (configured: %s)",
+
+	// Pause command
+	"pause.none":    "Monitoring is not paused",
+	"pause.reboots": "⏸️  Automatic reboots paused until %s",
+	"pause.checks":  "⏸️  Automatic reboots and checks paused until %s",
+	"pause.reason":  "   Reason: %s",
+	"pause.by":      "   Paused by %s at %s",
+	"pause.resumed": "Monitoring resumed",
+
+	// History command
+	"history.none":       "No events recorded in %s",
+	"history.decision":   "%v (%v): %v",
+	"history.annotated":  "Note added to %s",
+	"history.no_outages": "No outages recorded in %s",
+	"history.ongoing":    "ongoing",
+	"history.pruned":     "History %s: %d check results downsampled, %d events pruned; %d outage reports removed from %s",
+
+	// Config command
+	"config.valid":   "✅ %s is valid",
+	"config.invalid": "❌ %s: %s",
+
+	// Simulate command
+	"simulate.start":       "🧪 Simulating %s (check every %s, reboot after %d failures, recovery wait %s)",
+	"simulate.summary":     "📊 Summary:",
+	"simulate.phase":       "checks: %4d  failed: %4d  reboots: %d",
+	"simulate.totals":      "Total checks: %d, failed: %d, reboots: %d",
+	"simulate.unnecessary": "⚠️  %d reboot(s) happened while connectivity was healthy",
+	"simulate.lost":        "❌ connectivity lost",
+	"simulate.restored":    "✅ connectivity restored",
+	"simulate.reboot":      "🔄 modem reboot",
+	"simulate.check_error": "⚠️  check error: %s",
+
+	// Notify command
+	"notify.disabled": "⚠️  Notifications are disabled, enable the notifications feature to send them",
+	"notify.sent":     "✅ Sent test %s notification (%s)",
+	"notify.failed":   "❌ Test %s notification (%s) failed: %s",
+
+	// Self-update command
+	"self_update.current":     "Running %s, latest release is %s",
+	"self_update.up_to_date":  "✅ Already up to date",
+	"self_update.available":   "⬆️  Update available, run without --check to install it",
+	"self_update.installed":   "✅ Installed %s as %s",
+	"self_update.restarted":   "🔄 Restarted %s",
+	"self_update.restart_now": "Restart the service to run the new version",
+
+	// Install command
+	"install.user":    "✅ Created system user %s",
+	"install.binary":  "✅ Installed binary to %s",
+	"install.dir":     "✅ Prepared %s",
+	"install.config":  "✅ Wrote configuration to %s, set your modem password there",
+	"install.unit":    "✅ Installed unit %s",
+	"install.enabled": "✅ Enabled and started %s",
+	"install.next":    "Start the service with: systemctl enable --now %s",
+
+	"install.next_openwrt": "Start the service with: %s enable && %[1]s start",
+}
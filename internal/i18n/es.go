@@ -0,0 +1,207 @@
+package i18n
+
+// spanish translates the English catalog; missing keys fall back to English
+var spanish = map[string]string{
+	// Status column of check results
+	"output.ok":      "OK",
+	"output.info":    "INFO",
+	"output.warning": "AVISO",
+	"output.failed":  "FALLO",
+
+	"check.modem_url": "La interfaz web responde en %s",
+
+	// Components
+	"component.configuration":         "Configuración",
+	"component.process":               "Estado del proceso",
+	"component.working_directory":     "Directorio de trabajo",
+	"component.state_directory":       "Directorio de estado",
+	"component.log_file":              "Acceso al archivo de registro",
+	"component.modem_connectivity":    "Conectividad con el módem",
+	"component.internet_connectivity": "Conectividad a Internet",
+	"component.system_capabilities":   "Capacidades del sistema",
+	"component.statistics":            "Estadísticas",
+
+	// Health command
+	"health.title":    "Verificación de salud de MB8600 Watchdog",
+	"health.passed":   "Todas las verificaciones pasaron",
+	"health.warnings": "Todas las verificaciones pasaron, %d con advertencias",
+	"health.failed":   "Verificaciones fallidas: %d",
+	"health.skipped":  "no configurado",
+
+	// Status command
+	"status.title":                "Estado del servicio MB8600 Watchdog",
+	"status.stopped":              "DETENIDO - %v",
+	"status.running":              "EN EJECUCIÓN",
+	"status.rebooting":            "REINICIANDO, quedan %s (%s)",
+	"status.tunnel_up":            "ACTIVO vía %s %s (%dms)",
+	"status.tunnel_down":          "CAÍDO vía %s %s - %s",
+	"status.low_power":            "BAJO CONSUMO, comprobando cada %s",
+	"status.cluster_active":       "ACTIVO %s, reinicia el módem (%s)",
+	"status.cluster_standby":      "EN ESPERA como %s (%s)",
+	"status.unknown":              "DESCONOCIDO (no hay archivo PID configurado)",
+	"status.service":              "Estado del servicio",
+	"status.modem_status":         "Estado del módem",
+	"status.tunnel":               "Túnel al módem",
+	"status.power_mode":           "Modo de energía",
+	"status.cluster":              "Clúster",
+	"status.paused":               "En pausa",
+	"status.paused_until":         "hasta %s",
+	"status.config_summary":       "Resumen de configuración",
+	"status.modem_type":           "Tipo de módem",
+	"status.modem_host":           "Dirección del módem",
+	"status.check_interval":       "Intervalo de verificación",
+	"status.failure_threshold":    "Umbral de fallos",
+	"status.recovery_wait":        "Espera de recuperación",
+	"status.diagnostics_enabled":  "Diagnósticos habilitados",
+	"status.log_level":            "Nivel de registro",
+	"status.log_file":             "Archivo de registro",
+	"status.pid_file":             "Archivo PID",
+	"status.state_directory":      "Directorio de estado",
+	"status.control_socket":       "Socket de control",
+	"status.connection":           "Conexión",
+	"status.connection_healthy":   "SALUDABLE (puntuación %.0f/100)",
+	"status.connection_degraded":  "DEGRADADA (puntuación %.0f/100)",
+	"status.connection_outage":    "CAÍDA, %d verificaciones fallidas (puntuación %.0f/100)",
+	"status.service_statistics":   "Estadísticas del servicio",
+	"status.failure_count":        "Fallos consecutivos actuales",
+	"status.total_checks":         "Verificaciones de conectividad",
+	"status.modem_model":          "Modelo del módem",
+	"status.modem_mode":           "Modo del módem",
+	"status.modem_firmware":       "Firmware del módem",
+	"status.modem_access":         "Acceso al módem",
+	"status.total_reboots":        "Reinicios del módem",
+	"status.health":               "Salud de la conexión",
+	"status.health_score":         "%s (puntuación %s/100)",
+	"status.last_check":           "Última verificación",
+	"status.last_reboot":          "Último reinicio",
+	"status.ago":                  "%s (hace %s)",
+	"status.never":                "Nunca",
+	"status.no_statistics":        "no hay estadísticas disponibles (no se encontró el archivo de estado)",
+	"status.statistics_read_fail": "no se pueden leer las estadísticas",
+	"status.reboots_by_reason":    "Reinicios por motivo",
+
+	// Last diagnostic analysis in the status command
+	"status.diagnostics.title":           "Último diagnóstico",
+	"status.diagnostics.time":            "Analizado",
+	"status.diagnostics.verdict":         "Veredicto",
+	"status.diagnostics.reboot":          "se recomienda reiniciar",
+	"status.diagnostics.no_reboot":       "no hace falta reiniciar",
+	"status.diagnostics.score":           "Puntuación de salud",
+	"status.diagnostics.score_value":     "%.0f/100 (%s de las pruebas superadas)",
+	"status.diagnostics.cause":           "Causa",
+	"status.diagnostics.layers":          "Capas",
+	"status.diagnostics.patterns":        "Patrones de fallo",
+	"status.diagnostics.recommendations": "Recomendaciones",
+
+	// Reboot reasons in the status command
+	"status.reboot_reason.threshold":  "umbral",
+	"status.reboot_reason.manual":     "manual",
+	"status.reboot_reason.scheduled":  "programado",
+	"status.reboot_reason.self_test":  "autoprueba",
+	"status.reboot_reason.escalation": "escalado",
+
+	// Reload and stop commands
+	"reload.sent":  "Señal de recarga de configuración enviada al proceso %d",
+	"stop.sending": "Enviando señal de apagado ordenado al proceso %d...",
+	"stop.timeout": "⚠️  Se agotó el tiempo de apagado, el proceso podría seguir en ejecución",
+	"stop.stopped": "✅ Servicio detenido correctamente",
+
+	// Debug dump command
+	"debug_dump.sent": "Volcado de depuración solicitado al proceso %d, se escribe en %s",
+
+	// Man command
+	"man.written": "Páginas de manual escritas en %s",
+
+	// Top command
+	"top.title":            "MB8600 Watchdog",
+	"top.connecting":       "Conectando con el servicio...",
+	"top.error":            "⚠️  %v",
+	"top.modem":            "Módem",
+	"top.health":           "Salud",
+	"top.counts":           "fallos %d · pruebas %d · reinicios %d",
+	"top.last_check":       "Última",
+	"top.reboot":           "Reinicio",
+	"top.reboot_progress":  "%s (%s) desde %s, quedan %s",
+	"top.paused":           "Pausa",
+	"top.paused_until":     "hasta %s %s",
+	"top.checks":           "Pruebas",
+	"top.latency":          "Latencia",
+	"top.score":            "Puntuación",
+	"top.events":           "Eventos recientes",
+	"top.no_events":        "Sin eventos en las últimas 24 horas",
+	"top.keys":             "q salir · r actualizar",
+	"top.state.monitoring": "supervisando",
+	"top.state.failing":    "fallando (%d)",
+	"top.state.rebooting":  "reiniciando",
+	"top.state.recovering": "recuperando",
+	"top.state.paused":     "en pausa",
+	"top.state.standby":    "en espera",
+	"top.state.stopped":    "detenido",
+
+	// Backup commands
+	"backup.written":   "Copia de seguridad de %d archivos escrita en %s",
+	"backup.stored":    "Copia de seguridad de %d archivos guardada en %s como %s",
+	"backup.restored":  "Restaurados %d archivos de la copia de seguridad del %s",
+	"backup.config":    "Configuración restaurada en %s",
+	"backup.no_config": "La copia de seguridad contiene el archivo de configuración %s; use --config para restaurarlo",
+
+	// Log level command
+	"log_level.current": "Nivel de registro: %s (configurado: %s)",
+	"log_level.until":   "Vuelve a %s a las %s",
+	"log_level.module":  "  %-12s %s (configurado: %s)",
+
+	// Pause command
+	"pause.none":    "La monitorización no está en pausa",
+	"pause.reboots": "⏸️  Reinicios automáticos en pausa hasta %s",
+	"pause.checks":  "⏸️  Reinicios automáticos y comprobaciones en pausa hasta %s",
+	"pause.reason":  "   Motivo: %s",
+	"pause.by":      "   Pausado por %s el %s",
+	"pause.resumed": "Monitorización reanudada",
+
+	// History command
+	"history.none":       "No hay eventos registrados en %s",
+	"history.decision":   "%v (%v): %v",
+	"history.annotated":  "Nota añadida a %s",
+	"history.no_outages": "No hay cortes registrados en %s",
+	"history.ongoing":    "en curso",
+	"history.pruned":     "Historial %s: %d resultados de comprobaciones agregados, %d eventos eliminados; %d informes de cortes eliminados de %s",
+
+	// Config command
+	"config.valid":   "✅ %s es válido",
+	"config.invalid": "❌ %s: %s",
+
+	// Simulate command
+	"simulate.start":       "🧪 Simulando %s (verificación cada %s, reinicio tras %d fallos, espera de recuperación %s)",
+	"simulate.summary":     "📊 Resumen:",
+	"simulate.phase":       "verificaciones: %4d  fallidas: %4d  reinicios: %d",
+	"simulate.totals":      "Verificaciones: %d, fallidas: %d, reinicios: %d",
+	"simulate.unnecessary": "⚠️  %d reinicio(s) ocurrieron mientras la conectividad estaba bien",
+	"simulate.lost":        "❌ conectividad perdida",
+	"simulate.restored":    "✅ conectividad restablecida",
+	"simulate.reboot":      "🔄 reinicio del módem",
+	"simulate.check_error": "⚠️  error de verificación: %s",
+
+	// Notify command
+	"notify.disabled": "⚠️  Las notificaciones están desactivadas, active la función notifications para enviarlas",
+	"notify.sent":     "✅ Notificación de prueba %s enviada (%s)",
+	"notify.failed":   "❌ La notificación de prueba %s (%s) falló: %s",
+
+	// Self-update command
+	"self_update.current":     "Ejecutando %s, la última versión es %s",
+	"self_update.up_to_date":  "✅ Ya está actualizado",
+	"self_update.available":   "⬆️  Hay una actualización, ejecute sin --check para instalarla",
+	"self_update.installed":   "✅ %s instalado como %s",
+	"self_update.restarted":   "🔄 %s reiniciado",
+	"self_update.restart_now": "Reinicie el servicio para ejecutar la nueva versión",
+
+	// Install command
+	"install.user":    "✅ Usuario del sistema %s creado",
+	"install.binary":  "✅ Binario instalado en %s",
+	"install.dir":     "✅ %s preparado",
+	"install.config":  "✅ Configuración escrita en %s, indique allí la contraseña del módem",
+	"install.unit":    "✅ Unidad %s instalada",
+	"install.enabled": "✅ %s habilitado e iniciado",
+	"install.next":    "Inicie el servicio con: systemctl enable --now %s",
+
+	"install.next_openwrt": "Inicie el servicio con: %s enable && %[1]s start",
+}
@@ -0,0 +1,133 @@
+// Package i18n translates user-facing strings. Messages are looked up by key
+// in a catalog per language, falling back to English, and formatted with
+// fmt verbs. The language comes from the configuration or, when unset, from
+// the LANGUAGE, LC_ALL, LC_MESSAGES and LANG environment variables.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is used when no supported language is requested
+const DefaultLanguage = "en"
+
+// catalogs maps a language to its messages
+var catalogs = map[string]map[string]string{
+	"en": english,
+	"es": spanish,
+}
+
+// Languages returns the supported language codes
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for language := range catalogs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Supported reports whether a language has a catalog
+func Supported(language string) bool {
+	_, ok := catalogs[normalize(language)]
+	return ok
+}
+
+// normalize reduces a locale such as "es_MX.UTF-8" to its language code
+func normalize(locale string) string {
+	locale = strings.TrimSpace(locale)
+	if i := strings.IndexAny(locale, "_.@-"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(locale)
+}
+
+// Detect returns the language to use: preferred if set and supported,
+// otherwise the first supported language from the environment
+func Detect(preferred string) string {
+	if Supported(preferred) {
+		return normalize(preferred)
+	}
+
+	var candidates []string
+	// LANGUAGE holds a colon separated priority list
+	candidates = append(candidates, strings.Split(os.Getenv("LANGUAGE"), ":")...)
+	candidates = append(candidates, os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG"))
+
+	for _, candidate := range candidates {
+		if candidate == "" || candidate == "C" || candidate == "POSIX" {
+			continue
+		}
+		if Supported(candidate) {
+			return normalize(candidate)
+		}
+	}
+	return DefaultLanguage
+}
+
+// Localizer translates messages into one language
+type Localizer struct {
+	language string
+}
+
+// New returns a localizer for language, falling back to English when it is
+// not supported
+func New(language string) *Localizer {
+	language = normalize(language)
+	if _, ok := catalogs[language]; !ok {
+		language = DefaultLanguage
+	}
+	return &Localizer{language: language}
+}
+
+// Language returns the language code of the localizer
+func (l *Localizer) Language() string {
+	return l.language
+}
+
+// T returns the message for key formatted with args. Keys missing from the
+// catalog fall back to English, then to the key itself.
+func (l *Localizer) T(key string, args ...interface{}) string {
+	format, ok := catalogs[l.language][key]
+	if !ok {
+		format, ok = catalogs[DefaultLanguage][key]
+	}
+	if !ok {
+		format = key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+var (
+	mu      sync.RWMutex
+	current = New(DefaultLanguage)
+)
+
+// SetLanguage sets the language used by T
+func SetLanguage(language string) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = New(language)
+}
+
+// Language returns the language used by T
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.language
+}
+
+// T translates key into the current language
+func T(key string, args ...interface{}) string {
+	mu.RLock()
+	l := current
+	mu.RUnlock()
+	return l.T(key, args...)
+}
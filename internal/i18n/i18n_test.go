@@ -0,0 +1,99 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	for _, name := range []string{"LANGUAGE", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		t.Setenv(name, "")
+	}
+
+	if got := Detect(""); got != DefaultLanguage {
+		t.Errorf("Detect() without environment = %q, want %q", got, DefaultLanguage)
+	}
+	if got := Detect("ES"); got != "es" {
+		t.Errorf("Detect(ES) = %q, want es", got)
+	}
+
+	t.Setenv("LANG", "es_MX.UTF-8")
+	if got := Detect(""); got != "es" {
+		t.Errorf("Detect() with LANG = %q, want es", got)
+	}
+	if got := Detect("fr"); got != "es" {
+		t.Errorf("Detect(fr) should fall back to the environment, got %q", got)
+	}
+
+	// LANGUAGE is a priority list and wins over LANG
+	t.Setenv("LANGUAGE", "fr:en")
+	if got := Detect(""); got != "en" {
+		t.Errorf("Detect() with LANGUAGE = %q, want en", got)
+	}
+
+	t.Setenv("LANGUAGE", "")
+	t.Setenv("LC_ALL", "C")
+	if got := Detect(""); got != "es" {
+		t.Errorf("Detect() should skip the C locale, got %q", got)
+	}
+}
+
+func TestLocalizer(t *testing.T) {
+	es := New("es")
+	if es.Language() != "es" {
+		t.Errorf("Language() = %q, want es", es.Language())
+	}
+	if got := es.T("reload.sent", 42); got != "Señal de recarga de configuración enviada al proceso 42" {
+		t.Errorf("Unexpected translation %q", got)
+	}
+
+	if New("fr").Language() != DefaultLanguage {
+		t.Error("Unsupported language should fall back to English")
+	}
+	if got := es.T("missing.key"); got != "missing.key" {
+		t.Errorf("Missing key should return the key, got %q", got)
+	}
+
+	SetLanguage("es")
+	defer SetLanguage(DefaultLanguage)
+	if Language() != "es" || T("stop.stopped") != es.T("stop.stopped") {
+		t.Error("Package-level T should use the language set by SetLanguage")
+	}
+}
+
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+
+// TestCatalogsMatchEnglish checks every translation has an English original
+// with the same format verbs, so arguments line up in every language
+func TestCatalogsMatchEnglish(t *testing.T) {
+	for language, catalog := range catalogs {
+		for key, message := range catalog {
+			original, ok := english[key]
+			if !ok {
+				t.Errorf("%s: key %s has no English message", language, key)
+				continue
+			}
+			got := verbPattern.FindAllString(message, -1)
+			want := verbPattern.FindAllString(original, -1)
+			sort.Strings(got)
+			sort.Strings(want)
+			if len(got) != len(want) {
+				t.Errorf("%s: key %s has verbs %v, English has %v", language, key, got, want)
+				continue
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("%s: key %s has verbs %v, English has %v", language, key, got, want)
+					break
+				}
+			}
+		}
+	}
+
+	for key := range english {
+		if _, ok := spanish[key]; !ok {
+			t.Errorf("es: missing translation for %s", key)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -277,8 +278,13 @@ func TestConnectivityEscalationScenarios(t *testing.T) {
 			t.Fatalf("Tiered tests should not error: %v", err)
 		}
 
-		summary := result.GetTestSummary()
-		if summary == nil {
+		// Integrations see the summary as JSON
+		data, err := json.Marshal(result.GetTestSummary())
+		if err != nil {
+			t.Fatalf("Test summary should encode: %v", err)
+		}
+		var summary map[string]interface{}
+		if err := json.Unmarshal(data, &summary); err != nil || summary == nil {
 			t.Fatal("Test summary should not be nil")
 		}
 
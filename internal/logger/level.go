@@ -0,0 +1,378 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/sirupsen/logrus"
+)
+
+// Modules are the subsystems with a log level of their own. A module without
+// a level follows the global one.
+var Modules = []string{"app", "api", "monitor", "connectivity", "diagnostics", "modem", "plugin"}
+
+// ModuleField is added to every entry of a module logger
+const ModuleField = "module"
+
+// LevelController changes the level of a logger at runtime. A level set for
+// a duration reverts to the configured level on its own, so verbose logging
+// around an intermittent problem does not stay on by accident. Each module
+// logs through a logger of its own, so one subsystem can be verbose without
+// flooding the log with the others.
+type LevelController struct {
+	logger *logrus.Logger
+	clock  clock.Clock
+
+	mu      sync.Mutex
+	global  levelState
+	modules map[string]*moduleLevel
+}
+
+// levelState is the configured and temporary level of the global logger or
+// of a module
+type levelState struct {
+	configured logrus.Level
+	// override is false for modules that follow the global level
+	override bool
+	// temporary is the level set at runtime; nil without one
+	temporary *logrus.Level
+	until     time.Time
+	// cancel stops the pending revert; nil without a timed level
+	cancel chan struct{}
+}
+
+// moduleLevel is the level state of a module and its logger, created on
+// first use
+type moduleLevel struct {
+	levelState
+	logger *logrus.Logger
+}
+
+// LevelStatus is the current level and, for a temporary level, when the
+// configured level returns
+type LevelStatus struct {
+	Level      string     `json:"level"`
+	Configured string     `json:"configured"`
+	Until      *time.Time `json:"until,omitempty"`
+	// Modules holds the level of every module
+	Modules map[string]LevelStatus `json:"modules,omitempty"`
+}
+
+// NewLevelController controls the level of logger; its current level is the
+// configured one
+func NewLevelController(logger *logrus.Logger, c clock.Clock) *LevelController {
+	if c == nil {
+		c = clock.New()
+	}
+	lc := &LevelController{
+		logger:  logger,
+		clock:   c,
+		global:  levelState{configured: logger.GetLevel(), override: true},
+		modules: make(map[string]*moduleLevel, len(Modules)),
+	}
+	for _, name := range Modules {
+		lc.modules[name] = &moduleLevel{}
+	}
+	return lc
+}
+
+// Module returns the logger of module. It writes through the same output and
+// formatter as the global logger and tags every entry with the module name.
+func (lc *LevelController) Module(name string) *logrus.Logger {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	module, ok := lc.modules[name]
+	if !ok {
+		module = &moduleLevel{}
+		lc.modules[name] = module
+	}
+	if module.logger == nil {
+		module.logger = &logrus.Logger{
+			Out:          lc.logger.Out,
+			Formatter:    lc.logger.Formatter,
+			Hooks:        make(logrus.LevelHooks),
+			Level:        lc.level(&module.levelState),
+			ExitFunc:     lc.logger.ExitFunc,
+			ReportCaller: lc.logger.ReportCaller,
+		}
+		for level, hooks := range lc.logger.Hooks {
+			module.logger.Hooks[level] = append([]logrus.Hook(nil), hooks...)
+		}
+		module.logger.AddHook(moduleHook(name))
+	}
+	return module.logger
+}
+
+// Status returns the current level
+func (lc *LevelController) Status() LevelStatus {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	status := lc.status(&lc.global)
+	status.Modules = make(map[string]LevelStatus, len(lc.modules))
+	for name, module := range lc.modules {
+		status.Modules[name] = lc.status(&module.levelState)
+	}
+	return status
+}
+
+// Set changes the level. With a positive duration the configured level
+// returns after it; otherwise the level holds until Reset or a configuration
+// reload.
+func (lc *LevelController) Set(level logrus.Level, duration time.Duration) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	lc.set("", &lc.global, level, duration)
+}
+
+// SetModule changes the level of module like Set
+func (lc *LevelController) SetModule(module string, level logrus.Level, duration time.Duration) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	state, ok := lc.modules[module]
+	if !ok {
+		return fmt.Errorf("unknown log module: %s", module)
+	}
+	lc.set(module, &state.levelState, level, duration)
+	return nil
+}
+
+// Reset returns the global logger and every module to the configured levels
+func (lc *LevelController) Reset() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	lc.global.clear()
+	for _, module := range lc.modules {
+		module.clear()
+	}
+	lc.apply()
+	lc.logger.WithField("level", lc.global.configured.String()).Info("Log level reset to the configured level")
+}
+
+// ResetModule returns module to its configured level
+func (lc *LevelController) ResetModule(module string) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	state, ok := lc.modules[module]
+	if !ok {
+		return fmt.Errorf("unknown log module: %s", module)
+	}
+	state.clear()
+	lc.apply()
+	lc.logger.WithFields(logrus.Fields{
+		"level":     lc.level(&state.levelState).String(),
+		ModuleField: module,
+	}).Info("Log level reset to the configured level")
+	return nil
+}
+
+// SetConfigured records a new configured level, such as after a reload. It
+// applies immediately unless a timed level is active, which then reverts
+// to it. Levels set without a duration are dropped.
+func (lc *LevelController) SetConfigured(level logrus.Level) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	lc.global.configured = level
+	lc.global.dropHeld()
+	lc.apply()
+}
+
+// SetConfiguredModules replaces the configured module levels; modules left
+// out follow the global level. Like SetConfigured, timed levels stay active.
+func (lc *LevelController) SetConfiguredModules(levels map[string]logrus.Level) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	for name := range levels {
+		if _, ok := lc.modules[name]; !ok {
+			return fmt.Errorf("unknown log module: %s", name)
+		}
+	}
+	for name, module := range lc.modules {
+		module.configured, module.override = levels[name]
+		module.dropHeld()
+	}
+	lc.apply()
+	return nil
+}
+
+// SetOutput changes the output and formatter of the global logger and every
+// module, such as after a reload
+func (lc *LevelController) SetOutput(formatter logrus.Formatter, out io.Writer) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	lc.logger.SetFormatter(formatter)
+	lc.logger.SetOutput(out)
+	for _, module := range lc.modules {
+		if module.logger != nil {
+			module.logger.SetFormatter(formatter)
+			module.logger.SetOutput(out)
+		}
+	}
+}
+
+// ParseModuleLevels parses module levels written as module=level, such as
+// "modem=trace"
+func ParseModuleLevels(entries []string) (map[string]logrus.Level, error) {
+	levels := make(map[string]logrus.Level, len(entries))
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid module log level %q, expected module=level", entry)
+		}
+		if !isModule(name) {
+			return nil, fmt.Errorf("unknown log module %q, must be one of: %s", name, strings.Join(Modules, ", "))
+		}
+		level, err := logrus.ParseLevel(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level for module %s: %w", name, err)
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
+
+// set applies a runtime level to state; name is empty for the global logger.
+// Callers hold lc.mu.
+func (lc *LevelController) set(name string, state *levelState, level logrus.Level, duration time.Duration) {
+	state.stopRevert()
+	state.temporary = &level
+
+	fields := logrus.Fields{"level": level.String()}
+	if name != "" {
+		fields[ModuleField] = name
+	}
+	if duration > 0 {
+		state.until = lc.clock.Now().Add(duration)
+		state.cancel = make(chan struct{})
+		go lc.revertAfter(name, state, lc.clock.NewTimer(duration), state.cancel)
+		fields["until"] = state.until
+	}
+	lc.apply()
+	lc.logger.WithFields(fields).Info("Log level changed")
+}
+
+// revertAfter restores the configured level of state when timer fires,
+// unless the temporary level is replaced first
+func (lc *LevelController) revertAfter(name string, state *levelState, timer clock.Timer, cancel chan struct{}) {
+	select {
+	case <-timer.C():
+	case <-cancel:
+		timer.Stop()
+		return
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if state.cancel != cancel {
+		return
+	}
+	state.clear()
+	lc.apply()
+
+	fields := logrus.Fields{"level": lc.level(state).String()}
+	if name != "" {
+		fields[ModuleField] = name
+	}
+	lc.logger.WithFields(fields).Info("Temporary log level expired, restored the configured level")
+}
+
+// apply sets the level of every logger; callers hold lc.mu
+func (lc *LevelController) apply() {
+	lc.logger.SetLevel(lc.level(&lc.global))
+	for _, module := range lc.modules {
+		if module.logger != nil {
+			module.logger.SetLevel(lc.level(&module.levelState))
+		}
+	}
+}
+
+// level is the level in force for state: its temporary level, its
+// configured level, or for a module without one the global level. Callers
+// hold lc.mu.
+func (lc *LevelController) level(state *levelState) logrus.Level {
+	switch {
+	case state.temporary != nil:
+		return *state.temporary
+	case state.override:
+		return state.configured
+	default:
+		return lc.level(&lc.global)
+	}
+}
+
+// status describes state; callers hold lc.mu
+func (lc *LevelController) status(state *levelState) LevelStatus {
+	configured := lc.global.configured
+	if state.override {
+		configured = state.configured
+	}
+	status := LevelStatus{
+		Level:      lc.level(state).String(),
+		Configured: configured.String(),
+	}
+	if state.cancel != nil {
+		until := state.until
+		status.Until = &until
+	}
+	return status
+}
+
+// clear drops the temporary level
+func (s *levelState) clear() {
+	s.stopRevert()
+	s.temporary = nil
+}
+
+// dropHeld drops a temporary level set without a duration
+func (s *levelState) dropHeld() {
+	if s.cancel == nil {
+		s.temporary = nil
+	}
+}
+
+// stopRevert cancels a pending revert
+func (s *levelState) stopRevert() {
+	if s.cancel != nil {
+		close(s.cancel)
+		s.cancel = nil
+	}
+}
+
+// isModule reports whether name is one of Modules
+func isModule(name string) bool {
+	for _, module := range Modules {
+		if module == name {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleHook tags entries with the module that logged them
+type moduleHook string
+
+// Levels implements logrus.Hook
+func (h moduleHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h moduleHook) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data[ModuleField]; !ok {
+		entry.Data[ModuleField] = string(h)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/sirupsen/logrus"
+)
+
+// waitForLevel polls until the revert goroutine has set level
+func waitForLevel(t *testing.T, log *logrus.Logger, level logrus.Level) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for log.GetLevel() != level {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected level %s, got %s", level, log.GetLevel())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLevelControllerTemporaryLevel(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.InfoLevel)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	levels := NewLevelController(log, fake)
+
+	levels.Set(logrus.DebugLevel, 10*time.Minute)
+	status := levels.Status()
+	if status.Level != "debug" || status.Configured != "info" || status.Until == nil {
+		t.Fatalf("Unexpected status %+v", status)
+	}
+	if !status.Until.Equal(fake.Now().Add(10 * time.Minute)) {
+		t.Errorf("Expected the level to hold for 10m, until %v", status.Until)
+	}
+
+	// A reload during the temporary level changes what it reverts to
+	levels.SetConfigured(logrus.WarnLevel)
+	if log.GetLevel() != logrus.DebugLevel {
+		t.Errorf("Expected the temporary level to hold across a reload, got %s", log.GetLevel())
+	}
+
+	fake.BlockUntil(1)
+	fake.Advance(10 * time.Minute)
+	waitForLevel(t, log, logrus.WarnLevel)
+	if status := levels.Status(); status.Until != nil {
+		t.Errorf("Expected no pending revert, got %+v", status)
+	}
+}
+
+func TestLevelControllerReplaceAndReset(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.InfoLevel)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	levels := NewLevelController(log, fake)
+
+	levels.Set(logrus.DebugLevel, time.Minute)
+	levels.Set(logrus.TraceLevel, 0)
+
+	// The replaced revert must not fire
+	fake.Advance(time.Hour)
+	time.Sleep(10 * time.Millisecond)
+	if log.GetLevel() != logrus.TraceLevel {
+		t.Errorf("Expected a level without duration to hold, got %s", log.GetLevel())
+	}
+
+	levels.Reset()
+	if log.GetLevel() != logrus.InfoLevel {
+		t.Errorf("Expected reset to the configured level, got %s", log.GetLevel())
+	}
+}
+
+func TestLevelControllerModules(t *testing.T) {
+	var out bytes.Buffer
+	log := logrus.New()
+	log.SetLevel(logrus.InfoLevel)
+	log.SetOutput(&out)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	levels := NewLevelController(log, fake)
+
+	if err := levels.SetConfiguredModules(map[string]logrus.Level{"modem": logrus.TraceLevel}); err != nil {
+		t.Fatalf("SetConfiguredModules() failed: %v", err)
+	}
+	modem := levels.Module("modem")
+	connectivity := levels.Module("connectivity")
+	if modem.GetLevel() != logrus.TraceLevel || connectivity.GetLevel() != logrus.InfoLevel {
+		t.Errorf("Expected modem at trace and connectivity at the global level, got %s and %s",
+			modem.GetLevel(), connectivity.GetLevel())
+	}
+
+	// Modules without a level of their own follow the global one
+	levels.Set(logrus.WarnLevel, 0)
+	if connectivity.GetLevel() != logrus.WarnLevel || modem.GetLevel() != logrus.TraceLevel {
+		t.Errorf("Expected only connectivity to follow the global level, got %s and %s",
+			connectivity.GetLevel(), modem.GetLevel())
+	}
+
+	if err := levels.SetModule("connectivity", logrus.DebugLevel, 5*time.Minute); err != nil {
+		t.Fatalf("SetModule() failed: %v", err)
+	}
+	status := levels.Status().Modules["connectivity"]
+	if status.Level != "debug" || status.Until == nil {
+		t.Errorf("Expected a temporary debug level for connectivity, got %+v", status)
+	}
+	fake.BlockUntil(1)
+	fake.Advance(5 * time.Minute)
+	waitForLevel(t, connectivity, logrus.WarnLevel)
+
+	if err := levels.SetModule("router", logrus.DebugLevel, 0); err == nil {
+		t.Error("Expected an error for an unknown module")
+	}
+
+	// Module entries share the output and name the module
+	var replaced bytes.Buffer
+	levels.SetOutput(&logrus.JSONFormatter{}, &replaced)
+	modem.Trace("handshake")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(replaced.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON entry on the new output, got %q", replaced.String())
+	}
+	if entry[ModuleField] != "modem" || entry["msg"] != "handshake" {
+		t.Errorf("Expected the entry to name the modem module, got %v", entry)
+	}
+}
+
+func TestParseModuleLevels(t *testing.T) {
+	levels, err := ParseModuleLevels([]string{"connectivity=debug", " modem = trace "})
+	if err != nil {
+		t.Fatalf("ParseModuleLevels() failed: %v", err)
+	}
+	if levels["connectivity"] != logrus.DebugLevel || levels["modem"] != logrus.TraceLevel {
+		t.Errorf("Unexpected levels %v", levels)
+	}
+
+	for _, entry := range []string{"modem", "=debug", "router=debug", "modem=loud"} {
+		if _, err := ParseModuleLevels([]string{entry}); err == nil {
+			t.Errorf("Expected %q to be rejected", entry)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package modem
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Arris Surfboard (SB6190/SB8200) page paths
+const (
+	arrisStatusPath = "/cmconnectionstatus.html"
+	arrisInfoPath   = "/cmswinfo.html"
+	arrisConfigPath = "/cmconfiguration.html"
+)
+
+func init() {
+	Register(TypeArrisSB, func(opts Options, logger *logrus.Logger) Driver {
+		return NewArrisSB(opts, logger)
+	})
+}
+
+// ArrisSB drives Arris Surfboard SB6190/SB8200 modems.
+//
+// Newer firmware protects the status pages with a credential token: the
+// client requests the status page with "?login_<base64(user:pass)>" and
+// receives a token that must be appended as "?ct_<token>" to later requests.
+// Older firmware serves the pages without authentication.
+type ArrisSB struct {
+	opts       Options
+	httpClient *http.Client
+	logger     *logrus.Logger
+	baseURL    string
+
+	mu            sync.Mutex
+	token         string
+	authenticated bool
+}
+
+// NewArrisSB creates a new Arris Surfboard driver
+func NewArrisSB(opts Options, logger *logrus.Logger) *ArrisSB {
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.InfoLevel)
+	}
+
+	return &ArrisSB{
+		opts:       opts,
+		httpClient: newHTTPClient(opts, 30*time.Second),
+		logger:     logger,
+		baseURL:    baseURL(opts, "https"),
+	}
+}
+
+// Name returns the driver's modem type
+func (a *ArrisSB) Name() string {
+	return TypeArrisSB
+}
+
+// Login obtains a credential token from the modem
+func (a *ArrisSB) Login(ctx context.Context) error {
+	a.logger.Debug("Performing Arris Surfboard login")
+
+	credentials := base64.StdEncoding.EncodeToString([]byte(a.opts.Username + ":" + a.opts.Password))
+	loginURL := a.baseURL + arrisStatusPath + "?login_" + credentials
+
+	req, err := http.NewRequestWithContext(ctx, "GET", loginURL, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(a.opts.Username, a.opts.Password)
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("login request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read login response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%w: status %d", ErrAuthFailed, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected login response status: %d", resp.StatusCode)
+	}
+
+	token := strings.TrimSpace(string(body))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Older firmware ignores the login query and returns the status page itself
+	if strings.HasPrefix(token, "<") {
+		a.logger.Debug("Arris firmware does not require authentication")
+		a.token = ""
+		a.authenticated = true
+		return nil
+	}
+
+	if token == "" {
+		return fmt.Errorf("%w: empty credential token", ErrAuthFailed)
+	}
+
+	a.token = token
+	a.authenticated = true
+	a.logger.Info("Arris Surfboard authentication successful")
+	return nil
+}
+
+// GetStatus fetches and parses the connection status page
+func (a *ArrisSB) GetStatus(ctx context.Context) (*Status, error) {
+	body, err := a.getPage(ctx, arrisStatusPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch status page: %w", err)
+	}
+
+	rows := parseHTMLRows(body)
+	status := &Status{
+		Downstream:   parseArrisDownstream(findSection(rows, "Downstream Bonded Channels")),
+		Upstream:     parseArrisUpstream(findSection(rows, "Upstream Bonded Channels")),
+		TemperatureC: findTemperature(rows),
+		Mode:         ModeModem,
+		FetchedAt:    time.Now(),
+	}
+
+	// Product information lives on a separate page; it is optional
+	if info, err := a.getPage(ctx, arrisInfoPath); err == nil {
+		infoRows := parseHTMLRows(info)
+		status.Model = findValue(infoRows, "Model Name")
+		if status.Model == "" {
+			status.Model = findValue(infoRows, "Hardware Version")
+		}
+		status.FirmwareVersion = findValue(infoRows, "Software Version")
+		if status.TemperatureC == nil {
+			status.TemperatureC = findTemperature(infoRows)
+		}
+	} else {
+		a.logger.WithError(err).Debug("Failed to fetch Arris product information")
+	}
+
+	return status, nil
+}
+
+// Reboot submits the reboot form on the configuration page
+func (a *ArrisSB) Reboot(ctx context.Context) error {
+	a.logger.Info("Sending reboot command to Arris Surfboard modem")
+
+	err := a.postReboot(ctx)
+	if err == ErrSessionExpired {
+		a.logger.Info("Retrying reboot after authentication refresh")
+		if loginErr := a.Login(ctx); loginErr != nil {
+			return fmt.Errorf("re-authentication failed: %w", loginErr)
+		}
+		err = a.postReboot(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("reboot command failed: %w", err)
+	}
+
+	a.logger.Info("Reboot command sent successfully")
+	return nil
+}
+
+// postReboot posts the reboot form once
+func (a *ArrisSB) postReboot(ctx context.Context) error {
+	if err := a.ensureLogin(ctx); err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	form.Set("Rebooting", "1")
+	form.Set("RebootAction", "1")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.pageURL(arrisConfigPath), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("reboot request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		a.clearSession()
+		return ErrSessionExpired
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%w: status %d", werrors.ErrRebootRejected, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// getPage fetches a page, logging in first and re-authenticating once on session expiry
+func (a *ArrisSB) getPage(ctx context.Context, path string) (string, error) {
+	body, err := a.fetch(ctx, path)
+	if err == ErrSessionExpired {
+		a.logger.Debug("Arris session expired, re-authenticating")
+		if loginErr := a.Login(ctx); loginErr != nil {
+			return "", loginErr
+		}
+		body, err = a.fetch(ctx, path)
+	}
+	return body, err
+}
+
+// fetch performs a single authenticated GET request
+func (a *ArrisSB) fetch(ctx context.Context, path string) (string, error) {
+	if err := a.ensureLogin(ctx); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", a.pageURL(path), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	content := string(body)
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden ||
+		isArrisLoginPage(content) {
+		a.clearSession()
+		return "", ErrSessionExpired
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response status %d for %s", resp.StatusCode, path)
+	}
+
+	return content, nil
+}
+
+// ensureLogin logs in when no session is active
+func (a *ArrisSB) ensureLogin(ctx context.Context) error {
+	a.mu.Lock()
+	authenticated := a.authenticated
+	a.mu.Unlock()
+
+	if authenticated {
+		return nil
+	}
+	return a.Login(ctx)
+}
+
+// clearSession drops the current credential token
+func (a *ArrisSB) clearSession() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+	a.authenticated = false
+}
+
+// pageURL builds a page URL including the credential token if one is held
+func (a *ArrisSB) pageURL(path string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token == "" {
+		return a.baseURL + path
+	}
+	return a.baseURL + path + "?ct_" + a.token
+}
+
+// isArrisLoginPage detects the login form served when a token is missing or stale
+func isArrisLoginPage(body string) bool {
+	return strings.Contains(body, `id="loginUsername"`) || strings.Contains(body, `name="loginUsername"`)
+}
+
+// parseArrisDownstream parses rows of the "Downstream Bonded Channels" table:
+// Channel ID | Lock Status | Modulation | Frequency | Power | SNR/MER | Corrected | Uncorrectables
+func parseArrisDownstream(rows [][]string) []Channel {
+	var channels []Channel
+	for _, row := range rows {
+		if len(row) < 8 || !isNumeric(row[0]) {
+			continue
+		}
+		channels = append(channels, Channel{
+			ChannelID:      int(parseLeadingInt(row[0])),
+			LockStatus:     row[1],
+			Modulation:     row[2],
+			FrequencyMHz:   parseFrequencyMHz(row[3]),
+			PowerDBmV:      parseLeadingFloat(row[4]),
+			SNRDB:          parseLeadingFloat(row[5]),
+			Corrected:      parseLeadingInt(row[6]),
+			Uncorrectables: parseLeadingInt(row[7]),
+		})
+	}
+	return channels
+}
+
+// parseArrisUpstream parses rows of the "Upstream Bonded Channels" table:
+// Channel | Channel ID | Lock Status | US Channel Type | Frequency | Width | Power
+func parseArrisUpstream(rows [][]string) []Channel {
+	var channels []Channel
+	for _, row := range rows {
+		if len(row) < 7 || !isNumeric(row[0]) {
+			continue
+		}
+		channels = append(channels, Channel{
+			ChannelID:    int(parseLeadingInt(row[1])),
+			LockStatus:   row[2],
+			Modulation:   row[3],
+			FrequencyMHz: parseFrequencyMHz(row[4]),
+			PowerDBmV:    parseLeadingFloat(row[6]),
+		})
+	}
+	return channels
+}
@@ -0,0 +1,210 @@
+package modem
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const arrisStatusHTML = `<html><body><table>
+<tr><th colspan=8><strong>Downstream Bonded Channels</strong></th></tr>
+<tr><td><strong>Channel ID</strong></td><td><strong>Lock Status</strong></td><td><strong>Modulation</strong></td>
+<td><strong>Frequency</strong></td><td><strong>Power</strong></td><td><strong>SNR/MER</strong></td>
+<td><strong>Corrected</strong></td><td><strong>Uncorrectables</strong></td></tr>
+<tr><td>5</td><td>Locked</td><td>QAM256</td><td>795000000 Hz</td><td>3.2 dBmV</td><td>40.1 dB</td><td>12</td><td>3</td></tr>
+<tr><td>6</td><td>Locked</td><td>QAM256</td><td>801000000 Hz</td><td>-1.5 dBmV</td><td>38.9 dB</td><td>0</td><td>0</td></tr>
+</table><table>
+<tr><th colspan=7><strong>Upstream Bonded Channels</strong></th></tr>
+<tr><td>Channel</td><td>Channel ID</td><td>Lock Status</td><td>US Channel Type</td><td>Frequency</td><td>Width</td><td>Power</td></tr>
+<tr><td>1</td><td>2</td><td>Locked</td><td>SC-QAM Upstream</td><td>36400000 Hz</td><td>6400000 Hz</td><td>44.0 dBmV</td></tr>
+</table></body></html>`
+
+const arrisInfoHTML = `<table>
+<tr><td>Model Name:</td><td>SB8200</td></tr>
+<tr><td>Software Version</td><td>AB01.01.009.32_051619_183.0A.NSH</td></tr>
+</table>`
+
+const arrisLoginHTML = `<form><input id="loginUsername" name="loginUsername"></form>`
+
+// fakeArris emulates the token-based authentication of newer SB8200 firmware
+type fakeArris struct {
+	mu          sync.Mutex
+	token       string
+	reboots     int
+	logins      int
+	expireToken bool
+	// rejectReboot answers the reboot command with a server error
+	rejectReboot bool
+}
+
+func (f *fakeArris) handler(t *testing.T) http.Handler {
+	credentials := base64.StdEncoding.EncodeToString([]byte("admin:secret"))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		query := r.URL.RawQuery
+		if strings.HasPrefix(query, "login_") {
+			f.logins++
+			if strings.TrimPrefix(query, "login_") != credentials {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			f.token = "tok" + strings.Repeat("x", f.logins)
+			w.Write([]byte(f.token))
+			return
+		}
+
+		if f.expireToken {
+			f.expireToken = false
+			f.token = ""
+		}
+		if f.token == "" || query != "ct_"+f.token {
+			w.Write([]byte(arrisLoginHTML))
+			return
+		}
+
+		switch r.URL.Path {
+		case arrisStatusPath:
+			w.Write([]byte(arrisStatusHTML))
+		case arrisInfoPath:
+			w.Write([]byte(arrisInfoHTML))
+		case arrisConfigPath:
+			if r.Method != http.MethodPost || r.FormValue("Rebooting") != "1" {
+				t.Errorf("Unexpected reboot request: %s %v", r.Method, r.Form)
+			}
+			if f.rejectReboot {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			f.reboots++
+			w.Write([]byte("OK"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func newTestArris(t *testing.T, fake *fakeArris, password string) *ArrisSB {
+	server := httptest.NewTLSServer(fake.handler(t))
+	t.Cleanup(server.Close)
+
+	return NewArrisSB(Options{
+		Host:     strings.TrimPrefix(server.URL, "https://"),
+		Username: "admin",
+		Password: password,
+		NoVerify: true,
+	}, logrus.New())
+}
+
+func TestArrisGetStatus(t *testing.T) {
+	fake := &fakeArris{}
+	driver := newTestArris(t, fake, "secret")
+
+	status, err := driver.GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatus() failed: %v", err)
+	}
+
+	if status.Model != "SB8200" {
+		t.Errorf("Expected model SB8200, got %q", status.Model)
+	}
+	if !strings.HasPrefix(status.FirmwareVersion, "AB01") {
+		t.Errorf("Unexpected firmware version %q", status.FirmwareVersion)
+	}
+	if len(status.Downstream) != 2 {
+		t.Fatalf("Expected 2 downstream channels, got %d", len(status.Downstream))
+	}
+
+	ds := status.Downstream[0]
+	if ds.ChannelID != 5 || !ds.Locked() || ds.FrequencyMHz != 795 || ds.PowerDBmV != 3.2 ||
+		ds.SNRDB != 40.1 || ds.Corrected != 12 || ds.Uncorrectables != 3 {
+		t.Errorf("Unexpected downstream channel: %+v", ds)
+	}
+
+	if len(status.Upstream) != 1 {
+		t.Fatalf("Expected 1 upstream channel, got %d", len(status.Upstream))
+	}
+	if us := status.Upstream[0]; us.ChannelID != 2 || us.PowerDBmV != 44.0 || us.FrequencyMHz != 36.4 {
+		t.Errorf("Unexpected upstream channel: %+v", us)
+	}
+}
+
+func TestArrisLoginFailure(t *testing.T) {
+	driver := newTestArris(t, &fakeArris{}, "wrong")
+
+	err := driver.Login(context.Background())
+	if !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("Expected ErrAuthFailed, got %v", err)
+	}
+}
+
+func TestArrisReauthenticatesOnSessionExpiry(t *testing.T) {
+	fake := &fakeArris{}
+	driver := newTestArris(t, fake, "secret")
+
+	if err := driver.Login(context.Background()); err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+
+	fake.mu.Lock()
+	fake.expireToken = true
+	fake.mu.Unlock()
+
+	if _, err := driver.GetStatus(context.Background()); err != nil {
+		t.Fatalf("GetStatus() after session expiry failed: %v", err)
+	}
+	if fake.logins != 2 {
+		t.Errorf("Expected 2 logins, got %d", fake.logins)
+	}
+}
+
+func TestArrisReboot(t *testing.T) {
+	fake := &fakeArris{}
+	driver := newTestArris(t, fake, "secret")
+
+	if err := driver.Reboot(context.Background()); err != nil {
+		t.Fatalf("Reboot() failed: %v", err)
+	}
+	if fake.reboots != 1 {
+		t.Errorf("Expected 1 reboot, got %d", fake.reboots)
+	}
+}
+
+func TestArrisUnauthenticatedFirmware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(arrisStatusHTML))
+	}))
+	defer server.Close()
+
+	driver := NewArrisSB(Options{
+		Host:   strings.TrimPrefix(server.URL, "http://"),
+		Scheme: "http",
+	}, nil)
+
+	status, err := driver.GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatus() failed: %v", err)
+	}
+	if len(status.Downstream) != 2 {
+		t.Errorf("Expected 2 downstream channels, got %d", len(status.Downstream))
+	}
+}
+
+func TestArrisRebootRejected(t *testing.T) {
+	driver := newTestArris(t, &fakeArris{rejectReboot: true}, "secret")
+
+	err := driver.Reboot(context.Background())
+	if !errors.Is(err, werrors.ErrRebootRejected) {
+		t.Errorf("Expected ErrRebootRejected, got %v", err)
+	}
+}
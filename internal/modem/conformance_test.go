@@ -0,0 +1,20 @@
+package modem_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem/modemtest"
+)
+
+// TestDriverConformance runs the replayed transcripts in
+// testdata/conformance/<type> against every registered driver
+func TestDriverConformance(t *testing.T) {
+	for _, modemType := range modem.SupportedTypes() {
+		modemType := modemType
+		t.Run(modemType, func(t *testing.T) {
+			modemtest.RunConformance(t, modemType, filepath.Join("testdata", "conformance", modemType))
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package modem
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Supported modem types
+const (
+	TypeMB8600      = "mb8600"
+	TypeArrisSB     = "arris-sb"
+	TypeNetgearCM   = "netgear-cm"
+	TypeTechnicolor = "technicolor"
+)
+
+// Operating modes reported in Status.Mode
+const (
+	// ModeModem is a standalone cable modem without routing functions
+	ModeModem = "modem"
+	// ModeRouter is a combo gateway performing NAT and routing
+	ModeRouter = "router"
+	// ModeBridge is a combo gateway with routing disabled
+	ModeBridge = "bridge"
+)
+
+// DefaultType is used when no modem type is configured
+const DefaultType = TypeMB8600
+
+// Common driver errors. ErrAuthFailed is errors.ErrAuthFailed, so callers
+// outside the modem package can test for it without importing it.
+var (
+	ErrAuthFailed     = werrors.ErrAuthFailed
+	ErrSessionExpired = errors.New("modem session expired")
+	ErrUnknownType    = errors.New("unknown modem type")
+)
+
+// Driver is implemented by every supported modem family
+type Driver interface {
+	// Name returns the modem type the driver was registered under
+	Name() string
+	// Login authenticates against the modem web interface
+	Login(ctx context.Context) error
+	// GetStatus fetches the current modem status and channel information
+	GetStatus(ctx context.Context) (*Status, error)
+	// Reboot sends the reboot command to the modem
+	Reboot(ctx context.Context) error
+}
+
+// CycleMonitor is implemented by drivers that monitor the reboot cycle themselves
+type CycleMonitor interface {
+	RebootWithMonitoring(ctx context.Context, pollInterval, maxOfflineWait, maxOnlineWait time.Duration) (*RebootCycleResult, error)
+}
+
+// AccessMethodDriver is implemented by drivers that can reach the modem in
+// more than one way, such as over HTTP or HTTPS. They try the method that
+// worked last first and fall back to the others only when it fails; keeping
+// it across restarts saves the failed attempts.
+type AccessMethodDriver interface {
+	// AccessMethod returns the method tried first, the last one that worked
+	AccessMethod() string
+	// SetAccessMethod makes method the one tried first
+	SetAccessMethod(method string) error
+}
+
+// AccessMethodOf returns the access method d tries first, or an empty
+// string when d knows a single one
+func AccessMethodOf(d Driver) string {
+	if methods, ok := unwrap(d).(AccessMethodDriver); ok {
+		return methods.AccessMethod()
+	}
+	return ""
+}
+
+// SetAccessMethod makes method the one d tries first; drivers that know a
+// single method ignore it
+func SetAccessMethod(d Driver, method string) error {
+	if methods, ok := unwrap(d).(AccessMethodDriver); ok {
+		return methods.SetAccessMethod(method)
+	}
+	return nil
+}
+
+// unwrap returns the driver under the wrappers of d, such as WithRetry
+func unwrap(d Driver) Driver {
+	for {
+		wrapper, ok := d.(interface{ Unwrap() Driver })
+		if !ok {
+			return d
+		}
+		d = wrapper.Unwrap()
+	}
+}
+
+// Status represents the modem status reported by a driver
+type Status struct {
+	Model           string    `json:"model,omitempty"`
+	FirmwareVersion string    `json:"firmware_version,omitempty"`
+	Mode            string    `json:"mode,omitempty"`
+	Downstream      []Channel `json:"downstream,omitempty"`
+	Upstream        []Channel `json:"upstream,omitempty"`
+	// TemperatureC is the modem's temperature in degrees Celsius, nil
+	// unless its firmware shows it
+	TemperatureC *float64 `json:"temperature_c,omitempty"`
+	// Timeouts are the T3 and T4 timeouts the watchdog counted in the event
+	// log, nil for drivers that cannot read it
+	Timeouts  *TimeoutCounts `json:"timeouts,omitempty"`
+	FetchedAt time.Time      `json:"fetched_at"`
+}
+
+// Channel represents a single DOCSIS downstream or upstream channel
+type Channel struct {
+	ChannelID      int     `json:"channel_id"`
+	LockStatus     string  `json:"lock_status,omitempty"`
+	Modulation     string  `json:"modulation,omitempty"`
+	FrequencyMHz   float64 `json:"frequency_mhz"`
+	PowerDBmV      float64 `json:"power_dbmv"`
+	SNRDB          float64 `json:"snr_db,omitempty"`
+	Corrected      int64   `json:"corrected,omitempty"`
+	Uncorrectables int64   `json:"uncorrectables,omitempty"`
+}
+
+// Locked reports whether the channel is locked
+func (c Channel) Locked() bool {
+	return strings.EqualFold(c.LockStatus, "locked")
+}
+
+// UnlockedDownstream returns the downstream channels the modem reports as
+// not locked; channels without a lock status are left out
+func (s *Status) UnlockedDownstream() []Channel {
+	var unlocked []Channel
+	for _, channel := range s.Downstream {
+		if channel.LockStatus != "" && !channel.Locked() {
+			unlocked = append(unlocked, channel)
+		}
+	}
+	return unlocked
+}
+
+// RebootCycleResult represents the outcome of a monitored reboot cycle
+type RebootCycleResult struct {
+	Success         bool
+	TotalDuration   time.Duration
+	OfflineDuration time.Duration
+	OfflineDetected bool
+	OnlineRestored  bool
+	TimeoutReached  bool
+	Error           error
+}
+
+// Options holds the settings shared by all drivers
+type Options struct {
+	Host     string
+	Username string
+	Password string
+	NoVerify bool
+	// Port overrides the default port of the scheme, unless Host has one
+	Port int
+	// Scheme overrides the driver's default URL scheme ("http" or "https")
+	Scheme string
+	// Transport overrides the HTTP transport (used by tests and capture tools)
+	Transport http.RoundTripper
+	// Timeout for individual HTTP requests, 0 uses the driver default
+	Timeout time.Duration
+}
+
+// Factory creates a driver from options
+type Factory func(opts Options, logger *logrus.Logger) Driver
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a driver available under the given modem type
+func Register(modemType string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("modem: Register factory is nil")
+	}
+	registry[strings.ToLower(modemType)] = factory
+}
+
+// New creates the driver registered for modemType, empty selects DefaultType
+func New(modemType string, opts Options, logger *logrus.Logger) (Driver, error) {
+	if modemType == "" {
+		modemType = DefaultType
+	}
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.InfoLevel)
+	}
+
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(modemType)]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s (supported: %s)", ErrUnknownType, modemType, strings.Join(SupportedTypes(), ", "))
+	}
+
+	return factory(opts, logger), nil
+}
+
+// SupportedTypes returns the registered modem types in sorted order
+func SupportedTypes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for name := range registry {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// newHTTPClient creates an HTTP client with a cookie jar for session handling
+func newHTTPClient(opts Options, defaultTimeout time.Duration) *http.Client {
+	jar, _ := cookiejar.New(nil)
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	// The modem is on the local network, or behind the tunnel of
+	// opts.Transport, so the transport has no proxy whatever HTTP_PROXY and
+	// OutboundProxy say
+	transport := opts.Transport
+	if transport == nil {
+		transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: opts.NoVerify,
+			},
+		}
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: unreachableTransport{transport},
+		Jar:       jar,
+	}
+}
+
+// unreachableTransport marks requests that got no answer with
+// errors.ErrModemUnreachable
+type unreachableTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t unreachableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, werrors.Mark(err, werrors.ErrModemUnreachable)
+	}
+	return resp, nil
+}
+
+// baseURL builds the base URL for the modem using the given default scheme
+func baseURL(opts Options, defaultScheme string) string {
+	scheme := opts.Scheme
+	if scheme == "" {
+		scheme = defaultScheme
+	}
+	return fmt.Sprintf("%s://%s", scheme, opts.address())
+}
+
+// address returns the host and port the modem answers on
+func (o Options) address() string {
+	if o.Port == 0 {
+		return o.Host
+	}
+	if _, _, err := net.SplitHostPort(o.Host); err == nil {
+		return o.Host
+	}
+	return net.JoinHostPort(o.Host, strconv.Itoa(o.Port))
+}
@@ -0,0 +1,104 @@
+package modem
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func TestNewDefaultsToMB8600(t *testing.T) {
+	driver, err := New("", Options{Host: "192.0.2.1", Username: "admin", Password: "motorola"}, logrus.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if driver.Name() != TypeMB8600 {
+		t.Errorf("Expected default driver %s, got %s", TypeMB8600, driver.Name())
+	}
+}
+
+func TestNewUnknownType(t *testing.T) {
+	_, err := New("no-such-modem", Options{}, nil)
+	if !errors.Is(err, ErrUnknownType) {
+		t.Errorf("Expected ErrUnknownType, got %v", err)
+	}
+}
+
+func TestNewIsCaseInsensitive(t *testing.T) {
+	driver, err := New("ARRIS-SB", Options{Host: "192.0.2.1"}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if driver.Name() != TypeArrisSB {
+		t.Errorf("Expected %s driver, got %s", TypeArrisSB, driver.Name())
+	}
+}
+
+func TestConfigModemTypesAreRegistered(t *testing.T) {
+	registered := make(map[string]bool)
+	for _, name := range SupportedTypes() {
+		registered[name] = true
+	}
+
+	for _, modemType := range config.SupportedModemTypes {
+		if !registered[modemType] {
+			t.Errorf("Modem type %s accepted by config has no registered driver", modemType)
+		}
+	}
+	if !registered[config.DefaultModemType] {
+		t.Errorf("Default modem type %s has no registered driver", config.DefaultModemType)
+	}
+}
+
+func TestChannelLocked(t *testing.T) {
+	if !(Channel{LockStatus: "Locked"}).Locked() {
+		t.Error("Expected 'Locked' channel to be locked")
+	}
+	if (Channel{LockStatus: "Not Locked"}).Locked() {
+		t.Error("Expected 'Not Locked' channel to be unlocked")
+	}
+}
+
+func TestUnlockedDownstream(t *testing.T) {
+	status := &Status{
+		Downstream: []Channel{
+			{ChannelID: 1, LockStatus: "Locked"},
+			{ChannelID: 2, LockStatus: "Not Locked"},
+			{ChannelID: 3},
+			{ChannelID: 4, LockStatus: "Not Locked"},
+		},
+		Upstream: []Channel{{ChannelID: 1, LockStatus: "Not Locked"}},
+	}
+	unlocked := status.UnlockedDownstream()
+	if len(unlocked) != 2 || unlocked[0].ChannelID != 2 || unlocked[1].ChannelID != 4 {
+		t.Errorf("Expected downstream channels 2 and 4 unlocked, got %+v", unlocked)
+	}
+}
+
+func TestUnreachableModem(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve a port: %v", err)
+	}
+	host := listener.Addr().String()
+	listener.Close()
+
+	for _, modemType := range SupportedTypes() {
+		driver, err := New(modemType, Options{Host: host, Username: "admin", Password: "secret", Timeout: time.Second}, logrus.New())
+		if err != nil {
+			t.Fatalf("New(%s) failed: %v", modemType, err)
+		}
+		err = driver.Login(context.Background())
+		if !errors.Is(err, werrors.ErrModemUnreachable) {
+			t.Errorf("%s: expected ErrModemUnreachable, got %v", modemType, err)
+		}
+		if errors.Is(err, ErrAuthFailed) {
+			t.Errorf("%s: expected an unreachable modem not to be an authentication failure", modemType)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package modem
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is an entry of the modem's event log
+type Event struct {
+	// Time is when the modem logged the event; zero when the modem had no
+	// time yet, as before it registered
+	Time        time.Time `json:"time,omitempty"`
+	Priority    string    `json:"priority,omitempty"`
+	Description string    `json:"description"`
+}
+
+// EventLogDriver is implemented by drivers that can read the modem's event
+// log
+type EventLogDriver interface {
+	GetEventLog(ctx context.Context) ([]Event, error)
+}
+
+// EventLog reads the event log of d; ok is false for drivers that cannot
+// read one
+func EventLog(ctx context.Context, d Driver) (events []Event, ok bool, err error) {
+	reader, ok := unwrap(d).(EventLogDriver)
+	if !ok {
+		return nil, false, nil
+	}
+	events, err = reader.GetEventLog(ctx)
+	return events, true, err
+}
+
+// DOCSIS timeouts. A T3 timeout is a ranging request the CMTS did not
+// answer, a T4 timeout a CMTS that stopped polling the modem; both point at
+// upstream noise or a failing line.
+const (
+	TimeoutT3 = "t3"
+	TimeoutT4 = "t4"
+)
+
+// timeoutSpellings normalizes the ways modems spell a timeout
+var timeoutSpellings = strings.NewReplacer("time-out", "timeout", "time out", "timeout")
+
+// TimeoutOf returns the timeout an event description reports, TimeoutT3 or
+// TimeoutT4, or an empty string for other events
+func TimeoutOf(description string) string {
+	normalized := timeoutSpellings.Replace(strings.ToLower(description))
+	switch {
+	case strings.Contains(normalized, "t3 timeout"):
+		return TimeoutT3
+	case strings.Contains(normalized, "t4 timeout"):
+		return TimeoutT4
+	}
+	return ""
+}
+
+// TimeoutCounts are the T3 and T4 timeouts of the last hour and day
+type TimeoutCounts struct {
+	T3Hour int `json:"t3_hour"`
+	T3Day  int `json:"t3_day"`
+	T4Hour int `json:"t4_hour"`
+	T4Day  int `json:"t4_day"`
+}
+
+// Hour returns the timeouts of the last hour
+func (c TimeoutCounts) Hour() int {
+	return c.T3Hour + c.T4Hour
+}
+
+// Day returns the timeouts of the last day
+func (c TimeoutCounts) Day() int {
+	return c.T3Day + c.T4Day
+}
+
+// TimeoutCounter counts the timeouts of successive reads of the event log.
+// The log repeats the events of earlier reads, so each event is counted
+// once; one without a time counts from when it was first read.
+type TimeoutCounter struct {
+	mu   sync.Mutex
+	seen map[timeoutKey]time.Time
+}
+
+// timeoutKey identifies a logged timeout across reads
+type timeoutKey struct {
+	kind, logged, description string
+}
+
+// NewTimeoutCounter creates a counter without timeouts
+func NewTimeoutCounter() *TimeoutCounter {
+	return &TimeoutCounter{seen: make(map[timeoutKey]time.Time)}
+}
+
+// Observe counts the timeouts of events read at now and forgets those
+// older than a day
+func (c *TimeoutCounter) Observe(events []Event, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, event := range events {
+		kind := TimeoutOf(event.Description)
+		if kind == "" {
+			continue
+		}
+		key := timeoutKey{kind, event.Time.String(), event.Description}
+		if _, seen := c.seen[key]; seen {
+			continue
+		}
+		at := event.Time
+		// A modem clock ahead of ours cannot place the event
+		if at.IsZero() || at.After(now) {
+			at = now
+		}
+		c.seen[key] = at
+	}
+	for key, at := range c.seen {
+		if now.Sub(at) > 24*time.Hour {
+			delete(c.seen, key)
+		}
+	}
+}
+
+// Counts returns the timeouts of the hour and day before now
+func (c *TimeoutCounter) Counts(now time.Time) TimeoutCounts {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var counts TimeoutCounts
+	for key, at := range c.seen {
+		age := now.Sub(at)
+		if age > 24*time.Hour {
+			continue
+		}
+		hour := age <= time.Hour
+		switch key.kind {
+		case TimeoutT3:
+			counts.T3Day++
+			if hour {
+				counts.T3Hour++
+			}
+		case TimeoutT4:
+			counts.T4Day++
+			if hour {
+				counts.T4Hour++
+			}
+		}
+	}
+	return counts
+}
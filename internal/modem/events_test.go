@@ -0,0 +1,52 @@
+package modem
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMotoLog(t *testing.T) {
+	raw := "11:40:03^\n Thu Oct 7 2021^3^No Ranging Response received - T3 time-out;CM-MAC=aa:bb:cc:dd:ee:ff;}-{" +
+		"Time Not Established^^5^Started Unicast Maintenance Ranging - No Response received - T3 time-out}-{" +
+		"09:02:15 Fri Oct 8 2021^^6^Cable Modem Reboot due to power button press"
+	events := parseMotoLog(raw, time.UTC)
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %+v", events)
+	}
+	if want := time.Date(2021, 10, 7, 11, 40, 3, 0, time.UTC); !events[0].Time.Equal(want) || events[0].Priority != "3" {
+		t.Errorf("Unexpected first event %+v", events[0])
+	}
+	if !events[1].Time.IsZero() {
+		t.Errorf("Expected no time before the modem had one, got %v", events[1].Time)
+	}
+	if TimeoutOf(events[0].Description) != TimeoutT3 || TimeoutOf(events[2].Description) != "" {
+		t.Errorf("Unexpected timeouts of %+v", events)
+	}
+	if TimeoutOf("Received Response to Broadcast Maintenance Request, But no Unicast Maintenance opportunities received - T4 time out") != TimeoutT4 ||
+		TimeoutOf("SYNC Timing Synchronization failure - Failed to acquire QAM/QPSK symbol timing") != "" {
+		t.Error("Unexpected T4 classification")
+	}
+}
+
+func TestTimeoutCounter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	counter := NewTimeoutCounter()
+	log := []Event{
+		{Time: now.Add(-10 * time.Minute), Description: "No Ranging Response received - T3 time-out"},
+		{Time: now.Add(-5 * time.Hour), Description: "No Ranging Response received - T3 time-out"},
+		{Time: now.Add(-30 * time.Hour), Description: "No Ranging Response received - T3 time-out"},
+		{Description: "T4 timeout, no station maintenance opportunities"},
+		{Time: now.Add(-time.Minute), Description: "Cable Modem Reboot"},
+	}
+	counter.Observe(log, now)
+	// The same log read again counts nothing twice
+	counter.Observe(log, now.Add(time.Minute))
+
+	want := TimeoutCounts{T3Hour: 1, T3Day: 2, T4Hour: 1, T4Day: 1}
+	if got := counter.Counts(now.Add(time.Minute)); got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if got := counter.Counts(now.Add(2 * time.Hour)); got.Hour() != 0 || got.Day() != 3 {
+		t.Errorf("Expected the timeouts to leave the hour, got %+v", got)
+	}
+}
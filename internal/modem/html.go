@@ -0,0 +1,174 @@
+package modem
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	tagPattern        = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+	leadingNumber     = regexp.MustCompile(`^[-+]?[0-9]*\.?[0-9]+`)
+)
+
+// parseHTMLRows extracts the text of every table row in an HTML document.
+// Nested tables are flattened: an inner row starts a new row, which keeps
+// the parser robust against the layout tables used by modem status pages.
+func parseHTMLRows(body string) [][]string {
+	var rows [][]string
+	var row []string
+	var cell strings.Builder
+	inCell := false
+
+	flushRow := func() {
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+		row = nil
+	}
+	flushCell := func() {
+		if inCell {
+			row = append(row, cleanText(cell.String()))
+			cell.Reset()
+			inCell = false
+		}
+	}
+
+	pos := 0
+	for _, loc := range tagPattern.FindAllStringIndex(body, -1) {
+		if inCell {
+			cell.WriteString(body[pos:loc[0]])
+		}
+		pos = loc[1]
+
+		tag := strings.ToLower(body[loc[0]:loc[1]])
+		switch {
+		case strings.HasPrefix(tag, "<tr"):
+			flushCell()
+			flushRow()
+		case strings.HasPrefix(tag, "</tr"):
+			flushCell()
+			flushRow()
+		case strings.HasPrefix(tag, "<td"), strings.HasPrefix(tag, "<th"):
+			flushCell()
+			inCell = true
+		case strings.HasPrefix(tag, "</td"), strings.HasPrefix(tag, "</th"):
+			flushCell()
+		case strings.HasPrefix(tag, "<table"), strings.HasPrefix(tag, "</table"):
+			flushCell()
+			flushRow()
+		case strings.HasPrefix(tag, "<br"):
+			if inCell {
+				cell.WriteString(" ")
+			}
+		}
+	}
+	flushCell()
+	flushRow()
+
+	return rows
+}
+
+// findSection returns the rows following the title row that contains title,
+// stopping at the next single-cell title row
+func findSection(rows [][]string, title string) [][]string {
+	title = strings.ToLower(title)
+	for i, row := range rows {
+		if len(row) == 1 && strings.Contains(strings.ToLower(row[0]), title) {
+			var section [][]string
+			for _, next := range rows[i+1:] {
+				if len(next) == 1 {
+					break
+				}
+				section = append(section, next)
+			}
+			return section
+		}
+	}
+	return nil
+}
+
+// findValue returns the cell following the key cell in a two-column row
+func findValue(rows [][]string, key string) string {
+	key = strings.ToLower(key)
+	for _, row := range rows {
+		for i := 0; i+1 < len(row); i++ {
+			if strings.ToLower(strings.TrimSuffix(row[i], ":")) == key {
+				return row[i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// cleanText unescapes entities and collapses whitespace
+func cleanText(s string) string {
+	s = html.UnescapeString(s)
+	s = whitespacePattern.ReplaceAllString(s, " ")
+	return strings.TrimSpace(s)
+}
+
+// parseLeadingFloat parses the number at the start of values like "3.2 dBmV"
+func parseLeadingFloat(s string) float64 {
+	match := leadingNumber.FindString(strings.TrimSpace(s))
+	if match == "" {
+		return 0
+	}
+	value, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// parseTemperature parses a temperature such as "45 C", "45.5 °C" or
+// "113 °F" into degrees Celsius; nil if s holds no number
+func parseTemperature(s string) *float64 {
+	s = strings.TrimSpace(s)
+	match := leadingNumber.FindString(s)
+	if match == "" {
+		return nil
+	}
+	value, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return nil
+	}
+	if strings.HasSuffix(strings.ToUpper(s), "F") {
+		value = (value - 32) * 5 / 9
+	}
+	return &value
+}
+
+// findTemperature returns the temperature in the rows of a status page,
+// which some firmwares show; nil if it is missing
+func findTemperature(rows [][]string) *float64 {
+	return parseTemperature(findValue(rows, "Temperature"))
+}
+
+// parseLeadingInt parses the integer at the start of a cell value
+func parseLeadingInt(s string) int64 {
+	return int64(parseLeadingFloat(s))
+}
+
+// parseFrequencyMHz parses frequencies given in Hz, kHz or MHz into MHz
+func parseFrequencyMHz(s string) float64 {
+	value := parseLeadingFloat(s)
+	lower := strings.ToLower(s)
+	switch {
+	case strings.Contains(lower, "mhz"):
+		return value
+	case strings.Contains(lower, "khz"):
+		return value / 1e3
+	case strings.Contains(lower, "hz"), value > 1e5:
+		return value / 1e6
+	default:
+		return value
+	}
+}
+
+// isNumeric reports whether a cell starts with a number
+func isNumeric(s string) bool {
+	return leadingNumber.MatchString(strings.TrimSpace(s))
+}
@@ -0,0 +1,96 @@
+package modem
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseHTMLRowsFlattensNestedTables(t *testing.T) {
+	body := `<table><tr><td>outer
+		<table>
+			<tr><th colspan=2><strong>Startup Procedure</strong></th></tr>
+			<tr><td>Boot State</td><td>OK &amp; Operational</td></tr>
+		</table>
+	</td></tr></table>`
+
+	rows := parseHTMLRows(body)
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows, got %d: %v", len(rows), rows)
+	}
+	if rows[1][0] != "Startup Procedure" {
+		t.Errorf("Expected title row, got %v", rows[1])
+	}
+	if rows[2][1] != "OK & Operational" {
+		t.Errorf("Expected unescaped cell text, got %q", rows[2][1])
+	}
+}
+
+func TestFindSectionAndValue(t *testing.T) {
+	rows := [][]string{
+		{"Downstream"},
+		{"ID", "Power"},
+		{"1", "3.2 dBmV"},
+		{"Upstream"},
+		{"ID", "Power"},
+		{"Software Version:", "1.2.3"},
+	}
+
+	section := findSection(rows, "downstream")
+	if len(section) != 2 {
+		t.Fatalf("Expected 2 rows in section, got %d", len(section))
+	}
+	if findSection(rows, "missing") != nil {
+		t.Error("Expected nil for missing section")
+	}
+	if v := findValue(rows, "Software Version"); v != "1.2.3" {
+		t.Errorf("Expected value 1.2.3, got %q", v)
+	}
+}
+
+func TestParseFrequencyMHz(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"795000000 Hz", 795},
+		{"795 MHz", 795},
+		{"36400 kHz", 36.4},
+		{"579000000", 579},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseFrequencyMHz(tt.input); math.Abs(got-tt.expected) > 0.001 {
+			t.Errorf("parseFrequencyMHz(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseLeadingFloat(t *testing.T) {
+	if v := parseLeadingFloat("-4.5 dBmV"); v != -4.5 {
+		t.Errorf("Expected -4.5, got %v", v)
+	}
+	if v := parseLeadingFloat("n/a"); v != 0 {
+		t.Errorf("Expected 0 for non-numeric input, got %v", v)
+	}
+}
+
+func TestParseTemperature(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"45 C", 45},
+		{"45.5 °C", 45.5},
+		{"113 °F", 45},
+		{"52", 52},
+	}
+	for _, tt := range tests {
+		if got := parseTemperature(tt.input); got == nil || math.Abs(*got-tt.expected) > 0.001 {
+			t.Errorf("parseTemperature(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+	if got := parseTemperature("n/a"); got != nil {
+		t.Errorf("Expected no temperature for non-numeric input, got %v", *got)
+	}
+}
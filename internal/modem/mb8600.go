@@ -0,0 +1,254 @@
+package modem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/hnap"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	Register(TypeMB8600, func(opts Options, logger *logrus.Logger) Driver {
+		return NewMB8600(opts, logger)
+	})
+}
+
+// MB8600 drives Motorola MB8600 modems through the HNAP client
+type MB8600 struct {
+	client *hnap.Client
+}
+
+// NewMB8600 creates a new MB8600 driver
+func NewMB8600(opts Options, logger *logrus.Logger) *MB8600 {
+	client := hnap.NewClient(opts.address(), opts.Username, opts.Password, opts.NoVerify, logger)
+	if opts.Transport != nil {
+		client.SetTransport(opts.Transport)
+	}
+	return &MB8600{client: client}
+}
+
+// Name returns the driver's modem type
+func (m *MB8600) Name() string {
+	return TypeMB8600
+}
+
+// Login performs HTML form and HNAP authentication
+func (m *MB8600) Login(ctx context.Context) error {
+	if err := m.client.Login(ctx); err != nil {
+		if errors.Is(err, hnap.ErrLoginFailed) {
+			return fmt.Errorf("%w: %v", ErrAuthFailed, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetStatus queries software and channel information over HNAP
+func (m *MB8600) GetStatus(ctx context.Context) (*Status, error) {
+	responses, err := m.client.GetStatus(ctx)
+	if err != nil {
+		if errors.Is(err, hnap.ErrLoginFailed) {
+			return nil, fmt.Errorf("%w: %v", ErrAuthFailed, err)
+		}
+		return nil, err
+	}
+
+	status := &Status{
+		Model:     "MB8600",
+		Mode:      ModeModem,
+		FetchedAt: time.Now(),
+	}
+
+	software := hnapSection(responses, "GetMotoStatusSoftwareResponse")
+	if model, _ := software["StatusSoftwareModelName"].(string); model != "" {
+		status.Model = model
+	}
+	status.FirmwareVersion, _ = software["StatusSoftwareSfVer"].(string)
+
+	downstream, _ := hnapSection(responses, "GetMotoStatusDownstreamChannelInfoResponse")["MotoConnDownstreamChannel"].(string)
+	status.Downstream = parseMotoDownstream(downstream)
+
+	upstream, _ := hnapSection(responses, "GetMotoStatusUpstreamChannelInfoResponse")["MotoConnUpstreamChannel"].(string)
+	status.Upstream = parseMotoUpstream(upstream)
+	status.TemperatureC = hnapTemperature(responses)
+
+	return status, nil
+}
+
+// GetEventLog implements EventLogDriver
+func (m *MB8600) GetEventLog(ctx context.Context) ([]Event, error) {
+	responses, err := m.client.GetMultipleHNAPs(ctx, "GetMotoStatusLog")
+	if err != nil {
+		if errors.Is(err, hnap.ErrLoginFailed) {
+			return nil, fmt.Errorf("%w: %v", ErrAuthFailed, err)
+		}
+		return nil, err
+	}
+	log, _ := hnapSection(responses, "GetMotoStatusLogResponse")["MotoStatusLogList"].(string)
+	return parseMotoLog(log, time.Local), nil
+}
+
+// AccessMethod implements AccessMethodDriver
+func (m *MB8600) AccessMethod() string {
+	return m.client.AccessMethod().String()
+}
+
+// SetAccessMethod implements AccessMethodDriver
+func (m *MB8600) SetAccessMethod(method string) error {
+	parsed, err := hnap.ParseAccessMethod(method)
+	if err != nil {
+		return err
+	}
+	m.client.SetAccessMethod(parsed)
+	return nil
+}
+
+// Reboot sends the HNAP reboot command
+func (m *MB8600) Reboot(ctx context.Context) error {
+	return m.client.Reboot(ctx)
+}
+
+// RebootWithMonitoring delegates reboot cycle monitoring to the HNAP client
+func (m *MB8600) RebootWithMonitoring(ctx context.Context, pollInterval, maxOfflineWait, maxOnlineWait time.Duration) (*RebootCycleResult, error) {
+	result, err := m.client.RebootWithMonitoring(ctx, pollInterval, maxOfflineWait, maxOnlineWait)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RebootCycleResult{
+		Success:         result.Success,
+		TotalDuration:   result.TotalDuration,
+		OfflineDuration: result.OfflineDuration,
+		OfflineDetected: result.OfflineDetected,
+		OnlineRestored:  result.OnlineRestored,
+		TimeoutReached:  result.TimeoutReached,
+		Error:           result.Error,
+	}, nil
+}
+
+// hnapTemperature returns the first value of the responses named like a
+// temperature, which only some firmwares report; nil if there is none
+func hnapTemperature(responses map[string]interface{}) *float64 {
+	sections := make([]string, 0, len(responses))
+	for key := range responses {
+		sections = append(sections, key)
+	}
+	sort.Strings(sections)
+	for _, key := range sections {
+		section := hnapSection(responses, key)
+		names := make([]string, 0, len(section))
+		for name := range section {
+			if strings.Contains(strings.ToLower(name), "temperature") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if value, ok := section[name].(string); ok {
+				if temperature := parseTemperature(value); temperature != nil {
+					return temperature
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hnapSection returns a nested response object, or nil if it is missing
+func hnapSection(responses map[string]interface{}, key string) map[string]interface{} {
+	section, _ := responses[key].(map[string]interface{})
+	return section
+}
+
+// splitMotoRecords splits a Motorola channel list: records are separated by
+// "|+|" and fields by "^"
+func splitMotoRecords(raw string) [][]string {
+	var records [][]string
+	for _, record := range strings.Split(raw, "|+|") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		fields := strings.Split(record, "^")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		records = append(records, fields)
+	}
+	return records
+}
+
+// motoLogTimeLayout is the time and date of a Motorola event log entry, as
+// in "11:40:03 Thu Oct 7 2021" once the fields are joined
+const motoLogTimeLayout = "15:04:05 Mon Jan 2 2006"
+
+// parseMotoLog parses MotoStatusLogList entries, separated by "}-{":
+// Time ^ Date ^ Priority ^ Description. Entries logged before the modem
+// had the time have no time.
+func parseMotoLog(raw string, loc *time.Location) []Event {
+	var events []Event
+	for _, entry := range strings.Split(raw, "}-{") {
+		fields := strings.Split(entry, "^")
+		if len(fields) < 4 {
+			continue
+		}
+		event := Event{
+			Priority:    strings.TrimSpace(fields[2]),
+			Description: strings.TrimSpace(strings.Join(fields[3:], "^")),
+		}
+		if event.Description == "" {
+			continue
+		}
+		stamp := strings.Join(strings.Fields(fields[0]+" "+fields[1]), " ")
+		if t, err := time.ParseInLocation(motoLogTimeLayout, stamp, loc); err == nil {
+			event.Time = t
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// parseMotoDownstream parses MotoConnDownstreamChannel records:
+// Channel ^ Lock Status ^ Modulation ^ Channel ID ^ Freq (MHz) ^ Pwr ^ SNR ^ Corrected ^ Uncorrected
+func parseMotoDownstream(raw string) []Channel {
+	var channels []Channel
+	for _, fields := range splitMotoRecords(raw) {
+		if len(fields) < 9 {
+			continue
+		}
+		channels = append(channels, Channel{
+			ChannelID:      int(parseLeadingInt(fields[3])),
+			LockStatus:     fields[1],
+			Modulation:     fields[2],
+			FrequencyMHz:   parseLeadingFloat(fields[4]),
+			PowerDBmV:      parseLeadingFloat(fields[5]),
+			SNRDB:          parseLeadingFloat(fields[6]),
+			Corrected:      parseLeadingInt(fields[7]),
+			Uncorrectables: parseLeadingInt(fields[8]),
+		})
+	}
+	return channels
+}
+
+// parseMotoUpstream parses MotoConnUpstreamChannel records:
+// Channel ^ Lock Status ^ Channel Type ^ Channel ID ^ Symbol Rate ^ Freq (MHz) ^ Pwr
+func parseMotoUpstream(raw string) []Channel {
+	var channels []Channel
+	for _, fields := range splitMotoRecords(raw) {
+		if len(fields) < 7 {
+			continue
+		}
+		channels = append(channels, Channel{
+			ChannelID:    int(parseLeadingInt(fields[3])),
+			LockStatus:   fields[1],
+			Modulation:   fields[2],
+			FrequencyMHz: parseLeadingFloat(fields[5]),
+			PowerDBmV:    parseLeadingFloat(fields[6]),
+		})
+	}
+	return channels
+}
@@ -0,0 +1,41 @@
+package modem
+
+import "testing"
+
+func TestParseMotoChannels(t *testing.T) {
+	downstream := parseMotoDownstream("1^Locked^QAM256^20^579.0^ 3.6^43.1^15^2^|+|2^Not Locked^QAM256^21^585.0^-1.4^0.0^0^0^")
+	if len(downstream) != 2 {
+		t.Fatalf("Expected 2 downstream channels, got %d", len(downstream))
+	}
+	ds := downstream[0]
+	if ds.ChannelID != 20 || !ds.Locked() || ds.FrequencyMHz != 579.0 || ds.PowerDBmV != 3.6 ||
+		ds.SNRDB != 43.1 || ds.Corrected != 15 || ds.Uncorrectables != 2 {
+		t.Errorf("Unexpected downstream channel: %+v", ds)
+	}
+	if downstream[1].Locked() || downstream[1].PowerDBmV != -1.4 {
+		t.Errorf("Unexpected second downstream channel: %+v", downstream[1])
+	}
+
+	upstream := parseMotoUpstream("1^Locked^SC-QAM^2^5120^16.4^44.3^")
+	if len(upstream) != 1 || upstream[0].ChannelID != 2 || upstream[0].FrequencyMHz != 16.4 || upstream[0].PowerDBmV != 44.3 {
+		t.Errorf("Unexpected upstream channels: %+v", upstream)
+	}
+
+	if channels := parseMotoDownstream(""); channels != nil {
+		t.Errorf("Expected no channels from empty list, got %+v", channels)
+	}
+}
+
+func TestHNAPTemperature(t *testing.T) {
+	responses := map[string]interface{}{
+		"GetMotoStatusSoftwareResponse": map[string]interface{}{"StatusSoftwareSfVer": "8600-19.3.18"},
+	}
+	if temperature := hnapTemperature(responses); temperature != nil {
+		t.Errorf("Expected no temperature from firmware that does not report it, got %v", *temperature)
+	}
+
+	responses["GetMotoStatusConnectionInfoResponse"] = map[string]interface{}{"MotoConnSystemTemperature": "58.0 C"}
+	if temperature := hnapTemperature(responses); temperature == nil || *temperature != 58 {
+		t.Errorf("Expected a temperature of 58, got %v", temperature)
+	}
+}
@@ -0,0 +1,219 @@
+package modemtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/sirupsen/logrus"
+)
+
+// operationTimeout bounds every driver call made by the harness
+const operationTimeout = 10 * time.Second
+
+// RunConformance runs the conformance suite for a registered driver against
+// the transcripts in dir. Every name in RequiredCases must be present; any
+// additional transcripts are run as well. On top of the replayed transcripts
+// the driver must fail cleanly when the modem returns server errors or is
+// unreachable.
+func RunConformance(t *testing.T, modemType string, dir string) {
+	t.Helper()
+
+	transcripts, err := LoadTranscripts(dir)
+	if err != nil {
+		t.Fatalf("Failed to load transcripts for %s: %v", modemType, err)
+	}
+
+	for _, name := range RequiredCases {
+		if _, ok := transcripts[name]; !ok {
+			t.Errorf("Driver %s is missing required transcript %q", modemType, name)
+		}
+	}
+
+	for name, transcript := range transcripts {
+		transcript := transcript
+		t.Run(name, func(t *testing.T) {
+			RunTranscript(t, modemType, transcript)
+		})
+	}
+
+	scheme := ""
+	if login, ok := transcripts["login"]; ok {
+		scheme = login.Scheme
+	}
+
+	t.Run("server_error", func(t *testing.T) {
+		server := newErrorServer(scheme, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		driver := newDriver(t, modemType, scheme, server.Listener.Addr().String(), "admin", "password")
+		assertFails(t, "GetStatus", func(ctx context.Context) error {
+			_, err := driver.GetStatus(ctx)
+			return err
+		})
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		host := server.Listener.Addr().String()
+		server.Close()
+
+		driver := newDriver(t, modemType, scheme, host, "admin", "password")
+		assertFails(t, "Login", driver.Login)
+		assertFails(t, "GetStatus", func(ctx context.Context) error {
+			_, err := driver.GetStatus(ctx)
+			return err
+		})
+		assertFails(t, "Reboot", driver.Reboot)
+	})
+
+	t.Run("cancelled_context", func(t *testing.T) {
+		server := newErrorServer(scheme, http.NotFoundHandler())
+		defer server.Close()
+
+		driver := newDriver(t, modemType, scheme, server.Listener.Addr().String(), "admin", "password")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := driver.GetStatus(ctx); err == nil {
+			t.Error("Expected GetStatus to fail with a cancelled context")
+		}
+	})
+}
+
+// RunTranscript replays a single transcript against a driver. Login is
+// performed first for status and reboot transcripts so that session handling
+// is exercised the same way the watchdog uses drivers.
+func RunTranscript(t *testing.T, modemType string, transcript *Transcript) {
+	t.Helper()
+
+	server := NewServer(transcript)
+	defer server.Close()
+
+	driver := newDriver(t, modemType, transcript.Scheme, server.Host(), transcript.Username, transcript.Password)
+	if driver.Name() != modemType {
+		t.Errorf("Driver registered as %s reports name %q", modemType, driver.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+
+	var status *modem.Status
+	err := driver.Login(ctx)
+	if err == nil && transcript.Operation != OperationLogin {
+		switch transcript.Operation {
+		case OperationStatus:
+			status, err = driver.GetStatus(ctx)
+		case OperationReboot:
+			err = driver.Reboot(ctx)
+		}
+	}
+
+	checkError(t, transcript.Expect.Error, err)
+	if err == nil && status != nil {
+		checkStatus(t, transcript.Expect, status)
+	}
+
+	for _, request := range server.Unexpected() {
+		t.Errorf("Driver sent request not in transcript: %s", request)
+	}
+	if transcript.Expect.Error == ErrorNone {
+		for _, exchange := range server.Unused() {
+			t.Errorf("Driver did not send %s", exchange)
+		}
+	}
+}
+
+func checkError(t *testing.T, want string, err error) {
+	t.Helper()
+
+	switch want {
+	case ErrorNone:
+		if err != nil {
+			t.Errorf("Expected success, got error: %v", err)
+		}
+	case ErrorAuthFailed:
+		if !errors.Is(err, modem.ErrAuthFailed) {
+			t.Errorf("Expected ErrAuthFailed, got %v", err)
+		}
+	case ErrorSessionExpired:
+		if !errors.Is(err, modem.ErrSessionExpired) {
+			t.Errorf("Expected ErrSessionExpired, got %v", err)
+		}
+	case ErrorAny:
+		if err == nil {
+			t.Error("Expected an error, got success")
+		}
+	}
+}
+
+func checkStatus(t *testing.T, expect Expectation, status *modem.Status) {
+	t.Helper()
+
+	if status.Mode == "" {
+		t.Error("Status.Mode must be set")
+	}
+	if status.FetchedAt.IsZero() {
+		t.Error("Status.FetchedAt must be set")
+	}
+	if expect.Model != "" && status.Model != expect.Model {
+		t.Errorf("Expected model %q, got %q", expect.Model, status.Model)
+	}
+	if expect.FirmwareVersion != "" && status.FirmwareVersion != expect.FirmwareVersion {
+		t.Errorf("Expected firmware %q, got %q", expect.FirmwareVersion, status.FirmwareVersion)
+	}
+	if expect.Mode != "" && status.Mode != expect.Mode {
+		t.Errorf("Expected mode %q, got %q", expect.Mode, status.Mode)
+	}
+	if expect.DownstreamChannels != 0 && len(status.Downstream) != expect.DownstreamChannels {
+		t.Errorf("Expected %d downstream channels, got %d", expect.DownstreamChannels, len(status.Downstream))
+	}
+	if expect.UpstreamChannels != 0 && len(status.Upstream) != expect.UpstreamChannels {
+		t.Errorf("Expected %d upstream channels, got %d", expect.UpstreamChannels, len(status.Upstream))
+	}
+}
+
+func assertFails(t *testing.T, operation string, fn func(ctx context.Context) error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+
+	if err := fn(ctx); err == nil {
+		t.Errorf("Expected %s to fail", operation)
+	}
+}
+
+func newDriver(t *testing.T, modemType, scheme, host, username, password string) modem.Driver {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	driver, err := modem.New(modemType, modem.Options{
+		Host:     host,
+		Username: username,
+		Password: password,
+		NoVerify: true,
+		Scheme:   scheme,
+		Timeout:  operationTimeout,
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create driver %s: %v", modemType, err)
+	}
+	return driver
+}
+
+func newErrorServer(scheme string, handler http.Handler) *httptest.Server {
+	if scheme == "https" {
+		return httptest.NewTLSServer(handler)
+	}
+	return httptest.NewServer(handler)
+}
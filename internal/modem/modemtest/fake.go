@@ -0,0 +1,264 @@
+package modemtest
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeDownstream and FakeUpstream are the channels the fake MB8600 reports,
+// in the MotoConnDownstreamChannel and MotoConnUpstreamChannel formats
+const (
+	FakeDownstream = "1^Locked^QAM256^20^579.0^ 3.6^43.1^15^0^|+|2^Locked^QAM256^21^585.0^ 3.4^42.9^8^1^|+|3^Locked^OFDM PLC^33^722.0^ 1.2^40.5^1022^0^"
+	FakeUpstream   = "1^Locked^SC-QAM^2^5120^16.4^44.3^|+|2^Locked^SC-QAM^3^5120^22.8^44.8^"
+)
+
+// FakeFirmware is the firmware version the fake MB8600 reports
+const FakeFirmware = "8600-19.3.18"
+
+// Fake is a stateful fake MB8600. Unlike Server, which replays a single
+// transcript, it answers any number of logins and status reads, checks the
+// HNAP signatures like the modem does, and goes offline for RebootDowntime
+// after a reboot, forgetting its sessions.
+type Fake struct {
+	username, password string
+	// RebootDowntime is how long the modem is offline after a reboot
+	RebootDowntime time.Duration
+
+	mu           sync.Mutex
+	sessions     map[string]*fakeSession
+	offlineUntil time.Time
+	state        FakeState
+}
+
+// fakeSession is the HNAP login of one cookie
+type fakeSession struct {
+	challenge, publicKey, privateKey string
+	authenticated                    bool
+}
+
+// FakeState counts what the fake modem was asked to do
+type FakeState struct {
+	Logins       int       `json:"logins"`
+	FailedLogins int       `json:"failed_logins"`
+	StatusReads  int       `json:"status_reads"`
+	Reboots      int       `json:"reboots"`
+	LastReboot   time.Time `json:"last_reboot,omitempty"`
+	Online       bool      `json:"online"`
+}
+
+// NewFake creates a fake MB8600 accepting username and password
+func NewFake(username, password string) *Fake {
+	return &Fake{
+		username:       username,
+		password:       password,
+		RebootDowntime: 15 * time.Second,
+		sessions:       make(map[string]*fakeSession),
+	}
+}
+
+// State returns what the fake modem was asked to do so far
+func (f *Fake) State() FakeState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state := f.state
+	state.Online = !time.Now().Before(f.offlineUntil)
+	return state
+}
+
+// Reset forgets the sessions and counts and brings the modem back online
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions = make(map[string]*fakeSession)
+	f.offlineUntil = time.Time{}
+	f.state = FakeState{}
+}
+
+// ServeHTTP answers the modem's web interface
+func (f *Fake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	offline := time.Now().Before(f.offlineUntil)
+	f.mu.Unlock()
+	if offline {
+		dropConnection(w)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/Login.html":
+		fmt.Fprint(w, "<html>Login</html>")
+	case r.URL.Path == "/cgi-bin/moto/goform/MotoLogin" && r.Method == http.MethodPost:
+		// The form login only sets up the page session; HNAP checks the password
+		w.WriteHeader(http.StatusOK)
+	case r.URL.Path == "/HNAP1/" && r.Method == http.MethodPost:
+		f.serveHNAP(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// dropConnection closes the connection without an answer, as a rebooting
+// modem does; without hijacking, the request fails with 503
+func dropConnection(w http.ResponseWriter) {
+	if hijacker, ok := w.(http.Hijacker); ok {
+		if conn, _, err := hijacker.Hijack(); err == nil {
+			conn.Close()
+			return
+		}
+	}
+	http.Error(w, "modem rebooting", http.StatusServiceUnavailable)
+}
+
+// serveHNAP answers an HNAP action named by the SOAPACTION header
+func (f *Fake) serveHNAP(w http.ResponseWriter, r *http.Request) {
+	action := strings.TrimPrefix(strings.Trim(r.Header.Get("SOAPACTION"), `"`), "http://purenetworks.com/HNAP1/")
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var request map[string]map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "invalid HNAP request", http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if action == "Login" {
+		writeJSON(w, map[string]interface{}{"LoginResponse": f.login(r, request["Login"])})
+		return
+	}
+
+	session := f.authenticated(r, action)
+	if session == nil {
+		writeJSON(w, map[string]interface{}{action + "Response": map[string]string{action + "Result": "UN-AUTH"}})
+		return
+	}
+	switch action {
+	case "GetMultipleHNAPs":
+		f.state.StatusReads++
+		writeJSON(w, map[string]interface{}{"GetMultipleHNAPsResponse": f.multipleHNAPs(request["GetMultipleHNAPs"])})
+	case "SetStatusSecuritySettings":
+		f.state.Reboots++
+		f.state.LastReboot = time.Now()
+		f.offlineUntil = time.Now().Add(f.RebootDowntime)
+		f.sessions = make(map[string]*fakeSession)
+		writeJSON(w, map[string]interface{}{"SetStatusSecuritySettingsResponse": map[string]string{"SetStatusSecuritySettingsResult": "OK"}})
+	default:
+		writeJSON(w, map[string]interface{}{action + "Response": map[string]string{action + "Result": "ERROR"}})
+	}
+}
+
+// login answers the challenge request and the login of the HNAP handshake;
+// the caller holds mu
+func (f *Fake) login(r *http.Request, login map[string]interface{}) map[string]string {
+	username, _ := login["Username"].(string)
+	action, _ := login["Action"].(string)
+	if action == "request" {
+		cookie, session := randomHex(5), &fakeSession{challenge: randomHex(10), publicKey: randomHex(10)}
+		session.privateKey = hmacMD5(session.publicKey+f.password, session.challenge)
+		f.sessions[cookie] = session
+		return map[string]string{"Challenge": session.challenge, "PublicKey": session.publicKey, "Cookie": cookie, "LoginResult": "OK"}
+	}
+
+	password, _ := login["LoginPassword"].(string)
+	if cookie, err := r.Cookie("uid"); err == nil {
+		session := f.sessions[cookie.Value]
+		if session != nil && username == f.username && password == hmacMD5(session.privateKey, session.challenge) {
+			session.authenticated = true
+			f.state.Logins++
+			return map[string]string{"LoginResult": "OK"}
+		}
+	}
+	f.state.FailedLogins++
+	return map[string]string{"LoginResult": "FAILED"}
+}
+
+// authenticated returns the logged in session of the request's cookie if
+// its HNAP_AUTH header is signed for action; the caller holds mu
+func (f *Fake) authenticated(r *http.Request, action string) *fakeSession {
+	cookie, err := r.Cookie("uid")
+	if err != nil {
+		return nil
+	}
+	session := f.sessions[cookie.Value]
+	if session == nil || !session.authenticated {
+		return nil
+	}
+	parts := strings.Fields(r.Header.Get("HNAP_AUTH"))
+	if len(parts) != 2 {
+		return nil
+	}
+	if parts[0] != hmacMD5(session.privateKey, parts[1]+`"http://purenetworks.com/HNAP1/`+action+`"`) {
+		return nil
+	}
+	return session
+}
+
+// multipleHNAPs answers the actions of a GetMultipleHNAPs request; actions
+// the fake does not know answer OK without values
+func (f *Fake) multipleHNAPs(actions map[string]interface{}) map[string]interface{} {
+	response := map[string]interface{}{"GetMultipleHNAPsResult": "OK"}
+	for action := range actions {
+		values := map[string]string{action + "Result": "OK"}
+		switch action {
+		case "GetMotoStatusSoftware":
+			values["StatusSoftwareSfVer"] = FakeFirmware
+			values["StatusSoftwareSpecVer"] = "DOCSIS 3.1"
+		case "GetMotoStatusDownstreamChannelInfo":
+			values["MotoConnDownstreamChannel"] = FakeDownstream
+		case "GetMotoStatusUpstreamChannelInfo":
+			values["MotoConnUpstreamChannel"] = FakeUpstream
+		case "GetMotoStatusLog":
+			values["MotoStatusLogList"] = ""
+		}
+		response[action+"Response"] = values
+	}
+	return response
+}
+
+// ControlHandler serves the state of the fake modem for tests: GET /state
+// returns FakeState as JSON and POST /reset calls Reset
+func (f *Fake) ControlHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/state", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, f.State())
+	})
+	mux.HandleFunc("/reset", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		f.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+// hmacMD5 is the upper case hex HMAC-MD5 the HNAP handshake uses
+func hmacMD5(key, message string) string {
+	h := hmac.New(md5.New, []byte(key))
+	h.Write([]byte(message))
+	return strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return strings.ToUpper(hex.EncodeToString(b))
+}
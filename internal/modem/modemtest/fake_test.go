@@ -0,0 +1,90 @@
+package modemtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+)
+
+func TestFakeAnswersTheMB8600Driver(t *testing.T) {
+	fake := NewFake("admin", "password")
+	fake.RebootDowntime = 200 * time.Millisecond
+	server := httptest.NewTLSServer(fake)
+	defer server.Close()
+
+	ctx := context.Background()
+	driver := newDriver(t, "mb8600", "https", server.Listener.Addr().String(), "admin", "password")
+	status, err := driver.GetStatus(ctx)
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if status.FirmwareVersion != FakeFirmware || len(status.Downstream) != 3 || len(status.Upstream) != 2 {
+		t.Errorf("Unexpected status: firmware %q, %d downstream, %d upstream", status.FirmwareVersion, len(status.Downstream), len(status.Upstream))
+	}
+
+	if err := driver.Reboot(ctx); err != nil {
+		t.Fatalf("Reboot failed: %v", err)
+	}
+	if state := fake.State(); state.Reboots != 1 || state.Online {
+		t.Errorf("Expected one reboot and the modem offline, got %+v", state)
+	}
+	if _, err := driver.GetStatus(ctx); err == nil {
+		t.Error("Expected GetStatus to fail while the modem reboots")
+	}
+
+	// The reboot ended the session, so the driver logs in again
+	time.Sleep(fake.RebootDowntime)
+	if _, err := driver.GetStatus(ctx); err != nil {
+		t.Fatalf("GetStatus after the reboot failed: %v", err)
+	}
+	if state := fake.State(); state.Logins != 2 || !state.Online {
+		t.Errorf("Expected a second login with the modem online, got %+v", state)
+	}
+}
+
+func TestFakeRejectsWrongPassword(t *testing.T) {
+	fake := NewFake("admin", "password")
+	server := httptest.NewTLSServer(fake)
+	defer server.Close()
+
+	driver := newDriver(t, "mb8600", "https", server.Listener.Addr().String(), "admin", "wrong")
+	if err := driver.Login(context.Background()); !errors.Is(err, modem.ErrAuthFailed) {
+		t.Errorf("Expected ErrAuthFailed, got %v", err)
+	}
+	if state := fake.State(); state.FailedLogins == 0 || state.Logins != 0 {
+		t.Errorf("Expected only failed logins, got %+v", state)
+	}
+}
+
+func TestFakeControlHandler(t *testing.T) {
+	fake := NewFake("admin", "password")
+	fake.state.Reboots = 3
+	control := httptest.NewServer(fake.ControlHandler())
+	defer control.Close()
+
+	resp, err := http.Get(control.URL + "/state")
+	if err != nil {
+		t.Fatalf("GET /state failed: %v", err)
+	}
+	var state FakeState
+	err = json.NewDecoder(resp.Body).Decode(&state)
+	resp.Body.Close()
+	if err != nil || state.Reboots != 3 || !state.Online {
+		t.Errorf("Unexpected state %+v: %v", state, err)
+	}
+
+	resp, err = http.Post(control.URL+"/reset", "", nil)
+	if err != nil {
+		t.Fatalf("POST /reset failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || fake.State().Reboots != 0 {
+		t.Errorf("Expected reset to clear the counts, got %d and %+v", resp.StatusCode, fake.State())
+	}
+}
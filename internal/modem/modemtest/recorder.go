@@ -0,0 +1,318 @@
+package modemtest
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/sirupsen/logrus"
+)
+
+// Placeholder credentials written to captured transcripts
+const (
+	PlaceholderUsername = "admin"
+	PlaceholderPassword = "password"
+)
+
+// recordedRequestHeaders are request headers kept in transcripts for matching
+var recordedRequestHeaders = []string{"Authorization", "SOAPACTION"}
+
+// recordedResponseHeaders are response headers replayed to drivers
+var recordedResponseHeaders = []string{"Content-Type", "Location", "Set-Cookie"}
+
+var (
+	macPattern  = regexp.MustCompile(`(?i)\b([0-9a-f]{2}[:-]){5}[0-9a-f]{2}\b`)
+	ipv4Pattern = regexp.MustCompile(`\b(\d{1,3})\.(\d{1,3})\.(\d{1,3})\.(\d{1,3})\b`)
+)
+
+// Sanitizer scrubs credentials and identifying data from captured traffic.
+// Credentials are replaced by the placeholder credentials so the transcript
+// replays with them, MAC addresses are zeroed and public IPv4 addresses are
+// replaced by a documentation address.
+type Sanitizer struct {
+	replacer *strings.Replacer
+}
+
+// NewSanitizer creates a sanitizer for the given credentials. Additional
+// strings such as serial numbers or account ids are replaced by "REDACTED".
+func NewSanitizer(username, password string, redact ...string) *Sanitizer {
+	var pairs []string
+	add := func(old, replacement string) {
+		if len(old) >= 3 && old != replacement {
+			pairs = append(pairs, old, replacement)
+		}
+	}
+
+	// Longest, most specific forms first
+	add(base64.StdEncoding.EncodeToString([]byte(username+":"+password)),
+		base64.StdEncoding.EncodeToString([]byte(PlaceholderUsername+":"+PlaceholderPassword)))
+	add(url.QueryEscape(password), PlaceholderPassword)
+	add(password, PlaceholderPassword)
+	add(username, PlaceholderUsername)
+	for _, value := range redact {
+		add(value, "REDACTED")
+	}
+
+	return &Sanitizer{replacer: strings.NewReplacer(pairs...)}
+}
+
+// String sanitizes a single value
+func (s *Sanitizer) String(value string) string {
+	value = s.replacer.Replace(value)
+	value = macPattern.ReplaceAllString(value, "00:00:00:00:00:00")
+	return ipv4Pattern.ReplaceAllStringFunc(value, func(match string) string {
+		ip := net.ParseIP(match)
+		if ip == nil || isPrivateIPv4(ip) {
+			return match
+		}
+		return "203.0.113.1"
+	})
+}
+
+// isPrivateIPv4 reports addresses that do not identify a subscriber
+func isPrivateIPv4(ip net.IP) bool {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return false
+	}
+	switch {
+	case ip4[0] == 10, ip4[0] == 127, ip4[0] == 0:
+		return true
+	case ip4[0] == 172 && ip4[1] >= 16 && ip4[1] <= 31:
+		return true
+	case ip4[0] == 192 && ip4[1] == 168:
+		return true
+	case ip4[0] == 169 && ip4[1] == 254:
+		return true
+	case ip4[0] == 255:
+		return true
+	}
+	return false
+}
+
+// Recorder is an http.RoundTripper that records sanitized exchanges
+type Recorder struct {
+	transport http.RoundTripper
+	sanitizer *Sanitizer
+
+	mu        sync.Mutex
+	scheme    string
+	exchanges []Exchange
+}
+
+// NewRecorder wraps transport, recording every exchange through sanitizer
+func NewRecorder(transport http.RoundTripper, sanitizer *Sanitizer) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{transport: transport, sanitizer: sanitizer}
+}
+
+// RoundTrip performs the request and records it
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		requestBody = data
+		req.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	r.record(req, requestBody, resp, responseBody)
+	return resp, nil
+}
+
+func (r *Recorder) record(req *http.Request, requestBody []byte, resp *http.Response, responseBody []byte) {
+	exchange := Exchange{
+		Request: Request{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  r.sanitizer.String(req.URL.RawQuery),
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+			Body:   r.sanitizer.String(string(responseBody)),
+		},
+	}
+
+	for _, name := range recordedRequestHeaders {
+		if value := req.Header.Get(name); value != "" {
+			if exchange.Request.Headers == nil {
+				exchange.Request.Headers = make(map[string]string)
+			}
+			exchange.Request.Headers[name] = r.sanitizer.String(value)
+		}
+	}
+
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(string(requestBody)); err == nil && len(values) > 0 {
+			exchange.Request.Form = make(map[string]string, len(values))
+			for name := range values {
+				exchange.Request.Form[name] = r.sanitizer.String(values.Get(name))
+			}
+		}
+	}
+
+	for _, name := range recordedResponseHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			if exchange.Response.Headers == nil {
+				exchange.Response.Headers = make(map[string]string)
+			}
+			exchange.Response.Headers[name] = r.sanitizer.String(value)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.scheme == "" {
+		r.scheme = req.URL.Scheme
+	}
+	r.exchanges = append(r.exchanges, exchange)
+}
+
+// Transcript returns the recorded exchanges as a transcript for operation
+func (r *Recorder) Transcript(operation string) *Transcript {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &Transcript{
+		Operation: operation,
+		Scheme:    r.scheme,
+		Username:  PlaceholderUsername,
+		Password:  PlaceholderPassword,
+		Exchanges: append([]Exchange(nil), r.exchanges...),
+	}
+}
+
+// CaptureOptions configures a capture run
+type CaptureOptions struct {
+	ModemType string
+	Modem     modem.Options
+	// Operations to capture, in order; login is always captured
+	Operations []string
+	// Redact lists additional strings to scrub from the transcripts
+	Redact []string
+}
+
+// Capture records sanitized transcripts from a live modem. Each operation is
+// captured with a fresh driver that logs in first, mirroring how RunTranscript
+// replays them.
+func Capture(ctx context.Context, opts CaptureOptions, logger *logrus.Logger) (map[string]*Transcript, error) {
+	sanitizer := NewSanitizer(opts.Modem.Username, opts.Modem.Password, opts.Redact...)
+
+	operations := []string{OperationLogin}
+	for _, operation := range opts.Operations {
+		if operation != OperationLogin {
+			operations = append(operations, operation)
+		}
+	}
+
+	transcripts := make(map[string]*Transcript, len(operations))
+	for _, operation := range operations {
+		base := opts.Modem.Transport
+		if base == nil {
+			base = &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: opts.Modem.NoVerify},
+			}
+		}
+		recorder := NewRecorder(base, sanitizer)
+
+		driverOpts := opts.Modem
+		driverOpts.Transport = recorder
+		driver, err := modem.New(opts.ModemType, driverOpts, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		var status *modem.Status
+		err = driver.Login(ctx)
+		if err == nil {
+			switch operation {
+			case OperationStatus:
+				status, err = driver.GetStatus(ctx)
+			case OperationReboot:
+				err = driver.Reboot(ctx)
+			case OperationLogin:
+			default:
+				return nil, fmt.Errorf("unknown operation %q", operation)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s capture failed: %w", operation, err)
+		}
+
+		transcript := recorder.Transcript(operation)
+		transcript.Description = fmt.Sprintf("Captured %s from %s modem", operation, driver.Name())
+		if status != nil {
+			transcript.Description = fmt.Sprintf("Captured %s from %s (%s)", operation, sanitizer.String(status.Model), sanitizer.String(status.FirmwareVersion))
+			transcript.Expect = Expectation{
+				Model:              sanitizer.String(status.Model),
+				FirmwareVersion:    sanitizer.String(status.FirmwareVersion),
+				Mode:               status.Mode,
+				DownstreamChannels: len(status.Downstream),
+				UpstreamChannels:   len(status.Upstream),
+			}
+		}
+		transcripts[operation] = transcript
+	}
+
+	return transcripts, nil
+}
+
+// WriteTranscripts writes transcripts as <name>.json files into dir
+func WriteTranscripts(dir string, transcripts map[string]*Transcript) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+
+	names := make([]string, 0, len(transcripts))
+	for name := range transcripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var paths []string
+	for _, name := range names {
+		transcript := transcripts[name]
+		data, err := json.MarshalIndent(transcript, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,141 @@
+package modemtest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSanitizer(t *testing.T) {
+	sanitizer := NewSanitizer("joe", "s3cret!", "SN12345")
+
+	input := "user=joe&pass=s3cret%21 auth=" + base64.StdEncoding.EncodeToString([]byte("joe:s3cret!")) +
+		" mac=A4:11:62:0B:33:C1 wan=73.12.44.9 lan=192.168.100.1 serial=SN12345"
+	got := sanitizer.String(input)
+
+	for _, secret := range []string{"joe", "s3cret", "A4:11", "73.12.44.9", "SN12345"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("Sanitized output still contains %q: %s", secret, got)
+		}
+	}
+	for _, want := range []string{
+		"user=admin", "pass=password",
+		base64.StdEncoding.EncodeToString([]byte("admin:password")),
+		"mac=00:00:00:00:00:00", "wan=203.0.113.1", "lan=192.168.100.1", "serial=REDACTED",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected sanitized output to contain %q: %s", want, got)
+		}
+	}
+}
+
+func TestCaptureProducesReplayableTranscripts(t *testing.T) {
+	credentials := base64.StdEncoding.EncodeToString([]byte("joe:s3cret!"))
+	modemServer := NewServer(&Transcript{
+		Exchanges: []Exchange{
+			{Request: Request{Method: "GET", Path: "/cmconnectionstatus.html", Query: "login_" + credentials}, Response: Response{Body: "tok1"}},
+			{Request: Request{Method: "GET", Path: "/cmconnectionstatus.html", Query: "login_" + credentials}, Response: Response{Body: "tok2"}},
+			{Request: Request{Method: "GET", Path: "/cmconnectionstatus.html", Query: "ct_tok2"}, Response: Response{Body: `<table>
+<tr><th>Downstream Bonded Channels</th></tr>
+<tr><td>1</td><td>Locked</td><td>QAM256</td><td>795000000 Hz</td><td>3.2 dBmV</td><td>40.1 dB</td><td>12</td><td>3</td></tr>
+</table>`}},
+			{Request: Request{Method: "GET", Path: "/cmswinfo.html", Query: "ct_tok2"}, Response: Response{Body: `<table>
+<tr><td>Model Name</td><td>SB8200</td></tr><tr><td>HFC MAC Address</td><td>A4:11:62:0B:33:C1</td></tr></table>`}},
+		},
+	})
+	defer modemServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	transcripts, err := Capture(context.Background(), CaptureOptions{
+		ModemType: modem.TypeArrisSB,
+		Modem: modem.Options{
+			Host:     modemServer.Host(),
+			Username: "joe",
+			Password: "s3cret!",
+			Scheme:   "http",
+		},
+		Operations: []string{OperationStatus},
+	}, logger)
+	if err != nil {
+		t.Fatalf("Capture() failed: %v", err)
+	}
+
+	status := transcripts[OperationStatus]
+	if status == nil || transcripts[OperationLogin] == nil {
+		t.Fatalf("Expected login and status transcripts, got %v", transcripts)
+	}
+	if status.Expect.Model != "SB8200" || status.Expect.DownstreamChannels != 1 {
+		t.Errorf("Unexpected expectations: %+v", status.Expect)
+	}
+
+	dir := t.TempDir()
+	paths, err := WriteTranscripts(dir, transcripts)
+	if err != nil {
+		t.Fatalf("WriteTranscripts() failed: %v", err)
+	}
+	if len(paths) != 2 || filepath.Base(paths[0]) != "login.json" {
+		t.Errorf("Unexpected written files: %v", paths)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{"joe", "s3cret", credentials, "A4:11"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("%s leaks %q", filepath.Base(path), secret)
+			}
+		}
+	}
+
+	loaded, err := LoadTranscript(filepath.Join(dir, "status.json"))
+	if err != nil {
+		t.Fatalf("Captured transcript does not load: %v", err)
+	}
+	RunTranscript(t, modem.TypeArrisSB, loaded)
+}
+
+func TestRecorderKeepsBodiesReadable(t *testing.T) {
+	server := NewServer(&Transcript{
+		Exchanges: []Exchange{{
+			Request:  Request{Method: "POST", Path: "/form", Form: map[string]string{"password": "hunter2"}},
+			Response: Response{Body: "hello", Headers: map[string]string{"Set-Cookie": "sid=1"}},
+		}},
+	})
+	defer server.Close()
+
+	recorder := NewRecorder(nil, NewSanitizer("user", "hunter2"))
+	client := &http.Client{Transport: recorder}
+
+	resp, err := client.Post(server.URL+"/form", "application/x-www-form-urlencoded", strings.NewReader("password=hunter2"))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "hello" {
+		t.Errorf("Expected response body to be readable after recording, got %q", body)
+	}
+
+	transcript := recorder.Transcript(OperationLogin)
+	data, _ := json.Marshal(transcript)
+	if len(transcript.Exchanges) != 1 || strings.Contains(string(data), "hunter2") {
+		t.Errorf("Unexpected recorded transcript: %s", data)
+	}
+	if transcript.Exchanges[0].Request.Form["password"] != PlaceholderPassword ||
+		transcript.Exchanges[0].Response.Headers["Set-Cookie"] != "sid=1" {
+		t.Errorf("Unexpected recorded exchange: %+v", transcript.Exchanges[0])
+	}
+}
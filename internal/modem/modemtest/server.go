@@ -0,0 +1,126 @@
+package modemtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Server is a fake modem that replays a transcript
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	transcript *Transcript
+	used       []bool
+	unexpected []string
+}
+
+// NewServer starts a replay server for the transcript. HTTPS transcripts are
+// served with the httptest self-signed certificate.
+func NewServer(transcript *Transcript) *Server {
+	s := &Server{
+		transcript: transcript,
+		used:       make([]bool, len(transcript.Exchanges)),
+	}
+
+	if transcript.Scheme == "https" {
+		s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serve))
+	} else {
+		s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	}
+	return s
+}
+
+// Host returns the host:port the server listens on
+func (s *Server) Host() string {
+	return s.Listener.Addr().String()
+}
+
+// Unexpected returns the requests that matched no exchange
+func (s *Server) Unexpected() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.unexpected...)
+}
+
+// Unused returns descriptions of required exchanges that were never requested
+func (s *Server) Unused() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var unused []string
+	for i, exchange := range s.transcript.Exchanges {
+		if !s.used[i] && !exchange.Optional {
+			unused = append(unused, describe(i, exchange.Request))
+		}
+	}
+	return unused
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" && strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		r.ParseForm()
+	}
+
+	s.mu.Lock()
+	index := -1
+	for i, exchange := range s.transcript.Exchanges {
+		if !s.used[i] && matches(exchange.Request, r) {
+			index = i
+			s.used[i] = true
+			break
+		}
+	}
+	if index < 0 {
+		s.unexpected = append(s.unexpected, fmt.Sprintf("%s %s", r.Method, r.URL.RequestURI()))
+	}
+	s.mu.Unlock()
+
+	if index < 0 {
+		http.Error(w, "no matching exchange in transcript", http.StatusNotFound)
+		return
+	}
+
+	response := s.transcript.Exchanges[index].Response
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(response.Body))
+}
+
+// matches reports whether an incoming request satisfies a recorded request
+func matches(want Request, r *http.Request) bool {
+	if !strings.EqualFold(want.Method, r.Method) || want.Path != r.URL.Path {
+		return false
+	}
+	if want.Query != "" && want.Query != r.URL.RawQuery {
+		return false
+	}
+	for name, value := range want.Headers {
+		if r.Header.Get(name) != value {
+			return false
+		}
+	}
+	for name, value := range want.Form {
+		if r.PostFormValue(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func describe(index int, req Request) string {
+	uri := req.Path
+	if req.Query != "" {
+		uri += "?" + req.Query
+	}
+	return fmt.Sprintf("exchange %d: %s %s", index, req.Method, uri)
+}
@@ -0,0 +1,78 @@
+package modemtest
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestServerReplaysExchangesInOrder(t *testing.T) {
+	server := NewServer(&Transcript{
+		Exchanges: []Exchange{
+			{Request: Request{Method: "GET", Path: "/status"}, Response: Response{Status: 401}},
+			{Request: Request{Method: "GET", Path: "/status"}, Response: Response{Body: "ok"}},
+			{Request: Request{Method: "POST", Path: "/reboot", Form: map[string]string{"action": "1"}}},
+			{Request: Request{Method: "GET", Path: "/info"}, Optional: true},
+		},
+	})
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/status")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 401 {
+		t.Errorf("Expected first response 401, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/status")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Errorf("Expected second response body ok, got %q", body)
+	}
+
+	resp, err = http.PostForm(server.URL+"/reboot", url.Values{"action": {"2"}})
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected mismatched form to be rejected, got %d", resp.StatusCode)
+	}
+
+	if unexpected := server.Unexpected(); len(unexpected) != 1 || !strings.HasPrefix(unexpected[0], "POST /reboot") {
+		t.Errorf("Expected one unexpected POST, got %v", unexpected)
+	}
+	if unused := server.Unused(); len(unused) != 1 || !strings.Contains(unused[0], "/reboot") {
+		t.Errorf("Expected only the reboot exchange to be unused, got %v", unused)
+	}
+}
+
+func TestTranscriptValidate(t *testing.T) {
+	valid := &Transcript{
+		Operation: OperationStatus,
+		Exchanges: []Exchange{{Request: Request{Method: "GET", Path: "/"}}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid transcript, got %v", err)
+	}
+
+	tests := map[string]*Transcript{
+		"unknown operation": {Operation: "format", Exchanges: valid.Exchanges},
+		"unknown error":     {Operation: OperationLogin, Exchanges: valid.Exchanges, Expect: Expectation{Error: "boom"}},
+		"no exchanges":      {Operation: OperationLogin},
+		"missing path":      {Operation: OperationLogin, Exchanges: []Exchange{{Request: Request{Method: "GET"}}}},
+	}
+	for name, transcript := range tests {
+		if err := transcript.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
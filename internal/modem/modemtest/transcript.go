@@ -0,0 +1,148 @@
+// Package modemtest provides a conformance harness for modem drivers. Drivers
+// are exercised against fake modem servers that replay captured HTTP
+// transcripts, so login, status, reboot, session expiry and error handling can
+// be verified without hardware.
+package modemtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Operations a transcript can exercise
+const (
+	OperationLogin  = "login"
+	OperationStatus = "status"
+	OperationReboot = "reboot"
+)
+
+// Expected error kinds
+const (
+	ErrorNone           = ""
+	ErrorAuthFailed     = "auth_failed"
+	ErrorSessionExpired = "session_expired"
+	ErrorAny            = "any"
+)
+
+// RequiredCases are the transcript names every driver must provide
+var RequiredCases = []string{"login", "status", "reboot", "session_expiry", "auth_failure"}
+
+// Transcript is a captured conversation between a driver and a modem.
+// Exchanges are replayed in order: each request is answered by the first
+// unused exchange that matches it.
+type Transcript struct {
+	Description string      `json:"description"`
+	Operation   string      `json:"operation"`
+	Scheme      string      `json:"scheme,omitempty"`
+	Username    string      `json:"username"`
+	Password    string      `json:"password"`
+	Exchanges   []Exchange  `json:"exchanges"`
+	Expect      Expectation `json:"expect"`
+}
+
+// Exchange is a single request/response pair
+type Exchange struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+	// Optional exchanges may be skipped by the driver
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Request describes how an incoming request is matched. Empty fields match
+// anything; headers and form fields only need to be present with equal values.
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Query   string            `json:"query,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Form    map[string]string `json:"form,omitempty"`
+}
+
+// Response is the recorded answer to a request
+type Response struct {
+	Status  int               `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// Expectation describes the outcome the driver must produce
+type Expectation struct {
+	Error              string `json:"error,omitempty"`
+	Model              string `json:"model,omitempty"`
+	FirmwareVersion    string `json:"firmware_version,omitempty"`
+	Mode               string `json:"mode,omitempty"`
+	DownstreamChannels int    `json:"downstream_channels,omitempty"`
+	UpstreamChannels   int    `json:"upstream_channels,omitempty"`
+}
+
+// LoadTranscript reads a transcript from a JSON file
+func LoadTranscript(path string) (*Transcript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+
+	var transcript Transcript
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return nil, fmt.Errorf("failed to parse transcript %s: %w", path, err)
+	}
+
+	if err := transcript.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid transcript %s: %w", path, err)
+	}
+
+	return &transcript, nil
+}
+
+// LoadTranscripts reads every *.json transcript in a directory, keyed by file
+// name without extension
+func LoadTranscripts(dir string) (map[string]*Transcript, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no transcripts found in %s", dir)
+	}
+	sort.Strings(paths)
+
+	transcripts := make(map[string]*Transcript, len(paths))
+	for _, path := range paths {
+		transcript, err := LoadTranscript(path)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		transcripts[name] = transcript
+	}
+	return transcripts, nil
+}
+
+// Validate checks the transcript for obvious mistakes
+func (t *Transcript) Validate() error {
+	switch t.Operation {
+	case OperationLogin, OperationStatus, OperationReboot:
+	default:
+		return fmt.Errorf("unknown operation %q", t.Operation)
+	}
+
+	switch t.Expect.Error {
+	case ErrorNone, ErrorAuthFailed, ErrorSessionExpired, ErrorAny:
+	default:
+		return fmt.Errorf("unknown expected error %q", t.Expect.Error)
+	}
+
+	if len(t.Exchanges) == 0 {
+		return fmt.Errorf("no exchanges recorded")
+	}
+	for i, exchange := range t.Exchanges {
+		if exchange.Request.Method == "" || exchange.Request.Path == "" {
+			return fmt.Errorf("exchange %d: method and path are required", i)
+		}
+	}
+	return nil
+}
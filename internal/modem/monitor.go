@@ -0,0 +1,86 @@
+package modem
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RebootWithMonitoring reboots the modem and follows the reboot cycle.
+// Drivers implementing CycleMonitor handle the cycle themselves; for all
+// others the modem is polled via GetStatus until it first goes offline and
+// then comes back online.
+func RebootWithMonitoring(ctx context.Context, d Driver, pollInterval, maxOfflineWait, maxOnlineWait time.Duration) (*RebootCycleResult, error) {
+	if monitor, ok := d.(CycleMonitor); ok {
+		return monitor.RebootWithMonitoring(ctx, pollInterval, maxOfflineWait, maxOnlineWait)
+	}
+
+	if err := d.Reboot(ctx); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result := &RebootCycleResult{}
+
+	online := func() bool {
+		probeCtx, cancel := context.WithTimeout(ctx, pollInterval)
+		defer cancel()
+		_, err := d.GetStatus(probeCtx)
+		return err == nil
+	}
+
+	// Phase 1: wait for the modem to drop off
+	offlineStart, err := waitFor(ctx, pollInterval, maxOfflineWait, func() bool { return !online() })
+	if err != nil {
+		return nil, err
+	}
+	if offlineStart.IsZero() {
+		result.TimeoutReached = true
+		result.TotalDuration = time.Since(start)
+		result.Error = fmt.Errorf("modem did not go offline within %v", maxOfflineWait)
+		return result, nil
+	}
+	result.OfflineDetected = true
+
+	// Phase 2: wait for the modem to come back
+	onlineAt, err := waitFor(ctx, pollInterval, maxOnlineWait, online)
+	if err != nil {
+		return nil, err
+	}
+	if onlineAt.IsZero() {
+		result.TimeoutReached = true
+		result.OfflineDuration = time.Since(offlineStart)
+		result.TotalDuration = time.Since(start)
+		result.Error = fmt.Errorf("modem did not come back online within %v", maxOnlineWait)
+		return result, nil
+	}
+
+	result.OnlineRestored = true
+	result.Success = true
+	result.OfflineDuration = onlineAt.Sub(offlineStart)
+	result.TotalDuration = time.Since(start)
+	return result, nil
+}
+
+// waitFor polls cond until it holds or timeout elapses. It returns the time the
+// condition was met, or the zero time on timeout.
+func waitFor(ctx context.Context, pollInterval, timeout time.Duration, cond func() bool) (time.Time, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		case <-deadline.C:
+			return time.Time{}, nil
+		case <-ticker.C:
+			if cond() {
+				return time.Now(), nil
+			}
+		}
+	}
+}
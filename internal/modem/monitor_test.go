@@ -0,0 +1,96 @@
+package modem
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedDriver reports online/offline states from a script, one per GetStatus call
+type scriptedDriver struct {
+	mu      sync.Mutex
+	script  []bool
+	calls   int
+	reboots int
+}
+
+func (d *scriptedDriver) Name() string                    { return "scripted" }
+func (d *scriptedDriver) Login(ctx context.Context) error { return nil }
+
+func (d *scriptedDriver) Reboot(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reboots++
+	return nil
+}
+
+func (d *scriptedDriver) GetStatus(ctx context.Context) (*Status, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	online := d.script[len(d.script)-1]
+	if d.calls < len(d.script) {
+		online = d.script[d.calls]
+	}
+	d.calls++
+
+	if !online {
+		return nil, errors.New("offline")
+	}
+	return &Status{}, nil
+}
+
+func TestRebootWithMonitoringFullCycle(t *testing.T) {
+	driver := &scriptedDriver{script: []bool{true, false, false, true}}
+
+	result, err := RebootWithMonitoring(context.Background(), driver, 5*time.Millisecond, time.Second, time.Second)
+	if err != nil {
+		t.Fatalf("RebootWithMonitoring() failed: %v", err)
+	}
+
+	if driver.reboots != 1 {
+		t.Errorf("Expected 1 reboot, got %d", driver.reboots)
+	}
+	if !result.Success || !result.OfflineDetected || !result.OnlineRestored || result.TimeoutReached {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if result.OfflineDuration <= 0 {
+		t.Errorf("Expected positive offline duration, got %v", result.OfflineDuration)
+	}
+}
+
+func TestRebootWithMonitoringNeverOffline(t *testing.T) {
+	driver := &scriptedDriver{script: []bool{true}}
+
+	result, err := RebootWithMonitoring(context.Background(), driver, 5*time.Millisecond, 30*time.Millisecond, time.Second)
+	if err != nil {
+		t.Fatalf("RebootWithMonitoring() failed: %v", err)
+	}
+	if result.Success || !result.TimeoutReached || result.OfflineDetected {
+		t.Errorf("Expected offline timeout, got %+v", result)
+	}
+}
+
+func TestRebootWithMonitoringNeverOnline(t *testing.T) {
+	driver := &scriptedDriver{script: []bool{false}}
+
+	result, err := RebootWithMonitoring(context.Background(), driver, 5*time.Millisecond, time.Second, 30*time.Millisecond)
+	if err != nil {
+		t.Fatalf("RebootWithMonitoring() failed: %v", err)
+	}
+	if result.Success || !result.TimeoutReached || !result.OfflineDetected || result.OnlineRestored {
+		t.Errorf("Expected online timeout, got %+v", result)
+	}
+}
+
+func TestRebootWithMonitoringCancelled(t *testing.T) {
+	driver := &scriptedDriver{script: []bool{true}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := RebootWithMonitoring(ctx, driver, 5*time.Millisecond, time.Second, time.Second); err == nil {
+		t.Error("Expected error for cancelled context")
+	}
+}
@@ -0,0 +1,278 @@
+package modem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Netgear CM series page paths
+const (
+	netgearStatusPath = "/DocsisStatus.htm"
+	netgearRouterPath = "/RouterStatus.htm"
+)
+
+var (
+	netgearTagValuePattern = regexp.MustCompile(`(?s)function\s+(InitDsTableTagValue|InitUsTableTagValue)\s*\(\s*\)\s*\{.*?var\s+tagValueList\s*=\s*['"]([^'"]*)['"]`)
+	netgearFormPattern     = regexp.MustCompile(`(?i)action\s*=\s*["']([^"']*RouterStatus[^"']*)["']`)
+	netgearModelPattern    = regexp.MustCompile(`(?i)<title>[^<]*?\b(CM\d+[A-Z0-9]*)\b`)
+)
+
+func init() {
+	Register(TypeNetgearCM, func(opts Options, logger *logrus.Logger) Driver {
+		return NewNetgearCM(opts, logger)
+	})
+}
+
+// NetgearCM drives Netgear CM600/CM1000 cable modems.
+//
+// These modems use HTTP basic authentication. Some firmware answers the first
+// request with 401 and an XSRF_TOKEN cookie that has to be sent back, so every
+// request is retried once after a 401. Reboots are triggered by submitting the
+// RebootCheck form on RouterStatus.htm, whose action carries a one-time id.
+type NetgearCM struct {
+	opts       Options
+	httpClient *http.Client
+	logger     *logrus.Logger
+	baseURL    string
+}
+
+// NewNetgearCM creates a new Netgear CM driver
+func NewNetgearCM(opts Options, logger *logrus.Logger) *NetgearCM {
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.InfoLevel)
+	}
+
+	return &NetgearCM{
+		opts:       opts,
+		httpClient: newHTTPClient(opts, 30*time.Second),
+		logger:     logger,
+		baseURL:    baseURL(opts, "http"),
+	}
+}
+
+// Name returns the driver's modem type
+func (n *NetgearCM) Name() string {
+	return TypeNetgearCM
+}
+
+// Login verifies the basic auth credentials against the status page
+func (n *NetgearCM) Login(ctx context.Context) error {
+	n.logger.Debug("Performing Netgear CM login")
+
+	if _, err := n.get(ctx, netgearStatusPath); err != nil {
+		return err
+	}
+
+	n.logger.Info("Netgear CM authentication successful")
+	return nil
+}
+
+// GetStatus scrapes the DocsisStatus page
+func (n *NetgearCM) GetStatus(ctx context.Context) (*Status, error) {
+	body, err := n.get(ctx, netgearStatusPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch status page: %w", err)
+	}
+
+	status := parseNetgearStatus(body)
+	status.FetchedAt = time.Now()
+	return status, nil
+}
+
+// Reboot submits the RebootCheck form
+func (n *NetgearCM) Reboot(ctx context.Context) error {
+	n.logger.Info("Sending reboot command to Netgear CM modem")
+
+	page, err := n.get(ctx, netgearRouterPath)
+	if err != nil {
+		return fmt.Errorf("failed to fetch reboot form: %w", err)
+	}
+
+	action := netgearFormAction(page)
+	if action == "" {
+		return fmt.Errorf("reboot form not found on %s", netgearRouterPath)
+	}
+
+	form := url.Values{}
+	form.Set("buttonSelect", "2")
+
+	resp, err := n.do(ctx, "POST", action, form.Encode())
+	if err != nil {
+		return fmt.Errorf("reboot request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%w: status %d", werrors.ErrRebootRejected, resp.StatusCode)
+	}
+
+	n.logger.Info("Reboot command sent successfully")
+	return nil
+}
+
+// get fetches a page with basic auth and returns its body
+func (n *NetgearCM) get(ctx context.Context, path string) (string, error) {
+	resp, err := n.do(ctx, "GET", path, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response status %d for %s", resp.StatusCode, path)
+	}
+	return string(body), nil
+}
+
+// do sends a request with basic auth, retrying once when the modem answers
+// 401 so that an XSRF_TOKEN cookie set by the first response is sent back
+func (n *NetgearCM) do(ctx context.Context, method, path, form string) (*http.Response, error) {
+	var resp *http.Response
+	for attempt := 0; attempt < 2; attempt++ {
+		var body io.Reader
+		if form != "" {
+			body = strings.NewReader(form)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, n.baseURL+path, body)
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(n.opts.Username, n.opts.Password)
+		if form != "" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+
+		resp, err = n.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	return nil, fmt.Errorf("%w: status %d", ErrAuthFailed, resp.StatusCode)
+}
+
+// netgearFormAction extracts the RouterStatus form action including its id
+func netgearFormAction(page string) string {
+	match := netgearFormPattern.FindStringSubmatch(page)
+	if match == nil {
+		return ""
+	}
+	action := match[1]
+	if !strings.HasPrefix(action, "/") {
+		action = "/" + action
+	}
+	return action
+}
+
+// parseNetgearStatus parses the DocsisStatus page. CM1000 firmware embeds the
+// channel tables as pipe-separated JavaScript values; older CM600 firmware
+// renders them as HTML tables.
+func parseNetgearStatus(body string) *Status {
+	status := &Status{Mode: ModeModem}
+
+	if match := netgearModelPattern.FindStringSubmatch(body); match != nil {
+		status.Model = strings.ToUpper(match[1])
+	}
+
+	for _, match := range netgearTagValuePattern.FindAllStringSubmatch(body, -1) {
+		values := strings.Split(match[2], "|")
+		switch match[1] {
+		case "InitDsTableTagValue":
+			status.Downstream = parseNetgearTagValues(values, 9, netgearDownstreamChannel)
+		case "InitUsTableTagValue":
+			status.Upstream = parseNetgearTagValues(values, 7, netgearUpstreamChannel)
+		}
+	}
+
+	rows := parseHTMLRows(body)
+	if status.Downstream == nil && status.Upstream == nil {
+		status.Downstream = parseNetgearRows(findSection(rows, "Downstream Bonded Channels"), 9, netgearDownstreamChannel)
+		status.Upstream = parseNetgearRows(findSection(rows, "Upstream Bonded Channels"), 7, netgearUpstreamChannel)
+	}
+	status.FirmwareVersion = findValue(rows, "Firmware Version")
+	status.TemperatureC = findTemperature(rows)
+
+	return status
+}
+
+// netgearDownstreamChannel builds a channel from the downstream fields:
+// Channel | Lock Status | Modulation | Channel ID | Frequency | Power | SNR | Correctables | Uncorrectables
+func netgearDownstreamChannel(f []string) Channel {
+	return Channel{
+		LockStatus:     f[1],
+		Modulation:     f[2],
+		ChannelID:      int(parseLeadingInt(f[3])),
+		FrequencyMHz:   parseFrequencyMHz(f[4]),
+		PowerDBmV:      parseLeadingFloat(f[5]),
+		SNRDB:          parseLeadingFloat(f[6]),
+		Corrected:      parseLeadingInt(f[7]),
+		Uncorrectables: parseLeadingInt(f[8]),
+	}
+}
+
+// netgearUpstreamChannel builds a channel from the upstream fields:
+// Channel | Lock Status | US Channel Type | Channel ID | Symbol Rate | Frequency | Power
+func netgearUpstreamChannel(f []string) Channel {
+	return Channel{
+		LockStatus:   f[1],
+		Modulation:   f[2],
+		ChannelID:    int(parseLeadingInt(f[3])),
+		FrequencyMHz: parseFrequencyMHz(f[5]),
+		PowerDBmV:    parseLeadingFloat(f[6]),
+	}
+}
+
+// parseNetgearTagValues splits a "count|f1|f2|...|" list into channels of width fields
+func parseNetgearTagValues(values []string, width int, build func([]string) Channel) []Channel {
+	if len(values) == 0 {
+		return nil
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(values[0]))
+	if err != nil {
+		return nil
+	}
+
+	var channels []Channel
+	for i := 0; i < count; i++ {
+		start := 1 + i*width
+		if start+width > len(values) {
+			break
+		}
+		channels = append(channels, build(values[start:start+width]))
+	}
+	return channels
+}
+
+// parseNetgearRows parses HTML table rows that use the same field order as the tag values
+func parseNetgearRows(rows [][]string, width int, build func([]string) Channel) []Channel {
+	var channels []Channel
+	for _, row := range rows {
+		if len(row) < width || !isNumeric(row[0]) {
+			continue
+		}
+		channels = append(channels, build(row))
+	}
+	return channels
+}
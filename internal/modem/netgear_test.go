@@ -0,0 +1,157 @@
+package modem
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const netgearCM1000Status = `<html><head><title>NETGEAR Gateway CM1000</title></head>
+<script>
+function InitDsTableTagValue()
+{
+	var tagValueList = '2|1|Locked|QAM256|21|591000000 Hz|4.6|40.2|17|2|2|Locked|QAM256|22|597000000 Hz|4.4|40.1|0|0|';
+	return tagValueList.split("|");
+}
+function InitUsTableTagValue()
+{
+	var tagValueList = '1|1|Locked|ATDMA|3|5120 Ksym/sec|30600000 Hz|43.5 dBmV|';
+	return tagValueList.split("|");
+}
+</script>
+<table><tr><td>Firmware Version</td><td>V7.01.01.00</td></tr></table>
+</html>`
+
+const netgearCM600Status = `<html><head><title>NETGEAR Gateway CM600</title></head><table>
+<tr><th colspan=9>Downstream Bonded Channels</th></tr>
+<tr><td>Channel</td><td>Lock Status</td><td>Modulation</td><td>Channel ID</td><td>Frequency</td><td>Power</td><td>SNR</td><td>Correctables</td><td>Uncorrectables</td></tr>
+<tr><td>1</td><td>Locked</td><td>QAM256</td><td>9</td><td>555000000 Hz</td><td>2.1 dBmV</td><td>39.0 dB</td><td>5</td><td>1</td></tr>
+<tr><th colspan=7>Upstream Bonded Channels</th></tr>
+<tr><td>Channel</td><td>Lock Status</td><td>US Channel Type</td><td>Channel ID</td><td>Symbol Rate</td><td>Frequency</td><td>Power</td></tr>
+<tr><td>1</td><td>Locked</td><td>ATDMA</td><td>4</td><td>5120 Ksym/sec</td><td>23700000 Hz</td><td>45.0 dBmV</td></tr>
+</table></html>`
+
+const netgearRouterStatus = `<form name="RouterStatus" method="post" action="/goform/RouterStatus?id=1234567">
+<input type="button" name="RebootCheck" onclick="RebootCheck()"></form>`
+
+// fakeNetgear emulates basic auth with an XSRF_TOKEN cookie handshake
+type fakeNetgear struct {
+	mu      sync.Mutex
+	reboots int
+}
+
+func (f *fakeNetgear) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "admin" || pass != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if _, err := r.Cookie("XSRF_TOKEN"); err != nil {
+			http.SetCookie(w, &http.Cookie{Name: "XSRF_TOKEN", Value: "abc", Path: "/"})
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case netgearStatusPath:
+			w.Write([]byte(netgearCM1000Status))
+		case netgearRouterPath:
+			w.Write([]byte(netgearRouterStatus))
+		case "/goform/RouterStatus":
+			if r.URL.Query().Get("id") != "1234567" || r.FormValue("buttonSelect") != "2" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			f.reboots++
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func newTestNetgear(t *testing.T, fake *fakeNetgear, password string) *NetgearCM {
+	server := httptest.NewServer(fake.handler())
+	t.Cleanup(server.Close)
+
+	return NewNetgearCM(Options{
+		Host:     strings.TrimPrefix(server.URL, "http://"),
+		Username: "admin",
+		Password: password,
+	}, nil)
+}
+
+func TestNetgearGetStatus(t *testing.T) {
+	driver := newTestNetgear(t, &fakeNetgear{}, "password")
+
+	status, err := driver.GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatus() failed: %v", err)
+	}
+
+	if status.Model != "CM1000" {
+		t.Errorf("Expected model CM1000, got %q", status.Model)
+	}
+	if status.FirmwareVersion != "V7.01.01.00" {
+		t.Errorf("Expected firmware V7.01.01.00, got %q", status.FirmwareVersion)
+	}
+	if len(status.Downstream) != 2 {
+		t.Fatalf("Expected 2 downstream channels, got %d", len(status.Downstream))
+	}
+	if ds := status.Downstream[0]; ds.ChannelID != 21 || ds.FrequencyMHz != 591 || ds.SNRDB != 40.2 || ds.Uncorrectables != 2 {
+		t.Errorf("Unexpected downstream channel: %+v", ds)
+	}
+	if len(status.Upstream) != 1 || status.Upstream[0].PowerDBmV != 43.5 || status.Upstream[0].FrequencyMHz != 30.6 {
+		t.Errorf("Unexpected upstream channels: %+v", status.Upstream)
+	}
+}
+
+func TestNetgearParsesHTMLTables(t *testing.T) {
+	status := parseNetgearStatus(netgearCM600Status)
+
+	if status.Model != "CM600" {
+		t.Errorf("Expected model CM600, got %q", status.Model)
+	}
+	if len(status.Downstream) != 1 || status.Downstream[0].ChannelID != 9 || status.Downstream[0].Corrected != 5 {
+		t.Errorf("Unexpected downstream channels: %+v", status.Downstream)
+	}
+	if len(status.Upstream) != 1 || status.Upstream[0].ChannelID != 4 || status.Upstream[0].PowerDBmV != 45 {
+		t.Errorf("Unexpected upstream channels: %+v", status.Upstream)
+	}
+}
+
+func TestNetgearLoginFailure(t *testing.T) {
+	driver := newTestNetgear(t, &fakeNetgear{}, "wrong")
+
+	if err := driver.Login(context.Background()); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("Expected ErrAuthFailed, got %v", err)
+	}
+}
+
+func TestNetgearReboot(t *testing.T) {
+	fake := &fakeNetgear{}
+	driver := newTestNetgear(t, fake, "password")
+
+	if err := driver.Reboot(context.Background()); err != nil {
+		t.Fatalf("Reboot() failed: %v", err)
+	}
+	if fake.reboots != 1 {
+		t.Errorf("Expected 1 reboot, got %d", fake.reboots)
+	}
+}
+
+func TestNetgearFormAction(t *testing.T) {
+	if action := netgearFormAction(`<form action="goform/RouterStatus?id=42">`); action != "/goform/RouterStatus?id=42" {
+		t.Errorf("Unexpected form action %q", action)
+	}
+	if action := netgearFormAction(`<form action="/other">`); action != "" {
+		t.Errorf("Expected no form action, got %q", action)
+	}
+}
@@ -0,0 +1,112 @@
+package modem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// probeSchemes are the schemes a Prober tries, fastest answer first
+var probeSchemes = []string{"https", "http"}
+
+// defaultProbeTimeout bounds a probe when Options has no timeout
+const defaultProbeTimeout = 10 * time.Second
+
+// Prober finds the scheme the modem web interface answers on. Many MB8600
+// units only answer HTTP after certain firmware updates, so it probes HTTPS
+// and HTTP at once and the first to answer wins. The winning scheme is
+// remembered and tried alone on later probes until it stops answering.
+type Prober struct {
+	opts   Options
+	client *http.Client
+
+	mu     sync.Mutex
+	scheme string
+}
+
+// NewProber creates a prober for the modem at opts.Host and opts.Port
+func NewProber(opts Options) *Prober {
+	return &Prober{opts: opts, client: newHTTPClient(opts, defaultProbeTimeout)}
+}
+
+// Scheme returns the scheme the modem last answered on, or an empty string
+// before it answered
+func (p *Prober) Scheme() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.scheme
+}
+
+// Probe returns the scheme the modem answers on, or an error listing why
+// every scheme failed
+func (p *Prober) Probe(ctx context.Context) (string, error) {
+	if scheme := p.Scheme(); scheme != "" {
+		if err := p.probe(ctx, scheme); err == nil {
+			return scheme, nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		scheme string
+		err    error
+	}
+	results := make(chan result, len(probeSchemes))
+	for _, scheme := range probeSchemes {
+		go func(scheme string) {
+			results <- result{scheme: scheme, err: p.probe(ctx, scheme)}
+		}(scheme)
+	}
+
+	failures := make([]string, 0, len(probeSchemes))
+	for range probeSchemes {
+		r := <-results
+		if r.err == nil {
+			p.remember(r.scheme)
+			return r.scheme, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", r.scheme, r.err))
+	}
+	p.remember("")
+	sort.Strings(failures)
+	return "", fmt.Errorf("cannot connect to modem at %s: %s", p.opts.address(), strings.Join(failures, "; "))
+}
+
+// URL returns the base URL of the modem over scheme
+func (p *Prober) URL(scheme string) string {
+	return baseURL(p.opts, scheme)
+}
+
+// remember stores the scheme the modem answered on
+func (p *Prober) remember(scheme string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.scheme = scheme
+}
+
+// probe requests the modem home page over scheme. A server error, or the
+// 400 a server answers a request in the wrong scheme with, is a failure.
+func (p *Prober) probe(ctx context.Context, scheme string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL(scheme)+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusBadRequest {
+		return fmt.Errorf("modem returned %s", resp.Status)
+	}
+	return nil
+}
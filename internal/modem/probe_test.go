@@ -0,0 +1,121 @@
+package modem
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// schemeRecorder records the scheme of every request it forwards
+type schemeRecorder struct {
+	next    http.RoundTripper
+	mu      sync.Mutex
+	schemes []string
+}
+
+func (r *schemeRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.schemes = append(r.schemes, req.URL.Scheme)
+	r.mu.Unlock()
+	return r.next.RoundTrip(req)
+}
+
+// reset returns the recorded schemes once there are want of them, or after
+// a second, and forgets them
+func (r *schemeRecorder) reset(want int) []string {
+	deadline := time.Now().Add(time.Second)
+	for {
+		r.mu.Lock()
+		schemes := r.schemes
+		if len(schemes) >= want || time.Now().After(deadline) {
+			r.schemes = nil
+			r.mu.Unlock()
+			return schemes
+		}
+		r.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newTestProber(server *httptest.Server) (*Prober, *schemeRecorder) {
+	recorder := &schemeRecorder{next: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	host := strings.TrimPrefix(strings.TrimPrefix(server.URL, "https://"), "http://")
+	return NewProber(Options{Host: host, Transport: recorder}), recorder
+}
+
+func TestProberFindsScheme(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	t.Run("https", func(t *testing.T) {
+		server := httptest.NewTLSServer(handler)
+		defer server.Close()
+		prober, _ := newTestProber(server)
+
+		scheme, err := prober.Probe(context.Background())
+		if err != nil || scheme != "https" {
+			t.Errorf("Expected the modem to answer over https, got %q (%v)", scheme, err)
+		}
+	})
+
+	t.Run("http only", func(t *testing.T) {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		prober, recorder := newTestProber(server)
+
+		scheme, err := prober.Probe(context.Background())
+		if err != nil || scheme != "http" {
+			t.Fatalf("Expected the modem to answer over http, got %q (%v)", scheme, err)
+		}
+		if got := recorder.reset(2); len(got) != 2 {
+			t.Errorf("Expected both schemes to be probed, got %v", got)
+		}
+
+		// The working scheme is tried alone next time
+		if scheme, err := prober.Probe(context.Background()); err != nil || scheme != "http" {
+			t.Fatalf("Expected the remembered scheme, got %q (%v)", scheme, err)
+		}
+		if got := recorder.reset(1); len(got) != 1 || got[0] != "http" {
+			t.Errorf("Expected only the remembered scheme to be probed, got %v", got)
+		}
+		if prober.URL(prober.Scheme()) != server.URL {
+			t.Errorf("Expected the URL %s, got %s", server.URL, prober.URL(prober.Scheme()))
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(handler)
+		prober, _ := newTestProber(server)
+		server.Close()
+
+		_, err := prober.Probe(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "http:") || !strings.Contains(err.Error(), "https:") {
+			t.Errorf("Expected an error for both schemes, got %v", err)
+		}
+		if prober.Scheme() != "" {
+			t.Errorf("Expected no remembered scheme, got %q", prober.Scheme())
+		}
+	})
+}
+
+func TestOptionsPort(t *testing.T) {
+	tests := []struct {
+		host string
+		port int
+		want string
+	}{
+		{"192.168.100.1", 0, "http://192.168.100.1"},
+		{"192.168.100.1", 8080, "http://192.168.100.1:8080"},
+		{"192.168.100.1:8443", 8080, "http://192.168.100.1:8443"},
+		{"fe80::1", 8080, "http://[fe80::1]:8080"},
+	}
+	for _, tt := range tests {
+		if got := baseURL(Options{Host: tt.host, Port: tt.port}, "http"); got != tt.want {
+			t.Errorf("baseURL(%s, %d) = %s, want %s", tt.host, tt.port, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package modem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// channelMetric is a per-channel value exported to Prometheus
+type channelMetric struct {
+	name, help, kind string
+	value            func(c Channel) float64
+}
+
+var downstreamMetrics = []channelMetric{
+	{"docsis_downstream_power_dbmv", "Downstream channel power in dBmV.", "gauge", func(c Channel) float64 { return c.PowerDBmV }},
+	{"docsis_downstream_snr_db", "Downstream channel signal to noise ratio in dB.", "gauge", func(c Channel) float64 { return c.SNRDB }},
+	{"docsis_downstream_frequency_hz", "Downstream channel frequency in Hz.", "gauge", func(c Channel) float64 { return c.FrequencyMHz * 1e6 }},
+	{"docsis_downstream_locked", "Whether the downstream channel is locked.", "gauge", lockedValue},
+	{"docsis_downstream_corrected_codewords_total", "Codewords with errors the modem corrected, since it started.", "counter", func(c Channel) float64 { return float64(c.Corrected) }},
+	{"docsis_downstream_uncorrectable_codewords_total", "Codewords with errors the modem could not correct, since it started.", "counter", func(c Channel) float64 { return float64(c.Uncorrectables) }},
+}
+
+var upstreamMetrics = []channelMetric{
+	{"docsis_upstream_power_dbmv", "Upstream channel transmit power in dBmV.", "gauge", func(c Channel) float64 { return c.PowerDBmV }},
+	{"docsis_upstream_frequency_hz", "Upstream channel frequency in Hz.", "gauge", func(c Channel) float64 { return c.FrequencyMHz * 1e6 }},
+	{"docsis_upstream_locked", "Whether the upstream channel is locked.", "gauge", lockedValue},
+}
+
+// lockedValue is 1 for a locked channel and 0 otherwise
+func lockedValue(c Channel) float64 {
+	if c.Locked() {
+		return 1
+	}
+	return 0
+}
+
+// WritePrometheus writes the channels of status in the Prometheus text
+// format, one series per channel labeled with its channel ID and
+// modulation. A nil status writes nothing.
+func WritePrometheus(w io.Writer, status *Status) error {
+	if status == nil {
+		return nil
+	}
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "# HELP docsis_modem_info Modem model, firmware and mode.\n# TYPE docsis_modem_info gauge\n")
+	fmt.Fprintf(out, "docsis_modem_info{model=%q,firmware=%q,mode=%q} 1\n", status.Model, status.FirmwareVersion, status.Mode)
+	if !status.FetchedAt.IsZero() {
+		fmt.Fprintf(out, "# HELP docsis_status_timestamp_seconds When the channels were read from the modem.\n# TYPE docsis_status_timestamp_seconds gauge\n")
+		fmt.Fprintf(out, "docsis_status_timestamp_seconds %d\n", status.FetchedAt.Unix())
+	}
+	if status.TemperatureC != nil {
+		fmt.Fprintf(out, "# HELP docsis_modem_temperature_celsius Modem temperature in degrees Celsius.\n# TYPE docsis_modem_temperature_celsius gauge\n")
+		fmt.Fprintf(out, "docsis_modem_temperature_celsius %s\n", strconv.FormatFloat(*status.TemperatureC, 'g', -1, 64))
+	}
+	if t := status.Timeouts; t != nil {
+		fmt.Fprintf(out, "# HELP docsis_timeouts DOCSIS timeouts the modem logged in the window.\n# TYPE docsis_timeouts gauge\n")
+		fmt.Fprintf(out, "docsis_timeouts{type=%q,window=\"1h\"} %d\n", TimeoutT3, t.T3Hour)
+		fmt.Fprintf(out, "docsis_timeouts{type=%q,window=\"24h\"} %d\n", TimeoutT3, t.T3Day)
+		fmt.Fprintf(out, "docsis_timeouts{type=%q,window=\"1h\"} %d\n", TimeoutT4, t.T4Hour)
+		fmt.Fprintf(out, "docsis_timeouts{type=%q,window=\"24h\"} %d\n", TimeoutT4, t.T4Day)
+	}
+	writeChannels(out, downstreamMetrics, status.Downstream)
+	writeChannels(out, upstreamMetrics, status.Upstream)
+	return out.Flush()
+}
+
+// writeChannels writes every metric of channels; metrics of no channels are
+// left out entirely
+func writeChannels(out *bufio.Writer, metrics []channelMetric, channels []Channel) {
+	if len(channels) == 0 {
+		return
+	}
+	for _, metric := range metrics {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for _, channel := range channels {
+			fmt.Fprintf(out, "%s{channel=%q,modulation=%q} %s\n", metric.name, strconv.Itoa(channel.ChannelID),
+				channel.Modulation, strconv.FormatFloat(metric.value(channel), 'g', -1, 64))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package modem
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, nil); err != nil || buf.Len() != 0 {
+		t.Fatalf("Expected nothing without a status, got %q, %v", buf.String(), err)
+	}
+
+	status := &Status{
+		Model:           "MB8600",
+		FirmwareVersion: "8600-19.3.18",
+		Downstream: []Channel{
+			{ChannelID: 1, LockStatus: "Locked", Modulation: "QAM256", FrequencyMHz: 483, PowerDBmV: 2.4, SNRDB: 40.1, Corrected: 12, Uncorrectables: 3},
+			{ChannelID: 33, LockStatus: "Not Locked", Modulation: "OFDM PLC", FrequencyMHz: 957, PowerDBmV: -1.5, SNRDB: 38},
+		},
+		Upstream:  []Channel{{ChannelID: 2, LockStatus: "Locked", Modulation: "SC-QAM", FrequencyMHz: 29.2, PowerDBmV: 44.5}},
+		FetchedAt: time.Unix(1714560000, 0),
+	}
+	if err := WritePrometheus(&buf, status); err != nil {
+		t.Fatalf("WritePrometheus() failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`docsis_modem_info{model="MB8600",firmware="8600-19.3.18",mode=""} 1`,
+		"docsis_status_timestamp_seconds 1714560000",
+		"# TYPE docsis_downstream_corrected_codewords_total counter",
+		`docsis_downstream_power_dbmv{channel="1",modulation="QAM256"} 2.4`,
+		`docsis_downstream_snr_db{channel="33",modulation="OFDM PLC"} 38`,
+		`docsis_downstream_frequency_hz{channel="1",modulation="QAM256"} 4.83e+08`,
+		`docsis_downstream_locked{channel="33",modulation="OFDM PLC"} 0`,
+		`docsis_downstream_uncorrectable_codewords_total{channel="1",modulation="QAM256"} 3`,
+		`docsis_upstream_power_dbmv{channel="2",modulation="SC-QAM"} 44.5`,
+		`docsis_upstream_locked{channel="2",modulation="SC-QAM"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %s in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "docsis_upstream_snr_db") {
+		t.Error("Expected no upstream SNR")
+	}
+	if strings.Contains(out, "docsis_modem_temperature_celsius") {
+		t.Error("Expected no temperature from a modem that does not report it")
+	}
+
+	temperature := 61.5
+	status.TemperatureC = &temperature
+	status.Timeouts = &TimeoutCounts{T3Hour: 2, T3Day: 7, T4Day: 1}
+	buf.Reset()
+	if err := WritePrometheus(&buf, status); err != nil {
+		t.Fatalf("WritePrometheus() failed: %v", err)
+	}
+	for _, want := range []string{
+		"docsis_modem_temperature_celsius 61.5",
+		`docsis_timeouts{type="t3",window="1h"} 2`,
+		`docsis_timeouts{type="t3",window="24h"} 7`,
+		`docsis_timeouts{type="t4",window="24h"} 1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %s in:\n%s", want, buf.String())
+		}
+	}
+}
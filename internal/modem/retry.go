@@ -0,0 +1,83 @@
+package modem
+
+import (
+	"context"
+	"time"
+
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/perezjoseph/mb8600-watchdog/internal/retry"
+	"github.com/sirupsen/logrus"
+)
+
+// WithRetry returns a driver that retries logins and status requests the
+// modem never answered, under policy. Reboots are sent once, since a reboot
+// the modem received but did not answer must not be repeated. Reboot cycle
+// monitoring of the wrapped driver is kept.
+func WithRetry(d Driver, policy retry.Policy, logger *logrus.Logger) Driver {
+	if logger == nil {
+		logger = logrus.New()
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = werrors.IsUnreachable
+	}
+	r := &retryingDriver{Driver: d, policy: policy, logger: logger}
+	if monitor, ok := d.(CycleMonitor); ok {
+		return &retryingCycleDriver{retryingDriver: r, monitor: monitor}
+	}
+	return r
+}
+
+// retryingDriver retries the idempotent calls of a driver
+type retryingDriver struct {
+	Driver
+	policy retry.Policy
+	logger *logrus.Logger
+}
+
+// Login implements Driver
+func (d *retryingDriver) Login(ctx context.Context) error {
+	_, err := retry.Do(ctx, d.policy, d.Driver.Login, d.logRetry("login"))
+	return err
+}
+
+// GetStatus implements Driver
+func (d *retryingDriver) GetStatus(ctx context.Context) (*Status, error) {
+	var status *Status
+	_, err := retry.Do(ctx, d.policy, func(ctx context.Context) error {
+		var err error
+		status, err = d.Driver.GetStatus(ctx)
+		return err
+	}, d.logRetry("get_status"))
+	return status, err
+}
+
+// Unwrap returns the wrapped driver
+func (d *retryingDriver) Unwrap() Driver {
+	return d.Driver
+}
+
+// logRetry logs the retries of operation
+func (d *retryingDriver) logRetry(operation string) retry.Hook {
+	return func(attempt retry.Attempt) {
+		if attempt.Delay == 0 {
+			return
+		}
+		d.logger.WithFields(logrus.Fields{
+			"operation": operation,
+			"attempt":   attempt.Number,
+			"delay_ms":  attempt.Delay.Milliseconds(),
+		}).WithError(attempt.Err).Info("Modem did not answer, retrying")
+	}
+}
+
+// retryingCycleDriver is a retryingDriver whose wrapped driver monitors the
+// reboot cycle itself
+type retryingCycleDriver struct {
+	*retryingDriver
+	monitor CycleMonitor
+}
+
+// RebootWithMonitoring implements CycleMonitor
+func (d *retryingCycleDriver) RebootWithMonitoring(ctx context.Context, pollInterval, maxOfflineWait, maxOnlineWait time.Duration) (*RebootCycleResult, error) {
+	return d.monitor.RebootWithMonitoring(ctx, pollInterval, maxOfflineWait, maxOnlineWait)
+}
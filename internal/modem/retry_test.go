@@ -0,0 +1,60 @@
+package modem
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/perezjoseph/mb8600-watchdog/internal/retry"
+)
+
+// flakyDriver fails its first calls with failure
+type flakyDriver struct {
+	scriptedDriver
+	failures int
+	failure  error
+	statuses int
+}
+
+func (d *flakyDriver) GetStatus(ctx context.Context) (*Status, error) {
+	d.statuses++
+	if d.statuses <= d.failures {
+		return nil, d.failure
+	}
+	return &Status{Model: "flaky"}, nil
+}
+
+func TestWithRetryRetriesUnansweredRequests(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, Multiplier: 2}
+
+	flaky := &flakyDriver{failures: 2, failure: werrors.Mark(errors.New("connection refused"), werrors.ErrModemUnreachable)}
+	status, err := WithRetry(flaky, policy, nil).GetStatus(context.Background())
+	if err != nil || status.Model != "flaky" {
+		t.Fatalf("GetStatus() = %v, %v, want the status after two retries", status, err)
+	}
+	if flaky.statuses != 3 {
+		t.Errorf("Expected 3 status requests, got %d", flaky.statuses)
+	}
+
+	refused := &flakyDriver{failures: 2, failure: ErrAuthFailed}
+	if _, err := WithRetry(refused, policy, nil).GetStatus(context.Background()); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("Expected the authentication failure, got %v", err)
+	}
+	if refused.statuses != 1 {
+		t.Errorf("A modem that answered should not be asked again, got %d requests", refused.statuses)
+	}
+}
+
+func TestWithRetryKeepsCycleMonitoring(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 2}
+
+	if _, ok := WithRetry(&scriptedDriver{}, policy, nil).(CycleMonitor); ok {
+		t.Error("A driver without cycle monitoring should not gain it")
+	}
+	driver := NewMB8600(Options{Host: "127.0.0.1"}, nil)
+	if _, ok := WithRetry(driver, policy, nil).(CycleMonitor); !ok {
+		t.Error("Expected the cycle monitoring of the MB8600 driver to be kept")
+	}
+}
@@ -0,0 +1,116 @@
+package modem
+
+import (
+	"math"
+	"time"
+)
+
+// Signal comparison verdicts
+const (
+	SignalsImproved  = "improved"
+	SignalsUnchanged = "unchanged"
+	SignalsWorse     = "worse"
+)
+
+// snrSignificantDB is the change in mean downstream SNR that counts as a
+// change of the signal; smaller ones are noise between reads
+const snrSignificantDB = 1.0
+
+// SignalSummary sums up the channels of a status. Levels are the means of
+// the locked channels, since unlocked ones report no levels.
+type SignalSummary struct {
+	Time                time.Time `json:"time"`
+	DownstreamLocked    int       `json:"downstream_locked"`
+	DownstreamChannels  int       `json:"downstream_channels"`
+	DownstreamPowerDBmV float64   `json:"downstream_power_dbmv"`
+	DownstreamSNRDB     float64   `json:"downstream_snr_db"`
+	UpstreamLocked      int       `json:"upstream_locked"`
+	UpstreamChannels    int       `json:"upstream_channels"`
+	UpstreamPowerDBmV   float64   `json:"upstream_power_dbmv"`
+}
+
+// Summarize sums up the channels of status; ok is false when it has none
+func Summarize(status *Status) (SignalSummary, bool) {
+	if status == nil || len(status.Downstream)+len(status.Upstream) == 0 {
+		return SignalSummary{}, false
+	}
+	summary := SignalSummary{
+		Time:               status.FetchedAt,
+		DownstreamChannels: len(status.Downstream),
+		UpstreamChannels:   len(status.Upstream),
+	}
+	var snrChannels int
+	for _, channel := range status.Downstream {
+		if !channel.Locked() {
+			continue
+		}
+		summary.DownstreamLocked++
+		summary.DownstreamPowerDBmV += channel.PowerDBmV
+		if channel.SNRDB != 0 {
+			summary.DownstreamSNRDB += channel.SNRDB
+			snrChannels++
+		}
+	}
+	for _, channel := range status.Upstream {
+		if !channel.Locked() {
+			continue
+		}
+		summary.UpstreamLocked++
+		summary.UpstreamPowerDBmV += channel.PowerDBmV
+	}
+	summary.DownstreamPowerDBmV = mean(summary.DownstreamPowerDBmV, summary.DownstreamLocked)
+	summary.DownstreamSNRDB = mean(summary.DownstreamSNRDB, snrChannels)
+	summary.UpstreamPowerDBmV = mean(summary.UpstreamPowerDBmV, summary.UpstreamLocked)
+	return summary, true
+}
+
+// mean returns sum over n rounded to a tenth, or 0 without values
+func mean(sum float64, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	return math.Round(sum/float64(n)*10) / 10
+}
+
+// SignalDelta is the change of the signal from one summary to another
+type SignalDelta struct {
+	DownstreamLocked    int     `json:"downstream_locked"`
+	DownstreamPowerDBmV float64 `json:"downstream_power_dbmv"`
+	DownstreamSNRDB     float64 `json:"downstream_snr_db"`
+	UpstreamLocked      int     `json:"upstream_locked"`
+	UpstreamPowerDBmV   float64 `json:"upstream_power_dbmv"`
+}
+
+// SignalComparison compares the signal before a reboot with the signal
+// after the modem recovered
+type SignalComparison struct {
+	Before SignalSummary `json:"before"`
+	After  SignalSummary `json:"after"`
+	Delta  SignalDelta   `json:"delta"`
+	// Verdict is SignalsImproved, SignalsUnchanged or SignalsWorse
+	Verdict string `json:"verdict"`
+}
+
+// CompareSignals compares before with after. Locked channels decide the
+// verdict; with as many locked, a change of the mean downstream SNR does.
+func CompareSignals(before, after SignalSummary) SignalComparison {
+	delta := SignalDelta{
+		DownstreamLocked:    after.DownstreamLocked - before.DownstreamLocked,
+		DownstreamPowerDBmV: math.Round((after.DownstreamPowerDBmV-before.DownstreamPowerDBmV)*10) / 10,
+		DownstreamSNRDB:     math.Round((after.DownstreamSNRDB-before.DownstreamSNRDB)*10) / 10,
+		UpstreamLocked:      after.UpstreamLocked - before.UpstreamLocked,
+		UpstreamPowerDBmV:   math.Round((after.UpstreamPowerDBmV-before.UpstreamPowerDBmV)*10) / 10,
+	}
+	verdict := SignalsUnchanged
+	switch locked := delta.DownstreamLocked + delta.UpstreamLocked; {
+	case locked > 0:
+		verdict = SignalsImproved
+	case locked < 0:
+		verdict = SignalsWorse
+	case delta.DownstreamSNRDB >= snrSignificantDB:
+		verdict = SignalsImproved
+	case delta.DownstreamSNRDB <= -snrSignificantDB:
+		verdict = SignalsWorse
+	}
+	return SignalComparison{Before: before, After: after, Delta: delta, Verdict: verdict}
+}
@@ -0,0 +1,59 @@
+package modem
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	if _, ok := Summarize(&Status{Model: "MB8600"}); ok {
+		t.Error("Expected no summary of a status without channels")
+	}
+
+	status := &Status{
+		Downstream: []Channel{
+			{ChannelID: 1, LockStatus: "Locked", PowerDBmV: 2, SNRDB: 40},
+			{ChannelID: 2, LockStatus: "Locked", PowerDBmV: 4, SNRDB: 37},
+			{ChannelID: 3, LockStatus: "Not Locked"},
+		},
+		Upstream:  []Channel{{ChannelID: 1, LockStatus: "Locked", PowerDBmV: 44.5}},
+		FetchedAt: time.Unix(1714560000, 0),
+	}
+	summary, ok := Summarize(status)
+	want := SignalSummary{
+		Time:                status.FetchedAt,
+		DownstreamLocked:    2,
+		DownstreamChannels:  3,
+		DownstreamPowerDBmV: 3,
+		DownstreamSNRDB:     38.5,
+		UpstreamLocked:      1,
+		UpstreamChannels:    1,
+		UpstreamPowerDBmV:   44.5,
+	}
+	if !ok || summary != want {
+		t.Errorf("Expected %+v, got %+v", want, summary)
+	}
+}
+
+func TestCompareSignals(t *testing.T) {
+	before := SignalSummary{DownstreamLocked: 24, DownstreamSNRDB: 36, UpstreamLocked: 4, UpstreamPowerDBmV: 48}
+	for _, tc := range []struct {
+		name  string
+		after SignalSummary
+		want  string
+	}{
+		{"channels relocked", SignalSummary{DownstreamLocked: 32, DownstreamSNRDB: 35, UpstreamLocked: 4}, SignalsImproved},
+		{"channel lost", SignalSummary{DownstreamLocked: 24, DownstreamSNRDB: 40, UpstreamLocked: 3}, SignalsWorse},
+		{"better SNR", SignalSummary{DownstreamLocked: 24, DownstreamSNRDB: 37.5, UpstreamLocked: 4}, SignalsImproved},
+		{"noise", SignalSummary{DownstreamLocked: 24, DownstreamSNRDB: 36.4, UpstreamLocked: 4}, SignalsUnchanged},
+	} {
+		if got := CompareSignals(before, tc.after); got.Verdict != tc.want {
+			t.Errorf("%s: expected %s, got %+v", tc.name, tc.want, got)
+		}
+	}
+
+	delta := CompareSignals(before, SignalSummary{DownstreamLocked: 30, DownstreamSNRDB: 38.2, UpstreamLocked: 4, UpstreamPowerDBmV: 45.1}).Delta
+	if delta.DownstreamLocked != 6 || delta.DownstreamSNRDB != 2.2 || delta.UpstreamPowerDBmV != -2.9 {
+		t.Errorf("Unexpected delta %+v", delta)
+	}
+}
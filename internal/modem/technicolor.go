@@ -0,0 +1,277 @@
+package modem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Technicolor/ISP combo gateway page paths
+const (
+	technicolorLoginPath   = "/check.jst"
+	technicolorSummaryPath = "/at_a_glance.jst"
+	technicolorNetworkPath = "/network_setup.jst"
+	technicolorResetPath   = "/actionHandler/ajaxSet_Reset_Restore.jst"
+)
+
+// technicolorResetInfo holds the reset payloads per operating mode. In router
+// mode the whole gateway including the routing stack is restarted; in bridge
+// mode the router components are disabled and only the device is restarted.
+var technicolorResetInfo = map[string]string{
+	ModeRouter: `["btn1","Device","Router"]`,
+	ModeBridge: `["btn1","Device"]`,
+}
+
+func init() {
+	Register(TypeTechnicolor, func(opts Options, logger *logrus.Logger) Driver {
+		return NewTechnicolor(opts, logger)
+	})
+}
+
+// Technicolor drives ISP-supplied Technicolor combo gateways (modem + router)
+// using the JST web interface. The gateway may run in router or bridge mode;
+// the mode is detected from the summary page and decides how reboots are sent.
+type Technicolor struct {
+	opts       Options
+	httpClient *http.Client
+	logger     *logrus.Logger
+	baseURL    string
+}
+
+// NewTechnicolor creates a new Technicolor gateway driver
+func NewTechnicolor(opts Options, logger *logrus.Logger) *Technicolor {
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.InfoLevel)
+	}
+
+	return &Technicolor{
+		opts:       opts,
+		httpClient: newHTTPClient(opts, 30*time.Second),
+		logger:     logger,
+		baseURL:    baseURL(opts, "http"),
+	}
+}
+
+// Name returns the driver's modem type
+func (t *Technicolor) Name() string {
+	return TypeTechnicolor
+}
+
+// Login submits the login form; the session cookie is kept in the cookie jar
+func (t *Technicolor) Login(ctx context.Context) error {
+	t.logger.Debug("Performing Technicolor gateway login")
+
+	form := url.Values{}
+	form.Set("username", t.opts.Username)
+	form.Set("password", t.opts.Password)
+	form.Set("locale", "false")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.baseURL+technicolorLoginPath, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("login request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read login response: %w", err)
+	}
+
+	if resp.StatusCode >= 400 || isTechnicolorLoginPage(string(body)) {
+		return fmt.Errorf("%w: login form returned", ErrAuthFailed)
+	}
+
+	t.logger.Info("Technicolor gateway authentication successful")
+	return nil
+}
+
+// GetStatus detects the operating mode and scrapes the channel tables
+func (t *Technicolor) GetStatus(ctx context.Context) (*Status, error) {
+	summary, err := t.getPage(ctx, technicolorSummaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch summary page: %w", err)
+	}
+
+	summaryRows := parseHTMLRows(summary)
+	status := &Status{
+		Model:           findValue(summaryRows, "Model"),
+		FirmwareVersion: findValue(summaryRows, "Software Version"),
+		TemperatureC:    findTemperature(summaryRows),
+		Mode:            detectTechnicolorMode(summary),
+		FetchedAt:       time.Now(),
+	}
+
+	network, err := t.getPage(ctx, technicolorNetworkPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch network page: %w", err)
+	}
+
+	rows := parseHTMLRows(network)
+	status.Downstream = parseTransposedChannels(findSection(rows, "Downstream"))
+	status.Upstream = parseTransposedChannels(findSection(rows, "Upstream"))
+	applyCodewordCounts(status.Downstream, findSection(rows, "CM Error Codewords"))
+
+	return status, nil
+}
+
+// Reboot sends the reset request matching the gateway's current mode
+func (t *Technicolor) Reboot(ctx context.Context) error {
+	t.logger.Info("Sending reboot command to Technicolor gateway")
+
+	summary, err := t.getPage(ctx, technicolorSummaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to determine gateway mode: %w", err)
+	}
+	mode := detectTechnicolorMode(summary)
+
+	form := url.Values{}
+	form.Set("resetInfo", technicolorResetInfo[mode])
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.baseURL+technicolorResetPath, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("reboot request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%w: status %d", werrors.ErrRebootRejected, resp.StatusCode)
+	}
+
+	t.logger.WithField("mode", mode).Info("Reboot command sent successfully")
+	return nil
+}
+
+// getPage fetches a page, logging in when the gateway serves the login form
+func (t *Technicolor) getPage(ctx context.Context, path string) (string, error) {
+	body, err := t.fetch(ctx, path)
+	if err == ErrSessionExpired {
+		t.logger.Debug("Technicolor session expired, re-authenticating")
+		if loginErr := t.Login(ctx); loginErr != nil {
+			return "", loginErr
+		}
+		body, err = t.fetch(ctx, path)
+	}
+	return body, err
+}
+
+// fetch performs a single GET request using the session cookie
+func (t *Technicolor) fetch(ctx context.Context, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", t.baseURL+path, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	content := string(body)
+	if resp.StatusCode == http.StatusUnauthorized || isTechnicolorLoginPage(content) {
+		return "", ErrSessionExpired
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response status %d for %s", resp.StatusCode, path)
+	}
+	return content, nil
+}
+
+// isTechnicolorLoginPage detects the login form served to unauthenticated sessions
+func isTechnicolorLoginPage(body string) bool {
+	return strings.Contains(body, `action="check.jst"`) || strings.Contains(body, `action="/check.jst"`)
+}
+
+// detectTechnicolorMode reports bridge or router mode from the summary page
+func detectTechnicolorMode(summary string) string {
+	value := strings.ToLower(findValue(parseHTMLRows(summary), "Bridge Mode"))
+	if value == "enabled" || value == "on" || strings.Contains(strings.ToLower(summary), "bridge mode is enabled") {
+		return ModeBridge
+	}
+	return ModeRouter
+}
+
+// parseTransposedChannels parses channel tables where each row is an attribute
+// and each column a channel, e.g. ["Lock Status", "Locked", "Locked"]
+func parseTransposedChannels(rows [][]string) []Channel {
+	var channels []Channel
+	for _, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+		if channels == nil {
+			channels = make([]Channel, len(row)-1)
+		}
+
+		label := strings.ToLower(row[0])
+		for i, value := range row[1:] {
+			if i >= len(channels) {
+				break
+			}
+			ch := &channels[i]
+			switch {
+			case label == "channel id" || label == "index":
+				ch.ChannelID = int(parseLeadingInt(value))
+			case label == "lock status":
+				ch.LockStatus = value
+			case label == "frequency":
+				ch.FrequencyMHz = parseFrequencyMHz(value)
+			case strings.HasPrefix(label, "snr"):
+				ch.SNRDB = parseLeadingFloat(value)
+			case strings.HasPrefix(label, "power"):
+				ch.PowerDBmV = parseLeadingFloat(value)
+			case label == "modulation":
+				ch.Modulation = value
+			}
+		}
+	}
+	return channels
+}
+
+// applyCodewordCounts merges the error codeword table into downstream channels
+func applyCodewordCounts(channels []Channel, rows [][]string) {
+	for _, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+		label := strings.ToLower(row[0])
+		for i, value := range row[1:] {
+			if i >= len(channels) {
+				break
+			}
+			switch {
+			case strings.HasPrefix(label, "correctable"):
+				channels[i].Corrected = parseLeadingInt(value)
+			case strings.HasPrefix(label, "uncorrectable"):
+				channels[i].Uncorrectables = parseLeadingInt(value)
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package modem
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const technicolorLoginHTML = `<form action="check.jst" method="post"><input name="username"></form>`
+
+const technicolorNetworkHTML = `<table>
+<tr><th>Downstream</th></tr>
+<tr><td>Index</td><td>1</td><td>2</td></tr>
+<tr><td>Lock Status</td><td>Locked</td><td>Locked</td></tr>
+<tr><td>Frequency</td><td>555 MHz</td><td>561 MHz</td></tr>
+<tr><td>SNR</td><td>38.6 dB</td><td>38.9 dB</td></tr>
+<tr><td>Power Level</td><td>4.5 dBmV</td><td>4.1 dBmV</td></tr>
+<tr><td>Modulation</td><td>256 QAM</td><td>256 QAM</td></tr>
+<tr><th>Upstream</th></tr>
+<tr><td>Index</td><td>1</td></tr>
+<tr><td>Lock Status</td><td>Locked</td></tr>
+<tr><td>Frequency</td><td>35 MHz</td></tr>
+<tr><td>Power Level</td><td>42.0 dBmV</td></tr>
+<tr><th>CM Error Codewords</th></tr>
+<tr><td>Correctable Codewords</td><td>10</td><td>20</td></tr>
+<tr><td>Uncorrectable Codewords</td><td>1</td><td>2</td></tr>
+</table>`
+
+// fakeTechnicolor emulates the JST session login and mode-dependent reset
+type fakeTechnicolor struct {
+	mu        sync.Mutex
+	bridge    bool
+	resetInfo string
+}
+
+func (f *fakeTechnicolor) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if r.URL.Path == technicolorLoginPath {
+			if r.FormValue("username") != "admin" || r.FormValue("password") != "password" {
+				w.Write([]byte(technicolorLoginHTML))
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "DUKSID", Value: "session", Path: "/"})
+			w.Write([]byte("<html>at a glance</html>"))
+			return
+		}
+
+		if _, err := r.Cookie("DUKSID"); err != nil {
+			w.Write([]byte(technicolorLoginHTML))
+			return
+		}
+
+		switch r.URL.Path {
+		case technicolorSummaryPath:
+			bridge := "Disabled"
+			if f.bridge {
+				bridge = "Enabled"
+			}
+			w.Write([]byte(`<table><tr><td>Model:</td><td>TG3482G</td></tr>
+				<tr><td>Software Version:</td><td>9.1.103</td></tr>
+				<tr><td>Bridge Mode</td><td>` + bridge + `</td></tr></table>`))
+		case technicolorNetworkPath:
+			w.Write([]byte(technicolorNetworkHTML))
+		case technicolorResetPath:
+			f.resetInfo = r.FormValue("resetInfo")
+			w.Write([]byte(`{"reboot":"true"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func newTestTechnicolor(t *testing.T, fake *fakeTechnicolor, password string) *Technicolor {
+	server := httptest.NewServer(fake.handler())
+	t.Cleanup(server.Close)
+
+	return NewTechnicolor(Options{
+		Host:     strings.TrimPrefix(server.URL, "http://"),
+		Username: "admin",
+		Password: password,
+	}, nil)
+}
+
+func TestTechnicolorGetStatus(t *testing.T) {
+	driver := newTestTechnicolor(t, &fakeTechnicolor{}, "password")
+
+	status, err := driver.GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatus() failed: %v", err)
+	}
+
+	if status.Model != "TG3482G" || status.FirmwareVersion != "9.1.103" {
+		t.Errorf("Unexpected product info: %q %q", status.Model, status.FirmwareVersion)
+	}
+	if status.Mode != ModeRouter {
+		t.Errorf("Expected router mode, got %q", status.Mode)
+	}
+	if len(status.Downstream) != 2 {
+		t.Fatalf("Expected 2 downstream channels, got %d", len(status.Downstream))
+	}
+	ds := status.Downstream[1]
+	if ds.ChannelID != 2 || !ds.Locked() || ds.FrequencyMHz != 561 || ds.SNRDB != 38.9 ||
+		ds.PowerDBmV != 4.1 || ds.Corrected != 20 || ds.Uncorrectables != 2 {
+		t.Errorf("Unexpected downstream channel: %+v", ds)
+	}
+	if len(status.Upstream) != 1 || status.Upstream[0].PowerDBmV != 42.0 {
+		t.Errorf("Unexpected upstream channels: %+v", status.Upstream)
+	}
+}
+
+func TestTechnicolorBridgeMode(t *testing.T) {
+	fake := &fakeTechnicolor{bridge: true}
+	driver := newTestTechnicolor(t, fake, "password")
+
+	status, err := driver.GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatus() failed: %v", err)
+	}
+	if status.Mode != ModeBridge {
+		t.Errorf("Expected bridge mode, got %q", status.Mode)
+	}
+
+	if err := driver.Reboot(context.Background()); err != nil {
+		t.Fatalf("Reboot() failed: %v", err)
+	}
+	if fake.resetInfo != technicolorResetInfo[ModeBridge] {
+		t.Errorf("Expected bridge reset payload, got %q", fake.resetInfo)
+	}
+}
+
+func TestTechnicolorRouterReboot(t *testing.T) {
+	fake := &fakeTechnicolor{}
+	driver := newTestTechnicolor(t, fake, "password")
+
+	if err := driver.Reboot(context.Background()); err != nil {
+		t.Fatalf("Reboot() failed: %v", err)
+	}
+	if fake.resetInfo != technicolorResetInfo[ModeRouter] {
+		t.Errorf("Expected router reset payload, got %q", fake.resetInfo)
+	}
+}
+
+func TestTechnicolorLoginFailure(t *testing.T) {
+	driver := newTestTechnicolor(t, &fakeTechnicolor{}, "wrong")
+
+	if err := driver.Login(context.Background()); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("Expected ErrAuthFailed, got %v", err)
+	}
+	if _, err := driver.GetStatus(context.Background()); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("Expected ErrAuthFailed from GetStatus, got %v", err)
+	}
+}
+
+func TestDetectTechnicolorMode(t *testing.T) {
+	if mode := detectTechnicolorMode("<p>Bridge Mode is enabled on this device</p>"); mode != ModeBridge {
+		t.Errorf("Expected bridge mode from notice text, got %q", mode)
+	}
+	if mode := detectTechnicolorMode("<p>Welcome</p>"); mode != ModeRouter {
+		t.Errorf("Expected router mode by default, got %q", mode)
+	}
+}
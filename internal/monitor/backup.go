@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/backup"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+// BackupJobName is the scheduler job name of the scheduled backup
+const BackupJobName = "backup"
+
+// scheduleBackup registers the scheduled backup, if an interval is
+// configured
+func (s *Service) scheduleBackup() error {
+	if s.config.BackupInterval <= 0 {
+		return nil
+	}
+	dest, err := backup.NewDestination(s.config.Backup(), outboundClient(s.config, s.opts, 10*time.Minute))
+	if err != nil {
+		return err
+	}
+	run := func(ctx context.Context) error {
+		return s.runBackup(ctx, dest)
+	}
+	if err := s.scheduler.Add(BackupJobName, scheduler.Every(s.config.BackupInterval), run); err != nil {
+		return err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"destination": dest.String(),
+		"interval":    s.config.BackupInterval,
+	}).Info("Backing up state and history")
+	return nil
+}
+
+// runBackup writes a backup to a temporary file in the state directory and
+// stores it in dest
+func (s *Service) runBackup(ctx context.Context, dest backup.Destination) error {
+	// Events held in low-power mode belong in the backup
+	if err := history.Flush(); err != nil {
+		s.logger.WithError(err).Warn("Failed to write held history events before backup")
+	}
+
+	if err := os.MkdirAll(s.config.StateDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.config.StateDir(), ".backup-*")
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	now := s.clock.Now()
+	manifest, err := backup.Create(tmp, s.config.BackupSources(), now)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to create backup")
+		return err
+	}
+	size, err := tmp.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	name := backup.FileName(now)
+	if err := dest.Store(ctx, name, tmp, size); err != nil {
+		s.logger.WithError(err).WithField("destination", dest.String()).Error("Failed to store backup")
+		return err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"destination": dest.String(),
+		"name":        name,
+		"files":       len(manifest.Files),
+		"bytes":       size,
+	}).Info("Backup stored")
+	return nil
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/beacon"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+// BeaconJobName is the scheduler job name of the status beacon
+const BeaconJobName = "status_beacon"
+
+// scheduleBeacon registers the status beacon, if an endpoint is configured
+func (s *Service) scheduleBeacon() error {
+	if s.config.BeaconURL == "" {
+		return nil
+	}
+	b := beacon.New(s.config.Beacon(), outboundClient(s.config, s.opts, 30*time.Second))
+	send := func(ctx context.Context) error {
+		return s.sendBeacon(ctx, b)
+	}
+	if err := s.scheduler.Add(BeaconJobName, scheduler.Every(s.config.BeaconInterval), send); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"url":       s.config.BeaconURL,
+		"interface": s.config.BeaconInterface,
+		"interval":  s.config.BeaconInterval,
+	}).Info("Sending status beacon")
+	return nil
+}
+
+// sendBeacon reports the current state through b. Failures are logged when
+// the beacon stops and starts getting through, not on every attempt, since
+// the secondary link may be down for as long as the primary one.
+func (s *Service) sendBeacon(ctx context.Context, b *beacon.Beacon) error {
+	err := b.Send(ctx, s.beaconStatus())
+	if err != nil {
+		if !s.beaconFailing && ctx.Err() == nil {
+			s.logger.WithError(err).Warn("Status beacon failed")
+		}
+		s.beaconFailing = ctx.Err() == nil
+		return err
+	}
+	if s.beaconFailing {
+		s.logger.Info("Status beacon delivered again")
+		s.beaconFailing = false
+	}
+	return nil
+}
+
+// beaconStatus summarizes the published state for the beacon
+func (s *Service) beaconStatus() beacon.Status {
+	state := s.Snapshot()
+	now := s.clock.Now()
+	hostname, _ := os.Hostname()
+
+	status := beacon.Status{
+		Hostname:     hostname,
+		Time:         now,
+		Health:       state.Health,
+		HealthScore:  state.HealthScore,
+		FailureCount: state.FailureCount,
+		LastCheck:    state.LastCheck,
+		TotalReboots: state.TotalReboots,
+		Rebooting:    state.Reboot != nil,
+		Paused:       state.Pause != nil,
+		LowPower:     state.LowPower,
+	}
+	if !state.StartTime.IsZero() {
+		status.Uptime = int64(now.Sub(state.StartTime).Seconds())
+	}
+	return status
+}
@@ -0,0 +1,96 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/connectivity"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+	"github.com/perezjoseph/mb8600-watchdog/internal/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// HistoryMaintenanceJobName is the scheduler job name of the history
+// downsampling and pruning
+const HistoryMaintenanceJobName = "history_maintenance"
+
+// recordsChecks reports whether check results are recorded in the history
+func (s *Service) recordsChecks() bool {
+	return s.config.HistoryRawRetention > 0 && s.historyEnabled()
+}
+
+// recordCheck appends the outcome of a check to the history. Failures are
+// only logged; the check has already been acted on.
+func (s *Service) recordCheck(result *connectivity.TieredTestResult) {
+	if !s.recordsChecks() {
+		return
+	}
+	summary := result.GetTestSummary()
+	details := map[string]interface{}{
+		"success":     summary.OverallSuccess,
+		"strategy":    summary.Strategy,
+		"duration_ms": summary.TotalDurationMS,
+	}
+	if s.cycleID != "" {
+		details[trace.Field] = s.cycleID
+	}
+	event := history.Event{Time: s.clock.Now(), Kind: history.KindCheck, Details: details}
+	if err := history.Append(s.config.HistoryPath(), event); err != nil {
+		s.logger.WithError(err).Warn("Failed to record check result")
+	}
+}
+
+// Events returns the events of the history recorded at or after since,
+// oldest first, only those of kinds if any are given. Without the history
+// there are none.
+func (s *Service) Events(since time.Time, kinds ...string) ([]history.Event, error) {
+	if !s.historyEnabled() {
+		return nil, nil
+	}
+	return history.Read(s.config.HistoryPath(), since, kinds...)
+}
+
+// scheduleHistoryMaintenance registers the hourly downsampling and pruning
+// of the history
+func (s *Service) scheduleHistoryMaintenance() error {
+	if !s.historyEnabled() {
+		return nil
+	}
+	return s.scheduler.Add(HistoryMaintenanceJobName, scheduler.Every(time.Hour), s.maintainHistory)
+}
+
+// maintainHistory replaces the check results older than the raw retention
+// with hourly aggregates, then prunes the history to its limits
+func (s *Service) maintainHistory(ctx context.Context) error {
+	path := s.config.HistoryPath()
+	if s.recordsChecks() {
+		replaced, err := history.Downsample(path, s.clock.Now().Add(-s.config.HistoryRawRetention))
+		if err != nil {
+			s.logger.WithError(err).Warn("Failed to downsample history")
+			return err
+		}
+		if replaced > 0 {
+			s.logger.WithFields(logrus.Fields{
+				"checks":    replaced,
+				"retention": s.config.HistoryRawRetention,
+			}).Info("Downsampled old check results to hourly aggregates")
+		}
+	}
+
+	retention := s.config.HistoryRetention()
+	dropped, err := history.Prune(path, s.clock.Now(), retention)
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to prune history")
+		return err
+	}
+	if dropped > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"events":     dropped,
+			"max_age":    retention.MaxAge,
+			"max_events": retention.MaxEvents,
+			"max_bytes":  retention.MaxBytes,
+		}).Info("Pruned old events from the history")
+	}
+	return nil
+}
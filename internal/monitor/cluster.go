@@ -0,0 +1,111 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/cluster"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+// ClusterHeartbeatJobName is the scheduler job name of the heartbeat poll of
+// the other cluster node
+const ClusterHeartbeatJobName = "cluster_heartbeat"
+
+// scheduleClusterHeartbeat makes the service a cluster node and registers
+// the poll of its peer, if a cluster role is configured
+func (s *Service) scheduleClusterHeartbeat() error {
+	cfg := s.config.Cluster()
+	if !cfg.Enabled() {
+		s.setClusterNode(nil)
+		return nil
+	}
+	// The peer is on the local network, never behind the outbound proxy
+	node, err := cluster.New(cfg, &http.Client{}, s.clock.Now())
+	if err != nil {
+		return err
+	}
+	s.setClusterNode(node)
+	s.clusterActive = node.Status(s.clock.Now()).Active
+	s.clusterPeerFailing = false
+
+	poll := func(ctx context.Context) error {
+		return s.clusterHeartbeat(ctx, node)
+	}
+	if err := s.scheduler.Add(ClusterHeartbeatJobName, scheduler.Every(cfg.HeartbeatInterval), poll); err != nil {
+		return err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"role":           cfg.Role,
+		"peer":           cfg.Peer,
+		"interval":       cfg.HeartbeatInterval,
+		"failover_after": cfg.FailoverAfter,
+	}).Info("Running as cluster node")
+	return nil
+}
+
+// clusterHeartbeat polls the peer and logs when this node takes over or
+// hands back the modem reboots. Failed polls are logged when the peer stops
+// and starts answering, not on every attempt.
+func (s *Service) clusterHeartbeat(ctx context.Context, node *cluster.Node) error {
+	err := node.Poll(ctx, s.clock.Now())
+	if err != nil && ctx.Err() == nil {
+		if !s.clusterPeerFailing {
+			s.logger.WithError(err).Warn("Cluster peer heartbeat failed")
+		}
+		s.clusterPeerFailing = true
+	} else if err == nil && s.clusterPeerFailing {
+		s.logger.Info("Cluster peer answering again")
+		s.clusterPeerFailing = false
+	}
+
+	status := node.Status(s.clock.Now())
+	if status.Active != s.clusterActive {
+		s.clusterActive = status.Active
+		fields := logrus.Fields{"role": status.Role, "peer": status.Peer, "reason": status.Reason}
+		if status.Active {
+			s.logger.WithFields(fields).Warn("Cluster node taking over modem reboots")
+		} else {
+			s.logger.WithFields(fields).Warn("Cluster node standing by, the peer reboots the modem")
+		}
+		s.publishState()
+	}
+	return err
+}
+
+// setClusterNode makes the service the cluster node node, or not clustered
+// when it is nil
+func (s *Service) setClusterNode(node *cluster.Node) {
+	s.clusterMu.Lock()
+	s.cluster = node
+	s.clusterMu.Unlock()
+}
+
+// clusterNode returns the service's cluster node, nil when not clustered
+func (s *Service) clusterNode() *cluster.Node {
+	s.clusterMu.Lock()
+	defer s.clusterMu.Unlock()
+	return s.cluster
+}
+
+// clusterStatus returns the service's view of the cluster, nil when not
+// clustered
+func (s *Service) clusterStatus() *cluster.Status {
+	node := s.clusterNode()
+	if node == nil {
+		return nil
+	}
+	status := node.Status(s.clock.Now())
+	return &status
+}
+
+// clusterMayReboot reports whether this node may reboot the modem on its
+// own and, if not, why. Nodes that are not clustered always may.
+func (s *Service) clusterMayReboot() (bool, string) {
+	node := s.clusterNode()
+	if node == nil {
+		return true, ""
+	}
+	return node.MayReboot(s.clock.Now())
+}
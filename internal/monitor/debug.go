@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/connectivity"
+	"github.com/perezjoseph/mb8600-watchdog/internal/diagnostics"
+	"github.com/perezjoseph/mb8600-watchdog/internal/outage"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+)
+
+// DebugInfo is the service's part of a debug dump
+type DebugInfo struct {
+	State ServiceState `json:"state"`
+	// CheckRunning means a check cycle or reboot holds the cycle lock; when
+	// it stays set across dumps, the cycle is stuck
+	CheckRunning  bool                         `json:"check_running"`
+	CurrentOutage *outage.OutageEvent          `json:"current_outage,omitempty"`
+	Jobs          map[string]DebugJob          `json:"jobs"`
+	Breakers      map[string]map[string]string `json:"circuit_breakers"`
+	// Targets is the reliability of each connectivity check target
+	Targets        []connectivity.TargetHealth    `json:"targets,omitempty"`
+	LastTestResult *connectivity.TieredTestResult `json:"last_test_result,omitempty"`
+	LastAnalysis   *diagnostics.AnalysisResult    `json:"last_analysis,omitempty"`
+	StartupReport  *StartupReport                 `json:"startup_report,omitempty"`
+}
+
+// DebugJob is a scheduled job in a debug dump
+type DebugJob struct {
+	Next  time.Time          `json:"next"`
+	Stats scheduler.JobStats `json:"stats"`
+}
+
+// breakerReporter is implemented by checkers with circuit breakers
+type breakerReporter interface {
+	BreakerStates() map[string]string
+}
+
+// targetReporter is implemented by checkers that track their targets
+type targetReporter interface {
+	TargetHealth() []connectivity.TargetHealth
+}
+
+// DebugInfo collects the service state for a debug dump. It never waits for
+// a running check, so it also works when a check is stuck; the state and
+// results are those of the last completed check.
+func (s *Service) DebugInfo() DebugInfo {
+	s.snapshotMu.RLock()
+	info := DebugInfo{
+		State:          s.snapshot,
+		LastTestResult: s.snapshotResult,
+		LastAnalysis:   s.snapshotAnalysis,
+		StartupReport:  s.startupReport,
+	}
+	s.snapshotMu.RUnlock()
+
+	if s.cycleMu.TryLock() {
+		s.cycleMu.Unlock()
+	} else {
+		info.CheckRunning = true
+	}
+	info.CurrentOutage = s.outageTracker.GetCurrentOutage()
+
+	info.Jobs = make(map[string]DebugJob)
+	for _, name := range s.scheduler.Jobs() {
+		next, _ := s.scheduler.Next(name)
+		info.Jobs[name] = DebugJob{Next: next, Stats: s.scheduler.Stats(name)}
+	}
+
+	info.Breakers = make(map[string]map[string]string)
+	if s.analyzer != nil {
+		info.Breakers["diagnostics"] = s.analyzer.BreakerStates()
+	}
+	if tester, ok := s.tester.(breakerReporter); ok {
+		info.Breakers["connectivity"] = tester.BreakerStates()
+	}
+	if tester, ok := s.tester.(targetReporter); ok {
+		info.Targets = tester.TargetHealth()
+	}
+	return info
+}
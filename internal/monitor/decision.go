@@ -0,0 +1,187 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/features"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/quorum"
+	"github.com/sirupsen/logrus"
+)
+
+// Decision outcomes
+const (
+	DecisionReboot = "reboot"
+	DecisionSkip   = "skip"
+)
+
+// Decision triggers
+const (
+	TriggerAutomatic = "automatic"
+	TriggerManual    = "manual"
+	TriggerScheduled = "scheduled"
+)
+
+// Reboot reasons classify reboots, so statistics can tell reboots the
+// watchdog triggered on its own from ones a user asked for
+const (
+	// RebootThreshold is an automatic reboot once the failure threshold was reached
+	RebootThreshold = "threshold"
+	// RebootManual is a reboot requested through the API or the CLI
+	RebootManual = "manual"
+	// RebootScheduled is a preventive reboot on the ScheduledReboot schedule
+	RebootScheduled = "scheduled"
+	// RebootSelfTest is a reboot run by a scheduled self-test
+	RebootSelfTest = "self_test"
+	// RebootEscalation is a reboot a recovery escalation ended in
+	RebootEscalation = "escalation"
+	// RebootPartialService is a reboot after partial service lasted
+	// PartialServiceRebootAfter
+	RebootPartialService = "partial_service"
+)
+
+// RebootStatePrefix prefixes the reboot counter of every reason in the
+// state file, as in reboots_threshold=3
+const RebootStatePrefix = "reboots_"
+
+// RebootReasons lists every reboot reason
+var RebootReasons = []string{RebootThreshold, RebootManual, RebootScheduled, RebootSelfTest, RebootEscalation, RebootPartialService}
+
+// RebootAutomated reports whether reason is a reboot the watchdog triggered
+// on its own
+func RebootAutomated(reason string) bool {
+	return reason != RebootManual
+}
+
+// countReboot counts a completed reboot of the given reason
+func (s *Service) countReboot(reason string) {
+	s.totalReboots++
+	if s.rebootsByReason == nil {
+		s.rebootsByReason = make(map[string]int, len(RebootReasons))
+	}
+	s.rebootsByReason[reason]++
+}
+
+// Decision records a reboot that was triggered or deliberately skipped along
+// with the inputs that led to it. It is kept in the history, so
+// `watchdog history --explain` can tell why the modem was or was not
+// rebooted.
+type Decision struct {
+	Outcome string `json:"outcome"`
+	Trigger string `json:"trigger"`
+	Reason  string `json:"reason"`
+	// CycleID is the check cycle that made the decision, empty for reboots
+	// outside a cycle such as manual ones
+	CycleID string `json:"cycle_id,omitempty"`
+
+	FailureCount     int `json:"failure_count"`
+	FailureThreshold int `json:"failure_threshold"`
+
+	// Health and HealthScore describe the last connectivity check
+	Health      string  `json:"health,omitempty"`
+	HealthScore float64 `json:"health_score"`
+	// DegradedScore and RebootScore are the health score cutoffs
+	DegradedScore float64 `json:"degraded_score"`
+	RebootScore   float64 `json:"reboot_score"`
+
+	DiagnosticsEnabled bool `json:"diagnostics_enabled"`
+	// DiagnosticsScore is the health score of the diagnostics, nil when
+	// they did not run
+	DiagnosticsScore *float64 `json:"diagnostics_score,omitempty"`
+	Patterns         []string `json:"patterns,omitempty"`
+	Cause            string   `json:"cause,omitempty"`
+
+	// Verdict locates the failure behind an automatic decision: lan, wan
+	// or upstream
+	Verdict string `json:"verdict,omitempty"`
+	// Quorum is the answer of the other agents, nil when none were asked
+	Quorum *quorum.Result `json:"quorum,omitempty"`
+	// Policy names the decision policy that decided, empty for the
+	// built-in one
+	Policy string `json:"policy,omitempty"`
+
+	// RecoveryWait is the cool-down after a reboot before checks resume
+	RecoveryWait string `json:"recovery_wait"`
+	// SinceLastReboot is empty when the modem was not rebooted before
+	SinceLastReboot string `json:"since_last_reboot,omitempty"`
+	TotalReboots    int    `json:"total_reboots"`
+
+	// Error is set when a triggered reboot failed
+	Error string `json:"error,omitempty"`
+}
+
+// newDecision collects the inputs of a reboot decision from the current state
+func (s *Service) newDecision(outcome, trigger, reason string) Decision {
+	degraded, reboot := s.health.Cutoffs()
+	decision := Decision{
+		Outcome:            outcome,
+		Trigger:            trigger,
+		Reason:             reason,
+		CycleID:            s.cycleID,
+		FailureCount:       s.failureCount,
+		FailureThreshold:   s.config.FailureThreshold,
+		Health:             s.healthStatus,
+		HealthScore:        s.healthScore,
+		DegradedScore:      degraded,
+		RebootScore:        reboot,
+		DiagnosticsEnabled: s.config.EnableDiagnostics && s.diagnosticsAvailable(),
+		RecoveryWait:       s.config.RecoveryWait.String(),
+		TotalReboots:       s.totalReboots,
+	}
+	if analysis := s.lastAnalysis; analysis != nil {
+		score := analysis.HealthScore
+		decision.DiagnosticsScore = &score
+		decision.Cause = analysis.Cause
+		for _, pattern := range analysis.FailurePatterns {
+			decision.Patterns = append(decision.Patterns, pattern.Pattern)
+		}
+	}
+	if !s.lastReboot.IsZero() {
+		decision.SinceLastReboot = s.clock.Now().Sub(s.lastReboot).Round(time.Second).String()
+	}
+	return decision
+}
+
+// historyEnabled reports whether the history is built in and not switched
+// off with DISABLED_FEATURES
+func (s *Service) historyEnabled() bool {
+	return features.History && s.config.FeatureEnabled(features.NameHistory)
+}
+
+// recordDecision appends decision to the history, unless the history is
+// disabled. The decision has already been acted on, so failures are only
+// logged.
+func (s *Service) recordDecision(decision Decision) {
+	if !s.historyEnabled() {
+		return
+	}
+	details, err := decision.details()
+	if err == nil {
+		event := history.Event{Time: s.clock.Now(), Kind: history.KindRebootDecision, Details: details}
+		err = history.Append(s.config.HistoryPath(), event)
+	}
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to record reboot decision")
+		return
+	}
+	s.logger.WithFields(logrus.Fields{
+		"outcome": decision.Outcome,
+		"trigger": decision.Trigger,
+		"reason":  decision.Reason,
+	}).Debug("Recorded reboot decision")
+}
+
+// details returns the decision as history event details
+func (d Decision) details() (map[string]interface{}, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode reboot decision: %w", err)
+	}
+	var details map[string]interface{}
+	if err := json.Unmarshal(data, &details); err != nil {
+		return nil, fmt.Errorf("failed to encode reboot decision: %w", err)
+	}
+	return details, nil
+}
@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/diagnostics"
+)
+
+// DiagnosticsSummaryVersion is the version of the DiagnosticsSummary JSON
+// layout. It only changes when a key is renamed or removed, or its meaning
+// changes.
+const DiagnosticsSummaryVersion = 1
+
+// DiagnosticsSummary is the gist of the last diagnostic analysis, kept in
+// the state so the status command can explain the last reboot decision
+type DiagnosticsSummary struct {
+	Version      int       `json:"version"`
+	Time         time.Time `json:"time"`
+	CycleID      string    `json:"cycle_id,omitempty"`
+	ShouldReboot bool      `json:"should_reboot"`
+	HealthScore  float64   `json:"health_score"`
+	// SuccessRate is the share of passed tests, 0-1
+	SuccessRate float64 `json:"success_rate"`
+	// Cause classifies the failure when diagnostics pinned it down
+	Cause string `json:"cause,omitempty"`
+	// Layers are the success rates of the tested layers, lowest layer first
+	Layers          []LayerSummary `json:"layers,omitempty"`
+	Patterns        []string       `json:"patterns,omitempty"`
+	Recommendations []string       `json:"recommendations,omitempty"`
+}
+
+// LayerSummary is the success rate, 0-1, of the tests of a network layer
+type LayerSummary struct {
+	Layer       string  `json:"layer"`
+	SuccessRate float64 `json:"success_rate"`
+}
+
+// summarizeAnalysis returns the summary of analysis, made at now
+func summarizeAnalysis(analysis diagnostics.AnalysisResult, now time.Time) *DiagnosticsSummary {
+	summary := &DiagnosticsSummary{
+		Version:         DiagnosticsSummaryVersion,
+		Time:            now,
+		CycleID:         analysis.CycleID,
+		ShouldReboot:    analysis.ShouldReboot,
+		HealthScore:     analysis.HealthScore,
+		SuccessRate:     analysis.OverallSuccessRate,
+		Cause:           analysis.Cause,
+		Recommendations: analysis.Recommendations,
+	}
+	for layer := diagnostics.PhysicalLayer; layer <= diagnostics.ApplicationLayer; layer++ {
+		if stats, ok := analysis.LayerStatistics[layer.String()]; ok {
+			summary.Layers = append(summary.Layers, LayerSummary{Layer: layer.String(), SuccessRate: stats.SuccessRate})
+		}
+	}
+	for _, pattern := range analysis.FailurePatterns {
+		summary.Patterns = append(summary.Patterns, pattern.Description)
+	}
+	return summary
+}
@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/diagnostics"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDiagnosticsSummaryPersisted(t *testing.T) {
+	now := time.Date(2024, 1, 1, 3, 12, 0, 0, time.UTC)
+	summary := summarizeAnalysis(diagnostics.AnalysisResult{
+		OverallSuccessRate: 0.25,
+		HealthScore:        20,
+		ShouldReboot:       true,
+		LayerStatistics: map[string]diagnostics.LayerStats{
+			"Application": {SuccessRate: 0},
+			"Physical":    {SuccessRate: 1},
+			"Network":     {SuccessRate: 0.5},
+		},
+		FailurePatterns: []diagnostics.FailurePattern{
+			{Pattern: "complete_layer_failure", Description: "Complete failure in Application layer - all tests failed"},
+		},
+		Recommendations: []string{"Reboot the modem"},
+	}, now)
+
+	if summary.Version != DiagnosticsSummaryVersion {
+		t.Errorf("Expected summary version %d, got %d", DiagnosticsSummaryVersion, summary.Version)
+	}
+	want := []LayerSummary{{"Physical", 1}, {"Network", 0.5}, {"Application", 0}}
+	if len(summary.Layers) != len(want) {
+		t.Fatalf("Expected layers %v, got %v", want, summary.Layers)
+	}
+	for i := range want {
+		if summary.Layers[i] != want[i] {
+			t.Errorf("Expected layers %v lowest first, got %v", want, summary.Layers)
+			break
+		}
+	}
+	if len(summary.Patterns) != 1 || summary.Patterns[0] != "Complete failure in Application layer - all tests failed" {
+		t.Errorf("Expected the failure pattern description, got %v", summary.Patterns)
+	}
+
+	encoded, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("Failed to encode summary: %v", err)
+	}
+	stateFile := filepath.Join(t.TempDir(), "watchdog.state")
+	if err := os.WriteFile(stateFile, []byte("diagnostics="+string(encoded)+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	service := NewService(&config.Config{WorkingDirectory: t.TempDir(), ModemHost: config.DefaultModemHost, CheckInterval: 30 * time.Second}, logger)
+	if err := service.LoadPersistedState(stateFile); err != nil {
+		t.Fatalf("LoadPersistedState() failed: %v", err)
+	}
+	got := service.GetCurrentState().Diagnostics
+	if got == nil || !got.Time.Equal(now) || !got.ShouldReboot || len(got.Recommendations) != 1 {
+		t.Errorf("Expected the persisted diagnostics summary, got %+v", got)
+	}
+	if service.Snapshot().Diagnostics == nil {
+		t.Error("Expected the diagnostics summary in the published state")
+	}
+}
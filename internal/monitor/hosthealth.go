@@ -0,0 +1,44 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/hosthealth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+)
+
+// HostHealthChecker checks the health of the monitoring host;
+// *hosthealth.Checker satisfies it
+type HostHealthChecker interface {
+	Check(ctx context.Context, now time.Time) hosthealth.Report
+}
+
+// checkHostHealth checks the monitoring host once the failure threshold is
+// reached and returns the report, or nil when the check is disabled or the
+// threshold not reached. The host turning degraded is logged and sent as a
+// host_degraded notification once, not on every failed check.
+func (s *Service) checkHostHealth(ctx context.Context) *hosthealth.Report {
+	if !s.config.HostHealthCheck || s.failureCount < s.config.FailureThreshold {
+		return nil
+	}
+	checker := s.opts.HostHealth
+	if checker == nil {
+		checker = hosthealth.New(s.config.HostHealth())
+	}
+	report := checker.Check(ctx, s.clock.Now())
+
+	switch degraded := report.Degraded(); {
+	case degraded && !s.hostDegraded:
+		s.hostDegraded = true
+		s.logger.WithField("problems", report.String()).Warn("Monitoring host degraded, failed checks do not point at the modem")
+		s.notifier.Send(ctx, notify.KindHostDegraded, notify.Data{
+			Time:   s.clock.Now(),
+			Fields: map[string]interface{}{"failure_count": s.failureCount, "problems": report.Problems()},
+		})
+	case !degraded && s.hostDegraded:
+		s.hostDegraded = false
+		s.logger.Info("Monitoring host healthy again")
+	}
+	return &report
+}
@@ -0,0 +1,98 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+)
+
+// healthStates are the values of the state label of watchdog_health
+var healthStates = []string{health.Healthy, health.Degraded, health.Unhealthy}
+
+// WriteStateMetrics writes the gauges of state in the Prometheus text
+// format: whether the service is up, its health and the last check and
+// reboot. Timestamps of events that never happened are 0.
+func WriteStateMetrics(w io.Writer, state ServiceState) error {
+	m := &metricWriter{w: w}
+	m.gauge("watchdog_up", "Whether the watchdog service is running.", boolValue(state.IsRunning))
+	m.gauge("watchdog_start_time_seconds", "Start time of the watchdog service in seconds since the epoch.", timestamp(state.StartTime))
+	m.gauge("watchdog_health_score", "Health score of the last check, from 0 to 100.", state.HealthScore)
+	m.header("watchdog_health", "Health state of the last check.", "gauge")
+	for _, s := range healthStates {
+		m.printf("watchdog_health{state=%q} %s\n", s, formatValue(boolValue(state.Health == s)))
+	}
+	m.gauge("watchdog_consecutive_failures", "Consecutive failed connectivity checks.", float64(state.FailureCount))
+	m.counter("watchdog_checks_total", "Connectivity checks run.", float64(state.TotalChecks))
+	m.gauge("watchdog_last_check_timestamp_seconds", "Time of the last connectivity check in seconds since the epoch.", timestamp(state.LastCheck))
+	if state.Check != nil {
+		m.gauge("watchdog_last_check_success", "Whether the last connectivity check passed.", boolValue(state.Check.OverallSuccess))
+		m.gauge("watchdog_last_check_duration_seconds", "Duration of the last connectivity check.", time.Duration(state.Check.TotalDurationMS*int64(time.Millisecond)).Seconds())
+	}
+	m.gauge("watchdog_last_reboot_timestamp_seconds", "Time of the last modem reboot in seconds since the epoch.", timestamp(state.LastReboot))
+	m.gauge("watchdog_rebooting", "Whether the modem is being rebooted.", boolValue(state.Reboot != nil))
+	m.gauge("watchdog_paused", "Whether automatic reboots are paused.", boolValue(state.Pause != nil))
+	m.gauge("watchdog_low_power", "Whether the watchdog runs in low-power mode.", boolValue(state.LowPower))
+	return m.err
+}
+
+// WriteRebootCounters writes the reboots of state by reason, with every
+// reason present so rates can be taken before its first reboot
+func WriteRebootCounters(w io.Writer, state ServiceState) error {
+	m := &metricWriter{w: w}
+	m.header("watchdog_reboots_total", "Modem reboots by reason.", "counter")
+	for _, reason := range RebootReasons {
+		m.printf("watchdog_reboots_total{reason=%q,automated=\"%t\"} %d\n",
+			reason, RebootAutomated(reason), state.RebootsByReason[reason])
+	}
+	return m.err
+}
+
+// metricWriter writes metrics until the first error, which it keeps
+type metricWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (m *metricWriter) printf(format string, args ...interface{}) {
+	if m.err == nil {
+		_, m.err = fmt.Fprintf(m.w, format, args...)
+	}
+}
+
+func (m *metricWriter) header(name, help, kind string) {
+	m.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (m *metricWriter) gauge(name, help string, value float64) {
+	m.header(name, help, "gauge")
+	m.printf("%s %s\n", name, formatValue(value))
+}
+
+func (m *metricWriter) counter(name, help string, value float64) {
+	m.header(name, help, "counter")
+	m.printf("%s %s\n", name, formatValue(value))
+}
+
+// formatValue writes timestamps in full rather than in exponent notation
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// boolValue is 1 for true and 0 for false
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// timestamp is t in seconds since the epoch, 0 for the zero time
+func timestamp(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano()) / float64(time.Second)
+}
@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"context"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+)
+
+// ModemStatsJobName is the scheduler job name of the modem channel polling
+const ModemStatsJobName = "modem_stats"
+
+// scheduleModemStats registers the polling of the modem's channels, if an
+// interval is configured
+func (s *Service) scheduleModemStats() error {
+	if s.config.ModemStatsInterval <= 0 {
+		return nil
+	}
+	if err := s.scheduler.Add(ModemStatsJobName, scheduler.Every(s.config.ModemStatsInterval), s.pollModemStats); err != nil {
+		return err
+	}
+	s.logger.WithField("interval", s.config.ModemStatsInterval).Info("Polling modem channel statistics")
+	return nil
+}
+
+// pollModemStats reads the modem status for the channel metrics and
+// reboots the modem if partial service lasted too long. It leaves the modem
+// alone while a check cycle or reboot runs; the next poll catches up.
+func (s *Service) pollModemStats(ctx context.Context) error {
+	if !s.cycleMu.TryLock() {
+		s.logger.Debug("Check cycle running, skipping modem statistics")
+		return nil
+	}
+	defer s.cycleMu.Unlock()
+	defer s.publishState()
+	s.refreshModemStatus(ctx)
+	return s.rebootPartialService(ctx)
+}
+
+// ModemStatus returns the modem status, with its channels, as of the last
+// time it was read; nil if it never was
+func (s *Service) ModemStatus() *modem.Status {
+	s.snapshotMu.RLock()
+	defer s.snapshotMu.RUnlock()
+	return s.snapshotModem
+}
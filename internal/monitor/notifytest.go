@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/perezjoseph/mb8600-watchdog/internal/outage"
+)
+
+// Test notification events
+const (
+	TestEventOutage   = "outage"
+	TestEventReboot   = "reboot"
+	TestEventRecovery = "recovery"
+)
+
+// TestEvents are the events a test notification can be sent for, in the
+// order they happen
+var TestEvents = []string{TestEventOutage, TestEventReboot, TestEventRecovery}
+
+// testOutageDuration is the length of the synthetic outage
+const testOutageDuration = 5 * time.Minute
+
+// TestNotification returns the kind and the synthetic data of the
+// notification sent for event, as if it happened at now
+func TestNotification(event string, now time.Time) (notify.Kind, notify.Data, error) {
+	started := now.Add(-testOutageDuration)
+	switch event {
+	case TestEventOutage:
+		return notify.KindOutageStarted, notify.Data{
+			Time:   now,
+			Event:  &outage.OutageEvent{ID: "test", StartTime: now, Cause: "connectivity_failure"},
+			Fields: map[string]interface{}{"test_strategy": "notification test"},
+			Test:   true,
+		}, nil
+	case TestEventReboot:
+		return notify.KindRebootTriggered, notify.Data{
+			Time:   now,
+			Fields: map[string]interface{}{"reason": "notification test", "trigger": TriggerManual},
+			Test:   true,
+		}, nil
+	case TestEventRecovery:
+		return notify.KindOutageResolved, notify.Data{
+			Time: now,
+			Event: &outage.OutageEvent{
+				ID:        "test",
+				StartTime: started,
+				EndTime:   &now,
+				Duration:  testOutageDuration,
+				Resolved:  true,
+				Cause:     "connectivity_failure",
+			},
+			Test: true,
+		}, nil
+	}
+	return "", notify.Data{}, fmt.Errorf("unknown test event %q, valid: %s", event, strings.Join(TestEvents, ", "))
+}
+
+// SendTestNotification sends the synthetic notification of event through
+// notifier, rendered and delivered like a real one
+func SendTestNotification(ctx context.Context, notifier *notify.Dispatcher, event string, now time.Time) (notify.Kind, error) {
+	kind, data, err := TestNotification(event, now)
+	if err != nil {
+		return "", err
+	}
+	return kind, notifier.Send(ctx, kind, data)
+}
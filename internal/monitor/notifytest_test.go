@@ -0,0 +1,45 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSendTestNotification(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	cfg := &config.Config{WorkingDirectory: t.TempDir()}
+	recorder := &recordingNotifier{}
+	notifier := NewNotifier(cfg, logger, Options{Notifiers: []notify.Notifier{recorder}})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	want := []notify.Kind{notify.KindOutageStarted, notify.KindRebootTriggered, notify.KindOutageResolved}
+	for i, event := range TestEvents {
+		kind, err := SendTestNotification(context.Background(), notifier, event, now)
+		if err != nil || kind != want[i] {
+			t.Fatalf("SendTestNotification(%s) = %s, %v; want %s", event, kind, err, want[i])
+		}
+	}
+
+	if len(recorder.notifications) != len(want) {
+		t.Fatalf("Expected %d notifications, got %d", len(want), len(recorder.notifications))
+	}
+	for _, notification := range recorder.notifications {
+		if !strings.HasPrefix(notification.Title, notify.TestPrefix) {
+			t.Errorf("Expected a test title, got %q", notification.Title)
+		}
+	}
+	if body := recorder.notifications[2].Body; !strings.Contains(body, "5.0m") {
+		t.Errorf("Expected the outage duration in the recovery message, got %q", body)
+	}
+
+	if _, err := SendTestNotification(context.Background(), notifier, "flood", now); err == nil {
+		t.Error("Expected an error for an unknown event")
+	}
+}
@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// PartialService is a modem in partial service: bonded downstream channels
+// lost their lock, so the internet still works but slowly, and the
+// connectivity checks pass
+type PartialService struct {
+	Since time.Time `json:"since"`
+	// Unlocked and Channels count the unlocked and all downstream channels
+	Unlocked   int   `json:"unlocked"`
+	Channels   int   `json:"channels"`
+	ChannelIDs []int `json:"channel_ids"`
+}
+
+// checkPartialService looks for partial service in the modem status just
+// read. The modem entering partial service is logged and sent as a
+// partial_service notification once, not on every read.
+func (s *Service) checkPartialService(ctx context.Context, status *modem.Status) {
+	if s.config.PartialServiceChannels <= 0 || len(status.Downstream) == 0 {
+		return
+	}
+	unlocked := status.UnlockedDownstream()
+	if len(unlocked) < s.config.PartialServiceChannels {
+		if s.partialService != nil {
+			s.logger.WithField("duration", s.clock.Now().Sub(s.partialService.Since).Round(time.Second)).Info("Modem downstream channels locked again, partial service ended")
+			s.partialService = nil
+		}
+		return
+	}
+
+	ids := make([]int, len(unlocked))
+	for i, channel := range unlocked {
+		ids[i] = channel.ChannelID
+	}
+	if s.partialService != nil {
+		s.partialService.Unlocked = len(unlocked)
+		s.partialService.Channels = len(status.Downstream)
+		s.partialService.ChannelIDs = ids
+		return
+	}
+
+	s.partialService = &PartialService{
+		Since:      s.clock.Now(),
+		Unlocked:   len(unlocked),
+		Channels:   len(status.Downstream),
+		ChannelIDs: ids,
+	}
+	s.logger.WithFields(logrus.Fields{
+		"unlocked":     len(unlocked),
+		"channels":     len(status.Downstream),
+		"channel_ids":  ids,
+		"reboot_after": s.config.PartialServiceRebootAfter,
+	}).Warn("Modem in partial service, downstream channels unlocked")
+	s.notifier.Send(ctx, notify.KindPartialService, notify.Data{
+		Time: s.clock.Now(),
+		Fields: map[string]interface{}{
+			"unlocked":     len(unlocked),
+			"channels":     len(status.Downstream),
+			"channel_ids":  ids,
+			"reboot_after": s.config.PartialServiceRebootAfter,
+		},
+	})
+}
+
+// partialHealth returns status, degraded while the modem is in partial
+// service: the checks pass, but the connection is not healthy
+func (s *Service) partialHealth(status string) string {
+	if s.partialService != nil && status == health.Healthy {
+		return health.Degraded
+	}
+	return status
+}
+
+// rebootPartialService reboots the modem once partial service has lasted
+// PartialServiceRebootAfter, unless monitoring is paused or the other node
+// of the cluster is active; the caller holds cycleMu
+func (s *Service) rebootPartialService(ctx context.Context) error {
+	after := s.config.PartialServiceRebootAfter
+	if after <= 0 || s.partialService == nil {
+		return nil
+	}
+	lasted := s.clock.Now().Sub(s.partialService.Since)
+	if lasted < after {
+		return nil
+	}
+
+	reason := fmt.Sprintf("partial service for %s, %d of %d downstream channels unlocked",
+		lasted.Round(time.Second), s.partialService.Unlocked, s.partialService.Channels)
+	if pause := s.ActivePause(ctx); pause != nil {
+		s.logger.WithField("reason", reason).Debug("Monitoring paused, not rebooting for partial service")
+		return nil
+	}
+	if ok, skip := s.clusterMayReboot(); !ok {
+		s.logger.WithField("reason", skip).Debug("Not rebooting for partial service")
+		return nil
+	}
+
+	s.logger.WithField("reason", reason).Warn("Rebooting modem for persistent partial service")
+	s.partialService = nil
+	return s.runRebootWorkflow(TriggerAutomatic, reason, true, func(ctx context.Context) error {
+		return s.reboot(ctx, TriggerAutomatic, RebootPartialService, reason)
+	})
+}
@@ -0,0 +1,205 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxPause is the longest monitoring can be paused for, so a forgotten pause
+// cannot disable the watchdog for good
+const MaxPause = 7 * 24 * time.Hour
+
+// Pause is a manual pause of automatic reboots, such as while an ISP
+// technician works on the line. Checks keep running unless Checks is set.
+type Pause struct {
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until"`
+	Checks bool      `json:"checks"`
+	Reason string    `json:"reason,omitempty"`
+	By     string    `json:"by,omitempty"`
+}
+
+// PauseMonitoring pauses automatic reboots for duration, and connectivity
+// checks as well if pause.Checks is set. The pause is persisted, so it
+// survives restarts, and ends on its own at pause.Until. A pause that cannot
+// be persisted does not take effect.
+func (s *Service) PauseMonitoring(ctx context.Context, duration time.Duration, pause Pause) (Pause, error) {
+	if duration <= 0 || duration > MaxPause {
+		return Pause{}, fmt.Errorf("pause duration must be between 1s and %v, got %v", MaxPause, duration)
+	}
+	now := s.clock.Now()
+	pause.Since = now
+	pause.Until = now.Add(duration)
+
+	s.pauseMu.Lock()
+	err := s.savePause(&pause)
+	if err == nil {
+		s.pause = &pause
+	}
+	s.pauseMu.Unlock()
+	if err != nil {
+		return Pause{}, err
+	}
+	s.publishState()
+
+	s.logger.WithFields(logrus.Fields{
+		"until":  pause.Until,
+		"checks": pause.Checks,
+		"reason": pause.Reason,
+		"by":     pause.By,
+	}).Warn("Monitoring paused")
+	s.notifier.Send(ctx, notify.KindMonitoringPaused, notify.Data{
+		Time: now,
+		Fields: map[string]interface{}{
+			"until":  pause.Until,
+			"checks": pause.Checks,
+			"reason": pause.Reason,
+			"by":     pause.By,
+		},
+	})
+	return pause, nil
+}
+
+// ResumeMonitoring ends a pause early; it reports false when monitoring was
+// not paused. The pause stays in effect when its file cannot be removed, so
+// it would not come back after a restart.
+func (s *Service) ResumeMonitoring(ctx context.Context, by string) (bool, error) {
+	s.pauseMu.Lock()
+	if s.pause == nil {
+		s.pauseMu.Unlock()
+		return false, nil
+	}
+	err := s.savePause(nil)
+	if err == nil {
+		s.pause = nil
+	}
+	s.pauseMu.Unlock()
+	if err != nil {
+		return true, err
+	}
+	s.publishState()
+
+	s.logger.WithField("by", by).Info("Monitoring resumed")
+	s.notifier.Send(ctx, notify.KindMonitoringResumed, notify.Data{
+		Time:   s.clock.Now(),
+		Fields: map[string]interface{}{"by": by},
+	})
+	return true, nil
+}
+
+// ActivePause returns the current pause, or nil when monitoring is not
+// paused. A pause that has run out is ended.
+func (s *Service) ActivePause(ctx context.Context) *Pause {
+	s.pauseMu.Lock()
+	pause := s.pause
+	s.pauseMu.Unlock()
+	if pause == nil {
+		return nil
+	}
+	if s.clock.Now().Before(pause.Until) {
+		copied := *pause
+		return &copied
+	}
+
+	s.pauseMu.Lock()
+	expired := s.pause == pause
+	if expired {
+		s.pause = nil
+		if err := s.savePause(nil); err != nil {
+			s.logger.WithError(err).Warn("Failed to clear expired pause")
+		}
+	}
+	s.pauseMu.Unlock()
+	if expired {
+		s.logger.WithField("until", pause.Until).Info("Pause ended, monitoring resumed")
+		s.notifier.Send(ctx, notify.KindMonitoringResumed, notify.Data{Time: s.clock.Now()})
+	}
+	return nil
+}
+
+// PausePath returns the file the pause of the service configured by cfg is
+// persisted in
+func PausePath(cfg *config.Config) string {
+	return cfg.StatePath("state", "pause.json")
+}
+
+// pausePath returns the file the pause is persisted in
+func (s *Service) pausePath() string {
+	return PausePath(s.config)
+}
+
+// savePause writes pause to its file, or removes the file when pause is nil;
+// the caller holds pauseMu. Without a state directory the pause only lasts
+// until a restart.
+func (s *Service) savePause(pause *Pause) error {
+	path := s.pausePath()
+	if path == "" {
+		return nil
+	}
+	if pause == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove pause: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(pause, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pause: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write pause: %w", err)
+	}
+	if err := os.Rename(temp, path); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("failed to write pause: %w", err)
+	}
+	return nil
+}
+
+// loadPause restores a pause persisted by a previous run
+func (s *Service) loadPause() {
+	pause, err := ReadPause(s.pausePath())
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to read persisted pause, monitoring is not paused")
+		return
+	}
+	if pause == nil {
+		return
+	}
+	s.pauseMu.Lock()
+	s.pause = pause
+	s.pauseMu.Unlock()
+	s.logger.WithFields(logrus.Fields{
+		"until":  pause.Until,
+		"checks": pause.Checks,
+	}).Info("Restored monitoring pause")
+}
+
+// ReadPause reads a persisted pause, returning nil when there is none
+func ReadPause(path string) (*Pause, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pause: %w", err)
+	}
+	var pause Pause
+	if err := json.Unmarshal(data, &pause); err != nil {
+		return nil, fmt.Errorf("failed to parse pause: %w", err)
+	}
+	return &pause, nil
+}
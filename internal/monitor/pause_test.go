@@ -0,0 +1,180 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+func newPauseTestService(t *testing.T, fake *clock.Fake, dir string, driver *stubModemDriver, recorder *recordingNotifier) *Service {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 2,
+		WorkingDirectory: dir,
+	}
+	return NewServiceWithOptions(cfg, logger, Options{
+		Clock:       fake,
+		Checker:     failingChecker{},
+		ModemDriver: driver,
+		Notifiers:   []notify.Notifier{recorder},
+	})
+}
+
+func TestPauseSkipsRebootsUntilItEnds(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	driver := &stubModemDriver{}
+	recorder := &recordingNotifier{}
+	service := newPauseTestService(t, fake, dir, driver, recorder)
+	ctx := context.Background()
+
+	pause, err := service.PauseMonitoring(ctx, time.Hour, Pause{Reason: "technician", By: "alice"})
+	if err != nil {
+		t.Fatalf("PauseMonitoring() failed: %v", err)
+	}
+	if want := fake.Now().Add(time.Hour); !pause.Until.Equal(want) {
+		t.Errorf("Expected the pause to end at %v, got %v", want, pause.Until)
+	}
+	if state := service.Snapshot(); state.Pause == nil || state.Pause.Reason != "technician" {
+		t.Errorf("Expected the pause in the state, got %+v", state.Pause)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := service.RunCheck(ctx); err != nil {
+			t.Fatalf("RunCheck() failed: %v", err)
+		}
+	}
+	if driver.reboots != 0 {
+		t.Errorf("Expected no reboots while paused, got %d", driver.reboots)
+	}
+	if service.Snapshot().TotalChecks != 3 {
+		t.Errorf("Expected checks to keep running, got %d", service.Snapshot().TotalChecks)
+	}
+
+	// The pause survives a restart
+	restarted := newPauseTestService(t, fake, dir, driver, &recordingNotifier{})
+	if restored := restarted.ActivePause(ctx); restored == nil || restored.By != "alice" {
+		t.Fatalf("Expected the pause to be restored, got %+v", restored)
+	}
+
+	fake.Advance(time.Hour)
+	if err := service.RunCheck(ctx); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if driver.reboots != 1 {
+		t.Errorf("Expected a reboot once the pause ended, got %d", driver.reboots)
+	}
+	if pause, err := ReadPause(PausePath(service.config)); err != nil || pause != nil {
+		t.Errorf("Expected the ended pause to be removed, got %+v (%v)", pause, err)
+	}
+
+	var kinds []notify.Kind
+	for _, notification := range recorder.notifications {
+		kinds = append(kinds, notification.Kind)
+	}
+	if len(kinds) < 2 || kinds[0] != notify.KindMonitoringPaused {
+		t.Errorf("Expected a pause notification first, got %v", kinds)
+	}
+}
+
+func TestPauseChecksAndResume(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	driver := &stubModemDriver{}
+	service := newPauseTestService(t, fake, t.TempDir(), driver, &recordingNotifier{})
+	ctx := context.Background()
+
+	if _, err := service.PauseMonitoring(ctx, 0, Pause{}); err == nil {
+		t.Error("Expected an error for a zero duration")
+	}
+	if _, err := service.PauseMonitoring(ctx, MaxPause+time.Hour, Pause{}); err == nil {
+		t.Error("Expected an error for a pause longer than MaxPause")
+	}
+
+	if _, err := service.PauseMonitoring(ctx, time.Hour, Pause{Checks: true}); err != nil {
+		t.Fatalf("PauseMonitoring() failed: %v", err)
+	}
+	if err := service.RunCheck(ctx); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if service.Snapshot().TotalChecks != 0 {
+		t.Errorf("Expected no checks while checks are paused, got %d", service.Snapshot().TotalChecks)
+	}
+
+	resumed, err := service.ResumeMonitoring(ctx, "bob")
+	if err != nil || !resumed {
+		t.Fatalf("ResumeMonitoring() = %v, %v", resumed, err)
+	}
+	if service.Snapshot().Pause != nil {
+		t.Error("Expected no pause in the state after resuming")
+	}
+	if resumed, _ := service.ResumeMonitoring(ctx, "bob"); resumed {
+		t.Error("Expected resuming twice to report no pause")
+	}
+	if err := service.RunCheck(ctx); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if service.Snapshot().TotalChecks != 1 {
+		t.Errorf("Expected checks to run after resuming, got %d", service.Snapshot().TotalChecks)
+	}
+}
+
+func TestPauseNotAppliedWhenItCannotBePersisted(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	recorder := &recordingNotifier{}
+	service := newPauseTestService(t, fake, dir, &stubModemDriver{}, recorder)
+	ctx := context.Background()
+
+	// A file where the state directory of the pause belongs fails the save
+	blocker := filepath.Dir(service.pausePath())
+	if err := os.MkdirAll(filepath.Dir(blocker), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.PauseMonitoring(ctx, time.Hour, Pause{Reason: "technician"}); err == nil {
+		t.Fatal("Expected PauseMonitoring() to fail")
+	}
+	if pause := service.ActivePause(ctx); pause != nil {
+		t.Errorf("Expected no pause after a failed save, got %+v", pause)
+	}
+	if len(recorder.notifications) != 0 {
+		t.Errorf("Expected no notifications, got %+v", recorder.notifications)
+	}
+
+	// A pause whose file cannot be removed stays in effect
+	if err := os.Remove(blocker); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.PauseMonitoring(ctx, time.Hour, Pause{}); err != nil {
+		t.Fatalf("PauseMonitoring() failed: %v", err)
+	}
+	if err := os.Remove(service.pausePath()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(service.pausePath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(service.pausePath(), "keep"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ResumeMonitoring(ctx, "test"); err == nil {
+		t.Fatal("Expected ResumeMonitoring() to fail")
+	}
+	if service.ActivePause(ctx) == nil {
+		t.Error("Expected the pause to stay in effect after a failed resume")
+	}
+}
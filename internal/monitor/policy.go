@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/verdict"
+	"github.com/sirupsen/logrus"
+)
+
+// PolicyTimeout is how long a decision policy may take to decide
+const PolicyTimeout = 10 * time.Second
+
+// policyHistory is how far back PolicyInput counts decisions
+const policyHistory = 24 * time.Hour
+
+// DecisionPolicy decides in place of the built-in policy whether to reboot
+// once the failure threshold is reached
+type DecisionPolicy interface {
+	// Name names the policy in logs and recorded decisions
+	Name() string
+	Decide(ctx context.Context, input PolicyInput) (PolicyDecision, error)
+}
+
+// PolicyInput is what a decision policy decides on
+type PolicyInput struct {
+	// Decision is the decision of the built-in policy with its inputs: the
+	// health score, the diagnostics summary and the failure verdict
+	Decision Decision `json:"decision"`
+	// Action is the action configured for the verdict
+	Action string `json:"action"`
+	// RecentReboots and RecentSkips count the automatic reboots and the
+	// skipped reboots of the last 24 hours
+	RecentReboots int `json:"recent_reboots"`
+	RecentSkips   int `json:"recent_skips"`
+}
+
+// PolicyDecision is the answer of a decision policy
+type PolicyDecision struct {
+	// Action is reboot, wait or notify; empty keeps the built-in decision
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// consultPolicy asks the decision policy about the built-in decision to
+// reboot or not for reason, returning its answer and true when it overrides
+// the built-in decision. A policy that fails or answers an unknown action is
+// ignored.
+func (s *Service) consultPolicy(ctx context.Context, shouldReboot bool, reason string, located verdict.Result, action string) (PolicyDecision, bool) {
+	policy := s.opts.Policy
+	if policy == nil {
+		return PolicyDecision{}, false
+	}
+
+	outcome := DecisionSkip
+	if shouldReboot {
+		outcome = DecisionReboot
+	}
+	input := PolicyInput{Decision: s.newDecision(outcome, TriggerAutomatic, reason), Action: action}
+	input.Decision.Verdict = located.Verdict
+	input.RecentReboots, input.RecentSkips = s.recentDecisions()
+
+	policyCtx, cancel := context.WithTimeout(ctx, PolicyTimeout)
+	defer cancel()
+	answer, err := policy.Decide(policyCtx, input)
+	if err == nil && answer.Action != "" && !verdict.ValidAction(answer.Action) {
+		err = fmt.Errorf("unknown action %q", answer.Action)
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("policy", policy.Name()).Warn("Decision policy failed, keeping the built-in decision")
+		return PolicyDecision{}, false
+	}
+	if answer.Action == "" {
+		return PolicyDecision{}, false
+	}
+
+	if answer.Reason == "" {
+		answer.Reason = answer.Action
+	}
+	answer.Reason = policy.Name() + " policy: " + answer.Reason
+	s.logger.WithFields(logrus.Fields{
+		"policy":           policy.Name(),
+		"action":           answer.Action,
+		"built_in_outcome": outcome,
+	}).Info("Decision policy decided: " + answer.Reason)
+	return answer, true
+}
+
+// recentDecisions counts the automatic reboots and skipped reboots of the
+// last 24 hours in the history
+func (s *Service) recentDecisions() (reboots, skips int) {
+	if !s.historyEnabled() {
+		return 0, 0
+	}
+	events, err := history.Read(s.config.HistoryPath(), s.clock.Now().Add(-policyHistory), history.KindRebootDecision)
+	if err != nil {
+		s.logger.WithError(err).Debug("Failed to read reboot decisions for the decision policy")
+		return 0, 0
+	}
+	for _, event := range events {
+		if event.Details["trigger"] != TriggerAutomatic {
+			continue
+		}
+		switch event.Details["outcome"] {
+		case DecisionReboot:
+			reboots++
+		case DecisionSkip:
+			skips++
+		}
+	}
+	return reboots, skips
+}
@@ -0,0 +1,94 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/power"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+// lowPowerHistoryFlush is how often the history held in memory is written
+// in low-power mode
+const lowPowerHistoryFlush = time.Hour
+
+// updatePowerMode enters or leaves low-power mode by LowPowerMode and the
+// power source file. A power source that cannot be read keeps the current
+// mode; the error is logged once rather than on every check, since each log
+// line is a disk write too.
+func (s *Service) updatePowerMode() {
+	mode, err := power.ParseMode(s.config.LowPowerMode)
+	if err == nil {
+		var low bool
+		if low, err = power.LowPower(mode, s.config.PowerSourceFile); err == nil {
+			s.powerError = ""
+			s.setLowPower(low)
+			return
+		}
+	}
+	if err.Error() != s.powerError {
+		s.logger.WithError(err).WithField("low_power", s.lowPower).Warn("Failed to read power source, keeping the current power mode")
+		s.powerError = err.Error()
+	}
+}
+
+// setLowPower enters or leaves low-power mode. In low-power mode checks run
+// every LowPowerCheckInterval, diagnostics and outage reports are skipped,
+// and the history and performance metrics stay in memory between hourly
+// flushes.
+func (s *Service) setLowPower(low bool) {
+	if low == s.lowPower {
+		return
+	}
+	s.lowPower = low
+	fields := logrus.Fields{"check_interval": s.checkInterval()}
+	if low {
+		history.Hold()
+		s.historyFlushed = s.clock.Now()
+		s.logger.WithFields(fields).Info("Entering low-power mode")
+	} else {
+		if err := history.Release(); err != nil {
+			s.logger.WithError(err).Warn("Failed to write held history")
+		}
+		s.logger.WithFields(fields).Info("Leaving low-power mode")
+	}
+	if s.perfMonitor != nil {
+		s.perfMonitor.HoldSaves(low)
+	}
+	if err := s.scheduler.Reschedule(CheckJobName, scheduler.Every(s.checkInterval())); err != nil {
+		s.logger.WithError(err).Debug("Check not scheduled, keeping its interval")
+	}
+}
+
+// checkInterval returns the interval between checks in the current power
+// mode; low-power mode never checks more often than CheckInterval
+func (s *Service) checkInterval() time.Duration {
+	if s.lowPower && s.config.LowPowerCheckInterval > s.config.CheckInterval {
+		return s.config.LowPowerCheckInterval
+	}
+	return s.config.CheckInterval
+}
+
+// flushHeldHistory writes the history held in low-power mode once an hour
+func (s *Service) flushHeldHistory() {
+	if !s.lowPower || s.clock.Now().Sub(s.historyFlushed) < lowPowerHistoryFlush {
+		return
+	}
+	if err := history.Flush(); err != nil {
+		s.logger.WithError(err).Warn("Failed to write held history")
+	}
+	s.historyFlushed = s.clock.Now()
+}
+
+// runOutageReport writes the periodic outage report, except in low-power
+// mode. It runs on its own goroutine, so it reads the mode from the
+// published state.
+func (s *Service) runOutageReport(ctx context.Context) error {
+	if s.Snapshot().LowPower {
+		s.logger.Debug("Low-power mode, skipping outage report")
+		return nil
+	}
+	return s.outageReporter.RunReport(ctx)
+}
@@ -0,0 +1,33 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+// progress records that the check loop is advancing, for liveness checks
+// such as the systemd watchdog that must not be satisfied by a process
+// whose checks hang
+type progress struct {
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+// markProgress records that a check cycle returned. The loop is stalled
+// once no cycle returns within two check intervals, which covers the
+// degraded interval, plus a cycle and a reboot with its recovery wait.
+func (s *Service) markProgress() {
+	limit := 2*s.checkInterval() + s.config.CheckInterval + s.config.RebootDeadline()
+	s.progress.mu.Lock()
+	s.progress.deadline = s.clock.Now().Add(limit)
+	s.progress.mu.Unlock()
+}
+
+// Progressing reports whether the check loop is advancing: a check cycle
+// returned recently, or the service has not started yet. It is false once
+// the scheduler or a check cycle hangs.
+func (s *Service) Progressing() bool {
+	s.progress.mu.Lock()
+	defer s.progress.mu.Unlock()
+	return s.progress.deadline.IsZero() || s.clock.Now().Before(s.progress.deadline)
+}
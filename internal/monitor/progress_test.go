@@ -0,0 +1,36 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+func TestProgressingUntilChecksStopReturning(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	service := newPauseTestService(t, fake, t.TempDir(), &stubModemDriver{}, &recordingNotifier{})
+
+	if !service.Progressing() {
+		t.Fatal("Expected a service that has not started to be progressing")
+	}
+
+	service.markProgress()
+	limit := 3*service.config.CheckInterval + service.config.RebootDeadline()
+	fake.Advance(limit - time.Second)
+	if !service.Progressing() {
+		t.Error("Expected the check loop to be progressing within the limit")
+	}
+	fake.Advance(2 * time.Second)
+	if service.Progressing() {
+		t.Error("Expected the check loop to be stalled once no check returned within the limit")
+	}
+
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Logf("RunCheck() returned %v", err)
+	}
+	if !service.Progressing() {
+		t.Error("Expected a returned check to count as progress")
+	}
+}
@@ -0,0 +1,31 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/quorum"
+	"github.com/sirupsen/logrus"
+)
+
+// confirmQuorum asks the configured agents whether they see the internet
+// down too, and returns their answer, or nil when no agents are configured.
+// A quorum that cannot be asked is logged and does not hold the reboot back.
+func (s *Service) confirmQuorum(ctx context.Context) *quorum.Result {
+	cfg := s.config.Quorum()
+	if !cfg.Enabled() {
+		return nil
+	}
+	// The agents are on the local network, never behind the outbound proxy
+	q, err := quorum.New(cfg, &http.Client{})
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to ask the reboot quorum")
+		return nil
+	}
+	result := q.Confirm(ctx, s.clock.Now())
+	s.logger.WithFields(logrus.Fields{
+		"down":     result.Down,
+		"required": result.Required,
+	}).Info("Asked the other agents before rebooting: " + result.String())
+	return &result
+}
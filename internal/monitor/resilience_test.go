@@ -3,10 +3,14 @@ package monitor
 import (
 	"context"
 	"fmt"
+	"net"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
 	"github.com/sirupsen/logrus"
 )
 
@@ -23,6 +27,7 @@ func TestResilienceScenarios(t *testing.T) {
 		{
 			name: "high_failure_threshold_resilience",
 			config: &config.Config{
+				WorkingDirectory:   t.TempDir(),
 				FailureThreshold:   100, // Very high threshold
 				EnableDiagnostics:  false,
 				ModemHost:          config.DefaultModemHost,
@@ -57,6 +62,7 @@ func TestResilienceScenarios(t *testing.T) {
 		{
 			name: "rapid_recovery_resilience",
 			config: &config.Config{
+				WorkingDirectory:   t.TempDir(),
 				FailureThreshold:   3,
 				EnableDiagnostics:  false,
 				ModemHost:          config.DefaultModemHost,
@@ -90,6 +96,7 @@ func TestResilienceScenarios(t *testing.T) {
 		{
 			name: "configuration_update_resilience",
 			config: &config.Config{
+				WorkingDirectory:   t.TempDir(),
 				FailureThreshold:   3,
 				EnableDiagnostics:  false,
 				ModemHost:          config.DefaultModemHost,
@@ -107,6 +114,7 @@ func TestResilienceScenarios(t *testing.T) {
 			testFunc: func(t *testing.T, service *Service) {
 				// Test configuration updates
 				newConfig := &config.Config{
+					WorkingDirectory:   t.TempDir(),
 					FailureThreshold:   5,               // Changed threshold
 					EnableDiagnostics:  true,            // Changed diagnostics
 					ModemHost:          "192.168.100.2", // Changed host
@@ -152,6 +160,7 @@ func TestErrorClassificationAndHandling(t *testing.T) {
 	logger.SetLevel(logrus.ErrorLevel)
 
 	cfg := &config.Config{
+		WorkingDirectory:   t.TempDir(),
 		FailureThreshold:   3,
 		EnableDiagnostics:  false,
 		ModemHost:          config.DefaultModemHost,
@@ -171,10 +180,10 @@ func TestErrorClassificationAndHandling(t *testing.T) {
 
 	// Test network error classification
 	networkErrors := []error{
-		fmt.Errorf("network unreachable"),
-		fmt.Errorf("connection refused"),
-		fmt.Errorf("dial tcp: network is unreachable"),
-		fmt.Errorf("dns lookup failed"),
+		fmt.Errorf("status request failed: %w", werrors.ErrModemUnreachable),
+		&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+		fmt.Errorf("reboot failed: %w", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ENETUNREACH}),
+		&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true},
 	}
 
 	for _, err := range networkErrors {
@@ -191,10 +200,10 @@ func TestErrorClassificationAndHandling(t *testing.T) {
 
 	// Test authentication error classification
 	authErrors := []error{
-		fmt.Errorf("authentication failed"),
-		fmt.Errorf("login denied"),
-		fmt.Errorf("unauthorized access"),
-		fmt.Errorf("forbidden request"),
+		modem.ErrAuthFailed,
+		fmt.Errorf("login denied: %w", werrors.ErrAuthFailed),
+		fmt.Errorf("reboot failed: %w", modem.ErrSessionExpired),
+		werrors.NewAuthError("modem", "login", "forbidden request"),
 	}
 
 	for _, err := range authErrors {
@@ -211,9 +220,9 @@ func TestErrorClassificationAndHandling(t *testing.T) {
 
 	// Test timeout error classification
 	timeoutErrors := []error{
-		fmt.Errorf("context deadline exceeded"),
-		fmt.Errorf("timeout waiting for response"),
-		fmt.Errorf("context canceled"),
+		context.DeadlineExceeded,
+		fmt.Errorf("waiting for response: %w", werrors.ErrDNSTimeout),
+		fmt.Errorf("check interrupted: %w", context.Canceled),
 	}
 
 	for _, err := range timeoutErrors {
@@ -240,6 +249,7 @@ func TestServiceStatePersistenceAndRecovery(t *testing.T) {
 	logger.SetLevel(logrus.ErrorLevel)
 
 	cfg := &config.Config{
+		WorkingDirectory:   t.TempDir(),
 		FailureThreshold:   3,
 		EnableDiagnostics:  false,
 		ModemHost:          config.DefaultModemHost,
@@ -349,6 +359,7 @@ func TestBoundaryConditionsAndEdgeCases(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cfg := &config.Config{
+				WorkingDirectory:   t.TempDir(),
 				FailureThreshold:   tt.threshold,
 				EnableDiagnostics:  false,
 				ModemHost:          config.DefaultModemHost,
@@ -454,6 +465,7 @@ func TestContextCancellationHandling(t *testing.T) {
 	logger.SetLevel(logrus.ErrorLevel)
 
 	cfg := &config.Config{
+		WorkingDirectory:   t.TempDir(),
 		FailureThreshold:   3,
 		EnableDiagnostics:  false,
 		ModemHost:          config.DefaultModemHost,
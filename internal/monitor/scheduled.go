@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+// ScheduledRebootJobName is the scheduler job name of the scheduled
+// preventive reboot
+const ScheduledRebootJobName = "scheduled_reboot"
+
+// scheduleReboot registers the scheduled preventive reboot, if one is
+// configured
+func (s *Service) scheduleReboot() error {
+	if s.config.ScheduledReboot == "" {
+		return nil
+	}
+	schedule, err := scheduler.ParseCron(s.config.ScheduledReboot)
+	if err != nil {
+		return fmt.Errorf("invalid scheduled reboot: %w", err)
+	}
+	if err := s.scheduler.Add(ScheduledRebootJobName, schedule, s.RunScheduledReboot); err != nil {
+		return err
+	}
+
+	next, _ := s.scheduler.Next(ScheduledRebootJobName)
+	s.logger.WithFields(logrus.Fields{
+		"schedule":    s.config.ScheduledReboot,
+		"next":        next,
+		"skip_within": s.config.ScheduledRebootSkipWithin,
+	}).Info("Scheduled preventive modem reboot")
+	return nil
+}
+
+// RunScheduledReboot reboots the modem regardless of its health, unless
+// monitoring is paused or an outage or reboot happened within
+// ScheduledRebootSkipWithin. A skipped reboot is recorded as a decision.
+func (s *Service) RunScheduledReboot(ctx context.Context) error {
+	s.cycleMu.Lock()
+	defer s.cycleMu.Unlock()
+	defer s.publishState()
+
+	if reason := s.scheduledRebootSkipReason(ctx); reason != "" {
+		s.logger.WithField("reason", reason).Info("Skipping scheduled modem reboot")
+		s.recordDecision(s.newDecision(DecisionSkip, TriggerScheduled, reason))
+		return nil
+	}
+
+	s.logger.WithField("schedule", s.config.ScheduledReboot).Warn("Running scheduled preventive modem reboot")
+	reason := "scheduled preventive reboot"
+	return s.runRebootWorkflow(TriggerScheduled, reason, true, func(ctx context.Context) error {
+		return s.reboot(ctx, TriggerScheduled, RebootScheduled, reason)
+	})
+}
+
+// scheduledRebootSkipReason returns why the scheduled reboot should be
+// skipped now, or an empty string to run it. An outage in progress always
+// skips it; ScheduledRebootSkipWithin only widens the skip to recent reboots
+// and outages.
+func (s *Service) scheduledRebootSkipReason(ctx context.Context) string {
+	if pause := s.ActivePause(ctx); pause != nil {
+		return fmt.Sprintf("monitoring paused until %s", pause.Until.Format(time.RFC3339))
+	}
+	if ok, reason := s.clusterMayReboot(); !ok {
+		return reason
+	}
+	if s.outageTracker.GetCurrentOutage() != nil {
+		return "outage in progress"
+	}
+
+	window := s.config.ScheduledRebootSkipWithin
+	if window <= 0 {
+		return ""
+	}
+	now := s.clock.Now()
+	if !s.lastReboot.IsZero() && now.Sub(s.lastReboot) < window {
+		return fmt.Sprintf("modem rebooted %s ago, within %s", now.Sub(s.lastReboot).Round(time.Second), window)
+	}
+	for _, recorded := range s.outageTracker.GetOutageHistory() {
+		if recorded.EndTime != nil && now.Sub(*recorded.EndTime) < window {
+			return fmt.Sprintf("outage ended %s ago, within %s", now.Sub(*recorded.EndTime).Round(time.Second), window)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+func TestScheduledReboot(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg := &config.Config{
+		ModemHost:                 config.DefaultModemHost,
+		CheckInterval:             30 * time.Second,
+		FailureThreshold:          3,
+		WorkingDirectory:          t.TempDir(),
+		ScheduledReboot:           "0 4 * * sun",
+		ScheduledRebootSkipWithin: 24 * time.Hour,
+	}
+	driver := &stubModemDriver{}
+	recorder := &recordingNotifier{}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       fake,
+		Checker:     failingChecker{},
+		ModemDriver: driver,
+		Notifiers:   []notify.Notifier{recorder},
+	})
+	ctx := context.Background()
+
+	if err := service.scheduleReboot(); err != nil {
+		t.Fatalf("scheduleReboot() failed: %v", err)
+	}
+	schedule, _ := scheduler.ParseCron(cfg.ScheduledReboot)
+	next := service.GetCurrentState().NextScheduledReboot
+	if want := schedule.Next(fake.Now()); next == nil || !next.Equal(want) {
+		t.Errorf("Expected the next scheduled reboot at %v, got %v", want, next)
+	}
+
+	if err := service.RunScheduledReboot(ctx); err != nil {
+		t.Fatalf("RunScheduledReboot() failed: %v", err)
+	}
+	if driver.reboots != 1 || service.GetCurrentState().RebootsByReason[RebootScheduled] != 1 {
+		t.Fatalf("Expected a scheduled reboot, got %d reboots", driver.reboots)
+	}
+	last := recorder.notifications[len(recorder.notifications)-1]
+	if last.Kind != notify.KindRebootTriggered {
+		t.Errorf("Expected a reboot notification, got %+v", last)
+	}
+
+	// Skipped within the window after a reboot
+	fake.Advance(time.Hour)
+	if err := service.RunScheduledReboot(ctx); err != nil {
+		t.Fatalf("RunScheduledReboot() failed: %v", err)
+	}
+	if driver.reboots != 1 {
+		t.Errorf("Expected the reboot to be skipped after a recent reboot, got %d reboots", driver.reboots)
+	}
+
+	// Skipped while monitoring is paused
+	fake.Advance(48 * time.Hour)
+	if _, err := service.PauseMonitoring(ctx, time.Hour, Pause{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RunScheduledReboot(ctx); err != nil {
+		t.Fatalf("RunScheduledReboot() failed: %v", err)
+	}
+	if driver.reboots != 1 {
+		t.Errorf("Expected the reboot to be skipped while paused, got %d reboots", driver.reboots)
+	}
+
+	if _, err := service.ResumeMonitoring(ctx, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RunScheduledReboot(ctx); err != nil {
+		t.Fatalf("RunScheduledReboot() failed: %v", err)
+	}
+	if driver.reboots != 2 {
+		t.Errorf("Expected a reboot after resuming, got %d reboots", driver.reboots)
+	}
+
+	events, err := history.Read(cfg.HistoryPath(), time.Time{}, history.KindRebootDecision)
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	var outcomes []string
+	for _, event := range events {
+		if event.Details["trigger"] != TriggerScheduled {
+			t.Errorf("Expected scheduled decisions only, got %+v", event.Details)
+		}
+		outcomes = append(outcomes, event.Details["outcome"].(string))
+	}
+	want := []string{DecisionReboot, DecisionSkip, DecisionSkip, DecisionReboot}
+	if len(outcomes) != len(want) {
+		t.Fatalf("Expected decisions %v, got %v", want, outcomes)
+	}
+	for i := range want {
+		if outcomes[i] != want[i] {
+			t.Errorf("Expected decisions %v, got %v", want, outcomes)
+			break
+		}
+	}
+}
+
+func TestScheduledRebootSkippedDuringOutageWithoutWindow(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 3,
+		WorkingDirectory: t.TempDir(),
+		ScheduledReboot:  "0 4 * * sun",
+	}
+	driver := &stubModemDriver{}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       fake,
+		Checker:     failingChecker{},
+		ModemDriver: driver,
+	})
+	ctx := context.Background()
+
+	if err := service.outageTracker.RecordOutageStart("test", nil); err != nil {
+		t.Fatalf("RecordOutageStart() failed: %v", err)
+	}
+	if reason := service.scheduledRebootSkipReason(ctx); reason != "outage in progress" {
+		t.Errorf("Expected the outage to skip the reboot, got %q", reason)
+	}
+	if err := service.RunScheduledReboot(ctx); err != nil {
+		t.Fatalf("RunScheduledReboot() failed: %v", err)
+	}
+	if driver.reboots != 0 {
+		t.Errorf("Expected no reboot during an outage, got %d reboots", driver.reboots)
+	}
+
+	if err := service.outageTracker.RecordOutageEnd(); err != nil {
+		t.Fatalf("RecordOutageEnd() failed: %v", err)
+	}
+	if reason := service.scheduledRebootSkipReason(ctx); reason != "" {
+		t.Errorf("Expected the reboot to run after the outage without a window, got %q", reason)
+	}
+}
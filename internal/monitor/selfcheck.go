@@ -0,0 +1,215 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// selfCheckTimeout bounds the startup self-check, so a host without
+// connectivity still starts monitoring soon
+const selfCheckTimeout = 30 * time.Second
+
+// Self-check outcomes
+const (
+	SelfCheckPassed  = "ok"
+	SelfCheckWarning = "warning"
+	SelfCheckFailed  = "failed"
+)
+
+// SelfCheckItem is the outcome of one part of the startup self-check
+type SelfCheckItem struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// StartupReport is the result of the self-check run once at startup
+type StartupReport struct {
+	Time     time.Time       `json:"time"`
+	Duration time.Duration   `json:"duration"`
+	Checks   []SelfCheckItem `json:"checks"`
+}
+
+// Count returns the number of checks with status
+func (r *StartupReport) Count(status string) int {
+	count := 0
+	for _, check := range r.Checks {
+		if check.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Problems returns the checks that did not pass
+func (r *StartupReport) Problems() []SelfCheckItem {
+	var problems []SelfCheckItem
+	for _, check := range r.Checks {
+		if check.Status != SelfCheckPassed {
+			problems = append(problems, check)
+		}
+	}
+	return problems
+}
+
+// SelfCheck checks the configuration, the privileges of the process, the
+// modem login, the check targets and the paths the watchdog writes to. It
+// never reboots the modem.
+func (s *Service) SelfCheck(ctx context.Context) *StartupReport {
+	ctx, cancel := context.WithTimeout(ctx, selfCheckTimeout)
+	defer cancel()
+
+	started := s.clock.Now()
+	report := &StartupReport{Time: started}
+	for _, check := range []struct {
+		name string
+		run  func(ctx context.Context) (string, string)
+	}{
+		{"config", s.checkConfig},
+		{"privileges", s.checkPrivileges},
+		{"modem", s.checkModemLogin},
+		{"targets", s.checkTargets},
+		{"paths", s.checkPaths},
+	} {
+		status, detail := check.run(ctx)
+		report.Checks = append(report.Checks, SelfCheckItem{Name: check.name, Status: status, Detail: detail})
+	}
+	report.Duration = s.clock.Now().Sub(started)
+	return report
+}
+
+// checkConfig validates the configuration
+func (s *Service) checkConfig(ctx context.Context) (string, string) {
+	if err := s.config.Validate(); err != nil {
+		return SelfCheckFailed, err.Error()
+	}
+	return SelfCheckPassed, "configuration is valid"
+}
+
+// checkPrivileges reports the network checks degraded by missing
+// capabilities
+func (s *Service) checkPrivileges(ctx context.Context) (string, string) {
+	degradations := s.capabilities.Degradations()
+	if len(degradations) == 0 {
+		return SelfCheckPassed, fmt.Sprintf("pinging with %s", s.capabilities.PingMethod())
+	}
+	missing := make([]string, len(degradations))
+	for i, degradation := range degradations {
+		missing[i] = fmt.Sprintf("%s (%s, using %s)", degradation.Feature, degradation.Reason, degradation.Fallback)
+	}
+	return SelfCheckWarning, "degraded: " + strings.Join(missing, "; ")
+}
+
+// checkModemLogin logs in to the modem without rebooting it
+func (s *Service) checkModemLogin(ctx context.Context) (string, string) {
+	if s.modemDriver == nil {
+		return SelfCheckFailed, "no modem driver, reboots are impossible"
+	}
+	loginCtx, cancel := context.WithTimeout(ctx, s.config.ConnectionTimeout)
+	defer cancel()
+	if err := s.modemDriver.Login(loginCtx); err != nil {
+		return SelfCheckFailed, fmt.Sprintf("login to %s at %s failed: %v", s.modemDriver.Name(), s.config.ModemHost, err)
+	}
+	return SelfCheckPassed, fmt.Sprintf("logged in to %s at %s", s.modemDriver.Name(), s.config.ModemHost)
+}
+
+// checkTargets runs the connectivity checks once
+func (s *Service) checkTargets(ctx context.Context) (string, string) {
+	result, err := s.tester.ScheduleTests(ctx, nil, 0)
+	if err != nil {
+		return SelfCheckFailed, fmt.Sprintf("connectivity checks failed: %v", err)
+	}
+	detail := "connectivity checks passed"
+	if light := result.LightweightResult; light != nil {
+		detail = fmt.Sprintf("%d of %d lightweight tests passed", light.SuccessCount, light.SuccessCount+light.FailureCount)
+	}
+	if !result.OverallSuccess {
+		return SelfCheckFailed, detail + ", targets unreachable"
+	}
+	return SelfCheckPassed, detail
+}
+
+// checkPaths checks that the state directory, its logs and the log file's
+// directory are writable
+func (s *Service) checkPaths(ctx context.Context) (string, string) {
+	if s.config.StateDir() == "" {
+		return SelfCheckWarning, "no state directory, nothing is persisted"
+	}
+	dirs := []string{s.config.StateDir(), s.config.StatePath("logs")}
+	if s.config.LogFile != "" {
+		dirs = append(dirs, filepath.Dir(s.config.LogFile))
+	}
+	var failures []string
+	for _, dir := range dirs {
+		if err := checkWritable(dir); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return SelfCheckFailed, strings.Join(failures, "; ")
+	}
+	return SelfCheckPassed, strings.Join(dirs, ", ") + " writable"
+}
+
+// checkWritable creates and removes a file in dir, creating dir if needed
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("%s cannot be created: %w", dir, err)
+	}
+	file, err := os.CreateTemp(dir, ".selfcheck-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// runStartupCheck runs the self-check, logs the startup report and sends it
+// as a notification when something is wrong
+func (s *Service) runStartupCheck(ctx context.Context) {
+	report := s.SelfCheck(ctx)
+	s.snapshotMu.Lock()
+	s.startupReport = report
+	s.snapshotMu.Unlock()
+
+	for _, check := range report.Checks {
+		entry := s.logger.WithFields(logrus.Fields{"check": check.Name, "status": check.Status})
+		switch check.Status {
+		case SelfCheckPassed:
+			entry.Debug(check.Detail)
+		case SelfCheckWarning:
+			entry.Warn(check.Detail)
+		default:
+			entry.Error(check.Detail)
+		}
+	}
+	failed, warnings := report.Count(SelfCheckFailed), report.Count(SelfCheckWarning)
+	s.logger.WithFields(logrus.Fields{
+		"passed":      report.Count(SelfCheckPassed),
+		"warnings":    warnings,
+		"failed":      failed,
+		"duration_ms": report.Duration.Milliseconds(),
+	}).Info("Startup self-check completed")
+
+	if failed+warnings > 0 {
+		s.notifier.Send(ctx, notify.KindStartupReport, notify.Data{
+			Time:   report.Time,
+			Fields: map[string]interface{}{"failed": failed, "warnings": warnings, "problems": report.Problems()},
+		})
+	}
+}
+
+// StartupReport returns the report of the startup self-check, nil until it
+// ran
+func (s *Service) StartupReport() *StartupReport {
+	s.snapshotMu.RLock()
+	defer s.snapshotMu.RUnlock()
+	return s.startupReport
+}
@@ -3,18 +3,44 @@ package monitor
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/perezjoseph/mb8600-watchdog/internal/budget"
+	"github.com/perezjoseph/mb8600-watchdog/internal/chaos"
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/cluster"
 	"github.com/perezjoseph/mb8600-watchdog/internal/config"
 	"github.com/perezjoseph/mb8600-watchdog/internal/connectivity"
 	"github.com/perezjoseph/mb8600-watchdog/internal/diagnostics"
-	"github.com/perezjoseph/mb8600-watchdog/internal/hnap"
+	"github.com/perezjoseph/mb8600-watchdog/internal/docker"
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/perezjoseph/mb8600-watchdog/internal/features"
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
 	"github.com/perezjoseph/mb8600-watchdog/internal/outage"
 	"github.com/perezjoseph/mb8600-watchdog/internal/performance"
+	"github.com/perezjoseph/mb8600-watchdog/internal/publicip"
+	"github.com/perezjoseph/mb8600-watchdog/internal/quorum"
+	"github.com/perezjoseph/mb8600-watchdog/internal/retry"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+	"github.com/perezjoseph/mb8600-watchdog/internal/sms"
+	"github.com/perezjoseph/mb8600-watchdog/internal/system"
+	"github.com/perezjoseph/mb8600-watchdog/internal/trace"
+	"github.com/perezjoseph/mb8600-watchdog/internal/tunnel"
+	"github.com/perezjoseph/mb8600-watchdog/internal/verdict"
 	"github.com/sirupsen/logrus"
 )
 
@@ -27,20 +53,127 @@ type ServiceState struct {
 	TotalReboots int       `json:"total_reboots"`
 	IsRunning    bool      `json:"is_running"`
 	StartTime    time.Time `json:"start_time"`
+	ModemModel   string    `json:"modem_model,omitempty"`
+	ModemMode    string    `json:"modem_mode,omitempty"`
+	// BudgetOverruns counts check stages that ran past their slice of the cycle budget
+	BudgetOverruns int `json:"budget_overruns"`
+	// RetriesDenied counts retries refused because their cycle had spent its retry budget
+	RetriesDenied int `json:"retries_denied"`
+	// ModemFirmware is the firmware version the modem reported last
+	ModemFirmware string `json:"modem_firmware,omitempty"`
+	// ModemTemperature is the temperature in Celsius the modem reported
+	// last, if its firmware reports one
+	ModemTemperature *float64 `json:"modem_temperature_c,omitempty"`
+	// DOCSISTimeouts are the T3 and T4 timeouts of the modem's event log, if
+	// its driver reads the log
+	DOCSISTimeouts *modem.TimeoutCounts `json:"docsis_timeouts,omitempty"`
+	// ModemAccess is the access method the driver tries first, the last one
+	// that worked, such as https+form; empty for drivers with a single one
+	ModemAccess string `json:"modem_access,omitempty"`
+	// Health is HEALTHY, DEGRADED or UNHEALTHY by the score of the last check
+	Health      string  `json:"health,omitempty"`
+	HealthScore float64 `json:"health_score"`
+	// SkippedChecks counts checks dropped because the previous check overran the interval
+	SkippedChecks int `json:"skipped_checks"`
+	// RebootsByReason counts reboots by reason, such as RebootThreshold and
+	// RebootManual; reboots from before reasons were tracked are not in it
+	RebootsByReason map[string]int `json:"reboots_by_reason,omitempty"`
+	// NextScheduledReboot is when the scheduled preventive reboot runs next,
+	// if one is configured
+	NextScheduledReboot *time.Time `json:"next_scheduled_reboot,omitempty"`
+	// Pause is the manual pause of automatic reboots, if monitoring is paused
+	Pause *Pause `json:"pause,omitempty"`
+	// Reboot is the reboot in progress, if the modem is rebooting
+	Reboot *RebootWorkflow `json:"reboot,omitempty"`
+	// Check summarizes the last connectivity check
+	Check *connectivity.TestSummary `json:"check,omitempty"`
+	// Diagnostics summarizes the last diagnostic analysis, which decided
+	// whether the last failed check rebooted the modem
+	Diagnostics *DiagnosticsSummary `json:"diagnostics,omitempty"`
+	// Tunnel is the last check of the tunnel to the modem, if the modem is
+	// reached through one
+	Tunnel *tunnel.Health `json:"tunnel,omitempty"`
+	// LowPower is set while the watchdog runs in low-power mode
+	LowPower bool `json:"low_power,omitempty"`
+	// Cluster is the node's view of the active/standby cluster, if it is
+	// clustered
+	Cluster *cluster.Status `json:"cluster,omitempty"`
+	// PartialService is set while the modem is in partial service, with
+	// downstream channels unlocked
+	PartialService *PartialService `json:"partial_service,omitempty"`
 }
 
 // Service orchestrates the monitoring workflow
 type Service struct {
 	config         *config.Config
 	logger         *logrus.Logger
-	hnapClient     *hnap.Client
-	tester         *connectivity.Tester
-	analyzer       *diagnostics.Analyzer
+	modemDriver    modem.Driver
+	tester         ConnectivityChecker
+	analyzer       diagnoser
 	outageTracker  *outage.Tracker
 	outageReporter *outage.Reporter
 	perfMonitor    *performance.Monitor
+	notifier       *notify.Dispatcher
+	recovery       []RecoveryAction
+	lastAnalysis   *diagnostics.AnalysisResult
+	diagnostics    *DiagnosticsSummary
 	failureCount   int
 	lastTestResult *connectivity.TieredTestResult
+	modemStatus    *modem.Status
+	clock          clock.Clock
+	scheduler      *scheduler.Scheduler
+	opts           Options
+	capabilities   system.Capabilities
+
+	// cycles tags log entries with the ID of the check cycle in progress,
+	// cycleID; both are empty between cycles
+	cycles  *trace.Hook
+	cycleID string
+
+	// tunnel reaches the modem when it is not on the local network, nil
+	// when it is; tunnelProber probes the modem through it and
+	// tunnelHealth is the outcome of its last check
+	tunnel       *tunnel.Tunnel
+	tunnelProber *modem.Prober
+	tunnelHealth *tunnel.Health
+
+	// lowPower is set in low-power mode; historyFlushed is when the history
+	// held in memory was last written and powerError is the last failure to
+	// read the power source
+	lowPower       bool
+	historyFlushed time.Time
+	powerError     string
+
+	// beaconFailing is set while the status beacon is not getting through;
+	// only the beacon job touches it
+	beaconFailing bool
+
+	// cluster is the node of the active/standby cluster, nil when not
+	// clustered; clusterActive and clusterPeerFailing are only touched by
+	// the heartbeat job and its scheduling
+	clusterMu          sync.Mutex
+	cluster            *cluster.Node
+	clusterActive      bool
+	clusterPeerFailing bool
+
+	// hostDegraded is set while the monitoring host looks broken
+	hostDegraded bool
+
+	// failureCause is the cause the diagnostics found for the current
+	// failure; notifiedVerdict is the verdict notified during the current
+	// outage
+	failureCause    string
+	notifiedVerdict string
+
+	// partialService is set while the modem is in partial service
+	partialService *PartialService
+	// modemOverheating is set while the modem is above ModemMaxTemperature
+	modemOverheating bool
+
+	// timeouts counts the DOCSIS timeouts of the modem's event log;
+	// timeoutsOverThreshold is set while they reach a threshold
+	timeouts              *modem.TimeoutCounter
+	timeoutsOverThreshold bool
 
 	// State tracking
 	totalChecks  int
@@ -49,32 +182,242 @@ type Service struct {
 	lastReboot   time.Time
 	startTime    time.Time
 	isRunning    bool
+	// rebootsByReason counts reboots by reboot reason
+	rebootsByReason map[string]int
+
+	budgetOverruns int
+	retriesDenied  int
+	health         *health.Model
+	healthStatus   string
+	healthScore    float64
+
+	// cycleMu serializes check cycles and manual reboots
+	cycleMu sync.Mutex
+	// lastCompleted is when the last check cycle finished, guarded by cycleMu
+	lastCompleted time.Time
+	// progress tells whether scheduled check cycles keep returning
+	progress progress
+
+	// pause is the manual pause of automatic reboots, nil when not paused
+	pauseMu sync.Mutex
+	pause   *Pause
+
+	// activeReboot is the reboot workflow in progress, nil when none is; it
+	// is aborted when stopping closes under the abort shutdown policy
+	rebootMu     sync.Mutex
+	activeReboot *rebootRun
+	stopping     <-chan struct{}
+
+	// snapshot is the state published for readers on other goroutines,
+	// along with the results of the last check
+	snapshotMu       sync.RWMutex
+	snapshot         ServiceState
+	snapshotResult   *connectivity.TieredTestResult
+	snapshotAnalysis *diagnostics.AnalysisResult
+	snapshotModem    *modem.Status
+	// startupReport is the result of the startup self-check
+	startupReport *StartupReport
+}
+
+// ConnectivityChecker runs a connectivity check cycle; *connectivity.Tester
+// satisfies it and the simulator replaces it with scripted results
+type ConnectivityChecker interface {
+	ScheduleTests(ctx context.Context, lastResult *connectivity.TieredTestResult, consecutiveFailures int) (*connectivity.TieredTestResult, error)
+}
+
+// diagnoser runs the network diagnostics before a reboot;
+// *diagnostics.Analyzer satisfies it. Holding the analyzer behind an
+// interface lets the linker drop it from nodiagnostics builds.
+type diagnoser interface {
+	RunDiagnostics(ctx context.Context) ([]diagnostics.DiagnosticResult, error)
+	AnalyzeResults(results []diagnostics.DiagnosticResult) bool
+	PerformDetailedAnalysis(results []diagnostics.DiagnosticResult) diagnostics.AnalysisResult
+	WirelessLinkDegraded(ctx context.Context) (diagnostics.DiagnosticResult, bool)
+	BreakerStates() map[string]string
+	SetRoutingTargets(targets []string)
+	SetTargets(targets diagnostics.Targets)
+	SetHealthModel(m *health.Model)
+}
+
+// Options holds optional dependencies of the service. Zero values select the
+// real clock, network and configured modem driver; tests inject fakes.
+type Options struct {
+	Clock       clock.Clock
+	Dialer      connectivity.Dialer
+	HTTPClient  *http.Client
+	ModemDriver modem.Driver
+	// Checker replaces the connectivity tester; Dialer and HTTPClient are
+	// ignored when it is set
+	Checker ConnectivityChecker
+	// Faults injects faults into connectivity probes and modem calls
+	Faults *chaos.Injector
+	// Notifiers receive outage and reboot notifications; notifications are
+	// logged when none are set
+	Notifiers []notify.Notifier
+	// PluginNotifiers receive notifications alongside Notifiers or the
+	// log and SMS
+	PluginNotifiers []notify.Notifier
+	// Checks are custom connectivity checks, such as those of plugins, the
+	// lightweight tests run
+	Checks []connectivity.Check
+	// Policy decides in place of the built-in policy whether to reboot
+	// once the failure threshold is reached
+	Policy DecisionPolicy
+	// RecoveryActions run when connectivity returns from an outage; they
+	// replace the actions built from the configuration
+	RecoveryActions []RecoveryAction
+	// Capabilities replace the capabilities detected on the host
+	Capabilities *system.Capabilities
+	// Loggers provides the loggers of the connectivity, diagnostics and
+	// modem modules; they log to the service logger when it is nil
+	Loggers ModuleLoggers
+	// OnPanic receives panics of the goroutines the service starts, such as
+	// scheduled checks; without it a panic crashes the process
+	OnPanic func(where string, value interface{}, stack []byte)
+	// LookupIPAddr resolves the check targets to warn about local ones,
+	// net.DefaultResolver.LookupIPAddr when nil
+	LookupIPAddr func(ctx context.Context, host string) ([]net.IPAddr, error)
+	// HostHealth replaces the checks of the monitoring host
+	HostHealth HostHealthChecker
+}
+
+// ModuleLoggers returns the logger of a module, such as
+// logger.LevelController
+type ModuleLoggers interface {
+	Module(name string) *logrus.Logger
+}
+
+// moduleLogger returns the logger of module, or fallback without Loggers
+func (o Options) moduleLogger(module string, fallback *logrus.Logger) *logrus.Logger {
+	if o.Loggers == nil {
+		return fallback
+	}
+	return o.Loggers.Module(module)
+}
+
+// RecoveryAction runs when connectivity returns from an outage, e.g. to
+// restart services that do not recover on their own
+type RecoveryAction interface {
+	Name() string
+	Recover(ctx context.Context, downtime time.Duration) error
+}
+
+// newRecoveryActions builds the recovery actions enabled in cfg. The public
+// IP is checked first, so DDNS records are fixed before containers restart.
+func newRecoveryActions(cfg *config.Config, logger *logrus.Logger, opts Options) []RecoveryAction {
+	var actions []RecoveryAction
+	if watcher := newPublicIPWatcher(cfg, logger, opts); watcher != nil {
+		actions = append(actions, watcher)
+	}
+	if len(cfg.DockerRestartContainers) > 0 {
+		actions = append(actions, docker.NewRestartAction(docker.NewClient(cfg.DockerSocket),
+			cfg.DockerRestartContainers, cfg.DockerRestartAfter, logger))
+	}
+	return actions
+}
+
+// newPublicIPWatcher builds the public IP watcher and its DDNS updater, or
+// returns nil when public IP tracking is disabled
+func newPublicIPWatcher(cfg *config.Config, logger *logrus.Logger, opts Options) *publicip.Watcher {
+	if !cfg.PublicIPEnabled() {
+		return nil
+	}
+
+	resolver := publicip.NewResolver(cfg.PublicIPServices, outboundClient(cfg, opts, 10*time.Second))
+	watcher := publicip.NewWatcher(resolver, cfg.StatePath("public-ip.json"), opts.Clock, logger)
+	if features.History && cfg.FeatureEnabled(features.NameHistory) {
+		watcher.SetHistory(cfg.HistoryPath())
+	}
+	if cfg.PublicIPGeoService != "" {
+		watcher.SetLocator(publicip.NewLocator(cfg.PublicIPGeoService, outboundClient(cfg, opts, 10*time.Second)))
+	}
+
+	if cfg.DDNSProvider != "" {
+		updater, err := publicip.NewUpdater(publicip.DDNSConfig{
+			Provider: cfg.DDNSProvider,
+			Domain:   cfg.DDNSDomain,
+			Token:    cfg.DDNSToken,
+			ZoneID:   cfg.DDNSZoneID,
+			Script:   cfg.DDNSScript,
+		}, outboundClient(cfg, opts, 30*time.Second))
+		if err != nil {
+			logger.WithError(err).Error("Invalid DDNS configuration, IP changes are only recorded")
+		} else {
+			watcher.AddUpdater(updater)
+		}
+	}
+	return watcher
+}
+
+// outboundClient returns opts.HTTPClient, or a client with timeout that goes
+// through the configured outbound proxy
+func outboundClient(cfg *config.Config, opts Options, timeout time.Duration) *http.Client {
+	if opts.HTTPClient != nil {
+		return opts.HTTPClient
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = cfg.Proxy()
+	return &http.Client{Timeout: timeout, Transport: transport}
 }
 
 // NewService creates a new monitoring service
 func NewService(cfg *config.Config, logger *logrus.Logger) *Service {
-	// Create tester with configuration from config
-	tester := connectivity.NewTesterWithConfig(
-		logger,
-		cfg.ConnectionTimeout,
-		cfg.HTTPTimeout,
-		cfg.PingHosts,
-		cfg.HTTPHosts,
-	)
+	return NewServiceWithOptions(cfg, logger, Options{})
+}
+
+// NewServiceWithOptions creates a new monitoring service with injected dependencies
+func NewServiceWithOptions(cfg *config.Config, logger *logrus.Logger, opts Options) *Service {
+	if opts.Clock == nil {
+		opts.Clock = clock.New()
+	}
+	cycles := addCycleHook(logger, opts)
+
+	checker := opts.Checker
+	if checker == nil {
+		checker = newTester(cfg, opts.moduleLogger("connectivity", logger), opts)
+	}
+
+	var capabilities system.Capabilities
+	if opts.Capabilities != nil {
+		capabilities = *opts.Capabilities
+	} else {
+		capabilities = system.DetectCapabilities()
+	}
+
+	modemTunnel := newModemTunnel(cfg, logger)
+	modemDriver := opts.ModemDriver
+	if modemDriver == nil {
+		modemDriver = newModemDriver(cfg, modemTunnel, opts.moduleLogger("modem", logger))
+	}
+	if opts.Faults != nil {
+		modemDriver = opts.Faults.WrapDriver(modemDriver)
+	}
 
 	// Create outage tracker
-	outageTracker := outage.NewTracker(logger, cfg.WorkingDirectory+"/logs/outages.json")
+	outageTracker := outage.NewTracker(logger, cfg.OutagesPath())
 
 	// Create outage reporter
 	reportConfig := outage.ReportConfig{
 		ReportInterval:    cfg.OutageReportInterval,
-		ReportDirectory:   cfg.WorkingDirectory + "/logs/reports",
+		ReportDirectory:   cfg.ReportsPath(),
 		MaxRecentOutages:  10,
-		ReportRetention:   30 * 24 * time.Hour, // 30 days
-		EnableJSONReports: true,
+		ReportRetention:   time.Duration(cfg.ReportMaxAge) * 24 * time.Hour,
+		ReportMaxBytes:    int64(cfg.ReportMaxSize) * 1024 * 1024,
+		EnableJSONReports: cfg.ReportsPath() != "",
 		EnableLogReports:  true,
 	}
 	outageReporter := outage.NewReporter(outageTracker, reportConfig, logger)
+	if features.History && cfg.FeatureEnabled(features.NameHistory) {
+		outageReporter.SetHistory(cfg.HistoryPath())
+	}
+
+	notifier := NewNotifier(cfg, logger, opts)
+	outageReporter.SetTemplates(notifier.Templates())
+
+	recovery := opts.RecoveryActions
+	if recovery == nil {
+		recovery = newRecoveryActions(cfg, logger, opts)
+	}
 
 	// Create performance monitor with resource limits if enabled
 	var perfMonitor *performance.Monitor
@@ -84,7 +427,7 @@ func NewService(cfg *config.Config, logger *logrus.Logger) *Service {
 
 		perfMonitor = performance.NewMonitorWithLimitsAndInterval(
 			logger,
-			cfg.WorkingDirectory+"/logs/performance.json",
+			cfg.StatePath("logs", "performance.json"),
 			cfg.OutageReportInterval, // Use same interval as outage reports
 			memoryLimitBytes,
 			startupTimeLimit,
@@ -93,105 +436,532 @@ func NewService(cfg *config.Config, logger *logrus.Logger) *Service {
 	} else {
 		perfMonitor = performance.NewMonitor(
 			logger,
-			cfg.WorkingDirectory+"/logs/performance.json",
+			cfg.StatePath("logs", "performance.json"),
 			cfg.OutageReportInterval, // Use same interval as outage reports
 		)
 	}
+	perfMonitor.SetClock(opts.Clock)
+	perfMonitor.SetRetention(cfg.MetricsMaxOperations, cfg.MetricsRetention)
 
-	return &Service{
+	service := &Service{
 		config:         cfg,
 		logger:         logger,
-		hnapClient:     hnap.NewClient(cfg.ModemHost, cfg.ModemUsername, cfg.ModemPassword, cfg.ModemNoVerify, logger),
-		tester:         tester,
-		analyzer:       diagnostics.NewAnalyzer(logger, cfg.DiagnosticsTimeout),
+		modemDriver:    modemDriver,
+		tester:         checker,
+		analyzer:       newAnalyzer(cfg, opts.moduleLogger("diagnostics", logger), capabilities),
 		outageTracker:  outageTracker,
 		outageReporter: outageReporter,
 		perfMonitor:    perfMonitor,
-		startTime:      time.Now(),
+		notifier:       notifier,
+		recovery:       recovery,
+		startTime:      opts.Clock.Now(),
 		isRunning:      false,
+		clock:          opts.Clock,
+		scheduler:      newScheduler(opts, logger),
+		health:         cfg.HealthModel(),
+		opts:           opts,
+		capabilities:   capabilities,
+		cycles:         cycles,
+	}
+	service.setTunnel(modemTunnel)
+	service.loadPause()
+	return service
+}
+
+// addCycleHook tags the entries of logger, and of the module loggers in
+// opts, with the ID of the check cycle in progress
+func addCycleHook(logger *logrus.Logger, opts Options) *trace.Hook {
+	hook := trace.NewHook()
+	logger.AddHook(hook)
+	if opts.Loggers != nil {
+		for _, module := range []string{"connectivity", "diagnostics", "modem"} {
+			opts.Loggers.Module(module).AddHook(hook)
+		}
+	}
+	return hook
+}
+
+// NewNotifier creates the dispatcher that delivers the service's
+// notifications: the configured message templates in the configured
+// language, and opts.Notifiers, or the log and SMS when configured, and
+// opts.PluginNotifiers when the notifications subsystem is enabled, queueing
+// what they fail to deliver.
+// Otherwise it delivers nothing.
+func NewNotifier(cfg *config.Config, logger *logrus.Logger, opts Options) *notify.Dispatcher {
+	language := i18n.Detect(cfg.Language)
+	templates, err := notify.LoadLocalizedTemplates(cfg.MessageTemplates, language)
+	if err == nil {
+		err = templates.Validate()
+	}
+	if err != nil {
+		logger.WithError(err).Error("Invalid message templates, using built-in messages")
+		templates = notify.LocalizedTemplates(language)
+	}
+
+	var notifiers []notify.Notifier
+	if features.Notifications && cfg.FeatureEnabled(features.NameNotifications) {
+		notifiers = append(notifiers, opts.Notifiers...)
+		if len(notifiers) == 0 {
+			notifiers = []notify.Notifier{notify.NewLogNotifier(logger)}
+			if notifier := newSMSNotifier(cfg, logger, opts); notifier != nil {
+				notifiers = append(notifiers, notifier)
+			}
+		}
+		notifiers = append(notifiers, opts.PluginNotifiers...)
+	}
+	dispatcher := notify.NewDispatcher(templates, logger, notifiers...)
+	if len(notifiers) > 0 && cfg.NotificationQueueSize > 0 && cfg.NotificationQueuePath() != "" {
+		queue, err := notify.NewQueue(cfg.NotificationQueuePath(), cfg.NotificationQueueSize)
+		if err != nil {
+			logger.WithError(err).Error("Failed to load notification queue, undeliverable notifications are dropped")
+		} else {
+			dispatcher.SetQueue(queue)
+		}
+	}
+	return dispatcher
+}
+
+// newSMSNotifier creates the SMS notifier, or returns nil when SMS
+// notifications are disabled
+func newSMSNotifier(cfg *config.Config, logger *logrus.Logger, opts Options) notify.Notifier {
+	if cfg.SMSProvider == "" {
+		return nil
+	}
+	sender, err := sms.NewSender(cfg.SMS(), outboundClient(cfg, opts, 30*time.Second))
+	if err != nil {
+		logger.WithError(err).Error("Invalid SMS configuration, notifications are not texted")
+		return nil
+	}
+	return sms.NewNotifier(sender, cfg.SMSTo)
+}
+
+// newScheduler creates the job scheduler, passing panics of jobs to
+// opts.OnPanic
+func newScheduler(opts Options, logger *logrus.Logger) *scheduler.Scheduler {
+	sched := scheduler.New(opts.Clock, logger)
+	if opts.OnPanic != nil {
+		sched.SetPanicHandler(func(job string, value interface{}, stack []byte) {
+			opts.OnPanic("job "+job, value, stack)
+		})
+	}
+	return sched
+}
+
+// recoverPanic passes a panic of the goroutine named where to
+// opts.OnPanic; without it the panic continues. It must be deferred.
+func (s *Service) recoverPanic(where string) {
+	if s.opts.OnPanic == nil {
+		return
+	}
+	if value := recover(); value != nil {
+		s.opts.OnPanic(where, value, debug.Stack())
 	}
 }
 
+// newTester creates the connectivity tester with the injected dependencies.
+// A configured responder is the only target of the tests.
+func newTester(cfg *config.Config, logger *logrus.Logger, opts Options) *connectivity.Tester {
+	pingHosts, httpHosts := cfg.PingHosts, cfg.HTTPHosts
+	if cfg.Responder != "" {
+		pingHosts, httpHosts = []string{cfg.Responder}, nil
+		if cfg.ResponderURL != "" {
+			httpHosts = []string{cfg.ResponderURL}
+		}
+	}
+	tester := connectivity.NewTesterWithConfig(
+		logger,
+		cfg.ConnectionTimeout,
+		cfg.HTTPTimeout,
+		pingHosts,
+		httpHosts,
+	)
+	tester.SetResponder(cfg.Responder)
+	if cfg.ResponderKey != "" {
+		tester.SetResponderKey([]byte(cfg.ResponderKey))
+	}
+	tester.SetClock(opts.Clock)
+	tester.SetHealthModel(cfg.HealthModel())
+	tester.SetEscalationPolicy(cfg.EscalationPolicy())
+	tester.SetProxy(cfg.Proxy())
+	tester.SetWireGuard(cfg.WireGuardCheck())
+	tester.SetGateway(cfg.GatewayTarget)
+	tester.SetDependencies(cfg.Dependencies())
+	tester.SetTargetRotation(cfg.TargetRotation())
+	tester.SetChecks(opts.Checks)
+
+	dialer := opts.Dialer
+	if opts.Faults != nil {
+		dialer = opts.Faults.WrapDialer(dialer)
+	}
+	if dialer != nil {
+		tester.SetDialer(dialer)
+	}
+	if opts.HTTPClient != nil {
+		tester.SetHTTPClient(opts.HTTPClient)
+	}
+	return tester
+}
+
+// newModemDriver creates the modem driver for the configured modem type,
+// falling back to the default driver if the type is unknown
+// newAnalyzer creates the diagnostics analyzer, with the routing probe when
+// it is enabled and check implementations the host's capabilities allow, or
+// nil when diagnostics are not built in
+func newAnalyzer(cfg *config.Config, logger *logrus.Logger, caps system.Capabilities) diagnoser {
+	if !features.Diagnostics {
+		return nil
+	}
+	analyzer := diagnostics.NewAnalyzer(logger, cfg.DiagnosticsTimeout)
+	analyzer.SetRoutingTargets(routingTargets(cfg))
+	analyzer.SetTargets(diagnosticTargets(cfg))
+	analyzer.SetCapabilities(caps)
+	analyzer.SetHealthModel(cfg.HealthModel())
+	analyzer.SetProxy(cfg.Proxy())
+	return analyzer
+}
+
+// logCapabilities logs the detected capabilities and every check that runs
+// degraded because of a missing one
+func (s *Service) logCapabilities() {
+	degradations := s.capabilities.Degradations()
+	s.logger.WithFields(logrus.Fields{
+		"cap_net_raw":   s.capabilities.NetRaw,
+		"icmp_datagram": s.capabilities.ICMPDatagram,
+		"icmp_raw":      s.capabilities.ICMPRaw,
+		"netlink":       s.capabilities.Netlink,
+		"proc_net":      s.capabilities.ProcNet,
+		"sys_class_net": s.capabilities.SysClassNet,
+		"ping_method":   s.capabilities.PingMethod(),
+		"degraded":      len(degradations),
+	}).Info("Detected host capabilities")
+
+	for _, degradation := range degradations {
+		s.logger.WithFields(logrus.Fields{
+			"feature":  degradation.Feature,
+			"reason":   degradation.Reason,
+			"fallback": degradation.Fallback,
+		}).Warn("Network check degraded by missing capability")
+	}
+}
+
+// routingTargets returns the routing probe targets, or nil when the probe is disabled
+func routingTargets(cfg *config.Config) []string {
+	if !cfg.RoutingProbe {
+		return nil
+	}
+	if len(cfg.RoutingProbeTargets) > 0 {
+		return cfg.RoutingProbeTargets
+	}
+	return diagnostics.DefaultRoutingTargets
+}
+
+// diagnosticTargets returns the hosts the diagnostics probe: the configured
+// targets, or those derived from the hosts of the connectivity checks. With a
+// responder, only the responder is probed unless targets are configured.
+func diagnosticTargets(cfg *config.Config) diagnostics.Targets {
+	targets := diagnostics.TargetsFor(cfg.PingHosts, cfg.HTTPHosts)
+	if cfg.Responder != "" {
+		targets = diagnostics.TargetsForResponder(cfg.Responder, cfg.ResponderURL)
+	}
+	if len(cfg.DiagnosticPingTargets) > 0 {
+		targets.Ping = cfg.DiagnosticPingTargets
+	}
+	if len(cfg.DiagnosticTCPTargets) > 0 {
+		targets.TCP = cfg.DiagnosticTCPTargets
+	}
+	if len(cfg.DiagnosticDNSTargets) > 0 {
+		targets.DNS = cfg.DiagnosticDNSTargets
+	}
+	if len(cfg.DiagnosticHTTPTargets) > 0 {
+		targets.HTTP = cfg.DiagnosticHTTPTargets
+	}
+	return targets
+}
+
+func newModemDriver(cfg *config.Config, modemTunnel *tunnel.Tunnel, logger *logrus.Logger) modem.Driver {
+	opts := modem.Options{
+		Host:     cfg.ModemHost,
+		Port:     cfg.ModemPort,
+		Username: cfg.ModemUsername,
+		Password: cfg.ModemPassword,
+		NoVerify: cfg.ModemNoVerify,
+	}
+	if modemTunnel != nil {
+		opts.Transport = modemTunnel.Transport(cfg.ModemNoVerify)
+	}
+
+	driver, err := modem.New(cfg.ModemType, opts, logger)
+	if err != nil {
+		logger.WithError(err).Warn("Falling back to default modem driver")
+		driver, _ = modem.New(modem.DefaultType, opts, logger)
+	}
+	if cfg.RetryAttempts > 0 {
+		driver = modem.WithRetry(driver, retry.Policy{
+			MaxAttempts: cfg.RetryAttempts + 1,
+			BaseDelay:   time.Second,
+			MaxDelay:    10 * time.Second,
+			Multiplier:  cfg.RetryBackoffFactor,
+		}, logger)
+	}
+	return driver
+}
+
 // Start begins the monitoring loop
 func (s *Service) Start(ctx context.Context) error {
 	s.logger.Info("Starting monitoring service")
+	s.logCapabilities()
 	s.isRunning = true
-	s.startTime = time.Now()
+	s.startTime = s.clock.Now()
+	s.rebootMu.Lock()
+	s.stopping = ctx.Done()
+	s.rebootMu.Unlock()
+	s.markProgress()
 
 	// Start performance monitoring
 	perfCtx, perfCancel := context.WithCancel(ctx)
 	defer perfCancel()
 
 	go func() {
+		defer s.recoverPanic("performance")
 		if err := s.perfMonitor.Start(perfCtx); err != nil && err != context.Canceled {
 			s.logger.WithError(err).Error("Performance monitor error")
 		}
 	}()
 
-	// Start outage reporting
-	reportCtx, reportCancel := context.WithCancel(ctx)
-	defer reportCancel()
+	// Detect modem model and operating mode (bridge/router for combo gateways)
+	s.refreshModemStatus(ctx)
+	s.updatePowerMode()
+	s.publishState()
+	if s.config.StartupCheck {
+		s.runStartupCheck(ctx)
+	}
 
-	go func() {
-		if err := s.outageReporter.Start(reportCtx); err != nil && err != context.Canceled {
-			s.logger.WithError(err).Error("Outage reporter error")
+	// Remember the public IP, so the first change after startup is detected
+	go s.observePublicIP(ctx, s.recovery)
+	go s.warnLocalTargets(ctx, s.config)
+
+	if err := s.scheduleJobs(); err != nil {
+		s.isRunning = false
+		return err
+	}
+	defer s.scheduler.Remove(CheckJobName)
+	defer s.scheduler.Remove(outage.ReportJobName)
+	defer s.scheduler.Remove(ScheduledRebootJobName)
+	defer s.scheduler.Remove(BeaconJobName)
+	defer s.scheduler.Remove(ModemStatsJobName)
+	defer s.scheduler.Remove(TextfileJobName)
+	defer s.scheduler.Remove(HistoryMaintenanceJobName)
+	defer s.scheduler.Remove(BackupJobName)
+	defer s.scheduler.Remove(SyncJobName)
+	defer s.scheduler.Remove(ClusterHeartbeatJobName)
+
+	err := s.scheduler.Run(ctx)
+	s.waitForReboot()
+	if s.lowPower {
+		if err := history.Release(); err != nil {
+			s.logger.WithError(err).Warn("Failed to write held history")
 		}
-	}()
+	}
+	s.logger.Info("Monitoring service stopped")
+	s.isRunning = false
+	s.publishState()
+	// Leave watchdog_up at 0 for the collector
+	s.writeTextfile(context.Background())
+	return err
+}
+
+// CheckJobName is the scheduler job name of the connectivity check
+const CheckJobName = "connectivity_check"
 
-	ticker := time.NewTicker(s.config.CheckInterval)
-	defer ticker.Stop()
+// Scheduler returns the scheduler running the service's periodic jobs, so
+// other components can register their own jobs on the same loop
+func (s *Service) Scheduler() *scheduler.Scheduler {
+	return s.scheduler
+}
+
+// scheduleJobs registers the connectivity check and outage report jobs
+func (s *Service) scheduleJobs() error {
+	if err := s.outageReporter.Prepare(); err != nil {
+		s.logger.WithError(err).Error("Outage reporter error")
+	}
+	if s.config.OutageReportInterval > 0 {
+		if err := s.scheduler.Add(outage.ReportJobName, scheduler.Every(s.config.OutageReportInterval), s.runOutageReport); err != nil {
+			return err
+		}
+	}
 
 	// Track consecutive errors for graceful degradation
 	consecutiveErrors := 0
 	maxConsecutiveErrors := 5
+	degraded := false
 
-	for {
-		select {
-		case <-ctx.Done():
-			s.logger.Info("Monitoring service stopped")
-			s.isRunning = false
-			return ctx.Err()
-		case <-ticker.C:
-			s.totalChecks++
-			s.lastCheck = time.Now()
-
-			if err := s.performCheckWithRecovery(ctx); err != nil {
-				consecutiveErrors++
-				s.logger.WithFields(logrus.Fields{
-					"error":              err.Error(),
-					"consecutive_errors": consecutiveErrors,
-					"max_errors":         maxConsecutiveErrors,
-				}).Error("Error during monitoring check")
-
-				// Implement graceful degradation
-				if consecutiveErrors >= maxConsecutiveErrors {
-					s.logger.WithField("consecutive_errors", consecutiveErrors).Error("Too many consecutive errors, implementing graceful degradation")
-
-					// Increase check interval temporarily to reduce load
-					ticker.Stop()
-					degradedInterval := s.config.CheckInterval * 2
-					s.logger.WithField("degraded_interval", degradedInterval).Warn("Switching to degraded monitoring interval")
-					ticker = time.NewTicker(degradedInterval)
-
-					// Reset consecutive error counter after degradation
-					consecutiveErrors = 0
-				}
-			} else {
-				// Reset consecutive error counter on successful check
-				if consecutiveErrors > 0 {
-					s.logger.WithField("previous_errors", consecutiveErrors).Info("Monitoring check successful, resetting error counter")
-					consecutiveErrors = 0
-
-					// Restore normal check interval if we were in degraded mode
-					if ticker.C != time.NewTicker(s.config.CheckInterval).C {
-						ticker.Stop()
-						ticker = time.NewTicker(s.config.CheckInterval)
-						s.logger.Info("Restored normal monitoring interval")
-					}
-				}
+	check := func(ctx context.Context) error {
+		err := s.RunCheck(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
 			}
+			consecutiveErrors++
+			s.logger.WithFields(logrus.Fields{
+				"error":              err.Error(),
+				"consecutive_errors": consecutiveErrors,
+				"max_errors":         maxConsecutiveErrors,
+			}).Error("Error during monitoring check")
+
+			// Implement graceful degradation
+			if consecutiveErrors >= maxConsecutiveErrors {
+				s.logger.WithField("consecutive_errors", consecutiveErrors).Error("Too many consecutive errors, implementing graceful degradation")
+
+				// Increase check interval temporarily to reduce load
+				degradedInterval := s.checkInterval() * 2
+				s.logger.WithField("degraded_interval", degradedInterval).Warn("Switching to degraded monitoring interval")
+				s.scheduler.Reschedule(CheckJobName, scheduler.Every(degradedInterval))
+				degraded = true
+
+				// Reset consecutive error counter after degradation
+				consecutiveErrors = 0
+			}
+			return err
 		}
+
+		// Reset consecutive error counter on successful check
+		if consecutiveErrors > 0 {
+			s.logger.WithField("previous_errors", consecutiveErrors).Info("Monitoring check successful, resetting error counter")
+			consecutiveErrors = 0
+		}
+
+		// Restore normal check interval if we were in degraded mode
+		if degraded {
+			s.scheduler.Reschedule(CheckJobName, scheduler.Every(s.checkInterval()))
+			degraded = false
+			s.logger.Info("Restored normal monitoring interval")
+		}
+		return nil
+	}
+
+	// A check that overruns the interval holds back the next one according
+	// to the overlap policy instead of letting checks pile up
+	overlap, err := scheduler.ParseOverlap(s.config.CheckOverlapPolicy)
+	if err != nil {
+		s.logger.WithError(err).Warn("Falling back to skipping overlapping checks")
+	}
+	if err := s.scheduler.AddWithOptions(CheckJobName, scheduler.Every(s.checkInterval()), check, scheduler.JobOptions{Overlap: overlap}); err != nil {
+		s.scheduler.Remove(outage.ReportJobName)
+		return err
+	}
+	if err := s.scheduleReboot(); err != nil {
+		s.logger.WithError(err).Error("Failed to schedule preventive reboot")
+	}
+	if err := s.scheduleBeacon(); err != nil {
+		s.logger.WithError(err).Error("Failed to schedule status beacon")
+	}
+	if err := s.scheduleModemStats(); err != nil {
+		s.logger.WithError(err).Error("Failed to schedule modem statistics")
+	}
+	if err := s.scheduleTextfile(); err != nil {
+		s.logger.WithError(err).Error("Failed to schedule metrics textfile")
+	}
+	if err := s.scheduleHistoryMaintenance(); err != nil {
+		s.logger.WithError(err).Error("Failed to schedule history maintenance")
+	}
+	if err := s.scheduleBackup(); err != nil {
+		s.logger.WithError(err).Error("Failed to schedule backups")
+	}
+	if err := s.scheduleSync(); err != nil {
+		s.logger.WithError(err).Error("Failed to schedule remote sync")
+	}
+	if err := s.scheduleClusterHeartbeat(); err != nil {
+		s.logger.WithError(err).Error("Failed to join the cluster")
 	}
+	return nil
+}
+
+// RunCheck performs a single monitoring cycle. Start calls it on every tick;
+// the simulator calls it directly to step through a scenario.
+func (s *Service) RunCheck(ctx context.Context) error {
+	s.cycleMu.Lock()
+	defer s.cycleMu.Unlock()
+	defer s.markProgress()
+	return s.runCheck(ctx)
+}
+
+// Refresh runs a check cycle now and returns the resulting state, for
+// callers that need a fresh result rather than the last published one.
+// Concurrent callers share one cycle: a cycle that completed after the
+// request, or within APIRefreshInterval before it, is reused instead of
+// probing again. The cycle runs on a context of its own, bounded by the
+// check interval and ended when the service stops, rather than on ctx: a
+// caller that gives up, such as an HTTP client that disconnects, must not
+// cancel the tests and have them counted as failures toward a reboot.
+func (s *Service) Refresh(ctx context.Context) (ServiceState, error) {
+	requested := s.clock.Now()
+	s.cycleMu.Lock()
+	defer s.cycleMu.Unlock()
+
+	if !s.lastCompleted.IsZero() && !s.lastCompleted.Before(requested.Add(-s.config.APIRefreshInterval)) {
+		s.logger.WithField("completed", s.lastCompleted).Debug("Reusing recent check for status refresh")
+		return s.Snapshot(), nil
+	}
+	checkCtx, cancel := s.detachedContext(s.config.CheckInterval)
+	defer cancel()
+	err := s.runCheck(checkCtx)
+	return s.Snapshot(), err
+}
+
+// detachedContext returns a context that ends after timeout or when the
+// service stops, whichever comes first
+func (s *Service) detachedContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	s.rebootMu.Lock()
+	stopping := s.stopping
+	s.rebootMu.Unlock()
+	if stopping != nil {
+		go func() {
+			select {
+			case <-stopping:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
+
+// runCheck performs a check cycle, unless checks are paused; the caller
+// holds cycleMu
+func (s *Service) runCheck(ctx context.Context) error {
+	s.updatePowerMode()
+	if pause := s.ActivePause(ctx); pause != nil && pause.Checks {
+		s.logger.WithField("until", pause.Until).Debug("Checks paused, skipping check")
+		s.publishState()
+		return nil
+	}
+
+	s.totalChecks++
+	s.lastCheck = s.clock.Now()
+	s.startCycle()
+	defer s.publishState()
+	defer func() {
+		s.lastCompleted = s.clock.Now()
+		s.endCycle()
+		s.flushHeldHistory()
+	}()
+	s.recordPublicIP(ctx)
+	s.checkTunnel(ctx)
+	return s.performCheckWithRecovery(ctx)
+}
+
+// startCycle gives the check cycle starting now a new ID, which tags the log
+// entries, results and history records of the cycle
+func (s *Service) startCycle() {
+	s.cycleID = trace.NewCycleID()
+	s.cycles.Set(s.cycleID)
+}
+
+// endCycle ends the check cycle in progress
+func (s *Service) endCycle() {
+	s.cycleID = ""
+	s.cycles.Set("")
 }
 
 // performCheck executes a single monitoring cycle using tiered testing strategy
@@ -209,6 +979,15 @@ func (s *Service) performCheck(ctx context.Context) error {
 		return fmt.Errorf("performance monitor is not initialized")
 	}
 
+	// Testing and diagnostics share the cycle budget; the reboot and recovery
+	// wait are deliberately outside it
+	if total := s.cycleBudget(ctx); total > 0 {
+		cycle := budget.New(s.clock, total)
+		cycle.SetRetries(s.cycleRetryBudget())
+		ctx = budget.WithBudget(ctx, cycle)
+		defer s.recordBudget(cycle)
+	}
+
 	return s.perfMonitor.TimedOperation("connectivity_check", func() error {
 		s.logger.Debug("Performing connectivity check using tiered testing strategy")
 
@@ -224,14 +1003,18 @@ func (s *Service) performCheck(ctx context.Context) error {
 		}
 
 		// Store the result for next iteration
+		testResult.CycleID = s.cycleID
 		s.lastTestResult = testResult
+		s.recordHealth(testResult)
+		s.recordTargetLatencies(testResult)
+		s.recordCheck(testResult)
 
 		// Log test summary
-		summary := testResult.GetTestSummary()
-		s.logger.WithFields(logrus.Fields(summary)).Info("Connectivity test completed")
+		s.logger.WithFields(testResult.GetTestSummary().LogFields()).Info("Connectivity test completed")
 
 		// Update failure counter based on results
 		if testResult.OverallSuccess {
+			s.flushNotifications(ctx)
 			if s.failureCount > 0 {
 				s.logger.WithField("previous_failures", s.failureCount).Info("Connectivity restored, resetting failure counter")
 
@@ -241,16 +1024,27 @@ func (s *Service) performCheck(ctx context.Context) error {
 						if err := s.outageTracker.RecordOutageEnd(); err != nil {
 							s.logger.WithError(err).Error("Failed to record outage end")
 						}
+
+						currentOutage.Duration = s.clock.Now().Sub(currentOutage.StartTime)
+						s.notifier.Send(ctx, notify.KindOutageResolved, notify.Data{
+							Time:  s.clock.Now(),
+							Event: currentOutage,
+						})
+						s.runRecoveryActions(ctx, currentOutage.Duration)
 					}
 				}
 			}
 			s.failureCount = 0
+			s.notifiedVerdict = ""
 		} else {
 			// Start outage tracking if this is the first failure
 			if s.failureCount == 0 && s.outageTracker != nil {
 				outageDetails := map[string]interface{}{
 					"test_strategy": testResult.Strategy,
 				}
+				if s.cycleID != "" {
+					outageDetails[trace.Field] = s.cycleID
+				}
 
 				// Add failure details based on available results
 				if testResult.LightweightResult != nil {
@@ -263,6 +1057,12 @@ func (s *Service) performCheck(ctx context.Context) error {
 				if err := s.outageTracker.RecordOutageStart("connectivity_failure", outageDetails); err != nil {
 					s.logger.WithError(err).Error("Failed to record outage start")
 				}
+
+				startedData := notify.Data{Time: s.clock.Now(), Fields: outageDetails}
+				if currentOutage := s.outageTracker.GetCurrentOutage(); currentOutage != nil {
+					startedData.Event = currentOutage
+				}
+				s.notifier.Send(ctx, notify.KindOutageStarted, startedData)
 			}
 
 			s.failureCount++
@@ -273,38 +1073,104 @@ func (s *Service) performCheck(ctx context.Context) error {
 			}).Warn("Connectivity test failed")
 
 			// Check if we should trigger a reboot
-			if s.failureCount >= s.config.FailureThreshold {
+			if pause := s.ActivePause(ctx); pause != nil && s.failureCount >= s.config.FailureThreshold {
+				reason := fmt.Sprintf("automatic reboots paused until %s", pause.Until.Format(time.RFC3339))
+				s.logger.WithField("failure_count", s.failureCount).Warn("Failure threshold reached, but " + reason)
+				s.recordDecision(s.newDecision(DecisionSkip, TriggerAutomatic, reason))
+			} else if ok, reason := s.clusterMayReboot(); !ok && s.failureCount >= s.config.FailureThreshold {
+				s.logger.WithField("failure_count", s.failureCount).Warn("Failure threshold reached, but " + reason)
+				s.recordDecision(s.newDecision(DecisionSkip, TriggerAutomatic, reason))
+			} else if host := s.checkHostHealth(ctx); host != nil && host.Degraded() {
+				reason := "monitoring host degraded: " + host.String()
+				s.logger.WithField("failure_count", s.failureCount).Warn("Failure threshold reached, but " + reason)
+				s.recordDecision(s.newDecision(DecisionSkip, TriggerAutomatic, reason))
+			} else if s.failureCount >= s.config.FailureThreshold {
 				s.logger.WithField("failure_count", s.failureCount).Warn("Failure threshold reached, analyzing need for reboot")
 
 				// Perform intelligent reboot decision using diagnostics if enabled
-				shouldReboot, err := s.analyzeRebootNecessity(ctx)
-				if err != nil {
-					s.logger.WithError(err).Warn("Diagnostic analysis failed, proceeding with reboot")
-					shouldReboot = true // Default to reboot on analysis failure
+				shouldReboot, reason := s.analyzeRebootNecessity(ctx)
+				reason = s.attributeOverheating(reason)
+
+				// Where the failure is decides what to do about it
+				located := s.locateFailure(ctx)
+				action := s.config.FailurePolicy().Action(located.Verdict)
+				s.logger.WithFields(logrus.Fields{
+					"verdict": located.Verdict,
+					"action":  action,
+				}).Info("Located connectivity failure: " + located.Reason)
+				if action != verdict.ActionReboot {
+					shouldReboot, reason = false, located.String()
+				} else if located.Verdict != verdict.WAN {
+					// The policy reboots for a failure the diagnostics do not
+					// blame the modem for
+					shouldReboot, reason = true, located.String()
 				}
 
+				// A custom decision policy has the last word
+				var policy string
+				if answer, ok := s.consultPolicy(ctx, shouldReboot, reason, located, action); ok {
+					action, reason = answer.Action, answer.Reason
+					shouldReboot = action == verdict.ActionReboot
+					policy = s.opts.Policy.Name()
+				}
+
+				// Other agents still online mean the problem is this host's
+				var confirmation *quorum.Result
 				if shouldReboot {
-					s.logger.Info("Diagnostic analysis recommends reboot, triggering modem reboot")
-					if err := s.triggerReboot(ctx); err != nil {
-						s.logger.WithError(err).Error("Failed to reboot modem")
-						return fmt.Errorf("modem reboot failed: %w", err)
-					}
+					confirmation = s.confirmQuorum(ctx)
+				}
 
-					// Reset failure counter after reboot
-					s.failureCount = 0
-					s.totalReboots++
-					s.lastReboot = time.Now()
-
-					// Wait for recovery period
-					s.logger.WithField("recovery_wait", s.config.RecoveryWait).Info("Waiting for modem recovery")
-					select {
-					case <-ctx.Done():
-						return fmt.Errorf("context cancelled during recovery wait: %w", ctx.Err())
-					case <-time.After(s.config.RecoveryWait):
-						s.logger.Debug("Recovery wait period completed")
+				if confirmation != nil && !confirmation.Reached() {
+					reason = "reboot quorum not reached: " + confirmation.String()
+					s.logger.WithField("failure_count", s.failureCount).Warn("Failure threshold reached, but " + reason)
+					decision := s.newDecision(DecisionSkip, TriggerAutomatic, reason)
+					decision.Verdict = located.Verdict
+					decision.Quorum = confirmation
+					decision.Policy = policy
+					s.recordDecision(decision)
+				} else if shouldReboot {
+					s.logger.WithField("reason", reason).Info("Diagnostic analysis recommends reboot, triggering modem reboot")
+					decision := s.newDecision(DecisionReboot, TriggerAutomatic, reason)
+					decision.Verdict = located.Verdict
+					decision.Quorum = confirmation
+					decision.Policy = policy
+					rebootData := notify.Data{
+						Time:   s.clock.Now(),
+						Fields: map[string]interface{}{"failure_count": s.failureCount},
+					}
+					if s.lastAnalysis != nil {
+						rebootData.Diagnostics = s.lastAnalysis
 					}
+					s.notifier.Send(ctx, notify.KindRebootTriggered, rebootData)
+
+					// The reboot and recovery wait outlive the check timeout
+					return s.runRebootWorkflow(TriggerAutomatic, reason, true, func(ctx context.Context) error {
+						if err := s.triggerReboot(ctx); err != nil {
+							s.logger.WithError(err).Error("Failed to reboot modem")
+							rebootData.Fields["error"] = err.Error()
+							s.notifier.Send(ctx, notify.KindRebootFailed, rebootData)
+							decision.Error = err.Error()
+							s.recordDecision(decision)
+							return fmt.Errorf("modem reboot failed: %w", err)
+						}
+						s.recordDecision(decision)
+
+						// Reset failure counter after reboot
+						s.failureCount = 0
+						s.countReboot(RebootThreshold)
+						s.lastReboot = s.clock.Now()
+						s.publishState()
+						return nil
+					})
 				} else {
-					s.logger.Info("Diagnostic analysis suggests reboot may not help, continuing monitoring")
+					s.logger.WithField("reason", reason).Info("Reboot may not help, continuing monitoring")
+					decision := s.newDecision(DecisionSkip, TriggerAutomatic, reason)
+					decision.Verdict = located.Verdict
+					decision.Policy = policy
+					s.recordDecision(decision)
+					if action == verdict.ActionNotify {
+						s.notifyFailure(ctx, located)
+					}
 					// Don't reset failure counter, but don't reboot yet
 				}
 			}
@@ -314,6 +1180,250 @@ func (s *Service) performCheck(ctx context.Context) error {
 	})
 }
 
+// observePublicIP looks up the public IP when one of actions tracks it
+func (s *Service) observePublicIP(ctx context.Context, actions []RecoveryAction) {
+	defer s.recoverPanic("public_ip")
+	for _, action := range actions {
+		if watcher, ok := action.(*publicip.Watcher); ok {
+			if _, _, err := watcher.Check(ctx); err != nil && ctx.Err() == nil {
+				s.logger.WithError(err).Warn("Failed to look up public IP")
+			}
+		}
+	}
+}
+
+// recordPublicIP records the public IP every PublicIPRecordCycles checks. The
+// lookup runs in the background, so it never delays the check.
+func (s *Service) recordPublicIP(ctx context.Context) {
+	every := s.config.PublicIPRecordCycles
+	if every <= 0 || s.totalChecks%every != 0 {
+		return
+	}
+	for _, action := range s.recovery {
+		if watcher, ok := action.(*publicip.Watcher); ok {
+			go func() {
+				defer s.recoverPanic("public_ip")
+				if err := watcher.Record(ctx); err != nil && ctx.Err() == nil {
+					s.logger.WithError(err).Debug("Failed to record public IP")
+				}
+			}()
+		}
+	}
+}
+
+// newModemTunnel creates the tunnel to the modem, or returns nil when the
+// modem is reached directly
+func newModemTunnel(cfg *config.Config, logger *logrus.Logger) *tunnel.Tunnel {
+	tunnelCfg := cfg.ModemTunnel()
+	if !tunnelCfg.Enabled() {
+		return nil
+	}
+	modemTunnel, err := tunnel.New(tunnelCfg)
+	if err != nil {
+		logger.WithError(err).Warn("Invalid modem tunnel, reaching the modem directly")
+		return nil
+	}
+	return modemTunnel
+}
+
+// setTunnel makes the service reach the modem through modemTunnel, nil for
+// directly, and forgets the health of the previous tunnel
+func (s *Service) setTunnel(modemTunnel *tunnel.Tunnel) {
+	s.tunnel = modemTunnel
+	s.tunnelProber = nil
+	s.tunnelHealth = nil
+	if modemTunnel == nil {
+		return
+	}
+	s.tunnelProber = modem.NewProber(modem.Options{
+		Host:      s.config.ModemHost,
+		Port:      s.config.ModemPort,
+		NoVerify:  s.config.ModemNoVerify,
+		Transport: modemTunnel.Transport(s.config.ModemNoVerify),
+	})
+}
+
+// checkTunnel checks that the modem answers through the tunnel, if there is
+// one, and logs when the tunnel goes down or comes back. A tunnel that is
+// down does not fail the check, but it leaves the watchdog unable to reboot
+// the modem.
+func (s *Service) checkTunnel(ctx context.Context) {
+	if s.tunnel == nil {
+		return
+	}
+	probe := func(ctx context.Context) error {
+		_, err := s.tunnelProber.Probe(ctx)
+		return err
+	}
+	health := s.tunnel.Check(ctx, s.clock.Now(), probe)
+
+	fields := logrus.Fields{
+		"tunnel":   health.Kind,
+		"endpoint": health.Endpoint,
+	}
+	switch {
+	case !health.Healthy && (s.tunnelHealth == nil || s.tunnelHealth.Healthy):
+		fields["error"] = health.Error
+		s.logger.WithFields(fields).Warn("Modem tunnel is down, the modem cannot be rebooted")
+	case health.Healthy && s.tunnelHealth != nil && !s.tunnelHealth.Healthy:
+		fields["latency_ms"] = health.LatencyMS
+		s.logger.WithFields(fields).Info("Modem tunnel is back up")
+	default:
+		fields["healthy"] = health.Healthy
+		fields["latency_ms"] = health.LatencyMS
+		s.logger.WithFields(fields).Debug("Checked modem tunnel")
+	}
+	s.tunnelHealth = &health
+}
+
+// flushNotifications delivers the notifications queued while they could not
+// be delivered, once a check passes
+func (s *Service) flushNotifications(ctx context.Context) {
+	if s.notifier.Pending() == 0 {
+		return
+	}
+	delivered, err := s.notifier.Flush(ctx)
+	fields := logrus.Fields{"delivered": delivered, "pending": s.notifier.Pending()}
+	if err != nil {
+		s.logger.WithError(err).WithFields(fields).Warn("Failed to deliver queued notifications")
+		return
+	}
+	s.logger.WithFields(fields).Info("Delivered queued notifications")
+}
+
+// runRecoveryActions runs every recovery action after an outage of downtime.
+// Failures are logged; they do not fail the check.
+func (s *Service) runRecoveryActions(ctx context.Context, downtime time.Duration) {
+	for _, action := range s.recovery {
+		if err := action.Recover(ctx, downtime); err != nil {
+			s.logger.WithError(err).WithField("action", action.Name()).Warn("Recovery action failed")
+		}
+	}
+}
+
+// cycleBudget returns the time a check cycle may spend testing and
+// diagnosing: CycleBudget, by default the check interval, but no more than
+// is left before the deadline of ctx, so the last stages end, and report
+// their overruns, before the cycle is cut short
+func (s *Service) cycleBudget(ctx context.Context) time.Duration {
+	total := s.config.CheckInterval
+	if s.config.CycleBudget > 0 {
+		total = s.config.CycleBudget
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := deadline.Sub(s.clock.Now()); remaining < total {
+			total = remaining
+		}
+	}
+	return total
+}
+
+// recordHealth scores result and logs when the health status changes
+func (s *Service) recordHealth(result *connectivity.TieredTestResult) {
+	inputs := result.HealthInputs()
+	if rate, ok := s.timeoutsRate(); ok {
+		inputs[health.InputDOCSISTimeouts] = rate
+	}
+	score := s.health.Score(inputs)
+	status := s.partialHealth(s.health.Status(score))
+	if status != s.healthStatus {
+		fields := logrus.Fields{"health": status, "health_score": score, "previous": s.healthStatus}
+		if status == health.Healthy {
+			s.logger.WithFields(fields).Info("Connection health changed")
+		} else {
+			s.logger.WithFields(fields).Warn("Connection health changed")
+		}
+	}
+	s.healthStatus = status
+	s.healthScore = score
+}
+
+// recordTargetLatencies records the latency of every checked target, and of
+// each phase of the HTTP checks, in the performance metrics
+func (s *Service) recordTargetLatencies(result *connectivity.TieredTestResult) {
+	for _, test := range result.Results() {
+		if test.Target == "" || test.CircuitOpen || test.Suppressed {
+			continue
+		}
+		s.perfMonitor.RecordTargetLatency(test.TestType+" "+test.Target, test.Duration, test.Success)
+		for phase, d := range test.Phases {
+			s.perfMonitor.RecordTargetLatency(test.TestType+"_"+phase+" "+test.Target, d, test.Success)
+		}
+	}
+}
+
+// cycleRetryBudget returns the retries a check cycle may spend
+func (s *Service) cycleRetryBudget() int {
+	if s.config.CycleRetryBudget > 0 {
+		return s.config.CycleRetryBudget
+	}
+	return config.DefaultCycleRetryBudget
+}
+
+// recordBudget reports the stages that overran their slice of the cycle
+// budget and retries refused once the cycle spent its retry budget
+func (s *Service) recordBudget(cycle *budget.Budget) {
+	if used, denied := cycle.Retries(); denied > 0 {
+		s.retriesDenied += denied
+		s.logger.WithFields(logrus.Fields{
+			"retries_used":   used,
+			"retries_denied": denied,
+			"retry_budget":   s.cycleRetryBudget(),
+		}).Warn("Check cycle spent its retry budget")
+	}
+
+	for _, overrun := range cycle.Overruns() {
+		s.budgetOverruns++
+		s.logger.WithFields(logrus.Fields{
+			"stage":     overrun.Stage,
+			"allocated": overrun.Allocated,
+			"used":      overrun.Used,
+			"budget":    cycle.Total(),
+		}).Warn("Check stage overran its time budget")
+	}
+}
+
+// Reboot reboots the modem on request, e.g. from the API, regardless of the
+// connectivity state. It waits for a check in progress to finish and is
+// counted like an automatic reboot.
+func (s *Service) Reboot(ctx context.Context, reason string) error {
+	s.cycleMu.Lock()
+	defer s.cycleMu.Unlock()
+	defer s.publishState()
+
+	s.logger.WithField("reason", reason).Warn("Manual modem reboot requested")
+	return s.runRebootWorkflow(TriggerManual, reason, false, func(ctx context.Context) error {
+		return s.reboot(ctx, TriggerManual, RebootManual, reason)
+	})
+}
+
+// reboot reboots the modem outside the check cycle, notifying, recording the
+// decision and counting the reboot under rebootReason; the caller holds
+// cycleMu
+func (s *Service) reboot(ctx context.Context, trigger, rebootReason, reason string) error {
+	rebootData := notify.Data{
+		Time:   s.clock.Now(),
+		Fields: map[string]interface{}{"reason": reason, "trigger": trigger},
+	}
+	s.notifier.Send(ctx, notify.KindRebootTriggered, rebootData)
+	decision := s.newDecision(DecisionReboot, trigger, reason)
+
+	if err := s.triggerReboot(ctx); err != nil {
+		s.logger.WithError(err).Error("Failed to reboot modem")
+		rebootData.Fields["error"] = err.Error()
+		s.notifier.Send(ctx, notify.KindRebootFailed, rebootData)
+		decision.Error = err.Error()
+		s.recordDecision(decision)
+		return fmt.Errorf("modem reboot failed: %w", err)
+	}
+	s.recordDecision(decision)
+
+	s.failureCount = 0
+	s.countReboot(rebootReason)
+	s.lastReboot = s.clock.Now()
+	return nil
+}
+
 // triggerReboot initiates a modem reboot with cycle monitoring
 func (s *Service) triggerReboot(ctx context.Context) error {
 	if s == nil {
@@ -322,8 +1432,8 @@ func (s *Service) triggerReboot(ctx context.Context) error {
 	if ctx == nil {
 		return fmt.Errorf("context is nil")
 	}
-	if s.hnapClient == nil {
-		return fmt.Errorf("HNAP client is not initialized")
+	if s.modemDriver == nil {
+		return fmt.Errorf("modem driver is not initialized")
 	}
 	if s.perfMonitor == nil {
 		return fmt.Errorf("performance monitor is not initialized")
@@ -335,14 +1445,11 @@ func (s *Service) triggerReboot(ctx context.Context) error {
 	return s.perfMonitor.TimedOperation("modem_reboot", func() error {
 		s.logger.Info("Initiating modem reboot with cycle monitoring")
 
-		// Create fresh context for modem operations (not inheriting monitoring timeouts)
-		rebootCtx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-		defer cancel()
-
 		// Use reboot with monitoring if available, otherwise fall back to basic reboot
 		if s.config.EnableRebootMonitoring {
-			result, err := s.hnapClient.RebootWithMonitoring(
-				rebootCtx,
+			result, err := modem.RebootWithMonitoring(
+				ctx,
+				s.modemDriver,
 				s.config.RebootPollInterval,
 				s.config.RebootOfflineTimeout,
 				s.config.RebootOnlineTimeout,
@@ -375,7 +1482,7 @@ func (s *Service) triggerReboot(ctx context.Context) error {
 			return nil
 		} else {
 			// Fall back to basic reboot without monitoring
-			if err := s.hnapClient.Reboot(rebootCtx); err != nil {
+			if err := s.modemDriver.Reboot(ctx); err != nil {
 				return fmt.Errorf("modem reboot failed: %w", err)
 			}
 
@@ -385,19 +1492,125 @@ func (s *Service) triggerReboot(ctx context.Context) error {
 	})
 }
 
-// analyzeRebootNecessity performs diagnostic analysis to determine if reboot is necessary
-func (s *Service) analyzeRebootNecessity(ctx context.Context) (bool, error) {
-	return s.perfMonitor.TimedOperation("diagnostic_analysis", func() error {
-		// If diagnostics are disabled, always recommend reboot
+// refreshModemStatus fetches the modem status for reporting; failures are not fatal
+func (s *Service) refreshModemStatus(ctx context.Context) {
+	if s.modemDriver == nil {
+		return
+	}
+
+	statusCtx, cancel := context.WithTimeout(ctx, s.config.ConnectionTimeout)
+	defer cancel()
+
+	status, err := s.modemDriver.GetStatus(statusCtx)
+	if err != nil {
+		s.logger.WithError(err).Debug("Failed to fetch modem status")
+		return
+	}
+	status = s.countTimeouts(ctx, status)
+
+	if s.modemStatus == nil || s.modemStatus.Mode != status.Mode {
+		s.logger.WithFields(logrus.Fields{
+			"modem_type":     s.modemDriver.Name(),
+			"modem_model":    status.Model,
+			"modem_mode":     status.Mode,
+			"modem_firmware": status.FirmwareVersion,
+		}).Info("Detected modem status")
+	}
+
+	// A status page without the firmware version keeps the last known one
+	previous := ""
+	if s.modemStatus != nil {
+		previous = s.modemStatus.FirmwareVersion
+	}
+	if status.FirmwareVersion == "" && previous != "" {
+		updated := *status
+		updated.FirmwareVersion = previous
+		status = &updated
+	} else if previous != "" && status.FirmwareVersion != previous {
+		s.firmwareChanged(ctx, status.Model, previous, status.FirmwareVersion)
+	}
+	s.modemStatus = status
+	s.checkPartialService(ctx, status)
+	s.checkTemperature(ctx, status)
+}
+
+// firmwareChanged alerts that the modem firmware changed from old to new
+// and records the change in the history. ISPs push firmware without notice,
+// and new firmware often changes the pages the reboot relies on.
+func (s *Service) firmwareChanged(ctx context.Context, model, old, new string) {
+	s.logger.WithFields(logrus.Fields{
+		"modem_model": model,
+		"old_version": old,
+		"new_version": new,
+	}).Warn("Modem firmware changed")
+
+	fields := map[string]interface{}{"model": model, "old_version": old, "new_version": new}
+	s.notifier.Send(ctx, notify.KindFirmwareChanged, notify.Data{Time: s.clock.Now(), Fields: fields})
+
+	if !s.historyEnabled() {
+		return
+	}
+	if s.cycleID != "" {
+		fields[trace.Field] = s.cycleID
+	}
+	event := history.Event{Time: s.clock.Now(), Kind: history.KindFirmwareChange, Details: fields}
+	if err := history.Append(s.config.HistoryPath(), event); err != nil {
+		s.logger.WithError(err).Warn("Failed to record firmware change")
+	}
+}
+
+// diagnosticsAvailable reports whether the diagnostics subsystem is built in
+// and not switched off with DISABLED_FEATURES
+func (s *Service) diagnosticsAvailable() bool {
+	return features.Diagnostics && s.analyzer != nil && s.config.FeatureEnabled(features.NameDiagnostics)
+}
+
+// analyzeRebootNecessity performs diagnostic analysis to determine if reboot
+// is necessary, and returns the reason for the decision
+func (s *Service) analyzeRebootNecessity(ctx context.Context) (bool, string) {
+	s.lastAnalysis = nil
+	s.failureCause = ""
+
+	reason := "failure threshold reached and diagnostics recommend a reboot"
+	err := s.perfMonitor.TimedOperation("diagnostic_analysis", func() error {
+		// Without the diagnostics subsystem nothing is checked
+		if !s.diagnosticsAvailable() {
+			s.logger.Debug("Diagnostics subsystem disabled, defaulting to reboot")
+			reason = "failure threshold reached with diagnostics disabled"
+			return nil
+		}
+
+		// Low-power mode saves the power the diagnostics would draw
+		if s.lowPower {
+			s.logger.Debug("Low-power mode, skipping diagnostics and defaulting to reboot")
+			reason = "failure threshold reached in low-power mode"
+			return nil
+		}
+
+		// If diagnostics are disabled, recommend a reboot unless the host's
+		// own Wi-Fi is to blame
 		if !s.config.EnableDiagnostics {
+			if result, degraded := s.analyzer.WirelessLinkDegraded(ctx); degraded {
+				s.logger.WithError(result.Error).Warn("Wi-Fi link of the watchdog host is degraded, not rebooting the modem")
+				s.failureCause = diagnostics.CauseLocalWiFi
+				if s.outageTracker != nil {
+					if err := s.outageTracker.SetCause(diagnostics.CauseLocalWiFi); err != nil {
+						s.logger.WithError(err).Warn("Failed to record outage cause")
+					}
+				}
+				return fmt.Errorf("local Wi-Fi link degraded")
+			}
 			s.logger.Debug("Diagnostics disabled, defaulting to reboot")
+			reason = "failure threshold reached with diagnostics disabled"
 			return nil
 		}
 
 		s.logger.Info("Running network diagnostics to analyze reboot necessity")
 
-		// Create context with diagnostics timeout
-		diagCtx, cancel := context.WithTimeout(ctx, s.config.DiagnosticsTimeout)
+		// Create context with diagnostics timeout within the cycle budget
+		stageCtx, endStage := budget.Stage(ctx, budget.StageDiagnostics)
+		defer endStage()
+		diagCtx, cancel := context.WithTimeout(stageCtx, s.config.DiagnosticsTimeout)
 		defer cancel()
 
 		// Run comprehensive network diagnostics
@@ -412,10 +1625,22 @@ func (s *Service) analyzeRebootNecessity(ctx context.Context) (bool, error) {
 
 		// Get detailed analysis for logging
 		analysis := s.analyzer.PerformDetailedAnalysis(diagnosticResults)
+		analysis.CycleID = s.cycleID
+		s.lastAnalysis = &analysis
+		s.diagnostics = summarizeAnalysis(analysis, s.clock.Now())
+
+		// Diagnostics tell local Wi-Fi, a dead line and ISP routing incidents apart
+		s.failureCause = analysis.Cause
+		if analysis.Cause != "" && s.outageTracker != nil {
+			if err := s.outageTracker.SetCause(analysis.Cause); err != nil {
+				s.logger.WithError(err).Warn("Failed to record outage cause")
+			}
+		}
 
 		// Log diagnostic analysis results
 		s.logger.WithFields(logrus.Fields{
 			"overall_success_rate": analysis.OverallSuccessRate,
+			"health_score":         analysis.HealthScore,
 			"total_tests":          analysis.TotalTests,
 			"successful_tests":     analysis.SuccessfulTests,
 			"should_reboot":        analysis.ShouldReboot,
@@ -446,7 +1671,11 @@ func (s *Service) analyzeRebootNecessity(ctx context.Context) (bool, error) {
 			return fmt.Errorf("diagnostics suggest reboot not necessary")
 		}
 		return nil
-	}) == nil, nil
+	})
+	if err != nil {
+		return false, err.Error()
+	}
+	return true, reason
 }
 
 // performCheckWithRecovery wraps performCheck with additional error recovery mechanisms
@@ -469,7 +1698,7 @@ func (s *Service) performCheckWithRecovery(ctx context.Context) error {
 
 		if s.isAuthenticationError(err) {
 			s.logger.Warn("Authentication error detected, clearing cached credentials")
-			// The HNAP client will re-authenticate on next request
+			// The modem driver will re-authenticate on next request
 			return err
 		}
 
@@ -486,44 +1715,27 @@ func (s *Service) performCheckWithRecovery(ctx context.Context) error {
 	return nil
 }
 
-// isNetworkError checks if an error is network-related
+// isNetworkError checks if an error is network-related: the modem did not
+// answer, or a dial, connection or DNS lookup failed
 func (s *Service) isNetworkError(err error) bool {
-	if err == nil {
-		return false
-	}
-	errStr := strings.ToLower(err.Error())
-	return strings.Contains(errStr, "network") ||
-		strings.Contains(errStr, "connection") ||
-		strings.Contains(errStr, "dial") ||
-		strings.Contains(errStr, "dns")
+	return werrors.IsNetworkError(err)
 }
 
-// isAuthenticationError checks if an error is authentication-related
+// isAuthenticationError checks if an error is authentication-related: the
+// modem refused the login or dropped the session
 func (s *Service) isAuthenticationError(err error) bool {
-	if err == nil {
-		return false
-	}
-	errStr := strings.ToLower(err.Error())
-	return strings.Contains(errStr, "authentication") ||
-		strings.Contains(errStr, "login") ||
-		strings.Contains(errStr, "unauthorized") ||
-		strings.Contains(errStr, "forbidden")
+	return werrors.IsAuthError(err) || errors.Is(err, modem.ErrSessionExpired)
 }
 
-// isTimeoutError checks if an error is timeout-related
+// isTimeoutError checks if an error is timeout-related, including a check
+// cut short by cancellation
 func (s *Service) isTimeoutError(err error) bool {
-	if err == nil {
-		return false
-	}
-	errStr := strings.ToLower(err.Error())
-	return strings.Contains(errStr, "timeout") ||
-		strings.Contains(errStr, "deadline") ||
-		strings.Contains(errStr, "context canceled")
+	return werrors.IsTimeoutError(err) || errors.Is(err, context.Canceled)
 }
 
 // GetCurrentState returns the current state of the monitoring service
 func (s *Service) GetCurrentState() ServiceState {
-	return ServiceState{
+	state := ServiceState{
 		FailureCount: s.failureCount,
 		LastCheck:    s.lastCheck,
 		LastReboot:   s.lastReboot,
@@ -531,7 +1743,86 @@ func (s *Service) GetCurrentState() ServiceState {
 		TotalReboots: s.totalReboots,
 		IsRunning:    s.isRunning,
 		StartTime:    s.startTime,
+
+		BudgetOverruns: s.budgetOverruns,
+		RetriesDenied:  s.retriesDenied,
+		Health:         s.healthStatus,
+		HealthScore:    s.healthScore,
+		SkippedChecks:  s.scheduler.Stats(CheckJobName).Skipped,
+	}
+	if next, ok := s.scheduler.Next(ScheduledRebootJobName); ok {
+		state.NextScheduledReboot = &next
+	}
+	if len(s.rebootsByReason) > 0 {
+		state.RebootsByReason = make(map[string]int, len(s.rebootsByReason))
+		for reason, count := range s.rebootsByReason {
+			state.RebootsByReason[reason] = count
+		}
+	}
+	s.pauseMu.Lock()
+	if s.pause != nil && s.clock.Now().Before(s.pause.Until) {
+		pause := *s.pause
+		state.Pause = &pause
 	}
+	s.pauseMu.Unlock()
+	if s.modemStatus != nil {
+		state.ModemModel = s.modemStatus.Model
+		state.ModemMode = s.modemStatus.Mode
+		state.ModemFirmware = s.modemStatus.FirmwareVersion
+		state.ModemTemperature = s.modemStatus.TemperatureC
+		state.DOCSISTimeouts = s.modemStatus.Timeouts
+	}
+	state.ModemAccess = modem.AccessMethodOf(s.modemDriver)
+	state.Reboot = s.ActiveReboot()
+	state.Diagnostics = s.diagnostics
+	if s.tunnelHealth != nil {
+		tunnelHealth := *s.tunnelHealth
+		state.Tunnel = &tunnelHealth
+	}
+	state.LowPower = s.lowPower
+	if s.partialService != nil {
+		partialService := *s.partialService
+		state.PartialService = &partialService
+	}
+	if s.lastTestResult != nil {
+		summary := s.lastTestResult.GetTestSummary()
+		state.Check = &summary
+	}
+	return state
+}
+
+// publishState stores a copy of the current state for Snapshot
+func (s *Service) publishState() {
+	state := s.GetCurrentState()
+
+	s.snapshotMu.Lock()
+	s.snapshot = state
+	s.snapshotResult = s.lastTestResult
+	s.snapshotAnalysis = s.lastAnalysis
+	s.snapshotModem = s.modemStatus
+	s.snapshotMu.Unlock()
+}
+
+// Snapshot returns the state as of the last completed check. Unlike
+// GetCurrentState it is safe to call from other goroutines, such as the API.
+func (s *Service) Snapshot() ServiceState {
+	s.snapshotMu.RLock()
+	state := s.snapshot
+	s.snapshotMu.RUnlock()
+	state.Reboot = s.ActiveReboot()
+	state.Cluster = s.clusterStatus()
+	return state
+}
+
+// Notifier returns the dispatcher that delivers the service's notifications
+func (s *Service) Notifier() *notify.Dispatcher {
+	return s.notifier
+}
+
+// PerformanceMetrics returns the current performance metrics, including the
+// latency of every checked target
+func (s *Service) PerformanceMetrics() performance.Metrics {
+	return s.perfMonitor.GetCurrentMetrics()
 }
 
 // UpdateConfiguration updates the service configuration (for SIGHUP handling)
@@ -542,36 +1833,112 @@ func (s *Service) UpdateConfiguration(newConfig *config.Config) error {
 	oldConfig := s.config
 	s.config = newConfig
 
-	// Recreate HNAP client if modem settings changed
-	if oldConfig.ModemHost != newConfig.ModemHost ||
+	// Recreate modem driver if modem settings changed
+	tunnelChanged := oldConfig.ModemTunnel() != newConfig.ModemTunnel()
+	if oldConfig.ModemType != newConfig.ModemType ||
+		oldConfig.ModemHost != newConfig.ModemHost ||
+		oldConfig.ModemPort != newConfig.ModemPort ||
 		oldConfig.ModemUsername != newConfig.ModemUsername ||
 		oldConfig.ModemPassword != newConfig.ModemPassword ||
-		oldConfig.ModemNoVerify != newConfig.ModemNoVerify {
-
-		s.logger.Info("Modem configuration changed, recreating HNAP client")
-		s.hnapClient = hnap.NewClient(
-			newConfig.ModemHost,
-			newConfig.ModemUsername,
-			newConfig.ModemPassword,
-			newConfig.ModemNoVerify,
-			s.logger,
-		)
+		oldConfig.ModemNoVerify != newConfig.ModemNoVerify ||
+		tunnelChanged {
+
+		s.logger.Info("Modem configuration changed, recreating modem driver")
+		s.setTunnel(newModemTunnel(newConfig, s.logger))
+		access := modem.AccessMethodOf(s.modemDriver)
+		s.modemDriver = newModemDriver(newConfig, s.tunnel, s.opts.moduleLogger("modem", s.logger))
+		if s.opts.Faults != nil {
+			s.modemDriver = s.opts.Faults.WrapDriver(s.modemDriver)
+		}
+		// The same modem is reached the same way with other credentials
+		if access != "" && oldConfig.ModemType == newConfig.ModemType &&
+			oldConfig.ModemHost == newConfig.ModemHost && oldConfig.ModemPort == newConfig.ModemPort {
+			modem.SetAccessMethod(s.modemDriver, access)
+		}
 	}
 
-	// Update tester configuration if connectivity settings changed
-	if !stringSlicesEqual(oldConfig.PingHosts, newConfig.PingHosts) ||
+	// Update tester configuration if connectivity settings changed; an
+	// injected checker is kept as is
+	if _, ok := s.tester.(*connectivity.Tester); ok && !stringSlicesEqual(oldConfig.PingHosts, newConfig.PingHosts) ||
 		!stringSlicesEqual(oldConfig.HTTPHosts, newConfig.HTTPHosts) ||
+		oldConfig.Responder != newConfig.Responder || oldConfig.ResponderURL != newConfig.ResponderURL ||
+		oldConfig.ResponderKey != newConfig.ResponderKey ||
 		oldConfig.ConnectionTimeout != newConfig.ConnectionTimeout ||
-		oldConfig.HTTPTimeout != newConfig.HTTPTimeout {
+		oldConfig.HTTPTimeout != newConfig.HTTPTimeout ||
+		!stringSlicesEqual(oldConfig.CheckDependencies, newConfig.CheckDependencies) ||
+		oldConfig.GatewayTarget != newConfig.GatewayTarget ||
+		oldConfig.TargetRotation() != newConfig.TargetRotation() {
 
 		s.logger.Info("Connectivity test configuration changed, recreating tester")
-		s.tester = connectivity.NewTesterWithConfig(
-			s.logger,
-			newConfig.ConnectionTimeout,
-			newConfig.HTTPTimeout,
-			newConfig.PingHosts,
-			newConfig.HTTPHosts,
-		)
+		s.tester = newTester(newConfig, s.opts.moduleLogger("connectivity", s.logger), s.opts)
+	}
+	if (!stringSlicesEqual(oldConfig.PingHosts, newConfig.PingHosts) || !stringSlicesEqual(oldConfig.HTTPHosts, newConfig.HTTPHosts)) && s.isRunning {
+		go s.warnLocalTargets(context.Background(), newConfig)
+	}
+
+	if s.opts.RecoveryActions == nil {
+		s.recovery = newRecoveryActions(newConfig, s.logger, s.opts)
+	}
+	s.health = newConfig.HealthModel()
+	if oldConfig.ScheduledReboot != newConfig.ScheduledReboot && s.isRunning {
+		s.scheduler.Remove(ScheduledRebootJobName)
+		if err := s.scheduleReboot(); err != nil {
+			s.logger.WithError(err).Error("Failed to reschedule preventive reboot")
+		}
+	}
+	if (oldConfig.Beacon() != newConfig.Beacon() || oldConfig.BeaconInterval != newConfig.BeaconInterval) && s.isRunning {
+		s.scheduler.Remove(BeaconJobName)
+		if err := s.scheduleBeacon(); err != nil {
+			s.logger.WithError(err).Error("Failed to reschedule status beacon")
+		}
+	}
+	if oldConfig.ModemStatsInterval != newConfig.ModemStatsInterval && s.isRunning {
+		s.scheduler.Remove(ModemStatsJobName)
+		if err := s.scheduleModemStats(); err != nil {
+			s.logger.WithError(err).Error("Failed to reschedule modem statistics")
+		}
+	}
+	if (oldConfig.TextfilePath != newConfig.TextfilePath || oldConfig.TextfileInterval != newConfig.TextfileInterval) && s.isRunning {
+		s.scheduler.Remove(TextfileJobName)
+		if err := s.scheduleTextfile(); err != nil {
+			s.logger.WithError(err).Error("Failed to reschedule metrics textfile")
+		}
+	}
+	if oldConfig.FeatureEnabled(features.NameHistory) != newConfig.FeatureEnabled(features.NameHistory) && s.isRunning {
+		s.scheduler.Remove(HistoryMaintenanceJobName)
+		if err := s.scheduleHistoryMaintenance(); err != nil {
+			s.logger.WithError(err).Error("Failed to reschedule history maintenance")
+		}
+	}
+	if (oldConfig.BackupInterval != newConfig.BackupInterval || oldConfig.Backup() != newConfig.Backup()) && s.isRunning {
+		s.scheduler.Remove(BackupJobName)
+		if err := s.scheduleBackup(); err != nil {
+			s.logger.WithError(err).Error("Failed to reschedule backups")
+		}
+	}
+	if (oldConfig.SyncInterval != newConfig.SyncInterval || oldConfig.Sync() != newConfig.Sync() || oldConfig.SyncSite != newConfig.SyncSite) && s.isRunning {
+		s.scheduler.Remove(SyncJobName)
+		if err := s.scheduleSync(); err != nil {
+			s.logger.WithError(err).Error("Failed to reschedule remote sync")
+		}
+	}
+	if oldConfig.Cluster() != newConfig.Cluster() && s.isRunning {
+		s.scheduler.Remove(ClusterHeartbeatJobName)
+		if err := s.scheduleClusterHeartbeat(); err != nil {
+			s.logger.WithError(err).Error("Failed to rejoin the cluster")
+		}
+	}
+	if features.Diagnostics && s.analyzer != nil {
+		s.analyzer.SetRoutingTargets(routingTargets(newConfig))
+		s.analyzer.SetTargets(diagnosticTargets(newConfig))
+		s.analyzer.SetHealthModel(s.health)
+	}
+	if tester, ok := s.tester.(*connectivity.Tester); ok {
+		tester.SetHealthModel(s.health)
+		tester.SetEscalationPolicy(newConfig.EscalationPolicy())
+		if oldConfig.WireGuardCheck() != newConfig.WireGuardCheck() {
+			tester.SetWireGuard(newConfig.WireGuardCheck())
+		}
 	}
 
 	s.logger.Info("Monitoring service configuration updated successfully")
@@ -630,6 +1997,38 @@ func (s *Service) LoadPersistedState(stateFile string) error {
 			if count, err := strconv.Atoi(value); err == nil {
 				s.totalReboots = count
 			}
+		case "diagnostics":
+			var summary DiagnosticsSummary
+			if err := json.Unmarshal([]byte(value), &summary); err != nil {
+				s.logger.WithError(err).Warn("Ignoring persisted diagnostics summary")
+			} else {
+				s.diagnostics = &summary
+			}
+		case "modem_access":
+			if err := modem.SetAccessMethod(s.modemDriver, value); err != nil {
+				s.logger.WithError(err).Warn("Ignoring persisted modem access method")
+			}
+		case "modem_mode", "modem_model", "modem_firmware":
+			if s.modemStatus == nil {
+				s.modemStatus = &modem.Status{}
+			}
+			switch key {
+			case "modem_mode":
+				s.modemStatus.Mode = value
+			case "modem_model":
+				s.modemStatus.Model = value
+			default:
+				s.modemStatus.FirmwareVersion = value
+			}
+		default:
+			if reason := strings.TrimPrefix(key, RebootStatePrefix); reason != key {
+				if count, err := strconv.Atoi(value); err == nil {
+					if s.rebootsByReason == nil {
+						s.rebootsByReason = make(map[string]int, len(RebootReasons))
+					}
+					s.rebootsByReason[reason] = count
+				}
+			}
 		}
 	}
 
@@ -645,6 +2044,7 @@ func (s *Service) LoadPersistedState(stateFile string) error {
 		"last_reboot":   s.lastReboot,
 	}).Info("Loaded persisted state")
 
+	s.publishState()
 	return nil
 }
 
@@ -1,15 +1,40 @@
 package monitor
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/perezjoseph/mb8600-watchdog/internal/backup"
+	"github.com/perezjoseph/mb8600-watchdog/internal/beacon"
+	"github.com/perezjoseph/mb8600-watchdog/internal/budget"
+	"github.com/perezjoseph/mb8600-watchdog/internal/chaos"
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/cluster"
 	"github.com/perezjoseph/mb8600-watchdog/internal/config"
 	"github.com/perezjoseph/mb8600-watchdog/internal/connectivity"
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+	"github.com/perezjoseph/mb8600-watchdog/internal/health"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/hosthealth"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/perezjoseph/mb8600-watchdog/internal/power"
+	"github.com/perezjoseph/mb8600-watchdog/internal/verdict"
 	"github.com/sirupsen/logrus"
 )
 
@@ -18,6 +43,7 @@ func TestNewService(t *testing.T) {
 	logger.SetLevel(logrus.ErrorLevel) // Reduce noise during tests
 
 	cfg := &config.Config{
+		WorkingDirectory:   t.TempDir(),
 		ModemHost:          config.DefaultModemHost,
 		ModemUsername:      "admin",
 		ModemPassword:      "motorola",
@@ -70,6 +96,7 @@ func TestFailureCounterLogic(t *testing.T) {
 			}
 
 			cfg := &config.Config{
+				WorkingDirectory:   t.TempDir(),
 				FailureThreshold:   200, // High threshold to avoid reboot during test
 				EnableDiagnostics:  false,
 				ModemHost:          config.DefaultModemHost,
@@ -157,6 +184,7 @@ func TestThresholdBasedRebootTriggering(t *testing.T) {
 			}
 
 			cfg := &config.Config{
+				WorkingDirectory:   t.TempDir(),
 				FailureThreshold:   threshold,
 				RecoveryWait:       1 * time.Millisecond, // Minimal wait for testing
 				EnableDiagnostics:  false,                // Disable to ensure reboot happens
@@ -233,6 +261,7 @@ func TestConnectivityRestorationCounterReset(t *testing.T) {
 			}
 
 			cfg := &config.Config{
+				WorkingDirectory:   t.TempDir(),
 				FailureThreshold:   100, // High threshold to prevent reboot
 				EnableDiagnostics:  false,
 				ModemHost:          config.DefaultModemHost,
@@ -321,6 +350,7 @@ func TestFailureThresholdScenarios(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cfg := &config.Config{
+				WorkingDirectory:   t.TempDir(),
 				FailureThreshold:   tt.threshold,
 				EnableDiagnostics:  false,
 				ModemHost:          config.DefaultModemHost,
@@ -370,6 +400,7 @@ func TestGracefulDegradation(t *testing.T) {
 	logger.SetLevel(logrus.ErrorLevel)
 
 	cfg := &config.Config{
+		WorkingDirectory:   t.TempDir(),
 		FailureThreshold:   5,
 		EnableDiagnostics:  false,
 		ModemHost:          config.DefaultModemHost,
@@ -388,10 +419,11 @@ func TestGracefulDegradation(t *testing.T) {
 	service := NewService(cfg, logger)
 
 	// Test error classification
-	networkErr := fmt.Errorf("network unreachable")
-	authErr := fmt.Errorf("authentication failed")
-	timeoutErr := fmt.Errorf("context deadline exceeded")
-	otherErr := fmt.Errorf("unknown error")
+	networkErr := fmt.Errorf("network unreachable: %w", werrors.ErrModemUnreachable)
+	authErr := fmt.Errorf("login: %w", modem.ErrAuthFailed)
+	timeoutErr := fmt.Errorf("check: %w", context.DeadlineExceeded)
+	// Wording alone no longer classifies an error
+	otherErr := fmt.Errorf("unknown error: connection refused")
 
 	if !service.isNetworkError(networkErr) {
 		t.Error("Expected network error to be classified correctly")
@@ -413,6 +445,7 @@ func TestServiceStateManagement(t *testing.T) {
 	logger.SetLevel(logrus.ErrorLevel)
 
 	cfg := &config.Config{
+		WorkingDirectory:   t.TempDir(),
 		FailureThreshold:   3,
 		EnableDiagnostics:  false,
 		ModemHost:          config.DefaultModemHost,
@@ -465,3 +498,2126 @@ func TestServiceStateManagement(t *testing.T) {
 		t.Error("Expected service to be running")
 	}
 }
+
+// stubModemDriver reports a fixed modem status and counts reboots
+type stubModemDriver struct {
+	status  *modem.Status
+	err     error
+	reboots int
+}
+
+func (d *stubModemDriver) Name() string                    { return "stub" }
+func (d *stubModemDriver) Login(ctx context.Context) error { return d.err }
+func (d *stubModemDriver) Reboot(ctx context.Context) error {
+	d.reboots++
+	return d.err
+}
+func (d *stubModemDriver) GetStatus(ctx context.Context) (*modem.Status, error) {
+	return d.status, d.err
+}
+
+// Test modem model and mode are exposed through the service state
+func TestModemStatusInServiceState(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	cfg := &config.Config{
+		WorkingDirectory:  t.TempDir(),
+		ModemHost:         config.DefaultModemHost,
+		ConnectionTimeout: 1 * time.Second,
+		CheckInterval:     30 * time.Second,
+	}
+
+	service := NewService(cfg, logger)
+	service.modemDriver = &stubModemDriver{status: &modem.Status{Model: "TG3482G", Mode: modem.ModeBridge}}
+
+	service.refreshModemStatus(context.Background())
+
+	state := service.GetCurrentState()
+	if state.ModemModel != "TG3482G" || state.ModemMode != modem.ModeBridge {
+		t.Errorf("Expected modem TG3482G in bridge mode, got %q in %q mode", state.ModemModel, state.ModemMode)
+	}
+
+	// A failing status fetch keeps the last known status
+	service.modemDriver = &stubModemDriver{err: fmt.Errorf("unreachable")}
+	service.refreshModemStatus(context.Background())
+	if state := service.GetCurrentState(); state.ModemMode != modem.ModeBridge {
+		t.Errorf("Expected last known mode to be kept, got %q", state.ModemMode)
+	}
+
+	// Mode is restored from persisted state
+	stateFile := filepath.Join(t.TempDir(), "watchdog.state")
+	if err := os.WriteFile(stateFile, []byte("modem_model=CGM4231\nmodem_mode=router\n"), 0644); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+
+	restored := NewService(cfg, logger)
+	if err := restored.LoadPersistedState(stateFile); err != nil {
+		t.Fatalf("LoadPersistedState() failed: %v", err)
+	}
+	if state := restored.GetCurrentState(); state.ModemModel != "CGM4231" || state.ModemMode != modem.ModeRouter {
+		t.Errorf("Expected restored modem CGM4231 in router mode, got %q in %q mode", state.ModemModel, state.ModemMode)
+	}
+}
+
+// Test the access method that worked last is restored into the driver
+func TestModemAccessMethodPersisted(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		WorkingDirectory: t.TempDir(),
+		ModemType:        modem.TypeMB8600,
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		RetryAttempts:    2,
+	}
+	service := NewService(cfg, logger)
+	if got := service.GetCurrentState().ModemAccess; got != "https+form" {
+		t.Errorf("Expected the https+form access method first, got %q", got)
+	}
+
+	stateFile := filepath.Join(t.TempDir(), "watchdog.state")
+	if err := os.WriteFile(stateFile, []byte("modem_access=http+hnap\n"), 0644); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+	if err := service.LoadPersistedState(stateFile); err != nil {
+		t.Fatalf("LoadPersistedState() failed: %v", err)
+	}
+	if got := service.GetCurrentState().ModemAccess; got != "http+hnap" {
+		t.Errorf("Expected the persisted http+hnap access method, got %q", got)
+	}
+}
+
+func TestFirmwareChangeAlert(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		WorkingDirectory: t.TempDir(),
+	}
+	driver := &stubModemDriver{status: &modem.Status{Model: "MB8600", FirmwareVersion: "8600-19.3.18"}}
+	recorder := &recordingNotifier{}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		ModemDriver: driver,
+		Notifiers:   []notify.Notifier{recorder},
+	})
+	ctx := context.Background()
+
+	// The first version seen and an unchanged version are not changes
+	service.refreshModemStatus(ctx)
+	service.refreshModemStatus(ctx)
+	if len(recorder.notifications) != 0 {
+		t.Fatalf("Expected no notifications, got %+v", recorder.notifications)
+	}
+
+	// A status page without the version keeps the last known one
+	driver.status = &modem.Status{Model: "MB8600"}
+	service.refreshModemStatus(ctx)
+	if state := service.GetCurrentState(); state.ModemFirmware != "8600-19.3.18" {
+		t.Errorf("Expected the last known firmware to be kept, got %q", state.ModemFirmware)
+	}
+
+	driver.status = &modem.Status{Model: "MB8600", FirmwareVersion: "8600-21.3.5"}
+	service.refreshModemStatus(ctx)
+	if len(recorder.notifications) != 1 || recorder.notifications[0].Kind != notify.KindFirmwareChanged {
+		t.Fatalf("Expected a firmware change notification, got %+v", recorder.notifications)
+	}
+	if body := recorder.notifications[0].Body; !strings.Contains(body, "8600-19.3.18") || !strings.Contains(body, "8600-21.3.5") {
+		t.Errorf("Expected both versions in the notification, got %q", body)
+	}
+	if state := service.GetCurrentState(); state.ModemFirmware != "8600-21.3.5" {
+		t.Errorf("Expected the new firmware in the state, got %q", state.ModemFirmware)
+	}
+
+	events, err := history.Read(cfg.HistoryPath(), time.Time{}, history.KindFirmwareChange)
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	if len(events) != 1 || events[0].Details["old_version"] != "8600-19.3.18" || events[0].Details["new_version"] != "8600-21.3.5" {
+		t.Errorf("Expected the change in the history, got %+v", events)
+	}
+
+	// The version is restored from persisted state, so a change while the
+	// watchdog was stopped is still noticed
+	stateFile := filepath.Join(t.TempDir(), "watchdog.state")
+	if err := os.WriteFile(stateFile, []byte("modem_firmware=8600-21.3.5\n"), 0644); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+	restored := NewService(cfg, logger)
+	if err := restored.LoadPersistedState(stateFile); err != nil {
+		t.Fatalf("LoadPersistedState() failed: %v", err)
+	}
+	if state := restored.GetCurrentState(); state.ModemFirmware != "8600-21.3.5" {
+		t.Errorf("Expected the restored firmware, got %q", state.ModemFirmware)
+	}
+}
+
+func TestPartialService(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg := &config.Config{
+		ModemHost:                 config.DefaultModemHost,
+		CheckInterval:             30 * time.Second,
+		FailureThreshold:          3,
+		WorkingDirectory:          t.TempDir(),
+		ModemStatsInterval:        time.Minute,
+		PartialServiceChannels:    2,
+		PartialServiceRebootAfter: 30 * time.Minute,
+	}
+	channels := func(unlocked ...int) *modem.Status {
+		status := &modem.Status{Model: "MB8600"}
+		for id := 1; id <= 8; id++ {
+			lock := "Locked"
+			for _, u := range unlocked {
+				if u == id {
+					lock = "Not Locked"
+				}
+			}
+			status.Downstream = append(status.Downstream, modem.Channel{ChannelID: id, LockStatus: lock})
+		}
+		return status
+	}
+	driver := &stubModemDriver{status: channels(3)}
+	recorder := &recordingNotifier{}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       fake,
+		Checker:     &scriptedChecker{},
+		ModemDriver: driver,
+		Notifiers:   []notify.Notifier{recorder},
+	})
+	ctx := context.Background()
+
+	// A single unlocked channel is below the threshold
+	if err := service.pollModemStats(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if state := service.GetCurrentState(); state.PartialService != nil || len(recorder.notifications) != 0 {
+		t.Fatalf("Expected no partial service, got %+v", state.PartialService)
+	}
+
+	driver.status = channels(3, 5, 6)
+	for i := 0; i < 2; i++ {
+		if err := service.pollModemStats(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state := service.GetCurrentState()
+	if state.PartialService == nil || state.PartialService.Unlocked != 3 || state.PartialService.Channels != 8 {
+		t.Fatalf("Expected partial service with 3 of 8 channels unlocked, got %+v", state.PartialService)
+	}
+	if len(recorder.notifications) != 1 || recorder.notifications[0].Kind != notify.KindPartialService {
+		t.Fatalf("Expected one partial service notification, got %+v", recorder.notifications)
+	}
+	if err := service.RunCheck(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if state := service.GetCurrentState(); state.Health != health.Degraded {
+		t.Errorf("Expected passing checks to be DEGRADED during partial service, got %s", state.Health)
+	}
+
+	// Rebooted once partial service lasts PartialServiceRebootAfter
+	fake.Advance(29 * time.Minute)
+	if err := service.pollModemStats(ctx); err != nil || driver.reboots != 0 {
+		t.Fatalf("Expected no reboot yet, got %d reboots, %v", driver.reboots, err)
+	}
+	fake.Advance(time.Minute)
+	if err := service.pollModemStats(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if driver.reboots != 1 || service.GetCurrentState().RebootsByReason[RebootPartialService] != 1 {
+		t.Fatalf("Expected a partial service reboot, got %d reboots", driver.reboots)
+	}
+
+	driver.status = channels()
+	if err := service.pollModemStats(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if state := service.GetCurrentState(); state.PartialService != nil {
+		t.Errorf("Expected partial service to end with every channel locked, got %+v", state.PartialService)
+	}
+}
+
+func TestModemOverheating(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:           config.DefaultModemHost,
+		CheckInterval:       30 * time.Second,
+		FailureThreshold:    1,
+		WorkingDirectory:    t.TempDir(),
+		ModemMaxTemperature: 80,
+	}
+	at := func(temperature float64) *modem.Status {
+		return &modem.Status{Model: "MB8600", TemperatureC: &temperature}
+	}
+	driver := &stubModemDriver{status: &modem.Status{Model: "MB8600"}}
+	recorder := &recordingNotifier{}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Checker:     failingChecker{},
+		ModemDriver: driver,
+		Notifiers:   []notify.Notifier{recorder},
+	})
+	ctx := context.Background()
+
+	// Firmware without a temperature, and a normal one, do not alert
+	service.refreshModemStatus(ctx)
+	driver.status = at(65)
+	service.refreshModemStatus(ctx)
+	if state := service.GetCurrentState(); state.ModemTemperature == nil || *state.ModemTemperature != 65 {
+		t.Errorf("Expected the temperature in the state, got %v", state.ModemTemperature)
+	}
+	if len(recorder.notifications) != 0 {
+		t.Fatalf("Expected no notifications, got %+v", recorder.notifications)
+	}
+
+	// Alerted once, until the temperature drops below the hysteresis
+	for _, temperature := range []float64{85, 79, 88} {
+		driver.status = at(temperature)
+		service.refreshModemStatus(ctx)
+	}
+	if len(recorder.notifications) != 1 || recorder.notifications[0].Kind != notify.KindModemOverheating {
+		t.Fatalf("Expected one overheating notification, got %+v", recorder.notifications)
+	}
+	if body := recorder.notifications[0].Body; !strings.Contains(body, "85.0°C") {
+		t.Errorf("Expected the temperature in the notification, got %q", body)
+	}
+
+	// An outage while overheating is blamed on the heat
+	if err := service.RunCheck(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if current := service.outageTracker.GetCurrentOutage(); current == nil || current.Cause != CauseModemOverheating {
+		t.Errorf("Expected the outage to be classified as %s, got %+v", CauseModemOverheating, current)
+	}
+
+	driver.status = at(74)
+	service.refreshModemStatus(ctx)
+	driver.status = at(85)
+	service.refreshModemStatus(ctx)
+	count := 0
+	for _, notification := range recorder.notifications {
+		if notification.Kind == notify.KindModemOverheating {
+			count++
+		}
+	}
+	if count != 2 {
+		t.Errorf("Expected a second alert after cooling down, got %d", count)
+	}
+}
+
+// eventLogDriver is a stub driver that also reads an event log
+type eventLogDriver struct {
+	stubModemDriver
+	events []modem.Event
+}
+
+func (d *eventLogDriver) GetEventLog(ctx context.Context) ([]modem.Event, error) {
+	return d.events, nil
+}
+
+func TestDOCSISTimeoutsLowerHealth(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	cfg := &config.Config{
+		ModemHost:             config.DefaultModemHost,
+		CheckInterval:         30 * time.Second,
+		FailureThreshold:      3,
+		WorkingDirectory:      t.TempDir(),
+		DOCSISTimeoutsPerHour: 4,
+		DOCSISTimeoutsPerDay:  20,
+	}
+	driver := &eventLogDriver{stubModemDriver: stubModemDriver{status: &modem.Status{Model: "MB8600"}}}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       fake,
+		Checker:     &scriptedChecker{},
+		ModemDriver: driver,
+	})
+	ctx := context.Background()
+
+	// Without timeouts the checks alone make the score
+	service.refreshModemStatus(ctx)
+	if err := service.RunCheck(ctx); err != nil {
+		t.Fatal(err)
+	}
+	state := service.GetCurrentState()
+	if state.DOCSISTimeouts == nil || *state.DOCSISTimeouts != (modem.TimeoutCounts{}) || state.Health != health.Healthy {
+		t.Fatalf("Expected no timeouts and a healthy connection, got %+v, %s", state.DOCSISTimeouts, state.Health)
+	}
+
+	for i := 0; i < 4; i++ {
+		driver.events = append(driver.events, modem.Event{
+			Time:        fake.Now().Add(-time.Duration(i) * time.Minute),
+			Description: "No Ranging Response received - T3 time-out",
+		})
+	}
+	service.refreshModemStatus(ctx)
+	if err := service.RunCheck(ctx); err != nil {
+		t.Fatal(err)
+	}
+	state = service.GetCurrentState()
+	if state.DOCSISTimeouts.T3Hour != 4 || state.Health != health.Degraded {
+		t.Errorf("Expected 4 T3 timeouts to degrade passing checks, got %+v, %s (score %.0f)", state.DOCSISTimeouts, state.Health, state.HealthScore)
+	}
+}
+
+// rebootSignalDriver is a stub driver whose channels change once rebooted
+type rebootSignalDriver struct {
+	stubModemDriver
+	after *modem.Status
+}
+
+func (d *rebootSignalDriver) GetStatus(ctx context.Context) (*modem.Status, error) {
+	if d.reboots > 0 {
+		return d.after, nil
+	}
+	return d.status, nil
+}
+
+func TestRebootComparesSignals(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 1,
+		WorkingDirectory: t.TempDir(),
+	}
+	locked := func(n int) *modem.Status {
+		status := &modem.Status{Upstream: []modem.Channel{{ChannelID: 1, LockStatus: "Locked", PowerDBmV: 45}}}
+		for id := 1; id <= 8; id++ {
+			lock := "Not Locked"
+			if id <= n {
+				lock = "Locked"
+			}
+			status.Downstream = append(status.Downstream, modem.Channel{ChannelID: id, LockStatus: lock, SNRDB: 38})
+		}
+		return status
+	}
+	driver := &rebootSignalDriver{stubModemDriver: stubModemDriver{status: locked(3)}, after: locked(8)}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Checker:     failingChecker{},
+		ModemDriver: driver,
+	})
+
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if driver.reboots != 1 {
+		t.Fatalf("Expected a reboot, got %d", driver.reboots)
+	}
+	current := service.outageTracker.GetCurrentOutage()
+	if current == nil || current.Signals == nil {
+		t.Fatalf("Expected the signal comparison on the outage, got %+v", current)
+	}
+	if current.Signals.Verdict != modem.SignalsImproved || current.Signals.Delta.DownstreamLocked != 5 {
+		t.Errorf("Expected 5 more locked channels to be an improvement, got %+v", current.Signals)
+	}
+}
+
+// failingDialer refuses every connection without touching the network
+type failingDialer struct{}
+
+func (failingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return nil, fmt.Errorf("dial %s %s: network unreachable", network, address)
+}
+
+// advanceUntilDone advances the fake clock whenever something waits on it
+func advanceUntilDone(fake *clock.Fake, done <-chan struct{}, step time.Duration) {
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if fake.Waiters() > 0 {
+			fake.Advance(step)
+		} else {
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// Test the reboot and recovery wait run on the injected clock and network,
+// so a 10 minute recovery wait completes without real waiting or traffic
+func TestRecoveryWaitUsesInjectedClock(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	driver := &stubModemDriver{}
+
+	cfg := &config.Config{
+		ModemHost:         config.DefaultModemHost,
+		CheckInterval:     30 * time.Second,
+		FailureThreshold:  1,
+		RecoveryWait:      10 * time.Minute,
+		ConnectionTimeout: time.Second,
+		HTTPTimeout:       time.Second,
+		PingHosts:         []string{"192.0.2.1", "192.0.2.2"},
+		HTTPHosts:         []string{"http://192.0.2.1"},
+		WorkingDirectory:  t.TempDir(),
+	}
+
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       fake,
+		Dialer:      failingDialer{},
+		ModemDriver: driver,
+	})
+
+	done := make(chan struct{})
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- service.performCheck(context.Background())
+		close(done)
+	}()
+
+	realStart := time.Now()
+	advanceUntilDone(fake, done, time.Second)
+
+	if err := <-errChan; err != nil {
+		t.Fatalf("performCheck() failed: %v", err)
+	}
+	if driver.reboots != 1 {
+		t.Errorf("Expected 1 reboot, got %d", driver.reboots)
+	}
+	if elapsed := fake.Since(start); elapsed < cfg.RecoveryWait {
+		t.Errorf("Expected fake clock to pass the recovery wait, advanced only %v", elapsed)
+	}
+	if elapsed := time.Since(realStart); elapsed > 10*time.Second {
+		t.Errorf("Recovery wait should not take real time, took %v", elapsed)
+	}
+
+	state := service.GetCurrentState()
+	if state.TotalReboots != 1 || state.LastReboot.Before(start) || state.LastReboot.After(fake.Now()) {
+		t.Errorf("Expected reboot recorded on the fake clock, got %+v", state)
+	}
+}
+
+type failingChecker struct{}
+
+func (failingChecker) ScheduleTests(ctx context.Context, lastResult *connectivity.TieredTestResult, consecutiveFailures int) (*connectivity.TieredTestResult, error) {
+	return &connectivity.TieredTestResult{Strategy: "stub", OverallSuccess: false}, nil
+}
+
+func TestInjectedRebootFailureAndSnapshot(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	driver := &stubModemDriver{}
+	injector := chaos.NewInjector(logger)
+	if err := injector.Set(chaos.Faults{FailRebootOnce: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 1,
+		WorkingDirectory: t.TempDir(),
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Checker:     failingChecker{},
+		ModemDriver: driver,
+		Faults:      injector,
+	})
+
+	if err := service.RunCheck(context.Background()); !errors.Is(err, chaos.ErrInjected) {
+		t.Fatalf("Expected the injected reboot failure, got %v", err)
+	}
+	if driver.reboots != 0 {
+		t.Errorf("Expected the reboot not to reach the modem, got %d", driver.reboots)
+	}
+	if snapshot := service.Snapshot(); snapshot.TotalChecks != 1 || snapshot.TotalReboots != 0 {
+		t.Errorf("Unexpected snapshot after failed reboot: %+v", snapshot)
+	}
+
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("Expected the second reboot to succeed, got %v", err)
+	}
+	if driver.reboots != 1 {
+		t.Errorf("Expected 1 reboot, got %d", driver.reboots)
+	}
+	if snapshot := service.Snapshot(); snapshot.TotalChecks != 2 || snapshot.TotalReboots != 1 {
+		t.Errorf("Unexpected snapshot after reboot: %+v", snapshot)
+	}
+}
+
+// slowChecker blocks in its lightweight stage until the stage deadline
+type slowChecker struct{}
+
+func (slowChecker) ScheduleTests(ctx context.Context, lastResult *connectivity.TieredTestResult, consecutiveFailures int) (*connectivity.TieredTestResult, error) {
+	stageCtx, end := budget.Stage(ctx, budget.StageLightweight)
+	defer end()
+	<-stageCtx.Done()
+	return &connectivity.TieredTestResult{Strategy: "stub", OverallSuccess: true}, nil
+}
+
+func TestCycleBudgetOverrunIsReported(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		CycleBudget:      100 * time.Millisecond,
+		FailureThreshold: 3,
+		WorkingDirectory: t.TempDir(),
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     slowChecker{},
+		ModemDriver: &stubModemDriver{},
+	})
+
+	start := time.Now()
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Check should be cut off by its budget, took %v", elapsed)
+	}
+	if snapshot := service.Snapshot(); snapshot.BudgetOverruns != 1 {
+		t.Errorf("Expected one budget overrun, got %d", snapshot.BudgetOverruns)
+	}
+}
+
+func TestCycleBudgetCappedByCheckTimeout(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	// A cycle is cut short after half the check interval, well within the
+	// configured budget, so the budget shrinks to fit it
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    400 * time.Millisecond,
+		CycleBudget:      time.Minute,
+		FailureThreshold: 3,
+		WorkingDirectory: t.TempDir(),
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     slowChecker{},
+		ModemDriver: &stubModemDriver{},
+	})
+
+	start := time.Now()
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+		t.Errorf("Expected the lightweight stage to end within its slice of the capped budget, took %v", elapsed)
+	}
+	if snapshot := service.Snapshot(); snapshot.BudgetOverruns != 1 {
+		t.Errorf("Expected the overrun to be reported, got %d", snapshot.BudgetOverruns)
+	}
+}
+
+func TestCycleBudgetUsesServiceClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	service := newPauseTestService(t, fake, t.TempDir(), &stubModemDriver{}, &recordingNotifier{})
+
+	ctx, cancel := context.WithDeadline(context.Background(), fake.Now().Add(10*time.Second))
+	defer cancel()
+	if got := service.cycleBudget(ctx); got != 10*time.Second {
+		t.Errorf("Expected the budget to end at the deadline by the service clock, got %v", got)
+	}
+}
+
+// recordingNotifier collects the notifications sent by the service
+type recordingNotifier struct {
+	notifications []notify.Notification
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	r.notifications = append(r.notifications, notification)
+	return nil
+}
+
+func TestOutageAndRebootNotifications(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 2,
+		WorkingDirectory: t.TempDir(),
+	}
+	recorder := &recordingNotifier{}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Checker:     failingChecker{},
+		ModemDriver: &stubModemDriver{},
+		Notifiers:   []notify.Notifier{recorder},
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() failed: %v", err)
+		}
+	}
+
+	var kinds []notify.Kind
+	for _, notification := range recorder.notifications {
+		kinds = append(kinds, notification.Kind)
+	}
+	want := []notify.Kind{notify.KindOutageStarted, notify.KindRebootTriggered}
+	if fmt.Sprint(kinds) != fmt.Sprint(want) {
+		t.Fatalf("Expected notifications %v, got %v", want, kinds)
+	}
+	if body := recorder.notifications[1].Body; body != "2 consecutive connectivity checks failed." {
+		t.Errorf("Unexpected reboot notification body %q", body)
+	}
+}
+
+func TestManualReboot(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 3,
+		WorkingDirectory: t.TempDir(),
+	}
+	recorder := &recordingNotifier{}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Checker:     failingChecker{},
+		ModemDriver: &stubModemDriver{},
+		Notifiers:   []notify.Notifier{recorder},
+	})
+
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if err := service.Reboot(context.Background(), "line noise"); err != nil {
+		t.Fatalf("Reboot() failed: %v", err)
+	}
+
+	state := service.Snapshot()
+	if state.TotalReboots != 1 || state.FailureCount != 0 || state.LastReboot.IsZero() {
+		t.Errorf("Expected the manual reboot to be counted, got %+v", state)
+	}
+
+	last := recorder.notifications[len(recorder.notifications)-1]
+	if last.Kind != notify.KindRebootTriggered || last.Body != "Reboot requested: line noise" {
+		t.Errorf("Unexpected notification %+v", last)
+	}
+}
+
+// scriptedChecker returns the scripted results in order, then succeeds
+type scriptedChecker struct {
+	results []bool
+}
+
+func (c *scriptedChecker) ScheduleTests(ctx context.Context, lastResult *connectivity.TieredTestResult, consecutiveFailures int) (*connectivity.TieredTestResult, error) {
+	success := true
+	if len(c.results) > 0 {
+		success, c.results = c.results[0], c.results[1:]
+	}
+	return &connectivity.TieredTestResult{Strategy: "stub", OverallSuccess: success}, nil
+}
+
+// recordingAction records the downtime of every recovery
+type recordingAction struct {
+	downtimes []time.Duration
+	err       error
+}
+
+func (a *recordingAction) Name() string { return "recording" }
+
+func (a *recordingAction) Recover(ctx context.Context, downtime time.Duration) error {
+	a.downtimes = append(a.downtimes, downtime)
+	return a.err
+}
+
+func TestRecoveryActionsRunWhenOutageEnds(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 10,
+		WorkingDirectory: t.TempDir(),
+	}
+	action := &recordingAction{}
+	failing := &recordingAction{err: fmt.Errorf("docker unavailable")}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:         &scriptedChecker{results: []bool{true, false, false, true, true}},
+		ModemDriver:     &stubModemDriver{},
+		RecoveryActions: []RecoveryAction{failing, action},
+	})
+
+	for i := 0; i < 5; i++ {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() %d failed: %v", i, err)
+		}
+		if i < 3 && len(action.downtimes) != 0 {
+			t.Fatalf("Recovery actions ran before the outage ended (check %d)", i)
+		}
+	}
+
+	if len(action.downtimes) != 1 || len(failing.downtimes) != 1 {
+		t.Fatalf("Expected every action to run once, got %v and %v", action.downtimes, failing.downtimes)
+	}
+	if action.downtimes[0] < 0 {
+		t.Errorf("Unexpected downtime %v", action.downtimes[0])
+	}
+}
+
+// handshakeChecker reports lightweight results with the given number of
+// reachable servers out of four, one count per check
+type handshakeChecker struct {
+	reachable []int
+}
+
+func (c *handshakeChecker) ScheduleTests(ctx context.Context, lastResult *connectivity.TieredTestResult, consecutiveFailures int) (*connectivity.TieredTestResult, error) {
+	reachable := c.reachable[0]
+	c.reachable = c.reachable[1:]
+
+	results := make([]connectivity.TestResult, 4)
+	for i := range results {
+		results[i].Success = i < reachable
+	}
+	return &connectivity.TieredTestResult{
+		Strategy:          "lightweight_only",
+		LightweightResult: &connectivity.LightweightTestResult{TestResults: results, SuccessCount: reachable},
+		OverallSuccess:    reachable >= 2,
+	}, nil
+}
+
+func TestHealthStatusFollowsScore(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 10,
+		WorkingDirectory: t.TempDir(),
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     &handshakeChecker{reachable: []int{4, 2, 1}},
+		ModemDriver: &stubModemDriver{},
+	})
+
+	for _, want := range []struct {
+		health string
+		score  float64
+	}{
+		{health.Healthy, 100},
+		{health.Degraded, 50},
+		{health.Unhealthy, 25},
+	} {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() failed: %v", err)
+		}
+		state := service.GetCurrentState()
+		if state.Health != want.health || state.HealthScore != want.score {
+			t.Errorf("Expected %s with score %v, got %s with %v", want.health, want.score, state.Health, state.HealthScore)
+		}
+	}
+}
+
+func TestRecoveryActionsFromConfig(t *testing.T) {
+	cfg := &config.Config{WorkingDirectory: t.TempDir(), DockerRestartContainers: []string{"wireguard"}, DockerSocket: "/var/run/docker.sock"}
+	actions := newRecoveryActions(cfg, nil, Options{})
+	if len(actions) != 1 || actions[0].Name() != "docker-restart" {
+		t.Errorf("Expected the docker restart action, got %v", actions)
+	}
+	if actions := newRecoveryActions(&config.Config{WorkingDirectory: t.TempDir()}, nil, Options{}); len(actions) != 0 {
+		t.Errorf("Expected no recovery actions by default, got %v", actions)
+	}
+}
+
+func TestRecoveryActionsIncludePublicIP(t *testing.T) {
+	cfg := &config.Config{
+		DDNSProvider:            "duckdns",
+		DDNSDomain:              "myhome",
+		DDNSToken:               "token",
+		DockerRestartContainers: []string{"wireguard"},
+		DockerSocket:            "/var/run/docker.sock",
+		WorkingDirectory:        t.TempDir(),
+	}
+	actions := newRecoveryActions(cfg, nil, Options{})
+	if len(actions) != 2 || actions[0].Name() != "public-ip" || actions[1].Name() != "docker-restart" {
+		t.Errorf("Expected the public IP check before container restarts, got %v", actions)
+	}
+}
+
+func TestPublicIPRecordedEveryNCycles(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "198.51.100.4")
+	}))
+	defer echo.Close()
+
+	cfg := &config.Config{
+		ModemHost:            config.DefaultModemHost,
+		CheckInterval:        30 * time.Second,
+		FailureThreshold:     10,
+		WorkingDirectory:     t.TempDir(),
+		PublicIPServices:     []string{echo.URL},
+		PublicIPRecordCycles: 2,
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     &scriptedChecker{results: []bool{true, true, true, true, true}},
+		ModemDriver: &stubModemDriver{},
+	})
+
+	for i := 0; i < 5; i++ {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() %d failed: %v", i, err)
+		}
+	}
+
+	// Observations are recorded in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		events, err := history.Read(cfg.HistoryPath(), time.Time{}, history.KindPublicIP)
+		if err != nil {
+			t.Fatalf("Failed to read history: %v", err)
+		}
+		if len(events) == 2 {
+			if events[0].Details["ip"] != "198.51.100.4" {
+				t.Errorf("Unexpected observation %+v", events[0])
+			}
+			break
+		}
+		if len(events) > 2 || time.Now().After(deadline) {
+			t.Fatalf("Expected an observation every 2 cycles, got %d", len(events))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// blockingChecker holds the check cycle until release is closed
+type blockingChecker struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingChecker) ScheduleTests(ctx context.Context, lastResult *connectivity.TieredTestResult, consecutiveFailures int) (*connectivity.TieredTestResult, error) {
+	close(c.entered)
+	<-c.release
+	return &connectivity.TieredTestResult{Strategy: "stub", OverallSuccess: true}, nil
+}
+
+func TestDebugInfoDuringStuckCheck(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 3,
+		WorkingDirectory: t.TempDir(),
+	}
+	checker := &blockingChecker{entered: make(chan struct{}), release: make(chan struct{})}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     checker,
+		ModemDriver: &stubModemDriver{},
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- service.RunCheck(context.Background()) }()
+	<-checker.entered
+
+	info := service.DebugInfo()
+	if !info.CheckRunning {
+		t.Error("Expected a running check to be reported")
+	}
+	if info.LastTestResult != nil {
+		t.Error("Expected no test result before the first check completes")
+	}
+	if info.Breakers["diagnostics"]["ping"] != "closed" {
+		t.Errorf("Expected closed diagnostics breakers, got %v", info.Breakers)
+	}
+
+	close(checker.release)
+	if err := <-done; err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+
+	info = service.DebugInfo()
+	if info.CheckRunning {
+		t.Error("Expected no running check after the cycle completed")
+	}
+	if info.State.TotalChecks != 1 || info.LastTestResult == nil || info.LastTestResult.Strategy != "stub" {
+		t.Errorf("Expected the completed check in the debug info, got %+v", info)
+	}
+}
+
+func TestRebootDecisionsRecordedInHistory(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 2,
+		WorkingDirectory: t.TempDir(),
+	}
+	driver := &stubModemDriver{}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     &scriptedChecker{results: []bool{false, false}},
+		ModemDriver: driver,
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() %d failed: %v", i, err)
+		}
+	}
+	if err := service.Reboot(context.Background(), "maintenance"); err != nil {
+		t.Fatalf("Reboot() failed: %v", err)
+	}
+
+	events, err := history.Read(cfg.HistoryPath(), time.Time{}, history.KindRebootDecision)
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 reboot decisions, got %+v", events)
+	}
+
+	automatic := events[0].Details
+	if automatic["outcome"] != DecisionReboot || automatic["trigger"] != TriggerAutomatic {
+		t.Errorf("Expected an automatic reboot, got %+v", automatic)
+	}
+	if automatic["failure_count"] != float64(2) || automatic["failure_threshold"] != float64(2) {
+		t.Errorf("Expected the failure count and threshold as inputs, got %+v", automatic)
+	}
+	if automatic["reboot_score"] != float64(health.DefaultRebootScore) || automatic["health"] != health.Unhealthy {
+		t.Errorf("Expected the health score and cutoffs as inputs, got %+v", automatic)
+	}
+	if _, ok := automatic["since_last_reboot"]; ok {
+		t.Errorf("Expected no time since the last reboot before the first one, got %+v", automatic)
+	}
+
+	manual := events[1].Details
+	if manual["trigger"] != TriggerManual || manual["reason"] != "maintenance" || manual["since_last_reboot"] == nil {
+		t.Errorf("Expected a manual reboot after the automatic one, got %+v", manual)
+	}
+}
+
+func TestRebootCountersByReason(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 1,
+		WorkingDirectory: t.TempDir(),
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     &scriptedChecker{results: []bool{false, true, false}},
+		ModemDriver: &stubModemDriver{},
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() %d failed: %v", i, err)
+		}
+	}
+	if err := service.Reboot(context.Background(), "maintenance"); err != nil {
+		t.Fatalf("Reboot() failed: %v", err)
+	}
+
+	state := service.GetCurrentState()
+	if state.TotalReboots != 3 {
+		t.Errorf("Expected 3 reboots, got %d", state.TotalReboots)
+	}
+	want := map[string]int{RebootThreshold: 2, RebootManual: 1}
+	if !reflect.DeepEqual(state.RebootsByReason, want) {
+		t.Errorf("Expected reboots by reason %v, got %v", want, state.RebootsByReason)
+	}
+
+	// The counters are restored from persisted state
+	stateFile := filepath.Join(t.TempDir(), "watchdog.state")
+	data := "total_reboots=5\nreboots_threshold=3\nreboots_manual=1\nreboots_escalation=1\n"
+	if err := os.WriteFile(stateFile, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+	restored := NewService(cfg, logger)
+	if err := restored.LoadPersistedState(stateFile); err != nil {
+		t.Fatalf("LoadPersistedState() failed: %v", err)
+	}
+	want = map[string]int{RebootThreshold: 3, RebootManual: 1, RebootEscalation: 1}
+	if got := restored.GetCurrentState().RebootsByReason; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected restored reboots by reason %v, got %v", want, got)
+	}
+}
+
+func TestDisabledFeatures(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:         config.DefaultModemHost,
+		CheckInterval:     30 * time.Second,
+		FailureThreshold:  2,
+		EnableDiagnostics: true,
+		WorkingDirectory:  t.TempDir(),
+		DisabledFeatures:  []string{"history", "notifications", "diagnostics"},
+	}
+	recorder := &recordingNotifier{}
+	driver := &stubModemDriver{}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     failingChecker{},
+		ModemDriver: driver,
+		Notifiers:   []notify.Notifier{recorder},
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() failed: %v", err)
+		}
+	}
+
+	if driver.reboots != 1 {
+		t.Errorf("Expected a reboot without diagnostics, got %d", driver.reboots)
+	}
+	if len(recorder.notifications) != 0 {
+		t.Errorf("Expected no notifications, got %+v", recorder.notifications)
+	}
+	if _, err := os.Stat(cfg.HistoryPath()); !os.IsNotExist(err) {
+		t.Errorf("Expected no history to be written, got %v", err)
+	}
+}
+
+func TestRefreshReusesRecentCheck(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg := &config.Config{
+		ModemHost:          config.DefaultModemHost,
+		CheckInterval:      30 * time.Second,
+		FailureThreshold:   3,
+		WorkingDirectory:   t.TempDir(),
+		APIRefreshInterval: 10 * time.Second,
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       fake,
+		Checker:     &scriptedChecker{},
+		ModemDriver: &stubModemDriver{},
+	})
+
+	state, err := service.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("Refresh() failed: %v", err)
+	}
+	if state.TotalChecks != 1 {
+		t.Fatalf("Expected the first refresh to run a check, got %d checks", state.TotalChecks)
+	}
+
+	fake.Advance(5 * time.Second)
+	if state, _ = service.Refresh(context.Background()); state.TotalChecks != 1 {
+		t.Errorf("Expected a refresh within the interval to reuse the check, got %d checks", state.TotalChecks)
+	}
+
+	fake.Advance(10 * time.Second)
+	if state, _ = service.Refresh(context.Background()); state.TotalChecks != 2 {
+		t.Errorf("Expected a refresh after the interval to run a check, got %d checks", state.TotalChecks)
+	}
+}
+
+// contextChecker fails its tests when their context has ended
+type contextChecker struct{}
+
+func (contextChecker) ScheduleTests(ctx context.Context, lastResult *connectivity.TieredTestResult, consecutiveFailures int) (*connectivity.TieredTestResult, error) {
+	return &connectivity.TieredTestResult{Strategy: "stub", OverallSuccess: ctx.Err() == nil}, nil
+}
+
+func TestRefreshOutlivesCaller(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 3,
+		WorkingDirectory: t.TempDir(),
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     contextChecker{},
+		ModemDriver: &stubModemDriver{},
+	})
+
+	// A client that disconnected does not cancel the check it asked for
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	state, err := service.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("Refresh() failed: %v", err)
+	}
+	if state.TotalChecks != 1 || state.FailureCount != 0 {
+		t.Errorf("Expected a passing check, got %d checks and %d failures", state.TotalChecks, state.FailureCount)
+	}
+}
+
+func TestCycleIDCorrelatesLogsAndRecords(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetLevel(logrus.DebugLevel)
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 1,
+		WorkingDirectory: t.TempDir(),
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     &scriptedChecker{results: []bool{false, true}},
+		ModemDriver: &stubModemDriver{},
+	})
+
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	check := service.Snapshot().Check
+	if check == nil || len(check.CycleID) != 16 {
+		t.Fatalf("Expected the check to carry a cycle ID, got %+v", check)
+	}
+	cycleID := check.CycleID
+
+	events, err := history.Read(cfg.HistoryPath(), time.Time{}, history.KindRebootDecision)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Expected one reboot decision, got %+v (%v)", events, err)
+	}
+	if events[0].Details["cycle_id"] != cycleID {
+		t.Errorf("Expected the decision to carry cycle ID %s, got %+v", cycleID, events[0].Details)
+	}
+
+	tagged := 0
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to decode log line %q: %v", line, err)
+		}
+		if entry["cycle_id"] == cycleID {
+			tagged++
+		}
+	}
+	if tagged == 0 {
+		t.Errorf("Expected the log entries of the cycle to carry its ID, got %s", out.String())
+	}
+
+	out.Reset()
+	logger.Info("between cycles")
+	if strings.Contains(out.String(), "cycle_id") {
+		t.Errorf("Expected no cycle ID between cycles, got %s", out.String())
+	}
+
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("Second RunCheck() failed: %v", err)
+	}
+	if next := service.Snapshot().Check.CycleID; next == "" || next == cycleID {
+		t.Errorf("Expected a new cycle ID for the next cycle, got %q after %q", next, cycleID)
+	}
+}
+
+func TestTunnelHealthCheck(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+
+	cfg := &config.Config{
+		ModemHost:            config.DefaultModemHost,
+		ModemTunnelInterface: "wg-missing0",
+		CheckInterval:        30 * time.Second,
+		FailureThreshold:     3,
+		WorkingDirectory:     t.TempDir(),
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     &scriptedChecker{results: []bool{true, true}},
+		ModemDriver: &stubModemDriver{},
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() failed: %v", err)
+		}
+	}
+	state := service.Snapshot()
+	if state.Tunnel == nil || state.Tunnel.Healthy || state.Tunnel.Kind != "interface" || !strings.Contains(state.Tunnel.Error, "wg-missing0") {
+		t.Fatalf("Expected the missing interface to be reported down, got %+v", state.Tunnel)
+	}
+	if state.FailureCount != 0 {
+		t.Errorf("Expected a tunnel that is down not to fail the check, got %d failures", state.FailureCount)
+	}
+	if got := strings.Count(out.String(), "Modem tunnel is down"); got != 1 {
+		t.Errorf("Expected the tunnel going down to be logged once, got %d times", got)
+	}
+
+	// Without a tunnel the modem is reached directly and there is no check
+	direct := *cfg
+	direct.ModemTunnelInterface = ""
+	if err := service.UpdateConfiguration(&direct); err != nil {
+		t.Fatalf("UpdateConfiguration() failed: %v", err)
+	}
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if state := service.Snapshot(); state.Tunnel != nil {
+		t.Errorf("Expected no tunnel health without a tunnel, got %+v", state.Tunnel)
+	}
+}
+
+func TestLowPowerModeFollowsPowerSource(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	source := filepath.Join(t.TempDir(), "online")
+	if err := os.WriteFile(source, []byte("0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		ModemHost:             config.DefaultModemHost,
+		CheckInterval:         30 * time.Second,
+		FailureThreshold:      1,
+		WorkingDirectory:      t.TempDir(),
+		LowPowerMode:          power.ModeAuto,
+		PowerSourceFile:       source,
+		LowPowerCheckInterval: 10 * time.Minute,
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     &scriptedChecker{results: []bool{false}},
+		ModemDriver: &stubModemDriver{},
+	})
+	t.Cleanup(func() { history.Release() })
+
+	// On battery the failed check reboots without diagnostics, and the
+	// decision stays in memory
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if !service.Snapshot().LowPower || service.checkInterval() != 10*time.Minute {
+		t.Fatalf("Expected low-power mode on battery, got interval %v", service.checkInterval())
+	}
+	if _, err := os.Stat(cfg.HistoryPath()); !os.IsNotExist(err) {
+		t.Errorf("Expected no history written in low-power mode, got %v", err)
+	}
+	events, err := history.Read(cfg.HistoryPath(), time.Time{}, history.KindRebootDecision)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Expected the held reboot decision, got %+v (%v)", events, err)
+	}
+	if reason := events[0].Details["reason"]; reason != "failure threshold reached in low-power mode" {
+		t.Errorf("Expected diagnostics to be skipped, got reason %v", reason)
+	}
+
+	// Back on mains the held history is written
+	if err := os.WriteFile(source, []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if service.Snapshot().LowPower || service.checkInterval() != cfg.CheckInterval {
+		t.Errorf("Expected low-power mode to end on mains, got interval %v", service.checkInterval())
+	}
+	if _, err := os.Stat(cfg.HistoryPath()); err != nil {
+		t.Errorf("Expected the held history to be written, got %v", err)
+	}
+
+	// A power source that cannot be read keeps the current mode
+	os.Remove(source)
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if service.Snapshot().LowPower {
+		t.Error("Expected a missing power source to keep the current mode")
+	}
+}
+
+// offlineNotifier fails to deliver while offline, like a webhook during an
+// outage, and records what it delivers
+type offlineNotifier struct {
+	recordingNotifier
+	offline bool
+}
+
+func (n *offlineNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	if n.offline {
+		return errors.New("no route to host")
+	}
+	return n.recordingNotifier.Notify(ctx, notification)
+}
+
+func TestNotificationsQueuedDuringOutage(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:             config.DefaultModemHost,
+		CheckInterval:         30 * time.Second,
+		FailureThreshold:      5,
+		WorkingDirectory:      t.TempDir(),
+		NotificationQueueSize: 10,
+	}
+	notifier := &offlineNotifier{offline: true}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Checker:     &scriptedChecker{results: []bool{false, false, true}},
+		ModemDriver: &stubModemDriver{},
+		Notifiers:   []notify.Notifier{notifier},
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() failed: %v", err)
+		}
+	}
+	if service.Notifier().Pending() != 1 {
+		t.Fatalf("Expected the outage notification to be queued, got %d", service.Notifier().Pending())
+	}
+
+	// Connectivity returns: the queued notification goes first, marked as
+	// delayed, then the resolution
+	notifier.offline = false
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	sent := notifier.notifications
+	if len(sent) != 2 || sent[0].Kind != notify.KindOutageStarted || sent[1].Kind != notify.KindOutageResolved {
+		t.Fatalf("Expected the delayed outage notification then the resolution, got %+v", sent)
+	}
+	if !sent[0].Delayed || sent[1].Delayed {
+		t.Errorf("Expected only the queued notification to be delayed, got %+v", sent)
+	}
+	if service.Notifier().Pending() != 0 {
+		t.Errorf("Expected an empty queue, got %d", service.Notifier().Pending())
+	}
+}
+
+func TestStatusBeacon(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	received := make(chan beacon.Status, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var status beacon.Status
+		if err := json.NewDecoder(r.Body).Decode(&status); err != nil {
+			t.Errorf("Failed to decode beacon: %v", err)
+		}
+		received <- status
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 3,
+		WorkingDirectory: t.TempDir(),
+		BeaconURL:        server.URL,
+		BeaconInterval:   time.Minute,
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     &scriptedChecker{results: []bool{false}},
+		ModemDriver: &stubModemDriver{},
+	})
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+
+	if err := service.sendBeacon(context.Background(), beacon.New(cfg.Beacon(), server.Client())); err != nil {
+		t.Fatalf("sendBeacon() failed: %v", err)
+	}
+	status := <-received
+	if status.FailureCount != 1 || status.LastCheck.IsZero() || status.Hostname == "" {
+		t.Errorf("Expected the failed check in the beacon, got %+v", status)
+	}
+
+	// An unreachable endpoint fails the beacon until it is back
+	server.Close()
+	if err := service.sendBeacon(context.Background(), beacon.New(cfg.Beacon(), server.Client())); err == nil || !service.beaconFailing {
+		t.Errorf("Expected the beacon to fail, got %v", err)
+	}
+}
+
+func TestStartupSelfCheck(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		ModemHost:         config.DefaultModemHost,
+		CheckInterval:     30 * time.Second,
+		FailureThreshold:  3,
+		ConnectionTimeout: time.Second,
+		WorkingDirectory:  dir,
+		LogFile:           filepath.Join(dir, "log", "watchdog.log"),
+	}
+	recorder := &recordingNotifier{}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     &scriptedChecker{results: []bool{false}},
+		ModemDriver: &stubModemDriver{err: errors.New("invalid password")},
+		Notifiers:   []notify.Notifier{recorder},
+	})
+
+	service.runStartupCheck(context.Background())
+	report := service.StartupReport()
+	if report == nil {
+		t.Fatal("Expected a startup report")
+	}
+	statuses := make(map[string]string)
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	want := map[string]string{"config": SelfCheckFailed, "modem": SelfCheckFailed, "targets": SelfCheckFailed, "paths": SelfCheckPassed}
+	for name, status := range want {
+		if statuses[name] != status {
+			t.Errorf("Expected the %s check to be %s, got %q", name, status, statuses[name])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "log")); err != nil {
+		t.Errorf("Expected the log directory to be created: %v", err)
+	}
+
+	if len(recorder.notifications) != 1 || recorder.notifications[0].Kind != notify.KindStartupReport {
+		t.Fatalf("Expected a startup report notification, got %+v", recorder.notifications)
+	}
+	if body := recorder.notifications[0].Body; !strings.Contains(body, "- modem: login to stub at 192.168.100.1 failed: invalid password") {
+		t.Errorf("Expected the failed login in the notification, got %q", body)
+	}
+	if info := service.DebugInfo(); info.StartupReport != report {
+		t.Error("Expected the startup report in the debug dump")
+	}
+}
+
+func TestModemStatsPolling(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:          config.DefaultModemHost,
+		CheckInterval:      30 * time.Second,
+		ConnectionTimeout:  time.Second,
+		WorkingDirectory:   t.TempDir(),
+		ModemStatsInterval: time.Minute,
+	}
+	driver := &stubModemDriver{status: &modem.Status{
+		Model:      "MB8600",
+		Downstream: []modem.Channel{{ChannelID: 1, LockStatus: "Locked", SNRDB: 40}},
+	}}
+	service := NewServiceWithOptions(cfg, logger, Options{ModemDriver: driver})
+	if service.ModemStatus() != nil {
+		t.Fatal("Expected no modem status before the first poll")
+	}
+	if err := service.scheduleModemStats(); err != nil {
+		t.Fatalf("scheduleModemStats() failed: %v", err)
+	}
+	defer service.scheduler.Remove(ModemStatsJobName)
+	if _, ok := service.scheduler.Next(ModemStatsJobName); !ok {
+		t.Error("Expected the modem statistics job to be scheduled")
+	}
+
+	if err := service.pollModemStats(context.Background()); err != nil {
+		t.Fatalf("pollModemStats() failed: %v", err)
+	}
+	if status := service.ModemStatus(); status == nil || len(status.Downstream) != 1 {
+		t.Fatalf("Expected the polled channels, got %+v", status)
+	}
+
+	// A running check cycle holds the modem, so the poll is skipped
+	driver.status = &modem.Status{Model: "MB8600"}
+	service.cycleMu.Lock()
+	service.pollModemStats(context.Background())
+	service.cycleMu.Unlock()
+	if status := service.ModemStatus(); len(status.Downstream) != 1 {
+		t.Errorf("Expected the poll to be skipped during a check, got %+v", status)
+	}
+}
+
+func TestTextfile(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	path := filepath.Join(t.TempDir(), "mb8600_watchdog.prom")
+	cfg := &config.Config{
+		ModemHost:         config.DefaultModemHost,
+		CheckInterval:     30 * time.Second,
+		ConnectionTimeout: time.Second,
+		WorkingDirectory:  t.TempDir(),
+		TextfilePath:      path,
+		TextfileInterval:  time.Minute,
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{})
+	service.isRunning = true
+	service.failureCount = 2
+	service.publishState()
+
+	// The file is written when the job is scheduled, not an interval later
+	if err := service.scheduleTextfile(); err != nil {
+		t.Fatalf("scheduleTextfile() failed: %v", err)
+	}
+	defer service.scheduler.Remove(TextfileJobName)
+	if _, ok := service.scheduler.Next(TextfileJobName); !ok {
+		t.Error("Expected the textfile job to be scheduled")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected the textfile to be written: %v", err)
+	}
+	for _, want := range []string{
+		"watchdog_up 1\n",
+		"watchdog_consecutive_failures 2\n",
+		"watchdog_last_reboot_timestamp_seconds 0\n",
+		`watchdog_reboots_total{reason="threshold",automated="true"} 0`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %q in the textfile:\n%s", want, data)
+		}
+	}
+
+	service.isRunning = false
+	service.publishState()
+	if err := service.writeTextfile(context.Background()); err != nil {
+		t.Fatalf("writeTextfile() failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "watchdog_up 0\n") {
+		t.Errorf("Expected a stopped service to be down in the textfile:\n%s", data)
+	}
+}
+
+func TestCheckHistoryDownsampling(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	cfg := &config.Config{
+		ModemHost:           config.DefaultModemHost,
+		CheckInterval:       30 * time.Second,
+		FailureThreshold:    100,
+		WorkingDirectory:    t.TempDir(),
+		HistoryRawRetention: 24 * time.Hour,
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       fake,
+		Checker:     failingChecker{},
+		ModemDriver: &stubModemDriver{},
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() failed: %v", err)
+		}
+		fake.Advance(40 * time.Minute)
+	}
+	checks, err := history.Read(cfg.HistoryPath(), time.Time{}, history.KindCheck)
+	if err != nil || len(checks) != 3 {
+		t.Fatalf("Expected 3 recorded checks, got %v, %v", checks, err)
+	}
+	if checks[0].Details["success"] != false {
+		t.Errorf("Expected a failed check, got %+v", checks[0].Details)
+	}
+	if events, err := service.Events(start.Add(time.Hour), history.KindCheck); err != nil || len(events) != 1 {
+		t.Errorf("Expected the check of the last hour from Events, got %v, %v", events, err)
+	}
+
+	// Within the retention the results stay raw
+	if err := service.maintainHistory(context.Background()); err != nil {
+		t.Fatalf("maintainHistory() failed: %v", err)
+	}
+	if checks, _ := history.Read(cfg.HistoryPath(), time.Time{}, history.KindCheck); len(checks) != 3 {
+		t.Errorf("Expected the recent checks to stay raw, got %d", len(checks))
+	}
+
+	fake.Advance(48 * time.Hour)
+	if err := service.maintainHistory(context.Background()); err != nil {
+		t.Fatalf("maintainHistory() failed: %v", err)
+	}
+	events, _ := history.Read(cfg.HistoryPath(), time.Time{}, history.KindCheck, history.KindCheckHourly)
+	if len(events) != 2 || events[0].Kind != history.KindCheckHourly || events[0].Details["checks"] != 2.0 || events[1].Details["failures"] != 1.0 {
+		t.Errorf("Expected two hourly aggregates, got %+v", events)
+	}
+
+	// The history is pruned to its limits
+	cfg.HistoryMaxEvents = 1
+	if err := service.maintainHistory(context.Background()); err != nil {
+		t.Fatalf("maintainHistory() failed: %v", err)
+	}
+	if events, _ := history.Read(cfg.HistoryPath(), time.Time{}); len(events) != 1 || events[0].Kind != history.KindCheckHourly {
+		t.Errorf("Expected the last aggregate kept, got %+v", events)
+	}
+
+	// Without a raw retention no check results are recorded
+	cfg.HistoryRawRetention = 0
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if checks, _ := history.Read(cfg.HistoryPath(), time.Time{}, history.KindCheck); len(checks) != 0 {
+		t.Errorf("Expected no check recorded, got %d", len(checks))
+	}
+}
+
+func TestScheduledBackup(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		ModemHost:         config.DefaultModemHost,
+		CheckInterval:     30 * time.Second,
+		WorkingDirectory:  filepath.Join(dir, "state"),
+		BackupInterval:    24 * time.Hour,
+		BackupDestination: filepath.Join(dir, "backups"),
+		BackupKeep:        1,
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		ModemDriver: &stubModemDriver{},
+	})
+	if err := history.Append(cfg.HistoryPath(), history.Event{Kind: history.KindIPChange}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.scheduleBackup(); err != nil {
+		t.Fatalf("scheduleBackup() failed: %v", err)
+	}
+	defer service.scheduler.Remove(BackupJobName)
+	if _, ok := service.scheduler.Next(BackupJobName); !ok {
+		t.Error("Expected the backup job to be scheduled")
+	}
+
+	dest, err := backup.NewDestination(cfg.Backup(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.runBackup(context.Background(), dest); err != nil {
+		t.Fatalf("runBackup() failed: %v", err)
+	}
+	file, err := os.Open(filepath.Join(dir, "backups", backup.FileName(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))))
+	if err != nil {
+		t.Fatalf("Expected the backup in the destination: %v", err)
+	}
+	defer file.Close()
+
+	restored := filepath.Join(dir, "restored")
+	manifest, err := backup.Restore(file, backup.Sources{StateDir: restored})
+	if err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(restored, "logs", "history.jsonl")); err != nil {
+		t.Errorf("Expected the history in the backup, got %v", manifest.Files)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "state", ".backup-*")); len(leftovers) != 0 {
+		t.Errorf("Expected the temporary backup removed, got %v", leftovers)
+	}
+}
+
+func TestRemoteSync(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		WorkingDirectory: filepath.Join(dir, "state"),
+		SyncInterval:     time.Hour,
+		SyncDestination:  filepath.Join(dir, "remote"),
+		SyncSite:         "cabin",
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		ModemDriver: &stubModemDriver{},
+	})
+	if err := history.Append(cfg.HistoryPath(), history.Event{Kind: history.KindIPChange}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(cfg.ReportsPath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.ReportsPath(), "report-1.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.scheduleSync(); err != nil {
+		t.Fatalf("scheduleSync() failed: %v", err)
+	}
+	defer service.scheduler.Remove(SyncJobName)
+	if _, ok := service.scheduler.Next(SyncJobName); !ok {
+		t.Error("Expected the sync job to be scheduled")
+	}
+
+	dest, err := backup.NewDestination(cfg.Sync(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.runSync(context.Background(), dest); err != nil {
+		t.Fatalf("runSync() failed: %v", err)
+	}
+	for _, name := range []string{"history.jsonl", filepath.Join("reports", "report-1.json")} {
+		if _, err := os.Stat(filepath.Join(dir, "remote", "cabin", name)); err != nil {
+			t.Errorf("Expected %s uploaded: %v", name, err)
+		}
+	}
+}
+
+func TestStandbyRebootsOnlyWithoutPrimary(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	primaryUp := true
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !primaryUp {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(ServiceState{IsRunning: true, Cluster: &cluster.Status{Role: cluster.RolePrimary, Active: true}})
+	}))
+	defer peer.Close()
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	driver := &stubModemDriver{}
+	cfg := &config.Config{
+		ModemHost:                config.DefaultModemHost,
+		CheckInterval:            30 * time.Second,
+		FailureThreshold:         1,
+		WorkingDirectory:         t.TempDir(),
+		ClusterRole:              cluster.RoleStandby,
+		ClusterPeer:              peer.URL,
+		ClusterHeartbeatInterval: 10 * time.Second,
+		ClusterFailoverAfter:     time.Minute,
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       fake,
+		Checker:     failingChecker{},
+		ModemDriver: driver,
+	})
+	if err := service.scheduleClusterHeartbeat(); err != nil {
+		t.Fatalf("scheduleClusterHeartbeat() failed: %v", err)
+	}
+	defer service.scheduler.Remove(ClusterHeartbeatJobName)
+	node := service.clusterNode()
+
+	if err := service.clusterHeartbeat(context.Background(), node); err != nil {
+		t.Fatalf("clusterHeartbeat() failed: %v", err)
+	}
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if driver.reboots != 0 {
+		t.Errorf("Expected the standby not to reboot while the primary runs, got %d reboots", driver.reboots)
+	}
+	if state := service.Snapshot(); state.Cluster == nil || state.Cluster.Active {
+		t.Errorf("Expected a passive standby in the state, got %+v", state.Cluster)
+	}
+
+	primaryUp = false
+	fake.Advance(2 * time.Minute)
+	if err := service.clusterHeartbeat(context.Background(), node); err == nil {
+		t.Error("Expected the heartbeat to fail without the primary")
+	}
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if driver.reboots != 1 {
+		t.Errorf("Expected the standby to reboot once the primary is gone, got %d reboots", driver.reboots)
+	}
+	if state := service.Snapshot(); !state.Cluster.Active {
+		t.Errorf("Expected the standby active in the state, got %+v", state.Cluster)
+	}
+}
+
+func TestRebootWaitsForQuorum(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	agentOnline := true
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		check := &connectivity.TestSummary{OverallSuccess: agentOnline, Timestamp: fake.Now()}
+		json.NewEncoder(w).Encode(ServiceState{IsRunning: true, Check: check})
+	}))
+	defer agent.Close()
+
+	driver := &stubModemDriver{}
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 1,
+		WorkingDirectory: t.TempDir(),
+		QuorumPeers:      []string{agent.URL},
+		QuorumMaxAge:     time.Minute,
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       fake,
+		Checker:     failingChecker{},
+		ModemDriver: driver,
+	})
+
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if driver.reboots != 0 {
+		t.Errorf("Expected no reboot while the other agent is online, got %d reboots", driver.reboots)
+	}
+
+	agentOnline = false
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if driver.reboots != 1 {
+		t.Errorf("Expected a reboot once both agents see the internet down, got %d reboots", driver.reboots)
+	}
+}
+
+// stubHostHealth reports a host with the problems, healthy without any
+type stubHostHealth struct {
+	problems []hosthealth.Result
+}
+
+func (h *stubHostHealth) Check(ctx context.Context, now time.Time) hosthealth.Report {
+	return hosthealth.Report{Checks: append([]hosthealth.Result{{Name: hosthealth.CheckClock, OK: true}}, h.problems...)}
+}
+
+func TestDegradedHostHoldsRebootBack(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	host := &stubHostHealth{problems: []hosthealth.Result{{Name: hosthealth.CheckRoute, Detail: "no default route"}}}
+	driver := &stubModemDriver{}
+	recorder := &recordingNotifier{}
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 1,
+		WorkingDirectory: t.TempDir(),
+		HostHealthCheck:  true,
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     failingChecker{},
+		ModemDriver: driver,
+		Notifiers:   []notify.Notifier{recorder},
+		HostHealth:  host,
+	})
+
+	countDegraded := func() int {
+		count := 0
+		for _, notification := range recorder.notifications {
+			if notification.Kind == notify.KindHostDegraded {
+				count++
+			}
+		}
+		return count
+	}
+	for i := 0; i < 2; i++ {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() failed: %v", err)
+		}
+	}
+	if driver.reboots != 0 {
+		t.Errorf("Expected no reboot while the host is degraded, got %d reboots", driver.reboots)
+	}
+	if count := countDegraded(); count != 1 {
+		t.Errorf("Expected one host degraded notification, got %d", count)
+	}
+
+	host.problems = nil
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if driver.reboots != 1 {
+		t.Errorf("Expected a reboot once the host is healthy, got %d reboots", driver.reboots)
+	}
+}
+
+// gatewayDownChecker fails every check along with the gateway check
+type gatewayDownChecker struct{}
+
+func (gatewayDownChecker) ScheduleTests(ctx context.Context, lastResult *connectivity.TieredTestResult, consecutiveFailures int) (*connectivity.TieredTestResult, error) {
+	gateway := connectivity.TestResult{TestType: connectivity.TestTypeGateway, Target: "192.168.1.1:80"}
+	return &connectivity.TieredTestResult{
+		Strategy:          "stub",
+		LightweightResult: &connectivity.LightweightTestResult{GatewayResults: []connectivity.TestResult{gateway}},
+	}, nil
+}
+
+func TestFailureVerdictActions(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	// Neither the gateway nor the modem answers
+	driver := &stubModemDriver{err: fmt.Errorf("unreachable")}
+	recorder := &recordingNotifier{}
+	cfg := &config.Config{
+		ModemHost:             config.DefaultModemHost,
+		CheckInterval:         30 * time.Second,
+		FailureThreshold:      1,
+		WorkingDirectory:      t.TempDir(),
+		LANFailureAction:      verdict.ActionNotify,
+		WANFailureAction:      verdict.ActionReboot,
+		UpstreamFailureAction: verdict.ActionWait,
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     gatewayDownChecker{},
+		ModemDriver: driver,
+		Notifiers:   []notify.Notifier{recorder},
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := service.RunCheck(context.Background()); err != nil {
+			t.Fatalf("RunCheck() failed: %v", err)
+		}
+	}
+	if driver.reboots != 0 {
+		t.Errorf("Expected no reboot for a LAN failure, got %d reboots", driver.reboots)
+	}
+	located := 0
+	for _, notification := range recorder.notifications {
+		if notification.Kind == notify.KindFailureLocated {
+			located++
+			if !strings.Contains(notification.Title, "lan failure") {
+				t.Errorf("Expected a LAN failure notification, got %q", notification.Title)
+			}
+		}
+	}
+	if located != 1 {
+		t.Errorf("Expected one failure notification per outage, got %d", located)
+	}
+
+	// The modem answers again, so the modem is to blame
+	driver.err = nil
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if driver.reboots != 1 {
+		t.Errorf("Expected a reboot for a WAN failure, got %d reboots", driver.reboots)
+	}
+}
+
+// stubPolicy answers with decision, recording what it was asked
+type stubPolicy struct {
+	decision PolicyDecision
+	err      error
+	inputs   []PolicyInput
+}
+
+func (p *stubPolicy) Name() string { return "stub" }
+
+func (p *stubPolicy) Decide(ctx context.Context, input PolicyInput) (PolicyDecision, error) {
+	p.inputs = append(p.inputs, input)
+	return p.decision, p.err
+}
+
+func TestDecisionPolicy(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	// The gateway is down but the modem answers: a WAN failure, which reboots
+	driver := &stubModemDriver{}
+	policy := &stubPolicy{decision: PolicyDecision{Action: verdict.ActionWait, Reason: "neighbours are offline too"}}
+	cfg := &config.Config{
+		ModemHost:        config.DefaultModemHost,
+		CheckInterval:    30 * time.Second,
+		FailureThreshold: 1,
+		WorkingDirectory: t.TempDir(),
+	}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Checker:     gatewayDownChecker{},
+		ModemDriver: driver,
+		Policy:      policy,
+	})
+
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if driver.reboots != 0 {
+		t.Errorf("Expected the policy to hold the reboot back, got %d reboots", driver.reboots)
+	}
+	if len(policy.inputs) != 1 {
+		t.Fatalf("Expected the policy to be asked once, got %d", len(policy.inputs))
+	}
+	input := policy.inputs[0]
+	if input.Decision.Outcome != DecisionReboot || input.Decision.Verdict != verdict.WAN || input.Action != verdict.ActionReboot || input.Decision.FailureCount != 1 {
+		t.Errorf("Expected the built-in decision to reboot for a WAN failure, got %+v", input)
+	}
+
+	// An empty action and a failed policy keep the built-in decision
+	policy.decision = PolicyDecision{}
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	policy.err = fmt.Errorf("policy crashed")
+	if err := service.RunCheck(context.Background()); err != nil {
+		t.Fatalf("RunCheck() failed: %v", err)
+	}
+	if driver.reboots != 2 {
+		t.Errorf("Expected the built-in decision to reboot twice, got %d reboots", driver.reboots)
+	}
+	if last := policy.inputs[len(policy.inputs)-1]; last.RecentReboots != 1 || last.RecentSkips != 1 {
+		t.Errorf("Expected the policy to see the earlier reboot and skip, got %d reboots and %d skips", last.RecentReboots, last.RecentSkips)
+	}
+
+	events, err := history.Read(cfg.HistoryPath(), time.Time{}, history.KindRebootDecision)
+	if err != nil || len(events) != 3 {
+		t.Fatalf("Expected three recorded decisions, got %d: %v", len(events), err)
+	}
+	if events[0].Details["policy"] != "stub" || events[0].Details["reason"] != "stub policy: neighbours are offline too" {
+		t.Errorf("Expected the decision of the policy to be recorded, got %v", events[0].Details)
+	}
+	if _, ok := events[1].Details["policy"]; ok {
+		t.Errorf("Expected the built-in decision to record no policy, got %v", events[1].Details)
+	}
+}
+
+func TestServiceWithoutStateDirectory(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cfg := &config.Config{
+		ModemHost:            config.DefaultModemHost,
+		CheckInterval:        30 * time.Second,
+		ConnectionTimeout:    time.Second,
+		OutageReportInterval: time.Hour,
+	}
+	service := NewService(cfg, logger)
+	if err := service.outageTracker.RecordOutageStart("test", nil); err != nil {
+		t.Fatalf("RecordOutageStart() failed: %v", err)
+	}
+	if err := service.outageTracker.RecordOutageEnd(); err != nil {
+		t.Fatalf("RecordOutageEnd() failed: %v", err)
+	}
+	if err := service.outageReporter.Prepare(); err != nil {
+		t.Fatalf("Prepare() failed: %v", err)
+	}
+	if err := history.Append(cfg.HistoryPath(), history.Event{Kind: history.KindRebootDecision}); err != nil {
+		t.Fatalf("Append() failed: %v", err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected nothing written to the current directory, got %d entries", len(entries))
+	}
+}
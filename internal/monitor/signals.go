@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"context"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/sirupsen/logrus"
+)
+
+// signalSnapshot sums up the modem's signal before a reboot. A modem that
+// does not answer during the outage is summed up from its last status.
+func (s *Service) signalSnapshot(ctx context.Context) *modem.SignalSummary {
+	status := s.readSignalStatus(ctx)
+	if status == nil {
+		status = s.modemStatus
+	}
+	summary, ok := modem.Summarize(status)
+	if !ok {
+		return nil
+	}
+	return &summary
+}
+
+// compareSignals compares the modem's signal after it recovered with the
+// signal before the reboot, logs the comparison and records it on the
+// current outage
+func (s *Service) compareSignals(ctx context.Context, before *modem.SignalSummary) {
+	if before == nil {
+		return
+	}
+	after, ok := modem.Summarize(s.readSignalStatus(ctx))
+	if !ok {
+		s.logger.Debug("Modem channels unreadable after recovery, signals not compared")
+		return
+	}
+
+	comparison := modem.CompareSignals(*before, after)
+	s.logger.WithFields(logrus.Fields{
+		"verdict":               comparison.Verdict,
+		"downstream_locked":     comparison.Delta.DownstreamLocked,
+		"downstream_snr_db":     comparison.Delta.DownstreamSNRDB,
+		"downstream_power_dbmv": comparison.Delta.DownstreamPowerDBmV,
+		"upstream_locked":       comparison.Delta.UpstreamLocked,
+		"upstream_power_dbmv":   comparison.Delta.UpstreamPowerDBmV,
+	}).Info("Compared modem signal before the reboot and after recovery")
+	if s.outageTracker != nil {
+		if err := s.outageTracker.SetSignals(comparison); err != nil {
+			s.logger.WithError(err).Warn("Failed to record signal comparison")
+		}
+	}
+}
+
+// readSignalStatus reads the modem's channels, or returns nil when the
+// modem does not answer
+func (s *Service) readSignalStatus(ctx context.Context) *modem.Status {
+	if s.modemDriver == nil {
+		return nil
+	}
+	statusCtx, cancel := context.WithTimeout(ctx, s.config.ConnectionTimeout)
+	defer cancel()
+	status, err := s.modemDriver.GetStatus(statusCtx)
+	if err != nil {
+		s.logger.WithError(err).Debug("Failed to read modem channels")
+		return nil
+	}
+	return status
+}
@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/backup"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+// SyncJobName is the scheduler job name of the remote sync
+const SyncJobName = "remote_sync"
+
+// scheduleSync registers the upload of the history and outage reports, if
+// an interval is configured
+func (s *Service) scheduleSync() error {
+	if s.config.SyncInterval <= 0 {
+		return nil
+	}
+	dest, err := backup.NewDestination(s.config.Sync(), outboundClient(s.config, s.opts, 5*time.Minute))
+	if err != nil {
+		return err
+	}
+	run := func(ctx context.Context) error {
+		return s.runSync(ctx, dest)
+	}
+	if err := s.scheduler.Add(SyncJobName, scheduler.Every(s.config.SyncInterval), run); err != nil {
+		return err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"destination": dest.String(),
+		"site":        s.config.SyncSiteName(),
+		"interval":    s.config.SyncInterval,
+	}).Info("Syncing history and outage reports")
+	return nil
+}
+
+// syncFiles returns the files uploaded by the remote sync, in the site's
+// folder: the history, the outages and the outage reports
+func (s *Service) syncFiles() []backup.SyncFile {
+	site := s.config.SyncSiteName()
+	files := []backup.SyncFile{
+		{Path: s.config.HistoryPath(), Name: path.Join(site, "history.jsonl")},
+		{Path: s.config.OutagesPath(), Name: path.Join(site, "outages.json")},
+	}
+	entries, err := os.ReadDir(s.config.ReportsPath())
+	if err != nil && !os.IsNotExist(err) {
+		s.logger.WithError(err).Warn("Failed to list outage reports for sync")
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && entry.Name()[0] != '.' {
+			files = append(files, backup.SyncFile{
+				Path: filepath.Join(s.config.ReportsPath(), entry.Name()),
+				Name: path.Join(site, "reports", entry.Name()),
+			})
+		}
+	}
+	return files
+}
+
+// runSync uploads the files that changed since the last sync to dest
+func (s *Service) runSync(ctx context.Context, dest backup.Destination) error {
+	// Events held in low-power mode belong in the upload
+	if err := history.Flush(); err != nil {
+		s.logger.WithError(err).Warn("Failed to write held history events before sync")
+	}
+
+	uploaded, err := backup.Sync(ctx, dest, s.config.StatePath("state", "sync.json"), s.syncFiles())
+	if err != nil {
+		s.logger.WithError(err).WithField("destination", dest.String()).Error("Failed to sync history and reports")
+		return err
+	}
+	if uploaded > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"destination": dest.String(),
+			"files":       uploaded,
+		}).Info("History and reports synced")
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// targetLookupTimeout bounds resolving the check targets at startup
+const targetLookupTimeout = 10 * time.Second
+
+// warnLocalTargets warns about ping and HTTP targets that resolve to the
+// local network or the modem: they keep answering while the internet is
+// down, so an outage would go unnoticed. Literal addresses are rejected by
+// the configuration already, unless AllowLocalTargets allows them all.
+func (s *Service) warnLocalTargets(ctx context.Context, cfg *config.Config) {
+	defer s.recoverPanic("local_targets")
+	if cfg.AllowLocalTargets || s.opts.Checker != nil {
+		return
+	}
+	lookup := s.opts.LookupIPAddr
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupIPAddr
+	}
+	ctx, cancel := context.WithTimeout(ctx, targetLookupTimeout)
+	defer cancel()
+	for _, target := range cfg.ResolveLocalTargets(ctx, lookup) {
+		s.logger.WithFields(logrus.Fields{
+			"setting": target.Setting,
+			"target":  target.Target,
+			"address": target.Address,
+		}).Warnf("%s; outages would go unnoticed, set ALLOW_LOCAL_TARGETS=true if that is intended", target)
+	}
+}
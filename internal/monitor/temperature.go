@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/diagnostics"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// CauseModemOverheating classifies an outage that started while the modem
+// was overheating, a known failure mode of the MB8600
+const CauseModemOverheating = "modem_overheating"
+
+// temperatureHysteresis is how far, in degrees Celsius, the temperature
+// must drop below ModemMaxTemperature for the modem to stop counting as
+// overheating, so a temperature hovering at the limit alerts once
+const temperatureHysteresis = 5.0
+
+// checkTemperature alerts when the modem status just read shows the modem
+// above ModemMaxTemperature. Only some firmwares show a temperature.
+func (s *Service) checkTemperature(ctx context.Context, status *modem.Status) {
+	limit := s.config.ModemMaxTemperature
+	if limit <= 0 || status.TemperatureC == nil {
+		return
+	}
+	temperature := *status.TemperatureC
+	fields := logrus.Fields{"temperature": temperature, "max_temperature": limit}
+
+	switch {
+	case temperature > limit && !s.modemOverheating:
+		s.modemOverheating = true
+		s.logger.WithFields(fields).Warn("Modem overheating")
+		s.notifier.Send(ctx, notify.KindModemOverheating, notify.Data{
+			Time:   s.clock.Now(),
+			Fields: map[string]interface{}{"model": status.Model, "temperature": temperature, "max_temperature": limit},
+		})
+	case temperature <= limit-temperatureHysteresis && s.modemOverheating:
+		s.modemOverheating = false
+		s.logger.WithFields(fields).Info("Modem temperature back to normal")
+	}
+}
+
+// attributeOverheating classifies the current failure as caused by an
+// overheating modem, unless the diagnostics blamed something other than
+// the modem, and returns reason with the temperature appended
+func (s *Service) attributeOverheating(reason string) string {
+	if !s.modemOverheating || s.modemStatus == nil || s.modemStatus.TemperatureC == nil {
+		return reason
+	}
+	if s.failureCause == diagnostics.CauseLocalWiFi || s.failureCause == diagnostics.CauseISPRouting {
+		return reason
+	}
+
+	s.failureCause = CauseModemOverheating
+	if s.outageTracker != nil {
+		if err := s.outageTracker.SetCause(CauseModemOverheating); err != nil {
+			s.logger.WithError(err).Warn("Failed to record outage cause")
+		}
+	}
+	return fmt.Sprintf("%s; modem overheating at %.1f°C", reason, *s.modemStatus.TemperatureC)
+}
@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"context"
+	"io"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/performance"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
+	"github.com/perezjoseph/mb8600-watchdog/internal/textfile"
+	"github.com/sirupsen/logrus"
+)
+
+// TextfileJobName is the scheduler job name of the node_exporter textfile
+const TextfileJobName = "textfile"
+
+// scheduleTextfile registers the rewriting of the node_exporter textfile, if
+// a path is configured, and writes it right away so the collector does not
+// wait an interval for the first metrics
+func (s *Service) scheduleTextfile() error {
+	if s.config.TextfilePath == "" {
+		return nil
+	}
+	if err := s.scheduler.Add(TextfileJobName, scheduler.Every(s.config.TextfileInterval), s.writeTextfile); err != nil {
+		return err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"path":     s.config.TextfilePath,
+		"interval": s.config.TextfileInterval,
+	}).Info("Writing metrics for the node_exporter textfile collector")
+	// A failure is logged, and the job tries again
+	s.writeTextfile(context.Background())
+	return nil
+}
+
+// writeTextfile replaces the textfile with the current metrics: those of
+// /api/v1/metrics, so dashboards work with either
+func (s *Service) writeTextfile(ctx context.Context) error {
+	if s.config.TextfilePath == "" {
+		return nil
+	}
+	state := s.Snapshot()
+	err := textfile.Write(s.config.TextfilePath, func(w io.Writer) error {
+		if err := WriteStateMetrics(w, state); err != nil {
+			return err
+		}
+		if err := WriteRebootCounters(w, state); err != nil {
+			return err
+		}
+		if err := performance.WritePrometheus(w, s.PerformanceMetrics()); err != nil {
+			return err
+		}
+		return modem.WritePrometheus(w, s.ModemStatus())
+	})
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to write metrics textfile")
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"context"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/sirupsen/logrus"
+)
+
+// countTimeouts reads the modem's event log, counts its T3 and T4 timeouts
+// and returns status with the counts. Drivers that cannot read the log
+// leave status as it is; a failed read keeps the counts so far.
+func (s *Service) countTimeouts(ctx context.Context, status *modem.Status) *modem.Status {
+	logCtx, cancel := context.WithTimeout(ctx, s.config.ConnectionTimeout)
+	defer cancel()
+
+	events, ok, err := modem.EventLog(logCtx, s.modemDriver)
+	if !ok {
+		return status
+	}
+	if s.timeouts == nil {
+		s.timeouts = modem.NewTimeoutCounter()
+	}
+	now := s.clock.Now()
+	if err != nil {
+		s.logger.WithError(err).Debug("Failed to read modem event log")
+	} else {
+		s.timeouts.Observe(events, now)
+	}
+
+	counts := s.timeouts.Counts(now)
+	exceeded := s.timeoutsExceeded(counts)
+	fields := logrus.Fields{"t3_hour": counts.T3Hour, "t3_day": counts.T3Day, "t4_hour": counts.T4Hour, "t4_day": counts.T4Day}
+	switch {
+	case exceeded && !s.timeoutsOverThreshold:
+		s.logger.WithFields(fields).Warn("Modem logging DOCSIS timeouts above the threshold, the upstream may be failing")
+	case !exceeded && s.timeoutsOverThreshold:
+		s.logger.WithFields(fields).Info("DOCSIS timeouts back below the threshold")
+	}
+	s.timeoutsOverThreshold = exceeded
+
+	updated := *status
+	updated.Timeouts = &counts
+	return &updated
+}
+
+// timeoutsExceeded reports whether counts reach the hourly or daily
+// threshold
+func (s *Service) timeoutsExceeded(counts modem.TimeoutCounts) bool {
+	perHour, perDay := s.config.DOCSISTimeoutsPerHour, s.config.DOCSISTimeoutsPerDay
+	return (perHour > 0 && counts.Hour() >= perHour) || (perDay > 0 && counts.Day() >= perDay)
+}
+
+// timeoutsRate returns the docsis_timeouts health input: 1 less the
+// largest share of a threshold the timeouts reached, down to 0. Without
+// timeouts counted, the score is left as the checks made it.
+func (s *Service) timeoutsRate() (float64, bool) {
+	if s.modemStatus == nil || s.modemStatus.Timeouts == nil || s.modemStatus.Timeouts.Day() == 0 {
+		return 0, false
+	}
+	counts := *s.modemStatus.Timeouts
+	share, windows := 0.0, 0
+	for _, window := range []struct{ count, threshold int }{
+		{counts.Hour(), s.config.DOCSISTimeoutsPerHour},
+		{counts.Day(), s.config.DOCSISTimeoutsPerDay},
+	} {
+		if window.threshold <= 0 {
+			continue
+		}
+		windows++
+		if reached := float64(window.count) / float64(window.threshold); reached > share {
+			share = reached
+		}
+	}
+	if windows == 0 {
+		return 0, false
+	}
+	if share > 1 {
+		share = 1
+	}
+	return 1 - share, true
+}
@@ -0,0 +1,47 @@
+package monitor
+
+import (
+	"context"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/diagnostics"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/perezjoseph/mb8600-watchdog/internal/verdict"
+)
+
+// locateFailure locates the failure of the current check from the gateway
+// check, a login to the modem and the cause the diagnostics found
+func (s *Service) locateFailure(ctx context.Context) verdict.Result {
+	signals := verdict.Signals{
+		LocalWiFi:       s.failureCause == diagnostics.CauseLocalWiFi,
+		PartialUpstream: s.failureCause == diagnostics.CauseISPRouting,
+	}
+	if result := s.lastTestResult; result != nil && result.LightweightResult != nil {
+		for _, gateway := range result.LightweightResult.GatewayResults {
+			signals.GatewayChecked = true
+			signals.GatewayReachable = signals.GatewayReachable || gateway.Success
+		}
+	}
+	if s.modemDriver != nil {
+		loginCtx, cancel := context.WithTimeout(ctx, s.config.ConnectionTimeout)
+		signals.ModemReachable = s.modemDriver.Login(loginCtx) == nil
+		cancel()
+	}
+	return verdict.Locate(signals)
+}
+
+// notifyFailure sends a failure_located notification for located, once per
+// verdict and outage
+func (s *Service) notifyFailure(ctx context.Context, located verdict.Result) {
+	if s.notifiedVerdict == located.Verdict {
+		return
+	}
+	s.notifiedVerdict = located.Verdict
+	s.notifier.Send(ctx, notify.KindFailureLocated, notify.Data{
+		Time: s.clock.Now(),
+		Fields: map[string]interface{}{
+			"verdict":       located.Verdict,
+			"reason":        located.Reason,
+			"failure_count": s.failureCount,
+		},
+	})
+}
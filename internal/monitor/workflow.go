@@ -0,0 +1,165 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/sirupsen/logrus"
+)
+
+// Reboot workflow phases
+const (
+	// RebootPhaseRebooting is the reboot command and the wait for the modem
+	// to go offline and come back
+	RebootPhaseRebooting = "rebooting"
+	// RebootPhaseRecovering is the recovery wait after the reboot
+	RebootPhaseRecovering = "recovering"
+)
+
+// RebootWorkflow is a reboot in progress. The reboot and the recovery wait
+// after it run under one deadline, whichever trigger started them.
+type RebootWorkflow struct {
+	Trigger  string    `json:"trigger"`
+	Reason   string    `json:"reason,omitempty"`
+	Phase    string    `json:"phase"`
+	Started  time.Time `json:"started"`
+	Deadline time.Time `json:"deadline"`
+}
+
+// Remaining returns how long the workflow may still take as of now
+func (w RebootWorkflow) Remaining(now time.Time) time.Duration {
+	if remaining := w.Deadline.Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// rebootRun is a running reboot workflow; done closes when it ends
+type rebootRun struct {
+	workflow RebootWorkflow
+	done     chan struct{}
+}
+
+// runRebootWorkflow runs reboot, and the recovery wait after it if recovery
+// is set, as one workflow with the deadline of Config.RebootDeadline. The
+// workflow does not inherit the caller's context, so a check timeout cannot
+// cut a reboot short; a shutdown waits for it or aborts it according to
+// RebootShutdownPolicy. After the recovery wait, the signal is compared
+// with the signal before the reboot.
+func (s *Service) runRebootWorkflow(trigger, reason string, recovery bool, reboot func(ctx context.Context) error) error {
+	ctx, finish := s.startRebootWorkflow(trigger, reason)
+	defer finish()
+
+	var before *modem.SignalSummary
+	if recovery {
+		before = s.signalSnapshot(ctx)
+	}
+	if err := reboot(ctx); err != nil {
+		return err
+	}
+	if !recovery {
+		return nil
+	}
+
+	s.setRebootPhase(RebootPhaseRecovering)
+	s.logger.WithField("recovery_wait", s.config.RecoveryWait).Info("Waiting for modem recovery")
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("context cancelled during recovery wait: %w", ctx.Err())
+	case <-s.clock.After(s.config.RecoveryWait):
+		s.logger.Debug("Recovery wait period completed")
+	}
+	s.compareSignals(ctx, before)
+	return nil
+}
+
+// startRebootWorkflow makes a reboot workflow the one in progress and
+// returns its context along with the function that ends it
+func (s *Service) startRebootWorkflow(trigger, reason string) (context.Context, func()) {
+	timeout := s.config.RebootDeadline()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	now := s.clock.Now()
+	run := &rebootRun{
+		workflow: RebootWorkflow{
+			Trigger:  trigger,
+			Reason:   reason,
+			Phase:    RebootPhaseRebooting,
+			Started:  now,
+			Deadline: now.Add(timeout),
+		},
+		done: make(chan struct{}),
+	}
+
+	s.rebootMu.Lock()
+	s.activeReboot = run
+	stopping := s.stopping
+	s.rebootMu.Unlock()
+
+	if s.config.RebootShutdownPolicy == config.RebootShutdownAbort && stopping != nil {
+		go func() {
+			select {
+			case <-stopping:
+				s.logger.WithField("trigger", trigger).Warn("Shutting down, aborting reboot in progress")
+				cancel()
+			case <-run.done:
+			}
+		}()
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"trigger":  trigger,
+		"deadline": run.workflow.Deadline,
+	}).Debug("Reboot workflow started")
+	return ctx, func() {
+		cancel()
+		s.rebootMu.Lock()
+		s.activeReboot = nil
+		s.rebootMu.Unlock()
+		close(run.done)
+	}
+}
+
+// setRebootPhase moves the reboot workflow in progress to phase
+func (s *Service) setRebootPhase(phase string) {
+	s.rebootMu.Lock()
+	defer s.rebootMu.Unlock()
+	if s.activeReboot != nil {
+		s.activeReboot.workflow.Phase = phase
+	}
+}
+
+// ActiveReboot returns the reboot workflow in progress, or nil when the
+// modem is not being rebooted
+func (s *Service) ActiveReboot() *RebootWorkflow {
+	s.rebootMu.Lock()
+	defer s.rebootMu.Unlock()
+	if s.activeReboot == nil {
+		return nil
+	}
+	workflow := s.activeReboot.workflow
+	return &workflow
+}
+
+// waitForReboot blocks until the reboot workflow in progress, if any, ends.
+// Under the abort shutdown policy a stopping service has aborted it already.
+func (s *Service) waitForReboot() {
+	s.rebootMu.Lock()
+	run := s.activeReboot
+	var workflow RebootWorkflow
+	if run != nil {
+		workflow = run.workflow
+	}
+	s.rebootMu.Unlock()
+	if run == nil {
+		return
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"phase":     workflow.Phase,
+		"remaining": workflow.Remaining(s.clock.Now()).Round(time.Second),
+	}).Warn("Waiting for the reboot in progress to finish before stopping")
+	<-run.done
+}
@@ -0,0 +1,111 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+func newWorkflowTestService(t *testing.T, fake *clock.Fake, policy string) (*Service, *stubModemDriver) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.Config{
+		ModemHost:            config.DefaultModemHost,
+		CheckInterval:        30 * time.Second,
+		FailureThreshold:     3,
+		RecoveryWait:         5 * time.Minute,
+		WorkingDirectory:     t.TempDir(),
+		RebootShutdownPolicy: policy,
+	}
+	driver := &stubModemDriver{}
+	service := NewServiceWithOptions(cfg, logger, Options{
+		Clock:       fake,
+		Checker:     failingChecker{},
+		ModemDriver: driver,
+	})
+	return service, driver
+}
+
+func TestRebootWorkflowDeadline(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	service, driver := newWorkflowTestService(t, fake, config.RebootShutdownWait)
+
+	done := make(chan error, 1)
+	go func() { done <- service.RunScheduledReboot(context.Background()) }()
+	fake.BlockUntil(1)
+
+	workflow := service.ActiveReboot()
+	if workflow == nil {
+		t.Fatal("Expected a reboot in progress during the recovery wait")
+	}
+	if workflow.Phase != RebootPhaseRecovering || workflow.Trigger != TriggerScheduled {
+		t.Errorf("Expected a scheduled reboot recovering, got %+v", workflow)
+	}
+	if want := config.DefaultRebootCommandTimeout + 5*time.Minute; workflow.Remaining(fake.Now()) != want {
+		t.Errorf("Expected %v remaining, got %v", want, workflow.Remaining(fake.Now()))
+	}
+	if service.Snapshot().Reboot == nil {
+		t.Error("Expected the reboot in progress in the published state")
+	}
+
+	fake.Advance(5 * time.Minute)
+	if err := <-done; err != nil {
+		t.Fatalf("RunScheduledReboot() failed: %v", err)
+	}
+	if driver.reboots != 1 || service.ActiveReboot() != nil {
+		t.Errorf("Expected one finished reboot, got %d reboots and %+v", driver.reboots, service.ActiveReboot())
+	}
+}
+
+func TestRebootWorkflowShutdownPolicy(t *testing.T) {
+	tests := []struct {
+		policy string
+		abort  bool
+	}{
+		{config.RebootShutdownWait, false},
+		{config.RebootShutdownAbort, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			service, _ := newWorkflowTestService(t, fake, tt.policy)
+			stopping := make(chan struct{})
+			service.stopping = stopping
+
+			done := make(chan error, 1)
+			go func() { done <- service.RunScheduledReboot(context.Background()) }()
+			fake.BlockUntil(1)
+			close(stopping)
+
+			if tt.abort {
+				if err := <-done; !errors.Is(err, context.Canceled) {
+					t.Errorf("Expected the reboot to be aborted, got %v", err)
+				}
+				return
+			}
+
+			waited := make(chan struct{})
+			go func() {
+				service.waitForReboot()
+				close(waited)
+			}()
+			select {
+			case <-waited:
+				t.Fatal("Expected shutdown to wait for the recovery wait")
+			case <-time.After(50 * time.Millisecond):
+			}
+			fake.Advance(5 * time.Minute)
+			if err := <-done; err != nil {
+				t.Errorf("Expected the reboot to finish, got %v", err)
+			}
+			<-waited
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Notification is a rendered message
+type Notification struct {
+	Kind  Kind      `json:"kind"`
+	Time  time.Time `json:"time"`
+	Title string    `json:"title"`
+	Body  string    `json:"body"`
+	// Delayed marks a notification delivered from the queue after it could
+	// not be delivered on time
+	Delayed bool `json:"delayed,omitempty"`
+}
+
+// Notifier delivers notifications
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// LogNotifier writes notifications to the application log
+type LogNotifier struct {
+	logger *logrus.Logger
+}
+
+// NewLogNotifier creates a notifier writing to logger
+func NewLogNotifier(logger *logrus.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+// Notify logs the notification
+func (n *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.logger.WithFields(logrus.Fields{
+		"notification": notification.Kind,
+		"body":         notification.Body,
+	}).Info(notification.Title)
+	return nil
+}
+
+// Dispatcher renders messages and sends them to every notifier
+type Dispatcher struct {
+	templates *Templates
+	notifiers []Notifier
+	logger    *logrus.Logger
+	hostname  string
+	// queue keeps notifications that notifiers failed to deliver, nil to
+	// drop them
+	queue *Queue
+}
+
+// NewDispatcher creates a dispatcher rendering with templates; the default
+// templates are used when templates is nil
+func NewDispatcher(templates *Templates, logger *logrus.Logger, notifiers ...Notifier) *Dispatcher {
+	if templates == nil {
+		templates = DefaultTemplates()
+	}
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.InfoLevel)
+	}
+	hostname, _ := os.Hostname()
+
+	return &Dispatcher{
+		templates: templates,
+		notifiers: notifiers,
+		logger:    logger,
+		hostname:  hostname,
+	}
+}
+
+// Templates returns the templates the dispatcher renders with
+func (d *Dispatcher) Templates() *Templates {
+	return d.templates
+}
+
+// SetQueue makes the dispatcher queue notifications that a notifier fails
+// to deliver, for Flush to deliver later; nil drops them
+func (d *Dispatcher) SetQueue(queue *Queue) {
+	d.queue = queue
+}
+
+// Pending returns the number of queued notifications
+func (d *Dispatcher) Pending() int {
+	if d.queue == nil {
+		return 0
+	}
+	return d.queue.Len()
+}
+
+// Flush delivers the queued notifications, oldest first, with a note that
+// they are delayed, and returns how many were delivered. Those that fail
+// again stay queued.
+func (d *Dispatcher) Flush(ctx context.Context) (int, error) {
+	if d.queue == nil {
+		return 0, nil
+	}
+	entries := d.queue.Take()
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	var failed []QueuedNotification
+	for _, entry := range entries {
+		// The notifier is gone when the configuration changed
+		if entry.Notifier < 0 || entry.Notifier >= len(d.notifiers) {
+			continue
+		}
+		notification := entry.Notification
+		if !notification.Delayed {
+			notification.Delayed = true
+			if note, _, err := d.templates.Render(KindDelayed, Data{Time: notification.Time}); err == nil {
+				notification.Body = strings.TrimSpace(notification.Body + "\n\n" + note)
+			}
+		}
+		if err := d.notifiers[entry.Notifier].Notify(ctx, notification); err != nil {
+			d.logger.WithError(err).WithField("notification", entry.Kind).Debug("Queued notification still undeliverable")
+			failed = append(failed, entry)
+		}
+	}
+	if err := d.queue.Requeue(failed); err != nil {
+		return len(entries) - len(failed), err
+	}
+	if len(failed) > 0 {
+		return len(entries) - len(failed), fmt.Errorf("%d of %d queued notifications still undeliverable", len(failed), len(entries))
+	}
+	return len(entries), nil
+}
+
+// Enabled reports whether the dispatcher has notifiers to deliver to
+func (d *Dispatcher) Enabled() bool {
+	return len(d.notifiers) > 0
+}
+
+// Send renders the message of kind and delivers it to every notifier. Delivery
+// failures are logged and returned, but do not stop delivery to the others.
+// Without notifiers nothing is rendered.
+func (d *Dispatcher) Send(ctx context.Context, kind Kind, data Data) error {
+	if len(d.notifiers) == 0 {
+		return nil
+	}
+	if data.Time.IsZero() {
+		data.Time = time.Now()
+	}
+	if data.Hostname == "" {
+		data.Hostname = d.hostname
+	}
+
+	title, body, err := d.templates.Render(kind, data)
+	if err != nil {
+		d.logger.WithError(err).WithField("notification", kind).Error("Failed to render notification")
+		return err
+	}
+
+	if data.Test {
+		title = TestPrefix + title
+	}
+	notification := Notification{Kind: kind, Time: data.Time, Title: title, Body: body}
+
+	var failed int
+	for i, notifier := range d.notifiers {
+		if err := notifier.Notify(ctx, notification); err != nil {
+			failed++
+			d.logger.WithError(err).WithField("notification", kind).Warn("Failed to deliver notification")
+			d.enqueue(notification, i, data.Test)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d notifiers failed to deliver %s", failed, len(d.notifiers), kind)
+	}
+	return nil
+}
+
+// enqueue queues notification for the notifier at index, except test
+// notifications, which are only useful right away
+func (d *Dispatcher) enqueue(notification Notification, index int, test bool) {
+	if d.queue == nil || test {
+		return
+	}
+	if err := d.queue.Add(QueuedNotification{Notification: notification, Notifier: index}); err != nil {
+		d.logger.WithError(err).WithField("notification", notification.Kind).Error("Failed to queue notification")
+		return
+	}
+	d.logger.WithField("notification", notification.Kind).Info("Queued notification for delivery once connectivity returns")
+}
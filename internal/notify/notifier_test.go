@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type recordingNotifier struct {
+	notifications []Notification
+	err           error
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, notification Notification) error {
+	r.notifications = append(r.notifications, notification)
+	return r.err
+}
+
+func TestDispatcherSend(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	first := &recordingNotifier{err: errors.New("unreachable")}
+	second := &recordingNotifier{}
+	dispatcher := NewDispatcher(nil, logger, first, second, NewLogNotifier(logger))
+
+	err := dispatcher.Send(context.Background(), KindRebootFailed, Data{
+		Fields: map[string]interface{}{"error": "modem did not answer"},
+	})
+	if err == nil {
+		t.Error("Expected an error when a notifier fails")
+	}
+
+	// A failing notifier does not stop delivery to the others
+	if len(second.notifications) != 1 {
+		t.Fatalf("Expected one notification, got %d", len(second.notifications))
+	}
+	notification := second.notifications[0]
+	if notification.Kind != KindRebootFailed || notification.Title != "Modem reboot failed" {
+		t.Errorf("Unexpected notification %+v", notification)
+	}
+	if notification.Body != "The reboot command failed: modem did not answer" {
+		t.Errorf("Unexpected body %q", notification.Body)
+	}
+	if notification.Time.IsZero() {
+		t.Error("Notification time should default to now")
+	}
+}
+
+func TestDispatcherQueuesUndeliverableNotifications(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	path := filepath.Join(t.TempDir(), "notification-queue.json")
+	queue, err := NewQueue(path, 2)
+	if err != nil {
+		t.Fatalf("NewQueue() failed: %v", err)
+	}
+	offline := &recordingNotifier{err: errors.New("no route to host")}
+	online := &recordingNotifier{}
+	dispatcher := NewDispatcher(nil, logger, offline, online)
+	dispatcher.SetQueue(queue)
+
+	started := time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC)
+	dispatcher.Send(context.Background(), KindOutageStarted, Data{Time: started})
+	dispatcher.Send(context.Background(), KindRebootTriggered, Data{Fields: map[string]interface{}{"failure_count": 3}})
+	dispatcher.Send(context.Background(), KindRebootFailed, Data{Fields: map[string]interface{}{"error": "timeout"}})
+	dispatcher.Send(context.Background(), KindOutageStarted, Data{Test: true})
+
+	// Only the failing notifier's notifications are queued, at most 2 and
+	// never test notifications; the queue survives a restart
+	if dispatcher.Pending() != 2 {
+		t.Fatalf("Expected 2 queued notifications, got %d", dispatcher.Pending())
+	}
+	if queue, err = NewQueue(path, 2); err != nil || queue.Len() != 2 {
+		t.Fatalf("Expected the queue to be reloaded, got %v", err)
+	}
+	dispatcher.SetQueue(queue)
+
+	// Still offline: nothing is lost
+	if delivered, err := dispatcher.Flush(context.Background()); delivered != 0 || err == nil {
+		t.Errorf("Expected no delivery while offline, got %d (%v)", delivered, err)
+	}
+	if dispatcher.Pending() != 2 {
+		t.Errorf("Expected the notifications to stay queued, got %d", dispatcher.Pending())
+	}
+
+	offline.err = nil
+	offline.notifications = nil
+	if delivered, err := dispatcher.Flush(context.Background()); delivered != 2 || err != nil {
+		t.Fatalf("Expected 2 delivered notifications, got %d (%v)", delivered, err)
+	}
+	if len(offline.notifications) != 2 || offline.notifications[0].Kind != KindRebootTriggered {
+		t.Fatalf("Expected the newest 2 notifications in order, got %+v", offline.notifications)
+	}
+	delayed := offline.notifications[0]
+	if !delayed.Delayed || !strings.Contains(delayed.Body, "could not be delivered until now") {
+		t.Errorf("Expected a delayed note, got %+v", delayed)
+	}
+	if dispatcher.Pending() != 0 {
+		t.Errorf("Expected an empty queue, got %d", dispatcher.Pending())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the empty queue file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultQueueSize is how many undeliverable notifications are kept
+const DefaultQueueSize = 100
+
+// QueuedNotification is a notification waiting to be delivered again to the
+// notifier at position Notifier of the dispatcher
+type QueuedNotification struct {
+	Notification
+	Notifier int `json:"notifier"`
+}
+
+// Queue keeps notifications that could not be delivered, usually because the
+// internet was down, in a file so they survive restarts. Beyond its size the
+// oldest are dropped.
+type Queue struct {
+	mu      sync.Mutex
+	path    string
+	size    int
+	entries []QueuedNotification
+}
+
+// NewQueue creates a queue of at most size notifications stored in path,
+// loading the notifications queued by a previous run
+func NewQueue(path string, size int) (*Queue, error) {
+	q := &Queue{path: path, size: size}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification queue: %w", err)
+	}
+	if err := json.Unmarshal(data, &q.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse notification queue %s: %w", path, err)
+	}
+	q.trim()
+	return q, nil
+}
+
+// Len returns the number of queued notifications
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// Add queues entry, dropping the oldest notification when the queue is full
+func (q *Queue) Add(entry QueuedNotification) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.entries = append(q.entries, entry)
+	q.trim()
+	return q.save()
+}
+
+// Take removes and returns every queued notification, oldest first; Requeue
+// puts back the ones that still cannot be delivered
+func (q *Queue) Take() []QueuedNotification {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entries := q.entries
+	q.entries = nil
+	return entries
+}
+
+// Requeue puts entries taken from the queue back in front of the
+// notifications queued since
+func (q *Queue) Requeue(entries []QueuedNotification) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.entries = append(entries, q.entries...)
+	q.trim()
+	return q.save()
+}
+
+// trim drops the oldest notifications beyond the queue size; the caller
+// holds mu
+func (q *Queue) trim() {
+	if q.size > 0 && len(q.entries) > q.size {
+		q.entries = append([]QueuedNotification(nil), q.entries[len(q.entries)-q.size:]...)
+	}
+}
+
+// save writes the queue to its file, removing the file when the queue is
+// empty; the caller holds mu
+func (q *Queue) save() error {
+	if len(q.entries) == 0 {
+		if err := os.Remove(q.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove notification queue: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(q.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode notification queue: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return fmt.Errorf("failed to create notification queue directory: %w", err)
+	}
+	tempFile := q.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write notification queue: %w", err)
+	}
+	if err := os.Rename(tempFile, q.path); err != nil {
+		return fmt.Errorf("failed to rename notification queue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+// Package notify renders notification and report messages from Go templates
+// and delivers them to notifiers. Every message has a built-in default that
+// users can override from template files referenced in the configuration.
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Kind identifies a message
+type Kind string
+
+// Message kinds
+const (
+	KindOutageStarted     Kind = "outage_started"
+	KindOutageResolved    Kind = "outage_resolved"
+	KindRebootTriggered   Kind = "reboot_triggered"
+	KindRebootFailed      Kind = "reboot_failed"
+	KindReport            Kind = "report"
+	KindCrashed           Kind = "crashed"
+	KindMonitoringPaused  Kind = "monitoring_paused"
+	KindMonitoringResumed Kind = "monitoring_resumed"
+	KindFirmwareChanged   Kind = "firmware_changed"
+	KindStartupReport     Kind = "startup_report"
+	KindHostDegraded      Kind = "host_degraded"
+	KindFailureLocated    Kind = "failure_located"
+	KindPartialService    Kind = "partial_service"
+	KindModemOverheating  Kind = "modem_overheating"
+)
+
+// KindDelayed is the note appended to a notification delivered from the
+// queue after it could not be delivered on time; it is never sent alone
+const KindDelayed Kind = "delayed"
+
+// Kinds lists every message kind
+var Kinds = []Kind{KindOutageStarted, KindOutageResolved, KindRebootTriggered, KindRebootFailed, KindReport, KindCrashed, KindMonitoringPaused, KindMonitoringResumed, KindFirmwareChanged, KindStartupReport, KindHostDegraded, KindFailureLocated, KindPartialService, KindModemOverheating}
+
+// Data is passed to message templates
+type Data struct {
+	Kind     Kind
+	Time     time.Time
+	Hostname string
+	// Event is the outage event the message is about, if any
+	Event interface{}
+	// Statistics are the outage statistics of the reporting period
+	Statistics interface{}
+	// Diagnostics is the diagnostic analysis behind a reboot decision
+	Diagnostics interface{}
+	// Fields holds additional values such as the failure count or an error
+	Fields map[string]interface{}
+	// Test marks a synthetic message sent to check the notification setup;
+	// its title is prefixed with TestPrefix
+	Test bool
+}
+
+// TestPrefix starts the title of test messages
+const TestPrefix = "[TEST] "
+
+// defaultTemplates are the built-in messages. The first line of a rendered
+// message is its title, the remaining lines its body.
+var defaultTemplates = map[Kind]string{
+	KindOutageStarted: `Internet connectivity lost
+Connectivity checks started failing at {{datetime .Time}}{{with .Fields.strategy}} (strategy: {{.}}){{end}}.`,
+
+	KindOutageResolved: `Internet connectivity restored
+{{with .Event}}Connectivity returned after {{duration .Duration}} (outage started {{datetime .StartTime}}).{{else}}Connectivity returned at {{datetime .Time}}.{{end}}`,
+
+	KindRebootTriggered: `Rebooting modem
+{{with .Fields.reason}}Reboot requested: {{.}}{{else}}{{.Fields.failure_count}} consecutive connectivity checks failed.{{end}}
+{{- with .Diagnostics}}
+Diagnostics: {{.SuccessfulTests}}/{{.TotalTests}} tests passed ({{percent .OverallSuccessRate}}).
+{{- range .Recommendations}}
+- {{.}}
+{{- end}}
+{{- end}}`,
+
+	KindRebootFailed: `Modem reboot failed
+The reboot command failed: {{.Fields.error}}`,
+
+	KindCrashed: `Watchdog crashed
+The watchdog stopped after a panic: {{.Fields.panic}}{{with .Fields.report}}
+Crash report: {{.}}{{end}}`,
+
+	KindMonitoringPaused: `Monitoring paused
+Automatic reboots{{if .Fields.checks}} and connectivity checks{{end}} are paused{{with .Fields.until}} until {{datetime .}}{{end}}{{with .Fields.by}} by {{.}}{{end}}.{{with .Fields.reason}}
+Reason: {{.}}{{end}}`,
+
+	KindMonitoringResumed: `Monitoring resumed
+Automatic reboots are enabled again{{with .Fields.by}}, resumed by {{.}}{{end}}.`,
+
+	KindFirmwareChanged: `Modem firmware changed
+The modem{{with .Fields.model}} {{.}}{{end}} now runs firmware {{.Fields.new_version}}, previously {{.Fields.old_version}}. ISPs push firmware without notice and it can break reboots; check that the next reboot works.`,
+
+	KindStartupReport: `Watchdog startup check {{if .Fields.failed}}failed{{else}}found warnings{{end}}
+{{- range .Fields.problems}}
+- {{.Name}}: {{.Detail}}
+{{- end}}`,
+
+	KindHostDegraded: `Monitoring host degraded
+The watchdog's own host looks broken, so the modem is not rebooted after {{.Fields.failure_count}} failed checks.
+{{- range .Fields.problems}}
+- {{.Name}}: {{.Detail}}
+{{- end}}`,
+
+	KindFailureLocated: `Internet down, {{.Fields.verdict}} failure
+{{.Fields.reason}}. The modem is not rebooted; the watchdog keeps checking.`,
+
+	KindPartialService: `Modem in partial service
+{{.Fields.unlocked}} of {{.Fields.channels}} downstream channels are not locked, so the internet works but slowly.
+{{- if .Fields.reboot_after}} The modem is rebooted if this lasts {{duration .Fields.reboot_after}}.{{end}}`,
+
+	KindModemOverheating: `Modem overheating
+The modem{{with .Fields.model}} {{.}}{{end}} is at {{printf "%.1f" .Fields.temperature}}°C, above {{printf "%.1f" .Fields.max_temperature}}°C. Overheating modems drop their connection; give it airflow and keep it out of the sun.`,
+
+	KindDelayed: `Delayed: this notification from {{datetime .Time}} could not be delivered until now.`,
+
+	KindReport: `{{with .Statistics}}{{if eq .TotalOutages 0}}No outages recorded during the period from {{datetime .ReportPeriodStart}} to {{datetime .ReportPeriodEnd}}. Uptime: {{percent .UptimePercentage}}{{else}}Period: {{datetime .ReportPeriodStart}} to {{datetime .ReportPeriodEnd}} | Total outages: {{.TotalOutages}} | Total downtime: {{duration .TotalDowntime}} | Average outage: {{duration .AverageOutageDuration}} | Longest outage: {{duration .LongestOutage}} | Uptime: {{percent .UptimePercentage}}{{end}}{{end}}`,
+}
+
+// localizedTemplates are the built-in messages of languages other than
+// English; kinds missing from a language fall back to English
+var localizedTemplates = map[string]map[Kind]string{
+	"es": spanishTemplates,
+}
+
+// templateFuncs are available in every template
+var templateFuncs = template.FuncMap{
+	"duration": formatDuration,
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"percent":  func(value float64) string { return fmt.Sprintf("%.2f%%", value) },
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+}
+
+// Templates is a catalog of message templates
+type Templates struct {
+	tmpl *template.Template
+}
+
+// DefaultTemplates returns the built-in English messages
+func DefaultTemplates() *Templates {
+	return LocalizedTemplates("")
+}
+
+// LocalizedTemplates returns the built-in messages in language, falling back
+// to English for unsupported languages
+func LocalizedTemplates(language string) *Templates {
+	t, err := newTemplates(language)
+	if err != nil {
+		panic(fmt.Sprintf("notify: invalid default template: %v", err))
+	}
+	return t
+}
+
+func newTemplates(language string) (*Templates, error) {
+	root := template.New("messages").Funcs(templateFuncs).Option("missingkey=zero")
+	for kind, text := range defaultTemplates {
+		if localized, ok := localizedTemplates[language][kind]; ok {
+			text = localized
+		}
+		if _, err := root.New(string(kind)).Parse(text); err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", kind, err)
+		}
+	}
+	return &Templates{tmpl: root}, nil
+}
+
+// LoadTemplates returns the built-in English messages overridden by the
+// templates in path, a file or a directory of *.tmpl files. Overrides are
+// written as {{define "outage_started"}}...{{end}} blocks named after the
+// message kind.
+func LoadTemplates(path string) (*Templates, error) {
+	return LoadLocalizedTemplates(path, "")
+}
+
+// LoadLocalizedTemplates is LoadTemplates with the built-in messages in language
+func LoadLocalizedTemplates(path, language string) (*Templates, error) {
+	t, err := newTemplates(language)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return t, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message templates: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.tmpl"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list message templates: %w", err)
+		}
+		sort.Strings(files)
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message template %s: %w", file, err)
+		}
+		if _, err := t.tmpl.New(filepath.Base(file)).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("failed to parse message template %s: %w", file, err)
+		}
+	}
+	return t, nil
+}
+
+// Render renders the message of kind and splits it into title and body
+func (t *Templates) Render(kind Kind, data Data) (string, string, error) {
+	if data.Kind == "" {
+		data.Kind = kind
+	}
+	if data.Fields == nil {
+		data.Fields = map[string]interface{}{}
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.ExecuteTemplate(&buf, string(kind), data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s message: %w", kind, err)
+	}
+
+	text := strings.TrimSpace(buf.String())
+	title, body, _ := strings.Cut(text, "\n")
+	return strings.TrimSpace(title), strings.TrimSpace(body), nil
+}
+
+// Validate renders every message with sample data, so template errors are
+// reported at startup instead of when an outage happens
+func (t *Templates) Validate() error {
+	for _, kind := range append(Kinds, KindDelayed) {
+		if _, _, err := t.Render(kind, Data{Time: time.Now()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatDuration formats a duration in a human-readable way
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+
+	if d < time.Hour {
+		return fmt.Sprintf("%.1fm", d.Minutes())
+	}
+
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%.1fh", d.Hours())
+	}
+
+	return fmt.Sprintf("%.1fd", d.Hours()/24)
+}
@@ -0,0 +1,61 @@
+package notify
+
+// spanishTemplates are the built-in messages in Spanish
+var spanishTemplates = map[Kind]string{
+	KindOutageStarted: `Conectividad a Internet perdida
+Las comprobaciones de conectividad empezaron a fallar el {{datetime .Time}}{{with .Fields.strategy}} (estrategia: {{.}}){{end}}.`,
+
+	KindOutageResolved: `Conectividad a Internet restablecida
+{{with .Event}}La conectividad volvió tras {{duration .Duration}} (el corte empezó el {{datetime .StartTime}}).{{else}}La conectividad volvió el {{datetime .Time}}.{{end}}`,
+
+	KindRebootTriggered: `Reiniciando el módem
+{{with .Fields.reason}}Reinicio solicitado: {{.}}{{else}}Fallaron {{.Fields.failure_count}} comprobaciones de conectividad consecutivas.{{end}}
+{{- with .Diagnostics}}
+Diagnóstico: {{.SuccessfulTests}}/{{.TotalTests}} pruebas superadas ({{percent .OverallSuccessRate}}).
+{{- range .Recommendations}}
+- {{.}}
+{{- end}}
+{{- end}}`,
+
+	KindRebootFailed: `Falló el reinicio del módem
+El comando de reinicio falló: {{.Fields.error}}`,
+
+	KindCrashed: `El watchdog se detuvo
+El watchdog se detuvo tras un pánico: {{.Fields.panic}}{{with .Fields.report}}
+Informe del fallo: {{.}}{{end}}`,
+
+	KindMonitoringPaused: `Monitorización en pausa
+Los reinicios automáticos{{if .Fields.checks}} y las comprobaciones de conectividad{{end}} están en pausa{{with .Fields.until}} hasta el {{datetime .}}{{end}}{{with .Fields.by}}, por {{.}}{{end}}.{{with .Fields.reason}}
+Motivo: {{.}}{{end}}`,
+
+	KindMonitoringResumed: `Monitorización reanudada
+Los reinicios automáticos vuelven a estar activos{{with .Fields.by}}, reanudados por {{.}}{{end}}.`,
+
+	KindFirmwareChanged: `Firmware del módem cambiado
+El módem{{with .Fields.model}} {{.}}{{end}} usa ahora el firmware {{.Fields.new_version}}, antes {{.Fields.old_version}}. Los ISP instalan firmware sin avisar y puede romper los reinicios; compruebe que el próximo reinicio funciona.`,
+
+	KindStartupReport: `La comprobación de arranque del watchdog {{if .Fields.failed}}falló{{else}}encontró avisos{{end}}
+{{- range .Fields.problems}}
+- {{.Name}}: {{.Detail}}
+{{- end}}`,
+
+	KindFailureLocated: `Internet caído, fallo {{.Fields.verdict}}
+{{.Fields.reason}}. El módem no se reinicia; el watchdog sigue comprobando.`,
+
+	KindPartialService: `Módem con servicio parcial
+{{.Fields.unlocked}} de {{.Fields.channels}} canales de bajada no están enganchados, así que internet funciona pero lento.
+{{- if .Fields.reboot_after}} El módem se reinicia si esto dura {{duration .Fields.reboot_after}}.{{end}}`,
+
+	KindModemOverheating: `Módem sobrecalentado
+El módem{{with .Fields.model}} {{.}}{{end}} está a {{printf "%.1f" .Fields.temperature}}°C, por encima de {{printf "%.1f" .Fields.max_temperature}}°C. Los módems sobrecalentados pierden la conexión; déle ventilación y apártelo del sol.`,
+
+	KindHostDegraded: `Equipo de monitorización degradado
+El propio equipo del watchdog parece averiado, así que el módem no se reinicia tras {{.Fields.failure_count}} comprobaciones fallidas.
+{{- range .Fields.problems}}
+- {{.Name}}: {{.Detail}}
+{{- end}}`,
+
+	KindDelayed: `Con retraso: esta notificación del {{datetime .Time}} no se pudo entregar hasta ahora.`,
+
+	KindReport: `{{with .Statistics}}{{if eq .TotalOutages 0}}No se registraron cortes en el periodo del {{datetime .ReportPeriodStart}} al {{datetime .ReportPeriodEnd}}. Disponibilidad: {{percent .UptimePercentage}}{{else}}Periodo: {{datetime .ReportPeriodStart}} a {{datetime .ReportPeriodEnd}} | Cortes totales: {{.TotalOutages}} | Tiempo caído total: {{duration .TotalDowntime}} | Corte medio: {{duration .AverageOutageDuration}} | Corte más largo: {{duration .LongestOutage}} | Disponibilidad: {{percent .UptimePercentage}}{{end}}{{end}}`,
+}
@@ -0,0 +1,179 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// statistics mirrors the fields of outage.OutageStatistics used by the templates
+type statistics struct {
+	TotalOutages          int
+	TotalDowntime         time.Duration
+	AverageOutageDuration time.Duration
+	LongestOutage         time.Duration
+	UptimePercentage      float64
+	ReportPeriodStart     time.Time
+	ReportPeriodEnd       time.Time
+}
+
+type event struct {
+	StartTime time.Time
+	Duration  time.Duration
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	templates := DefaultTemplates()
+	if err := templates.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	title, body, err := templates.Render(KindOutageResolved, Data{
+		Time:  at,
+		Event: &event{StartTime: at.Add(-90 * time.Second), Duration: 90 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	if title != "Internet connectivity restored" {
+		t.Errorf("Unexpected title %q", title)
+	}
+	if body != "Connectivity returned after 1.5m (outage started 2024-01-01 11:58:30)." {
+		t.Errorf("Unexpected body %q", body)
+	}
+
+	title, _, err = templates.Render(KindReport, Data{Statistics: statistics{
+		ReportPeriodStart: at.Add(-24 * time.Hour),
+		ReportPeriodEnd:   at,
+		UptimePercentage:  100,
+	}})
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	want := "No outages recorded during the period from 2023-12-31 12:00:00 to 2024-01-01 12:00:00. Uptime: 100.00%"
+	if title != want {
+		t.Errorf("Report summary = %q, want %q", title, want)
+	}
+}
+
+func TestRebootTemplateIncludesDiagnostics(t *testing.T) {
+	type analysis struct {
+		OverallSuccessRate float64
+		TotalTests         int
+		SuccessfulTests    int
+		Recommendations    []string
+	}
+
+	_, body, err := DefaultTemplates().Render(KindRebootTriggered, Data{
+		Fields:      map[string]interface{}{"failure_count": 3},
+		Diagnostics: &analysis{OverallSuccessRate: 25, TotalTests: 8, SuccessfulTests: 2, Recommendations: []string{"Check the coax cable"}},
+	})
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	for _, want := range []string{"3 consecutive connectivity checks failed.", "2/8 tests passed (25.00%)", "- Check the coax cable"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Body %q should contain %q", body, want)
+		}
+	}
+}
+
+func TestLoadTemplatesOverrides(t *testing.T) {
+	dir := t.TempDir()
+	override := `{{define "outage_started"}}Internet caído en {{.Hostname}}
+Desde {{datetime .Time}}{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "es.tmpl"), []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Files without the .tmpl extension are ignored
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("{{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := LoadTemplates(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplates() failed: %v", err)
+	}
+
+	title, body, err := templates.Render(KindOutageStarted, Data{
+		Hostname: "router",
+		Time:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	if title != "Internet caído en router" || body != "Desde 2024-01-01 12:00:00" {
+		t.Errorf("Override not applied: %q / %q", title, body)
+	}
+
+	// Messages without an override keep the default
+	if title, _, _ := templates.Render(KindRebootFailed, Data{}); title != "Modem reboot failed" {
+		t.Errorf("Expected default reboot failure title, got %q", title)
+	}
+}
+
+func TestLoadTemplatesErrors(t *testing.T) {
+	if _, err := LoadTemplates(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("Expected error for a missing template file")
+	}
+
+	file := filepath.Join(t.TempDir(), "broken.tmpl")
+	if err := os.WriteFile(file, []byte(`{{define "report"}}{{.Statistics.{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTemplates(file); err == nil {
+		t.Error("Expected error for a template that does not parse")
+	}
+
+	file = filepath.Join(t.TempDir(), "unknown-func.tmpl")
+	if err := os.WriteFile(file, []byte(`{{define "report"}}{{.Statistics | nosuchfunc}}{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTemplates(file); err == nil {
+		t.Error("Expected error for a template calling an unknown function")
+	}
+
+	file = filepath.Join(t.TempDir(), "bad-field.tmpl")
+	if err := os.WriteFile(file, []byte(`{{define "reboot_failed"}}{{.NoSuchField}}{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := LoadTemplates(file)
+	if err != nil {
+		t.Fatalf("LoadTemplates() failed: %v", err)
+	}
+	if err := templates.Validate(); err == nil {
+		t.Error("Validate() should report templates referencing unknown fields")
+	}
+}
+
+func TestLocalizedTemplates(t *testing.T) {
+	for _, language := range []string{"en", "es", "fr"} {
+		if err := LocalizedTemplates(language).Validate(); err != nil {
+			t.Errorf("Validate() failed for %s: %v", language, err)
+		}
+	}
+
+	title, _, err := LocalizedTemplates("es").Render(KindRebootFailed, Data{Fields: map[string]interface{}{"error": "timeout"}})
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	if title != "Falló el reinicio del módem" {
+		t.Errorf("Unexpected Spanish title %q", title)
+	}
+
+	// Unsupported languages use the English messages
+	title, _, _ = LocalizedTemplates("fr").Render(KindRebootFailed, Data{})
+	if title != "Modem reboot failed" {
+		t.Errorf("Unexpected fallback title %q", title)
+	}
+
+	for kind := range spanishTemplates {
+		if _, ok := defaultTemplates[kind]; !ok {
+			t.Errorf("Spanish template %s has no English default", kind)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Package openwrt renders the files that run the watchdog as an OpenWrt
+// service: a procd init script, which respawns it and hands its output to
+// logd, and a UCI configuration the watchdog reads directly.
+package openwrt
+
+import (
+	"fmt"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+)
+
+// Install defaults
+const (
+	DefaultBinary   = "/usr/bin/mb8600-watchdog"
+	DefaultInitPath = "/etc/init.d/" + config.OpenWrtPackage
+	// DefaultStateDir is on /var, a RAM disk on OpenWrt, so state does not
+	// wear out the flash
+	DefaultStateDir = "/var/lib/" + config.OpenWrtPackage
+)
+
+// RenderInitScript returns a procd init script that runs binary with the
+// UCI configuration at configFile. procd restarts the watchdog when it exits,
+// forwards its output to logd, where logread shows it, and sends SIGHUP to
+// reload it when the UCI package changes.
+func RenderInitScript(binary, configFile string) string {
+	return fmt.Sprintf(`#!/bin/sh /etc/rc.common
+# MB8600 Watchdog, generated by "watchdog install --openwrt"
+
+START=99
+STOP=10
+USE_PROCD=1
+
+PROG=%s
+CONFIG=%s
+
+start_service() {
+	config_load %s
+	local enabled
+	config_get_bool enabled main enabled 1
+	[ "$enabled" -eq 1 ] || return 0
+
+	local state_directory
+	config_get state_directory main state_directory
+	[ -z "$state_directory" ] || mkdir -p "$state_directory"
+
+	procd_open_instance
+	procd_set_param command "$PROG" --config "$CONFIG"
+	procd_set_param respawn 3600 5 0
+	procd_set_param stdout 1
+	procd_set_param stderr 1
+	procd_close_instance
+}
+
+service_triggers() {
+	procd_add_reload_trigger %s
+}
+
+reload_service() {
+	procd_send_signal %s '*' HUP
+}
+`, binary, configFile, config.OpenWrtPackage, config.OpenWrtPackage, config.OpenWrtPackage)
+}
+
+// DefaultConfig returns a UCI configuration for a new install. Logs go to
+// stdout for logd and state to the RAM disk.
+func DefaultConfig() string {
+	return fmt.Sprintf(`config %s 'main'
+	option enabled '1'
+	option modem_host '192.168.100.1'
+	option modem_username 'admin'
+	option modem_password 'YOUR_MODEM_PASSWORD'
+	option modem_noverify '1'
+	option check_interval '2m'
+	option failure_threshold '3'
+	option recovery_wait '5m'
+	list ping_hosts '8.8.8.8'
+	list ping_hosts '1.1.1.1'
+	option log_format 'text'
+	option log_file 'none'
+	option state_directory '%s'
+	option pid_file '/var/run/%s.pid'
+`, config.OpenWrtSection, DefaultStateDir, config.OpenWrtPackage)
+}
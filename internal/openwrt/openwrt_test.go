@@ -0,0 +1,48 @@
+package openwrt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+)
+
+func TestDefaultConfigLoads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.OpenWrtPackage)
+	if err := os.WriteFile(path, []byte(DefaultConfig()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	if cfg.StateDirectory != DefaultStateDir {
+		t.Errorf("Expected state directory %s, got %s", DefaultStateDir, cfg.StateDirectory)
+	}
+	if cfg.LogFormat != "text" || cfg.LogFile != "" {
+		t.Errorf("Expected text logs to stdout for logd, got format %q and file %q", cfg.LogFormat, cfg.LogFile)
+	}
+	if len(cfg.PingHosts) != 2 {
+		t.Errorf("Expected two ping hosts, got %v", cfg.PingHosts)
+	}
+}
+
+func TestRenderInitScript(t *testing.T) {
+	script := RenderInitScript("/usr/bin/watchdog", "/etc/config/mb8600-watchdog")
+	for _, want := range []string{
+		"#!/bin/sh /etc/rc.common\n",
+		"USE_PROCD=1\n",
+		"PROG=/usr/bin/watchdog\n",
+		`procd_set_param command "$PROG" --config "$CONFIG"`,
+		"procd_set_param stdout 1\n",
+		"procd_add_reload_trigger mb8600-watchdog\n",
+		"procd_send_signal mb8600-watchdog '*' HUP\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Expected the init script to contain %q:\n%s", want, script)
+		}
+	}
+}
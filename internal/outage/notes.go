@@ -0,0 +1,82 @@
+package outage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+)
+
+// Note is free text attached to an outage after the fact, such as what the
+// ISP said caused it
+type Note struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// Annotate records note for the outage with id in the history at path.
+// Notes are appended to the history rather than written to the outage data,
+// so they can be added while the service runs.
+func Annotate(path, id string, note Note) error {
+	text := strings.TrimSpace(note.Text)
+	if id == "" || text == "" {
+		return fmt.Errorf("an outage ID and a note are required")
+	}
+	return history.Append(path, history.Event{
+		Time:    note.Time,
+		Kind:    history.KindAnnotation,
+		Details: map[string]interface{}{"outage_id": id, "note": text},
+	})
+}
+
+// ReadNotes returns the notes in the history at path by outage ID, oldest
+// first
+func ReadNotes(path string) (map[string][]Note, error) {
+	events, err := history.Read(path, time.Time{}, history.KindAnnotation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outage notes: %w", err)
+	}
+	notes := make(map[string][]Note)
+	for _, event := range events {
+		id, _ := event.Details["outage_id"].(string)
+		text, _ := event.Details["note"].(string)
+		if id == "" || text == "" {
+			continue
+		}
+		notes[id] = append(notes[id], Note{Time: event.Time, Text: text})
+	}
+	return notes, nil
+}
+
+// attachNotes sets the notes of each outage from notes
+func attachNotes(outages []OutageEvent, notes map[string][]Note) {
+	for i := range outages {
+		outages[i].Notes = notes[outages[i].ID]
+	}
+}
+
+// ReadOutages returns the outages in the tracker data file at path, oldest
+// first, including one still in progress. A missing file holds no outages.
+func ReadOutages(path string) ([]OutageEvent, error) {
+	jsonData, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outage data: %w", err)
+	}
+
+	var data outageData
+	if err := json.Unmarshal(jsonData, &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal outage data: %w", err)
+	}
+	outages := data.OutageHistory
+	if data.CurrentOutage != nil && !data.CurrentOutage.Resolved {
+		outages = append(outages, *data.CurrentOutage)
+	}
+	return outages, nil
+}
@@ -0,0 +1,73 @@
+package outage
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestOutageNotes(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tempDir := t.TempDir()
+	historyPath := filepath.Join(tempDir, "history.jsonl")
+	dataPath := filepath.Join(tempDir, "outages.json")
+	tracker := NewTracker(logger, dataPath)
+	if err := tracker.RecordOutageStart("network", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.RecordOutageEnd(); err != nil {
+		t.Fatal(err)
+	}
+
+	outages, err := ReadOutages(dataPath)
+	if err != nil || len(outages) != 1 {
+		t.Fatalf("Expected the recorded outage, got %v (%v)", outages, err)
+	}
+	id := outages[0].ID
+
+	if err := Annotate(historyPath, id, Note{Text: "  "}); err == nil {
+		t.Error("Expected an error for an empty note")
+	}
+	for _, text := range []string{"ISP confirmed node maintenance", "Ticket 4521 closed"} {
+		if err := Annotate(historyPath, id, Note{Time: time.Now(), Text: text}); err != nil {
+			t.Fatalf("Annotate() failed: %v", err)
+		}
+	}
+
+	notes, err := ReadNotes(historyPath)
+	if err != nil {
+		t.Fatalf("ReadNotes() failed: %v", err)
+	}
+	if len(notes[id]) != 2 || notes[id][0].Text != "ISP confirmed node maintenance" {
+		t.Errorf("Expected both notes in order, got %+v", notes[id])
+	}
+
+	reporter := NewReporter(tracker, ReportConfig{ReportDirectory: tempDir, MaxRecentOutages: 10}, logger)
+	report, _ := reporter.GenerateCustomReport(time.Now().Add(-time.Hour), nil, 10)
+	if len(report.RecentOutages) != 1 || len(report.RecentOutages[0].Notes) != 0 {
+		t.Errorf("Expected no notes without a history, got %+v", report.RecentOutages)
+	}
+
+	reporter.SetHistory(historyPath)
+	report, _ = reporter.GenerateCustomReport(time.Now().Add(-time.Hour), nil, 10)
+	if len(report.RecentOutages) != 1 || len(report.RecentOutages[0].Notes) != 2 {
+		t.Errorf("Expected the notes in the report, got %+v", report.RecentOutages)
+	}
+
+	// Notes are not written to the tracker data
+	if outages, _ := ReadOutages(dataPath); len(outages[0].Notes) != 0 {
+		t.Errorf("Expected the tracker data to hold no notes, got %+v", outages[0].Notes)
+	}
+}
+
+func TestReadOutagesMissingFile(t *testing.T) {
+	outages, err := ReadOutages(filepath.Join(t.TempDir(), "outages.json"))
+	if err != nil || outages != nil {
+		t.Errorf("Expected no outages and no error, got %v (%v)", outages, err)
+	}
+}
@@ -0,0 +1,96 @@
+package outage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+)
+
+// PublicIPChangeWindow is how long after an outage a public IP change is
+// still counted as related to it; ISPs often assign the new address a few
+// minutes after the line comes back
+const PublicIPChangeWindow = 15 * time.Minute
+
+// PublicIPSummary describes the public IP addresses seen during a report
+// period, so IP churn and CGNAT flapping can be correlated with outages
+type PublicIPSummary struct {
+	Observations int      `json:"observations"`
+	Addresses    []string `json:"addresses"`
+	Changes      int      `json:"changes"`
+	// ChangesNearOutages counts changes during an outage or within
+	// PublicIPChangeWindow after it ended
+	ChangesNearOutages int `json:"changes_near_outages"`
+	// Networks lists the ISPs or autonomous systems the addresses belong to,
+	// when observations were geolocated
+	Networks []string `json:"networks,omitempty"`
+}
+
+// summarizePublicIP builds the public IP summary of the period starting at
+// since from the history at path; it is nil when nothing was recorded
+func summarizePublicIP(path string, since time.Time, outages []OutageEvent) (*PublicIPSummary, error) {
+	events, err := history.Read(path, since, history.KindPublicIP, history.KindIPChange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public IP history: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	summary := &PublicIPSummary{}
+	addresses := make(map[string]bool)
+	networks := make(map[string]bool)
+	for _, event := range events {
+		switch event.Kind {
+		case history.KindPublicIP:
+			summary.Observations++
+			if ip, ok := event.Details["ip"].(string); ok {
+				addresses[ip] = true
+			}
+			if location, ok := event.Details["location"].(map[string]interface{}); ok {
+				if org, ok := location["org"].(string); ok && org != "" {
+					networks[org] = true
+				}
+			}
+		case history.KindIPChange:
+			summary.Changes++
+			for _, key := range []string{"old_ip", "new_ip"} {
+				if ip, ok := event.Details[key].(string); ok {
+					addresses[ip] = true
+				}
+			}
+			if nearOutage(event.Time, outages) {
+				summary.ChangesNearOutages++
+			}
+		}
+	}
+
+	summary.Addresses = sortedKeys(addresses)
+	if len(networks) > 0 {
+		summary.Networks = sortedKeys(networks)
+	}
+	return summary, nil
+}
+
+// nearOutage reports whether t falls within an outage or the change window after it
+func nearOutage(t time.Time, outages []OutageEvent) bool {
+	for _, outage := range outages {
+		if t.Before(outage.StartTime) {
+			continue
+		}
+		if outage.EndTime == nil || !t.After(outage.EndTime.Add(PublicIPChangeWindow)) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
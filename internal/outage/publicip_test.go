@@ -0,0 +1,75 @@
+package outage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/sirupsen/logrus"
+)
+
+func TestReportPublicIPSummary(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+
+	tempDir := t.TempDir()
+	historyPath := filepath.Join(tempDir, "history.jsonl")
+	tracker := NewTracker(logger, filepath.Join(tempDir, "outages.json"))
+
+	baseTime := time.Now().Add(-2 * time.Hour)
+	end := baseTime.Add(5 * time.Minute)
+	tracker.outageHistory = []OutageEvent{{
+		ID:        "outage1",
+		StartTime: baseTime,
+		EndTime:   &end,
+		Duration:  5 * time.Minute,
+		Resolved:  true,
+	}}
+
+	events := []history.Event{
+		{Time: baseTime.Add(-time.Hour), Kind: history.KindPublicIP, Details: map[string]interface{}{"ip": "100.64.0.1",
+			"location": map[string]interface{}{"org": "AS64500 Example Cable"}}},
+		{Time: end.Add(3 * time.Minute), Kind: history.KindIPChange, Details: map[string]interface{}{"old_ip": "100.64.0.1", "new_ip": "100.64.0.2"}},
+		{Time: end.Add(3 * time.Minute), Kind: history.KindPublicIP, Details: map[string]interface{}{"ip": "100.64.0.2"}},
+		{Time: end.Add(time.Hour), Kind: history.KindIPChange, Details: map[string]interface{}{"old_ip": "100.64.0.2", "new_ip": "100.64.0.3"}},
+	}
+	for _, event := range events {
+		if err := history.Append(historyPath, event); err != nil {
+			t.Fatalf("Failed to write history: %v", err)
+		}
+	}
+
+	reporter := NewReporter(tracker, ReportConfig{ReportDirectory: tempDir, MaxRecentOutages: 10}, logger)
+	report, err := reporter.GenerateCustomReport(baseTime.Add(-3*time.Hour), nil, 10)
+	if err != nil {
+		t.Fatalf("GenerateCustomReport failed: %v", err)
+	}
+	if report.PublicIP != nil {
+		t.Error("Expected no public IP summary without a history")
+	}
+
+	reporter.SetHistory(historyPath)
+	report, _ = reporter.GenerateCustomReport(baseTime.Add(-3*time.Hour), nil, 10)
+	summary := report.PublicIP
+	if summary == nil {
+		t.Fatal("Expected a public IP summary")
+	}
+	if summary.Observations != 2 || summary.Changes != 2 || summary.ChangesNearOutages != 1 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	if !reflect.DeepEqual(summary.Addresses, []string{"100.64.0.1", "100.64.0.2", "100.64.0.3"}) {
+		t.Errorf("Unexpected addresses %v", summary.Addresses)
+	}
+	if !reflect.DeepEqual(summary.Networks, []string{"AS64500 Example Cable"}) {
+		t.Errorf("Unexpected networks %v", summary.Networks)
+	}
+
+	// Events before the period are left out
+	report, _ = reporter.GenerateCustomReport(end.Add(30*time.Minute), nil, 10)
+	if report.PublicIP == nil || report.PublicIP.Changes != 1 || report.PublicIP.ChangesNearOutages != 0 {
+		t.Errorf("Unexpected summary for a later period %+v", report.PublicIP)
+	}
+}
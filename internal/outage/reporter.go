@@ -10,24 +10,33 @@ import (
 	"strings"
 	"time"
 
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/perezjoseph/mb8600-watchdog/internal/scheduler"
 	"github.com/sirupsen/logrus"
 )
 
 // ReportConfig holds configuration for outage reporting
 type ReportConfig struct {
-	ReportInterval    time.Duration
-	ReportDirectory   string
-	MaxRecentOutages  int
-	ReportRetention   time.Duration
+	ReportInterval   time.Duration
+	ReportDirectory  string
+	MaxRecentOutages int
+	ReportRetention  time.Duration
+	// ReportMaxBytes bounds the size of the reports kept, 0 for no bound
+	ReportMaxBytes    int64
 	EnableJSONReports bool
 	EnableLogReports  bool
 }
 
 // Reporter handles periodic outage reporting
 type Reporter struct {
-	tracker *Tracker
-	config  ReportConfig
-	logger  *logrus.Logger
+	tracker   *Tracker
+	config    ReportConfig
+	logger    *logrus.Logger
+	templates *notify.Templates
+	// historyPath is the network event history summarized in reports, and
+	// where outage notes are kept
+	historyPath string
 }
 
 // NewReporter creates a new outage reporter
@@ -39,8 +48,43 @@ func NewReporter(tracker *Tracker, config ReportConfig, logger *logrus.Logger) *
 	}
 }
 
+// SetTemplates renders report summaries with templates instead of the
+// built-in summary
+func (r *Reporter) SetTemplates(templates *notify.Templates) {
+	r.templates = templates
+}
+
+// SetHistory adds a summary of the public IP history at path to reports,
+// and the notes recorded there to their outages
+func (r *Reporter) SetHistory(path string) {
+	r.historyPath = path
+}
+
 // Start begins the periodic reporting process
 func (r *Reporter) Start(ctx context.Context) error {
+	if err := r.Prepare(); err != nil {
+		return err
+	}
+
+	sched := scheduler.New(clock.New(), r.logger)
+	if r.config.ReportInterval > 0 {
+		if err := sched.Add(ReportJobName, scheduler.Every(r.config.ReportInterval), r.RunReport); err != nil {
+			return err
+		}
+	}
+
+	err := sched.Run(ctx)
+	r.logger.Info("Outage reporter stopped")
+	return err
+}
+
+// ReportJobName is the scheduler job name of the periodic outage report
+const ReportJobName = "outage_report"
+
+// Prepare creates the report directory and generates the initial report.
+// Start calls it before scheduling periodic reports; callers that run
+// RunReport on their own scheduler call it once beforehand.
+func (r *Reporter) Prepare() error {
 	r.logger.WithFields(logrus.Fields{
 		"report_interval":     r.config.ReportInterval,
 		"report_directory":    r.config.ReportDirectory,
@@ -60,29 +104,28 @@ func (r *Reporter) Start(ctx context.Context) error {
 	if err := r.generateReport(); err != nil {
 		r.logger.WithError(err).Warn("Failed to generate initial outage report")
 	}
+	if r.config.ReportRetention > 0 || r.config.ReportMaxBytes > 0 {
+		if err := r.cleanupOldReports(); err != nil {
+			r.logger.WithError(err).Warn("Failed to cleanup old reports")
+		}
+	}
+	return nil
+}
 
-	// Start periodic reporting
-	ticker := time.NewTicker(r.config.ReportInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			r.logger.Info("Outage reporter stopped")
-			return ctx.Err()
-		case <-ticker.C:
-			if err := r.generateReport(); err != nil {
-				r.logger.WithError(err).Error("Failed to generate periodic outage report")
-			}
+// RunReport generates a periodic report and removes expired ones
+func (r *Reporter) RunReport(ctx context.Context) error {
+	if err := r.generateReport(); err != nil {
+		r.logger.WithError(err).Error("Failed to generate periodic outage report")
+		return err
+	}
 
-			// Cleanup old reports if retention is configured
-			if r.config.ReportRetention > 0 {
-				if err := r.cleanupOldReports(); err != nil {
-					r.logger.WithError(err).Warn("Failed to cleanup old reports")
-				}
-			}
+	// Cleanup old reports if retention is configured
+	if r.config.ReportRetention > 0 || r.config.ReportMaxBytes > 0 {
+		if err := r.cleanupOldReports(); err != nil {
+			r.logger.WithError(err).Warn("Failed to cleanup old reports")
 		}
 	}
+	return nil
 }
 
 // generateReport creates and saves an outage report
@@ -90,6 +133,9 @@ func (r *Reporter) generateReport() error {
 	// Generate report for the last 24 hours by default
 	since := time.Now().Add(-24 * time.Hour)
 	report := r.tracker.GenerateReport(since, r.config.MaxRecentOutages)
+	r.addNotes(&report)
+	r.addPublicIP(&report, since)
+	r.renderSummary(&report)
 
 	// Log the report summary
 	if r.config.EnableLogReports {
@@ -106,6 +152,61 @@ func (r *Reporter) generateReport() error {
 	return nil
 }
 
+// addPublicIP adds the public IP history since the start of the period
+func (r *Reporter) addPublicIP(report *OutageReport, since time.Time) {
+	if r.historyPath == "" {
+		return
+	}
+
+	outages := r.tracker.GetOutageHistory()
+	if current := r.tracker.GetCurrentOutage(); current != nil {
+		outages = append(outages, *current)
+	}
+	summary, err := summarizePublicIP(r.historyPath, since, outages)
+	if err != nil {
+		r.logger.WithError(err).Warn("Failed to summarize public IP history")
+		return
+	}
+	report.PublicIP = summary
+}
+
+// addNotes attaches the notes recorded in the history to the outages of the
+// report
+func (r *Reporter) addNotes(report *OutageReport) {
+	if r.historyPath == "" || len(report.RecentOutages) == 0 {
+		return
+	}
+
+	notes, err := ReadNotes(r.historyPath)
+	if err != nil {
+		r.logger.WithError(err).Warn("Failed to read outage notes")
+		return
+	}
+	attachNotes(report.RecentOutages, notes)
+}
+
+// renderSummary replaces the report summary with the templated one
+func (r *Reporter) renderSummary(report *OutageReport) {
+	if r.templates == nil {
+		return
+	}
+
+	title, body, err := r.templates.Render(notify.KindReport, notify.Data{
+		Time:       report.GeneratedAt,
+		Statistics: report.Statistics,
+		Fields:     map[string]interface{}{"recent_outages": report.RecentOutages, "public_ip": report.PublicIP},
+	})
+	if err != nil {
+		r.logger.WithError(err).Warn("Failed to render report summary, keeping built-in summary")
+		return
+	}
+
+	report.Summary = title
+	if body != "" {
+		report.Summary += "\n" + body
+	}
+}
+
 // logReport logs the outage report to the application log
 func (r *Reporter) logReport(report OutageReport) {
 	fields := logrus.Fields{
@@ -118,11 +219,17 @@ func (r *Reporter) logReport(report OutageReport) {
 		"report_period_start":  report.Statistics.ReportPeriodStart.Format("2006-01-02 15:04:05"),
 		"report_period_end":    report.Statistics.ReportPeriodEnd.Format("2006-01-02 15:04:05"),
 		"recent_outages_count": len(report.RecentOutages),
+		"summary":              report.Summary,
 	}
 
 	if report.Statistics.LastOutage != nil {
 		fields["last_outage"] = report.Statistics.LastOutage.Format("2006-01-02 15:04:05")
 	}
+	if report.PublicIP != nil {
+		fields["public_ip_addresses"] = len(report.PublicIP.Addresses)
+		fields["public_ip_changes"] = report.PublicIP.Changes
+		fields["public_ip_changes_near_outages"] = report.PublicIP.ChangesNearOutages
+	}
 
 	r.logger.WithFields(fields).Info("Outage report generated")
 
@@ -145,6 +252,19 @@ func (r *Reporter) logReport(report OutageReport) {
 			outageFields["details"] = outage.Details
 		}
 
+		if outage.Signals != nil {
+			outageFields["signals_after_reboot"] = outage.Signals.Verdict
+			outageFields["signal_delta"] = outage.Signals.Delta
+		}
+
+		if len(outage.Notes) > 0 {
+			texts := make([]string, len(outage.Notes))
+			for i, note := range outage.Notes {
+				texts[i] = note.Text
+			}
+			outageFields["notes"] = texts
+		}
+
 		r.logger.WithFields(outageFields).Info("Recent outage details")
 	}
 }
@@ -174,55 +294,22 @@ func (r *Reporter) saveJSONReport(report OutageReport) error {
 	return nil
 }
 
-// cleanupOldReports removes old report files based on retention policy
+// cleanupOldReports removes report files past their retention or beyond
+// the size limit
 func (r *Reporter) cleanupOldReports() error {
 	if !r.config.EnableJSONReports {
 		return nil // No files to cleanup
 	}
 
-	cutoff := time.Now().Add(-r.config.ReportRetention)
-
-	files, err := os.ReadDir(r.config.ReportDirectory)
-	if err != nil {
-		return fmt.Errorf("failed to read report directory: %w", err)
-	}
-
-	removedCount := 0
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
-
-		// Check if it's an outage report file
-		if !isOutageReportFile(file.Name()) {
-			continue
-		}
-
-		// Get file info to check modification time
-		fileInfo, err := file.Info()
-		if err != nil {
-			continue
-		}
-
-		// Check if file is older than retention period
-		if fileInfo.ModTime().Before(cutoff) {
-			filePath := filepath.Join(r.config.ReportDirectory, file.Name())
-			if err := os.Remove(filePath); err != nil {
-				r.logger.WithError(err).WithField("file", filePath).Warn("Failed to remove old report file")
-				continue
-			}
-			removedCount++
-		}
-	}
-
-	if removedCount > 0 {
+	removed, err := PruneReports(r.config.ReportDirectory, time.Now(), r.config.ReportRetention, r.config.ReportMaxBytes)
+	if removed > 0 {
 		r.logger.WithFields(logrus.Fields{
-			"removed_files": removedCount,
-			"cutoff_date":   cutoff.Format("2006-01-02 15:04:05"),
+			"removed_files": removed,
+			"retention":     r.config.ReportRetention,
+			"max_bytes":     r.config.ReportMaxBytes,
 		}).Info("Cleaned up old outage report files")
 	}
-
-	return nil
+	return err
 }
 
 // isOutageReportFile checks if a filename matches the outage report pattern
@@ -258,6 +345,9 @@ func (r *Reporter) GenerateCustomReport(since time.Time, until *time.Time, maxOu
 	if until != nil {
 		report.Statistics.ReportPeriodEnd = *until
 	}
+	r.addNotes(&report)
+	r.addPublicIP(&report, since)
+	r.renderSummary(&report)
 
 	r.logger.WithFields(logrus.Fields{
 		"custom_report":  true,
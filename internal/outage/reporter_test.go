@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
 	"github.com/sirupsen/logrus"
 )
 
@@ -392,3 +393,101 @@ func TestReporterStart(t *testing.T) {
 		t.Error("At least one report should have been generated")
 	}
 }
+
+func TestReportSummaryTemplates(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	tempDir := t.TempDir()
+	tracker := NewTracker(logger, filepath.Join(tempDir, "outages.json"))
+	start := time.Now().Add(-time.Hour)
+	tracker.outageHistory = []OutageEvent{{
+		ID:        "outage1",
+		StartTime: start,
+		EndTime:   &[]time.Time{start.Add(5 * time.Minute)}[0],
+		Duration:  5 * time.Minute,
+		Resolved:  true,
+	}}
+
+	config := ReportConfig{ReportDirectory: tempDir, MaxRecentOutages: 10}
+	reporter := NewReporter(tracker, config, logger)
+	since := time.Now().Add(-24 * time.Hour)
+
+	// The default template reproduces the built-in summary
+	builtIn, err := reporter.GenerateCustomReport(since, nil, 10)
+	if err != nil {
+		t.Fatalf("GenerateCustomReport failed: %v", err)
+	}
+	reporter.SetTemplates(notify.DefaultTemplates())
+	templated, err := reporter.GenerateCustomReport(since, &builtIn.Statistics.ReportPeriodEnd, 10)
+	if err != nil {
+		t.Fatalf("GenerateCustomReport failed: %v", err)
+	}
+	if templated.Summary != builtIn.Summary {
+		t.Errorf("Default template summary %q differs from built-in %q", templated.Summary, builtIn.Summary)
+	}
+
+	templateFile := filepath.Join(tempDir, "report.tmpl")
+	custom := `{{define "report"}}{{.Statistics.TotalOutages}} outage(s), {{duration .Statistics.TotalDowntime}} down{{end}}`
+	if err := os.WriteFile(templateFile, []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := notify.LoadTemplates(templateFile)
+	if err != nil {
+		t.Fatalf("LoadTemplates failed: %v", err)
+	}
+	reporter.SetTemplates(templates)
+
+	report, err := reporter.GenerateCustomReport(since, nil, 10)
+	if err != nil {
+		t.Fatalf("GenerateCustomReport failed: %v", err)
+	}
+	if report.Summary != "1 outage(s), 5.0m down" {
+		t.Errorf("Unexpected custom summary %q", report.Summary)
+	}
+}
+
+func TestPruneReports(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	names := []string{
+		"outage_report_20240101_000000.json",
+		"outage_report_20240102_000000.json",
+		"outage_report_20240103_000000.json",
+		"outage_report_20240104_000000.json",
+	}
+	for i, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, 100), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modified := now.Add(time.Duration(i-len(names)) * 24 * time.Hour)
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(other, make([]byte, 1000), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The oldest report is four days old
+	if removed, err := PruneReports(dir, now, 84*time.Hour, 0); err != nil || removed != 1 {
+		t.Fatalf("Expected 1 report removed by age, got %d, %v", removed, err)
+	}
+	if removed, err := PruneReports(dir, now, 0, 200); err != nil || removed != 1 {
+		t.Fatalf("Expected 1 report removed by size, got %d, %v", removed, err)
+	}
+	for i, name := range names {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if kept := i >= 2; kept != (err == nil) {
+			t.Errorf("Report %s: expected kept=%v, got %v", name, kept, err)
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Error("Expected files other than reports to be left alone")
+	}
+	if removed, err := PruneReports(filepath.Join(dir, "missing"), now, time.Hour, 1); err != nil || removed != 0 {
+		t.Errorf("Expected a missing directory to hold no reports, got %d, %v", removed, err)
+	}
+}
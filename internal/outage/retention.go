@@ -0,0 +1,62 @@
+package outage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// PruneReports removes the outage reports in dir older than maxAge, then
+// the oldest ones until the rest take at most maxBytes. A zero limit does
+// not apply. Files other than outage reports are left alone, and a missing
+// directory holds no reports. It returns the number of reports removed.
+func PruneReports(dir string, now time.Time, maxAge time.Duration, maxBytes int64) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read report directory: %w", err)
+	}
+
+	var reports []os.FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !isOutageReportFile(entry.Name()) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			reports = append(reports, info)
+		}
+	}
+	// Oldest first
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].ModTime().Before(reports[j].ModTime())
+	})
+
+	total := int64(0)
+	for _, report := range reports {
+		total += report.Size()
+	}
+
+	removed := 0
+	var firstErr error
+	for _, report := range reports {
+		expired := maxAge > 0 && report.ModTime().Before(now.Add(-maxAge))
+		oversized := maxBytes > 0 && total > maxBytes
+		if !expired && !oversized {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, report.Name())); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to remove report %s: %w", report.Name(), err)
+			}
+			continue
+		}
+		total -= report.Size()
+		removed++
+	}
+	return removed, firstErr
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,6 +21,19 @@ type OutageEvent struct {
 	Resolved  bool                   `json:"resolved"`
 	Cause     string                 `json:"cause,omitempty"`
 	Details   map[string]interface{} `json:"details,omitempty"`
+	// Notes are attached by users after the fact; they are kept in the
+	// history and only filled in for reports
+	Notes []Note `json:"notes,omitempty"`
+	// Signals compares the modem's signal before a reboot during the outage
+	// with the signal after the modem recovered
+	Signals *modem.SignalComparison `json:"signals,omitempty"`
+}
+
+// outageData is the layout of the tracker data file
+type outageData struct {
+	CurrentOutage     *OutageEvent  `json:"current_outage,omitempty"`
+	OutageHistory     []OutageEvent `json:"outage_history"`
+	TrackingStartTime time.Time     `json:"tracking_start_time"`
 }
 
 // OutageStatistics holds aggregated outage statistics
@@ -41,6 +55,8 @@ type OutageReport struct {
 	Statistics    OutageStatistics `json:"statistics"`
 	RecentOutages []OutageEvent    `json:"recent_outages"`
 	Summary       string           `json:"summary"`
+	// PublicIP summarizes the public IP history of the period, when recorded
+	PublicIP *PublicIPSummary `json:"public_ip,omitempty"`
 }
 
 // Tracker manages outage event recording and statistics
@@ -53,7 +69,8 @@ type Tracker struct {
 	trackingStartTime time.Time
 }
 
-// NewTracker creates a new outage tracker
+// NewTracker creates a new outage tracker; with an empty dataFile, outages
+// are kept in memory only
 func NewTracker(logger *logrus.Logger, dataFile string) *Tracker {
 	if logger == nil {
 		// Create a default logger if none provided
@@ -61,11 +78,6 @@ func NewTracker(logger *logrus.Logger, dataFile string) *Tracker {
 		logger.SetLevel(logrus.WarnLevel)
 	}
 
-	if dataFile == "" {
-		logger.Warn("No data file specified, using default location")
-		dataFile = "outages.json"
-	}
-
 	tracker := &Tracker{
 		logger:            logger,
 		dataFile:          dataFile,
@@ -121,6 +133,48 @@ func (t *Tracker) RecordOutageStart(cause string, details map[string]interface{}
 	return t.saveOutageData()
 }
 
+// SetCause reclassifies the current outage, e.g. once diagnostics found
+// what caused it; it does nothing when no outage is active
+func (t *Tracker) SetCause(cause string) error {
+	if t == nil {
+		return fmt.Errorf("tracker is nil")
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.currentOutage == nil || t.currentOutage.Resolved || t.currentOutage.Cause == cause {
+		return nil
+	}
+
+	t.logger.WithFields(logrus.Fields{
+		"outage_id":      t.currentOutage.ID,
+		"previous_cause": t.currentOutage.Cause,
+		"cause":          cause,
+	}).Info("Outage cause classified")
+	t.currentOutage.Cause = cause
+
+	return t.saveOutageData()
+}
+
+// SetSignals records how a reboot during the current outage changed the
+// modem's signal; it does nothing when no outage is active
+func (t *Tracker) SetSignals(comparison modem.SignalComparison) error {
+	if t == nil {
+		return fmt.Errorf("tracker is nil")
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.currentOutage == nil || t.currentOutage.Resolved {
+		return nil
+	}
+	t.currentOutage.Signals = &comparison
+
+	return t.saveOutageData()
+}
+
 // RecordOutageEnd records the end of the current outage
 func (t *Tracker) RecordOutageEnd() error {
 	if t == nil {
@@ -366,14 +420,10 @@ func (t *Tracker) saveOutageData() error {
 		return fmt.Errorf("tracker is nil")
 	}
 	if t.dataFile == "" {
-		return fmt.Errorf("data file path is empty")
+		return nil
 	}
 
-	data := struct {
-		CurrentOutage     *OutageEvent  `json:"current_outage,omitempty"`
-		OutageHistory     []OutageEvent `json:"outage_history"`
-		TrackingStartTime time.Time     `json:"tracking_start_time"`
-	}{
+	data := outageData{
 		CurrentOutage:     t.currentOutage,
 		OutageHistory:     t.outageHistory,
 		TrackingStartTime: t.trackingStartTime,
@@ -408,7 +458,7 @@ func (t *Tracker) loadOutageData() error {
 		return fmt.Errorf("tracker is nil")
 	}
 	if t.dataFile == "" {
-		return fmt.Errorf("data file path is empty")
+		return nil
 	}
 
 	if _, err := os.Stat(t.dataFile); os.IsNotExist(err) {
@@ -423,12 +473,7 @@ func (t *Tracker) loadOutageData() error {
 		return fmt.Errorf("failed to read outage data: %w", err)
 	}
 
-	var data struct {
-		CurrentOutage     *OutageEvent  `json:"current_outage,omitempty"`
-		OutageHistory     []OutageEvent `json:"outage_history"`
-		TrackingStartTime time.Time     `json:"tracking_start_time"`
-	}
-
+	var data outageData
 	if err := json.Unmarshal(jsonData, &data); err != nil {
 		return fmt.Errorf("failed to unmarshal outage data: %w", err)
 	}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
 	"github.com/sirupsen/logrus"
 )
 
@@ -364,3 +365,65 @@ func TestMultipleOutageStarts(t *testing.T) {
 		t.Error("Expected first outage to be resolved")
 	}
 }
+
+func TestSetCause(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+
+	tracker := NewTracker(logger, filepath.Join(t.TempDir(), "outages.json"))
+
+	// Without an active outage there is nothing to classify
+	if err := tracker.SetCause("isp_routing_incident"); err != nil {
+		t.Fatalf("SetCause failed: %v", err)
+	}
+
+	if err := tracker.RecordOutageStart("connectivity_failure", nil); err != nil {
+		t.Fatalf("RecordOutageStart failed: %v", err)
+	}
+	if err := tracker.SetCause("isp_routing_incident"); err != nil {
+		t.Fatalf("SetCause failed: %v", err)
+	}
+	if current := tracker.GetCurrentOutage(); current == nil || current.Cause != "isp_routing_incident" {
+		t.Errorf("Expected the outage to be reclassified, got %+v", current)
+	}
+
+	if err := tracker.RecordOutageEnd(); err != nil {
+		t.Fatalf("RecordOutageEnd failed: %v", err)
+	}
+	history := tracker.GetOutageHistory()
+	if len(history) != 1 || history[0].Cause != "isp_routing_incident" {
+		t.Errorf("Expected the cause to be kept in the history, got %+v", history)
+	}
+}
+
+func TestSetSignals(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+
+	path := filepath.Join(t.TempDir(), "outages.json")
+	tracker := NewTracker(logger, path)
+	comparison := modem.CompareSignals(
+		modem.SignalSummary{DownstreamLocked: 20, DownstreamSNRDB: 36},
+		modem.SignalSummary{DownstreamLocked: 32, DownstreamSNRDB: 38.5},
+	)
+
+	// Without an active outage there is nothing to record it on
+	if err := tracker.SetSignals(comparison); err != nil {
+		t.Fatalf("SetSignals failed: %v", err)
+	}
+
+	if err := tracker.RecordOutageStart("connectivity_failure", nil); err != nil {
+		t.Fatalf("RecordOutageStart failed: %v", err)
+	}
+	if err := tracker.SetSignals(comparison); err != nil {
+		t.Fatalf("SetSignals failed: %v", err)
+	}
+	if err := tracker.RecordOutageEnd(); err != nil {
+		t.Fatalf("RecordOutageEnd failed: %v", err)
+	}
+
+	history := NewTracker(logger, path).GetOutageHistory()
+	if len(history) != 1 || history[0].Signals == nil || history[0].Signals.Verdict != modem.SignalsImproved || history[0].Signals.Delta.DownstreamLocked != 12 {
+		t.Errorf("Expected the signal comparison to be kept in the history, got %+v", history)
+	}
+}
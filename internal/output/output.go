@@ -0,0 +1,314 @@
+// Package output formats the human output of CLI commands such as status
+// and --health-check: a title, check results with a status column, and
+// label and value fields, aligned in columns. Colors follow the terminal
+// and NO_COLOR, long values are cut to the terminal width unless wide, and
+// quiet leaves only warnings and failures, so a script or a cron job that
+// mails its output only hears of problems; verbose adds details. The most
+// severe outcome reported becomes the exit code.
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/muesli/termenv"
+	"github.com/perezjoseph/mb8600-watchdog/internal/exitcode"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"golang.org/x/term"
+)
+
+// Color modes
+const (
+	// ColorAuto colors output to a terminal unless NO_COLOR is set
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// ColorModes returns the supported color modes
+func ColorModes() []string {
+	return []string{ColorAuto, ColorAlways, ColorNever}
+}
+
+// Status is the outcome of a check
+type Status int
+
+// Statuses from best to worst; Info reports a state that is neither good
+// nor bad, such as low power mode
+const (
+	OK Status = iota
+	Info
+	Warning
+	Failed
+)
+
+// String returns the localized status column text
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return i18n.T("output.ok")
+	case Info:
+		return i18n.T("output.info")
+	case Warning:
+		return i18n.T("output.warning")
+	default:
+		return i18n.T("output.failed")
+	}
+}
+
+// color is the ANSI color of the status column
+func (s Status) color() string {
+	switch s {
+	case OK:
+		return "2"
+	case Info:
+		return "6"
+	case Warning:
+		return "3"
+	default:
+		return "1"
+	}
+}
+
+// Options configure a Printer
+type Options struct {
+	// Color is a color mode, ColorAuto when empty
+	Color string
+	// Wide never cuts long values to the terminal width
+	Wide bool
+	// Quiet prints only warnings and failures
+	Quiet bool
+	// Verbose never cuts long values either, and commands add details
+	Verbose bool
+}
+
+// row is a check result, or a field when check is false
+type row struct {
+	check  bool
+	status Status
+	label  string
+	value  string
+}
+
+// Printer writes formatted output. Rows are held until the next title,
+// section, line or Flush, so their columns line up.
+type Printer struct {
+	w       io.Writer
+	options Options
+	profile termenv.Profile
+	// width is the terminal width values are cut to, 0 for no limit
+	width int
+
+	rows []row
+	code int
+	// last is what was written last: nothing, a title or section, rows or
+	// a line of text
+	last int
+}
+
+// What a printer wrote last
+const (
+	wroteNothing = iota
+	wroteHeading
+	wroteRows
+	wroteLine
+)
+
+// New creates a printer writing to w
+func New(w io.Writer, options Options) (*Printer, error) {
+	if options.Color == "" {
+		options.Color = ColorAuto
+	}
+	if options.Quiet && options.Verbose {
+		return nil, fmt.Errorf("quiet and verbose output cannot be combined")
+	}
+	if options.Verbose {
+		options.Wide = true
+	}
+	p := &Printer{w: w, options: options, profile: termenv.Ascii}
+	switch options.Color {
+	case ColorAuto:
+		p.profile = termenv.NewOutput(w).EnvColorProfile()
+	case ColorAlways:
+		p.profile = termenv.ANSI
+	case ColorNever:
+	default:
+		return nil, fmt.Errorf("invalid color mode %q, must be one of %s", options.Color, strings.Join(ColorModes(), ", "))
+	}
+	if f, ok := w.(*os.File); ok && !options.Wide && term.IsTerminal(int(f.Fd())) {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil {
+			p.width = width
+		}
+	}
+	return p, nil
+}
+
+// ExitCode returns the most severe exit code reported; see package
+// exitcode
+func (p *Printer) ExitCode() int {
+	return p.code
+}
+
+// Verbose reports whether details are wanted
+func (p *Printer) Verbose() bool {
+	return p.options.Verbose
+}
+
+// Title prints an underlined title
+func (p *Printer) Title(title string) {
+	p.Flush()
+	if p.options.Quiet {
+		return
+	}
+	p.separate(p.last != wroteNothing)
+	fmt.Fprintln(p.w, p.bold(title))
+	fmt.Fprintln(p.w, strings.Repeat("=", utf8.RuneCountInString(title)))
+	p.last = wroteHeading
+}
+
+// Section prints the title of a group of rows
+func (p *Printer) Section(title string) {
+	p.Flush()
+	if p.options.Quiet {
+		return
+	}
+	p.separate(p.last != wroteNothing)
+	fmt.Fprintln(p.w, p.bold(title))
+	p.last = wroteHeading
+}
+
+// Line prints a line of text, such as a summary or a confirmation; a blank
+// line sets it apart from rows above
+func (p *Printer) Line(text string) {
+	p.Flush()
+	if p.options.Quiet {
+		return
+	}
+	p.separate(p.last == wroteRows)
+	fmt.Fprintln(p.w, text)
+	p.last = wroteLine
+}
+
+// Check adds the result of a check, with detail such as the error in the
+// value column. A warning makes the exit code at least exitcode.Degraded
+// and a failure exitcode.Failure; Fail sets a more specific one.
+func (p *Printer) Check(status Status, label, detail string) {
+	switch status {
+	case Warning:
+		p.Exit(exitcode.Degraded)
+	case Failed:
+		p.Exit(exitcode.Failure)
+	}
+	p.add(status, label, detail)
+}
+
+// Fail adds a failed check that makes the exit code at least code, such
+// as exitcode.ModemUnreachable
+func (p *Printer) Fail(code int, label, detail string) {
+	p.Exit(code)
+	p.add(Failed, label, detail)
+}
+
+// Exit makes the exit code at least code, for outcomes without a row
+func (p *Printer) Exit(code int) {
+	p.code = exitcode.Worst(p.code, code)
+}
+
+// add adds a check row; quiet leaves out what is not a problem
+func (p *Printer) add(status Status, label, detail string) {
+	if p.options.Quiet && status < Warning {
+		return
+	}
+	p.rows = append(p.rows, row{check: true, status: status, label: label, value: detail})
+}
+
+// Field adds a label and its value; the lines of a multi-line value line
+// up under the first
+func (p *Printer) Field(label string, value interface{}) {
+	if p.options.Quiet {
+		return
+	}
+	p.rows = append(p.rows, row{label: label, value: fmt.Sprint(value)})
+}
+
+// Flush writes the rows added since the last title, section or line
+func (p *Printer) Flush() {
+	if len(p.rows) == 0 {
+		return
+	}
+	statusWidth, labelWidth := 0, 0
+	for _, r := range p.rows {
+		if n := utf8.RuneCountInString(r.status.String()); r.check && n > statusWidth {
+			statusWidth = n
+		}
+		if n := utf8.RuneCountInString(r.label); n > labelWidth {
+			labelWidth = n
+		}
+	}
+
+	// The value column starts after the status and label columns
+	indent := 2 + labelWidth + 2
+	if statusWidth > 0 {
+		indent += statusWidth + 2
+	}
+	for _, r := range p.rows {
+		line := "  "
+		if statusWidth > 0 {
+			status := ""
+			if r.check {
+				status = r.status.String()
+			}
+			padding := strings.Repeat(" ", statusWidth-utf8.RuneCountInString(status)+2)
+			line += p.colored(status, r.status.color()) + padding
+		}
+		line += r.label
+		if r.value == "" {
+			fmt.Fprintln(p.w, strings.TrimRight(line, " "))
+			continue
+		}
+		line += strings.Repeat(" ", labelWidth-utf8.RuneCountInString(r.label)+2)
+		for i, value := range strings.Split(r.value, "\n") {
+			if i > 0 {
+				line = strings.Repeat(" ", indent)
+			}
+			fmt.Fprintln(p.w, line+p.cut(value, indent))
+		}
+	}
+	p.rows = nil
+	p.last = wroteRows
+}
+
+// separate writes a blank line between blocks of output when blank is set
+func (p *Printer) separate(blank bool) {
+	if blank {
+		fmt.Fprintln(p.w)
+	}
+}
+
+// cut shortens value to what fits the terminal after indent columns
+func (p *Printer) cut(value string, indent int) string {
+	if p.width == 0 || p.options.Wide {
+		return value
+	}
+	room := p.width - indent
+	if room < 1 || utf8.RuneCountInString(value) <= room {
+		return value
+	}
+	return string([]rune(value)[:room-1]) + "…"
+}
+
+// colored colors text with an ANSI color when colors are on
+func (p *Printer) colored(text, color string) string {
+	if text == "" {
+		return text
+	}
+	return p.profile.String(text).Foreground(p.profile.Color(color)).Bold().String()
+}
+
+// bold makes text bold when colors are on
+func (p *Printer) bold(text string) string {
+	return p.profile.String(text).Bold().String()
+}
@@ -0,0 +1,162 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/exitcode"
+)
+
+func newTestPrinter(t *testing.T, options Options) (*Printer, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	p, err := New(&buf, options)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return p, &buf
+}
+
+func TestPrinterAlignsColumns(t *testing.T) {
+	p, buf := newTestPrinter(t, Options{})
+	p.Title("Health Check")
+	p.Check(OK, "Configuration", "")
+	p.Fail(exitcode.ModemUnreachable, "Modem Connectivity", "connection refused")
+	p.Check(Warning, "Capabilities", "CAP_NET_RAW missing")
+	p.Section("Summary")
+	p.Field("Modem Type", "mb8600")
+	p.Field("Recommendations", "Check the cable\nCall the ISP")
+	p.Line("2 health checks failed")
+	p.Flush()
+
+	want := `Health Check
+============
+  OK    Configuration
+  FAIL  Modem Connectivity  connection refused
+  WARN  Capabilities        CAP_NET_RAW missing
+
+Summary
+  Modem Type       mb8600
+  Recommendations  Check the cable
+                   Call the ISP
+
+2 health checks failed
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+	if code := p.ExitCode(); code != exitcode.ModemUnreachable {
+		t.Errorf("Expected exit code %d, got %d", exitcode.ModemUnreachable, code)
+	}
+}
+
+func TestPrinterQuiet(t *testing.T) {
+	p, buf := newTestPrinter(t, Options{Quiet: true})
+	p.Title("Status")
+	p.Check(OK, "Service", "RUNNING")
+	p.Check(Info, "Power Mode", "LOW POWER")
+	p.Field("Modem Type", "mb8600")
+	p.Line("All health checks passed")
+	p.Flush()
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output without problems, got %q", buf.String())
+	}
+	if code := p.ExitCode(); code != exitcode.OK {
+		t.Errorf("Expected exit code %d, got %d", exitcode.OK, code)
+	}
+
+	p.Check(Warning, "Modem Tunnel", "DOWN")
+	p.Flush()
+	if got := buf.String(); got != "  WARN  Modem Tunnel  DOWN\n" {
+		t.Errorf("Expected only the warning, got %q", got)
+	}
+	if code := p.ExitCode(); code != exitcode.Degraded {
+		t.Errorf("Expected exit code %d, got %d", exitcode.Degraded, code)
+	}
+
+	// Outcomes keep the most severe code, whether printed or not
+	p.Fail(exitcode.Outage, "Internet Connectivity", "DNS resolution failed")
+	p.Check(Failed, "Log File Access", "read-only file system")
+	p.Check(Warning, "System Capabilities", "CAP_NET_RAW missing")
+	if code := p.ExitCode(); code != exitcode.Failure {
+		t.Errorf("Expected exit code %d, got %d", exitcode.Failure, code)
+	}
+}
+
+func TestPrinterVerbose(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, Options{Quiet: true, Verbose: true}); err == nil {
+		t.Error("Expected quiet and verbose together to fail")
+	}
+
+	p, buf := newTestPrinter(t, Options{Verbose: true})
+	p.width = 20
+	if !p.Verbose() {
+		t.Error("Expected a verbose printer")
+	}
+	p.Line("Sending SIGTERM to process 42")
+	p.Line("Service stopped")
+	p.Check(Failed, "Modem", "cannot connect to modem at 192.168.100.1")
+	p.Line("Done")
+	p.Flush()
+	want := "Sending SIGTERM to process 42\nService stopped\n  FAIL  Modem  cannot connect to modem at 192.168.100.1\n\nDone\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Expected whole values and lines kept together, got %q", got)
+	}
+}
+
+func TestPrinterColor(t *testing.T) {
+	p, buf := newTestPrinter(t, Options{Color: ColorAlways})
+	p.Check(Failed, "Service", "STOPPED")
+	p.Flush()
+	if got := buf.String(); !strings.Contains(got, "\x1b[31;1mFAIL\x1b[0m") {
+		t.Errorf("Expected a red status, got %q", got)
+	}
+
+	// Output to something other than a terminal is never colored
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "")
+	p, buf = newTestPrinter(t, Options{Color: ColorAuto})
+	p.Check(Failed, "Service", "STOPPED")
+	p.Flush()
+	if got := buf.String(); strings.Contains(got, "\x1b[") {
+		t.Errorf("Expected no colors, got %q", got)
+	}
+
+	if _, err := New(&bytes.Buffer{}, Options{Color: "rainbow"}); err == nil {
+		t.Error("Expected an invalid color mode to fail")
+	}
+}
+
+func TestPrinterNoColorOverridesForcedColors(t *testing.T) {
+	t.Setenv("CLICOLOR_FORCE", "1")
+	p, _ := newTestPrinter(t, Options{})
+	if got := p.colored("OK", OK.color()); !strings.Contains(got, "\x1b[") {
+		t.Errorf("Expected CLICOLOR_FORCE to color, got %q", got)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	p, _ = newTestPrinter(t, Options{})
+	if got := p.colored("OK", OK.color()); got != "OK" {
+		t.Errorf("Expected NO_COLOR to turn colors off, got %q", got)
+	}
+}
+
+func TestPrinterCutsToWidth(t *testing.T) {
+	p, buf := newTestPrinter(t, Options{})
+	p.width = 30
+	p.Check(Failed, "Modem", "cannot connect to modem at 192.168.100.1")
+	p.Flush()
+	line := strings.TrimSuffix(buf.String(), "\n")
+	if n := len([]rune(line)); n != 30 || !strings.HasSuffix(line, "…") {
+		t.Errorf("Expected the value cut to 30 columns, got %q (%d)", line, n)
+	}
+
+	p, buf = newTestPrinter(t, Options{Wide: true})
+	p.width = 30
+	p.Check(Failed, "Modem", "cannot connect to modem at 192.168.100.1")
+	p.Flush()
+	if !strings.Contains(buf.String(), "192.168.100.1") {
+		t.Errorf("Expected wide to keep the whole value, got %q", buf.String())
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,8 +22,10 @@ type Metrics struct {
 	StartupTime      time.Duration            `json:"startup_time"`
 	MemoryUsage      MemoryMetrics            `json:"memory_usage"`
 	OperationMetrics map[string]OperationStat `json:"operation_metrics"`
-	SystemMetrics    SystemMetrics            `json:"system_metrics"`
-	Timestamp        time.Time                `json:"timestamp"`
+	// TargetMetrics holds the latency of the checks of every target
+	TargetMetrics map[string]OperationStat `json:"target_metrics,omitempty"`
+	SystemMetrics SystemMetrics            `json:"system_metrics"`
+	Timestamp     time.Time                `json:"timestamp"`
 }
 
 // MemoryMetrics holds memory usage statistics
@@ -48,6 +52,9 @@ type OperationStat struct {
 	LastExecution   time.Time     `json:"last_execution"`
 	ErrorCount      int64         `json:"error_count"`
 	SuccessRate     float64       `json:"success_rate"`
+	// Window holds the statistics of the last window, which unlike the
+	// lifetime ones above follow changes in latency
+	Window WindowStats `json:"window"`
 }
 
 // ResourceLeakDetector monitors for resource leaks
@@ -183,15 +190,32 @@ type SystemMetrics struct {
 	GOARCH        string `json:"goarch"`
 }
 
+// Defaults bounding the operations and targets a monitor tracks
+const (
+	// DefaultMaxOperations caps the operations, and separately the targets,
+	// that are tracked
+	DefaultMaxOperations = 100
+	// DefaultRetention is how long an operation or target that is no longer
+	// recorded is kept
+	DefaultRetention = 7 * 24 * time.Hour
+)
+
 // Monitor tracks performance metrics with resource monitoring and limits
 type Monitor struct {
 	logger            *logrus.Logger
 	startTime         time.Time
 	operationStats    map[string]*OperationStat
+	targetStats       map[string]*OperationStat
+	windows           map[string]*latencyWindow // windowed latencies of operationStats
+	targetWindows     map[string]*latencyWindow // windowed latencies of targetStats
+	clock             clock.Clock
+	maxOperations     int           // cap on tracked names per map, 0 = no cap
+	retention         time.Duration // stale names are pruned after it, 0 = never
 	mutex             sync.RWMutex
 	metricsFile       string
 	reportInterval    time.Duration
 	enablePersistence bool
+	savesHeld         bool // periodic saves are skipped, guarded by mutex
 
 	// Resource monitoring and limits
 	memoryLimit           uint64        // Memory limit in bytes (0 = no limit)
@@ -209,6 +233,12 @@ func NewMonitor(logger *logrus.Logger, metricsFile string, reportInterval time.D
 		logger:                logger,
 		startTime:             time.Now(),
 		operationStats:        make(map[string]*OperationStat),
+		targetStats:           make(map[string]*OperationStat),
+		windows:               make(map[string]*latencyWindow),
+		targetWindows:         make(map[string]*latencyWindow),
+		clock:                 clock.New(),
+		maxOperations:         DefaultMaxOperations,
+		retention:             DefaultRetention,
 		metricsFile:           metricsFile,
 		reportInterval:        reportInterval,
 		enablePersistence:     metricsFile != "",
@@ -230,6 +260,12 @@ func NewMonitorWithLimitsAndInterval(logger *logrus.Logger, metricsFile string,
 		logger:                logger,
 		startTime:             time.Now(),
 		operationStats:        make(map[string]*OperationStat),
+		targetStats:           make(map[string]*OperationStat),
+		windows:               make(map[string]*latencyWindow),
+		targetWindows:         make(map[string]*latencyWindow),
+		clock:                 clock.New(),
+		maxOperations:         DefaultMaxOperations,
+		retention:             DefaultRetention,
 		metricsFile:           metricsFile,
 		reportInterval:        reportInterval,
 		enablePersistence:     metricsFile != "",
@@ -296,8 +332,12 @@ func (m *Monitor) Start(ctx context.Context) error {
 				}
 				return ctx.Err()
 			case <-ticker.C:
+				m.Prune()
 				m.logCurrentMetrics()
-				if m.enablePersistence {
+				m.mutex.RLock()
+				held := m.savesHeld
+				m.mutex.RUnlock()
+				if m.enablePersistence && !held {
 					if err := m.saveMetrics(); err != nil {
 						m.logger.WithError(err).Error("Failed to save periodic metrics")
 					}
@@ -309,25 +349,75 @@ func (m *Monitor) Start(ctx context.Context) error {
 	return nil
 }
 
+// SetClock replaces the clock that times the latency windows
+func (m *Monitor) SetClock(c clock.Clock) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.clock = c
+}
+
+// HoldSaves skips the periodic saves of the metrics to disk while hold is
+// set, so a disk that spun down is not woken; the final save on stop still
+// runs
+func (m *Monitor) HoldSaves(hold bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.savesHeld = hold
+}
+
+// SetRetention bounds the tracked operations and targets: at most
+// maxOperations of each are kept, evicting the least recently recorded, and
+// those not recorded within retention are pruned. 0 disables either bound.
+func (m *Monitor) SetRetention(maxOperations int, retention time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.maxOperations = maxOperations
+	m.retention = retention
+}
+
 // RecordOperation records the execution time and result of an operation
 func (m *Monitor) RecordOperation(operationName string, duration time.Duration, success bool) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	stat, exists := m.operationStats[operationName]
+	m.record(m.operationStats, m.windows, operationName, duration, success)
+}
+
+// RecordTargetLatency records the latency and result of a check of target,
+// such as a ping host or HTTP URL
+func (m *Monitor) RecordTargetLatency(target string, duration time.Duration, success bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.record(m.targetStats, m.targetWindows, target, duration, success)
+}
+
+// record adds a sample of name to stats and windows; callers hold m.mutex
+func (m *Monitor) record(stats map[string]*OperationStat, windows map[string]*latencyWindow, name string, duration time.Duration, success bool) {
+	now := m.clock.Now()
+	stat, exists := stats[name]
 	if !exists {
+		if m.maxOperations > 0 && len(stats) >= m.maxOperations {
+			m.evict(stats, windows, len(stats)-m.maxOperations+1)
+		}
 		stat = &OperationStat{
 			MinDuration: duration,
 			MaxDuration: duration,
 		}
-		m.operationStats[operationName] = stat
+		stats[name] = stat
+	}
+	window, exists := windows[name]
+	if !exists {
+		window = newLatencyWindow(DefaultWindow)
+		windows[name] = window
 	}
+	window.add(now, duration)
 
 	// Update statistics
 	stat.Count++
 	stat.TotalDuration += duration
 	stat.AverageDuration = stat.TotalDuration / time.Duration(stat.Count)
-	stat.LastExecution = time.Now()
+	stat.LastExecution = now
 
 	if duration < stat.MinDuration {
 		stat.MinDuration = duration
@@ -357,6 +447,68 @@ func (m *Monitor) TimedOperation(operationName string, operation func() error) e
 	return err
 }
 
+// Prune drops the operations and targets not recorded within the retention
+// and returns how many were dropped
+func (m *Monitor) Prune() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.prune()
+}
+
+// prune drops stale names and evicts names over the cap; callers hold
+// m.mutex
+func (m *Monitor) prune() int {
+	pruned := 0
+	if m.retention > 0 {
+		cutoff := m.clock.Now().Add(-m.retention)
+		for _, maps := range []struct {
+			stats   map[string]*OperationStat
+			windows map[string]*latencyWindow
+		}{{m.operationStats, m.windows}, {m.targetStats, m.targetWindows}} {
+			for name, stat := range maps.stats {
+				if stat.LastExecution.Before(cutoff) {
+					delete(maps.stats, name)
+					delete(maps.windows, name)
+					pruned++
+				}
+			}
+		}
+	}
+	if m.maxOperations > 0 {
+		if over := len(m.operationStats) - m.maxOperations; over > 0 {
+			pruned += m.evict(m.operationStats, m.windows, over)
+		}
+		if over := len(m.targetStats) - m.maxOperations; over > 0 {
+			pruned += m.evict(m.targetStats, m.targetWindows, over)
+		}
+	}
+	if pruned > 0 {
+		m.logger.WithField("pruned", pruned).Debug("Pruned stale operation metrics")
+	}
+	return pruned
+}
+
+// evict drops the n least recently recorded names of stats; callers hold
+// m.mutex
+func (m *Monitor) evict(stats map[string]*OperationStat, windows map[string]*latencyWindow, n int) int {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return stats[names[i]].LastExecution.Before(stats[names[j]].LastExecution)
+	})
+	if n > len(names) {
+		n = len(names)
+	}
+	for _, name := range names[:n] {
+		delete(stats, name)
+		delete(windows, name)
+	}
+	return n
+}
+
 // GetCurrentMetrics returns the current performance metrics
 func (m *Monitor) GetCurrentMetrics() Metrics {
 	m.mutex.RLock()
@@ -380,9 +532,10 @@ func (m *Monitor) GetCurrentMetrics() Metrics {
 	}
 
 	// Copy operation stats to avoid race conditions
-	operationMetrics := make(map[string]OperationStat)
-	for name, stat := range m.operationStats {
-		operationMetrics[name] = *stat
+	operationMetrics := m.snapshot(m.operationStats, m.windows)
+	var targetMetrics map[string]OperationStat
+	if len(m.targetStats) > 0 {
+		targetMetrics = m.snapshot(m.targetStats, m.targetWindows)
 	}
 
 	systemMetrics := SystemMetrics{
@@ -397,6 +550,7 @@ func (m *Monitor) GetCurrentMetrics() Metrics {
 		StartupTime:      time.Since(m.startTime),
 		MemoryUsage:      memoryMetrics,
 		OperationMetrics: operationMetrics,
+		TargetMetrics:    targetMetrics,
 		SystemMetrics:    systemMetrics,
 		Timestamp:        time.Now(),
 	}
@@ -424,7 +578,11 @@ func (m *Monitor) GetOperationStats(operationName string) (OperationStat, bool)
 		return OperationStat{}, false
 	}
 
-	return *stat, true
+	result := *stat
+	if window, ok := m.windows[operationName]; ok {
+		result.Window = window.stats(m.clock.Now())
+	}
+	return result, true
 }
 
 // GetAllOperationStats returns all operation statistics
@@ -432,11 +590,29 @@ func (m *Monitor) GetAllOperationStats() map[string]OperationStat {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
-	result := make(map[string]OperationStat)
-	for name, stat := range m.operationStats {
-		result[name] = *stat
-	}
+	return m.snapshot(m.operationStats, m.windows)
+}
 
+// GetAllTargetStats returns the latency statistics of every target
+func (m *Monitor) GetAllTargetStats() map[string]OperationStat {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.snapshot(m.targetStats, m.targetWindows)
+}
+
+// snapshot copies stats with the current statistics of their windows;
+// callers hold m.mutex
+func (m *Monitor) snapshot(stats map[string]*OperationStat, windows map[string]*latencyWindow) map[string]OperationStat {
+	now := m.clock.Now()
+	result := make(map[string]OperationStat, len(stats))
+	for name, stat := range stats {
+		copied := *stat
+		if window, ok := windows[name]; ok {
+			copied.Window = window.stats(now)
+		}
+		result[name] = copied
+	}
 	return result
 }
 
@@ -474,9 +650,29 @@ func (m *Monitor) logCurrentMetrics() {
 				"success_rate":   stat.SuccessRate,
 				"error_count":    stat.ErrorCount,
 				"last_execution": stat.LastExecution.Format("2006-01-02 15:04:05"),
+				"window_count":   stat.Window.Count,
+				"ema_duration":   stat.Window.EMA.String(),
+				"p50_duration":   stat.Window.P50.String(),
+				"p95_duration":   stat.Window.P95.String(),
+				"p99_duration":   stat.Window.P99.String(),
 			}).Info("Operation performance metrics")
 		}
 	}
+
+	// Log per-target latency
+	for target, stat := range metrics.TargetMetrics {
+		m.logger.WithFields(logrus.Fields{
+			"metric_type":  "target",
+			"target":       target,
+			"count":        stat.Count,
+			"success_rate": stat.SuccessRate,
+			"window_count": stat.Window.Count,
+			"ema_duration": stat.Window.EMA.String(),
+			"p50_duration": stat.Window.P50.String(),
+			"p95_duration": stat.Window.P95.String(),
+			"p99_duration": stat.Window.P99.String(),
+		}).Info("Target latency metrics")
+	}
 }
 
 // saveMetrics saves current metrics to disk
@@ -535,10 +731,18 @@ func (m *Monitor) loadMetrics() error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	// Windows start empty: the samples of the last run are not kept
 	for name, stat := range metrics.OperationMetrics {
 		statCopy := stat // Create a copy to avoid pointer issues
+		statCopy.Window = WindowStats{}
 		m.operationStats[name] = &statCopy
 	}
+	for name, stat := range metrics.TargetMetrics {
+		statCopy := stat
+		statCopy.Window = WindowStats{}
+		m.targetStats[name] = &statCopy
+	}
+	m.prune()
 
 	m.logger.WithFields(logrus.Fields{
 		"loaded_operations": len(metrics.OperationMetrics),
@@ -554,6 +758,9 @@ func (m *Monitor) ResetOperationStats() {
 	defer m.mutex.Unlock()
 
 	m.operationStats = make(map[string]*OperationStat)
+	m.targetStats = make(map[string]*OperationStat)
+	m.windows = make(map[string]*latencyWindow)
+	m.targetWindows = make(map[string]*latencyWindow)
 	m.logger.Info("Reset all operation statistics")
 }
 
@@ -563,6 +770,7 @@ func (m *Monitor) ResetOperationStat(operationName string) {
 	defer m.mutex.Unlock()
 
 	delete(m.operationStats, operationName)
+	delete(m.windows, operationName)
 	m.logger.WithField("operation", operationName).Info("Reset operation statistics")
 }
 
@@ -595,13 +803,47 @@ func (m *Monitor) GetMetricsSummary() string {
 			summary.WriteString(stat.AverageDuration.String())
 			summary.WriteString(", success ")
 			summary.WriteString(strconv.FormatFloat(stat.SuccessRate, 'f', 1, 64))
-			summary.WriteString("%\n")
+			summary.WriteString("%")
+			writeWindow(&summary, stat.Window)
+			summary.WriteString("\n")
+		}
+	}
+
+	if len(metrics.TargetMetrics) > 0 {
+		summary.WriteString("  Targets:\n")
+		for target, stat := range metrics.TargetMetrics {
+			summary.WriteString("    ")
+			summary.WriteString(target)
+			summary.WriteString(": ")
+			summary.WriteString(strconv.FormatInt(stat.Count, 10))
+			summary.WriteString(" checks, success ")
+			summary.WriteString(strconv.FormatFloat(stat.SuccessRate, 'f', 1, 64))
+			summary.WriteString("%")
+			writeWindow(&summary, stat.Window)
+			summary.WriteString("\n")
 		}
 	}
 
 	return summary.String()
 }
 
+// writeWindow appends the windowed statistics to a summary line
+func writeWindow(summary *strings.Builder, window WindowStats) {
+	if window.Count == 0 {
+		return
+	}
+	summary.WriteString(", last ")
+	summary.WriteString(window.Window.String())
+	summary.WriteString(": ema ")
+	summary.WriteString(window.EMA.Round(time.Microsecond).String())
+	summary.WriteString(", p50 ")
+	summary.WriteString(window.P50.Round(time.Microsecond).String())
+	summary.WriteString(", p95 ")
+	summary.WriteString(window.P95.Round(time.Microsecond).String())
+	summary.WriteString(", p99 ")
+	summary.WriteString(window.P99.Round(time.Microsecond).String())
+}
+
 // ForceGC triggers garbage collection and logs memory stats
 func (m *Monitor) ForceGC() {
 	var beforeStats, afterStats runtime.MemStats
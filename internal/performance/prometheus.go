@@ -0,0 +1,186 @@
+package performance
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PrometheusContentType is the content type of the Prometheus text format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// maxLabelLength caps a label value in runes
+const maxLabelLength = 128
+
+// WritePrometheus writes metrics in the Prometheus text exposition format.
+// Operation and target names become label values after SanitizeLabelValue;
+// names that end up with the same labels are exported as one series.
+func WritePrometheus(w io.Writer, metrics Metrics) error {
+	out := bufio.NewWriter(w)
+
+	operations := groupStats(metrics.OperationMetrics, func(name string) []label {
+		return []label{{"operation", SanitizeLabelValue(name)}}
+	})
+	targets := groupStats(metrics.TargetMetrics, func(name string) []label {
+		test, target, ok := strings.Cut(name, " ")
+		if !ok {
+			test, target = "", name
+		}
+		return []label{{"test", SanitizeLabelValue(test)}, {"target", SanitizeLabelValue(target)}}
+	})
+
+	writeSummary(out, "watchdog_operation_duration_seconds", "Duration of watchdog operations; quantiles cover the last window.", operations)
+	writeCounter(out, "watchdog_operation_errors_total", "Failed watchdog operations.", operations)
+	writeEMA(out, "watchdog_operation_duration_ema_seconds", "Moving average of the duration of watchdog operations.", operations)
+	writeSummary(out, "watchdog_target_duration_seconds", "Duration of the checks of every target; quantiles cover the last window.", targets)
+	writeCounter(out, "watchdog_target_errors_total", "Failed checks of every target.", targets)
+	writeEMA(out, "watchdog_target_duration_ema_seconds", "Moving average of the duration of the checks of every target.", targets)
+
+	fmt.Fprintf(out, "# HELP watchdog_memory_alloc_bytes Bytes of allocated heap objects.\n# TYPE watchdog_memory_alloc_bytes gauge\nwatchdog_memory_alloc_bytes %d\n", metrics.MemoryUsage.AllocBytes)
+	fmt.Fprintf(out, "# HELP watchdog_goroutines Number of goroutines.\n# TYPE watchdog_goroutines gauge\nwatchdog_goroutines %d\n", metrics.SystemMetrics.NumGoroutines)
+	return out.Flush()
+}
+
+// SanitizeLabelValue makes name fit as a label value without multiplying
+// series: a URL is cut to its scheme and host, so query strings and paths
+// such as per-request nonces do not each become a series. Invalid UTF-8 is
+// dropped and the value is capped at 128 runes.
+func SanitizeLabelValue(name string) string {
+	if parsed, err := url.Parse(name); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		name = parsed.Scheme + "://" + parsed.Host
+	}
+	name = strings.ToValidUTF8(name, "")
+	if utf8.RuneCountInString(name) > maxLabelLength {
+		name = string([]rune(name)[:maxLabelLength])
+	}
+	return name
+}
+
+// label is a label name and value
+type label struct {
+	name, value string
+}
+
+// series is the merged statistics of the names that share labels
+type series struct {
+	labels []label
+	stat   OperationStat
+}
+
+// groupStats merges stats by their labels and sorts the series by them
+func groupStats(stats map[string]OperationStat, labelsOf func(string) []label) []series {
+	byKey := make(map[string]*series, len(stats))
+	for name, stat := range stats {
+		labels := labelsOf(name)
+		key := formatLabels(labels)
+		if existing, ok := byKey[key]; ok {
+			existing.stat = mergeStats(existing.stat, stat)
+			continue
+		}
+		byKey[key] = &series{labels: labels, stat: stat}
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]series, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *byKey[key])
+	}
+	return result
+}
+
+// mergeStats adds up the counts of a and b; for the windowed statistics it
+// keeps the worse of both
+func mergeStats(a, b OperationStat) OperationStat {
+	a.Count += b.Count
+	a.ErrorCount += b.ErrorCount
+	a.TotalDuration += b.TotalDuration
+	a.Window.Count += b.Window.Count
+	if b.Window.EMA > a.Window.EMA {
+		a.Window.EMA = b.Window.EMA
+	}
+	if b.Window.P50 > a.Window.P50 {
+		a.Window.P50 = b.Window.P50
+	}
+	if b.Window.P95 > a.Window.P95 {
+		a.Window.P95 = b.Window.P95
+	}
+	if b.Window.P99 > a.Window.P99 {
+		a.Window.P99 = b.Window.P99
+	}
+	return a
+}
+
+// writeSummary writes the windowed quantiles and lifetime sum and count of
+// every series
+func writeSummary(out io.Writer, name, help string, all []series) {
+	if len(all) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s summary\n", name, help, name)
+	for _, s := range all {
+		if s.stat.Window.Count > 0 {
+			for _, q := range []struct {
+				quantile string
+				value    time.Duration
+			}{{"0.5", s.stat.Window.P50}, {"0.95", s.stat.Window.P95}, {"0.99", s.stat.Window.P99}} {
+				labels := append(append([]label(nil), s.labels...), label{"quantile", q.quantile})
+				fmt.Fprintf(out, "%s%s %g\n", name, formatLabels(labels), q.value.Seconds())
+			}
+		}
+		fmt.Fprintf(out, "%s_sum%s %g\n", name, formatLabels(s.labels), s.stat.TotalDuration.Seconds())
+		fmt.Fprintf(out, "%s_count%s %d\n", name, formatLabels(s.labels), s.stat.Count)
+	}
+}
+
+// writeCounter writes the error count of every series
+func writeCounter(out io.Writer, name, help string, all []series) {
+	if len(all) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, s := range all {
+		fmt.Fprintf(out, "%s%s %d\n", name, formatLabels(s.labels), s.stat.ErrorCount)
+	}
+}
+
+// writeEMA writes the moving average of the series that have one
+func writeEMA(out io.Writer, name, help string, all []series) {
+	if len(all) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	for _, s := range all {
+		if s.stat.Window.EMA > 0 {
+			fmt.Fprintf(out, "%s%s %g\n", name, formatLabels(s.labels), s.stat.Window.EMA.Seconds())
+		}
+	}
+}
+
+// formatLabels writes labels as {name="value",...} with values escaped
+func formatLabels(labels []label) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l.name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(l.value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// labelEscaper escapes a label value for the text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,65 @@
+package performance
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSanitizeLabelValue(t *testing.T) {
+	tests := map[string]string{
+		"connectivity_check":                        "connectivity_check",
+		"https://www.google.com/generate_204":       "https://www.google.com",
+		"http://vps.example.net:8601/check?nonce=a": "http://vps.example.net:8601",
+		"1.1.1.1:443":                               "1.1.1.1:443",
+		"bad\xffutf8":                               "badutf8",
+		strings.Repeat("x", 200):                    strings.Repeat("x", maxLabelLength),
+	}
+	for name, want := range tests {
+		if got := SanitizeLabelValue(name); got != want {
+			t.Errorf("SanitizeLabelValue(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	metrics := Metrics{
+		OperationMetrics: map[string]OperationStat{
+			"connectivity_check": {
+				Count:         4,
+				ErrorCount:    1,
+				TotalDuration: 2 * time.Second,
+				Window:        WindowStats{Count: 4, EMA: 500 * time.Millisecond, P50: 400 * time.Millisecond, P95: 900 * time.Millisecond, P99: time.Second},
+			},
+		},
+		TargetMetrics: map[string]OperationStat{
+			`http_connectivity http://vps.example.net:8601/check?nonce=a`: {Count: 1, TotalDuration: time.Second},
+			`http_connectivity http://vps.example.net:8601/check?nonce=b`: {Count: 2, ErrorCount: 1, TotalDuration: time.Second},
+			`tcp_handshake a"b`: {Count: 1},
+		},
+	}
+
+	var out strings.Builder
+	if err := WritePrometheus(&out, metrics); err != nil {
+		t.Fatalf("WritePrometheus() failed: %v", err)
+	}
+	text := out.String()
+
+	for _, line := range []string{
+		"# TYPE watchdog_operation_duration_seconds summary",
+		`watchdog_operation_duration_seconds{operation="connectivity_check",quantile="0.95"} 0.9`,
+		`watchdog_operation_duration_seconds_count{operation="connectivity_check"} 4`,
+		`watchdog_operation_errors_total{operation="connectivity_check"} 1`,
+		`watchdog_operation_duration_ema_seconds{operation="connectivity_check"} 0.5`,
+		`watchdog_target_duration_seconds_count{test="http_connectivity",target="http://vps.example.net:8601"} 3`,
+		`watchdog_target_errors_total{test="http_connectivity",target="http://vps.example.net:8601"} 1`,
+		`watchdog_target_duration_seconds_count{test="tcp_handshake",target="a\"b"} 1`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("Expected line %q in:\n%s", line, text)
+		}
+	}
+	if strings.Contains(text, "nonce") {
+		t.Errorf("Expected query strings to be cut from labels:\n%s", text)
+	}
+}
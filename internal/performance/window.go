@@ -0,0 +1,143 @@
+package performance
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultWindow is the span of the windowed latency statistics and the time
+// constant of their moving average
+const DefaultWindow = time.Hour
+
+const (
+	// windowSlots is the number of histograms a window rotates through, so
+	// samples leave it a sixth of the window at a time
+	windowSlots = 6
+	// bucketGrowth is the ratio between the bounds of neighbouring histogram
+	// buckets; a percentile is off by at most 4%
+	bucketGrowth = 1.04
+	// bucketBase is the upper bound of the first bucket
+	bucketBase = time.Microsecond
+)
+
+// WindowStats are latency statistics over the recent window instead of the
+// lifetime of the process
+type WindowStats struct {
+	Window time.Duration `json:"window"`
+	// Count is the number of samples in the window
+	Count uint64 `json:"count"`
+	// EMA is the exponential moving average with the window as time constant
+	EMA time.Duration `json:"ema"`
+	P50 time.Duration `json:"p50"`
+	P95 time.Duration `json:"p95"`
+	P99 time.Duration `json:"p99"`
+}
+
+// latencyWindow keeps a log-bucketed histogram of the latencies of the last
+// window, split into slots that expire one by one, and their moving average
+type latencyWindow struct {
+	span  time.Duration
+	slots [windowSlots]histogram
+
+	ema    float64
+	emaAt  time.Time
+	hasEMA bool
+}
+
+// histogram counts the samples of one slot per bucket
+type histogram struct {
+	start  time.Time
+	counts map[int]uint64
+}
+
+// newLatencyWindow creates a window over span
+func newLatencyWindow(span time.Duration) *latencyWindow {
+	if span <= 0 {
+		span = DefaultWindow
+	}
+	return &latencyWindow{span: span}
+}
+
+// add records a latency measured at now
+func (w *latencyWindow) add(now time.Time, d time.Duration) {
+	slotSpan := w.span / windowSlots
+	start := now.Truncate(slotSpan)
+	slot := &w.slots[(start.UnixNano()/int64(slotSpan))%windowSlots]
+	if !slot.start.Equal(start) || slot.counts == nil {
+		slot.start = start
+		slot.counts = make(map[int]uint64)
+	}
+	slot.counts[bucket(d)]++
+
+	if !w.hasEMA {
+		w.ema, w.emaAt, w.hasEMA = float64(d), now, true
+		return
+	}
+	if elapsed := now.Sub(w.emaAt); elapsed > 0 {
+		alpha := 1 - math.Exp(-float64(elapsed)/float64(w.span))
+		w.ema += alpha * (float64(d) - w.ema)
+		w.emaAt = now
+	}
+}
+
+// stats returns the statistics of the samples still in the window at now
+func (w *latencyWindow) stats(now time.Time) WindowStats {
+	stats := WindowStats{Window: w.span}
+	if w.hasEMA {
+		stats.EMA = time.Duration(w.ema)
+	}
+
+	counts := make(map[int]uint64)
+	oldest := now.Add(-w.span)
+	for _, slot := range w.slots {
+		if slot.counts == nil || !slot.start.After(oldest) {
+			continue
+		}
+		for b, n := range slot.counts {
+			counts[b] += n
+			stats.Count += n
+		}
+	}
+	if stats.Count == 0 {
+		return stats
+	}
+
+	buckets := make([]int, 0, len(counts))
+	for b := range counts {
+		buckets = append(buckets, b)
+	}
+	sort.Ints(buckets)
+
+	stats.P50 = percentile(buckets, counts, stats.Count, 0.50)
+	stats.P95 = percentile(buckets, counts, stats.Count, 0.95)
+	stats.P99 = percentile(buckets, counts, stats.Count, 0.99)
+	return stats
+}
+
+// percentile returns the upper bound of the bucket holding quantile q of
+// total samples, with buckets sorted
+func percentile(buckets []int, counts map[int]uint64, total uint64, q float64) time.Duration {
+	rank := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for _, b := range buckets {
+		seen += counts[b]
+		if seen >= rank {
+			return bucketBound(b)
+		}
+	}
+	return bucketBound(buckets[len(buckets)-1])
+}
+
+// bucket returns the histogram bucket of d
+func bucket(d time.Duration) int {
+	if d <= bucketBase {
+		return 0
+	}
+	return int(math.Ceil(math.Log(float64(d)/float64(bucketBase)) / math.Log(bucketGrowth)))
+}
+
+// bucketBound returns the upper bound of bucket b
+func bucketBound(b int) time.Duration {
+	return time.Duration(float64(bucketBase) * math.Pow(bucketGrowth, float64(b)))
+}
@@ -0,0 +1,140 @@
+package performance
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/sirupsen/logrus"
+)
+
+// within reports whether got is no more than one bucket above want
+func within(got, want time.Duration) bool {
+	return got >= want && float64(got) <= float64(want)*bucketGrowth
+}
+
+func TestLatencyWindowPercentiles(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	window := newLatencyWindow(time.Hour)
+	for i := 1; i <= 100; i++ {
+		window.add(now, time.Duration(i)*time.Millisecond)
+	}
+
+	stats := window.stats(now)
+	if stats.Count != 100 {
+		t.Fatalf("Expected 100 samples, got %d", stats.Count)
+	}
+	for _, tt := range []struct {
+		name      string
+		got, want time.Duration
+	}{
+		{"p50", stats.P50, 50 * time.Millisecond},
+		{"p95", stats.P95, 95 * time.Millisecond},
+		{"p99", stats.P99, 99 * time.Millisecond},
+	} {
+		if !within(tt.got, tt.want) {
+			t.Errorf("Expected %s near %v, got %v", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestLatencyWindowForgetsOldSamples(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	window := newLatencyWindow(time.Hour)
+	for i := 0; i < 50; i++ {
+		window.add(now, 2*time.Second)
+	}
+
+	later := now.Add(90 * time.Minute)
+	for i := 0; i < 10; i++ {
+		window.add(later, 20*time.Millisecond)
+	}
+
+	stats := window.stats(later)
+	if stats.Count != 10 {
+		t.Errorf("Expected only the last hour's samples, got %d", stats.Count)
+	}
+	if !within(stats.P99, 20*time.Millisecond) {
+		t.Errorf("Expected p99 to follow the recent latency, got %v", stats.P99)
+	}
+	if stats.EMA <= 20*time.Millisecond || stats.EMA >= 2*time.Second {
+		t.Errorf("Expected the moving average between the old and new latency, got %v", stats.EMA)
+	}
+
+	if stats := window.stats(later.Add(2 * time.Hour)); stats.Count != 0 || stats.P50 != 0 {
+		t.Errorf("Expected an empty window after two idle hours, got %+v", stats)
+	}
+}
+
+func TestRecordTargetLatency(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+
+	monitor := NewMonitor(logger, "", 0)
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	monitor.SetClock(fake)
+
+	monitor.RecordTargetLatency("http_connectivity https://example.com", 30*time.Millisecond, true)
+	monitor.RecordTargetLatency("http_connectivity https://example.com", 50*time.Millisecond, false)
+
+	stats := monitor.GetAllTargetStats()
+	stat, ok := stats["http_connectivity https://example.com"]
+	if !ok {
+		t.Fatalf("Expected target stats, got %v", stats)
+	}
+	if stat.Count != 2 || stat.SuccessRate != 50 {
+		t.Errorf("Expected 2 checks at 50%% success, got %+v", stat)
+	}
+	if stat.Window.Count != 2 || !within(stat.Window.P99, 50*time.Millisecond) {
+		t.Errorf("Expected windowed stats of both checks, got %+v", stat.Window)
+	}
+	if _, ok := monitor.GetCurrentMetrics().TargetMetrics["http_connectivity https://example.com"]; !ok {
+		t.Error("Expected the target in the current metrics")
+	}
+
+	fake.Advance(2 * time.Hour)
+	if stat := monitor.GetAllTargetStats()["http_connectivity https://example.com"]; stat.Window.Count != 0 || stat.Count != 2 {
+		t.Errorf("Expected the window to empty while lifetime stats stay, got %+v", stat)
+	}
+}
+
+func TestPruneAndCap(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+
+	monitor := NewMonitor(logger, "", 0)
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	monitor.SetClock(fake)
+	monitor.SetRetention(2, 24*time.Hour)
+
+	monitor.RecordOperation("stale", time.Millisecond, true)
+	fake.Advance(25 * time.Hour)
+	monitor.RecordOperation("recent", time.Millisecond, true)
+	if pruned := monitor.Prune(); pruned != 1 {
+		t.Errorf("Expected the stale operation to be pruned, pruned %d", pruned)
+	}
+	if _, ok := monitor.GetOperationStats("stale"); ok {
+		t.Error("Expected the stale operation to be gone")
+	}
+
+	fake.Advance(time.Minute)
+	monitor.RecordOperation("second", time.Millisecond, true)
+	fake.Advance(time.Minute)
+	monitor.RecordOperation("third", time.Millisecond, true)
+
+	stats := monitor.GetAllOperationStats()
+	if len(stats) != 2 {
+		t.Fatalf("Expected the cap to keep 2 operations, got %v", stats)
+	}
+	if _, ok := stats["recent"]; ok {
+		t.Error("Expected the least recently recorded operation to be evicted")
+	}
+
+	for i := 0; i < 5; i++ {
+		monitor.RecordTargetLatency(string(rune('a'+i)), time.Millisecond, true)
+	}
+	if targets := monitor.GetAllTargetStats(); len(targets) != 2 {
+		t.Errorf("Expected the cap to apply to targets, got %d", len(targets))
+	}
+}
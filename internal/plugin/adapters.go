@@ -0,0 +1,209 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/connectivity"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// ModemType is the modem type of the modem driver a plugin provides
+const ModemType = "plugin"
+
+// Set is the plugins the watchdog runs
+type Set struct {
+	plugins []*Plugin
+}
+
+// Load starts the plugins at paths. A plugin that fails to start is logged
+// and left out, so one broken plugin does not stop the watchdog. The first
+// plugin providing a modem driver is registered as modem type ModemType, and
+// the first providing a decision policy is the policy.
+func Load(ctx context.Context, paths []string, logger *logrus.Logger) *Set {
+	set := &Set{}
+	for _, path := range paths {
+		p, err := Start(ctx, path, logger)
+		if err != nil {
+			logger.WithError(err).Error("Failed to start plugin, skipping it")
+			continue
+		}
+		logger.WithFields(logrus.Fields{
+			"plugin":       p.Name(),
+			"path":         path,
+			"capabilities": p.Info().Capabilities,
+		}).Info("Plugin started")
+		set.plugins = append(set.plugins, p)
+	}
+
+	for _, p := range set.plugins {
+		if p.Info().Has(CapabilityModem) {
+			p := p
+			modem.Register(ModemType, func(opts modem.Options, _ *logrus.Logger) modem.Driver {
+				return newModemDriver(p, opts)
+			})
+			break
+		}
+	}
+
+	policies := 0
+	for _, p := range set.plugins {
+		if p.Info().Has(CapabilityPolicy) {
+			policies++
+		}
+	}
+	if policies > 1 {
+		logger.WithField("policy", set.Policy().Name()).Warn("Several plugins provide a decision policy, using the first")
+	}
+	return set
+}
+
+// Plugins returns the plugins that started
+func (s *Set) Plugins() []*Plugin {
+	return s.plugins
+}
+
+// Checks returns the checks of the plugins with CapabilityCheck
+func (s *Set) Checks() []connectivity.Check {
+	var checks []connectivity.Check
+	for _, p := range s.plugins {
+		if p.Info().Has(CapabilityCheck) {
+			checks = append(checks, &check{plugin: p})
+		}
+	}
+	return checks
+}
+
+// Notifiers returns the notifiers of the plugins with CapabilityNotifier
+func (s *Set) Notifiers() []notify.Notifier {
+	var notifiers []notify.Notifier
+	for _, p := range s.plugins {
+		if p.Info().Has(CapabilityNotifier) {
+			notifiers = append(notifiers, &notifier{plugin: p})
+		}
+	}
+	return notifiers
+}
+
+// Policy returns the decision policy of the first plugin with
+// CapabilityPolicy, nil without one
+func (s *Set) Policy() monitor.DecisionPolicy {
+	for _, p := range s.plugins {
+		if p.Info().Has(CapabilityPolicy) {
+			return &policy{plugin: p}
+		}
+	}
+	return nil
+}
+
+// Close stops the plugins, returning the first error
+func (s *Set) Close() error {
+	var firstErr error
+	for _, p := range s.plugins {
+		if err := p.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+	}
+	return firstErr
+}
+
+// check is the connectivity check of a plugin
+type check struct {
+	plugin *Plugin
+}
+
+// Name returns the plugin name
+func (c *check) Name() string {
+	return c.plugin.Name()
+}
+
+// Run runs the check, failing when the plugin reports a failure
+func (c *check) Run(ctx context.Context) error {
+	var result CheckResult
+	if err := c.plugin.call(ctx, MethodCheck, Empty{}, &result); err != nil {
+		return err
+	}
+	if !result.Success {
+		if result.Message == "" {
+			return errors.New("check failed")
+		}
+		return errors.New(result.Message)
+	}
+	return nil
+}
+
+// notifier is the notifier of a plugin
+type notifier struct {
+	plugin *Plugin
+}
+
+// Notify hands notification to the plugin
+func (n *notifier) Notify(ctx context.Context, notification notify.Notification) error {
+	return n.plugin.call(ctx, MethodNotify, notification, &Empty{})
+}
+
+// policy is the decision policy of a plugin
+type policy struct {
+	plugin *Plugin
+}
+
+// Name returns the plugin name
+func (p *policy) Name() string {
+	return p.plugin.Name()
+}
+
+// Decide asks the plugin whether to reboot
+func (p *policy) Decide(ctx context.Context, input monitor.PolicyInput) (monitor.PolicyDecision, error) {
+	var decision monitor.PolicyDecision
+	err := p.plugin.call(ctx, MethodPolicy, input, &decision)
+	return decision, err
+}
+
+// modemDriver is the modem driver of a plugin
+type modemDriver struct {
+	plugin *Plugin
+	login  ModemLogin
+}
+
+// newModemDriver creates the modem driver of p, which logs in with the
+// settings of opts
+func newModemDriver(p *Plugin, opts modem.Options) *modemDriver {
+	return &modemDriver{
+		plugin: p,
+		login: ModemLogin{
+			Host:     opts.Host,
+			Port:     opts.Port,
+			Username: opts.Username,
+			Password: opts.Password,
+			NoVerify: opts.NoVerify,
+		},
+	}
+}
+
+// Name returns ModemType
+func (d *modemDriver) Name() string {
+	return ModemType
+}
+
+// Login asks the plugin to log in to the modem
+func (d *modemDriver) Login(ctx context.Context) error {
+	return d.plugin.call(ctx, MethodModemLogin, d.login, &Empty{})
+}
+
+// GetStatus asks the plugin for the modem status
+func (d *modemDriver) GetStatus(ctx context.Context) (*modem.Status, error) {
+	var status modem.Status
+	if err := d.plugin.call(ctx, MethodModemStatus, Empty{}, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Reboot asks the plugin to reboot the modem
+func (d *modemDriver) Reboot(ctx context.Context) error {
+	return d.plugin.call(ctx, MethodModemReboot, Empty{}, &Empty{})
+}
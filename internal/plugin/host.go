@@ -0,0 +1,255 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StartTimeout is how long a plugin may take to answer Plugin.Info
+const StartTimeout = 10 * time.Second
+
+// stopTimeout is how long a plugin may take to exit once its standard input
+// closes before it is killed
+const stopTimeout = 5 * time.Second
+
+// EnvPrefix marks the variables of the watchdog's environment that are
+// passed on to plugins, such as WATCHDOG_PLUGIN_NAS_HOST for a check plugin
+const EnvPrefix = "WATCHDOG_PLUGIN_"
+
+// inheritedEnv are the other variables plugins get: the search path, home
+// and locale, nothing that could hold a password or token
+var inheritedEnv = []string{"PATH", "HOME", "TMPDIR", "TZ", "LANG", "LC_ALL"}
+
+// Plugin is a running plugin. It restarts the plugin process when a call
+// finds that it exited.
+type Plugin struct {
+	path   string
+	logger *logrus.Logger
+
+	mu      sync.Mutex
+	info    Info
+	process *process
+}
+
+// process is one run of a plugin's program
+type process struct {
+	cmd    *exec.Cmd
+	client *rpc.Client
+	// exited is closed when the program exits
+	exited chan struct{}
+}
+
+// Start starts the plugin at path and asks it for its info
+func Start(ctx context.Context, path string, logger *logrus.Logger) (*Plugin, error) {
+	p := &Plugin{path: path, logger: logger}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.start(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Info returns what the plugin reported about itself
+func (p *Plugin) Info() Info {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.info
+}
+
+// Name returns the name the plugin reported
+func (p *Plugin) Name() string {
+	return p.Info().Name
+}
+
+// Path returns the path of the plugin's program
+func (p *Plugin) Path() string {
+	return p.path
+}
+
+// start runs the plugin's program and checks its info; the caller holds mu
+func (p *Plugin) start(ctx context.Context) error {
+	if err := checkTrusted(p.path); err != nil {
+		return err
+	}
+	cmd := exec.Command(p.path)
+	cmd.Env = pluginEnv(os.Environ())
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start plugin %s: %w", p.path, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start plugin %s: %w", p.path, err)
+	}
+	stderr := p.logger.WithField("plugin", filepath.Base(p.path)).WriterLevel(logrus.InfoLevel)
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		stderr.Close()
+		return fmt.Errorf("failed to start plugin %s: %w", p.path, err)
+	}
+
+	proc := &process{
+		cmd:    cmd,
+		client: jsonrpc.NewClient(pipe{stdout, stdin}),
+		exited: make(chan struct{}),
+	}
+	go func() {
+		err := cmd.Wait()
+		stderr.Close()
+		close(proc.exited)
+		p.logger.WithField("plugin", p.path).WithError(err).Debug("Plugin exited")
+	}()
+
+	startCtx, cancel := context.WithTimeout(ctx, StartTimeout)
+	defer cancel()
+	var info Info
+	if err := proc.call(startCtx, MethodInfo, Empty{}, &info); err != nil {
+		proc.stop()
+		return fmt.Errorf("plugin %s did not answer %s: %w", p.path, MethodInfo, err)
+	}
+	if info.ProtocolVersion != ProtocolVersion {
+		proc.stop()
+		return fmt.Errorf("plugin %s speaks protocol version %d, want %d", p.path, info.ProtocolVersion, ProtocolVersion)
+	}
+	if info.Name == "" {
+		info.Name = filepath.Base(p.path)
+	}
+	p.info, p.process = info, proc
+	return nil
+}
+
+// pluginEnv returns the environment of a plugin from that of the watchdog:
+// inheritedEnv and the variables starting with EnvPrefix. The modem
+// password, API secrets and notification tokens stay with the watchdog.
+func pluginEnv(environ []string) []string {
+	var env []string
+	for _, variable := range environ {
+		name, _, _ := strings.Cut(variable, "=")
+		if strings.HasPrefix(name, EnvPrefix) {
+			env = append(env, variable)
+			continue
+		}
+		for _, inherited := range inheritedEnv {
+			if name == inherited {
+				env = append(env, variable)
+				break
+			}
+		}
+	}
+	return env
+}
+
+// checkTrusted refuses a program that a user other than root or the
+// watchdog's own could replace. Plugins are not sandboxed: they run with the
+// watchdog's user and capabilities, so whoever can write the program or its
+// directory controls the watchdog.
+func checkTrusted(path string) error {
+	for _, name := range []string{path, filepath.Dir(path)} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return fmt.Errorf("failed to start plugin %s: %w", path, err)
+		}
+		if info.Mode().Perm()&0o022 != 0 {
+			return fmt.Errorf("refusing plugin %s: %s is writable by group or others", path, name)
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Uid != 0 && int(stat.Uid) != os.Getuid() {
+			return fmt.Errorf("refusing plugin %s: %s is owned by uid %d, not root or the watchdog's user", path, name, stat.Uid)
+		}
+	}
+	return nil
+}
+
+// call calls method of the plugin, restarting the plugin first when it
+// exited since the last call
+func (p *Plugin) call(ctx context.Context, method string, args, reply interface{}) error {
+	p.mu.Lock()
+	proc := p.process
+	if proc == nil || proc.hasExited() {
+		if proc != nil {
+			p.logger.WithField("plugin", p.info.Name).Warn("Plugin exited, restarting it")
+		}
+		if err := p.start(ctx); err != nil {
+			p.process = nil
+			p.mu.Unlock()
+			return err
+		}
+		proc = p.process
+	}
+	name := p.info.Name
+	p.mu.Unlock()
+
+	if err := proc.call(ctx, method, args, reply); err != nil {
+		return fmt.Errorf("plugin %s: %s: %w", name, method, err)
+	}
+	return nil
+}
+
+// Close stops the plugin, killing it unless it exits soon after its
+// standard input closes
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.process == nil {
+		return nil
+	}
+	err := p.process.stop()
+	p.process = nil
+	return err
+}
+
+// call calls method, giving up when ctx ends
+func (proc *process) call(ctx context.Context, method string, args, reply interface{}) error {
+	call := proc.client.Go(method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-proc.exited:
+		return errors.New("plugin exited")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (proc *process) hasExited() bool {
+	select {
+	case <-proc.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop closes the plugin's standard input and waits for it to exit
+func (proc *process) stop() error {
+	proc.client.Close()
+	select {
+	case <-proc.exited:
+		return nil
+	case <-time.After(stopTimeout):
+		if err := proc.cmd.Process.Kill(); err != nil {
+			return fmt.Errorf("failed to kill plugin: %w", err)
+		}
+		<-proc.exited
+		return nil
+	}
+}
+
+// pipe is the standard output and input of a plugin as a connection
+type pipe struct {
+	io.Reader
+	io.WriteCloser
+}
@@ -0,0 +1,260 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/perezjoseph/mb8600-watchdog/internal/verdict"
+	"github.com/sirupsen/logrus"
+)
+
+// The test binary serves as the plugin of the tests when started with
+// testPluginEnv set
+const testPluginEnv = EnvPrefix + "TEST"
+
+// testPluginOutput names the file the test plugin writes notifications to
+const testPluginOutput = EnvPrefix + "TEST_OUTPUT"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) != "" {
+		if err := Serve(testServer()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testServer is a plugin whose check fails while testPluginEnv is "down" or
+// it sees MODEM_PASSWORD, writing notification titles to the file named by
+// testPluginOutput, and whose policy notifies instead of a third reboot in a
+// day
+func testServer() Server {
+	return Server{
+		Name: "test",
+		Check: func(ctx context.Context) (CheckResult, error) {
+			if os.Getenv(testPluginEnv) == "down" {
+				return CheckResult{Message: "nas unreachable"}, nil
+			}
+			if os.Getenv("MODEM_PASSWORD") != "" {
+				return CheckResult{Message: "MODEM_PASSWORD was passed on"}, nil
+			}
+			return CheckResult{Success: true}, nil
+		},
+		Notify: func(ctx context.Context, notification notify.Notification) error {
+			return os.WriteFile(os.Getenv(testPluginOutput), []byte(notification.Title), 0o600)
+		},
+		Modem: &testModem{},
+		Policy: func(ctx context.Context, input monitor.PolicyInput) (monitor.PolicyDecision, error) {
+			if input.RecentReboots >= 2 {
+				return monitor.PolicyDecision{Action: verdict.ActionNotify, Reason: "rebooted twice today"}, nil
+			}
+			return monitor.PolicyDecision{}, nil
+		},
+	}
+}
+
+type testModem struct {
+	loggedIn bool
+}
+
+func (m *testModem) Login(ctx context.Context, login ModemLogin) error {
+	if login.Username != "admin" || login.Password != "secret" {
+		return errors.New("wrong password")
+	}
+	m.loggedIn = true
+	return nil
+}
+
+func (m *testModem) GetStatus(ctx context.Context) (*modem.Status, error) {
+	if !m.loggedIn {
+		return nil, errors.New("not logged in")
+	}
+	return &modem.Status{Model: "Test", FirmwareVersion: "1.0", Downstream: []modem.Channel{{ChannelID: 1, LockStatus: "Locked"}}}, nil
+}
+
+func (m *testModem) Reboot(ctx context.Context) error {
+	return nil
+}
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return logger
+}
+
+// startTestPlugin starts the test binary as a plugin
+func startTestPlugin(t *testing.T, mode string) *Set {
+	t.Helper()
+	t.Setenv(testPluginEnv, mode)
+	set := Load(context.Background(), []string{os.Args[0]}, testLogger())
+	t.Cleanup(func() { set.Close() })
+	if len(set.Plugins()) != 1 {
+		t.Fatal("Expected the test plugin to start")
+	}
+	return set
+}
+
+func TestPluginEnvironmentWithoutSecrets(t *testing.T) {
+	t.Setenv("MODEM_PASSWORD", "secret")
+	set := startTestPlugin(t, "up")
+	if err := set.Checks()[0].Run(context.Background()); err != nil {
+		t.Errorf("Expected the plugin not to see MODEM_PASSWORD, got %v", err)
+	}
+
+	env := pluginEnv([]string{"PATH=/usr/bin", "MODEM_PASSWORD=secret", "API_TOKEN=x", EnvPrefix + "NAS=nas.lan", "PATHEXT=1"})
+	want := []string{"PATH=/usr/bin", EnvPrefix + "NAS=nas.lan"}
+	if strings.Join(env, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected environment %v, got %v", want, env)
+	}
+}
+
+func TestPluginCapabilities(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "notification")
+	t.Setenv(testPluginOutput, output)
+	set := startTestPlugin(t, "up")
+	ctx := context.Background()
+
+	info := set.Plugins()[0].Info()
+	if info.Name != "test" || info.ProtocolVersion != ProtocolVersion || len(info.Capabilities) != 4 {
+		t.Errorf("Unexpected info %+v", info)
+	}
+
+	checks := set.Checks()
+	if len(checks) != 1 || checks[0].Name() != "test" {
+		t.Fatalf("Expected the check of the test plugin, got %v", checks)
+	}
+	if err := checks[0].Run(ctx); err != nil {
+		t.Errorf("Expected the check to pass, got %v", err)
+	}
+
+	notifiers := set.Notifiers()
+	if len(notifiers) != 1 {
+		t.Fatalf("Expected the notifier of the test plugin, got %v", notifiers)
+	}
+	if err := notifiers[0].Notify(ctx, notify.Notification{Kind: notify.KindRebootTriggered, Title: "Modem rebooted"}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if data, err := os.ReadFile(output); err != nil || string(data) != "Modem rebooted" {
+		t.Errorf("Expected the plugin to receive the notification, got %q (%v)", data, err)
+	}
+
+	driver, err := modem.New(ModemType, modem.Options{Host: "192.168.100.1", Username: "admin", Password: "secret"}, testLogger())
+	if err != nil {
+		t.Fatalf("Expected the plugin's modem driver to be registered: %v", err)
+	}
+	if err := driver.Login(ctx); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	status, err := driver.GetStatus(ctx)
+	if err != nil || status.Model != "Test" || len(status.Downstream) != 1 || !status.Downstream[0].Locked() {
+		t.Errorf("Unexpected status %+v: %v", status, err)
+	}
+	if err := driver.Reboot(ctx); err != nil {
+		t.Errorf("Reboot failed: %v", err)
+	}
+
+	wrong, _ := modem.New(ModemType, modem.Options{Username: "admin", Password: "wrong"}, testLogger())
+	if err := wrong.Login(ctx); err == nil {
+		t.Error("Expected the plugin's error for a wrong password")
+	}
+}
+
+func TestPluginPolicy(t *testing.T) {
+	set := startTestPlugin(t, "up")
+	policy := set.Policy()
+	if policy == nil || policy.Name() != "test" {
+		t.Fatalf("Expected the policy of the test plugin, got %v", policy)
+	}
+
+	input := monitor.PolicyInput{
+		Decision:      monitor.Decision{Outcome: monitor.DecisionReboot, HealthScore: 20, Verdict: verdict.WAN},
+		Action:        verdict.ActionReboot,
+		RecentReboots: 2,
+	}
+	decision, err := policy.Decide(context.Background(), input)
+	if err != nil || decision.Action != verdict.ActionNotify || decision.Reason != "rebooted twice today" {
+		t.Errorf("Expected the policy to notify, got %+v: %v", decision, err)
+	}
+	input.RecentReboots = 0
+	if decision, err := policy.Decide(context.Background(), input); err != nil || decision.Action != "" {
+		t.Errorf("Expected the policy to keep the built-in decision, got %+v: %v", decision, err)
+	}
+}
+
+func TestPluginCheckFailure(t *testing.T) {
+	set := startTestPlugin(t, "down")
+	err := set.Checks()[0].Run(context.Background())
+	if err == nil || err.Error() != "nas unreachable" {
+		t.Errorf("Expected the plugin's failure message, got %v", err)
+	}
+}
+
+func TestPluginRestartsAfterExit(t *testing.T) {
+	set := startTestPlugin(t, "up")
+	p := set.Plugins()[0]
+
+	p.mu.Lock()
+	proc := p.process
+	p.mu.Unlock()
+	if err := proc.cmd.Process.Kill(); err != nil {
+		t.Fatalf("Failed to kill plugin: %v", err)
+	}
+	select {
+	case <-proc.exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Plugin did not exit")
+	}
+
+	if err := set.Checks()[0].Run(context.Background()); err != nil {
+		t.Errorf("Expected the plugin to restart for the check, got %v", err)
+	}
+}
+
+func TestPluginMustNotBeWritableByOthers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nexit 0\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	// WriteFile is subject to the umask
+	if err := os.Chmod(path, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Start(context.Background(), path, testLogger())
+	if err == nil || !strings.Contains(err.Error(), "writable by group or others") {
+		t.Errorf("Expected a world-writable plugin to be refused, got %v", err)
+	}
+
+	if err := os.Chmod(path, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Dir(path), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Start(context.Background(), path, testLogger()); err == nil || !strings.Contains(err.Error(), "writable") {
+		t.Errorf("Expected a plugin in a world-writable directory to be refused, got %v", err)
+	}
+}
+
+func TestLoadSkipsPluginsThatFailToStart(t *testing.T) {
+	notPlugin := filepath.Join(t.TempDir(), "not-a-plugin")
+	if err := os.WriteFile(notPlugin, []byte("#!/bin/sh\nexit 0\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	set := Load(context.Background(), []string{filepath.Join(t.TempDir(), "missing"), notPlugin}, testLogger())
+	defer set.Close()
+	if len(set.Plugins()) != 0 || len(set.Checks()) != 0 || len(set.Notifiers()) != 0 || set.Policy() != nil {
+		t.Errorf("Expected no plugins, got %v", set.Plugins())
+	}
+}
@@ -0,0 +1,232 @@
+// Package plugin runs the watchdog's plugins, programs that add
+// connectivity checks, notifiers, a modem driver or a reboot decision policy
+// without changes to the watchdog. The watchdog starts each plugin and talks
+// JSON-RPC 1.0 to it over the plugin's standard input and output, one JSON
+// object per line, so a plugin can be written in any language:
+//
+//	{"method":"Check.Run","params":[{}],"id":1}
+//	{"id":1,"result":{"success":true},"error":null}
+//
+// The watchdog first calls Plugin.Info. A plugin answers the methods of the
+// capabilities it reports: Check.Run for CapabilityCheck, Notifier.Notify
+// for CapabilityNotifier, Modem.Login, Modem.GetStatus and Modem.Reboot for
+// CapabilityModem, and Policy.Decide for CapabilityPolicy. A plugin logs
+// to its standard error, which goes to the watchdog log, and exits when its
+// standard input closes.
+//
+// Plugins, decision policies included, are trusted code: they are not
+// sandboxed and run with the watchdog's user and capabilities. Their
+// environment is reduced to the search path, home, locale and the variables
+// starting with EnvPrefix, so secrets in the watchdog's environment do not
+// reach them. The watchdog refuses a program that a user other than root or
+// its own could replace.
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+)
+
+// ProtocolVersion is the version of the protocol; the watchdog refuses
+// plugins reporting another one
+const ProtocolVersion = 1
+
+// Capabilities a plugin reports in Info
+const (
+	CapabilityCheck    = "check"
+	CapabilityNotifier = "notifier"
+	CapabilityModem    = "modem"
+	CapabilityPolicy   = "policy"
+)
+
+// Methods of the protocol
+const (
+	MethodInfo        = "Plugin.Info"
+	MethodCheck       = "Check.Run"
+	MethodNotify      = "Notifier.Notify"
+	MethodModemLogin  = "Modem.Login"
+	MethodModemStatus = "Modem.GetStatus"
+	MethodModemReboot = "Modem.Reboot"
+	MethodPolicy      = "Policy.Decide"
+)
+
+// Info describes a plugin
+type Info struct {
+	// Name names the plugin in logs and check results
+	Name            string   `json:"name"`
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities"`
+}
+
+// Has reports whether the plugin reports capability
+func (i Info) Has(capability string) bool {
+	for _, c := range i.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty is the parameter and result of methods without one
+type Empty struct{}
+
+// CheckResult is the result of Check.Run
+type CheckResult struct {
+	Success bool `json:"success"`
+	// Message says why the check failed
+	Message string `json:"message,omitempty"`
+}
+
+// ModemLogin is the parameter of Modem.Login: the modem settings of the
+// watchdog configuration
+type ModemLogin struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	NoVerify bool   `json:"no_verify"`
+}
+
+// Server is a plugin written in Go. The funcs of the capabilities the
+// plugin does not have stay nil.
+type Server struct {
+	Name string
+	// Check runs a connectivity check
+	Check func(ctx context.Context) (CheckResult, error)
+	// Notify delivers a notification
+	Notify func(ctx context.Context, notification notify.Notification) error
+	// Modem drives the modem in place of the built-in drivers
+	Modem ModemDriver
+	// Policy decides whether to reboot once the failure threshold is reached
+	Policy func(ctx context.Context, input monitor.PolicyInput) (monitor.PolicyDecision, error)
+}
+
+// ModemDriver is the modem driver of a plugin
+type ModemDriver interface {
+	Login(ctx context.Context, login ModemLogin) error
+	GetStatus(ctx context.Context) (*modem.Status, error)
+	Reboot(ctx context.Context) error
+}
+
+// Info returns the info the server answers Plugin.Info with
+func (s Server) Info() Info {
+	info := Info{Name: s.Name, ProtocolVersion: ProtocolVersion}
+	if s.Check != nil {
+		info.Capabilities = append(info.Capabilities, CapabilityCheck)
+	}
+	if s.Notify != nil {
+		info.Capabilities = append(info.Capabilities, CapabilityNotifier)
+	}
+	if s.Modem != nil {
+		info.Capabilities = append(info.Capabilities, CapabilityModem)
+	}
+	if s.Policy != nil {
+		info.Capabilities = append(info.Capabilities, CapabilityPolicy)
+	}
+	return info
+}
+
+// Serve answers the watchdog on the standard input and output until the
+// standard input closes
+func Serve(s Server) error {
+	return ServeConn(s, stdio{})
+}
+
+// ServeConn answers the watchdog on conn until it closes
+func ServeConn(s Server, conn io.ReadWriteCloser) error {
+	if s.Name == "" {
+		return errors.New("plugin name is required")
+	}
+	server := rpc.NewServer()
+	services := map[string]interface{}{"Plugin": &infoService{s.Info()}}
+	if s.Check != nil {
+		services["Check"] = &checkService{s.Check}
+	}
+	if s.Notify != nil {
+		services["Notifier"] = &notifierService{s.Notify}
+	}
+	if s.Modem != nil {
+		services["Modem"] = &modemService{s.Modem}
+	}
+	if s.Policy != nil {
+		services["Policy"] = &policyService{s.Policy}
+	}
+	for name, service := range services {
+		if err := server.RegisterName(name, service); err != nil {
+			return fmt.Errorf("failed to register %s: %w", name, err)
+		}
+	}
+	server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	return nil
+}
+
+// stdio is the standard input and output as a connection
+type stdio struct{}
+
+func (stdio) Read(p []byte) (int, error)  { return os.Stdin.Read(p) }
+func (stdio) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+func (stdio) Close() error                { return os.Stdin.Close() }
+
+type infoService struct{ info Info }
+
+func (s *infoService) Info(_ Empty, reply *Info) error {
+	*reply = s.info
+	return nil
+}
+
+type checkService struct {
+	check func(ctx context.Context) (CheckResult, error)
+}
+
+func (s *checkService) Run(_ Empty, reply *CheckResult) error {
+	result, err := s.check(context.Background())
+	*reply = result
+	return err
+}
+
+type notifierService struct {
+	notify func(ctx context.Context, notification notify.Notification) error
+}
+
+func (s *notifierService) Notify(notification notify.Notification, _ *Empty) error {
+	return s.notify(context.Background(), notification)
+}
+
+type modemService struct{ driver ModemDriver }
+
+func (s *modemService) Login(login ModemLogin, _ *Empty) error {
+	return s.driver.Login(context.Background(), login)
+}
+
+func (s *modemService) GetStatus(_ Empty, reply *modem.Status) error {
+	status, err := s.driver.GetStatus(context.Background())
+	if err != nil {
+		return err
+	}
+	*reply = *status
+	return nil
+}
+
+func (s *modemService) Reboot(_ Empty, _ *Empty) error {
+	return s.driver.Reboot(context.Background())
+}
+
+type policyService struct {
+	decide func(ctx context.Context, input monitor.PolicyInput) (monitor.PolicyDecision, error)
+}
+
+func (s *policyService) Decide(input monitor.PolicyInput, reply *monitor.PolicyDecision) error {
+	decision, err := s.decide(context.Background(), input)
+	*reply = decision
+	return err
+}
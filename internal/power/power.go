@@ -0,0 +1,102 @@
+// Package power tells whether the watchdog should run in low-power mode,
+// for deployments on battery or solar power such as a remote cabin with an
+// LTE modem. The mode is switched on and off by configuration, or follows
+// the power source a file reports: a sysfs power supply attribute, or the
+// status of a UPS written to a file.
+package power
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Low-power modes
+const (
+	// ModeOff never runs in low-power mode
+	ModeOff = "off"
+	// ModeOn always runs in low-power mode
+	ModeOn = "on"
+	// ModeAuto runs in low-power mode while the power source file reports
+	// battery power
+	ModeAuto = "auto"
+)
+
+// DefaultCheckInterval is the check interval in low-power mode
+const DefaultCheckInterval = 5 * time.Minute
+
+// Power sources
+const (
+	SourceMains   = "mains"
+	SourceBattery = "battery"
+)
+
+// ParseMode validates a low-power mode; empty is ModeOff
+func ParseMode(mode string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", ModeOff:
+		return ModeOff, nil
+	case ModeOn:
+		return ModeOn, nil
+	case ModeAuto:
+		return ModeAuto, nil
+	}
+	return "", fmt.Errorf("unknown low-power mode %q (supported: off, on, auto)", mode)
+}
+
+// batteryWords and mainsWords are the first words of power source file
+// contents that mean battery or mains power: sysfs online and status
+// attributes, NUT ups.status flags and plain words
+var (
+	batteryWords = map[string]bool{"0": true, "battery": true, "discharging": true, "ob": true}
+	mainsWords   = map[string]bool{"1": true, "mains": true, "ac": true, "online": true, "charging": true, "full": true, "not": true, "ol": true}
+)
+
+// ParseSource returns SourceBattery or SourceMains for the contents of a
+// power source file, such as "0" or "1" from
+// /sys/class/power_supply/AC/online, "Discharging" from
+// /sys/class/power_supply/BAT0/status, or "OB LB" from a UPS's ups.status
+func ParseSource(content string) (string, error) {
+	fields := strings.Fields(strings.ToLower(content))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty power source")
+	}
+	switch {
+	case batteryWords[fields[0]]:
+		return SourceBattery, nil
+	case mainsWords[fields[0]]:
+		return SourceMains, nil
+	}
+	return "", fmt.Errorf("unknown power source %q", strings.TrimSpace(content))
+}
+
+// ReadSource reads the power source from the file at path
+func ReadSource(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read power source: %w", err)
+	}
+	source, err := ParseSource(string(content))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return source, nil
+}
+
+// LowPower reports whether mode runs in low-power mode with the power
+// source file at path. In ModeAuto a file that cannot be read returns the
+// error, and the caller keeps its current mode.
+func LowPower(mode, path string) (bool, error) {
+	switch mode {
+	case ModeOn:
+		return true, nil
+	case ModeAuto:
+		source, err := ReadSource(path)
+		if err != nil {
+			return false, err
+		}
+		return source == SourceBattery, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,64 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"0\n", SourceBattery},
+		{"1\n", SourceMains},
+		{"Discharging\n", SourceBattery},
+		{"Charging\n", SourceMains},
+		{"Not charging\n", SourceMains},
+		{"Full\n", SourceMains},
+		{"OB LB\n", SourceBattery},
+		{"OL CHRG\n", SourceMains},
+		{"battery", SourceBattery},
+	}
+	for _, tt := range tests {
+		if got, err := ParseSource(tt.content); err != nil || got != tt.want {
+			t.Errorf("ParseSource(%q) = %q (%v), want %q", tt.content, got, err, tt.want)
+		}
+	}
+	for _, invalid := range []string{"", "  \n", "Unknown"} {
+		if _, err := ParseSource(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestLowPower(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "online")
+
+	if low, _ := LowPower(ModeOn, path); !low {
+		t.Error("Expected ModeOn to run in low-power mode")
+	}
+	if low, _ := LowPower(ModeOff, path); low {
+		t.Error("Expected ModeOff not to run in low-power mode")
+	}
+	if _, err := LowPower(ModeAuto, path); err == nil {
+		t.Error("Expected an error for a missing power source file")
+	}
+
+	os.WriteFile(path, []byte("0\n"), 0644)
+	if low, err := LowPower(ModeAuto, path); err != nil || !low {
+		t.Errorf("Expected battery power to run in low-power mode, got %v (%v)", low, err)
+	}
+	os.WriteFile(path, []byte("1\n"), 0644)
+	if low, err := LowPower(ModeAuto, path); err != nil || low {
+		t.Errorf("Expected mains power not to run in low-power mode, got %v (%v)", low, err)
+	}
+
+	if mode, err := ParseMode(""); err != nil || mode != ModeOff {
+		t.Errorf("Expected an empty mode to be off, got %q (%v)", mode, err)
+	}
+	if _, err := ParseMode("eco"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
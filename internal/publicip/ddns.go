@@ -0,0 +1,234 @@
+package publicip
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DDNS providers
+const (
+	ProviderCloudflare = "cloudflare"
+	ProviderDuckDNS    = "duckdns"
+	ProviderScript     = "script"
+)
+
+// Providers lists every DDNS provider
+var Providers = []string{ProviderCloudflare, ProviderDuckDNS, ProviderScript}
+
+// DDNSConfig selects and configures a DDNS provider
+type DDNSConfig struct {
+	Provider string
+	// Domain is the Cloudflare record name or the DuckDNS subdomain
+	Domain string
+	// Token is the Cloudflare API token or the DuckDNS token
+	Token string
+	// ZoneID is the Cloudflare zone of the record
+	ZoneID string
+	// Script is run with the old and new address for ProviderScript
+	Script string
+}
+
+// NewUpdater creates the updater of the configured provider
+func NewUpdater(cfg DDNSConfig, client *http.Client) (Updater, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	switch cfg.Provider {
+	case ProviderCloudflare:
+		if cfg.Domain == "" || cfg.Token == "" || cfg.ZoneID == "" {
+			return nil, fmt.Errorf("cloudflare needs a domain, token and zone ID")
+		}
+		return &CloudflareUpdater{baseURL: cloudflareAPI, client: client, zoneID: cfg.ZoneID, record: cfg.Domain, token: cfg.Token}, nil
+	case ProviderDuckDNS:
+		if cfg.Domain == "" || cfg.Token == "" {
+			return nil, fmt.Errorf("duckdns needs a domain and token")
+		}
+		return &DuckDNSUpdater{baseURL: duckDNSAPI, client: client, domain: strings.TrimSuffix(cfg.Domain, ".duckdns.org"), token: cfg.Token}, nil
+	case ProviderScript:
+		if cfg.Script == "" {
+			return nil, fmt.Errorf("the script provider needs a script")
+		}
+		return &ScriptUpdater{path: cfg.Script, timeout: time.Minute}, nil
+	default:
+		return nil, fmt.Errorf("unknown DDNS provider %q (expected %s)", cfg.Provider, strings.Join(Providers, ", "))
+	}
+}
+
+const (
+	cloudflareAPI = "https://api.cloudflare.com/client/v4"
+	duckDNSAPI    = "https://www.duckdns.org"
+)
+
+// CloudflareUpdater points an A or AAAA record at the new address
+type CloudflareUpdater struct {
+	baseURL string
+	client  *http.Client
+	zoneID  string
+	record  string
+	token   string
+}
+
+// Name identifies the updater in logs
+func (u *CloudflareUpdater) Name() string {
+	return ProviderCloudflare
+}
+
+// cloudflareResponse is the envelope of every Cloudflare API response
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+// Update looks up the record of the new address family and replaces its content
+func (u *CloudflareUpdater) Update(ctx context.Context, change Change) error {
+	recordType := "A"
+	if ip := net.ParseIP(change.New); ip != nil && ip.To4() == nil {
+		recordType = "AAAA"
+	}
+
+	query := url.Values{"type": {recordType}, "name": {u.record}}
+	var records []struct {
+		ID string `json:"id"`
+	}
+	path := fmt.Sprintf("/zones/%s/dns_records?%s", url.PathEscape(u.zoneID), query.Encode())
+	if err := u.call(ctx, http.MethodGet, path, nil, &records); err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("cloudflare has no %s record named %s", recordType, u.record)
+	}
+
+	body := map[string]string{"content": change.New}
+	path = fmt.Sprintf("/zones/%s/dns_records/%s", url.PathEscape(u.zoneID), url.PathEscape(records[0].ID))
+	return u.call(ctx, http.MethodPatch, path, body, nil)
+}
+
+// call sends an API request and decodes the result into result
+func (u *CloudflareUpdater) call(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create cloudflare request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+u.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach cloudflare: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var envelope cloudflareResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&envelope); err != nil {
+		return fmt.Errorf("invalid cloudflare response (%s): %w", resp.Status, err)
+	}
+	if !envelope.Success {
+		message := resp.Status
+		if len(envelope.Errors) > 0 {
+			message = envelope.Errors[0].Message
+		}
+		return fmt.Errorf("cloudflare request failed: %s", message)
+	}
+	if result != nil {
+		if err := json.Unmarshal(envelope.Result, result); err != nil {
+			return fmt.Errorf("invalid cloudflare result: %w", err)
+		}
+	}
+	return nil
+}
+
+// DuckDNSUpdater updates a DuckDNS subdomain
+type DuckDNSUpdater struct {
+	baseURL string
+	client  *http.Client
+	domain  string
+	token   string
+}
+
+// Name identifies the updater in logs
+func (u *DuckDNSUpdater) Name() string {
+	return ProviderDuckDNS
+}
+
+// Update sets the subdomain to the new address; DuckDNS answers OK or KO
+func (u *DuckDNSUpdater) Update(ctx context.Context, change Change) error {
+	query := url.Values{"domains": {u.domain}, "token": {u.token}}
+	if ip := net.ParseIP(change.New); ip != nil && ip.To4() == nil {
+		query.Set("ipv6", change.New)
+	} else {
+		query.Set("ip", change.New)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.baseURL+"/update?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create duckdns request: %w", err)
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		// The request URL holds the token, so do not include it in the error
+		return fmt.Errorf("failed to reach duckdns: %w", stripURL(err))
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != "OK" {
+		return fmt.Errorf("duckdns rejected the update for %s", u.domain)
+	}
+	return nil
+}
+
+// stripURL strips the request URL from HTTP client errors
+func stripURL(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
+
+// ScriptUpdater runs a user script with the old and new address as
+// arguments and as WATCHDOG_OLD_IP and WATCHDOG_NEW_IP in its environment
+type ScriptUpdater struct {
+	path    string
+	timeout time.Duration
+}
+
+// Name identifies the updater in logs
+func (u *ScriptUpdater) Name() string {
+	return ProviderScript
+}
+
+// Update runs the script and fails when it exits with an error
+func (u *ScriptUpdater) Update(ctx context.Context, change Change) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, u.path, change.Old, change.New)
+	cmd.Env = append(os.Environ(), "WATCHDOG_OLD_IP="+change.Old, "WATCHDOG_NEW_IP="+change.New)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("script %s failed: %w: %s", u.path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package publicip
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewUpdater(t *testing.T) {
+	tests := []struct {
+		cfg     DDNSConfig
+		want    string
+		wantErr bool
+	}{
+		{DDNSConfig{Provider: ProviderCloudflare, Domain: "home.example.com", Token: "t", ZoneID: "z"}, ProviderCloudflare, false},
+		{DDNSConfig{Provider: ProviderCloudflare, Domain: "home.example.com", Token: "t"}, "", true},
+		{DDNSConfig{Provider: ProviderDuckDNS, Domain: "myhome.duckdns.org", Token: "t"}, ProviderDuckDNS, false},
+		{DDNSConfig{Provider: ProviderDuckDNS, Domain: "myhome"}, "", true},
+		{DDNSConfig{Provider: ProviderScript, Script: "/usr/local/bin/ddns"}, ProviderScript, false},
+		{DDNSConfig{Provider: ProviderScript}, "", true},
+		{DDNSConfig{Provider: "noip"}, "", true},
+	}
+	for _, tt := range tests {
+		updater, err := NewUpdater(tt.cfg, nil)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewUpdater(%+v) error = %v, wantErr %v", tt.cfg, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && updater.Name() != tt.want {
+			t.Errorf("NewUpdater(%+v) = %s, want %s", tt.cfg, updater.Name(), tt.want)
+		}
+	}
+
+	updater, _ := NewUpdater(DDNSConfig{Provider: ProviderDuckDNS, Domain: "myhome.duckdns.org", Token: "t"}, nil)
+	if domain := updater.(*DuckDNSUpdater).domain; domain != "myhome" {
+		t.Errorf("Expected the duckdns.org suffix to be dropped, got %q", domain)
+	}
+}
+
+func TestCloudflareUpdater(t *testing.T) {
+	var patched map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"success": false, "errors": [{"message": "Invalid API token"}]}`))
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/zone1/dns_records":
+			if r.URL.Query().Get("name") != "home.example.com" || r.URL.Query().Get("type") != "A" {
+				w.Write([]byte(`{"success": true, "result": []}`))
+				return
+			}
+			w.Write([]byte(`{"success": true, "result": [{"id": "rec1"}]}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/zones/zone1/dns_records/rec1":
+			json.NewDecoder(r.Body).Decode(&patched)
+			w.Write([]byte(`{"success": true, "result": {"id": "rec1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success": false, "errors": [{"message": "not found"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	updater := &CloudflareUpdater{baseURL: server.URL, client: server.Client(), zoneID: "zone1", record: "home.example.com", token: "secret-token"}
+	if err := updater.Update(context.Background(), Change{Old: "203.0.113.1", New: "203.0.113.2"}); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if patched["content"] != "203.0.113.2" {
+		t.Errorf("Expected the record to point at the new address, got %v", patched)
+	}
+
+	// IPv6 addresses update the AAAA record, which does not exist here
+	if err := updater.Update(context.Background(), Change{New: "2001:db8::1"}); err == nil || !strings.Contains(err.Error(), "AAAA") {
+		t.Errorf("Expected a missing AAAA record error, got %v", err)
+	}
+
+	updater.token = "wrong"
+	if err := updater.Update(context.Background(), Change{New: "203.0.113.2"}); err == nil || !strings.Contains(err.Error(), "Invalid API token") {
+		t.Errorf("Expected the API error message, got %v", err)
+	}
+}
+
+func TestDuckDNSUpdater(t *testing.T) {
+	var query map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		if r.URL.Query().Get("token") != "secret-token" {
+			w.Write([]byte("KO"))
+			return
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	updater := &DuckDNSUpdater{baseURL: server.URL, client: server.Client(), domain: "myhome", token: "secret-token"}
+	if err := updater.Update(context.Background(), Change{New: "203.0.113.2"}); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if query["domains"][0] != "myhome" || query["ip"][0] != "203.0.113.2" {
+		t.Errorf("Unexpected query %v", query)
+	}
+
+	updater.token = "wrong"
+	err := updater.Update(context.Background(), Change{New: "203.0.113.2"})
+	if err == nil {
+		t.Fatal("Expected KO to fail the update")
+	}
+	if strings.Contains(err.Error(), "wrong") {
+		t.Errorf("The error must not contain the token: %v", err)
+	}
+}
+
+func TestScriptUpdater(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output")
+	script := filepath.Join(dir, "ddns.sh")
+	content := "#!/bin/sh\necho \"$1 $2 $WATCHDOG_OLD_IP $WATCHDOG_NEW_IP\" > " + output + "\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	updater, _ := NewUpdater(DDNSConfig{Provider: ProviderScript, Script: script}, nil)
+	if err := updater.Update(context.Background(), Change{Old: "203.0.113.1", New: "203.0.113.2"}); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	got, _ := os.ReadFile(output)
+	if strings.TrimSpace(string(got)) != "203.0.113.1 203.0.113.2 203.0.113.1 203.0.113.2" {
+		t.Errorf("Unexpected script input %q", got)
+	}
+
+	failing := filepath.Join(dir, "fail.sh")
+	os.WriteFile(failing, []byte("#!/bin/sh\necho boom\nexit 3\n"), 0755)
+	updater, _ = NewUpdater(DDNSConfig{Provider: ProviderScript, Script: failing}, nil)
+	if err := updater.Update(context.Background(), Change{New: "203.0.113.2"}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected the script failure with its output, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package publicip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Location is the approximate location and network of a public IP address
+type Location struct {
+	Country string `json:"country,omitempty"`
+	Region  string `json:"region,omitempty"`
+	City    string `json:"city,omitempty"`
+	// Org is the ISP or autonomous system announcing the address
+	Org string `json:"org,omitempty"`
+}
+
+// Locator looks up the location of an IP address through a geolocation
+// service answering in JSON, such as ipinfo.io or ip-api.com
+type Locator struct {
+	service string
+	client  *http.Client
+}
+
+// NewLocator creates a locator for service, a URL in which {ip} is replaced
+// by the address, e.g. "https://ipinfo.io/{ip}/json"; client defaults to one
+// with a 10 second timeout
+func NewLocator(service string, client *http.Client) *Locator {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Locator{service: service, client: client}
+}
+
+// geoResponse covers the field names of the common geolocation services
+type geoResponse struct {
+	Country     string `json:"country"`
+	CountryCode string `json:"countryCode"`
+	Region      string `json:"region"`
+	RegionName  string `json:"regionName"`
+	City        string `json:"city"`
+	Org         string `json:"org"`
+	ISP         string `json:"isp"`
+	AS          string `json:"as"`
+}
+
+// Locate returns the location of ip
+func (l *Locator) Locate(ctx context.Context, ip net.IP) (Location, error) {
+	url := strings.ReplaceAll(l.service, "{ip}", ip.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid geolocation service %s: %w", l.service, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return Location{}, fmt.Errorf("failed to locate %s: %w", ip, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Location{}, fmt.Errorf("geolocation service returned %s", resp.Status)
+	}
+	var geo geoResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&geo); err != nil {
+		return Location{}, fmt.Errorf("invalid geolocation response: %w", err)
+	}
+
+	location := Location{
+		Country: firstNonEmpty(geo.Country, geo.CountryCode),
+		Region:  firstNonEmpty(geo.RegionName, geo.Region),
+		City:    geo.City,
+		Org:     firstNonEmpty(geo.Org, geo.AS, geo.ISP),
+	}
+	if location == (Location{}) {
+		return Location{}, fmt.Errorf("geolocation service returned no location for %s", ip)
+	}
+	return location, nil
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,333 @@
+// Package publicip looks up the public IP address through IP echo services
+// and reports when it changes, e.g. after an outage or a modem reboot, so
+// dynamic DNS records can follow.
+package publicip
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultServices answer with the caller's IP address as plain text
+var DefaultServices = []string{
+	"https://api.ipify.org",
+	"https://icanhazip.com",
+	"https://ifconfig.me/ip",
+}
+
+// Resolver looks up the public IP address
+type Resolver struct {
+	services []string
+	client   *http.Client
+}
+
+// NewResolver creates a resolver asking services in order; client defaults
+// to one with a 10 second timeout
+func NewResolver(services []string, client *http.Client) *Resolver {
+	if len(services) == 0 {
+		services = DefaultServices
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Resolver{services: services, client: client}
+}
+
+// Lookup returns the public IP address reported by the first service that
+// answers with a valid address
+func (r *Resolver) Lookup(ctx context.Context) (net.IP, error) {
+	var lastErr error
+	for _, service := range r.services {
+		ip, err := r.ask(ctx, service)
+		if err == nil {
+			return ip, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("failed to look up public IP: %w", lastErr)
+}
+
+// ask queries one IP echo service
+func (r *Resolver) ask(ctx context.Context, service string) (net.IP, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, service, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP service %s: %w", service, err)
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", service, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", service, err)
+	}
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil {
+		return nil, fmt.Errorf("%s returned no IP address", service)
+	}
+	return ip, nil
+}
+
+// Change is a change of the public IP address
+type Change struct {
+	Old  string    `json:"old_ip"`
+	New  string    `json:"new_ip"`
+	Time time.Time `json:"time"`
+}
+
+// Updater publishes a new public IP address, e.g. to a dynamic DNS provider
+type Updater interface {
+	Name() string
+	Update(ctx context.Context, change Change) error
+}
+
+// state is the last observed address, persisted across restarts
+type state struct {
+	IP         string    `json:"ip"`
+	ObservedAt time.Time `json:"observed_at"`
+}
+
+// Watcher compares the public IP address with the last one observed and,
+// when it changed, records the change in the history and runs the updaters
+type Watcher struct {
+	resolver    *Resolver
+	statePath   string
+	historyPath string
+	clock       clock.Clock
+	logger      *logrus.Logger
+
+	mu       sync.Mutex
+	updaters []Updater
+	locator  *Locator
+	// located caches the location of the last located address
+	locatedIP string
+	located   Location
+	// last is the last observed address when there is no statePath
+	last state
+}
+
+// NewWatcher creates a watcher keeping the last observed address in
+// statePath, or in memory when statePath is empty
+func NewWatcher(resolver *Resolver, statePath string, c clock.Clock, logger *logrus.Logger) *Watcher {
+	if c == nil {
+		c = clock.New()
+	}
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.InfoLevel)
+	}
+	return &Watcher{resolver: resolver, statePath: statePath, clock: c, logger: logger}
+}
+
+// SetHistory records changes in the history at path
+func (w *Watcher) SetHistory(path string) {
+	w.historyPath = path
+}
+
+// SetLocator adds the location of the address to recorded observations
+func (w *Watcher) SetLocator(l *Locator) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.locator = l
+}
+
+// AddUpdater runs u on every change
+func (w *Watcher) AddUpdater(u Updater) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.updaters = append(w.updaters, u)
+}
+
+// Name identifies the watcher as a recovery action
+func (w *Watcher) Name() string {
+	return "public-ip"
+}
+
+// Recover checks the address once connectivity returns from an outage
+func (w *Watcher) Recover(ctx context.Context, downtime time.Duration) error {
+	_, _, err := w.Check(ctx)
+	return err
+}
+
+// Check looks up the public IP address and reports whether it changed. The
+// first address observed is only remembered. Updater failures are logged and
+// returned, but the new address is remembered anyway, so a failed update is
+// not retried until the address changes again.
+func (w *Watcher) Check(ctx context.Context) (Change, bool, error) {
+	_, change, changed, err := w.check(ctx)
+	return change, changed, err
+}
+
+// Record checks the address like Check and records the observation in the
+// history, so IP churn can be correlated with outages. The location is only
+// looked up when the address differs from the last one located.
+func (w *Watcher) Record(ctx context.Context) error {
+	ip, change, changed, err := w.check(ctx)
+	if ip == nil || w.historyPath == "" {
+		return err
+	}
+
+	details := map[string]interface{}{"ip": ip.String()}
+	if changed {
+		details["changed"] = true
+		details["old_ip"] = change.Old
+	}
+	if location, ok := w.locate(ctx, ip); ok {
+		details["location"] = location
+	}
+
+	event := history.Event{Time: w.clock.Now(), Kind: history.KindPublicIP, Details: details}
+	if appendErr := history.Append(w.historyPath, event); appendErr != nil {
+		w.logger.WithError(appendErr).Warn("Failed to record public IP")
+	}
+	return err
+}
+
+// locate returns the location of ip, reusing the last lookup for the same
+// address; failures are logged and leave the location out
+func (w *Watcher) locate(ctx context.Context, ip net.IP) (Location, bool) {
+	w.mu.Lock()
+	locator := w.locator
+	if w.locatedIP == ip.String() {
+		location := w.located
+		w.mu.Unlock()
+		return location, true
+	}
+	w.mu.Unlock()
+
+	if locator == nil {
+		return Location{}, false
+	}
+	location, err := locator.Locate(ctx, ip)
+	if err != nil {
+		w.logger.WithError(err).Debug("Failed to locate public IP")
+		return Location{}, false
+	}
+
+	w.mu.Lock()
+	w.locatedIP, w.located = ip.String(), location
+	w.mu.Unlock()
+	return location, true
+}
+
+// check looks up the address and handles a change; the address is nil when
+// the lookup failed
+func (w *Watcher) check(ctx context.Context) (net.IP, Change, bool, error) {
+	ip, err := w.resolver.Lookup(ctx)
+	if err != nil {
+		return nil, Change{}, false, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.clock.Now()
+	previous, err := w.load()
+	if err != nil {
+		w.logger.WithError(err).Warn("Failed to read last public IP, treating it as unknown")
+	}
+	if err := w.save(state{IP: ip.String(), ObservedAt: now}); err != nil {
+		w.logger.WithError(err).Warn("Failed to remember public IP")
+	}
+
+	if previous.IP == "" {
+		w.logger.WithField("ip", ip.String()).Info("Public IP address observed")
+		return ip, Change{}, false, nil
+	}
+	if previous.IP == ip.String() {
+		return ip, Change{}, false, nil
+	}
+
+	change := Change{Old: previous.IP, New: ip.String(), Time: now}
+	w.logger.WithFields(logrus.Fields{
+		"old_ip": change.Old,
+		"new_ip": change.New,
+	}).Warn("Public IP address changed")
+
+	if w.historyPath != "" {
+		event := history.Event{
+			Time:    now,
+			Kind:    history.KindIPChange,
+			Details: map[string]interface{}{"old_ip": change.Old, "new_ip": change.New},
+		}
+		if err := history.Append(w.historyPath, event); err != nil {
+			w.logger.WithError(err).Warn("Failed to record public IP change")
+		}
+	}
+
+	var failed []string
+	for _, updater := range w.updaters {
+		if err := updater.Update(ctx, change); err != nil {
+			w.logger.WithError(err).WithField("updater", updater.Name()).Error("Failed to publish new public IP")
+			failed = append(failed, updater.Name())
+			continue
+		}
+		w.logger.WithFields(logrus.Fields{
+			"updater": updater.Name(),
+			"new_ip":  change.New,
+		}).Info("Published new public IP")
+	}
+	if len(failed) > 0 {
+		return ip, change, true, fmt.Errorf("failed to publish new public IP with %s", strings.Join(failed, ", "))
+	}
+	return ip, change, true, nil
+}
+
+// load reads the last observed address; a missing file means none
+func (w *Watcher) load() (state, error) {
+	var s state
+	if w.statePath == "" {
+		return w.last, nil
+	}
+	content, err := os.ReadFile(w.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(content, &s); err != nil {
+		return state{}, fmt.Errorf("invalid public IP state %s: %w", w.statePath, err)
+	}
+	return s, nil
+}
+
+// save writes the last observed address
+func (w *Watcher) save(s state) error {
+	if w.statePath == "" {
+		w.last = s
+		return nil
+	}
+	content, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.statePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(w.statePath, content, 0644)
+}
@@ -0,0 +1,219 @@
+package publicip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/sirupsen/logrus"
+)
+
+// echoServer answers with *ip, so tests can change the address
+func echoServer(t *testing.T, ip *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, *ip)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResolverFallsBack(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+	garbage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html>rate limited</html>")
+	}))
+	defer garbage.Close()
+	ip := "203.0.113.7"
+	working := echoServer(t, &ip)
+
+	resolver := NewResolver([]string{broken.URL, garbage.URL, working.URL}, nil)
+	got, err := resolver.Lookup(context.Background())
+	if err != nil {
+		t.Fatalf("Lookup() failed: %v", err)
+	}
+	if got.String() != ip {
+		t.Errorf("Expected %s, got %s", ip, got)
+	}
+
+	if _, err := NewResolver([]string{broken.URL, garbage.URL}, nil).Lookup(context.Background()); err == nil {
+		t.Error("Expected an error when no service answers")
+	}
+}
+
+type recordingUpdater struct {
+	changes []Change
+	err     error
+}
+
+func (u *recordingUpdater) Name() string { return "recording" }
+
+func (u *recordingUpdater) Update(ctx context.Context, change Change) error {
+	u.changes = append(u.changes, change)
+	return u.err
+}
+
+func TestWatcherDetectsChanges(t *testing.T) {
+	dir := t.TempDir()
+	ip := "203.0.113.1"
+	server := echoServer(t, &ip)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	newWatcher := func() (*Watcher, *recordingUpdater) {
+		watcher := NewWatcher(NewResolver([]string{server.URL}, nil), filepath.Join(dir, "public-ip.json"), fake, logger)
+		watcher.SetHistory(filepath.Join(dir, "history.jsonl"))
+		updater := &recordingUpdater{}
+		watcher.AddUpdater(updater)
+		return watcher, updater
+	}
+	watcher, updater := newWatcher()
+
+	if _, changed, err := watcher.Check(context.Background()); err != nil || changed {
+		t.Fatalf("The first address should only be remembered, got %v, %v", changed, err)
+	}
+	if _, changed, _ := watcher.Check(context.Background()); changed {
+		t.Error("An unchanged address should not be reported")
+	}
+
+	ip = "203.0.113.2"
+	fake.Advance(time.Hour)
+	change, changed, err := watcher.Check(context.Background())
+	if err != nil || !changed {
+		t.Fatalf("Expected a change, got %v, %v", changed, err)
+	}
+	if change.Old != "203.0.113.1" || change.New != "203.0.113.2" || !change.Time.Equal(fake.Now()) {
+		t.Errorf("Unexpected change %+v", change)
+	}
+	if len(updater.changes) != 1 {
+		t.Errorf("Expected the updater to run once, got %d", len(updater.changes))
+	}
+
+	events, err := history.Read(filepath.Join(dir, "history.jsonl"), time.Time{}, history.KindIPChange)
+	if err != nil || len(events) != 1 || events[0].Details["new_ip"] != "203.0.113.2" {
+		t.Errorf("Expected the change in the history, got %+v, %v", events, err)
+	}
+
+	// The last address survives a restart
+	ip = "203.0.113.3"
+	restarted, _ := newWatcher()
+	if change, changed, _ := restarted.Check(context.Background()); !changed || change.Old != "203.0.113.2" {
+		t.Errorf("Expected a change from the persisted address, got %+v", change)
+	}
+}
+
+func TestWatcherReportsUpdaterFailures(t *testing.T) {
+	dir := t.TempDir()
+	ip := "203.0.113.1"
+	server := echoServer(t, &ip)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	watcher := NewWatcher(NewResolver([]string{server.URL}, nil), filepath.Join(dir, "public-ip.json"), nil, logger)
+	failing := &recordingUpdater{err: fmt.Errorf("provider down")}
+	working := &recordingUpdater{}
+	watcher.AddUpdater(failing)
+	watcher.AddUpdater(working)
+
+	watcher.Check(context.Background())
+	ip = "203.0.113.2"
+	if err := watcher.Recover(context.Background(), time.Minute); err == nil {
+		t.Error("Expected the updater failure to be reported")
+	}
+	if len(working.changes) != 1 {
+		t.Error("A failing updater should not stop the others")
+	}
+
+	// The address is remembered even though an update failed
+	if _, changed, _ := watcher.Check(context.Background()); changed {
+		t.Error("Expected no change after the failed update")
+	}
+}
+
+func TestWatcherRecordsObservations(t *testing.T) {
+	dir := t.TempDir()
+	ip := "100.64.0.10"
+	server := echoServer(t, &ip)
+	lookups := 0
+	geo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		if r.URL.Path != "/"+ip+"/json" {
+			t.Errorf("Unexpected geolocation request %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"ip": "`+ip+`", "city": "San Juan", "region": "San Juan", "country": "PR", "org": "AS64500 Example Cable"}`)
+	}))
+	defer geo.Close()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	historyPath := filepath.Join(dir, "history.jsonl")
+
+	watcher := NewWatcher(NewResolver([]string{server.URL}, nil), filepath.Join(dir, "public-ip.json"), fake, logger)
+	watcher.SetHistory(historyPath)
+	watcher.SetLocator(NewLocator(geo.URL+"/{ip}/json", nil))
+
+	for i := 0; i < 2; i++ {
+		if err := watcher.Record(context.Background()); err != nil {
+			t.Fatalf("Record() failed: %v", err)
+		}
+		fake.Advance(10 * time.Minute)
+	}
+	ip = "100.64.0.11"
+	if err := watcher.Record(context.Background()); err != nil {
+		t.Fatalf("Record() failed: %v", err)
+	}
+
+	if lookups != 2 {
+		t.Errorf("Expected one geolocation lookup per address, got %d", lookups)
+	}
+	events, err := history.Read(historyPath, time.Time{}, history.KindPublicIP)
+	if err != nil || len(events) != 3 {
+		t.Fatalf("Expected three observations, got %+v, %v", events, err)
+	}
+	if events[0].Details["ip"] != "100.64.0.10" || events[0].Details["changed"] != nil {
+		t.Errorf("Unexpected first observation %+v", events[0])
+	}
+	location, _ := events[0].Details["location"].(map[string]interface{})
+	if location["org"] != "AS64500 Example Cable" || location["country"] != "PR" {
+		t.Errorf("Expected the location in the observation, got %+v", events[0].Details)
+	}
+	if events[2].Details["changed"] != true || events[2].Details["old_ip"] != "100.64.0.10" {
+		t.Errorf("Expected the last observation to be marked as a change, got %+v", events[2].Details)
+	}
+	if changes, _ := history.Read(historyPath, time.Time{}, history.KindIPChange); len(changes) != 1 {
+		t.Errorf("Expected the change to be recorded once, got %d", len(changes))
+	}
+}
+
+func TestLocatorFieldVariants(t *testing.T) {
+	geo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("empty") != "" {
+			fmt.Fprint(w, `{"status": "fail"}`)
+			return
+		}
+		fmt.Fprint(w, `{"countryCode": "US", "regionName": "Texas", "city": "Austin", "isp": "Example ISP"}`)
+	}))
+	defer geo.Close()
+
+	location, err := NewLocator(geo.URL+"/json/{ip}", nil).Locate(context.Background(), net.ParseIP("203.0.113.9"))
+	if err != nil {
+		t.Fatalf("Locate() failed: %v", err)
+	}
+	if location != (Location{Country: "US", Region: "Texas", City: "Austin", Org: "Example ISP"}) {
+		t.Errorf("Unexpected location %+v", location)
+	}
+	if _, err := NewLocator(geo.URL+"/json/{ip}?empty=1", nil).Locate(context.Background(), net.ParseIP("203.0.113.9")); err == nil {
+		t.Error("Expected an error for a response without a location")
+	}
+}
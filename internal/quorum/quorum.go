@@ -0,0 +1,226 @@
+// Package quorum asks the other watchdogs on the network whether they see
+// the internet down too before the modem is rebooted, so that a problem of
+// one host, such as a loose cable or a broken Wi-Fi link, does not reboot a
+// modem that works for everyone else. The agents answer with their last
+// check, read from their status API.
+package quorum
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxAge is how old an agent's last check may be to count unless
+// configured
+const DefaultMaxAge = 5 * time.Minute
+
+// requestTimeout bounds asking one agent; the reboot waits for the answers
+const requestTimeout = 5 * time.Second
+
+// statusPath is the status endpoint of an agent's API
+const statusPath = "/api/v1/status"
+
+// Votes
+const (
+	// VoteDown is an agent whose last check failed
+	VoteDown = "down"
+	// VoteUp is an agent whose last check succeeded
+	VoteUp = "up"
+	// VoteAbstain is an agent that did not answer, or has no recent check
+	VoteAbstain = "abstain"
+)
+
+// Config configures the quorum
+type Config struct {
+	// Peers are the base URLs of the other agents' APIs, such as
+	// https://192.168.1.11:8600; none disables the quorum
+	Peers []string
+	// Key is an API key with the read scope, sent to every agent as a
+	// bearer token
+	Key string
+	// Required is the number of agents, this one included, that must see
+	// the internet down; 0 is a majority of all agents
+	Required int
+	// MaxAge is how old an agent's last check may be to count
+	MaxAge time.Duration
+	// NoVerify skips the verification of the agents' TLS certificates, for
+	// self-signed ones
+	NoVerify bool
+}
+
+// Enabled reports whether cfg asks other agents before a reboot
+func (cfg Config) Enabled() bool {
+	return len(cfg.Peers) > 0
+}
+
+// Validate rejects invalid agent URLs and a quorum that cannot be reached
+func (cfg Config) Validate() error {
+	for _, peer := range cfg.Peers {
+		u, err := url.Parse(peer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("agent must be the http or https URL of its API, got %q", peer)
+		}
+	}
+	if cfg.Required < 0 || cfg.Required > len(cfg.Peers)+1 {
+		return fmt.Errorf("required agents must be between 0 (a majority) and %d, got %d", len(cfg.Peers)+1, cfg.Required)
+	}
+	if cfg.Enabled() && cfg.MaxAge <= 0 {
+		return fmt.Errorf("maximum check age must be positive, got %v", cfg.MaxAge)
+	}
+	return nil
+}
+
+// required returns the number of agents that must see the internet down
+func (cfg Config) required() int {
+	if cfg.Required > 0 {
+		return cfg.Required
+	}
+	return (len(cfg.Peers)+1)/2 + 1
+}
+
+// Vote is the answer of one agent
+type Vote struct {
+	Peer string `json:"peer"`
+	Vote string `json:"vote"`
+	// Detail says why an agent abstained
+	Detail string `json:"detail,omitempty"`
+}
+
+// Result is the outcome of asking the agents
+type Result struct {
+	// Down is the number of agents seeing the internet down, this one
+	// included
+	Down     int    `json:"down"`
+	Required int    `json:"required"`
+	Votes    []Vote `json:"votes"`
+}
+
+// Reached reports whether enough agents see the internet down
+func (r Result) Reached() bool {
+	return r.Down >= r.Required
+}
+
+// String summarizes the result for logs and decisions
+func (r Result) String() string {
+	votes := make([]string, len(r.Votes))
+	for i, vote := range r.Votes {
+		votes[i] = vote.Peer + ": " + vote.Vote
+	}
+	return fmt.Sprintf("%d of %d required agents see the internet down (%s)", r.Down, r.Required, strings.Join(votes, ", "))
+}
+
+// agentState is the part of an agent's status a vote is made of
+type agentState struct {
+	IsRunning bool `json:"is_running"`
+	Check     *struct {
+		OverallSuccess bool      `json:"overall_success"`
+		Timestamp      time.Time `json:"timestamp"`
+	} `json:"check"`
+}
+
+// Quorum asks the configured agents
+type Quorum struct {
+	cfg    Config
+	client *http.Client
+}
+
+// New creates the quorum of cfg. Requests go through client, or a default
+// client when it is nil; with NoVerify a client skipping certificate
+// verification is used instead.
+func New(cfg Config, client *http.Client) (*Quorum, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled() {
+		return nil, errors.New("no agents configured")
+	}
+	if cfg.NoVerify {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		client = &http.Client{Transport: transport}
+	} else if client == nil {
+		client = &http.Client{}
+	}
+	return &Quorum{cfg: cfg, client: client}, nil
+}
+
+// Confirm asks every agent at once whether it sees the internet down as of
+// now. This agent, which is about to reboot, counts as down.
+func (q *Quorum) Confirm(ctx context.Context, now time.Time) Result {
+	votes := make([]Vote, len(q.cfg.Peers))
+	var wg sync.WaitGroup
+	for i, peer := range q.cfg.Peers {
+		wg.Add(1)
+		go func(i int, peer string) {
+			defer wg.Done()
+			votes[i] = q.ask(ctx, peer, now)
+		}(i, peer)
+	}
+	wg.Wait()
+
+	result := Result{Down: 1, Required: q.cfg.required(), Votes: votes}
+	for _, vote := range votes {
+		if vote.Vote == VoteDown {
+			result.Down++
+		}
+	}
+	return result
+}
+
+// ask returns the vote of the agent at peer
+func (q *Quorum) ask(ctx context.Context, peer string, now time.Time) Vote {
+	vote := Vote{Peer: peer, Vote: VoteAbstain}
+	if u, err := url.Parse(peer); err == nil {
+		vote.Peer = u.Host
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(peer, "/")+statusPath, nil)
+	if err != nil {
+		vote.Detail = err.Error()
+		return vote
+	}
+	if q.cfg.Key != "" {
+		req.Header.Set("Authorization", "Bearer "+q.cfg.Key)
+	}
+	resp, err := q.client.Do(req)
+	if err != nil {
+		vote.Detail = "unreachable"
+		return vote
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		vote.Detail = resp.Status
+		return vote
+	}
+	var state agentState
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&state); err != nil {
+		vote.Detail = "invalid status"
+		return vote
+	}
+
+	switch {
+	case !state.IsRunning:
+		vote.Detail = "not monitoring"
+	case state.Check == nil:
+		vote.Detail = "no check yet"
+	case now.Sub(state.Check.Timestamp) > q.cfg.MaxAge:
+		vote.Detail = fmt.Sprintf("last check %s old", now.Sub(state.Check.Timestamp).Round(time.Second))
+	case state.Check.OverallSuccess:
+		vote.Vote = VoteUp
+	default:
+		vote.Vote = VoteDown
+	}
+	return vote
+}
@@ -0,0 +1,77 @@
+package quorum
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newAgent serves a status whose last check at checked succeeded or not
+func newAgent(t *testing.T, success bool, checked time.Time) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"is_running":true,"check":{"overall_success":%t,"timestamp":%q}}`, success, checked.Format(time.RFC3339))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestConfirm(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	down := newAgent(t, false, now.Add(-time.Minute))
+	up := newAgent(t, true, now.Add(-time.Minute))
+	stale := newAgent(t, false, now.Add(-time.Hour))
+	gone := httptest.NewServer(http.NotFoundHandler())
+	gone.Close()
+
+	q, err := New(Config{Peers: []string{down, up, stale, gone.URL}, Key: "key", MaxAge: DefaultMaxAge}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	result := q.Confirm(context.Background(), now)
+	// A majority of five agents is three; this one and the down one agree
+	if result.Down != 2 || result.Required != 3 || result.Reached() {
+		t.Errorf("Expected 2 of 3 required, got %+v", result)
+	}
+	var votes []string
+	for _, vote := range result.Votes {
+		votes = append(votes, vote.Vote)
+	}
+	if want := "down,up,abstain,abstain"; strings.Join(votes, ",") != want {
+		t.Errorf("Expected votes %s, got %v", want, result.Votes)
+	}
+	if !strings.Contains(result.String(), "2 of 3 required") {
+		t.Errorf("Unexpected summary: %s", result)
+	}
+
+	q, err = New(Config{Peers: []string{down, up}, Key: "key", Required: 2, MaxAge: DefaultMaxAge}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if result := q.Confirm(context.Background(), now); !result.Reached() {
+		t.Errorf("Expected 2 agents to reach a quorum of 2, got %+v", result)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("Expected no quorum to be valid, got %v", err)
+	}
+	for _, cfg := range []Config{
+		{Peers: []string{"pi2:8600"}, MaxAge: DefaultMaxAge},
+		{Peers: []string{"http://pi2:8600"}, Required: 3, MaxAge: DefaultMaxAge},
+		{Peers: []string{"http://pi2:8600"}},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", cfg)
+		}
+	}
+}
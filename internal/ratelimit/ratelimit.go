@@ -0,0 +1,75 @@
+// Package ratelimit bounds how often an action may happen within a sliding
+// window, so a misbehaving automation cannot reboot the modem in a loop.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+// Limiter allows at most limit events per window
+type Limiter struct {
+	clock  clock.Clock
+	limit  int
+	window time.Duration
+
+	mu     sync.Mutex
+	events []time.Time
+}
+
+// New creates a limiter allowing limit events per window; a limit of 0 or
+// less allows everything
+func New(c clock.Clock, limit int, window time.Duration) *Limiter {
+	if c == nil {
+		c = clock.New()
+	}
+	return &Limiter{clock: c, limit: limit, window: window}
+}
+
+// Allow records an event if the limit permits it. Otherwise it returns false
+// and how long until the next event is allowed.
+func (l *Limiter) Allow() (bool, time.Duration) {
+	if l == nil || l.limit <= 0 {
+		return true, 0
+	}
+
+	now := l.clock.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+	if len(l.events) >= l.limit {
+		return false, l.events[0].Add(l.window).Sub(now)
+	}
+	l.events = append(l.events, now)
+	return true, 0
+}
+
+// Remaining returns how many events are still allowed in the current window,
+// or -1 without a limit
+func (l *Limiter) Remaining() int {
+	if l == nil || l.limit <= 0 {
+		return -1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(l.clock.Now())
+	return l.limit - len(l.events)
+}
+
+// prune drops events that left the window; callers hold l.mu
+func (l *Limiter) prune(now time.Time) {
+	cutoff := now.Add(-l.window)
+	kept := l.events[:0]
+	for _, event := range l.events {
+		if event.After(cutoff) {
+			kept = append(kept, event)
+		}
+	}
+	l.events = kept
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+func TestLimiterSlidingWindow(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	limiter := New(fake, 2, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow(); !ok {
+			t.Fatalf("Event %d should be allowed", i+1)
+		}
+		fake.Advance(10 * time.Minute)
+	}
+	if limiter.Remaining() != 0 {
+		t.Errorf("Expected no remaining events, got %d", limiter.Remaining())
+	}
+
+	ok, retryAfter := limiter.Allow()
+	if ok {
+		t.Fatal("Third event within the window should be refused")
+	}
+	if retryAfter != 40*time.Minute {
+		t.Errorf("Expected retry after 40m, got %v", retryAfter)
+	}
+
+	// Refused events do not count against the window
+	fake.Advance(40 * time.Minute)
+	if ok, _ := limiter.Allow(); !ok {
+		t.Error("Event should be allowed once the first one left the window")
+	}
+	if ok, _ := limiter.Allow(); ok {
+		t.Error("Second event should still be within the window")
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	limiter := New(nil, 0, time.Hour)
+	for i := 0; i < 100; i++ {
+		if ok, _ := limiter.Allow(); !ok {
+			t.Fatal("A zero limit should allow everything")
+		}
+	}
+	if limiter.Remaining() != -1 {
+		t.Errorf("Expected -1 remaining without a limit, got %d", limiter.Remaining())
+	}
+
+	var nilLimiter *Limiter
+	if ok, _ := nilLimiter.Allow(); !ok {
+		t.Error("A nil limiter should allow everything")
+	}
+}
@@ -0,0 +1,122 @@
+// Package responder implements a small check target to run on a server of
+// one's own, so the watchdog never has to probe public services. It answers
+// TCP, UDP and HTTP probes; every answer carries the probe's nonce, the time
+// and an HMAC-SHA256 signature made with a key shared with the watchdog.
+package responder
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+)
+
+// Protocol details
+const (
+	// DefaultPort is the TCP and UDP port of the responder
+	DefaultPort = 8600
+	// DefaultHTTPPort is the HTTP port of the responder
+	DefaultHTTPPort = 8601
+	// CheckPath is the HTTP path of the check, with the nonce as the nonce
+	// query parameter
+	CheckPath = "/check"
+	// Version starts every response line
+	Version = "MBW1"
+	// MinUDPRequest is the size a UDP probe is padded to. Smaller datagrams
+	// are dropped, so the responder never sends more than it receives and
+	// cannot be used to amplify spoofed traffic.
+	MinUDPRequest = 160
+	// MaxNonce is the longest nonce the responder signs
+	MaxNonce = 64
+)
+
+// Response is a signed answer to a probe
+type Response struct {
+	Nonce     string
+	Time      time.Time
+	Signature string
+}
+
+// NewNonce returns a random nonce for a probe
+func NewNonce() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		// The time still makes the nonce unique per probe
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// UDPProbe returns the datagram that asks for a signature of nonce, padded
+// to MinUDPRequest
+func UDPProbe(nonce string) []byte {
+	probe := []byte(nonce + "\n")
+	for len(probe) < MinUDPRequest {
+		probe = append(probe, ' ')
+	}
+	return probe
+}
+
+// Sign answers nonce at t with key, as the line the responder sends
+func Sign(key []byte, nonce string, t time.Time) string {
+	unix := t.Unix()
+	return fmt.Sprintf("%s %s %d %s", Version, nonce, unix, signature(key, nonce, unix))
+}
+
+// ParseResponse parses a response line without checking its signature
+func ParseResponse(line string) (Response, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[0] != Version {
+		return Response{}, fmt.Errorf("malformed responder response: %q", line)
+	}
+	unix, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return Response{}, fmt.Errorf("malformed time in responder response: %w", err)
+	}
+	return Response{Nonce: fields[1], Time: time.Unix(unix, 0), Signature: fields[3]}, nil
+}
+
+// Verify checks that line answers nonce with a signature made with key. An
+// answer that fails the check, including one that is not a responder answer
+// at all, matches errors.ErrUnverifiedResponse.
+func Verify(key []byte, nonce, line string) error {
+	response, err := ParseResponse(line)
+	if err != nil {
+		return werrors.Mark(err, werrors.ErrUnverifiedResponse)
+	}
+	if response.Nonce != nonce {
+		return werrors.Mark(fmt.Errorf("responder answer carries nonce %q instead of %q", response.Nonce, nonce), werrors.ErrUnverifiedResponse)
+	}
+	expected := signature(key, nonce, response.Time.Unix())
+	if !hmac.Equal([]byte(response.Signature), []byte(expected)) {
+		return werrors.Mark(fmt.Errorf("responder answer has an invalid signature"), werrors.ErrUnverifiedResponse)
+	}
+	return nil
+}
+
+// validNonce reports whether nonce can be signed: printable without spaces
+// and at most MaxNonce long
+func validNonce(nonce string) bool {
+	if nonce == "" || len(nonce) > MaxNonce {
+		return false
+	}
+	for _, c := range nonce {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// signature is the hex HMAC-SHA256 of nonce and unix under key
+func signature(key []byte, nonce string, unix int64) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s|%d", nonce, unix)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,38 @@
+package responder
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	werrors "github.com/perezjoseph/mb8600-watchdog/internal/errors"
+)
+
+func TestVerify(t *testing.T) {
+	nonce := NewNonce()
+	signed := Sign(testKey, nonce, time.Unix(1714564800, 0))
+
+	if err := Verify(testKey, nonce, signed+"\n"); err != nil {
+		t.Errorf("Expected a signed answer to verify, got %v", err)
+	}
+
+	tests := map[string]struct {
+		key   []byte
+		nonce string
+		line  string
+	}{
+		"wrong key":     {[]byte("other secret"), nonce, signed},
+		"other nonce":   {testKey, NewNonce(), signed},
+		"malformed":     {testKey, nonce, "HTTP/1.1 200 OK"},
+		"empty answer":  {testKey, nonce, ""},
+		"forged answer": {testKey, nonce, "MBW1 " + nonce + " 1714564800 00"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Verify(tt.key, tt.nonce, tt.line)
+			if !errors.Is(err, werrors.ErrUnverifiedResponse) {
+				t.Errorf("Expected ErrUnverifiedResponse, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package responder
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/sirupsen/logrus"
+)
+
+// probeTimeout bounds how long a TCP probe may take to send its nonce
+const probeTimeout = 5 * time.Second
+
+// Server answers TCP, UDP and HTTP probes with signed responses
+type Server struct {
+	key    []byte
+	logger *logrus.Logger
+	clock  clock.Clock
+}
+
+// NewServer creates a responder that signs with key
+func NewServer(key []byte, logger *logrus.Logger) *Server {
+	if logger == nil {
+		logger = logrus.New()
+	}
+	return &Server{key: key, logger: logger, clock: clock.New()}
+}
+
+// SetClock replaces the clock that timestamps responses
+func (s *Server) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// ListenAndServe answers TCP and UDP probes on address and HTTP probes on
+// httpAddress until ctx ends. An empty httpAddress disables HTTP.
+func (s *Server) ListenAndServe(ctx context.Context, address, httpAddress string) error {
+	tcp, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on TCP %s: %w", address, err)
+	}
+	udp, err := net.ListenPacket("udp", address)
+	if err != nil {
+		tcp.Close()
+		return fmt.Errorf("failed to listen on UDP %s: %w", address, err)
+	}
+	var httpListener net.Listener
+	if httpAddress != "" {
+		if httpListener, err = net.Listen("tcp", httpAddress); err != nil {
+			tcp.Close()
+			udp.Close()
+			return fmt.Errorf("failed to listen on HTTP %s: %w", httpAddress, err)
+		}
+	}
+	return s.Serve(ctx, tcp, udp, httpListener)
+}
+
+// Serve answers probes on the given listeners until ctx ends; nil listeners
+// are skipped. The listeners are closed on return.
+func (s *Server) Serve(ctx context.Context, tcp net.Listener, udp net.PacketConn, httpListener net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	run := func(serve func() error, closer func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := serve(); err != nil && ctx.Err() == nil {
+				errs <- err
+				cancel()
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-ctx.Done()
+			closer()
+		}()
+	}
+
+	if tcp != nil {
+		s.logger.WithField("address", tcp.Addr().String()).Info("Answering TCP probes")
+		run(func() error { return s.serveTCP(tcp) }, tcp.Close)
+	}
+	if udp != nil {
+		s.logger.WithField("address", udp.LocalAddr().String()).Info("Answering UDP probes")
+		run(func() error { return s.serveUDP(udp) }, udp.Close)
+	}
+	if httpListener != nil {
+		server := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: probeTimeout}
+		s.logger.WithField("address", httpListener.Addr().String()).Info("Answering HTTP probes")
+		run(func() error {
+			if err := server.Serve(httpListener); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		}, server.Close)
+	}
+
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// Handler answers HTTP probes: GET or HEAD on CheckPath, with the nonce as
+// the nonce query parameter. Without a nonce it only confirms reachability.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(CheckPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		nonce := r.URL.Query().Get("nonce")
+		if nonce == "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if !validNonce(nonce) {
+			http.Error(w, "invalid nonce", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, Sign(s.key, nonce, s.clock.Now()))
+	})
+	return mux
+}
+
+// serveTCP answers every connection with the signed nonce it sends
+func (s *Server) serveTCP(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return fmt.Errorf("failed to accept TCP probe: %w", err)
+		}
+		go s.answerTCP(conn)
+	}
+}
+
+// answerTCP reads a nonce line and answers it. A connection closed without
+// a nonce was a plain handshake and gets no answer.
+func (s *Server) answerTCP(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(probeTimeout))
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	nonce := strings.TrimSpace(line)
+	if err != nil && nonce == "" {
+		return
+	}
+	if !validNonce(nonce) {
+		s.logger.WithField("remote", conn.RemoteAddr().String()).Debug("Ignoring TCP probe with an invalid nonce")
+		return
+	}
+	fmt.Fprintln(conn, Sign(s.key, nonce, s.clock.Now()))
+}
+
+// serveUDP answers every padded datagram with the signed nonce it starts with
+func (s *Server) serveUDP(conn net.PacketConn) error {
+	buf := make([]byte, 2048)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return fmt.Errorf("failed to read UDP probe: %w", err)
+		}
+		if n < MinUDPRequest {
+			continue
+		}
+		fields := strings.Fields(string(buf[:n]))
+		if len(fields) == 0 || !validNonce(fields[0]) {
+			continue
+		}
+		if _, err := conn.WriteTo([]byte(Sign(s.key, fields[0], s.clock.Now())+"\n"), addr); err != nil {
+			s.logger.WithError(err).WithField("remote", addr.String()).Debug("Failed to answer UDP probe")
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package responder
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/sirupsen/logrus"
+)
+
+var testKey = []byte("shared secret")
+
+// startServer serves TCP and UDP probes on loopback until the test ends
+func startServer(t *testing.T) (tcpAddr, udpAddr string, now time.Time) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	server := NewServer(testKey, logger)
+	server.SetClock(clock.NewFake(now))
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, tcp, udp, nil) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve() failed: %v", err)
+		}
+	})
+	return tcp.Addr().String(), udp.LocalAddr().String(), now
+}
+
+func TestTCPProbeIsSigned(t *testing.T) {
+	tcpAddr, _, now := startServer(t)
+
+	conn, err := net.DialTimeout("tcp", tcpAddr, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	nonce := NewNonce()
+	if _, err := conn.Write([]byte(nonce + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Expected an answer, got %v", err)
+	}
+	if strings.TrimSpace(line) != Sign(testKey, nonce, now) {
+		t.Errorf("Unexpected answer %q", line)
+	}
+}
+
+func TestUDPProbeRequiresPadding(t *testing.T) {
+	_, udpAddr, now := startServer(t)
+
+	conn, err := net.Dial("udp", udpAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	nonce := NewNonce()
+	conn.Write([]byte(nonce + "\n"))
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	buf := make([]byte, 512)
+	if _, err := conn.Read(buf); err == nil {
+		t.Error("Expected an unpadded probe to be dropped")
+	}
+
+	probe := UDPProbe(nonce)
+	conn.Write(probe)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Expected an answer to a padded probe, got %v", err)
+	}
+	if n > len(probe) {
+		t.Errorf("Answer of %d bytes is larger than the %d byte probe", n, len(probe))
+	}
+	response, err := ParseResponse(string(buf[:n]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Nonce != nonce || !response.Time.Equal(now) || strings.TrimSpace(string(buf[:n])) != Sign(testKey, nonce, now) {
+		t.Errorf("Unexpected answer %+v", response)
+	}
+}
+
+func TestHTTPProbe(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	server := httptest.NewServer(NewServer(testKey, logger).Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + CheckPath + "?nonce=abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+	response, err := ParseResponse(line)
+	if resp.StatusCode != http.StatusOK || err != nil || response.Nonce != "abc123" {
+		t.Errorf("Expected a signed answer, got %d %q (%v)", resp.StatusCode, line, err)
+	}
+	if resp.Header.Get("Cache-Control") != "no-store" {
+		t.Error("Expected answers not to be cached")
+	}
+
+	tests := []struct {
+		method string
+		query  string
+		status int
+	}{
+		{http.MethodHead, "", http.StatusNoContent},
+		{http.MethodGet, "?nonce=has%20space", http.StatusBadRequest},
+		{http.MethodPost, "?nonce=abc", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, server.URL+CheckPath+tt.query, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.query, tt.status, resp.StatusCode)
+		}
+	}
+}
+
+func TestParseResponseRejectsMalformedLines(t *testing.T) {
+	for _, line := range []string{"", "MBW1 abc", "MBW2 abc 1 sig", "MBW1 abc now sig"} {
+		if _, err := ParseResponse(line); err == nil {
+			t.Errorf("Expected an error for %q", line)
+		}
+	}
+}
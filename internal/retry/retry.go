@@ -0,0 +1,121 @@
+// Package retry runs operations again after a failure, waiting between
+// attempts with exponential backoff and decorrelated jitter. Every retry
+// spends a token of the check cycle's retry budget, so retries stay bounded
+// however many operations fail at once.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/budget"
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+// Policy describes how often and how fast an operation is retried
+type Policy struct {
+	// MaxAttempts is the number of tries including the first; below 1 means one
+	MaxAttempts int
+	// BaseDelay is the shortest wait between attempts
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts, 0 leaves it uncapped
+	MaxDelay time.Duration
+	// Multiplier bounds the growth of the wait: each one is drawn between
+	// BaseDelay and Multiplier times the previous one
+	Multiplier float64
+	// Retryable reports whether an error is worth retrying; nil retries all
+	Retryable func(error) bool
+	// Clock times the waits; nil uses the system clock
+	Clock clock.Clock
+}
+
+// Attempt describes a finished try of an operation
+type Attempt struct {
+	// Number counts the tries, starting at 1
+	Number   int
+	Duration time.Duration
+	Err      error
+	// Delay is the wait before the next try, 0 when none follows
+	Delay time.Duration
+	// BudgetSpent is set when the cycle retry budget refused the next try
+	BudgetSpent bool
+}
+
+// Hook observes every attempt of one call, such as to log or count retries
+type Hook func(Attempt)
+
+// Do runs op until it succeeds, fails with an error that is not retryable,
+// runs out of attempts or of cycle retry budget, or ctx ends while waiting.
+// It returns the number of tries made and the last error.
+func Do(ctx context.Context, p Policy, op func(ctx context.Context) error, hooks ...Hook) (int, error) {
+	c := p.Clock
+	if c == nil {
+		c = clock.New()
+	}
+	maxAttempts := p.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	delay := p.BaseDelay
+	for number := 1; ; number++ {
+		start := c.Now()
+		err := op(ctx)
+		attempt := Attempt{Number: number, Duration: c.Now().Sub(start), Err: err}
+
+		if err == nil || number >= maxAttempts || (p.Retryable != nil && !p.Retryable(err)) {
+			notify(hooks, attempt)
+			return number, err
+		}
+		if !budget.TakeRetry(ctx) {
+			attempt.BudgetSpent = true
+			notify(hooks, attempt)
+			return number, err
+		}
+
+		delay = p.next(delay)
+		attempt.Delay = delay
+		notify(hooks, attempt)
+
+		select {
+		case <-ctx.Done():
+			return number, ctx.Err()
+		case <-c.After(delay):
+		}
+	}
+}
+
+// next returns the wait after one of prev: a random duration between
+// BaseDelay and Multiplier times prev, capped at MaxDelay
+func (p Policy) next(prev time.Duration) time.Duration {
+	upper := time.Duration(float64(prev) * p.Multiplier)
+	delay := p.BaseDelay
+	if upper > p.BaseDelay {
+		delay += jitter(upper - p.BaseDelay)
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// notify passes attempt to every hook
+func notify(hooks []Hook, attempt Attempt) {
+	for _, hook := range hooks {
+		hook(attempt)
+	}
+}
+
+var (
+	randMu sync.Mutex
+	random = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// jitter returns a random duration in [0, d)
+func jitter(d time.Duration) time.Duration {
+	randMu.Lock()
+	defer randMu.Unlock()
+	return time.Duration(random.Int63n(int64(d)))
+}
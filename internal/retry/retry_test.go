@@ -0,0 +1,118 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/budget"
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+var errTransient = errors.New("transient")
+
+func TestDoRetriesUntilSuccess(t *testing.T) {
+	policy := Policy{MaxAttempts: 5, BaseDelay: time.Millisecond, MaxDelay: 4 * time.Millisecond, Multiplier: 3}
+
+	calls := 0
+	var attempts []Attempt
+	n, err := Do(context.Background(), policy, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	}, func(a Attempt) { attempts = append(attempts, a) })
+
+	if err != nil || n != 3 {
+		t.Fatalf("Do() = %d, %v, want 3 attempts and no error", n, err)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("Expected the hook to see 3 attempts, got %d", len(attempts))
+	}
+	for i, a := range attempts[:2] {
+		if a.Number != i+1 || a.Err != errTransient {
+			t.Errorf("Attempt %d = %+v", i, a)
+		}
+		if a.Delay < policy.BaseDelay || a.Delay > policy.MaxDelay {
+			t.Errorf("Delay %v outside [%v, %v]", a.Delay, policy.BaseDelay, policy.MaxDelay)
+		}
+	}
+	if last := attempts[2]; last.Err != nil || last.Delay != 0 {
+		t.Errorf("The successful attempt should not schedule a retry: %+v", last)
+	}
+}
+
+func TestDoStopsOnPermanentError(t *testing.T) {
+	permanent := errors.New("permanent")
+	policy := Policy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Millisecond,
+		Multiplier:  2,
+		Retryable:   func(err error) bool { return err == errTransient },
+	}
+
+	n, err := Do(context.Background(), policy, func(context.Context) error { return permanent })
+	if n != 1 || err != permanent {
+		t.Errorf("Do() = %d, %v, want a single attempt", n, err)
+	}
+
+	n, err = Do(context.Background(), policy, func(context.Context) error { return errTransient })
+	if n != 5 || err != errTransient {
+		t.Errorf("Do() = %d, %v, want 5 attempts", n, err)
+	}
+}
+
+func TestDoSpendsCycleRetryBudget(t *testing.T) {
+	b := budget.New(clock.New(), time.Minute)
+	b.SetRetries(1)
+	ctx := budget.WithBudget(context.Background(), b)
+	policy := Policy{MaxAttempts: 5, BaseDelay: time.Millisecond, Multiplier: 2}
+
+	var last Attempt
+	n, err := Do(ctx, policy, func(context.Context) error { return errTransient }, func(a Attempt) { last = a })
+	if n != 2 || err != errTransient {
+		t.Errorf("Do() = %d, %v, want 2 attempts before the budget runs out", n, err)
+	}
+	if !last.BudgetSpent {
+		t.Error("Expected the last attempt to report the spent budget")
+	}
+	if used, denied := b.Retries(); used != 1 || denied != 1 {
+		t.Errorf("Retries() = %d used, %d denied, want 1 and 1", used, denied)
+	}
+}
+
+func TestDoReturnsWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := Policy{MaxAttempts: 3, BaseDelay: time.Hour, Multiplier: 2}
+
+	n, err := Do(ctx, policy, func(context.Context) error {
+		cancel()
+		return errTransient
+	})
+	if n != 1 || err != context.Canceled {
+		t.Errorf("Do() = %d, %v, want to stop waiting once the context is cancelled", n, err)
+	}
+}
+
+func TestNextDelayIsDecorrelated(t *testing.T) {
+	policy := Policy{BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second, Multiplier: 3}
+
+	prev := policy.BaseDelay
+	for i := 0; i < 100; i++ {
+		delay := policy.next(prev)
+		upper := time.Duration(float64(prev) * policy.Multiplier)
+		if upper > policy.MaxDelay {
+			upper = policy.MaxDelay
+		}
+		if delay < policy.BaseDelay || delay > upper {
+			t.Fatalf("next(%v) = %v, want within [%v, %v]", prev, delay, policy.BaseDelay, upper)
+		}
+		prev = delay
+	}
+
+	if delay := (Policy{BaseDelay: time.Second, Multiplier: 1}).next(time.Second); delay != time.Second {
+		t.Errorf("Without growth the delay should stay at the base delay, got %v", delay)
+	}
+}
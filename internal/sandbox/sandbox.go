@@ -0,0 +1,131 @@
+// Package sandbox confines the watchdog with landlock and seccomp, so a
+// compromised API or dashboard cannot reach files and kernel interfaces the
+// watchdog never uses
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"golang.org/x/sys/unix"
+)
+
+// systemReadPaths are readable and executable in the sandbox: binaries for
+// the command fallbacks, their libraries, configuration such as resolv.conf
+// and CA certificates, and the kernel interfaces the diagnostics read
+var systemReadPaths = []string{"/bin", "/sbin", "/usr", "/lib", "/lib64", "/etc", "/proc", "/sys", "/run", "/dev"}
+
+// Policy lists the paths the sandboxed process may use; everything beneath
+// a path is covered, and paths that do not exist are skipped
+type Policy struct {
+	// ReadPaths may be read and executed
+	ReadPaths []string
+	// WritePaths may also be written, created and removed
+	WritePaths []string
+}
+
+// Status reports which parts of the sandbox are in force
+type Status struct {
+	Landlock bool `json:"landlock"`
+	// LandlockABI is the landlock version of the kernel, 0 without landlock
+	LandlockABI int `json:"landlock_abi"`
+	// LandlockError explains why landlock is not in force
+	LandlockError string `json:"landlock_error,omitempty"`
+	Seccomp       bool   `json:"seccomp"`
+}
+
+// PolicyFor returns the policy for cfg: the files it references are
+// readable and the directories the watchdog writes to are writable
+func PolicyFor(cfg *config.Config) Policy {
+	policy := Policy{
+		ReadPaths:  append([]string(nil), systemReadPaths...),
+		WritePaths: []string{cfg.WorkingDirectory, cfg.StateDir(), "/dev/null"},
+	}
+
+	for _, path := range []string{
+		cfg.MessageTemplates,
+		cfg.APIUsersFile,
+		cfg.APITLSCert,
+		cfg.APITLSKey,
+		cfg.DDNSScript,
+		cfg.HomeAssistantOptionsFile,
+		cfg.ModemTunnelSSHKey,
+		cfg.ModemTunnelSSHKnownHosts,
+		cfg.BackupSFTPKey,
+		cfg.BackupSFTPKnownHosts,
+	} {
+		if path != "" {
+			policy.ReadPaths = append(policy.ReadPaths, path)
+		}
+	}
+	// Plugins are restarted when they exit
+	policy.ReadPaths = append(policy.ReadPaths, cfg.Plugins...)
+	// ssh reads its configuration, default keys and known hosts from ~/.ssh
+	if home, err := os.UserHomeDir(); err == nil && (cfg.ModemTunnelSSH != "" || cfg.Backup().SFTP() || cfg.Sync().SFTP()) {
+		policy.ReadPaths = append(policy.ReadPaths, filepath.Join(home, ".ssh"))
+	}
+
+	for _, path := range []string{cfg.LogFile, cfg.PidFile, cfg.AuditLogPath(), cfg.APIKeysPath(), cfg.TextfilePath} {
+		if path != "" {
+			policy.WritePaths = append(policy.WritePaths, filepath.Dir(path))
+		}
+	}
+	// Backups and synced files to a local directory, such as a mounted NAS
+	// share
+	for _, dir := range []string{cfg.Backup().Directory(), cfg.Sync().Directory()} {
+		if dir != "" {
+			policy.WritePaths = append(policy.WritePaths, dir)
+		}
+	}
+	// The GSM modem's serial port is written to send SMS
+	if cfg.SMSDevice != "" {
+		policy.WritePaths = append(policy.WritePaths, cfg.SMSDevice)
+	}
+
+	return policy
+}
+
+// Apply confines the process for the rest of its life. The seccomp filter
+// is required; landlock is applied where the kernel supports it, and Status
+// explains when it is not. Setting no_new_privs also means setuid binaries
+// such as ping no longer gain privileges.
+func Apply(policy Policy) (Status, error) {
+	var status Status
+
+	if err := setNoNewPrivs(); err != nil {
+		return status, fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+
+	abi, err := applyLandlock(policy)
+	status.LandlockABI = abi
+	if err != nil {
+		status.LandlockError = err.Error()
+	} else {
+		status.Landlock = true
+	}
+
+	if err := applySeccomp(); err != nil {
+		return status, fmt.Errorf("failed to install seccomp filter: %w", err)
+	}
+	status.Seccomp = true
+
+	return status, nil
+}
+
+// setNoNewPrivs sets no_new_privs on every thread, which landlock and
+// seccomp both require
+func setNoNewPrivs() error {
+	_, _, errno := syscall.AllThreadsSyscall(unix.SYS_PRCTL, unix.PR_SET_NO_NEW_PRIVS, 1, 0)
+	if errno == syscall.ENOTSUP {
+		// Binaries built with cgo cannot reach every thread; seccomp's
+		// thread synchronization propagates the flag instead
+		return unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0)
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"golang.org/x/sys/unix"
+)
+
+// childEnv makes the test binary run TestSandboxChild, which confines the
+// process and cannot be undone
+const childEnv = "SANDBOX_TEST_CHILD_WRITE_DIR"
+
+func TestPolicyFor(t *testing.T) {
+	cfg := &config.Config{
+		WorkingDirectory: "/var/lib/watchdog",
+		LogFile:          "/var/log/watchdog/watchdog.log",
+		PidFile:          "/run/watchdog/watchdog.pid",
+		MessageTemplates: "/etc/watchdog/messages.json",
+		Plugins:          []string{"/usr/local/lib/watchdog/nas-check"},
+	}
+
+	policy := PolicyFor(cfg)
+
+	for _, path := range []string{"/var/lib/watchdog", "/var/log/watchdog", "/run/watchdog", "/dev/null"} {
+		if !contains(policy.WritePaths, path) {
+			t.Errorf("Expected %s to be writable, got %v", path, policy.WritePaths)
+		}
+	}
+	for _, path := range []string{"/usr", "/etc/watchdog/messages.json", "/usr/local/lib/watchdog/nas-check"} {
+		if !contains(policy.ReadPaths, path) {
+			t.Errorf("Expected %s to be readable, got %v", path, policy.ReadPaths)
+		}
+	}
+	if contains(policy.ReadPaths, "") || contains(policy.WritePaths, "") {
+		t.Error("Expected unset paths to be left out")
+	}
+}
+
+func TestPolicyForBackups(t *testing.T) {
+	cfg := &config.Config{
+		WorkingDirectory:  "/var/lib/watchdog",
+		BackupDestination: "/mnt/nas/watchdog",
+	}
+	if policy := PolicyFor(cfg); !contains(policy.WritePaths, "/mnt/nas/watchdog") {
+		t.Errorf("Expected the backup directory to be writable, got %v", policy.WritePaths)
+	}
+
+	cfg.BackupDestination = "sftp://backup@nas/watchdog"
+	cfg.BackupSFTPKey = "/etc/watchdog/backup_key"
+	cfg.BackupSFTPKnownHosts = "/etc/watchdog/known_hosts"
+	policy := PolicyFor(cfg)
+	for _, path := range []string{"/etc/watchdog/backup_key", "/etc/watchdog/known_hosts"} {
+		if !contains(policy.ReadPaths, path) {
+			t.Errorf("Expected %s to be readable, got %v", path, policy.ReadPaths)
+		}
+	}
+	if contains(policy.WritePaths, cfg.BackupDestination) {
+		t.Errorf("Expected a remote destination not to be a path, got %v", policy.WritePaths)
+	}
+}
+
+func TestPolicyForSync(t *testing.T) {
+	cfg := &config.Config{
+		WorkingDirectory: "/var/lib/watchdog",
+		SyncDestination:  "/mnt/nas/sync",
+	}
+	if policy := PolicyFor(cfg); !contains(policy.WritePaths, "/mnt/nas/sync") {
+		t.Errorf("Expected the sync directory to be writable, got %v", policy.WritePaths)
+	}
+
+	// Sync shares the sftp credentials of backups
+	cfg.SyncDestination = "sftp://sync@nas/watchdog"
+	cfg.BackupSFTPKey = "/etc/watchdog/sync_key"
+	cfg.BackupSFTPKnownHosts = "/etc/watchdog/known_hosts"
+	policy := PolicyFor(cfg)
+	for _, path := range []string{"/etc/watchdog/sync_key", "/etc/watchdog/known_hosts"} {
+		if !contains(policy.ReadPaths, path) {
+			t.Errorf("Expected %s to be readable, got %v", path, policy.ReadPaths)
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil && !contains(policy.ReadPaths, filepath.Join(home, ".ssh")) {
+		t.Errorf("Expected ~/.ssh to be readable for sftp, got %v", policy.ReadPaths)
+	}
+}
+
+func TestSeccompFilter(t *testing.T) {
+	filter := seccompFilter(unix.AUDIT_ARCH_X86_64)
+
+	if len(filter) > 255 {
+		t.Fatalf("Expected the filter to fit the 8-bit jump offsets, got %d instructions", len(filter))
+	}
+	if filter[0].K != seccompDataArch || filter[1].K != unix.AUDIT_ARCH_X86_64 {
+		t.Error("Expected the filter to check the architecture first")
+	}
+	last := filter[len(filter)-1]
+	if last.Code != unix.BPF_RET|unix.BPF_K || last.K != seccompRetAllow {
+		t.Error("Expected the filter to allow unlisted syscalls")
+	}
+	for i, instruction := range filter {
+		if int(instruction.Jt)+i >= len(filter) || int(instruction.Jf)+i >= len(filter) {
+			t.Errorf("Instruction %d jumps past the end of the filter", i)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	if os.Getenv(childEnv) != "" {
+		t.Skip("Running as the sandboxed child")
+	}
+
+	writeDir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSandboxChild$", "-test.v")
+	cmd.Env = append(os.Environ(), childEnv+"="+writeDir)
+	output, err := cmd.CombinedOutput()
+	if strings.Contains(string(output), "--- SKIP") {
+		t.Skipf("Sandbox unavailable: %s", output)
+	}
+	if err != nil {
+		t.Fatalf("Sandboxed child failed: %v\n%s", err, output)
+	}
+}
+
+// TestSandboxChild runs in a separate process started by TestApply
+func TestSandboxChild(t *testing.T) {
+	writeDir := os.Getenv(childEnv)
+	if writeDir == "" {
+		t.Skip("Only runs as a child of TestApply")
+	}
+	deniedDir, err := os.MkdirTemp("", "sandbox-denied")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	defer os.RemoveAll(deniedDir)
+
+	status, err := Apply(Policy{ReadPaths: systemReadPaths, WritePaths: []string{writeDir, "/dev/null"}})
+	if err != nil {
+		t.Skipf("Failed to apply sandbox: %v", err)
+	}
+
+	if err := unix.Unshare(unix.CLONE_NEWUSER); !errors.Is(err, unix.EPERM) {
+		t.Errorf("Expected unshare to fail with EPERM, got %v", err)
+	}
+	if fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, 0); !errors.Is(err, unix.EAFNOSUPPORT) {
+		unix.Close(fd)
+		t.Errorf("Expected packet sockets to fail with EAFNOSUPPORT, got %v", err)
+	}
+	if fd, err := unix.Socket(unix.AF_INET, unix.SOCK_STREAM, 0); err != nil {
+		t.Errorf("Expected IP sockets to be allowed, got %v", err)
+	} else {
+		unix.Close(fd)
+	}
+
+	if err := os.WriteFile(filepath.Join(writeDir, "state.json"), []byte("{}"), 0644); err != nil {
+		t.Errorf("Expected writes to the write paths to succeed, got %v", err)
+	}
+	if !status.Landlock {
+		t.Logf("Landlock not in force: %s", status.LandlockError)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(deniedDir, "state.json"), []byte("{}"), 0644); err == nil {
+		t.Error("Expected writes outside the write paths to fail")
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,426 @@
+// Package scheduler runs periodic and one-shot jobs on the clock abstraction.
+// Jobs are scheduled by interval, cron expression or a single point in time,
+// so checks, reports and maintenance tasks share one loop that tests can drive
+// with a fake clock.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/sirupsen/logrus"
+)
+
+// Schedule decides when a job runs
+type Schedule interface {
+	// Next returns the first run time after t, or the zero time when the
+	// job should not run again
+	Next(t time.Time) time.Time
+}
+
+// Job is the work performed on each run
+type Job func(ctx context.Context) error
+
+// Every returns a schedule that runs every d, starting d after the job is added
+func Every(d time.Duration) Schedule {
+	return intervalSchedule(d)
+}
+
+type intervalSchedule time.Duration
+
+func (i intervalSchedule) Next(t time.Time) time.Time {
+	if i <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(i))
+}
+
+// Once returns a schedule that runs a single time at t; a time in the past
+// runs immediately
+func Once(t time.Time) Schedule {
+	return &onceSchedule{at: t}
+}
+
+type onceSchedule struct {
+	at time.Time
+}
+
+func (o *onceSchedule) Next(t time.Time) time.Time {
+	if o.at.IsZero() {
+		return time.Time{}
+	}
+	at := o.at
+	o.at = time.Time{}
+	if at.Before(t) {
+		return t
+	}
+	return at
+}
+
+// Overlap decides what happens to a run that comes due while the previous
+// run of the same job is still in progress
+type Overlap int
+
+const (
+	// OverlapSkip drops the run, like a time.Ticker drops ticks
+	OverlapSkip Overlap = iota
+	// OverlapQueue starts the run as soon as the previous one returns, up to
+	// JobOptions.QueueLimit pending runs; further runs are skipped
+	OverlapQueue
+)
+
+// ParseOverlap parses "skip" or "queue"
+func ParseOverlap(value string) (Overlap, error) {
+	switch value {
+	case "skip", "":
+		return OverlapSkip, nil
+	case "queue":
+		return OverlapQueue, nil
+	default:
+		return OverlapSkip, fmt.Errorf("invalid overlap policy %q (expected skip or queue)", value)
+	}
+}
+
+// String returns the configuration name of the policy
+func (o Overlap) String() string {
+	if o == OverlapQueue {
+		return "queue"
+	}
+	return "skip"
+}
+
+// SustainedOverrunRuns is the number of consecutive skipped runs after which
+// a job is reported as overrunning its schedule
+const SustainedOverrunRuns = 3
+
+// JobOptions tunes how a job is run
+type JobOptions struct {
+	Overlap Overlap
+	// QueueLimit bounds the pending runs with OverlapQueue; 0 means 1
+	QueueLimit int
+}
+
+// JobStats counts the runs of a job
+type JobStats struct {
+	Runs    int `json:"runs"`
+	Skipped int `json:"skipped"`
+	Queued  int `json:"queued"`
+	// ConsecutiveSkipped is reset by the next run that starts on schedule
+	ConsecutiveSkipped int `json:"consecutive_skipped"`
+}
+
+// entry is a registered job
+type entry struct {
+	name     string
+	schedule Schedule
+	job      Job
+	opts     JobOptions
+	next     time.Time
+	running  bool
+	pending  int
+	stats    *JobStats
+}
+
+// Scheduler runs registered jobs at their scheduled times. Each run happens
+// on its own goroutine; a run that is due while the previous run of the same
+// job is still in progress is skipped or queued according to its options.
+type Scheduler struct {
+	clock  clock.Clock
+	logger *logrus.Logger
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	stats   map[string]*JobStats
+	wake    chan struct{}
+	wg      sync.WaitGroup
+
+	// panicHandler receives panics of jobs; nil lets them crash the process
+	panicHandler PanicHandler
+}
+
+// PanicHandler receives the value and stack of a panic in job
+type PanicHandler func(job string, value interface{}, stack []byte)
+
+// New creates a scheduler driven by c
+func New(c clock.Clock, logger *logrus.Logger) *Scheduler {
+	if c == nil {
+		c = clock.New()
+	}
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.InfoLevel)
+	}
+	return &Scheduler{
+		clock:   c,
+		logger:  logger,
+		entries: make(map[string]*entry),
+		stats:   make(map[string]*JobStats),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Add registers a job under a unique name; overlapping runs are skipped
+func (s *Scheduler) Add(name string, schedule Schedule, job Job) error {
+	return s.AddWithOptions(name, schedule, job, JobOptions{})
+}
+
+// AddWithOptions registers a job under a unique name
+func (s *Scheduler) AddWithOptions(name string, schedule Schedule, job Job, opts JobOptions) error {
+	if schedule == nil || job == nil {
+		return fmt.Errorf("job %q needs a schedule and a function", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[name]; exists {
+		return fmt.Errorf("job %q is already scheduled", name)
+	}
+
+	if opts.QueueLimit <= 0 {
+		opts.QueueLimit = 1
+	}
+
+	e := &entry{name: name, schedule: schedule, job: job, opts: opts}
+	e.next = schedule.Next(s.clock.Now())
+	if e.next.IsZero() {
+		return fmt.Errorf("schedule for job %q never fires", name)
+	}
+
+	// Statistics survive removal, so a job added again keeps counting
+	if _, ok := s.stats[name]; !ok {
+		s.stats[name] = &JobStats{}
+	}
+	e.stats = s.stats[name]
+	s.entries[name] = e
+	s.notify()
+	return nil
+}
+
+// Reschedule replaces the schedule of a job; the next run is computed from now
+func (s *Scheduler) Reschedule(name string, schedule Schedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return fmt.Errorf("job %q is not scheduled", name)
+	}
+	e.schedule = schedule
+	e.next = schedule.Next(s.clock.Now())
+	s.notify()
+	return nil
+}
+
+// Remove unregisters a job; a run in progress is not interrupted
+func (s *Scheduler) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, name)
+	s.notify()
+}
+
+// Next returns when a job runs next
+func (s *Scheduler) Next(name string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok || e.next.IsZero() {
+		return time.Time{}, false
+	}
+	return e.next, true
+}
+
+// Stats returns the run statistics of a job
+func (s *Scheduler) Stats(name string) JobStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stats, ok := s.stats[name]; ok {
+		return *stats
+	}
+	return JobStats{}
+}
+
+// Jobs returns the names of the registered jobs in order
+func (s *Scheduler) Jobs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.entries))
+	for name := range s.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// notify wakes the run loop after a change; callers hold s.mu
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run executes jobs until ctx is cancelled, then waits for running jobs to
+// return and reports ctx.Err()
+func (s *Scheduler) Run(ctx context.Context) error {
+	defer s.wg.Wait()
+
+	for {
+		next, ok := s.nextRun()
+
+		var timer clock.Timer
+		var fire <-chan time.Time
+		if ok {
+			timer = s.clock.NewTimer(next.Sub(s.clock.Now()))
+			fire = timer.C()
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return ctx.Err()
+		case <-s.wake:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-fire:
+			s.runDue(ctx)
+		}
+	}
+}
+
+// nextRun returns the earliest pending run time
+func (s *Scheduler) nextRun() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, e := range s.entries {
+		if e.next.IsZero() {
+			continue
+		}
+		if next.IsZero() || e.next.Before(next) {
+			next = e.next
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// runDue starts every job whose run time has come
+func (s *Scheduler) runDue(ctx context.Context) {
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		if e.next.IsZero() || e.next.After(now) {
+			continue
+		}
+
+		// Runs missed while the job was busy are dropped rather than queued
+		scheduled := e.next
+		e.next = e.schedule.Next(now)
+		if e.next.IsZero() {
+			delete(s.entries, e.name)
+		}
+
+		if e.running {
+			s.overlap(e, scheduled)
+			continue
+		}
+
+		e.stats.ConsecutiveSkipped = 0
+		e.running = true
+		s.wg.Add(1)
+		go s.execute(ctx, e)
+	}
+}
+
+// recoverJob passes a panic of e to the panic handler and marks e as no
+// longer running; without a handler the panic continues
+func (s *Scheduler) recoverJob(e *entry) {
+	if s.panicHandler == nil {
+		return
+	}
+	value := recover()
+	if value == nil {
+		return
+	}
+	stack := debug.Stack()
+
+	s.mu.Lock()
+	e.pending = 0
+	e.running = false
+	s.mu.Unlock()
+
+	s.panicHandler(e.name, value, stack)
+}
+
+// overlap handles a run that came due while the job is still running;
+// callers hold s.mu
+func (s *Scheduler) overlap(e *entry, scheduled time.Time) {
+	fields := logrus.Fields{"job": e.name, "scheduled": scheduled}
+
+	if e.opts.Overlap == OverlapQueue && e.pending < e.opts.QueueLimit {
+		e.pending++
+		e.stats.Queued++
+		s.logger.WithFields(fields).Debug("Previous run still in progress, queueing scheduled run")
+		return
+	}
+
+	e.stats.Skipped++
+	e.stats.ConsecutiveSkipped++
+	s.logger.WithFields(fields).Debug("Previous run still in progress, skipping scheduled run")
+
+	if e.stats.ConsecutiveSkipped == SustainedOverrunRuns {
+		s.logger.WithFields(logrus.Fields{
+			"job":            e.name,
+			"skipped_runs":   e.stats.ConsecutiveSkipped,
+			"total_skipped":  e.stats.Skipped,
+			"overlap_policy": e.opts.Overlap.String(),
+			"next_scheduled": e.next,
+		}).Warn("Job keeps overrunning its schedule, runs are being skipped")
+	}
+}
+
+// SetPanicHandler recovers panics of jobs and passes them to handler. Call
+// it before Run.
+func (s *Scheduler) SetPanicHandler(handler PanicHandler) {
+	s.panicHandler = handler
+}
+
+// execute runs a job, then any runs queued while it was running
+func (s *Scheduler) execute(ctx context.Context, e *entry) {
+	defer s.wg.Done()
+	defer s.recoverJob(e)
+
+	for {
+		if err := e.job(ctx); err != nil && ctx.Err() == nil {
+			s.logger.WithError(err).WithField("job", e.name).Debug("Scheduled job returned an error")
+		}
+
+		s.mu.Lock()
+		e.stats.Runs++
+		if e.pending == 0 || ctx.Err() != nil {
+			e.pending = 0
+			e.running = false
+			s.mu.Unlock()
+			return
+		}
+		e.pending--
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,394 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestScheduler(start time.Time) (*Scheduler, *clock.Fake) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	fake := clock.NewFake(start)
+	return New(fake, logger), fake
+}
+
+// runs records the times a job ran
+type runs struct {
+	mu    sync.Mutex
+	times []time.Time
+	ch    chan time.Time
+}
+
+func newRuns() *runs {
+	return &runs{ch: make(chan time.Time, 100)}
+}
+
+func (r *runs) job(c clock.Clock) Job {
+	return func(ctx context.Context) error {
+		now := c.Now()
+		r.mu.Lock()
+		r.times = append(r.times, now)
+		r.mu.Unlock()
+		r.ch <- now
+		return nil
+	}
+}
+
+func (r *runs) wait(t *testing.T) time.Time {
+	t.Helper()
+	select {
+	case at := <-r.ch:
+		return at
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for job to run")
+		return time.Time{}
+	}
+}
+
+func startScheduler(t *testing.T, s *Scheduler) (context.CancelFunc, <-chan error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.Run(ctx)
+	}()
+	return cancel, errChan
+}
+
+func TestIntervalJob(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, fake := newTestScheduler(start)
+	r := newRuns()
+
+	if err := s.Add("check", Every(30*time.Second), r.job(fake)); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	cancel, errChan := startScheduler(t, s)
+
+	for i := 1; i <= 3; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(30 * time.Second)
+		if at := r.wait(t); !at.Equal(start.Add(time.Duration(i) * 30 * time.Second)) {
+			t.Errorf("Run %d at %v", i, at)
+		}
+	}
+
+	cancel()
+	if err := <-errChan; err != context.Canceled {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+}
+
+func TestOnceJob(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, fake := newTestScheduler(start)
+	r := newRuns()
+
+	if err := s.Add("self_test", Once(start.Add(time.Hour)), r.job(fake)); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	cancel, errChan := startScheduler(t, s)
+	defer func() {
+		cancel()
+		<-errChan
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Hour)
+	r.wait(t)
+
+	// The job is removed after its only run
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.Jobs()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if jobs := s.Jobs(); len(jobs) != 0 {
+		t.Errorf("Expected one-shot job to be removed, got %v", jobs)
+	}
+}
+
+func TestCronJob(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	s, fake := newTestScheduler(start)
+	r := newRuns()
+
+	schedule, err := ParseCron("0 4 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron() failed: %v", err)
+	}
+	if err := s.Add("digest", schedule, r.job(fake)); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if next, ok := s.Next("digest"); !ok || !next.Equal(time.Date(2024, 1, 2, 4, 0, 0, 0, time.UTC)) {
+		t.Errorf("Next() = %v, %v", next, ok)
+	}
+
+	cancel, errChan := startScheduler(t, s)
+	defer func() {
+		cancel()
+		<-errChan
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(18 * time.Hour)
+	if at := r.wait(t); !at.Equal(time.Date(2024, 1, 2, 4, 0, 0, 0, time.UTC)) {
+		t.Errorf("Cron job ran at %v", at)
+	}
+}
+
+func TestRescheduleAndRemove(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, fake := newTestScheduler(start)
+	r := newRuns()
+
+	if err := s.Add("check", Every(time.Minute), r.job(fake)); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := s.Add("check", Every(time.Minute), r.job(fake)); err == nil {
+		t.Error("Adding a duplicate job should fail")
+	}
+	if err := s.Reschedule("missing", Every(time.Minute)); err == nil {
+		t.Error("Rescheduling an unknown job should fail")
+	}
+
+	if err := s.Reschedule("check", Every(10*time.Second)); err != nil {
+		t.Fatalf("Reschedule() failed: %v", err)
+	}
+	cancel, errChan := startScheduler(t, s)
+	defer func() {
+		cancel()
+		<-errChan
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(10 * time.Second)
+	if at := r.wait(t); !at.Equal(start.Add(10 * time.Second)) {
+		t.Errorf("Rescheduled job ran at %v", at)
+	}
+
+	s.Remove("check")
+	if _, ok := s.Next("check"); ok {
+		t.Error("Removed job should have no next run")
+	}
+	fake.Advance(time.Hour)
+	select {
+	case at := <-r.ch:
+		t.Errorf("Removed job ran at %v", at)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestOverlappingRunIsSkipped(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, fake := newTestScheduler(start)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	var mu sync.Mutex
+	count := 0
+	job := func(ctx context.Context) error {
+		mu.Lock()
+		count++
+		mu.Unlock()
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+
+	if err := s.Add("slow", Every(time.Second), job); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	cancel, errChan := startScheduler(t, s)
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Second)
+	<-started
+
+	// Two more runs come due while the first is still running
+	for i := 0; i < 2; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(time.Second)
+	}
+	fake.BlockUntil(1)
+
+	close(release)
+	cancel()
+	if err := <-errChan; err != context.Canceled {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if count != 1 {
+		t.Errorf("Expected overlapping runs to be skipped, job ran %d times", count)
+	}
+}
+
+func TestAddValidation(t *testing.T) {
+	s, _ := newTestScheduler(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	noop := func(ctx context.Context) error { return nil }
+
+	if err := s.Add("zero", Every(0), noop); err == nil {
+		t.Error("A schedule that never fires should be rejected")
+	}
+	if err := s.Add("nil", nil, noop); err == nil {
+		t.Error("A nil schedule should be rejected")
+	}
+	if err := s.Add("nojob", Every(time.Second), nil); err == nil {
+		t.Error("A nil job should be rejected")
+	}
+}
+
+func TestSkippedRunsAreCounted(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, fake := newTestScheduler(start)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	job := func(ctx context.Context) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+	if err := s.Add("slow", Every(time.Second), job); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	cancel, errChan := startScheduler(t, s)
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Second)
+	<-started
+
+	for i := 0; i < SustainedOverrunRuns; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(time.Second)
+	}
+	fake.BlockUntil(1)
+
+	stats := s.Stats("slow")
+	if stats.Skipped != SustainedOverrunRuns || stats.ConsecutiveSkipped != SustainedOverrunRuns {
+		t.Errorf("Expected %d skipped runs, got %+v", SustainedOverrunRuns, stats)
+	}
+
+	// The next run that starts on schedule resets the streak
+	release <- struct{}{}
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Stats("slow").Runs == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	fake.Advance(time.Second)
+	<-started
+
+	stats = s.Stats("slow")
+	if stats.Runs != 1 || stats.ConsecutiveSkipped != 0 || stats.Skipped != SustainedOverrunRuns {
+		t.Errorf("Unexpected stats after an on-time run: %+v", stats)
+	}
+
+	close(release)
+	cancel()
+	<-errChan
+}
+
+func TestQueuedRunsAreBounded(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, fake := newTestScheduler(start)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	job := func(ctx context.Context) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+	if err := s.AddWithOptions("slow", Every(time.Second), job, JobOptions{Overlap: OverlapQueue, QueueLimit: 1}); err != nil {
+		t.Fatalf("AddWithOptions() failed: %v", err)
+	}
+	cancel, errChan := startScheduler(t, s)
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Second)
+	<-started
+
+	// One run is queued, the second one is skipped
+	for i := 0; i < 2; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(time.Second)
+	}
+	fake.BlockUntil(1)
+
+	release <- struct{}{}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Queued run did not start after the previous run returned")
+	}
+	release <- struct{}{}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Stats("slow").Runs < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	stats := s.Stats("slow")
+	if stats.Runs != 2 || stats.Queued != 1 || stats.Skipped != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	cancel()
+	if err := <-errChan; err != context.Canceled {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+}
+
+func TestParseOverlap(t *testing.T) {
+	for value, want := range map[string]Overlap{"": OverlapSkip, "skip": OverlapSkip, "queue": OverlapQueue} {
+		got, err := ParseOverlap(value)
+		if err != nil || got != want {
+			t.Errorf("ParseOverlap(%q) = %v, %v", value, got, err)
+		}
+	}
+	if _, err := ParseOverlap("stack"); err == nil {
+		t.Error("ParseOverlap should reject unknown policies")
+	}
+}
+
+func TestPanicHandlerRecoversJobs(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, fake := newTestScheduler(start)
+
+	type recovered struct {
+		job   string
+		value interface{}
+	}
+	panics := make(chan recovered, 1)
+	s.SetPanicHandler(func(job string, value interface{}, stack []byte) {
+		panics <- recovered{job, value}
+	})
+
+	if err := s.Add("check", Every(30*time.Second), func(ctx context.Context) error {
+		panic("boom")
+	}); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	cancel, errChan := startScheduler(t, s)
+	defer func() {
+		cancel()
+		<-errChan
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(30 * time.Second)
+	select {
+	case got := <-panics:
+		if got.job != "check" || got.value != "boom" {
+			t.Errorf("Unexpected panic %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the panic to reach the handler")
+	}
+}
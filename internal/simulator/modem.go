@@ -0,0 +1,140 @@
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+)
+
+// fakeModemToken is the credential token handed out by the fake modem
+const fakeModemToken = "simulated"
+
+// FakeModem serves the Arris Surfboard protocol from a local HTTP server so
+// the real arris-sb driver can be used against it. The modem can be taken
+// offline, and drops off the network for the reboot duration after a reboot.
+type FakeModem struct {
+	server         *httptest.Server
+	clock          clock.Clock
+	rebootDuration time.Duration
+	onReboot       func(at time.Time)
+
+	mu           sync.Mutex
+	unreachable  bool
+	offlineUntil time.Time
+	reboots      int
+}
+
+// NewFakeModem starts a fake modem whose reboot timing follows c
+func NewFakeModem(c clock.Clock, rebootDuration time.Duration) *FakeModem {
+	m := &FakeModem{clock: c, rebootDuration: rebootDuration}
+	m.server = httptest.NewServer(http.HandlerFunc(m.serveHTTP))
+	return m
+}
+
+// Host returns the host:port the modem listens on
+func (m *FakeModem) Host() string {
+	return strings.TrimPrefix(m.server.URL, "http://")
+}
+
+// Close shuts down the server
+func (m *FakeModem) Close() {
+	m.server.Close()
+}
+
+// SetUnreachable takes the modem off the network or brings it back
+func (m *FakeModem) SetUnreachable(unreachable bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unreachable = unreachable
+}
+
+// Online reports whether the modem currently answers requests
+func (m *FakeModem) Online() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.onlineLocked()
+}
+
+// Rebooting reports whether the modem is still restarting
+func (m *FakeModem) Rebooting() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.clock.Now().Before(m.offlineUntil)
+}
+
+// Reboots returns the number of reboot commands received
+func (m *FakeModem) Reboots() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reboots
+}
+
+func (m *FakeModem) onlineLocked() bool {
+	return !m.unreachable && !m.clock.Now().Before(m.offlineUntil)
+}
+
+func (m *FakeModem) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	online := m.onlineLocked()
+	m.mu.Unlock()
+
+	if !online {
+		// Drop the connection like an unreachable host would
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/cmconnectionstatus.html" && strings.HasPrefix(r.URL.RawQuery, "login_"):
+		fmt.Fprint(w, fakeModemToken)
+	case r.URL.Path == "/cmconnectionstatus.html":
+		fmt.Fprint(w, fakeStatusPage)
+	case r.URL.Path == "/cmswinfo.html":
+		fmt.Fprint(w, fakeInfoPage)
+	case r.URL.Path == "/cmconfiguration.html" && r.Method == http.MethodPost:
+		m.reboot()
+		fmt.Fprint(w, "<html><body>Rebooting</body></html>")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// reboot takes the modem offline for the reboot duration
+func (m *FakeModem) reboot() {
+	m.mu.Lock()
+	now := m.clock.Now()
+	m.reboots++
+	m.offlineUntil = now.Add(m.rebootDuration)
+	onReboot := m.onReboot
+	m.mu.Unlock()
+
+	if onReboot != nil {
+		onReboot(now)
+	}
+}
+
+const fakeStatusPage = `<html><body><table>
+<tr><th>Downstream Bonded Channels</th></tr>
+<tr><td>Channel ID</td><td>Lock Status</td><td>Modulation</td><td>Frequency</td><td>Power</td><td>SNR/MER</td><td>Corrected</td><td>Uncorrectables</td></tr>
+<tr><td>1</td><td>Locked</td><td>QAM256</td><td>591000000 Hz</td><td>2.1 dBmV</td><td>40.2 dB</td><td>0</td><td>0</td></tr>
+</table><table>
+<tr><th>Upstream Bonded Channels</th></tr>
+<tr><td>Channel</td><td>Channel ID</td><td>Lock Status</td><td>US Channel Type</td><td>Frequency</td><td>Width</td><td>Power</td></tr>
+<tr><td>1</td><td>1</td><td>Locked</td><td>SC-QAM Upstream</td><td>36000000 Hz</td><td>6400000 Hz</td><td>44.0 dBmV</td></tr>
+</table></body></html>`
+
+const fakeInfoPage = `<html><body><table>
+<tr><td>Model Name:</td><td>Simulated SB8200</td></tr>
+<tr><td>Software Version:</td><td>SIM.1.0</td></tr>
+</table></body></html>`
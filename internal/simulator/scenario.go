@@ -0,0 +1,177 @@
+// Package simulator replays scripted connectivity scenarios against the
+// monitoring service using a fake clock and a fake modem, so failure
+// thresholds, reboots and recovery waits can be exercised in CI and while
+// tuning a configuration, without touching the network or a real modem.
+package simulator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Phase kinds understood in scenario files
+const (
+	PhaseHealthy          = "healthy"
+	PhaseOutage           = "outage"
+	PhaseModemUnreachable = "modem-unreachable"
+	PhaseDegraded         = "degraded"
+)
+
+// untilRebootOption ends an outage phase once the modem has been rebooted
+const untilRebootOption = "until-reboot"
+
+// Phase is one step of a scenario
+type Phase struct {
+	Kind     string
+	Duration time.Duration
+	// LossPercent is the share of failing checks in a degraded phase
+	LossPercent int
+	// UntilReboot restores connectivity once the modem reboots during an outage
+	UntilReboot bool
+}
+
+// String formats the phase the way it is written in scenario files
+func (p Phase) String() string {
+	s := fmt.Sprintf("%s %s", p.Kind, p.Duration)
+	if p.Kind == PhaseDegraded {
+		s += fmt.Sprintf(" %d%%", p.LossPercent)
+	}
+	if p.UntilReboot {
+		s += " " + untilRebootOption
+	}
+	return s
+}
+
+// Scenario is an ordered list of phases
+type Scenario struct {
+	Phases []Phase
+}
+
+// Duration returns the total length of the scenario
+func (s *Scenario) Duration() time.Duration {
+	var total time.Duration
+	for _, phase := range s.Phases {
+		total += phase.Duration
+	}
+	return total
+}
+
+// PhaseAt returns the index of the phase active at offset, or -1 past the end
+func (s *Scenario) PhaseAt(offset time.Duration) int {
+	var end time.Duration
+	for i, phase := range s.Phases {
+		end += phase.Duration
+		if offset < end {
+			return i
+		}
+	}
+	return -1
+}
+
+// LoadScenario reads a scenario file
+func LoadScenario(path string) (*Scenario, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scenario: %w", err)
+	}
+	defer file.Close()
+
+	scenario, err := ParseScenario(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return scenario, nil
+}
+
+// ParseScenario parses phases written one per line or separated by commas:
+//
+//	healthy 10m
+//	outage 20m until-reboot
+//	degraded 15m 30%
+//	modem-unreachable 5m
+//
+// Blank lines and lines starting with '#' are ignored.
+func ParseScenario(r io.Reader) (*Scenario, error) {
+	scenario := &Scenario{}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		for _, entry := range strings.Split(line, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			phase, err := parsePhase(entry)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			scenario.Phases = append(scenario.Phases, phase)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+
+	if len(scenario.Phases) == 0 {
+		return nil, fmt.Errorf("scenario has no phases")
+	}
+	return scenario, nil
+}
+
+// parsePhase parses a single "<kind> <duration> [options]" entry
+func parsePhase(entry string) (Phase, error) {
+	fields := strings.Fields(strings.ToLower(entry))
+
+	// Accept "modem unreachable" as written in prose
+	if len(fields) >= 2 && fields[0] == "modem" && fields[1] == "unreachable" {
+		fields = append([]string{PhaseModemUnreachable}, fields[2:]...)
+	}
+
+	phase := Phase{Kind: fields[0]}
+	switch phase.Kind {
+	case PhaseHealthy, PhaseOutage, PhaseModemUnreachable, PhaseDegraded:
+	default:
+		return Phase{}, fmt.Errorf("unknown phase %q", fields[0])
+	}
+
+	if len(fields) < 2 {
+		return Phase{}, fmt.Errorf("phase %q needs a duration", phase.Kind)
+	}
+	duration, err := time.ParseDuration(fields[1])
+	if err != nil || duration <= 0 {
+		return Phase{}, fmt.Errorf("invalid duration %q for phase %q", fields[1], phase.Kind)
+	}
+	phase.Duration = duration
+
+	for _, option := range fields[2:] {
+		switch {
+		case option == untilRebootOption && phase.Kind == PhaseOutage:
+			phase.UntilReboot = true
+		case strings.HasSuffix(option, "%") && phase.Kind == PhaseDegraded:
+			percent, err := strconv.Atoi(strings.TrimSuffix(option, "%"))
+			if err != nil || percent < 0 || percent > 100 {
+				return Phase{}, fmt.Errorf("invalid loss percentage %q", option)
+			}
+			phase.LossPercent = percent
+		default:
+			return Phase{}, fmt.Errorf("unexpected option %q for phase %q", option, phase.Kind)
+		}
+	}
+
+	if phase.Kind == PhaseDegraded && phase.LossPercent == 0 && !strings.Contains(entry, "%") {
+		return Phase{}, fmt.Errorf("degraded phase needs a loss percentage, e.g. 30%%")
+	}
+
+	return phase, nil
+}
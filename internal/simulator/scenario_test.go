@@ -0,0 +1,101 @@
+package simulator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScenario(t *testing.T) {
+	input := `# outage that a reboot fixes
+healthy 10m
+outage 20m until-reboot, modem unreachable 5m
+degraded 15m 30%
+`
+	scenario, err := ParseScenario(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseScenario failed: %v", err)
+	}
+
+	expected := []Phase{
+		{Kind: PhaseHealthy, Duration: 10 * time.Minute},
+		{Kind: PhaseOutage, Duration: 20 * time.Minute, UntilReboot: true},
+		{Kind: PhaseModemUnreachable, Duration: 5 * time.Minute},
+		{Kind: PhaseDegraded, Duration: 15 * time.Minute, LossPercent: 30},
+	}
+	if len(scenario.Phases) != len(expected) {
+		t.Fatalf("Expected %d phases, got %d", len(expected), len(scenario.Phases))
+	}
+	for i, phase := range expected {
+		if scenario.Phases[i] != phase {
+			t.Errorf("Phase %d: expected %+v, got %+v", i, phase, scenario.Phases[i])
+		}
+	}
+
+	if scenario.Duration() != 50*time.Minute {
+		t.Errorf("Expected 50m total, got %v", scenario.Duration())
+	}
+	if scenario.PhaseAt(0) != 0 || scenario.PhaseAt(10*time.Minute) != 1 || scenario.PhaseAt(50*time.Minute) != -1 {
+		t.Error("PhaseAt returned unexpected indexes")
+	}
+	if got := scenario.Phases[3].String(); got != "degraded 15m0s 30%" {
+		t.Errorf("Unexpected phase string %q", got)
+	}
+}
+
+func TestParseScenarioErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty":               "# nothing\n",
+		"unknown phase":       "sunny 10m",
+		"missing duration":    "healthy",
+		"bad duration":        "healthy soon",
+		"percent on outage":   "outage 10m 20%",
+		"missing loss":        "degraded 10m",
+		"loss out of range":   "degraded 10m 120%",
+		"until-reboot misuse": "healthy 10m until-reboot",
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseScenario(strings.NewReader(input)); err == nil {
+				t.Errorf("Expected %q to be rejected", input)
+			}
+		})
+	}
+}
+
+func TestLoadScenario(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.txt")
+	if err := os.WriteFile(path, []byte("healthy 1h\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scenario, err := LoadScenario(path)
+	if err != nil {
+		t.Fatalf("LoadScenario failed: %v", err)
+	}
+	if scenario.Duration() != time.Hour {
+		t.Errorf("Expected 1h scenario, got %v", scenario.Duration())
+	}
+
+	if _, err := LoadScenario(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestExampleScenariosParse(t *testing.T) {
+	paths, err := filepath.Glob("../../config/scenarios/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("No example scenarios found")
+	}
+	for _, path := range paths {
+		if _, err := LoadScenario(path); err != nil {
+			t.Errorf("Example scenario %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/clock"
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/perezjoseph/mb8600-watchdog/internal/connectivity"
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultRebootDuration is how long the fake modem stays offline after a reboot
+const DefaultRebootDuration = 2 * time.Minute
+
+// epoch is the simulated start time, fixed so runs are reproducible
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Event kinds recorded during a simulation
+const (
+	EventPhase                = "phase"
+	EventConnectivityLost     = "connectivity_lost"
+	EventConnectivityRestored = "connectivity_restored"
+	EventReboot               = "reboot"
+	EventCheckError           = "check_error"
+)
+
+// Options tunes a simulation run
+type Options struct {
+	// RebootDuration is how long the fake modem is offline after a reboot
+	RebootDuration time.Duration
+	// Seed makes degraded phases reproducible
+	Seed int64
+	// WorkingDirectory receives outage logs; a temporary directory is used when empty
+	WorkingDirectory string
+}
+
+// Event is a notable moment of a simulation, relative to its start
+type Event struct {
+	Offset  time.Duration
+	Kind    string
+	Message string
+}
+
+// PhaseReport summarises what happened during one phase
+type PhaseReport struct {
+	Phase        Phase
+	Checks       int
+	FailedChecks int
+	Reboots      int
+}
+
+// Report is the outcome of a simulation
+type Report struct {
+	Duration     time.Duration
+	Checks       int
+	FailedChecks int
+	Reboots      int
+	Phases       []PhaseReport
+	Events       []Event
+}
+
+// UnnecessaryReboots counts reboots triggered during healthy phases
+func (r *Report) UnnecessaryReboots() int {
+	count := 0
+	for _, phase := range r.Phases {
+		if phase.Phase.Kind == PhaseHealthy {
+			count += phase.Reboots
+		}
+	}
+	return count
+}
+
+// Simulator runs the monitoring service through a scenario in virtual time.
+// The service's connectivity checker is replaced by scripted results and its
+// modem driver talks to a FakeModem, so reboots and recovery waits behave as
+// configured while a 24 hour scenario completes in seconds.
+type Simulator struct {
+	scenario *Scenario
+	config   *config.Config
+	logger   *logrus.Logger
+	clock    *clock.Fake
+	modem    *FakeModem
+	service  *monitor.Service
+	tempDir  string
+
+	mu         sync.Mutex
+	rand       *rand.Rand
+	report     *Report
+	phaseIndex int
+	connected  bool
+	repaired   map[int]bool
+}
+
+// New prepares a simulation of scenario using the thresholds and intervals
+// of cfg. Modem and connectivity settings in cfg are replaced by the fakes;
+// diagnostics and reboot cycle monitoring are disabled because they probe
+// the real network.
+func New(cfg *config.Config, scenario *Scenario, opts Options, logger *logrus.Logger) (*Simulator, error) {
+	if cfg == nil || scenario == nil {
+		return nil, fmt.Errorf("configuration and scenario are required")
+	}
+	if cfg.CheckInterval <= 0 {
+		return nil, fmt.Errorf("check interval must be positive")
+	}
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.WarnLevel)
+	}
+	if opts.RebootDuration <= 0 {
+		opts.RebootDuration = DefaultRebootDuration
+	}
+
+	s := &Simulator{
+		scenario:   scenario,
+		logger:     logger,
+		clock:      clock.NewFake(epoch),
+		rand:       rand.New(rand.NewSource(opts.Seed)),
+		report:     &Report{Duration: scenario.Duration()},
+		phaseIndex: -1,
+		connected:  true,
+		repaired:   make(map[int]bool),
+	}
+	for _, phase := range scenario.Phases {
+		s.report.Phases = append(s.report.Phases, PhaseReport{Phase: phase})
+	}
+
+	workingDirectory := opts.WorkingDirectory
+	if workingDirectory == "" {
+		dir, err := os.MkdirTemp("", "watchdog-simulate-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create working directory: %w", err)
+		}
+		s.tempDir = dir
+		workingDirectory = dir
+	}
+
+	s.modem = NewFakeModem(s.clock, opts.RebootDuration)
+	s.modem.onReboot = s.recordReboot
+
+	simConfig := *cfg
+	simConfig.ModemType = modem.TypeArrisSB
+	simConfig.ModemHost = s.modem.Host()
+	simConfig.EnableDiagnostics = false
+	simConfig.EnableRebootMonitoring = false
+	simConfig.EnableResourceLimits = false
+	simConfig.WorkingDirectory = workingDirectory
+	s.config = &simConfig
+
+	driver, err := modem.New(modem.TypeArrisSB, modem.Options{
+		Host:     simConfig.ModemHost,
+		Scheme:   "http",
+		Username: simConfig.ModemUsername,
+		Password: simConfig.ModemPassword,
+		Timeout:  5 * time.Second,
+	}, logger)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	s.service = monitor.NewServiceWithOptions(&simConfig, logger, monitor.Options{
+		Clock:       s.clock,
+		ModemDriver: driver,
+		Checker:     s,
+	})
+	return s, nil
+}
+
+// Close stops the fake modem and removes the temporary working directory
+func (s *Simulator) Close() {
+	s.modem.Close()
+	if s.tempDir != "" {
+		os.RemoveAll(s.tempDir)
+	}
+}
+
+// Run steps the service through the scenario, one check per check interval,
+// and returns what happened
+func (s *Simulator) Run(ctx context.Context) (*Report, error) {
+	start := s.clock.Now()
+	end := start.Add(s.scenario.Duration())
+	interval := s.config.CheckInterval
+
+	next := start
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Ticks missed during a recovery wait are dropped, like time.Ticker does
+		now := s.clock.Now()
+		for !next.After(now) {
+			next = next.Add(interval)
+		}
+		if !next.Before(end) {
+			break
+		}
+
+		s.clock.Advance(next.Sub(now))
+		s.enterPhase(next.Sub(start))
+
+		if err := s.runCheck(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			s.addEvent(EventCheckError, err.Error())
+		}
+	}
+
+	if now := s.clock.Now(); now.Before(end) {
+		s.clock.Advance(end.Sub(now))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report := *s.report
+	return &report, nil
+}
+
+// runCheck runs one service check, advancing virtual time whenever the
+// service waits on the clock (the post-reboot recovery wait)
+func (s *Simulator) runCheck(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- s.service.RunCheck(ctx)
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(time.Millisecond):
+			s.clock.AdvanceToNext()
+		}
+	}
+}
+
+// enterPhase records phase changes and applies their modem state
+func (s *Simulator) enterPhase(offset time.Duration) {
+	index := s.scenario.PhaseAt(offset)
+
+	s.mu.Lock()
+	changed := index != s.phaseIndex
+	s.phaseIndex = index
+	s.mu.Unlock()
+
+	if !changed || index < 0 {
+		return
+	}
+
+	phase := s.scenario.Phases[index]
+	s.modem.SetUnreachable(phase.Kind == PhaseModemUnreachable)
+
+	var phaseStart time.Duration
+	for _, earlier := range s.scenario.Phases[:index] {
+		phaseStart += earlier.Duration
+	}
+	s.addEventAt(phaseStart, EventPhase, phase.String())
+}
+
+// ScheduleTests implements monitor.ConnectivityChecker with scripted results
+func (s *Simulator) ScheduleTests(ctx context.Context, lastResult *connectivity.TieredTestResult, consecutiveFailures int) (*connectivity.TieredTestResult, error) {
+	now := s.clock.Now()
+	online := s.modem.Online()
+
+	s.mu.Lock()
+	success := online && s.phaseConnectivity()
+	if s.phaseIndex >= 0 {
+		phase := &s.report.Phases[s.phaseIndex]
+		phase.Checks++
+		if !success {
+			phase.FailedChecks++
+		}
+	}
+	s.report.Checks++
+	if !success {
+		s.report.FailedChecks++
+	}
+	transition := ""
+	if success != s.connected {
+		s.connected = success
+		transition = EventConnectivityRestored
+		if !success {
+			transition = EventConnectivityLost
+		}
+	}
+	s.mu.Unlock()
+
+	if transition != "" {
+		s.addEvent(transition, "")
+	}
+
+	lightweight := &connectivity.LightweightTestResult{
+		OverallSuccess: success,
+		Timestamp:      now,
+	}
+	if success {
+		lightweight.SuccessCount = 1
+	} else {
+		lightweight.FailureCount = 1
+	}
+
+	return &connectivity.TieredTestResult{
+		Strategy:          "simulated",
+		LightweightResult: lightweight,
+		OverallSuccess:    success,
+		Timestamp:         now,
+	}, nil
+}
+
+// phaseConnectivity decides the scripted result of the current phase; callers hold s.mu
+func (s *Simulator) phaseConnectivity() bool {
+	if s.phaseIndex < 0 {
+		return true
+	}
+
+	phase := s.scenario.Phases[s.phaseIndex]
+	switch phase.Kind {
+	case PhaseOutage:
+		return phase.UntilReboot && s.repaired[s.phaseIndex]
+	case PhaseModemUnreachable:
+		return false
+	case PhaseDegraded:
+		return s.rand.Intn(100) >= phase.LossPercent
+	default:
+		return true
+	}
+}
+
+// recordReboot is called by the fake modem when it receives a reboot command
+func (s *Simulator) recordReboot(at time.Time) {
+	s.mu.Lock()
+	s.report.Reboots++
+	if s.phaseIndex >= 0 {
+		s.report.Phases[s.phaseIndex].Reboots++
+		s.repaired[s.phaseIndex] = true
+	}
+	s.mu.Unlock()
+
+	s.addEvent(EventReboot, "")
+}
+
+func (s *Simulator) addEvent(kind, message string) {
+	s.addEventAt(s.clock.Now().Sub(epoch), kind, message)
+}
+
+func (s *Simulator) addEventAt(offset time.Duration, kind, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.report.Events = append(s.report.Events, Event{Offset: offset, Kind: kind, Message: message})
+}
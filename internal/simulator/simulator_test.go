@@ -0,0 +1,151 @@
+package simulator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		CheckInterval:        30 * time.Second,
+		FailureThreshold:     3,
+		RecoveryWait:         5 * time.Minute,
+		ConnectionTimeout:    5 * time.Second,
+		HTTPTimeout:          5 * time.Second,
+		DiagnosticsTimeout:   time.Minute,
+		OutageReportInterval: time.Hour,
+		ModemUsername:        "admin",
+		ModemPassword:        "password",
+	}
+}
+
+func runScenario(t *testing.T, input string, opts Options) *Report {
+	t.Helper()
+
+	scenario, err := ParseScenario(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseScenario failed: %v", err)
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	sim, err := New(testConfig(), scenario, opts, logger)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report, err := sim.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	return report
+}
+
+func countEvents(report *Report, kind string) int {
+	count := 0
+	for _, event := range report.Events {
+		if event.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+func TestHealthyScenarioNeverReboots(t *testing.T) {
+	report := runScenario(t, "healthy 1h", Options{})
+
+	if report.Checks != 119 {
+		t.Errorf("Expected 119 checks in an hour at 30s, got %d", report.Checks)
+	}
+	if report.FailedChecks != 0 || report.Reboots != 0 {
+		t.Errorf("Expected no failures or reboots, got %d failures and %d reboots", report.FailedChecks, report.Reboots)
+	}
+}
+
+func TestOutageFixedByReboot(t *testing.T) {
+	report := runScenario(t, "healthy 5m, outage 20m until-reboot, healthy 10m", Options{RebootDuration: time.Minute})
+
+	if report.Reboots != 1 {
+		t.Fatalf("Expected exactly one reboot, got %d (events %+v)", report.Reboots, report.Events)
+	}
+	if report.UnnecessaryReboots() != 0 {
+		t.Errorf("Expected no reboots during healthy phases, got %d", report.UnnecessaryReboots())
+	}
+	if report.Phases[1].Reboots != 1 {
+		t.Errorf("Expected the reboot during the outage phase, got %+v", report.Phases)
+	}
+
+	// The third failed check at 30s intervals after the outage starts at 5m
+	for _, event := range report.Events {
+		if event.Kind == EventReboot {
+			if event.Offset != 6*time.Minute {
+				t.Errorf("Expected reboot at 6m, got %v", event.Offset)
+			}
+		}
+	}
+	if countEvents(report, EventConnectivityRestored) != 1 {
+		t.Errorf("Expected connectivity to be restored once, events %+v", report.Events)
+	}
+}
+
+func TestPersistentOutageRebootsRepeatedly(t *testing.T) {
+	report := runScenario(t, "outage 30m", Options{RebootDuration: time.Minute})
+
+	// Each cycle: 3 failed checks (90s) then a 5m recovery wait
+	if report.Reboots < 4 {
+		t.Errorf("Expected repeated reboots during a 30m outage, got %d", report.Reboots)
+	}
+	if report.FailedChecks != report.Checks {
+		t.Errorf("Expected every check to fail, %d of %d failed", report.FailedChecks, report.Checks)
+	}
+}
+
+func TestModemUnreachableCannotReboot(t *testing.T) {
+	report := runScenario(t, "healthy 2m, modem unreachable 10m", Options{})
+
+	if report.Reboots != 0 {
+		t.Errorf("Expected no reboots of an unreachable modem, got %d", report.Reboots)
+	}
+	if countEvents(report, EventCheckError) == 0 {
+		t.Error("Expected failed reboot attempts to be reported as check errors")
+	}
+}
+
+func TestDegradedScenarioIsReproducible(t *testing.T) {
+	input := "degraded 30m 40%"
+	first := runScenario(t, input, Options{Seed: 42})
+	second := runScenario(t, input, Options{Seed: 42})
+
+	if first.FailedChecks == 0 || first.FailedChecks == first.Checks {
+		t.Errorf("Expected some but not all checks to fail, %d of %d failed", first.FailedChecks, first.Checks)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Error("Expected runs with the same seed to produce the same report")
+	}
+}
+
+func TestRunHonoursContext(t *testing.T) {
+	scenario, _ := ParseScenario(strings.NewReader("healthy 1h"))
+	sim, err := New(testConfig(), scenario, Options{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := sim.Run(ctx); err == nil {
+		t.Error("Expected a cancelled context to stop the simulation")
+	}
+}
@@ -0,0 +1,214 @@
+package sms
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+// Characters in a single text message: 160 in the GSM 7-bit alphabet, 70
+// in UCS-2 for anything else. The GSM modem sends single messages only.
+const (
+	gsmMaxLength  = 160
+	ucs2MaxLength = 70
+)
+
+// ctrlZ ends the text of AT+CMGS
+const ctrlZ = "\x1a"
+
+// GSMSender sends messages through a GSM modem, such as a USB LTE dongle,
+// with AT commands on its serial port
+type GSMSender struct {
+	device  string
+	timeout time.Duration
+	// open opens the serial port; tests replace it with a scripted modem
+	open func(device string) (io.ReadWriteCloser, error)
+	// mu keeps concurrent messages from interleaving their commands
+	mu sync.Mutex
+}
+
+// NewGSMSender creates a sender using the modem on the serial port device
+func NewGSMSender(device string) *GSMSender {
+	return &GSMSender{device: device, timeout: time.Minute, open: openSerial}
+}
+
+// Name identifies the sender in logs
+func (s *GSMSender) Name() string {
+	return ProviderGSM
+}
+
+// MaxLength returns the characters one message carries: 160 for ASCII
+// text, 70 for text that needs UCS-2
+func (s *GSMSender) MaxLength(text string) int {
+	if isASCII(text) {
+		return gsmMaxLength
+	}
+	return ucs2MaxLength
+}
+
+// Send texts to through the modem in text mode. A modem that stops answering
+// fails the send after the timeout.
+func (s *GSMSender) Send(ctx context.Context, to, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	port, err := s.open(s.device)
+	if err != nil {
+		return fmt.Errorf("failed to open GSM modem %s: %w", s.device, err)
+	}
+	defer port.Close()
+
+	// Closing the port ends a read the modem never answers
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			port.Close()
+		case <-done:
+		}
+	}()
+
+	session := &atSession{w: port, r: bufio.NewReader(port)}
+	if err := session.sendSMS(to, text); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("GSM modem %s did not answer: %w", s.device, ctx.Err())
+		}
+		return err
+	}
+	return nil
+}
+
+// atSession exchanges AT commands with a modem
+type atSession struct {
+	w io.Writer
+	r *bufio.Reader
+}
+
+// sendSMS texts to in text mode: ASCII text in the IRA character set, other
+// text, and then the number too, as UCS-2 hex
+func (a *atSession) sendSMS(to, text string) error {
+	text = sanitize(text)
+	commands := []string{"AT", "ATE0", "AT+CMGF=1"}
+	if isASCII(text) {
+		commands = append(commands, `AT+CSCS="IRA"`, "AT+CSMP=17,167,0,0")
+	} else {
+		commands = append(commands, `AT+CSCS="UCS2"`, "AT+CSMP=17,167,0,8")
+		to, text = ucs2Hex(to), ucs2Hex(text)
+	}
+	for _, command := range commands {
+		if err := a.command(command); err != nil {
+			return err
+		}
+	}
+
+	if err := a.write(fmt.Sprintf("AT+CMGS=%q\r", to)); err != nil {
+		return err
+	}
+	if err := a.prompt("AT+CMGS"); err != nil {
+		return err
+	}
+	if err := a.write(text + ctrlZ); err != nil {
+		return err
+	}
+	return a.result("AT+CMGS")
+}
+
+// command sends an AT command and waits for its final result
+func (a *atSession) command(command string) error {
+	if err := a.write(command + "\r"); err != nil {
+		return err
+	}
+	return a.result(command)
+}
+
+func (a *atSession) write(data string) error {
+	if _, err := io.WriteString(a.w, data); err != nil {
+		return fmt.Errorf("failed to write to GSM modem: %w", err)
+	}
+	return nil
+}
+
+// result reads lines up to the final result of command, skipping the echo
+// and intermediate results such as +CMGS: 12
+func (a *atSession) result(command string) error {
+	for {
+		line, err := a.r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("%s: failed to read from GSM modem: %w", command, err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "OK" {
+			return nil
+		}
+		if isATError(line) {
+			return fmt.Errorf("%s failed: %s", command, line)
+		}
+	}
+}
+
+// prompt waits for the > prompt that asks for the text of a message
+func (a *atSession) prompt(command string) error {
+	var line strings.Builder
+	for {
+		b, err := a.r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("%s: failed to read from GSM modem: %w", command, err)
+		}
+		switch b {
+		case '>':
+			return nil
+		case '\n':
+			if text := strings.TrimSpace(line.String()); isATError(text) {
+				return fmt.Errorf("%s failed: %s", command, text)
+			}
+			line.Reset()
+		default:
+			line.WriteByte(b)
+		}
+	}
+}
+
+// isATError reports whether line is a final error result
+func isATError(line string) bool {
+	return line == "ERROR" || strings.HasPrefix(line, "+CME ERROR") || strings.HasPrefix(line, "+CMS ERROR")
+}
+
+// isASCII reports whether text needs no UCS-2
+func isASCII(text string) bool {
+	for _, r := range text {
+		if r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitize drops control characters other than newlines, which would end
+// the text or escape it early
+func sanitize(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' && r != '\n' {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// ucs2Hex encodes text as the hex UTF-16 code units the UCS2 character set
+// expects
+func ucs2Hex(text string) string {
+	var b strings.Builder
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package sms
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// openSerial opens the serial port device in raw mode at 115200 baud; USB
+// modems ignore the speed
+func openSerial(device string) (io.ReadWriteCloser, error) {
+	// Non-blocking, so closing the port ends a pending read
+	port, err := os.OpenFile(device, os.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := port.SyscallConn()
+	if err != nil {
+		port.Close()
+		return nil, err
+	}
+	var termErr error
+	if err := conn.Control(func(fd uintptr) {
+		termErr = makeRaw(int(fd))
+	}); err != nil {
+		termErr = err
+	}
+	if termErr != nil {
+		port.Close()
+		return nil, fmt.Errorf("failed to configure %s: %w", device, termErr)
+	}
+	return port, nil
+}
+
+// makeRaw turns off line editing, echo and translation on the terminal fd
+func makeRaw(fd int) error {
+	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return err
+	}
+	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	t.Oflag &^= unix.OPOST
+	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	t.Cflag &^= unix.CSIZE | unix.PARENB | unix.CBAUD
+	t.Cflag |= unix.CS8 | unix.CREAD | unix.CLOCAL | unix.B115200
+	t.Ispeed = unix.B115200
+	t.Ospeed = unix.B115200
+	t.Cc[unix.VMIN] = 1
+	t.Cc[unix.VTIME] = 0
+	return unix.IoctlSetTermios(fd, unix.TCSETS, t)
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package sms
+
+import (
+	"io"
+	"os"
+)
+
+// openSerial opens the serial port device with its current settings; set
+// them with stty beforehand
+func openSerial(device string) (io.ReadWriteCloser, error) {
+	return os.OpenFile(device, os.O_RDWR, 0)
+}
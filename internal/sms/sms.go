@@ -0,0 +1,112 @@
+// Package sms delivers notifications as text messages, through the Twilio
+// API or a GSM modem on a serial port driven with AT commands. With a
+// cellular modem on the host, SMS still works while the cable connection the
+// watchdog monitors is down.
+package sms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+)
+
+// SMS providers
+const (
+	ProviderTwilio = "twilio"
+	ProviderGSM    = "gsm"
+)
+
+// Providers lists every SMS provider
+var Providers = []string{ProviderTwilio, ProviderGSM}
+
+// Config selects and configures an SMS provider
+type Config struct {
+	Provider string
+	// To are the recipients' phone numbers in international format, such
+	// as +15551234567
+	To []string
+	// AccountSID and AuthToken authenticate with Twilio
+	AccountSID string
+	AuthToken  string
+	// From is the Twilio phone number or messaging service SID to send from
+	From string
+	// Device is the serial port of the GSM modem for ProviderGSM, such as
+	// /dev/ttyUSB2
+	Device string
+}
+
+// Sender sends a text message to one phone number
+type Sender interface {
+	// Name identifies the provider in logs
+	Name() string
+	Send(ctx context.Context, to, text string) error
+	// MaxLength is the longest text a single Send delivers, in characters
+	MaxLength(text string) int
+}
+
+// NewSender creates the sender of the configured provider; client reaches
+// the Twilio API
+func NewSender(cfg Config, client *http.Client) (Sender, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	switch cfg.Provider {
+	case ProviderTwilio:
+		if cfg.AccountSID == "" || cfg.AuthToken == "" || cfg.From == "" {
+			return nil, fmt.Errorf("twilio needs an account SID, auth token and sender")
+		}
+		return &TwilioSender{baseURL: twilioAPI, client: client, accountSID: cfg.AccountSID, authToken: cfg.AuthToken, from: cfg.From}, nil
+	case ProviderGSM:
+		if cfg.Device == "" {
+			return nil, fmt.Errorf("gsm needs the modem's serial device")
+		}
+		return NewGSMSender(cfg.Device), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q (expected %s)", cfg.Provider, strings.Join(Providers, ", "))
+	}
+}
+
+// Notifier texts notifications to every recipient
+type Notifier struct {
+	sender     Sender
+	recipients []string
+}
+
+// NewNotifier creates a notifier texting recipients through sender
+func NewNotifier(sender Sender, recipients []string) *Notifier {
+	return &Notifier{sender: sender, recipients: recipients}
+}
+
+// Notify sends the notification's title and body, shortened to what one
+// message carries, to every recipient. It returns the first failure but
+// still tries the other recipients.
+func (n *Notifier) Notify(ctx context.Context, notification notify.Notification) error {
+	text := notification.Title
+	if notification.Body != "" {
+		text += "\n" + notification.Body
+	}
+	text = truncate(text, n.sender.MaxLength(text))
+
+	var firstErr error
+	for _, to := range n.recipients {
+		if err := n.sender.Send(ctx, to, text); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to text %s via %s: %w", to, n.sender.Name(), err)
+		}
+	}
+	return firstErr
+}
+
+// truncate shortens text to at most max characters, ending it with an
+// ellipsis when it is cut
+func truncate(text string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:max-3])) + "..."
+}
@@ -0,0 +1,197 @@
+package sms
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+)
+
+func TestNewSender(t *testing.T) {
+	tests := []struct {
+		cfg     Config
+		want    string
+		wantErr bool
+	}{
+		{Config{Provider: ProviderTwilio, AccountSID: "AC1", AuthToken: "t", From: "+15550001111"}, ProviderTwilio, false},
+		{Config{Provider: ProviderTwilio, AccountSID: "AC1"}, "", true},
+		{Config{Provider: ProviderGSM, Device: "/dev/ttyUSB2"}, ProviderGSM, false},
+		{Config{Provider: ProviderGSM}, "", true},
+		{Config{Provider: "carrier-pigeon"}, "", true},
+	}
+	for _, tt := range tests {
+		sender, err := NewSender(tt.cfg, nil)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewSender(%+v) error = %v, wantErr %v", tt.cfg, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && sender.Name() != tt.want {
+			t.Errorf("NewSender(%+v) = %s, want %s", tt.cfg, sender.Name(), tt.want)
+		}
+	}
+}
+
+func TestTwilioSender(t *testing.T) {
+	var form map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "AC1" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code": 20003, "message": "Authenticate"}`))
+			return
+		}
+		if r.URL.Path != "/Accounts/AC1/Messages.json" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		r.ParseForm()
+		form = map[string]string{"To": r.PostForm.Get("To"), "From": r.PostForm.Get("From"), "Body": r.PostForm.Get("Body")}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid": "SM1", "status": "queued"}`))
+	}))
+	defer server.Close()
+
+	sender := &TwilioSender{baseURL: server.URL, client: server.Client(), accountSID: "AC1", authToken: "secret", from: "+15550001111"}
+	if err := sender.Send(context.Background(), "+15552223333", "Internet connectivity lost"); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+	if form["To"] != "+15552223333" || form["From"] != "+15550001111" || form["Body"] != "Internet connectivity lost" {
+		t.Errorf("Unexpected message %v", form)
+	}
+
+	sender.authToken = "wrong"
+	if err := sender.Send(context.Background(), "+15552223333", "test"); err == nil || !strings.Contains(err.Error(), "Authenticate") {
+		t.Errorf("Expected Twilio's error message, got %v", err)
+	}
+}
+
+// fakeModem answers AT commands on one end of a pipe like a GSM modem in
+// text mode; commands starting with fail get an error
+type fakeModem struct {
+	fail     string
+	silent   bool
+	commands []string
+	text     string
+}
+
+func (m *fakeModem) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		command, err := reader.ReadString('\r')
+		if err != nil {
+			return
+		}
+		command = strings.TrimSpace(command)
+		m.commands = append(m.commands, command)
+		if m.silent {
+			continue
+		}
+		if m.fail != "" && strings.HasPrefix(command, m.fail) {
+			io.WriteString(conn, "\r\n+CMS ERROR: 500\r\n")
+			continue
+		}
+		if !strings.HasPrefix(command, "AT+CMGS=") {
+			io.WriteString(conn, command+"\r\r\nOK\r\n")
+			continue
+		}
+		io.WriteString(conn, "\r\n> ")
+		text, err := reader.ReadString(0x1a)
+		if err != nil {
+			return
+		}
+		m.text = strings.TrimSuffix(text, "\x1a")
+		io.WriteString(conn, "\r\n+CMGS: 12\r\n\r\nOK\r\n")
+	}
+}
+
+// newFakeGSMSender returns a sender talking to modem
+func newFakeGSMSender(modem *fakeModem) *GSMSender {
+	sender := NewGSMSender("/dev/ttyUSB2")
+	sender.open = func(device string) (io.ReadWriteCloser, error) {
+		client, server := net.Pipe()
+		go modem.serve(server)
+		return client, nil
+	}
+	return sender
+}
+
+func TestGSMSender(t *testing.T) {
+	modem := &fakeModem{}
+	sender := newFakeGSMSender(modem)
+	if err := sender.Send(context.Background(), "+15552223333", "Internet connectivity lost\nChecks failed"); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+	if got := modem.commands[len(modem.commands)-1]; got != `AT+CMGS="+15552223333"` {
+		t.Errorf("Unexpected send command %s", got)
+	}
+	if modem.text != "Internet connectivity lost\nChecks failed" {
+		t.Errorf("Unexpected text %q", modem.text)
+	}
+
+	// Text outside ASCII, and the number, go as UCS-2 hex
+	modem = &fakeModem{}
+	sender = newFakeGSMSender(modem)
+	if err := sender.Send(context.Background(), "+34", "Módem"); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+	if modem.text != "004D00F300640065006D" || modem.commands[len(modem.commands)-1] != `AT+CMGS="002B00330034"` {
+		t.Errorf("Expected UCS-2 hex, got %q and %v", modem.text, modem.commands)
+	}
+	if sender.MaxLength("Módem") != ucs2MaxLength || sender.MaxLength("Modem") != gsmMaxLength {
+		t.Error("Expected UCS-2 text to fit fewer characters")
+	}
+}
+
+func TestGSMSenderErrors(t *testing.T) {
+	sender := newFakeGSMSender(&fakeModem{fail: "AT+CMGS"})
+	if err := sender.Send(context.Background(), "+15552223333", "test"); err == nil || !strings.Contains(err.Error(), "+CMS ERROR: 500") {
+		t.Errorf("Expected the modem's error, got %v", err)
+	}
+
+	sender = newFakeGSMSender(&fakeModem{silent: true})
+	sender.timeout = 50 * time.Millisecond
+	if err := sender.Send(context.Background(), "+15552223333", "test"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a silent modem to time out, got %v", err)
+	}
+}
+
+// recordingSender records what it sends
+type recordingSender struct {
+	sent []string
+	err  error
+}
+
+func (s *recordingSender) Name() string              { return "recording" }
+func (s *recordingSender) MaxLength(text string) int { return 40 }
+func (s *recordingSender) Send(ctx context.Context, to, text string) error {
+	s.sent = append(s.sent, to+": "+text)
+	return s.err
+}
+
+func TestNotifier(t *testing.T) {
+	sender := &recordingSender{}
+	notifier := NewNotifier(sender, []string{"+1555", "+1666"})
+	err := notifier.Notify(context.Background(), notify.Notification{
+		Title: "Rebooting modem",
+		Body:  "3 consecutive connectivity checks failed.",
+	})
+	if err != nil {
+		t.Fatalf("Notify() failed: %v", err)
+	}
+	want := "+1555: Rebooting modem\n3 consecutive connect..."
+	if len(sender.sent) != 2 || sender.sent[0] != want {
+		t.Errorf("Expected the shortened message to every recipient, got %q", sender.sent)
+	}
+
+	sender.err = errors.New("no signal")
+	if err := notifier.Notify(context.Background(), notify.Notification{Title: "test"}); err == nil || len(sender.sent) != 4 {
+		t.Errorf("Expected a failure after trying every recipient, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const twilioAPI = "https://api.twilio.com/2010-04-01"
+
+// twilioMaxLength is the longest message Twilio accepts; longer ones are
+// split into segments and reassembled on the phone
+const twilioMaxLength = 1600
+
+// TwilioSender sends messages with the Twilio Messaging API
+type TwilioSender struct {
+	baseURL    string
+	client     *http.Client
+	accountSID string
+	authToken  string
+	// from is a phone number, or a messaging service SID starting with MG
+	from string
+}
+
+// Name identifies the sender in logs
+func (s *TwilioSender) Name() string {
+	return ProviderTwilio
+}
+
+// MaxLength returns the longest message Twilio accepts
+func (s *TwilioSender) MaxLength(text string) int {
+	return twilioMaxLength
+}
+
+// Send creates a message to to; Twilio queues it and answers 201 Created
+func (s *TwilioSender) Send(ctx context.Context, to, text string) error {
+	form := url.Values{"To": {to}, "Body": {text}}
+	if strings.HasPrefix(s.from, "MG") {
+		form.Set("MessagingServiceSid", s.from)
+	} else {
+		form.Set("From", s.from)
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", s.baseURL, url.PathEscape(s.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create twilio request: %w", err)
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach twilio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	var failure struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&failure); err != nil || failure.Message == "" {
+		return fmt.Errorf("twilio request failed: %s", resp.Status)
+	}
+	return fmt.Errorf("twilio request failed: %s (error %d)", failure.Message, failure.Code)
+}
@@ -0,0 +1,214 @@
+package system
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// capNetRaw is the bit of CAP_NET_RAW in the capability sets of
+// /proc/self/status
+const capNetRaw = 13
+
+// Ping methods, from the most to the least capable
+const (
+	// PingMethodNative sends ICMP echo requests from the watchdog itself
+	PingMethodNative = "icmp"
+	// PingMethodCommand runs the ping binary, which may be setuid or carry
+	// file capabilities of its own
+	PingMethodCommand = "command"
+	// PingMethodTCP opens TCP connections instead; it proves reachability
+	// but yields no packet loss or latency statistics
+	PingMethodTCP = "tcp"
+)
+
+// capabilityCommands are the external binaries the diagnostics can fall
+// back to
+var capabilityCommands = []string{"ip", "arp", "ping", "iw", "ethtool"}
+
+// Capabilities are the privileges and kernel interfaces available to the
+// watchdog, which decide the implementation of each network check
+type Capabilities struct {
+	// NetRaw is CAP_NET_RAW in the effective set
+	NetRaw bool `json:"cap_net_raw"`
+	// ICMPDatagram means unprivileged ICMP sockets are allowed by
+	// net.ipv4.ping_group_range
+	ICMPDatagram bool `json:"icmp_datagram"`
+	// ICMPRaw means raw ICMP sockets can be opened
+	ICMPRaw bool `json:"icmp_raw"`
+	// Netlink means interfaces and addresses can be listed
+	Netlink bool `json:"netlink"`
+	// ProcNet means the routing and ARP tables in /proc/net are readable
+	ProcNet bool `json:"proc_net"`
+	// SysClassNet means interface attributes in /sys/class/net are readable
+	SysClassNet bool `json:"sys_class_net"`
+	// Commands lists which external binaries are installed
+	Commands map[string]bool `json:"commands"`
+}
+
+// Degradation is a check that runs with a weaker implementation, or not at
+// all, because the host lacks a capability
+type Degradation struct {
+	Feature  string `json:"feature"`
+	Reason   string `json:"reason"`
+	Fallback string `json:"fallback"`
+}
+
+// DetectCapabilities probes the privileges and kernel interfaces available
+// to the process
+func DetectCapabilities() Capabilities {
+	caps := Capabilities{
+		NetRaw:   hasEffectiveCapability(capNetRaw),
+		Commands: make(map[string]bool),
+	}
+
+	if fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.IPPROTO_ICMP); err == nil {
+		syscall.Close(fd)
+		caps.ICMPDatagram = true
+	}
+	if fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.IPPROTO_ICMP); err == nil {
+		syscall.Close(fd)
+		caps.ICMPRaw = true
+	}
+
+	_, err := net.Interfaces()
+	caps.Netlink = err == nil
+	caps.ProcNet = readable(filepath.Join(procNet, "route")) && readable(filepath.Join(procNet, "arp"))
+	_, err = os.ReadDir(sysClassNet)
+	caps.SysClassNet = err == nil
+
+	for _, command := range capabilityCommands {
+		_, err := exec.LookPath(command)
+		caps.Commands[command] = err == nil
+	}
+	return caps
+}
+
+// PingMethod returns the best ping implementation the capabilities allow
+func (c Capabilities) PingMethod() string {
+	switch {
+	case c.ICMPDatagram || c.ICMPRaw:
+		return PingMethodNative
+	case c.Commands["ping"]:
+		return PingMethodCommand
+	default:
+		return PingMethodTCP
+	}
+}
+
+// Degradations lists the checks that cannot use their preferred
+// implementation, with the reason and what runs instead
+func (c Capabilities) Degradations() []Degradation {
+	var degradations []Degradation
+
+	switch c.PingMethod() {
+	case PingMethodCommand:
+		degradations = append(degradations, Degradation{
+			Feature:  "ping",
+			Reason:   "no ICMP socket: needs CAP_NET_RAW or a net.ipv4.ping_group_range including this group",
+			Fallback: "running the ping command",
+		})
+	case PingMethodTCP:
+		degradations = append(degradations, Degradation{
+			Feature:  "ping",
+			Reason:   "no ICMP socket and no ping command",
+			Fallback: "TCP connections to ports 443 and 80, without packet loss or latency",
+		})
+	}
+
+	if !c.Netlink {
+		degradations = append(degradations, Degradation{
+			Feature:  "interfaces and addresses",
+			Reason:   "netlink is not available",
+			Fallback: c.commandFallback("ip"),
+		})
+	}
+	if !c.ProcNet {
+		degradations = append(degradations, Degradation{
+			Feature:  "routing and ARP tables",
+			Reason:   "/proc/net is not readable",
+			Fallback: c.commandFallback("ip"),
+		})
+	}
+
+	if !c.SysClassNet {
+		degradations = append(degradations, Degradation{
+			Feature:  "Wi-Fi and Ethernet link checks",
+			Reason:   "/sys/class/net is not readable",
+			Fallback: "skipped",
+		})
+	} else if !c.Commands["iw"] {
+		degradations = append(degradations, Degradation{
+			Feature:  "Wi-Fi link check",
+			Reason:   "the iw command is not installed",
+			Fallback: "skipped on wireless hosts",
+		})
+	}
+
+	return degradations
+}
+
+// commandFallback describes falling back to command, or to nothing when it
+// is not installed
+func (c Capabilities) commandFallback(command string) string {
+	if c.Commands[command] {
+		return "running the " + command + " command"
+	}
+	return "unavailable, the " + command + " command is not installed either"
+}
+
+// SetCapabilities restricts the network commands to implementations the
+// host supports, so unusable ones are not attempted on every call
+func (nc *NetworkCommands) SetCapabilities(caps Capabilities) {
+	nc.capabilities = &caps
+}
+
+// hasCapability reports whether check holds for the detected capabilities;
+// everything is attempted until capabilities are set
+func (nc *NetworkCommands) hasCapability(check func(Capabilities) bool) bool {
+	return nc.capabilities == nil || check(*nc.capabilities)
+}
+
+// hasEffectiveCapability reports whether the process has capability bit in
+// its effective set
+func hasEffectiveCapability(bit uint) bool {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value, ok := cutPrefix(scanner.Text(), "CapEff:")
+		if !ok {
+			continue
+		}
+		mask, err := strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		return err == nil && mask&(1<<bit) != 0
+	}
+	return false
+}
+
+// cutPrefix returns s without prefix and whether it was present
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+// readable reports whether path can be opened for reading
+func readable(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	file.Close()
+	return true
+}
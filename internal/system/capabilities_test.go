@@ -0,0 +1,94 @@
+package system
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestPingMethod(t *testing.T) {
+	tests := []struct {
+		name string
+		caps Capabilities
+		want string
+	}{
+		{"unprivileged ICMP", Capabilities{ICMPDatagram: true}, PingMethodNative},
+		{"raw ICMP", Capabilities{ICMPRaw: true}, PingMethodNative},
+		{"ping command", Capabilities{Commands: map[string]bool{"ping": true}}, PingMethodCommand},
+		{"nothing", Capabilities{}, PingMethodTCP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.caps.PingMethod(); got != tt.want {
+				t.Errorf("Expected ping method %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDegradations(t *testing.T) {
+	full := Capabilities{
+		ICMPDatagram: true,
+		Netlink:      true,
+		ProcNet:      true,
+		SysClassNet:  true,
+		Commands:     map[string]bool{"ip": true, "iw": true},
+	}
+	if degradations := full.Degradations(); len(degradations) != 0 {
+		t.Errorf("Expected no degradations, got %+v", degradations)
+	}
+
+	bare := Capabilities{Netlink: true, SysClassNet: true, Commands: map[string]bool{"ip": true}}
+	features := make(map[string]Degradation)
+	for _, degradation := range bare.Degradations() {
+		features[degradation.Feature] = degradation
+	}
+	if ping, ok := features["ping"]; !ok || !strings.Contains(ping.Fallback, "TCP") {
+		t.Errorf("Expected ping to fall back to TCP, got %+v", ping)
+	}
+	if tables, ok := features["routing and ARP tables"]; !ok || tables.Fallback != "running the ip command" {
+		t.Errorf("Expected routing tables to fall back to ip, got %+v", tables)
+	}
+	if _, ok := features["Wi-Fi link check"]; !ok {
+		t.Error("Expected the Wi-Fi link check to be degraded without iw")
+	}
+	if _, ok := features["interfaces and addresses"]; ok {
+		t.Error("Expected interfaces not to be degraded with netlink")
+	}
+}
+
+func TestDetectCapabilities(t *testing.T) {
+	caps := DetectCapabilities()
+	for _, command := range capabilityCommands {
+		if _, ok := caps.Commands[command]; !ok {
+			t.Errorf("Expected command %s to be probed", command)
+		}
+	}
+	if caps.NetRaw && !caps.ICMPRaw {
+		t.Log("CAP_NET_RAW present but raw ICMP sockets unavailable, e.g. under seccomp")
+	}
+}
+
+func TestNetworkCommandsRespectCapabilities(t *testing.T) {
+	writeProcNet(t, map[string]string{"route": `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+fixture0	00000000	0164A8C0	0003	0	0	100	00000000	0	0	0
+`})
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	nc := NewNetworkCommands(NewExecutor(logger))
+
+	result, err := nc.GetRoutingTable(context.Background())
+	if err != nil || !strings.Contains(result.Output, "fixture0") {
+		t.Fatalf("Expected the native routing table, got %v %+v", err, result)
+	}
+
+	nc.SetCapabilities(Capabilities{Netlink: true})
+	result, err = nc.GetRoutingTable(context.Background())
+	if err == nil && strings.Contains(result.Output, "fixture0") {
+		t.Error("Expected the native routing table to be skipped without /proc/net access")
+	}
+}
@@ -0,0 +1,87 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LinkSettings are the negotiated speed and duplex of an Ethernet interface
+type LinkSettings struct {
+	Interface string `json:"interface"`
+	// SpeedMbps is 0 when the speed is unknown, e.g. while the link is down
+	SpeedMbps int    `json:"speed_mbps"`
+	Duplex    string `json:"duplex"`
+}
+
+// ReadLinkSettings reads the negotiated speed and duplex of iface from sysfs,
+// the same values ethtool reports
+func ReadLinkSettings(iface string) (LinkSettings, error) {
+	settings := LinkSettings{Interface: iface}
+	if iface == "" || strings.ContainsAny(iface, "/.") {
+		return settings, fmt.Errorf("invalid interface name %q", iface)
+	}
+
+	speed, err := os.ReadFile(filepath.Join(sysClassNet, iface, "speed"))
+	if err != nil {
+		return settings, fmt.Errorf("failed to read link speed of %s: %w", iface, err)
+	}
+	// Down links report -1 or fail the read with EINVAL
+	if n, err := strconv.Atoi(strings.TrimSpace(string(speed))); err == nil && n > 0 {
+		settings.SpeedMbps = n
+	}
+
+	duplex, err := os.ReadFile(filepath.Join(sysClassNet, iface, "duplex"))
+	if err != nil {
+		return settings, fmt.Errorf("failed to read duplex of %s: %w", iface, err)
+	}
+	settings.Duplex = strings.ToLower(strings.TrimSpace(string(duplex)))
+	return settings, nil
+}
+
+// GetEthtool gets the link settings of an interface using ethtool
+func (nc *NetworkCommands) GetEthtool(ctx context.Context, iface string) (*CommandResult, error) {
+	return nc.executor.ExecuteWithContext(ctx, "ethtool", iface)
+}
+
+// ParseEthtool parses the speed and duplex from 'ethtool <interface>' output
+func (p *Parser) ParseEthtool(output string) (LinkSettings, error) {
+	var settings LinkSettings
+	found := false
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Settings for ") {
+			settings.Interface = strings.TrimSuffix(strings.TrimPrefix(trimmed, "Settings for "), ":")
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Speed":
+			found = true
+			// "1000Mb/s", or "Unknown!" while the link is down
+			if n, err := strconv.Atoi(strings.TrimSuffix(value, "Mb/s")); err == nil && n > 0 {
+				settings.SpeedMbps = n
+			}
+		case "Duplex":
+			found = true
+			// "Full", "Half", or "Unknown! (255)" while the link is down
+			if fields := strings.Fields(value); len(fields) > 0 {
+				settings.Duplex = strings.ToLower(strings.TrimSuffix(fields[0], "!"))
+			}
+		}
+	}
+
+	if !found {
+		return settings, fmt.Errorf("no link settings in ethtool output")
+	}
+	return settings, nil
+}
@@ -0,0 +1,78 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadLinkSettings(t *testing.T) {
+	root := t.TempDir()
+	original := sysClassNet
+	sysClassNet = root
+	defer func() { sysClassNet = original }()
+
+	write := func(iface, speed, duplex string) {
+		dir := filepath.Join(root, iface)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create fixture: %v", err)
+		}
+		os.WriteFile(filepath.Join(dir, "speed"), []byte(speed+"\n"), 0644)
+		os.WriteFile(filepath.Join(dir, "duplex"), []byte(duplex+"\n"), 0644)
+	}
+	write("eth0", "100", "half")
+	write("eth1", "-1", "unknown")
+
+	settings, err := ReadLinkSettings("eth0")
+	if err != nil {
+		t.Fatalf("ReadLinkSettings failed: %v", err)
+	}
+	if settings != (LinkSettings{Interface: "eth0", SpeedMbps: 100, Duplex: "half"}) {
+		t.Errorf("Unexpected settings %+v", settings)
+	}
+
+	settings, err = ReadLinkSettings("eth1")
+	if err != nil || settings.SpeedMbps != 0 || settings.Duplex != "unknown" {
+		t.Errorf("Expected an unknown speed for a down link, got %+v, %v", settings, err)
+	}
+
+	if _, err := ReadLinkSettings("eth2"); err == nil {
+		t.Error("Expected an error for a missing interface")
+	}
+	if _, err := ReadLinkSettings("../eth0"); err == nil {
+		t.Error("Expected an error for an invalid interface name")
+	}
+}
+
+func TestParseEthtool(t *testing.T) {
+	parser := NewParser("linux")
+
+	output := `Settings for enp3s0:
+	Supported ports: [ TP ]
+	Supported link modes:   10baseT/Half 10baseT/Full
+	                        100baseT/Half 100baseT/Full
+	                        1000baseT/Full
+	Advertised auto-negotiation: Yes
+	Speed: 100Mb/s
+	Duplex: Half
+	Auto-negotiation: on
+	Port: Twisted Pair
+	Link detected: yes`
+
+	settings, err := parser.ParseEthtool(output)
+	if err != nil {
+		t.Fatalf("ParseEthtool failed: %v", err)
+	}
+	if settings != (LinkSettings{Interface: "enp3s0", SpeedMbps: 100, Duplex: "half"}) {
+		t.Errorf("Unexpected settings %+v", settings)
+	}
+
+	down, err := parser.ParseEthtool("Settings for eth0:\n\tSpeed: Unknown!\n\tDuplex: Unknown! (255)\n\tLink detected: no")
+	if err != nil || down.SpeedMbps != 0 || down.Duplex != "unknown" {
+		t.Errorf("Expected an unknown speed for a down link, got %+v, %v", down, err)
+	}
+
+	if _, err := parser.ParseEthtool("command not found"); err == nil {
+		t.Error("Expected an error for output without link settings")
+	}
+}
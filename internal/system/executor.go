@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os/exec"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -146,37 +147,87 @@ func (e *Executor) createCommand(ctx context.Context, command string, args ...st
 // NetworkCommands provides Linux-specific network command implementations
 type NetworkCommands struct {
 	executor *Executor
+	// ipJSONUnsupported is set once 'ip -json' failed, e.g. with busybox ip
+	// or iproute2 before 4.13, so later calls go straight to text output
+	ipJSONUnsupported int32
+	// native selects the pure-Go implementations in native.go
+	native bool
+	// capabilities are set once detected; nil means unknown
+	capabilities *Capabilities
 }
 
 // NewNetworkCommands creates a new Linux network commands handler
 func NewNetworkCommands(executor *Executor) *NetworkCommands {
 	return &NetworkCommands{
 		executor: executor,
+		native:   true,
 	}
 }
 
+// ip runs an ip subcommand, preferring 'ip -json' and falling back to text
+// output where JSON is not supported
+func (nc *NetworkCommands) ip(ctx context.Context, args ...string) (*CommandResult, error) {
+	if atomic.LoadInt32(&nc.ipJSONUnsupported) == 0 {
+		result, err := nc.executor.ExecuteWithContext(ctx, "ip", append([]string{"-json"}, args...)...)
+		if err != nil || result.Success {
+			return result, err
+		}
+		if ctx.Err() != nil {
+			return result, nil
+		}
+		nc.executor.logger.Info("ip does not support -json, using text output")
+		atomic.StoreInt32(&nc.ipJSONUnsupported, 1)
+	}
+	return nc.executor.ExecuteWithContext(ctx, "ip", args...)
+}
+
 // GetInterfaceStatus gets network interface status using Linux commands
 func (nc *NetworkCommands) GetInterfaceStatus(ctx context.Context) (*CommandResult, error) {
-	return nc.executor.ExecuteWithContext(ctx, "ip", "link", "show")
+	if result, ok := nc.runNative([]string{"link", "show"}, needsNetlink, nativeInterfaceStatus); ok {
+		return result, nil
+	}
+	return nc.ip(ctx, "link", "show")
 }
 
-// GetARPTable gets the ARP table using Linux commands
+// GetARPTable gets the ARP table using Linux commands. 'ip neigh' works on
+// OpenWrt, where busybox is often built without arp; 'arp -a' remains the
+// fallback for busybox ip builds without neigh support.
 func (nc *NetworkCommands) GetARPTable(ctx context.Context) (*CommandResult, error) {
+	if result, ok := nc.runNative([]string{"neigh", "show"}, needsProcNet, nativeARPTable); ok {
+		return result, nil
+	}
+	result, err := nc.ip(ctx, "neigh", "show")
+	if err != nil || result.Success {
+		return result, err
+	}
 	return nc.executor.ExecuteWithContext(ctx, "arp", "-a")
 }
 
 // GetIPConfiguration gets IP address configuration using Linux commands
 func (nc *NetworkCommands) GetIPConfiguration(ctx context.Context) (*CommandResult, error) {
-	return nc.executor.ExecuteWithContext(ctx, "ip", "addr", "show")
+	if result, ok := nc.runNative([]string{"addr", "show"}, needsNetlink, nativeIPConfiguration); ok {
+		return result, nil
+	}
+	return nc.ip(ctx, "addr", "show")
 }
 
 // GetRoutingTable gets the routing table using Linux commands
 func (nc *NetworkCommands) GetRoutingTable(ctx context.Context) (*CommandResult, error) {
-	return nc.executor.ExecuteWithContext(ctx, "ip", "route", "show")
+	if result, ok := nc.runNative([]string{"route", "show"}, needsProcNet, nativeRoutingTable); ok {
+		return result, nil
+	}
+	return nc.ip(ctx, "route", "show")
 }
 
 // Ping performs a ping test using Linux commands
 func (nc *NetworkCommands) Ping(ctx context.Context, host string, count int, timeout int) (*CommandResult, error) {
+	if nc.native && nc.executor.platform == "linux" && nc.hasCapability(needsICMP) {
+		result, err := nativePing(ctx, host, count, timeout)
+		if err == nil {
+			return result, nil
+		}
+		nc.executor.logger.WithError(err).Debug("Native ping unavailable, running ping instead")
+	}
 	return nc.executor.ExecuteWithContext(ctx, "ping", "-c", fmt.Sprintf("%d", count), "-W", fmt.Sprintf("%d", timeout), host)
 }
 
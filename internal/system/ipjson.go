@@ -0,0 +1,205 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ipLinkJSON is one interface in 'ip -json link show' output
+type ipLinkJSON struct {
+	IfName    string   `json:"ifname"`
+	Flags     []string `json:"flags"`
+	MTU       int      `json:"mtu"`
+	OperState string   `json:"operstate"`
+	Address   string   `json:"address"`
+}
+
+// ipAddrJSON is one interface in 'ip -json addr show' output
+type ipAddrJSON struct {
+	IfName   string           `json:"ifname"`
+	AddrInfo []ipAddrInfoJSON `json:"addr_info"`
+}
+
+// ipAddrInfoJSON is one address of an interface in 'ip -json addr show' output
+type ipAddrInfoJSON struct {
+	Family    string `json:"family"`
+	Local     string `json:"local"`
+	PrefixLen int    `json:"prefixlen"`
+	Scope     string `json:"scope"`
+}
+
+// ipRouteJSON is one route in 'ip -json route show' output
+type ipRouteJSON struct {
+	Dst      string `json:"dst"`
+	Gateway  string `json:"gateway"`
+	Dev      string `json:"dev"`
+	Protocol string `json:"protocol"`
+	Metric   int    `json:"metric"`
+}
+
+// ipNeighJSON is one neighbour in 'ip -json neigh show' output
+type ipNeighJSON struct {
+	Dst    string   `json:"dst"`
+	Dev    string   `json:"dev"`
+	LLAddr string   `json:"lladdr"`
+	State  []string `json:"state"`
+}
+
+// isJSONOutput reports whether output came from 'ip -json'; older iproute2
+// releases ignore the flag for some objects and print text instead
+func isJSONOutput(output string) bool {
+	return strings.HasPrefix(strings.TrimSpace(output), "[")
+}
+
+// decodeIPJSON decodes 'ip -json' output into v
+func decodeIPJSON(output string, v interface{}) error {
+	if err := json.Unmarshal([]byte(output), v); err != nil {
+		return fmt.Errorf("failed to decode ip JSON output: %w", err)
+	}
+	return nil
+}
+
+// parseJSONInterfaceStatus parses 'ip -json link show' output
+func (p *Parser) parseJSONInterfaceStatus(output string) ([]InterfaceInfo, error) {
+	var links []ipLinkJSON
+	if err := decodeIPJSON(output, &links); err != nil {
+		return nil, err
+	}
+
+	var interfaces []InterfaceInfo
+	for _, link := range links {
+		state := linkState(link.OperState, link.Flags)
+		if state == "" {
+			continue
+		}
+		interfaces = append(interfaces, InterfaceInfo{
+			Name:  link.IfName,
+			State: state,
+			MAC:   link.Address,
+			MTU:   link.MTU,
+		})
+	}
+	return interfaces, nil
+}
+
+// parseJSONIPAddresses parses 'ip -json addr show' output
+func (p *Parser) parseJSONIPAddresses(output string) ([]IPAddress, error) {
+	var ifaces []ipAddrJSON
+	if err := decodeIPJSON(output, &ifaces); err != nil {
+		return nil, err
+	}
+
+	var addresses []IPAddress
+	for _, iface := range ifaces {
+		for _, info := range iface.AddrInfo {
+			if info.Family != "inet" || info.Local == "127.0.0.1" {
+				continue
+			}
+			cidr := fmt.Sprintf("%s/%d", info.Local, info.PrefixLen)
+			ip, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				continue
+			}
+			addresses = append(addresses, IPAddress{
+				IP:      ip.String(),
+				CIDR:    cidr,
+				Network: network.String(),
+				Raw:     fmt.Sprintf("inet %s scope %s %s", cidr, info.Scope, iface.IfName),
+			})
+		}
+	}
+	return addresses, nil
+}
+
+// parseJSONRoutingTable parses 'ip -json route show' output
+func (p *Parser) parseJSONRoutingTable(output string) ([]Route, string, error) {
+	var entries []ipRouteJSON
+	if err := decodeIPJSON(output, &entries); err != nil {
+		return nil, "", err
+	}
+
+	var routes []Route
+	var defaultRoute string
+	for _, entry := range entries {
+		route := Route{
+			Destination: entry.Dst,
+			Gateway:     entry.Gateway,
+			Interface:   entry.Dev,
+			Metric:      entry.Metric,
+			Raw:         entry.text(),
+		}
+		routes = append(routes, route)
+		if entry.Dst == "default" && defaultRoute == "" {
+			defaultRoute = route.Raw
+		}
+	}
+	return routes, defaultRoute, nil
+}
+
+// text renders the route the way 'ip route show' prints it
+func (r ipRouteJSON) text() string {
+	parts := []string{r.Dst}
+	if r.Gateway != "" {
+		parts = append(parts, "via", r.Gateway)
+	}
+	if r.Dev != "" {
+		parts = append(parts, "dev", r.Dev)
+	}
+	if r.Protocol != "" {
+		parts = append(parts, "proto", r.Protocol)
+	}
+	if r.Metric != 0 {
+		parts = append(parts, "metric", fmt.Sprintf("%d", r.Metric))
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseJSONARPTable parses 'ip -json neigh show' output
+func (p *Parser) parseJSONARPTable(output string) ([]ARPEntry, error) {
+	var neighbours []ipNeighJSON
+	if err := decodeIPJSON(output, &neighbours); err != nil {
+		return nil, err
+	}
+
+	var entries []ARPEntry
+	for _, neighbour := range neighbours {
+		// Incomplete and failed entries have no link-layer address
+		if neighbour.LLAddr == "" || !isIPv4(neighbour.Dst) {
+			continue
+		}
+		entries = append(entries, ARPEntry{
+			IP:  neighbour.Dst,
+			MAC: neighbour.LLAddr,
+			Raw: fmt.Sprintf("%s dev %s lladdr %s %s", neighbour.Dst, neighbour.Dev, neighbour.LLAddr, strings.Join(neighbour.State, " ")),
+		})
+	}
+	return entries, nil
+}
+
+// linkState returns "UP" or "DOWN" for an interface, or an empty string for
+// states the diagnostics do not count, such as UNKNOWN. Old busybox ip
+// releases print no state at all, so it is derived from the flags instead.
+func linkState(operState string, flags []string) string {
+	switch operState {
+	case "UP", "DOWN":
+		return operState
+	case "":
+		for _, flag := range flags {
+			if flag == "LOWER_UP" {
+				return "UP"
+			}
+		}
+		return "DOWN"
+	default:
+		return ""
+	}
+}
+
+// isIPv4 reports whether s is an IPv4 address; the ARP table holds no IPv6
+// neighbours
+func isIPv4(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && ip.To4() != nil
+}
@@ -0,0 +1,469 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// procNet is where the kernel publishes the IPv4 routing and ARP tables;
+// tests point it at a fixture directory
+var procNet = "/proc/net"
+
+// Route flags from /proc/net/route and ARP flags from /proc/net/arp
+const (
+	rtfUp      = 0x1
+	rtfGateway = 0x2
+	atfCom     = 0x2
+	atfPerm    = 0x4
+)
+
+// SetNative selects the pure-Go implementations of the interface, address,
+// route, ARP and ping commands, which need no external binaries. Each one
+// falls back to running the command when it cannot be used. Enabled by
+// default on Linux.
+func (nc *NetworkCommands) SetNative(enabled bool) {
+	nc.native = enabled
+}
+
+// runNative runs a pure-Go implementation and wraps its output like the
+// result of the ip command it stands in for; ok is false when the
+// implementation is disabled, lacks the capability it needs, or failed, and
+// the caller should run the command instead
+func (nc *NetworkCommands) runNative(args []string, needs func(Capabilities) bool, fn func() (string, error)) (*CommandResult, bool) {
+	if !nc.native || nc.executor.platform != "linux" || !nc.hasCapability(needs) {
+		return nil, false
+	}
+
+	startTime := time.Now()
+	output, err := fn()
+	if err != nil {
+		nc.executor.logger.WithError(err).WithField("args", args).Debug("Native network command unavailable, running the command instead")
+		return nil, false
+	}
+
+	return &CommandResult{
+		Command:   "ip",
+		Args:      args,
+		Output:    output,
+		Duration:  time.Since(startTime),
+		Success:   true,
+		Timestamp: time.Now(),
+	}, true
+}
+
+// needsNetlink, needsProcNet and needsICMP report whether the capabilities
+// allow each native implementation
+func needsNetlink(c Capabilities) bool { return c.Netlink }
+func needsProcNet(c Capabilities) bool { return c.ProcNet }
+func needsICMP(c Capabilities) bool    { return c.ICMPDatagram || c.ICMPRaw }
+
+// nativeInterfaceStatus lists interfaces in 'ip -json link show' format
+func nativeInterfaceStatus() (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("failed to list interfaces: %w", err)
+	}
+
+	links := make([]ipLinkJSON, 0, len(ifaces))
+	for _, iface := range ifaces {
+		links = append(links, ipLinkJSON{
+			IfName:    iface.Name,
+			Flags:     interfaceFlags(iface),
+			MTU:       iface.MTU,
+			OperState: strings.ToUpper(readSysfsValue(iface.Name, "operstate")),
+			Address:   iface.HardwareAddr.String(),
+		})
+	}
+	return encodeIPJSON(links)
+}
+
+// interfaceFlags returns the flags ip prints for iface; LOWER_UP comes from
+// the carrier state in sysfs
+func interfaceFlags(iface net.Interface) []string {
+	var flags []string
+	if iface.Flags&net.FlagLoopback != 0 {
+		flags = append(flags, "LOOPBACK")
+	}
+	if iface.Flags&net.FlagBroadcast != 0 {
+		flags = append(flags, "BROADCAST")
+	}
+	if iface.Flags&net.FlagPointToPoint != 0 {
+		flags = append(flags, "POINTOPOINT")
+	}
+	if iface.Flags&net.FlagMulticast != 0 {
+		flags = append(flags, "MULTICAST")
+	}
+	if iface.Flags&net.FlagUp != 0 {
+		flags = append(flags, "UP")
+		if readSysfsValue(iface.Name, "carrier") == "1" {
+			flags = append(flags, "LOWER_UP")
+		}
+	}
+	return flags
+}
+
+// readSysfsValue reads an attribute of iface from sysfs, or returns an empty
+// string when it cannot be read
+func readSysfsValue(iface, attribute string) string {
+	data, err := os.ReadFile(filepath.Join(sysClassNet, iface, attribute))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// nativeIPConfiguration lists addresses in 'ip -json addr show' format
+func nativeIPConfiguration() (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("failed to list interfaces: %w", err)
+	}
+
+	entries := make([]ipAddrJSON, 0, len(ifaces))
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return "", fmt.Errorf("failed to list addresses of %s: %w", iface.Name, err)
+		}
+
+		entry := ipAddrJSON{IfName: iface.Name}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			family, scope := "inet6", "global"
+			if ipNet.IP.To4() != nil {
+				family = "inet"
+			}
+			switch {
+			case ipNet.IP.IsLoopback():
+				scope = "host"
+			case ipNet.IP.IsLinkLocalUnicast():
+				scope = "link"
+			}
+			prefixLen, _ := ipNet.Mask.Size()
+			entry.AddrInfo = append(entry.AddrInfo, ipAddrInfoJSON{
+				Family:    family,
+				Local:     ipNet.IP.String(),
+				PrefixLen: prefixLen,
+				Scope:     scope,
+			})
+		}
+		entries = append(entries, entry)
+	}
+	return encodeIPJSON(entries)
+}
+
+// nativeRoutingTable lists IPv4 routes from /proc/net/route in 'ip -json
+// route show' format
+func nativeRoutingTable() (string, error) {
+	file, err := os.Open(filepath.Join(procNet, "route"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read routing table: %w", err)
+	}
+	defer file.Close()
+
+	routes := []ipRouteJSON{}
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		flags, _ := strconv.ParseUint(fields[3], 16, 32)
+		if flags&rtfUp == 0 {
+			continue
+		}
+		destination, destErr := procNetIPv4(fields[1])
+		gateway, gwErr := procNetIPv4(fields[2])
+		mask, maskErr := procNetIPv4(fields[7])
+		if destErr != nil || gwErr != nil || maskErr != nil {
+			return "", fmt.Errorf("malformed routing table entry: %q", scanner.Text())
+		}
+		metric, _ := strconv.Atoi(fields[6])
+
+		route := ipRouteJSON{Dev: fields[0], Metric: metric}
+		prefixLen, _ := net.IPMask(mask).Size()
+		if prefixLen == 0 && destination.Equal(net.IPv4zero) {
+			route.Dst = "default"
+		} else {
+			route.Dst = fmt.Sprintf("%s/%d", destination, prefixLen)
+		}
+		if flags&rtfGateway != 0 {
+			route.Gateway = gateway.String()
+		}
+		routes = append(routes, route)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read routing table: %w", err)
+	}
+	return encodeIPJSON(routes)
+}
+
+// procNetIPv4 decodes an address from /proc/net/route, which the kernel
+// prints as hex in host byte order
+func procNetIPv4(hex string) (net.IP, error) {
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, err
+	}
+	ip := make(net.IP, net.IPv4len)
+	binary.LittleEndian.PutUint32(ip, uint32(value))
+	return ip, nil
+}
+
+// nativeARPTable lists IPv4 neighbours from /proc/net/arp in 'ip -json
+// neigh show' format
+func nativeARPTable() (string, error) {
+	file, err := os.Open(filepath.Join(procNet, "arp"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read ARP table: %w", err)
+	}
+	defer file.Close()
+
+	neighbours := []ipNeighJSON{}
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// IP address, HW type, Flags, HW address, Mask, Device
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		flags, _ := strconv.ParseUint(fields[2], 0, 32)
+
+		neighbour := ipNeighJSON{Dst: fields[0], Dev: fields[5]}
+		switch {
+		case flags&atfPerm != 0:
+			neighbour.LLAddr = fields[3]
+			neighbour.State = []string{"PERMANENT"}
+		case flags&atfCom != 0:
+			neighbour.LLAddr = fields[3]
+			neighbour.State = []string{"REACHABLE"}
+		default:
+			neighbour.State = []string{"INCOMPLETE"}
+		}
+		neighbours = append(neighbours, neighbour)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read ARP table: %w", err)
+	}
+	return encodeIPJSON(neighbours)
+}
+
+// encodeIPJSON renders v the way 'ip -json' prints it
+func encodeIPJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode network state: %w", err)
+	}
+	return string(data), nil
+}
+
+// pingPayloadSize matches the default payload of iputils ping
+const pingPayloadSize = 56
+
+// nativePing sends ICMP echo requests to host and reports the replies in
+// iputils ping format. It uses an unprivileged ICMP socket where the kernel
+// allows one (net.ipv4.ping_group_range) and a raw socket otherwise, and
+// returns an error when neither can be opened.
+func nativePing(ctx context.Context, host string, count int, timeout int) (*CommandResult, error) {
+	args := []string{"-c", strconv.Itoa(count), "-W", strconv.Itoa(timeout), host}
+	conn, unprivileged, err := openICMP()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	startTime := time.Now()
+	result := &CommandResult{Command: "ping", Args: args}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	var target net.IP
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			target = addr.IP.To4()
+			break
+		}
+	}
+	if target == nil {
+		if err == nil {
+			err = fmt.Errorf("no IPv4 address")
+		}
+		result.Output = fmt.Sprintf("ping: %s: %v\n", host, err)
+		result.Error = fmt.Sprintf("failed to resolve %s: %v", host, err)
+		result.ExitCode = 2
+		result.Duration = time.Since(startTime)
+		result.Timestamp = time.Now()
+		return result, nil
+	}
+
+	var dst net.Addr = &net.IPAddr{IP: target}
+	if unprivileged {
+		dst = &net.UDPAddr{IP: target}
+	}
+	id := os.Getpid() & 0xffff
+
+	var output strings.Builder
+	fmt.Fprintf(&output, "PING %s (%s) %d(%d) bytes of data.\n", host, target, pingPayloadSize, pingPayloadSize+28)
+
+	var rtts []time.Duration
+	sent := 0
+	for seq := 1; seq <= count; seq++ {
+		if seq > 1 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		sent++
+		rtt, ok := pingOnce(ctx, conn, dst, id, seq, !unprivileged, time.Duration(timeout)*time.Second)
+		if ok {
+			rtts = append(rtts, rtt)
+			fmt.Fprintf(&output, "%d bytes from %s: icmp_seq=%d time=%.3f ms\n", pingPayloadSize+8, target, seq, durationMs(rtt))
+		}
+	}
+
+	result.Duration = time.Since(startTime)
+	output.WriteString(formatPingStatistics(host, sent, rtts, result.Duration))
+	result.Output = output.String()
+	result.Success = len(rtts) > 0
+	if !result.Success {
+		result.ExitCode = 1
+		result.Error = fmt.Sprintf("no reply from %s", host)
+	}
+	result.Timestamp = time.Now()
+	return result, nil
+}
+
+// openICMP opens an ICMP socket; unprivileged is true for the datagram kind,
+// whose replies carry the kernel-chosen identifier rather than ours
+func openICMP() (net.PacketConn, bool, error) {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.IPPROTO_ICMP)
+	if err == nil {
+		file := os.NewFile(uintptr(fd), "icmp")
+		conn, err := net.FilePacketConn(file)
+		file.Close()
+		if err == nil {
+			return conn, true, nil
+		}
+	}
+
+	conn, rawErr := net.ListenPacket("ip4:icmp", "0.0.0.0")
+	if rawErr != nil {
+		return nil, false, fmt.Errorf("cannot open an ICMP socket: %w", rawErr)
+	}
+	return conn, false, nil
+}
+
+// pingOnce sends one echo request and waits for its reply
+func pingOnce(ctx context.Context, conn net.PacketConn, dst net.Addr, id, seq int, checkID bool, timeout time.Duration) (time.Duration, bool) {
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return 0, false
+	}
+
+	startTime := time.Now()
+	if _, err := conn.WriteTo(icmpEcho(id, seq), dst); err != nil {
+		return 0, false
+	}
+
+	reply := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(reply)
+		if err != nil {
+			return 0, false
+		}
+		// Echo reply: type 0, code 0, checksum, identifier, sequence
+		if n < 8 || reply[0] != 0 || int(binary.BigEndian.Uint16(reply[6:8])) != seq {
+			continue
+		}
+		if checkID && int(binary.BigEndian.Uint16(reply[4:6])) != id {
+			continue
+		}
+		return time.Since(startTime), true
+	}
+}
+
+// icmpEcho builds an ICMP echo request
+func icmpEcho(id, seq int) []byte {
+	packet := make([]byte, 8+pingPayloadSize)
+	packet[0] = 8 // echo request
+	binary.BigEndian.PutUint16(packet[4:6], uint16(id))
+	binary.BigEndian.PutUint16(packet[6:8], uint16(seq))
+	for i := 8; i < len(packet); i++ {
+		packet[i] = byte(i)
+	}
+	binary.BigEndian.PutUint16(packet[2:4], icmpChecksum(packet))
+	return packet
+}
+
+// icmpChecksum computes the Internet checksum of an ICMP message (RFC 1071)
+func icmpChecksum(data []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(data[i])<<8 | uint32(data[i+1])
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// formatPingStatistics renders the summary iputils ping prints on exit
+func formatPingStatistics(host string, sent int, rtts []time.Duration, elapsed time.Duration) string {
+	loss := 100.0
+	if sent > 0 {
+		loss = float64(sent-len(rtts)) * 100 / float64(sent)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n--- %s ping statistics ---\n", host)
+	fmt.Fprintf(&b, "%d packets transmitted, %d received, %g%% packet loss, time %dms\n",
+		sent, len(rtts), loss, elapsed.Milliseconds())
+	if len(rtts) == 0 {
+		return b.String()
+	}
+
+	minRTT, maxRTT, sum, sumSquares := math.MaxFloat64, 0.0, 0.0, 0.0
+	for _, rtt := range rtts {
+		ms := durationMs(rtt)
+		minRTT = math.Min(minRTT, ms)
+		maxRTT = math.Max(maxRTT, ms)
+		sum += ms
+		sumSquares += ms * ms
+	}
+	avg := sum / float64(len(rtts))
+	mdev := math.Sqrt(math.Max(sumSquares/float64(len(rtts))-avg*avg, 0))
+	fmt.Fprintf(&b, "rtt min/avg/max/mdev = %.3f/%.3f/%.3f/%.3f ms\n", minRTT, avg, maxRTT, mdev)
+	return b.String()
+}
+
+// durationMs converts d to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,161 @@
+package system
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeProcNet(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write fixture: %v", err)
+		}
+	}
+	original := procNet
+	procNet = dir
+	t.Cleanup(func() { procNet = original })
+}
+
+func TestNativeRoutingTable(t *testing.T) {
+	writeProcNet(t, map[string]string{"route": `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0164A8C0	0003	0	0	100	00000000	0	0	0
+eth0	0064A8C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+eth1	0000A8C0	00000000	0000	0	0	0	00FFFFFF	0	0	0
+`})
+
+	output, err := nativeRoutingTable()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	parser := NewParser("linux")
+	routes, defaultRoute, err := parser.ParseRoutingTable(output)
+	if err != nil {
+		t.Fatalf("Expected native output to parse, got %v", err)
+	}
+	if len(routes) != 2 {
+		t.Fatalf("Expected 2 routes that are up, got %d: %+v", len(routes), routes)
+	}
+	if defaultRoute != "default via 192.168.100.1 dev eth0 metric 100" {
+		t.Errorf("Unexpected default route %q", defaultRoute)
+	}
+	if routes[1].Destination != "192.168.100.0/24" || routes[1].Gateway != "" {
+		t.Errorf("Expected on-link route to 192.168.100.0/24, got %+v", routes[1])
+	}
+	if iface := parser.ParseDefaultInterface(output); iface != "eth0" {
+		t.Errorf("Expected default interface eth0, got %q", iface)
+	}
+}
+
+func TestNativeARPTable(t *testing.T) {
+	writeProcNet(t, map[string]string{"arp": `IP address       HW type     Flags       HW address            Mask     Device
+192.168.100.1    0x1         0x2         00:11:22:33:44:55     *        eth0
+192.168.100.20   0x1         0x0         00:00:00:00:00:00     *        eth0
+`})
+
+	output, err := nativeARPTable()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	entries, err := NewParser("linux").ParseARPTable(output)
+	if err != nil {
+		t.Fatalf("Expected native output to parse, got %v", err)
+	}
+	if len(entries) != 1 || entries[0].IP != "192.168.100.1" || entries[0].MAC != "00:11:22:33:44:55" {
+		t.Errorf("Expected only the complete entry, got %+v", entries)
+	}
+}
+
+func TestNativeTablesMissing(t *testing.T) {
+	writeProcNet(t, nil)
+
+	if _, err := nativeRoutingTable(); err == nil {
+		t.Error("Expected an error without /proc/net/route")
+	}
+	if _, err := nativeARPTable(); err == nil {
+		t.Error("Expected an error without /proc/net/arp")
+	}
+}
+
+func TestNativeInterfaces(t *testing.T) {
+	parser := NewParser("linux")
+
+	output, err := nativeInterfaceStatus()
+	if err != nil {
+		t.Fatalf("Expected no error listing interfaces, got %v", err)
+	}
+	if _, err := parser.ParseInterfaceStatus(output); err != nil {
+		t.Errorf("Expected native link output to parse, got %v", err)
+	}
+
+	output, err = nativeIPConfiguration()
+	if err != nil {
+		t.Fatalf("Expected no error listing addresses, got %v", err)
+	}
+	if _, err := parser.ParseIPAddresses(output); err != nil {
+		t.Errorf("Expected native address output to parse, got %v", err)
+	}
+}
+
+func TestICMPChecksum(t *testing.T) {
+	packet := icmpEcho(0x1234, 1)
+	if packet[0] != 8 {
+		t.Errorf("Expected echo request type 8, got %d", packet[0])
+	}
+	// A message including its checksum sums to zero
+	if sum := icmpChecksum(packet); sum != 0 {
+		t.Errorf("Expected checksum to verify, got %#x", sum)
+	}
+}
+
+func TestFormatPingStatistics(t *testing.T) {
+	parser := NewParser("linux")
+
+	output := formatPingStatistics("example.com", 4, []time.Duration{
+		10 * time.Millisecond, 12 * time.Millisecond, 14 * time.Millisecond,
+	}, 3*time.Second)
+	stats, err := parser.ParsePingOutput(output)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stats.PacketsSent != 4 || stats.PacketsReceived != 3 || stats.PacketLoss != 25 {
+		t.Errorf("Expected 4 sent, 3 received, 25%% loss, got %+v", stats)
+	}
+	if stats.MinTime != 10 || stats.AvgTime != 12 || stats.MaxTime != 14 {
+		t.Errorf("Expected 10/12/14 ms, got %+v", stats)
+	}
+
+	stats, _ = parser.ParsePingOutput(formatPingStatistics("example.com", 3, nil, 3*time.Second))
+	if stats.PacketLoss != 100 {
+		t.Errorf("Expected 100%% loss without replies, got %.1f", stats.PacketLoss)
+	}
+}
+
+func TestNativePingLoopback(t *testing.T) {
+	conn, _, err := openICMP()
+	if err != nil {
+		t.Skipf("ICMP sockets unavailable: %v", err)
+	}
+	conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := nativePing(ctx, "127.0.0.1", 1, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("Expected loopback to reply, got %s", result.Error)
+	}
+	stats, _ := NewParser("linux").ParsePingOutput(result.Output)
+	if stats.PacketsReceived != 1 {
+		t.Errorf("Expected 1 reply, got %d:\n%s", stats.PacketsReceived, result.Output)
+	}
+}
@@ -63,46 +63,75 @@ func NewParser(platform string) *Parser {
 	}
 }
 
-// ParseInterfaceStatus parses network interface status output
+// linkLineRegex matches the first line of an interface in 'ip link show'
+// output, e.g. "2: eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 ..."
+var linkLineRegex = regexp.MustCompile(`^\d+:\s+([^:\s]+):\s+<([^>]*)>(.*)$`)
+
+// ParseInterfaceStatus parses network interface status output, either
+// 'ip -json link show' or the text of iproute2 and busybox ip
 func (p *Parser) ParseInterfaceStatus(output string) ([]InterfaceInfo, error) {
-	// Linux-only parsing
+	if isJSONOutput(output) {
+		return p.parseJSONInterfaceStatus(output)
+	}
 	return p.parseLinuxInterfaceStatus(output)
 }
 
 // parseLinuxInterfaceStatus parses 'ip link show' output on Linux
 func (p *Parser) parseLinuxInterfaceStatus(output string) ([]InterfaceInfo, error) {
 	var interfaces []InterfaceInfo
+	var current *InterfaceInfo
 
 	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		if strings.Contains(line, ":") && (strings.Contains(line, "state UP") || strings.Contains(line, "state DOWN")) {
-			// Parse interface line: "2: eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc pfifo_fast state UP mode DEFAULT group default qlen 1000"
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				name := strings.TrimSuffix(parts[1], ":")
-				state := "DOWN"
-				mtu := 0
 
-				// Find state and MTU
-				for i, part := range parts {
-					if part == "state" && i+1 < len(parts) {
-						state = parts[i+1]
-					}
-					if part == "mtu" && i+1 < len(parts) {
-						if mtuVal, err := strconv.Atoi(parts[i+1]); err == nil {
-							mtu = mtuVal
-						}
-					}
-				}
+		// Continuation line: "link/ether 08:00:27:12:34:56 brd ff:ff:ff:ff:ff:ff"
+		if current != nil && strings.HasPrefix(line, "link/") {
+			if parts := strings.Fields(line); len(parts) >= 2 {
+				current.MAC = parts[1]
+			}
+			continue
+		}
 
-				interfaces = append(interfaces, InterfaceInfo{
-					Name:  name,
-					State: state,
-					MTU:   mtu,
-				})
+		matches := linkLineRegex.FindStringSubmatch(line)
+		if matches == nil {
+			current = nil
+			continue
+		}
+
+		// VLANs are listed as "eth0.2@eth0"
+		name := matches[1]
+		if i := strings.Index(name, "@"); i > 0 {
+			name = name[:i]
+		}
+
+		operState := ""
+		mtu := 0
+		parts := strings.Fields(matches[3])
+		for i, part := range parts {
+			if part == "state" && i+1 < len(parts) {
+				operState = parts[i+1]
+			}
+			if part == "mtu" && i+1 < len(parts) {
+				if mtuVal, err := strconv.Atoi(parts[i+1]); err == nil {
+					mtu = mtuVal
+				}
 			}
 		}
+
+		// Busybox ip may omit the state, so it falls back to the flags
+		state := linkState(operState, strings.Split(matches[2], ","))
+		if state == "" {
+			current = nil
+			continue
+		}
+
+		interfaces = append(interfaces, InterfaceInfo{
+			Name:  name,
+			State: state,
+			MTU:   mtu,
+		})
+		current = &interfaces[len(interfaces)-1]
 	}
 
 	return interfaces, nil
@@ -166,8 +195,16 @@ func (p *Parser) parseDarwinInterfaceStatus(output string) ([]InterfaceInfo, err
 	return interfaces, nil
 }
 
-// ParseARPTable parses ARP table output
+// arpLineRegex matches a line of 'arp -a' output from net-tools or busybox
+var arpLineRegex = regexp.MustCompile(`^(\S+)\s+\(([^)]+)\)\s+at\s+([a-fA-F0-9:]+)`)
+
+// ParseARPTable parses ARP table output: 'ip -json neigh show', 'ip neigh
+// show', or 'arp -a' from net-tools or busybox
 func (p *Parser) ParseARPTable(output string) ([]ARPEntry, error) {
+	if isJSONOutput(output) {
+		return p.parseJSONARPTable(output)
+	}
+
 	var entries []ARPEntry
 
 	lines := strings.Split(output, "\n")
@@ -177,10 +214,24 @@ func (p *Parser) ParseARPTable(output string) ([]ARPEntry, error) {
 			continue
 		}
 
+		// Parse ip neigh: "192.168.1.1 dev eth0 lladdr aa:bb:cc:dd:ee:ff REACHABLE"
+		if parts := strings.Fields(line); isIPv4(parts[0]) {
+			for i := 1; i+1 < len(parts); i++ {
+				if parts[i] == "lladdr" {
+					entries = append(entries, ARPEntry{
+						IP:  parts[0],
+						MAC: parts[i+1],
+						Raw: line,
+					})
+					break
+				}
+			}
+			continue
+		}
+
 		// Parse Linux ARP: "gateway (192.168.1.1) at aa:bb:cc:dd:ee:ff [ether] on eth0"
 		if strings.Contains(line, "(") && strings.Contains(line, ")") && strings.Contains(line, "at") {
-			re := regexp.MustCompile(`^(\S+)\s+\(([^)]+)\)\s+at\s+([a-fA-F0-9:]+)`)
-			matches := re.FindStringSubmatch(line)
+			matches := arpLineRegex.FindStringSubmatch(line)
 
 			if len(matches) >= 4 {
 				entries = append(entries, ARPEntry{
@@ -196,9 +247,12 @@ func (p *Parser) ParseARPTable(output string) ([]ARPEntry, error) {
 	return entries, nil
 }
 
-// ParseIPAddresses parses IP address configuration output
+// ParseIPAddresses parses IP address configuration output, either 'ip -json
+// addr show' or the text of iproute2 and busybox ip
 func (p *Parser) ParseIPAddresses(output string) ([]IPAddress, error) {
-	// Linux-only parsing
+	if isJSONOutput(output) {
+		return p.parseJSONIPAddresses(output)
+	}
 	return p.parseLinuxIPAddresses(output)
 }
 
@@ -255,9 +309,12 @@ func (p *Parser) parseDarwinIPAddresses(output string) ([]IPAddress, error) {
 	return addresses, nil
 }
 
-// ParseRoutingTable parses routing table output
+// ParseRoutingTable parses routing table output, either 'ip -json route
+// show' or the text of iproute2 and busybox ip
 func (p *Parser) ParseRoutingTable(output string) ([]Route, string, error) {
-	// Linux-only parsing
+	if isJSONOutput(output) {
+		return p.parseJSONRoutingTable(output)
+	}
 	return p.parseLinuxRoutingTable(output)
 }
 
@@ -273,9 +330,23 @@ func (p *Parser) parseLinuxRoutingTable(output string) ([]Route, string, error)
 			continue
 		}
 
-		routes = append(routes, Route{
-			Raw: line,
-		})
+		// "default via 192.168.1.1 dev eth0 proto dhcp metric 100"
+		parts := strings.Fields(line)
+		route := Route{
+			Destination: parts[0],
+			Raw:         line,
+		}
+		for i := 1; i+1 < len(parts); i++ {
+			switch parts[i] {
+			case "via":
+				route.Gateway = parts[i+1]
+			case "dev":
+				route.Interface = parts[i+1]
+			case "metric":
+				route.Metric, _ = strconv.Atoi(parts[i+1])
+			}
+		}
+		routes = append(routes, route)
 
 		if strings.HasPrefix(line, "default") {
 			defaultRoute = line
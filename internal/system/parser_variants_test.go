@@ -0,0 +1,109 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ipVariants are the fixture directories under testdata/ip, one per flavour
+// of command output. Each holds the same network: eth0 up with
+// 192.168.100.10/24 behind the gateway 192.168.100.1, and wlan0 down.
+var ipVariants = []string{
+	"iproute2",      // Debian, Ubuntu, Raspberry Pi OS
+	"iproute2-json", // 'ip -json', preferred where supported
+	"busybox",       // OpenWrt and Alpine; arp -a for the neighbour table
+}
+
+func readIPFixture(t *testing.T, variant, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "ip", variant, name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	return string(data)
+}
+
+func TestParserVariants(t *testing.T) {
+	parser := NewParser("linux")
+
+	for _, variant := range ipVariants {
+		t.Run(variant, func(t *testing.T) {
+			interfaces, err := parser.ParseInterfaceStatus(readIPFixture(t, variant, "link.txt"))
+			if err != nil {
+				t.Fatalf("Expected no error parsing links, got %v", err)
+			}
+			byName := make(map[string]InterfaceInfo)
+			for _, iface := range interfaces {
+				byName[iface.Name] = iface
+			}
+			if eth0 := byName["eth0"]; eth0.State != "UP" || eth0.MTU != 1500 || eth0.MAC != "08:00:27:12:34:56" {
+				t.Errorf("Expected eth0 UP with MTU 1500 and MAC 08:00:27:12:34:56, got %+v", eth0)
+			}
+			if wlan0 := byName["wlan0"]; wlan0.State != "DOWN" {
+				t.Errorf("Expected wlan0 DOWN, got %+v", wlan0)
+			}
+
+			addresses, err := parser.ParseIPAddresses(readIPFixture(t, variant, "addr.txt"))
+			if err != nil {
+				t.Fatalf("Expected no error parsing addresses, got %v", err)
+			}
+			if len(addresses) != 1 {
+				t.Fatalf("Expected 1 address, got %d: %+v", len(addresses), addresses)
+			}
+			if addresses[0].CIDR != "192.168.100.10/24" || addresses[0].Network != "192.168.100.0/24" {
+				t.Errorf("Expected 192.168.100.10/24 in 192.168.100.0/24, got %+v", addresses[0])
+			}
+
+			routeOutput := readIPFixture(t, variant, "route.txt")
+			routes, defaultRoute, err := parser.ParseRoutingTable(routeOutput)
+			if err != nil {
+				t.Fatalf("Expected no error parsing routes, got %v", err)
+			}
+			if len(routes) != 2 {
+				t.Fatalf("Expected 2 routes, got %d", len(routes))
+			}
+			if defaultRoute == "" {
+				t.Error("Expected a default route")
+			}
+			if routes[0].Destination != "default" || routes[0].Gateway != "192.168.100.1" ||
+				routes[0].Interface != "eth0" || routes[0].Metric != 100 {
+				t.Errorf("Expected default via 192.168.100.1 dev eth0 metric 100, got %+v", routes[0])
+			}
+			if iface := parser.ParseDefaultInterface(routeOutput); iface != "eth0" {
+				t.Errorf("Expected default interface eth0, got %q", iface)
+			}
+
+			entries, err := parser.ParseARPTable(readIPFixture(t, variant, "neigh.txt"))
+			if err != nil {
+				t.Fatalf("Expected no error parsing neighbours, got %v", err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("Expected 1 complete IPv4 neighbour, got %d: %+v", len(entries), entries)
+			}
+			if entries[0].IP != "192.168.100.1" || entries[0].MAC != "00:11:22:33:44:55" {
+				t.Errorf("Expected 192.168.100.1 at 00:11:22:33:44:55, got %+v", entries[0])
+			}
+		})
+	}
+}
+
+func TestParseInvalidJSON(t *testing.T) {
+	parser := NewParser("linux")
+
+	if _, err := parser.ParseInterfaceStatus(`[{"ifname":`); err == nil {
+		t.Error("Expected an error for truncated JSON output")
+	}
+	if _, _, err := parser.ParseRoutingTable("[]"); err != nil {
+		t.Errorf("Expected no error for an empty JSON list, got %v", err)
+	}
+}
+
+func TestParseVLANInterfaceName(t *testing.T) {
+	parser := NewParser("linux")
+
+	interfaces, _ := parser.ParseInterfaceStatus("5: eth0.2@eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP qlen 1000")
+	if len(interfaces) != 1 || interfaces[0].Name != "eth0.2" {
+		t.Errorf("Expected interface eth0.2, got %+v", interfaces)
+	}
+}
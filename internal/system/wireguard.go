@@ -0,0 +1,75 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WireGuardPeer is a peer of a WireGuard interface, as reported by
+// 'wg show <interface> dump'
+type WireGuardPeer struct {
+	PublicKey  string   `json:"public_key"`
+	Endpoint   string   `json:"endpoint,omitempty"`
+	AllowedIPs []string `json:"allowed_ips,omitempty"`
+	// LatestHandshake is zero before the first handshake
+	LatestHandshake time.Time `json:"latest_handshake"`
+	RxBytes         int64     `json:"rx_bytes"`
+	TxBytes         int64     `json:"tx_bytes"`
+	// PersistentKeepalive is the keepalive interval, 0 when off
+	PersistentKeepalive time.Duration `json:"persistent_keepalive"`
+}
+
+// GetWireGuardDump lists the peers of a WireGuard interface. Reading them
+// needs CAP_NET_ADMIN.
+func (nc *NetworkCommands) GetWireGuardDump(ctx context.Context, iface string) (*CommandResult, error) {
+	return nc.executor.ExecuteWithContext(ctx, "wg", "show", iface, "dump")
+}
+
+// ParseWireGuardDump parses 'wg show <interface> dump' output: a line for
+// the interface, then a tab-separated line per peer with its public key,
+// preshared key, endpoint, allowed IPs, latest handshake, received and sent
+// bytes and keepalive interval
+func (p *Parser) ParseWireGuardDump(output string) ([]WireGuardPeer, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) == 0 || len(strings.Split(lines[0], "\t")) != 4 {
+		return nil, fmt.Errorf("unexpected wg dump output: missing interface line")
+	}
+
+	peers := make([]WireGuardPeer, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 8 {
+			return nil, fmt.Errorf("unexpected wg dump peer line with %d fields", len(fields))
+		}
+
+		peer := WireGuardPeer{PublicKey: fields[0]}
+		if fields[2] != "(none)" {
+			peer.Endpoint = fields[2]
+		}
+		if fields[3] != "(none)" {
+			peer.AllowedIPs = strings.Split(fields[3], ",")
+		}
+
+		handshake, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wg latest handshake %q: %w", fields[4], err)
+		}
+		if handshake > 0 {
+			peer.LatestHandshake = time.Unix(handshake, 0)
+		}
+		if peer.RxBytes, err = strconv.ParseInt(fields[5], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid wg received bytes %q: %w", fields[5], err)
+		}
+		if peer.TxBytes, err = strconv.ParseInt(fields[6], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid wg sent bytes %q: %w", fields[6], err)
+		}
+		if keepalive, err := strconv.Atoi(fields[7]); err == nil {
+			peer.PersistentKeepalive = time.Duration(keepalive) * time.Second
+		}
+		peers = append(peers, peer)
+	}
+	return peers, nil
+}
@@ -0,0 +1,45 @@
+package system
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWireGuardDump(t *testing.T) {
+	parser := NewParser("linux")
+
+	output := "cPrivateKey=\tcPublicKey=\t51820\toff\n" +
+		"vpsKey=\t(none)\t203.0.113.7:51820\t10.8.0.1/32,10.8.1.0/24\t1714570000\t183423\t20451\t25\n" +
+		"laptopKey=\t(none)\t(none)\t10.8.0.3/32\t0\t0\t0\toff\n"
+
+	peers, err := parser.ParseWireGuardDump(output)
+	if err != nil {
+		t.Fatalf("ParseWireGuardDump failed: %v", err)
+	}
+	if len(peers) != 2 {
+		t.Fatalf("Expected 2 peers, got %d", len(peers))
+	}
+
+	vps := peers[0]
+	if vps.PublicKey != "vpsKey=" || vps.Endpoint != "203.0.113.7:51820" || len(vps.AllowedIPs) != 2 {
+		t.Errorf("Unexpected peer %+v", vps)
+	}
+	if !vps.LatestHandshake.Equal(time.Unix(1714570000, 0)) || vps.RxBytes != 183423 || vps.TxBytes != 20451 {
+		t.Errorf("Unexpected handshake and transfer %+v", vps)
+	}
+	if vps.PersistentKeepalive != 25*time.Second {
+		t.Errorf("Expected a 25s keepalive, got %v", vps.PersistentKeepalive)
+	}
+
+	// A peer that never completed a handshake has no endpoint or handshake
+	laptop := peers[1]
+	if laptop.Endpoint != "" || !laptop.LatestHandshake.IsZero() || laptop.PersistentKeepalive != 0 {
+		t.Errorf("Unexpected idle peer %+v", laptop)
+	}
+
+	for _, invalid := range []string{"", "Unable to access interface: Operation not permitted", output + "short\tline\n"} {
+		if _, err := parser.ParseWireGuardDump(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package system
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WirelessStation is the access point a wireless interface is associated
+// with, as reported by 'iw dev <interface> station dump'
+type WirelessStation struct {
+	MAC       string `json:"mac"`
+	Interface string `json:"interface"`
+	// Signal and SignalAverage are in dBm
+	Signal        int `json:"signal_dbm"`
+	SignalAverage int `json:"signal_avg_dbm,omitempty"`
+	// TxBitrate and RxBitrate are in MBit/s
+	TxBitrate float64 `json:"tx_bitrate_mbps,omitempty"`
+	RxBitrate float64 `json:"rx_bitrate_mbps,omitempty"`
+	TxPackets int     `json:"tx_packets"`
+	TxRetries int     `json:"tx_retries"`
+	TxFailed  int     `json:"tx_failed"`
+	// InactiveMs is the time since the last frame from the station
+	InactiveMs int `json:"inactive_ms"`
+}
+
+// TxFailureRate returns the share of transmitted packets that failed
+func (s WirelessStation) TxFailureRate() float64 {
+	if s.TxPackets == 0 {
+		return 0
+	}
+	return float64(s.TxFailed) / float64(s.TxPackets)
+}
+
+// sysClassNet is where the kernel lists network interfaces; tests point it
+// at a fixture directory
+var sysClassNet = "/sys/class/net"
+
+// IsWireless reports whether the network interface is a Wi-Fi interface
+func IsWireless(iface string) bool {
+	if iface == "" || strings.ContainsAny(iface, "/.") {
+		return false
+	}
+	for _, entry := range []string{"wireless", "phy80211"} {
+		if _, err := os.Stat(filepath.Join(sysClassNet, iface, entry)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// GetStationDump lists the stations a wireless interface is associated with
+func (nc *NetworkCommands) GetStationDump(ctx context.Context, iface string) (*CommandResult, error) {
+	return nc.executor.ExecuteWithContext(ctx, "iw", "dev", iface, "station", "dump")
+}
+
+// ParseStationDump parses 'iw dev <interface> station dump' output. A client
+// interface lists a single station, its access point; an empty list means
+// the interface is not associated.
+func (p *Parser) ParseStationDump(output string) ([]WirelessStation, error) {
+	var stations []WirelessStation
+	var current *WirelessStation
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		// "Station aa:bb:cc:dd:ee:ff (on wlan0)" starts a new station
+		if strings.HasPrefix(trimmed, "Station ") {
+			fields := strings.Fields(trimmed)
+			station := WirelessStation{MAC: fields[1]}
+			if len(fields) >= 4 && fields[2] == "(on" {
+				station.Interface = strings.TrimSuffix(fields[3], ")")
+			}
+			stations = append(stations, station)
+			current = &stations[len(stations)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "signal":
+			current.Signal = leadingInt(value)
+		case "signal avg":
+			current.SignalAverage = leadingInt(value)
+		case "tx bitrate":
+			current.TxBitrate = leadingFloat(value)
+		case "rx bitrate":
+			current.RxBitrate = leadingFloat(value)
+		case "tx packets":
+			current.TxPackets = leadingInt(value)
+		case "tx retries":
+			current.TxRetries = leadingInt(value)
+		case "tx failed":
+			current.TxFailed = leadingInt(value)
+		case "inactive time":
+			current.InactiveMs = leadingInt(value)
+		}
+	}
+
+	return stations, nil
+}
+
+// ParseDefaultInterface returns the interface of the default route in
+// 'ip route show' output, e.g. "default via 192.168.0.1 dev wlan0"
+func (p *Parser) ParseDefaultInterface(output string) string {
+	if isJSONOutput(output) {
+		routes, _, _ := p.parseJSONRoutingTable(output)
+		for _, route := range routes {
+			if route.Destination == "default" {
+				return route.Interface
+			}
+		}
+		return ""
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "default" {
+			continue
+		}
+		for i := 1; i+1 < len(fields); i++ {
+			if fields[i] == "dev" {
+				return fields[i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// leadingInt parses the number at the start of value, e.g. "-61 [-61, -63] dBm"
+func leadingInt(value string) int {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(fields[0])
+	return n
+}
+
+// leadingFloat parses the number at the start of value, e.g. "866.7 MBit/s VHT-MCS 9"
+func leadingFloat(value string) float64 {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+	n, _ := strconv.ParseFloat(fields[0], 64)
+	return n
+}
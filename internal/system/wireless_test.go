@@ -0,0 +1,89 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseStationDump(t *testing.T) {
+	parser := NewParser("linux")
+
+	output := `Station 3c:84:6a:12:34:56 (on wlp2s0)
+	inactive time:	1204 ms
+	rx bytes:	183423321
+	rx packets:	152341
+	tx bytes:	20451293
+	tx packets:	80000
+	tx retries:	5342
+	tx failed:	12000
+	beacon loss:	0
+	signal:  	-81 [-83, -84] dBm
+	signal avg:	-80 [-82, -83] dBm
+	tx bitrate:	6.5 MBit/s MCS 0
+	rx bitrate:	13.0 MBit/s MCS 1
+	authorized:	yes
+	associated at:	1714570000000 ms`
+
+	stations, err := parser.ParseStationDump(output)
+	if err != nil {
+		t.Fatalf("ParseStationDump failed: %v", err)
+	}
+	if len(stations) != 1 {
+		t.Fatalf("Expected 1 station, got %d", len(stations))
+	}
+
+	station := stations[0]
+	if station.MAC != "3c:84:6a:12:34:56" || station.Interface != "wlp2s0" {
+		t.Errorf("Unexpected station %s on %s", station.MAC, station.Interface)
+	}
+	if station.Signal != -81 || station.SignalAverage != -80 {
+		t.Errorf("Expected signal -81/-80 dBm, got %d/%d", station.Signal, station.SignalAverage)
+	}
+	if station.TxBitrate != 6.5 || station.RxBitrate != 13.0 {
+		t.Errorf("Unexpected bitrates %v/%v", station.TxBitrate, station.RxBitrate)
+	}
+	if station.TxPackets != 80000 || station.TxRetries != 5342 || station.TxFailed != 12000 || station.InactiveMs != 1204 {
+		t.Errorf("Unexpected counters %+v", station)
+	}
+	if rate := station.TxFailureRate(); rate != 0.15 {
+		t.Errorf("Expected a 15%% failure rate, got %v", rate)
+	}
+
+	// A disassociated interface prints nothing
+	if stations, _ := parser.ParseStationDump(""); len(stations) != 0 {
+		t.Errorf("Expected no stations, got %d", len(stations))
+	}
+}
+
+func TestParseDefaultInterface(t *testing.T) {
+	parser := NewParser("linux")
+
+	output := `default via 192.168.0.1 dev wlan0 proto dhcp src 192.168.0.23 metric 600
+192.168.0.0/24 dev wlan0 proto kernel scope link src 192.168.0.23 metric 600`
+	if iface := parser.ParseDefaultInterface(output); iface != "wlan0" {
+		t.Errorf("Expected wlan0, got %q", iface)
+	}
+	if iface := parser.ParseDefaultInterface("10.0.0.0/8 dev eth0"); iface != "" {
+		t.Errorf("Expected no interface without a default route, got %q", iface)
+	}
+}
+
+func TestIsWireless(t *testing.T) {
+	root := t.TempDir()
+	original := sysClassNet
+	sysClassNet = root
+	defer func() { sysClassNet = original }()
+
+	for _, dir := range []string{"eth0", "wlan0/wireless", "wlp2s0/phy80211"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("Failed to create fixture: %v", err)
+		}
+	}
+
+	for iface, want := range map[string]bool{"eth0": false, "wlan0": true, "wlp2s0": true, "": false, "../wlan0": false} {
+		if got := IsWireless(iface); got != want {
+			t.Errorf("IsWireless(%q) = %v, want %v", iface, got, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package systemd integrates the watchdog with systemd: it reports readiness
+// and watchdog keep-alives over the notify socket of a Type=notify unit, and
+// renders the unit and the directories that the install command sets up.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Notifier reports the service state to systemd through $NOTIFY_SOCKET.
+// Outside a Type=notify unit there is no socket and its methods do nothing.
+type Notifier struct {
+	conn     net.Conn
+	watchdog time.Duration
+}
+
+// NewNotifier connects to the socket in $NOTIFY_SOCKET, if any, and reads
+// the watchdog timeout from $WATCHDOG_USEC. Connect before the sandbox is
+// applied, which may no longer allow it.
+func NewNotifier() (*Notifier, error) {
+	n := &Notifier{}
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return n, nil
+	}
+
+	// Go reads a leading @ as an abstract socket, as systemd means it
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return n, fmt.Errorf("failed to connect to systemd notify socket: %w", err)
+	}
+	n.conn = conn
+	n.watchdog = watchdogTimeout()
+	return n, nil
+}
+
+// watchdogTimeout returns the timeout in $WATCHDOG_USEC if it is meant for
+// this process, or 0
+func watchdogTimeout() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// Enabled reports whether systemd listens for notifications
+func (n *Notifier) Enabled() bool {
+	return n.conn != nil
+}
+
+// WatchdogTimeout is the WatchdogSec= of the unit, or 0 when the systemd
+// watchdog is off; keep-alives must be sent more often than that
+func (n *Notifier) WatchdogTimeout() time.Duration {
+	return n.watchdog
+}
+
+// Notify sends state, such as READY=1, to systemd
+func (n *Notifier) Notify(state string) error {
+	if n.conn == nil {
+		return nil
+	}
+	if _, err := n.conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("failed to notify systemd: %w", err)
+	}
+	return nil
+}
+
+// Ready tells systemd that startup finished
+func (n *Notifier) Ready() error {
+	return n.Notify("READY=1")
+}
+
+// Stopping tells systemd that shutdown began
+func (n *Notifier) Stopping() error {
+	return n.Notify("STOPPING=1")
+}
+
+// Watchdog sends a watchdog keep-alive
+func (n *Notifier) Watchdog() error {
+	return n.Notify("WATCHDOG=1")
+}
+
+// ExtendTimeout asks systemd to wait d longer for the current start or stop
+// before timing it out
+func (n *Notifier) ExtendTimeout(d time.Duration) error {
+	return n.Notify(fmt.Sprintf("EXTEND_TIMEOUT_USEC=%d", d.Microseconds()))
+}
+
+// Status sets the status line shown by systemctl status
+func (n *Notifier) Status(status string) error {
+	return n.Notify("STATUS=" + status)
+}
+
+// Close closes the notify socket
+func (n *Notifier) Close() error {
+	if n.conn == nil {
+		return nil
+	}
+	return n.conn.Close()
+}
@@ -0,0 +1,174 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/config"
+)
+
+func TestNotifierSendsState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify")
+	listener, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+
+	notifier, err := NewNotifier()
+	if err != nil {
+		t.Fatalf("NewNotifier failed: %v", err)
+	}
+	defer notifier.Close()
+
+	if !notifier.Enabled() {
+		t.Error("Expected the notifier to be enabled")
+	}
+	if got := notifier.WatchdogTimeout(); got != 30*time.Second {
+		t.Errorf("Expected a watchdog timeout of 30s, got %s", got)
+	}
+
+	buf := make([]byte, 64)
+	for _, tt := range []struct {
+		send func() error
+		want string
+	}{
+		{notifier.Ready, "READY=1"},
+		{notifier.Watchdog, "WATCHDOG=1"},
+		{func() error { return notifier.Status("checking") }, "STATUS=checking"},
+		{notifier.Stopping, "STOPPING=1"},
+	} {
+		if err := tt.send(); err != nil {
+			t.Fatalf("Failed to send %s: %v", tt.want, err)
+		}
+		listener.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := listener.Read(buf)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", tt.want, err)
+		}
+		if got := string(buf[:n]); got != tt.want {
+			t.Errorf("Expected %s, got %s", tt.want, got)
+		}
+	}
+}
+
+func TestNotifierWithoutSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	t.Setenv("WATCHDOG_USEC", "30000000")
+
+	notifier, err := NewNotifier()
+	if err != nil {
+		t.Fatalf("NewNotifier failed: %v", err)
+	}
+	if notifier.Enabled() || notifier.WatchdogTimeout() != 0 {
+		t.Error("Expected a disabled notifier without a socket")
+	}
+	if err := notifier.Ready(); err != nil {
+		t.Errorf("Expected notifications to be dropped, got %v", err)
+	}
+	if err := notifier.Close(); err != nil {
+		t.Errorf("Expected Close to succeed, got %v", err)
+	}
+}
+
+func TestWatchdogTimeoutForOtherProcess(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	if got := watchdogTimeout(); got != 0 {
+		t.Errorf("Expected no timeout for another process, got %s", got)
+	}
+}
+
+func TestRenderUnit(t *testing.T) {
+	opts := DefaultUnitOptions()
+	unit := RenderUnit(opts)
+	for _, want := range []string{
+		"Type=notify\n",
+		"User=watchdog\n",
+		"ExecStart=/opt/mb8600-watchdog/bin/watchdog --config /etc/mb8600-watchdog/config.json\n",
+		"WatchdogSec=120\n",
+		"StateDirectory=mb8600-watchdog\n",
+		"AmbientCapabilities=CAP_NET_RAW\n",
+		"CapabilityBoundingSet=CAP_NET_RAW\n",
+		"ProtectSystem=strict\n",
+		"NoNewPrivileges=true\n",
+		"WantedBy=multi-user.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("Expected the unit to contain %q:\n%s", want, unit)
+		}
+	}
+
+	opts.WatchdogSec = 0
+	if unit := RenderUnit(opts); strings.Contains(unit, "WatchdogSec") {
+		t.Errorf("Expected no WatchdogSec when disabled:\n%s", unit)
+	}
+}
+
+func TestRenderUnitHardening(t *testing.T) {
+	opts := DefaultUnitOptions()
+	unit := RenderUnit(opts)
+	for _, want := range []string{"ProtectHome=true\n", "PrivateDevices=true\n"} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("Expected the unit to contain %q without serial devices or tunnel:\n%s", want, unit)
+		}
+	}
+	if strings.Contains(unit, "DeviceAllow") {
+		t.Errorf("Expected no DeviceAllow without serial devices:\n%s", unit)
+	}
+
+	opts.Devices = []string{"/dev/ttyUSB2"}
+	opts.SSHTunnel = true
+	unit = RenderUnit(opts)
+	for _, want := range []string{"ProtectHome=read-only\n", "DevicePolicy=closed\n", "DeviceAllow=/dev/ttyUSB2 rw\n", "SupplementaryGroups=dialout\n"} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("Expected the unit to contain %q:\n%s", want, unit)
+		}
+	}
+	for _, unwanted := range []string{"ProtectHome=true", "PrivateDevices=true"} {
+		if strings.Contains(unit, unwanted) {
+			t.Errorf("Expected no %q with a serial device and tunnel:\n%s", unwanted, unit)
+		}
+	}
+}
+
+func TestComposeSnippet(t *testing.T) {
+	snippet := ComposeSnippet(DefaultCapabilities)
+	for _, want := range []string{"read_only: true", "cap_add:\n      - NET_RAW\n", "network_mode: host", "STATE_DIRECTORY: /state"} {
+		if !strings.Contains(snippet, want) {
+			t.Errorf("Expected the snippet to contain %q:\n%s", want, snippet)
+		}
+	}
+	if snippet := ComposeSnippet(nil); strings.Contains(snippet, "cap_add") {
+		t.Errorf("Expected no cap_add without capabilities:\n%s", snippet)
+	}
+}
+
+func TestPrepareDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state", "nested")
+	if err := PrepareDirectory(dir, os.Getuid(), os.Getgid(), 0750); err != nil {
+		t.Fatalf("PrepareDirectory failed: %v", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() || info.Mode().Perm() != 0750 {
+		t.Errorf("Expected a directory with mode 0750, got %v", info.Mode())
+	}
+}
+
+func TestDefaultConfigMatchesSchema(t *testing.T) {
+	if err := config.ValidateSchema(DefaultConfig()); err != nil {
+		t.Errorf("Starter configuration does not match the schema: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Install defaults, matching config/production.json
+const (
+	DefaultUnitName    = "mb8600-watchdog"
+	DefaultUnitDir     = "/etc/systemd/system"
+	DefaultBinary      = "/opt/mb8600-watchdog/bin/watchdog"
+	DefaultConfigFile  = "/etc/mb8600-watchdog/config.json"
+	DefaultUser        = "watchdog"
+	DefaultStateDir    = "/var/lib/mb8600-watchdog"
+	DefaultLogDir      = "/var/log/mb8600-watchdog"
+	DefaultRuntimeDir  = "/run/mb8600-watchdog"
+	DefaultWatchdogSec = 2 * time.Minute
+)
+
+// DefaultCapabilities lets the watchdog send ICMP echo requests itself
+var DefaultCapabilities = []string{"CAP_NET_RAW"}
+
+// UnitOptions describe the service unit to render
+type UnitOptions struct {
+	Binary     string
+	ConfigFile string
+	User       string
+	// WatchdogSec restarts the service when it sends no keep-alive for that
+	// long; 0 turns the systemd watchdog off
+	WatchdogSec time.Duration
+	// Capabilities are granted to the service user; all others are dropped
+	Capabilities []string
+	// Devices are the serial devices the service opens, such as the GSM
+	// modem of SMSDevice; they are allowed instead of hiding all devices
+	Devices []string
+	// SSHTunnel keeps home directories readable for the keys and known hosts
+	// of ModemTunnelSSH instead of hiding them
+	SSHTunnel bool
+}
+
+// DefaultUnitOptions returns the options of the standard install
+func DefaultUnitOptions() UnitOptions {
+	return UnitOptions{
+		Binary:       DefaultBinary,
+		ConfigFile:   DefaultConfigFile,
+		User:         DefaultUser,
+		WatchdogSec:  DefaultWatchdogSec,
+		Capabilities: append([]string(nil), DefaultCapabilities...),
+	}
+}
+
+// RenderUnit returns a hardened Type=notify service unit. State, logs and
+// the PID file go to directories systemd creates for the service user; the
+// rest of the filesystem is read-only. Home directories and devices are
+// hidden unless the SSH tunnel or a serial device needs them.
+func RenderUnit(opts UnitOptions) string {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format+"\n", args...)
+	}
+
+	line("[Unit]")
+	line("Description=MB8600 Watchdog - Watchdog Service")
+	line("After=network-online.target")
+	line("Wants=network-online.target")
+	line("")
+	line("[Service]")
+	line("Type=notify")
+	line("NotifyAccess=main")
+	line("User=%s", opts.User)
+	line("Group=%s", opts.User)
+	line("ExecStart=%s --config %s", opts.Binary, opts.ConfigFile)
+	line("Environment=ENABLE_SYSTEMD=true")
+	line("Restart=always")
+	line("RestartSec=5")
+	if opts.WatchdogSec > 0 {
+		line("WatchdogSec=%d", int64(opts.WatchdogSec/time.Second))
+	}
+	line("")
+	line("StateDirectory=%s", DefaultUnitName)
+	line("RuntimeDirectory=%s", DefaultUnitName)
+	line("LogsDirectory=%s", DefaultUnitName)
+	line("")
+	caps := strings.Join(opts.Capabilities, " ")
+	line("AmbientCapabilities=%s", caps)
+	line("CapabilityBoundingSet=%s", caps)
+	line("NoNewPrivileges=true")
+	line("ProtectSystem=strict")
+	if opts.SSHTunnel {
+		line("ProtectHome=read-only")
+	} else {
+		line("ProtectHome=true")
+	}
+	line("PrivateTmp=true")
+	if len(opts.Devices) > 0 {
+		// PrivateDevices would hide the devices, so only they are allowed
+		line("DevicePolicy=closed")
+		for _, device := range opts.Devices {
+			line("DeviceAllow=%s rw", device)
+		}
+		line("SupplementaryGroups=dialout")
+	} else {
+		line("PrivateDevices=true")
+	}
+	line("ProtectKernelTunables=true")
+	line("ProtectKernelModules=true")
+	line("ProtectKernelLogs=true")
+	line("ProtectControlGroups=true")
+	line("ProtectClock=true")
+	line("ProtectHostname=true")
+	line("RestrictAddressFamilies=AF_UNIX AF_INET AF_INET6 AF_NETLINK")
+	line("RestrictNamespaces=true")
+	line("RestrictRealtime=true")
+	line("RestrictSUIDSGID=true")
+	line("LockPersonality=true")
+	line("MemoryDenyWriteExecute=true")
+	line("SystemCallArchitectures=native")
+	line("")
+	line("StandardOutput=journal")
+	line("StandardError=journal")
+	line("")
+	line("[Install]")
+	line("WantedBy=multi-user.target")
+	return b.String()
+}
+
+// ComposeSnippet returns a Docker Compose service running the watchdog with
+// the same confinement as the unit: a read-only root, host networking to
+// reach the modem and only the given capabilities
+func ComposeSnippet(capabilities []string) string {
+	var b strings.Builder
+	b.WriteString(`services:
+  mb8600-watchdog:
+    image: mb8600-watchdog
+    restart: unless-stopped
+    network_mode: host
+    read_only: true
+    security_opt:
+      - no-new-privileges:true
+    cap_drop:
+      - ALL
+`)
+	if len(capabilities) > 0 {
+		b.WriteString("    cap_add:\n")
+		for _, capability := range capabilities {
+			fmt.Fprintf(&b, "      - %s\n", strings.TrimPrefix(capability, "CAP_"))
+		}
+	}
+	b.WriteString(`    environment:
+      STATE_DIRECTORY: /state
+      LOG_FILE: /state/logs/watchdog.log
+      MODEM_HOST: 192.168.100.1
+      MODEM_PASSWORD: YOUR_MODEM_PASSWORD
+    volumes:
+      - watchdog-state:/state
+
+volumes:
+  watchdog-state:
+`)
+	return b.String()
+}
+
+// PrepareDirectory creates path if needed and gives it to uid and gid with
+// mode, so the service user can write to it before systemd first starts the
+// unit and CLI commands find the state of the service
+func PrepareDirectory(path string, uid, gid int, mode os.FileMode) error {
+	if err := os.MkdirAll(path, mode); err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := os.Chown(path, uid, gid); err != nil {
+		return fmt.Errorf("failed to set owner of %s: %w", path, err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("failed to set mode of %s: %w", path, err)
+	}
+	return nil
+}
+
+// DefaultConfig returns a configuration file for a new install that keeps
+// state, logs and the PID file in the directories of the unit
+func DefaultConfig() []byte {
+	return []byte(`{
+  "ModemHost": "192.168.100.1",
+  "ModemUsername": "admin",
+  "ModemPassword": "YOUR_MODEM_PASSWORD",
+  "ModemNoVerify": true,
+
+  "LogFormat": "json",
+  "LogFile": "` + DefaultLogDir + `/watchdog.log",
+  "LogRotation": true,
+
+  "EnableSystemd": true,
+  "PidFile": "` + DefaultRuntimeDir + `/watchdog.pid",
+  "StateDirectory": "` + DefaultStateDir + `"
+}
+`)
+}
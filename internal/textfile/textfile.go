@@ -0,0 +1,56 @@
+// Package textfile writes metrics for the textfile collector of the
+// Prometheus node_exporter, which exposes the *.prom files of a directory
+// with the metrics of the host. A file is replaced through a temporary file
+// and a rename, so the collector never reads it half written.
+package textfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultInterval is how often the file is rewritten unless configured
+const DefaultInterval = 30 * time.Second
+
+// Extension is the extension the collector reads files with
+const Extension = ".prom"
+
+// Write replaces the file at path with what write writes. The temporary
+// file does not end in .prom, so the collector skips it while it is being
+// written; a failed write leaves the previous file in place.
+func Write(path string, write func(io.Writer) error) error {
+	if !strings.HasSuffix(path, Extension) {
+		return fmt.Errorf("textfile %s must end in %s", path, Extension)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write textfile: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	out := bufio.NewWriter(tmp)
+	if err := write(out); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write textfile: %w", err)
+	}
+	if err := out.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write textfile: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write textfile: %w", err)
+	}
+	// node_exporter usually runs as another user
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write textfile: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write textfile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package textfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mb8600_watchdog.prom")
+
+	for _, value := range []int{1, 2} {
+		err := Write(path, func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "watchdog_up %d\n", value)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read textfile: %v", err)
+		}
+		if want := fmt.Sprintf("watchdog_up %d\n", value); string(data) != want {
+			t.Errorf("Expected %q, got %q", want, data)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Expected the collector to be able to read the file, got mode %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files left, got %d entries", len(entries))
+	}
+}
+
+func TestWriteFailureKeepsPreviousFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mb8600_watchdog.prom")
+	if err := os.WriteFile(path, []byte("watchdog_up 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Write(path, func(w io.Writer) error {
+		fmt.Fprint(w, "watchdog_up")
+		return errors.New("state unavailable")
+	})
+	if err == nil {
+		t.Fatal("Expected the write to fail")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "watchdog_up 1\n" {
+		t.Errorf("Expected the previous file to be kept, got %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Expected no temporary files left, got %d entries", len(entries))
+	}
+}
+
+func TestWriteRequiresPromExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watchdog.txt")
+	if err := Write(path, func(io.Writer) error { return nil }); err == nil {
+		t.Error("Expected a file the collector would not read to be refused")
+	}
+}
@@ -0,0 +1,419 @@
+// Package top is the live terminal view of the watchdog top command. It
+// polls the running service for its state and history, and shows the
+// service state, the last checks with sparklines of their latency and
+// health score, and the recent events.
+package top
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/i18n"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+)
+
+// Defaults of Options
+const (
+	DefaultInterval = 2 * time.Second
+	DefaultEvents   = 8
+)
+
+// samples is the number of checks the sparklines cover at most
+const samples = 60
+
+// eventWindow is how far back events and check results are read
+const eventWindow = 24 * time.Hour
+
+// eventKinds are the events listed; check results go to the sparklines
+// instead, and public IP observations repeat every few cycles
+var eventKinds = []string{
+	history.KindRebootDecision,
+	history.KindIPChange,
+	history.KindFirmwareChange,
+	history.KindAnnotation,
+}
+
+// Source is the running service the view polls, such as its control socket
+type Source interface {
+	Status() (monitor.ServiceState, error)
+	// Events returns at most limit of the newest events of kinds recorded
+	// within since, oldest first
+	Events(since time.Duration, limit int, kinds ...string) ([]history.Event, error)
+}
+
+// Options configure the view
+type Options struct {
+	// Interval is the time between polls, DefaultInterval when zero
+	Interval time.Duration
+	// Events is the number of recent events listed, DefaultEvents when zero
+	Events int
+	// Describe summarizes an event for the list, by default its details
+	Describe func(history.Event) string
+}
+
+// sample is one check in the sparklines
+type sample struct {
+	time       time.Time
+	success    bool
+	durationMS float64
+	// health is the health score of the check, NaN when not known, as for
+	// checks read from the history
+	health float64
+}
+
+// Model is the bubbletea model of the view
+type Model struct {
+	source  Source
+	options Options
+	profile termenv.Profile
+
+	state   monitor.ServiceState
+	polled  time.Time
+	err     error
+	checks  []sample
+	events  []history.Event
+	width   int
+	started bool
+}
+
+// pollMsg is the outcome of a poll
+type pollMsg struct {
+	time   time.Time
+	state  monitor.ServiceState
+	events []history.Event
+	// checks are the check results of the history, read on the first poll
+	checks []history.Event
+	err    error
+	// manual marks a poll requested with r, which leaves the next tick as
+	// it is
+	manual bool
+}
+
+// tickMsg starts the next poll
+type tickMsg struct{}
+
+// New creates the view of source
+func New(source Source, options Options) *Model {
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+	if options.Events <= 0 {
+		options.Events = DefaultEvents
+	}
+	if options.Describe == nil {
+		options.Describe = describe
+	}
+	return &Model{source: source, options: options, profile: termenv.EnvColorProfile()}
+}
+
+// Init starts the first poll
+func (m *Model) Init() tea.Cmd {
+	return m.poll(true, false)
+}
+
+// poll reads the state and events of the service, and the check results of
+// the history with backfill
+func (m *Model) poll(backfill, manual bool) tea.Cmd {
+	source, limit := m.source, m.options.Events
+	return func() tea.Msg {
+		msg := pollMsg{time: time.Now(), manual: manual}
+		if msg.state, msg.err = source.Status(); msg.err != nil {
+			return msg
+		}
+		if msg.events, msg.err = source.Events(eventWindow, limit, eventKinds...); msg.err != nil {
+			return msg
+		}
+		if backfill {
+			msg.checks, msg.err = source.Events(eventWindow, samples, history.KindCheck)
+		}
+		return msg
+	}
+}
+
+// Update handles keys, resizes and polls
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "r":
+			return m, m.poll(!m.started, true)
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tickMsg:
+		return m, m.poll(!m.started, false)
+	case pollMsg:
+		m.apply(msg)
+		if msg.manual {
+			return m, nil
+		}
+		return m, tea.Tick(m.options.Interval, func(time.Time) tea.Msg { return tickMsg{} })
+	}
+	return m, nil
+}
+
+// apply records the outcome of a poll. The sparklines start from the check
+// results of the history and grow with every check the service runs after.
+func (m *Model) apply(msg pollMsg) {
+	m.polled, m.err = msg.time, msg.err
+	if msg.err != nil {
+		return
+	}
+	m.state, m.events = msg.state, msg.events
+
+	if !m.started {
+		m.started = true
+		for _, event := range msg.checks {
+			success, _ := event.Details["success"].(bool)
+			duration, _ := event.Details["duration_ms"].(float64)
+			m.addSample(sample{time: event.Time, success: success, durationMS: duration, health: math.NaN()})
+		}
+	}
+	if check := m.state.Check; check != nil && (len(m.checks) == 0 || check.Timestamp.After(m.checks[len(m.checks)-1].time)) {
+		m.addSample(sample{
+			time:       check.Timestamp,
+			success:    check.OverallSuccess,
+			durationMS: float64(check.TotalDurationMS),
+			health:     m.state.HealthScore,
+		})
+	}
+}
+
+// addSample adds a check to the sparklines, dropping the oldest beyond
+// samples
+func (m *Model) addSample(s sample) {
+	m.checks = append(m.checks, s)
+	if len(m.checks) > samples {
+		m.checks = m.checks[len(m.checks)-samples:]
+	}
+}
+
+// View renders the view
+func (m *Model) View() string {
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	now := m.polled
+	if now.IsZero() {
+		now = time.Now()
+	}
+	add("%s · %s   %s", m.bold(i18n.T("top.title")), m.stateText(), now.Local().Format("15:04:05"))
+	if m.err != nil {
+		add("%s", m.color("1", i18n.T("top.error", m.err)))
+	}
+	if !m.started {
+		add("")
+		add("%s", i18n.T("top.connecting"))
+		return m.render(lines)
+	}
+
+	state := m.state
+	add("")
+	if state.ModemModel != "" {
+		modem := state.ModemModel
+		if state.ModemFirmware != "" {
+			modem += " · " + state.ModemFirmware
+		}
+		if state.ModemTemperature != nil {
+			modem += fmt.Sprintf(" · %.1f°C", *state.ModemTemperature)
+		}
+		add("%-9s %s", i18n.T("top.modem"), modem)
+	}
+	health := state.Health
+	if health == "" {
+		health = "-"
+	}
+	add("%-9s %s %.0f · %s", i18n.T("top.health"), m.healthText(health), state.HealthScore,
+		i18n.T("top.counts", state.FailureCount, state.TotalChecks, state.TotalReboots))
+	if check := state.Check; check != nil {
+		add("%-9s %s %s %s %dms", i18n.T("top.last_check"), check.Timestamp.Local().Format("15:04:05"),
+			check.Strategy, m.result(check.OverallSuccess), check.TotalDurationMS)
+	}
+	if reboot := state.Reboot; reboot != nil {
+		add("%-9s %s", i18n.T("top.reboot"), i18n.T("top.reboot_progress", reboot.Phase, reboot.Trigger,
+			reboot.Started.Local().Format("15:04:05"), reboot.Remaining(now).Round(time.Second)))
+	}
+	if pause := state.Pause; pause != nil {
+		add("%-9s %s", i18n.T("top.paused"), i18n.T("top.paused_until", pause.Until.Local().Format("15:04"), pause.Reason))
+	}
+
+	width := samples
+	if m.width > 0 && m.width-10 < width {
+		width = m.width - 10
+	}
+	checks := m.checks
+	if width > 0 && len(checks) > width {
+		checks = checks[len(checks)-width:]
+	}
+	if len(checks) > 0 {
+		var results strings.Builder
+		durations := make([]float64, len(checks))
+		scores := make([]float64, 0, len(checks))
+		low, high := math.Inf(1), math.Inf(-1)
+		for i, check := range checks {
+			results.WriteString(m.result(check.success))
+			durations[i] = check.durationMS
+			low, high = math.Min(low, check.durationMS), math.Max(high, check.durationMS)
+			if !math.IsNaN(check.health) {
+				scores = append(scores, check.health)
+			}
+		}
+		add("")
+		add("%-9s %s", i18n.T("top.checks"), results.String())
+		add("%-9s %s %.0f-%.0fms", i18n.T("top.latency"), Sparkline(durations), low, high)
+		if len(scores) > 1 {
+			add("%-9s %s", i18n.T("top.score"), Sparkline(scores))
+		}
+	}
+
+	add("")
+	add("%s", m.bold(i18n.T("top.events")))
+	if len(m.events) == 0 {
+		add("  %s", i18n.T("top.no_events"))
+	}
+	for i := len(m.events) - 1; i >= 0; i-- {
+		event := m.events[i]
+		add("  %s  %-16s %s", event.Time.Local().Format("01-02 15:04"), event.Kind, m.options.Describe(event))
+	}
+
+	add("")
+	add("%s", m.faint(i18n.T("top.keys")))
+	return m.render(lines)
+}
+
+// render joins lines, cut to the width of the terminal
+func (m *Model) render(lines []string) string {
+	if m.width > 0 {
+		for i, line := range lines {
+			lines[i] = truncate(line, m.width)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// stateText is the state of the service: stopped, rebooting or recovering,
+// paused, standing by in a cluster, failing, or monitoring
+func (m *Model) stateText() string {
+	if !m.started {
+		return "…"
+	}
+	state := m.state
+	switch {
+	case !state.IsRunning:
+		return m.color("1", i18n.T("top.state.stopped"))
+	case state.Reboot != nil:
+		return m.color("3", i18n.T("top.state."+state.Reboot.Phase))
+	case state.Pause != nil:
+		return m.color("3", i18n.T("top.state.paused"))
+	case state.Cluster != nil && !state.Cluster.Active:
+		return i18n.T("top.state.standby")
+	case state.FailureCount > 0:
+		return m.color("1", i18n.T("top.state.failing", state.FailureCount))
+	}
+	return m.color("2", i18n.T("top.state.monitoring"))
+}
+
+// healthText colors the health status
+func (m *Model) healthText(health string) string {
+	switch health {
+	case "HEALTHY":
+		return m.color("2", health)
+	case "DEGRADED":
+		return m.color("3", health)
+	case "UNHEALTHY":
+		return m.color("1", health)
+	}
+	return health
+}
+
+// result is the mark of a passed or failed check
+func (m *Model) result(success bool) string {
+	if success {
+		return m.color("2", "●")
+	}
+	return m.color("1", "○")
+}
+
+func (m *Model) color(color, text string) string {
+	return m.profile.String(text).Foreground(m.profile.Color(color)).String()
+}
+
+func (m *Model) bold(text string) string {
+	return m.profile.String(text).Bold().String()
+}
+
+func (m *Model) faint(text string) string {
+	return m.profile.String(text).Faint().String()
+}
+
+// sparks are the bars of a sparkline, lowest first
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values as a line of bars scaled from their minimum to
+// their maximum; equal values draw the lowest bar
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	low, high := values[0], values[0]
+	for _, value := range values {
+		low, high = math.Min(low, value), math.Max(high, value)
+	}
+	line := make([]rune, len(values))
+	for i, value := range values {
+		level := 0
+		if high > low {
+			level = int(math.Round((value - low) / (high - low) * float64(len(sparks)-1)))
+		}
+		line[i] = sparks[level]
+	}
+	return string(line)
+}
+
+// describe lists the details of an event as key=value pairs
+func describe(event history.Event) string {
+	parts := make([]string, 0, len(event.Details))
+	for key, value := range event.Details {
+		parts = append(parts, fmt.Sprintf("%s=%v", key, value))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+// truncate cuts line to width visible characters, skipping ANSI escape
+// sequences so colors are kept
+func truncate(line string, width int) string {
+	var out strings.Builder
+	visible, escape := 0, false
+	for _, r := range line {
+		switch {
+		case escape:
+			out.WriteRune(r)
+			escape = (r < 'a' || r > 'z') && (r < 'A' || r > 'Z')
+			continue
+		case r == '\x1b':
+			out.WriteRune(r)
+			escape = true
+			continue
+		}
+		if visible == width {
+			if strings.ContainsRune(line, '\x1b') {
+				out.WriteString("\x1b[0m")
+			}
+			break
+		}
+		out.WriteRune(r)
+		visible++
+	}
+	return out.String()
+}
@@ -0,0 +1,171 @@
+package top
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+	"github.com/perezjoseph/mb8600-watchdog/internal/connectivity"
+	"github.com/perezjoseph/mb8600-watchdog/internal/history"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+)
+
+// stubSource is a service with a state and history
+type stubSource struct {
+	state  monitor.ServiceState
+	events []history.Event
+	checks []history.Event
+	err    error
+}
+
+func (s *stubSource) Status() (monitor.ServiceState, error) {
+	return s.state, s.err
+}
+
+func (s *stubSource) Events(since time.Duration, limit int, kinds ...string) ([]history.Event, error) {
+	if len(kinds) == 1 && kinds[0] == history.KindCheck {
+		return s.checks, nil
+	}
+	return s.events, nil
+}
+
+func newTestModel(source Source) *Model {
+	m := New(source, Options{})
+	m.profile = termenv.Ascii
+	return m
+}
+
+// run runs cmd and feeds its message to m
+func run(t *testing.T, m *Model, cmd tea.Cmd) tea.Cmd {
+	t.Helper()
+	_, next := m.Update(cmd())
+	return next
+}
+
+func TestSparkline(t *testing.T) {
+	if line := Sparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}); line != "▁▂▃▄▅▆▇█" {
+		t.Errorf("Expected every bar, got %q", line)
+	}
+	if line := Sparkline([]float64{100, 100, 100}); line != "▁▁▁" {
+		t.Errorf("Expected equal values to draw the lowest bar, got %q", line)
+	}
+	if line := Sparkline(nil); line != "" {
+		t.Errorf("Expected an empty line, got %q", line)
+	}
+}
+
+func TestViewBackfillsChecksFromHistory(t *testing.T) {
+	now := time.Now()
+	source := &stubSource{
+		state: monitor.ServiceState{
+			IsRunning:     true,
+			ModemModel:    "MB8600",
+			ModemFirmware: "8600-19.3.18",
+			Health:        "HEALTHY",
+			HealthScore:   92,
+			TotalChecks:   120,
+			TotalReboots:  2,
+			Check: &connectivity.TestSummary{
+				Strategy:        "lightweight",
+				OverallSuccess:  true,
+				TotalDurationMS: 320,
+				Timestamp:       now.Add(-2 * time.Minute),
+			},
+		},
+		checks: []history.Event{
+			{Time: now.Add(-3 * time.Minute), Kind: history.KindCheck, Details: map[string]interface{}{"success": true, "duration_ms": 100.0}},
+			{Time: now.Add(-2 * time.Minute), Kind: history.KindCheck, Details: map[string]interface{}{"success": false, "duration_ms": 900.0}},
+		},
+		events: []history.Event{
+			{Time: now.Add(-time.Hour), Kind: history.KindRebootDecision, Details: map[string]interface{}{"outcome": "reboot"}},
+		},
+	}
+	m := newTestModel(source)
+	if next := run(t, m, m.Init()); next == nil {
+		t.Fatal("Expected the next poll to be scheduled")
+	}
+
+	// The check of the state is the last one of the history
+	if len(m.checks) != 2 {
+		t.Fatalf("Expected the 2 checks of the history, got %d", len(m.checks))
+	}
+
+	view := m.View()
+	for _, want := range []string{"monitoring", "MB8600 · 8600-19.3.18", "HEALTHY 92", "checks 120 · reboots 2", "lightweight ● 320ms", "●○", "▁█ 100-900ms", "reboot_decision  outcome=reboot"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the view:\n%s", want, view)
+		}
+	}
+
+	source.state.FailureCount = 1
+	source.state.HealthScore = 40
+	source.state.Check = &connectivity.TestSummary{Strategy: "tiered", TotalDurationMS: 5000, Timestamp: now}
+	m.apply(m.poll(false, false)().(pollMsg))
+	if len(m.checks) != 3 || m.checks[2].health != 40 {
+		t.Fatalf("Expected the new check to be added, got %+v", m.checks)
+	}
+	if view := m.View(); !strings.Contains(view, "failing (1)") || !strings.Contains(view, "●○○") {
+		t.Errorf("Expected the failed check in the view:\n%s", view)
+	}
+}
+
+func TestViewKeepsLastStateWhenUnreachable(t *testing.T) {
+	source := &stubSource{state: monitor.ServiceState{IsRunning: true, TotalChecks: 7}}
+	m := newTestModel(source)
+	run(t, m, m.Init())
+
+	source.err = errors.New("connection refused")
+	m.apply(m.poll(false, false)().(pollMsg))
+	view := m.View()
+	if !strings.Contains(view, "connection refused") || !strings.Contains(view, "checks 7") {
+		t.Errorf("Expected the error and the last state:\n%s", view)
+	}
+}
+
+func TestStateText(t *testing.T) {
+	tests := []struct {
+		state monitor.ServiceState
+		want  string
+	}{
+		{monitor.ServiceState{}, "stopped"},
+		{monitor.ServiceState{IsRunning: true, Reboot: &monitor.RebootWorkflow{Phase: monitor.RebootPhaseRecovering}}, "recovering"},
+		{monitor.ServiceState{IsRunning: true, Pause: &monitor.Pause{}}, "paused"},
+		{monitor.ServiceState{IsRunning: true, FailureCount: 2}, "failing (2)"},
+		{monitor.ServiceState{IsRunning: true}, "monitoring"},
+	}
+	for _, tt := range tests {
+		m := newTestModel(&stubSource{state: tt.state})
+		run(t, m, m.Init())
+		if got := m.stateText(); got != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, got)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	m := newTestModel(&stubSource{})
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Fatal("Expected q to quit")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("Expected q to quit")
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if next := run(t, m, cmd); next != nil {
+		t.Error("Expected a refresh to leave the schedule of polls as it is")
+	}
+}
+
+func TestTruncateKeepsColors(t *testing.T) {
+	line := termenv.ANSI.String("healthy").Foreground(termenv.ANSI.Color("2")).String() + " and more"
+	got := truncate(line, 4)
+	if !strings.HasPrefix(got, "\x1b[") || !strings.Contains(got, "heal") || strings.Contains(got, "healt") || !strings.HasSuffix(got, "\x1b[0m") {
+		t.Errorf("Unexpected truncation %q", got)
+	}
+	if got := truncate("plain text", 5); got != "plain" {
+		t.Errorf("Expected plain, got %q", got)
+	}
+}
@@ -0,0 +1,70 @@
+// Package trace ties together what one monitoring cycle produced. Every
+// cycle gets a short random ID that is attached to its log entries, test
+// results, diagnostics and history records, so they can be correlated
+// after the fact.
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Field is the log field and JSON key that carries the cycle ID
+const Field = "cycle_id"
+
+// NewCycleID returns a random 16 character hex cycle ID
+func NewCycleID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand does not fail on supported platforms; an empty ID
+		// only loses correlation
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Hook tags log entries with the ID of the cycle in progress. Entries that
+// already carry a cycle ID keep it.
+type Hook struct {
+	mu sync.RWMutex
+	id string
+}
+
+// NewHook creates a hook with no cycle in progress
+func NewHook() *Hook {
+	return &Hook{}
+}
+
+// Set makes id the cycle in progress; an empty id ends it
+func (h *Hook) Set(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.id = id
+}
+
+// ID returns the ID of the cycle in progress, or an empty string between
+// cycles
+func (h *Hook) ID() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.id
+}
+
+// Levels implements logrus.Hook
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data[Field]; ok {
+		return nil
+	}
+	if id := h.ID(); id != "" {
+		entry.Data[Field] = id
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package trace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNewCycleID(t *testing.T) {
+	a, b := NewCycleID(), NewCycleID()
+	if len(a) != 16 || a == b {
+		t.Errorf("Expected two distinct 16 character IDs, got %q and %q", a, b)
+	}
+}
+
+func TestHookTagsEntries(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	hook := NewHook()
+	logger.AddHook(hook)
+
+	logger.Info("before")
+	hook.Set("abc123")
+	logger.Info("during")
+	logger.WithField(Field, "other").Info("explicit")
+	hook.Set("")
+	logger.Info("after")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 log lines, got %q", lines)
+	}
+	if strings.Contains(lines[0], Field) || strings.Contains(lines[3], Field) {
+		t.Errorf("Expected no cycle ID outside a cycle, got %q", lines)
+	}
+	if !strings.Contains(lines[1], "cycle_id=abc123") {
+		t.Errorf("Expected the cycle ID on entries during the cycle, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "cycle_id=other") {
+		t.Errorf("Expected an explicit cycle ID to be kept, got %q", lines[2])
+	}
+}
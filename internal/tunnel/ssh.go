@@ -0,0 +1,181 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// exitWait bounds the wait for the error message of an ssh that closed the
+// connection
+const exitWait = time.Second
+
+// dialSSH connects to address with `ssh -W` through the jump host. The
+// connection is the standard input and output of the ssh process, which
+// ends when the connection closes.
+func (t *Tunnel) dialSSH(ctx context.Context, address string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Pipes from os.Pipe support deadlines, which TLS and HTTP rely on
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ssh pipe: %w", err)
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		stdinR.Close()
+		stdinW.Close()
+		return nil, fmt.Errorf("failed to create ssh pipe: %w", err)
+	}
+
+	conn := &sshConn{
+		stdin:   stdinW,
+		stdout:  stdoutR,
+		address: address,
+		exited:  make(chan struct{}),
+	}
+	cmd := exec.Command(t.ssh, t.sshArgs(address)...)
+	cmd.Stdin = stdinR
+	cmd.Stdout = stdoutW
+	cmd.Stderr = &conn.stderr
+
+	err = cmd.Start()
+	stdinR.Close()
+	stdoutW.Close()
+	if err != nil {
+		stdinW.Close()
+		stdoutR.Close()
+		return nil, fmt.Errorf("failed to start ssh to %s: %w", t.cfg.SSHHost, err)
+	}
+	conn.process = cmd.Process
+	go func() {
+		cmd.Wait()
+		close(conn.exited)
+	}()
+	return conn, nil
+}
+
+// sshArgs returns the arguments of ssh that forward a connection to address
+func (t *Tunnel) sshArgs(address string) []string {
+	args := []string{
+		"-W", address,
+		"-o", "BatchMode=yes",
+		"-o", fmt.Sprintf("ConnectTimeout=%d", int(dialTimeout.Seconds())),
+		"-o", "ServerAliveInterval=15",
+	}
+	if t.cfg.SSHKey != "" {
+		args = append(args, "-i", t.cfg.SSHKey, "-o", "IdentitiesOnly=yes")
+	}
+	if t.cfg.SSHKnownHosts != "" {
+		args = append(args, "-o", "UserKnownHostsFile="+t.cfg.SSHKnownHosts)
+	}
+
+	destination := t.cfg.SSHHost
+	if user, host, ok := strings.Cut(destination, "@"); ok {
+		args = append(args, "-l", user)
+		destination = host
+	}
+	if host, port, err := net.SplitHostPort(destination); err == nil {
+		args = append(args, "-p", port)
+		destination = host
+	}
+	return append(args, "--", destination)
+}
+
+// sshConn is a connection carried by the standard input and output of ssh
+type sshConn struct {
+	process *os.Process
+	stdin   *os.File
+	stdout  *os.File
+	stderr  lockedBuffer
+	address string
+	// exited closes when ssh has exited
+	exited chan struct{}
+
+	closeOnce sync.Once
+}
+
+// Read reads from the connection. When ssh exits, the error it printed,
+// such as a failed login or a refused forward, is returned instead of EOF.
+func (c *sshConn) Read(b []byte) (int, error) {
+	n, err := c.stdout.Read(b)
+	if err == io.EOF {
+		select {
+		case <-c.exited:
+		case <-time.After(exitWait):
+		}
+		if message := strings.TrimSpace(c.stderr.String()); message != "" {
+			return n, fmt.Errorf("ssh: %s", message)
+		}
+	}
+	return n, err
+}
+
+// Write writes to the connection
+func (c *sshConn) Write(b []byte) (int, error) {
+	return c.stdin.Write(b)
+}
+
+// Close closes the connection and ends ssh
+func (c *sshConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		c.stdout.Close()
+		c.process.Kill()
+		<-c.exited
+	})
+	return nil
+}
+
+// LocalAddr implements net.Conn
+func (c *sshConn) LocalAddr() net.Addr { return sshAddr("ssh") }
+
+// RemoteAddr implements net.Conn
+func (c *sshConn) RemoteAddr() net.Addr { return sshAddr(c.address) }
+
+// SetDeadline implements net.Conn
+func (c *sshConn) SetDeadline(t time.Time) error {
+	if err := c.stdout.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.stdin.SetWriteDeadline(t)
+}
+
+// SetReadDeadline implements net.Conn
+func (c *sshConn) SetReadDeadline(t time.Time) error { return c.stdout.SetReadDeadline(t) }
+
+// SetWriteDeadline implements net.Conn
+func (c *sshConn) SetWriteDeadline(t time.Time) error { return c.stdin.SetWriteDeadline(t) }
+
+// sshAddr is the address of either end of an ssh connection
+type sshAddr string
+
+func (a sshAddr) Network() string { return "ssh" }
+func (a sshAddr) String() string  { return string(a) }
+
+// lockedBuffer collects the error output of ssh while the connection reads
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,195 @@
+// Package tunnel reaches a modem that is not on the local network, for a
+// watchdog running off-site such as on a VPS. Connections to the modem go
+// through an SSH jump host on the home network, or through a network
+// interface such as a WireGuard tunnel to it.
+package tunnel
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Tunnel kinds
+const (
+	// KindSSH opens every connection with `ssh -W` through a jump host
+	KindSSH = "ssh"
+	// KindInterface binds every connection to a network interface
+	KindInterface = "interface"
+)
+
+// dialTimeout bounds connecting through the tunnel
+const dialTimeout = 10 * time.Second
+
+// Config selects the tunnel; at most one of SSHHost and Interface is set
+type Config struct {
+	// SSHHost is the jump host as [user@]host[:port]
+	SSHHost string
+	// SSHKey is the identity file, empty for the ssh defaults
+	SSHKey string
+	// SSHKnownHosts is the known hosts file, empty for the ssh default
+	SSHKnownHosts string
+	// Interface is the interface to the modem's network, such as wg0
+	Interface string
+}
+
+// Enabled reports whether cfg selects a tunnel
+func (cfg Config) Enabled() bool {
+	return cfg.SSHHost != "" || cfg.Interface != ""
+}
+
+// Validate rejects a config that selects both tunnels, or SSH settings
+// without a jump host
+func (cfg Config) Validate() error {
+	if cfg.SSHHost != "" && cfg.Interface != "" {
+		return errors.New("set either an SSH jump host or an interface, not both")
+	}
+	if cfg.SSHHost == "" && (cfg.SSHKey != "" || cfg.SSHKnownHosts != "") {
+		return errors.New("the SSH key and known hosts file need an SSH jump host")
+	}
+	return nil
+}
+
+// Tunnel dials connections to the modem's network
+type Tunnel struct {
+	cfg  Config
+	kind string
+	// ssh is the ssh binary, replaced in tests
+	ssh string
+}
+
+// New creates the tunnel cfg selects
+func New(cfg Config) (*Tunnel, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	switch {
+	case cfg.SSHHost != "":
+		return &Tunnel{cfg: cfg, kind: KindSSH, ssh: "ssh"}, nil
+	case cfg.Interface != "":
+		return &Tunnel{cfg: cfg, kind: KindInterface}, nil
+	}
+	return nil, errors.New("no tunnel configured")
+}
+
+// Kind returns KindSSH or KindInterface
+func (t *Tunnel) Kind() string {
+	return t.kind
+}
+
+// Endpoint returns the jump host or interface the tunnel goes through
+func (t *Tunnel) Endpoint() string {
+	if t.kind == KindSSH {
+		return t.cfg.SSHHost
+	}
+	return t.cfg.Interface
+}
+
+// DialContext opens a connection to address through the tunnel
+func (t *Tunnel) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if t.kind == KindSSH {
+		return t.dialSSH(ctx, address)
+	}
+	return t.dialInterface(ctx, network, address)
+}
+
+// Transport returns an HTTP transport that reaches the modem through the
+// tunnel. noVerify skips the verification of the modem's certificate.
+func (t *Tunnel) Transport(noVerify bool) *http.Transport {
+	return &http.Transport{
+		DialContext:         t.DialContext,
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: noVerify},
+		TLSHandshakeTimeout: dialTimeout,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// dialInterface connects to address with the socket bound to the interface
+func (t *Tunnel) dialInterface(ctx context.Context, network, address string) (net.Conn, error) {
+	if err := t.interfaceUp(); err != nil {
+		return nil, err
+	}
+	return InterfaceDialer(t.cfg.Interface, dialTimeout).DialContext(ctx, network, address)
+}
+
+// InterfaceDialer returns a dialer whose connections leave through the
+// network interface iface whatever the routing table says, as do the DNS
+// lookups of the host names it dials. Binding needs CAP_NET_RAW.
+func InterfaceDialer(iface string, timeout time.Duration) *net.Dialer {
+	bind := func(network, address string, conn syscall.RawConn) error {
+		var bindErr error
+		err := conn.Control(func(fd uintptr) {
+			bindErr = unix.SetsockoptString(int(fd), unix.SOL_SOCKET, unix.SO_BINDTODEVICE, iface)
+		})
+		if err != nil {
+			return err
+		}
+		if bindErr != nil {
+			return fmt.Errorf("failed to bind to interface %s: %w", iface, bindErr)
+		}
+		return nil
+	}
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: bind,
+		Resolver: &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				dialer := &net.Dialer{Timeout: timeout, Control: bind}
+				return dialer.DialContext(ctx, network, address)
+			},
+		},
+	}
+}
+
+// interfaceUp returns an error unless the tunnel interface exists and is up
+func (t *Tunnel) interfaceUp() error {
+	iface, err := net.InterfaceByName(t.cfg.Interface)
+	if err != nil {
+		return fmt.Errorf("tunnel interface %s: %w", t.cfg.Interface, err)
+	}
+	if iface.Flags&net.FlagUp == 0 {
+		return fmt.Errorf("tunnel interface %s is down", t.cfg.Interface)
+	}
+	return nil
+}
+
+// Health is the outcome of a tunnel check
+type Health struct {
+	Kind     string `json:"kind"`
+	Endpoint string `json:"endpoint"`
+	Healthy  bool   `json:"healthy"`
+	// LatencyMS is how long reaching the modem through the tunnel took
+	LatencyMS int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	Checked   time.Time `json:"checked"`
+}
+
+// Check reports whether the modem can be reached through the tunnel as of
+// now, with probe making a request to the modem over it
+func (t *Tunnel) Check(ctx context.Context, now time.Time, probe func(ctx context.Context) error) Health {
+	health := Health{Kind: t.kind, Endpoint: t.Endpoint(), Checked: now}
+
+	var err error
+	if t.kind == KindInterface {
+		err = t.interfaceUp()
+	}
+	if err == nil {
+		started := time.Now()
+		err = probe(ctx)
+		health.LatencyMS = time.Since(started).Milliseconds()
+	}
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	health.Healthy = true
+	return health
+}
@@ -0,0 +1,163 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain runs the test binary as a fake ssh when FAKE_SSH is set: it
+// forwards its standard input and output to the -W address, or fails like
+// a rejected login with FAKE_SSH=fail
+func TestMain(m *testing.M) {
+	switch os.Getenv("FAKE_SSH") {
+	case "":
+		os.Exit(m.Run())
+	case "fail":
+		fmt.Fprintln(os.Stderr, "admin@jump.example: Permission denied (publickey).")
+		os.Exit(255)
+	}
+
+	var address string
+	for i, arg := range os.Args {
+		if arg == "-W" && i+1 < len(os.Args) {
+			address = os.Args[i+1]
+		}
+	}
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "channel 0: open failed: connect failed: %v\n", err)
+		os.Exit(1)
+	}
+	go io.Copy(conn, os.Stdin)
+	io.Copy(os.Stdout, conn)
+	os.Exit(0)
+}
+
+func newFakeSSHTunnel(t *testing.T, mode string) *Tunnel {
+	t.Helper()
+	t.Setenv("FAKE_SSH", mode)
+	tunnel, err := New(Config{SSHHost: "admin@jump.example"})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	tunnel.ssh = os.Args[0]
+	return tunnel
+}
+
+func TestSSHTunnelCarriesHTTP(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "modem")
+	}))
+	defer server.Close()
+
+	tunnel := newFakeSSHTunnel(t, "forward")
+	transport := tunnel.Transport(true)
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Timeout: 10 * time.Second, Transport: transport}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Request %d through the tunnel failed: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "modem" {
+			t.Errorf("Expected the modem page, got %q", body)
+		}
+	}
+}
+
+func TestSSHTunnelReportsSSHErrors(t *testing.T) {
+	tunnel := newFakeSSHTunnel(t, "fail")
+	transport := tunnel.Transport(true)
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Timeout: 10 * time.Second, Transport: transport}
+
+	_, err := client.Get("http://192.168.100.1/")
+	if err == nil || !strings.Contains(err.Error(), "Permission denied") {
+		t.Errorf("Expected the ssh error, got %v", err)
+	}
+}
+
+func TestSSHArgs(t *testing.T) {
+	tunnel, err := New(Config{SSHHost: "admin@jump.example:2222", SSHKey: "/etc/watchdog/id_ed25519", SSHKnownHosts: "/etc/watchdog/known_hosts"})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	want := []string{
+		"-W", "192.168.100.1:443",
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout=10",
+		"-o", "ServerAliveInterval=15",
+		"-i", "/etc/watchdog/id_ed25519", "-o", "IdentitiesOnly=yes",
+		"-o", "UserKnownHostsFile=/etc/watchdog/known_hosts",
+		"-l", "admin",
+		"-p", "2222",
+		"--", "jump.example",
+	}
+	if got := tunnel.sshArgs("192.168.100.1:443"); !reflect.DeepEqual(got, want) {
+		t.Errorf("sshArgs() = %v, want %v", got, want)
+	}
+
+	tunnel, _ = New(Config{SSHHost: "jump.example"})
+	if got := tunnel.sshArgs("192.168.100.1:80"); got[len(got)-1] != "jump.example" || strings.Contains(strings.Join(got, " "), "-l") {
+		t.Errorf("Expected only the host without a user or port, got %v", got)
+	}
+}
+
+func TestTunnelCheck(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tunnel, _ := New(Config{SSHHost: "jump.example"})
+
+	health := tunnel.Check(context.Background(), now, func(ctx context.Context) error { return nil })
+	if !health.Healthy || health.Kind != KindSSH || health.Endpoint != "jump.example" || !health.Checked.Equal(now) {
+		t.Errorf("Expected a healthy SSH tunnel, got %+v", health)
+	}
+
+	health = tunnel.Check(context.Background(), now, func(ctx context.Context) error { return errors.New("connection refused") })
+	if health.Healthy || health.Error != "connection refused" {
+		t.Errorf("Expected the probe error, got %+v", health)
+	}
+
+	tunnel, _ = New(Config{Interface: "wg-missing0"})
+	probed := false
+	health = tunnel.Check(context.Background(), now, func(ctx context.Context) error {
+		probed = true
+		return nil
+	})
+	if health.Healthy || probed || !strings.Contains(health.Error, "wg-missing0") {
+		t.Errorf("Expected a missing interface to fail without probing, got %+v", health)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		cfg   Config
+		valid bool
+	}{
+		{Config{}, true},
+		{Config{SSHHost: "jump.example", SSHKey: "/key"}, true},
+		{Config{Interface: "wg0"}, true},
+		{Config{SSHHost: "jump.example", Interface: "wg0"}, false},
+		{Config{SSHKey: "/key"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) = %v, want valid %v", tt.cfg, err, tt.valid)
+		}
+	}
+	if _, err := New(Config{}); err == nil {
+		t.Error("Expected an error without a tunnel")
+	}
+}
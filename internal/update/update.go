@@ -0,0 +1,362 @@
+// Package update replaces the running binary with a newer release. A release
+// carries one binary per platform, a checksums.txt with their SHA-256 sums
+// and checksums.txt.sig, an ed25519 signature of checksums.txt. A binary is
+// only installed once the signature matches the release public key and its
+// sum matches the signed list.
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PublicKey is the base64 ed25519 key that signs releases, set at build
+// time with -ldflags "-X .../internal/update.PublicKey=..."
+var PublicKey = ""
+
+const (
+	// DefaultRepository is the GitHub repository releases come from
+	DefaultRepository = "perezjoseph/mb8600-watchdog"
+	// DefaultAPIURL is the GitHub API
+	DefaultAPIURL = "https://api.github.com"
+	// DefaultUnit is the systemd unit restarted after an update
+	DefaultUnit = "mb8600-watchdog"
+
+	// ChecksumsName is the release asset listing the SHA-256 of every binary
+	ChecksumsName = "checksums.txt"
+	// SignatureName is the release asset holding the signature of ChecksumsName
+	SignatureName = "checksums.txt.sig"
+)
+
+const (
+	// maxBinarySize caps the download of a binary
+	maxBinarySize = 64 << 20
+	// maxMetadataSize caps the download of the release, checksums and signature
+	maxMetadataSize = 1 << 20
+	// requestTimeout bounds every request of the default client
+	requestTimeout = 5 * time.Minute
+)
+
+// Release is a published release and the download URLs of its assets
+type Release struct {
+	Version string
+	Assets  map[string]string
+}
+
+// Updater finds, downloads and verifies releases
+type Updater struct {
+	client     *http.Client
+	apiURL     string
+	repository string
+	key        ed25519.PublicKey
+	logger     *logrus.Logger
+}
+
+// NewUpdater creates an updater for the releases of repository, such as
+// DefaultRepository, signed with key
+func NewUpdater(repository string, key ed25519.PublicKey, logger *logrus.Logger) *Updater {
+	return &Updater{
+		client:     &http.Client{Timeout: requestTimeout},
+		apiURL:     DefaultAPIURL,
+		repository: repository,
+		key:        key,
+		logger:     logger,
+	}
+}
+
+// SetHTTPClient replaces the HTTP client
+func (u *Updater) SetHTTPClient(client *http.Client) {
+	u.client = client
+}
+
+// SetAPIURL replaces the GitHub API URL, such as for a mirror
+func (u *Updater) SetAPIURL(url string) {
+	u.apiURL = strings.TrimRight(url, "/")
+}
+
+// ParsePublicKey decodes a base64 ed25519 public key
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, fmt.Errorf("no release public key configured")
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid release public key: expected %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// AssetName returns the name of the release binary for a platform. 32-bit
+// ARM binaries are named by ARM version, goarm, which defaults to ARMv7 as
+// on a Raspberry Pi 2 or later; MIPS binaries use soft float.
+func AssetName(goos, goarch, goarm string) string {
+	if goarch == "arm" {
+		if goarm == "" {
+			goarm = "7"
+		}
+		goarch += goarm
+	}
+	return fmt.Sprintf("mb8600-watchdog-%s-%s", goos, goarch)
+}
+
+// CurrentAssetName returns the name of the release binary matching the
+// running one, so an ARMv6 build is replaced by an ARMv6 build
+func CurrentAssetName() string {
+	goarm := ""
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "GOARM" {
+				goarm = setting.Value
+			}
+		}
+	}
+	return AssetName(runtime.GOOS, runtime.GOARCH, goarm)
+}
+
+// Latest returns the latest published release
+func (u *Updater) Latest(ctx context.Context) (*Release, error) {
+	data, err := u.get(ctx, fmt.Sprintf("%s/repos/%s/releases/latest", u.apiURL, u.repository), maxMetadataSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest release: %w", err)
+	}
+
+	var body struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("failed to parse latest release: %w", err)
+	}
+	if body.TagName == "" {
+		return nil, fmt.Errorf("latest release has no version")
+	}
+
+	release := &Release{Version: body.TagName, Assets: make(map[string]string, len(body.Assets))}
+	for _, asset := range body.Assets {
+		release.Assets[asset.Name] = asset.URL
+	}
+	return release, nil
+}
+
+// Download returns the binary named asset of release once the signature of
+// the checksums and the checksum of the binary are verified
+func (u *Updater) Download(ctx context.Context, release *Release, asset string) ([]byte, error) {
+	if len(u.key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("no release public key configured")
+	}
+	for _, name := range []string{asset, ChecksumsName, SignatureName} {
+		if release.Assets[name] == "" {
+			return nil, fmt.Errorf("release %s has no %s", release.Version, name)
+		}
+	}
+
+	checksums, err := u.get(ctx, release.Assets[ChecksumsName], maxMetadataSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", ChecksumsName, err)
+	}
+	signature, err := u.get(ctx, release.Assets[SignatureName], maxMetadataSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", SignatureName, err)
+	}
+	if !ed25519.Verify(u.key, checksums, signature) {
+		return nil, fmt.Errorf("signature of %s does not match the release public key", ChecksumsName)
+	}
+
+	want, err := checksumOf(checksums, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := u.get(ctx, release.Assets[asset], maxBinarySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset, err)
+	}
+	sum := sha256.Sum256(binary)
+	if hex.EncodeToString(sum[:]) != want {
+		return nil, fmt.Errorf("checksum of %s does not match %s", asset, ChecksumsName)
+	}
+
+	u.logger.WithFields(logrus.Fields{
+		"version": release.Version,
+		"asset":   asset,
+		"size":    len(binary),
+	}).Info("Downloaded and verified release binary")
+	return binary, nil
+}
+
+// checksumOf returns the SHA-256 listed for name in sha256sum output
+func checksumOf(checksums []byte, name string) (string, error) {
+	for _, line := range strings.Split(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s lists no checksum for %s", ChecksumsName, name)
+}
+
+// get downloads url, failing if the body exceeds limit bytes
+func (u *Updater) get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json, application/octet-stream")
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("response larger than %d bytes", limit)
+	}
+	return data, nil
+}
+
+// Newer reports whether version latest is newer than current; both are
+// versions such as v1.2.3, and a pre-release is older than its release
+func Newer(latest, current string) (bool, error) {
+	l, err := parseVersion(latest)
+	if err != nil {
+		return false, err
+	}
+	c, err := parseVersion(current)
+	if err != nil {
+		return false, err
+	}
+	for i := range l.numbers {
+		if l.numbers[i] != c.numbers[i] {
+			return l.numbers[i] > c.numbers[i], nil
+		}
+	}
+	switch {
+	case l.pre == c.pre:
+		return false, nil
+	case l.pre == "":
+		return true, nil
+	case c.pre == "":
+		return false, nil
+	default:
+		return l.pre > c.pre, nil
+	}
+}
+
+// version is a parsed major.minor.patch version
+type version struct {
+	numbers [3]int
+	pre     string
+}
+
+// parseVersion parses v1.2.3 or v1.2.3-rc1, with or without the v
+func parseVersion(s string) (version, error) {
+	var v version
+	core := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(core, '+'); i >= 0 {
+		core = core[:i]
+	}
+	core, v.pre, _ = strings.Cut(core, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid version %q, expected vMAJOR.MINOR.PATCH", s)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q, expected vMAJOR.MINOR.PATCH", s)
+		}
+		v.numbers[i] = n
+	}
+	return v, nil
+}
+
+// Install atomically replaces the file at path with binary, keeping its
+// mode. The new binary is written next to path and renamed over it, so the
+// file at path is always either the old or the new binary; the old one is
+// kept as path.old.
+func Install(binary []byte, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat current binary: %w", err)
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create new binary: %w", err)
+	}
+	tempPath := temp.Name()
+	defer os.Remove(tempPath)
+
+	if _, err := temp.Write(binary); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tempPath, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to set mode of new binary: %w", err)
+	}
+
+	backup := path + ".old"
+	os.Remove(backup)
+	if err := os.Link(path, backup); err != nil {
+		return fmt.Errorf("failed to keep current binary: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("failed to replace current binary: %w", err)
+	}
+	return nil
+}
+
+// Restart restarts unit through systemctl
+func Restart(ctx context.Context, unit string) error {
+	output, err := exec.CommandContext(ctx, "systemctl", "restart", unit).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to restart %s: %w: %s", unit, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// UnderSystemd reports whether systemd is the init system
+func UnderSystemd() bool {
+	_, err := os.Stat("/run/systemd/system")
+	return err == nil
+}
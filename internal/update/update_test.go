@@ -0,0 +1,247 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// releaseServer serves a latest release with a binary for linux/arm64
+type releaseServer struct {
+	*httptest.Server
+	binary    []byte
+	checksums []byte
+	signature []byte
+}
+
+func newReleaseServer(t *testing.T, key ed25519.PrivateKey, binary []byte) *releaseServer {
+	t.Helper()
+	sum := sha256.Sum256(binary)
+	checksums := []byte(fmt.Sprintf("%s  mb8600-watchdog-0.tar.gz\n%s  %s\n",
+		strings.Repeat("0", 64), hex.EncodeToString(sum[:]), AssetName("linux", "arm64", "")))
+	s := &releaseServer{binary: binary, checksums: checksums, signature: ed25519.Sign(key, checksums)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name":"v1.2.0","assets":[
+			{"name":%q,"browser_download_url":"%s/binary"},
+			{"name":"checksums.txt","browser_download_url":"%s/checksums"},
+			{"name":"checksums.txt.sig","browser_download_url":"%s/signature"}]}`,
+			AssetName("linux", "arm64", ""), s.URL, s.URL, s.URL)
+	})
+	mux.HandleFunc("/binary", func(w http.ResponseWriter, r *http.Request) { w.Write(s.binary) })
+	mux.HandleFunc("/checksums", func(w http.ResponseWriter, r *http.Request) { w.Write(s.checksums) })
+	mux.HandleFunc("/signature", func(w http.ResponseWriter, r *http.Request) { w.Write(s.signature) })
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestUpdater(server *releaseServer, key ed25519.PublicKey) *Updater {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	updater := NewUpdater("owner/repo", key, logger)
+	updater.SetAPIURL(server.URL)
+	return updater
+}
+
+func TestDownloadVerifiesRelease(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary := []byte("new watchdog binary")
+	asset := AssetName("linux", "arm64", "")
+
+	t.Run("valid", func(t *testing.T) {
+		server := newReleaseServer(t, private, binary)
+		updater := newTestUpdater(server, public)
+		release, err := updater.Latest(context.Background())
+		if err != nil {
+			t.Fatalf("Latest failed: %v", err)
+		}
+		if release.Version != "v1.2.0" {
+			t.Errorf("Expected version v1.2.0, got %s", release.Version)
+		}
+		got, err := updater.Download(context.Background(), release, asset)
+		if err != nil {
+			t.Fatalf("Download failed: %v", err)
+		}
+		if string(got) != string(binary) {
+			t.Errorf("Expected the release binary, got %q", got)
+		}
+	})
+
+	t.Run("tampered binary", func(t *testing.T) {
+		server := newReleaseServer(t, private, binary)
+		server.binary = []byte("malicious binary")
+		updater := newTestUpdater(server, public)
+		release, err := updater.Latest(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := updater.Download(context.Background(), release, asset); err == nil || !strings.Contains(err.Error(), "checksum") {
+			t.Errorf("Expected a checksum error, got %v", err)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		_, other, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := newReleaseServer(t, other, binary)
+		updater := newTestUpdater(server, public)
+		release, err := updater.Latest(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := updater.Download(context.Background(), release, asset); err == nil || !strings.Contains(err.Error(), "signature") {
+			t.Errorf("Expected a signature error, got %v", err)
+		}
+	})
+
+	t.Run("missing platform", func(t *testing.T) {
+		server := newReleaseServer(t, private, binary)
+		updater := newTestUpdater(server, public)
+		release, err := updater.Latest(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := updater.Download(context.Background(), release, AssetName("linux", "riscv64", "")); err == nil {
+			t.Error("Expected an error for a platform without a binary")
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		server := newReleaseServer(t, private, binary)
+		updater := newTestUpdater(server, nil)
+		release, err := updater.Latest(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := updater.Download(context.Background(), release, asset); err == nil {
+			t.Error("Expected an error without a public key")
+		}
+	})
+}
+
+func TestParsePublicKey(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParsePublicKey(base64.StdEncoding.EncodeToString(public))
+	if err != nil {
+		t.Fatalf("ParsePublicKey failed: %v", err)
+	}
+	if !key.Equal(public) {
+		t.Error("Expected the encoded key")
+	}
+
+	for _, invalid := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParsePublicKey(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestAssetName(t *testing.T) {
+	tests := []struct {
+		goarch, goarm, want string
+	}{
+		{"amd64", "", "mb8600-watchdog-linux-amd64"},
+		{"arm64", "", "mb8600-watchdog-linux-arm64"},
+		{"arm", "", "mb8600-watchdog-linux-arm7"},
+		{"arm", "6", "mb8600-watchdog-linux-arm6"},
+		{"mipsle", "", "mb8600-watchdog-linux-mipsle"},
+	}
+	for _, tt := range tests {
+		if got := AssetName("linux", tt.goarch, tt.goarm); got != tt.want {
+			t.Errorf("AssetName(linux, %s, %s) = %s, want %s", tt.goarch, tt.goarm, got, tt.want)
+		}
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v1.2.0", "v1.1.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v2.0.0", "1.99.99", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.1.0", "v1.2.0", false},
+		{"v1.2.0", "v1.2.0-rc1", true},
+		{"v1.2.0-rc2", "v1.2.0-rc1", true},
+		{"v1.2.0-rc1", "v1.2.0", false},
+	}
+	for _, tt := range tests {
+		got, err := Newer(tt.latest, tt.current)
+		if err != nil {
+			t.Errorf("Newer(%s, %s) failed: %v", tt.latest, tt.current, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Newer(%s, %s) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+
+	if _, err := Newer("v1.2.0", "dev"); err == nil {
+		t.Error("Expected an error for a dev build")
+	}
+}
+
+func TestInstallReplacesBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watchdog")
+	if err := os.WriteFile(path, []byte("old"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Install([]byte("new"), path); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Errorf("Expected the new binary, got %q (%v)", data, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0750 {
+		t.Errorf("Expected mode 0750 to be kept, got %v (%v)", info.Mode().Perm(), err)
+	}
+	backup, err := os.ReadFile(path + ".old")
+	if err != nil || string(backup) != "old" {
+		t.Errorf("Expected the old binary to be kept, got %q (%v)", backup, err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected only the binary and its backup, got %d files", len(entries))
+	}
+
+	// A second update replaces the backup
+	if err := Install([]byte("newer"), path); err != nil {
+		t.Fatalf("Second Install failed: %v", err)
+	}
+	if backup, _ := os.ReadFile(path + ".old"); string(backup) != "new" {
+		t.Errorf("Expected the previous binary as backup, got %q", backup)
+	}
+}
@@ -0,0 +1,133 @@
+// Package verdict locates a connectivity failure: on the local network
+// between the watchdog and the modem, at the modem and its line, or upstream
+// at the ISP. Each verdict has its own action, since rebooting the modem only
+// helps when the modem is at fault.
+package verdict
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verdicts
+const (
+	// LAN is a failure between this host and the modem, such as a router,
+	// switch or Wi-Fi link that is down
+	LAN = "lan"
+	// WAN is a failure of the modem or its line
+	WAN = "wan"
+	// Upstream is a failure beyond the modem, in the ISP's network
+	Upstream = "upstream"
+)
+
+// Verdicts lists every verdict
+var Verdicts = []string{LAN, WAN, Upstream}
+
+// Actions
+const (
+	// ActionReboot reboots the modem
+	ActionReboot = "reboot"
+	// ActionWait keeps checking without rebooting
+	ActionWait = "wait"
+	// ActionNotify keeps checking without rebooting and sends a notification
+	ActionNotify = "notify"
+)
+
+// Actions lists every action
+var Actions = []string{ActionReboot, ActionWait, ActionNotify}
+
+// Default actions
+const (
+	DefaultLANAction      = ActionNotify
+	DefaultWANAction      = ActionReboot
+	DefaultUpstreamAction = ActionWait
+)
+
+// Policy is the action taken for each verdict
+type Policy struct {
+	LAN      string
+	WAN      string
+	Upstream string
+}
+
+// Validate rejects unknown actions; unset ones reboot
+func (p Policy) Validate() error {
+	for verdict, action := range map[string]string{LAN: p.LAN, WAN: p.WAN, Upstream: p.Upstream} {
+		if action != "" && !ValidAction(action) {
+			return fmt.Errorf("%s action must be one of %s, got %q", verdict, strings.Join(Actions, ", "), action)
+		}
+	}
+	return nil
+}
+
+// ValidAction reports whether action is a known action
+func ValidAction(action string) bool {
+	for _, known := range Actions {
+		if action == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Action returns the action for verdict; verdicts without a configured
+// action reboot, as the watchdog did before failures were located
+func (p Policy) Action(verdict string) string {
+	var action string
+	switch verdict {
+	case LAN:
+		action = p.LAN
+	case WAN:
+		action = p.WAN
+	case Upstream:
+		action = p.Upstream
+	}
+	if action == "" {
+		return ActionReboot
+	}
+	return action
+}
+
+// Signals are what the watchdog knows about a failed check
+type Signals struct {
+	// GatewayChecked is set when the check included the local gateway, and
+	// GatewayReachable when it answered
+	GatewayChecked   bool
+	GatewayReachable bool
+	// ModemReachable is set when the modem's web interface answered
+	ModemReachable bool
+	// LocalWiFi is set when the host's own Wi-Fi link is degraded
+	LocalWiFi bool
+	// PartialUpstream is set when the diagnostics reached some upstream
+	// networks but not others
+	PartialUpstream bool
+}
+
+// Result is a located failure
+type Result struct {
+	Verdict string `json:"verdict"`
+	// Reason says what points at the verdict
+	Reason string `json:"reason"`
+}
+
+// String returns the verdict with its reason
+func (r Result) String() string {
+	return r.Verdict + " failure: " + r.Reason
+}
+
+// Locate returns the verdict the signals point at. Without evidence of a
+// local or an upstream failure, the modem is blamed.
+func Locate(s Signals) Result {
+	switch {
+	case s.LocalWiFi:
+		return Result{LAN, "the Wi-Fi link of this host is degraded"}
+	case s.GatewayChecked && !s.GatewayReachable && !s.ModemReachable:
+		return Result{LAN, "neither the gateway nor the modem answers"}
+	case s.PartialUpstream:
+		return Result{Upstream, "some upstream networks are reachable and others are not"}
+	case s.ModemReachable:
+		return Result{WAN, "the modem answers but the internet does not"}
+	default:
+		return Result{WAN, "neither the modem nor the internet answers"}
+	}
+}
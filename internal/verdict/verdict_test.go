@@ -0,0 +1,40 @@
+package verdict
+
+import "testing"
+
+func TestLocate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		signals Signals
+		want    string
+	}{
+		{"wifi", Signals{LocalWiFi: true, ModemReachable: true}, LAN},
+		{"gateway and modem down", Signals{GatewayChecked: true}, LAN},
+		{"gateway down, modem up", Signals{GatewayChecked: true, ModemReachable: true}, WAN},
+		{"no gateway check, modem down", Signals{}, WAN},
+		{"modem up", Signals{GatewayChecked: true, GatewayReachable: true, ModemReachable: true}, WAN},
+		{"routing incident", Signals{GatewayChecked: true, GatewayReachable: true, ModemReachable: true, PartialUpstream: true}, Upstream},
+	} {
+		if got := Locate(tc.signals); got.Verdict != tc.want || got.Reason == "" {
+			t.Errorf("%s: expected %s, got %+v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	policy := Policy{LAN: DefaultLANAction, WAN: DefaultWANAction, Upstream: DefaultUpstreamAction}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Expected the default policy to be valid, got %v", err)
+	}
+	if policy.Action(LAN) != ActionNotify || policy.Action(WAN) != ActionReboot || policy.Action(Upstream) != ActionWait {
+		t.Errorf("Unexpected actions of %+v", policy)
+	}
+	if action := (Policy{}).Action(Upstream); action != ActionReboot {
+		t.Errorf("Expected an unset action to reboot, got %q", action)
+	}
+
+	policy.Upstream = "ignore"
+	if err := policy.Validate(); err == nil {
+		t.Error("Expected an unknown action to be invalid")
+	}
+}
@@ -0,0 +1,119 @@
+// Package connectivity is the public API of the watchdog's connectivity
+// checks. A Tester runs the same tiered checks as the watchdog: TCP
+// handshakes first, then DNS and HTTP checks when those fail.
+//
+// The result types are those the watchdog uses itself; the package keeps
+// their names and meaning stable and only adds to them.
+package connectivity
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/connectivity"
+	"github.com/sirupsen/logrus"
+)
+
+// Defaults of Options
+const (
+	DefaultConnectionTimeout = 5 * time.Second
+	DefaultHTTPTimeout       = 10 * time.Second
+)
+
+type (
+	// Result is the outcome of a tiered check
+	Result = connectivity.TieredTestResult
+	// LightweightResult is the outcome of the TCP handshake checks
+	LightweightResult = connectivity.LightweightTestResult
+	// ComprehensiveResult is the outcome of the DNS and HTTP checks
+	ComprehensiveResult = connectivity.ComprehensiveTestResult
+	// TestResult is the outcome of a check of a single target
+	TestResult = connectivity.TestResult
+	// TargetHealth counts the checks and failures of a target
+	TargetHealth = connectivity.TargetHealth
+)
+
+// Options are the settings of a Tester. Zero values take the defaults.
+type Options struct {
+	// Logger receives the tester's logs; nil discards them
+	Logger *logrus.Logger
+	// ConnectionTimeout bounds each TCP handshake and DNS query
+	ConnectionTimeout time.Duration
+	// HTTPTimeout bounds each HTTP check
+	HTTPTimeout time.Duration
+	// DNSServers are checked with TCP handshakes and DNS queries, as host or
+	// host:port; empty uses public resolvers
+	DNSServers []string
+	// HTTPHosts are the URLs of the HTTP checks; empty uses public sites
+	HTTPHosts []string
+	// Responder is the host:port of a self-hosted responder, which replaces
+	// DNSServers; give its check URL in HTTPHosts
+	Responder string
+	// ResponderKey verifies the signed answers of the responder
+	ResponderKey []byte
+	// Proxy routes the HTTP checks, as http.Transport.Proxy does
+	Proxy func(*http.Request) (*url.URL, error)
+}
+
+// Tester checks the internet connection
+type Tester struct {
+	tester *connectivity.Tester
+}
+
+// New creates a tester with opts
+func New(opts Options) *Tester {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+	}
+	if opts.ConnectionTimeout <= 0 {
+		opts.ConnectionTimeout = DefaultConnectionTimeout
+	}
+	if opts.HTTPTimeout <= 0 {
+		opts.HTTPTimeout = DefaultHTTPTimeout
+	}
+	if len(opts.DNSServers) == 0 {
+		opts.DNSServers = []string{"1.1.1.1", "8.8.8.8", "9.9.9.9", "208.67.222.222"}
+	}
+	if len(opts.HTTPHosts) == 0 && opts.Responder == "" {
+		opts.HTTPHosts = []string{"https://google.com", "https://cloudflare.com", "https://amazon.com"}
+	}
+	if opts.Responder != "" {
+		opts.DNSServers = []string{opts.Responder}
+	}
+
+	tester := connectivity.NewTesterWithConfig(logger, opts.ConnectionTimeout, opts.HTTPTimeout, opts.DNSServers, opts.HTTPHosts)
+	tester.SetResponder(opts.Responder)
+	if len(opts.ResponderKey) > 0 {
+		tester.SetResponderKey(opts.ResponderKey)
+	}
+	if opts.Proxy != nil {
+		tester.SetProxy(opts.Proxy)
+	}
+	return &Tester{tester: tester}
+}
+
+// Check runs the tiered checks: the TCP handshakes, then the DNS and HTTP
+// checks if the handshakes failed
+func (t *Tester) Check(ctx context.Context) (*Result, error) {
+	return t.tester.RunTieredTests(ctx)
+}
+
+// CheckLightweight runs the TCP handshake checks only
+func (t *Tester) CheckLightweight(ctx context.Context) (*LightweightResult, error) {
+	return t.tester.RunLightweightTests(ctx)
+}
+
+// CheckComprehensive runs the DNS and HTTP checks only
+func (t *Tester) CheckComprehensive(ctx context.Context) (*ComprehensiveResult, error) {
+	return t.tester.RunComprehensiveTests(ctx)
+}
+
+// TargetHealth returns the checks and failures of each target so far
+func (t *Tester) TargetHealth() []TargetHealth {
+	return t.tester.TargetHealth()
+}
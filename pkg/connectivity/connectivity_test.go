@@ -0,0 +1,48 @@
+package connectivity_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/pkg/connectivity"
+)
+
+func TestCheckLocalTargets(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tester := connectivity.New(connectivity.Options{
+		ConnectionTimeout: time.Second,
+		HTTPTimeout:       time.Second,
+		DNSServers:        []string{listener.Addr().String()},
+		HTTPHosts:         []string{server.URL},
+	})
+	result, err := tester.CheckLightweight(context.Background())
+	if err != nil {
+		t.Fatalf("CheckLightweight failed: %v", err)
+	}
+	if !result.OverallSuccess || result.SuccessCount != 1 {
+		t.Errorf("Expected the handshake to pass, got %+v", result)
+	}
+	if health := tester.TargetHealth(); len(health) == 0 {
+		t.Error("Expected target health after a check")
+	}
+}
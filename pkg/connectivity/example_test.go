@@ -0,0 +1,25 @@
+package connectivity_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/pkg/connectivity"
+)
+
+func Example() {
+	tester := connectivity.New(connectivity.Options{
+		DNSServers: []string{"1.1.1.1", "9.9.9.9"},
+		HTTPHosts:  []string{"https://example.com"},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	result, err := tester.Check(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("online: %v (%s, %v)\n", result.OverallSuccess, result.Strategy, result.TotalDuration)
+}
@@ -0,0 +1,93 @@
+// Package diagnostics is the public API of the watchdog's network
+// diagnostics. An Analyzer probes the network layer by layer, from the
+// modem to public services, and tells whether a modem reboot would help.
+//
+// The result types are those the watchdog uses itself; the package keeps
+// their names and meaning stable and only adds to them.
+package diagnostics
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/diagnostics"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultTimeout bounds a diagnostics run when Options.Timeout is zero
+const DefaultTimeout = 120 * time.Second
+
+// Causes an Analysis can pin a failure down to
+const (
+	CauseLocalWiFi  = diagnostics.CauseLocalWiFi
+	CauseISPRouting = diagnostics.CauseISPRouting
+	CauseLineDown   = diagnostics.CauseLineDown
+)
+
+type (
+	// Result is the outcome of a single diagnostic test
+	Result = diagnostics.DiagnosticResult
+	// Analysis sums up the results and recommends whether to reboot
+	Analysis = diagnostics.AnalysisResult
+	// LayerStats counts the tests of a network layer
+	LayerStats = diagnostics.LayerStats
+	// FailurePattern is a pattern of failures the analysis recognized
+	FailurePattern = diagnostics.FailurePattern
+	// Targets are the hosts the diagnostics probe
+	Targets = diagnostics.Targets
+)
+
+// Options are the settings of an Analyzer. Zero values take the defaults.
+type Options struct {
+	// Logger receives the analyzer's logs; nil discards them
+	Logger *logrus.Logger
+	// Timeout bounds a diagnostics run
+	Timeout time.Duration
+	// ModemIP is the address of the modem, 192.168.100.1 by default
+	ModemIP string
+	// Targets are the hosts probed after the modem; empty uses public services
+	Targets *Targets
+}
+
+// Analyzer runs network diagnostics
+type Analyzer struct {
+	analyzer *diagnostics.Analyzer
+}
+
+// New creates an analyzer with opts
+func New(opts Options) *Analyzer {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	analyzer := diagnostics.NewAnalyzer(logger, opts.Timeout)
+	if opts.ModemIP != "" {
+		analyzer.SetModemIP(opts.ModemIP)
+	}
+	if opts.Targets != nil {
+		analyzer.SetTargets(*opts.Targets)
+	}
+	return &Analyzer{analyzer: analyzer}
+}
+
+// TargetsFor derives the targets of the diagnostics from the hosts of the
+// connectivity checks
+func TargetsFor(pingHosts, httpHosts []string) Targets {
+	return diagnostics.TargetsFor(pingHosts, httpHosts)
+}
+
+// Run runs the diagnostics of every layer
+func (a *Analyzer) Run(ctx context.Context) ([]Result, error) {
+	return a.analyzer.RunDiagnostics(ctx)
+}
+
+// Analyze sums up results and recommends whether to reboot the modem
+func (a *Analyzer) Analyze(results []Result) Analysis {
+	return a.analyzer.PerformDetailedAnalysis(results)
+}
@@ -0,0 +1,24 @@
+package diagnostics_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/perezjoseph/mb8600-watchdog/pkg/diagnostics"
+)
+
+func Example() {
+	targets := diagnostics.TargetsFor([]string{"1.1.1.1"}, []string{"https://example.com"})
+	analyzer := diagnostics.New(diagnostics.Options{ModemIP: "192.168.100.1", Targets: &targets})
+
+	results, err := analyzer.Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	analysis := analyzer.Analyze(results)
+	fmt.Printf("health %.0f, reboot recommended: %v\n", analysis.HealthScore, analysis.ShouldReboot)
+	for _, recommendation := range analysis.Recommendations {
+		fmt.Println(recommendation)
+	}
+}
@@ -0,0 +1,33 @@
+package modem_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/pkg/modem"
+)
+
+func Example() {
+	driver, err := modem.New(modem.TypeMB8600, modem.Options{
+		Host:     "192.168.100.1",
+		Username: "admin",
+		Password: "motorola",
+		NoVerify: true,
+	}, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	status, err := driver.GetStatus(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if summary, ok := modem.Summarize(status); ok {
+		fmt.Printf("%s: %d of %d downstream channels locked, SNR %.1f dB\n",
+			status.Model, summary.DownstreamLocked, summary.DownstreamChannels, summary.DownstreamSNRDB)
+	}
+}
@@ -0,0 +1,100 @@
+// Package modem is the public API of the watchdog's cable modem drivers. It
+// lets other Go programs log in to a modem, read its status and channels
+// and reboot it without running the watchdog.
+//
+// The types are those the watchdog uses itself; the package keeps their
+// names and meaning stable and only adds to them.
+package modem
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/sirupsen/logrus"
+)
+
+// Supported modem types
+const (
+	TypeMB8600      = modem.TypeMB8600
+	TypeArrisSB     = modem.TypeArrisSB
+	TypeNetgearCM   = modem.TypeNetgearCM
+	TypeTechnicolor = modem.TypeTechnicolor
+)
+
+// Operating modes reported in Status.Mode
+const (
+	ModeModem  = modem.ModeModem
+	ModeRouter = modem.ModeRouter
+	ModeBridge = modem.ModeBridge
+)
+
+// Errors returned by drivers; test for them with errors.Is
+var (
+	ErrAuthFailed     = modem.ErrAuthFailed
+	ErrSessionExpired = modem.ErrSessionExpired
+	ErrUnknownType    = modem.ErrUnknownType
+)
+
+type (
+	// Driver talks to one modem family
+	Driver = modem.Driver
+	// Options are the settings of a driver
+	Options = modem.Options
+	// Status is the modem status and its channels
+	Status = modem.Status
+	// Channel is a DOCSIS downstream or upstream channel
+	Channel = modem.Channel
+	// Event is an entry of the modem's event log
+	Event = modem.Event
+	// RebootCycleResult describes a reboot followed until the modem was back
+	RebootCycleResult = modem.RebootCycleResult
+	// SignalSummary sums up the channels of a status
+	SignalSummary = modem.SignalSummary
+	// SignalComparison compares two signal summaries
+	SignalComparison = modem.SignalComparison
+)
+
+// New creates the driver for modemType, empty for the MB8600. A nil logger
+// discards the driver's logs.
+func New(modemType string, opts Options, logger *logrus.Logger) (Driver, error) {
+	if logger == nil {
+		logger = discardLogger()
+	}
+	return modem.New(modemType, opts, logger)
+}
+
+// SupportedTypes returns the modem types New accepts
+func SupportedTypes() []string {
+	return modem.SupportedTypes()
+}
+
+// EventLog reads the event log of d; ok is false for drivers that cannot
+// read one
+func EventLog(ctx context.Context, d Driver) (events []Event, ok bool, err error) {
+	return modem.EventLog(ctx, d)
+}
+
+// RebootWithMonitoring reboots the modem and waits, polling every
+// pollInterval, until it went offline and came back online
+func RebootWithMonitoring(ctx context.Context, d Driver, pollInterval, maxOfflineWait, maxOnlineWait time.Duration) (*RebootCycleResult, error) {
+	return modem.RebootWithMonitoring(ctx, d, pollInterval, maxOfflineWait, maxOnlineWait)
+}
+
+// Summarize sums up the channels of status; ok is false when it has none
+func Summarize(status *Status) (SignalSummary, bool) {
+	return modem.Summarize(status)
+}
+
+// CompareSignals compares the signal before a reboot with the signal after
+func CompareSignals(before, after SignalSummary) SignalComparison {
+	return modem.CompareSignals(before, after)
+}
+
+// discardLogger returns a logger writing nowhere
+func discardLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
@@ -0,0 +1,43 @@
+package modem_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem/modemtest"
+	"github.com/perezjoseph/mb8600-watchdog/pkg/modem"
+)
+
+func TestNewReadsStatus(t *testing.T) {
+	server := httptest.NewTLSServer(modemtest.NewFake("admin", "password"))
+	defer server.Close()
+
+	driver, err := modem.New(modem.TypeMB8600, modem.Options{
+		Host:     server.Listener.Addr().String(),
+		Username: "admin",
+		Password: "password",
+		NoVerify: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	status, err := driver.GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	summary, ok := modem.Summarize(status)
+	if !ok || summary.DownstreamLocked != 3 || summary.UpstreamLocked != 2 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+}
+
+func TestNewRejectsUnknownType(t *testing.T) {
+	if _, err := modem.New("toaster", modem.Options{}, nil); !errors.Is(err, modem.ErrUnknownType) {
+		t.Errorf("Expected ErrUnknownType, got %v", err)
+	}
+	if len(modem.SupportedTypes()) == 0 {
+		t.Error("Expected supported types")
+	}
+}
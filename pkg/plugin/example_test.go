@@ -0,0 +1,28 @@
+package plugin_test
+
+import (
+	"context"
+	"log"
+	"net"
+	"time"
+
+	"github.com/perezjoseph/mb8600-watchdog/pkg/plugin"
+)
+
+// A plugin checking that a NAS on the LAN answers
+func Example() {
+	err := plugin.Serve(plugin.Server{
+		Name: "nas",
+		Check: func(ctx context.Context) (plugin.CheckResult, error) {
+			conn, err := net.DialTimeout("tcp", "192.168.1.20:445", 5*time.Second)
+			if err != nil {
+				return plugin.CheckResult{Message: err.Error()}, nil
+			}
+			conn.Close()
+			return plugin.CheckResult{Success: true}, nil
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
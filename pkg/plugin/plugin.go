@@ -0,0 +1,86 @@
+// Package plugin is the public API for writing watchdog plugins in Go:
+// programs that add connectivity checks, notifiers, a modem driver or a
+// reboot decision policy without changes to the watchdog. A plugin fills in
+// a Server with the capabilities it has and calls Serve from its main
+// function:
+//
+//	func main() {
+//		plugin.Serve(plugin.Server{
+//			Name: "nas",
+//			Check: func(ctx context.Context) (plugin.CheckResult, error) {
+//				...
+//			},
+//		})
+//	}
+//
+// The watchdog talks JSON-RPC 1.0 to plugins over their standard input and
+// output, so plugins in other languages implement the same methods; see the
+// README for the wire format. A plugin logs to its standard error, which
+// goes to the watchdog log.
+package plugin
+
+import (
+	"io"
+
+	"github.com/perezjoseph/mb8600-watchdog/internal/modem"
+	"github.com/perezjoseph/mb8600-watchdog/internal/monitor"
+	"github.com/perezjoseph/mb8600-watchdog/internal/notify"
+	"github.com/perezjoseph/mb8600-watchdog/internal/plugin"
+)
+
+// ProtocolVersion is the version of the protocol plugins speak
+const ProtocolVersion = plugin.ProtocolVersion
+
+// Capabilities a plugin reports
+const (
+	CapabilityCheck    = plugin.CapabilityCheck
+	CapabilityNotifier = plugin.CapabilityNotifier
+	CapabilityModem    = plugin.CapabilityModem
+	CapabilityPolicy   = plugin.CapabilityPolicy
+)
+
+// Methods of the protocol
+const (
+	MethodInfo        = plugin.MethodInfo
+	MethodCheck       = plugin.MethodCheck
+	MethodNotify      = plugin.MethodNotify
+	MethodModemLogin  = plugin.MethodModemLogin
+	MethodModemStatus = plugin.MethodModemStatus
+	MethodModemReboot = plugin.MethodModemReboot
+	MethodPolicy      = plugin.MethodPolicy
+)
+
+type (
+	// Server is a plugin written in Go
+	Server = plugin.Server
+	// Info describes a plugin
+	Info = plugin.Info
+	// CheckResult is the result of a check
+	CheckResult = plugin.CheckResult
+	// ModemLogin holds the modem settings of the watchdog configuration
+	ModemLogin = plugin.ModemLogin
+	// ModemDriver is the modem driver of a plugin
+	ModemDriver = plugin.ModemDriver
+	// Notification is a rendered notification of the watchdog
+	Notification = notify.Notification
+	// Status is the modem status a modem driver returns, the Status of
+	// package pkg/modem
+	Status = modem.Status
+	// PolicyInput is what a decision policy decides on
+	PolicyInput = monitor.PolicyInput
+	// PolicyDecision is the answer of a decision policy
+	PolicyDecision = monitor.PolicyDecision
+	// Decision is the built-in decision in PolicyInput
+	Decision = monitor.Decision
+)
+
+// Serve answers the watchdog on the standard input and output until the
+// standard input closes
+func Serve(s Server) error {
+	return plugin.Serve(s)
+}
+
+// ServeConn answers the watchdog on conn until it closes
+func ServeConn(s Server, conn io.ReadWriteCloser) error {
+	return plugin.ServeConn(s, conn)
+}
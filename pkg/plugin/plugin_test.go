@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+)
+
+// TestWireFormat checks the messages a plugin in another language exchanges
+// with the watchdog
+func TestWireFormat(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	go ServeConn(Server{
+		Name: "nas",
+		Check: func(ctx context.Context) (CheckResult, error) {
+			return CheckResult{Message: "nas unreachable"}, nil
+		},
+	}, server)
+
+	reader := bufio.NewReader(client)
+	exchange := func(request string) map[string]interface{} {
+		t.Helper()
+		if _, err := client.Write([]byte(request + "\n")); err != nil {
+			t.Fatalf("Failed to send %s: %v", request, err)
+		}
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("Failed to read the answer to %s: %v", request, err)
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(line, &response); err != nil {
+			t.Fatalf("Invalid answer %s: %v", line, err)
+		}
+		return response
+	}
+
+	info := exchange(`{"method":"Plugin.Info","params":[{}],"id":1}`)
+	result, _ := info["result"].(map[string]interface{})
+	if info["id"] != float64(1) || result["name"] != "nas" || result["protocol_version"] != float64(ProtocolVersion) {
+		t.Errorf("Unexpected info answer %v", info)
+	}
+	if capabilities, _ := result["capabilities"].([]interface{}); len(capabilities) != 1 || capabilities[0] != CapabilityCheck {
+		t.Errorf("Expected the check capability, got %v", result["capabilities"])
+	}
+
+	check := exchange(`{"method":"Check.Run","params":[{}],"id":2}`)
+	result, _ = check["result"].(map[string]interface{})
+	if result["success"] != false || result["message"] != "nas unreachable" || check["error"] != nil {
+		t.Errorf("Unexpected check answer %v", check)
+	}
+
+	missing := exchange(`{"method":"Notifier.Notify","params":[{"title":"Modem rebooted"}],"id":3}`)
+	if missing["error"] == nil {
+		t.Errorf("Expected an error for a capability the plugin lacks, got %v", missing)
+	}
+}
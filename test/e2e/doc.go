@@ -0,0 +1,8 @@
+// Package e2e holds the end-to-end tests. They run the real watchdog binary
+// in the docker compose environment of this directory against the fake
+// modem of cmd/fakemodem, break the simulated internet with tc and
+// iptables, and check what the watchdog did through its API and the fake
+// modem's control port. The tests only build with the e2e tag:
+//
+//	go test -tags e2e -v ./test/e2e
+package e2e
@@ -0,0 +1,203 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// Ports published by docker-compose.yml, overridden like in the compose file
+var (
+	apiURL     = "http://127.0.0.1:" + envOr("E2E_API_PORT", "18600")
+	controlURL = "http://127.0.0.1:" + envOr("E2E_MODEM_CONTROL_PORT", "18080")
+)
+
+// modemState is the state served by the fake modem's control port
+type modemState struct {
+	Logins  int  `json:"logins"`
+	Reboots int  `json:"reboots"`
+	Online  bool `json:"online"`
+}
+
+// watchdogState holds the fields of /api/v1/status the tests look at
+type watchdogState struct {
+	FailureCount int             `json:"failure_count"`
+	TotalChecks  int             `json:"total_checks"`
+	TotalReboots int             `json:"total_reboots"`
+	ModemModel   string          `json:"modem_model"`
+	Reboot       json.RawMessage `json:"reboot"`
+}
+
+func TestMain(m *testing.M) {
+	if err := exec.Command("docker", "compose", "version").Run(); err != nil {
+		fmt.Println("docker compose is not available, skipping the end-to-end tests")
+		os.Exit(0)
+	}
+	if err := compose("up", "-d", "--build"); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start the test environment: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	if code != 0 {
+		compose("logs", "--no-color", "watchdog")
+	}
+	if os.Getenv("E2E_KEEP") == "" {
+		compose("down", "-v")
+	}
+	os.Exit(code)
+}
+
+func TestHealthyConnectionDoesNotReboot(t *testing.T) {
+	start(t)
+
+	waitFor(t, time.Minute, "three checks", func() bool {
+		return status(t).TotalChecks >= 3
+	})
+	state := status(t)
+	if state.FailureCount != 0 || state.TotalReboots != 0 {
+		t.Errorf("Expected no failures or reboots, got %+v", state)
+	}
+	if modem := modemStatus(t); modem.Reboots != 0 {
+		t.Errorf("Expected the modem not to be rebooted, got %+v", modem)
+	}
+}
+
+func TestOutageRebootsModem(t *testing.T) {
+	start(t)
+
+	fault(t, "outage")
+	waitFor(t, 2*time.Minute, "the modem to be rebooted", func() bool {
+		return modemStatus(t).Reboots >= 1
+	})
+	// The reboot fixed the outage
+	fault(t, "clear")
+
+	waitFor(t, 2*time.Minute, "the watchdog to recover", func() bool {
+		state := status(t)
+		return state.TotalReboots == 1 && state.FailureCount == 0 && len(state.Reboot) == 0
+	})
+	if modem := modemStatus(t); modem.Reboots != 1 || modem.Logins < 2 {
+		t.Errorf("Expected one reboot and a login after it, got %+v", modem)
+	}
+}
+
+func TestLatencyBelowTimeoutDoesNotReboot(t *testing.T) {
+	start(t)
+
+	fault(t, "latency", "300ms")
+	checks := status(t).TotalChecks
+	waitFor(t, time.Minute, "four checks with latency", func() bool {
+		return status(t).TotalChecks >= checks+4
+	})
+	if state := status(t); state.TotalReboots != 0 || state.FailureCount != 0 {
+		t.Errorf("Expected slow but working checks to pass, got %+v", state)
+	}
+	if modem := modemStatus(t); modem.Reboots != 0 {
+		t.Errorf("Expected the modem not to be rebooted, got %+v", modem)
+	}
+}
+
+// start clears the faults, resets the fake modem and restarts the watchdog,
+// waiting until its API answers
+func start(t *testing.T) {
+	t.Helper()
+	fault(t, "clear")
+	t.Cleanup(func() { fault(t, "clear") })
+
+	resp, err := http.Post(controlURL+"/reset", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to reset the fake modem: %v", err)
+	}
+	resp.Body.Close()
+	if err := compose("restart", "watchdog"); err != nil {
+		t.Fatalf("Failed to restart the watchdog: %v", err)
+	}
+
+	waitFor(t, time.Minute, "the watchdog API", func() bool {
+		resp, err := http.Get(apiURL + "/api/v1/health")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	})
+}
+
+// fault runs the fault script of the faults container
+func fault(t *testing.T, args ...string) {
+	t.Helper()
+	if err := compose(append([]string{"exec", "-T", "faults", "fault"}, args...)...); err != nil {
+		t.Fatalf("fault %v failed: %v", args, err)
+	}
+}
+
+// status reads the watchdog state from its API
+func status(t *testing.T) watchdogState {
+	t.Helper()
+	var state watchdogState
+	getJSON(t, apiURL+"/api/v1/status", &state)
+	return state
+}
+
+// modemStatus reads the fake modem state from its control port
+func modemStatus(t *testing.T) modemState {
+	t.Helper()
+	var state modemState
+	getJSON(t, controlURL+"/state", &state)
+	return state
+}
+
+func getJSON(t *testing.T, url string, value interface{}) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: unexpected status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(value); err != nil {
+		t.Fatalf("GET %s: invalid JSON: %v", url, err)
+	}
+}
+
+// waitFor polls cond every second until it holds, failing the test after
+// timeout
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for !cond() {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out after %v waiting for %s", timeout, what)
+		case <-ticker.C:
+		}
+	}
+}
+
+// compose runs docker compose on the environment of this directory
+func compose(args ...string) error {
+	cmd := exec.Command("docker", append([]string{"compose", "-f", "docker-compose.yml"}, args...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,36 @@
+#!/bin/sh
+# fault breaks the network of the container it shares a namespace with:
+#   fault outage          drop all incoming traffic
+#   fault latency DELAY   delay every packet, as in 300ms
+#   fault loss PERCENT    drop a share of packets, as in 30%
+#   fault clear           remove all faults
+set -eu
+
+dev=${FAULT_DEVICE:-eth0}
+
+clear_faults() {
+	while iptables -D INPUT -j DROP 2>/dev/null; do :; done
+	tc qdisc del dev "$dev" root 2>/dev/null || true
+}
+
+case "${1:-}" in
+outage)
+	clear_faults
+	iptables -I INPUT -j DROP
+	;;
+latency)
+	clear_faults
+	tc qdisc add dev "$dev" root netem delay "$2"
+	;;
+loss)
+	clear_faults
+	tc qdisc add dev "$dev" root netem loss "$2"
+	;;
+clear)
+	clear_faults
+	;;
+*)
+	echo "usage: fault outage | latency DELAY | loss PERCENT | clear" >&2
+	exit 2
+	;;
+esac